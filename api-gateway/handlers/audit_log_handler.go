@@ -0,0 +1,362 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models/notification"
+	"forgecrud-backend/shared/utils/query"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// auditLogFilters are the columns audit logs can be filtered and sorted by, e.g.
+// filters[status_code][gte]=400 or filters[created_at][gte]=2025-01-01&filters[created_at][lt]=2025-02-01
+var auditLogFilters = map[string]string{
+	"user_id":     "user_id",
+	"method":      "method",
+	"path":        "path",
+	"status_code": "status_code",
+	"ip_address":  "ip_address",
+	"request_id":  "request_id",
+	"duration_ms": "duration",
+	"created_at":  "created_at",
+}
+
+// auditLogFields are the selectable fields of the list; the request and response bodies are only
+// listed when asked for, they are often large
+var auditLogFields = map[string]string{
+	"user_id":       "user_id",
+	"method":        "method",
+	"path":          "path",
+	"status_code":   "status_code",
+	"request_body":  "request_body",
+	"response_body": "response_body",
+	"ip_address":    "ip_address",
+	"user_agent":    "user_agent",
+	"duration_ms":   "duration",
+	"request_id":    "request_id",
+	"created_at":    "created_at",
+}
+
+// auditLogSummaryFields are listed when no fields parameter is given
+var auditLogSummaryFields = []string{"user_id", "method", "path", "status_code", "ip_address", "user_agent", "duration_ms", "request_id", "created_at"}
+
+// auditLogCSVHeader are the columns of a CSV export
+var auditLogCSVHeader = []string{"id", "created_at", "user_id", "method", "path", "status_code", "duration_ms", "ip_address", "user_agent", "request_id"}
+
+// auditLogQuery returns the audit logs visible to the caller with the filters of the request applied.
+// Super admins see every log, organization admins the logs of their organization's users and
+// everyone else their own.
+func auditLogQuery(c *gin.Context, params query.FilterParams) (*gorm.DB, bool) {
+	db := database.GetDB()
+	if db == nil {
+		if err := database.InitDatabase(); err != nil {
+			log.Printf("⚠️  Audit logs unavailable: %v", err)
+			apperrors.Respond(c, apperrors.New(apperrors.CodeUnavailable, "Audit logs are unavailable"))
+			return nil, false
+		}
+		db = database.GetDB()
+	}
+
+	dbQuery := db.WithContext(c.Request.Context()).Model(&notification.AuditLog{})
+	switch {
+	case c.GetBool("tenant_bypass"):
+	case c.GetString("organization_id") != "":
+		dbQuery = dbQuery.Where("user_id IN (SELECT id FROM users WHERE organization_id = ?)", c.GetString("organization_id"))
+	default:
+		dbQuery = dbQuery.Where("user_id = ?", c.GetString("user_id"))
+	}
+
+	dbQuery = query.ApplyFilters(dbQuery, params.Filters, auditLogFilters)
+	return query.ApplySearch(dbQuery, params.Search, []string{"path", "request_id", "ip_address"}), true
+}
+
+// decodeAuditLogBodies turns the request and response bodies, read from jsonb as text, back into JSON
+func decodeAuditLogBodies(auditLog *notification.AuditLog) {
+	auditLog.RequestBody = decodeJSONColumn(auditLog.RequestBody)
+	auditLog.ResponseBody = decodeJSONColumn(auditLog.ResponseBody)
+}
+
+func decodeJSONColumn(value interface{}) interface{} {
+	var raw []byte
+	switch v := value.(type) {
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	default:
+		return value
+	}
+
+	var decoded interface{}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return string(raw)
+	}
+	return decoded
+}
+
+// GetAuditLogs lists the audit logs of requests through the gateway
+// @Summary List audit logs
+// @Description List audit logs with filters (filters[user_id], filters[path][like], filters[status_code][gte], filters[created_at][gte]/[lte], filters[request_id], ...), search over path, request ID and IP address, sorting, and page or cursor pagination. Request and response bodies are only included when requested with fields. Super admins see every log, organization admins the logs of their organization's users.
+// @Tags audit-logs
+// @Produce json
+// @Param page query int false "Page number (default 1)"
+// @Param limit query int false "Items per page (default 10, max 100)"
+// @Param cursor query string false "Cursor of the next page (keyset pagination)"
+// @Param search query string false "Search in path, request ID and IP address"
+// @Param fields query string false "Comma separated fields, e.g. path,status_code,request_body"
+// @Param sort[field] query string false "Sort field (created_at, status_code, duration_ms, path)"
+// @Param sort[order] query string false "Sort order (asc, desc)"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Audit logs with pagination"
+// @Failure 400 {object} map[string]string "Invalid cursor"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /audit-logs [get]
+func GetAuditLogs(c *gin.Context) {
+	params := query.ParseQueryParams(c)
+
+	dbQuery, ok := auditLogQuery(c, params)
+	if !ok {
+		return
+	}
+
+	var finalQuery *gorm.DB
+	var pagination interface{}
+
+	if params.CursorMode {
+		cursorQuery, err := query.ApplyCursor(dbQuery, params, "audit_logs")
+		if err != nil {
+			apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid cursor"))
+			return
+		}
+		finalQuery = cursorQuery
+	} else {
+		var total int64
+		if err := dbQuery.Count(&total).Error; err != nil {
+			apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to count audit logs"))
+			return
+		}
+
+		finalQuery = query.ApplySort(dbQuery, params.Sort, auditLogFilters)
+		finalQuery = query.ApplyPagination(finalQuery, params.Page, params.Limit)
+		pagination = query.BuildPaginationResponse(params.Page, params.Limit, total)
+	}
+
+	fields := params.Fields
+	if len(fields) == 0 {
+		fields = auditLogSummaryFields
+	}
+	finalQuery = query.ApplyFieldSelection(finalQuery, fields, auditLogFields, "id", "created_at")
+
+	var auditLogs []notification.AuditLog
+	if err := finalQuery.Find(&auditLogs).Error; err != nil {
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to retrieve audit logs"))
+		return
+	}
+	for i := range auditLogs {
+		decodeAuditLogBodies(&auditLogs[i])
+	}
+
+	if params.CursorMode {
+		auditLogs, pagination = query.TrimCursorPage(auditLogs, params.Limit, func(auditLog notification.AuditLog) (time.Time, string) {
+			return auditLog.CreatedAt, auditLog.ID.String()
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"audit_logs": query.SparseFieldset(auditLogs, params.Fields, nil),
+			"pagination": pagination,
+		},
+	})
+}
+
+// GetAuditLog returns one audit log with its request and response bodies
+// @Summary Get an audit log
+// @Description An audit log with its request and response bodies
+// @Tags audit-logs
+// @Produce json
+// @Param id path string true "Audit log ID"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Audit log"
+// @Failure 400 {object} map[string]string "Invalid ID"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 404 {object} map[string]string "Audit log not found"
+// @Router /audit-logs/{id} [get]
+func GetAuditLog(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apperrors.Respond(c, apperrors.BadRequest("Invalid audit log ID"))
+		return
+	}
+
+	dbQuery, ok := auditLogQuery(c, query.FilterParams{})
+	if !ok {
+		return
+	}
+
+	var auditLog notification.AuditLog
+	if err := dbQuery.Where("id = ?", id).First(&auditLog).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apperrors.Respond(c, apperrors.NotFound("Audit log not found"))
+			return
+		}
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to retrieve the audit log"))
+		return
+	}
+	decodeAuditLogBodies(&auditLog)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    auditLog,
+	})
+}
+
+// ExportAuditLogs streams the matching audit logs as a CSV or JSON file
+// @Summary Export audit logs
+// @Description Export every audit log matching the filters and search of the list, for compliance. CSV has one row per request without the bodies; JSON is an array of complete audit logs. The file is streamed, so large exports don't need to fit in memory.
+// @Tags audit-logs
+// @Produce text/csv
+// @Produce json
+// @Param format query string false "csv (default) or json"
+// @Param search query string false "Search in path, request ID and IP address"
+// @Param sort[field] query string false "Sort field (created_at, status_code, duration_ms, path)"
+// @Param sort[order] query string false "Sort order (asc, desc)"
+// @Security BearerAuth
+// @Success 200 {file} file "Audit log export"
+// @Failure 400 {object} map[string]string "Unknown format"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Router /audit-logs/export [get]
+func ExportAuditLogs(c *gin.Context) {
+	format := c.DefaultQuery("format", "csv")
+	if format != "csv" && format != "json" {
+		apperrors.Respond(c, apperrors.BadRequest("Unknown export format: "+format).WithDetails(gin.H{"allowed_formats": []string{"csv", "json"}}))
+		return
+	}
+
+	params := query.ParseQueryParams(c)
+	dbQuery, ok := auditLogQuery(c, params)
+	if !ok {
+		return
+	}
+
+	rows, err := query.ApplySort(dbQuery, params.Sort, auditLogFilters).Order("id").Rows()
+	if err != nil {
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to export audit logs"))
+		return
+	}
+	defer rows.Close()
+
+	fileName := fmt.Sprintf("audit-logs-%s.%s", time.Now().UTC().Format("20060102-150405"), format)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", fileName))
+	if format == "csv" {
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+	} else {
+		c.Header("Content-Type", "application/json")
+	}
+	c.Status(http.StatusOK)
+
+	// The status is sent, a failure from here on can only cut the file short
+	var exported int
+	if format == "csv" {
+		exported, err = writeAuditLogCSV(c, dbQuery, rows)
+	} else {
+		exported, err = writeAuditLogJSON(c, dbQuery, rows)
+	}
+	if err != nil {
+		log.Printf("❌ Audit log export stopped after %d logs: %v", exported, err)
+	}
+}
+
+// writeAuditLogCSV writes the audit logs as CSV rows and returns how many it wrote
+func writeAuditLogCSV(c *gin.Context, db *gorm.DB, rows *sql.Rows) (int, error) {
+	writer := csv.NewWriter(c.Writer)
+	if err := writer.Write(auditLogCSVHeader); err != nil {
+		return 0, err
+	}
+
+	exported := 0
+	for rows.Next() {
+		var auditLog notification.AuditLog
+		if err := db.ScanRows(rows, &auditLog); err != nil {
+			return exported, err
+		}
+
+		userID := ""
+		if auditLog.UserID != nil {
+			userID = auditLog.UserID.String()
+		}
+		if err := writer.Write([]string{
+			auditLog.ID.String(),
+			auditLog.CreatedAt.UTC().Format(time.RFC3339),
+			userID,
+			auditLog.Method,
+			auditLog.Path,
+			strconv.Itoa(auditLog.StatusCode),
+			strconv.FormatInt(auditLog.Duration, 10),
+			auditLog.IPAddress,
+			auditLog.UserAgent,
+			auditLog.RequestID,
+		}); err != nil {
+			return exported, err
+		}
+		exported++
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return exported, err
+	}
+	return exported, rows.Err()
+}
+
+// writeAuditLogJSON writes the audit logs as a JSON array and returns how many it wrote
+func writeAuditLogJSON(c *gin.Context, db *gorm.DB, rows *sql.Rows) (int, error) {
+	if _, err := c.Writer.Write([]byte("[")); err != nil {
+		return 0, err
+	}
+
+	exported := 0
+	for rows.Next() {
+		var auditLog notification.AuditLog
+		if err := db.ScanRows(rows, &auditLog); err != nil {
+			return exported, err
+		}
+		decodeAuditLogBodies(&auditLog)
+
+		encoded, err := json.Marshal(auditLog)
+		if err != nil {
+			return exported, err
+		}
+		if exported > 0 {
+			encoded = append([]byte(","), encoded...)
+		}
+		if _, err := c.Writer.Write(encoded); err != nil {
+			return exported, err
+		}
+		exported++
+	}
+	if err := rows.Err(); err != nil {
+		return exported, err
+	}
+
+	_, err := c.Writer.Write([]byte("]"))
+	return exported, err
+}
@@ -0,0 +1,275 @@
+package handlers
+
+import (
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"forgecrud-backend/api-gateway/middleware"
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/config"
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models"
+	"forgecrud-backend/shared/utils/query"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// IPBanHandler manages the IP reputations and bans of the gateway
+type IPBanHandler struct {
+	reputation *middleware.IPReputation
+}
+
+// NewIPBanHandler returns the handler of the ban list, applying changes to the gateway's bans
+func NewIPBanHandler(reputation *middleware.IPReputation) *IPBanHandler {
+	return &IPBanHandler{reputation: reputation}
+}
+
+// BanIPRequest bans an address from the API
+type BanIPRequest struct {
+	IPAddress       string `json:"ip_address" binding:"required" example:"203.0.113.7"`
+	DurationMinutes int    `json:"duration_minutes" binding:"omitempty,min=1" example:"60"` // Default IP_BAN_MINUTES
+	Reason          string `json:"reason" example:"Credential stuffing"`
+}
+
+// ipReputationView is a reputation with its score decayed to now
+type ipReputationView struct {
+	models.IPReputation
+	CurrentScore float64 `json:"current_score"`
+	Banned       bool    `json:"banned"`
+}
+
+// ipReputationDB returns the database holding the IP reputations, answering the request when it is
+// unavailable
+func ipReputationDB(c *gin.Context) (*gorm.DB, bool) {
+	db := database.GetDB()
+	if db == nil {
+		if err := database.InitDatabase(); err != nil {
+			log.Printf("⚠️  IP reputations unavailable: %v", err)
+			apperrors.Respond(c, apperrors.New(apperrors.CodeUnavailable, "IP reputations are unavailable"))
+			return nil, false
+		}
+		db = database.GetDB()
+	}
+	return db.WithContext(c.Request.Context()), true
+}
+
+// parseIP returns the canonical form of an address, answering the request when it is invalid
+func parseIP(c *gin.Context, value string) (string, bool) {
+	ip := net.ParseIP(strings.TrimSpace(value))
+	if ip == nil {
+		apperrors.Respond(c, apperrors.BadRequest("Invalid IP address: "+value))
+		return "", false
+	}
+	return ip.String(), true
+}
+
+func ipReputationViews(reputations []models.IPReputation) []ipReputationView {
+	halfLife := time.Duration(config.GetConfig().IPReputationHalfLifeMinutes) * time.Minute
+	now := time.Now()
+	views := make([]ipReputationView, 0, len(reputations))
+	for _, reputation := range reputations {
+		views = append(views, ipReputationView{
+			IPReputation: reputation,
+			CurrentScore: database.DecayedIPScore(reputation, halfLife, now),
+			Banned:       reputation.IsBanned(now),
+		})
+	}
+	return views
+}
+
+// GetIPReputations lists the scores of the addresses with failed requests
+// @Summary List IP reputations
+// @Description Addresses with 401, 403 or 429 responses through the gateway, highest score first, with their failures, score decayed to now and ban. Requires super admin.
+// @Tags security
+// @Produce json
+// @Param banned query bool false "Only banned addresses"
+// @Param ip query string false "Only addresses starting with this prefix"
+// @Param page query int false "Page number (default 1)"
+// @Param limit query int false "Items per page (default 10, max 100)"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Reputations with pagination"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 503 {object} map[string]string "Database unavailable"
+// @Router /security/ip-reputation [get]
+func (h *IPBanHandler) GetIPReputations(c *gin.Context) {
+	db, ok := ipReputationDB(c)
+	if !ok {
+		return
+	}
+
+	dbQuery := db.Model(&models.IPReputation{})
+	if c.Query("banned") == "true" {
+		dbQuery = dbQuery.Where("banned_until > ?", time.Now())
+	}
+	if prefix := strings.TrimSpace(c.Query("ip")); prefix != "" {
+		dbQuery = dbQuery.Where("ip_address LIKE ?", prefix+"%")
+	}
+
+	var total int64
+	if err := dbQuery.Count(&total).Error; err != nil {
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to count IP reputations"))
+		return
+	}
+
+	params := query.ParseQueryParams(c)
+	var reputations []models.IPReputation
+	if err := query.ApplyPagination(dbQuery.Order("score DESC, last_failure_at DESC"), params.Page, params.Limit).Find(&reputations).Error; err != nil {
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to list IP reputations"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"reputations": ipReputationViews(reputations),
+			"pagination":  query.BuildPaginationResponse(params.Page, params.Limit, total),
+		},
+	})
+}
+
+// GetIPBans lists the banned addresses
+// @Summary List IP bans
+// @Description Addresses banned at the gateway, automatically or by an admin, ending soonest first. Requires super admin.
+// @Tags security
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Active bans"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 503 {object} map[string]string "Database unavailable"
+// @Router /security/ip-bans [get]
+func (h *IPBanHandler) GetIPBans(c *gin.Context) {
+	db, ok := ipReputationDB(c)
+	if !ok {
+		return
+	}
+
+	bans, err := database.ActiveIPBans(db.Order("banned_until"), time.Now())
+	if err != nil {
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to list IP bans"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"bans": ipReputationViews(bans),
+		},
+	})
+}
+
+// BanIP bans an address from the API
+// @Summary Ban an IP address
+// @Description Reject every request of an address at the gateway until the ban ends. Other gateway instances apply the ban within 15 seconds. Addresses of IP_REPUTATION_ALLOWLIST cannot be banned. Requires super admin.
+// @Tags security
+// @Accept json
+// @Produce json
+// @Param request body BanIPRequest true "Address and duration"
+// @Security BearerAuth
+// @Success 201 {object} map[string]interface{} "Ban"
+// @Failure 400 {object} map[string]string "Invalid or allowlisted address"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 503 {object} map[string]string "Database unavailable"
+// @Router /security/ip-bans [post]
+func (h *IPBanHandler) BanIP(c *gin.Context) {
+	var req BanIPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperrors.Respond(c, apperrors.BadRequest("Invalid request body: "+err.Error()))
+		return
+	}
+	ip, ok := parseIP(c, req.IPAddress)
+	if !ok {
+		return
+	}
+	if h.reputation.Allowlisted(ip) {
+		apperrors.Respond(c, apperrors.BadRequest("Address is in IP_REPUTATION_ALLOWLIST and cannot be banned"))
+		return
+	}
+	if ip == c.ClientIP() {
+		apperrors.Respond(c, apperrors.BadRequest("You cannot ban your own address"))
+		return
+	}
+
+	db, ok := ipReputationDB(c)
+	if !ok {
+		return
+	}
+
+	minutes := req.DurationMinutes
+	if minutes == 0 {
+		minutes = config.GetConfig().IPBanMinutes
+	}
+	until := time.Now().Add(time.Duration(minutes) * time.Minute)
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		reason = "Banned by an admin"
+	}
+	var bannedBy *uuid.UUID
+	if userID, err := uuid.Parse(c.GetString("user_id")); err == nil {
+		bannedBy = &userID
+	}
+
+	reputation, err := database.BanIP(db, ip, until, reason, bannedBy)
+	if err != nil {
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to ban IP address"))
+		return
+	}
+	h.reputation.Ban(ip, until)
+	log.Printf("🚫 %s banned by %s until %s", ip, c.GetString("user_id"), until.UTC().Format(time.RFC3339))
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data": gin.H{
+			"ban": ipReputationViews([]models.IPReputation{reputation})[0],
+		},
+	})
+}
+
+// UnbanIP lifts the ban of an address
+// @Summary Unban an IP address
+// @Description Lift the ban of an address and reset its score. Other gateway instances lift the ban within 15 seconds. Requires super admin.
+// @Tags security
+// @Produce json
+// @Param ip path string true "IP address"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Ban lifted"
+// @Failure 400 {object} map[string]string "Invalid address"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 404 {object} map[string]string "Address has no reputation"
+// @Failure 503 {object} map[string]string "Database unavailable"
+// @Router /security/ip-bans/{ip} [delete]
+func (h *IPBanHandler) UnbanIP(c *gin.Context) {
+	ip, ok := parseIP(c, c.Param("ip"))
+	if !ok {
+		return
+	}
+
+	db, ok := ipReputationDB(c)
+	if !ok {
+		return
+	}
+
+	found, err := database.UnbanIP(db, ip)
+	if err != nil {
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to unban IP address"))
+		return
+	}
+	if !found {
+		apperrors.Respond(c, apperrors.NotFound("IP address has no reputation"))
+		return
+	}
+	h.reputation.Unban(ip)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "IP address unbanned",
+	})
+}
@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/jobs"
+	"forgecrud-backend/shared/utils/query"
+
+	"github.com/gin-gonic/gin"
+)
+
+// jobInspector returns the job queue inspector, answering the request when the queue keeps no jobs
+func jobInspector(c *gin.Context) (jobs.Inspector, bool) {
+	inspector, ok := jobs.GetInspector()
+	if !ok {
+		apperrors.Respond(c, apperrors.New(apperrors.CodeNotImplemented, "The job queue driver keeps no jobs to inspect"))
+	}
+	return inspector, ok
+}
+
+// respondJobError answers a failed job queue operation
+func respondJobError(c *gin.Context, err error, message string) {
+	if errors.Is(err, jobs.ErrNotFound) {
+		apperrors.Respond(c, apperrors.NotFound("Job not found"))
+		return
+	}
+	apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeUnavailable, message))
+}
+
+// GetJobStats returns the job counts of every service
+// @Summary Job queue statistics
+// @Description Pending jobs by priority, scheduled, active and dead jobs, and processed and failed counts by job type for every service. Requires super admin.
+// @Tags jobs
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Queue statistics"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 501 {object} map[string]string "Job queue disabled"
+// @Failure 503 {object} map[string]string "Job queue unavailable"
+// @Router /jobs [get]
+func GetJobStats(c *gin.Context) {
+	inspector, ok := jobInspector(c)
+	if !ok {
+		return
+	}
+
+	stats, err := inspector.Stats(c.Request.Context())
+	if err != nil {
+		respondJobError(c, err, "Failed to read the job queue")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"queues": stats,
+		},
+	})
+}
+
+// ListJobs returns a page of the jobs of a service in a state
+// @Summary List jobs
+// @Description List the pending, scheduled, active or dead jobs of a service. Requires super admin.
+// @Tags jobs
+// @Produce json
+// @Param service path string true "Service name, e.g. document-service"
+// @Param state path string true "Job state (pending, scheduled, active, dead)"
+// @Param page query int false "Page number (default 1)"
+// @Param limit query int false "Items per page (default 10, max 100)"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Jobs with pagination"
+// @Failure 400 {object} map[string]string "Unknown state"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 404 {object} map[string]string "Unknown service"
+// @Failure 501 {object} map[string]string "Job queue disabled"
+// @Failure 503 {object} map[string]string "Job queue unavailable"
+// @Router /jobs/{service}/{state} [get]
+func ListJobs(c *gin.Context) {
+	state := c.Param("state")
+	known := false
+	for _, s := range jobs.States {
+		if s == state {
+			known = true
+			break
+		}
+	}
+	if !known {
+		apperrors.Respond(c, apperrors.BadRequest("Unknown job state: "+state).WithDetails(gin.H{"allowed_states": jobs.States}))
+		return
+	}
+
+	inspector, ok := jobInspector(c)
+	if !ok {
+		return
+	}
+
+	params := query.ParseQueryParams(c)
+	list, total, err := inspector.List(c.Request.Context(), c.Param("service"), state, (params.Page-1)*params.Limit, params.Limit)
+	if err != nil {
+		respondJobError(c, err, "Failed to read the job queue")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"jobs":       list,
+			"pagination": query.BuildPaginationResponse(params.Page, params.Limit, total),
+		},
+	})
+}
+
+// RetryJob moves a dead or scheduled job back to its pending list
+// @Summary Retry a job
+// @Description Run a dead or scheduled job now. Requires super admin.
+// @Tags jobs
+// @Produce json
+// @Param service path string true "Service name"
+// @Param id path string true "Job ID"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Job queued"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 404 {object} map[string]string "Job not found"
+// @Failure 501 {object} map[string]string "Job queue disabled"
+// @Failure 503 {object} map[string]string "Job queue unavailable"
+// @Router /jobs/{service}/{id}/retry [post]
+func RetryJob(c *gin.Context) {
+	inspector, ok := jobInspector(c)
+	if !ok {
+		return
+	}
+
+	if err := inspector.Retry(c.Request.Context(), c.Param("service"), c.Param("id")); err != nil {
+		respondJobError(c, err, "Failed to retry the job")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Job queued",
+	})
+}
+
+// DeleteJob removes a dead or scheduled job
+// @Summary Delete a job
+// @Description Remove a dead or scheduled job without running it. Requires super admin.
+// @Tags jobs
+// @Produce json
+// @Param service path string true "Service name"
+// @Param id path string true "Job ID"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Job deleted"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 404 {object} map[string]string "Job not found"
+// @Failure 501 {object} map[string]string "Job queue disabled"
+// @Failure 503 {object} map[string]string "Job queue unavailable"
+// @Router /jobs/{service}/{id} [delete]
+func DeleteJob(c *gin.Context) {
+	inspector, ok := jobInspector(c)
+	if !ok {
+		return
+	}
+
+	if err := inspector.Delete(c.Request.Context(), c.Param("service"), c.Param("id")); err != nil {
+		respondJobError(c, err, "Failed to delete the job")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Job deleted",
+	})
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/config"
+	"forgecrud-backend/shared/readonly"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readOnlyServices are the services that can be switched to read-only mode one by one
+var readOnlyServices = []string{"api-gateway", "auth-service", "permission-service", "core-service", "document-service", "notification-service"}
+
+// SetReadOnlyRequest switches the platform or single services to read-only mode
+type SetReadOnlyRequest struct {
+	// Every service
+	Global bool `json:"global" example:"false"`
+	// Read-only services, by name
+	Services []string `json:"services" binding:"dive,oneof=api-gateway auth-service permission-service core-service document-service notification-service" example:"core-service"`
+	Reason   string   `json:"reason" binding:"omitempty,max=500" example:"Database migration"`
+}
+
+// readOnlyStatus is the configured and the switched read-only mode, and the services they make read-only
+func readOnlyStatus() gin.H {
+	configured := config.GetConfig().ReadOnly
+	services := make(map[string]bool, len(readOnlyServices))
+	for _, service := range readOnlyServices {
+		services[service] = readonly.Enabled(service)
+	}
+
+	return gin.H{
+		"configured": gin.H{
+			"global":   configured.Global,
+			"services": configured.Services,
+		},
+		"switch":   readonly.Current(),
+		"services": services,
+	}
+}
+
+// GetReadOnly returns the read-only mode of the platform
+// @Summary Get the read-only mode
+// @Description The read-only mode from READ_ONLY_MODE and READ_ONLY_SERVICES, the switch set through this API and whether each service is read-only. Requires super admin.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Read-only mode"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Router /admin/read-only [get]
+func GetReadOnly(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    readOnlyStatus(),
+	})
+}
+
+// SetReadOnly switches the platform or single services to read-only mode, or back
+// @Summary Set the read-only mode
+// @Description Switch the whole platform or the listed services to read-only mode; an empty switch ends it. Read-only services reject every request but GET, HEAD and OPTIONS
+// @Description with 503 at the gateway, and their scheduler and job workers pause. Every instance picks up the switch within READ_ONLY_REFRESH_SECONDS. The configured read-only
+// @Description mode cannot be switched off here. Requires super admin.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body SetReadOnlyRequest true "Read-only switch"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Read-only mode"
+// @Failure 400 {object} map[string]string "Invalid request body"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 503 {object} map[string]string "Read-only switch unavailable"
+// @Router /admin/read-only [put]
+func SetReadOnly(c *gin.Context) {
+	var req SetReadOnlyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperrors.Respond(c, apperrors.BadRequest("Invalid request body: "+err.Error()))
+		return
+	}
+
+	next := readonly.Switch{
+		Global:    req.Global,
+		Services:  uniqueServices(req.Services),
+		Reason:    strings.TrimSpace(req.Reason),
+		UpdatedBy: c.GetString("user_id"),
+	}
+	if err := readonly.Set(c.Request.Context(), next); err != nil {
+		if errors.Is(err, readonly.ErrUnavailable) {
+			apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeUnavailable, "The read-only switch is unavailable").
+				WithDetails("Set READ_ONLY_DRIVER=redis, or READ_ONLY_MODE and READ_ONLY_SERVICES"))
+			return
+		}
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeUnavailable, "Failed to set the read-only switch"))
+		return
+	}
+	log.Printf("🔒 Read-only switch set by %s (global: %t, services: %v, reason: %q)", next.UpdatedBy, next.Global, next.Services, next.Reason)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Read-only mode updated",
+		"data":    readOnlyStatus(),
+	})
+}
+
+// uniqueServices returns the services without duplicates, in their original order
+func uniqueServices(services []string) []string {
+	unique := make([]string, 0, len(services))
+	for _, service := range services {
+		if !slices.Contains(unique, service) {
+			unique = append(unique, service)
+		}
+	}
+	return unique
+}
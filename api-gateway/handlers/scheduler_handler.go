@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models"
+	"forgecrud-backend/shared/utils/query"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// schedulerDB returns the database holding the scheduler history, answering the request when it is
+// unavailable
+func schedulerDB(c *gin.Context) (*gorm.DB, bool) {
+	db := database.GetDB()
+	if db == nil {
+		if err := database.InitDatabase(); err != nil {
+			log.Printf("⚠️  Scheduler history unavailable: %v", err)
+			apperrors.Respond(c, apperrors.New(apperrors.CodeUnavailable, "Scheduler history is unavailable"))
+			return nil, false
+		}
+		db = database.GetDB()
+	}
+	return db.WithContext(c.Request.Context()), true
+}
+
+// GetScheduledJobs lists the periodic jobs of every service
+// @Summary List scheduled jobs
+// @Description Periodic jobs of every service with their schedule, the instance leading the scheduler, and the last and next run. Requires super admin.
+// @Tags scheduler
+// @Produce json
+// @Param service query string false "Only jobs of this service, e.g. core-service"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Scheduled jobs"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 503 {object} map[string]string "Database unavailable"
+// @Router /scheduler/jobs [get]
+func GetScheduledJobs(c *gin.Context) {
+	db, ok := schedulerDB(c)
+	if !ok {
+		return
+	}
+
+	dbQuery := db.Model(&models.ScheduledJob{})
+	if service := strings.TrimSpace(c.Query("service")); service != "" {
+		dbQuery = dbQuery.Where("service = ?", service)
+	}
+
+	var scheduledJobs []models.ScheduledJob
+	if err := dbQuery.Order("service, name").Find(&scheduledJobs).Error; err != nil {
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to list scheduled jobs"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"jobs": scheduledJobs,
+		},
+	})
+}
+
+// GetScheduledJobRuns returns the run history of the scheduled jobs, most recent first
+// @Summary Scheduled job run history
+// @Description Runs of the scheduled jobs, most recent first, with the instance that ran them, their status, error and duration. Requires super admin.
+// @Tags scheduler
+// @Produce json
+// @Param service query string false "Only runs of this service"
+// @Param job query string false "Only runs of this job, e.g. core.audit_log_retention"
+// @Param status query string false "Only runs with this status (RUNNING, SUCCEEDED, FAILED)"
+// @Param page query int false "Page number (default 1)"
+// @Param limit query int false "Items per page (default 10, max 100)"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Runs with pagination"
+// @Failure 400 {object} map[string]string "Unknown status"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 503 {object} map[string]string "Database unavailable"
+// @Router /scheduler/runs [get]
+func GetScheduledJobRuns(c *gin.Context) {
+	statuses := []string{models.ScheduledJobRunRunning, models.ScheduledJobRunSucceeded, models.ScheduledJobRunFailed}
+	status := strings.ToUpper(strings.TrimSpace(c.Query("status")))
+	if status != "" {
+		known := false
+		for _, s := range statuses {
+			if s == status {
+				known = true
+				break
+			}
+		}
+		if !known {
+			apperrors.Respond(c, apperrors.BadRequest("Unknown run status: "+status).WithDetails(gin.H{"allowed_statuses": statuses}))
+			return
+		}
+	}
+
+	db, ok := schedulerDB(c)
+	if !ok {
+		return
+	}
+
+	dbQuery := db.Model(&models.ScheduledJobRun{})
+	if service := strings.TrimSpace(c.Query("service")); service != "" {
+		dbQuery = dbQuery.Where("service = ?", service)
+	}
+	if job := strings.TrimSpace(c.Query("job")); job != "" {
+		dbQuery = dbQuery.Where("job_name = ?", job)
+	}
+	if status != "" {
+		dbQuery = dbQuery.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := dbQuery.Count(&total).Error; err != nil {
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to count scheduled job runs"))
+		return
+	}
+
+	params := query.ParseQueryParams(c)
+	var runs []models.ScheduledJobRun
+	if err := query.ApplyPagination(dbQuery.Order("started_at DESC"), params.Page, params.Limit).Find(&runs).Error; err != nil {
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to list scheduled job runs"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"runs":       runs,
+			"pagination": query.BuildPaginationResponse(params.Page, params.Limit, total),
+		},
+	})
+}
+
+// GetScheduledJobRun returns one run of a scheduled job
+// @Summary Get a scheduled job run
+// @Description A run of a scheduled job with its error. Requires super admin.
+// @Tags scheduler
+// @Produce json
+// @Param id path string true "Run ID"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Run"
+// @Failure 400 {object} map[string]string "Invalid ID"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 404 {object} map[string]string "Run not found"
+// @Failure 503 {object} map[string]string "Database unavailable"
+// @Router /scheduler/runs/{id} [get]
+func GetScheduledJobRun(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apperrors.Respond(c, apperrors.BadRequest("Invalid run ID"))
+		return
+	}
+
+	db, ok := schedulerDB(c)
+	if !ok {
+		return
+	}
+
+	var run models.ScheduledJobRun
+	if err := db.First(&run, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apperrors.Respond(c, apperrors.NotFound("Run not found"))
+			return
+		}
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to get the run"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    run,
+	})
+}
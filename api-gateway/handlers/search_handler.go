@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/search"
+	"forgecrud-backend/shared/tenancy"
+	"forgecrud-backend/shared/utils/permission"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Search searches users, organizations, roles, folders and documents the caller can access
+// @Summary Global search
+// @Description Search across users, organizations, roles, folders and documents. Only entity types the caller may read (resource:read) are searched, scoped to the caller's organization. Results are grouped by entity, ranked, and matching fields are highlighted with <mark> tags.
+// @Tags search
+// @Accept json
+// @Produce json
+// @Param q query string true "Search text (min 2 characters)"
+// @Param types query string false "Comma separated entity types (users, organizations, roles, folders, documents); defaults to all"
+// @Param limit query int false "Results per entity (default 5, max 50)"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Grouped search results"
+// @Failure 400 {object} map[string]string "Invalid query"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /search [get]
+func Search(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("q"))
+	if len([]rune(query)) < search.MinQueryLength {
+		apperrors.Respond(c, apperrors.Newf(apperrors.CodeBadRequest, "Query parameter q must be at least %d characters", search.MinQueryLength).With("code", "INVALID_QUERY"))
+		return
+	}
+
+	requested := search.Entities()
+	if types := c.Query("types"); types != "" {
+		requested = nil
+		known := map[string]bool{}
+		for _, name := range search.Entities() {
+			known[name] = true
+		}
+		for _, name := range strings.Split(types, ",") {
+			name = strings.TrimSpace(name)
+			if !known[name] {
+				apperrors.Respond(c, apperrors.BadRequest("Unknown search type: "+name).WithDetails(gin.H{"allowed_types": search.Entities()}).With("code", "INVALID_QUERY"))
+				return
+			}
+			requested = append(requested, name)
+		}
+	}
+
+	limit := search.DefaultLimit
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			apperrors.Respond(c, apperrors.BadRequest("Query parameter limit must be a positive integer").With("code", "INVALID_QUERY"))
+			return
+		}
+		limit = parsed
+	}
+
+	userID := c.GetString("user_id")
+
+	// Only search entity types the caller may read
+	checks := make([]permission.ResourceActionCheck, len(requested))
+	for i, name := range requested {
+		checks[i] = permission.ResourceActionCheck{ResourceSlug: name, ActionSlug: "read"}
+	}
+	results, err := permission.BatchCheckPermissions(userID, checks)
+	if err != nil {
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to check permissions").With("code", "PERMISSION_CHECK_FAILED"))
+		return
+	}
+
+	allowed := []string{}
+	for _, name := range requested {
+		if results[name+":read"] {
+			allowed = append(allowed, name)
+		}
+	}
+
+	db := database.GetDB()
+	if db == nil {
+		if err := database.InitDatabase(); err != nil {
+			log.Printf("⚠️  Search unavailable: %v", err)
+			apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Search is unavailable").With("code", "SEARCH_FAILED"))
+			return
+		}
+		db = database.GetDB()
+	}
+
+	groups, err := search.Search(db.WithContext(tenancy.WithTenant(c.Request.Context(), searchTenant(c))), search.Options{
+		Query:    query,
+		Entities: allowed,
+		Limit:    limit,
+	})
+	if err != nil {
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Search failed").With("code", "SEARCH_FAILED"))
+		return
+	}
+
+	total := 0
+	for _, group := range groups {
+		total += group.Count
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"query":    query,
+			"total":    total,
+			"groups":   groups,
+			"searched": allowed,
+		},
+	})
+}
+
+// searchTenant builds the tenant scope from what the permission middleware resolved for the caller
+func searchTenant(c *gin.Context) tenancy.Tenant {
+	tenant := tenancy.Tenant{Bypass: c.GetBool("tenant_bypass")}
+
+	if userID, err := uuid.Parse(c.GetString("user_id")); err == nil {
+		tenant.UserID = &userID
+	}
+	if organizationID, err := uuid.Parse(c.GetString("organization_id")); err == nil {
+		tenant.OrganizationID = &organizationID
+	}
+
+	return tenant
+}
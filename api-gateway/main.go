@@ -6,9 +6,19 @@ import (
 	"strings"
 	"time"
 
+	"forgecrud-backend/api-gateway/handlers"
 	"forgecrud-backend/api-gateway/middleware"
+	"forgecrud-backend/api-gateway/openapi"
 	"forgecrud-backend/api-gateway/routes"
+	"forgecrud-backend/shared/clientip"
 	"forgecrud-backend/shared/config"
+	"forgecrud-backend/shared/health"
+	"forgecrud-backend/shared/jobs"
+	"forgecrud-backend/shared/messaging"
+	"forgecrud-backend/shared/readonly"
+	"forgecrud-backend/shared/revocation"
+	"forgecrud-backend/shared/server"
+	documentUtils "forgecrud-backend/shared/utils/document"
 	"forgecrud-backend/shared/utils/permission"
 
 	_ "forgecrud-backend/docs/swagger"
@@ -49,6 +59,15 @@ import (
 // @tag.name organizations
 // @tag.description Organization management operations
 
+// @tag.name teams
+// @tag.description Team management operations
+
+// @tag.name webhooks
+// @tag.description Outbound webhook management operations
+
+// @tag.name registration-rules
+// @tag.description Organization and role assignment of self-registered users
+
 // @tag.name permissions
 // @tag.description Permission management operations
 
@@ -64,6 +83,21 @@ import (
 // @tag.name folders
 // @tag.description Folder management operations
 
+// @tag.name search
+// @tag.description Global search across entities
+
+// @tag.name jobs
+// @tag.description Background job queue dashboard
+
+// @tag.name scheduler
+// @tag.description Scheduled jobs and their run history
+
+// @tag.name audit-logs
+// @tag.description Audit logs of requests through the gateway
+
+// @tag.name security
+// @tag.description IP reputations and bans of the gateway
+
 // @securityDefinitions.apikey BearerAuth
 // @in header
 // @name Authorization
@@ -82,28 +116,97 @@ func main() {
 	// Initialize permission client with config-based URL
 	permission.InitPermissionClient(cfg.PermissionServiceURL)
 
+	// Publish organization API quota alerts for the notification service
+	if err := messaging.Init("api-gateway"); err != nil {
+		log.Printf("⚠️  Warning: Event bus not available: %v", err)
+	} else {
+		server.OnShutdown("event bus", messaging.Close)
+		health.AddOptionalCheck("event bus", health.Ping(messaging.Ping))
+	}
+
+	// Reject the access tokens of sessions terminated at the auth service right away
+	if err := revocation.Init(); err != nil {
+		log.Printf("⚠️  Warning: Session revocations not available: %v", err)
+	} else if err := revocation.Subscribe(middleware.RevokeSessions); err != nil {
+		log.Printf("⚠️  Warning: Session revocations not available: %v", err)
+	} else {
+		server.OnShutdown("session revocations", revocation.Close)
+		health.AddOptionalCheck("session revocations", health.Ping(revocation.Ping))
+	}
+
+	// Reject write requests while the platform or their service is read-only
+	if err := readonly.Init("api-gateway"); err != nil {
+		log.Printf("⚠️  Warning: Read-only switch not available: %v", err)
+	} else {
+		server.OnShutdown("read-only switch", readonly.Close)
+		health.AddOptionalCheck("read-only switch", health.Ping(readonly.Ping))
+	}
+
 	// Initialize global rate limiter
 	rateLimiter := middleware.NewRateLimiter(5 * time.Minute) // Cleanup every 5 minutes
 
-	// Global rate limit configuration from environment variables
-	globalRateConfig := middleware.NewRateLimitConfig()
-
 	// Gin router oluştur
-	router := gin.Default()
+	router := gin.New()
+	router.Use(server.Logger(), gin.Recovery())
+
+	// Client IPs from X-Forwarded-For of trusted load balancers only
+	clientip.ConfigureGateway(router)
 
 	// Add CORS middleware
 	router.Use(cors.Default())
 
-	// Global rate limiter middleware
-	router.Use(rateLimiter.GlobalRateLimitMiddleware(globalRateConfig))
+	// Ban addresses with too many failed requests, scoring the rate limiter's responses as well
+	ipReputation := middleware.NewIPReputation()
+	ipReputation.Start()
+	server.OnShutdown("ip reputation", ipReputation.Close)
+	health.AddOptionalCheck("ip reputation", ipReputation.Check)
+	router.Use(ipReputation.Middleware())
 
-	// Add unified response middleware (transforms all service responses)
-	router.Use(middleware.UnifiedResponseMiddleware())
+	// Global rate limiter middleware, following configuration reloads
+	router.Use(rateLimiter.GlobalRateLimitMiddleware(middleware.NewRateLimitConfig))
 
-	// Health check endpoint
-	router.GET("/health", func(ctx *gin.Context) {
-		ctx.JSON(http.StatusOK, gin.H{"status": "API Gateway is running", "Port": "8000"})
-	})
+	// Per-organization API request budget (organization quotas), counted in Redis and added to the
+	// daily usage in the background
+	apiQuota := middleware.NewAPIQuotaLimiter()
+	apiQuota.Start()
+	server.OnShutdown("api quota limiter", apiQuota.Close)
+	health.AddOptionalCheck("api quota limiter", apiQuota.Check)
+	router.Use(apiQuota.Middleware())
+
+	// Add unified response middleware (transforms all service responses), writing the audit logs in
+	// batches off the request path
+	auditWriter := middleware.NewAuditWriter()
+	auditWriter.Start()
+	server.OnShutdown("audit log writer", auditWriter.Close)
+	health.AddOptionalCheck("audit log writer", auditWriter.Check)
+	router.Use(middleware.UnifiedResponseMiddleware(auditWriter))
+
+	// Copy JSON request bodies for the audit log as they stream to the services
+	router.Use(middleware.CaptureRequestBody())
+
+	// Reject write requests while the platform or the gateway is read-only
+	router.Use(middleware.ReadOnly())
+
+	// Liveness and readiness probes, and metrics. Services being down are reported, but keep the
+	// gateway ready so the routes of the other services keep working.
+	for name, url := range map[string]string{
+		"auth-service":         cfg.AuthServiceURL,
+		"permission-service":   cfg.PermissionServiceURL,
+		"core-service":         cfg.CoreServiceURL,
+		"notification-service": cfg.NotificationServiceURL,
+		"document-service":     cfg.DocumentServiceURL,
+	} {
+		health.AddOptionalCheck(name, health.HTTPCheck(url+"/health/live"))
+	}
+
+	// Job queue inspection for the dashboard; the gateway runs no jobs itself
+	if err := jobs.Init("api-gateway"); err != nil {
+		log.Printf("⚠️  Warning: Job queue not available, the job dashboard is disabled: %v", err)
+	} else {
+		health.AddOptionalCheck("job queue", health.Ping(jobs.Ping))
+		server.OnShutdown("job queue", jobs.Close)
+	}
+	health.Register(router, "api-gateway")
 
 	// Test endpoint
 	router.GET("/api/test", func(c *gin.Context) {
@@ -134,6 +237,9 @@ func main() {
 	router.DELETE("/api/permissions/:id",
 		middleware.RequirePermission("permissions", "delete"),
 		routes.ProxyToService("permissions"))
+	router.GET("/api/permissions/:id/history",
+		middleware.RequirePermission("permissions", "read"),
+		routes.ProxyToService("permissions"))
 
 	// Resource Management routes
 	router.GET("/api/permissions/resources",
@@ -168,9 +274,104 @@ func main() {
 		middleware.RequirePermission("permissions", "manage"),
 		routes.ProxyToService("permissions"))
 
+	// Permission matrix report, as JSON or a CSV file
+	router.GET("/api/reports/permission-matrix",
+		middleware.RequirePermission("permissions", "read"),
+		routes.ProxyToService("permissions"))
+
+	// Global search (each entity type additionally requires its read permission)
+	router.GET("/api/search",
+		middleware.RequireAuthentication(),
+		handlers.Search)
+
+	// Background job dashboard (super admin only)
+	router.GET("/api/jobs",
+		middleware.RequirePermission("ALL", "manage"),
+		handlers.GetJobStats)
+	router.GET("/api/jobs/:service/:state",
+		middleware.RequirePermission("ALL", "manage"),
+		handlers.ListJobs)
+	router.POST("/api/jobs/:service/:id/retry",
+		middleware.RequirePermission("ALL", "manage"),
+		handlers.RetryJob)
+	router.DELETE("/api/jobs/:service/:id",
+		middleware.RequirePermission("ALL", "manage"),
+		handlers.DeleteJob)
+
+	// Scheduled jobs and their run history (super admin only)
+	router.GET("/api/scheduler/jobs",
+		middleware.RequirePermission("ALL", "manage"),
+		handlers.GetScheduledJobs)
+	router.GET("/api/scheduler/runs",
+		middleware.RequirePermission("ALL", "manage"),
+		handlers.GetScheduledJobRuns)
+	router.GET("/api/scheduler/runs/:id",
+		middleware.RequirePermission("ALL", "manage"),
+		handlers.GetScheduledJobRun)
+
+	// Audit logs (organization admins see the logs of their organization's users)
+	router.GET("/api/audit-logs",
+		middleware.RequirePermission("security-logs", "read"),
+		handlers.GetAuditLogs)
+	router.GET("/api/audit-logs/export",
+		middleware.RawResponse(), // CSV and JSON files are downloaded as they are
+		middleware.RequirePermission("security-logs", "read"),
+		handlers.ExportAuditLogs)
+	router.GET("/api/audit-logs/:id",
+		middleware.RequirePermission("security-logs", "read"),
+		handlers.GetAuditLog)
+	// Read-only mode of the platform (super admin only), served in read-only mode as well
+	router.GET(middleware.ReadOnlySwitchPath,
+		middleware.RequirePermission("ALL", "manage"),
+		handlers.GetReadOnly)
+	router.PUT(middleware.ReadOnlySwitchPath,
+		middleware.RequirePermission("ALL", "manage"),
+		handlers.SetReadOnly)
+
+	// IP reputations and bans of the gateway
+	ipBanHandler := handlers.NewIPBanHandler(ipReputation)
+	router.GET("/api/security/ip-reputation",
+		middleware.RequirePermission("ALL", "manage"),
+		ipBanHandler.GetIPReputations)
+	router.GET("/api/security/ip-bans",
+		middleware.RequirePermission("ALL", "manage"),
+		ipBanHandler.GetIPBans)
+	router.POST("/api/security/ip-bans",
+		middleware.RequirePermission("ALL", "manage"),
+		ipBanHandler.BanIP)
+	router.DELETE("/api/security/ip-bans/:ip",
+		middleware.RequirePermission("ALL", "manage"),
+		ipBanHandler.UnbanIP)
+
+	// Archives of old audit logs are written to document storage by the document service
+	router.GET("/api/audit-logs/archives",
+		middleware.RequirePermission("ALL", "manage"),
+		routes.ProxyToService("document"))
+	router.POST("/api/audit-logs/archives",
+		middleware.RequirePermission("ALL", "manage"),
+		routes.ProxyToService("document"))
+	router.POST("/api/audit-logs/archives/:id/verify",
+		middleware.RequirePermission("ALL", "manage"),
+		routes.ProxyToService("document"))
+
+	// Backups of the database and document storage are written by the document service
+	router.GET("/api/backups",
+		middleware.RequirePermission("ALL", "manage"),
+		routes.ProxyToService("document"))
+	router.POST("/api/backups",
+		middleware.RequirePermission("ALL", "manage"),
+		routes.ProxyToService("document"))
+	router.GET("/api/backups/:id",
+		middleware.RequirePermission("ALL", "manage"),
+		routes.ProxyToService("document"))
+	router.DELETE("/api/backups/:id",
+		middleware.RequirePermission("ALL", "manage"),
+		routes.ProxyToService("document"))
+
 	// Core service routes
 	router.GET("/api/users",
 		middleware.RequirePermission("users", "read"),
+		middleware.RequirePermissionForQuery("include_deleted", "users", "manage"),
 		routes.ProxyToService("core"))
 	router.POST("/api/users",
 		middleware.RequirePermission("users", "create"),
@@ -181,12 +382,63 @@ func main() {
 	router.DELETE("/api/users/:id",
 		middleware.RequirePermission("users", "delete"),
 		routes.ProxyToService("core"))
+	router.POST("/api/users/:id/restore",
+		middleware.RequirePermission("users", "delete"),
+		routes.ProxyToService("core"))
+	router.POST("/api/users/:id/merge",
+		middleware.RequirePermission("users", "manage"),
+		routes.ProxyToService("core"))
+	router.POST("/api/users/:id/offboard",
+		middleware.RequirePermission("users", "manage"),
+		routes.ProxyToService("core"))
+	router.POST("/api/users/:id/activate",
+		middleware.RequirePermission("users", "update"),
+		routes.ProxyToService("core"))
+	router.POST("/api/users/:id/suspend",
+		middleware.RequirePermission("users", "update"),
+		routes.ProxyToService("core"))
+	router.POST("/api/users/:id/deactivate",
+		middleware.RequirePermission("users", "update"),
+		routes.ProxyToService("core"))
+	router.GET("/api/users/:id/transitions",
+		middleware.RequirePermission("users", "read"),
+		routes.ProxyToService("core"))
+	router.GET("/api/users/:id/role-history",
+		middleware.RequirePermission("users", "read"),
+		routes.ProxyToService("core"))
+	router.GET("/api/users/:id/role-changes",
+		middleware.RequirePermission("users", "read"),
+		routes.ProxyToService("core"))
+	router.POST("/api/users/:id/role-changes",
+		middleware.RequirePermission("users", "update"),
+		routes.ProxyToService("core"))
+	router.DELETE("/api/users/:id/role-changes/:change_id",
+		middleware.RequirePermission("users", "update"),
+		routes.ProxyToService("core"))
+	router.POST("/api/users/:id/avatar",
+		middleware.RequirePermission("users", "update"),
+		routes.ProxyToService("document"))
+	router.DELETE("/api/users/:id/avatar",
+		middleware.RequirePermission("users", "update"),
+		routes.ProxyToService("document"))
+	// Avatar images are public so they can be cached by a CDN
+	router.GET("/api/avatars/:user_id/:file", routes.ProxyToService("document"))
+	router.GET("/api/users/:id/history",
+		middleware.RequirePermission("users", "read"),
+		routes.ProxyToService("core"))
+	router.PUT("/api/users/:id/quota",
+		middleware.RequirePermission("users", "manage"),
+		routes.ProxyToService("core"))
 	router.GET("/api/users/:id/permissions",
 		middleware.RequirePermission("users", "read"),
 		routes.ProxyToService("core"))
 
 	// Role routes
 	router.GET("/api/roles",
+		middleware.RequirePermission("roles", "read"),
+		middleware.RequirePermissionForQuery("include_deleted", "roles", "manage"),
+		routes.ProxyToService("core"))
+	router.GET("/api/roles/catalog",
 		middleware.RequirePermission("roles", "read"),
 		routes.ProxyToService("core"))
 	router.POST("/api/roles",
@@ -198,13 +450,32 @@ func main() {
 	router.DELETE("/api/roles/:id",
 		middleware.RequirePermission("roles", "delete"),
 		routes.ProxyToService("core"))
+	router.POST("/api/roles/:id/restore",
+		middleware.RequirePermission("roles", "delete"),
+		routes.ProxyToService("core"))
+	router.GET("/api/roles/:id/history",
+		middleware.RequirePermission("roles", "read"),
+		routes.ProxyToService("core"))
 	router.GET("/api/roles/:id/permissions",
 		middleware.RequirePermission("roles", "read"),
 		routes.ProxyToService("core"))
+	router.POST("/api/roles/:id/clone",
+		middleware.RequirePermission("roles", "create"),
+		routes.ProxyToService("core"))
+	router.GET("/api/roles/:id/users",
+		middleware.RequirePermission("users", "read"),
+		routes.ProxyToService("core"))
+	router.POST("/api/roles/:id/users",
+		middleware.RequirePermission("users", "update"),
+		routes.ProxyToService("core"))
+	router.DELETE("/api/roles/:id/users",
+		middleware.RequirePermission("users", "update"),
+		routes.ProxyToService("core"))
 
 	// Organization routes
 	router.GET("/api/organizations",
 		middleware.RequirePermission("organizations", "read"),
+		middleware.RequirePermissionForQuery("include_deleted", "organizations", "manage"),
 		routes.ProxyToService("core"))
 	router.POST("/api/organizations",
 		middleware.RequirePermission("organizations", "create"),
@@ -215,17 +486,181 @@ func main() {
 	router.DELETE("/api/organizations/:id",
 		middleware.RequirePermission("organizations", "delete"),
 		routes.ProxyToService("core"))
+	router.POST("/api/organizations/:id/restore",
+		middleware.RequirePermission("organizations", "delete"),
+		routes.ProxyToService("core"))
+	router.GET("/api/organizations/:id/history",
+		middleware.RequirePermission("organizations", "read"),
+		routes.ProxyToService("core"))
 	router.GET("/api/organizations/:id/permissions",
 		middleware.RequirePermission("organizations", "read"),
 		routes.ProxyToService("core"))
+	router.POST("/api/organizations/:id/roles/catalog",
+		middleware.RequirePermission("roles", "create"),
+		routes.ProxyToService("core"))
+	router.GET("/api/organizations/:id/usage",
+		middleware.RequirePermission("organizations", "manage"),
+		routes.ProxyToService("core"))
+	router.GET("/api/organizations/:id/usage/api",
+		middleware.RequirePermission("organizations", "manage"),
+		routes.ProxyToService("core"))
+	router.PUT("/api/organizations/:id/quota",
+		middleware.RequirePermission("organizations", "manage"),
+		routes.ProxyToService("core"))
+	router.GET("/api/organizations/:id/file-policy",
+		middleware.RequirePermission("organizations", "read"),
+		routes.ProxyToService("core"))
+	router.PUT("/api/organizations/:id/file-policy",
+		middleware.RequirePermission("organizations", "manage"),
+		routes.ProxyToService("core"))
+	router.DELETE("/api/organizations/:id/file-policy",
+		middleware.RequirePermission("organizations", "manage"),
+		routes.ProxyToService("core"))
+	router.GET("/api/organizations/:id/storage",
+		middleware.RequirePermission("organizations", "manage"),
+		routes.ProxyToService("document"))
+	router.PUT("/api/organizations/:id/storage",
+		middleware.RequirePermission("organizations", "manage"),
+		routes.ProxyToService("document"))
+	router.DELETE("/api/organizations/:id/storage",
+		middleware.RequirePermission("organizations", "manage"),
+		routes.ProxyToService("document"))
+	router.GET("/api/organizations/:id/user-fields",
+		middleware.RequirePermission("organizations", "read"),
+		routes.ProxyToService("core"))
+	router.POST("/api/organizations/:id/user-fields",
+		middleware.RequirePermission("organizations", "update"),
+		routes.ProxyToService("core"))
+	router.PUT("/api/organizations/:id/user-fields/:field_id",
+		middleware.RequirePermission("organizations", "update"),
+		routes.ProxyToService("core"))
+	router.DELETE("/api/organizations/:id/user-fields/:field_id",
+		middleware.RequirePermission("organizations", "update"),
+		routes.ProxyToService("core"))
+	router.GET("/api/organizations/:id/domains",
+		middleware.RequirePermission("organizations", "manage"),
+		routes.ProxyToService("core"))
+	router.POST("/api/organizations/:id/domains",
+		middleware.RequirePermission("organizations", "manage"),
+		routes.ProxyToService("core"))
+	router.PUT("/api/organizations/:id/domains/:domain_id",
+		middleware.RequirePermission("organizations", "manage"),
+		routes.ProxyToService("core"))
+	router.DELETE("/api/organizations/:id/domains/:domain_id",
+		middleware.RequirePermission("organizations", "manage"),
+		routes.ProxyToService("core"))
+	router.POST("/api/organizations/:id/domains/:domain_id/verify",
+		middleware.RequirePermission("organizations", "manage"),
+		routes.ProxyToService("core"))
+	router.GET("/api/organizations/:id/join-requests",
+		middleware.RequirePermission("organizations", "manage"),
+		routes.ProxyToService("core"))
+	router.POST("/api/organizations/:id/join-requests/:request_id/approve",
+		middleware.RequirePermission("organizations", "manage"),
+		routes.ProxyToService("core"))
+	router.POST("/api/organizations/:id/join-requests/:request_id/reject",
+		middleware.RequirePermission("organizations", "manage"),
+		routes.ProxyToService("core"))
+
+	// Team routes
+	router.GET("/api/teams",
+		middleware.RequirePermission("teams", "read"),
+		routes.ProxyToService("core"))
+	router.GET("/api/teams/:id",
+		middleware.RequirePermission("teams", "read"),
+		routes.ProxyToService("core"))
+	router.POST("/api/teams",
+		middleware.RequirePermission("teams", "create"),
+		routes.ProxyToService("core"))
+	router.PUT("/api/teams/:id",
+		middleware.RequirePermission("teams", "update"),
+		routes.ProxyToService("core"))
+	router.DELETE("/api/teams/:id",
+		middleware.RequirePermission("teams", "delete"),
+		routes.ProxyToService("core"))
+	router.GET("/api/teams/:id/members",
+		middleware.RequirePermission("teams", "read"),
+		routes.ProxyToService("core"))
+	router.POST("/api/teams/:id/members",
+		middleware.RequirePermission("teams", "update"),
+		routes.ProxyToService("core"))
+	router.DELETE("/api/teams/:id/members/:user_id",
+		middleware.RequirePermission("teams", "update"),
+		routes.ProxyToService("core"))
+	router.GET("/api/teams/:id/permissions",
+		middleware.RequirePermission("teams", "read"),
+		routes.ProxyToService("core"))
+
+	// Webhook routes
+	router.GET("/api/webhooks",
+		middleware.RequirePermission("webhooks", "read"),
+		routes.ProxyToService("core"))
+	router.GET("/api/webhooks/:id",
+		middleware.RequirePermission("webhooks", "read"),
+		routes.ProxyToService("core"))
+	router.POST("/api/webhooks",
+		middleware.RequirePermission("webhooks", "create"),
+		routes.ProxyToService("core"))
+	router.PUT("/api/webhooks/:id",
+		middleware.RequirePermission("webhooks", "update"),
+		routes.ProxyToService("core"))
+	router.DELETE("/api/webhooks/:id",
+		middleware.RequirePermission("webhooks", "delete"),
+		routes.ProxyToService("core"))
+	router.POST("/api/webhooks/:id/rotate-secret",
+		middleware.RequirePermission("webhooks", "update"),
+		routes.ProxyToService("core"))
+	router.GET("/api/webhooks/:id/deliveries",
+		middleware.RequirePermission("webhooks", "read"),
+		routes.ProxyToService("core"))
+	router.POST("/api/webhooks/:id/deliveries/:delivery_id/redeliver",
+		middleware.RequirePermission("webhooks", "update"),
+		routes.ProxyToService("core"))
+
+	// Registration rule routes
+	router.GET("/api/registration-rules",
+		middleware.RequirePermission("users", "manage"),
+		routes.ProxyToService("core"))
+	router.POST("/api/registration-rules",
+		middleware.RequirePermission("users", "manage"),
+		routes.ProxyToService("core"))
+	router.PUT("/api/registration-rules/:id",
+		middleware.RequirePermission("users", "manage"),
+		routes.ProxyToService("core"))
+	router.DELETE("/api/registration-rules/:id",
+		middleware.RequirePermission("users", "manage"),
+		routes.ProxyToService("core"))
 
 	// Notification service routes
 	router.GET("/api/notifications",
 		middleware.RequirePermission("notifications", "read"),
 		routes.ProxyToService("notification"))
+	router.GET("/api/notifications/stream",
+		middleware.RawResponse(), // Server-Sent Events are streamed as they are
+		middleware.RealtimeToken(),
+		middleware.RequirePermission("notifications", "read"),
+		routes.ProxyToService("notification"))
 	router.POST("/api/notifications",
 		middleware.RequirePermission("notifications", "create"),
 		routes.ProxyToService("notification"))
+	router.POST("/api/notifications/broadcast",
+		middleware.RequirePermission("notifications", "manage"),
+		routes.ProxyToService("notification"))
+	router.GET("/api/notifications/unread-count",
+		middleware.RequirePermission("notifications", "read"),
+		routes.ProxyToService("notification"))
+	router.GET("/api/notifications/archive",
+		middleware.RequirePermission("notifications", "read"),
+		routes.ProxyToService("notification"))
+	router.POST("/api/notifications/read-all",
+		middleware.RequirePermission("notifications", "update"),
+		routes.ProxyToService("notification"))
+	router.POST("/api/notifications/bulk/delete",
+		middleware.RequirePermission("notifications", "delete"),
+		routes.ProxyToService("notification"))
+	router.POST("/api/notifications/bulk/archive",
+		middleware.RequirePermission("notifications", "update"),
+		routes.ProxyToService("notification"))
 	router.GET("/api/notifications/:id",
 		middleware.RequirePermission("notifications", "read"),
 		routes.ProxyToService("notification"))
@@ -235,12 +670,88 @@ func main() {
 	router.DELETE("/api/notifications/:id",
 		middleware.RequirePermission("notifications", "delete"),
 		routes.ProxyToService("notification"))
+	router.GET("/api/notifications/:id/deliveries",
+		middleware.RequirePermission("notifications", "manage"),
+		routes.ProxyToService("notification"))
+
+	// Scheduled notification routes - one-off and recurring notifications and emails
+	router.GET("/api/notifications/scheduled",
+		middleware.RequirePermission("notifications", "read"),
+		routes.ProxyToService("notification"))
+	router.POST("/api/notifications/scheduled",
+		middleware.RequirePermission("notifications", "create"),
+		routes.ProxyToService("notification"))
+	router.GET("/api/notifications/scheduled/:id",
+		middleware.RequirePermission("notifications", "read"),
+		routes.ProxyToService("notification"))
+	router.DELETE("/api/notifications/scheduled/:id",
+		middleware.RequirePermission("notifications", "delete"),
+		routes.ProxyToService("notification"))
+
+	// Notification integration routes - Slack, Teams and webhooks of the organization
+	router.GET("/api/notifications/integrations",
+		middleware.RequirePermission("notifications", "read"),
+		routes.ProxyToService("notification"))
+	router.POST("/api/notifications/integrations",
+		middleware.RequirePermission("notifications", "manage"),
+		routes.ProxyToService("notification"))
+	router.GET("/api/notifications/integrations/:id",
+		middleware.RequirePermission("notifications", "read"),
+		routes.ProxyToService("notification"))
+	router.PUT("/api/notifications/integrations/:id",
+		middleware.RequirePermission("notifications", "manage"),
+		routes.ProxyToService("notification"))
+	router.DELETE("/api/notifications/integrations/:id",
+		middleware.RequirePermission("notifications", "manage"),
+		routes.ProxyToService("notification"))
+	router.POST("/api/notifications/integrations/:id/test",
+		middleware.RequirePermission("notifications", "manage"),
+		routes.ProxyToService("notification"))
+	router.GET("/api/notifications/integrations/:id/deliveries",
+		middleware.RequirePermission("notifications", "read"),
+		routes.ProxyToService("notification"))
+
+	// Preference routes - every user manages their own channels and quiet hours
+	router.GET("/api/notifications/preferences",
+		middleware.RequireAuthentication(),
+		routes.ProxyToService("notification"))
+	router.PUT("/api/notifications/preferences/:category",
+		middleware.RequireAuthentication(),
+		routes.ProxyToService("notification"))
+
+	// Push device routes - every user manages their own devices
+	router.GET("/api/notifications/devices",
+		middleware.RequireAuthentication(),
+		routes.ProxyToService("notification"))
+	router.POST("/api/notifications/devices",
+		middleware.RequireAuthentication(),
+		routes.ProxyToService("notification"))
+	router.DELETE("/api/notifications/devices/:id",
+		middleware.RequireAuthentication(),
+		routes.ProxyToService("notification"))
+
+	// SMS routes - every user verifies their own phone, security alerts are sent by admins and services
+	router.GET("/api/notifications/sms/phone",
+		middleware.RequireAuthentication(),
+		routes.ProxyToService("notification"))
+	router.POST("/api/notifications/sms/phone/verification",
+		middleware.RequireAuthentication(),
+		routes.ProxyToService("notification"))
+	router.POST("/api/notifications/sms/phone/verify",
+		middleware.RequireAuthentication(),
+		routes.ProxyToService("notification"))
+	router.POST("/api/notifications/sms/security-alerts",
+		middleware.RequirePermission("notifications", "manage"),
+		routes.ProxyToService("notification"))
 
 	// Email service routes
 	// Protected route - only admin/system can send arbitrary emails
 	router.POST("/api/notifications/email/send",
 		middleware.RequirePermission("notifications", "create"),
 		routes.ProxyToService("notification"))
+	router.POST("/api/notifications/email/user-action",
+		middleware.RequirePermission("notifications", "create"),
+		routes.ProxyToService("notification"))
 
 	router.POST("/api/notifications/email/welcome",
 		routes.ProxyToService("notification"))
@@ -251,8 +762,91 @@ func main() {
 	router.POST("/api/notifications/email/resend-verification",
 		routes.ProxyToService("notification"))
 
-	// WebSocket routes
+	// Email queue routes - delivery status and dead letters
+	router.GET("/api/notifications/email/messages",
+		middleware.RequirePermission("notifications", "read"),
+		routes.ProxyToService("notification"))
+	router.GET("/api/notifications/email/messages/:id",
+		middleware.RequirePermission("notifications", "read"),
+		routes.ProxyToService("notification"))
+	router.GET("/api/notifications/email/messages/:id/events",
+		middleware.RequirePermission("notifications", "read"),
+		routes.ProxyToService("notification"))
+	router.GET("/api/notifications/email/analytics",
+		middleware.RequirePermission("notifications", "read"),
+		routes.ProxyToService("notification"))
+	router.GET("/api/notifications/email/dead-letters",
+		middleware.RequirePermission("notifications", "read"),
+		routes.ProxyToService("notification"))
+	router.POST("/api/notifications/email/dead-letters/:id/retry",
+		middleware.RequirePermission("notifications", "manage"),
+		routes.ProxyToService("notification"))
+
+	// Email suppression list - shared by all organizations, super admins only
+	router.GET("/api/notifications/email/suppressions",
+		middleware.RequirePermission("notifications", "manage"),
+		routes.ProxyToService("notification"))
+	router.POST("/api/notifications/email/suppressions",
+		middleware.RequirePermission("notifications", "manage"),
+		routes.ProxyToService("notification"))
+	router.DELETE("/api/notifications/email/suppressions/:email",
+		middleware.RequirePermission("notifications", "manage"),
+		routes.ProxyToService("notification"))
+
+	// Public routes - provider event webhooks are verified by their signatures, tracking links are signed.
+	// Providers, mail clients and browsers get the answers of the service as they are.
+	router.POST("/api/notifications/email/webhooks/ses",
+		middleware.RawResponse(),
+		routes.ProxyToService("notification"))
+	router.POST("/api/notifications/email/webhooks/sendgrid",
+		middleware.RawResponse(),
+		routes.ProxyToService("notification"))
+	router.POST("/api/notifications/email/webhooks/mailgun",
+		middleware.RawResponse(),
+		routes.ProxyToService("notification"))
+	router.GET("/api/notifications/email/track/open/:token",
+		middleware.RawResponse(),
+		routes.ProxyToService("notification"))
+	router.GET("/api/notifications/email/track/click/:token",
+		middleware.RawResponse(),
+		routes.ProxyToService("notification"))
+
+	// Email template routes - global templates and organization overrides
+	router.GET("/api/notifications/email/templates",
+		middleware.RequirePermission("notifications", "read"),
+		routes.ProxyToService("notification"))
+	router.POST("/api/notifications/email/templates",
+		middleware.RequirePermission("notifications", "manage"),
+		routes.ProxyToService("notification"))
+	router.GET("/api/notifications/email/templates/:id",
+		middleware.RequirePermission("notifications", "read"),
+		routes.ProxyToService("notification"))
+	router.PUT("/api/notifications/email/templates/:id",
+		middleware.RequirePermission("notifications", "manage"),
+		routes.ProxyToService("notification"))
+	router.DELETE("/api/notifications/email/templates/:id",
+		middleware.RequirePermission("notifications", "manage"),
+		routes.ProxyToService("notification"))
+	router.GET("/api/notifications/email/templates/:id/versions",
+		middleware.RequirePermission("notifications", "read"),
+		routes.ProxyToService("notification"))
+	router.POST("/api/notifications/email/templates/:id/versions/:version/restore",
+		middleware.RequirePermission("notifications", "manage"),
+		routes.ProxyToService("notification"))
+	router.POST("/api/notifications/email/templates/:id/preview",
+		middleware.RequirePermission("notifications", "read"),
+		routes.ProxyToService("notification"))
+	router.POST("/api/notifications/email/templates/:id/test-send",
+		middleware.RequirePermission("notifications", "manage"),
+		routes.ProxyToService("notification"))
+
+	// WebSocket routes - the connection belongs to the user of the access token
+	router.GET("/ws/notifications",
+		middleware.RealtimeToken(),
+		middleware.RequirePermission("notifications", "read"),
+		routes.ProxyToService("notification"))
 	router.GET("/ws/notifications/:user_id",
+		middleware.RealtimeToken(),
 		middleware.RequirePermission("notifications", "read"),
 		routes.ProxyToService("notification"))
 
@@ -265,22 +859,48 @@ func main() {
 		middleware.RequirePermission("file-management", "create"),
 		routes.ProxyToService("document"))
 	router.GET("/api/folders/:id",
-		middleware.RequirePermission("file-management", "read"),
+		middleware.RequireObjectPermission("file-management", "read", "folder", "id"),
 		routes.ProxyToService("document"))
 	router.PUT("/api/folders/:id",
-		middleware.RequirePermission("file-management", "update"),
+		middleware.RequireObjectPermission("file-management", "update", "folder", "id"),
 		routes.ProxyToService("document"))
 	router.POST("/api/folders/:id/move",
-		middleware.RequirePermission("file-management", "update"),
+		middleware.RequireObjectPermission("file-management", "update", "folder", "id"),
 		routes.ProxyToService("document"))
 	router.DELETE("/api/folders/:id",
-		middleware.RequirePermission("file-management", "delete"),
+		middleware.RequireObjectPermission("file-management", "delete", "folder", "id"),
+		routes.ProxyToService("document"))
+	router.POST("/api/folders/:id/restore",
+		middleware.RequireObjectPermission("file-management", "delete", "folder", "id"),
 		routes.ProxyToService("document"))
 	router.GET("/api/folders/:id/contents",
-		middleware.RequirePermission("file-management", "read"),
+		middleware.RequireObjectPermission("file-management", "read", "folder", "id"),
 		routes.ProxyToService("document"))
 	router.GET("/api/folders/:id/download",
-		middleware.RequirePermission("file-management", "read"),
+		middleware.RequireObjectPermission("file-management", "read", "folder", "id"),
+		routes.ProxyToService("document"))
+	router.GET("/api/folders/:id/size",
+		middleware.RequireObjectPermission("file-management", "read", "folder", "id"),
+		routes.ProxyToService("document"))
+	router.GET("/api/folders/:id/stats",
+		middleware.RequireObjectPermission("file-management", "read", "folder", "id"),
+		routes.ProxyToService("document"))
+	router.POST("/api/folders/:id/export",
+		middleware.RequireObjectPermission("file-management", "read", "folder", "id"),
+		routes.ProxyToService("document"))
+	// Folder exports are only visible to the user who started them
+	router.GET("/api/folder-exports/:id",
+		middleware.RequireAuthentication(),
+		routes.ProxyToService("document"))
+	router.GET("/api/folder-exports/:id/download",
+		middleware.RequireAuthentication(),
+		routes.ProxyToService("document"))
+	// Batch routes, the document service checks the access grants of every item
+	router.POST("/api/folders/bulk/move",
+		middleware.RequirePermission("file-management", "update"),
+		routes.ProxyToService("document"))
+	router.POST("/api/folders/bulk/delete",
+		middleware.RequirePermission("file-management", "delete"),
 		routes.ProxyToService("document"))
 
 	// Document routes
@@ -290,42 +910,251 @@ func main() {
 	router.POST("/api/documents",
 		middleware.RequirePermission("file-management", "create"),
 		routes.ProxyToService("document"))
-	router.GET("/api/documents/:id",
+	router.GET("/api/documents/search",
 		middleware.RequirePermission("file-management", "read"),
 		routes.ProxyToService("document"))
+	router.GET("/api/documents/:id",
+		middleware.RequireObjectPermission("file-management", "read", "document", "id"),
+		routes.ProxyToService("document"))
 	router.GET("/api/documents/:id/download",
-		middleware.RequirePermission("file-management", "read"),
+		middleware.RequireObjectPermission("file-management", "read", "document", "id"),
+		routes.ProxyToService("document"))
+	router.GET("/api/documents/:id/render",
+		middleware.RequireObjectPermission("file-management", "read", "document", "id"),
 		routes.ProxyToService("document"))
 	router.PUT("/api/documents/:id",
-		middleware.RequirePermission("file-management", "update"),
+		middleware.RequireObjectPermission("file-management", "update", "document", "id"),
 		routes.ProxyToService("document"))
 	router.DELETE("/api/documents/:id",
-		middleware.RequirePermission("file-management", "delete"),
+		middleware.RequireObjectPermission("file-management", "delete", "document", "id"),
+		routes.ProxyToService("document"))
+	router.POST("/api/documents/:id/restore",
+		middleware.RequireObjectPermission("file-management", "delete", "document", "id"),
 		routes.ProxyToService("document"))
 	router.POST("/api/documents/:id/move",
-		middleware.RequirePermission("file-management", "update"),
+		middleware.RequireObjectPermission("file-management", "update", "document", "id"),
 		routes.ProxyToService("document"))
 	router.POST("/api/documents/:id/copy",
 		middleware.RequirePermission("file-management", "update"),
 		routes.ProxyToService("document"))
+	router.POST("/api/documents/bulk/move",
+		middleware.RequirePermission("file-management", "update"),
+		routes.ProxyToService("document"))
+	router.POST("/api/documents/bulk/copy",
+		middleware.RequirePermission("file-management", "update"),
+		routes.ProxyToService("document"))
+	router.POST("/api/documents/bulk/delete",
+		middleware.RequirePermission("file-management", "delete"),
+		routes.ProxyToService("document"))
+	router.POST("/api/documents/bulk/tag",
+		middleware.RequirePermission("file-management", "update"),
+		routes.ProxyToService("document"))
+
+	// Recent and favorite documents, per caller
+	router.GET("/api/documents/recent",
+		middleware.RequirePermission("file-management", "read"),
+		routes.ProxyToService("document"))
+	router.GET("/api/documents/favorites",
+		middleware.RequirePermission("file-management", "read"),
+		routes.ProxyToService("document"))
+	router.POST("/api/documents/:id/star",
+		middleware.RequireObjectPermission("file-management", "read", "document", "id"),
+		routes.ProxyToService("document"))
+	router.DELETE("/api/documents/:id/star",
+		middleware.RequirePermission("file-management", "read"),
+		routes.ProxyToService("document"))
+
+	// Document activity, the feed of a document and the compliance export of all documents
+	router.GET("/api/documents/:id/activity",
+		middleware.RequireObjectPermission("file-management", "read", "document", "id"),
+		routes.ProxyToService("document"))
+	router.GET("/api/documents/activity/export",
+		middleware.RequirePermission("file-management", "manage"),
+		routes.ProxyToService("document"))
 
 	// Document version routes
 	router.GET("/api/documents/:id/versions",
-		middleware.RequirePermission("file-management", "read"),
+		middleware.RequireObjectPermission("file-management", "read", "document", "id"),
 		routes.ProxyToService("document"))
 	router.GET("/api/documents/:id/versions/latest",
-		middleware.RequirePermission("file-management", "read"),
+		middleware.RequireObjectPermission("file-management", "read", "document", "id"),
+		routes.ProxyToService("document"))
+	router.GET("/api/documents/:id/versions/compare",
+		middleware.RequireObjectPermission("file-management", "read", "document", "id"),
 		routes.ProxyToService("document"))
 	router.POST("/api/documents/:id/versions",
+		middleware.RequireObjectPermission("file-management", "create", "document", "id"),
+		routes.ProxyToService("document"))
+	router.GET("/api/documents/:id/versions/:version/download",
+		middleware.RequireObjectPermission("file-management", "read", "document", "id"),
+		routes.ProxyToService("document"))
+	router.POST("/api/documents/:id/versions/:version/restore",
+		middleware.RequireObjectPermission("file-management", "update", "document", "id"),
+		routes.ProxyToService("document"))
+	router.POST("/api/documents/:id/versions/:version/pin",
+		middleware.RequireObjectPermission("file-management", "update", "document", "id"),
+		routes.ProxyToService("document"))
+	router.DELETE("/api/documents/:id/versions/:version/pin",
+		middleware.RequireObjectPermission("file-management", "update", "document", "id"),
+		routes.ProxyToService("document"))
+
+	// Document lock routes, managers of a document can release the lock of another user
+	router.POST("/api/documents/:id/lock",
+		middleware.RequireObjectPermission("file-management", "update", "document", "id"),
+		routes.ProxyToService("document"))
+	router.DELETE("/api/documents/:id/lock",
+		middleware.RequireObjectPermission("file-management", "update", "document", "id"),
+		routes.ProxyToService("document"))
+	router.DELETE("/api/documents/:id/lock/force",
+		middleware.RequireObjectPermission("file-management", "manage", "document", "id"),
+		routes.ProxyToService("document"))
+
+	// Chunked upload routes
+	router.POST("/api/uploads",
+		middleware.RequirePermission("file-management", "create"),
+		routes.ProxyToService("document"))
+	router.GET("/api/uploads/:id",
 		middleware.RequirePermission("file-management", "create"),
 		routes.ProxyToService("document"))
+	router.PATCH("/api/uploads/:id",
+		middleware.RequirePermission("file-management", "create"),
+		routes.ProxyToService("document"))
+	router.POST("/api/uploads/:id/complete",
+		middleware.RequirePermission("file-management", "create"),
+		routes.ProxyToService("document"))
+	router.DELETE("/api/uploads/:id",
+		middleware.RequirePermission("file-management", "create"),
+		routes.ProxyToService("document"))
+
+	// Share routes
+	router.POST("/api/documents/:id/share",
+		middleware.RequireObjectPermission("file-management", "update", "document", "id"),
+		routes.ProxyToService("document"))
+	router.GET("/api/documents/:id/shares",
+		middleware.RequireObjectPermission("file-management", "read", "document", "id"),
+		routes.ProxyToService("document"))
+	router.DELETE("/api/documents/:id/shares/:share_id",
+		middleware.RequireObjectPermission("file-management", "update", "document", "id"),
+		routes.ProxyToService("document"))
+	// Share links are public, the signed token and the share's restrictions grant access
+	router.GET("/api/shares/:token", routes.ProxyToService("document"))
+	router.GET("/api/shares/:token/download", routes.ProxyToService("document"))
+
+	// Access control routes, the owners and managers of an object change its grants
+	router.GET("/api/folders/:id/acl",
+		middleware.RequireObjectPermission("file-management", "read", "folder", "id"),
+		routes.ProxyToService("document"))
+	router.POST("/api/folders/:id/acl",
+		middleware.RequireObjectPermission("file-management", "manage", "folder", "id"),
+		routes.ProxyToService("document"))
+	router.DELETE("/api/folders/:id/acl/:grant_id",
+		middleware.RequireObjectPermission("file-management", "manage", "folder", "id"),
+		routes.ProxyToService("document"))
+	router.GET("/api/documents/:id/acl",
+		middleware.RequireObjectPermission("file-management", "read", "document", "id"),
+		routes.ProxyToService("document"))
+	router.POST("/api/documents/:id/acl",
+		middleware.RequireObjectPermission("file-management", "manage", "document", "id"),
+		routes.ProxyToService("document"))
+	router.DELETE("/api/documents/:id/acl/:grant_id",
+		middleware.RequireObjectPermission("file-management", "manage", "document", "id"),
+		routes.ProxyToService("document"))
+
+	// Metadata field routes, managers of a folder define the metadata of its documents
+	router.GET("/api/folders/:id/metadata-fields",
+		middleware.RequireObjectPermission("file-management", "read", "folder", "id"),
+		routes.ProxyToService("document"))
+	router.POST("/api/folders/:id/metadata-fields",
+		middleware.RequireObjectPermission("file-management", "manage", "folder", "id"),
+		routes.ProxyToService("document"))
+	router.PUT("/api/folders/:id/metadata-fields/:field_id",
+		middleware.RequireObjectPermission("file-management", "manage", "folder", "id"),
+		routes.ProxyToService("document"))
+	router.DELETE("/api/folders/:id/metadata-fields/:field_id",
+		middleware.RequireObjectPermission("file-management", "manage", "folder", "id"),
+		routes.ProxyToService("document"))
+
+	// Retention and legal hold routes, managers of a folder set its retention policy
+	router.GET("/api/folders/:id/retention",
+		middleware.RequireObjectPermission("file-management", "read", "folder", "id"),
+		routes.ProxyToService("document"))
+	router.PUT("/api/folders/:id/retention",
+		middleware.RequireObjectPermission("file-management", "manage", "folder", "id"),
+		routes.ProxyToService("document"))
+	router.DELETE("/api/folders/:id/retention",
+		middleware.RequireObjectPermission("file-management", "manage", "folder", "id"),
+		routes.ProxyToService("document"))
+	router.GET("/api/legal-holds",
+		middleware.RequirePermission("file-management", "manage"),
+		routes.ProxyToService("document"))
+	router.POST("/api/legal-holds",
+		middleware.RequirePermission("file-management", "manage"),
+		routes.ProxyToService("document"))
+	router.POST("/api/legal-holds/:id/release",
+		middleware.RequirePermission("file-management", "manage"),
+		routes.ProxyToService("document"))
+
+	// Folder subscriptions, per caller
+	router.GET("/api/folders/subscriptions",
+		middleware.RequirePermission("file-management", "read"),
+		routes.ProxyToService("document"))
+	router.GET("/api/folders/:id/subscription",
+		middleware.RequirePermission("file-management", "read"),
+		routes.ProxyToService("document"))
+	router.PUT("/api/folders/:id/subscription",
+		middleware.RequireObjectPermission("file-management", "read", "folder", "id"),
+		routes.ProxyToService("document"))
+	router.DELETE("/api/folders/:id/subscription",
+		middleware.RequirePermission("file-management", "read"),
+		routes.ProxyToService("document"))
+
+	// Storage quota routes
+	router.GET("/api/storage/usage",
+		middleware.RequireAuthentication(),
+		routes.ProxyToService("document"))
+	router.GET("/api/storage/stats",
+		middleware.RequirePermission("file-management", "manage"),
+		routes.ProxyToService("document"))
+	router.GET("/api/storage/integrity",
+		middleware.RequirePermission("file-management", "manage"),
+		routes.ProxyToService("document"))
+	router.POST("/api/storage/integrity",
+		middleware.RequirePermission("file-management", "manage"),
+		routes.ProxyToService("document"))
+	router.GET("/api/storage/operations",
+		middleware.RequirePermission("file-management", "manage"),
+		routes.ProxyToService("document"))
+	router.POST("/api/storage/operations/:id/retry",
+		middleware.RequirePermission("file-management", "manage"),
+		routes.ProxyToService("document"))
+
+	// Trash routes
+	router.GET("/api/trash",
+		middleware.RequirePermission("file-management", "delete"),
+		routes.ProxyToService("document"))
+	router.DELETE("/api/trash",
+		middleware.RequirePermission("file-management", "manage"),
+		routes.ProxyToService("document"))
+
+	// WebDAV, mountable in Finder and Explorer with a token or an app password. The document service
+	// enforces folder and document access grants.
+	webdavAuth := middleware.NewWebDAVAuthenticator()
+	for _, method := range documentUtils.WebDAVMethods {
+		router.Handle(method, documentUtils.WebDAVPrefix+"/*path",
+			webdavAuth.Middleware(),
+			routes.ProxyToService("document"))
+	}
+
+	// One OpenAPI document of the gateway and every service, for external consumers
+	openAPI := openapi.NewAggregator(router, openapi.Sources())
+	router.GET("/docs/openapi.json", openAPI.Handler)
 
 	// Swagger documentation UI
 	// Swagger documentation UI - conditional olarak ekleyelim
 	router.GET("/swagger/*any", func(c *gin.Context) {
 		// Development environment'ta swagger'ı göster
 		if gin.Mode() == gin.DebugMode {
-			ginSwagger.WrapHandler(swaggerFiles.Handler)(c)
+			ginSwagger.WrapHandler(swaggerFiles.Handler, ginSwagger.URL("/docs/openapi.json"))(c)
 		} else {
 			c.JSON(http.StatusNotFound, gin.H{
 				"message": "Swagger documentation not available in production",
@@ -336,7 +1165,5 @@ func main() {
 	// Server Start
 	port := strings.Split(config.GetConfig().APIGatewayURL, ":")[2]
 	log.Printf("API Gateway is running on port %s", port)
-	if err := router.Run(":" + port); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
-	}
+	server.Run(router, ":"+port)
 }
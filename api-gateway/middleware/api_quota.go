@@ -0,0 +1,465 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/config"
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models"
+	"forgecrud-backend/shared/messaging"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+const (
+	// quotaCacheTTL bounds how long a changed API request budget takes to apply
+	quotaCacheTTL = time.Minute
+
+	// apiQuotaCountTimeout bounds counting a request in Redis; slower counts fall back to this instance
+	apiQuotaCountTimeout = 500 * time.Millisecond
+
+	// Counters in Redis outlive their day and minute, for gateway instances with a skewed clock
+	apiQuotaDayTTL    = 48 * time.Hour
+	apiQuotaMinuteTTL = 2 * time.Minute
+)
+
+type cachedAPIQuota struct {
+	perDay    int
+	perMinute int
+	expiresAt time.Time
+}
+
+// apiUsageKey is an organization's (UTC) day of API requests
+type apiUsageKey struct {
+	organizationID uuid.UUID
+	day            time.Time
+}
+
+// localAPICount counts an organization's requests on this instance while Redis is not used
+type localAPICount struct {
+	day            time.Time
+	requests       int64
+	minute         time.Time
+	minuteRequests int64
+}
+
+// APIQuotaLimiter meters API requests per organization and enforces the daily and per-minute request
+// budgets from the organization's quota. Requests are counted in Redis, shared by every gateway
+// instance, and each instance adds the requests it metered to organization_api_usage every
+// API_QUOTA_FLUSH_SECONDS, so no request waits for the database. With API_QUOTA_COUNTER_DRIVER=none,
+// or while Redis does not answer, each instance counts on its own.
+type APIQuotaLimiter struct {
+	redis         *redis.Client
+	keyPrefix     string
+	flushInterval time.Duration
+
+	mutex   sync.Mutex
+	quotas  map[uuid.UUID]cachedAPIQuota
+	local   map[uuid.UUID]*localAPICount
+	pending map[apiUsageKey]*database.APIUsage
+	alerted map[apiUsageKey]int // Highest share of the daily budget this instance notified about
+
+	metered   atomic.Int64 // Requests counted
+	fallbacks atomic.Int64 // Requests counted on this instance only, without Redis or as it failed
+
+	redisError atomic.Value // string, empty after a successful count
+	flushError atomic.Value // string, empty after a successful flush
+
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewAPIQuotaLimiter creates an APIQuotaLimiter with the API quota configuration; Start records the
+// metered requests
+func NewAPIQuotaLimiter() *APIQuotaLimiter {
+	cfg := config.GetConfig()
+	l := &APIQuotaLimiter{
+		keyPrefix:     cfg.APIQuotaKeyPrefix,
+		flushInterval: time.Duration(cfg.APIQuotaFlushSeconds) * time.Second,
+		quotas:        make(map[uuid.UUID]cachedAPIQuota),
+		local:         make(map[uuid.UUID]*localAPICount),
+		pending:       make(map[apiUsageKey]*database.APIUsage),
+		alerted:       make(map[apiUsageKey]int),
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+	if l.flushInterval <= 0 {
+		l.flushInterval = 10 * time.Second
+	}
+	l.redisError.Store("")
+	l.flushError.Store("")
+
+	// The client reconnects by itself, requests are counted on this instance meanwhile
+	if cfg.APIQuotaCounterDriver == "redis" {
+		l.redis = redis.NewClient(&redis.Options{
+			Addr:     fmt.Sprintf("%s:%s", cfg.RedisHost, cfg.RedisPort),
+			Password: cfg.RedisPassword,
+			DB:       cfg.RedisDB,
+		})
+	}
+	return l
+}
+
+// Start adds the metered requests to the daily usage in the background
+func (l *APIQuotaLimiter) Start() {
+	go l.run()
+	counter := "per instance"
+	if l.redis != nil {
+		counter = "redis"
+	}
+	log.Printf("📊 API quota limiter started (counters: %s, flush interval: %s)", counter, l.flushInterval)
+}
+
+// Close stops the background work after recording the requests metered so far
+func (l *APIQuotaLimiter) Close() error {
+	l.stopOnce.Do(func() { close(l.stop) })
+	<-l.done
+	if l.redis != nil {
+		return l.redis.Close()
+	}
+	return nil
+}
+
+// Check reports the counters of the health endpoints, failing while Redis or the database fail
+func (l *APIQuotaLimiter) Check(ctx context.Context) (interface{}, error) {
+	l.mutex.Lock()
+	pending := len(l.pending)
+	l.mutex.Unlock()
+
+	details := gin.H{
+		"metered":   l.metered.Load(),
+		"fallbacks": l.fallbacks.Load(),
+		"pending":   pending,
+	}
+	if redisError := l.redisError.Load().(string); redisError != "" {
+		return details, errors.New(redisError)
+	}
+	if flushError := l.flushError.Load().(string); flushError != "" {
+		return details, errors.New(flushError)
+	}
+	return details, nil
+}
+
+// Middleware counts every authenticated request against the caller's organization.
+// Requests without a valid token or organization are not metered; metering errors never block requests.
+func (l *APIQuotaLimiter) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, err := extractClaimsFromToken(c)
+		if err != nil {
+			c.Next()
+			return
+		}
+		organizationIDStr, _ := claims["organization_id"].(string)
+		organizationID, err := uuid.Parse(organizationIDStr)
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		now := time.Now().UTC()
+		requests, minuteRequests := l.count(c.Request.Context(), organizationID, now)
+
+		perDay, perMinute := l.limits(organizationID)
+		if perDay > 0 {
+			l.alert(organizationID, now, requests, perDay)
+		}
+
+		if perDay > 0 && requests > int64(perDay) {
+			resetAt := now.Truncate(24 * time.Hour).Add(24 * time.Hour)
+			respondQuotaExceeded(c, perDay, "day", now, resetAt)
+			return
+		}
+		if perMinute > 0 && minuteRequests > int64(perMinute) {
+			resetAt := now.Truncate(time.Minute).Add(time.Minute)
+			respondQuotaExceeded(c, perMinute, "minute", now, resetAt)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// count adds a request to the organization's counters of the day and the minute and returns their totals
+func (l *APIQuotaLimiter) count(ctx context.Context, organizationID uuid.UUID, now time.Time) (requests, minuteRequests int64) {
+	l.metered.Add(1)
+	day, minute := now.Truncate(24*time.Hour), now.Truncate(time.Minute)
+
+	counted := false
+	if l.redis != nil {
+		requests, minuteRequests, counted = l.countInRedis(ctx, organizationID, day, minute)
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if !counted {
+		l.fallbacks.Add(1)
+		local, ok := l.local[organizationID]
+		if !ok {
+			local = &localAPICount{}
+			l.local[organizationID] = local
+		}
+		if !local.day.Equal(day) {
+			local.day, local.requests = day, 0
+		}
+		if !local.minute.Equal(minute) {
+			local.minute, local.minuteRequests = minute, 0
+		}
+		local.requests++
+		local.minuteRequests++
+		requests, minuteRequests = local.requests, local.minuteRequests
+	}
+
+	key := apiUsageKey{organizationID: organizationID, day: day}
+	usage, ok := l.pending[key]
+	if !ok {
+		usage = &database.APIUsage{}
+		l.pending[key] = usage
+	}
+	usage.Requests++
+	if usage.MinuteStart.Before(minute) {
+		usage.MinuteStart, usage.MinuteRequests = minute, 0
+	}
+	if usage.MinuteStart.Equal(minute) {
+		usage.MinuteRequests = max(usage.MinuteRequests, minuteRequests)
+	}
+	usage.PeakMinuteRequests = max(usage.PeakMinuteRequests, minuteRequests)
+	return requests, minuteRequests
+}
+
+// countInRedis increments the counters shared by every gateway instance in one round trip
+func (l *APIQuotaLimiter) countInRedis(ctx context.Context, organizationID uuid.UUID, day, minute time.Time) (requests, minuteRequests int64, ok bool) {
+	ctx, cancel := context.WithTimeout(ctx, apiQuotaCountTimeout)
+	defer cancel()
+
+	dayKey := fmt.Sprintf("%s:%s:%s", l.keyPrefix, organizationID, day.Format("20060102"))
+	minuteKey := fmt.Sprintf("%s:%s:%s", l.keyPrefix, organizationID, minute.Format("200601021504"))
+	var dayCount, minuteCount *redis.IntCmd
+	_, err := l.redis.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		dayCount = pipe.Incr(ctx, dayKey)
+		pipe.Expire(ctx, dayKey, apiQuotaDayTTL)
+		minuteCount = pipe.Incr(ctx, minuteKey)
+		pipe.Expire(ctx, minuteKey, apiQuotaMinuteTTL)
+		return nil
+	})
+	if err != nil {
+		if l.redisError.Load().(string) == "" {
+			log.Printf("⚠️  API quota counters unavailable, counting per gateway instance: %v", err)
+		}
+		l.redisError.Store(fmt.Sprintf("redis: %v", err))
+		return 0, 0, false
+	}
+	if l.redisError.Load().(string) != "" {
+		l.redisError.Store("")
+	}
+	return dayCount.Val(), minuteCount.Val(), true
+}
+
+func (l *APIQuotaLimiter) run() {
+	defer close(l.done)
+
+	ticker := time.NewTicker(l.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			l.flush()
+		case <-l.stop:
+			l.flush()
+			return
+		}
+	}
+}
+
+// flush adds the requests metered since the last flush to the daily usage. Requests that could not be
+// recorded are kept for the next flush.
+func (l *APIQuotaLimiter) flush() {
+	l.mutex.Lock()
+	pending := l.pending
+	l.pending = make(map[apiUsageKey]*database.APIUsage)
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	for key := range l.alerted {
+		if key.day.Before(today) {
+			delete(l.alerted, key)
+		}
+	}
+	l.mutex.Unlock()
+	if len(pending) == 0 {
+		return
+	}
+
+	db, err := apiQuotaDB()
+	if err != nil {
+		l.flushError.Store(err.Error())
+		l.requeue(pending)
+		return
+	}
+
+	var lastErr error
+	for key, usage := range pending {
+		if err := database.RecordAPIUsage(db, key.organizationID, key.day, *usage); err != nil {
+			lastErr = err
+			continue
+		}
+		delete(pending, key)
+	}
+
+	if lastErr != nil {
+		l.flushError.Store(lastErr.Error())
+		l.requeue(pending)
+		log.Printf("❌ Failed to record API usage of %d organizations: %v", len(pending), lastErr)
+		return
+	}
+	l.flushError.Store("")
+}
+
+// requeue adds requests that could not be recorded back to those of the next flush
+func (l *APIQuotaLimiter) requeue(failed map[apiUsageKey]*database.APIUsage) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	for key, usage := range failed {
+		current, ok := l.pending[key]
+		if !ok {
+			l.pending[key] = usage
+			continue
+		}
+		current.Requests += usage.Requests
+		if current.MinuteStart.Equal(usage.MinuteStart) {
+			current.MinuteRequests = max(current.MinuteRequests, usage.MinuteRequests)
+		} else if current.MinuteStart.Before(usage.MinuteStart) {
+			current.MinuteStart, current.MinuteRequests = usage.MinuteStart, usage.MinuteRequests
+		}
+		current.PeakMinuteRequests = max(current.PeakMinuteRequests, usage.PeakMinuteRequests)
+	}
+}
+
+// respondQuotaExceeded answers 429 with the budget of the window that was used up and when it renews
+func respondQuotaExceeded(c *gin.Context, limit int, window string, now, resetAt time.Time) {
+	c.Header("Retry-After", strconv.Itoa(int(resetAt.Sub(now).Seconds())+1))
+	apperrors.Respond(c, apperrors.New(apperrors.CodeRateLimited, "API request quota exceeded").WithDetails(gin.H{
+		"limit":    limit,
+		"window":   window,
+		"reset_at": resetAt,
+	}).With("code", "QUOTA_EXCEEDED"))
+	c.Abort()
+}
+
+// alert notifies the organization owner once a day for each share of QUOTA_API_ALERT_PERCENTS of the
+// daily budget the organization reaches, off the request path
+func (l *APIQuotaLimiter) alert(organizationID uuid.UUID, now time.Time, requests int64, perDay int) {
+	key := apiUsageKey{organizationID: organizationID, day: now.Truncate(24 * time.Hour)}
+
+	l.mutex.Lock()
+	percent := 0
+	for _, threshold := range config.GetConfig().QuotaAPIAlertPercents {
+		if threshold > l.alerted[key] && requests*100 >= int64(threshold)*int64(perDay) {
+			percent = threshold
+		}
+	}
+	if percent > 0 {
+		l.alerted[key] = percent
+	}
+	l.mutex.Unlock()
+	if percent == 0 {
+		return
+	}
+
+	go func() {
+		db, err := apiQuotaDB()
+		if err != nil {
+			log.Printf("⚠️  Failed to record API quota alert: %v", err)
+			return
+		}
+
+		// Only the instance recording the share first notifies
+		marked, err := database.MarkAPIQuotaAlert(db, organizationID, percent)
+		if err != nil {
+			log.Printf("⚠️  Failed to record API quota alert: %v", err)
+			return
+		}
+		if !marked {
+			return
+		}
+
+		var organization models.Organization
+		if err := db.Select("id", "name", "owner_id").First(&organization, "id = ?", organizationID).Error; err != nil {
+			log.Printf("⚠️  API quota alert: organization %s not found: %v", organizationID, err)
+			return
+		}
+
+		priority := "normal"
+		description := fmt.Sprintf("Organization '%s' used %d%% of its daily budget of %d API requests.", organization.Name, percent, perDay)
+		if percent >= 100 {
+			priority = "high"
+			description = fmt.Sprintf("Organization '%s' used its daily budget of %d API requests; further requests are rejected until midnight UTC.", organization.Name, perDay)
+		}
+		messaging.Publish(context.Background(), messaging.EventOrganizationAPIQuota, nil, messaging.ActivityData{
+			OwnerID:      organization.OwnerID,
+			ActionType:   "API Quota Threshold",
+			ResourceType: "organization",
+			ResourceID:   organization.ID,
+			ResourceName: organization.Name,
+			Description:  description,
+			Priority:     priority,
+			Data: map[string]interface{}{
+				"percent":  percent,
+				"limit":    perDay,
+				"requests": requests,
+			},
+		})
+	}()
+}
+
+// limits returns the organization's daily and per-minute API request budgets, cached for quotaCacheTTL
+func (l *APIQuotaLimiter) limits(organizationID uuid.UUID) (perDay, perMinute int) {
+	l.mutex.Lock()
+	cached, ok := l.quotas[organizationID]
+	l.mutex.Unlock()
+	if ok && time.Now().Before(cached.expiresAt) {
+		return cached.perDay, cached.perMinute
+	}
+
+	db, err := apiQuotaDB()
+	if err != nil {
+		return cached.perDay, cached.perMinute
+	}
+	quota, err := database.GetOrganizationQuota(db, organizationID)
+	if err != nil {
+		return cached.perDay, cached.perMinute
+	}
+
+	l.mutex.Lock()
+	l.quotas[organizationID] = cachedAPIQuota{
+		perDay:    quota.MaxAPIRequestsPerDay,
+		perMinute: quota.MaxAPIRequestsPerMinute,
+		expiresAt: time.Now().Add(quotaCacheTTL),
+	}
+	l.mutex.Unlock()
+
+	return quota.MaxAPIRequestsPerDay, quota.MaxAPIRequestsPerMinute
+}
+
+// apiQuotaDB returns the database, connecting on first use
+func apiQuotaDB() (*gorm.DB, error) {
+	db := database.GetDB()
+	if db == nil {
+		if err := database.InitDatabase(); err != nil {
+			return nil, fmt.Errorf("database unavailable: %v", err)
+		}
+		db = database.GetDB()
+	}
+	return db, nil
+}
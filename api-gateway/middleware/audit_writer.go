@@ -0,0 +1,280 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"forgecrud-backend/shared/config"
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models/notification"
+	"forgecrud-backend/shared/utils/redact"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Audit log drop policies, applied when the buffer is full
+const (
+	AuditDropNewest = "newest" // Discard the incoming log
+	AuditDropOldest = "oldest" // Discard the oldest buffered log to make room
+)
+
+// auditWriteAttempts is how often a batch is inserted before its logs are dropped
+const auditWriteAttempts = 3
+
+// auditEntry is an audit log waiting for its batch. The bodies are decoded by the writer, so the
+// request only pays for copying them.
+type auditEntry struct {
+	log             notification.AuditLog
+	requestBody     []byte
+	requestTooLarge int64 // Size of a request body larger than AUDIT_LOG_MAX_BODY_BYTES
+	responseBody    string
+}
+
+// AuditWriter buffers the audit logs of the gateway and inserts them in batches on one goroutine, so
+// writing them never holds up a request. When the database falls behind the buffer fills up and logs
+// are dropped by the drop policy instead of queueing requests behind it.
+type AuditWriter struct {
+	entries       chan auditEntry
+	batchSize     int
+	flushInterval time.Duration
+	dropPolicy    string
+
+	written atomic.Int64
+	dropped atomic.Int64
+	failed  atomic.Int64
+
+	lastError atomic.Value // string, empty after a successful batch
+
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewAuditWriter creates an AuditWriter with the audit log writer configuration
+func NewAuditWriter() *AuditWriter {
+	cfg := config.GetConfig()
+	w := &AuditWriter{
+		entries:       make(chan auditEntry, cfg.AuditLogBufferSize),
+		batchSize:     cfg.AuditLogBatchSize,
+		flushInterval: time.Duration(cfg.AuditLogFlushIntervalMs) * time.Millisecond,
+		dropPolicy:    cfg.AuditLogDropPolicy,
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+	w.lastError.Store("")
+	return w
+}
+
+// Start inserts the buffered logs in the background
+func (w *AuditWriter) Start() {
+	go w.run()
+	log.Printf("📝 Audit log writer started (buffer: %d, batch: %d, flush interval: %s, drop: %s)",
+		cap(w.entries), w.batchSize, w.flushInterval, w.dropPolicy)
+}
+
+// Close stops accepting logs and inserts the buffered ones
+func (w *AuditWriter) Close() error {
+	w.stopOnce.Do(func() { close(w.stop) })
+	<-w.done
+	if dropped := w.dropped.Load(); dropped > 0 {
+		log.Printf("⚠️  Audit log writer dropped %d logs since the gateway started", dropped)
+	}
+	return nil
+}
+
+// write buffers a log without blocking; when the buffer is full the drop policy decides which log
+// is lost
+func (w *AuditWriter) write(entry auditEntry) {
+	select {
+	case <-w.stop:
+		w.dropped.Add(1)
+		return
+	default:
+	}
+
+	select {
+	case w.entries <- entry:
+		return
+	default:
+	}
+
+	if w.dropPolicy == AuditDropOldest {
+		select {
+		case <-w.entries:
+			w.dropped.Add(1)
+		default:
+		}
+		select {
+		case w.entries <- entry:
+			return
+		default:
+		}
+	}
+	w.dropped.Add(1)
+}
+
+// Check reports the buffer usage and counters, failing while batches cannot be inserted
+func (w *AuditWriter) Check(ctx context.Context) (interface{}, error) {
+	details := gin.H{
+		"buffered": len(w.entries),
+		"capacity": cap(w.entries),
+		"written":  w.written.Load(),
+		"dropped":  w.dropped.Load(),
+		"failed":   w.failed.Load(),
+	}
+	if lastError := w.lastError.Load().(string); lastError != "" {
+		return details, errors.New(lastError)
+	}
+	return details, nil
+}
+
+func (w *AuditWriter) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]auditEntry, 0, w.batchSize)
+	flush := func() {
+		if len(batch) > 0 {
+			w.insert(batch)
+			batch = batch[:0]
+		}
+	}
+
+	for {
+		select {
+		case entry := <-w.entries:
+			batch = append(batch, entry)
+			if len(batch) >= w.batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-w.stop:
+			// Logs buffered before the stop are still written
+			for {
+				select {
+				case entry := <-w.entries:
+					batch = append(batch, entry)
+					if len(batch) >= w.batchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// insert writes a batch, retrying a failed insert before dropping its logs. Logs buffered meanwhile
+// wait, or are dropped once the buffer is full.
+func (w *AuditWriter) insert(batch []auditEntry) {
+	redactor := redact.New(config.GetConfig().RedactFields)
+	logs := make([]notification.AuditLog, len(batch))
+	for i, entry := range batch {
+		logs[i] = entry.decode(redactor)
+	}
+
+	var err error
+	for attempt := 1; attempt <= auditWriteAttempts; attempt++ {
+		if err = w.create(logs); err == nil {
+			w.written.Add(int64(len(logs)))
+			w.lastError.Store("")
+			return
+		}
+		if attempt < auditWriteAttempts {
+			select {
+			case <-w.stop:
+			case <-time.After(time.Duration(attempt) * time.Second):
+			}
+		}
+	}
+
+	w.failed.Add(int64(len(logs)))
+	w.lastError.Store(err.Error())
+	log.Printf("❌ Failed to save %d audit logs: %v", len(logs), err)
+}
+
+// create inserts the logs, connecting to the database on first use
+func (w *AuditWriter) create(logs []notification.AuditLog) error {
+	db := database.GetDB()
+	if db == nil {
+		if err := database.InitDatabase(); err != nil {
+			return fmt.Errorf("database unavailable: %v", err)
+		}
+		db = database.GetDB()
+	}
+	return db.CreateInBatches(logs, len(logs)).Error
+}
+
+// newAuditEntry captures the audit log of a request. It runs on the request goroutine, as the
+// context is reused once the request finished.
+func newAuditEntry(c *gin.Context, originalResponse string, statusCode int, requestID string, executionTime time.Duration) auditEntry {
+	// Get user ID from context (if available)
+	var userID *uuid.UUID
+	if userIDStr, exists := c.Get("user_id"); exists {
+		if id, err := uuid.Parse(fmt.Sprintf("%v", userIDStr)); err == nil {
+			userID = &id
+		}
+	}
+
+	entry := auditEntry{
+		log: notification.AuditLog{
+			UserID:     userID,
+			Method:     c.Request.Method,
+			Path:       c.Request.URL.Path,
+			StatusCode: statusCode,
+			IPAddress:  c.ClientIP(),
+			UserAgent:  c.Request.UserAgent(),
+			Duration:   executionTime.Milliseconds(),
+			RequestID:  requestID,
+			CreatedAt:  time.Now(),
+		},
+	}
+
+	// Request body, as copied by CaptureRequestBody or kept by a handler
+	if c.Request.Method != "GET" && c.Request.Method != "DELETE" {
+		if body, size, truncated, ok := capturedRequestBody(c); ok {
+			if truncated {
+				entry.requestTooLarge = size
+			} else {
+				entry.requestBody = body
+			}
+		} else if rawData, exists := c.Get("raw_body"); exists {
+			entry.requestBody, _ = rawData.([]byte)
+		}
+	}
+
+	// Responses listing audit logs are not kept, every read would copy the logs
+	if !strings.HasPrefix(c.Request.URL.Path, "/api/audit-logs") {
+		entry.responseBody = originalResponse
+	}
+	return entry
+}
+
+// decode returns the log with its JSON bodies, the sensitive fields masked
+func (e auditEntry) decode(redactor *redact.Redactor) notification.AuditLog {
+	auditLog := e.log
+	if len(e.requestBody) > 0 {
+		json.Unmarshal(e.requestBody, &auditLog.RequestBody)
+		auditLog.RequestBody = redactor.Value(auditLog.RequestBody)
+	} else if e.requestTooLarge > 0 {
+		auditLog.RequestBody = map[string]interface{}{"truncated": true, "size": e.requestTooLarge}
+	}
+	if e.responseBody != "" {
+		json.Unmarshal([]byte(e.responseBody), &auditLog.ResponseBody)
+		auditLog.ResponseBody = redactor.Value(auditLog.ResponseBody)
+	}
+	return auditLog
+}
@@ -0,0 +1,344 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/config"
+	"forgecrud-backend/shared/database"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	// ipReputationFlushInterval is how often the failed requests counted by the gateway are added to the scores
+	ipReputationFlushInterval = 5 * time.Second
+
+	// ipBanRefreshInterval bounds how long bans of other gateway instances and of the admin API take to apply
+	ipBanRefreshInterval = 15 * time.Second
+)
+
+// IPReputation counts the 401, 403 and 429 responses of every client IP and adds them to the scores of
+// the ip_reputations table in the background. Addresses whose score reaches IP_REPUTATION_BAN_SCORE are
+// banned, and the gateway rejects their requests until the ban ends.
+type IPReputation struct {
+	mutex   sync.Mutex
+	pending map[string]*database.IPFailures
+	bans    map[string]time.Time // Address -> end of its ban
+
+	allowlist []*net.IPNet // Addresses never counted nor banned
+
+	failures atomic.Int64 // Failed requests counted
+	rejected atomic.Int64 // Requests of banned addresses
+	banned   atomic.Int64 // Automatic bans
+
+	lastError atomic.Value // string, empty after a successful flush
+
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewIPReputation creates an IPReputation without bans; Start loads them
+func NewIPReputation() *IPReputation {
+	r := &IPReputation{
+		pending:   make(map[string]*database.IPFailures),
+		bans:      make(map[string]time.Time),
+		allowlist: parseIPAllowlist(config.GetConfig().IPReputationAllowlist),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	r.lastError.Store("")
+	return r
+}
+
+// Start loads the bans and scores the failed requests in the background
+func (r *IPReputation) Start() {
+	go r.run()
+}
+
+// Close stops the background work after scoring the failed requests counted so far
+func (r *IPReputation) Close() error {
+	r.stopOnce.Do(func() { close(r.stop) })
+	<-r.done
+	return nil
+}
+
+// Check reports the counters of the health endpoints
+func (r *IPReputation) Check(ctx context.Context) (interface{}, error) {
+	r.mutex.Lock()
+	activeBans := 0
+	now := time.Now()
+	for _, until := range r.bans {
+		if until.After(now) {
+			activeBans++
+		}
+	}
+	r.mutex.Unlock()
+
+	details := gin.H{
+		"failures":    r.failures.Load(),
+		"rejected":    r.rejected.Load(),
+		"banned":      r.banned.Load(),
+		"active_bans": activeBans,
+	}
+	if lastError := r.lastError.Load().(string); lastError != "" {
+		return details, errors.New(lastError)
+	}
+	return details, nil
+}
+
+// Middleware rejects requests of banned addresses with 403 and counts failed responses. It runs before
+// the rate limiter so its 429 responses are counted too. Addresses of IP_REPUTATION_ALLOWLIST, like
+// monitoring or office networks, pass through.
+func (r *IPReputation) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !config.GetConfig().IPReputationEnabled {
+			c.Next()
+			return
+		}
+
+		ip := c.ClientIP()
+		if allowlisted(r.allowlist, ip) {
+			c.Next()
+			return
+		}
+		if until, banned := r.bannedUntil(ip); banned {
+			r.rejected.Add(1)
+			c.Header("Retry-After", strconv.Itoa(int(time.Until(until).Seconds())+1))
+			apperrors.Respond(c, apperrors.Forbidden("IP address temporarily banned").WithDetails(gin.H{
+				"banned_until": until.UTC(),
+			}))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+
+		r.count(ip, c.Writer.Status())
+	}
+}
+
+func (r *IPReputation) bannedUntil(ip string) (time.Time, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	until, ok := r.bans[ip]
+	return until, ok && until.After(time.Now())
+}
+
+// count adds a failed response to the address's failures of the next flush
+func (r *IPReputation) count(ip string, status int) {
+	cfg := config.GetConfig()
+	var weight int
+	switch status {
+	case http.StatusUnauthorized:
+		weight = cfg.IPReputationWeight401
+	case http.StatusForbidden:
+		weight = cfg.IPReputationWeight403
+	case http.StatusTooManyRequests:
+		weight = cfg.IPReputationWeight429
+	default:
+		return
+	}
+	if ip == "" {
+		return
+	}
+	r.failures.Add(1)
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	failures, ok := r.pending[ip]
+	if !ok {
+		failures = &database.IPFailures{}
+		r.pending[ip] = failures
+	}
+	switch status {
+	case http.StatusUnauthorized:
+		failures.Unauthorized++
+	case http.StatusForbidden:
+		failures.Forbidden++
+	case http.StatusTooManyRequests:
+		failures.RateLimited++
+	}
+	failures.Score += float64(weight)
+	failures.LastAt = time.Now().UTC()
+}
+
+func (r *IPReputation) run() {
+	defer close(r.done)
+
+	flushTicker := time.NewTicker(ipReputationFlushInterval)
+	defer flushTicker.Stop()
+	refreshTicker := time.NewTicker(ipBanRefreshInterval)
+	defer refreshTicker.Stop()
+
+	r.refresh()
+	for {
+		select {
+		case <-flushTicker.C:
+			r.flush()
+		case <-refreshTicker.C:
+			r.refresh()
+		case <-r.stop:
+			r.flush()
+			return
+		}
+	}
+}
+
+// flush adds the counted failures to the scores and bans the addresses reaching the threshold
+func (r *IPReputation) flush() {
+	r.mutex.Lock()
+	pending := r.pending
+	r.pending = make(map[string]*database.IPFailures)
+	r.mutex.Unlock()
+	if len(pending) == 0 {
+		return
+	}
+
+	db, err := ipReputationDB()
+	if err != nil {
+		r.lastError.Store(err.Error())
+		return
+	}
+
+	cfg := config.GetConfig()
+	halfLife := time.Duration(cfg.IPReputationHalfLifeMinutes) * time.Minute
+	var lastErr error
+	for ip, failures := range pending {
+		reputation, err := database.RecordIPFailures(db, ip, *failures, halfLife)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		now := time.Now()
+		if reputation.Score < float64(cfg.IPReputationBanScore) || reputation.IsBanned(now) {
+			continue
+		}
+		until := now.Add(time.Duration(cfg.IPBanMinutes) * time.Minute)
+		reason := fmt.Sprintf("Automatic: score %.0f after %d unauthorized, %d forbidden and %d rate limited requests",
+			reputation.Score, reputation.Unauthorized, reputation.Forbidden, reputation.RateLimited)
+		if _, err := database.BanIP(db, ip, until, reason, nil); err != nil {
+			lastErr = err
+			continue
+		}
+
+		r.banned.Add(1)
+		r.mutex.Lock()
+		r.bans[ip] = until
+		r.mutex.Unlock()
+		log.Printf("🚫 Banned %s until %s (score %.0f)", ip, until.UTC().Format(time.RFC3339), reputation.Score)
+	}
+
+	if lastErr != nil {
+		r.lastError.Store(lastErr.Error())
+		log.Printf("❌ Failed to update IP reputations: %v", lastErr)
+		return
+	}
+	r.lastError.Store("")
+}
+
+// refresh loads the active bans, including those of other gateway instances and the admin API
+func (r *IPReputation) refresh() {
+	db, err := ipReputationDB()
+	if err != nil {
+		r.lastError.Store(err.Error())
+		return
+	}
+	reputations, err := database.ActiveIPBans(db, time.Now())
+	if err != nil {
+		r.lastError.Store(err.Error())
+		log.Printf("❌ Failed to load IP bans: %v", err)
+		return
+	}
+
+	bans := make(map[string]time.Time, len(reputations))
+	for _, reputation := range reputations {
+		bans[reputation.IPAddress] = *reputation.BannedUntil
+	}
+	r.mutex.Lock()
+	r.bans = bans
+	r.mutex.Unlock()
+}
+
+// ipReputationDB returns the database, connecting on first use
+func ipReputationDB() (*gorm.DB, error) {
+	db := database.GetDB()
+	if db == nil {
+		if err := database.InitDatabase(); err != nil {
+			return nil, fmt.Errorf("database unavailable: %v", err)
+		}
+		db = database.GetDB()
+	}
+	return db, nil
+}
+
+// parseIPAllowlist parses IP_REPUTATION_ALLOWLIST; single addresses become networks of one address
+func parseIPAllowlist(value string) []*net.IPNet {
+	var networks []*net.IPNet
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			if ip := net.ParseIP(entry); ip != nil && ip.To4() != nil {
+				entry += "/32"
+			} else {
+				entry += "/128"
+			}
+		}
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			networks = append(networks, network)
+		} else {
+			log.Printf("⚠️  Ignoring invalid IP_REPUTATION_ALLOWLIST entry %q", entry)
+		}
+	}
+	return networks
+}
+
+func allowlisted(networks []*net.IPNet, ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, network := range networks {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// Ban applies a ban of the admin API on this gateway right away; other gateways load it on their
+// next refresh
+func (r *IPReputation) Ban(ip string, until time.Time) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.bans[ip] = until
+}
+
+// Unban lifts a ban on this gateway right away, dropping the failures not scored yet
+func (r *IPReputation) Unban(ip string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	delete(r.bans, ip)
+	delete(r.pending, ip)
+}
+
+// Allowlisted reports whether an address is in IP_REPUTATION_ALLOWLIST
+func (r *IPReputation) Allowlisted(ip string) bool {
+	return allowlisted(r.allowlist, ip)
+}
@@ -1,9 +1,10 @@
 package middleware
 
 import (
-	"net/http"
+	"errors"
 	"strings"
 
+	"forgecrud-backend/shared/apperrors"
 	"forgecrud-backend/shared/config"
 	"forgecrud-backend/shared/utils/permission"
 
@@ -11,40 +12,32 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// errPasswordResetRequired rejects the tokens of users who must change their password first
+var errPasswordResetRequired = errors.New("password must be changed first")
+
 // RequirePermission creates a middleware that checks if user has specific permission
 func RequirePermission(resourceSlug, actionSlug string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Extract user ID from JWT token
 		userID, err := extractUserIDFromToken(c)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "Invalid or missing token",
-				"code":  "UNAUTHORIZED",
-			})
-			c.Abort()
+			respondTokenError(c, err)
 			return
 		}
 
 		// Check permission
 		allowed, err := permission.CheckPermission(userID, resourceSlug, actionSlug)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "Failed to check permissions",
-				"code":  "PERMISSION_CHECK_FAILED",
-			})
+			apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to check permissions").With("code", "PERMISSION_CHECK_FAILED"))
 			c.Abort()
 			return
 		}
 
 		if !allowed {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": "Insufficient permissions",
-				"code":  "FORBIDDEN",
-				"details": gin.H{
-					"required_resource": resourceSlug,
-					"required_action":   actionSlug,
-				},
-			})
+			apperrors.Respond(c, apperrors.Forbidden("Insufficient permissions").WithDetails(gin.H{
+				"required_resource": resourceSlug,
+				"required_action":   actionSlug,
+			}).With("code", "FORBIDDEN"))
 			c.Abort()
 			return
 		}
@@ -54,21 +47,70 @@ func RequirePermission(resourceSlug, actionSlug string) gin.HandlerFunc {
 		c.Set("resource", resourceSlug)
 		c.Set("action", actionSlug)
 		c.Set("permission_checked", true)
+		setTenantContext(c, userID)
+
+		c.Next()
+	}
+}
+
+// RequireObjectPermission checks a permission on the folder or document identified by a path
+// parameter. When the object has access grants they decide instead of the user's permissions,
+// so grantees reach objects their role alone would not allow.
+func RequireObjectPermission(resourceSlug, actionSlug, objectType, idParam string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := extractUserIDFromToken(c)
+		if err != nil {
+			respondTokenError(c, err)
+			return
+		}
+
+		allowed, err := permission.CheckObjectPermission(userID, resourceSlug, actionSlug, objectType, c.Param(idParam))
+		if err != nil {
+			apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to check permissions").With("code", "PERMISSION_CHECK_FAILED"))
+			c.Abort()
+			return
+		}
+
+		if !allowed {
+			apperrors.Respond(c, apperrors.Forbidden("Insufficient permissions").WithDetails(gin.H{
+				"required_resource": resourceSlug,
+				"required_action":   actionSlug,
+				"object_type":       objectType,
+				"object_id":         c.Param(idParam),
+			}).With("code", "FORBIDDEN"))
+			c.Abort()
+			return
+		}
+
+		c.Set("user_id", userID)
+		c.Set("resource", resourceSlug)
+		c.Set("action", actionSlug)
+		c.Set("permission_checked", true)
+		setTenantContext(c, userID)
 
 		c.Next()
 	}
 }
 
+// RequirePermissionForQuery additionally requires a permission when a query parameter is present,
+// e.g. only admins with users:manage may list soft-deleted users via include_deleted
+func RequirePermissionForQuery(queryParam, resourceSlug, actionSlug string) gin.HandlerFunc {
+	check := RequirePermission(resourceSlug, actionSlug)
+	return func(c *gin.Context) {
+		if c.Query(queryParam) == "" {
+			c.Next()
+			return
+		}
+		check(c)
+	}
+}
+
 // RequireAnyPermission checks if user has ANY of the provided permissions
 func RequireAnyPermission(permissions []struct{ Resource, Action string }) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userID, err := extractUserIDFromToken(c)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "Invalid or missing token",
-				"code":  "UNAUTHORIZED",
-			})
-			c.Abort()
+			respondTokenError(c, err)
 			return
 		}
 
@@ -84,10 +126,7 @@ func RequireAnyPermission(permissions []struct{ Resource, Action string }) gin.H
 		// Batch check permissions
 		results, err := permission.BatchCheckPermissions(userID, checks)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "Failed to check permissions",
-				"code":  "PERMISSION_CHECK_FAILED",
-			})
+			apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to check permissions").With("code", "PERMISSION_CHECK_FAILED"))
 			c.Abort()
 			return
 		}
@@ -102,19 +141,16 @@ func RequireAnyPermission(permissions []struct{ Resource, Action string }) gin.H
 		}
 
 		if !hasAnyPermission {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": "Insufficient permissions",
-				"code":  "FORBIDDEN",
-				"details": gin.H{
-					"required_any_of": permissions,
-				},
-			})
+			apperrors.Respond(c, apperrors.Forbidden("Insufficient permissions").WithDetails(gin.H{
+				"required_any_of": permissions,
+			}).With("code", "FORBIDDEN"))
 			c.Abort()
 			return
 		}
 
 		c.Set("user_id", userID)
 		c.Set("permission_checked", true)
+		setTenantContext(c, userID)
 		c.Next()
 	}
 }
@@ -124,33 +160,103 @@ func RequireAuthentication() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userID, err := extractUserIDFromToken(c)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "Invalid or missing token",
-				"code":  "UNAUTHORIZED",
-			})
-			c.Abort()
+			respondTokenError(c, err)
 			return
 		}
 
 		c.Set("user_id", userID)
+		setTenantContext(c, userID)
 		c.Next()
 	}
 }
 
+// setTenantContext stores the caller's organization from the JWT and whether the caller is a
+// super admin (wildcard ALL permission), who is not limited to one organization.
+// ProxyToService forwards both to the services.
+func setTenantContext(c *gin.Context, userID string) {
+	if _, exists := c.Get("tenant_resolved"); exists {
+		return
+	}
+	c.Set("tenant_resolved", true)
+
+	if claims, err := extractClaimsFromToken(c); err == nil {
+		if organizationID, ok := claims["organization_id"].(string); ok && organizationID != "" {
+			c.Set("organization_id", organizationID)
+		}
+	}
+
+	// A failed check keeps the caller scoped to their organization
+	if bypass, err := permission.CheckPermission(userID, "ALL", "manage"); err == nil && bypass {
+		c.Set("tenant_bypass", true)
+	}
+}
+
 // extractUserIDFromToken extracts user ID from JWT token
 func extractUserIDFromToken(c *gin.Context) (string, error) {
+	claims, err := extractClaimsFromToken(c)
+	if err != nil {
+		return "", err
+	}
+
+	// Extract user ID from claims
+	if userID, exists := claims["user_id"]; exists {
+		if userIDStr, ok := userID.(string); ok {
+			return userIDStr, nil
+		}
+	}
+
+	return "", jwt.ErrInvalidKey
+}
+
+// respondTokenError rejects a request whose bearer token was refused
+func respondTokenError(c *gin.Context, err error) {
+	if errors.Is(err, errPasswordResetRequired) {
+		apperrors.Respond(c, apperrors.Forbidden("Password must be changed first").With("code", "PASSWORD_RESET_REQUIRED"))
+	} else {
+		apperrors.Respond(c, apperrors.Unauthorized("Invalid or missing token").With("code", "UNAUTHORIZED"))
+	}
+	c.Abort()
+}
+
+// extractClaimsFromToken parses and verifies the bearer token, rejecting tokens of terminated sessions
+// and of users who must reset their password
+func extractClaimsFromToken(c *gin.Context) (jwt.MapClaims, error) {
 	// Get token from Authorization header
 	authHeader := c.GetHeader("Authorization")
 	if authHeader == "" {
-		return "", jwt.ErrInvalidKey
+		return nil, jwt.ErrInvalidKey
 	}
 
 	// Remove "Bearer " prefix
 	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 	if tokenString == authHeader {
-		return "", jwt.ErrInvalidKey
+		return nil, jwt.ErrInvalidKey
+	}
+
+	claims, err := parseToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateClaims(claims); err != nil {
+		return nil, err
 	}
+	return claims, nil
+}
 
+// validateClaims rejects the claims of terminated sessions and of users who must reset their
+// password, whose tokens only work for the auth service's change-password endpoint
+func validateClaims(claims jwt.MapClaims) error {
+	if err := validateSession(claims); err != nil {
+		return err
+	}
+	if mustReset, _ := claims["pwd_reset"].(bool); mustReset {
+		return errPasswordResetRequired
+	}
+	return nil
+}
+
+// parseToken verifies a JWT and returns its claims
+func parseToken(tokenString string) (jwt.MapClaims, error) {
 	// Parse JWT token
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		// Get JWT secret from config
@@ -159,23 +265,18 @@ func extractUserIDFromToken(c *gin.Context) (string, error) {
 	})
 
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	if !token.Valid {
-		return "", jwt.ErrInvalidKey
+		return nil, jwt.ErrInvalidKey
 	}
 
-	// Extract user ID from claims
-	if claims, ok := token.Claims.(jwt.MapClaims); ok {
-		if userID, exists := claims["user_id"]; exists {
-			if userIDStr, ok := userID.(string); ok {
-				return userIDStr, nil
-			}
-		}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, jwt.ErrInvalidKey
 	}
-
-	return "", jwt.ErrInvalidKey
+	return claims, nil
 }
 
 // PermissionDebug middleware for debugging permission checks
@@ -1,10 +1,10 @@
 package middleware
 
 import (
-	"net/http"
 	"sync"
 	"time"
 
+	"forgecrud-backend/shared/apperrors"
 	"forgecrud-backend/shared/config"
 
 	"github.com/gin-gonic/gin"
@@ -33,14 +33,14 @@ type RateLimitConfig struct {
 	BlockDuration time.Duration
 }
 
-// NewRateLimitConfig - Creates a new RateLimitConfig from environment variables
+// NewRateLimitConfig - Creates a new RateLimitConfig from the current configuration
 func NewRateLimitConfig() RateLimitConfig {
-	cfg := config.GetConfig()
+	limit := config.GetConfig().RateLimits.Global
 
 	return RateLimitConfig{
-		MaxRequests:   cfg.GetRateLimitMaxRequests(),
-		TimeWindow:    time.Duration(cfg.GetRateLimitTimeWindowSeconds()) * time.Second,
-		BlockDuration: time.Duration(cfg.GetRateLimitBlockDurationMinutes()) * time.Minute,
+		MaxRequests:   limit.MaxRequests,
+		TimeWindow:    limit.Window,
+		BlockDuration: limit.Block,
 	}
 }
 
@@ -129,18 +129,16 @@ func (rl *RateLimiter) isAllowed(key string, config RateLimitConfig) bool {
 	return true
 }
 
-// GlobalRateLimitMiddleware - Global rate limiting for all API Gateway requests
-func (rl *RateLimiter) GlobalRateLimitMiddleware(config RateLimitConfig) gin.HandlerFunc {
+// GlobalRateLimitMiddleware - Global rate limiting for all API Gateway requests. The limit is read on
+// every request, so a configuration reload applies without a restart.
+func (rl *RateLimiter) GlobalRateLimitMiddleware(limit func() RateLimitConfig) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		config := limit()
 		clientIP := c.ClientIP()
 		key := "global:" + clientIP
 
 		if !rl.isAllowed(key, config) {
-			c.JSON(http.StatusTooManyRequests, gin.H{
-				"error":       "Rate limit exceeded",
-				"message":     "Too many requests from this IP. Please try again later.",
-				"retry_after": config.BlockDuration.Seconds(),
-			})
+			apperrors.Respond(c, apperrors.New(apperrors.CodeRateLimited, "Rate limit exceeded").WithDetails("Too many requests from this IP. Please try again later.").With("retry_after", config.BlockDuration.Seconds()))
 			c.Abort()
 			return
 		}
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/readonly"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReadOnlySwitchPath is the write request served in read-only mode, so super admins can end it
+const ReadOnlySwitchPath = "/api/admin/read-only"
+
+// ReadOnly rejects write requests with 503 while the platform or the gateway is read-only. Reads
+// always pass; the proxy rejects the write requests to a single read-only service.
+func ReadOnly() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if readonly.Safe(c.Request.Method) || c.FullPath() == ReadOnlySwitchPath || !readonly.Active() {
+			c.Next()
+			return
+		}
+		apperrors.Abort(c, readonly.Error("api-gateway"))
+	}
+}
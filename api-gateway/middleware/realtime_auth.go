@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// webSocketSubprotocol is offered by browsers together with the access token, e.g.
+// new WebSocket(url, ["bearer", token]), since they can't set headers on WebSocket requests
+const webSocketSubprotocol = "bearer"
+
+// RealtimeToken lets browsers authenticate WebSocket handshakes and event streams, which can't
+// carry an Authorization header: an access token sent as the subprotocol following "bearer" or as
+// the access_token query parameter is used as the bearer token of the request, so
+// RequirePermission checks it like any other token. The notification service verifies it again.
+// The access_token parameter is removed from the forwarded query.
+func RealtimeToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") != "" {
+			c.Next()
+			return
+		}
+
+		token := ""
+		protocols := websocket.Subprotocols(c.Request)
+		for i := 0; i+1 < len(protocols); i++ {
+			if protocols[i] == webSocketSubprotocol {
+				token = protocols[i+1]
+				break
+			}
+		}
+		if token == "" {
+			token = c.Query("access_token")
+			// Forwarded as the Authorization header only, so it doesn't reach the services' request logs
+			query := c.Request.URL.Query()
+			query.Del("access_token")
+			c.Request.URL.RawQuery = query.Encode()
+		}
+		if token != "" {
+			c.Request.Header.Set("Authorization", "Bearer "+token)
+		}
+		c.Next()
+	}
+}
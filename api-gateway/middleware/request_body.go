@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+
+	"forgecrud-backend/shared/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+const requestBodyKey = "request_body_capture"
+
+// bodyCapture keeps a copy of the first bytes of a request body while the handler, usually the proxy,
+// reads it. The proxy may still be sending the body when the response is written, so it is locked.
+type bodyCapture struct {
+	io.ReadCloser
+	limit int
+
+	mutex     sync.Mutex
+	buffer    bytes.Buffer
+	size      int64
+	truncated bool
+}
+
+func (b *bodyCapture) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.mutex.Lock()
+		b.size += int64(n)
+		if room := b.limit - b.buffer.Len(); room > 0 {
+			b.buffer.Write(p[:min(n, room)])
+		}
+		if b.buffer.Len() < int(b.size) {
+			b.truncated = true
+		}
+		b.mutex.Unlock()
+	}
+	return n, err
+}
+
+// captured returns the copied body, or the size of the body when it was larger than the limit
+func (b *bodyCapture) captured() (body []byte, size int64, truncated bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.truncated {
+		return nil, b.size, true
+	}
+	return bytes.Clone(b.buffer.Bytes()), b.size, false
+}
+
+// CaptureRequestBody copies JSON request bodies for the audit log as the handler reads them, up to
+// AUDIT_LOG_MAX_BODY_BYTES. The body still streams to the service unchanged; bodies larger than the
+// limit are only logged with their size. Uploads and other bodies that are not JSON are not copied.
+func CaptureRequestBody() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit := config.GetConfig().AuditLogMaxBodyBytes
+		if limit > 0 && c.Request.Body != nil && c.Request.Body != http.NoBody && isJSONContent(c.Request.Header.Get("Content-Type")) {
+			capture := &bodyCapture{ReadCloser: c.Request.Body, limit: limit}
+			c.Request.Body = capture
+			c.Set(requestBodyKey, capture)
+		}
+		c.Next()
+	}
+}
+
+// capturedRequestBody returns the request body copied by CaptureRequestBody
+func capturedRequestBody(c *gin.Context) (body []byte, size int64, truncated bool, ok bool) {
+	value, exists := c.Get(requestBodyKey)
+	if !exists {
+		return nil, 0, false, false
+	}
+	body, size, truncated = value.(*bodyCapture).captured()
+	return body, size, truncated, true
+}
+
+func isJSONContent(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"))
+}
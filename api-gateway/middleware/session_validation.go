@@ -0,0 +1,156 @@
+package middleware
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"forgecrud-backend/shared/config"
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/revocation"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// sessionPruneInterval is how often expired checks and revocations are dropped
+const sessionPruneInterval = time.Minute
+
+// errSessionRevoked rejects the access tokens of terminated sessions
+var errSessionRevoked = errors.New("session has been terminated")
+
+// sessionCheck is the result of looking up a session
+type sessionCheck struct {
+	userID    string
+	active    bool
+	checkedAt time.Time
+}
+
+// sessionValidator rejects the access tokens of terminated sessions. Sessions are looked up at most
+// every SESSION_VALIDATION_CACHE_SECONDS; revocations announced by the auth service replace the
+// cached checks right away.
+type sessionValidator struct {
+	mutex       sync.Mutex
+	sessions    map[string]sessionCheck // Session ID -> last check
+	usersBefore map[string]time.Time    // User ID -> tokens issued before are revoked
+	lastPrune   time.Time
+}
+
+var sessions = &sessionValidator{
+	sessions:    map[string]sessionCheck{},
+	usersBefore: map[string]time.Time{},
+}
+
+// RevokeSessions applies a revocation announced by the auth service: the sessions' access tokens are
+// rejected from the next request on, or every token issued to the user so far for all sessions
+func RevokeSessions(r revocation.Revocation) {
+	sessions.revoke(r, time.Now())
+}
+
+// validateSession rejects the claims of a terminated session. Tokens without a session, issued before
+// sessions were carried in them, are only rejected by revocations of all the user's sessions. When the
+// database is unavailable the token is accepted, as it was before sessions were checked.
+func validateSession(claims jwt.MapClaims) error {
+	userID, _ := claims["user_id"].(string)
+	sessionID, _ := claims["sid"].(string)
+	issuedAt, _ := claims.GetIssuedAt()
+	return sessions.validate(userID, sessionID, issuedAt, time.Now())
+}
+
+func (v *sessionValidator) validate(userID, sessionID string, issuedAt *jwt.NumericDate, now time.Time) error {
+	ttl := time.Duration(config.GetConfig().SessionValidationCacheSeconds) * time.Second
+
+	v.mutex.Lock()
+	v.prune(now, ttl)
+	if before, ok := v.usersBefore[userID]; ok && (issuedAt == nil || issuedAt.Time.Before(before)) {
+		v.mutex.Unlock()
+		return errSessionRevoked
+	}
+	if sessionID == "" {
+		v.mutex.Unlock()
+		return nil
+	}
+	check, cached := v.sessions[sessionID]
+	v.mutex.Unlock()
+
+	// Revoked sessions stay rejected; active ones are looked up again once the check expires
+	if cached && check.userID == userID && (!check.active || now.Sub(check.checkedAt) < ttl) {
+		if !check.active {
+			return errSessionRevoked
+		}
+		return nil
+	}
+
+	active, err := lookupSession(userID, sessionID)
+	if err != nil {
+		log.Printf("⚠️  Failed to check session of user %s: %v", userID, err)
+		return nil
+	}
+
+	v.mutex.Lock()
+	// A revocation announced during the lookup wins over its result
+	if current, ok := v.sessions[sessionID]; !ok || current.active {
+		v.sessions[sessionID] = sessionCheck{userID: userID, active: active, checkedAt: now}
+	} else {
+		active = false
+	}
+	v.mutex.Unlock()
+
+	if !active {
+		return errSessionRevoked
+	}
+	return nil
+}
+
+func (v *sessionValidator) revoke(r revocation.Revocation, now time.Time) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	if r.All() {
+		// Tokens carry their issue time in seconds, so the second of the revocation is kept for
+		// tokens of logins right after it
+		v.usersBefore[r.UserID] = r.RevokedAt.Truncate(time.Second)
+		for sessionID, check := range v.sessions {
+			if check.userID == r.UserID {
+				delete(v.sessions, sessionID)
+			}
+		}
+		return
+	}
+	for _, sessionID := range r.SessionIDs {
+		v.sessions[sessionID] = sessionCheck{userID: r.UserID, active: false, checkedAt: now}
+	}
+}
+
+// prune drops checks past their expiry and revocations older than any token still valid
+func (v *sessionValidator) prune(now time.Time, ttl time.Duration) {
+	if now.Sub(v.lastPrune) < sessionPruneInterval {
+		return
+	}
+	v.lastPrune = now
+
+	// Access tokens of revoked sessions have expired by now, and the session check covers them anyway
+	tokenLifetime := time.Duration(config.GetConfig().JWTExpireHours) * time.Hour
+	for sessionID, check := range v.sessions {
+		if (check.active && now.Sub(check.checkedAt) >= ttl) || now.Sub(check.checkedAt) >= tokenLifetime {
+			delete(v.sessions, sessionID)
+		}
+	}
+	for userID, before := range v.usersBefore {
+		if now.Sub(before) >= tokenLifetime {
+			delete(v.usersBefore, userID)
+		}
+	}
+}
+
+// lookupSession reports whether the session is active, connecting to the database on first use
+func lookupSession(userID, sessionID string) (bool, error) {
+	db := database.GetDB()
+	if db == nil {
+		if err := database.InitDatabase(); err != nil {
+			return false, err
+		}
+		db = database.GetDB()
+	}
+	return database.IsSessionActive(db, userID, sessionID)
+}
@@ -8,9 +8,12 @@ import (
 	"strings"
 	"time"
 
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/clients"
 	"forgecrud-backend/shared/config"
-	"forgecrud-backend/shared/database"
 	"forgecrud-backend/shared/database/models/notification"
+	documentUtils "forgecrud-backend/shared/utils/document"
+	"forgecrud-backend/shared/utils/redact"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -27,8 +30,9 @@ type UnifiedResponse struct {
 
 // ErrorInfo represents error details
 type ErrorInfo struct {
-	Code    string `json:"code"`
-	Details string `json:"details"`
+	Code    string      `json:"code"`
+	Details string      `json:"details"`
+	Fields  interface{} `json:"fields,omitempty"` // Per-field validation errors: field, rule and message
 }
 
 // MetaInfo represents response metadata
@@ -40,25 +44,118 @@ type MetaInfo struct {
 	Path          string `json:"path"`
 }
 
-// responseWriter wraps gin.ResponseWriter to capture response
+// ResponseFormatHeader lets a client ask for the raw response of the service with the value "raw",
+// e.g. an integration expecting the payload of the service as documented by it
+const ResponseFormatHeader = "X-Response-Format"
+
+const rawResponseKey = "raw_response"
+
+// RawResponse passes the responses of a route through unchanged and unbuffered, for downloads, streams
+// and callers like email providers that expect the service's own answer
+func RawResponse() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(rawResponseKey, true)
+		c.Next()
+	}
+}
+
+// responseWriter buffers the response to wrap it, unless it turns out to be raw when the handler starts
+// writing it; raw responses are written through as they come
 type responseWriter struct {
 	gin.ResponseWriter
-	body   *bytes.Buffer
-	status int
+	context *gin.Context
+	body    *bytes.Buffer
+	status  int
+	decided bool
+	raw     bool
 }
 
-func (w *responseWriter) Write(b []byte) (int, error) {
-	w.body.Write(b)
-	return w.ResponseWriter.Write(b)
+// decide settles whether the response is raw once the handler starts writing the body, when the route
+// and the headers of the response are known
+func (w *responseWriter) decide() {
+	if w.decided {
+		return
+	}
+	w.decided = true
+	w.raw = w.context.GetBool(rawResponseKey) || isRawContent(w.ResponseWriter.Header())
+	if w.raw {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
 }
 
 func (w *responseWriter) WriteHeader(status int) {
+	if w.decided {
+		if w.raw {
+			w.ResponseWriter.WriteHeader(status)
+		}
+		return
+	}
 	w.status = status
-	w.ResponseWriter.WriteHeader(status)
 }
 
-// UnifiedResponseMiddleware transforms all responses to unified format
-func UnifiedResponseMiddleware() gin.HandlerFunc {
+func (w *responseWriter) WriteHeaderNow() {
+	w.decide()
+	if w.raw {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.decide()
+	if w.raw {
+		return w.ResponseWriter.Write(b)
+	}
+	return w.body.Write(b)
+}
+
+func (w *responseWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *responseWriter) Flush() {
+	w.decide()
+	if w.raw {
+		w.ResponseWriter.Flush()
+	}
+}
+
+func (w *responseWriter) Status() int {
+	if w.raw {
+		return w.ResponseWriter.Status()
+	}
+	return w.status
+}
+
+func (w *responseWriter) Size() int {
+	if w.raw {
+		return w.ResponseWriter.Size()
+	}
+	return w.body.Len()
+}
+
+func (w *responseWriter) Written() bool {
+	return w.decided
+}
+
+// isRawContent reports whether a response is a file or a stream rather than a JSON document
+func isRawContent(header http.Header) bool {
+	if disposition := header.Get("Content-Disposition"); strings.HasPrefix(disposition, "attachment") || strings.HasPrefix(disposition, "inline") {
+		return true
+	}
+	contentType := strings.ToLower(header.Get("Content-Type"))
+	for _, prefix := range []string{"text/event-stream", "application/octet-stream", "application/zip", "image/", "audio/", "video/"} {
+		if strings.HasPrefix(contentType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// UnifiedResponseMiddleware transforms all responses to unified format and hands the audit log of
+// every request to the audit writer. Raw responses are passed through: those of skipped paths, of
+// routes using RawResponse, of clients asking for them with ResponseFormatHeader, and files and
+// streams.
+func UnifiedResponseMiddleware(auditWriter *AuditWriter) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		startTime := time.Now()
 
@@ -75,7 +172,7 @@ func UnifiedResponseMiddleware() gin.HandlerFunc {
 				if statusCode == 0 {
 					statusCode = 200 // Default status
 				}
-				go saveAuditLogAsync(c, "", statusCode, requestID, executionTime)
+				auditWriter.write(newAuditEntry(c, "", statusCode, requestID, executionTime))
 			}()
 			c.Next()
 			return
@@ -84,6 +181,7 @@ func UnifiedResponseMiddleware() gin.HandlerFunc {
 		// Create custom response writer
 		w := &responseWriter{
 			ResponseWriter: c.Writer,
+			context:        c,
 			body:           &bytes.Buffer{},
 			status:         200,
 		}
@@ -91,10 +189,18 @@ func UnifiedResponseMiddleware() gin.HandlerFunc {
 
 		// Execute handler
 		c.Next()
+		c.Writer = w.ResponseWriter
+		w.decide()
 
 		// Calculate execution time
 		executionTime := time.Since(startTime)
 
+		// Raw responses were written as they came
+		if w.raw {
+			auditWriter.write(newAuditEntry(c, "", w.ResponseWriter.Status(), requestID, executionTime))
+			return
+		}
+
 		// Get original response
 		originalResponse := w.body.String()
 		statusCode := w.status
@@ -103,14 +209,15 @@ func UnifiedResponseMiddleware() gin.HandlerFunc {
 		unified := transformToUnifiedResponse(c, originalResponse, statusCode, requestID, executionTime)
 
 		// Set proper headers and status code before writing response
+		w.ResponseWriter.Header().Del("Content-Length")
 		w.ResponseWriter.Header().Set("Content-Type", "application/json")
 		w.ResponseWriter.WriteHeader(statusCode)
 
 		// Write unified response to the actual response writer
-		json.NewEncoder(w).Encode(unified)
+		json.NewEncoder(w.ResponseWriter).Encode(unified)
 
 		// 🔥 FIRE & FORGET - Async background tasks
-		go saveAuditLogAsync(c, originalResponse, statusCode, requestID, executionTime)
+		auditWriter.write(newAuditEntry(c, originalResponse, statusCode, requestID, executionTime))
 		go sendNotificationAsync(c, unified)
 	}
 }
@@ -152,23 +259,30 @@ func transformToUnifiedResponse(c *gin.Context, originalResponse string, statusC
 					unified.Data = originalData
 				}
 			} else {
-				// Error response
+				// Error response, echoing the sensitive fields of the body masked
+				redactor := redact.New(config.GetConfig().RedactFields)
 				if errorMap, ok := originalData.(map[string]interface{}); ok {
 					if errMsg, exists := errorMap["error"]; exists {
+						// Services answering with apperrors send the code along with the message
+						code, _ := errorMap["code"].(string)
+						if code == "" {
+							code = getErrorCode(statusCode)
+						}
 						unified.Error = &ErrorInfo{
-							Code:    getErrorCode(statusCode),
-							Details: fmt.Sprintf("%v", errMsg),
+							Code:    code,
+							Details: fmt.Sprintf("%v", redactor.Value(errMsg)),
+							Fields:  redactor.Value(errorMap["fields"]),
 						}
 					} else {
 						unified.Error = &ErrorInfo{
 							Code:    getErrorCode(statusCode),
-							Details: originalResponse,
+							Details: redactor.String(originalResponse),
 						}
 					}
 				} else {
 					unified.Error = &ErrorInfo{
 						Code:    getErrorCode(statusCode),
-						Details: originalResponse,
+						Details: redactor.String(originalResponse),
 					}
 				}
 			}
@@ -215,104 +329,9 @@ func getAutoMessage(method string, statusCode int, isSuccess bool) string {
 	}
 }
 
-// getErrorCode generates error codes based on status
+// getErrorCode generates error codes based on status, for responses without a code
 func getErrorCode(statusCode int) string {
-	switch statusCode {
-	case 400:
-		return "BAD_REQUEST"
-	case 401:
-		return "UNAUTHORIZED"
-	case 403:
-		return "FORBIDDEN"
-	case 404:
-		return "NOT_FOUND"
-	case 409:
-		return "CONFLICT"
-	case 422:
-		return "VALIDATION_ERROR"
-	case 500:
-		return "INTERNAL_ERROR"
-	default:
-		return "UNKNOWN_ERROR"
-	}
-}
-
-// saveAuditLogAsync saves audit log asynchronously
-func saveAuditLogAsync(c *gin.Context, originalResponse string, statusCode int, requestID string, executionTime time.Duration) {
-	defer func() {
-		if r := recover(); r != nil {
-			fmt.Printf("Audit log failed: %v\n", r)
-		}
-	}()
-
-	// Get user ID from context (if available)
-	var userID *uuid.UUID
-	if userIDStr, exists := c.Get("user_id"); exists {
-		if id, err := uuid.Parse(fmt.Sprintf("%v", userIDStr)); err == nil {
-			userID = &id
-		}
-	}
-
-	// Parse request body
-	var requestBody interface{}
-	if c.Request.Method != "GET" && c.Request.Method != "DELETE" {
-		// Try to get request body from context first (if it was already read)
-		if rawData, exists := c.Get("raw_body"); exists {
-			if bodyBytes, ok := rawData.([]byte); ok {
-				json.Unmarshal(bodyBytes, &requestBody)
-			}
-		} else {
-			// For JSON requests, try to reconstruct from form values or params
-			if c.Request.Header.Get("Content-Type") == "application/json" {
-				// Body might be already consumed, skip for now
-				requestBody = nil
-			}
-		}
-	}
-
-	// Parse response body
-	var responseBody interface{}
-	if originalResponse != "" {
-		json.Unmarshal([]byte(originalResponse), &responseBody)
-	}
-
-	// Create audit log
-	auditLog := notification.AuditLog{
-		UserID:       userID,
-		Method:       c.Request.Method,
-		Path:         c.Request.URL.Path,
-		StatusCode:   statusCode,
-		RequestBody:  requestBody,
-		ResponseBody: responseBody,
-		IPAddress:    c.ClientIP(),
-		UserAgent:    c.Request.UserAgent(),
-		Duration:     executionTime.Milliseconds(),
-		RequestID:    requestID,
-	}
-
-	// Save to database (lazy initialization)
-	db := database.GetDB()
-	if db == nil {
-		if err := database.InitDatabase(); err != nil {
-			fmt.Printf("❌ Failed to initialize database for audit logging: %v\n", err)
-			return
-		}
-		db = database.GetDB()
-		if db == nil {
-			fmt.Printf("❌ Database connection is still nil after initialization\n")
-			return
-		}
-	}
-
-	fmt.Printf("🔍 Attempting to save audit log: Method=%s, Path=%s, Status=%d, UserID=%v\n",
-		auditLog.Method, auditLog.Path, auditLog.StatusCode, auditLog.UserID)
-
-	if err := db.Create(&auditLog).Error; err != nil {
-		fmt.Printf("❌ Failed to save audit log: %v\n", err)
-		fmt.Printf("🔍 Audit log data: %+v\n", auditLog)
-	} else {
-		fmt.Printf("✅ Audit log saved successfully with ID: %s\n", auditLog.ID.String())
-	}
+	return string(apperrors.CodeForStatus(statusCode))
 }
 
 // sendNotificationAsync sends real-time notification asynchronously
@@ -370,6 +389,9 @@ func sendNotificationAsync(c *gin.Context, unified UnifiedResponse) {
 	fmt.Printf("📡 WebSocket message sent to user %s: %+v\n", userID.String(), wsMessage)
 }
 
+// notificationClient delivers WebSocket messages through the notification service
+var notificationClient = clients.NewHTTPClient("notification", 10*time.Second)
+
 // sendToWebSocket sends message to WebSocket service
 func sendToWebSocket(userID string, message *notification.WebSocketMessage) {
 	// Get notification service URL from config
@@ -390,7 +412,7 @@ func sendToWebSocket(userID string, message *notification.WebSocketMessage) {
 
 	// Send async HTTP request to notification service
 	go func() {
-		resp, err := http.Post(url, "application/json", bytes.NewBuffer(jsonData))
+		resp, err := notificationClient.Post(url, "application/json", bytes.NewBuffer(jsonData))
 		if err != nil {
 			fmt.Printf("❌ Error sending WebSocket message: %v\n", err)
 			return
@@ -415,6 +437,10 @@ func shouldSkipUnifiedResponse(c *gin.Context) bool {
 		"/docs",
 		"/health",
 		"/metrics",
+		"/ready",
+		"/api/avatars",             // public avatar images
+		documentUtils.WebDAVPrefix, // WebDAV responses are XML for the client
+		"/ws/",                     // WebSocket connections are hijacked from the response
 	}
 
 	for _, excludePath := range excludePaths {
@@ -423,6 +449,23 @@ func shouldSkipUnifiedResponse(c *gin.Context) bool {
 		}
 	}
 
+	// Paths configured to pass through, e.g. for integrations expecting the service's own payload
+	for _, rawPath := range strings.Split(config.GetConfig().RawResponsePaths, ",") {
+		if rawPath = strings.TrimSpace(rawPath); rawPath != "" && strings.HasPrefix(path, rawPath) {
+			return true
+		}
+	}
+
+	// Clients asking for the raw response
+	if strings.EqualFold(c.Request.Header.Get(ResponseFormatHeader), "raw") {
+		return true
+	}
+
+	// File downloads and rendered images are streamed as they are, partial content included
+	if strings.HasSuffix(path, "/download") || strings.HasSuffix(path, "/render") {
+		return true
+	}
+
 	// Check if request is coming from Swagger UI by examining Referer header
 	referer := c.Request.Header.Get("Referer")
 	if strings.Contains(referer, "/swagger") || strings.Contains(referer, "/docs") {
@@ -0,0 +1,214 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models"
+	"forgecrud-backend/shared/database/models/auth"
+	utils "forgecrud-backend/shared/utils/auth"
+	"forgecrud-backend/shared/utils/permission"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// webdavRealm is shown by WebDAV clients when they ask for credentials
+const webdavRealm = "ForgeCRUD WebDAV"
+
+// appPasswordCacheTTL bounds how long a revoked app password keeps working. WebDAV clients send
+// their credentials with every request, checking the bcrypt hash each time would be too slow.
+const appPasswordCacheTTL = time.Minute
+
+// errInvalidCredentials is returned when the basic authentication credentials do not match
+var errInvalidCredentials = errors.New("invalid credentials")
+
+// webdavActions maps the WebDAV methods to the file-management action they need
+var webdavActions = map[string]string{
+	"OPTIONS":   "read",
+	"GET":       "read",
+	"HEAD":      "read",
+	"PROPFIND":  "read",
+	"PUT":       "create",
+	"MKCOL":     "create",
+	"COPY":      "create",
+	"MOVE":      "update",
+	"PROPPATCH": "update",
+	"LOCK":      "update",
+	"UNLOCK":    "update",
+	"DELETE":    "delete",
+}
+
+type cachedAppPassword struct {
+	userID         string
+	organizationID string
+	expiresAt      time.Time
+}
+
+// WebDAVAuthenticator signs in WebDAV clients. Clients that cannot send bearer tokens use HTTP basic
+// authentication with the user's email and an app password, or a token as the password.
+type WebDAVAuthenticator struct {
+	appPasswords map[[sha256.Size]byte]cachedAppPassword
+	mutex        sync.Mutex
+}
+
+// NewWebDAVAuthenticator creates a new WebDAVAuthenticator
+func NewWebDAVAuthenticator() *WebDAVAuthenticator {
+	return &WebDAVAuthenticator{
+		appPasswords: make(map[[sha256.Size]byte]cachedAppPassword),
+	}
+}
+
+// Middleware authenticates the WebDAV client and checks the file-management permission the method
+// needs. Folder and document access grants are enforced by the document service.
+func (a *WebDAVAuthenticator) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, organizationID, err := a.authenticate(c)
+		if errors.Is(err, errPasswordResetRequired) {
+			respondTokenError(c, err)
+			return
+		}
+		if err != nil {
+			// Ask the client for credentials, Finder and Explorer only send them when challenged
+			c.Header("WWW-Authenticate", `Basic realm="`+webdavRealm+`", charset="UTF-8"`)
+			apperrors.Respond(c, apperrors.Unauthorized("Invalid or missing credentials").With("code", "UNAUTHORIZED"))
+			c.Abort()
+			return
+		}
+
+		action, ok := webdavActions[c.Request.Method]
+		if !ok {
+			action = "read"
+		}
+
+		allowed, err := permission.CheckPermission(userID, "file-management", action)
+		if err != nil {
+			apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to check permissions").With("code", "PERMISSION_CHECK_FAILED"))
+			c.Abort()
+			return
+		}
+
+		if !allowed {
+			apperrors.Respond(c, apperrors.Forbidden("Insufficient permissions").WithDetails(gin.H{
+				"required_resource": "file-management",
+				"required_action":   action,
+			}).With("code", "FORBIDDEN"))
+			c.Abort()
+			return
+		}
+
+		c.Set("user_id", userID)
+		c.Set("resource", "file-management")
+		c.Set("action", action)
+		c.Set("permission_checked", true)
+		if organizationID != "" {
+			c.Set("organization_id", organizationID)
+		}
+		setTenantContext(c, userID)
+
+		c.Next()
+	}
+}
+
+// authenticate returns the user and organization of the credentials
+func (a *WebDAVAuthenticator) authenticate(c *gin.Context) (string, string, error) {
+	login, password, ok := c.Request.BasicAuth()
+	if !ok {
+		claims, err := extractClaimsFromToken(c)
+		if err != nil {
+			return "", "", err
+		}
+		return tokenUser(claims)
+	}
+
+	// Clients without bearer token support can send the token as the password
+	if claims, err := parseToken(password); err == nil {
+		if err := validateClaims(claims); err != nil {
+			return "", "", err
+		}
+		return tokenUser(claims)
+	}
+
+	return a.checkAppPassword(login, password)
+}
+
+// checkAppPassword verifies an app password of the user with the email
+func (a *WebDAVAuthenticator) checkAppPassword(email, password string) (string, string, error) {
+	key := sha256.Sum256([]byte(email + "\x00" + password))
+
+	a.mutex.Lock()
+	cached, ok := a.appPasswords[key]
+	a.mutex.Unlock()
+	if ok && time.Now().Before(cached.expiresAt) {
+		return cached.userID, cached.organizationID, nil
+	}
+
+	db := database.GetDB()
+	if db == nil {
+		if err := database.InitDatabase(); err != nil {
+			log.Printf("⚠️  App password sign in unavailable: %v", err)
+			return "", "", err
+		}
+		db = database.GetDB()
+	}
+
+	var user models.User
+	if err := db.Where("email = ?", email).First(&user).Error; err != nil {
+		return "", "", errInvalidCredentials
+	}
+	if !models.UserStatusCanSignIn(user.Status) {
+		return "", "", errInvalidCredentials
+	}
+
+	var appPasswords []auth.AppPassword
+	if err := db.Where("user_id = ?", user.ID).Find(&appPasswords).Error; err != nil {
+		return "", "", err
+	}
+
+	for _, appPassword := range appPasswords {
+		if !utils.CheckPasswordHash(password, appPassword.PasswordHash) {
+			continue
+		}
+
+		if err := db.Model(&appPassword).Update("last_used_at", time.Now()).Error; err != nil {
+			log.Printf("⚠️  Failed to record app password use: %v", err)
+		}
+
+		cached := cachedAppPassword{
+			userID:    user.ID.String(),
+			expiresAt: time.Now().Add(appPasswordCacheTTL),
+		}
+		if user.OrganizationID != nil {
+			cached.organizationID = user.OrganizationID.String()
+		}
+
+		a.mutex.Lock()
+		// Drop expired entries so credentials that are no longer used do not pile up
+		for cachedKey, entry := range a.appPasswords {
+			if !time.Now().Before(entry.expiresAt) {
+				delete(a.appPasswords, cachedKey)
+			}
+		}
+		a.appPasswords[key] = cached
+		a.mutex.Unlock()
+
+		return cached.userID, cached.organizationID, nil
+	}
+
+	return "", "", errInvalidCredentials
+}
+
+// tokenUser returns the user and organization of verified token claims
+func tokenUser(claims jwt.MapClaims) (string, string, error) {
+	userID, ok := claims["user_id"].(string)
+	if !ok || userID == "" {
+		return "", "", jwt.ErrInvalidKey
+	}
+	organizationID, _ := claims["organization_id"].(string)
+	return userID, organizationID, nil
+}
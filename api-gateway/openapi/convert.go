@@ -0,0 +1,256 @@
+package openapi
+
+import (
+	"strings"
+)
+
+// object is a decoded JSON object of a specification
+type object = map[string]interface{}
+
+// convertOperation converts a Swagger 2.0 operation to OpenAPI 3: body and form parameters become the
+// request body, and the media types of consumes and produces move into request and response content
+func convertOperation(operation object, consumes, produces []string, refs func(interface{}) interface{}) object {
+	converted := object{}
+	for key, value := range operation {
+		switch key {
+		case "parameters", "responses", "consumes", "produces", "schemes":
+		default:
+			converted[key] = refs(value)
+		}
+	}
+	if list := stringList(operation["consumes"]); len(list) > 0 {
+		consumes = list
+	}
+	if list := stringList(operation["produces"]); len(list) > 0 {
+		produces = list
+	}
+	if len(consumes) == 0 {
+		consumes = []string{"application/json"}
+	}
+	if len(produces) == 0 {
+		produces = []string{"application/json"}
+	}
+
+	var parameters []interface{}
+	form := object{"type": "object", "properties": object{}}
+	var formRequired []interface{}
+	for _, value := range list(operation["parameters"]) {
+		parameter, ok := value.(object)
+		if !ok {
+			continue
+		}
+		switch parameter["in"] {
+		case "body":
+			body := object{"content": mediaTypes(consumes, refs(convertSchema(parameter["schema"])))}
+			if description, ok := parameter["description"]; ok {
+				body["description"] = description
+			}
+			if required, _ := parameter["required"].(bool); required {
+				body["required"] = true
+			}
+			converted["requestBody"] = body
+		case "formData":
+			name, _ := parameter["name"].(string)
+			property := parameterSchema(parameter)
+			if description, ok := parameter["description"]; ok {
+				property["description"] = description
+			}
+			form["properties"].(object)[name] = property
+			if required, _ := parameter["required"].(bool); required {
+				formRequired = append(formRequired, name)
+			}
+		default:
+			parameters = append(parameters, convertParameter(parameter))
+		}
+	}
+	if len(parameters) > 0 {
+		converted["parameters"] = parameters
+	}
+	if len(form["properties"].(object)) > 0 {
+		if len(formRequired) > 0 {
+			form["required"] = formRequired
+		}
+		mediaType := "multipart/form-data"
+		for _, consumed := range consumes {
+			if consumed == "application/x-www-form-urlencoded" {
+				mediaType = consumed
+			}
+		}
+		converted["requestBody"] = object{"content": object{mediaType: object{"schema": form}}}
+	}
+
+	responses := object{}
+	for status, value := range mapOf(operation["responses"]) {
+		response, ok := value.(object)
+		if !ok {
+			continue
+		}
+		description, _ := response["description"].(string)
+		convertedResponse := object{"description": description}
+		if schema, ok := response["schema"]; ok {
+			convertedResponse["content"] = mediaTypes(produces, refs(convertSchema(schema)))
+		}
+		if headers := mapOf(response["headers"]); len(headers) > 0 {
+			convertedHeaders := object{}
+			for name, header := range headers {
+				headerObject, _ := header.(object)
+				convertedHeader := object{"schema": parameterSchema(headerObject)}
+				if description, ok := headerObject["description"]; ok {
+					convertedHeader["description"] = description
+				}
+				convertedHeaders[name] = convertedHeader
+			}
+			convertedResponse["headers"] = convertedHeaders
+		}
+		responses[status] = convertedResponse
+	}
+	if len(responses) == 0 {
+		responses["default"] = object{"description": ""}
+	}
+	converted["responses"] = responses
+	return converted
+}
+
+// convertParameter converts a path, query or header parameter, whose type moves into a schema
+func convertParameter(parameter object) object {
+	converted := object{"name": parameter["name"], "in": parameter["in"], "schema": parameterSchema(parameter)}
+	if description, ok := parameter["description"]; ok {
+		converted["description"] = description
+	}
+	if required, _ := parameter["required"].(bool); required || parameter["in"] == "path" {
+		converted["required"] = true
+	}
+	if parameter["type"] == "array" {
+		switch parameter["collectionFormat"] {
+		case "multi":
+			converted["explode"] = true
+		case "csv", nil:
+			converted["explode"] = false
+		}
+	}
+	return converted
+}
+
+// parameterSchema collects the schema keywords of a Swagger 2.0 parameter or header
+func parameterSchema(parameter object) object {
+	schema := object{}
+	for _, key := range []string{"type", "format", "items", "enum", "default", "minimum", "maximum",
+		"exclusiveMinimum", "exclusiveMaximum", "minLength", "maxLength", "pattern", "minItems", "maxItems",
+		"uniqueItems", "multipleOf", "example"} {
+		if value, ok := parameter[key]; ok {
+			schema[key] = value
+		}
+	}
+	return convertSchema(schema).(object)
+}
+
+// convertSchema converts the Swagger 2.0 keywords of a schema: files become binary strings and
+// x-nullable becomes nullable
+func convertSchema(value interface{}) interface{} {
+	switch node := value.(type) {
+	case object:
+		converted := object{}
+		for key, child := range node {
+			switch key {
+			case "x-nullable":
+				converted["nullable"] = child
+			case "properties", "definitions", "patternProperties":
+				children := object{}
+				for name, schema := range mapOf(child) {
+					children[name] = convertSchema(schema)
+				}
+				converted[key] = children
+			default:
+				converted[key] = convertSchema(child)
+			}
+		}
+		if converted["type"] == "file" {
+			converted["type"] = "string"
+			converted["format"] = "binary"
+		}
+		return converted
+	case []interface{}:
+		converted := make([]interface{}, len(node))
+		for i, child := range node {
+			converted[i] = convertSchema(child)
+		}
+		return converted
+	default:
+		return value
+	}
+}
+
+// convertSecurityScheme converts a Swagger 2.0 security definition
+func convertSecurityScheme(definition object) object {
+	converted := object{}
+	for key, value := range definition {
+		converted[key] = value
+	}
+	if definition["type"] == "basic" {
+		converted["type"] = "http"
+		converted["scheme"] = "basic"
+	}
+	return converted
+}
+
+// mediaTypes returns the content of a body in each media type
+func mediaTypes(types []string, schema interface{}) object {
+	content := object{}
+	for _, mediaType := range types {
+		if schema == nil {
+			content[mediaType] = object{}
+		} else {
+			content[mediaType] = object{"schema": schema}
+		}
+	}
+	return content
+}
+
+// rewriteRefs returns a copy of a value with the definition references of a service pointing at the
+// merged components, renamed where the names of services collide
+func rewriteRefs(value interface{}, renamed map[string]string) interface{} {
+	switch node := value.(type) {
+	case object:
+		converted := object{}
+		for key, child := range node {
+			if ref, ok := child.(string); ok && key == "$ref" && strings.HasPrefix(ref, "#/definitions/") {
+				name := strings.TrimPrefix(ref, "#/definitions/")
+				if newName, ok := renamed[name]; ok {
+					name = newName
+				}
+				converted[key] = "#/components/schemas/" + name
+				continue
+			}
+			converted[key] = rewriteRefs(child, renamed)
+		}
+		return converted
+	case []interface{}:
+		converted := make([]interface{}, len(node))
+		for i, child := range node {
+			converted[i] = rewriteRefs(child, renamed)
+		}
+		return converted
+	default:
+		return value
+	}
+}
+
+func mapOf(value interface{}) object {
+	node, _ := value.(object)
+	return node
+}
+
+func list(value interface{}) []interface{} {
+	node, _ := value.([]interface{})
+	return node
+}
+
+func stringList(value interface{}) []string {
+	var values []string
+	for _, item := range list(value) {
+		if s, ok := item.(string); ok {
+			values = append(values, s)
+		}
+	}
+	return values
+}
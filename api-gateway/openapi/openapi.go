@@ -0,0 +1,341 @@
+// Package openapi merges the Swagger documentation of the gateway and the services into one OpenAPI 3
+// document of the public API. Only operations the gateway routes are kept, at the path clients call
+// them on, and the document is versioned by its content so consumers can tell when the contract changed.
+package openapi
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/clients"
+	"forgecrud-backend/shared/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/swaggo/swag"
+)
+
+// refreshInterval is how long a merged document is served before the services are asked again
+const refreshInterval = time.Minute
+
+// Source is a service whose documentation is merged
+type Source struct {
+	Name string // Service name, as for routes.ProxyToService
+	URL  string // Base URL of the service, whose documentation is at /swagger/doc.json
+}
+
+// Sources returns the services of the configuration
+func Sources() []Source {
+	cfg := config.GetConfig()
+	return []Source{
+		{Name: "auth", URL: cfg.AuthServiceURL},
+		{Name: "core", URL: cfg.CoreServiceURL},
+		{Name: "permissions", URL: cfg.PermissionServiceURL},
+		{Name: "document", URL: cfg.DocumentServiceURL},
+		{Name: "notification", URL: cfg.NotificationServiceURL},
+	}
+}
+
+// Aggregator serves the merged document. The documentation of each service is fetched when the
+// document is older than refreshInterval; a service that does not answer keeps its last documentation.
+type Aggregator struct {
+	router  *gin.Engine
+	sources []Source
+
+	mutex     sync.Mutex
+	specs     map[string]object // Last documentation of each service, by name
+	document  []byte
+	version   string
+	builtAt   time.Time
+	httpCalls map[string]*http.Client // Client of each service, sharing its circuit breaker
+}
+
+// NewAggregator returns an aggregator of the gateway's own documentation and the sources' documentation,
+// keeping the operations the router routes
+func NewAggregator(router *gin.Engine, sources []Source) *Aggregator {
+	httpCalls := map[string]*http.Client{}
+	for _, source := range sources {
+		httpCalls[source.Name] = clients.NewHTTPClient(source.Name, 5*time.Second)
+	}
+	return &Aggregator{router: router, sources: sources, specs: map[string]object{}, httpCalls: httpCalls}
+}
+
+// Handler serves the merged document as JSON. The version is sent as the ETag, so clients polling for
+// changes get 304 Not Modified until the contract changes.
+// @Summary Merged OpenAPI document
+// @Description The OpenAPI 3 document of every service reachable through the gateway. Services that could not be reached are listed in x-unavailable-services.
+// @Tags docs
+// @Produce json
+// @Success 200 {object} map[string]interface{} "OpenAPI document"
+// @Failure 503 {object} map[string]string "No documentation available"
+// @Router /docs/openapi.json [get]
+func (a *Aggregator) Handler(c *gin.Context) {
+	document, version, err := a.Document()
+	if err != nil {
+		apperrors.Respond(c, apperrors.New(apperrors.CodeUnavailable, "API documentation is not available"))
+		return
+	}
+
+	etag := `"` + version + `"`
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "public, max-age=60")
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, "application/json", document)
+}
+
+// Document returns the merged document and its version, merging it again when it is stale
+func (a *Aggregator) Document() ([]byte, string, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if a.document != nil && time.Since(a.builtAt) < refreshInterval {
+		return a.document, a.version, nil
+	}
+
+	var missing []string
+	for _, source := range a.sources {
+		spec, err := a.fetch(source)
+		if err != nil {
+			if _, kept := a.specs[source.Name]; !kept {
+				missing = append(missing, source.Name)
+			}
+			log.Printf("⚠️  OpenAPI: documentation of %s not available: %v", source.Name, err)
+			continue
+		}
+		a.specs[source.Name] = spec
+	}
+
+	gateway, err := localSpec()
+	if err != nil {
+		return nil, "", err
+	}
+	document, version, err := a.merge(gateway, missing)
+	if err != nil {
+		return nil, "", err
+	}
+	a.document, a.version, a.builtAt = document, version, time.Now()
+	return a.document, a.version, nil
+}
+
+// fetch reads the Swagger documentation of a service
+func (a *Aggregator) fetch(source Source) (object, error) {
+	resp, err := a.httpCalls[source.Name].Get(strings.TrimSuffix(source.URL, "/") + "/swagger/doc.json")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	var spec object
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 32<<20)).Decode(&spec); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+// localSpec is the gateway's own documentation, registered by the docs/swagger package
+func localSpec() (object, error) {
+	data, err := swag.ReadDoc()
+	if err != nil {
+		return nil, err
+	}
+	var spec object
+	if err := json.Unmarshal([]byte(data), &spec); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+// namedSpec is the documentation of the gateway or of a service
+type namedSpec struct {
+	name string
+	spec object
+}
+
+// merge builds the OpenAPI document from the gateway's documentation, which also provides the title,
+// tags and security schemes, and the last documentation of every service
+func (a *Aggregator) merge(gateway object, missing []string) ([]byte, string, error) {
+	specs := []namedSpec{{name: "gateway", spec: gateway}}
+	for _, source := range a.sources {
+		if spec, ok := a.specs[source.Name]; ok {
+			specs = append(specs, namedSpec{name: source.Name, spec: spec})
+		}
+	}
+
+	// Definitions named alike by several services are namespaced by service unless they are the same
+	contents := map[string]map[string]bool{}
+	for _, named := range specs {
+		for name, definition := range mapOf(named.spec["definitions"]) {
+			data, _ := json.Marshal(definition)
+			if contents[name] == nil {
+				contents[name] = map[string]bool{}
+			}
+			contents[name][string(data)] = true
+		}
+	}
+
+	routes := a.router.Routes()
+	paths := object{}
+	schemas := object{}
+	securitySchemes := object{}
+	var tags []interface{}
+	tagged := map[string]bool{}
+
+	for _, named := range specs {
+		renamed := map[string]string{}
+		for name := range mapOf(named.spec["definitions"]) {
+			if len(contents[name]) > 1 {
+				renamed[name] = named.name + "." + name
+			}
+		}
+		refs := func(value interface{}) interface{} { return rewriteRefs(value, renamed) }
+
+		for name, definition := range mapOf(named.spec["definitions"]) {
+			if newName, ok := renamed[name]; ok {
+				name = newName
+			}
+			schemas[name] = refs(convertSchema(definition))
+		}
+		for name, definition := range mapOf(named.spec["securityDefinitions"]) {
+			if _, ok := securitySchemes[name]; !ok {
+				securitySchemes[name] = convertSecurityScheme(mapOf(definition))
+			}
+		}
+		for _, value := range list(named.spec["tags"]) {
+			name, _ := mapOf(value)["name"].(string)
+			if name != "" && !tagged[name] {
+				tagged[name] = true
+				tags = append(tags, value)
+			}
+		}
+
+		basePath, _ := named.spec["basePath"].(string)
+		consumes := stringList(named.spec["consumes"])
+		produces := stringList(named.spec["produces"])
+		for specPath, value := range mapOf(named.spec["paths"]) {
+			for method, operation := range mapOf(value) {
+				operationObject, ok := operation.(object)
+				if !ok || !isMethod(method) {
+					continue
+				}
+				gatewayPath, routed := routedPath(routes, strings.ToUpper(method), basePath, specPath)
+				if !routed {
+					continue
+				}
+				pathItem, _ := paths[gatewayPath].(object)
+				if pathItem == nil {
+					pathItem = object{}
+					paths[gatewayPath] = pathItem
+				}
+				pathItem[method] = convertOperation(operationObject, consumes, produces, refs)
+			}
+		}
+	}
+
+	cfg := config.GetConfig()
+	serverURL := cfg.OpenAPIServerURL
+	if serverURL == "" {
+		serverURL = cfg.APIGatewayURL
+	}
+	info := object{}
+	for key, value := range mapOf(gateway["info"]) {
+		info[key] = value
+	}
+	document := object{
+		"openapi":    "3.0.3",
+		"info":       info,
+		"servers":    []interface{}{object{"url": strings.TrimSuffix(serverURL, "/")}},
+		"paths":      paths,
+		"components": object{"schemas": schemas, "securitySchemes": securitySchemes},
+	}
+	if len(tags) > 0 {
+		sort.SliceStable(tags, func(i, j int) bool {
+			return fmt.Sprint(mapOf(tags[i])["name"]) < fmt.Sprint(mapOf(tags[j])["name"])
+		})
+		document["tags"] = tags
+	}
+	if len(missing) > 0 {
+		document["x-unavailable-services"] = missing
+	}
+
+	// The version is the gateway's documented version with a hash of the contract, which changes
+	// whenever an operation or schema does
+	contract, err := json.Marshal(object{"paths": paths, "components": document["components"]})
+	if err != nil {
+		return nil, "", err
+	}
+	sum := sha256.Sum256(contract)
+	baseVersion, _ := info["version"].(string)
+	if baseVersion == "" {
+		baseVersion = "1.0"
+	}
+	version := baseVersion + "+" + hex.EncodeToString(sum[:])[:12]
+	info["version"] = version
+
+	data, err := json.Marshal(document)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, version, nil
+}
+
+func isMethod(method string) bool {
+	switch method {
+	case "get", "put", "post", "delete", "options", "head", "patch":
+		return true
+	}
+	return false
+}
+
+// routedPath returns the path the gateway serves an operation on: the documented path below the
+// documentation's base path, or the documented path itself for services documenting full paths. It
+// reports false for operations the gateway does not route, like calls between services.
+func routedPath(routes gin.RoutesInfo, method, basePath, specPath string) (string, bool) {
+	candidates := []string{specPath}
+	if basePath != "" && basePath != "/" {
+		candidates = []string{path.Join(basePath, specPath), specPath}
+	}
+	for _, candidate := range candidates {
+		for _, route := range routes {
+			if route.Method == method && matchRoute(route.Path, candidate) {
+				return candidate, true
+			}
+		}
+	}
+	return "", false
+}
+
+// matchRoute reports whether a documented path, with {name} parameters, is served by a gin route
+func matchRoute(route, specPath string) bool {
+	routeSegments := strings.Split(strings.Trim(route, "/"), "/")
+	specSegments := strings.Split(strings.Trim(specPath, "/"), "/")
+	for i, segment := range routeSegments {
+		if strings.HasPrefix(segment, "*") {
+			return true
+		}
+		if i >= len(specSegments) {
+			return false
+		}
+		if strings.HasPrefix(segment, ":") {
+			continue
+		}
+		if segment != specSegments[i] {
+			return false
+		}
+	}
+	return len(routeSegments) == len(specSegments)
+}
@@ -1,13 +1,23 @@
 package routes
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
 
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/clientip"
+	"forgecrud-backend/shared/clients"
 	"forgecrud-backend/shared/config"
+	"forgecrud-backend/shared/readonly"
+	"forgecrud-backend/shared/tenancy"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 // getServiceURLs returns service URLs from configuration
@@ -22,6 +32,15 @@ func getServiceURLs() map[string]string {
 	}
 }
 
+// serviceNames maps the proxied services to the names they run under, e.g. in READ_ONLY_SERVICES
+var serviceNames = map[string]string{
+	"auth":         "auth-service",
+	"permissions":  "permission-service",
+	"core":         "core-service",
+	"notification": "notification-service",
+	"document":     "document-service",
+}
+
 // ProxyHandler handles requests and proxies them to the appropriate service
 func ProxyToService(serviceName string) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
@@ -31,20 +50,62 @@ func ProxyToService(serviceName string) gin.HandlerFunc {
 		// Service URL lookup
 		serviceURL, exists := serviceURLs[serviceName]
 		if !exists {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Service not found", "service": serviceName})
+			apperrors.Respond(ctx, apperrors.NotFound("Service not found").With("service", serviceName))
+			return
+		}
+		// Write requests to a read-only service fail fast instead of reaching it
+		if !readonly.Safe(ctx.Request.Method) && readonly.Enabled(serviceNames[serviceName]) {
+			apperrors.Respond(ctx, readonly.Error(serviceNames[serviceName]))
 			return
 		}
 		// Parse the service URL
 		target, err := url.Parse(serviceURL)
 		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid service URL", "service": serviceName})
+			apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Invalid service URL").With("service", serviceName))
 			return
 		}
 
-		// Create a reverse proxy
+		// Create a reverse proxy over the pooled connections of the service, which retries idempotent
+		// requests and fails fast while the service's circuit breaker is open
 		proxy := httputil.NewSingleHostReverseProxy(target)
+		proxy.Transport = clients.Transport(serviceName)
+		proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+			if errors.Is(err, clients.ErrCircuitOpen) {
+				apperrors.Respond(ctx, apperrors.New(apperrors.CodeUnavailable, "Service temporarily unavailable").With("service", serviceName))
+				return
+			}
+			if errors.Is(r.Context().Err(), context.Canceled) {
+				return
+			}
+			log.Printf("❌ Proxy to %s failed: %v", serviceName, err)
+			apperrors.Respond(ctx, apperrors.New(apperrors.CodeBadGateway, "Service unavailable").With("service", serviceName))
+		}
+
+		// Forward the authenticated user and the tenant, signed, so services scope their queries to the
+		// caller's organization; never trust a client-supplied value
+		tenancy.Forward(ctx.Request, forwardedTenant(ctx))
+
+		// Forward the client IP, signed so services can tell it from a client-supplied value
+		clientip.Forward(ctx.Request, ctx.ClientIP())
 
 		// add request to proxy
 		proxy.ServeHTTP(ctx.Writer, ctx.Request)
 	}
 }
+
+// forwardedTenant returns the caller and organization the gateway verified for the request
+func forwardedTenant(ctx *gin.Context) tenancy.Tenant {
+	var tenant tenancy.Tenant
+	if userID, exists := ctx.Get("user_id"); exists {
+		if id, err := uuid.Parse(fmt.Sprint(userID)); err == nil {
+			tenant.UserID = &id
+		}
+	}
+	if organizationID, exists := ctx.Get("organization_id"); exists {
+		if id, err := uuid.Parse(fmt.Sprint(organizationID)); err == nil {
+			tenant.OrganizationID = &id
+		}
+	}
+	tenant.Bypass = ctx.GetBool("tenant_bypass")
+	return tenant
+}
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/database/models/auth"
+	utils "forgecrud-backend/shared/utils/auth"
+	"forgecrud-backend/shared/utils/validation"
+)
+
+// maxAppPasswords limits how many app passwords a user can have at once
+const maxAppPasswords = 20
+
+// CreateAppPasswordRequest represents the request body for creating an app password
+type CreateAppPasswordRequest struct {
+	Name string `json:"name" binding:"required,max=100" example:"Finder on my laptop"`
+}
+
+// AppPasswordResponse represents an app password in the response
+type AppPasswordResponse struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// CreatedAppPasswordResponse is a new app password, the only response containing the password
+type CreatedAppPasswordResponse struct {
+	AppPasswordResponse
+	Password string `json:"password"`
+}
+
+// ListAppPasswords lists the app passwords of the authenticated user
+// @Summary List app passwords
+// @Description Get the app passwords of the currently authenticated user. Passwords themselves are only returned when they are created.
+// @Tags auth-security
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "List of app passwords"
+// @Failure 401 {object} map[string]string "User not authenticated"
+// @Failure 500 {object} map[string]string "Failed to retrieve app passwords"
+// @Router /auth/app-passwords [get]
+func (h *AuthHandler) ListAppPasswords(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperrors.Respond(c, apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	var appPasswords []auth.AppPassword
+	if err := h.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&appPasswords).Error; err != nil {
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to retrieve app passwords"))
+		return
+	}
+
+	response := make([]AppPasswordResponse, 0, len(appPasswords))
+	for _, appPassword := range appPasswords {
+		response = append(response, buildAppPasswordResponse(appPassword))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    response,
+	})
+}
+
+// CreateAppPassword generates an app password for the authenticated user
+// @Summary Create app password
+// @Description Generate a password for applications that cannot sign in with a token, such as WebDAV clients. Sign in to them with your email and this password; it is only shown in this response.
+// @Tags auth-security
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body CreateAppPasswordRequest true "App password name"
+// @Success 201 {object} map[string]interface{} "Created app password"
+// @Failure 400 {object} map[string]string "Invalid request format"
+// @Failure 401 {object} map[string]string "User not authenticated"
+// @Failure 409 {object} map[string]string "Too many app passwords"
+// @Failure 500 {object} map[string]string "Failed to create app password"
+// @Router /auth/app-passwords [post]
+func (h *AuthHandler) CreateAppPassword(c *gin.Context) {
+	var req CreateAppPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperrors.Respond(c, validation.Error(err, "Invalid request body"))
+		return
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		apperrors.Respond(c, apperrors.BadRequest("Name is required"))
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperrors.Respond(c, apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	var count int64
+	if err := h.db.Model(&auth.AppPassword{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to create app password"))
+		return
+	}
+	if count >= maxAppPasswords {
+		apperrors.Respond(c, apperrors.Conflict("Too many app passwords, revoke one you no longer use first"))
+		return
+	}
+
+	password, err := utils.GenerateRandomToken(16)
+	if err != nil {
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to create app password"))
+		return
+	}
+
+	hash, err := utils.HashPassword(password)
+	if err != nil {
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to create app password"))
+		return
+	}
+
+	appPassword := auth.AppPassword{
+		UserID:       userID.(uuid.UUID),
+		Name:         name,
+		PasswordHash: hash,
+	}
+	if err := h.db.Create(&appPassword).Error; err != nil {
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to create app password"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "App password created, it will not be shown again",
+		"data": CreatedAppPasswordResponse{
+			AppPasswordResponse: buildAppPasswordResponse(appPassword),
+			Password:            password,
+		},
+	})
+}
+
+// RevokeAppPassword deletes an app password of the authenticated user
+// @Summary Revoke app password
+// @Description Delete an app password; applications signed in with it can no longer connect
+// @Tags auth-security
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "App password ID"
+// @Success 200 {object} map[string]string "App password revoked successfully"
+// @Failure 400 {object} map[string]string "Invalid app password ID format"
+// @Failure 401 {object} map[string]string "User not authenticated"
+// @Failure 404 {object} map[string]string "App password not found"
+// @Failure 500 {object} map[string]string "Failed to revoke app password"
+// @Router /auth/app-passwords/{id} [delete]
+func (h *AuthHandler) RevokeAppPassword(c *gin.Context) {
+	appPasswordID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apperrors.Respond(c, apperrors.BadRequest("Invalid app password ID format"))
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperrors.Respond(c, apperrors.Unauthorized("User not authenticated"))
+		return
+	}
+
+	result := h.db.Where("id = ? AND user_id = ?", appPasswordID, userID).Delete(&auth.AppPassword{})
+	if result.Error != nil {
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to revoke app password"))
+		return
+	}
+	if result.RowsAffected == 0 {
+		apperrors.Respond(c, apperrors.NotFound("App password not found or does not belong to the user"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "App password revoked successfully"})
+}
+
+func buildAppPasswordResponse(appPassword auth.AppPassword) AppPasswordResponse {
+	return AppPasswordResponse{
+		ID:         appPassword.ID,
+		Name:       appPassword.Name,
+		LastUsedAt: appPassword.LastUsedAt,
+		CreatedAt:  appPassword.CreatedAt,
+	}
+}
@@ -8,10 +8,15 @@ import (
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 
+	"forgecrud-backend/shared/apperrors"
 	"forgecrud-backend/shared/clients"
+	"forgecrud-backend/shared/database"
 	"forgecrud-backend/shared/database/models"
 	"forgecrud-backend/shared/database/models/auth"
+	"forgecrud-backend/shared/i18n"
+	"forgecrud-backend/shared/messaging"
 	utils "forgecrud-backend/shared/utils/auth"
+	"forgecrud-backend/shared/utils/validation"
 )
 
 type AuthHandler struct {
@@ -36,14 +41,15 @@ type LoginResponse struct {
 }
 
 type UserInfo struct {
-	ID             uuid.UUID `json:"id"`
-	Email          string    `json:"email"`
-	FirstName      string    `json:"first_name"`
-	LastName       string    `json:"last_name"`
-	OrganizationID uuid.UUID `json:"organization_id"`
-	RoleID         uuid.UUID `json:"role_id"`
-	RoleName       string    `json:"role_name"`
-	Status         string    `json:"status"`
+	ID                uuid.UUID `json:"id"`
+	Email             string    `json:"email"`
+	FirstName         string    `json:"first_name"`
+	LastName          string    `json:"last_name"`
+	OrganizationID    uuid.UUID `json:"organization_id"`
+	RoleID            uuid.UUID `json:"role_id"`
+	RoleName          string    `json:"role_name"`
+	Status            string    `json:"status"`
+	MustResetPassword bool      `json:"must_reset_password"`
 }
 
 // Register Request struct
@@ -52,6 +58,9 @@ type RegisterRequest struct {
 	Password  string `json:"password" binding:"required,min=8" example:"securepassword123"`
 	FirstName string `json:"first_name" binding:"required" example:"John"`
 	LastName  string `json:"last_name" binding:"required" example:"Doe"`
+	Language  string `json:"language" binding:"omitempty,bcp47_language_tag" example:"en"` // Language of emails and notifications
+	// Invite token of a registration rule; its organization and role are assigned right away
+	InviteToken string `json:"invite_token" binding:"omitempty,max=64"`
 }
 
 // Refresh Request struct
@@ -110,14 +119,14 @@ type CreateVerificationTokenResponse struct {
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apperrors.Respond(c, validation.Error(err, "Invalid request body"))
 		return
 	}
 
 	// Rate limiting Control (login attempt)
 	clientIP := c.ClientIP()
 	if err := h.checkRateLimit(req.Email, clientIP); err != nil {
-		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many login attempts. Please try again later."})
+		apperrors.Respond(c, apperrors.New(apperrors.CodeRateLimited, "Too many login attempts. Please try again later."))
 		return
 	}
 
@@ -125,21 +134,21 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	var user models.User
 	if err := h.db.Preload("Organization").Preload("Role").Where("email = ?", req.Email).First(&user).Error; err != nil {
 		h.recordFailedLogin(req.Email, clientIP, "User not found")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		apperrors.Respond(c, apperrors.Unauthorized("Invalid credentials"))
 		return
 	}
 
-	// Check if user is active
-	if user.Status != "ACTIVE" {
+	// Check if user may sign in (active or still onboarding)
+	if !models.UserStatusCanSignIn(user.Status) {
 		h.recordFailedLogin(req.Email, clientIP, "User inactive")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Account is inactive"})
+		apperrors.Respond(c, apperrors.Unauthorized("Account is inactive"))
 		return
 	}
 
 	// Check password
 	if !utils.CheckPasswordHash(req.Password, user.Password) {
 		h.recordFailedLogin(req.Email, clientIP, "Invalid password")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		apperrors.Respond(c, apperrors.Unauthorized("Invalid credentials"))
 		return
 	}
 
@@ -152,26 +161,30 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		roleID = *user.RoleID
 	}
 
-	token, err := utils.GenerateJWT(user.ID, user.Email, orgID, roleID)
+	// The access token carries the session, so terminating the session revokes it
+	sessionID, _ := utils.GenerateSessionID()
+	token, err := utils.GenerateSessionJWT(user.ID, user.Email, orgID, roleID, sessionID, user.MustResetPassword)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not generate token"})
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Could not generate token"))
 		return
 	}
 
 	// Create Refresh Token
 	refreshToken, err := utils.GenerateRefreshJWT(user.ID, user.Email)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not generate refresh token"})
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Could not generate refresh token"))
 		return
 	}
 
+	// Check for a new device before the session of this login is stored
+	newDevice := h.isNewDevice(user.ID, c.GetHeader("User-Agent"))
+
 	// Set up user session
-	sessionID, _ := utils.GenerateSessionID()
 	expireDuration := utils.GetJWTExpireDuration()
 	userSession := auth.UserSession{
 		UserID:       user.ID,
 		SessionID:    sessionID,
-		TokenHash:    token[:32],
+		TokenHash:    utils.HashToken(token),
 		RefreshToken: refreshToken,
 		IPAddress:    clientIP,
 		UserAgent:    c.GetHeader("User-Agent"),
@@ -180,12 +193,20 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	}
 
 	if err := h.db.Create(&userSession).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create session"})
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Could not create session"))
 		return
 	}
 
 	h.recordSuccessfulLogin(user.Email, clientIP)
 
+	if newDevice {
+		messaging.Publish(c.Request.Context(), messaging.EventSecurityNewDeviceLogin, &user.ID, messaging.SecurityAlertData{
+			UserID:    user.ID,
+			IPAddress: clientIP,
+			UserAgent: c.GetHeader("User-Agent"),
+		})
+	}
+
 	var roleName string
 	if user.RoleID != nil {
 		roleName = user.Role.Name
@@ -196,14 +217,15 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		RefreshToken: refreshToken,
 		ExpiresAt:    time.Now().Add(expireDuration),
 		User: UserInfo{
-			ID:             user.ID,
-			Email:          user.Email,
-			FirstName:      user.FirstName,
-			LastName:       user.LastName,
-			OrganizationID: orgID,
-			RoleID:         roleID,
-			RoleName:       roleName,
-			Status:         user.Status,
+			ID:                user.ID,
+			Email:             user.Email,
+			FirstName:         user.FirstName,
+			LastName:          user.LastName,
+			OrganizationID:    orgID,
+			RoleID:            roleID,
+			RoleName:          roleName,
+			Status:            user.Status,
+			MustResetPassword: user.MustResetPassword,
 		},
 	}
 
@@ -225,7 +247,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 func (h *AuthHandler) Logout(c *gin.Context) {
 	tokenString := c.GetHeader("Authorization")
 	if tokenString == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Token required"})
+		apperrors.Respond(c, apperrors.BadRequest("Token required"))
 		return
 	}
 
@@ -236,17 +258,20 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 	// Validate JWT token
 	claims, err := utils.ValidateJWT(tokenString)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		apperrors.Respond(c, apperrors.Unauthorized("Invalid token"))
 		return
 	}
 
 	// Set Session passive
-	tokenHash := tokenString[:32]
+	tokenHash := utils.HashToken(tokenString)
 	userID, _ := uuid.Parse(claims.UserID)
-	if err := h.db.Model(&auth.UserSession{}).
-		Where("user_id = ? AND token_hash = ? AND is_active = ?", userID, tokenHash, true).
-		Update("is_active", false).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not logout"})
+	if err := h.terminateSessions(c, userID, func(db *gorm.DB) *gorm.DB {
+		if claims.SessionID != "" {
+			return db.Where("session_id = ?", claims.SessionID)
+		}
+		return db.Where("token_hash = ?", tokenHash)
+	}); err != nil {
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Could not logout"))
 		return
 	}
 
@@ -255,13 +280,14 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 
 // POST /api/auth/register
 // @Summary Register new user
-// @Description Register a new user account
+// @Description Register a new user account. With an invite_token the invite's organization and role are assigned right away; email domain registration rules are applied once the email is verified.
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param register body RegisterRequest true "User registration data"
 // @Success 201 {object} handlers.LoginResponse "User registered successfully"
-// @Failure 400 {object} map[string]string "Invalid request format or validation error"
+// @Failure 400 {object} map[string]string "Invalid request format, validation error or invalid invite token"
+// @Failure 402 {object} map[string]string "The invite's organization has no room for another user"
 // @Failure 409 {object} map[string]string "Email already exists"
 // @Failure 429 {object} map[string]string "Too many registration attempts"
 // @Failure 500 {object} map[string]string "Failed to register user"
@@ -269,33 +295,33 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 func (h *AuthHandler) Register(c *gin.Context) {
 	var req RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apperrors.Respond(c, validation.Error(err, "Invalid request body"))
 		return
 	}
 
 	// Email validation
 	if err := utils.ValidateEmail(req.Email); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apperrors.Respond(c, apperrors.BadRequest(err.Error()))
 		return
 	}
 
 	// Password validation
 	if err := utils.ValidatePassword(req.Password); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apperrors.Respond(c, apperrors.BadRequest(err.Error()))
 		return
 	}
 
-	// Check email uniqueness
+	// Check email uniqueness (deleted accounts keep their email until purged)
 	var existingUser models.User
-	if err := h.db.Where("email = ?", req.Email).First(&existingUser).Error; err == nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "Email already exists"})
+	if err := h.db.Unscoped().Where("email = ?", req.Email).First(&existingUser).Error; err == nil {
+		apperrors.Respond(c, apperrors.Conflict("Email already exists"))
 		return
 	}
 
 	// Hash password
 	hashedPassword, err := utils.HashPassword(req.Password)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not hash password"})
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Could not hash password"))
 		return
 	}
 
@@ -305,17 +331,39 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		Password:      hashedPassword,
 		FirstName:     req.FirstName,
 		LastName:      req.LastName,
-		Status:        "ACTIVE",
+		Language:      i18n.NormalizeLocale(req.Language),
+		Status:        models.UserStatusPendingVerification,
 		EmailVerified: false,
 		CreatedAt:     time.Now(),
 		UpdatedAt:     time.Now(),
 	}
 
-	if err := h.db.Create(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create user"})
+	// Invites assign their organization and role right away; email domain rules wait until the
+	// email is verified, as anyone can register with an address of the domain
+	var rule *models.RegistrationRule
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if req.InviteToken != "" {
+			var err error
+			if rule, err = database.FindInviteRule(tx, req.InviteToken, time.Now()); err != nil {
+				return err
+			}
+		}
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		if rule == nil {
+			return nil
+		}
+		_, err := database.ApplyRegistrationRule(tx, &user, rule)
+		return err
+	})
+	if err != nil {
+		apperrors.Respond(c, registrationError(err, "Could not create user"))
 		return
 	}
 
+	h.publishUserRegistered(c, messaging.EventUserCreated, user, rule)
+
 	// Send verification email automatically after registration
 	verificationToken, err := utils.CreateEmailVerificationToken(h.db, user.ID)
 	if err != nil {
@@ -373,14 +421,14 @@ func (h *AuthHandler) Register(c *gin.Context) {
 func (h *AuthHandler) Refresh(c *gin.Context) {
 	var req RefreshRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apperrors.Respond(c, validation.Error(err, "Invalid request body"))
 		return
 	}
 
 	// Refresh token validation
 	claims, err := utils.ValidateRefreshJWT(req.RefreshToken)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+		apperrors.Respond(c, apperrors.Unauthorized("Invalid refresh token"))
 		return
 	}
 
@@ -389,20 +437,20 @@ func (h *AuthHandler) Refresh(c *gin.Context) {
 	var userSession auth.UserSession
 	if err := h.db.Where("user_id = ? AND refresh_token = ? AND is_active = ?",
 		userID, req.RefreshToken, true).First(&userSession).Error; err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token not found or expired"})
+		apperrors.Respond(c, apperrors.Unauthorized("Refresh token not found or expired"))
 		return
 	}
 
 	// User bilgilerini al
 	var user models.User
 	if err := h.db.Where("id = ?", userID).First(&user).Error; err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		apperrors.Respond(c, apperrors.Unauthorized("User not found"))
 		return
 	}
 
 	// Kullanıcı aktif mi kontrol et
-	if user.Status != "ACTIVE" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Account is inactive"})
+	if !models.UserStatusCanSignIn(user.Status) {
+		apperrors.Respond(c, apperrors.Unauthorized("Account is inactive"))
 		return
 	}
 
@@ -415,26 +463,26 @@ func (h *AuthHandler) Refresh(c *gin.Context) {
 		roleID = *user.RoleID
 	}
 
-	newToken, err := utils.GenerateJWT(user.ID, user.Email, orgID, roleID)
+	newToken, err := utils.GenerateSessionJWT(user.ID, user.Email, orgID, roleID, userSession.SessionID, user.MustResetPassword)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not generate token"})
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Could not generate token"))
 		return
 	}
 
 	newRefreshToken, err := utils.GenerateRefreshJWT(user.ID, user.Email)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not generate refresh token"})
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Could not generate refresh token"))
 		return
 	}
 
 	expireDuration := utils.GetJWTExpireDuration()
-	userSession.TokenHash = newToken[:32]
+	userSession.TokenHash = utils.HashToken(newToken)
 	userSession.RefreshToken = newRefreshToken
 	userSession.ExpiresAt = time.Now().Add(expireDuration)
 	userSession.UpdatedAt = time.Now()
 
 	if err := h.db.Save(&userSession).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not update session"})
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Could not update session"))
 		return
 	}
 
@@ -460,7 +508,7 @@ func (h *AuthHandler) Refresh(c *gin.Context) {
 func (h *AuthHandler) Validate(c *gin.Context) {
 	var req ValidateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apperrors.Respond(c, validation.Error(err, "Invalid request body"))
 		return
 	}
 
@@ -480,7 +528,7 @@ func (h *AuthHandler) Validate(c *gin.Context) {
 	}
 
 	userID, _ := uuid.Parse(claims.UserID)
-	tokenHash := req.Token[:32]
+	tokenHash := utils.HashToken(req.Token)
 
 	// Check if token is blacklisted
 	var blacklistedToken auth.BlacklistedToken
@@ -491,9 +539,14 @@ func (h *AuthHandler) Validate(c *gin.Context) {
 		return
 	}
 
+	sessionQuery := h.db.Where("user_id = ? AND is_active = ?", userID, true)
+	if claims.SessionID != "" {
+		sessionQuery = sessionQuery.Where("session_id = ?", claims.SessionID)
+	} else {
+		sessionQuery = sessionQuery.Where("token_hash = ?", tokenHash)
+	}
 	var userSession auth.UserSession
-	if err := h.db.Where("user_id = ? AND token_hash = ? AND is_active = ?",
-		userID, tokenHash, true).First(&userSession).Error; err != nil {
+	if err := sessionQuery.First(&userSession).Error; err != nil {
 		c.JSON(http.StatusOK, ValidateResponse{
 			Valid: false,
 		})
@@ -526,14 +579,14 @@ func (h *AuthHandler) Validate(c *gin.Context) {
 func (h *AuthHandler) Blacklist(c *gin.Context) {
 	var req BlacklistRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apperrors.Respond(c, validation.Error(err, "Invalid request body"))
 		return
 	}
 
 	// Validate JWT token
 	claims, err := utils.ValidateJWT(req.Token)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		apperrors.Respond(c, apperrors.Unauthorized("Invalid token"))
 		return
 	}
 
@@ -544,7 +597,7 @@ func (h *AuthHandler) Blacklist(c *gin.Context) {
 	}
 
 	// Get token hash and user ID
-	tokenHash := req.Token[:32]
+	tokenHash := utils.HashToken(req.Token)
 	userID, _ := uuid.Parse(claims.UserID)
 
 	// Create blacklisted token record
@@ -564,7 +617,7 @@ func (h *AuthHandler) Blacklist(c *gin.Context) {
 
 	// Save blacklisted token
 	if err := h.db.Create(&blacklistedToken).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not blacklist token"})
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Could not blacklist token"))
 		return
 	}
 
@@ -591,31 +644,31 @@ func (h *AuthHandler) Blacklist(c *gin.Context) {
 func (h *AuthHandler) CreateVerificationToken(c *gin.Context) {
 	var req CreateVerificationTokenRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apperrors.Respond(c, validation.Error(err, "Invalid request body"))
 		return
 	}
 
 	var user models.User
 	if err := h.db.Where("email = ?", req.Email).First(&user).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		apperrors.Respond(c, apperrors.NotFound("User not found"))
 		return
 	}
 
 	if user.EmailVerified {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Email is already verified"})
+		apperrors.Respond(c, apperrors.BadRequest("Email is already verified"))
 		return
 	}
 
 	// Invalidate old verification tokens
 	if err := utils.InvalidateOldVerificationTokens(h.db, user.ID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to invalidate old tokens"})
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to invalidate old tokens"))
 		return
 	}
 
 	// Create new verification token
 	verificationToken, err := utils.CreateEmailVerificationToken(h.db, user.ID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create verification token"})
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to create verification token"))
 		return
 	}
 
@@ -627,7 +680,7 @@ func (h *AuthHandler) CreateVerificationToken(c *gin.Context) {
 
 // VerifyEmail verifies the email using the provided token
 // @Summary Verify email
-// @Description Verify user's email using the provided token
+// @Description Verify user's email using the provided token. Users without an organization and role get those of the registration rule for their email domain.
 // @Tags auth
 // @Accept json
 // @Produce json
@@ -639,16 +692,18 @@ func (h *AuthHandler) CreateVerificationToken(c *gin.Context) {
 func (h *AuthHandler) VerifyEmail(c *gin.Context) {
 	token := c.Param("token")
 	if token == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Token is required"})
+		apperrors.Respond(c, apperrors.BadRequest("Token is required"))
 		return
 	}
 
 	user, err := utils.VerifyEmailToken(h.db, token)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apperrors.Respond(c, apperrors.BadRequest(err.Error()))
 		return
 	}
 
+	h.applyEmailDomainRule(c, user)
+
 	var orgID, roleID uuid.UUID
 	if user.OrganizationID != nil {
 		orgID = *user.OrganizationID
@@ -657,15 +712,15 @@ func (h *AuthHandler) VerifyEmail(c *gin.Context) {
 		roleID = *user.RoleID
 	}
 
-	authToken, err := utils.GenerateJWT(user.ID, user.Email, orgID, roleID)
+	authToken, err := utils.GenerateSessionJWT(user.ID, user.Email, orgID, roleID, "", user.MustResetPassword)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not generate token"})
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Could not generate token"))
 		return
 	}
 
 	refreshToken, err := utils.GenerateRefreshJWT(user.ID, user.Email)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not generate refresh token"})
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Could not generate refresh token"))
 		return
 	}
 
@@ -674,7 +729,7 @@ func (h *AuthHandler) VerifyEmail(c *gin.Context) {
 		"first_name": user.FirstName,
 		"last_name":  user.LastName,
 		"email":      user.Email,
-		"status":     "ACTIVE",
+		"status":     user.Status,
 	}
 
 	if user.OrganizationID != nil {
@@ -711,6 +766,18 @@ func (h *AuthHandler) checkRateLimit(email, ipAddress string) error {
 	return nil
 }
 
+// isNewDevice reports whether the user signed in before, but never with this user agent. The
+// first login of a user is not reported as a new device.
+func (h *AuthHandler) isNewDevice(userID uuid.UUID, userAgent string) bool {
+	var sessions, sameDevice int64
+	h.db.Model(&auth.UserSession{}).Where("user_id = ?", userID).Count(&sessions)
+	if sessions == 0 {
+		return false
+	}
+	h.db.Model(&auth.UserSession{}).Where("user_id = ? AND user_agent = ?", userID, userAgent).Count(&sameDevice)
+	return sameDevice == 0
+}
+
 func (h *AuthHandler) recordFailedLogin(email, ipAddress, failureType string) {
 	attempt := auth.LoginAttempt{
 		Email:       email,
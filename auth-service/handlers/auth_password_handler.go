@@ -2,16 +2,20 @@ package handlers
 
 import (
 	"fmt"
+	"log"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 
+	"forgecrud-backend/shared/apperrors"
 	"forgecrud-backend/shared/clients"
 	"forgecrud-backend/shared/database/models"
 	"forgecrud-backend/shared/database/models/auth"
+	"forgecrud-backend/shared/messaging"
 	utils "forgecrud-backend/shared/utils/auth"
+	"forgecrud-backend/shared/utils/validation"
 )
 
 // Password Management Request/Response structs
@@ -37,7 +41,7 @@ type ResetPasswordRequest struct {
 
 // ChangePassword changes a user's password after verifying the current password
 // @Summary Change password
-// @Description Change user's password after verifying current password
+// @Description Change user's password after verifying current password. Users who must reset their password can only call this endpoint and logout; refresh the token afterwards to get one without the restriction.
 // @Tags auth-password
 // @Accept json
 // @Produce json
@@ -52,64 +56,64 @@ type ResetPasswordRequest struct {
 func (h *AuthHandler) ChangePassword(c *gin.Context) {
 	var req ChangePasswordRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apperrors.Respond(c, validation.Error(err, "Invalid request body"))
 		return
 	}
 
 	// Get user ID from JWT token
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		apperrors.Respond(c, apperrors.Unauthorized("User not authenticated"))
 		return
 	}
 
 	// Find user
 	var user models.User
 	if err := h.db.Where("id = ?", userID).First(&user).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		apperrors.Respond(c, apperrors.NotFound("User not found"))
 		return
 	}
 
 	// Verify current password
 	if !utils.CheckPasswordHash(req.CurrentPassword, user.Password) {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Current password is incorrect"})
+		apperrors.Respond(c, apperrors.Unauthorized("Current password is incorrect"))
 		return
 	}
 
 	// Validate new password
 	if err := utils.ValidatePassword(req.NewPassword); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apperrors.Respond(c, apperrors.BadRequest(err.Error()))
 		return
 	}
 
 	// Ensure new password is different from current password
 	if req.CurrentPassword == req.NewPassword {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "New password must be different from current password"})
+		apperrors.Respond(c, apperrors.BadRequest("New password must be different from current password"))
 		return
 	}
 
 	// Hash the new password
 	hashedPassword, err := utils.HashPassword(req.NewPassword)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not hash password"})
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Could not hash password"))
 		return
 	}
 
 	// Update user's password
-	if err := h.db.Model(&user).Update("password", hashedPassword).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not update password"})
+	if err := h.db.Model(&user).Updates(passwordUpdates(&user, hashedPassword)).Error; err != nil {
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Could not update password"))
 		return
 	}
 
 	// Optionally, invalidate all user's sessions except the current one
 	// This is a security measure to log out the user from all other devices
-	currentTokenHash, _ := c.Get("tokenHash")
-	if err := h.db.Model(&auth.UserSession{}).
-		Where("user_id = ? AND token_hash != ?", userID, currentTokenHash).
-		Update("is_active", false).Error; err != nil {
+	if err := h.terminateSessions(c, userID, exceptCurrentSession(c)); err != nil {
 		// Non-critical error, just log it
+		log.Printf("⚠️  Failed to terminate the other sessions of user %v: %v", userID, err)
 	}
 
+	h.publishPasswordChanged(c, user.ID)
+
 	// Return success response
 	c.JSON(http.StatusOK, gin.H{"message": "Password changed successfully"})
 }
@@ -129,14 +133,14 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 func (h *AuthHandler) ForgotPassword(c *gin.Context) {
 	var req ForgotPasswordRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apperrors.Respond(c, validation.Error(err, "Invalid request body"))
 		return
 	}
 
 	// Check if the rate limit has been exceeded for this email/IP
 	clientIP := c.ClientIP()
 	if err := h.checkPasswordResetRateLimit(req.Email, clientIP); err != nil {
-		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many password reset attempts. Please try again later."})
+		apperrors.Respond(c, apperrors.New(apperrors.CodeRateLimited, "Too many password reset attempts. Please try again later."))
 		return
 	}
 
@@ -150,21 +154,21 @@ func (h *AuthHandler) ForgotPassword(c *gin.Context) {
 
 	// Invalidate old reset tokens for this user
 	if err := h.invalidateOldPasswordResetTokens(user.ID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not process request"})
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Could not process request"))
 		return
 	}
 
 	// Create a new password reset token
 	resetToken, err := h.createPasswordResetToken(user.ID, clientIP)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create reset token"})
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Could not create reset token"))
 		return
 	}
 
 	// Send password reset email
 	notificationClient := clients.NewNotificationClient()
 	if err := notificationClient.SendPasswordResetEmail(user.Email, user.FirstName, resetToken.Token); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not send reset email"})
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Could not send reset email"))
 		return
 	}
 
@@ -188,33 +192,33 @@ func (h *AuthHandler) ForgotPassword(c *gin.Context) {
 func (h *AuthHandler) ResetPassword(c *gin.Context) {
 	var req ResetPasswordRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apperrors.Respond(c, validation.Error(err, "Invalid request body"))
 		return
 	}
 
 	// Validate token and get user
 	user, err := h.validatePasswordResetToken(req.Token)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apperrors.Respond(c, apperrors.BadRequest(err.Error()))
 		return
 	}
 
 	// Validate new password
 	if err := utils.ValidatePassword(req.NewPassword); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apperrors.Respond(c, apperrors.BadRequest(err.Error()))
 		return
 	}
 
 	// Hash the new password
 	hashedPassword, err := utils.HashPassword(req.NewPassword)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not hash password"})
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Could not hash password"))
 		return
 	}
 
 	// Update user's password
-	if err := h.db.Model(&user).Update("password", hashedPassword).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not update password"})
+	if err := h.db.Model(&user).Updates(passwordUpdates(user, hashedPassword)).Error; err != nil {
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Could not update password"))
 		return
 	}
 
@@ -230,12 +234,23 @@ func (h *AuthHandler) ResetPassword(c *gin.Context) {
 		// Non-critical error, just log it
 	}
 
+	h.publishPasswordChanged(c, user.ID)
+
 	// Return success response
 	c.JSON(http.StatusOK, gin.H{"message": "Password reset successful. You can now log in with your new password."})
 }
 
 // Helper functions
 
+// publishPasswordChanged lets notification-service alert the user, in case someone else changed it
+func (h *AuthHandler) publishPasswordChanged(c *gin.Context, userID uuid.UUID) {
+	messaging.Publish(c.Request.Context(), messaging.EventSecurityPasswordChanged, &userID, messaging.SecurityAlertData{
+		UserID:    userID,
+		IPAddress: c.ClientIP(),
+		UserAgent: c.GetHeader("User-Agent"),
+	})
+}
+
 // checkPasswordResetRateLimit checks if the rate limit has been exceeded for password reset attempts
 func (h *AuthHandler) checkPasswordResetRateLimit(email, ipAddress string) error {
 	var count int64
@@ -330,3 +345,19 @@ func (h *AuthHandler) recordPasswordResetAttempt(email, ipAddress string, succes
 	}
 	h.db.Create(&attempt)
 }
+
+// passwordUpdates returns the columns to update when the user sets a new password.
+// Invited users move on in onboarding once they have chosen their own password.
+func passwordUpdates(user *models.User, hashedPassword string) map[string]interface{} {
+	updates := map[string]interface{}{
+		"password":            hashedPassword,
+		"must_reset_password": false,
+	}
+
+	if user.Status == models.UserStatusInvited {
+		onboarded := *user
+		onboarded.MustResetPassword = false
+		updates["status"] = models.OnboardedUserStatus(&onboarded)
+	}
+	return updates
+}
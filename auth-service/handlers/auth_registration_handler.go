@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models"
+	"forgecrud-backend/shared/messaging"
+)
+
+// RegisteredUser is the payload of the user events published for self-registered users
+type RegisteredUser struct {
+	ID                 uuid.UUID  `json:"id"`
+	Email              string     `json:"email"`
+	FirstName          string     `json:"first_name"`
+	LastName           string     `json:"last_name"`
+	Status             string     `json:"status"`
+	OrganizationID     *uuid.UUID `json:"organization_id"`
+	RoleID             *uuid.UUID `json:"role_id"`
+	RegistrationRuleID *uuid.UUID `json:"registration_rule_id,omitempty"` // Rule that assigned the organization and role
+}
+
+// registrationError maps the errors of applying a registration rule to API errors
+func registrationError(err error, message string) error {
+	var quotaErr *database.QuotaExceededError
+	switch {
+	case errors.Is(err, database.ErrInvalidInvite):
+		return apperrors.BadRequest("Invalid invite token").WithDetails(err.Error())
+	case errors.As(err, &quotaErr):
+		return apperrors.Wrap(quotaErr, apperrors.CodeQuotaExceeded, "Quota exceeded").With("quota", quotaErr)
+	default:
+		return apperrors.New(apperrors.CodeInternal, message)
+	}
+}
+
+// applyEmailDomainRule assigns the organization and role of the registration rule for the domain
+// of the user's now verified email. Without a rule, an organization that verified the domain lets
+// the user join or files their request to join, depending on its join policy. Users an admin
+// already placed are left alone. Failures are logged, so they never fail the verification itself.
+func (h *AuthHandler) applyEmailDomainRule(c *gin.Context, user *models.User) {
+	if user.OrganizationID != nil || user.RoleID != nil {
+		return
+	}
+
+	var rule *models.RegistrationRule
+	var domain *models.OrganizationDomain
+	var joinRequest *models.OrganizationJoinRequest
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if rule, err = database.FindEmailDomainRule(tx, user.Email, time.Now()); err != nil {
+			return err
+		}
+		if rule != nil {
+			_, err = database.ApplyRegistrationRule(tx, user, rule)
+			return err
+		}
+
+		if domain, err = database.FindVerifiedDomain(tx, user.Email); err != nil || domain == nil {
+			return err
+		}
+		switch domain.JoinPolicy {
+		case models.DomainJoinAuto:
+			_, err = database.JoinOrganization(tx, user, &domain.OrganizationID, domain.RoleID, "Verified domain "+domain.Domain)
+		case models.DomainJoinRequest:
+			var created bool
+			if joinRequest, created, err = database.RequestToJoin(tx, user, domain); !created {
+				// Organization admins were already told about a pending request
+				joinRequest = nil
+			}
+		}
+		return err
+	})
+	if err != nil {
+		log.Printf("⚠️  Failed to apply registration rule or verified domain for %s: %v", user.Email, err)
+		// The transaction was rolled back, so the user keeps no organization and role
+		user.OrganizationID, user.RoleID = nil, nil
+		return
+	}
+
+	if rule != nil || (domain != nil && domain.JoinPolicy == models.DomainJoinAuto) {
+		h.publishUserRegistered(c, messaging.EventUserUpdated, *user, rule)
+	}
+	if joinRequest != nil {
+		h.publishJoinRequested(c, *user, domain, joinRequest)
+	}
+}
+
+// publishJoinRequested tells the owner of the domain's organization that a user asks to join it
+func (h *AuthHandler) publishJoinRequested(c *gin.Context, user models.User, domain *models.OrganizationDomain, request *models.OrganizationJoinRequest) {
+	var organization models.Organization
+	if err := h.db.Select("id", "name", "owner_id").First(&organization, "id = ?", domain.OrganizationID).Error; err != nil {
+		log.Printf("⚠️  Join request %s: organization %s not found: %v", request.ID, domain.OrganizationID, err)
+		return
+	}
+
+	messaging.Publish(c.Request.Context(), messaging.EventOrganizationJoinRequested, &user.ID, messaging.ActivityData{
+		OwnerID:      organization.OwnerID,
+		ActionType:   "Request to join",
+		ResourceType: "organization",
+		ResourceID:   organization.ID,
+		ResourceName: organization.Name,
+		Description: fmt.Sprintf("%s %s (%s) verified an email address of %s and asks to join %s.",
+			user.FirstName, user.LastName, user.Email, domain.Domain, organization.Name),
+		Priority: "normal",
+		Data: map[string]interface{}{
+			"join_request_id": request.ID,
+			"user_id":         user.ID,
+			"domain":          domain.Domain,
+		},
+	})
+}
+
+// publishUserRegistered announces a self-registered user to core-service webhooks and the event
+// bus, e.g. so permission-service drops the permissions it cached before a rule assigned a role
+func (h *AuthHandler) publishUserRegistered(c *gin.Context, event string, user models.User, rule *models.RegistrationRule) {
+	payload := RegisteredUser{
+		ID:             user.ID,
+		Email:          user.Email,
+		FirstName:      user.FirstName,
+		LastName:       user.LastName,
+		Status:         user.Status,
+		OrganizationID: user.OrganizationID,
+		RoleID:         user.RoleID,
+	}
+	if rule != nil {
+		payload.RegistrationRuleID = &rule.ID
+	}
+
+	database.EnqueueWebhookEvent(event, payload)
+	messaging.Publish(c.Request.Context(), event, &user.ID, payload)
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
 	"strings"
 	"time"
@@ -9,8 +10,10 @@ import (
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 
+	"forgecrud-backend/shared/apperrors"
 	"forgecrud-backend/shared/database/models"
 	"forgecrud-backend/shared/database/models/auth"
+	"forgecrud-backend/shared/revocation"
 	"forgecrud-backend/shared/utils/query"
 )
 
@@ -80,7 +83,7 @@ type PaginationResponse struct {
 func (h *AuthHandler) ListSessions(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		apperrors.Respond(c, apperrors.Unauthorized("User not authenticated"))
 		return
 	}
 
@@ -99,8 +102,6 @@ func (h *AuthHandler) ListSessions(c *gin.Context) {
 		"last_used_at": "updated_at",
 	}
 
-	currentTokenHash, _ := c.Get("tokenHash")
-
 	// Build base query - always filter by user and active status
 	dbQuery := h.db.Model(&auth.UserSession{}).Where("user_id = ? AND is_active = ?", userID, true)
 
@@ -113,7 +114,7 @@ func (h *AuthHandler) ListSessions(c *gin.Context) {
 	// Get total count
 	var total int64
 	if err := dbQuery.Count(&total).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count sessions"})
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to count sessions"))
 		return
 	}
 
@@ -123,7 +124,7 @@ func (h *AuthHandler) ListSessions(c *gin.Context) {
 	// Get sessions
 	var sessions []auth.UserSession
 	if err := dbQuery.Find(&sessions).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve sessions"})
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to retrieve sessions"))
 		return
 	}
 
@@ -131,10 +132,7 @@ func (h *AuthHandler) ListSessions(c *gin.Context) {
 	for _, session := range sessions {
 		deviceInfo := parseUserAgent(session.UserAgent)
 
-		isCurrentSession := false
-		if currentTokenHash != nil && session.TokenHash == currentTokenHash.(string) {
-			isCurrentSession = true
-		}
+		isCurrentSession := isCurrentSession(c, session)
 
 		response = append(response, SessionResponse{
 			ID:               session.ID,
@@ -158,9 +156,55 @@ func (h *AuthHandler) ListSessions(c *gin.Context) {
 	})
 }
 
+// isCurrentSession reports whether the session is the one of the request's access token. Tokens
+// issued before sessions were carried in them are matched by their hash.
+func isCurrentSession(c *gin.Context, session auth.UserSession) bool {
+	if sessionID := c.GetString("sessionID"); sessionID != "" {
+		return session.SessionID == sessionID
+	}
+	currentTokenHash := c.GetString("tokenHash")
+	return currentTokenHash != "" && session.TokenHash == currentTokenHash
+}
+
+// exceptCurrentSession scopes a session query to the sessions other than the request's
+func exceptCurrentSession(c *gin.Context) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if sessionID := c.GetString("sessionID"); sessionID != "" {
+			return db.Where("session_id != ?", sessionID)
+		}
+		return db.Where("token_hash != ?", c.GetString("tokenHash"))
+	}
+}
+
+// terminateSessions deactivates the user's active sessions in the scope and announces their
+// revocation, so the gateway rejects their access tokens and the notification service closes their
+// connections right away
+func (h *AuthHandler) terminateSessions(c *gin.Context, userID interface{}, scope func(db *gorm.DB) *gorm.DB) error {
+	var sessionIDs []string
+	if err := scope(h.db.Model(&auth.UserSession{}).Where("user_id = ? AND is_active = ?", userID, true)).
+		Pluck("session_id", &sessionIDs).Error; err != nil {
+		return err
+	}
+	if len(sessionIDs) == 0 {
+		return nil
+	}
+
+	if err := h.db.Model(&auth.UserSession{}).
+		Where("user_id = ? AND session_id IN ?", userID, sessionIDs).
+		Update("is_active", false).Error; err != nil {
+		return err
+	}
+
+	revocation.Publish(c.Request.Context(), revocation.Revocation{
+		UserID:     fmt.Sprint(userID),
+		SessionIDs: sessionIDs,
+	})
+	return nil
+}
+
 // TerminateSession terminates a specific session
 // @Summary Terminate session
-// @Description Terminate a specific user session by ID
+// @Description Terminate a specific user session by ID. Its access tokens stop working and its real-time connections close right away.
 // @Tags sessions
 // @Accept json
 // @Produce json
@@ -175,39 +219,37 @@ func (h *AuthHandler) ListSessions(c *gin.Context) {
 func (h *AuthHandler) TerminateSession(c *gin.Context) {
 	sessionID := c.Param("id")
 	if sessionID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Session ID is required"})
+		apperrors.Respond(c, apperrors.BadRequest("Session ID is required"))
 		return
 	}
 
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		apperrors.Respond(c, apperrors.Unauthorized("User not authenticated"))
 		return
 	}
 
 	sessionUUID, err := uuid.Parse(sessionID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID format"})
+		apperrors.Respond(c, apperrors.BadRequest("Invalid session ID format"))
 		return
 	}
 
-	currentTokenHash, _ := c.Get("tokenHash")
-
 	var session auth.UserSession
 	if err := h.db.Where("id = ? AND user_id = ?", sessionUUID, userID).First(&session).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found or does not belong to the user"})
+		apperrors.Respond(c, apperrors.NotFound("Session not found or does not belong to the user"))
 		return
 	}
 
-	if currentTokenHash != nil && session.TokenHash == currentTokenHash.(string) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot terminate the current session"})
+	if isCurrentSession(c, session) {
+		apperrors.Respond(c, apperrors.BadRequest("Cannot terminate the current session"))
 		return
 	}
 
-	if err := h.db.Model(&auth.UserSession{}).
-		Where("id = ? AND user_id = ?", sessionUUID, userID).
-		Update("is_active", false).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to terminate session"})
+	if err := h.terminateSessions(c, userID, func(db *gorm.DB) *gorm.DB {
+		return db.Where("id = ?", sessionUUID)
+	}); err != nil {
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to terminate session"))
 		return
 	}
 
@@ -228,16 +270,12 @@ func (h *AuthHandler) TerminateSession(c *gin.Context) {
 func (h *AuthHandler) TerminateAllSessions(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		apperrors.Respond(c, apperrors.Unauthorized("User not authenticated"))
 		return
 	}
 
-	currentTokenHash, _ := c.Get("tokenHash")
-
-	if err := h.db.Model(&auth.UserSession{}).
-		Where("user_id = ? AND token_hash != ? AND is_active = ?", userID, currentTokenHash, true).
-		Update("is_active", false).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to terminate sessions"})
+	if err := h.terminateSessions(c, userID, exceptCurrentSession(c)); err != nil {
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to terminate sessions"))
 		return
 	}
 
@@ -265,7 +303,7 @@ func (h *AuthHandler) TerminateAllSessions(c *gin.Context) {
 func (h *AuthHandler) GetLoginHistory(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		apperrors.Respond(c, apperrors.Unauthorized("User not authenticated"))
 		return
 	}
 
@@ -288,7 +326,7 @@ func (h *AuthHandler) GetLoginHistory(c *gin.Context) {
 	// Get user email for filtering login attempts
 	userEmail := getUserEmail(h.db, userID.(uuid.UUID))
 	if userEmail == "" {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user email"})
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to get user email"))
 		return
 	}
 
@@ -323,7 +361,7 @@ func (h *AuthHandler) GetLoginHistory(c *gin.Context) {
 	// Get total count
 	var total int64
 	if err := dbQuery.Count(&total).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count login history"})
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to count login history"))
 		return
 	}
 
@@ -333,7 +371,7 @@ func (h *AuthHandler) GetLoginHistory(c *gin.Context) {
 	// Get login attempts
 	var loginAttempts []auth.LoginAttempt
 	if err := dbQuery.Find(&loginAttempts).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve login history"})
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to retrieve login history"))
 		return
 	}
 
@@ -1,37 +1,38 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
-	"strconv"
 	"strings"
 	"time"
 
+	_ "forgecrud-backend/auth-service/docs"
 	"forgecrud-backend/auth-service/handlers"
 	"forgecrud-backend/auth-service/middleware"
+	"forgecrud-backend/shared/clientip"
 	"forgecrud-backend/shared/config"
 	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/health"
+	"forgecrud-backend/shared/messaging"
+	"forgecrud-backend/shared/readonly"
+	"forgecrud-backend/shared/revocation"
+	"forgecrud-backend/shared/scheduler"
+	"forgecrud-backend/shared/server"
+	utils "forgecrud-backend/shared/utils/auth"
 
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
-// getIntConfig is a helper function to get integer configuration values
-func getIntConfig(key string, defaultValue int) int {
-	strValue := config.GetConfig().GetField(key)
-	if strValue == "" {
-		return defaultValue
-	}
-
-	intValue, err := strconv.Atoi(strValue)
-	if err != nil {
-		log.Printf("Warning: Could not convert %s value '%s' to int, using default %d", key, strValue, defaultValue)
-		return defaultValue
-	}
-
-	return intValue
-}
+// @title Auth Service API
+// @version 1.0
+// @description Registration, login, email verification, sessions and app passwords
+// @BasePath /api
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
 
 func main() {
 	// Load configuration
@@ -41,7 +42,40 @@ func main() {
 	if err := database.InitDatabase(); err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
-	defer database.CloseDatabase()
+	server.OnShutdown("database", database.CloseDatabase)
+	health.AddCheck("database", health.CheckDatabase)
+
+	// Publish security events users are alerted about
+	if err := messaging.Init("auth-service"); err != nil {
+		log.Printf("⚠️  Warning: Event bus not available: %v", err)
+	} else {
+		server.OnShutdown("event bus", messaging.Close)
+		health.AddOptionalCheck("event bus", health.Ping(messaging.Ping))
+	}
+
+	// Announce terminated sessions to the gateway and the notification service
+	if err := revocation.Init(); err != nil {
+		log.Printf("⚠️  Warning: Session revocations not available: %v", err)
+	} else {
+		server.OnShutdown("session revocations", revocation.Close)
+		health.AddOptionalCheck("session revocations", health.Ping(revocation.Ping))
+	}
+
+	// Pause the scheduled jobs while the service is read-only
+	if err := readonly.Init("auth-service"); err != nil {
+		log.Printf("⚠️  Warning: Read-only switch not available: %v", err)
+	} else {
+		server.OnShutdown("read-only switch", readonly.Close)
+		health.AddOptionalCheck("read-only switch", health.Ping(readonly.Ping))
+	}
+
+	// Remove expired verification, password reset and blacklisted tokens on one instance
+	if err := scheduler.Init("auth-service"); err != nil {
+		log.Printf("⚠️  Warning: Scheduler lock not available, every instance runs the schedule: %v", err)
+	}
+	scheduler.Register("auth.token_cleanup", "@hourly", cleanupExpiredTokens)
+	scheduler.Start()
+	server.OnShutdown("scheduler", scheduler.Close)
 
 	// Initialize handlers
 	authHandler := handlers.NewAuthHandler(database.GetDB())
@@ -50,32 +84,17 @@ func main() {
 	rateLimiterCleanupTime := 30 * time.Minute
 	rateLimiter := middleware.NewRateLimiter(rateLimiterCleanupTime)
 
-	// Rate limiting configs
-	generalConfig := middleware.RateLimitConfig{
-		MaxRequests:   getIntConfig("RateLimitMaxRequests", 100),
-		TimeWindow:    time.Duration(getIntConfig("RateLimitTimeWindowSeconds", 60)) * time.Second,
-		BlockDuration: time.Duration(getIntConfig("RateLimitBlockDurationMinutes", 15)) * time.Minute,
-	}
-
-	loginConfig := middleware.RateLimitConfig{
-		MaxRequests:   getIntConfig("LoginRateLimitMaxAttempts", 5),
-		TimeWindow:    time.Duration(getIntConfig("LoginRateLimitWindowSeconds", 300)) * time.Second,
-		BlockDuration: time.Duration(getIntConfig("LoginRateLimitBlockMinutes", 30)) * time.Minute,
-	}
+	// Rate limiting configs, read on every request so configuration reloads apply without a restart
+	generalConfig := middleware.ConfiguredLimit(func(l config.RateLimits) config.RateLimit { return l.Global })
+	loginConfig := middleware.ConfiguredLimit(func(l config.RateLimits) config.RateLimit { return l.Login })
+	registerConfig := middleware.ConfiguredLimit(func(l config.RateLimits) config.RateLimit { return l.Register })
+	passwordResetConfig := middleware.ConfiguredLimit(func(l config.RateLimits) config.RateLimit { return l.PasswordReset })
 
-	registerConfig := middleware.RateLimitConfig{
-		MaxRequests:   getIntConfig("RegisterRateLimitMaxAttempts", 3),
-		TimeWindow:    time.Duration(getIntConfig("RegisterRateLimitWindowHours", 24)) * time.Hour,
-		BlockDuration: time.Duration(getIntConfig("RegisterRateLimitBlockHours", 48)) * time.Hour,
-	}
+	router := gin.New()
+	router.Use(server.Logger(), gin.Recovery())
 
-	passwordResetConfig := middleware.RateLimitConfig{
-		MaxRequests:   getIntConfig("PasswordResetMaxAttempts", 3),
-		TimeWindow:    time.Duration(getIntConfig("PasswordResetWindowMinutes", 60)) * time.Minute,
-		BlockDuration: time.Duration(getIntConfig("PasswordResetBlockHours", 24)) * time.Hour,
-	}
-
-	router := gin.Default()
+	// Client IPs forwarded by the gateway
+	clientip.ConfigureService(router)
 
 	// Auth endpoints
 	router.POST("/api/auth/login", rateLimiter.LoginRateLimitMiddleware(loginConfig), authHandler.Login)
@@ -101,6 +120,11 @@ func main() {
 	router.POST("/api/auth/sessions/terminate-all", middleware.AuthMiddleware(), authHandler.TerminateAllSessions)
 	router.GET("/api/auth/login-history", middleware.AuthMiddleware(), authHandler.GetLoginHistory)
 
+	// App passwords for clients that cannot sign in with a token, such as WebDAV
+	router.GET("/api/auth/app-passwords", middleware.AuthMiddleware(), authHandler.ListAppPasswords)
+	router.POST("/api/auth/app-passwords", middleware.AuthMiddleware(), authHandler.CreateAppPassword)
+	router.DELETE("/api/auth/app-passwords/:id", middleware.AuthMiddleware(), authHandler.RevokeAppPassword)
+
 	// Test endpoint
 	router.GET("/api/auth/test", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{
@@ -111,18 +135,26 @@ func main() {
 		})
 	})
 
-	// Health check
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{
-			"status":  "healthy",
-			"service": "auth",
-		})
-	})
+	// Liveness and readiness probes, and metrics
+	health.Register(router, "auth")
 
-	// Swagger documentation
+	// Swagger documentation, merged by the gateway into its OpenAPI document
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	port := strings.Split(config.GetConfig().AuthServiceURL, ":")[2]
 	log.Printf("Auth Service starting on port %s...", port)
-	router.Run(":" + port)
+	server.Run(router, ":"+port)
+}
+
+// cleanupExpiredTokens removes the expired tokens of the auth tables
+func cleanupExpiredTokens(ctx context.Context) error {
+	removed, err := utils.CleanupExpiredAuthTokens(database.GetDB().WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	if total := removed["email_verification_tokens"] + removed["password_reset_tokens"] + removed["blacklisted_tokens"]; total > 0 {
+		log.Printf("🧹 Removed expired tokens: %d email verification, %d password reset, %d blacklisted",
+			removed["email_verification_tokens"], removed["password_reset_tokens"], removed["blacklisted_tokens"])
+	}
+	return nil
 }
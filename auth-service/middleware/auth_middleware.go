@@ -7,48 +7,70 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/database"
 	"forgecrud-backend/shared/utils/auth"
 )
 
+// passwordResetPaths are the endpoints users who must reset their password can still reach
+var passwordResetPaths = map[string]bool{
+	"/api/auth/change-password": true,
+	"/api/auth/logout":          true,
+}
+
 // AuthMiddleware extracts user information from JWT token and sets it in context
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(401, gin.H{"error": "Authorization header is required"})
+			apperrors.Respond(c, apperrors.Unauthorized("Authorization header is required"))
 			c.Abort()
 			return
 		}
 
 		tokenParts := strings.Split(authHeader, " ")
 		if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
-			c.JSON(401, gin.H{"error": "Invalid authorization format. Expected Bearer {token}"})
+			apperrors.Respond(c, apperrors.Unauthorized("Invalid authorization format. Expected Bearer {token}"))
 			c.Abort()
 			return
 		}
 
 		tokenString := tokenParts[1]
 
-		if len(tokenString) >= 32 {
-			c.Set("tokenHash", tokenString[:32])
-		}
+		c.Set("tokenHash", utils.HashToken(tokenString))
 
 		claims, err := utils.ValidateJWT(tokenString)
 		if err != nil {
-			c.JSON(401, gin.H{"error": "Invalid or expired token"})
+			apperrors.Respond(c, apperrors.Unauthorized("Invalid or expired token"))
 			c.Abort()
 			return
 		}
 
 		userID, err := uuid.Parse(claims.UserID)
 		if err != nil {
-			c.JSON(401, gin.H{"error": "Invalid user ID in token"})
+			apperrors.Respond(c, apperrors.Unauthorized("Invalid user ID in token"))
+			c.Abort()
+			return
+		}
+
+		// Tokens of terminated sessions are rejected even before they expire
+		if claims.SessionID != "" {
+			if active, err := database.IsSessionActive(database.GetDB(), claims.UserID, claims.SessionID); err == nil && !active {
+				apperrors.Respond(c, apperrors.Unauthorized("Session has been terminated"))
+				c.Abort()
+				return
+			}
+		}
+
+		if claims.MustResetPassword && !passwordResetPaths[c.FullPath()] {
+			apperrors.Respond(c, apperrors.Forbidden("Password must be changed first").With("code", "PASSWORD_RESET_REQUIRED"))
 			c.Abort()
 			return
 		}
 
 		c.Set("userID", userID)
 		c.Set("userEmail", claims.Email)
+		c.Set("sessionID", claims.SessionID)
 
 		c.Next()
 	}
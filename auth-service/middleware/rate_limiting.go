@@ -1,10 +1,12 @@
 package middleware
 
 import (
-	"net/http"
 	"sync"
 	"time"
 
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/config"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -31,6 +33,19 @@ type RateLimitConfig struct {
 	BlockDuration time.Duration
 }
 
+// ConfiguredLimit - Reads a rate limit from the current configuration on every request, so a
+// configuration reload applies without a restart
+func ConfiguredLimit(pick func(config.RateLimits) config.RateLimit) func() RateLimitConfig {
+	return func() RateLimitConfig {
+		limit := pick(config.GetConfig().RateLimits)
+		return RateLimitConfig{
+			MaxRequests:   limit.MaxRequests,
+			TimeWindow:    limit.Window,
+			BlockDuration: limit.Block,
+		}
+	}
+}
+
 // NewRateLimiter - Creates a new RateLimiter instance
 func NewRateLimiter(cleanupTime time.Duration) *RateLimiter {
 	limiter := &RateLimiter{
@@ -109,16 +124,14 @@ func (rl *RateLimiter) isAllowed(key string, config RateLimitConfig) bool {
 }
 
 // RateLimitMiddleware - General rate limiting middleware
-func (rl *RateLimiter) RateLimitMiddleware(config RateLimitConfig) gin.HandlerFunc {
+func (rl *RateLimiter) RateLimitMiddleware(limit func() RateLimitConfig) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		config := limit()
 		clientIP := c.ClientIP()
 		key := clientIP
 
 		if !rl.isAllowed(key, config) {
-			c.JSON(http.StatusTooManyRequests, gin.H{
-				"error":   "Too many requests",
-				"message": "Rate limit exceeded. Please try again later.",
-			})
+			apperrors.Respond(c, apperrors.New(apperrors.CodeRateLimited, "Too many requests").WithDetails("Rate limit exceeded. Please try again later."))
 			c.Abort()
 			return
 		}
@@ -128,17 +141,15 @@ func (rl *RateLimiter) RateLimitMiddleware(config RateLimitConfig) gin.HandlerFu
 }
 
 // LoginRateLimitMiddleware - Loing endpoint rate limiting middleware
-func (rl *RateLimiter) LoginRateLimitMiddleware(config RateLimitConfig) gin.HandlerFunc {
+func (rl *RateLimiter) LoginRateLimitMiddleware(limit func() RateLimitConfig) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		config := limit()
 		// IP adresini al
 		clientIP := c.ClientIP()
 		key := "login:" + clientIP
 
 		if !rl.isAllowed(key, config) {
-			c.JSON(http.StatusTooManyRequests, gin.H{
-				"error":   "Too many login attempts",
-				"message": "Too many login attempts. Please try again later.",
-			})
+			apperrors.Respond(c, apperrors.New(apperrors.CodeRateLimited, "Too many login attempts").WithDetails("Too many login attempts. Please try again later."))
 			c.Abort()
 			return
 		}
@@ -148,16 +159,14 @@ func (rl *RateLimiter) LoginRateLimitMiddleware(config RateLimitConfig) gin.Hand
 }
 
 // RegistrationRateLimitMiddleware - Registration endpoint rate limiting middleware
-func (rl *RateLimiter) RegistrationRateLimitMiddleware(config RateLimitConfig) gin.HandlerFunc {
+func (rl *RateLimiter) RegistrationRateLimitMiddleware(limit func() RateLimitConfig) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		config := limit()
 		clientIP := c.ClientIP()
 		key := "register:" + clientIP
 
 		if !rl.isAllowed(key, config) {
-			c.JSON(http.StatusTooManyRequests, gin.H{
-				"error":   "Too many registration attempts",
-				"message": "Too many registration attempts. Please try again later.",
-			})
+			apperrors.Respond(c, apperrors.New(apperrors.CodeRateLimited, "Too many registration attempts").WithDetails("Too many registration attempts. Please try again later."))
 			c.Abort()
 			return
 		}
@@ -167,16 +176,14 @@ func (rl *RateLimiter) RegistrationRateLimitMiddleware(config RateLimitConfig) g
 }
 
 // PasswordResetRateLimitMiddleware - Password reset endpoint rate limiting middleware
-func (rl *RateLimiter) PasswordResetRateLimitMiddleware(config RateLimitConfig) gin.HandlerFunc {
+func (rl *RateLimiter) PasswordResetRateLimitMiddleware(limit func() RateLimitConfig) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		config := limit()
 		clientIP := c.ClientIP()
 		key := "password-reset:" + clientIP
 
 		if !rl.isAllowed(key, config) {
-			c.JSON(http.StatusTooManyRequests, gin.H{
-				"error":   "Too many password reset attempts",
-				"message": "Too many password reset attempts. Please try again later.",
-			})
+			apperrors.Respond(c, apperrors.New(apperrors.CodeRateLimited, "Too many password reset attempts").WithDetails("Too many password reset attempts. Please try again later."))
 			c.Abort()
 			return
 		}
@@ -0,0 +1,206 @@
+// Command anonymize scrubs the personal data of a database restored from a production snapshot, so it
+// can be loaded into a development or staging environment. Emails, names, phone numbers and IP
+// addresses are replaced by pseudonyms derived from a salted hash of the original value: the same
+// address becomes the same pseudonym in every table, so rows that refer to each other by email or
+// phone still match, and IDs are never changed. It refuses to run against APP_ENV=prod.
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+
+	"forgecrud-backend/shared/config"
+	"forgecrud-backend/shared/database"
+	utils "forgecrud-backend/shared/utils/auth"
+	"forgecrud-backend/shared/utils/redact"
+
+	"gorm.io/gorm"
+)
+
+// functions are the pseudonym functions, created for the transaction only. The salt and the kept
+// addresses are read from the temporary tables created before them.
+var functions = []string{
+	`CREATE FUNCTION pg_temp.anon_hash(value text) RETURNS text LANGUAGE sql STABLE AS $$
+		SELECT md5((SELECT salt FROM pg_temp.anon_settings) || value)
+	$$`,
+	`CREATE FUNCTION pg_temp.anon_kept(email text) RETURNS boolean LANGUAGE sql STABLE AS $$
+		SELECT EXISTS (SELECT 1 FROM pg_temp.anon_keep k WHERE k.email = lower(trim(anon_kept.email)))
+	$$`,
+	`CREATE FUNCTION pg_temp.anon_email(email text) RETURNS text LANGUAGE sql STABLE AS $$
+		SELECT CASE
+			WHEN email IS NULL OR trim(email) = '' OR pg_temp.anon_kept(email) THEN email
+			ELSE 'user-' || substr(pg_temp.anon_hash(lower(trim(email))), 1, 16) || '@example.invalid'
+		END
+	$$`,
+	`CREATE FUNCTION pg_temp.anon_emails(emails text) RETURNS text LANGUAGE sql STABLE AS $$
+		SELECT CASE
+			WHEN emails IS NULL OR emails = '' THEN emails
+			ELSE array_to_string(ARRAY(
+				SELECT pg_temp.anon_email(trim(address)) FROM unnest(string_to_array(emails, ',')) AS address
+			), ',')
+		END
+	$$`,
+	`CREATE FUNCTION pg_temp.anon_phone(phone text) RETURNS text LANGUAGE sql STABLE AS $$
+		SELECT CASE
+			WHEN phone IS NULL OR phone = '' THEN phone
+			ELSE '+1555' || lpad(((('x' || substr(pg_temp.anon_hash(regexp_replace(phone, '\D', '', 'g')), 1, 7))::bit(28)::int) % 10000000)::text, 7, '0')
+		END
+	$$`,
+	`CREATE FUNCTION pg_temp.anon_ip(ip text) RETURNS text LANGUAGE sql STABLE AS $$
+		SELECT CASE
+			WHEN ip IS NULL OR ip = '' THEN ip
+			ELSE (SELECT '10.' || get_byte(d, 0) || '.' || get_byte(d, 1) || '.' || get_byte(d, 2)
+				FROM (SELECT decode(pg_temp.anon_hash(ip), 'hex') AS d) AS digest)
+		END
+	$$`,
+}
+
+// step is one scrubbing statement
+type step struct {
+	name string
+	sql  string
+}
+
+// steps scrub the personal data, table by table. Users are updated first, so the revisions step can
+// still tell kept users apart by their original email.
+func steps() []step {
+	keptUsers := "SELECT id FROM users WHERE pg_temp.anon_kept(email)"
+	scrubbed := []step{
+		{"users", `UPDATE users SET
+			email = pg_temp.anon_email(email),
+			first_name = 'User',
+			last_name = upper(substr(pg_temp.anon_hash(id::text), 1, 6)),
+			phone = pg_temp.anon_phone(phone),
+			avatar = ''
+			WHERE NOT pg_temp.anon_kept(email)`},
+		{"login attempts", `UPDATE login_attempts SET email = pg_temp.anon_email(email), ip_address = pg_temp.anon_ip(ip_address), location = ''`},
+		{"password reset attempts", `UPDATE password_reset_attempts SET email = pg_temp.anon_email(email), ip_address = pg_temp.anon_ip(ip_address)`},
+		{"password reset tokens", `UPDATE password_reset_tokens SET ip_address = pg_temp.anon_ip(ip_address)`},
+		{"email verification tokens", `UPDATE email_verification_tokens SET email = pg_temp.anon_email(email), ip_address = pg_temp.anon_ip(ip_address)`},
+		{"sessions", `UPDATE user_sessions SET ip_address = pg_temp.anon_ip(ip_address)`},
+		{"audit logs", `UPDATE audit_logs SET ip_address = pg_temp.anon_ip(ip_address), request_body = NULL, response_body = NULL`},
+		{"document activity", `UPDATE document_activities SET ip_address = pg_temp.anon_ip(ip_address)`},
+		{"email messages", `UPDATE email_messages SET "to" = pg_temp.anon_emails("to"), cc = pg_temp.anon_emails(cc), bcc = pg_temp.anon_emails(bcc), body = '', text_body = ''`},
+		{"email dead letters", `UPDATE email_dead_letters SET "to" = pg_temp.anon_emails("to")`},
+		{"email events", `UPDATE email_events SET recipient = pg_temp.anon_email(recipient), ip_address = pg_temp.anon_ip(ip_address)`},
+		{"email suppressions", `UPDATE email_suppressions SET email = pg_temp.anon_email(email)`},
+		{"scheduled emails", `UPDATE scheduled_notifications SET email_to = pg_temp.anon_emails(email_to)`},
+		{"SMS log", `UPDATE sms_messages SET phone = pg_temp.anon_phone(phone)`},
+		{"phone verifications", `UPDATE phone_verifications SET phone = pg_temp.anon_phone(phone)`},
+		// Push tokens reach real devices, so they are disabled rather than pseudonymized
+		{"push devices", `UPDATE device_tokens SET token = 'anonymized-' || id::text, device_name = '', disabled_at = COALESCE(disabled_at, now())`},
+		// Deliveries keep the payload sent, which holds the users and documents of the event
+		{"webhook deliveries", `UPDATE webhook_deliveries SET payload = '{}'`},
+		{"integration deliveries", `UPDATE integration_deliveries SET payload = '{}'`},
+	}
+
+	// Revisions of users hold the old and new values of the changed fields
+	for _, column := range []string{"old_values", "new_values"} {
+		for _, field := range [][2]string{
+			{"email", fmt.Sprintf("pg_temp.anon_email(%s->>'email')", column)},
+			{"phone", fmt.Sprintf("pg_temp.anon_phone(%s->>'phone')", column)},
+			{"first_name", fmt.Sprintf("'%s'", redact.Mask)},
+			{"last_name", fmt.Sprintf("'%s'", redact.Mask)},
+		} {
+			scrubbed = append(scrubbed, step{
+				name: fmt.Sprintf("user revisions (%s.%s)", column, field[0]),
+				sql: fmt.Sprintf(`UPDATE revisions SET %[1]s = jsonb_set(%[1]s, '{%[2]s}', to_jsonb(%[3]s))
+					WHERE entity_type = 'user' AND %[1]s ? '%[2]s' AND jsonb_typeof(%[1]s->'%[2]s') = 'string'
+					AND entity_id NOT IN (%[4]s)`, column, field[0], field[1], keptUsers),
+			})
+		}
+	}
+	return scrubbed
+}
+
+func main() {
+	salt := flag.String("salt", "", "Salt of the pseudonyms; random when empty. Reuse it to get the same pseudonyms for another snapshot")
+	keep := flag.String("keep", "", "Comma separated emails of users to leave untouched, like the team's own accounts")
+	password := flag.String("password", "", "Set the password of every anonymized user, so testers can sign in as them")
+	yes := flag.Bool("yes", false, "Confirm that the configured database should be anonymized")
+	flag.Parse()
+
+	log.Println("🕶️  Starting database anonymization...")
+
+	config.LoadConfig()
+	cfg := config.GetConfig()
+	if cfg.Environment == config.EnvProd {
+		log.Fatalf("❌ Refusing to anonymize the database of the %s environment", cfg.Environment)
+	}
+	if !*yes {
+		log.Fatalf("❌ This rewrites the personal data of %s on %s:%s (%s environment); rerun with -yes to confirm",
+			cfg.DBName, cfg.DBHost, cfg.DBPort, cfg.Environment)
+	}
+
+	if *salt == "" {
+		random := make([]byte, 16)
+		if _, err := rand.Read(random); err != nil {
+			log.Fatalf("Failed to generate a salt: %v", err)
+		}
+		*salt = hex.EncodeToString(random)
+	}
+
+	var hashedPassword string
+	if *password != "" {
+		var err error
+		if hashedPassword, err = utils.HashPassword(*password); err != nil {
+			log.Fatalf("Failed to hash the password: %v", err)
+		}
+	}
+
+	if err := database.InitDatabase(); err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer database.CloseDatabase()
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		setup := []string{
+			"CREATE TEMP TABLE anon_settings (salt text NOT NULL) ON COMMIT DROP",
+			"CREATE TEMP TABLE anon_keep (email text PRIMARY KEY) ON COMMIT DROP",
+		}
+		for _, statement := range append(setup, functions...) {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Exec("INSERT INTO pg_temp.anon_settings (salt) VALUES (?)", *salt).Error; err != nil {
+			return err
+		}
+		for _, email := range strings.Split(*keep, ",") {
+			email = strings.ToLower(strings.TrimSpace(email))
+			if email == "" {
+				continue
+			}
+			if err := tx.Exec("INSERT INTO pg_temp.anon_keep (email) VALUES (?) ON CONFLICT DO NOTHING", email).Error; err != nil {
+				return err
+			}
+		}
+
+		// Passwords are set before the emails are replaced, while kept users can still be recognized
+		if hashedPassword != "" {
+			result := tx.Exec("UPDATE users SET password = ?, must_reset_password = false WHERE NOT pg_temp.anon_kept(email)", hashedPassword)
+			if result.Error != nil {
+				return fmt.Errorf("passwords: %w", result.Error)
+			}
+			log.Printf("🔑 Set the password of %d users", result.RowsAffected)
+		}
+
+		for _, s := range steps() {
+			result := tx.Exec(s.sql)
+			if result.Error != nil {
+				return fmt.Errorf("%s: %w", s.name, result.Error)
+			}
+			log.Printf("✅ %s: %d rows", s.name, result.RowsAffected)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Fatalf("❌ Anonymization failed, nothing was changed: %v", err)
+	}
+
+	log.Printf("✅ Database anonymized (salt: %s)", *salt)
+}
@@ -0,0 +1,86 @@
+// Command backup backs up the database and the document storage to BACKUP_PATH, like the scheduled
+// backups of the document service, then removes the backups past their retention. With -list it
+// prints the backups instead.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"forgecrud-backend/document-service/services"
+	"forgecrud-backend/shared/config"
+	"forgecrud-backend/shared/database"
+)
+
+func main() {
+	list := flag.Bool("list", false, "List the backups instead of creating one")
+	prune := flag.Bool("prune", true, "Remove the backups past BACKUP_RETENTION_DAYS after the backup")
+	flag.Parse()
+
+	config.LoadConfig()
+
+	if *list {
+		manager, err := services.NewBackupManager(nil)
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		printBackups(manager)
+		return
+	}
+
+	log.Println("💾 Starting backup...")
+
+	if err := database.InitDatabase(); err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer database.CloseDatabase()
+
+	storage, err := services.NewStorageProvider()
+	if err != nil {
+		log.Fatalf("Failed to initialize storage: %v", err)
+	}
+	defer storage.Close()
+
+	manager, err := services.NewBackupManager(storage)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+
+	ctx := context.Background()
+	manifest, err := manager.Create(ctx)
+	if err != nil {
+		log.Fatalf("❌ Backup failed: %v", err)
+	}
+	log.Printf("✅ Backup %s completed (snapshot %s): %d objects, %d bytes, %d copied",
+		manifest.ID, manifest.SnapshotAt.Format(time.RFC3339), manifest.ObjectCount, manifest.ObjectBytes, manifest.CopiedObjects)
+	for _, key := range manifest.MissingObjects {
+		log.Printf("⚠️  %s was deleted before it could be copied", key)
+	}
+
+	if *prune {
+		removed, err := manager.Prune(ctx)
+		if err != nil {
+			log.Fatalf("❌ Failed to remove old backups: %v", err)
+		}
+		log.Printf("✅ Removed %d backups past their retention", removed)
+	}
+}
+
+func printBackups(manager *services.BackupManager) {
+	manifests, err := manager.List()
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSNAPSHOT\tENCRYPTED\tDATABASE BYTES\tOBJECTS\tOBJECT BYTES\tMISSING")
+	for _, manifest := range manifests {
+		fmt.Fprintf(w, "%s\t%s\t%t\t%d\t%d\t%d\t%d\n", manifest.ID, manifest.SnapshotAt.Format(time.RFC3339), manifest.Encrypted,
+			manifest.Database.Size, manifest.ObjectCount, manifest.ObjectBytes, len(manifest.MissingObjects))
+	}
+	w.Flush()
+}
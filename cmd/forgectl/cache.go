@@ -0,0 +1,89 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"forgecrud-backend/shared/utils/cache"
+
+	"github.com/spf13/cobra"
+)
+
+func newCacheCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Inspect and invalidate the permission cache",
+	}
+	cmd.AddCommand(newCacheInvalidateCommand(), newCacheStatsCommand())
+	return cmd
+}
+
+func newCacheInvalidateCommand() *cobra.Command {
+	var userRef string
+	var all bool
+
+	cmd := &cobra.Command{
+		Use:   "invalidate",
+		Short: "Drop cached permission checks",
+		Long:  "Drop the cached permission checks of one user, or of everyone with --all, so they are evaluated again.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if (userRef == "") == !all {
+				return errors.New("one of --user or --all is required")
+			}
+			if err := cache.InitCacheManager(); err != nil {
+				return err
+			}
+			cacheManager := cache.GetCacheManager()
+			defer cacheManager.Close()
+
+			if all {
+				if err := cacheManager.InvalidateAllPermissions(); err != nil {
+					return err
+				}
+				fmt.Println("✅ Invalidated the permission cache of all users")
+				return nil
+			}
+
+			closeAll, err := connect()
+			if err != nil {
+				return err
+			}
+			defer closeAll()
+
+			user, err := findUser(userRef)
+			if err != nil {
+				return err
+			}
+			if err := cacheManager.InvalidateUserPermissions(cache.UserCacheID(user.ID)); err != nil {
+				return err
+			}
+			fmt.Printf("✅ Invalidated the permission cache of %s\n", user.Email)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&userRef, "user", "", "User email or ID")
+	cmd.Flags().BoolVar(&all, "all", false, "Invalidate the cache of all users")
+	return cmd
+}
+
+func newCacheStatsCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "stats",
+		Short: "Show the number of cached permission checks",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := cache.InitCacheManager(); err != nil {
+				return err
+			}
+			cacheManager := cache.GetCacheManager()
+			defer cacheManager.Close()
+
+			stats, err := cacheManager.GetCacheStats()
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Cached permission keys: %v\n", stats["total_permission_keys"])
+			return nil
+		},
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models"
+	utils "forgecrud-backend/shared/utils/auth"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// findUser finds a user by email or ID
+func findUser(ref string) (*models.User, error) {
+	var user models.User
+	query := database.DB.Where("email = ?", ref)
+	if id, err := uuid.Parse(ref); err == nil {
+		query = database.DB.Where("id = ?", id)
+	}
+	if err := query.First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("user %q not found", ref)
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// findOrganization finds an organization by slug or ID
+func findOrganization(ref string) (*models.Organization, error) {
+	var organization models.Organization
+	query := database.DB.Where("slug = ?", ref)
+	if id, err := uuid.Parse(ref); err == nil {
+		query = database.DB.Where("id = ?", id)
+	}
+	if err := query.First(&organization).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("organization %q not found", ref)
+		}
+		return nil, err
+	}
+	return &organization, nil
+}
+
+// findRole finds a role by ID, or by name within the organization (global roles when nil)
+func findRole(ref string, organizationID *uuid.UUID) (*models.Role, error) {
+	var role models.Role
+	query := database.DB.Where("name = ?", ref)
+	if organizationID != nil {
+		query = query.Where("organization_id = ?", *organizationID)
+	} else {
+		query = query.Where("organization_id IS NULL")
+	}
+	if id, err := uuid.Parse(ref); err == nil {
+		query = database.DB.Where("id = ?", id)
+	}
+	if err := query.First(&role).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("role %q not found", ref)
+		}
+		return nil, err
+	}
+	return &role, nil
+}
+
+// findTeam finds a team by ID
+func findTeam(ref string) (*models.Team, error) {
+	id, err := uuid.Parse(ref)
+	if err != nil {
+		return nil, fmt.Errorf("team %q is not an ID", ref)
+	}
+	var team models.Team
+	if err := database.DB.First(&team, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("team %q not found", ref)
+		}
+		return nil, err
+	}
+	return &team, nil
+}
+
+// generatePassword returns a random password meeting the password rules
+func generatePassword() (string, error) {
+	token, err := utils.GenerateRandomToken(9)
+	if err != nil {
+		return "", err
+	}
+	return "Fc#" + token + "a7", nil
+}
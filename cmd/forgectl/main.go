@@ -0,0 +1,63 @@
+// Command forgectl administers a ForgeCRUD environment: it creates users, resets passwords, grants
+// roles and permissions, invalidates the permission cache, runs migrations and inspects sessions. It
+// connects to the database, Redis and the event bus of the environment configured by the .env file
+// and APP_ENV, like the services do, and publishes the same events, so running services pick the
+// changes up.
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"forgecrud-backend/shared/config"
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/messaging"
+	"forgecrud-backend/shared/revocation"
+
+	"github.com/spf13/cobra"
+)
+
+func main() {
+	root := &cobra.Command{
+		Use:           "forgectl",
+		Short:         "Administer a ForgeCRUD environment",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			config.LoadConfig()
+		},
+	}
+
+	root.AddCommand(
+		newUsersCommand(),
+		newRolesCommand(),
+		newPermissionsCommand(),
+		newSessionsCommand(),
+		newCacheCommand(),
+		newMigrateCommand(),
+	)
+
+	if err := root.Execute(); err != nil {
+		fmt.Fprintln(os.Stderr, "❌", err)
+		os.Exit(1)
+	}
+}
+
+// connect opens the database, running pending migrations, and the event bus. The returned function
+// closes both.
+func connect() (func(), error) {
+	if err := database.InitDatabase(); err != nil {
+		return nil, err
+	}
+	if err := messaging.Init("forgectl"); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Event bus not available, running services will not see the change until their caches expire: %v\n", err)
+	}
+	if err := revocation.Init(); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Session revocations not available, ended sessions may keep working at the gateway for SESSION_VALIDATION_CACHE_SECONDS: %v\n", err)
+	}
+	return func() {
+		revocation.Close()
+		messaging.Close()
+		database.CloseDatabase()
+	}, nil
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+
+	"forgecrud-backend/shared/database"
+
+	"github.com/spf13/cobra"
+)
+
+func newMigrateCommand() *cobra.Command {
+	var seed bool
+
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Migrate the database schema",
+		Long: "Migrate the database schema to the current models. With --seed the system resources, actions and " +
+			"roles are seeded too, and the super admin of SUPER_ADMIN_EMAIL is created when missing.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := database.InitDatabase(); err != nil {
+				return err
+			}
+			defer database.CloseDatabase()
+			fmt.Println("✅ Database schema migrated")
+
+			if !seed {
+				return nil
+			}
+			if err := database.SeedDatabase(); err != nil {
+				return err
+			}
+			if err := database.CreateSuperAdminFromConfig(); err != nil {
+				return err
+			}
+			fmt.Println("✅ Database seeded")
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&seed, "seed", false, "Seed system data and the super admin after migrating")
+	return cmd
+}
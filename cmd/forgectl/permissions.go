@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models"
+	"forgecrud-backend/shared/messaging"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+)
+
+func newPermissionsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "permissions",
+		Short: "Grant permissions",
+	}
+	cmd.AddCommand(newPermissionsGrantCommand())
+	return cmd
+}
+
+func newPermissionsGrantCommand() *cobra.Command {
+	var userRef, teamRef, roleRef, organizationRef, resourceSlug string
+	var actionSlugs []string
+
+	cmd := &cobra.Command{
+		Use:   "grant",
+		Short: "Grant actions on a resource to a user, team, role or organization",
+		Long: "Grant actions on a resource to exactly one target. When the target already has a permission on the " +
+			"resource the actions are added to it, otherwise a permission is created.",
+		Example: "  forgectl permissions grant --user jane@example.com --resource documents --actions read,update\n" +
+			"  forgectl permissions grant --role Editor --organization acme --resource documents --actions manage",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			targets := 0
+			for _, ref := range []string{userRef, teamRef, roleRef} {
+				if ref != "" {
+					targets++
+				}
+			}
+			if targets > 1 {
+				return errors.New("grant to one of --user, --team or --role")
+			}
+			if targets == 0 && organizationRef == "" {
+				return errors.New("one of --user, --team, --role or --organization is required")
+			}
+
+			closeAll, err := connect()
+			if err != nil {
+				return err
+			}
+			defer closeAll()
+
+			permission := models.Permission{}
+			targetName := ""
+			switch {
+			case userRef != "":
+				user, err := findUser(userRef)
+				if err != nil {
+					return err
+				}
+				permission.Target, permission.UserID, targetName = models.PermissionTargetUser, &user.ID, user.Email
+			case teamRef != "":
+				team, err := findTeam(teamRef)
+				if err != nil {
+					return err
+				}
+				permission.Target, permission.TeamID, targetName = models.PermissionTargetTeam, &team.ID, team.Name
+			default:
+				var organizationID *uuid.UUID
+				if organizationRef != "" {
+					organization, err := findOrganization(organizationRef)
+					if err != nil {
+						return err
+					}
+					organizationID = &organization.ID
+					permission.Target, permission.OrganizationID, targetName = models.PermissionTargetOrganization, organizationID, organization.Name
+				}
+				if roleRef != "" {
+					role, err := findRole(roleRef, organizationID)
+					if err != nil {
+						return err
+					}
+					permission.Target, permission.RoleID, permission.OrganizationID, targetName = models.PermissionTargetRole, &role.ID, nil, role.Name
+				}
+			}
+
+			var resource models.Resource
+			if err := database.DB.First(&resource, "slug = ?", resourceSlug).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return fmt.Errorf("resource %q not found", resourceSlug)
+				}
+				return err
+			}
+			permission.ResourceID = resource.ID
+
+			var actions []models.Action
+			for _, slug := range actionSlugs {
+				var action models.Action
+				if err := database.DB.First(&action, "slug = ?", strings.TrimSpace(slug)).Error; err != nil {
+					if errors.Is(err, gorm.ErrRecordNotFound) {
+						return fmt.Errorf("action %q not found", slug)
+					}
+					return err
+				}
+				actions = append(actions, action)
+			}
+
+			created := false
+			added := 0
+			err = database.DB.Transaction(func(tx *gorm.DB) error {
+				query := tx.Where("resource_id = ? AND target = ?", permission.ResourceID, permission.Target)
+				switch permission.Target {
+				case models.PermissionTargetUser:
+					query = query.Where("user_id = ?", *permission.UserID)
+				case models.PermissionTargetTeam:
+					query = query.Where("team_id = ?", *permission.TeamID)
+				case models.PermissionTargetRole:
+					query = query.Where("role_id = ?", *permission.RoleID)
+				case models.PermissionTargetOrganization:
+					query = query.Where("organization_id = ?", *permission.OrganizationID)
+				}
+
+				var existing models.Permission
+				err := query.First(&existing).Error
+				switch {
+				case err == nil:
+					permission = existing
+				case errors.Is(err, gorm.ErrRecordNotFound):
+					if err := tx.Create(&permission).Error; err != nil {
+						return err
+					}
+					created = true
+				default:
+					return err
+				}
+
+				for _, action := range actions {
+					var count int64
+					if err := tx.Model(&models.PermissionAction{}).
+						Where("permission_id = ? AND action_id = ?", permission.ID, action.ID).
+						Count(&count).Error; err != nil {
+						return err
+					}
+					if count > 0 {
+						continue
+					}
+					if err := tx.Create(&models.PermissionAction{PermissionID: permission.ID, ActionID: action.ID}).Error; err != nil {
+						return err
+					}
+					added++
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			switch {
+			case created:
+				messaging.Publish(context.Background(), messaging.EventPermissionCreated, nil, permission)
+				fmt.Printf("✅ Granted %s on %s to %s %s\n", strings.Join(actionSlugs, ", "), resource.Slug, strings.ToLower(permission.Target), targetName)
+			case added > 0:
+				messaging.Publish(context.Background(), messaging.EventPermissionUpdated, nil, permission)
+				fmt.Printf("✅ Added %d actions to the permission of %s %s on %s\n", added, strings.ToLower(permission.Target), targetName, resource.Slug)
+			default:
+				fmt.Printf("ℹ️  %s %s already has these actions on %s\n", strings.ToLower(permission.Target), targetName, resource.Slug)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&userRef, "user", "", "User email or ID")
+	cmd.Flags().StringVar(&teamRef, "team", "", "Team ID")
+	cmd.Flags().StringVar(&roleRef, "role", "", "Role name or ID; a name is looked up in --organization, or among global roles")
+	cmd.Flags().StringVar(&organizationRef, "organization", "", "Organization slug or ID; the target unless --role is set")
+	cmd.Flags().StringVar(&resourceSlug, "resource", "", "Resource slug (required)")
+	cmd.Flags().StringSliceVar(&actionSlugs, "actions", nil, "Comma separated action slugs (required)")
+	cmd.MarkFlagRequired("resource")
+	cmd.MarkFlagRequired("actions")
+	return cmd
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models/auth"
+	"forgecrud-backend/shared/revocation"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+)
+
+func newSessionsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sessions",
+		Short: "Inspect and end user sessions",
+	}
+	cmd.AddCommand(newSessionsListCommand(), newSessionsRevokeCommand())
+	return cmd
+}
+
+func newSessionsListCommand() *cobra.Command {
+	var all bool
+
+	cmd := &cobra.Command{
+		Use:   "list <user email or ID>",
+		Short: "List the sessions of a user",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			closeAll, err := connect()
+			if err != nil {
+				return err
+			}
+			defer closeAll()
+
+			user, err := findUser(args[0])
+			if err != nil {
+				return err
+			}
+
+			query := database.DB.Where("user_id = ?", user.ID)
+			if !all {
+				query = query.Where("is_active = ? AND expires_at > ?", true, time.Now())
+			}
+			var sessions []auth.UserSession
+			if err := query.Order("created_at DESC").Find(&sessions).Error; err != nil {
+				return err
+			}
+
+			if len(sessions) == 0 {
+				fmt.Printf("No sessions for %s\n", user.Email)
+				return nil
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tACTIVE\tIP ADDRESS\tDEVICE\tCREATED\tLAST USED\tEXPIRES")
+			for _, session := range sessions {
+				lastUsed := "-"
+				if session.LastUsedAt != nil {
+					lastUsed = session.LastUsedAt.Format(time.RFC3339)
+				}
+				device := session.DeviceInfo
+				if device == "" {
+					device = session.UserAgent
+				}
+				if len(device) > 40 {
+					device = device[:37] + "..."
+				}
+				fmt.Fprintf(w, "%s\t%t\t%s\t%s\t%s\t%s\t%s\n",
+					session.ID, session.IsActive, session.IPAddress, device,
+					session.CreatedAt.Format(time.RFC3339), lastUsed, session.ExpiresAt.Format(time.RFC3339))
+			}
+			return w.Flush()
+		},
+	}
+
+	cmd.Flags().BoolVar(&all, "all", false, "Include ended and expired sessions")
+	return cmd
+}
+
+func newSessionsRevokeCommand() *cobra.Command {
+	var sessionRef string
+
+	cmd := &cobra.Command{
+		Use:   "revoke <user email or ID>",
+		Short: "End the sessions of a user",
+		Long:  "End every active session of a user, or only the one given by --session.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			closeAll, err := connect()
+			if err != nil {
+				return err
+			}
+			defer closeAll()
+
+			user, err := findUser(args[0])
+			if err != nil {
+				return err
+			}
+
+			query := database.DB.Model(&auth.UserSession{}).Where("user_id = ? AND is_active = ?", user.ID, true)
+			if sessionRef != "" {
+				sessionID, err := uuid.Parse(sessionRef)
+				if err != nil {
+					return fmt.Errorf("session %q is not an ID", sessionRef)
+				}
+				query = query.Where("id = ?", sessionID)
+			}
+			var sessionIDs []string
+			if err := query.Pluck("session_id", &sessionIDs).Error; err != nil {
+				return err
+			}
+			result := database.DB.Model(&auth.UserSession{}).
+				Where("user_id = ? AND session_id IN ?", user.ID, sessionIDs).
+				Update("is_active", false)
+			if result.Error != nil {
+				return result.Error
+			}
+			if sessionRef != "" && result.RowsAffected == 0 {
+				return fmt.Errorf("no active session %s for %s", sessionRef, user.Email)
+			}
+			if len(sessionIDs) > 0 {
+				revocation.Publish(context.Background(), revocation.Revocation{UserID: user.ID.String(), SessionIDs: sessionIDs})
+			}
+
+			fmt.Printf("✅ Ended %d sessions of %s\n", result.RowsAffected, user.Email)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&sessionRef, "session", "", "ID of the session to end, as listed by sessions list")
+	return cmd
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models"
+	"forgecrud-backend/shared/database/models/auth"
+	"forgecrud-backend/shared/messaging"
+	"forgecrud-backend/shared/revocation"
+	utils "forgecrud-backend/shared/utils/auth"
+
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+)
+
+func newUsersCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "users",
+		Short: "Create users and reset their passwords",
+	}
+	cmd.AddCommand(newUsersCreateCommand(), newUsersResetPasswordCommand())
+	return cmd
+}
+
+func newUsersCreateCommand() *cobra.Command {
+	var (
+		email, password, firstName, lastName string
+		organizationRef, roleRef             string
+		active                               bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create a user",
+		Long: "Create a user like an admin does through the API: the user is invited and must change the " +
+			"password on first login, unless --active is set. Without --password a random one is generated and printed.",
+		Example: "  forgectl users create --email jane@example.com --organization acme --role Editor",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			closeAll, err := connect()
+			if err != nil {
+				return err
+			}
+			defer closeAll()
+
+			var count int64
+			if err := database.DB.Unscoped().Model(&models.User{}).Where("email = ?", email).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return fmt.Errorf("a user with email %s already exists", email)
+			}
+
+			user := models.User{
+				Email:             email,
+				FirstName:         firstName,
+				LastName:          lastName,
+				Status:            models.UserStatusInvited,
+				MustResetPassword: !active,
+				EmailVerified:     active,
+			}
+			if active {
+				user.Status = models.UserStatusActive
+			}
+
+			if organizationRef != "" {
+				organization, err := findOrganization(organizationRef)
+				if err != nil {
+					return err
+				}
+				if err := database.CheckUserQuota(database.DB, organization.ID); err != nil {
+					return err
+				}
+				user.OrganizationID = &organization.ID
+			}
+			if roleRef != "" {
+				role, err := findRole(roleRef, user.OrganizationID)
+				if err != nil {
+					return err
+				}
+				user.RoleID = &role.ID
+			}
+
+			generated := password == ""
+			if generated {
+				if password, err = generatePassword(); err != nil {
+					return err
+				}
+			}
+			if err := utils.ValidatePassword(password); err != nil {
+				return err
+			}
+			if user.Password, err = utils.HashPassword(password); err != nil {
+				return err
+			}
+
+			err = database.DB.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Create(&user).Error; err != nil {
+					return err
+				}
+				_, err := database.RecordRoleAssignment(tx, database.RoleAssignmentEntry{
+					UserID: user.ID,
+					RoleID: user.RoleID,
+					Source: models.RoleAssignmentSourceCLI,
+				})
+				return err
+			})
+			if err != nil {
+				return err
+			}
+
+			data := map[string]interface{}{
+				"id":              user.ID,
+				"email":           user.Email,
+				"status":          user.Status,
+				"organization_id": user.OrganizationID,
+				"role_id":         user.RoleID,
+			}
+			database.EnqueueWebhookEvent(messaging.EventUserCreated, data)
+			messaging.Publish(context.Background(), messaging.EventUserCreated, nil, data)
+
+			fmt.Printf("✅ Created user %s (%s, status %s)\n", user.Email, user.ID, user.Status)
+			if generated {
+				fmt.Printf("🔑 Password: %s\n", password)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&email, "email", "", "Email of the user (required)")
+	cmd.Flags().StringVar(&password, "password", "", "Password, generated when empty")
+	cmd.Flags().StringVar(&firstName, "first-name", "", "First name")
+	cmd.Flags().StringVar(&lastName, "last-name", "", "Last name")
+	cmd.Flags().StringVar(&organizationRef, "organization", "", "Organization slug or ID")
+	cmd.Flags().StringVar(&roleRef, "role", "", "Role name within the organization, or role ID")
+	cmd.Flags().BoolVar(&active, "active", false, "Create the user active with a verified email, without forcing a password change")
+	cmd.MarkFlagRequired("email")
+	return cmd
+}
+
+func newUsersResetPasswordCommand() *cobra.Command {
+	var (
+		password  string
+		keepLogin bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "reset-password <email or ID>",
+		Short: "Set a new password for a user",
+		Long: "Set a new password for a user, who must change it on the next login, and end the user's sessions. " +
+			"Without --password a random one is generated and printed.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			closeAll, err := connect()
+			if err != nil {
+				return err
+			}
+			defer closeAll()
+
+			user, err := findUser(args[0])
+			if err != nil {
+				return err
+			}
+
+			generated := password == ""
+			if generated {
+				if password, err = generatePassword(); err != nil {
+					return err
+				}
+			}
+			if err := utils.ValidatePassword(password); err != nil {
+				return err
+			}
+			hashedPassword, err := utils.HashPassword(password)
+			if err != nil {
+				return err
+			}
+
+			if err := database.DB.Model(user).Updates(map[string]interface{}{
+				"password":            hashedPassword,
+				"must_reset_password": true,
+			}).Error; err != nil {
+				return err
+			}
+
+			ended := int64(0)
+			if !keepLogin {
+				result := database.DB.Model(&auth.UserSession{}).
+					Where("user_id = ? AND is_active = ?", user.ID, true).
+					Update("is_active", false)
+				if result.Error != nil {
+					return result.Error
+				}
+				ended = result.RowsAffected
+				revocation.Publish(context.Background(), revocation.Revocation{UserID: user.ID.String()})
+			}
+
+			// Alert the user, in case the reset was not requested
+			messaging.Publish(context.Background(), messaging.EventSecurityPasswordChanged, nil, messaging.SecurityAlertData{
+				UserID:    user.ID,
+				UserAgent: "forgectl",
+			})
+
+			fmt.Printf("✅ Password of %s reset, %d sessions ended\n", user.Email, ended)
+			if generated {
+				fmt.Printf("🔑 Password: %s\n", password)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&password, "password", "", "New password, generated when empty")
+	cmd.Flags().BoolVar(&keepLogin, "keep-sessions", false, "Keep the user's sessions active")
+	return cmd
+}
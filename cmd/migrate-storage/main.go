@@ -0,0 +1,64 @@
+// Command migrate-storage moves the files an organization stored before it got its own storage into
+// that storage, or with -to-default back into the default storage before the organization's storage is
+// removed or moved. Files are copied before their records change, so the services can keep running and
+// an interrupted migration simply runs again; versions being scanned for malware are left for a later run.
+package main
+
+import (
+	"flag"
+	"log"
+
+	"forgecrud-backend/document-service/services"
+	"forgecrud-backend/shared/config"
+	"forgecrud-backend/shared/database"
+
+	"github.com/google/uuid"
+)
+
+func main() {
+	org := flag.String("org", "", "ID of the organization whose files are moved")
+	toDefault := flag.Bool("to-default", false, "Move the files out of the organization's storage back into the default storage")
+	dryRun := flag.Bool("dry-run", false, "Count the files that would be moved without moving them")
+	flag.Parse()
+
+	organizationID, err := uuid.Parse(*org)
+	if err != nil {
+		log.Fatal("❌ Pass the organization with -org <id>")
+	}
+
+	config.LoadConfig()
+
+	if err := database.InitDatabase(); err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer database.CloseDatabase()
+
+	storage, err := services.NewStorageProvider()
+	if err != nil {
+		log.Fatalf("Failed to initialize storage: %v", err)
+	}
+	defer storage.Close()
+
+	target := "its own storage"
+	if *toDefault {
+		target = "the default storage"
+	}
+	log.Printf("📦 Moving the files of organization %s into %s...", organizationID, target)
+
+	result, err := services.MigrateOrganizationStorage(database.GetDB(), storage, organizationID, services.StorageMigrationOptions{
+		ToDefault: *toDefault,
+		DryRun:    *dryRun,
+	})
+	if err != nil {
+		log.Fatalf("❌ Migration failed: %v", err)
+	}
+
+	if *dryRun {
+		log.Printf("✅ %d files (%d bytes) would be moved, %d are being scanned and would be skipped", result.Moved, result.Bytes, result.Skipped)
+		return
+	}
+	log.Printf("✅ Moved %d files (%d bytes), skipped %d being scanned", result.Moved, result.Bytes, result.Skipped)
+	if result.Failed > 0 || result.Skipped > 0 {
+		log.Fatalf("⚠️  %d files failed and %d were skipped, run the migration again to move them", result.Failed, result.Skipped)
+	}
+}
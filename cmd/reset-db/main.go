@@ -39,6 +39,21 @@ func main() {
 		"email_verification_tokens",
 		"permission_actions",
 		"permissions",
+		"team_members",
+		"teams",
+		"user_field_definitions",
+		"revisions",
+		"webhook_deliveries",
+		"webhooks",
+		"organization_quotas",
+		"user_quotas",
+		"organization_api_usage",
+		"ip_reputations",
+		"role_assignments",
+		"scheduled_role_changes",
+		"registration_rules",
+		"organization_join_requests",
+		"organization_domains",
 		"users",
 		"roles",
 		"organizations",
@@ -46,6 +61,10 @@ func main() {
 		"resources",
 		"documents",
 		"document_versions",
+		"upload_sessions",
+		"document_shares",
+		"access_grants",
+		"content_objects",
 		"folders",
 		"notifications",
 		"audit_logs",
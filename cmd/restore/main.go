@@ -0,0 +1,92 @@
+// Command restore puts the database and the document storage back to the state of a backup taken by
+// cmd/backup or the document service. The schema is migrated first, then the objects are restored
+// and the rows of every table replaced in one transaction. Stop the services meanwhile: the rows and
+// objects they cache or write would not match the restored ones.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"time"
+
+	"forgecrud-backend/document-service/services"
+	"forgecrud-backend/shared/config"
+	"forgecrud-backend/shared/database"
+)
+
+func main() {
+	id := flag.String("backup", "", "ID of the backup to restore, e.g. 20240131T020000Z")
+	at := flag.String("at", "", "Restore the most recent backup taken at or before this RFC3339 time; \"latest\" for the most recent one")
+	restoreDatabase := flag.Bool("database", true, "Replace the rows of every table")
+	restoreObjects := flag.Bool("objects", true, "Put back the objects missing from storage or changed since the backup")
+	pruneObjects := flag.Bool("prune-objects", false, "Remove the objects created after the backup")
+	yes := flag.Bool("yes", false, "Confirm that the configured database and storage should be replaced")
+	flag.Parse()
+
+	config.LoadConfig()
+	cfg := config.GetConfig()
+
+	if (*id == "") == (*at == "") {
+		log.Fatal("❌ Pass either -backup <id> or -at <time|latest>; run cmd/backup -list to see the backups")
+	}
+	if !*restoreDatabase && !*restoreObjects && !*pruneObjects {
+		log.Fatal("❌ Nothing to restore")
+	}
+
+	manager, err := services.NewBackupManager(nil)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	var manifest *services.BackupManifest
+	if *id != "" {
+		manifest, err = manager.Get(*id)
+	} else {
+		until := time.Now()
+		if *at != "latest" {
+			if until, err = time.Parse(time.RFC3339, *at); err != nil {
+				log.Fatalf("❌ Invalid -at time: %v", err)
+			}
+		}
+		manifest, err = manager.Latest(until)
+	}
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+
+	if !*yes {
+		log.Fatalf("❌ This replaces the data of %s on %s:%s (%s environment) and the document storage with backup %s (snapshot %s); stop the services, then rerun with -yes to confirm",
+			cfg.DBName, cfg.DBHost, cfg.DBPort, cfg.Environment, manifest.ID, manifest.SnapshotAt.Format(time.RFC3339))
+	}
+
+	log.Printf("♻️  Restoring backup %s (snapshot %s)...", manifest.ID, manifest.SnapshotAt.Format(time.RFC3339))
+
+	// InitDatabase migrates the schema the rows are restored into
+	if err := database.InitDatabase(); err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer database.CloseDatabase()
+
+	storage, err := services.NewStorageProvider()
+	if err != nil {
+		log.Fatalf("Failed to initialize storage: %v", err)
+	}
+	defer storage.Close()
+
+	if manager, err = services.NewBackupManager(storage); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	result, err := manager.Restore(context.Background(), manifest.ID, services.RestoreOptions{
+		Database:     *restoreDatabase,
+		Objects:      *restoreObjects,
+		PruneObjects: *pruneObjects,
+	})
+	if result != nil && (*restoreObjects || *pruneObjects) {
+		log.Printf("📦 Objects: %d restored, %d unchanged, %d removed", result.RestoredObjects, result.SkippedObjects, result.RemovedObjects)
+	}
+	if err != nil {
+		log.Fatalf("❌ Restore failed: %v", err)
+	}
+
+	log.Printf("✅ Restored backup %s, the platform is back to %s", manifest.ID, manifest.SnapshotAt.Format(time.RFC3339))
+}
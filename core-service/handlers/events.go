@@ -0,0 +1,15 @@
+package handlers
+
+import (
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/messaging"
+	utils "forgecrud-backend/shared/utils/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// emitEvent notifies webhook subscribers and publishes the domain event on the event bus
+func emitEvent(ctx *gin.Context, eventType string, data interface{}) {
+	database.EnqueueWebhookEvent(eventType, data)
+	messaging.Publish(ctx.Request.Context(), eventType, utils.GetActorID(ctx), data)
+}
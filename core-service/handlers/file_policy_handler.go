@@ -0,0 +1,277 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/config"
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models"
+	"forgecrud-backend/shared/tenancy"
+	utils "forgecrud-backend/shared/utils/auth"
+	"forgecrud-backend/shared/utils/validation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+var (
+	// fileExtensionPattern matches an extension with or without its dot
+	fileExtensionPattern = regexp.MustCompile(`^\.?[a-z0-9][a-z0-9_+-]{0,31}$`)
+	// mimeTypePattern matches a MIME type, a class like image/* or *
+	mimeTypePattern = regexp.MustCompile(`^(\*|\*/\*|[a-z0-9][a-z0-9!#$&^_.+-]*/(\*|[a-z0-9][a-z0-9!#$&^_.+-]*))$`)
+)
+
+// UpdateFileTypePolicyRequest represents request body for changing an organization's file type policy.
+// Omitted fields are left unchanged, empty lists allow everything.
+type UpdateFileTypePolicyRequest struct {
+	AllowedMimeTypes  *[]string `json:"allowed_mime_types" example:"image/*,application/pdf"`
+	DeniedMimeTypes   *[]string `json:"denied_mime_types" example:"video/*"`
+	AllowedExtensions *[]string `json:"allowed_extensions" example:".pdf,.png"`
+	DeniedExtensions  *[]string `json:"denied_extensions" example:".iso"`
+	// Maximum size in bytes by extension (.mp4) or MIME type (video/*), replacing the current limits
+	MaxSizes *map[string]int64 `json:"max_sizes"`
+	// Lift the platform's block of execution-prone types, super admins only
+	AllowExecutables *bool `json:"allow_executables"`
+}
+
+// FileTypePolicyResponse is an organization's file type policy next to the types the platform blocks
+type FileTypePolicyResponse struct {
+	OrganizationID    uuid.UUID        `json:"organization_id"`
+	AllowedMimeTypes  []string         `json:"allowed_mime_types"`
+	DeniedMimeTypes   []string         `json:"denied_mime_types"`
+	AllowedExtensions []string         `json:"allowed_extensions"`
+	DeniedExtensions  []string         `json:"denied_extensions"`
+	MaxSizes          map[string]int64 `json:"max_sizes"`
+	AllowExecutables  bool             `json:"allow_executables"`
+	BlockedMimeTypes  []string         `json:"blocked_mime_types"` // UPLOAD_BLOCKED_MIME_TYPES
+	BlockedExtensions []string         `json:"blocked_extensions"` // UPLOAD_BLOCKED_EXTENSIONS
+	UpdatedBy         *uuid.UUID       `json:"updated_by"`
+	UpdatedAt         *time.Time       `json:"updated_at"` // nil while the organization has no policy
+}
+
+// buildFileTypePolicyResponse converts a file type policy for API responses
+func buildFileTypePolicyResponse(policy models.FileTypePolicy) FileTypePolicyResponse {
+	cfg := config.GetConfig()
+	response := FileTypePolicyResponse{
+		OrganizationID:    policy.OrganizationID,
+		AllowedMimeTypes:  splitFileTypeList(policy.AllowedMimeTypes),
+		DeniedMimeTypes:   splitFileTypeList(policy.DeniedMimeTypes),
+		AllowedExtensions: splitFileTypeList(policy.AllowedExtensions),
+		DeniedExtensions:  splitFileTypeList(policy.DeniedExtensions),
+		MaxSizes:          map[string]int64{},
+		AllowExecutables:  policy.AllowExecutables,
+		BlockedMimeTypes:  splitFileTypeList(cfg.UploadBlockedMimeTypes),
+		BlockedExtensions: splitFileTypeList(cfg.UploadBlockedExtensions),
+		UpdatedBy:         policy.UpdatedBy,
+	}
+	for key, value := range policy.MaxSizes {
+		if limit, ok := database.FileTypeSizeLimit(value); ok {
+			response.MaxSizes[key] = limit
+		}
+	}
+	if policy.ID != uuid.Nil {
+		response.UpdatedAt = &policy.UpdatedAt
+	}
+	return response
+}
+
+// splitFileTypeList splits a comma separated list of a file type policy
+func splitFileTypeList(list string) []string {
+	items := []string{}
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// joinFileTypeList validates and normalizes a list of MIME types or extensions for a file type policy
+func joinFileTypeList(items []string, extensions bool) (string, error) {
+	normalized := make([]string, 0, len(items))
+	seen := map[string]bool{}
+	for _, item := range items {
+		item = strings.ToLower(strings.TrimSpace(item))
+		if extensions {
+			if !fileExtensionPattern.MatchString(item) {
+				return "", fmt.Errorf("invalid extension %q", item)
+			}
+			item = "." + strings.TrimPrefix(item, ".")
+		} else if !mimeTypePattern.MatchString(item) {
+			return "", fmt.Errorf("invalid MIME type %q", item)
+		}
+		if !seen[item] {
+			seen[item] = true
+			normalized = append(normalized, item)
+		}
+	}
+	return strings.Join(normalized, ","), nil
+}
+
+// GetFileTypePolicy returns the file type policy of an organization
+// @Summary Get organization file type policy
+// @Description Get the MIME types and extensions allowed and denied in the folders of an organization and its users, the maximum size per type, and the execution-prone types
+// @Description the platform blocks. Types are sniffed from the uploaded content, the client's Content-Type is ignored. Organizations without a policy allow every type the platform does not block.
+// @Tags organizations
+// @Produce json
+// @Param id path string true "Organization ID" format(uuid)
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "File type policy"
+// @Failure 400 {object} map[string]string "Invalid organization ID format"
+// @Failure 404 {object} map[string]string "Organization not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /organizations/{id}/file-policy [get]
+func GetFileTypePolicy(ctx *gin.Context) {
+	org, ok := findQuotaOrganization(ctx)
+	if !ok {
+		return
+	}
+
+	policy, err := database.GetFileTypePolicy(requestDB(ctx), &org.ID)
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to retrieve file type policy"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    buildFileTypePolicyResponse(policy),
+	})
+}
+
+// UpdateFileTypePolicy sets the file type policy of an organization
+// @Summary Update organization file type policy
+// @Description Set the MIME types (image/png, image/*) and extensions allowed and denied in the folders of an organization and its users, and the maximum size in bytes per extension or MIME type.
+// @Description Denied types win over allowed ones and the most specific size limit applies. Uploads of other types are rejected with 415, files over the limit of their type with 413.
+// @Description Only super admins can set allow_executables, which lifts the platform's block of execution-prone types.
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Param id path string true "Organization ID" format(uuid)
+// @Param request body UpdateFileTypePolicyRequest true "File type policy"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Updated file type policy"
+// @Failure 400 {object} map[string]string "Invalid request"
+// @Failure 403 {object} map[string]string "Only super admins can allow executables"
+// @Failure 404 {object} map[string]string "Organization not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /organizations/{id}/file-policy [put]
+func UpdateFileTypePolicy(ctx *gin.Context) {
+	org, ok := findQuotaOrganization(ctx)
+	if !ok {
+		return
+	}
+
+	var req UpdateFileTypePolicyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apperrors.Respond(ctx, validation.Error(err, "Invalid request"))
+		return
+	}
+
+	// Organization admins restrict their uploads but only super admins lift the platform's block
+	if req.AllowExecutables != nil && *req.AllowExecutables {
+		if tenant, ok := tenancy.FromContext(ctx.Request.Context()); ok && !tenant.Bypass {
+			apperrors.Respond(ctx, apperrors.Forbidden("Insufficient permissions").WithDetails("Only super admins can allow executable file types"))
+			return
+		}
+	}
+
+	db := requestDB(ctx)
+
+	policy, err := database.GetFileTypePolicy(db, &org.ID)
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to retrieve file type policy"))
+		return
+	}
+
+	lists := []struct {
+		items      *[]string
+		target     *string
+		extensions bool
+	}{
+		{req.AllowedMimeTypes, &policy.AllowedMimeTypes, false},
+		{req.DeniedMimeTypes, &policy.DeniedMimeTypes, false},
+		{req.AllowedExtensions, &policy.AllowedExtensions, true},
+		{req.DeniedExtensions, &policy.DeniedExtensions, true},
+	}
+	for _, list := range lists {
+		if list.items == nil {
+			continue
+		}
+		joined, err := joinFileTypeList(*list.items, list.extensions)
+		if err != nil {
+			apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid file type policy").WithDetails(err.Error()))
+			return
+		}
+		*list.target = joined
+	}
+
+	if req.MaxSizes != nil {
+		maxSizes := models.JSONMap{}
+		for key, limit := range *req.MaxSizes {
+			key = strings.ToLower(strings.TrimSpace(key))
+			if limit < 1 {
+				apperrors.Respond(ctx, apperrors.BadRequest("Invalid file type policy").WithDetails(fmt.Sprintf("Maximum size of %q must be at least 1 byte", key)))
+				return
+			}
+			isExtension := strings.HasPrefix(key, ".") && fileExtensionPattern.MatchString(key)
+			if !isExtension && !mimeTypePattern.MatchString(key) {
+				apperrors.Respond(ctx, apperrors.BadRequest("Invalid file type policy").WithDetails(fmt.Sprintf("%q is neither an extension like .mp4 nor a MIME type like video/*", key)))
+				return
+			}
+			maxSizes[key] = limit
+		}
+		policy.MaxSizes = maxSizes
+	}
+	if req.AllowExecutables != nil {
+		policy.AllowExecutables = *req.AllowExecutables
+	}
+	policy.UpdatedBy = utils.GetActorID(ctx)
+
+	// Organizations on the platform defaults get their own row on the first change
+	if err := db.Save(&policy).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to update file type policy"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "File type policy updated successfully",
+		"data":    buildFileTypePolicyResponse(policy),
+	})
+}
+
+// DeleteFileTypePolicy removes the file type policy of an organization
+// @Summary Delete organization file type policy
+// @Description Remove the file type policy of an organization, so its folders accept every type the platform does not block
+// @Tags organizations
+// @Produce json
+// @Param id path string true "Organization ID" format(uuid)
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "File type policy removed"
+// @Failure 400 {object} map[string]string "Invalid organization ID format"
+// @Failure 404 {object} map[string]string "Organization not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /organizations/{id}/file-policy [delete]
+func DeleteFileTypePolicy(ctx *gin.Context) {
+	org, ok := findQuotaOrganization(ctx)
+	if !ok {
+		return
+	}
+
+	if err := requestDB(ctx).Where("organization_id = ?", org.ID).Delete(&models.FileTypePolicy{}).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to delete file type policy"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "File type policy removed",
+		"data":    buildFileTypePolicyResponse(models.FileTypePolicy{OrganizationID: org.ID}),
+	})
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"net/http"
+
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models"
+	"forgecrud-backend/shared/tenancy"
+	utils "forgecrud-backend/shared/utils/auth"
+	"forgecrud-backend/shared/utils/query"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RevisionResponse represents a single recorded change of an entity
+type RevisionResponse struct {
+	ID        uuid.UUID      `json:"id"`
+	Action    string         `json:"action"`
+	ActorID   *uuid.UUID     `json:"actor_id"`
+	OldValues models.JSONMap `json:"old_values"`
+	NewValues models.JSONMap `json:"new_values"`
+	CreatedAt string         `json:"created_at"`
+}
+
+// RevisionListResponse represents an entity's change history with pagination
+type RevisionListResponse struct {
+	Success bool `json:"success"`
+	Data    struct {
+		Items      []RevisionResponse `json:"items"`
+		Pagination PaginationResponse `json:"pagination"`
+	} `json:"data"`
+}
+
+// requestDB returns a database handle that records the requesting user as the actor of any change
+func requestDB(ctx *gin.Context) *gorm.DB {
+	return database.DB.WithContext(database.WithActor(ctx.Request.Context(), utils.GetActorID(ctx)))
+}
+
+// withoutTenancy lifts the tenant scope of a request handle while keeping its actor. Callers must
+// check that the caller may see the records themselves, e.g. by loading their organization first.
+func withoutTenancy(db *gorm.DB) *gorm.DB {
+	return db.WithContext(tenancy.WithTenant(db.Statement.Context, tenancy.Tenant{Bypass: true}))
+}
+
+// GetUserHistory returns the change history of a user
+// @Summary Get user change history
+// @Description Get revisions of a user (who changed what and when), newest first
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID" format(uuid)
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Security BearerAuth
+// @Success 200 {object} handlers.RevisionListResponse
+// @Failure 400 {object} map[string]string "Invalid user ID format"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /users/{id}/history [get]
+func GetUserHistory(ctx *gin.Context) {
+	respondWithHistory(ctx, &models.User{}, "user")
+}
+
+// GetRoleHistory returns the change history of a role
+// @Summary Get role change history
+// @Description Get revisions of a role (who changed what and when), newest first
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Param id path string true "Role ID" format(uuid)
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Security BearerAuth
+// @Success 200 {object} handlers.RevisionListResponse
+// @Failure 400 {object} map[string]string "Invalid role ID format"
+// @Failure 404 {object} map[string]string "Role not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /roles/{id}/history [get]
+func GetRoleHistory(ctx *gin.Context) {
+	respondWithHistory(ctx, &models.Role{}, "role")
+}
+
+// GetOrganizationHistory returns the change history of an organization
+// @Summary Get organization change history
+// @Description Get revisions of an organization (who changed what and when), newest first
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Param id path string true "Organization ID" format(uuid)
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Security BearerAuth
+// @Success 200 {object} handlers.RevisionListResponse
+// @Failure 400 {object} map[string]string "Invalid organization ID format"
+// @Failure 404 {object} map[string]string "Organization not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /organizations/{id}/history [get]
+func GetOrganizationHistory(ctx *gin.Context) {
+	respondWithHistory(ctx, &models.Organization{}, "organization")
+}
+
+// respondWithHistory writes the paginated revisions of the entity identified by the :id path param.
+// Soft-deleted entities keep their history.
+func respondWithHistory(ctx *gin.Context, entity models.Revisioned, label string) {
+	entityID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid "+label+" ID format"))
+		return
+	}
+
+	db := requestDB(ctx)
+
+	var count int64
+	if err := db.Unscoped().Model(entity).Where("id = ?", entityID).Count(&count).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to retrieve "+label))
+		return
+	}
+	if count == 0 {
+		apperrors.Respond(ctx, apperrors.NotFound("Entity not found").WithDetails("No "+label+" exists with the given ID"))
+		return
+	}
+
+	params := query.ParseQueryParams(ctx)
+	revisions, total, err := database.GetRevisions(db, entity.RevisionEntityType(), entityID, params.Page, params.Limit)
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to retrieve history"))
+		return
+	}
+
+	items := make([]RevisionResponse, 0, len(revisions))
+	for _, revision := range revisions {
+		items = append(items, RevisionResponse{
+			ID:        revision.ID,
+			Action:    revision.Action,
+			ActorID:   revision.ActorID,
+			OldValues: revision.OldValues,
+			NewValues: revision.NewValues,
+			CreatedAt: revision.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		})
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"history":    items,
+			"pagination": query.BuildPaginationResponse(params.Page, params.Limit, total),
+		},
+	})
+}
@@ -0,0 +1,700 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models"
+	"forgecrud-backend/shared/messaging"
+	utils "forgecrud-backend/shared/utils/auth"
+	"forgecrud-backend/shared/utils/query"
+	"forgecrud-backend/shared/utils/validation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// domainVerificationTimeout bounds the DNS lookup of a domain's verification record
+const domainVerificationTimeout = 10 * time.Second
+
+// DNSRecord describes the DNS record an organization has to publish
+type DNSRecord struct {
+	Type  string `json:"type"`
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// OrganizationDomainResponse represents organization domain data for API responses
+type OrganizationDomainResponse struct {
+	ID             uuid.UUID    `json:"id"`
+	OrganizationID uuid.UUID    `json:"organization_id"`
+	Domain         string       `json:"domain"`
+	Status         string       `json:"status"`
+	JoinPolicy     string       `json:"join_policy"`
+	RoleID         *uuid.UUID   `json:"role_id"`
+	Role           *models.Role `json:"role,omitempty"`
+	DNSRecord      DNSRecord    `json:"dns_record"`
+	VerifiedAt     *time.Time   `json:"verified_at"`
+	LastCheckedAt  *time.Time   `json:"last_checked_at"`
+	LastCheckError string       `json:"last_check_error,omitempty"`
+	CreatedBy      *uuid.UUID   `json:"created_by"`
+	CreatedAt      string       `json:"created_at"`
+	UpdatedAt      string       `json:"updated_at"`
+}
+
+// CreateOrganizationDomainRequest represents request body for claiming an email domain
+type CreateOrganizationDomainRequest struct {
+	Domain     string     `json:"domain" binding:"required,max=255"`
+	JoinPolicy string     `json:"join_policy" binding:"omitempty,oneof=NONE AUTO_JOIN REQUEST"` // Default: REQUEST
+	RoleID     *uuid.UUID `json:"role_id"`                                                      // Role of users joining through the domain
+}
+
+// UpdateOrganizationDomainRequest represents request body for updating a domain's join settings.
+// The domain itself cannot change because its verification record depends on it.
+type UpdateOrganizationDomainRequest struct {
+	JoinPolicy string     `json:"join_policy" binding:"omitempty,oneof=NONE AUTO_JOIN REQUEST"`
+	RoleID     *uuid.UUID `json:"role_id"`
+}
+
+// ReviewJoinRequestRequest represents request body for approving or rejecting a join request
+type ReviewJoinRequestRequest struct {
+	RoleID *uuid.UUID `json:"role_id"` // Overrides the domain's role on approval
+	Note   string     `json:"note" binding:"omitempty,max=500"`
+}
+
+// JoinRequestListResponse represents a list of join requests with pagination
+type JoinRequestListResponse struct {
+	Success bool `json:"success"`
+	Data    struct {
+		Items      []models.OrganizationJoinRequest `json:"items"`
+		Pagination PaginationResponse               `json:"pagination"`
+	} `json:"data"`
+}
+
+// buildOrganizationDomainResponse converts an organization domain model to its API representation
+func buildOrganizationDomainResponse(domain models.OrganizationDomain) OrganizationDomainResponse {
+	return OrganizationDomainResponse{
+		ID:             domain.ID,
+		OrganizationID: domain.OrganizationID,
+		Domain:         domain.Domain,
+		Status:         domain.Status,
+		JoinPolicy:     domain.JoinPolicy,
+		RoleID:         domain.RoleID,
+		Role:           domain.Role,
+		DNSRecord:      DNSRecord{Type: "TXT", Name: domain.RecordName(), Value: domain.RecordValue()},
+		VerifiedAt:     domain.VerifiedAt,
+		LastCheckedAt:  domain.LastCheckedAt,
+		LastCheckError: domain.LastCheckError,
+		CreatedBy:      domain.CreatedBy,
+		CreatedAt:      domain.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:      domain.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
+
+// checkDomainRecord looks up the domain's TXT record and reports why it does not prove ownership
+func checkDomainRecord(ctx context.Context, domain *models.OrganizationDomain) error {
+	ctx, cancel := context.WithTimeout(ctx, domainVerificationTimeout)
+	defer cancel()
+
+	records, err := net.DefaultResolver.LookupTXT(ctx, domain.RecordName())
+	if err != nil {
+		return fmt.Errorf("TXT record %s could not be resolved: %w", domain.RecordName(), err)
+	}
+	for _, record := range records {
+		if strings.TrimSpace(record) == domain.RecordValue() {
+			return nil
+		}
+	}
+	return fmt.Errorf("TXT record %s does not contain %s", domain.RecordName(), domain.RecordValue())
+}
+
+// validateDomainRole checks that the role is global or belongs to the organization and writes the
+// error response on failure
+func validateDomainRole(ctx *gin.Context, db *gorm.DB, organizationID uuid.UUID, roleID *uuid.UUID) bool {
+	if roleID == nil {
+		return true
+	}
+
+	var role models.Role
+	if err := db.First(&role, *roleID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			apperrors.Respond(ctx, apperrors.BadRequest("Role not found").WithDetails("The specified role does not exist"))
+			return false
+		}
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to validate role"))
+		return false
+	}
+	if role.OrganizationID != nil && *role.OrganizationID != organizationID {
+		apperrors.Respond(ctx, apperrors.BadRequest("Role belongs to another organization").WithDetails("The role must be global or belong to the domain's organization"))
+		return false
+	}
+	return true
+}
+
+// domainVerifiedElsewhere reports whether another organization already verified the domain. Domains
+// are matched across all organizations, so only one may own each.
+func domainVerifiedElsewhere(domain string, organizationID uuid.UUID) (bool, error) {
+	var count int64
+	err := database.DB.Model(&models.OrganizationDomain{}).
+		Where("domain = ? AND status = ? AND organization_id <> ?", domain, models.DomainStatusVerified, organizationID).
+		Where("organization_id IN (?)", database.DB.Model(&models.Organization{}).Select("id")).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// findOrganizationDomain loads a domain by the :id and :domain_id path parameters and writes the error response on failure
+func findOrganizationDomain(ctx *gin.Context, db *gorm.DB) (*models.OrganizationDomain, bool) {
+	orgUUID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid organization ID format"))
+		return nil, false
+	}
+
+	domainUUID, err := uuid.Parse(ctx.Param("domain_id"))
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid domain ID format"))
+		return nil, false
+	}
+
+	var domain models.OrganizationDomain
+	if err := db.Preload("Role").Where("id = ? AND organization_id = ?", domainUUID, orgUUID).First(&domain).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			apperrors.Respond(ctx, apperrors.NotFound("Domain not found").WithDetails("Domain with the given ID does not exist in this organization"))
+			return nil, false
+		}
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to retrieve domain"))
+		return nil, false
+	}
+
+	return &domain, true
+}
+
+// GetOrganizationDomains lists the email domains of an organization
+// @Summary Get organization domains
+// @Description Get the email domains claimed by an organization with their verification status and the TXT record proving ownership
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Param id path string true "Organization ID" format(uuid)
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Organization domains"
+// @Failure 400 {object} map[string]string "Invalid organization ID format"
+// @Failure 404 {object} map[string]string "Organization not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /organizations/{id}/domains [get]
+func GetOrganizationDomains(ctx *gin.Context) {
+	org, ok := findQuotaOrganization(ctx)
+	if !ok {
+		return
+	}
+
+	var domains []models.OrganizationDomain
+	if err := requestDB(ctx).Preload("Role").Where("organization_id = ?", org.ID).Order("domain ASC").Find(&domains).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to retrieve domains"))
+		return
+	}
+
+	domainResponses := make([]OrganizationDomainResponse, 0, len(domains))
+	for _, domain := range domains {
+		domainResponses = append(domainResponses, buildOrganizationDomainResponse(domain))
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    domainResponses,
+	})
+}
+
+// CreateOrganizationDomain claims an email domain for an organization
+// @Summary Claim an organization domain
+// @Description Claim an email domain for an organization. Publish the returned TXT record in the domain's DNS zone, then verify the domain.
+// @Description Once verified, users who verify an email address of the domain join the organization (AUTO_JOIN), request to join it (REQUEST)
+// @Description or are left alone (NONE).
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Param id path string true "Organization ID" format(uuid)
+// @Param domain body CreateOrganizationDomainRequest true "Domain"
+// @Security BearerAuth
+// @Success 201 {object} map[string]interface{} "Claimed domain with its TXT record"
+// @Failure 400 {object} map[string]string "Invalid request data or role"
+// @Failure 404 {object} map[string]string "Organization not found"
+// @Failure 409 {object} map[string]string "Domain already claimed by this organization or verified by another"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /organizations/{id}/domains [post]
+func CreateOrganizationDomain(ctx *gin.Context) {
+	org, ok := findQuotaOrganization(ctx)
+	if !ok {
+		return
+	}
+
+	var req CreateOrganizationDomainRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apperrors.Respond(ctx, validation.Error(err, "Invalid request body"))
+		return
+	}
+
+	name, err := normalizeEmailDomain(req.Domain)
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid domain"))
+		return
+	}
+
+	db := requestDB(ctx)
+	if !validateDomainRole(ctx, db, org.ID, req.RoleID) {
+		return
+	}
+
+	var count int64
+	if err := db.Model(&models.OrganizationDomain{}).Where("organization_id = ? AND domain = ?", org.ID, name).Count(&count).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to validate domain"))
+		return
+	}
+	if count > 0 {
+		apperrors.Respond(ctx, apperrors.Conflict("Domain already claimed").WithDetails("The organization already claimed this domain"))
+		return
+	}
+
+	verified, err := domainVerifiedElsewhere(name, org.ID)
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to validate domain"))
+		return
+	}
+	if verified {
+		apperrors.Respond(ctx, apperrors.Conflict("Domain already verified").WithDetails("Another organization has verified this domain"))
+		return
+	}
+
+	token, err := utils.GenerateRandomToken(24)
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to generate verification token"))
+		return
+	}
+
+	domain := models.OrganizationDomain{
+		OrganizationID:    org.ID,
+		Domain:            name,
+		Status:            models.DomainStatusPending,
+		VerificationToken: token,
+		JoinPolicy:        models.DomainJoinRequest,
+		RoleID:            req.RoleID,
+		CreatedBy:         utils.GetActorID(ctx),
+	}
+	if req.JoinPolicy != "" {
+		domain.JoinPolicy = req.JoinPolicy
+	}
+
+	if err := db.Create(&domain).Error; err != nil {
+		if errors.Is(err, database.ErrTenantMismatch) {
+			apperrors.Respond(ctx, apperrors.Forbidden("Cannot claim domains for another organization"))
+			return
+		}
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to create domain"))
+		return
+	}
+
+	db.Preload("Role").First(&domain, domain.ID)
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Domain created successfully",
+		"data":    buildOrganizationDomainResponse(domain),
+	})
+}
+
+// UpdateOrganizationDomain updates the join settings of an organization domain
+// @Summary Update an organization domain
+// @Description Update what happens to users of the domain (join policy) and the role they receive
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Param id path string true "Organization ID" format(uuid)
+// @Param domain_id path string true "Domain ID" format(uuid)
+// @Param domain body UpdateOrganizationDomainRequest true "Join settings"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Updated domain"
+// @Failure 400 {object} map[string]string "Invalid request data or role"
+// @Failure 404 {object} map[string]string "Domain not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /organizations/{id}/domains/{domain_id} [put]
+func UpdateOrganizationDomain(ctx *gin.Context) {
+	db := requestDB(ctx)
+
+	domain, ok := findOrganizationDomain(ctx, db)
+	if !ok {
+		return
+	}
+
+	var req UpdateOrganizationDomainRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apperrors.Respond(ctx, validation.Error(err, "Invalid request body"))
+		return
+	}
+
+	if req.JoinPolicy != "" {
+		domain.JoinPolicy = req.JoinPolicy
+	}
+	if req.RoleID != nil {
+		if !validateDomainRole(ctx, db, domain.OrganizationID, req.RoleID) {
+			return
+		}
+		domain.RoleID = req.RoleID
+	}
+
+	err := db.Model(domain).Updates(map[string]interface{}{
+		"join_policy": domain.JoinPolicy,
+		"role_id":     domain.RoleID,
+	}).Error
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to update domain"))
+		return
+	}
+
+	db.Preload("Role").First(domain, domain.ID)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Domain updated successfully",
+		"data":    buildOrganizationDomainResponse(*domain),
+	})
+}
+
+// DeleteOrganizationDomain removes an email domain from an organization
+// @Summary Delete an organization domain
+// @Description Remove a domain from an organization. Users who already joined through it stay; its pending join requests can still be reviewed.
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Param id path string true "Organization ID" format(uuid)
+// @Param domain_id path string true "Domain ID" format(uuid)
+// @Security BearerAuth
+// @Success 200 {object} handlers.SuccessResponse "Domain deleted successfully"
+// @Failure 400 {object} map[string]string "Invalid ID format"
+// @Failure 404 {object} map[string]string "Domain not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /organizations/{id}/domains/{domain_id} [delete]
+func DeleteOrganizationDomain(ctx *gin.Context) {
+	db := requestDB(ctx)
+
+	domain, ok := findOrganizationDomain(ctx, db)
+	if !ok {
+		return
+	}
+
+	if err := db.Delete(domain).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to delete domain"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Domain deleted successfully",
+	})
+}
+
+// VerifyOrganizationDomain checks the TXT record of an organization domain
+// @Summary Verify an organization domain
+// @Description Look up the domain's TXT record and mark the domain verified when it contains the verification value.
+// @Description The outcome of the check is stored on the domain either way.
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Param id path string true "Organization ID" format(uuid)
+// @Param domain_id path string true "Domain ID" format(uuid)
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Verified domain"
+// @Failure 400 {object} map[string]string "Invalid ID format or TXT record missing"
+// @Failure 404 {object} map[string]string "Domain not found"
+// @Failure 409 {object} map[string]string "Domain verified by another organization"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /organizations/{id}/domains/{domain_id}/verify [post]
+func VerifyOrganizationDomain(ctx *gin.Context) {
+	db := requestDB(ctx)
+
+	domain, ok := findOrganizationDomain(ctx, db)
+	if !ok {
+		return
+	}
+
+	verified, err := domainVerifiedElsewhere(domain.Domain, domain.OrganizationID)
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to validate domain"))
+		return
+	}
+	if verified {
+		apperrors.Respond(ctx, apperrors.Conflict("Domain already verified").WithDetails("Another organization has verified this domain"))
+		return
+	}
+
+	now := time.Now()
+	checkErr := checkDomainRecord(ctx.Request.Context(), domain)
+
+	updates := map[string]interface{}{
+		"last_checked_at":  now,
+		"last_check_error": "",
+	}
+	if checkErr != nil {
+		// A verified domain stays verified; the failed check is only recorded
+		updates["last_check_error"] = checkErr.Error()
+	} else if domain.Status != models.DomainStatusVerified {
+		updates["status"] = models.DomainStatusVerified
+		updates["verified_at"] = now
+	}
+	if err := db.Model(domain).Updates(updates).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to update domain"))
+		return
+	}
+
+	if checkErr != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(checkErr, apperrors.CodeBadRequest, "Domain verification failed").WithDetails(checkErr.Error()))
+		return
+	}
+
+	db.Preload("Role").First(domain, domain.ID)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Domain verified successfully",
+		"data":    buildOrganizationDomainResponse(*domain),
+	})
+}
+
+// GetJoinRequests lists the requests of users to join an organization
+// @Summary Get organization join requests
+// @Description Get the requests of users with a verified domain email to join the organization, with pagination and a status filter
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Param id path string true "Organization ID" format(uuid)
+// @Param page query int false "Page number (default: 1)"
+// @Param limit query int false "Items per page (default: 10)"
+// @Param filters[status] query string false "Filter by status (PENDING, APPROVED, REJECTED)"
+// @Security BearerAuth
+// @Success 200 {object} handlers.JoinRequestListResponse
+// @Failure 400 {object} map[string]string "Invalid organization ID format"
+// @Failure 404 {object} map[string]string "Organization not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /organizations/{id}/join-requests [get]
+func GetJoinRequests(ctx *gin.Context) {
+	org, ok := findQuotaOrganization(ctx)
+	if !ok {
+		return
+	}
+
+	params := query.ParseQueryParams(ctx)
+
+	allowedFilters := map[string]string{
+		"status":  "status",
+		"user_id": "user_id",
+	}
+	allowedSortFields := map[string]string{
+		"status":     "status",
+		"created_at": "created_at",
+	}
+
+	// Requesting users belong to no organization yet, so the tenant scope would hide them
+	baseQuery := withoutTenancy(requestDB(ctx)).Model(&models.OrganizationJoinRequest{}).
+		Where("organization_id = ?", org.ID).
+		Preload("User").Preload("Domain").Preload("Role")
+	filteredQuery := query.ApplyFilters(baseQuery, params.Filters, allowedFilters)
+
+	var total int64
+	filteredQuery.Count(&total)
+
+	finalQuery := query.ApplySort(filteredQuery, params.Sort, allowedSortFields)
+	finalQuery = query.ApplyPagination(finalQuery, params.Page, params.Limit)
+
+	var requests []models.OrganizationJoinRequest
+	if err := finalQuery.Find(&requests).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to retrieve join requests"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"join_requests": requests,
+			"pagination":    query.BuildPaginationResponse(params.Page, params.Limit, total),
+		},
+	})
+}
+
+// ApproveJoinRequest lets the requesting user join the organization
+// @Summary Approve a join request
+// @Description Add the requesting user to the organization with the domain's role, or the given one. The user is notified of the decision.
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Param id path string true "Organization ID" format(uuid)
+// @Param request_id path string true "Join request ID" format(uuid)
+// @Param review body ReviewJoinRequestRequest false "Role and note"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Approved join request"
+// @Failure 400 {object} map[string]string "Invalid request data or role"
+// @Failure 404 {object} map[string]string "Join request not found"
+// @Failure 409 {object} map[string]string "Join request already reviewed or user already in an organization"
+// @Failure 402 {object} map[string]string "Organization user quota exceeded"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /organizations/{id}/join-requests/{request_id}/approve [post]
+func ApproveJoinRequest(ctx *gin.Context) {
+	reviewJoinRequest(ctx, models.JoinRequestApproved)
+}
+
+// RejectJoinRequest turns down the request of a user to join the organization
+// @Summary Reject a join request
+// @Description Reject a pending join request. The user is notified of the decision and the note.
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Param id path string true "Organization ID" format(uuid)
+// @Param request_id path string true "Join request ID" format(uuid)
+// @Param review body ReviewJoinRequestRequest false "Note"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Rejected join request"
+// @Failure 400 {object} map[string]string "Invalid request data"
+// @Failure 404 {object} map[string]string "Join request not found"
+// @Failure 409 {object} map[string]string "Join request already reviewed"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /organizations/{id}/join-requests/{request_id}/reject [post]
+func RejectJoinRequest(ctx *gin.Context) {
+	reviewJoinRequest(ctx, models.JoinRequestRejected)
+}
+
+// reviewJoinRequest approves or rejects a pending join request of a visible organization
+func reviewJoinRequest(ctx *gin.Context, status string) {
+	org, ok := findQuotaOrganization(ctx)
+	if !ok {
+		return
+	}
+
+	requestUUID, err := uuid.Parse(ctx.Param("request_id"))
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid join request ID format"))
+		return
+	}
+
+	var req ReviewJoinRequestRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		apperrors.Respond(ctx, validation.Error(err, "Invalid request body"))
+		return
+	}
+	if status == models.JoinRequestApproved && !validateDomainRole(ctx, requestDB(ctx), org.ID, req.RoleID) {
+		return
+	}
+
+	var request models.OrganizationJoinRequest
+	var user models.User
+	var assignment *models.RoleAssignment
+	err = withoutTenancy(requestDB(ctx)).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND organization_id = ?", requestUUID, org.ID).First(&request).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return apperrors.NotFound("Join request not found").WithDetails("Join request with the given ID does not exist in this organization")
+			}
+			return err
+		}
+		if request.Status != models.JoinRequestPending {
+			return apperrors.Conflict("Join request already reviewed").WithDetails("The join request is already " + request.Status)
+		}
+
+		if status == models.JoinRequestApproved {
+			if err := tx.First(&user, request.UserID).Error; err != nil {
+				if err == gorm.ErrRecordNotFound {
+					return apperrors.NotFound("User not found").WithDetails("The requesting user no longer exists")
+				}
+				return err
+			}
+			if user.OrganizationID != nil {
+				return apperrors.Conflict("User already in an organization").WithDetails("The requesting user joined another organization in the meantime")
+			}
+
+			if req.RoleID != nil {
+				request.RoleID = req.RoleID
+			}
+			var err error
+			if assignment, err = database.JoinOrganization(tx, &user, &org.ID, request.RoleID, "Join request approved"); err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		request.Status = status
+		request.ReviewedBy = utils.GetActorID(ctx)
+		request.ReviewedAt = &now
+		request.Note = req.Note
+		return tx.Model(&request).Updates(map[string]interface{}{
+			"status":      request.Status,
+			"role_id":     request.RoleID,
+			"reviewed_by": request.ReviewedBy,
+			"reviewed_at": request.ReviewedAt,
+			"note":        request.Note,
+		}).Error
+	})
+	if err != nil {
+		var quotaErr *database.QuotaExceededError
+		if errors.As(err, &quotaErr) {
+			apperrors.Respond(ctx, apperrors.Wrap(quotaErr, apperrors.CodeQuotaExceeded, "Quota exceeded").With("quota", quotaErr))
+			return
+		}
+		var appErr *apperrors.Error
+		if errors.As(err, &appErr) {
+			apperrors.Respond(ctx, appErr)
+			return
+		}
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to review join request"))
+		return
+	}
+
+	if status == models.JoinRequestApproved {
+		emitEvent(ctx, messaging.EventUserUpdated, buildUserResponse(user))
+		publishRoleChanged(ctx, user, assignment)
+	} else {
+		withoutTenancy(requestDB(ctx)).First(&user, request.UserID)
+	}
+	publishJoinReviewed(ctx, org, user, request)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Join request " + strings.ToLower(status) + " successfully",
+		"data":    request,
+	})
+}
+
+// publishJoinReviewed notifies the requesting user of the decision on their join request
+func publishJoinReviewed(ctx *gin.Context, org models.Organization, user models.User, request models.OrganizationJoinRequest) {
+	if user.ID == uuid.Nil {
+		return
+	}
+
+	description := fmt.Sprintf("Your request to join %s was approved.", org.Name)
+	if request.Status == models.JoinRequestRejected {
+		description = fmt.Sprintf("Your request to join %s was rejected.", org.Name)
+	}
+	if request.Note != "" {
+		description += " Note: " + request.Note
+	}
+
+	messaging.Publish(ctx.Request.Context(), messaging.EventOrganizationJoinReviewed, utils.GetActorID(ctx), messaging.ActivityData{
+		OwnerID:      user.ID,
+		ActionType:   "Join request reviewed",
+		ResourceType: "organization",
+		ResourceID:   org.ID,
+		ResourceName: org.Name,
+		Description:  description,
+		Priority:     "normal",
+		Data: map[string]interface{}{
+			"join_request_id": request.ID,
+			"status":          request.Status,
+		},
+	})
+}
@@ -1,11 +1,16 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
+	"forgecrud-backend/shared/apperrors"
 	"forgecrud-backend/shared/database"
 	"forgecrud-backend/shared/database/models"
+	"forgecrud-backend/shared/messaging"
+	"forgecrud-backend/shared/utils/concurrency"
 	"forgecrud-backend/shared/utils/query"
+	"forgecrud-backend/shared/utils/validation"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -20,8 +25,10 @@ type OrganizationResponse struct {
 	Status    string     `json:"status"`
 	OwnerID   uuid.UUID  `json:"owner_id"`
 	ParentID  *uuid.UUID `json:"parent_id"`
+	Version   string     `json:"version"` // Send back as If-Match or version to detect concurrent updates
 	CreatedAt string     `json:"created_at"`
 	UpdatedAt string     `json:"updated_at"`
+	DeletedAt *string    `json:"deleted_at,omitempty"`
 }
 
 // CreateOrganizationRequest represents request body for creating organization
@@ -40,6 +47,7 @@ type UpdateOrganizationRequest struct {
 	Status   string     `json:"status"`
 	OwnerID  *uuid.UUID `json:"owner_id"`
 	ParentID *uuid.UUID `json:"parent_id"`
+	Version  string     `json:"version"` // Optional precondition, alternative to If-Match
 }
 
 // OrganizationListResponse represents a list of organizations with pagination
@@ -57,6 +65,22 @@ type SingleOrganizationResponse struct {
 	Data    OrganizationResponse `json:"data"`
 }
 
+// buildOrganizationResponse converts an organization model to its API representation
+func buildOrganizationResponse(org models.Organization) OrganizationResponse {
+	return OrganizationResponse{
+		ID:        org.ID,
+		Name:      org.Name,
+		Slug:      org.Slug,
+		Status:    org.Status,
+		OwnerID:   org.OwnerID,
+		ParentID:  org.ParentID,
+		Version:   concurrency.Version(org.UpdatedAt),
+		CreatedAt: org.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt: org.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		DeletedAt: formatDeletedAt(org.DeletedAt),
+	}
+}
+
 // GetOrganizations retrieves all organizations with pagination and filtering
 // @Summary Get all organizations
 // @Description Get all organizations with pagination, filtering, sorting and search
@@ -71,22 +95,24 @@ type SingleOrganizationResponse struct {
 // @Param filters[parent_id] query string false "Filter by parent organization ID"
 // @Param sort[field] query string false "Sort field (name, slug, status, created_at, updated_at)"
 // @Param sort[order] query string false "Sort order (asc, desc)"
+// @Param include_deleted query string false "Include soft-deleted organizations (true) or list only the trash (only); requires organizations:manage"
 // @Security BearerAuth
 // @Success 200 {object} handlers.OrganizationListResponse
 // @Failure 401 {object} map[string]string "Unauthorized"
 // @Failure 500 {object} map[string]string "Server error"
 // @Router /organizations [get]
 func GetOrganizations(ctx *gin.Context) {
-	db := database.DB
+	db := requestDB(ctx)
 
 	// Parse query parameters using shared utility
 	params := query.ParseQueryParams(ctx)
 
 	// Define allowed filter fields
 	allowedFilters := map[string]string{
-		"status":    "status",
-		"owner_id":  "owner_id",
-		"parent_id": "parent_id",
+		"status":     "status",
+		"owner_id":   "owner_id",
+		"parent_id":  "parent_id",
+		"created_at": "created_at",
 	}
 
 	// Define allowed sort fields
@@ -102,7 +128,7 @@ func GetOrganizations(ctx *gin.Context) {
 	searchFields := []string{"name", "slug"}
 
 	// Build query
-	dbQuery := db.Model(&models.Organization{})
+	dbQuery := applyDeletedScope(ctx, db.Model(&models.Organization{}))
 
 	// Apply filters, search, sorting, and pagination
 	dbQuery = query.ApplyFilters(dbQuery, params.Filters, allowedFilters)
@@ -112,10 +138,7 @@ func GetOrganizations(ctx *gin.Context) {
 	// Get total count before pagination
 	var total int64
 	if err := dbQuery.Count(&total).Error; err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to count organizations",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to count organizations"))
 		return
 	}
 
@@ -125,27 +148,14 @@ func GetOrganizations(ctx *gin.Context) {
 	// Get organizations
 	var organizations []models.Organization
 	if err := dbQuery.Find(&organizations).Error; err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to retrieve organizations",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to retrieve organizations"))
 		return
 	}
 
 	// Convert to response format
 	var orgResponses []OrganizationResponse
 	for _, org := range organizations {
-		orgResponse := OrganizationResponse{
-			ID:        org.ID,
-			Name:      org.Name,
-			Slug:      org.Slug,
-			Status:    org.Status,
-			OwnerID:   org.OwnerID,
-			ParentID:  org.ParentID,
-			CreatedAt: org.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-			UpdatedAt: org.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		}
-		orgResponses = append(orgResponses, orgResponse)
+		orgResponses = append(orgResponses, buildOrganizationResponse(org))
 	}
 
 	// Build pagination response
@@ -178,42 +188,25 @@ func GetOrganization(ctx *gin.Context) {
 	orgID := ctx.Param("id")
 	orgUUID, err := uuid.Parse(orgID)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid organization ID format",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid organization ID format"))
 		return
 	}
 
-	db := database.DB
+	db := requestDB(ctx)
 
 	var org models.Organization
 	if err := db.First(&org, orgUUID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			ctx.JSON(http.StatusNotFound, gin.H{
-				"error":   "Organization not found",
-				"message": "Organization with the given ID does not exist",
-			})
+			apperrors.Respond(ctx, apperrors.NotFound("Organization not found").WithDetails("Organization with the given ID does not exist"))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to retrieve organization",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to retrieve organization"))
 		return
 	}
 
-	orgResponse := OrganizationResponse{
-		ID:        org.ID,
-		Name:      org.Name,
-		Slug:      org.Slug,
-		Status:    org.Status,
-		OwnerID:   org.OwnerID,
-		ParentID:  org.ParentID,
-		CreatedAt: org.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt: org.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
-	}
+	orgResponse := buildOrganizationResponse(org)
 
+	concurrency.SetETag(ctx, org.UpdatedAt)
 	ctx.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"data":    orgResponse,
@@ -222,7 +215,7 @@ func GetOrganization(ctx *gin.Context) {
 
 // CreateOrganization creates a new organization
 // @Summary Create a new organization
-// @Description Create a new organization with the provided information
+// @Description Create a new organization with the provided information. The organization gets a copy of every role in the role catalog.
 // @Tags organizations
 // @Accept json
 // @Produce json
@@ -237,29 +230,20 @@ func GetOrganization(ctx *gin.Context) {
 func CreateOrganization(ctx *gin.Context) {
 	var req CreateOrganizationRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request body",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, validation.Error(err, "Invalid request body"))
 		return
 	}
 
-	db := database.DB
+	db := requestDB(ctx)
 
 	// Check if owner exists
 	var owner models.User
 	if err := db.First(&owner, req.OwnerID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			ctx.JSON(http.StatusBadRequest, gin.H{
-				"error":   "Owner not found",
-				"message": "The specified owner does not exist",
-			})
+			apperrors.Respond(ctx, apperrors.BadRequest("Owner not found").WithDetails("The specified owner does not exist"))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to validate owner",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to validate owner"))
 		return
 	}
 
@@ -268,27 +252,18 @@ func CreateOrganization(ctx *gin.Context) {
 		var parentOrg models.Organization
 		if err := db.First(&parentOrg, *req.ParentID).Error; err != nil {
 			if err == gorm.ErrRecordNotFound {
-				ctx.JSON(http.StatusBadRequest, gin.H{
-					"error":   "Parent organization not found",
-					"message": "The specified parent organization does not exist",
-				})
+				apperrors.Respond(ctx, apperrors.BadRequest("Parent organization not found").WithDetails("The specified parent organization does not exist"))
 				return
 			}
-			ctx.JSON(http.StatusInternalServerError, gin.H{
-				"error":   "Failed to validate parent organization",
-				"message": err.Error(),
-			})
+			apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to validate parent organization"))
 			return
 		}
 	}
 
 	// Check if slug already exists
 	var existingOrg models.Organization
-	if err := db.Where("slug = ?", req.Slug).First(&existingOrg).Error; err == nil {
-		ctx.JSON(http.StatusConflict, gin.H{
-			"error":   "Slug already exists",
-			"message": "An organization with this slug already exists",
-		})
+	if err := db.Unscoped().Where("slug = ?", req.Slug).First(&existingOrg).Error; err == nil {
+		apperrors.Respond(ctx, apperrors.Conflict("Slug already exists").WithDetails("An organization with this slug already exists"))
 		return
 	}
 
@@ -306,24 +281,25 @@ func CreateOrganization(ctx *gin.Context) {
 		ParentID: req.ParentID,
 	}
 
-	if err := db.Create(&org).Error; err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to create organization",
-			"message": err.Error(),
-		})
+	// New organizations start with a copy of every catalog role
+	var stamped []models.Role
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&org).Error; err != nil {
+			return err
+		}
+		var err error
+		stamped, err = database.StampRoleCatalog(stampingDB(tx), org.ID)
+		return err
+	})
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to create organization"))
 		return
 	}
 
-	orgResponse := OrganizationResponse{
-		ID:        org.ID,
-		Name:      org.Name,
-		Slug:      org.Slug,
-		Status:    org.Status,
-		OwnerID:   org.OwnerID,
-		ParentID:  org.ParentID,
-		CreatedAt: org.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt: org.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
-	}
+	orgResponse := buildOrganizationResponse(org)
+
+	emitEvent(ctx, messaging.EventOrganizationCreated, orgResponse)
+	emitStampedRoles(ctx, stamped)
 
 	ctx.JSON(http.StatusCreated, gin.H{
 		"success": true,
@@ -339,51 +315,51 @@ func CreateOrganization(ctx *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param id path string true "Organization ID" format(uuid)
+// @Param If-Match header string false "Version (ETag) the update is based on"
 // @Param organization body UpdateOrganizationRequest true "Updated organization information"
 // @Security BearerAuth
 // @Success 200 {object} handlers.SingleOrganizationResponse "Updated organization"
 // @Failure 400 {object} map[string]string "Invalid request data or ID format"
 // @Failure 401 {object} map[string]string "Unauthorized"
 // @Failure 404 {object} map[string]string "Organization not found"
-// @Failure 409 {object} map[string]string "Slug already exists"
+// @Failure 409 {object} map[string]string "Slug already exists or version conflict"
 // @Failure 500 {object} map[string]string "Server error"
 // @Router /organizations/{id} [put]
 func UpdateOrganization(ctx *gin.Context) {
 	orgID := ctx.Param("id")
 	orgUUID, err := uuid.Parse(orgID)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid organization ID format",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid organization ID format"))
 		return
 	}
 
 	var req UpdateOrganizationRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request body",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, validation.Error(err, "Invalid request body"))
 		return
 	}
 
-	db := database.DB
+	expectedVersion, err := concurrency.ExpectedVersion(ctx, req.Version)
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid version"))
+		return
+	}
+
+	db := requestDB(ctx)
 
 	// Check if organization exists
 	var org models.Organization
 	if err := db.First(&org, orgUUID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			ctx.JSON(http.StatusNotFound, gin.H{
-				"error":   "Organization not found",
-				"message": "Organization with the given ID does not exist",
-			})
+			apperrors.Respond(ctx, apperrors.NotFound("Organization not found").WithDetails("Organization with the given ID does not exist"))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to retrieve organization",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to retrieve organization"))
+		return
+	}
+
+	if err := concurrency.CheckVersion(org.UpdatedAt, expectedVersion); err != nil {
+		respondVersionConflict(ctx, err)
 		return
 	}
 
@@ -392,16 +368,10 @@ func UpdateOrganization(ctx *gin.Context) {
 		var owner models.User
 		if err := db.First(&owner, *req.OwnerID).Error; err != nil {
 			if err == gorm.ErrRecordNotFound {
-				ctx.JSON(http.StatusBadRequest, gin.H{
-					"error":   "Owner not found",
-					"message": "The specified owner does not exist",
-				})
+				apperrors.Respond(ctx, apperrors.BadRequest("Owner not found").WithDetails("The specified owner does not exist"))
 				return
 			}
-			ctx.JSON(http.StatusInternalServerError, gin.H{
-				"error":   "Failed to validate owner",
-				"message": err.Error(),
-			})
+			apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to validate owner"))
 			return
 		}
 	}
@@ -411,16 +381,10 @@ func UpdateOrganization(ctx *gin.Context) {
 		var parentOrg models.Organization
 		if err := db.First(&parentOrg, *req.ParentID).Error; err != nil {
 			if err == gorm.ErrRecordNotFound {
-				ctx.JSON(http.StatusBadRequest, gin.H{
-					"error":   "Parent organization not found",
-					"message": "The specified parent organization does not exist",
-				})
+				apperrors.Respond(ctx, apperrors.BadRequest("Parent organization not found").WithDetails("The specified parent organization does not exist"))
 				return
 			}
-			ctx.JSON(http.StatusInternalServerError, gin.H{
-				"error":   "Failed to validate parent organization",
-				"message": err.Error(),
-			})
+			apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to validate parent organization"))
 			return
 		}
 	}
@@ -428,11 +392,8 @@ func UpdateOrganization(ctx *gin.Context) {
 	// Check if slug already exists (if slug is being changed)
 	if req.Slug != "" && req.Slug != org.Slug {
 		var existingOrg models.Organization
-		if err := db.Where("slug = ? AND id != ?", req.Slug, orgUUID).First(&existingOrg).Error; err == nil {
-			ctx.JSON(http.StatusConflict, gin.H{
-				"error":   "Slug already exists",
-				"message": "An organization with this slug already exists",
-			})
+		if err := db.Unscoped().Where("slug = ? AND id != ?", req.Slug, orgUUID).First(&existingOrg).Error; err == nil {
+			apperrors.Respond(ctx, apperrors.Conflict("Slug already exists").WithDetails("An organization with this slug already exists"))
 			return
 		}
 	}
@@ -454,25 +415,28 @@ func UpdateOrganization(ctx *gin.Context) {
 		org.ParentID = req.ParentID
 	}
 
-	if err := db.Save(&org).Error; err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to update organization",
-			"message": err.Error(),
-		})
+	err = concurrency.Updates(db, &org, expectedVersion, map[string]interface{}{
+		"name":      org.Name,
+		"slug":      org.Slug,
+		"status":    org.Status,
+		"owner_id":  org.OwnerID,
+		"parent_id": org.ParentID,
+	})
+	if errors.Is(err, concurrency.ErrVersionConflict) {
+		respondVersionConflict(ctx, err)
 		return
 	}
-
-	orgResponse := OrganizationResponse{
-		ID:        org.ID,
-		Name:      org.Name,
-		Slug:      org.Slug,
-		Status:    org.Status,
-		OwnerID:   org.OwnerID,
-		ParentID:  org.ParentID,
-		CreatedAt: org.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt: org.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to update organization"))
+		return
 	}
 
+	db.First(&org, org.ID)
+	orgResponse := buildOrganizationResponse(org)
+
+	emitEvent(ctx, messaging.EventOrganizationUpdated, orgResponse)
+
+	concurrency.SetETag(ctx, org.UpdatedAt)
 	ctx.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "Organization updated successfully",
@@ -480,9 +444,9 @@ func UpdateOrganization(ctx *gin.Context) {
 	})
 }
 
-// DeleteOrganization deletes an organization (soft delete by setting inactive status)
+// DeleteOrganization deletes an organization (soft delete, restorable until purged)
 // @Summary Delete an organization
-// @Description Delete an organization if it has no child organizations, users, or roles
+// @Description Move an organization to trash if it has no child organizations, users, or roles
 // @Tags organizations
 // @Accept json
 // @Produce json
@@ -499,29 +463,20 @@ func DeleteOrganization(ctx *gin.Context) {
 	orgID := ctx.Param("id")
 	orgUUID, err := uuid.Parse(orgID)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid organization ID format",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid organization ID format"))
 		return
 	}
 
-	db := database.DB
+	db := requestDB(ctx)
 
 	// Check if organization exists
 	var org models.Organization
 	if err := db.First(&org, orgUUID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			ctx.JSON(http.StatusNotFound, gin.H{
-				"error":   "Organization not found",
-				"message": "Organization with the given ID does not exist",
-			})
+			apperrors.Respond(ctx, apperrors.NotFound("Organization not found").WithDetails("Organization with the given ID does not exist"))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to retrieve organization",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to retrieve organization"))
 		return
 	}
 
@@ -529,10 +484,7 @@ func DeleteOrganization(ctx *gin.Context) {
 	var childCount int64
 	db.Model(&models.Organization{}).Where("parent_id = ?", orgUUID).Count(&childCount)
 	if childCount > 0 {
-		ctx.JSON(http.StatusConflict, gin.H{
-			"error":   "Organization has child organizations",
-			"message": "Cannot delete organization that has child organizations",
-		})
+		apperrors.Respond(ctx, apperrors.Conflict("Organization has child organizations").WithDetails("Cannot delete organization that has child organizations"))
 		return
 	}
 
@@ -540,10 +492,7 @@ func DeleteOrganization(ctx *gin.Context) {
 	var userCount int64
 	db.Model(&models.User{}).Where("organization_id = ?", orgUUID).Count(&userCount)
 	if userCount > 0 {
-		ctx.JSON(http.StatusConflict, gin.H{
-			"error":   "Organization has users",
-			"message": "Cannot delete organization that has users",
-		})
+		apperrors.Respond(ctx, apperrors.Conflict("Organization has users").WithDetails("Cannot delete organization that has users"))
 		return
 	}
 
@@ -551,22 +500,18 @@ func DeleteOrganization(ctx *gin.Context) {
 	var roleCount int64
 	db.Model(&models.Role{}).Where("organization_id = ?", orgUUID).Count(&roleCount)
 	if roleCount > 0 {
-		ctx.JSON(http.StatusConflict, gin.H{
-			"error":   "Organization has roles",
-			"message": "Cannot delete organization that has roles",
-		})
+		apperrors.Respond(ctx, apperrors.Conflict("Organization has roles").WithDetails("Cannot delete organization that has roles"))
 		return
 	}
 
 	// Delete the organization
 	if err := db.Delete(&org).Error; err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to delete organization",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to delete organization"))
 		return
 	}
 
+	emitEvent(ctx, messaging.EventOrganizationDeleted, buildOrganizationResponse(org))
+
 	ctx.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "Organization deleted successfully",
@@ -591,29 +536,20 @@ func GetOrganizationPermissions(ctx *gin.Context) {
 	orgID := ctx.Param("id")
 	orgUUID, err := uuid.Parse(orgID)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid organization ID format",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid organization ID format"))
 		return
 	}
 
-	db := database.DB
+	db := requestDB(ctx)
 
 	// Check if organization exists
 	var org models.Organization
 	if err := db.First(&org, orgUUID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			ctx.JSON(http.StatusNotFound, gin.H{
-				"error":   "Organization not found",
-				"message": "Organization with the given ID does not exist",
-			})
+			apperrors.Respond(ctx, apperrors.NotFound("Organization not found").WithDetails("Organization with the given ID does not exist"))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to retrieve organization",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to retrieve organization"))
 		return
 	}
 
@@ -0,0 +1,302 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models"
+	"forgecrud-backend/shared/tenancy"
+	"forgecrud-backend/shared/utils/validation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// UpdateQuotaRequest represents request body for changing an organization's quota.
+// Omitted limits are left unchanged, 0 means unlimited.
+type UpdateQuotaRequest struct {
+	MaxUsers                *int   `json:"max_users" binding:"omitempty,min=0"`
+	MaxStorageBytes         *int64 `json:"max_storage_bytes" binding:"omitempty,min=0"`
+	MaxDocuments            *int   `json:"max_documents" binding:"omitempty,min=0"`
+	MaxAPIRequestsPerDay    *int   `json:"max_api_requests_per_day" binding:"omitempty,min=0"`
+	MaxAPIRequestsPerMinute *int   `json:"max_api_requests_per_minute" binding:"omitempty,min=0"`
+}
+
+// UpdateUserQuotaRequest represents request body for changing a user's storage quota (0 = unlimited)
+type UpdateUserQuotaRequest struct {
+	MaxStorageBytes *int64 `json:"max_storage_bytes" binding:"required,min=0"`
+}
+
+// respondQuotaExceeded writes the 402 response for a QuotaExceededError and reports whether err was one
+func respondQuotaExceeded(ctx *gin.Context, err error) bool {
+	var quotaErr *database.QuotaExceededError
+	if !errors.As(err, &quotaErr) {
+		return false
+	}
+
+	apperrors.Respond(ctx, apperrors.Wrap(quotaErr, apperrors.CodeQuotaExceeded, "Quota exceeded").With("quota", quotaErr))
+	return true
+}
+
+// quotaOrganizationID returns the organization a new record is counted against: the requested one,
+// or the caller's organization which the tenancy layer assigns when none is given
+func quotaOrganizationID(ctx *gin.Context, requested *uuid.UUID) *uuid.UUID {
+	if requested != nil {
+		return requested
+	}
+	if tenant, ok := tenancy.FromContext(ctx.Request.Context()); ok && !tenant.Bypass {
+		return tenant.OrganizationID
+	}
+	return nil
+}
+
+// GetOrganizationUsage returns the quota and current usage of an organization
+// @Summary Get organization usage
+// @Description Get the quota limits of an organization together with its current users, documents, storage and API requests today and in the current minute
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Param id path string true "Organization ID" format(uuid)
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Quota and usage"
+// @Failure 400 {object} map[string]string "Invalid organization ID format"
+// @Failure 404 {object} map[string]string "Organization not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /organizations/{id}/usage [get]
+func GetOrganizationUsage(ctx *gin.Context) {
+	org, ok := findQuotaOrganization(ctx)
+	if !ok {
+		return
+	}
+
+	quota, err := database.GetOrganizationQuota(database.DB, org.ID)
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to retrieve quota"))
+		return
+	}
+
+	usage, err := database.GetOrganizationUsage(database.DB, org.ID)
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to retrieve usage"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"organization_id": org.ID,
+			"quota":           quota,
+			"usage":           usage,
+		},
+	})
+}
+
+// GetOrganizationAPIUsage returns the daily API request counters of an organization
+// @Summary Get organization API usage
+// @Description Get the API requests an organization made on each of the last days (UTC), with the most requests in one minute of each day, next to its daily and per-minute budgets
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Param id path string true "Organization ID" format(uuid)
+// @Param days query int false "Number of days, including today (default 30, max 366)"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Budgets and daily usage"
+// @Failure 400 {object} map[string]string "Invalid organization ID format or days"
+// @Failure 404 {object} map[string]string "Organization not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /organizations/{id}/usage/api [get]
+func GetOrganizationAPIUsage(ctx *gin.Context) {
+	days, err := strconv.Atoi(ctx.DefaultQuery("days", "30"))
+	if err != nil || days < 1 || days > 366 {
+		apperrors.Respond(ctx, apperrors.BadRequest("days must be a number from 1 to 366"))
+		return
+	}
+
+	org, ok := findQuotaOrganization(ctx)
+	if !ok {
+		return
+	}
+
+	quota, err := database.GetOrganizationQuota(database.DB, org.ID)
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to retrieve quota"))
+		return
+	}
+
+	history, err := database.GetOrganizationAPIUsageHistory(database.DB, org.ID, days)
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to retrieve usage"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"organization_id":             org.ID,
+			"max_api_requests_per_day":    quota.MaxAPIRequestsPerDay,
+			"max_api_requests_per_minute": quota.MaxAPIRequestsPerMinute,
+			"days":                        history,
+		},
+	})
+}
+
+// UpdateOrganizationQuota sets the quota limits of an organization
+// @Summary Update organization quota
+// @Description Set the max users, storage bytes, documents and daily and per-minute API requests of an organization (0 = unlimited)
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Param id path string true "Organization ID" format(uuid)
+// @Param request body UpdateQuotaRequest true "Quota limits"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Updated quota"
+// @Failure 400 {object} map[string]string "Invalid request"
+// @Failure 403 {object} map[string]string "Only super admins can change quotas"
+// @Failure 404 {object} map[string]string "Organization not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /organizations/{id}/quota [put]
+func UpdateOrganizationQuota(ctx *gin.Context) {
+	// Organization admins may see their usage but only super admins change limits
+	if tenant, ok := tenancy.FromContext(ctx.Request.Context()); ok && !tenant.Bypass {
+		apperrors.Respond(ctx, apperrors.Forbidden("Insufficient permissions").WithDetails("Only super admins can change organization quotas"))
+		return
+	}
+
+	org, ok := findQuotaOrganization(ctx)
+	if !ok {
+		return
+	}
+
+	var req UpdateQuotaRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apperrors.Respond(ctx, validation.Error(err, "Invalid request"))
+		return
+	}
+
+	db := requestDB(ctx)
+
+	quota, err := database.GetOrganizationQuota(db, org.ID)
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to retrieve quota"))
+		return
+	}
+
+	if req.MaxUsers != nil {
+		quota.MaxUsers = *req.MaxUsers
+	}
+	if req.MaxStorageBytes != nil {
+		quota.MaxStorageBytes = *req.MaxStorageBytes
+	}
+	if req.MaxDocuments != nil {
+		quota.MaxDocuments = *req.MaxDocuments
+	}
+	if req.MaxAPIRequestsPerDay != nil {
+		quota.MaxAPIRequestsPerDay = *req.MaxAPIRequestsPerDay
+	}
+	if req.MaxAPIRequestsPerMinute != nil {
+		quota.MaxAPIRequestsPerMinute = *req.MaxAPIRequestsPerMinute
+	}
+
+	// Organizations on the configured defaults get their own row on the first change
+	if err := db.Save(&quota).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to update quota"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Quota updated successfully",
+		"data":    quota,
+	})
+}
+
+// UpdateUserQuota sets the storage quota of a user
+// @Summary Update user storage quota
+// @Description Set the max storage bytes of the folders a user owns (0 = unlimited). Uploads over the limit are rejected with 413.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID" format(uuid)
+// @Param request body UpdateUserQuotaRequest true "Storage limit"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Updated quota"
+// @Failure 400 {object} map[string]string "Invalid request"
+// @Failure 403 {object} map[string]string "Only super admins can change quotas"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /users/{id}/quota [put]
+func UpdateUserQuota(ctx *gin.Context) {
+	if tenant, ok := tenancy.FromContext(ctx.Request.Context()); ok && !tenant.Bypass {
+		apperrors.Respond(ctx, apperrors.Forbidden("Insufficient permissions").WithDetails("Only super admins can change user quotas"))
+		return
+	}
+
+	userUUID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid user ID format"))
+		return
+	}
+
+	var req UpdateUserQuotaRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apperrors.Respond(ctx, validation.Error(err, "Invalid request"))
+		return
+	}
+
+	db := requestDB(ctx)
+
+	var user models.User
+	if err := db.First(&user, userUUID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			apperrors.Respond(ctx, apperrors.NotFound("User not found").WithDetails("User with the given ID does not exist"))
+			return
+		}
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to retrieve user"))
+		return
+	}
+
+	quota, err := database.GetUserQuota(db, user.ID)
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to retrieve quota"))
+		return
+	}
+	quota.MaxStorageBytes = *req.MaxStorageBytes
+
+	// Users on the configured default get their own row on the first change
+	if err := db.Save(&quota).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to update quota"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Quota updated successfully",
+		"data":    quota,
+	})
+}
+
+// findQuotaOrganization loads the organization from the :id path parameter and writes the error response if needed
+func findQuotaOrganization(ctx *gin.Context) (models.Organization, bool) {
+	var org models.Organization
+
+	orgUUID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid organization ID format"))
+		return org, false
+	}
+
+	if err := requestDB(ctx).First(&org, orgUUID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			apperrors.Respond(ctx, apperrors.NotFound("Organization not found").WithDetails("Organization with the given ID does not exist"))
+			return org, false
+		}
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to retrieve organization"))
+		return org, false
+	}
+
+	return org, true
+}
@@ -0,0 +1,495 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/config"
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models"
+	"forgecrud-backend/shared/tenancy"
+	utils "forgecrud-backend/shared/utils/auth"
+	"forgecrud-backend/shared/utils/query"
+	"forgecrud-backend/shared/utils/validation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RegistrationRuleResponse represents registration rule data for API responses.
+// InviteToken and InviteURL are only returned when an invite rule is created.
+type RegistrationRuleResponse struct {
+	ID             uuid.UUID            `json:"id"`
+	Name           string               `json:"name"`
+	Type           string               `json:"type"`
+	EmailDomain    string               `json:"email_domain,omitempty"`
+	InviteToken    string               `json:"invite_token,omitempty"`
+	InviteURL      string               `json:"invite_url,omitempty"`
+	OrganizationID *uuid.UUID           `json:"organization_id"`
+	Organization   *models.Organization `json:"organization,omitempty"`
+	RoleID         *uuid.UUID           `json:"role_id"`
+	Role           *models.Role         `json:"role,omitempty"`
+	MaxUses        int                  `json:"max_uses"`
+	UseCount       int                  `json:"use_count"`
+	ExpiresAt      *time.Time           `json:"expires_at"`
+	IsActive       bool                 `json:"is_active"`
+	CreatedBy      *uuid.UUID           `json:"created_by"`
+	CreatedAt      string               `json:"created_at"`
+	UpdatedAt      string               `json:"updated_at"`
+}
+
+// CreateRegistrationRuleRequest represents request body for creating a registration rule
+type CreateRegistrationRuleRequest struct {
+	Name           string     `json:"name" binding:"required,max=100"`
+	Type           string     `json:"type" binding:"required,oneof=EMAIL_DOMAIN INVITE"`
+	EmailDomain    string     `json:"email_domain" binding:"omitempty,max=255"` // Required for EMAIL_DOMAIN rules
+	OrganizationID *uuid.UUID `json:"organization_id"`
+	RoleID         *uuid.UUID `json:"role_id"`
+	MaxUses        int        `json:"max_uses" binding:"omitempty,min=0"` // 0 = unlimited
+	ExpiresAt      *time.Time `json:"expires_at"`
+	IsActive       *bool      `json:"is_active"`
+}
+
+// UpdateRegistrationRuleRequest represents request body for updating a registration rule.
+// Omitted fields are left unchanged; the type of a rule cannot change.
+type UpdateRegistrationRuleRequest struct {
+	Name           string     `json:"name" binding:"omitempty,max=100"`
+	EmailDomain    string     `json:"email_domain" binding:"omitempty,max=255"`
+	OrganizationID *uuid.UUID `json:"organization_id"`
+	RoleID         *uuid.UUID `json:"role_id"`
+	MaxUses        *int       `json:"max_uses" binding:"omitempty,min=0"`
+	ExpiresAt      *time.Time `json:"expires_at"`
+	IsActive       *bool      `json:"is_active"`
+}
+
+// RegistrationRuleListResponse represents a list of registration rules with pagination
+type RegistrationRuleListResponse struct {
+	Success bool `json:"success"`
+	Data    struct {
+		Items      []RegistrationRuleResponse `json:"items"`
+		Pagination PaginationResponse         `json:"pagination"`
+	} `json:"data"`
+}
+
+// SingleRegistrationRuleResponse represents a single registration rule response
+type SingleRegistrationRuleResponse struct {
+	Success bool                     `json:"success"`
+	Data    RegistrationRuleResponse `json:"data"`
+}
+
+// buildRegistrationRuleResponse converts a registration rule model to its API representation
+func buildRegistrationRuleResponse(rule models.RegistrationRule) RegistrationRuleResponse {
+	return RegistrationRuleResponse{
+		ID:             rule.ID,
+		Name:           rule.Name,
+		Type:           rule.Type,
+		EmailDomain:    rule.EmailDomain,
+		OrganizationID: rule.OrganizationID,
+		Organization:   rule.Organization,
+		RoleID:         rule.RoleID,
+		Role:           rule.Role,
+		MaxUses:        rule.MaxUses,
+		UseCount:       rule.UseCount,
+		ExpiresAt:      rule.ExpiresAt,
+		IsActive:       rule.IsActive,
+		CreatedBy:      rule.CreatedBy,
+		CreatedAt:      rule.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:      rule.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
+
+// normalizeEmailDomain lowercases the domain and drops a leading "@"
+func normalizeEmailDomain(domain string) (string, error) {
+	domain = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(domain), "@"))
+	if domain == "" || strings.ContainsAny(domain, "@ ") || !strings.Contains(domain, ".") {
+		return "", fmt.Errorf("email_domain must be a domain such as example.com")
+	}
+	return domain, nil
+}
+
+// validateRegistrationRule checks the rule's targets and writes the error response on failure.
+// The role must be global or belong to the rule's organization.
+func validateRegistrationRule(ctx *gin.Context, db *gorm.DB, rule *models.RegistrationRule) bool {
+	if rule.OrganizationID == nil && rule.RoleID == nil {
+		apperrors.Respond(ctx, apperrors.BadRequest("Invalid registration rule").WithDetails("organization_id, role_id or both are required"))
+		return false
+	}
+
+	if rule.OrganizationID != nil {
+		var org models.Organization
+		if err := db.First(&org, *rule.OrganizationID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				apperrors.Respond(ctx, apperrors.BadRequest("Organization not found").WithDetails("The specified organization does not exist"))
+				return false
+			}
+			apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to validate organization"))
+			return false
+		}
+	}
+
+	if rule.RoleID != nil {
+		var role models.Role
+		if err := db.First(&role, *rule.RoleID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				apperrors.Respond(ctx, apperrors.BadRequest("Role not found").WithDetails("The specified role does not exist"))
+				return false
+			}
+			apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to validate role"))
+			return false
+		}
+		if role.OrganizationID != nil && (rule.OrganizationID == nil || *role.OrganizationID != *rule.OrganizationID) {
+			apperrors.Respond(ctx, apperrors.BadRequest("Role belongs to another organization").WithDetails("The role must be global or belong to the rule's organization"))
+			return false
+		}
+	}
+
+	// Domains are matched across all organizations, so each may only have one active rule
+	if rule.Type == models.RegistrationRuleEmailDomain && rule.IsActive {
+		var count int64
+		if err := database.DB.Model(&models.RegistrationRule{}).
+			Where("type = ? AND email_domain = ? AND is_active = ? AND id <> ?", models.RegistrationRuleEmailDomain, rule.EmailDomain, true, rule.ID).
+			Count(&count).Error; err != nil {
+			apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to validate email domain"))
+			return false
+		}
+		if count > 0 {
+			apperrors.Respond(ctx, apperrors.Conflict("Email domain already has a registration rule").WithDetails("Deactivate the other rule for this domain first"))
+			return false
+		}
+	}
+
+	return true
+}
+
+// requireDomainRuleAdmin only lets super admins manage email domain rules: any user can register
+// with an address of a domain, so a rule decides who joins an organization across all tenants
+func requireDomainRuleAdmin(ctx *gin.Context, ruleType string) bool {
+	if ruleType != models.RegistrationRuleEmailDomain {
+		return true
+	}
+	if tenant, ok := tenancy.FromContext(ctx.Request.Context()); ok && !tenant.Bypass {
+		apperrors.Respond(ctx, apperrors.Forbidden("Insufficient permissions").WithDetails("Only super admins can manage email domain rules"))
+		return false
+	}
+	return true
+}
+
+// findRegistrationRule loads a registration rule by the :id path parameter and writes the error response on failure
+func findRegistrationRule(ctx *gin.Context, db *gorm.DB) (*models.RegistrationRule, bool) {
+	ruleUUID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid registration rule ID format"))
+		return nil, false
+	}
+
+	var rule models.RegistrationRule
+	if err := db.Preload("Organization").Preload("Role").First(&rule, ruleUUID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			apperrors.Respond(ctx, apperrors.NotFound("Registration rule not found").WithDetails("Registration rule with the given ID does not exist"))
+			return nil, false
+		}
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to retrieve registration rule"))
+		return nil, false
+	}
+
+	return &rule, true
+}
+
+// GetRegistrationRules retrieves the registration rules
+// @Summary Get registration rules
+// @Description Get the rules assigning an organization and role to self-registered users, with pagination, filtering and search
+// @Tags registration-rules
+// @Accept json
+// @Produce json
+// @Param page query int false "Page number (default: 1)"
+// @Param limit query int false "Items per page (default: 10)"
+// @Param search query string false "Search term across name and email domain"
+// @Param filters[type] query string false "Filter by type (EMAIL_DOMAIN, INVITE)"
+// @Param filters[organization_id] query string false "Filter by organization ID"
+// @Param filters[is_active] query string false "Filter by active status"
+// @Param sort[field] query string false "Sort field (name, type, use_count, expires_at, created_at)"
+// @Param sort[order] query string false "Sort order (asc, desc)"
+// @Security BearerAuth
+// @Success 200 {object} handlers.RegistrationRuleListResponse
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /registration-rules [get]
+func GetRegistrationRules(ctx *gin.Context) {
+	db := requestDB(ctx)
+
+	params := query.ParseQueryParams(ctx)
+
+	allowedFilters := map[string]string{
+		"type":            "type",
+		"organization_id": "organization_id",
+		"role_id":         "role_id",
+		"is_active":       "is_active",
+	}
+	allowedSortFields := map[string]string{
+		"name":       "name",
+		"type":       "type",
+		"use_count":  "use_count",
+		"expires_at": "expires_at",
+		"created_at": "created_at",
+	}
+	searchFields := []string{"name", "email_domain"}
+
+	baseQuery := db.Model(&models.RegistrationRule{}).Preload("Organization").Preload("Role")
+	filteredQuery := query.ApplyFilters(baseQuery, params.Filters, allowedFilters)
+	searchedQuery := query.ApplySearch(filteredQuery, params.Search, searchFields)
+
+	var total int64
+	searchedQuery.Count(&total)
+
+	finalQuery := query.ApplySort(searchedQuery, params.Sort, allowedSortFields)
+	finalQuery = query.ApplyPagination(finalQuery, params.Page, params.Limit)
+
+	var rules []models.RegistrationRule
+	if err := finalQuery.Find(&rules).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to retrieve registration rules"))
+		return
+	}
+
+	ruleResponses := make([]RegistrationRuleResponse, 0, len(rules))
+	for _, rule := range rules {
+		ruleResponses = append(ruleResponses, buildRegistrationRuleResponse(rule))
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"registration_rules": ruleResponses,
+			"pagination":         query.BuildPaginationResponse(params.Page, params.Limit, total),
+		},
+	})
+}
+
+// CreateRegistrationRule creates a registration rule
+// @Summary Create a registration rule
+// @Description Create a rule assigning an organization, a role or both to self-registered users. EMAIL_DOMAIN rules apply once a user verifies an
+// @Description email address of the domain and can only be managed by super admins. INVITE rules apply to users registering with the generated
+// @Description invite token, which is only returned in this response.
+// @Tags registration-rules
+// @Accept json
+// @Produce json
+// @Param rule body CreateRegistrationRuleRequest true "Registration rule"
+// @Security BearerAuth
+// @Success 201 {object} handlers.SingleRegistrationRuleResponse "Created registration rule"
+// @Failure 400 {object} map[string]string "Invalid request data, organization or role not found"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Only super admins can manage email domain rules"
+// @Failure 409 {object} map[string]string "Email domain already has a registration rule"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /registration-rules [post]
+func CreateRegistrationRule(ctx *gin.Context) {
+	var req CreateRegistrationRuleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apperrors.Respond(ctx, validation.Error(err, "Invalid request body"))
+		return
+	}
+
+	if !requireDomainRuleAdmin(ctx, req.Type) {
+		return
+	}
+
+	rule := models.RegistrationRule{
+		Name:           req.Name,
+		Type:           req.Type,
+		OrganizationID: quotaOrganizationID(ctx, req.OrganizationID),
+		RoleID:         req.RoleID,
+		MaxUses:        req.MaxUses,
+		ExpiresAt:      req.ExpiresAt,
+		IsActive:       req.IsActive == nil || *req.IsActive,
+		CreatedBy:      utils.GetActorID(ctx),
+	}
+
+	var inviteToken string
+	switch req.Type {
+	case models.RegistrationRuleEmailDomain:
+		domain, err := normalizeEmailDomain(req.EmailDomain)
+		if err != nil {
+			apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid email domain"))
+			return
+		}
+		rule.EmailDomain = domain
+	case models.RegistrationRuleInvite:
+		if req.EmailDomain != "" {
+			apperrors.Respond(ctx, apperrors.BadRequest("Invalid registration rule").WithDetails("email_domain is only used by EMAIL_DOMAIN rules"))
+			return
+		}
+		token, err := utils.GenerateRandomToken(24)
+		if err != nil {
+			apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to generate invite token"))
+			return
+		}
+		inviteToken = token
+		rule.InviteToken = &inviteToken
+	}
+
+	db := requestDB(ctx)
+	if !validateRegistrationRule(ctx, db, &rule) {
+		return
+	}
+
+	if err := db.Create(&rule).Error; err != nil {
+		if errors.Is(err, database.ErrTenantMismatch) {
+			apperrors.Respond(ctx, apperrors.Forbidden("Cannot create registration rules for another organization"))
+			return
+		}
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to create registration rule"))
+		return
+	}
+
+	// GORM skips false for fields with a default tag on create
+	if !rule.IsActive {
+		db.Model(&rule).Update("is_active", false)
+	}
+
+	db.Preload("Organization").Preload("Role").First(&rule, rule.ID)
+
+	response := buildRegistrationRuleResponse(rule)
+	if inviteToken != "" {
+		response.InviteToken = inviteToken
+		response.InviteURL = fmt.Sprintf("%s/auth/register?invite=%s", config.GetConfig().FrontendURL, inviteToken)
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Registration rule created successfully",
+		"data":    response,
+	})
+}
+
+// UpdateRegistrationRule updates a registration rule
+// @Summary Update a registration rule
+// @Description Update a registration rule's name, email domain, organization, role, use limit, expiry or active status
+// @Tags registration-rules
+// @Accept json
+// @Produce json
+// @Param id path string true "Registration rule ID" format(uuid)
+// @Param rule body UpdateRegistrationRuleRequest true "Updated registration rule"
+// @Security BearerAuth
+// @Success 200 {object} handlers.SingleRegistrationRuleResponse "Updated registration rule"
+// @Failure 400 {object} map[string]string "Invalid request data, ID format, organization or role not found"
+// @Failure 403 {object} map[string]string "Only super admins can manage email domain rules"
+// @Failure 404 {object} map[string]string "Registration rule not found"
+// @Failure 409 {object} map[string]string "Email domain already has a registration rule"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /registration-rules/{id} [put]
+func UpdateRegistrationRule(ctx *gin.Context) {
+	db := requestDB(ctx)
+
+	rule, ok := findRegistrationRule(ctx, db)
+	if !ok {
+		return
+	}
+
+	if !requireDomainRuleAdmin(ctx, rule.Type) {
+		return
+	}
+
+	var req UpdateRegistrationRuleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apperrors.Respond(ctx, validation.Error(err, "Invalid request body"))
+		return
+	}
+
+	if req.Name != "" {
+		rule.Name = req.Name
+	}
+	if req.EmailDomain != "" {
+		if rule.Type != models.RegistrationRuleEmailDomain {
+			apperrors.Respond(ctx, apperrors.BadRequest("Invalid registration rule").WithDetails("email_domain is only used by EMAIL_DOMAIN rules"))
+			return
+		}
+		domain, err := normalizeEmailDomain(req.EmailDomain)
+		if err != nil {
+			apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid email domain"))
+			return
+		}
+		rule.EmailDomain = domain
+	}
+	if req.OrganizationID != nil {
+		rule.OrganizationID = req.OrganizationID
+	}
+	if req.RoleID != nil {
+		rule.RoleID = req.RoleID
+	}
+	if req.MaxUses != nil {
+		rule.MaxUses = *req.MaxUses
+	}
+	if req.ExpiresAt != nil {
+		rule.ExpiresAt = req.ExpiresAt
+	}
+	if req.IsActive != nil {
+		rule.IsActive = *req.IsActive
+	}
+
+	if !validateRegistrationRule(ctx, db, rule) {
+		return
+	}
+
+	err := db.Model(rule).Updates(map[string]interface{}{
+		"name":            rule.Name,
+		"email_domain":    rule.EmailDomain,
+		"organization_id": rule.OrganizationID,
+		"role_id":         rule.RoleID,
+		"max_uses":        rule.MaxUses,
+		"expires_at":      rule.ExpiresAt,
+		"is_active":       rule.IsActive,
+	}).Error
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to update registration rule"))
+		return
+	}
+
+	db.Preload("Organization").Preload("Role").First(rule, rule.ID)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Registration rule updated successfully",
+		"data":    buildRegistrationRuleResponse(*rule),
+	})
+}
+
+// DeleteRegistrationRule deletes a registration rule
+// @Summary Delete a registration rule
+// @Description Delete a registration rule. Users it already assigned keep their organization and role; its invite token stops working.
+// @Tags registration-rules
+// @Accept json
+// @Produce json
+// @Param id path string true "Registration rule ID" format(uuid)
+// @Security BearerAuth
+// @Success 200 {object} handlers.SuccessResponse "Registration rule deleted successfully"
+// @Failure 400 {object} map[string]string "Invalid registration rule ID format"
+// @Failure 403 {object} map[string]string "Only super admins can manage email domain rules"
+// @Failure 404 {object} map[string]string "Registration rule not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /registration-rules/{id} [delete]
+func DeleteRegistrationRule(ctx *gin.Context) {
+	db := requestDB(ctx)
+
+	rule, ok := findRegistrationRule(ctx, db)
+	if !ok {
+		return
+	}
+
+	if !requireDomainRuleAdmin(ctx, rule.Type) {
+		return
+	}
+
+	if err := db.Delete(rule).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to delete registration rule"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Registration rule deleted successfully",
+	})
+}
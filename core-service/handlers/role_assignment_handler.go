@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"forgecrud-backend/core-service/services"
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/database/models"
+	"forgecrud-backend/shared/messaging"
+	utils "forgecrud-backend/shared/utils/auth"
+	"forgecrud-backend/shared/utils/validation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ScheduleRoleChangeRequest represents request body for scheduling a role change
+type ScheduleRoleChangeRequest struct {
+	RoleID      *uuid.UUID `json:"role_id"` // null removes the user's role
+	EffectiveAt time.Time  `json:"effective_at" binding:"required"`
+	Reason      string     `json:"reason"`
+}
+
+// GetUserRoleHistory lists the role assignments of a user
+// @Summary Get user role history
+// @Description Get every role assignment, change and removal of a user with actor, source and timestamp, newest first
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID" format(uuid)
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Role assignments"
+// @Failure 400 {object} map[string]string "Invalid user ID format"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /users/{id}/role-history [get]
+func GetUserRoleHistory(ctx *gin.Context) {
+	user, ok := findLifecycleUser(ctx)
+	if !ok {
+		return
+	}
+
+	var assignments []models.RoleAssignment
+	if err := requestDB(ctx).
+		Preload("PreviousRole", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Preload("Role", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Where("user_id = ?", user.ID).
+		Order("created_at DESC").
+		Find(&assignments).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to retrieve role history"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    assignments,
+	})
+}
+
+// GetScheduledRoleChanges lists the scheduled role changes of a user
+// @Summary Get scheduled role changes
+// @Description Get the scheduled role changes of a user, optionally filtered by status (PENDING, EXECUTED, CANCELLED, FAILED)
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID" format(uuid)
+// @Param status query string false "Filter by status"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Scheduled role changes"
+// @Failure 400 {object} map[string]string "Invalid user ID format"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /users/{id}/role-changes [get]
+func GetScheduledRoleChanges(ctx *gin.Context) {
+	user, ok := findLifecycleUser(ctx)
+	if !ok {
+		return
+	}
+
+	query := requestDB(ctx).Preload("Role").Where("user_id = ?", user.ID)
+	if status := ctx.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var changes []models.ScheduledRoleChange
+	if err := query.Order("effective_at").Find(&changes).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to retrieve scheduled role changes"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    changes,
+	})
+}
+
+// ScheduleRoleChange schedules a change of a user's role
+// @Summary Schedule a role change
+// @Description Set the user's role (or remove it with role_id null) at a future date. The change is applied by a background scheduler and the user is notified.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID" format(uuid)
+// @Param request body ScheduleRoleChangeRequest true "Role change"
+// @Security BearerAuth
+// @Success 201 {object} map[string]interface{} "Scheduled role change"
+// @Failure 400 {object} map[string]string "Invalid request data"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /users/{id}/role-changes [post]
+func ScheduleRoleChange(ctx *gin.Context) {
+	user, ok := findLifecycleUser(ctx)
+	if !ok {
+		return
+	}
+
+	var req ScheduleRoleChangeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apperrors.Respond(ctx, validation.Error(err, "Invalid request data"))
+		return
+	}
+
+	if !req.EffectiveAt.After(time.Now()) {
+		apperrors.Respond(ctx, apperrors.BadRequest("Invalid effective date").WithDetails("effective_at must be in the future"))
+		return
+	}
+
+	db := requestDB(ctx)
+
+	if req.RoleID != nil {
+		var role models.Role
+		if err := db.First(&role, *req.RoleID).Error; err != nil {
+			apperrors.Respond(ctx, apperrors.BadRequest("Invalid role ID").WithDetails("Role not found"))
+			return
+		}
+	}
+
+	change := models.ScheduledRoleChange{
+		UserID:      user.ID,
+		RoleID:      req.RoleID,
+		EffectiveAt: req.EffectiveAt.UTC(),
+		Status:      models.ScheduledRoleChangePending,
+		Reason:      req.Reason,
+		CreatedBy:   utils.GetActorID(ctx),
+	}
+	if err := db.Create(&change).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to schedule role change"))
+		return
+	}
+
+	db.Preload("Role").First(&change, change.ID)
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Role change scheduled successfully",
+		"data":    change,
+	})
+}
+
+// CancelScheduledRoleChange cancels a pending role change
+// @Summary Cancel a scheduled role change
+// @Description Cancel a role change that has not been applied yet
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID" format(uuid)
+// @Param change_id path string true "Scheduled role change ID" format(uuid)
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Cancelled role change"
+// @Failure 400 {object} map[string]string "Invalid ID format"
+// @Failure 404 {object} map[string]string "Scheduled role change not found"
+// @Failure 409 {object} map[string]string "Role change is no longer pending"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /users/{id}/role-changes/{change_id} [delete]
+func CancelScheduledRoleChange(ctx *gin.Context) {
+	user, ok := findLifecycleUser(ctx)
+	if !ok {
+		return
+	}
+
+	changeID, err := uuid.Parse(ctx.Param("change_id"))
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid scheduled role change ID format"))
+		return
+	}
+
+	db := requestDB(ctx)
+
+	var change models.ScheduledRoleChange
+	if err := db.Where("id = ? AND user_id = ?", changeID, user.ID).First(&change).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			apperrors.Respond(ctx, apperrors.NotFound("Scheduled role change not found").WithDetails("Scheduled role change with the given ID does not exist"))
+			return
+		}
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to retrieve scheduled role change"))
+		return
+	}
+
+	if change.Status != models.ScheduledRoleChangePending {
+		apperrors.Respond(ctx, apperrors.Conflict("Role change is no longer pending").WithDetails("The role change is already "+change.Status))
+		return
+	}
+
+	// Only cancel if the scheduler has not picked it up in the meantime
+	result := db.Model(&change).Where("status = ?", models.ScheduledRoleChangePending).
+		Update("status", models.ScheduledRoleChangeCancelled)
+	if result.Error != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(result.Error, apperrors.CodeInternal, "Failed to cancel scheduled role change"))
+		return
+	}
+	if result.RowsAffected == 0 {
+		apperrors.Respond(ctx, apperrors.Conflict("Role change is no longer pending").WithDetails("The role change is being applied"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Scheduled role change cancelled successfully",
+		"data":    change,
+	})
+}
+
+// publishRoleChanged notifies the user about a role change made through the API
+func publishRoleChanged(ctx *gin.Context, user models.User, assignment *models.RoleAssignment) {
+	if assignment == nil {
+		return
+	}
+	messaging.Publish(ctx.Request.Context(), messaging.EventUserRoleChanged, utils.GetActorID(ctx),
+		services.RoleChangedActivity(requestDB(ctx), user, assignment))
+}
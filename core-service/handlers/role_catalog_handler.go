@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models"
+	"forgecrud-backend/shared/messaging"
+	"forgecrud-backend/shared/utils/validation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CloneRoleRequest represents request body for cloning a role. Every field is optional.
+type CloneRoleRequest struct {
+	Name           string     `json:"name"`            // Defaults to "<name> (copy)", or the same name in another organization
+	Description    string     `json:"description"`     // Defaults to the description of the role
+	OrganizationID *uuid.UUID `json:"organization_id"` // Defaults to the organization of the role
+}
+
+// CloneRole copies a role and all its permissions
+// @Summary Clone a role
+// @Description Copy a role together with every permission granted to it, optionally into another organization. The copy and its permissions are created in one transaction.
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Param id path string true "Role ID" format(uuid)
+// @Param role body CloneRoleRequest false "Name, description and organization of the copy"
+// @Security BearerAuth
+// @Success 201 {object} handlers.SingleRoleResponse "Created role"
+// @Failure 400 {object} map[string]string "Invalid request data, ID format or organization not found"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Organization belongs to another tenant"
+// @Failure 404 {object} map[string]string "Role not found"
+// @Failure 409 {object} map[string]string "Role name already exists"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /roles/{id}/clone [post]
+func CloneRole(ctx *gin.Context) {
+	roleID := ctx.Param("id")
+	roleUUID, err := uuid.Parse(roleID)
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid role ID format"))
+		return
+	}
+
+	var req CloneRoleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		apperrors.Respond(ctx, validation.Error(err, "Invalid request body"))
+		return
+	}
+
+	db := requestDB(ctx)
+
+	var source models.Role
+	if err := db.First(&source, roleUUID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			apperrors.Respond(ctx, apperrors.NotFound("Role not found").WithDetails("Role with the given ID does not exist"))
+			return
+		}
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to retrieve role"))
+		return
+	}
+
+	organizationID := source.OrganizationID
+	if req.OrganizationID != nil {
+		var org models.Organization
+		if err := db.First(&org, *req.OrganizationID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				apperrors.Respond(ctx, apperrors.BadRequest("Organization not found").WithDetails("The specified organization does not exist"))
+				return
+			}
+			apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to validate organization"))
+			return
+		}
+		organizationID = req.OrganizationID
+	}
+
+	name := req.Name
+	if name == "" {
+		name = source.Name + " (copy)"
+		// A copy into another organization keeps the name
+		if req.OrganizationID != nil && (source.OrganizationID == nil || *source.OrganizationID != *req.OrganizationID) {
+			name = source.Name
+		}
+	}
+	description := req.Description
+	if description == "" {
+		description = source.Description
+	}
+
+	// Check if role name already exists in the target organization
+	var existingRole models.Role
+	query := db.Where("name = ?", name)
+	if organizationID != nil {
+		query = query.Where("organization_id = ?", *organizationID)
+	} else {
+		query = query.Where("organization_id IS NULL")
+	}
+	if err := query.First(&existingRole).Error; err == nil {
+		apperrors.Respond(ctx, apperrors.Conflict("Role name already exists").WithDetails("A role with this name already exists in the specified organization"))
+		return
+	}
+
+	var clone *models.Role
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		clone, err = database.CloneRole(tx, source, organizationID, name, description)
+		return err
+	})
+	if errors.Is(err, database.ErrTenantMismatch) {
+		apperrors.Respond(ctx, apperrors.Forbidden("Cannot clone roles into another organization"))
+		return
+	}
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to clone role"))
+		return
+	}
+
+	role := *clone
+	db.Preload("Organization").First(&role, role.ID)
+
+	roleResponse := buildRoleResponse(role)
+
+	emitEvent(ctx, messaging.EventRoleCreated, roleResponse)
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Role cloned successfully",
+		"data":    roleResponse,
+	})
+}
+
+// GetRoleCatalog lists the global roles stamped into every new organization
+// @Summary Get the role catalog
+// @Description Get the global roles flagged with in_catalog. New organizations get a copy of each, with its permissions.
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Catalog roles"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /roles/catalog [get]
+func GetRoleCatalog(ctx *gin.Context) {
+	var roles []models.Role
+	if err := requestDB(ctx).Where("organization_id IS NULL AND in_catalog = ?", true).Order("name").Find(&roles).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to retrieve role catalog"))
+		return
+	}
+
+	roleResponses := []RoleResponse{}
+	for _, role := range roles {
+		roleResponses = append(roleResponses, buildRoleResponse(role))
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"roles": roleResponses,
+		},
+	})
+}
+
+// StampOrganizationRoles copies the catalog roles an organization does not have yet into it
+// @Summary Stamp the role catalog into an organization
+// @Description Copy every catalog role the organization does not have yet, with its permissions. New organizations are stamped when they are created; use this for organizations created before a role was added to the catalog.
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Param id path string true "Organization ID" format(uuid)
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Roles created"
+// @Failure 400 {object} map[string]string "Invalid organization ID format"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 404 {object} map[string]string "Organization not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /organizations/{id}/roles/catalog [post]
+func StampOrganizationRoles(ctx *gin.Context) {
+	orgID := ctx.Param("id")
+	orgUUID, err := uuid.Parse(orgID)
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid organization ID format"))
+		return
+	}
+
+	db := requestDB(ctx)
+
+	var org models.Organization
+	if err := db.First(&org, orgUUID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			apperrors.Respond(ctx, apperrors.NotFound("Organization not found").WithDetails("Organization with the given ID does not exist"))
+			return
+		}
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to retrieve organization"))
+		return
+	}
+
+	var stamped []models.Role
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		stamped, err = database.StampRoleCatalog(stampingDB(tx), org.ID)
+		return err
+	})
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to stamp role catalog"))
+		return
+	}
+
+	roleResponses := emitStampedRoles(ctx, stamped)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"roles": roleResponses,
+		},
+	})
+}
+
+// stampingDB lets catalog roles be created in an organization the caller can see, such as a new
+// sub-organization, which the tenancy layer would otherwise reject as another organization's
+func stampingDB(tx *gorm.DB) *gorm.DB {
+	return withoutTenancy(tx)
+}
+
+// emitStampedRoles announces the roles stamped from the catalog and returns their responses
+func emitStampedRoles(ctx *gin.Context, roles []models.Role) []RoleResponse {
+	roleResponses := []RoleResponse{}
+	for _, role := range roles {
+		roleResponse := buildRoleResponse(role)
+		emitEvent(ctx, messaging.EventRoleCreated, roleResponse)
+		roleResponses = append(roleResponses, roleResponse)
+	}
+	return roleResponses
+}
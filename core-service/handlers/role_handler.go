@@ -1,11 +1,15 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
-	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/apperrors"
 	"forgecrud-backend/shared/database/models"
+	"forgecrud-backend/shared/messaging"
+	"forgecrud-backend/shared/utils/concurrency"
 	"forgecrud-backend/shared/utils/query"
+	"forgecrud-backend/shared/utils/validation"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -20,8 +24,12 @@ type RoleResponse struct {
 	IsDefault      bool                 `json:"is_default"`
 	Organization   *models.Organization `json:"organization,omitempty"`
 	OrganizationID *uuid.UUID           `json:"organization_id"`
+	InCatalog      bool                 `json:"in_catalog"`
+	SourceRoleID   *uuid.UUID           `json:"source_role_id,omitempty"`
+	Version        string               `json:"version"` // Send back as If-Match or version to detect concurrent updates
 	CreatedAt      string               `json:"created_at"`
 	UpdatedAt      string               `json:"updated_at"`
+	DeletedAt      *string              `json:"deleted_at,omitempty"`
 }
 
 // CreateRoleRequest represents request body for creating role
@@ -30,6 +38,7 @@ type CreateRoleRequest struct {
 	Description    string     `json:"description"`
 	IsDefault      bool       `json:"is_default"`
 	OrganizationID *uuid.UUID `json:"organization_id"`
+	InCatalog      bool       `json:"in_catalog"` // Stamp into every new organization; global roles only
 }
 
 // UpdateRoleRequest represents request body for updating role
@@ -38,6 +47,8 @@ type UpdateRoleRequest struct {
 	Description    string     `json:"description"`
 	IsDefault      bool       `json:"is_default"`
 	OrganizationID *uuid.UUID `json:"organization_id"`
+	InCatalog      *bool      `json:"in_catalog"` // Unchanged when omitted
+	Version        string     `json:"version"`    // Optional precondition, alternative to If-Match
 }
 
 // RoleListResponse represents a list of roles with pagination
@@ -61,6 +72,30 @@ type SuccessResponse struct {
 	Message string `json:"message"`
 }
 
+// buildRoleResponse converts a role model (with preloaded organization) to its API representation
+func buildRoleResponse(role models.Role) RoleResponse {
+	roleResponse := RoleResponse{
+		ID:             role.ID,
+		Name:           role.Name,
+		Description:    role.Description,
+		IsDefault:      role.IsDefault,
+		OrganizationID: role.OrganizationID,
+		InCatalog:      role.InCatalog,
+		SourceRoleID:   role.SourceRoleID,
+		Version:        concurrency.Version(role.UpdatedAt),
+		CreatedAt:      role.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:      role.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		DeletedAt:      formatDeletedAt(role.DeletedAt),
+	}
+
+	// Add organization if exists
+	if role.OrganizationID != nil && role.Organization.ID != uuid.Nil {
+		roleResponse.Organization = &role.Organization
+	}
+
+	return roleResponse
+}
+
 // GetRoles retrieves all roles with pagination and filtering
 // @Summary Get all roles
 // @Description Get all roles with pagination, filtering, sorting and search
@@ -72,15 +107,17 @@ type SuccessResponse struct {
 // @Param search query string false "Search term across name and description"
 // @Param filters[organization_id] query string false "Filter by organization ID"
 // @Param filters[is_default] query string false "Filter by default status (true, false)"
+// @Param filters[in_catalog] query string false "Filter by role catalog membership (true, false)"
 // @Param sort[field] query string false "Sort field (name, description, is_default, created_at, updated_at)"
 // @Param sort[order] query string false "Sort order (asc, desc)"
+// @Param include_deleted query string false "Include soft-deleted roles (true) or list only the trash (only); requires roles:manage"
 // @Security BearerAuth
 // @Success 200 {object} handlers.RoleListResponse
 // @Failure 401 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /roles [get]
 func GetRoles(ctx *gin.Context) {
-	db := database.DB
+	db := requestDB(ctx)
 
 	// Parse standardized query parameters
 	params := query.ParseQueryParams(ctx)
@@ -89,6 +126,8 @@ func GetRoles(ctx *gin.Context) {
 	allowedFilters := map[string]string{
 		"organization_id": "organization_id",
 		"is_default":      "is_default",
+		"in_catalog":      "in_catalog",
+		"created_at":      "created_at",
 	}
 
 	// Define allowed sort fields
@@ -104,7 +143,7 @@ func GetRoles(ctx *gin.Context) {
 	searchFields := []string{"name", "description"}
 
 	// Build base query
-	baseQuery := db.Model(&models.Role{})
+	baseQuery := applyDeletedScope(ctx, db.Model(&models.Role{}).Preload("Organization"))
 
 	// Apply filters
 	filteredQuery := query.ApplyFilters(baseQuery, params.Filters, allowedFilters)
@@ -123,35 +162,14 @@ func GetRoles(ctx *gin.Context) {
 	// Get roles
 	var roles []models.Role
 	if err := finalQuery.Find(&roles).Error; err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to retrieve roles",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to retrieve roles"))
 		return
 	}
 
 	// Convert to response format
 	var roleResponses []RoleResponse
 	for _, role := range roles {
-		roleResponse := RoleResponse{
-			ID:             role.ID,
-			Name:           role.Name,
-			Description:    role.Description,
-			IsDefault:      role.IsDefault,
-			OrganizationID: role.OrganizationID,
-			CreatedAt:      role.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-			UpdatedAt:      role.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		}
-
-		// Add organization if exists
-		if role.OrganizationID != nil {
-			var org models.Organization
-			if err := db.First(&org, *role.OrganizationID).Error; err == nil {
-				roleResponse.Organization = &org
-			}
-		}
-
-		roleResponses = append(roleResponses, roleResponse)
+		roleResponses = append(roleResponses, buildRoleResponse(role))
 	}
 
 	// Build pagination response
@@ -184,49 +202,25 @@ func GetRole(ctx *gin.Context) {
 	roleID := ctx.Param("id")
 	roleUUID, err := uuid.Parse(roleID)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid role ID format",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid role ID format"))
 		return
 	}
 
-	db := database.DB
+	db := requestDB(ctx)
 
 	var role models.Role
 	if err := db.Preload("Organization").First(&role, roleUUID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			ctx.JSON(http.StatusNotFound, gin.H{
-				"error":   "Role not found",
-				"message": "Role with the given ID does not exist",
-			})
+			apperrors.Respond(ctx, apperrors.NotFound("Role not found").WithDetails("Role with the given ID does not exist"))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to retrieve role",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to retrieve role"))
 		return
 	}
 
-	roleResponse := RoleResponse{
-		ID:             role.ID,
-		Name:           role.Name,
-		Description:    role.Description,
-		IsDefault:      role.IsDefault,
-		OrganizationID: role.OrganizationID,
-		CreatedAt:      role.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt:      role.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
-	}
-
-	// Add organization if exists
-	if role.OrganizationID != nil {
-		var org models.Organization
-		if err := db.First(&org, *role.OrganizationID).Error; err == nil {
-			roleResponse.Organization = &org
-		}
-	}
+	roleResponse := buildRoleResponse(role)
 
+	concurrency.SetETag(ctx, role.UpdatedAt)
 	ctx.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"data":    roleResponse,
@@ -235,14 +229,14 @@ func GetRole(ctx *gin.Context) {
 
 // CreateRole creates a new role
 // @Summary Create a new role
-// @Description Create a new role with the provided information
+// @Description Create a new role with the provided information. Global roles with in_catalog are stamped into every new organization.
 // @Tags roles
 // @Accept json
 // @Produce json
 // @Param role body CreateRoleRequest true "Role information"
 // @Security BearerAuth
 // @Success 201 {object} handlers.SingleRoleResponse "Created role"
-// @Failure 400 {object} map[string]string "Invalid request data or organization not found"
+// @Failure 400 {object} map[string]string "Invalid request data, organization not found or catalog role of an organization"
 // @Failure 401 {object} map[string]string "Unauthorized"
 // @Failure 409 {object} map[string]string "Role name already exists"
 // @Failure 500 {object} map[string]string "Server error"
@@ -250,30 +244,27 @@ func GetRole(ctx *gin.Context) {
 func CreateRole(ctx *gin.Context) {
 	var req CreateRoleRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request body",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, validation.Error(err, "Invalid request body"))
+		return
+	}
+
+	// The tenancy layer assigns the caller's organization when none is given
+	if req.InCatalog && quotaOrganizationID(ctx, req.OrganizationID) != nil {
+		apperrors.Respond(ctx, apperrors.BadRequest("Only global roles can be in the catalog").WithDetails("Roles of an organization are not stamped into new organizations"))
 		return
 	}
 
-	db := database.DB
+	db := requestDB(ctx)
 
 	// Check if organization exists (if provided)
 	if req.OrganizationID != nil {
 		var org models.Organization
 		if err := db.First(&org, *req.OrganizationID).Error; err != nil {
 			if err == gorm.ErrRecordNotFound {
-				ctx.JSON(http.StatusBadRequest, gin.H{
-					"error":   "Organization not found",
-					"message": "The specified organization does not exist",
-				})
+				apperrors.Respond(ctx, apperrors.BadRequest("Organization not found").WithDetails("The specified organization does not exist"))
 				return
 			}
-			ctx.JSON(http.StatusInternalServerError, gin.H{
-				"error":   "Failed to validate organization",
-				"message": err.Error(),
-			})
+			apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to validate organization"))
 			return
 		}
 	}
@@ -288,10 +279,7 @@ func CreateRole(ctx *gin.Context) {
 	}
 
 	if err := query.First(&existingRole).Error; err == nil {
-		ctx.JSON(http.StatusConflict, gin.H{
-			"error":   "Role name already exists",
-			"message": "A role with this name already exists in the specified organization",
-		})
+		apperrors.Respond(ctx, apperrors.Conflict("Role name already exists").WithDetails("A role with this name already exists in the specified organization"))
 		return
 	}
 
@@ -301,28 +289,20 @@ func CreateRole(ctx *gin.Context) {
 		Description:    req.Description,
 		IsDefault:      req.IsDefault,
 		OrganizationID: req.OrganizationID,
+		InCatalog:      req.InCatalog,
 	}
 
 	if err := db.Create(&role).Error; err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to create role",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to create role"))
 		return
 	}
 
 	// Load organization relation
 	db.Preload("Organization").First(&role, role.ID)
 
-	roleResponse := RoleResponse{
-		ID:             role.ID,
-		Name:           role.Name,
-		Description:    role.Description,
-		IsDefault:      role.IsDefault,
-		OrganizationID: role.OrganizationID,
-		CreatedAt:      role.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt:      role.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
-	}
+	roleResponse := buildRoleResponse(role)
+
+	emitEvent(ctx, messaging.EventRoleCreated, roleResponse)
 
 	ctx.JSON(http.StatusCreated, gin.H{
 		"success": true,
@@ -338,51 +318,51 @@ func CreateRole(ctx *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param id path string true "Role ID" format(uuid)
+// @Param If-Match header string false "Version (ETag) the update is based on"
 // @Param role body UpdateRoleRequest true "Updated role information"
 // @Security BearerAuth
 // @Success 200 {object} handlers.SingleRoleResponse "Updated role"
 // @Failure 400 {object} map[string]string "Invalid request data or ID format"
 // @Failure 401 {object} map[string]string "Unauthorized"
 // @Failure 404 {object} map[string]string "Role not found"
-// @Failure 409 {object} map[string]string "Role name already exists"
+// @Failure 409 {object} map[string]string "Role name already exists or version conflict"
 // @Failure 500 {object} map[string]string "Server error"
 // @Router /roles/{id} [put]
 func UpdateRole(ctx *gin.Context) {
 	roleID := ctx.Param("id")
 	roleUUID, err := uuid.Parse(roleID)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid role ID format",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid role ID format"))
 		return
 	}
 
 	var req UpdateRoleRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request body",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, validation.Error(err, "Invalid request body"))
+		return
+	}
+
+	expectedVersion, err := concurrency.ExpectedVersion(ctx, req.Version)
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid version"))
 		return
 	}
 
-	db := database.DB
+	db := requestDB(ctx)
 
 	// Check if role exists
 	var role models.Role
 	if err := db.First(&role, roleUUID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			ctx.JSON(http.StatusNotFound, gin.H{
-				"error":   "Role not found",
-				"message": "Role with the given ID does not exist",
-			})
+			apperrors.Respond(ctx, apperrors.NotFound("Role not found").WithDetails("Role with the given ID does not exist"))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to retrieve role",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to retrieve role"))
+		return
+	}
+
+	if err := concurrency.CheckVersion(role.UpdatedAt, expectedVersion); err != nil {
+		respondVersionConflict(ctx, err)
 		return
 	}
 
@@ -391,16 +371,10 @@ func UpdateRole(ctx *gin.Context) {
 		var org models.Organization
 		if err := db.First(&org, *req.OrganizationID).Error; err != nil {
 			if err == gorm.ErrRecordNotFound {
-				ctx.JSON(http.StatusBadRequest, gin.H{
-					"error":   "Organization not found",
-					"message": "The specified organization does not exist",
-				})
+				apperrors.Respond(ctx, apperrors.BadRequest("Organization not found").WithDetails("The specified organization does not exist"))
 				return
 			}
-			ctx.JSON(http.StatusInternalServerError, gin.H{
-				"error":   "Failed to validate organization",
-				"message": err.Error(),
-			})
+			apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to validate organization"))
 			return
 		}
 	}
@@ -416,10 +390,7 @@ func UpdateRole(ctx *gin.Context) {
 		}
 
 		if err := query.First(&existingRole).Error; err == nil {
-			ctx.JSON(http.StatusConflict, gin.H{
-				"error":   "Role name already exists",
-				"message": "A role with this name already exists in the specified organization",
-			})
+			apperrors.Respond(ctx, apperrors.Conflict("Role name already exists").WithDetails("A role with this name already exists in the specified organization"))
 			return
 		}
 	}
@@ -435,28 +406,40 @@ func UpdateRole(ctx *gin.Context) {
 	if req.OrganizationID != nil {
 		role.OrganizationID = req.OrganizationID
 	}
+	if req.InCatalog != nil {
+		role.InCatalog = *req.InCatalog
+	}
 
-	if err := db.Save(&role).Error; err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to update role",
-			"message": err.Error(),
-		})
+	// Only global roles are stamped into new organizations
+	if role.InCatalog && role.OrganizationID != nil {
+		apperrors.Respond(ctx, apperrors.BadRequest("Only global roles can be in the catalog").WithDetails("Roles of an organization are not stamped into new organizations"))
+		return
+	}
+
+	err = concurrency.Updates(db, &role, expectedVersion, map[string]interface{}{
+		"name":            role.Name,
+		"description":     role.Description,
+		"is_default":      role.IsDefault,
+		"organization_id": role.OrganizationID,
+		"in_catalog":      role.InCatalog,
+	})
+	if errors.Is(err, concurrency.ErrVersionConflict) {
+		respondVersionConflict(ctx, err)
+		return
+	}
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to update role"))
 		return
 	}
 
 	// Load organization relation
 	db.Preload("Organization").First(&role, role.ID)
 
-	roleResponse := RoleResponse{
-		ID:             role.ID,
-		Name:           role.Name,
-		Description:    role.Description,
-		IsDefault:      role.IsDefault,
-		OrganizationID: role.OrganizationID,
-		CreatedAt:      role.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt:      role.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
-	}
+	roleResponse := buildRoleResponse(role)
 
+	emitEvent(ctx, messaging.EventRoleUpdated, roleResponse)
+
+	concurrency.SetETag(ctx, role.UpdatedAt)
 	ctx.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "Role updated successfully",
@@ -464,9 +447,9 @@ func UpdateRole(ctx *gin.Context) {
 	})
 }
 
-// DeleteRole deletes a role (soft delete by setting inactive status)
+// DeleteRole deletes a role (soft delete, restorable until purged)
 // @Summary Delete a role
-// @Description Delete a role if it's not being used by any users
+// @Description Move a role to trash if it's not being used by any users
 // @Tags roles
 // @Accept json
 // @Produce json
@@ -483,29 +466,20 @@ func DeleteRole(ctx *gin.Context) {
 	roleID := ctx.Param("id")
 	roleUUID, err := uuid.Parse(roleID)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid role ID format",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid role ID format"))
 		return
 	}
 
-	db := database.DB
+	db := requestDB(ctx)
 
 	// Check if role exists
 	var role models.Role
 	if err := db.First(&role, roleUUID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			ctx.JSON(http.StatusNotFound, gin.H{
-				"error":   "Role not found",
-				"message": "Role with the given ID does not exist",
-			})
+			apperrors.Respond(ctx, apperrors.NotFound("Role not found").WithDetails("Role with the given ID does not exist"))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to retrieve role",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to retrieve role"))
 		return
 	}
 
@@ -513,22 +487,18 @@ func DeleteRole(ctx *gin.Context) {
 	var userCount int64
 	db.Model(&models.User{}).Where("role_id = ?", roleUUID).Count(&userCount)
 	if userCount > 0 {
-		ctx.JSON(http.StatusConflict, gin.H{
-			"error":   "Role is in use",
-			"message": "Cannot delete role that is assigned to users",
-		})
+		apperrors.Respond(ctx, apperrors.Conflict("Role is in use").WithDetails("Cannot delete role that is assigned to users"))
 		return
 	}
 
 	// Delete the role
 	if err := db.Delete(&role).Error; err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to delete role",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to delete role"))
 		return
 	}
 
+	emitEvent(ctx, messaging.EventRoleDeleted, buildRoleResponse(role))
+
 	ctx.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "Role deleted successfully",
@@ -553,29 +523,20 @@ func GetRolePermissions(ctx *gin.Context) {
 	roleID := ctx.Param("id")
 	roleUUID, err := uuid.Parse(roleID)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid role ID format",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid role ID format"))
 		return
 	}
 
-	db := database.DB
+	db := requestDB(ctx)
 
 	// Check if role exists
 	var role models.Role
 	if err := db.Preload("Organization").First(&role, roleUUID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			ctx.JSON(http.StatusNotFound, gin.H{
-				"error":   "Role not found",
-				"message": "Role with the given ID does not exist",
-			})
+			apperrors.Respond(ctx, apperrors.NotFound("Role not found").WithDetails("Role with the given ID does not exist"))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to retrieve role",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to retrieve role"))
 		return
 	}
 
@@ -0,0 +1,271 @@
+package handlers
+
+import (
+	"net/http"
+
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models"
+	"forgecrud-backend/shared/messaging"
+	utils "forgecrud-backend/shared/utils/auth"
+	"forgecrud-backend/shared/utils/query"
+	"forgecrud-backend/shared/utils/validation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RoleMembersRequest represents request body for assigning a role to users or removing it from them
+type RoleMembersRequest struct {
+	UserIDs []uuid.UUID `json:"user_ids" binding:"required,min=1,max=500"`
+	Reason  string      `json:"reason" binding:"omitempty,max=500"` // Recorded in the users' role history
+}
+
+// RoleMembersResult summarizes a bulk role membership change
+type RoleMembersResult struct {
+	Changed   int         `json:"changed"`
+	Unchanged int         `json:"unchanged"` // Users that already had (or did not have) the role
+	UserIDs   []uuid.UUID `json:"user_ids"`  // Changed users
+}
+
+// findRole loads a role by the :id path parameter and writes the error response on failure
+func findRole(ctx *gin.Context, db *gorm.DB) (*models.Role, bool) {
+	roleUUID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid role ID format"))
+		return nil, false
+	}
+
+	var role models.Role
+	if err := db.First(&role, roleUUID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			apperrors.Respond(ctx, apperrors.NotFound("Role not found").WithDetails("Role with the given ID does not exist"))
+			return nil, false
+		}
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to retrieve role"))
+		return nil, false
+	}
+
+	return &role, true
+}
+
+// GetRoleUsers lists the users holding a role
+// @Summary Get role members
+// @Description Get the users holding a role, with pagination, filtering and search
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Param id path string true "Role ID" format(uuid)
+// @Param page query int false "Page number (default: 1)"
+// @Param limit query int false "Items per page (default: 10)"
+// @Param search query string false "Search term across first name, last name and email"
+// @Param filters[status] query string false "Filter by status"
+// @Param filters[organization_id] query string false "Filter by organization ID"
+// @Param sort[field] query string false "Sort field (email, first_name, last_name, status, created_at)"
+// @Param sort[order] query string false "Sort order (asc, desc)"
+// @Security BearerAuth
+// @Success 200 {object} handlers.UserListResponse
+// @Failure 400 {object} map[string]string "Invalid role ID format"
+// @Failure 404 {object} map[string]string "Role not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /roles/{id}/users [get]
+func GetRoleUsers(ctx *gin.Context) {
+	db := requestDB(ctx)
+
+	role, ok := findRole(ctx, db)
+	if !ok {
+		return
+	}
+
+	params := query.ParseQueryParams(ctx)
+
+	allowedFilters := map[string]string{
+		"status":          "status",
+		"organization_id": "organization_id",
+		"created_at":      "created_at",
+	}
+	allowedSortFields := map[string]string{
+		"email":      "email",
+		"first_name": "first_name",
+		"last_name":  "last_name",
+		"status":     "status",
+		"created_at": "created_at",
+	}
+	searchFields := []string{"first_name", "last_name", "email"}
+
+	baseQuery := db.Model(&models.User{}).Preload("Organization").Preload("Role").Where("role_id = ?", role.ID)
+	filteredQuery := query.ApplyFilters(baseQuery, params.Filters, allowedFilters)
+	searchedQuery := query.ApplySearch(filteredQuery, params.Search, searchFields)
+
+	var total int64
+	searchedQuery.Count(&total)
+
+	finalQuery := query.ApplySort(searchedQuery, params.Sort, allowedSortFields)
+	finalQuery = query.ApplyPagination(finalQuery, params.Page, params.Limit)
+
+	var users []models.User
+	if err := finalQuery.Find(&users).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to retrieve role members"))
+		return
+	}
+
+	userResponses := make([]UserResponse, 0, len(users))
+	for _, user := range users {
+		userResponses = append(userResponses, buildUserResponse(user))
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"items":      userResponses,
+			"pagination": query.BuildPaginationResponse(params.Page, params.Limit, total),
+		},
+	})
+}
+
+// AssignRoleToUsers gives a role to a list of users
+// @Summary Assign a role to users
+// @Description Assign the role to up to 500 users in one transaction. Users of an organization role must belong to its organization.
+// @Description Every change is recorded in the user's role history and the users are notified.
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Param id path string true "Role ID" format(uuid)
+// @Param members body RoleMembersRequest true "Users and reason"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Changed and unchanged users"
+// @Failure 400 {object} map[string]string "Invalid request data or users"
+// @Failure 404 {object} map[string]string "Role not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /roles/{id}/users [post]
+func AssignRoleToUsers(ctx *gin.Context) {
+	changeRoleMembers(ctx, true)
+}
+
+// RemoveRoleFromUsers takes a role away from a list of users
+// @Summary Remove a role from users
+// @Description Remove the role from up to 500 users in one transaction; listed users without the role are left alone.
+// @Description Every change is recorded in the user's role history and the users are notified.
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Param id path string true "Role ID" format(uuid)
+// @Param members body RoleMembersRequest true "Users and reason"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Changed and unchanged users"
+// @Failure 400 {object} map[string]string "Invalid request data or users"
+// @Failure 404 {object} map[string]string "Role not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /roles/{id}/users [delete]
+func RemoveRoleFromUsers(ctx *gin.Context) {
+	changeRoleMembers(ctx, false)
+}
+
+// changeRoleMembers assigns the role from the :id path parameter to the listed users, or removes it
+// from those holding it
+func changeRoleMembers(ctx *gin.Context, assign bool) {
+	db := requestDB(ctx)
+
+	role, ok := findRole(ctx, db)
+	if !ok {
+		return
+	}
+
+	var req RoleMembersRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apperrors.Respond(ctx, validation.Error(err, "Invalid request body"))
+		return
+	}
+	userIDs := uniqueUUIDs(req.UserIDs)
+
+	var users []models.User
+	if err := db.Where("id IN ?", userIDs).Find(&users).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to validate users"))
+		return
+	}
+	if len(users) != len(userIDs) {
+		apperrors.Respond(ctx, apperrors.BadRequest("Invalid users").WithDetails("One or more users do not exist"))
+		return
+	}
+	if assign && role.OrganizationID != nil {
+		for _, user := range users {
+			if user.OrganizationID == nil || *user.OrganizationID != *role.OrganizationID {
+				apperrors.Respond(ctx, apperrors.BadRequest("Invalid users").WithDetails("Users must belong to the role's organization").With("user_id", user.ID))
+				return
+			}
+		}
+	}
+
+	var newRoleID *uuid.UUID
+	if assign {
+		newRoleID = &role.ID
+	}
+
+	result := RoleMembersResult{UserIDs: []uuid.UUID{}}
+	assignments := map[uuid.UUID]*models.RoleAssignment{}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, user := range users {
+			holdsRole := user.RoleID != nil && *user.RoleID == role.ID
+			if holdsRole == assign {
+				result.Unchanged++
+				continue
+			}
+
+			if err := tx.Model(&user).Update("role_id", newRoleID).Error; err != nil {
+				return err
+			}
+			assignment, err := database.RecordRoleAssignment(tx, database.RoleAssignmentEntry{
+				UserID:         user.ID,
+				PreviousRoleID: user.RoleID,
+				RoleID:         newRoleID,
+				Source:         models.RoleAssignmentSourceAPI,
+				ActorID:        utils.GetActorID(ctx),
+				Reason:         req.Reason,
+			})
+			if err != nil {
+				return err
+			}
+			assignments[user.ID] = assignment
+			result.Changed++
+			result.UserIDs = append(result.UserIDs, user.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to update role members"))
+		return
+	}
+
+	if len(result.UserIDs) > 0 {
+		var changed []models.User
+		db.Preload("Organization").Preload("Role").Where("id IN ?", result.UserIDs).Find(&changed)
+		for _, user := range changed {
+			emitEvent(ctx, messaging.EventUserUpdated, buildUserResponse(user))
+			publishRoleChanged(ctx, user, assignments[user.ID])
+		}
+	}
+
+	message := "Role assigned successfully"
+	if !assign {
+		message = "Role removed successfully"
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": message,
+		"data":    result,
+	})
+}
+
+// uniqueUUIDs returns the IDs without duplicates, in their original order
+func uniqueUUIDs(ids []uuid.UUID) []uuid.UUID {
+	seen := make(map[uuid.UUID]bool, len(ids))
+	unique := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
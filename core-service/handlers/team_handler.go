@@ -0,0 +1,624 @@
+package handlers
+
+import (
+	"net/http"
+
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/database/models"
+	"forgecrud-backend/shared/messaging"
+	utils "forgecrud-backend/shared/utils/auth"
+	"forgecrud-backend/shared/utils/query"
+	"forgecrud-backend/shared/utils/validation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TeamResponse represents team data for API responses
+type TeamResponse struct {
+	ID             uuid.UUID            `json:"id"`
+	Name           string               `json:"name"`
+	Description    string               `json:"description"`
+	OrganizationID uuid.UUID            `json:"organization_id"`
+	Organization   *models.Organization `json:"organization,omitempty"`
+	MemberCount    int64                `json:"member_count"`
+	CreatedAt      string               `json:"created_at"`
+	UpdatedAt      string               `json:"updated_at"`
+}
+
+// TeamMemberResponse represents a team membership entry
+type TeamMemberResponse struct {
+	UserID    uuid.UUID `json:"user_id"`
+	Email     string    `json:"email"`
+	FirstName string    `json:"first_name"`
+	LastName  string    `json:"last_name"`
+	Status    string    `json:"status"`
+	JoinedAt  string    `json:"joined_at"`
+}
+
+// CreateTeamRequest represents request body for creating team
+type CreateTeamRequest struct {
+	Name           string    `json:"name" binding:"required"`
+	Description    string    `json:"description"`
+	OrganizationID uuid.UUID `json:"organization_id" binding:"required"`
+}
+
+// UpdateTeamRequest represents request body for updating team
+type UpdateTeamRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// TeamMembersRequest represents request body for adding members to a team
+type TeamMembersRequest struct {
+	UserIDs []uuid.UUID `json:"user_ids" binding:"required,min=1"`
+}
+
+// TeamListResponse represents a list of teams with pagination
+type TeamListResponse struct {
+	Success bool `json:"success"`
+	Data    struct {
+		Items      []TeamResponse     `json:"items"`
+		Pagination PaginationResponse `json:"pagination"`
+	} `json:"data"`
+}
+
+// SingleTeamResponse represents a single team response
+type SingleTeamResponse struct {
+	Success bool         `json:"success"`
+	Data    TeamResponse `json:"data"`
+}
+
+// buildTeamResponse converts a team model to its API representation
+func buildTeamResponse(db *gorm.DB, team models.Team) TeamResponse {
+	teamResponse := TeamResponse{
+		ID:             team.ID,
+		Name:           team.Name,
+		Description:    team.Description,
+		OrganizationID: team.OrganizationID,
+		CreatedAt:      team.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:      team.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+
+	if team.Organization.ID != uuid.Nil {
+		teamResponse.Organization = &team.Organization
+	}
+
+	db.Model(&models.TeamMember{}).Where("team_id = ?", team.ID).Count(&teamResponse.MemberCount)
+
+	return teamResponse
+}
+
+// publishTeamMembersChanged announces membership changes so permission caches of the users are refreshed
+func publishTeamMembersChanged(ctx *gin.Context, teamID uuid.UUID, userIDs []uuid.UUID) {
+	if len(userIDs) == 0 {
+		return
+	}
+	messaging.Publish(ctx.Request.Context(), messaging.EventTeamMembersChanged, utils.GetActorID(ctx), messaging.TeamMembersChangedData{
+		TeamID:  teamID,
+		UserIDs: userIDs,
+	})
+}
+
+// findTeam loads a team by the :id path parameter and writes the error response on failure
+func findTeam(ctx *gin.Context, db *gorm.DB) (*models.Team, bool) {
+	teamUUID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid team ID format"))
+		return nil, false
+	}
+
+	var team models.Team
+	if err := db.Preload("Organization").First(&team, teamUUID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			apperrors.Respond(ctx, apperrors.NotFound("Team not found").WithDetails("Team with the given ID does not exist"))
+			return nil, false
+		}
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to retrieve team"))
+		return nil, false
+	}
+
+	return &team, true
+}
+
+// GetTeams retrieves all teams with pagination and filtering
+// @Summary Get all teams
+// @Description Get all teams with pagination, filtering, sorting and search
+// @Tags teams
+// @Accept json
+// @Produce json
+// @Param page query int false "Page number (default: 1)"
+// @Param limit query int false "Items per page (default: 10)"
+// @Param search query string false "Search term across name and description"
+// @Param filters[organization_id] query string false "Filter by organization ID"
+// @Param sort[field] query string false "Sort field (name, created_at, updated_at)"
+// @Param sort[order] query string false "Sort order (asc, desc)"
+// @Security BearerAuth
+// @Success 200 {object} handlers.TeamListResponse
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /teams [get]
+func GetTeams(ctx *gin.Context) {
+	db := requestDB(ctx)
+
+	// Parse standardized query parameters
+	params := query.ParseQueryParams(ctx)
+
+	// Define allowed filter fields
+	allowedFilters := map[string]string{
+		"organization_id": "organization_id",
+	}
+
+	// Define allowed sort fields
+	allowedSortFields := map[string]string{
+		"name":       "name",
+		"created_at": "created_at",
+		"updated_at": "updated_at",
+	}
+
+	// Define search fields
+	searchFields := []string{"name", "description"}
+
+	// Build base query
+	baseQuery := db.Model(&models.Team{}).Preload("Organization")
+
+	// Apply filters
+	filteredQuery := query.ApplyFilters(baseQuery, params.Filters, allowedFilters)
+
+	// Apply search
+	searchedQuery := query.ApplySearch(filteredQuery, params.Search, searchFields)
+
+	// Get total count
+	var total int64
+	searchedQuery.Count(&total)
+
+	// Apply sorting and pagination
+	finalQuery := query.ApplySort(searchedQuery, params.Sort, allowedSortFields)
+	finalQuery = query.ApplyPagination(finalQuery, params.Page, params.Limit)
+
+	// Get teams
+	var teams []models.Team
+	if err := finalQuery.Find(&teams).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to retrieve teams"))
+		return
+	}
+
+	// Convert to response format
+	var teamResponses []TeamResponse
+	for _, team := range teams {
+		teamResponses = append(teamResponses, buildTeamResponse(db, team))
+	}
+
+	// Build pagination response
+	pagination := query.BuildPaginationResponse(params.Page, params.Limit, total)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"items":      teamResponses,
+			"pagination": pagination,
+		},
+	})
+}
+
+// GetTeam retrieves a single team by ID
+// @Summary Get team by ID
+// @Description Get detailed information about a specific team
+// @Tags teams
+// @Accept json
+// @Produce json
+// @Param id path string true "Team ID" format(uuid)
+// @Security BearerAuth
+// @Success 200 {object} handlers.SingleTeamResponse
+// @Failure 400 {object} map[string]string "Invalid team ID format"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 404 {object} map[string]string "Team not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /teams/{id} [get]
+func GetTeam(ctx *gin.Context) {
+	db := requestDB(ctx)
+
+	team, ok := findTeam(ctx, db)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    buildTeamResponse(db, *team),
+	})
+}
+
+// CreateTeam creates a new team
+// @Summary Create a new team
+// @Description Create a new team inside an organization
+// @Tags teams
+// @Accept json
+// @Produce json
+// @Param team body CreateTeamRequest true "Team information"
+// @Security BearerAuth
+// @Success 201 {object} handlers.SingleTeamResponse "Created team"
+// @Failure 400 {object} map[string]string "Invalid request data or organization not found"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 409 {object} map[string]string "Team name already exists"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /teams [post]
+func CreateTeam(ctx *gin.Context) {
+	var req CreateTeamRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apperrors.Respond(ctx, validation.Error(err, "Invalid request body"))
+		return
+	}
+
+	db := requestDB(ctx)
+
+	// Check if organization exists
+	var org models.Organization
+	if err := db.First(&org, req.OrganizationID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			apperrors.Respond(ctx, apperrors.BadRequest("Organization not found").WithDetails("The specified organization does not exist"))
+			return
+		}
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to validate organization"))
+		return
+	}
+
+	// Check if team name already exists in the same organization
+	var existingTeam models.Team
+	if err := db.Where("name = ? AND organization_id = ?", req.Name, req.OrganizationID).First(&existingTeam).Error; err == nil {
+		apperrors.Respond(ctx, apperrors.Conflict("Team name already exists").WithDetails("A team with this name already exists in the specified organization"))
+		return
+	}
+
+	team := models.Team{
+		Name:           req.Name,
+		Description:    req.Description,
+		OrganizationID: req.OrganizationID,
+	}
+
+	if err := db.Create(&team).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to create team"))
+		return
+	}
+
+	// Load organization relation
+	db.Preload("Organization").First(&team, team.ID)
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Team created successfully",
+		"data":    buildTeamResponse(db, team),
+	})
+}
+
+// UpdateTeam updates an existing team
+// @Summary Update a team
+// @Description Update an existing team's name or description
+// @Tags teams
+// @Accept json
+// @Produce json
+// @Param id path string true "Team ID" format(uuid)
+// @Param team body UpdateTeamRequest true "Updated team information"
+// @Security BearerAuth
+// @Success 200 {object} handlers.SingleTeamResponse "Updated team"
+// @Failure 400 {object} map[string]string "Invalid request data or ID format"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 404 {object} map[string]string "Team not found"
+// @Failure 409 {object} map[string]string "Team name already exists"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /teams/{id} [put]
+func UpdateTeam(ctx *gin.Context) {
+	db := requestDB(ctx)
+
+	team, ok := findTeam(ctx, db)
+	if !ok {
+		return
+	}
+
+	var req UpdateTeamRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apperrors.Respond(ctx, validation.Error(err, "Invalid request body"))
+		return
+	}
+
+	// Check if team name already exists (if name is being changed)
+	if req.Name != "" && req.Name != team.Name {
+		var existingTeam models.Team
+		if err := db.Where("name = ? AND organization_id = ? AND id != ?", req.Name, team.OrganizationID, team.ID).First(&existingTeam).Error; err == nil {
+			apperrors.Respond(ctx, apperrors.Conflict("Team name already exists").WithDetails("A team with this name already exists in the organization"))
+			return
+		}
+	}
+
+	updates := make(map[string]interface{})
+	if req.Name != "" {
+		updates["name"] = req.Name
+	}
+	if req.Description != "" {
+		updates["description"] = req.Description
+	}
+
+	if err := db.Model(team).Updates(updates).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to update team"))
+		return
+	}
+
+	db.Preload("Organization").First(team, team.ID)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Team updated successfully",
+		"data":    buildTeamResponse(db, *team),
+	})
+}
+
+// DeleteTeam deletes a team together with its memberships and team permissions
+// @Summary Delete a team
+// @Description Delete a team, its memberships and any permissions targeting it
+// @Tags teams
+// @Accept json
+// @Produce json
+// @Param id path string true "Team ID" format(uuid)
+// @Security BearerAuth
+// @Success 200 {object} handlers.SuccessResponse "Success message"
+// @Failure 400 {object} map[string]string "Invalid team ID format"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 404 {object} map[string]string "Team not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /teams/{id} [delete]
+func DeleteTeam(ctx *gin.Context) {
+	db := requestDB(ctx)
+
+	team, ok := findTeam(ctx, db)
+	if !ok {
+		return
+	}
+
+	var memberIDs []uuid.UUID
+	db.Model(&models.TeamMember{}).Where("team_id = ?", team.ID).Pluck("user_id", &memberIDs)
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Remove permissions granted to the team
+		if err := tx.Where("permission_id IN (?)",
+			tx.Model(&models.Permission{}).Select("id").Where("target = ? AND team_id = ?", "TEAM", team.ID),
+		).Delete(&models.PermissionAction{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("target = ? AND team_id = ?", "TEAM", team.ID).Delete(&models.Permission{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("team_id = ?", team.ID).Delete(&models.TeamMember{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Delete(team).Error
+	})
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to delete team"))
+		return
+	}
+
+	publishTeamMembersChanged(ctx, team.ID, memberIDs)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Team deleted successfully",
+	})
+}
+
+// GetTeamMembers lists the members of a team
+// @Summary Get team members
+// @Description Get all users that belong to a team
+// @Tags teams
+// @Accept json
+// @Produce json
+// @Param id path string true "Team ID" format(uuid)
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Team members"
+// @Failure 400 {object} map[string]string "Invalid team ID format"
+// @Failure 404 {object} map[string]string "Team not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /teams/{id}/members [get]
+func GetTeamMembers(ctx *gin.Context) {
+	db := requestDB(ctx)
+
+	team, ok := findTeam(ctx, db)
+	if !ok {
+		return
+	}
+
+	var members []models.TeamMember
+	if err := db.Preload("User").Where("team_id = ?", team.ID).Order("created_at ASC").Find(&members).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to retrieve team members"))
+		return
+	}
+
+	memberResponses := make([]TeamMemberResponse, 0, len(members))
+	for _, member := range members {
+		memberResponses = append(memberResponses, TeamMemberResponse{
+			UserID:    member.UserID,
+			Email:     member.User.Email,
+			FirstName: member.User.FirstName,
+			LastName:  member.User.LastName,
+			Status:    member.User.Status,
+			JoinedAt:  member.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		})
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"team":    buildTeamResponse(db, *team),
+			"members": memberResponses,
+		},
+	})
+}
+
+// AddTeamMembers adds users to a team
+// @Summary Add team members
+// @Description Add one or more users of the team's organization to the team. Repeated IDs and users already in the team are skipped.
+// @Tags teams
+// @Accept json
+// @Produce json
+// @Param id path string true "Team ID" format(uuid)
+// @Param members body TeamMembersRequest true "Users to add"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Added members"
+// @Failure 400 {object} map[string]string "Invalid request data or user not in organization"
+// @Failure 404 {object} map[string]string "Team not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /teams/{id}/members [post]
+func AddTeamMembers(ctx *gin.Context) {
+	db := requestDB(ctx)
+
+	team, ok := findTeam(ctx, db)
+	if !ok {
+		return
+	}
+
+	var req TeamMembersRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apperrors.Respond(ctx, validation.Error(err, "Invalid request body"))
+		return
+	}
+
+	// Members must belong to the team's organization; repeated IDs are added once
+	userIDs := uniqueUUIDs(req.UserIDs)
+	var users []models.User
+	if err := db.Where("id IN ? AND organization_id = ?", userIDs, team.OrganizationID).Find(&users).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to validate users"))
+		return
+	}
+	if len(users) != len(userIDs) {
+		apperrors.Respond(ctx, apperrors.BadRequest("Invalid users").WithDetails("One or more users do not exist or do not belong to the team's organization"))
+		return
+	}
+
+	// Users already in the team are skipped, also when added concurrently, and the rest are added together
+	var addedIDs []uuid.UUID
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var existingIDs []uuid.UUID
+		if err := tx.Model(&models.TeamMember{}).Where("team_id = ? AND user_id IN ?", team.ID, userIDs).
+			Pluck("user_id", &existingIDs).Error; err != nil {
+			return err
+		}
+		existing := make(map[uuid.UUID]bool, len(existingIDs))
+		for _, id := range existingIDs {
+			existing[id] = true
+		}
+
+		var members []models.TeamMember
+		for _, id := range userIDs {
+			if !existing[id] {
+				members = append(members, models.TeamMember{TeamID: team.ID, UserID: id})
+				addedIDs = append(addedIDs, id)
+			}
+		}
+		if len(members) == 0 {
+			return nil
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(&members, 100).Error
+	})
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to add team members"))
+		return
+	}
+	added := len(addedIDs)
+
+	publishTeamMembersChanged(ctx, team.ID, addedIDs)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Team members added successfully",
+		"data": gin.H{
+			"added": added,
+		},
+	})
+}
+
+// RemoveTeamMember removes a user from a team
+// @Summary Remove team member
+// @Description Remove a single user from a team
+// @Tags teams
+// @Accept json
+// @Produce json
+// @Param id path string true "Team ID" format(uuid)
+// @Param user_id path string true "User ID" format(uuid)
+// @Security BearerAuth
+// @Success 200 {object} handlers.SuccessResponse "Success message"
+// @Failure 400 {object} map[string]string "Invalid ID format"
+// @Failure 404 {object} map[string]string "Team or membership not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /teams/{id}/members/{user_id} [delete]
+func RemoveTeamMember(ctx *gin.Context) {
+	db := requestDB(ctx)
+
+	team, ok := findTeam(ctx, db)
+	if !ok {
+		return
+	}
+
+	userUUID, err := uuid.Parse(ctx.Param("user_id"))
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid user ID format"))
+		return
+	}
+
+	result := db.Where("team_id = ? AND user_id = ?", team.ID, userUUID).Delete(&models.TeamMember{})
+	if result.Error != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(result.Error, apperrors.CodeInternal, "Failed to remove team member"))
+		return
+	}
+	if result.RowsAffected == 0 {
+		apperrors.Respond(ctx, apperrors.NotFound("Membership not found").WithDetails("User is not a member of this team"))
+		return
+	}
+
+	publishTeamMembersChanged(ctx, team.ID, []uuid.UUID{userUUID})
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Team member removed successfully",
+	})
+}
+
+// GetTeamPermissions retrieves all permissions granted to a team
+// @Summary Get team permissions
+// @Description Get all permissions targeting a specific team
+// @Tags teams
+// @Accept json
+// @Produce json
+// @Param id path string true "Team ID" format(uuid)
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Team permissions data"
+// @Failure 400 {object} map[string]string "Invalid team ID format"
+// @Failure 404 {object} map[string]string "Team not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /teams/{id}/permissions [get]
+func GetTeamPermissions(ctx *gin.Context) {
+	db := requestDB(ctx)
+
+	team, ok := findTeam(ctx, db)
+	if !ok {
+		return
+	}
+
+	var teamPermissions []models.Permission
+	db.Preload("Resource").
+		Preload("PermissionActions.Action").
+		Where("target = ? AND team_id = ?", "TEAM", team.ID).
+		Find(&teamPermissions)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"team": buildTeamResponse(db, *team),
+			"permissions": gin.H{
+				"team_permissions": teamPermissions,
+			},
+		},
+	})
+}
@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"net/http"
+
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/database/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// applyDeletedScope honours the include_deleted query flag on list endpoints.
+// "true" includes soft-deleted rows, "only" lists the trash. The gateway restricts the flag to admins.
+func applyDeletedScope(ctx *gin.Context, q *gorm.DB) *gorm.DB {
+	switch ctx.Query("include_deleted") {
+	case "true":
+		return q.Unscoped()
+	case "only":
+		return q.Unscoped().Where("deleted_at IS NOT NULL")
+	default:
+		return q
+	}
+}
+
+// formatDeletedAt formats a soft-delete timestamp for API responses
+func formatDeletedAt(deletedAt gorm.DeletedAt) *string {
+	if !deletedAt.Valid {
+		return nil
+	}
+	formatted := deletedAt.Time.Format("2006-01-02T15:04:05Z07:00")
+	return &formatted
+}
+
+// RestoreUser restores a soft-deleted user
+// @Summary Restore a user
+// @Description Restore a soft-deleted user from trash and reactivate it
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID" format(uuid)
+// @Security BearerAuth
+// @Success 200 {object} handlers.SingleUserResponse "Restored user"
+// @Failure 400 {object} map[string]string "Invalid user ID format"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 409 {object} map[string]string "User is not deleted or its organization is deleted"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /users/{id}/restore [post]
+func RestoreUser(ctx *gin.Context) {
+	userUUID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid user ID format"))
+		return
+	}
+
+	db := requestDB(ctx)
+
+	var user models.User
+	if err := db.Unscoped().First(&user, userUUID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			apperrors.Respond(ctx, apperrors.NotFound("User not found").WithDetails("User with the given ID does not exist"))
+			return
+		}
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to retrieve user"))
+		return
+	}
+
+	if !user.DeletedAt.Valid {
+		apperrors.Respond(ctx, apperrors.Conflict("User is not deleted").WithDetails("Only deleted users can be restored"))
+		return
+	}
+
+	// The organization must be restored first
+	if user.OrganizationID != nil {
+		var org models.Organization
+		if err := db.First(&org, *user.OrganizationID).Error; err != nil {
+			apperrors.Respond(ctx, apperrors.Conflict("Organization is deleted").WithDetails("Restore the user's organization before restoring the user"))
+			return
+		}
+	}
+
+	if err := db.Unscoped().Model(&user).Updates(map[string]interface{}{
+		"deleted_at": nil,
+		"status":     models.UserStatusActive,
+	}).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to restore user"))
+		return
+	}
+
+	db.Preload("Organization").Preload("Role").First(&user, userUUID)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "User restored successfully",
+		"data":    buildUserResponse(user),
+	})
+}
+
+// RestoreRole restores a soft-deleted role
+// @Summary Restore a role
+// @Description Restore a soft-deleted role from trash
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Param id path string true "Role ID" format(uuid)
+// @Security BearerAuth
+// @Success 200 {object} handlers.SingleRoleResponse "Restored role"
+// @Failure 400 {object} map[string]string "Invalid role ID format"
+// @Failure 404 {object} map[string]string "Role not found"
+// @Failure 409 {object} map[string]string "Role is not deleted or its organization is deleted"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /roles/{id}/restore [post]
+func RestoreRole(ctx *gin.Context) {
+	roleUUID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid role ID format"))
+		return
+	}
+
+	db := requestDB(ctx)
+
+	var role models.Role
+	if err := db.Unscoped().First(&role, roleUUID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			apperrors.Respond(ctx, apperrors.NotFound("Role not found").WithDetails("Role with the given ID does not exist"))
+			return
+		}
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to retrieve role"))
+		return
+	}
+
+	if !role.DeletedAt.Valid {
+		apperrors.Respond(ctx, apperrors.Conflict("Role is not deleted").WithDetails("Only deleted roles can be restored"))
+		return
+	}
+
+	// The organization must be restored first
+	if role.OrganizationID != nil {
+		var org models.Organization
+		if err := db.First(&org, *role.OrganizationID).Error; err != nil {
+			apperrors.Respond(ctx, apperrors.Conflict("Organization is deleted").WithDetails("Restore the role's organization before restoring the role"))
+			return
+		}
+	}
+
+	if err := db.Unscoped().Model(&role).Update("deleted_at", nil).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to restore role"))
+		return
+	}
+
+	db.Preload("Organization").First(&role, roleUUID)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Role restored successfully",
+		"data":    buildRoleResponse(role),
+	})
+}
+
+// RestoreOrganization restores a soft-deleted organization
+// @Summary Restore an organization
+// @Description Restore a soft-deleted organization from trash
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Param id path string true "Organization ID" format(uuid)
+// @Security BearerAuth
+// @Success 200 {object} handlers.SingleOrganizationResponse "Restored organization"
+// @Failure 400 {object} map[string]string "Invalid organization ID format"
+// @Failure 404 {object} map[string]string "Organization not found"
+// @Failure 409 {object} map[string]string "Organization is not deleted or its parent is deleted"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /organizations/{id}/restore [post]
+func RestoreOrganization(ctx *gin.Context) {
+	orgUUID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid organization ID format"))
+		return
+	}
+
+	db := requestDB(ctx)
+
+	var org models.Organization
+	if err := db.Unscoped().First(&org, orgUUID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			apperrors.Respond(ctx, apperrors.NotFound("Organization not found").WithDetails("Organization with the given ID does not exist"))
+			return
+		}
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to retrieve organization"))
+		return
+	}
+
+	if !org.DeletedAt.Valid {
+		apperrors.Respond(ctx, apperrors.Conflict("Organization is not deleted").WithDetails("Only deleted organizations can be restored"))
+		return
+	}
+
+	// The parent organization must be restored first
+	if org.ParentID != nil {
+		var parent models.Organization
+		if err := db.First(&parent, *org.ParentID).Error; err != nil {
+			apperrors.Respond(ctx, apperrors.Conflict("Parent organization is deleted").WithDetails("Restore the parent organization before restoring this organization"))
+			return
+		}
+	}
+
+	if err := db.Unscoped().Model(&org).Update("deleted_at", nil).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to restore organization"))
+		return
+	}
+
+	db.First(&org, orgUUID)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Organization restored successfully",
+		"data":    buildOrganizationResponse(org),
+	})
+}
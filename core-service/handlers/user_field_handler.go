@@ -0,0 +1,466 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/database/models"
+	"forgecrud-backend/shared/utils/validation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// userFieldKeyPattern restricts keys so they can be used safely inside jsonb index expressions
+var userFieldKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,62}$`)
+
+// CreateUserFieldRequest represents request body for defining a custom user field
+type CreateUserFieldRequest struct {
+	Key       string   `json:"key" binding:"required"`
+	Label     string   `json:"label" binding:"required"`
+	Type      string   `json:"type" binding:"required,oneof=string number boolean date enum"`
+	Required  bool     `json:"required"`
+	Options   []string `json:"options"`
+	Pattern   string   `json:"pattern"`
+	MinValue  *float64 `json:"min_value"`
+	MaxValue  *float64 `json:"max_value"`
+	MaxLength int      `json:"max_length"`
+	Indexed   bool     `json:"indexed"`
+}
+
+// UpdateUserFieldRequest represents request body for updating a custom user field.
+// Key and type are immutable because existing user values depend on them.
+type UpdateUserFieldRequest struct {
+	Label     string   `json:"label"`
+	Required  *bool    `json:"required"`
+	Options   []string `json:"options"`
+	Pattern   *string  `json:"pattern"`
+	MinValue  *float64 `json:"min_value"`
+	MaxValue  *float64 `json:"max_value"`
+	MaxLength *int     `json:"max_length"`
+	Indexed   *bool    `json:"indexed"`
+}
+
+// GetUserFields lists the custom user field definitions of an organization
+// @Summary Get custom user fields
+// @Description Get the custom profile field schema defined for an organization
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Param id path string true "Organization ID" format(uuid)
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Field definitions"
+// @Failure 400 {object} map[string]string "Invalid organization ID format"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /organizations/{id}/user-fields [get]
+func GetUserFields(ctx *gin.Context) {
+	orgUUID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid organization ID format"))
+		return
+	}
+
+	var fields []models.UserFieldDefinition
+	if err := requestDB(ctx).Where("organization_id = ?", orgUUID).Order("created_at ASC").Find(&fields).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to retrieve user fields"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    fields,
+	})
+}
+
+// CreateUserField defines a new custom user field for an organization
+// @Summary Create custom user field
+// @Description Define a new custom profile field (type, validation, required) for an organization
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Param id path string true "Organization ID" format(uuid)
+// @Param field body CreateUserFieldRequest true "Field definition"
+// @Security BearerAuth
+// @Success 201 {object} map[string]interface{} "Created field definition"
+// @Failure 400 {object} map[string]string "Invalid request data"
+// @Failure 404 {object} map[string]string "Organization not found"
+// @Failure 409 {object} map[string]string "Field key already exists"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /organizations/{id}/user-fields [post]
+func CreateUserField(ctx *gin.Context) {
+	orgUUID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid organization ID format"))
+		return
+	}
+
+	var req CreateUserFieldRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apperrors.Respond(ctx, validation.Error(err, "Invalid request body"))
+		return
+	}
+
+	db := requestDB(ctx)
+
+	var org models.Organization
+	if err := db.First(&org, orgUUID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			apperrors.Respond(ctx, apperrors.NotFound("Organization not found").WithDetails("Organization with the given ID does not exist"))
+			return
+		}
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to retrieve organization"))
+		return
+	}
+
+	field := models.UserFieldDefinition{
+		OrganizationID: orgUUID,
+		Key:            req.Key,
+		Label:          req.Label,
+		Type:           req.Type,
+		Required:       req.Required,
+		Options:        strings.Join(req.Options, ","),
+		Pattern:        req.Pattern,
+		MinValue:       req.MinValue,
+		MaxValue:       req.MaxValue,
+		MaxLength:      req.MaxLength,
+		Indexed:        req.Indexed,
+	}
+
+	if err := validateUserFieldDefinition(&field); err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid field definition"))
+		return
+	}
+
+	var existing models.UserFieldDefinition
+	if err := db.Where("organization_id = ? AND key = ?", orgUUID, field.Key).First(&existing).Error; err == nil {
+		apperrors.Respond(ctx, apperrors.Conflict("Field key already exists").WithDetails("A field with this key already exists in the organization"))
+		return
+	}
+
+	if err := db.Create(&field).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to create user field"))
+		return
+	}
+
+	if field.Indexed {
+		if err := ensureUserAttributeIndex(db, field.Key); err != nil {
+			apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to create attribute index"))
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "User field created successfully",
+		"data":    field,
+	})
+}
+
+// UpdateUserField updates a custom user field definition
+// @Summary Update custom user field
+// @Description Update validation rules of a custom profile field. Key and type cannot be changed.
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Param id path string true "Organization ID" format(uuid)
+// @Param field_id path string true "Field ID" format(uuid)
+// @Param field body UpdateUserFieldRequest true "Updated field definition"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Updated field definition"
+// @Failure 400 {object} map[string]string "Invalid request data"
+// @Failure 404 {object} map[string]string "Field not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /organizations/{id}/user-fields/{field_id} [put]
+func UpdateUserField(ctx *gin.Context) {
+	db := requestDB(ctx)
+
+	field, ok := findUserField(ctx, db)
+	if !ok {
+		return
+	}
+
+	var req UpdateUserFieldRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apperrors.Respond(ctx, validation.Error(err, "Invalid request body"))
+		return
+	}
+
+	if req.Label != "" {
+		field.Label = req.Label
+	}
+	if req.Required != nil {
+		field.Required = *req.Required
+	}
+	if req.Options != nil {
+		field.Options = strings.Join(req.Options, ",")
+	}
+	if req.Pattern != nil {
+		field.Pattern = *req.Pattern
+	}
+	if req.MinValue != nil {
+		field.MinValue = req.MinValue
+	}
+	if req.MaxValue != nil {
+		field.MaxValue = req.MaxValue
+	}
+	if req.MaxLength != nil {
+		field.MaxLength = *req.MaxLength
+	}
+	if req.Indexed != nil {
+		field.Indexed = *req.Indexed
+	}
+
+	if err := validateUserFieldDefinition(field); err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid field definition"))
+		return
+	}
+
+	if err := db.Save(field).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to update user field"))
+		return
+	}
+
+	if field.Indexed {
+		if err := ensureUserAttributeIndex(db, field.Key); err != nil {
+			apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to create attribute index"))
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "User field updated successfully",
+		"data":    field,
+	})
+}
+
+// DeleteUserField removes a custom user field definition
+// @Summary Delete custom user field
+// @Description Delete a custom profile field definition. Existing values stay in user attributes until the next update.
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Param id path string true "Organization ID" format(uuid)
+// @Param field_id path string true "Field ID" format(uuid)
+// @Security BearerAuth
+// @Success 200 {object} handlers.SuccessResponse "Success message"
+// @Failure 400 {object} map[string]string "Invalid ID format"
+// @Failure 404 {object} map[string]string "Field not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /organizations/{id}/user-fields/{field_id} [delete]
+func DeleteUserField(ctx *gin.Context) {
+	db := requestDB(ctx)
+
+	field, ok := findUserField(ctx, db)
+	if !ok {
+		return
+	}
+
+	if err := db.Delete(field).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to delete user field"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "User field deleted successfully",
+	})
+}
+
+// findUserField loads a field definition by the :id and :field_id path parameters
+func findUserField(ctx *gin.Context, db *gorm.DB) (*models.UserFieldDefinition, bool) {
+	orgUUID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid organization ID format"))
+		return nil, false
+	}
+
+	fieldUUID, err := uuid.Parse(ctx.Param("field_id"))
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid field ID format"))
+		return nil, false
+	}
+
+	var field models.UserFieldDefinition
+	if err := db.Where("id = ? AND organization_id = ?", fieldUUID, orgUUID).First(&field).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			apperrors.Respond(ctx, apperrors.NotFound("User field not found").WithDetails("Field with the given ID does not exist in this organization"))
+			return nil, false
+		}
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to retrieve user field"))
+		return nil, false
+	}
+
+	return &field, true
+}
+
+// validateUserFieldDefinition checks that a field definition is internally consistent
+func validateUserFieldDefinition(field *models.UserFieldDefinition) error {
+	if !userFieldKeyPattern.MatchString(field.Key) {
+		return fmt.Errorf("key must start with a lowercase letter and contain only lowercase letters, digits and underscores")
+	}
+
+	if field.Type == models.UserFieldTypeEnum && len(splitOptions(field.Options)) == 0 {
+		return fmt.Errorf("enum fields require at least one option")
+	}
+
+	if field.Pattern != "" {
+		if field.Type != models.UserFieldTypeString {
+			return fmt.Errorf("pattern is only supported for string fields")
+		}
+		if _, err := regexp.Compile(field.Pattern); err != nil {
+			return fmt.Errorf("invalid pattern: %v", err)
+		}
+	}
+
+	if field.MinValue != nil && field.MaxValue != nil && *field.MinValue > *field.MaxValue {
+		return fmt.Errorf("min_value cannot be greater than max_value")
+	}
+
+	if field.MaxLength < 0 {
+		return fmt.Errorf("max_length cannot be negative")
+	}
+
+	return nil
+}
+
+// ensureUserAttributeIndex creates an expression index for filtering/sorting on an attribute key
+func ensureUserAttributeIndex(db *gorm.DB, key string) error {
+	if !userFieldKeyPattern.MatchString(key) {
+		return fmt.Errorf("invalid attribute key: %s", key)
+	}
+	return db.Exec(fmt.Sprintf(
+		"CREATE INDEX IF NOT EXISTS idx_users_attr_%s ON users ((attributes->>'%s'))", key, key,
+	)).Error
+}
+
+// indexedUserAttributeColumns returns filter/sort mappings for all indexed custom fields,
+// keyed as "attributes.<key>" so they can be merged into GetUsers allow-lists
+func indexedUserAttributeColumns(db *gorm.DB) map[string]string {
+	var keys []string
+	db.Model(&models.UserFieldDefinition{}).Where("indexed = ?", true).Distinct().Pluck("key", &keys)
+
+	columns := make(map[string]string, len(keys))
+	for _, key := range keys {
+		if userFieldKeyPattern.MatchString(key) {
+			columns["attributes."+key] = fmt.Sprintf("users.attributes->>'%s'", key)
+		}
+	}
+	return columns
+}
+
+// validateUserAttributes checks attribute values against the organization's field definitions.
+// Nil values remove the attribute. Returns the cleaned attribute map.
+func validateUserAttributes(db *gorm.DB, organizationID *uuid.UUID, attributes map[string]interface{}) (models.JSONMap, error) {
+	cleaned := models.JSONMap{}
+	for key, value := range attributes {
+		if value != nil {
+			cleaned[key] = value
+		}
+	}
+
+	if organizationID == nil {
+		if len(cleaned) > 0 {
+			return nil, fmt.Errorf("custom attributes require the user to belong to an organization")
+		}
+		return cleaned, nil
+	}
+
+	var fields []models.UserFieldDefinition
+	if err := db.Where("organization_id = ?", *organizationID).Find(&fields).Error; err != nil {
+		return nil, err
+	}
+
+	definitions := make(map[string]models.UserFieldDefinition, len(fields))
+	for _, field := range fields {
+		definitions[field.Key] = field
+	}
+
+	for key, value := range cleaned {
+		field, exists := definitions[key]
+		if !exists {
+			return nil, fmt.Errorf("unknown attribute: %s", key)
+		}
+		if err := validateUserAttributeValue(field, value); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, field := range fields {
+		if _, exists := cleaned[field.Key]; field.Required && !exists {
+			return nil, fmt.Errorf("attribute %s is required", field.Key)
+		}
+	}
+
+	return cleaned, nil
+}
+
+// validateUserAttributeValue validates a single value against its field definition
+func validateUserAttributeValue(field models.UserFieldDefinition, value interface{}) error {
+	switch field.Type {
+	case models.UserFieldTypeString:
+		str, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("attribute %s must be a string", field.Key)
+		}
+		if field.MaxLength > 0 && len(str) > field.MaxLength {
+			return fmt.Errorf("attribute %s must be at most %d characters", field.Key, field.MaxLength)
+		}
+		if field.Pattern != "" {
+			if matched, _ := regexp.MatchString(field.Pattern, str); !matched {
+				return fmt.Errorf("attribute %s does not match the required format", field.Key)
+			}
+		}
+	case models.UserFieldTypeNumber:
+		num, ok := value.(float64)
+		if !ok {
+			return fmt.Errorf("attribute %s must be a number", field.Key)
+		}
+		if field.MinValue != nil && num < *field.MinValue {
+			return fmt.Errorf("attribute %s must be at least %v", field.Key, *field.MinValue)
+		}
+		if field.MaxValue != nil && num > *field.MaxValue {
+			return fmt.Errorf("attribute %s must be at most %v", field.Key, *field.MaxValue)
+		}
+	case models.UserFieldTypeBoolean:
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("attribute %s must be a boolean", field.Key)
+		}
+	case models.UserFieldTypeDate:
+		str, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("attribute %s must be a date string (YYYY-MM-DD)", field.Key)
+		}
+		if _, err := time.Parse("2006-01-02", str); err != nil {
+			return fmt.Errorf("attribute %s must be a date string (YYYY-MM-DD)", field.Key)
+		}
+	case models.UserFieldTypeEnum:
+		str, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("attribute %s must be one of: %s", field.Key, field.Options)
+		}
+		for _, option := range splitOptions(field.Options) {
+			if option == str {
+				return nil
+			}
+		}
+		return fmt.Errorf("attribute %s must be one of: %s", field.Key, field.Options)
+	}
+	return nil
+}
+
+// splitOptions parses the comma separated enum options of a field definition
+func splitOptions(options string) []string {
+	var result []string
+	for _, option := range strings.Split(options, ",") {
+		if option = strings.TrimSpace(option); option != "" {
+			result = append(result, option)
+		}
+	}
+	return result
+}
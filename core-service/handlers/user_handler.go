@@ -1,11 +1,20 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"time"
 
+	"forgecrud-backend/shared/apperrors"
 	"forgecrud-backend/shared/database"
 	"forgecrud-backend/shared/database/models"
+	"forgecrud-backend/shared/database/models/auth"
+	"forgecrud-backend/shared/i18n"
+	"forgecrud-backend/shared/messaging"
+	utils "forgecrud-backend/shared/utils/auth"
+	"forgecrud-backend/shared/utils/concurrency"
 	"forgecrud-backend/shared/utils/query"
+	"forgecrud-backend/shared/utils/validation"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -14,42 +23,51 @@ import (
 
 // UserResponse represents user data for API responses
 type UserResponse struct {
-	ID            uuid.UUID            `json:"id"`
-	Email         string               `json:"email"`
-	FirstName     string               `json:"first_name"`
-	LastName      string               `json:"last_name"`
-	Phone         string               `json:"phone"`
-	Avatar        string               `json:"avatar"`
-	Status        string               `json:"status"`
-	EmailVerified bool                 `json:"email_verified"`
-	Organization  *models.Organization `json:"organization,omitempty"`
-	Role          *models.Role         `json:"role,omitempty"`
-	CreatedAt     string               `json:"created_at"`
-	UpdatedAt     string               `json:"updated_at"`
+	ID                uuid.UUID            `json:"id"`
+	Email             string               `json:"email"`
+	FirstName         string               `json:"first_name"`
+	LastName          string               `json:"last_name"`
+	Phone             string               `json:"phone"`
+	PhoneVerified     bool                 `json:"phone_verified"`
+	Avatar            string               `json:"avatar"`
+	Language          string               `json:"language"`
+	Status            string               `json:"status"`
+	Version           string               `json:"version"` // Send back as If-Match or version to detect concurrent updates
+	EmailVerified     bool                 `json:"email_verified"`
+	MustResetPassword bool                 `json:"must_reset_password"`
+	Attributes        models.JSONMap       `json:"attributes"`
+	DeletedAt         *string              `json:"deleted_at,omitempty"`
+	Organization      *models.Organization `json:"organization,omitempty"`
+	Role              *models.Role         `json:"role,omitempty"`
+	CreatedAt         string               `json:"created_at"`
+	UpdatedAt         string               `json:"updated_at"`
 }
 
 // CreateUserRequest represents request body for creating user
 type CreateUserRequest struct {
-	Email          string     `json:"email" binding:"required,email"`
-	Password       string     `json:"password" binding:"required,min=6"`
-	FirstName      string     `json:"first_name" binding:"required"`
-	LastName       string     `json:"last_name" binding:"required"`
-	Phone          string     `json:"phone"`
-	Avatar         string     `json:"avatar"`
-	OrganizationID *uuid.UUID `json:"organization_id"`
-	RoleID         *uuid.UUID `json:"role_id"`
+	Email          string                 `json:"email" binding:"required,email"`
+	Password       string                 `json:"password" binding:"required,min=8"`
+	FirstName      string                 `json:"first_name" binding:"required"`
+	LastName       string                 `json:"last_name" binding:"required"`
+	Phone          string                 `json:"phone"`
+	Language       string                 `json:"language" binding:"omitempty,bcp47_language_tag"` // Language of emails and notifications
+	OrganizationID *uuid.UUID             `json:"organization_id"`
+	RoleID         *uuid.UUID             `json:"role_id"`
+	Attributes     map[string]interface{} `json:"attributes"`
 }
 
 // UpdateUserRequest represents request body for updating user
 type UpdateUserRequest struct {
-	Email          string     `json:"email" binding:"omitempty,email"`
-	FirstName      string     `json:"first_name"`
-	LastName       string     `json:"last_name"`
-	Phone          string     `json:"phone"`
-	Avatar         string     `json:"avatar"`
-	Status         string     `json:"status"`
-	OrganizationID *uuid.UUID `json:"organization_id"`
-	RoleID         *uuid.UUID `json:"role_id"`
+	Email          string                 `json:"email" binding:"omitempty,email"`
+	FirstName      string                 `json:"first_name"`
+	LastName       string                 `json:"last_name"`
+	Phone          string                 `json:"phone"`
+	Language       string                 `json:"language" binding:"omitempty,bcp47_language_tag"`
+	Status         string                 `json:"status"`
+	OrganizationID *uuid.UUID             `json:"organization_id"`
+	RoleID         *uuid.UUID             `json:"role_id"`
+	Attributes     map[string]interface{} `json:"attributes"` // Merged into existing attributes; null removes a key
+	Version        string                 `json:"version"`    // Optional precondition, alternative to If-Match
 }
 
 // UserListResponse represents a list of users with pagination
@@ -75,6 +93,40 @@ type PaginationResponse struct {
 	TotalPages  int   `json:"total_pages"`
 }
 
+// buildUserResponse converts a user model (with preloaded relations) to its API representation
+func buildUserResponse(user models.User) UserResponse {
+	userResponse := UserResponse{
+		ID:                user.ID,
+		Email:             user.Email,
+		FirstName:         user.FirstName,
+		LastName:          user.LastName,
+		Phone:             user.Phone,
+		PhoneVerified:     user.PhoneVerified,
+		Avatar:            user.Avatar,
+		Language:          user.Language,
+		Status:            user.Status,
+		Version:           concurrency.Version(user.UpdatedAt),
+		EmailVerified:     user.EmailVerified,
+		MustResetPassword: user.MustResetPassword,
+		Attributes:        user.Attributes,
+		DeletedAt:         formatDeletedAt(user.DeletedAt),
+		CreatedAt:         user.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:         user.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+
+	// Add organization if exists and was loaded
+	if user.OrganizationID != nil && user.Organization.ID != uuid.Nil {
+		userResponse.Organization = &user.Organization
+	}
+
+	// Add role if exists and was loaded
+	if user.RoleID != nil && user.Role.ID != uuid.Nil {
+		userResponse.Role = &user.Role
+	}
+
+	return userResponse
+}
+
 // GetUsers retrieves all users with pagination and filtering
 // @Summary Get all users
 // @Description Get all users with pagination, filtering, sorting and search
@@ -84,18 +136,25 @@ type PaginationResponse struct {
 // @Param page query int false "Page number (default: 1)"
 // @Param limit query int false "Items per page (default: 10)"
 // @Param search query string false "Search term across name and email"
-// @Param filters[status] query string false "Filter by status (ACTIVE, INACTIVE, DELETED)"
+// @Param filters[status] query string false "Filter by status (INVITED, PENDING_VERIFICATION, ACTIVE, SUSPENDED, DEACTIVATED, DELETED, MERGED); filters[status][in] accepts a comma separated list"
 // @Param filters[organization_id] query string false "Filter by organization ID"
 // @Param filters[role_id] query string false "Filter by role ID"
-// @Param sort[field] query string false "Sort field (email, first_name, last_name, created_at, updated_at)"
+// @Param filters[attributes.key] query string false "Filter by an indexed custom field, e.g. filters[attributes.department]"
+// @Param sort[field] query string false "Sort field (email, first_name, last_name, created_at, updated_at, attributes.<indexed key>)"
 // @Param sort[order] query string false "Sort order (asc, desc)"
+// @Param include_deleted query string false "Include soft-deleted users (true) or list only the trash (only); requires users:manage"
+// @Param pagination query string false "Set to cursor for keyset pagination (ordered by created_at, no total count)"
+// @Param cursor query string false "Opaque cursor from pagination.next_cursor of the previous page"
+// @Param fields query string false "Comma separated fields to return, e.g. id,email,first_name (default: all)"
+// @Param expand query string false "Comma separated relations to embed: organization, role (default: both; empty for none)"
 // @Security BearerAuth
 // @Success 200 {object} handlers.UserListResponse
+// @Failure 400 {object} map[string]string "Invalid cursor"
 // @Failure 401 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /users [get]
 func GetUsers(ctx *gin.Context) {
-	db := database.DB
+	db := requestDB(ctx)
 
 	// Parse standardized query parameters
 	params := query.ParseQueryParams(ctx)
@@ -105,6 +164,8 @@ func GetUsers(ctx *gin.Context) {
 		"status":          "status",
 		"organization_id": "organization_id",
 		"role_id":         "role_id",
+		"created_at":      "created_at",
+		"updated_at":      "updated_at",
 	}
 
 	// Define allowed sort fields
@@ -117,13 +178,38 @@ func GetUsers(ctx *gin.Context) {
 		"updated_at": "updated_at",
 	}
 
+	// Indexed custom fields are filterable and sortable as attributes.<key>
+	for field, column := range indexedUserAttributeColumns(db) {
+		allowedFilters[field] = column
+		allowedSortFields[field] = column
+	}
+
 	// Define search fields
 	searchFields := []string{"first_name", "last_name", "email"}
 
+	// Define selectable fields and expandable relations
+	allowedFields := map[string]string{
+		"email":               "email",
+		"first_name":          "first_name",
+		"last_name":           "last_name",
+		"phone":               "phone",
+		"avatar":              "avatar",
+		"status":              "status",
+		"email_verified":      "email_verified",
+		"must_reset_password": "must_reset_password",
+		"attributes":          "attributes",
+		"deleted_at":          "deleted_at",
+		"created_at":          "created_at",
+		"updated_at":          "updated_at",
+	}
+	allowedExpand := map[string]string{
+		"organization": "Organization",
+		"role":         "Role",
+	}
+	relations := query.ResolveExpand(params.Expand, allowedExpand, "organization", "role")
+
 	// Build base query
-	baseQuery := db.Model(&models.User{}).
-		Preload("Organization").
-		Preload("Role")
+	baseQuery := applyDeletedScope(ctx, query.ApplyExpand(db.Model(&models.User{}), relations, allowedExpand))
 
 	// Apply filters
 	filteredQuery := query.ApplyFilters(baseQuery, params.Filters, allowedFilters)
@@ -131,60 +217,55 @@ func GetUsers(ctx *gin.Context) {
 	// Apply search
 	searchedQuery := query.ApplySearch(filteredQuery, params.Search, searchFields)
 
-	// Get total count
-	var total int64
-	searchedQuery.Count(&total)
+	var finalQuery *gorm.DB
+	var pagination interface{}
 
-	// Apply sorting and pagination
-	finalQuery := query.ApplySort(searchedQuery, params.Sort, allowedSortFields)
-	finalQuery = query.ApplyPagination(finalQuery, params.Page, params.Limit)
+	if params.CursorMode {
+		// Keyset pagination skips the total count, which gets expensive on large tables
+		cursorQuery, err := query.ApplyCursor(searchedQuery, params, "users")
+		if err != nil {
+			apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid cursor"))
+			return
+		}
+		finalQuery = cursorQuery
+	} else {
+		// Get total count
+		var total int64
+		searchedQuery.Count(&total)
+
+		// Apply sorting and pagination
+		finalQuery = query.ApplySort(searchedQuery, params.Sort, allowedSortFields)
+		finalQuery = query.ApplyPagination(finalQuery, params.Page, params.Limit)
+		pagination = query.BuildPaginationResponse(params.Page, params.Limit, total)
+	}
+
+	// Foreign keys stay selected so expanded relations can be preloaded
+	finalQuery = query.ApplyFieldSelection(finalQuery, params.Fields, allowedFields,
+		"id", "created_at", "organization_id", "role_id")
 
 	// Get users
 	var users []models.User
 	if err := finalQuery.Find(&users).Error; err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to retrieve users",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to retrieve users"))
 		return
 	}
 
+	if params.CursorMode {
+		users, pagination = query.TrimCursorPage(users, params.Limit, func(user models.User) (time.Time, string) {
+			return user.CreatedAt, user.ID.String()
+		})
+	}
+
 	// Convert to response format
 	var userResponses []UserResponse
 	for _, user := range users {
-		userResponse := UserResponse{
-			ID:            user.ID,
-			Email:         user.Email,
-			FirstName:     user.FirstName,
-			LastName:      user.LastName,
-			Phone:         user.Phone,
-			Avatar:        user.Avatar,
-			Status:        user.Status,
-			EmailVerified: user.EmailVerified,
-			CreatedAt:     user.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-			UpdatedAt:     user.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		}
-
-		// Add organization if exists
-		if user.OrganizationID != nil {
-			userResponse.Organization = &user.Organization
-		}
-
-		// Add role if exists
-		if user.RoleID != nil {
-			userResponse.Role = &user.Role
-		}
-
-		userResponses = append(userResponses, userResponse)
+		userResponses = append(userResponses, buildUserResponse(user))
 	}
 
-	// Build pagination response
-	pagination := query.BuildPaginationResponse(params.Page, params.Limit, total)
-
 	ctx.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"data": gin.H{
-			"items":      userResponses,
+			"items":      query.SparseFieldset(userResponses, params.Fields, relations),
 			"pagination": pagination,
 		},
 	})
@@ -208,55 +289,26 @@ func GetUser(ctx *gin.Context) {
 	userID := ctx.Param("id")
 	userUUID, err := uuid.Parse(userID)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid user ID format",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid user ID format"))
 		return
 	}
 
-	db := database.DB
+	db := requestDB(ctx)
 	var user models.User
 
 	if err := db.Preload("Organization").Preload("Role").First(&user, userUUID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			ctx.JSON(http.StatusNotFound, gin.H{
-				"error":   "User not found",
-				"message": "User with the given ID does not exist",
-			})
+			apperrors.Respond(ctx, apperrors.NotFound("User not found").WithDetails("User with the given ID does not exist"))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to retrieve user",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to retrieve user"))
 		return
 	}
 
 	// Convert to response format
-	userResponse := UserResponse{
-		ID:            user.ID,
-		Email:         user.Email,
-		FirstName:     user.FirstName,
-		LastName:      user.LastName,
-		Phone:         user.Phone,
-		Avatar:        user.Avatar,
-		Status:        user.Status,
-		EmailVerified: user.EmailVerified,
-		CreatedAt:     user.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt:     user.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
-	}
-
-	// Add organization if exists
-	if user.OrganizationID != nil {
-		userResponse.Organization = &user.Organization
-	}
-
-	// Add role if exists
-	if user.RoleID != nil {
-		userResponse.Role = &user.Role
-	}
+	userResponse := buildUserResponse(user)
 
+	concurrency.SetETag(ctx, user.UpdatedAt)
 	ctx.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"data":    userResponse,
@@ -280,22 +332,16 @@ func GetUser(ctx *gin.Context) {
 func CreateUser(ctx *gin.Context) {
 	var request CreateUserRequest
 	if err := ctx.ShouldBindJSON(&request); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request data",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, validation.Error(err, "Invalid request data"))
 		return
 	}
 
-	db := database.DB
+	db := requestDB(ctx)
 
-	// Check if email already exists
+	// Check if email already exists (deleted users keep their email until purged)
 	var existingUser models.User
-	if err := db.Where("email = ?", request.Email).First(&existingUser).Error; err == nil {
-		ctx.JSON(http.StatusConflict, gin.H{
-			"error":   "Email already exists",
-			"message": "A user with this email already exists",
-		})
+	if err := db.Unscoped().Where("email = ?", request.Email).First(&existingUser).Error; err == nil {
+		apperrors.Respond(ctx, apperrors.Conflict("Email already exists").WithDetails("A user with this email already exists"))
 		return
 	}
 
@@ -303,10 +349,18 @@ func CreateUser(ctx *gin.Context) {
 	if request.OrganizationID != nil {
 		var org models.Organization
 		if err := db.First(&org, *request.OrganizationID).Error; err != nil {
-			ctx.JSON(http.StatusBadRequest, gin.H{
-				"error":   "Invalid organization ID",
-				"message": "Organization not found",
-			})
+			apperrors.Respond(ctx, apperrors.BadRequest("Invalid organization ID").WithDetails("Organization not found"))
+			return
+		}
+	}
+
+	// Enforce the organization's user quota
+	if organizationID := quotaOrganizationID(ctx, request.OrganizationID); organizationID != nil {
+		if err := database.CheckUserQuota(database.DB, *organizationID); err != nil {
+			if respondQuotaExceeded(ctx, err) {
+				return
+			}
+			apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to check quota"))
 			return
 		}
 	}
@@ -315,33 +369,60 @@ func CreateUser(ctx *gin.Context) {
 	if request.RoleID != nil {
 		var role models.Role
 		if err := db.First(&role, *request.RoleID).Error; err != nil {
-			ctx.JSON(http.StatusBadRequest, gin.H{
-				"error":   "Invalid role ID",
-				"message": "Role not found",
-			})
+			apperrors.Respond(ctx, apperrors.BadRequest("Invalid role ID").WithDetails("Role not found"))
 			return
 		}
 	}
 
-	// Create new user
+	// Validate password strength with the same rules as self-registration
+	if err := utils.ValidatePassword(request.Password); err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid password"))
+		return
+	}
+
+	// Validate custom attributes against the organization's field schema
+	attributes, err := validateUserAttributes(db, request.OrganizationID, request.Attributes)
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid attributes"))
+		return
+	}
+
+	hashedPassword, err := utils.HashPassword(request.Password)
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to hash password"))
+		return
+	}
+
+	// Create new user; admin-created accounts must set their own password on first login
 	user := models.User{
-		Email:          request.Email,
-		Password:       request.Password, // Note: In production, hash this password
-		FirstName:      request.FirstName,
-		LastName:       request.LastName,
-		Phone:          request.Phone,
-		Avatar:         request.Avatar,
-		Status:         "ACTIVE",
-		EmailVerified:  false,
-		OrganizationID: request.OrganizationID,
-		RoleID:         request.RoleID,
-	}
-
-	if err := db.Create(&user).Error; err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to create user",
-			"message": err.Error(),
+		Email:             request.Email,
+		Password:          hashedPassword,
+		MustResetPassword: true,
+		FirstName:         request.FirstName,
+		LastName:          request.LastName,
+		Phone:             request.Phone,
+		Language:          i18n.NormalizeLocale(request.Language),
+		Status:            models.UserStatusInvited,
+		EmailVerified:     false,
+		OrganizationID:    request.OrganizationID,
+		RoleID:            request.RoleID,
+		Attributes:        attributes,
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		_, err := database.RecordRoleAssignment(tx, database.RoleAssignmentEntry{
+			UserID:  user.ID,
+			RoleID:  user.RoleID,
+			Source:  models.RoleAssignmentSourceAPI,
+			ActorID: utils.GetActorID(ctx),
 		})
+		return err
+	})
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to create user"))
 		return
 	}
 
@@ -349,28 +430,9 @@ func CreateUser(ctx *gin.Context) {
 	db.Preload("Organization").Preload("Role").First(&user, user.ID)
 
 	// Convert to response format
-	userResponse := UserResponse{
-		ID:            user.ID,
-		Email:         user.Email,
-		FirstName:     user.FirstName,
-		LastName:      user.LastName,
-		Phone:         user.Phone,
-		Avatar:        user.Avatar,
-		Status:        user.Status,
-		EmailVerified: user.EmailVerified,
-		CreatedAt:     user.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt:     user.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
-	}
-
-	// Add organization if exists
-	if user.OrganizationID != nil {
-		userResponse.Organization = &user.Organization
-	}
+	userResponse := buildUserResponse(user)
 
-	// Add role if exists
-	if user.RoleID != nil {
-		userResponse.Role = &user.Role
-	}
+	emitEvent(ctx, messaging.EventUserCreated, userResponse)
 
 	ctx.JSON(http.StatusCreated, gin.H{
 		"success": true,
@@ -386,62 +448,59 @@ func CreateUser(ctx *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param id path string true "User ID" format(uuid)
+// @Param If-Match header string false "Version (ETag) the update is based on"
 // @Param user body UpdateUserRequest true "Updated user information"
 // @Security BearerAuth
 // @Success 200 {object} handlers.SingleUserResponse "Updated user"
 // @Failure 400 {object} map[string]string "Invalid request data or ID format"
 // @Failure 401 {object} map[string]string "Unauthorized"
 // @Failure 404 {object} map[string]string "User not found"
-// @Failure 409 {object} map[string]string "Email already exists"
+// @Failure 409 {object} map[string]string "Email already exists or version conflict"
 // @Failure 500 {object} map[string]string "Server error"
 // @Router /users/{id} [put]
 func UpdateUser(ctx *gin.Context) {
 	userID := ctx.Param("id")
 	userUUID, err := uuid.Parse(userID)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid user ID format",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid user ID format"))
 		return
 	}
 
 	var request UpdateUserRequest
 	if err := ctx.ShouldBindJSON(&request); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request data",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, validation.Error(err, "Invalid request data"))
+		return
+	}
+
+	expectedVersion, err := concurrency.ExpectedVersion(ctx, request.Version)
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid version"))
 		return
 	}
 
-	db := database.DB
+	db := requestDB(ctx)
 	var user models.User
 
 	// Check if user exists
 	if err := db.First(&user, userUUID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			ctx.JSON(http.StatusNotFound, gin.H{
-				"error":   "User not found",
-				"message": "User with the given ID does not exist",
-			})
+			apperrors.Respond(ctx, apperrors.NotFound("User not found").WithDetails("User with the given ID does not exist"))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to retrieve user",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to retrieve user"))
 		return
 	}
 
-	// Check if email already exists for another user
+	if err := concurrency.CheckVersion(user.UpdatedAt, expectedVersion); err != nil {
+		respondVersionConflict(ctx, err)
+		return
+	}
+
+	// Check if email already exists for another user (including deleted users)
 	if request.Email != "" && request.Email != user.Email {
 		var existingUser models.User
-		if err := db.Where("email = ? AND id != ?", request.Email, userUUID).First(&existingUser).Error; err == nil {
-			ctx.JSON(http.StatusConflict, gin.H{
-				"error":   "Email already exists",
-				"message": "Another user with this email already exists",
-			})
+		if err := db.Unscoped().Where("email = ? AND id != ?", request.Email, userUUID).First(&existingUser).Error; err == nil {
+			apperrors.Respond(ctx, apperrors.Conflict("Email already exists").WithDetails("Another user with this email already exists"))
 			return
 		}
 	}
@@ -450,10 +509,7 @@ func UpdateUser(ctx *gin.Context) {
 	if request.OrganizationID != nil {
 		var org models.Organization
 		if err := db.First(&org, *request.OrganizationID).Error; err != nil {
-			ctx.JSON(http.StatusBadRequest, gin.H{
-				"error":   "Invalid organization ID",
-				"message": "Organization not found",
-			})
+			apperrors.Respond(ctx, apperrors.BadRequest("Invalid organization ID").WithDetails("Organization not found"))
 			return
 		}
 	}
@@ -462,10 +518,7 @@ func UpdateUser(ctx *gin.Context) {
 	if request.RoleID != nil {
 		var role models.Role
 		if err := db.First(&role, *request.RoleID).Error; err != nil {
-			ctx.JSON(http.StatusBadRequest, gin.H{
-				"error":   "Invalid role ID",
-				"message": "Role not found",
-			})
+			apperrors.Respond(ctx, apperrors.BadRequest("Invalid role ID").WithDetails("Role not found"))
 			return
 		}
 	}
@@ -483,12 +536,18 @@ func UpdateUser(ctx *gin.Context) {
 	}
 	if request.Phone != "" {
 		updates["phone"] = request.Phone
+		// A changed number has to be verified by SMS again before security alerts are texted to it
+		if request.Phone != user.Phone {
+			updates["phone_verified"] = false
+		}
 	}
-	if request.Avatar != "" {
-		updates["avatar"] = request.Avatar
+	if request.Language != "" {
+		updates["language"] = i18n.NormalizeLocale(request.Language)
 	}
-	if request.Status != "" {
-		updates["status"] = request.Status
+	// Status changes follow the lifecycle transitions
+	if request.Status != "" && request.Status != user.Status && !models.CanTransitionUserStatus(user.Status, request.Status) {
+		apperrors.Respond(ctx, apperrors.Wrap((&UserTransitionError{From: user.Status, To: request.Status}), apperrors.CodeConflict, "Transition not allowed").With("transitions", models.UserStatusTransitions[user.Status]))
+		return
 	}
 	if request.OrganizationID != nil {
 		updates["organization_id"] = request.OrganizationID
@@ -497,42 +556,79 @@ func UpdateUser(ctx *gin.Context) {
 		updates["role_id"] = request.RoleID
 	}
 
+	// Re-validate attributes when they change or when the user moves to another organization
+	organizationChanged := request.OrganizationID != nil &&
+		(user.OrganizationID == nil || *request.OrganizationID != *user.OrganizationID)
+	if request.Attributes != nil || organizationChanged {
+		targetOrgID := user.OrganizationID
+		merged := map[string]interface{}{}
+		if organizationChanged {
+			targetOrgID = request.OrganizationID
+		} else {
+			for key, value := range user.Attributes {
+				merged[key] = value
+			}
+		}
+		for key, value := range request.Attributes {
+			merged[key] = value
+		}
+
+		attributes, err := validateUserAttributes(db, targetOrgID, merged)
+		if err != nil {
+			apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid attributes"))
+			return
+		}
+		updates["attributes"] = attributes
+	}
+
 	// Perform update
-	if err := db.Model(&user).Updates(updates).Error; err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to update user",
-			"message": err.Error(),
-		})
+	previousRoleID := user.RoleID
+	previousStatus := user.Status
+	var roleAssignment *models.RoleAssignment
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := concurrency.Updates(tx, &user, expectedVersion, updates); err != nil {
+			return err
+		}
+		if request.RoleID != nil {
+			var err error
+			roleAssignment, err = database.RecordRoleAssignment(tx, database.RoleAssignmentEntry{
+				UserID:         user.ID,
+				PreviousRoleID: previousRoleID,
+				RoleID:         request.RoleID,
+				Source:         models.RoleAssignmentSourceAPI,
+				ActorID:        utils.GetActorID(ctx),
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if request.Status != "" && request.Status != user.Status {
+			return transitionUserStatus(tx, &user, request.Status)
+		}
+		return nil
+	})
+	if errors.Is(err, concurrency.ErrVersionConflict) {
+		respondVersionConflict(ctx, err)
+		return
+	}
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to update user"))
 		return
 	}
+	if user.Status != previousStatus && terminatesSessions(user.Status) {
+		revokeUserSessions(ctx, user.ID)
+	}
 
 	// Load updated user with relations
 	db.Preload("Organization").Preload("Role").First(&user, userUUID)
 
 	// Convert to response format
-	userResponse := UserResponse{
-		ID:            user.ID,
-		Email:         user.Email,
-		FirstName:     user.FirstName,
-		LastName:      user.LastName,
-		Phone:         user.Phone,
-		Avatar:        user.Avatar,
-		Status:        user.Status,
-		EmailVerified: user.EmailVerified,
-		CreatedAt:     user.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt:     user.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
-	}
-
-	// Add organization if exists
-	if user.OrganizationID != nil {
-		userResponse.Organization = &user.Organization
-	}
+	userResponse := buildUserResponse(user)
 
-	// Add role if exists
-	if user.RoleID != nil {
-		userResponse.Role = &user.Role
-	}
+	emitEvent(ctx, messaging.EventUserUpdated, userResponse)
+	publishRoleChanged(ctx, user, roleAssignment)
 
+	concurrency.SetETag(ctx, user.UpdatedAt)
 	ctx.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "User updated successfully",
@@ -542,7 +638,7 @@ func UpdateUser(ctx *gin.Context) {
 
 // DeleteUser deletes a user (soft delete)
 // @Summary Delete a user
-// @Description Soft delete a user by setting status to DELETED
+// @Description Soft delete a user: sets status to DELETED, moves the user to trash and ends active sessions
 // @Tags users
 // @Accept json
 // @Produce json
@@ -558,40 +654,40 @@ func DeleteUser(ctx *gin.Context) {
 	userID := ctx.Param("id")
 	userUUID, err := uuid.Parse(userID)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid user ID format",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid user ID format"))
 		return
 	}
 
-	db := database.DB
+	db := requestDB(ctx)
 	var user models.User
 
 	// Check if user exists
 	if err := db.First(&user, userUUID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			ctx.JSON(http.StatusNotFound, gin.H{
-				"error":   "User not found",
-				"message": "User with the given ID does not exist",
-			})
+			apperrors.Respond(ctx, apperrors.NotFound("User not found").WithDetails("User with the given ID does not exist"))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to retrieve user",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to retrieve user"))
 		return
 	}
 
-	// Soft delete by setting status to DELETED
-	if err := db.Model(&user).Update("status", "DELETED").Error; err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to delete user",
-			"message": err.Error(),
-		})
+	// Soft delete: mark as DELETED and move to trash so the user can be restored until purged
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Update("status", models.UserStatusDeleted).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&auth.UserSession{}).Where("user_id = ?", user.ID).Update("is_active", false).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&user).Error
+	})
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to delete user"))
 		return
 	}
+	revokeUserSessions(ctx, user.ID)
+
+	emitEvent(ctx, messaging.EventUserDeleted, buildUserResponse(user))
 
 	ctx.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -601,7 +697,7 @@ func DeleteUser(ctx *gin.Context) {
 
 // GetUserPermissions retrieves all permissions for a specific user
 // @Summary Get user permissions
-// @Description Get all permissions assigned to a specific user including user-level, role-level and organization-level permissions
+// @Description Get all permissions assigned to a specific user including user-level, team-level, role-level and organization-level permissions
 // @Tags users
 // @Accept json
 // @Produce json
@@ -617,29 +713,20 @@ func GetUserPermissions(ctx *gin.Context) {
 	userID := ctx.Param("id")
 	userUUID, err := uuid.Parse(userID)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid user ID format",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid user ID format"))
 		return
 	}
 
-	db := database.DB
+	db := requestDB(ctx)
 
 	// Check if user exists
 	var user models.User
 	if err := db.Preload("Organization").Preload("Role").First(&user, userUUID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			ctx.JSON(http.StatusNotFound, gin.H{
-				"error":   "User not found",
-				"message": "User with the given ID does not exist",
-			})
+			apperrors.Respond(ctx, apperrors.NotFound("User not found").WithDetails("User with the given ID does not exist"))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to retrieve user",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to retrieve user"))
 		return
 	}
 
@@ -650,6 +737,15 @@ func GetUserPermissions(ctx *gin.Context) {
 		Where("target = ? AND user_id = ?", "USER", userUUID).
 		Find(&userPermissions)
 
+	// Get team-level permissions for every team the user belongs to
+	var teamPermissions []models.Permission
+	db.Preload("Resource").
+		Preload("PermissionActions.Action").
+		Preload("Team").
+		Where("target = ? AND team_id IN (?)", "TEAM",
+			db.Model(&models.TeamMember{}).Select("team_id").Where("user_id = ?", userUUID)).
+		Find(&teamPermissions)
+
 	// Get role-level permissions if user has a role
 	var rolePermissions []models.Permission
 	if user.RoleID != nil {
@@ -674,6 +770,7 @@ func GetUserPermissions(ctx *gin.Context) {
 			"user": user,
 			"permissions": gin.H{
 				"user_permissions": userPermissions,
+				"team_permissions": teamPermissions,
 				"role_permissions": rolePermissions,
 				"org_permissions":  orgPermissions,
 			},
@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/database/models"
+	"forgecrud-backend/shared/database/models/auth"
+	"forgecrud-backend/shared/messaging"
+	"forgecrud-backend/shared/revocation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// UserTransitionError is returned when a lifecycle transition is not allowed from the current state
+type UserTransitionError struct {
+	From string
+	To   string
+}
+
+func (e *UserTransitionError) Error() string {
+	return fmt.Sprintf("user cannot move from %s to %s", e.From, e.To)
+}
+
+// transitionUserStatus moves the user to the given lifecycle state. Suspending or deactivating
+// a user terminates all of their sessions.
+func transitionUserStatus(tx *gorm.DB, user *models.User, to string) error {
+	if !models.CanTransitionUserStatus(user.Status, to) {
+		return &UserTransitionError{From: user.Status, To: to}
+	}
+
+	return tx.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Update("status", to).Error; err != nil {
+			return err
+		}
+		if terminatesSessions(to) {
+			if err := tx.Model(&auth.UserSession{}).Where("user_id = ?", user.ID).Update("is_active", false).Error; err != nil {
+				return fmt.Errorf("terminate sessions: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// terminatesSessions reports whether moving a user to the status terminates their sessions
+func terminatesSessions(status string) bool {
+	return status == models.UserStatusSuspended || status == models.UserStatusDeactivated
+}
+
+// revokeUserSessions announces that every session of the user was terminated, so the gateway
+// rejects their access tokens and the notification service closes their connections right away
+func revokeUserSessions(ctx *gin.Context, userID uuid.UUID) {
+	revocation.Publish(ctx.Request.Context(), revocation.Revocation{UserID: userID.String()})
+}
+
+// ActivateUser activates a user
+// @Summary Activate a user
+// @Description Move an invited, unverified, suspended or deactivated user to ACTIVE
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID" format(uuid)
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Updated user"
+// @Failure 400 {object} map[string]string "Invalid user ID format"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 409 {object} map[string]string "Transition not allowed"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /users/{id}/activate [post]
+func ActivateUser(ctx *gin.Context) {
+	handleUserTransition(ctx, models.UserStatusActive)
+}
+
+// SuspendUser suspends a user
+// @Summary Suspend a user
+// @Description Temporarily block an active user and terminate all of their sessions
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID" format(uuid)
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Updated user"
+// @Failure 400 {object} map[string]string "Invalid user ID format"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 409 {object} map[string]string "Transition not allowed"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /users/{id}/suspend [post]
+func SuspendUser(ctx *gin.Context) {
+	handleUserTransition(ctx, models.UserStatusSuspended)
+}
+
+// DeactivateUser deactivates a user
+// @Summary Deactivate a user
+// @Description Deactivate a user and terminate all of their sessions; the account can be activated again later
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID" format(uuid)
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Updated user"
+// @Failure 400 {object} map[string]string "Invalid user ID format"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 409 {object} map[string]string "Transition not allowed"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /users/{id}/deactivate [post]
+func DeactivateUser(ctx *gin.Context) {
+	handleUserTransition(ctx, models.UserStatusDeactivated)
+}
+
+// GetUserTransitions lists the lifecycle states a user can move to
+// @Summary Get allowed user transitions
+// @Description Get the current lifecycle state of a user and the states it can move to
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID" format(uuid)
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Current status and allowed transitions"
+// @Failure 400 {object} map[string]string "Invalid user ID format"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /users/{id}/transitions [get]
+func GetUserTransitions(ctx *gin.Context) {
+	user, ok := findLifecycleUser(ctx)
+	if !ok {
+		return
+	}
+
+	transitions := models.UserStatusTransitions[user.Status]
+	if transitions == nil {
+		transitions = []string{}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"status":      user.Status,
+			"transitions": transitions,
+		},
+	})
+}
+
+// handleUserTransition loads the user from the :id path parameter and moves it to the given state
+func handleUserTransition(ctx *gin.Context, to string) {
+	user, ok := findLifecycleUser(ctx)
+	if !ok {
+		return
+	}
+
+	db := requestDB(ctx)
+	from := user.Status
+
+	if err := transitionUserStatus(db, &user, to); err != nil {
+		if transitionErr, isTransition := err.(*UserTransitionError); isTransition {
+			apperrors.Respond(ctx, apperrors.Wrap(transitionErr, apperrors.CodeConflict, "Transition not allowed").With("transitions", models.UserStatusTransitions[from]))
+			return
+		}
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to update user status"))
+		return
+	}
+
+	if terminatesSessions(to) {
+		revokeUserSessions(ctx, user.ID)
+	}
+
+	db.Preload("Organization").Preload("Role").First(&user, user.ID)
+	userResponse := buildUserResponse(user)
+
+	emitEvent(ctx, messaging.EventUserUpdated, userResponse)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": fmt.Sprintf("User status changed from %s to %s", from, to),
+		"data":    userResponse,
+	})
+}
+
+// findLifecycleUser loads the user from the :id path parameter and writes the error response if needed
+func findLifecycleUser(ctx *gin.Context) (models.User, bool) {
+	var user models.User
+
+	userUUID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid user ID format"))
+		return user, false
+	}
+
+	if err := requestDB(ctx).First(&user, userUUID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			apperrors.Respond(ctx, apperrors.NotFound("User not found").WithDetails("User with the given ID does not exist"))
+			return user, false
+		}
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to retrieve user"))
+		return user, false
+	}
+
+	return user, true
+}
@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/database/models"
+	"forgecrud-backend/shared/database/models/auth"
+	"forgecrud-backend/shared/database/models/document"
+	"forgecrud-backend/shared/database/models/notification"
+	"forgecrud-backend/shared/messaging"
+	utils "forgecrud-backend/shared/utils/auth"
+	"forgecrud-backend/shared/utils/validation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// MergeUsersRequest represents request body for merging a duplicate account into a user
+type MergeUsersRequest struct {
+	DuplicateID uuid.UUID `json:"duplicate_id" binding:"required"`
+}
+
+// MergeUsersResult summarizes what was moved to the surviving account
+type MergeUsersResult struct {
+	SurvivorID    uuid.UUID        `json:"survivor_id"`
+	DuplicateID   uuid.UUID        `json:"duplicate_id"`
+	DuplicateMail string           `json:"duplicate_email"`
+	Reassigned    map[string]int64 `json:"reassigned"`
+}
+
+// MergeUsers merges a duplicate account into the user
+// @Summary Merge two user accounts
+// @Description Reassign documents, folders, sessions, permissions, team memberships and notifications of the duplicate account to the surviving user, record the merge in the audit log and tombstone the duplicate
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "Surviving user ID" format(uuid)
+// @Param request body MergeUsersRequest true "Duplicate account"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Merge summary"
+// @Failure 400 {object} map[string]string "Invalid request"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /users/{id}/merge [post]
+func MergeUsers(ctx *gin.Context) {
+	survivorID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid user ID format"))
+		return
+	}
+
+	var req MergeUsersRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apperrors.Respond(ctx, validation.Error(err, "Invalid request"))
+		return
+	}
+
+	if req.DuplicateID == survivorID {
+		apperrors.Respond(ctx, apperrors.BadRequest("Invalid request").WithDetails("A user cannot be merged into itself"))
+		return
+	}
+
+	db := requestDB(ctx)
+
+	var survivor, duplicate models.User
+	if err := db.First(&survivor, survivorID).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.NotFound("User not found").WithDetails("Surviving user does not exist"))
+		return
+	}
+	if err := db.First(&duplicate, req.DuplicateID).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.NotFound("User not found").WithDetails("Duplicate user does not exist"))
+		return
+	}
+
+	result := MergeUsersResult{
+		SurvivorID:    survivor.ID,
+		DuplicateID:   duplicate.ID,
+		DuplicateMail: duplicate.Email,
+		Reassigned:    make(map[string]int64),
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := reassignUserOwnership(tx, duplicate.ID, survivor.ID, result.Reassigned); err != nil {
+			return err
+		}
+
+		// Tombstone the duplicate so it can still be looked up in the trash and history
+		if err := tx.Model(&duplicate).Update("status", models.UserStatusMerged).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&duplicate).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&notification.AuditLog{
+			UserID:      utils.GetActorID(ctx),
+			Method:      "MERGE",
+			Path:        ctx.Request.URL.Path,
+			StatusCode:  http.StatusOK,
+			RequestBody: result,
+			IPAddress:   ctx.ClientIP(),
+			UserAgent:   ctx.Request.UserAgent(),
+		}).Error
+	})
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to merge users"))
+		return
+	}
+
+	db.Preload("Organization").Preload("Role").First(&survivor, survivor.ID)
+
+	// Both accounts changed, which also invalidates their cached permission checks
+	emitEvent(ctx, messaging.EventUserDeleted, buildUserResponse(duplicate))
+	emitEvent(ctx, messaging.EventUserUpdated, buildUserResponse(survivor))
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Users merged successfully",
+		"data": gin.H{
+			"user":  buildUserResponse(survivor),
+			"merge": result,
+		},
+	})
+}
+
+// reassignUserOwnership moves everything owned by the duplicate to the survivor and records
+// the number of moved rows per kind in counts
+func reassignUserOwnership(tx *gorm.DB, duplicateID, survivorID uuid.UUID, counts map[string]int64) error {
+	reassign := func(name string, model interface{}, column string, extra ...interface{}) error {
+		q := tx.Model(model).Where(column+" = ?", duplicateID)
+		if len(extra) > 0 {
+			q = q.Where(extra[0], extra[1:]...)
+		}
+		res := q.Update(column, survivorID)
+		if res.Error != nil {
+			return fmt.Errorf("reassign %s: %w", name, res.Error)
+		}
+		counts[name] = res.RowsAffected
+		return nil
+	}
+
+	if err := reassign("documents", &document.Document{}, "uploaded_by"); err != nil {
+		return err
+	}
+	if err := reassign("document_versions", &document.DocumentVersion{}, "created_by"); err != nil {
+		return err
+	}
+	if err := reassign("folders", &document.Folder{}, "owner_id", "owner_type = ?", "user"); err != nil {
+		return err
+	}
+	if err := reassign("organizations", &models.Organization{}, "owner_id"); err != nil {
+		return err
+	}
+	if err := reassign("notifications", &notification.Notification{}, "user_id"); err != nil {
+		return err
+	}
+
+	// Sessions move to the survivor but are ended, the duplicate's tokens must not keep working
+	if err := tx.Model(&auth.UserSession{}).Where("user_id = ?", duplicateID).Updates(map[string]interface{}{
+		"user_id":   survivorID,
+		"is_active": false,
+	}).Error; err != nil {
+		return fmt.Errorf("reassign sessions: %w", err)
+	}
+
+	// Pending verification and reset tokens belong to the duplicate's email address
+	if err := tx.Where("user_id = ?", duplicateID).Delete(&auth.EmailVerificationToken{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("user_id = ?", duplicateID).Delete(&auth.PasswordResetToken{}).Error; err != nil {
+		return err
+	}
+
+	// Team memberships: drop the ones the survivor already has, move the rest
+	if err := tx.Where("user_id = ? AND team_id IN (?)", duplicateID,
+		tx.Model(&models.TeamMember{}).Select("team_id").Where("user_id = ?", survivorID),
+	).Delete(&models.TeamMember{}).Error; err != nil {
+		return err
+	}
+	if err := reassign("team_members", &models.TeamMember{}, "user_id"); err != nil {
+		return err
+	}
+
+	return mergeUserPermissions(tx, duplicateID, survivorID, counts)
+}
+
+// mergeUserPermissions moves the duplicate's user-level permissions to the survivor. When both
+// have a permission on the same resource the actions are combined on the survivor's permission.
+func mergeUserPermissions(tx *gorm.DB, duplicateID, survivorID uuid.UUID, counts map[string]int64) error {
+	overlapping := tx.Table("permissions AS dp").
+		Joins("JOIN permissions sp ON sp.resource_id = dp.resource_id AND sp.target = ? AND sp.user_id = ?",
+			models.PermissionTargetUser, survivorID).
+		Where("dp.target = ? AND dp.user_id = ?", models.PermissionTargetUser, duplicateID)
+
+	if err := tx.Exec(`
+		INSERT INTO permission_actions (permission_id, action_id, created_at, updated_at)
+		SELECT DISTINCT sp.id, pa.action_id, NOW(), NOW()
+		FROM permissions dp
+		JOIN permission_actions pa ON pa.permission_id = dp.id
+		JOIN permissions sp ON sp.resource_id = dp.resource_id AND sp.target = ? AND sp.user_id = ?
+		WHERE dp.target = ? AND dp.user_id = ?
+		AND NOT EXISTS (
+			SELECT 1 FROM permission_actions existing
+			WHERE existing.permission_id = sp.id AND existing.action_id = pa.action_id
+		)`,
+		models.PermissionTargetUser, survivorID, models.PermissionTargetUser, duplicateID,
+	).Error; err != nil {
+		return fmt.Errorf("merge permission actions: %w", err)
+	}
+
+	var overlappingIDs []uuid.UUID
+	if err := overlapping.Pluck("dp.id", &overlappingIDs).Error; err != nil {
+		return err
+	}
+	if len(overlappingIDs) > 0 {
+		if err := tx.Where("permission_id IN ?", overlappingIDs).Delete(&models.PermissionAction{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("id IN ?", overlappingIDs).Delete(&models.Permission{}).Error; err != nil {
+			return err
+		}
+	}
+
+	res := tx.Model(&models.Permission{}).
+		Where("target = ? AND user_id = ?", models.PermissionTargetUser, duplicateID).
+		Update("user_id", survivorID)
+	if res.Error != nil {
+		return fmt.Errorf("reassign permissions: %w", res.Error)
+	}
+	counts["permissions"] = res.RowsAffected + int64(len(overlappingIDs))
+	return nil
+}
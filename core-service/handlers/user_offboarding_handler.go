@@ -0,0 +1,283 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/database/models"
+	"forgecrud-backend/shared/database/models/auth"
+	"forgecrud-backend/shared/database/models/document"
+	"forgecrud-backend/shared/database/models/notification"
+	"forgecrud-backend/shared/messaging"
+	utils "forgecrud-backend/shared/utils/auth"
+	"forgecrud-backend/shared/utils/validation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// OffboardUserRequest represents request body for offboarding a user
+type OffboardUserRequest struct {
+	SuccessorID *uuid.UUID `json:"successor_id"` // Receives documents, folders and owned organizations
+	Reason      string     `json:"reason" binding:"omitempty,max=500"`
+}
+
+// OffboardUserResult summarizes what offboarding revoked and handed over
+type OffboardUserResult struct {
+	UserID         uuid.UUID        `json:"user_id"`
+	SuccessorID    *uuid.UUID       `json:"successor_id"`
+	PreviousStatus string           `json:"previous_status"`
+	Reason         string           `json:"reason,omitempty"`
+	Revoked        map[string]int64 `json:"revoked"`
+	Reassigned     map[string]int64 `json:"reassigned"`
+}
+
+// OffboardUser deactivates a user and revokes everything that gave them access
+// @Summary Offboard a user
+// @Description In one transaction: deactivate the user, terminate their sessions, delete their app passwords, revoke their user-level
+// @Description permissions and release their document locks. Documents, folders and owned organizations go to the successor; without one,
+// @Description the user's folders become folders of their organization and their documents stay orphaned with them. The offboarding is
+// @Description recorded in the audit log and the successor is notified.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID" format(uuid)
+// @Param request body OffboardUserRequest false "Successor and reason"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Offboarding summary"
+// @Failure 400 {object} map[string]string "Invalid request or successor"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 409 {object} map[string]string "User cannot be deactivated or owns organizations without a successor"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /users/{id}/offboard [post]
+func OffboardUser(ctx *gin.Context) {
+	user, ok := findLifecycleUser(ctx)
+	if !ok {
+		return
+	}
+
+	var req OffboardUserRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		apperrors.Respond(ctx, validation.Error(err, "Invalid request"))
+		return
+	}
+
+	if user.Status != models.UserStatusDeactivated && !models.CanTransitionUserStatus(user.Status, models.UserStatusDeactivated) {
+		transitionErr := &UserTransitionError{From: user.Status, To: models.UserStatusDeactivated}
+		apperrors.Respond(ctx, apperrors.Wrap(transitionErr, apperrors.CodeConflict, "Transition not allowed").With("transitions", models.UserStatusTransitions[user.Status]))
+		return
+	}
+
+	db := requestDB(ctx)
+
+	var successor *models.User
+	if req.SuccessorID != nil {
+		if successor, ok = findSuccessor(ctx, db, user, *req.SuccessorID); !ok {
+			return
+		}
+	} else {
+		var owned int64
+		if err := db.Model(&models.Organization{}).Where("owner_id = ?", user.ID).Count(&owned).Error; err != nil {
+			apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to check owned organizations"))
+			return
+		}
+		if owned > 0 {
+			apperrors.Respond(ctx, apperrors.Conflict("Successor required").WithDetails("The user owns organizations, which need a new owner"))
+			return
+		}
+	}
+
+	result := OffboardUserResult{
+		UserID:         user.ID,
+		SuccessorID:    req.SuccessorID,
+		PreviousStatus: user.Status,
+		Reason:         req.Reason,
+		Revoked:        make(map[string]int64),
+		Reassigned:     make(map[string]int64),
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Update("status", models.UserStatusDeactivated).Error; err != nil {
+			return err
+		}
+		if err := revokeUserAccess(tx, user.ID, result.Revoked); err != nil {
+			return err
+		}
+		if err := handOverUserOwnership(tx, user, successor, result.Reassigned); err != nil {
+			return err
+		}
+
+		return tx.Create(&notification.AuditLog{
+			UserID:      utils.GetActorID(ctx),
+			Method:      "OFFBOARD",
+			Path:        ctx.Request.URL.Path,
+			StatusCode:  http.StatusOK,
+			RequestBody: result,
+			IPAddress:   ctx.ClientIP(),
+			UserAgent:   ctx.Request.UserAgent(),
+		}).Error
+	})
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to offboard user"))
+		return
+	}
+
+	revokeUserSessions(ctx, user.ID)
+
+	db.Preload("Organization").Preload("Role").First(&user, user.ID)
+	userResponse := buildUserResponse(user)
+
+	// The revoked permissions and the handed over folders change both users' permission checks
+	emitEvent(ctx, messaging.EventUserUpdated, userResponse)
+	if successor != nil {
+		emitEvent(ctx, messaging.EventUserUpdated, buildUserResponse(*successor))
+		publishUserOffboarded(ctx, user, *successor, result)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "User offboarded successfully",
+		"data": gin.H{
+			"user":     userResponse,
+			"offboard": result,
+		},
+	})
+}
+
+// findSuccessor loads the active user taking over from the offboarded one and writes the error
+// response if needed. Successors must belong to the offboarded user's organization.
+func findSuccessor(ctx *gin.Context, db *gorm.DB, user models.User, successorID uuid.UUID) (*models.User, bool) {
+	if successorID == user.ID {
+		apperrors.Respond(ctx, apperrors.BadRequest("Invalid successor").WithDetails("A user cannot be their own successor"))
+		return nil, false
+	}
+
+	var successor models.User
+	if err := db.First(&successor, successorID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			apperrors.Respond(ctx, apperrors.BadRequest("Successor not found").WithDetails("The specified successor does not exist"))
+			return nil, false
+		}
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to retrieve successor"))
+		return nil, false
+	}
+
+	if successor.Status != models.UserStatusActive {
+		apperrors.Respond(ctx, apperrors.BadRequest("Invalid successor").WithDetails("The successor must be an active user"))
+		return nil, false
+	}
+	if user.OrganizationID != nil && (successor.OrganizationID == nil || *successor.OrganizationID != *user.OrganizationID) {
+		apperrors.Respond(ctx, apperrors.BadRequest("Invalid successor").WithDetails("The successor must belong to the user's organization"))
+		return nil, false
+	}
+
+	return &successor, true
+}
+
+// revokeUserAccess ends the user's sessions, deletes their app passwords and user-level permissions
+// and releases their document locks, recording the number of revoked rows per kind in counts
+func revokeUserAccess(tx *gorm.DB, userID uuid.UUID, counts map[string]int64) error {
+	res := tx.Model(&auth.UserSession{}).Where("user_id = ? AND is_active = ?", userID, true).Update("is_active", false)
+	if res.Error != nil {
+		return fmt.Errorf("terminate sessions: %w", res.Error)
+	}
+	counts["sessions"] = res.RowsAffected
+
+	res = tx.Where("user_id = ?", userID).Delete(&auth.AppPassword{})
+	if res.Error != nil {
+		return fmt.Errorf("delete app passwords: %w", res.Error)
+	}
+	counts["app_passwords"] = res.RowsAffected
+
+	userPermissions := tx.Model(&models.Permission{}).Select("id").
+		Where("target = ? AND user_id = ?", models.PermissionTargetUser, userID)
+	if err := tx.Where("permission_id IN (?)", userPermissions).Delete(&models.PermissionAction{}).Error; err != nil {
+		return fmt.Errorf("revoke permission actions: %w", err)
+	}
+	res = tx.Where("target = ? AND user_id = ?", models.PermissionTargetUser, userID).Delete(&models.Permission{})
+	if res.Error != nil {
+		return fmt.Errorf("revoke permissions: %w", res.Error)
+	}
+	counts["permissions"] = res.RowsAffected
+
+	res = tx.Model(&document.Document{}).Where("locked_by = ?", userID).Updates(map[string]interface{}{
+		"locked_by":       nil,
+		"locked_at":       nil,
+		"lock_expires_at": nil,
+	})
+	if res.Error != nil {
+		return fmt.Errorf("release document locks: %w", res.Error)
+	}
+	counts["document_locks"] = res.RowsAffected
+
+	return nil
+}
+
+// handOverUserOwnership moves the user's documents, folders and organizations to the successor,
+// recording the number of moved rows per kind in counts. Without a successor the user's folders
+// move to their organization, if any, and their documents keep their uploader.
+func handOverUserOwnership(tx *gorm.DB, user models.User, successor *models.User, counts map[string]int64) error {
+	if successor == nil {
+		if user.OrganizationID == nil {
+			return nil
+		}
+		res := tx.Model(&document.Folder{}).Where("owner_type = ? AND owner_id = ?", "user", user.ID).Updates(map[string]interface{}{
+			"owner_type": "organization",
+			"owner_id":   *user.OrganizationID,
+		})
+		if res.Error != nil {
+			return fmt.Errorf("hand over folders: %w", res.Error)
+		}
+		counts["folders"] = res.RowsAffected
+		return nil
+	}
+
+	moves := []struct {
+		name   string
+		model  interface{}
+		column string
+		where  string
+	}{
+		{"documents", &document.Document{}, "uploaded_by", ""},
+		{"folders", &document.Folder{}, "owner_id", "owner_type = 'user'"},
+		{"organizations", &models.Organization{}, "owner_id", ""},
+	}
+	for _, move := range moves {
+		q := tx.Model(move.model).Where(move.column+" = ?", user.ID)
+		if move.where != "" {
+			q = q.Where(move.where)
+		}
+		res := q.Update(move.column, successor.ID)
+		if res.Error != nil {
+			return fmt.Errorf("hand over %s: %w", move.name, res.Error)
+		}
+		counts[move.name] = res.RowsAffected
+	}
+	return nil
+}
+
+// publishUserOffboarded tells the successor what they took over from the offboarded user
+func publishUserOffboarded(ctx *gin.Context, user, successor models.User, result OffboardUserResult) {
+	description := fmt.Sprintf("%s %s was offboarded and you took over %d documents, %d folders and %d organizations.",
+		user.FirstName, user.LastName, result.Reassigned["documents"], result.Reassigned["folders"], result.Reassigned["organizations"])
+	if result.Reason != "" {
+		description += " Reason: " + result.Reason
+	}
+
+	messaging.Publish(ctx.Request.Context(), messaging.EventUserOffboarded, utils.GetActorID(ctx), messaging.ActivityData{
+		OwnerID:      successor.ID,
+		ActionType:   "User offboarded",
+		ResourceType: "user",
+		ResourceID:   user.ID,
+		ResourceName: fmt.Sprintf("%s %s", user.FirstName, user.LastName),
+		Description:  description,
+		Priority:     "high",
+		Data: map[string]interface{}{
+			"reassigned": result.Reassigned,
+		},
+	})
+}
@@ -0,0 +1,12 @@
+package handlers
+
+import (
+	"forgecrud-backend/shared/apperrors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// respondVersionConflict writes the 409 response for an update based on an outdated version
+func respondVersionConflict(ctx *gin.Context, err error) {
+	apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeConflict, "Version conflict"))
+}
@@ -0,0 +1,547 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"forgecrud-backend/core-service/services"
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/database/models"
+	utils "forgecrud-backend/shared/utils/auth"
+	"forgecrud-backend/shared/utils/query"
+	"forgecrud-backend/shared/utils/validation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// WebhookResponse represents webhook data for API responses.
+// Secret is only returned when the webhook is created or its secret is rotated.
+type WebhookResponse struct {
+	ID          uuid.UUID `json:"id"`
+	Name        string    `json:"name"`
+	URL         string    `json:"url"`
+	Events      []string  `json:"events"`
+	IsActive    bool      `json:"is_active"`
+	Description string    `json:"description"`
+	Secret      string    `json:"secret,omitempty"`
+	CreatedAt   string    `json:"created_at"`
+	UpdatedAt   string    `json:"updated_at"`
+}
+
+// CreateWebhookRequest represents request body for registering a webhook
+type CreateWebhookRequest struct {
+	Name        string   `json:"name" binding:"required"`
+	URL         string   `json:"url" binding:"required,url"`
+	Events      []string `json:"events" binding:"required,min=1"`
+	Secret      string   `json:"secret" binding:"omitempty,min=16"`
+	IsActive    *bool    `json:"is_active"`
+	Description string   `json:"description"`
+}
+
+// UpdateWebhookRequest represents request body for updating a webhook
+type UpdateWebhookRequest struct {
+	Name        string   `json:"name"`
+	URL         string   `json:"url" binding:"omitempty,url"`
+	Events      []string `json:"events"`
+	IsActive    *bool    `json:"is_active"`
+	Description string   `json:"description"`
+}
+
+// WebhookListResponse represents a list of webhooks with pagination
+type WebhookListResponse struct {
+	Success bool `json:"success"`
+	Data    struct {
+		Items      []WebhookResponse  `json:"items"`
+		Pagination PaginationResponse `json:"pagination"`
+	} `json:"data"`
+}
+
+// SingleWebhookResponse represents a single webhook response
+type SingleWebhookResponse struct {
+	Success bool            `json:"success"`
+	Data    WebhookResponse `json:"data"`
+}
+
+// WebhookDeliveryListResponse represents the delivery log of a webhook with pagination
+type WebhookDeliveryListResponse struct {
+	Success bool `json:"success"`
+	Data    struct {
+		Items      []models.WebhookDelivery `json:"items"`
+		Pagination PaginationResponse       `json:"pagination"`
+	} `json:"data"`
+}
+
+// buildWebhookResponse converts a webhook model to its API representation
+func buildWebhookResponse(webhook models.Webhook) WebhookResponse {
+	return WebhookResponse{
+		ID:          webhook.ID,
+		Name:        webhook.Name,
+		URL:         webhook.URL,
+		Events:      splitOptions(webhook.Events),
+		IsActive:    webhook.IsActive,
+		Description: webhook.Description,
+		CreatedAt:   webhook.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:   webhook.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
+
+// validateWebhookEvents checks the event filter against the supported events and returns it comma separated
+func validateWebhookEvents(events []string) (string, error) {
+	supported := map[string]bool{models.WebhookEventAll: true}
+	for _, event := range models.SupportedWebhookEvents {
+		supported[event] = true
+	}
+
+	for _, event := range events {
+		if !supported[event] {
+			return "", fmt.Errorf("unsupported event %q, supported events: %s or %s",
+				event, strings.Join(models.SupportedWebhookEvents, ", "), models.WebhookEventAll)
+		}
+	}
+	return strings.Join(events, ","), nil
+}
+
+// validateWebhookURL only allows absolute http(s) endpoints
+func validateWebhookURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return fmt.Errorf("url must be an absolute http or https URL")
+	}
+	return nil
+}
+
+// findWebhook loads a webhook by the :id path parameter and writes the error response on failure
+func findWebhook(ctx *gin.Context, db *gorm.DB) (*models.Webhook, bool) {
+	webhookUUID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid webhook ID format"))
+		return nil, false
+	}
+
+	var webhook models.Webhook
+	if err := db.First(&webhook, webhookUUID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			apperrors.Respond(ctx, apperrors.NotFound("Webhook not found").WithDetails("Webhook with the given ID does not exist"))
+			return nil, false
+		}
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to retrieve webhook"))
+		return nil, false
+	}
+
+	return &webhook, true
+}
+
+// GetWebhooks retrieves all registered webhooks
+// @Summary Get all webhooks
+// @Description Get registered webhook endpoints with pagination, filtering and search
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param page query int false "Page number (default: 1)"
+// @Param limit query int false "Items per page (default: 10)"
+// @Param search query string false "Search term across name and url"
+// @Param filters[is_active] query string false "Filter by active status"
+// @Param sort[field] query string false "Sort field (name, created_at, updated_at)"
+// @Param sort[order] query string false "Sort order (asc, desc)"
+// @Security BearerAuth
+// @Success 200 {object} handlers.WebhookListResponse
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /webhooks [get]
+func GetWebhooks(ctx *gin.Context) {
+	db := requestDB(ctx)
+
+	params := query.ParseQueryParams(ctx)
+
+	allowedFilters := map[string]string{
+		"is_active": "is_active",
+	}
+	allowedSortFields := map[string]string{
+		"name":       "name",
+		"created_at": "created_at",
+		"updated_at": "updated_at",
+	}
+	searchFields := []string{"name", "url"}
+
+	baseQuery := db.Model(&models.Webhook{})
+	filteredQuery := query.ApplyFilters(baseQuery, params.Filters, allowedFilters)
+	searchedQuery := query.ApplySearch(filteredQuery, params.Search, searchFields)
+
+	var total int64
+	searchedQuery.Count(&total)
+
+	finalQuery := query.ApplySort(searchedQuery, params.Sort, allowedSortFields)
+	finalQuery = query.ApplyPagination(finalQuery, params.Page, params.Limit)
+
+	var webhooks []models.Webhook
+	if err := finalQuery.Find(&webhooks).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to retrieve webhooks"))
+		return
+	}
+
+	webhookResponses := make([]WebhookResponse, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		webhookResponses = append(webhookResponses, buildWebhookResponse(webhook))
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"webhooks":   webhookResponses,
+			"pagination": query.BuildPaginationResponse(params.Page, params.Limit, total),
+		},
+	})
+}
+
+// GetWebhook retrieves a single webhook by ID
+// @Summary Get webhook by ID
+// @Description Get a registered webhook endpoint
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param id path string true "Webhook ID" format(uuid)
+// @Security BearerAuth
+// @Success 200 {object} handlers.SingleWebhookResponse
+// @Failure 400 {object} map[string]string "Invalid webhook ID format"
+// @Failure 404 {object} map[string]string "Webhook not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /webhooks/{id} [get]
+func GetWebhook(ctx *gin.Context) {
+	webhook, ok := findWebhook(ctx, requestDB(ctx))
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    buildWebhookResponse(*webhook),
+	})
+}
+
+// CreateWebhook registers a new webhook endpoint
+// @Summary Register a webhook
+// @Description Register an endpoint notified about entity lifecycle events. Requests are signed with
+// @Description HMAC-SHA256 over "<timestamp>.<body>" in the X-Webhook-Signature header. A secret is generated
+// @Description when none is provided and is only returned in this response.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param webhook body CreateWebhookRequest true "Webhook information"
+// @Security BearerAuth
+// @Success 201 {object} handlers.SingleWebhookResponse "Registered webhook"
+// @Failure 400 {object} map[string]string "Invalid request data"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /webhooks [post]
+func CreateWebhook(ctx *gin.Context) {
+	var req CreateWebhookRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apperrors.Respond(ctx, validation.Error(err, "Invalid request body"))
+		return
+	}
+
+	if err := validateWebhookURL(req.URL); err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid webhook URL"))
+		return
+	}
+
+	events, err := validateWebhookEvents(req.Events)
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid webhook events"))
+		return
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		if secret, err = utils.GenerateRandomToken(32); err != nil {
+			apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to generate webhook secret"))
+			return
+		}
+	}
+
+	webhook := models.Webhook{
+		Name:        req.Name,
+		URL:         req.URL,
+		Secret:      secret,
+		Events:      events,
+		IsActive:    req.IsActive == nil || *req.IsActive,
+		Description: req.Description,
+	}
+
+	db := requestDB(ctx)
+	if err := db.Create(&webhook).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to create webhook"))
+		return
+	}
+
+	// GORM skips false for fields with a default tag on create
+	if !webhook.IsActive {
+		db.Model(&webhook).Update("is_active", false)
+	}
+
+	response := buildWebhookResponse(webhook)
+	response.Secret = secret
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Webhook created successfully",
+		"data":    response,
+	})
+}
+
+// UpdateWebhook updates an existing webhook
+// @Summary Update a webhook
+// @Description Update a webhook's name, URL, event filter or active status
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param id path string true "Webhook ID" format(uuid)
+// @Param webhook body UpdateWebhookRequest true "Updated webhook information"
+// @Security BearerAuth
+// @Success 200 {object} handlers.SingleWebhookResponse "Updated webhook"
+// @Failure 400 {object} map[string]string "Invalid request data or ID format"
+// @Failure 404 {object} map[string]string "Webhook not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /webhooks/{id} [put]
+func UpdateWebhook(ctx *gin.Context) {
+	db := requestDB(ctx)
+
+	webhook, ok := findWebhook(ctx, db)
+	if !ok {
+		return
+	}
+
+	var req UpdateWebhookRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apperrors.Respond(ctx, validation.Error(err, "Invalid request body"))
+		return
+	}
+
+	updates := make(map[string]interface{})
+	if req.Name != "" {
+		updates["name"] = req.Name
+	}
+	if req.URL != "" {
+		if err := validateWebhookURL(req.URL); err != nil {
+			apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid webhook URL"))
+			return
+		}
+		updates["url"] = req.URL
+	}
+	if len(req.Events) > 0 {
+		events, err := validateWebhookEvents(req.Events)
+		if err != nil {
+			apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid webhook events"))
+			return
+		}
+		updates["events"] = events
+	}
+	if req.IsActive != nil {
+		updates["is_active"] = *req.IsActive
+	}
+	if req.Description != "" {
+		updates["description"] = req.Description
+	}
+
+	if err := db.Model(webhook).Updates(updates).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to update webhook"))
+		return
+	}
+
+	db.First(webhook, webhook.ID)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Webhook updated successfully",
+		"data":    buildWebhookResponse(*webhook),
+	})
+}
+
+// RotateWebhookSecret replaces the signing secret of a webhook
+// @Summary Rotate webhook secret
+// @Description Generate a new signing secret. The new secret is only returned in this response.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param id path string true "Webhook ID" format(uuid)
+// @Security BearerAuth
+// @Success 200 {object} handlers.SingleWebhookResponse "Webhook with its new secret"
+// @Failure 400 {object} map[string]string "Invalid webhook ID format"
+// @Failure 404 {object} map[string]string "Webhook not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /webhooks/{id}/rotate-secret [post]
+func RotateWebhookSecret(ctx *gin.Context) {
+	db := requestDB(ctx)
+
+	webhook, ok := findWebhook(ctx, db)
+	if !ok {
+		return
+	}
+
+	secret, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to generate webhook secret"))
+		return
+	}
+
+	if err := db.Model(webhook).Update("secret", secret).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to rotate webhook secret"))
+		return
+	}
+
+	response := buildWebhookResponse(*webhook)
+	response.Secret = secret
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Webhook secret rotated successfully",
+		"data":    response,
+	})
+}
+
+// DeleteWebhook deletes a webhook and its delivery log
+// @Summary Delete a webhook
+// @Description Delete a webhook endpoint together with its delivery log
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param id path string true "Webhook ID" format(uuid)
+// @Security BearerAuth
+// @Success 200 {object} handlers.SuccessResponse "Webhook deleted successfully"
+// @Failure 400 {object} map[string]string "Invalid webhook ID format"
+// @Failure 404 {object} map[string]string "Webhook not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /webhooks/{id} [delete]
+func DeleteWebhook(ctx *gin.Context) {
+	db := requestDB(ctx)
+
+	webhook, ok := findWebhook(ctx, db)
+	if !ok {
+		return
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("webhook_id = ?", webhook.ID).Delete(&models.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(webhook).Error
+	})
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to delete webhook"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Webhook deleted successfully",
+	})
+}
+
+// GetWebhookDeliveries retrieves the delivery log of a webhook
+// @Summary Get webhook deliveries
+// @Description Get the delivery log of a webhook (payload, attempts, last response), newest first
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param id path string true "Webhook ID" format(uuid)
+// @Param page query int false "Page number (default: 1)"
+// @Param limit query int false "Items per page (default: 10)"
+// @Param filters[status] query string false "Filter by status (pending, succeeded, failed)"
+// @Param filters[event] query string false "Filter by event"
+// @Security BearerAuth
+// @Success 200 {object} handlers.WebhookDeliveryListResponse
+// @Failure 400 {object} map[string]string "Invalid webhook ID format"
+// @Failure 404 {object} map[string]string "Webhook not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /webhooks/{id}/deliveries [get]
+func GetWebhookDeliveries(ctx *gin.Context) {
+	db := requestDB(ctx)
+
+	webhook, ok := findWebhook(ctx, db)
+	if !ok {
+		return
+	}
+
+	params := query.ParseQueryParams(ctx)
+
+	allowedFilters := map[string]string{
+		"status": "status",
+		"event":  "event",
+	}
+
+	baseQuery := db.Model(&models.WebhookDelivery{}).Where("webhook_id = ?", webhook.ID)
+	filteredQuery := query.ApplyFilters(baseQuery, params.Filters, allowedFilters)
+
+	var total int64
+	filteredQuery.Count(&total)
+
+	var deliveries []models.WebhookDelivery
+	if err := query.ApplyPagination(filteredQuery.Order("created_at DESC"), params.Page, params.Limit).
+		Find(&deliveries).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to retrieve webhook deliveries"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"deliveries": deliveries,
+			"pagination": query.BuildPaginationResponse(params.Page, params.Limit, total),
+		},
+	})
+}
+
+// RedeliverWebhookDelivery sends a logged delivery again
+// @Summary Redeliver a webhook delivery
+// @Description Immediately send a logged delivery again with the original payload
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param id path string true "Webhook ID" format(uuid)
+// @Param delivery_id path string true "Delivery ID" format(uuid)
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Delivery with the outcome of the new attempt"
+// @Failure 400 {object} map[string]string "Invalid ID format"
+// @Failure 404 {object} map[string]string "Webhook or delivery not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /webhooks/{id}/deliveries/{delivery_id}/redeliver [post]
+func RedeliverWebhookDelivery(ctx *gin.Context) {
+	db := requestDB(ctx)
+
+	webhook, ok := findWebhook(ctx, db)
+	if !ok {
+		return
+	}
+
+	deliveryUUID, err := uuid.Parse(ctx.Param("delivery_id"))
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid delivery ID format"))
+		return
+	}
+
+	var delivery models.WebhookDelivery
+	if err := db.Where("id = ? AND webhook_id = ?", deliveryUUID, webhook.ID).First(&delivery).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			apperrors.Respond(ctx, apperrors.NotFound("Delivery not found").WithDetails("Delivery with the given ID does not exist for this webhook"))
+			return
+		}
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to retrieve delivery"))
+		return
+	}
+	delivery.Webhook = *webhook
+
+	if err := services.NewWebhookDispatcher().Redeliver(&delivery); err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to record delivery attempt"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Delivery attempted",
+		"data":    delivery,
+	})
+}
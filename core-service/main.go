@@ -4,16 +4,35 @@ import (
 	"log"
 	"net/http"
 	"strings"
+	"time"
 
+	_ "forgecrud-backend/core-service/docs"
 	"forgecrud-backend/core-service/handlers"
+	"forgecrud-backend/core-service/services"
+	"forgecrud-backend/shared/clientip"
 	"forgecrud-backend/shared/config"
 	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/health"
+	"forgecrud-backend/shared/messaging"
+	"forgecrud-backend/shared/readonly"
+	"forgecrud-backend/shared/revocation"
+	"forgecrud-backend/shared/scheduler"
+	"forgecrud-backend/shared/server"
+	"forgecrud-backend/shared/tenancy"
 
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
+// @title Core Service API
+// @version 1.0
+// @description Users, roles, organizations, teams, webhooks and registration rules
+// @BasePath /api
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+
 func main() {
 	// Load configuration
 	config.LoadConfig()
@@ -22,9 +41,55 @@ func main() {
 	if err := database.InitDatabase(); err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
-	defer database.CloseDatabase()
+	server.OnShutdown("database", database.CloseDatabase)
+	health.AddCheck("database", health.CheckDatabase)
+
+	// Publish domain events for other services
+	if err := messaging.Init("core-service"); err != nil {
+		log.Printf("⚠️  Warning: Event bus not available: %v", err)
+	} else {
+		server.OnShutdown("event bus", messaging.Close)
+		health.AddOptionalCheck("event bus", health.Ping(messaging.Ping))
+	}
+
+	// Announce the sessions terminated with suspended, deactivated and deleted users
+	if err := revocation.Init(); err != nil {
+		log.Printf("⚠️  Warning: Session revocations not available: %v", err)
+	} else {
+		server.OnShutdown("session revocations", revocation.Close)
+		health.AddOptionalCheck("session revocations", health.Ping(revocation.Ping))
+	}
 
-	router := gin.Default()
+	// Pause the background writers while the service is read-only
+	if err := readonly.Init("core-service"); err != nil {
+		log.Printf("⚠️  Warning: Read-only switch not available: %v", err)
+	} else {
+		server.OnShutdown("read-only switch", readonly.Close)
+		health.AddOptionalCheck("read-only switch", health.Ping(readonly.Ping))
+	}
+
+	// Deliver queued webhook events
+	services.NewWebhookDispatcher().Start(5 * time.Second)
+
+	// Apply scheduled role changes once they are due
+	services.NewRoleChangeScheduler().Start(time.Minute)
+
+	// Purge soft-deleted records and audit logs past their retention periods on one instance
+	if err := scheduler.Init("core-service"); err != nil {
+		log.Printf("⚠️  Warning: Scheduler lock not available, every instance runs the schedule: %v", err)
+	}
+	services.RegisterScheduledJobs()
+	scheduler.Start()
+	server.OnShutdown("scheduler", scheduler.Close)
+
+	router := gin.New()
+	router.Use(server.Logger(), gin.Recovery())
+
+	// Client IPs forwarded by the gateway
+	clientip.ConfigureService(router)
+
+	// Scope queries to the caller's organization forwarded by the gateway
+	router.Use(tenancy.Middleware())
 
 	// User routes
 	router.GET("/api/users", handlers.GetUsers)
@@ -32,15 +97,35 @@ func main() {
 	router.POST("/api/users", handlers.CreateUser)
 	router.PUT("/api/users/:id", handlers.UpdateUser)
 	router.DELETE("/api/users/:id", handlers.DeleteUser)
+	router.POST("/api/users/:id/restore", handlers.RestoreUser)
+	router.POST("/api/users/:id/merge", handlers.MergeUsers)
+	router.POST("/api/users/:id/offboard", handlers.OffboardUser)
+	router.POST("/api/users/:id/activate", handlers.ActivateUser)
+	router.POST("/api/users/:id/suspend", handlers.SuspendUser)
+	router.POST("/api/users/:id/deactivate", handlers.DeactivateUser)
+	router.GET("/api/users/:id/transitions", handlers.GetUserTransitions)
+	router.GET("/api/users/:id/role-history", handlers.GetUserRoleHistory)
+	router.GET("/api/users/:id/role-changes", handlers.GetScheduledRoleChanges)
+	router.POST("/api/users/:id/role-changes", handlers.ScheduleRoleChange)
+	router.DELETE("/api/users/:id/role-changes/:change_id", handlers.CancelScheduledRoleChange)
+	router.GET("/api/users/:id/history", handlers.GetUserHistory)
+	router.PUT("/api/users/:id/quota", handlers.UpdateUserQuota)
 	router.GET("/api/users/:id/permissions", handlers.GetUserPermissions)
 
 	// Role routes
 	router.GET("/api/roles", handlers.GetRoles)
+	router.GET("/api/roles/catalog", handlers.GetRoleCatalog)
 	router.GET("/api/roles/:id", handlers.GetRole)
 	router.POST("/api/roles", handlers.CreateRole)
 	router.PUT("/api/roles/:id", handlers.UpdateRole)
 	router.DELETE("/api/roles/:id", handlers.DeleteRole)
+	router.POST("/api/roles/:id/restore", handlers.RestoreRole)
+	router.GET("/api/roles/:id/history", handlers.GetRoleHistory)
 	router.GET("/api/roles/:id/permissions", handlers.GetRolePermissions)
+	router.POST("/api/roles/:id/clone", handlers.CloneRole)
+	router.GET("/api/roles/:id/users", handlers.GetRoleUsers)
+	router.POST("/api/roles/:id/users", handlers.AssignRoleToUsers)
+	router.DELETE("/api/roles/:id/users", handlers.RemoveRoleFromUsers)
 
 	// Organization routes
 	router.GET("/api/organizations", handlers.GetOrganizations)
@@ -48,7 +133,55 @@ func main() {
 	router.POST("/api/organizations", handlers.CreateOrganization)
 	router.PUT("/api/organizations/:id", handlers.UpdateOrganization)
 	router.DELETE("/api/organizations/:id", handlers.DeleteOrganization)
+	router.POST("/api/organizations/:id/restore", handlers.RestoreOrganization)
+	router.GET("/api/organizations/:id/history", handlers.GetOrganizationHistory)
 	router.GET("/api/organizations/:id/permissions", handlers.GetOrganizationPermissions)
+	router.POST("/api/organizations/:id/roles/catalog", handlers.StampOrganizationRoles)
+	router.GET("/api/organizations/:id/usage", handlers.GetOrganizationUsage)
+	router.GET("/api/organizations/:id/usage/api", handlers.GetOrganizationAPIUsage)
+	router.PUT("/api/organizations/:id/quota", handlers.UpdateOrganizationQuota)
+	router.GET("/api/organizations/:id/file-policy", handlers.GetFileTypePolicy)
+	router.PUT("/api/organizations/:id/file-policy", handlers.UpdateFileTypePolicy)
+	router.DELETE("/api/organizations/:id/file-policy", handlers.DeleteFileTypePolicy)
+	router.GET("/api/organizations/:id/user-fields", handlers.GetUserFields)
+	router.POST("/api/organizations/:id/user-fields", handlers.CreateUserField)
+	router.PUT("/api/organizations/:id/user-fields/:field_id", handlers.UpdateUserField)
+	router.DELETE("/api/organizations/:id/user-fields/:field_id", handlers.DeleteUserField)
+	router.GET("/api/organizations/:id/domains", handlers.GetOrganizationDomains)
+	router.POST("/api/organizations/:id/domains", handlers.CreateOrganizationDomain)
+	router.PUT("/api/organizations/:id/domains/:domain_id", handlers.UpdateOrganizationDomain)
+	router.DELETE("/api/organizations/:id/domains/:domain_id", handlers.DeleteOrganizationDomain)
+	router.POST("/api/organizations/:id/domains/:domain_id/verify", handlers.VerifyOrganizationDomain)
+	router.GET("/api/organizations/:id/join-requests", handlers.GetJoinRequests)
+	router.POST("/api/organizations/:id/join-requests/:request_id/approve", handlers.ApproveJoinRequest)
+	router.POST("/api/organizations/:id/join-requests/:request_id/reject", handlers.RejectJoinRequest)
+
+	// Team routes
+	router.GET("/api/teams", handlers.GetTeams)
+	router.GET("/api/teams/:id", handlers.GetTeam)
+	router.POST("/api/teams", handlers.CreateTeam)
+	router.PUT("/api/teams/:id", handlers.UpdateTeam)
+	router.DELETE("/api/teams/:id", handlers.DeleteTeam)
+	router.GET("/api/teams/:id/members", handlers.GetTeamMembers)
+	router.POST("/api/teams/:id/members", handlers.AddTeamMembers)
+	router.DELETE("/api/teams/:id/members/:user_id", handlers.RemoveTeamMember)
+	router.GET("/api/teams/:id/permissions", handlers.GetTeamPermissions)
+
+	// Webhook routes
+	router.GET("/api/webhooks", handlers.GetWebhooks)
+	router.GET("/api/webhooks/:id", handlers.GetWebhook)
+	router.POST("/api/webhooks", handlers.CreateWebhook)
+	router.PUT("/api/webhooks/:id", handlers.UpdateWebhook)
+	router.DELETE("/api/webhooks/:id", handlers.DeleteWebhook)
+	router.POST("/api/webhooks/:id/rotate-secret", handlers.RotateWebhookSecret)
+	router.GET("/api/webhooks/:id/deliveries", handlers.GetWebhookDeliveries)
+	router.POST("/api/webhooks/:id/deliveries/:delivery_id/redeliver", handlers.RedeliverWebhookDelivery)
+
+	// Registration rule routes
+	router.GET("/api/registration-rules", handlers.GetRegistrationRules)
+	router.POST("/api/registration-rules", handlers.CreateRegistrationRule)
+	router.PUT("/api/registration-rules/:id", handlers.UpdateRegistrationRule)
+	router.DELETE("/api/registration-rules/:id", handlers.DeleteRegistrationRule)
 
 	// Test endpoint
 	router.GET("/api/core/test", func(c *gin.Context) {
@@ -60,19 +193,14 @@ func main() {
 		})
 	})
 
-	// Health check
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{
-			"status":  "healthy",
-			"service": "core",
-		})
-	})
+	// Liveness and readiness probes, and metrics
+	health.Register(router, "core")
 
-	// Swagger documentation
+	// Swagger documentation, merged by the gateway into its OpenAPI document
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	// Parse port from config URL
 	port := strings.Split(config.GetConfig().CoreServiceURL, ":")[2]
 	log.Printf("Core Service starting on port %s...", port)
-	router.Run(":" + port)
+	server.Run(router, ":"+port)
 }
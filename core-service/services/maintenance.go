@@ -0,0 +1,50 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"forgecrud-backend/shared/config"
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/scheduler"
+)
+
+// Scheduled maintenance jobs of core-service
+const (
+	JobPurgeSoftDeleted  = "core.soft_delete_purge"
+	JobAuditLogRetention = "core.audit_log_retention"
+)
+
+// RegisterScheduledJobs schedules the purge of soft-deleted records and of audit logs past their
+// retention periods. With audit log archival enabled, logs are only purged once document-service
+// archived their day.
+func RegisterScheduledJobs() {
+	cfg := config.GetConfig()
+
+	if days := cfg.SoftDeleteRetentionDays; days > 0 {
+		retention := time.Duration(days) * 24 * time.Hour
+		scheduler.Register(JobPurgeSoftDeleted, "0 2 * * *", func(ctx context.Context) error {
+			purged, err := database.PurgeSoftDeleted(time.Now().Add(-retention))
+			if err != nil {
+				return err
+			}
+			if purged["users"]+purged["roles"]+purged["organizations"] > 0 {
+				log.Printf("🧹 Purged soft-deleted records: %d users, %d roles, %d organizations",
+					purged["users"], purged["roles"], purged["organizations"])
+			}
+			return nil
+		})
+	}
+
+	if days := cfg.AuditLogRetentionDays; days > 0 {
+		retention := time.Duration(days) * 24 * time.Hour
+		scheduler.Register(JobAuditLogRetention, "30 2 * * *", func(ctx context.Context) error {
+			purged, err := database.PurgeAuditLogs(ctx, time.Now().Add(-retention), cfg.AuditLogArchiveEnabled)
+			if purged > 0 {
+				log.Printf("🧹 Purged %d audit logs older than %d days", purged, days)
+			}
+			return err
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models"
+	"forgecrud-backend/shared/messaging"
+	"forgecrud-backend/shared/readonly"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const roleChangeBatchSize = 50
+
+// RoleChangeScheduler applies scheduled role changes once they are due
+type RoleChangeScheduler struct{}
+
+func NewRoleChangeScheduler() *RoleChangeScheduler {
+	return &RoleChangeScheduler{}
+}
+
+// Start polls for due role changes in the background
+func (s *RoleChangeScheduler) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if readonly.Active() {
+				continue
+			}
+			if err := s.ExecuteDue(); err != nil {
+				log.Printf("⚠️  Scheduled role changes failed: %v", err)
+			}
+		}
+	}()
+
+	log.Printf("⏰ Role change scheduler started (interval: %s)", interval)
+}
+
+// executedRoleChange is a role change applied in the current run, announced after the transaction commits
+type executedRoleChange struct {
+	user       models.User
+	assignment *models.RoleAssignment
+}
+
+// ExecuteDue applies a batch of pending role changes whose effective date has passed.
+// Rows are locked with SKIP LOCKED so several core-service instances can run the scheduler.
+func (s *RoleChangeScheduler) ExecuteDue() error {
+	var executed []executedRoleChange
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var changes []models.ScheduledRoleChange
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND effective_at <= ?", models.ScheduledRoleChangePending, time.Now().UTC()).
+			Order("effective_at").
+			Limit(roleChangeBatchSize).
+			Find(&changes).Error; err != nil {
+			return err
+		}
+
+		for i := range changes {
+			change := &changes[i]
+
+			// Each change runs in a savepoint so one failing change does not roll back the others
+			var result executedRoleChange
+			applyErr := tx.Transaction(func(tx *gorm.DB) error {
+				var err error
+				result, err = applyRoleChange(tx, change)
+				return err
+			})
+
+			now := time.Now().UTC()
+			updates := map[string]interface{}{"executed_at": now}
+			if applyErr != nil {
+				updates["status"] = models.ScheduledRoleChangeFailed
+				updates["last_error"] = applyErr.Error()
+				log.Printf("⚠️  Scheduled role change %s for user %s failed: %v", change.ID, change.UserID, applyErr)
+			} else {
+				updates["status"] = models.ScheduledRoleChangeExecuted
+				updates["last_error"] = ""
+				executed = append(executed, result)
+			}
+
+			if err := tx.Model(change).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, change := range executed {
+		AnnounceRoleChange(change.user, change.assignment)
+	}
+	return nil
+}
+
+// applyRoleChange sets the user's role and records it in the role assignment history
+func applyRoleChange(tx *gorm.DB, change *models.ScheduledRoleChange) (executedRoleChange, error) {
+	var user models.User
+	if err := tx.First(&user, change.UserID).Error; err != nil {
+		return executedRoleChange{}, fmt.Errorf("user not found: %w", err)
+	}
+
+	if change.RoleID != nil {
+		var role models.Role
+		if err := tx.First(&role, *change.RoleID).Error; err != nil {
+			return executedRoleChange{}, fmt.Errorf("role not found: %w", err)
+		}
+	}
+
+	previousRoleID := user.RoleID
+	if err := tx.Model(&user).Update("role_id", change.RoleID).Error; err != nil {
+		return executedRoleChange{}, err
+	}
+	user.RoleID = change.RoleID
+
+	assignment, err := database.RecordRoleAssignment(tx, database.RoleAssignmentEntry{
+		UserID:            user.ID,
+		PreviousRoleID:    previousRoleID,
+		RoleID:            change.RoleID,
+		Source:            models.RoleAssignmentSourceSchedule,
+		ActorID:           change.CreatedBy,
+		ScheduledChangeID: &change.ID,
+		Reason:            change.Reason,
+	})
+	if err != nil {
+		return executedRoleChange{}, err
+	}
+
+	return executedRoleChange{user: user, assignment: assignment}, nil
+}
+
+// AnnounceRoleChange publishes the changed user for webhooks and permission cache invalidation and
+// notifies the user about their new role. Nothing is announced when the role was already set.
+func AnnounceRoleChange(user models.User, assignment *models.RoleAssignment) {
+	if assignment == nil {
+		return
+	}
+
+	data := map[string]interface{}{
+		"id":              user.ID,
+		"email":           user.Email,
+		"status":          user.Status,
+		"organization_id": user.OrganizationID,
+		"role_id":         user.RoleID,
+	}
+	database.EnqueueWebhookEvent(messaging.EventUserUpdated, data)
+	messaging.Publish(context.Background(), messaging.EventUserUpdated, assignment.ActorID, data)
+
+	messaging.Publish(context.Background(), messaging.EventUserRoleChanged, assignment.ActorID,
+		RoleChangedActivity(database.DB, user, assignment))
+}
+
+// RoleChangedActivity builds the notification payload telling a user that their role changed
+func RoleChangedActivity(db *gorm.DB, user models.User, assignment *models.RoleAssignment) messaging.ActivityData {
+	previous := roleName(db, assignment.PreviousRoleID)
+	current := roleName(db, assignment.RoleID)
+
+	description := fmt.Sprintf("Your role was changed from %s to %s.", previous, current)
+	switch assignment.Action {
+	case models.RoleAssignmentAssigned:
+		description = fmt.Sprintf("You were assigned the %s role.", current)
+	case models.RoleAssignmentRemoved:
+		description = fmt.Sprintf("Your %s role was removed.", previous)
+	}
+	if assignment.Reason != "" {
+		description += " Reason: " + assignment.Reason
+	}
+
+	return messaging.ActivityData{
+		OwnerID:      user.ID,
+		ActionType:   "Role changed",
+		ResourceType: "user",
+		ResourceID:   user.ID,
+		ResourceName: fmt.Sprintf("%s %s", user.FirstName, user.LastName),
+		Description:  description,
+		Priority:     "high",
+		Changes: []messaging.ActivityChange{
+			{Field: "role", OldValue: previous, NewValue: current},
+		},
+	}
+}
+
+// roleName returns the name of a role, including deleted ones, or "no role"
+func roleName(db *gorm.DB, roleID *uuid.UUID) string {
+	if roleID == nil {
+		return "no role"
+	}
+
+	var role models.Role
+	if err := db.Unscoped().Select("name").First(&role, *roleID).Error; err != nil {
+		return roleID.String()
+	}
+	return role.Name
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"forgecrud-backend/shared/config"
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models"
+	"forgecrud-backend/shared/readonly"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	webhookBatchSize       = 20
+	webhookBaseBackoff     = 30 * time.Second
+	webhookMaxResponseBody = 4096
+)
+
+// WebhookDispatcher sends queued webhook deliveries and retries failed ones with exponential backoff
+type WebhookDispatcher struct {
+	httpClient  *http.Client
+	maxAttempts int
+}
+
+func NewWebhookDispatcher() *WebhookDispatcher {
+	cfg := config.GetConfig()
+	return &WebhookDispatcher{
+		httpClient: &http.Client{
+			Timeout: time.Duration(cfg.WebhookTimeoutSeconds) * time.Second,
+		},
+		maxAttempts: cfg.WebhookMaxAttempts,
+	}
+}
+
+// Start polls for due deliveries in the background
+func (d *WebhookDispatcher) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if readonly.Active() {
+				continue
+			}
+			if err := d.DispatchDue(); err != nil {
+				log.Printf("⚠️  Webhook dispatch failed: %v", err)
+			}
+		}
+	}()
+
+	log.Printf("🪝 Webhook dispatcher started (interval: %s)", interval)
+}
+
+// DispatchDue sends a batch of pending deliveries whose next attempt is due.
+// Rows are locked with SKIP LOCKED so several core-service instances can dispatch concurrently.
+func (d *WebhookDispatcher) DispatchDue() error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		var deliveries []models.WebhookDelivery
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Preload("Webhook").
+			Where("status = ? AND next_attempt_at <= ?", models.WebhookDeliveryPending, time.Now().UTC()).
+			Order("next_attempt_at").
+			Limit(webhookBatchSize).
+			Find(&deliveries).Error; err != nil {
+			return err
+		}
+
+		for i := range deliveries {
+			if err := d.deliver(tx, &deliveries[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Redeliver immediately sends a delivery again, regardless of its current status
+func (d *WebhookDispatcher) Redeliver(delivery *models.WebhookDelivery) error {
+	delivery.Status = models.WebhookDeliveryPending
+	return d.deliver(database.DB, delivery)
+}
+
+// deliver performs one attempt and records its outcome
+func (d *WebhookDispatcher) deliver(db *gorm.DB, delivery *models.WebhookDelivery) error {
+	now := time.Now().UTC()
+	delivery.Attempts++
+
+	statusCode, body, err := d.send(delivery)
+	delivery.ResponseStatus = statusCode
+	delivery.ResponseBody = body
+
+	switch {
+	case err == nil && statusCode >= 200 && statusCode < 300:
+		delivery.Status = models.WebhookDeliverySucceeded
+		delivery.DeliveredAt = &now
+		delivery.LastError = ""
+	default:
+		if err != nil {
+			delivery.LastError = err.Error()
+		} else {
+			delivery.LastError = fmt.Sprintf("endpoint responded with status %d", statusCode)
+		}
+
+		if delivery.Attempts >= d.maxAttempts {
+			delivery.Status = models.WebhookDeliveryFailed
+		} else {
+			// 30s, 1m, 2m, 4m, ...
+			delivery.NextAttemptAt = now.Add(webhookBaseBackoff << (delivery.Attempts - 1))
+		}
+	}
+
+	return db.Model(delivery).Select(
+		"status", "attempts", "next_attempt_at", "response_status", "response_body", "last_error", "delivered_at",
+	).Updates(delivery).Error
+}
+
+// send posts the signed payload to the webhook endpoint
+func (d *WebhookDispatcher) send(delivery *models.WebhookDelivery) (int, string, error) {
+	if !delivery.Webhook.IsActive {
+		return 0, "", fmt.Errorf("webhook is disabled")
+	}
+
+	payload := []byte(delivery.Payload)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequest(http.MethodPost, delivery.Webhook.URL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "ForgeCRUD-Webhooks/1.0")
+	req.Header.Set(database.WebhookEventHeader, delivery.Event)
+	req.Header.Set(database.WebhookDeliveryHeader, delivery.ID.String())
+	req.Header.Set(database.WebhookTimestampHeader, timestamp)
+	req.Header.Set(database.WebhookSignatureHeader, database.SignWebhookPayload(delivery.Webhook.Secret, timestamp, payload))
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, webhookMaxResponseBody))
+	return resp.StatusCode, string(body), nil
+}
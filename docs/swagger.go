@@ -34,6 +34,12 @@ package docs
 // @tag.description Role management
 // @tag.name organizations
 // @tag.description Organization management
+// @tag.name teams
+// @tag.description Team management
+// @tag.name webhooks
+// @tag.description Outbound webhook management
+// @tag.name registration-rules
+// @tag.description Organization and role assignment of self-registered users
 
 // Permission Service Endpoints
 // @tag.name permissions
@@ -42,3 +48,21 @@ package docs
 // @tag.description Resource management
 // @tag.name actions
 // @tag.description Action management
+// @tag.name reports
+// @tag.description Security review reports
+
+// Document and Notification Service Endpoints
+// @tag.name documents
+// @tag.description Document management
+// @tag.name folders
+// @tag.description Folder management
+// @tag.name notifications
+// @tag.description Notifications, preferences and devices
+// @tag.name email
+// @tag.description Email delivery and templates
+
+// API Gateway Endpoints
+// @tag.name audit-logs
+// @tag.description Audit trail of every request
+// @tag.name docs
+// @tag.description Merged OpenAPI document of the services
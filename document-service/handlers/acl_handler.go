@@ -0,0 +1,436 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models"
+	"forgecrud-backend/shared/database/models/document"
+	"forgecrud-backend/shared/tenancy"
+	utils "forgecrud-backend/shared/utils/auth"
+	"forgecrud-backend/shared/utils/validation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AccessGrantRequest represents request body for granting access to a folder or document
+type AccessGrantRequest struct {
+	PrincipalType string `json:"principal_type" binding:"required,oneof=user role team"`
+	PrincipalID   string `json:"principal_id" binding:"required,uuid"`
+	Level         string `json:"level" binding:"required,oneof=read write manage"`
+	UserID        string `json:"user_id"` // for testing purposes, the gateway forwards the caller
+}
+
+// AccessGrantResponse is a grant on the object or, when inherited, on one of its parent folders
+type AccessGrantResponse struct {
+	document.AccessGrant
+	Inherited     bool   `json:"inherited"`
+	InheritedFrom string `json:"inherited_from,omitempty"` // Path of the folder holding an inherited grant
+}
+
+// GetFolderAccess lists the access grants of a folder
+// @Summary List folder access grants
+// @Description List the grants on a folder and the ones it inherits from its parent folders, with the caller's own access level. Folders without grants are open to everyone with the file-management permission.
+// @Tags folders
+// @Accept json
+// @Produce json
+// @Param id path string true "Folder ID" format(uuid)
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Access grants"
+// @Failure 403 {object} map[string]string "Access denied"
+// @Failure 404 {object} map[string]string "Folder not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /folders/{id}/acl [get]
+func GetFolderAccess(ctx *gin.Context) {
+	folder, ok := findAccessFolder(ctx)
+	if !ok || !checkFolderAccess(ctx, &folder, document.AccessLevelRead) {
+		return
+	}
+	respondAccessGrants(ctx, folder.Path, document.AccessObjectFolder, folder.ID)
+}
+
+// GrantFolderAccess grants a user, role or team access to a folder
+// @Summary Grant folder access
+// @Description Grant read, write or manage access to a folder and everything in it. Granting again replaces the level. The first grant restricts the folder to its owners and grantees; a caller who is not an owner is granted manage so they keep access.
+// @Tags folders
+// @Accept json
+// @Produce json
+// @Param id path string true "Folder ID" format(uuid)
+// @Param request body AccessGrantRequest true "Grantee and access level"
+// @Security BearerAuth
+// @Success 201 {object} map[string]interface{} "Access granted"
+// @Failure 400 {object} map[string]string "Invalid request data"
+// @Failure 403 {object} map[string]string "Access denied"
+// @Failure 404 {object} map[string]string "Folder or grantee not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /folders/{id}/acl [post]
+func GrantFolderAccess(ctx *gin.Context) {
+	folder, ok := findAccessFolder(ctx)
+	if !ok || !checkFolderAccess(ctx, &folder, document.AccessLevelManage) {
+		return
+	}
+	grantAccess(ctx, document.AccessObjectFolder, folder.ID, func(db *gorm.DB, userID uuid.UUID) (database.ObjectAccess, error) {
+		return database.FolderAccess(db, userID, &folder)
+	})
+}
+
+// RevokeFolderAccess removes an access grant from a folder
+// @Summary Revoke folder access
+// @Description Remove a grant from a folder. Once its last grant is removed the folder is open again to everyone with the file-management permission, unless a parent folder has grants.
+// @Tags folders
+// @Accept json
+// @Produce json
+// @Param id path string true "Folder ID" format(uuid)
+// @Param grant_id path string true "Grant ID" format(uuid)
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Access revoked"
+// @Failure 403 {object} map[string]string "Access denied"
+// @Failure 404 {object} map[string]string "Folder or grant not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /folders/{id}/acl/{grant_id} [delete]
+func RevokeFolderAccess(ctx *gin.Context) {
+	folder, ok := findAccessFolder(ctx)
+	if !ok || !checkFolderAccess(ctx, &folder, document.AccessLevelManage) {
+		return
+	}
+	revokeAccess(ctx, document.AccessObjectFolder, folder.ID)
+}
+
+// GetDocumentAccess lists the access grants of a document
+// @Summary List document access grants
+// @Description List the grants on a document and the ones it inherits from its folders, with the caller's own access level
+// @Tags documents
+// @Accept json
+// @Produce json
+// @Param id path string true "Document ID" format(uuid)
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Access grants"
+// @Failure 403 {object} map[string]string "Access denied"
+// @Failure 404 {object} map[string]string "Document not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /documents/{id}/acl [get]
+func GetDocumentAccess(ctx *gin.Context) {
+	doc, ok := findAccessDocument(ctx)
+	if !ok || !checkDocumentAccess(ctx, &doc, document.AccessLevelRead) {
+		return
+	}
+	respondAccessGrants(ctx, doc.Folder.Path, document.AccessObjectDocument, doc.ID)
+}
+
+// GrantDocumentAccess grants a user, role or team access to a document
+// @Summary Grant document access
+// @Description Grant read, write or manage access to a document. Granting again replaces the level. The first grant restricts the document to its owners and grantees; a caller who is not an owner is granted manage so they keep access.
+// @Tags documents
+// @Accept json
+// @Produce json
+// @Param id path string true "Document ID" format(uuid)
+// @Param request body AccessGrantRequest true "Grantee and access level"
+// @Security BearerAuth
+// @Success 201 {object} map[string]interface{} "Access granted"
+// @Failure 400 {object} map[string]string "Invalid request data"
+// @Failure 403 {object} map[string]string "Access denied"
+// @Failure 404 {object} map[string]string "Document or grantee not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /documents/{id}/acl [post]
+func GrantDocumentAccess(ctx *gin.Context) {
+	doc, ok := findAccessDocument(ctx)
+	if !ok || !checkDocumentAccess(ctx, &doc, document.AccessLevelManage) {
+		return
+	}
+	grantAccess(ctx, document.AccessObjectDocument, doc.ID, func(db *gorm.DB, userID uuid.UUID) (database.ObjectAccess, error) {
+		return database.DocumentAccess(db, userID, &doc)
+	})
+}
+
+// RevokeDocumentAccess removes an access grant from a document
+// @Summary Revoke document access
+// @Description Remove a grant from a document
+// @Tags documents
+// @Accept json
+// @Produce json
+// @Param id path string true "Document ID" format(uuid)
+// @Param grant_id path string true "Grant ID" format(uuid)
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Access revoked"
+// @Failure 403 {object} map[string]string "Access denied"
+// @Failure 404 {object} map[string]string "Document or grant not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /documents/{id}/acl/{grant_id} [delete]
+func RevokeDocumentAccess(ctx *gin.Context) {
+	doc, ok := findAccessDocument(ctx)
+	if !ok || !checkDocumentAccess(ctx, &doc, document.AccessLevelManage) {
+		return
+	}
+	revokeAccess(ctx, document.AccessObjectDocument, doc.ID)
+}
+
+func findAccessFolder(ctx *gin.Context) (document.Folder, bool) {
+	var folder document.Folder
+	if err := requestDB(ctx).First(&folder, "id = ?", ctx.Param("id")).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.NotFound("Folder not found").WithDetails("Folder with the given ID does not exist"))
+		return folder, false
+	}
+	return folder, true
+}
+
+func findAccessDocument(ctx *gin.Context) (document.Document, bool) {
+	var doc document.Document
+	if err := requestDB(ctx).Preload("Folder").First(&doc, "id = ?", ctx.Param("id")).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.NotFound("Document not found"))
+		return doc, false
+	}
+	return doc, true
+}
+
+// respondAccessGrants writes the grants on the object and on the folders above folderPath
+func respondAccessGrants(ctx *gin.Context, folderPath, objectType string, objectID uuid.UUID) {
+	db := requestDB(ctx)
+
+	var folders []document.Folder
+	if err := db.Unscoped().Select("id", "path").
+		Where("(? = path OR ? LIKE path || '/%')", folderPath, folderPath).
+		Find(&folders).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to fetch access grants"))
+		return
+	}
+	folderPaths := make(map[uuid.UUID]string, len(folders))
+	folderIDs := make([]uuid.UUID, 0, len(folders))
+	for _, folder := range folders {
+		folderPaths[folder.ID] = folder.Path
+		folderIDs = append(folderIDs, folder.ID)
+	}
+
+	var grants []document.AccessGrant
+	if err := db.Where("((object_type = ? AND object_id IN ?) OR (object_type = ? AND object_id = ?))",
+		document.AccessObjectFolder, folderIDs, objectType, objectID).
+		Order("created_at").
+		Find(&grants).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to fetch access grants"))
+		return
+	}
+
+	response := make([]AccessGrantResponse, 0, len(grants))
+	for _, grant := range grants {
+		inherited := grant.ObjectID != objectID
+		grantResponse := AccessGrantResponse{AccessGrant: grant, Inherited: inherited}
+		if inherited {
+			grantResponse.InheritedFrom = folderPaths[grant.ObjectID]
+		}
+		response = append(response, grantResponse)
+	}
+
+	data := gin.H{
+		"restricted": len(grants) > 0,
+		"grants":     response,
+	}
+	if userID, ok := accessCheckedUser(ctx); ok {
+		access, err := database.ResolveObjectAccess(db, userID, objectType, objectID)
+		if err == nil {
+			data["level"] = access.Level
+		}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    data,
+	})
+}
+
+// grantAccess creates or updates the grant of the request. callerAccess resolves the caller's
+// access before the grant so a caller relying on their file-management permission keeps access.
+func grantAccess(ctx *gin.Context, objectType string, objectID uuid.UUID, callerAccess func(*gorm.DB, uuid.UUID) (database.ObjectAccess, error)) {
+	db := requestDB(ctx)
+
+	var req AccessGrantRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apperrors.Respond(ctx, validation.Error(err, "Invalid request body"))
+		return
+	}
+	principalID := uuid.MustParse(req.PrincipalID)
+
+	var principal interface{}
+	switch req.PrincipalType {
+	case document.AccessPrincipalUser:
+		principal = &models.User{}
+	case document.AccessPrincipalRole:
+		principal = &models.Role{}
+	case document.AccessPrincipalTeam:
+		principal = &models.Team{}
+	}
+	if err := db.First(principal, principalID).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.Newf(apperrors.CodeNotFound, "Grantee %s not found", req.PrincipalType))
+		return
+	}
+
+	grantedBy := utils.GetActorID(ctx)
+	if grantedBy == nil {
+		userID, err := uuid.Parse(req.UserID)
+		if err != nil {
+			apperrors.Respond(ctx, apperrors.BadRequest("user_id is required"))
+			return
+		}
+		grantedBy = &userID
+	}
+
+	grant := document.AccessGrant{
+		ObjectType:    objectType,
+		ObjectID:      objectID,
+		PrincipalType: req.PrincipalType,
+		PrincipalID:   principalID,
+		Level:         req.Level,
+		GrantedBy:     *grantedBy,
+	}
+
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if userID, ok := accessCheckedUser(ctx); ok {
+			access, err := callerAccess(tx, userID)
+			if err != nil {
+				return err
+			}
+			if !access.Decisive() {
+				if err := upsertAccessGrant(tx, &document.AccessGrant{
+					ObjectType:    objectType,
+					ObjectID:      objectID,
+					PrincipalType: document.AccessPrincipalUser,
+					PrincipalID:   userID,
+					Level:         document.AccessLevelManage,
+					GrantedBy:     userID,
+				}); err != nil {
+					return err
+				}
+			}
+		}
+		return upsertAccessGrant(tx, &grant)
+	}); err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to grant access"))
+		return
+	}
+	if objectType == document.AccessObjectDocument {
+		recordDocumentActivity(ctx, objectID, document.DocumentActionShare,
+			fmt.Sprintf("Granted %s access to %s %s", grant.Level, grant.PrincipalType, grant.PrincipalID))
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Access granted successfully",
+		"data":    grant,
+	})
+}
+
+// upsertAccessGrant creates the grant or updates the level of the principal's existing grant
+func upsertAccessGrant(tx *gorm.DB, grant *document.AccessGrant) error {
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "object_type"}, {Name: "object_id"}, {Name: "principal_type"}, {Name: "principal_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"level", "granted_by", "updated_at"}),
+	}).Create(grant).Error
+}
+
+func revokeAccess(ctx *gin.Context, objectType string, objectID uuid.UUID) {
+	result := requestDB(ctx).
+		Where("id = ? AND object_type = ? AND object_id = ?", ctx.Param("grant_id"), objectType, objectID).
+		Delete(&document.AccessGrant{})
+	if result.Error != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to revoke access"))
+		return
+	}
+	if result.RowsAffected == 0 {
+		apperrors.Respond(ctx, apperrors.NotFound("Grant not found"))
+		return
+	}
+	if objectType == document.AccessObjectDocument {
+		recordDocumentActivity(ctx, objectID, document.DocumentActionUnshare, "Revoked access grant "+ctx.Param("grant_id"))
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Access revoked successfully",
+	})
+}
+
+// accessCheckedUser returns the caller whose access grants are enforced. Super admins and calls
+// that did not come through the gateway on behalf of a user are not limited by access grants.
+func accessCheckedUser(ctx *gin.Context) (uuid.UUID, bool) {
+	tenant, ok := tenancy.FromRequest(ctx)
+	if !ok || tenant.Bypass {
+		return uuid.Nil, false
+	}
+	return *tenant.UserID, true
+}
+
+// checkFolderAccess writes the 403 response when the caller lacks the access level on the folder
+func checkFolderAccess(ctx *gin.Context, folder *document.Folder, level string) bool {
+	return checkObjectAccess(ctx, document.AccessObjectFolder, level, func(db *gorm.DB, userID uuid.UUID) (database.ObjectAccess, error) {
+		return database.FolderAccess(db, userID, folder)
+	})
+}
+
+// checkDocumentAccess writes the 403 response when the caller lacks the access level on the document
+func checkDocumentAccess(ctx *gin.Context, doc *document.Document, level string) bool {
+	return checkObjectAccess(ctx, document.AccessObjectDocument, level, func(db *gorm.DB, userID uuid.UUID) (database.ObjectAccess, error) {
+		return database.DocumentAccess(db, userID, doc)
+	})
+}
+
+func checkObjectAccess(ctx *gin.Context, objectType, level string, resolve func(*gorm.DB, uuid.UUID) (database.ObjectAccess, error)) bool {
+	denied, err := objectAccessDenied(ctx, objectType, level, resolve)
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to check access"))
+		return false
+	}
+	if denied != "" {
+		apperrors.Respond(ctx, apperrors.Forbidden("Access denied").WithDetails(denied))
+		return false
+	}
+	return true
+}
+
+// folderAccessDenied returns why the caller lacks the access level on the folder, empty when allowed
+func folderAccessDenied(ctx *gin.Context, folder *document.Folder, level string) (string, error) {
+	return objectAccessDenied(ctx, document.AccessObjectFolder, level, func(db *gorm.DB, userID uuid.UUID) (database.ObjectAccess, error) {
+		return database.FolderAccess(db, userID, folder)
+	})
+}
+
+// documentAccessDenied returns why the caller lacks the access level on the document, empty when allowed
+func documentAccessDenied(ctx *gin.Context, doc *document.Document, level string) (string, error) {
+	return objectAccessDenied(ctx, document.AccessObjectDocument, level, func(db *gorm.DB, userID uuid.UUID) (database.ObjectAccess, error) {
+		return database.DocumentAccess(db, userID, doc)
+	})
+}
+
+func objectAccessDenied(ctx *gin.Context, objectType, level string, resolve func(*gorm.DB, uuid.UUID) (database.ObjectAccess, error)) (string, error) {
+	userID, ok := accessCheckedUser(ctx)
+	if !ok {
+		return "", nil
+	}
+
+	access, err := resolve(requestDB(ctx), userID)
+	if err != nil {
+		return "", err
+	}
+	if !access.Allows(level) {
+		return fmt.Sprintf("%s access to this %s is required", level, objectType), nil
+	}
+	return "", nil
+}
+
+// readableFolders limits a folder query to the folders the caller may read
+func readableFolders(ctx *gin.Context, dbQuery *gorm.DB) *gorm.DB {
+	if userID, ok := accessCheckedUser(ctx); ok {
+		return dbQuery.Where(database.FolderAccessCondition("folders", userID, document.AccessLevelRead))
+	}
+	return dbQuery
+}
+
+// readableDocuments limits a document query to the documents the caller may read
+func readableDocuments(ctx *gin.Context, dbQuery *gorm.DB) *gorm.DB {
+	if userID, ok := accessCheckedUser(ctx); ok {
+		return dbQuery.Where(database.DocumentAccessCondition("documents", userID, document.AccessLevelRead))
+	}
+	return dbQuery
+}
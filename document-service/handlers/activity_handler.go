@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"forgecrud-backend/document-service/services"
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/database/models/document"
+	utils "forgecrud-backend/shared/utils/auth"
+	"forgecrud-backend/shared/utils/query"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DocumentActivityResponse is a document activity with the name and email of the actor
+type DocumentActivityResponse struct {
+	document.DocumentActivity
+	ActorName  string `json:"actor_name,omitempty"`
+	ActorEmail string `json:"actor_email,omitempty"`
+}
+
+// documentActivityFilters are the columns document activities can be filtered and sorted by, e.g.
+// filters[action][in]=download,share or filters[created_at][gte]=2025-01-01
+var documentActivityFilters = map[string]string{
+	"document_id": "document_activities.document_id",
+	"actor_id":    "document_activities.actor_id",
+	"action":      "document_activities.action",
+	"ip_address":  "document_activities.ip_address",
+	"created_at":  "document_activities.created_at",
+}
+
+// documentActivityCSVHeader are the columns of a CSV export
+var documentActivityCSVHeader = []string{"id", "created_at", "document_id", "action", "actor_id", "actor_name", "actor_email", "ip_address", "user_agent", "details"}
+
+// recordDocumentActivity records an action of the caller on a document with the caller's IP address
+// and user agent. A failure is logged; it does not fail the request.
+func recordDocumentActivity(ctx *gin.Context, documentID uuid.UUID, action, details string) {
+	recordDocumentActivities(ctx, []uuid.UUID{documentID}, action, details)
+}
+
+// recordDocumentActivities records the same action of the caller on several documents
+func recordDocumentActivities(ctx *gin.Context, documentIDs []uuid.UUID, action, details string) {
+	if len(documentIDs) == 0 {
+		return
+	}
+
+	actorID := utils.GetActorID(ctx)
+	activities := make([]document.DocumentActivity, len(documentIDs))
+	for i, documentID := range documentIDs {
+		activities[i] = document.DocumentActivity{
+			DocumentID: documentID,
+			ActorID:    actorID,
+			Action:     action,
+			Details:    details,
+			IPAddress:  ctx.ClientIP(),
+			UserAgent:  ctx.Request.UserAgent(),
+		}
+	}
+	if err := requestDB(ctx).CreateInBatches(&activities, 500).Error; err != nil {
+		log.Printf("⚠️  Failed to record %s activity of %d documents: %v", action, len(documentIDs), err)
+	}
+}
+
+// recordFolderChange queues a change of the caller to documents for the subscribers of their folders
+func recordFolderChange(ctx *gin.Context, documentIDs []uuid.UUID, change string) {
+	services.RecordDocumentChanges(requestDB(ctx), documentIDs, change, utils.GetActorID(ctx))
+}
+
+// documentMoveDetails describes a move between folders
+func documentMoveDetails(fromPath, toPath string) string {
+	return fmt.Sprintf("From %s to %s", fromPath, toPath)
+}
+
+// documentActivityQuery selects document activities with the name and email of their actor
+func documentActivityQuery(db *gorm.DB) *gorm.DB {
+	return db.Model(&document.DocumentActivity{}).
+		Select("document_activities.*, TRIM(CONCAT(users.first_name, ' ', users.last_name)) AS actor_name, users.email AS actor_email").
+		Joins("LEFT JOIN users ON users.id = document_activities.actor_id")
+}
+
+// GetDocumentActivity lists who viewed, downloaded, uploaded, moved and shared a document
+// @Summary Get document activity
+// @Description List the views, downloads, uploads, moves and shares of a document with the actor, IP address and user agent, newest first.
+// @Description Downloads through public share links have no actor. Documents in the trash keep their activity. Requires manage access to the document.
+// @Tags documents
+// @Produce json
+// @Param id path string true "Document ID" format(uuid)
+// @Param page query int false "Page number (default: 1)"
+// @Param limit query int false "Items per page (default: 10)"
+// @Param filters[action] query string false "Filter by action (view, download, upload, move, share, unshare), e.g. filters[action][in]=download,share"
+// @Param filters[actor_id] query string false "Filter by actor ID"
+// @Param filters[created_at] query string false "Filter by time, e.g. filters[created_at][gte]=2025-01-01"
+// @Param sort[field] query string false "Sort field (created_at, action)"
+// @Param sort[order] query string false "Sort order (asc, desc)"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Document activity with pagination"
+// @Failure 400 {object} map[string]string "Invalid document ID format"
+// @Failure 403 {object} map[string]string "Access denied"
+// @Failure 404 {object} map[string]string "Document not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /documents/{id}/activity [get]
+func GetDocumentActivity(ctx *gin.Context) {
+	documentUUID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid document ID format"))
+		return
+	}
+
+	db := requestDB(ctx)
+
+	var doc document.Document
+	if err := db.Unscoped().First(&doc, documentUUID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			apperrors.Respond(ctx, apperrors.NotFound("Document not found").WithDetails("Document with the given ID does not exist"))
+			return
+		}
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to fetch document"))
+		return
+	}
+
+	if !checkDocumentAccess(ctx, &doc, document.AccessLevelManage) {
+		return
+	}
+
+	params := query.ParseQueryParams(ctx)
+	activityQuery := query.ApplyFilters(documentActivityQuery(db).Where("document_activities.document_id = ?", doc.ID), params.Filters, documentActivityFilters)
+
+	var total int64
+	if err := activityQuery.Count(&total).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to count document activity"))
+		return
+	}
+
+	// The default sort of ApplySort is ambiguous next to the users' created_at
+	sort := params.Sort
+	if _, ok := documentActivityFilters[sort.Field]; !ok {
+		sort.Field = "created_at"
+	}
+
+	activities := []DocumentActivityResponse{}
+	finalQuery := query.ApplySort(activityQuery, sort, documentActivityFilters).Order("document_activities.id")
+	if err := query.ApplyPagination(finalQuery, params.Page, params.Limit).Scan(&activities).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to retrieve document activity"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"items":      activities,
+			"pagination": query.BuildPaginationResponse(params.Page, params.Limit, total),
+		},
+	})
+}
+
+// ExportDocumentActivity streams the activity of every document matching the filters as CSV
+// @Summary Export document activity
+// @Description Export the views, downloads, uploads, moves and shares of documents as CSV for compliance, oldest first, one row per action with the actor, IP address and user agent.
+// @Description Callers scoped to an organization export the activity of their organization's documents. The file is streamed, so large exports don't need to fit in memory.
+// @Tags documents
+// @Produce text/csv
+// @Param filters[document_id] query string false "Filter by document ID"
+// @Param filters[action] query string false "Filter by action (view, download, upload, move, share, unshare)"
+// @Param filters[actor_id] query string false "Filter by actor ID"
+// @Param filters[created_at] query string false "Filter by time, e.g. filters[created_at][gte]=2025-01-01&filters[created_at][lt]=2025-02-01"
+// @Security BearerAuth
+// @Success 200 {file} file "Document activity export"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /documents/activity/export [get]
+func ExportDocumentActivity(ctx *gin.Context) {
+	db := requestDB(ctx)
+	params := query.ParseQueryParams(ctx)
+
+	activityQuery := query.ApplyFilters(documentActivityQuery(db), params.Filters, documentActivityFilters)
+	rows, err := activityQuery.Order("document_activities.created_at, document_activities.id").Rows()
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to export document activity"))
+		return
+	}
+	defer rows.Close()
+
+	fileName := fmt.Sprintf("document-activity-%s.csv", time.Now().UTC().Format("20060102-150405"))
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", fileName))
+	ctx.Header("Content-Type", "text/csv; charset=utf-8")
+	ctx.Status(http.StatusOK)
+
+	// The status is sent, a failure from here on can only cut the file short
+	writer := csv.NewWriter(ctx.Writer)
+	exported := 0
+	err = writer.Write(documentActivityCSVHeader)
+	for err == nil && rows.Next() {
+		var activity DocumentActivityResponse
+		if err = db.ScanRows(rows, &activity); err != nil {
+			break
+		}
+
+		actorID := ""
+		if activity.ActorID != nil {
+			actorID = activity.ActorID.String()
+		}
+		err = writer.Write([]string{
+			activity.ID.String(),
+			activity.CreatedAt.UTC().Format(time.RFC3339),
+			activity.DocumentID.String(),
+			activity.Action,
+			actorID,
+			activity.ActorName,
+			activity.ActorEmail,
+			activity.IPAddress,
+			activity.UserAgent,
+			activity.Details,
+		})
+		exported++
+	}
+	writer.Flush()
+	if err == nil {
+		err = writer.Error()
+	}
+	if err == nil {
+		err = rows.Err()
+	}
+	if err != nil {
+		log.Printf("❌ Document activity export stopped after %d activities: %v", exported, err)
+	}
+}
@@ -0,0 +1,320 @@
+package handlers
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"sort"
+	"strings"
+
+	"forgecrud-backend/document-service/services"
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/config"
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models"
+	"forgecrud-backend/shared/database/models/document"
+	"forgecrud-backend/shared/messaging"
+	utils "forgecrud-backend/shared/utils/auth"
+	docUtils "forgecrud-backend/shared/utils/document"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ArchiveEntryResult is an archive entry that was not extracted
+type ArchiveEntryResult struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// archiveFile is a file entry of an archive with the folders it is extracted into
+type archiveFile struct {
+	entry   *zip.File
+	folders []string
+	name    string
+}
+
+// extractArchive expands an uploaded ZIP archive into the folder. The whole archive is validated
+// before anything is created: entries pointing outside the folder and archives beyond the entry,
+// depth and size limits are rejected. Empty files, files over the upload limit, files whose extension
+// the file type policy does not allow and symbolic links are skipped and reported. Files whose content
+// the policy does not allow and files whose metadata does not match the metadata fields of their
+// folder are reported as failed. uploadedBy is resolved by the caller before anything is created.
+func extractArchive(ctx *gin.Context, folder *document.Folder, file multipart.File, header *multipart.FileHeader, uploadedBy uuid.UUID, tags []string, metadata map[string]interface{}) {
+	db := requestDB(ctx)
+	cfg := config.GetConfig()
+
+	reader, err := zip.NewReader(file, header.Size)
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.BadRequest("File is not a valid ZIP archive"))
+		return
+	}
+
+	policy, err := folderFileTypePolicy(folder)
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to check file types"))
+		return
+	}
+
+	var files []archiveFile
+	folderPaths := map[string][]string{}
+	skipped := []ArchiveEntryResult{}
+	var totalSize int64
+
+	for _, entry := range reader.File {
+		segments, err := docUtils.ArchiveEntryPath(entry.Name)
+		if err != nil {
+			apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid archive"))
+			return
+		}
+		if docUtils.IsArchiveMetadata(segments) {
+			continue
+		}
+
+		isDir := entry.FileInfo().IsDir()
+		folders := segments
+		if !isDir {
+			folders = segments[:len(segments)-1]
+		}
+		if len(folders) > cfg.ArchiveMaxDepth {
+			apperrors.Respond(ctx, apperrors.BadRequest("Archive too deep").WithDetails(fmt.Sprintf("Entry %q is nested deeper than %d folders", entry.Name, cfg.ArchiveMaxDepth)))
+			return
+		}
+		for i := 1; i <= len(folders); i++ {
+			folderPaths[strings.Join(folders[:i], "/")] = folders[:i]
+		}
+		if isDir {
+			continue
+		}
+
+		if entry.Mode()&os.ModeSymlink != 0 {
+			skipped = append(skipped, ArchiveEntryResult{Name: entry.Name, Reason: "symbolic links are not extracted"})
+			continue
+		}
+		size := int64(entry.UncompressedSize64)
+		if err := docUtils.ValidateUploadedFile(&multipart.FileHeader{Filename: entry.Name, Size: size}); err != nil {
+			skipped = append(skipped, ArchiveEntryResult{Name: entry.Name, Reason: err.Error()})
+			continue
+		}
+		// The content of the entry is checked once it is extracted
+		if err := database.CheckFileType(policy, entry.Name, "", size); err != nil {
+			skipped = append(skipped, ArchiveEntryResult{Name: entry.Name, Reason: err.Error()})
+			continue
+		}
+
+		files = append(files, archiveFile{entry: entry, folders: folders, name: segments[len(segments)-1]})
+		totalSize += size
+	}
+
+	if len(files) > cfg.ArchiveMaxEntries {
+		apperrors.Respond(ctx, apperrors.BadRequest("Too many archive entries").WithDetails(fmt.Sprintf("Archive contains %d files, the limit is %d", len(files), cfg.ArchiveMaxEntries)))
+		return
+	}
+	if totalSize > cfg.ArchiveMaxExtractedBytes {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodePayloadTooLarge, "Archive too large").WithDetails(fmt.Sprintf("Archive expands to %d bytes, the limit is %d", totalSize, cfg.ArchiveMaxExtractedBytes)))
+		return
+	}
+	if len(files) == 0 && len(folderPaths) == 0 {
+		apperrors.Respond(ctx, apperrors.BadRequest("Archive is empty"))
+		return
+	}
+
+	// The extracted files count towards the quota, not the archive
+	if !checkFolderQuota(ctx, folder, totalSize, len(files)) {
+		return
+	}
+
+	storage, err := services.NewStorageProvider()
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Storage service unavailable"))
+		return
+	}
+
+	// Create the folders, parents first. Existing folders of the same name are reused.
+	paths := make([]string, 0, len(folderPaths))
+	for folderPath := range folderPaths {
+		paths = append(paths, folderPath)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		if len(folderPaths[paths[i]]) != len(folderPaths[paths[j]]) {
+			return len(folderPaths[paths[i]]) < len(folderPaths[paths[j]])
+		}
+		return paths[i] < paths[j]
+	})
+
+	extractedFolders := map[string]*document.Folder{"": folder}
+	createdFolders := []docUtils.FolderResponse{}
+	for _, folderPath := range paths {
+		segments := folderPaths[folderPath]
+		parent := extractedFolders[strings.Join(segments[:len(segments)-1], "/")]
+
+		subfolder, created, err := ensureSubfolder(db, storage, parent, segments[len(segments)-1])
+		if err != nil {
+			apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeConflict, "Failed to create archive folder").With("data", gin.H{"folders": createdFolders}))
+			return
+		}
+		if created {
+			createdFolders = append(createdFolders, docUtils.BuildFolderResponse(subfolder))
+		} else {
+			// Existing folders may have stricter access grants than the target folder
+			denied, err := folderAccessDenied(ctx, subfolder, document.AccessLevelWrite)
+			if err != nil {
+				apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to check access"))
+				return
+			}
+			if denied != "" {
+				apperrors.Respond(ctx, apperrors.Forbidden("Access denied").WithDetails(fmt.Sprintf("Folder %s: %s", subfolder.Path, denied)).With("data", gin.H{"folders": createdFolders}))
+				return
+			}
+		}
+		extractedFolders[folderPath] = subfolder
+	}
+
+	// Extract the files
+	description := ctx.PostForm("description")
+
+	documents := []docUtils.DocumentResponse{}
+	var documentIDs []uuid.UUID
+	failed := []ArchiveEntryResult{}
+	folderFields := map[uuid.UUID][]document.MetadataField{}
+	for _, archived := range files {
+		target := extractedFolders[strings.Join(archived.folders, "/")]
+
+		// Subfolders can define their own metadata fields
+		fields, ok := folderFields[target.ID]
+		if !ok {
+			fields, err = folderMetadataFields(db, target)
+			if err != nil {
+				failed = append(failed, ArchiveEntryResult{Name: archived.entry.Name, Reason: "failed to fetch metadata fields"})
+				continue
+			}
+			folderFields[target.ID] = fields
+		}
+		validMetadata, err := docUtils.ValidateMetadata(fields, metadata)
+		if err != nil {
+			failed = append(failed, ArchiveEntryResult{Name: archived.entry.Name, Reason: err.Error()})
+			continue
+		}
+
+		doc, err := extractArchiveFile(db, storage, target, archived, policy, uploadedBy, tags, description, validMetadata)
+		if err != nil {
+			failed = append(failed, ArchiveEntryResult{Name: archived.entry.Name, Reason: err.Error()})
+			continue
+		}
+
+		doc.Folder = *target
+		docResponse := docUtils.BuildDocumentResponse(doc, db)
+		database.EnqueueWebhookEvent(models.WebhookEventDocumentUploaded, docResponse)
+		messaging.Publish(ctx.Request.Context(), messaging.EventDocumentUploaded, utils.GetActorID(ctx), docResponse)
+		documents = append(documents, docResponse)
+		documentIDs = append(documentIDs, doc.ID)
+	}
+
+	// Update statistics of the extracted folders and the target folder
+	for _, folderPath := range paths {
+		services.QueueFolderStats(extractedFolders[folderPath].ID)
+	}
+	services.QueueFolderStats(folder.ID)
+	recordDocumentActivities(ctx, documentIDs, document.DocumentActionUpload, "Extracted from "+header.Filename)
+	recordFolderChange(ctx, documentIDs, document.FolderChangeAdded)
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success": len(failed) == 0,
+		"message": fmt.Sprintf("Archive extracted: %d documents, %d folders created", len(documents), len(createdFolders)),
+		"data": gin.H{
+			"folders":   createdFolders,
+			"documents": documents,
+			"skipped":   skipped,
+			"failed":    failed,
+		},
+	})
+}
+
+// extractArchiveFile stores an archive entry as a new document in the folder. The entry is spooled
+// to a temporary file first as its type and checksums are needed before it is stored.
+func extractArchiveFile(db *gorm.DB, storage services.StorageProvider, folder *document.Folder, archived archiveFile, policy models.FileTypePolicy, uploadedBy uuid.UUID, tags []string, description string, metadata models.JSONMap) (*document.Document, error) {
+	entryReader, err := archived.entry.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read entry: %v", err)
+	}
+	defer entryReader.Close()
+
+	tempFile, err := os.CreateTemp("", "archive-entry-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary file: %v", err)
+	}
+	defer os.Remove(tempFile.Name())
+	defer tempFile.Close()
+
+	// Never write more than the header declares, archives can lie about their sizes
+	size := int64(archived.entry.UncompressedSize64)
+	written, err := io.Copy(tempFile, io.LimitReader(entryReader, size+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read entry: %v", err)
+	}
+	if written != size {
+		return nil, fmt.Errorf("entry size does not match the archive header")
+	}
+	if _, err := tempFile.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to read entry: %v", err)
+	}
+
+	mimeType, err := docUtils.DetectFileType(tempFile, archived.name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read entry: %v", err)
+	}
+	if err := database.CheckFileType(policy, archived.name, mimeType, size); err != nil {
+		return nil, err
+	}
+	header := &multipart.FileHeader{
+		Filename: archived.name,
+		Size:     size,
+		Header:   textproto.MIMEHeader{"Content-Type": {mimeType}},
+	}
+
+	return createDocument(db, storage, folder, tempFile, header, uploadedBy, tags, description, metadata)
+}
+
+// ensureSubfolder returns the subfolder with the name in the parent folder, creating it when it does
+// not exist. Reports whether it was created.
+func ensureSubfolder(db *gorm.DB, storage services.StorageProvider, parent *document.Folder, name string) (*document.Folder, bool, error) {
+	var existing document.Folder
+	if err := db.Where("parent_id = ? AND name = ?", parent.ID, name).First(&existing).Error; err == nil {
+		return &existing, false, nil
+	}
+
+	folderPath := docUtils.GenerateFolderPath(parent.Path, name)
+
+	// Paths stay taken while a folder is in the trash
+	if err := db.Unscoped().Where("path = ? AND deleted_at IS NOT NULL", folderPath).First(&existing).Error; err == nil {
+		return nil, false, fmt.Errorf("folder %s is in the trash, restore it or empty the trash first", folderPath)
+	}
+
+	subfolder := document.Folder{
+		Name:      name,
+		Path:      folderPath,
+		ParentID:  &parent.ID,
+		OwnerID:   parent.OwnerID,
+		OwnerType: parent.OwnerType,
+	}
+	var operation *document.StorageOperation
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&subfolder).Error; err != nil {
+			return err
+		}
+		queued, err := services.QueueCreateFolder(tx, &subfolder)
+		operation = queued
+		return err
+	}); err != nil {
+		return nil, false, fmt.Errorf("failed to create folder %s: %v", folderPath, err)
+	}
+
+	services.NewStorageOutbox(storage).Run(operation)
+
+	return &subfolder, true, nil
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"forgecrud-backend/document-service/services"
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/config"
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models/notification"
+	"forgecrud-backend/shared/utils/query"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// GetAuditLogArchives lists the archived days of audit logs
+// @Summary List audit log archives
+// @Description List the days of audit logs archived to storage, most recent first, with their object key, number of logs, size, checksum and verification status. Only callers outside an organization can see them.
+// @Tags audit-logs
+// @Produce json
+// @Param status query string false "Archive status" Enums(ARCHIVED, VERIFIED, FAILED)
+// @Param page query int false "Page number (default: 1)"
+// @Param limit query int false "Archives per page (default: 10, max: 100)"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Archives"
+// @Failure 403 {object} map[string]string "Access denied"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /audit-logs/archives [get]
+func GetAuditLogArchives(ctx *gin.Context) {
+	if !checkPlatformCaller(ctx) {
+		return
+	}
+
+	archiveQuery := database.GetDB().Model(&notification.AuditLogArchive{})
+	if status := strings.ToUpper(ctx.Query("status")); status != "" {
+		archiveQuery = archiveQuery.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := archiveQuery.Count(&total).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to fetch audit log archives"))
+		return
+	}
+
+	params := query.ParseQueryParams(ctx)
+	var archives []notification.AuditLogArchive
+	if err := query.ApplyPagination(archiveQuery, params.Page, params.Limit).
+		Order("day DESC").
+		Find(&archives).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to fetch audit log archives"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"data":       archives,
+		"pagination": query.BuildPaginationResponse(params.Page, params.Limit, total),
+	})
+}
+
+// ArchiveAuditLogs queues archiving the audit logs past their retention
+// @Summary Archive audit logs
+// @Description Queue archiving every day of audit logs older than AUDIT_LOG_RETENTION_DAYS that is not archived yet or whose archive failed verification. Archival also runs daily at 01:00 UTC. Only callers outside an organization can start it.
+// @Tags audit-logs
+// @Produce json
+// @Security BearerAuth
+// @Success 202 {object} map[string]interface{} "Archival queued"
+// @Failure 403 {object} map[string]string "Access denied"
+// @Failure 409 {object} map[string]string "Audit log archival is disabled"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /audit-logs/archives [post]
+func ArchiveAuditLogs(ctx *gin.Context) {
+	if !checkPlatformCaller(ctx) {
+		return
+	}
+
+	if !config.GetConfig().AuditLogArchiveEnabled {
+		apperrors.Respond(ctx, apperrors.Conflict("Audit log archival is disabled").WithDetails("Set AUDIT_LOG_ARCHIVE_ENABLED=true to archive audit logs"))
+		return
+	}
+
+	if err := services.QueueAuditLogArchival(ctx.Request.Context()); err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to queue audit log archival"))
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"message": "Audit log archival queued",
+	})
+}
+
+// VerifyAuditLogArchive checks an archive against its record
+// @Summary Verify audit log archive
+// @Description Read an archive back from storage and check its checksum, that it is valid gzipped JSON lines and that it holds as many logs as were archived and as are still in the database for its day. A failed archive is written again by the next archival and its logs are not deleted meanwhile. Only callers outside an organization can verify archives.
+// @Tags audit-logs
+// @Produce json
+// @Param id path string true "Archive ID" format(uuid)
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Verified archive, with status VERIFIED or FAILED"
+// @Failure 400 {object} map[string]string "Invalid archive ID"
+// @Failure 403 {object} map[string]string "Access denied"
+// @Failure 404 {object} map[string]string "Archive not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /audit-logs/archives/{id}/verify [post]
+func VerifyAuditLogArchive(ctx *gin.Context) {
+	if !checkPlatformCaller(ctx) {
+		return
+	}
+
+	archiveID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.BadRequest("Invalid archive ID"))
+		return
+	}
+
+	db := database.GetDB().WithContext(ctx.Request.Context())
+	var archive notification.AuditLogArchive
+	if err := db.First(&archive, "id = ?", archiveID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apperrors.Respond(ctx, apperrors.NotFound("Audit log archive not found"))
+			return
+		}
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to fetch audit log archive"))
+		return
+	}
+
+	storage, err := services.NewStorageProvider()
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Storage service unavailable"))
+		return
+	}
+
+	if err := services.VerifyAuditLogArchive(ctx.Request.Context(), db, storage, &archive); err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to verify audit log archive"))
+		return
+	}
+	if err := db.First(&archive, "id = ?", archiveID).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to fetch audit log archive"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    archive,
+	})
+}
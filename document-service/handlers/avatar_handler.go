@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"forgecrud-backend/document-service/services"
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/config"
+	"forgecrud-backend/shared/database/models"
+	docUtils "forgecrud-backend/shared/utils/document"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AvatarSizes are the square sizes (in pixels) every avatar is rendered in; the largest is stored on the user
+var AvatarSizes = []int{64, 128, 256}
+
+const (
+	avatarPrefix      = "avatars/"
+	avatarJPEGQuality = 85
+)
+
+// avatarFilePattern matches the object names written by UploadAvatar: <content hash>-<size>.jpg
+var avatarFilePattern = regexp.MustCompile(`^[0-9a-f]{16}-[0-9]+\.jpg$`)
+
+// UploadAvatar uploads and processes a user's avatar
+// @Summary Upload user avatar
+// @Description Upload a JPEG, PNG or GIF image as the user's avatar. The image is cropped to a square, resized to 64, 128 and 256 pixels and stored in object storage; the user's avatar is set to the URL of the largest size.
+// @Tags users
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path string true "User ID" format(uuid)
+// @Param file formData file true "Avatar image (JPEG, PNG or GIF)"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Avatar URLs"
+// @Failure 400 {object} map[string]string "Invalid image"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 413 {object} map[string]string "Image too large"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /users/{id}/avatar [post]
+func UploadAvatar(ctx *gin.Context) {
+	user, ok := findAvatarUser(ctx)
+	if !ok {
+		return
+	}
+
+	file, header, err := ctx.Request.FormFile("file")
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.BadRequest("File is required"))
+		return
+	}
+	defer file.Close()
+
+	maxBytes := config.GetConfig().AvatarMaxUploadBytes
+	if maxBytes > 0 && header.Size > maxBytes {
+		apperrors.Respond(ctx, apperrors.Newf(apperrors.CodePayloadTooLarge, "Avatar must not exceed %d bytes", maxBytes))
+		return
+	}
+
+	img, _, err := docUtils.DecodeImage(file)
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.BadRequest(err.Error()))
+		return
+	}
+
+	// Render every size first so a failing image never leaves a partial avatar behind
+	square := docUtils.CropSquare(img)
+	rendered := make(map[int][]byte, len(AvatarSizes))
+	for _, size := range AvatarSizes {
+		var buf bytes.Buffer
+		if err := docUtils.EncodeJPEG(&buf, docUtils.ResizeImage(square, size, size), avatarJPEGQuality); err != nil {
+			apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to process image"))
+			return
+		}
+		rendered[size] = buf.Bytes()
+	}
+
+	// Object names carry a content hash so URLs change with the image and can be cached forever
+	hash := sha256.Sum256(rendered[AvatarSizes[len(AvatarSizes)-1]])
+	version := fmt.Sprintf("%x", hash[:8])
+
+	storage, err := services.NewStorageProvider()
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Storage service unavailable"))
+		return
+	}
+
+	urls := make(map[string]string, len(AvatarSizes))
+	keys := make(map[string]bool, len(AvatarSizes))
+	for _, size := range AvatarSizes {
+		key := avatarObjectKey(user.ID, version, size)
+		data := rendered[size]
+		if err := storage.PutObject(context.Background(), key, bytes.NewReader(data), int64(len(data)), "image/jpeg"); err != nil {
+			apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to store avatar"))
+			return
+		}
+		keys[key] = true
+		urls[strconv.Itoa(size)] = avatarURL(key)
+	}
+
+	avatar := urls[strconv.Itoa(AvatarSizes[len(AvatarSizes)-1])]
+	if err := requestDB(ctx).Model(&user).Update("avatar", avatar).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to update user avatar"))
+		return
+	}
+
+	removeAvatarObjects(storage, user.ID, keys)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Avatar uploaded successfully",
+		"data": gin.H{
+			"user_id": user.ID,
+			"avatar":  avatar,
+			"sizes":   urls,
+		},
+	})
+}
+
+// DeleteAvatar removes a user's avatar
+// @Summary Delete user avatar
+// @Description Remove the user's avatar images and clear the avatar URL
+// @Tags users
+// @Produce json
+// @Param id path string true "User ID" format(uuid)
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Avatar removed"
+// @Failure 400 {object} map[string]string "Invalid user ID format"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /users/{id}/avatar [delete]
+func DeleteAvatar(ctx *gin.Context) {
+	user, ok := findAvatarUser(ctx)
+	if !ok {
+		return
+	}
+
+	if err := requestDB(ctx).Model(&user).Update("avatar", "").Error; err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to update user avatar"))
+		return
+	}
+
+	if storage, err := services.NewStorageProvider(); err == nil {
+		removeAvatarObjects(storage, user.ID, nil)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Avatar removed successfully",
+	})
+}
+
+// GetAvatar serves a processed avatar image. Avatar URLs are public and immutable.
+// @Summary Get avatar image
+// @Description Serve an avatar image by user ID and file name as returned by the avatar upload
+// @Tags users
+// @Produce jpeg
+// @Param user_id path string true "User ID" format(uuid)
+// @Param file path string true "Avatar file name"
+// @Success 200 {file} binary "Avatar image"
+// @Failure 404 {object} map[string]string "Avatar not found"
+// @Router /avatars/{user_id}/{file} [get]
+func GetAvatar(ctx *gin.Context) {
+	userID, err := uuid.Parse(ctx.Param("user_id"))
+	file := ctx.Param("file")
+	if err != nil || !avatarFilePattern.MatchString(file) {
+		apperrors.Respond(ctx, apperrors.NotFound("Avatar not found"))
+		return
+	}
+
+	storage, err := services.NewStorageProvider()
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Storage service unavailable"))
+		return
+	}
+
+	object, info, err := storage.GetObject(context.Background(), avatarPrefix+userID.String()+"/"+file)
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.NotFound("Avatar not found"))
+		return
+	}
+	defer object.Close()
+
+	ctx.Header("Cache-Control", "public, max-age=31536000, immutable")
+	ctx.Header("ETag", `"`+info.ETag+`"`)
+	ctx.DataFromReader(http.StatusOK, info.Size, "image/jpeg", object, nil)
+}
+
+// findAvatarUser loads the user from the :id path parameter and writes the error response if needed
+func findAvatarUser(ctx *gin.Context) (models.User, bool) {
+	var user models.User
+
+	userID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.BadRequest("Invalid user ID format"))
+		return user, false
+	}
+
+	if err := requestDB(ctx).First(&user, userID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			apperrors.Respond(ctx, apperrors.NotFound("User not found"))
+			return user, false
+		}
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to retrieve user"))
+		return user, false
+	}
+
+	return user, true
+}
+
+// removeAvatarObjects deletes the user's stored avatar images except the ones to keep
+func removeAvatarObjects(storage services.StorageProvider, userID uuid.UUID, keep map[string]bool) {
+	objects, err := storage.ListFolderContents(avatarPrefix + userID.String())
+	if err != nil {
+		log.Printf("⚠️  Failed to list avatars of user %s: %v", userID, err)
+		return
+	}
+
+	for _, key := range objects {
+		if keep[key] {
+			continue
+		}
+		if err := storage.RemoveObject(context.Background(), key); err != nil {
+			log.Printf("⚠️  Failed to remove avatar %s: %v", key, err)
+		}
+	}
+}
+
+func avatarObjectKey(userID uuid.UUID, version string, size int) string {
+	return fmt.Sprintf("%s%s/%s-%d.jpg", avatarPrefix, userID, version, size)
+}
+
+// avatarURL returns the public URL of an avatar object
+func avatarURL(objectKey string) string {
+	baseURL := strings.TrimSuffix(config.GetConfig().AvatarBaseURL, "/")
+	return baseURL + "/" + strings.TrimPrefix(objectKey, avatarPrefix)
+}
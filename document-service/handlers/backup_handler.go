@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"forgecrud-backend/document-service/services"
+	"forgecrud-backend/shared/apperrors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetBackups lists the complete backups
+// @Summary List backups
+// @Description List the complete backups in BACKUP_PATH, the most recent snapshot first, with the time of their database snapshot, whether they are encrypted, the size and checksum of their files and the number of objects they hold, copied and missing. Only callers outside an organization can see them.
+// @Tags backups
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Backups"
+// @Failure 403 {object} map[string]string "Access denied"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /backups [get]
+func GetBackups(ctx *gin.Context) {
+	if !checkPlatformCaller(ctx) {
+		return
+	}
+
+	manager, err := services.NewBackupManager(nil)
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Backups unavailable"))
+		return
+	}
+	backups, err := manager.List()
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to list backups"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    backups,
+	})
+}
+
+// GetBackup returns the manifest of a backup
+// @Summary Get backup
+// @Description Get the manifest of a complete backup, including the keys of the objects that were deleted before they could be copied. Only callers outside an organization can see it.
+// @Tags backups
+// @Produce json
+// @Param id path string true "Backup ID, e.g. 20240131T020000Z"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Backup manifest"
+// @Failure 403 {object} map[string]string "Access denied"
+// @Failure 404 {object} map[string]string "Backup not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /backups/{id} [get]
+func GetBackup(ctx *gin.Context) {
+	if !checkPlatformCaller(ctx) {
+		return
+	}
+
+	manager, err := services.NewBackupManager(nil)
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Backups unavailable"))
+		return
+	}
+	backup, err := manager.Get(ctx.Param("id"))
+	if err != nil {
+		respondBackupError(ctx, err, "Failed to fetch backup")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    backup,
+	})
+}
+
+// CreateBackup queues a backup
+// @Summary Create backup
+// @Description Queue a backup of the database and the document storage. The database is dumped from one snapshot, then the objects not stored by an earlier backup are copied; backups past BACKUP_RETENTION_DAYS are removed afterwards. Backups also run every BACKUP_INTERVAL_HOURS when set. Restoring is only possible with cmd/restore, while the services are stopped. Only callers outside an organization can start backups.
+// @Tags backups
+// @Produce json
+// @Security BearerAuth
+// @Success 202 {object} map[string]interface{} "Backup queued"
+// @Failure 403 {object} map[string]string "Access denied"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /backups [post]
+func CreateBackup(ctx *gin.Context) {
+	if !checkPlatformCaller(ctx) {
+		return
+	}
+
+	if err := services.QueueBackup(ctx.Request.Context()); err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to queue backup"))
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"message": "Backup queued",
+	})
+}
+
+// DeleteBackup removes a backup
+// @Summary Delete backup
+// @Description Remove a backup and the copied objects no other backup uses. Only callers outside an organization can delete backups.
+// @Tags backups
+// @Produce json
+// @Param id path string true "Backup ID, e.g. 20240131T020000Z"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Backup deleted"
+// @Failure 403 {object} map[string]string "Access denied"
+// @Failure 404 {object} map[string]string "Backup not found"
+// @Failure 409 {object} map[string]string "A backup or restore is running"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /backups/{id} [delete]
+func DeleteBackup(ctx *gin.Context) {
+	if !checkPlatformCaller(ctx) {
+		return
+	}
+
+	manager, err := services.NewBackupManager(nil)
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Backups unavailable"))
+		return
+	}
+	if err := manager.Delete(ctx.Request.Context(), ctx.Param("id")); err != nil {
+		respondBackupError(ctx, err, "Failed to delete backup")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Backup deleted",
+	})
+}
+
+func respondBackupError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrBackupNotFound):
+		apperrors.Respond(ctx, apperrors.NotFound("Backup not found"))
+	case errors.Is(err, services.ErrBackupRunning):
+		apperrors.Respond(ctx, apperrors.Conflict("A backup or restore is running, try again once it finished"))
+	default:
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, message))
+	}
+}
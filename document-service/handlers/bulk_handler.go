@@ -0,0 +1,797 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"forgecrud-backend/document-service/services"
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/database/models"
+	"forgecrud-backend/shared/database/models/document"
+	docUtils "forgecrud-backend/shared/utils/document"
+	"forgecrud-backend/shared/utils/validation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// errBulkRejected rolls back an atomic batch when one of its items fails
+var errBulkRejected = errors.New("batch rejected")
+
+// BulkDocumentsRequest represents request body for a batch operation on documents
+type BulkDocumentsRequest struct {
+	DocumentIDs []uuid.UUID `json:"document_ids" binding:"required,min=1,max=500"`
+	Atomic      bool        `json:"atomic"` // Change nothing unless every document can be processed
+}
+
+// BulkTargetDocumentsRequest represents request body for moving or copying documents to a folder
+type BulkTargetDocumentsRequest struct {
+	BulkDocumentsRequest
+	TargetFolderID uuid.UUID `json:"target_folder_id" binding:"required"`
+}
+
+// BulkTagDocumentsRequest represents request body for tagging documents
+type BulkTagDocumentsRequest struct {
+	BulkDocumentsRequest
+	Tags []string `json:"tags" binding:"required"`
+	Mode string   `json:"mode" binding:"omitempty,oneof=add remove replace"` // add (default), remove or replace
+}
+
+// BulkFoldersRequest represents request body for a batch operation on folders
+type BulkFoldersRequest struct {
+	FolderIDs []uuid.UUID `json:"folder_ids" binding:"required,min=1,max=500"`
+	Atomic    bool        `json:"atomic"` // Change nothing unless every folder can be processed
+}
+
+// BulkMoveFoldersRequest represents request body for moving folders to another parent
+type BulkMoveFoldersRequest struct {
+	BulkFoldersRequest
+	TargetParentID *uuid.UUID `json:"target_parent_id"` // null moves the folders to the root
+}
+
+// BulkItemResult is the outcome of one document or folder of a batch request
+type BulkItemResult struct {
+	ID      uuid.UUID   `json:"id"`
+	Success bool        `json:"success"`
+	Status  int         `json:"status"` // Status the single-item endpoint responds with
+	Error   string      `json:"error,omitempty"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// bulkResults holds the results of a batch request in request order. Items without a status are
+// still pending.
+type bulkResults []BulkItemResult
+
+// newBulkResults creates pending results for the IDs, dropping duplicates
+func newBulkResults(ids []uuid.UUID) bulkResults {
+	seen := make(map[uuid.UUID]bool, len(ids))
+	results := make(bulkResults, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			results = append(results, BulkItemResult{ID: id})
+		}
+	}
+	return results
+}
+
+func (r bulkResults) ids() []uuid.UUID {
+	ids := make([]uuid.UUID, len(r))
+	for i, result := range r {
+		ids[i] = result.ID
+	}
+	return ids
+}
+
+func (r bulkResults) pending(i int) bool {
+	return r[i].Status == 0
+}
+
+func (r bulkResults) fail(i, status int, message string) {
+	r[i].Success = false
+	r[i].Status = status
+	r[i].Error = message
+}
+
+func (r bulkResults) succeed(i, status int, data interface{}) {
+	r[i].Success = true
+	r[i].Status = status
+	r[i].Data = data
+}
+
+// failUncommitted fails the pending and succeeded items when the shared transaction of a batch is
+// rolled back
+func (r bulkResults) failUncommitted(message string) {
+	for i := range r {
+		if r.pending(i) || r[i].Success {
+			r.fail(i, http.StatusInternalServerError, message)
+		}
+	}
+}
+
+func (r bulkResults) failed() int {
+	failed := 0
+	for _, result := range r {
+		if result.Status != 0 && !result.Success {
+			failed++
+		}
+	}
+	return failed
+}
+
+// rejectAtomic writes the 409 response when an atomic batch has failed items. The other items
+// are reported as not processed.
+func (r bulkResults) rejectAtomic(ctx *gin.Context, atomic bool) bool {
+	if !atomic || r.failed() == 0 {
+		return false
+	}
+	for i := range r {
+		if r.pending(i) || r[i].Success {
+			r[i] = BulkItemResult{ID: r[i].ID, Status: http.StatusFailedDependency, Error: "Not processed, another item of the atomic batch failed"}
+		}
+	}
+	apperrors.Respond(ctx, apperrors.Conflict("Batch rejected").WithDetails("Nothing was changed because some items failed").With("data", r.summary()))
+	return true
+}
+
+func (r bulkResults) summary() gin.H {
+	failed := r.failed()
+	return gin.H{
+		"succeeded": len(r) - failed,
+		"failed":    failed,
+		"results":   r,
+	}
+}
+
+// respond writes the results, 207 Multi-Status when some items failed
+func (r bulkResults) respond(ctx *gin.Context, message string) {
+	status := http.StatusOK
+	if r.failed() > 0 {
+		status = http.StatusMultiStatus
+	}
+	ctx.JSON(status, gin.H{
+		"success": status == http.StatusOK,
+		"message": message,
+		"data":    r.summary(),
+	})
+}
+
+// loadBulkDocuments loads the documents of a batch and fails the ones that do not exist or the
+// caller lacks the access level on. It writes the 500 response when the documents cannot be loaded.
+func loadBulkDocuments(ctx *gin.Context, db *gorm.DB, results bulkResults, level string) (map[uuid.UUID]*document.Document, bool) {
+	var documents []document.Document
+	if err := db.Preload("Folder").Where("id IN ?", results.ids()).Find(&documents).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to fetch documents"))
+		return nil, false
+	}
+
+	docs := make(map[uuid.UUID]*document.Document, len(documents))
+	for i := range documents {
+		docs[documents[i].ID] = &documents[i]
+	}
+
+	for i, result := range results {
+		doc, ok := docs[result.ID]
+		if !ok {
+			results.fail(i, http.StatusNotFound, "Document not found")
+			continue
+		}
+		denied, err := documentAccessDenied(ctx, doc, level)
+		if err != nil {
+			results.fail(i, http.StatusInternalServerError, "Failed to check access")
+		} else if denied != "" {
+			results.fail(i, http.StatusForbidden, denied)
+		}
+	}
+	return docs, true
+}
+
+// loadBulkFolders loads the folders of a batch and fails the ones that do not exist or the caller
+// lacks the access level on. It writes the 500 response when the folders cannot be loaded.
+func loadBulkFolders(ctx *gin.Context, db *gorm.DB, results bulkResults, level string) (map[uuid.UUID]*document.Folder, bool) {
+	var folderList []document.Folder
+	if err := db.Where("id IN ?", results.ids()).Find(&folderList).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to fetch folders"))
+		return nil, false
+	}
+
+	folders := make(map[uuid.UUID]*document.Folder, len(folderList))
+	for i := range folderList {
+		folders[folderList[i].ID] = &folderList[i]
+	}
+
+	for i, result := range results {
+		folder, ok := folders[result.ID]
+		if !ok {
+			results.fail(i, http.StatusNotFound, "Folder not found")
+			continue
+		}
+		denied, err := folderAccessDenied(ctx, folder, level)
+		if err != nil {
+			results.fail(i, http.StatusInternalServerError, "Failed to check access")
+		} else if denied != "" {
+			results.fail(i, http.StatusForbidden, denied)
+		}
+	}
+	return folders, true
+}
+
+// findBulkTargetFolder loads the target folder of a batch and checks the caller may write to it
+func findBulkTargetFolder(ctx *gin.Context, db *gorm.DB, folderID uuid.UUID) (*document.Folder, bool) {
+	var folder document.Folder
+	if err := db.First(&folder, folderID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			apperrors.Respond(ctx, apperrors.NotFound("Target folder not found"))
+			return nil, false
+		}
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to fetch target folder"))
+		return nil, false
+	}
+	if !checkFolderAccess(ctx, &folder, document.AccessLevelWrite) {
+		return nil, false
+	}
+	return &folder, true
+}
+
+// BulkMoveDocuments moves documents to another folder
+// @Summary Move documents to another folder
+// @Description Move up to 500 documents with their versions to a folder. Each document is reported separately; with atomic nothing is moved unless every document can be moved.
+// @Tags documents
+// @Accept json
+// @Produce json
+// @Param request body BulkTargetDocumentsRequest true "Documents and target folder"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "All documents moved"
+// @Success 207 {object} map[string]interface{} "Some documents failed, see data.results"
+// @Failure 400 {object} map[string]string "Invalid request data"
+// @Failure 403 {object} map[string]string "Access to the target folder denied"
+// @Failure 404 {object} map[string]string "Target folder not found"
+// @Failure 409 {object} map[string]interface{} "Atomic batch rejected"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /documents/bulk/move [post]
+func BulkMoveDocuments(ctx *gin.Context) {
+	var req BulkTargetDocumentsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apperrors.Respond(ctx, validation.Error(err, "Invalid request body"))
+		return
+	}
+
+	db := requestDB(ctx)
+
+	targetFolder, ok := findBulkTargetFolder(ctx, db, req.TargetFolderID)
+	if !ok {
+		return
+	}
+
+	results := newBulkResults(req.DocumentIDs)
+	docs, ok := loadBulkDocuments(ctx, db, results, document.AccessLevelWrite)
+	if !ok {
+		return
+	}
+
+	for i, result := range results {
+		if !results.pending(i) {
+			continue
+		}
+		doc := docs[result.ID]
+		if doc.FolderID == targetFolder.ID {
+			results.fail(i, http.StatusConflict, "Document is already in the target folder")
+		} else if doc.ScanStatus == document.ScanStatusPending || doc.ScanStatus == document.ScanStatusScanning {
+			// The scan worker looks the quarantined file up by its object key
+			results.fail(i, http.StatusConflict, "Document is being scanned for malware, try again shortly")
+		} else if reason, err := services.DocumentMoveBlock(db, doc, targetFolder); err != nil {
+			results.fail(i, http.StatusInternalServerError, "Failed to check legal holds")
+		} else if reason != "" {
+			results.fail(i, http.StatusLocked, reason)
+		}
+	}
+
+	if results.rejectAtomic(ctx, req.Atomic) {
+		return
+	}
+
+	// Documents are moved one by one as their files are moved in storage
+	for i, result := range results {
+		if !results.pending(i) {
+			continue
+		}
+		doc := docs[result.ID]
+		fromPath := doc.Folder.Path
+		if err := moveDocument(db, doc, targetFolder); err != nil {
+			results.fail(i, http.StatusInternalServerError, err.Error())
+			continue
+		}
+		recordDocumentActivity(ctx, doc.ID, document.DocumentActionMove, documentMoveDetails(fromPath, targetFolder.Path))
+		db.Preload("Folder").First(doc, doc.ID)
+		results.succeed(i, http.StatusOK, docUtils.BuildDocumentResponse(doc, db))
+	}
+
+	results.respond(ctx, "Documents moved")
+}
+
+// BulkCopyDocuments copies documents to another folder
+// @Summary Copy documents to another folder
+// @Description Copy up to 500 documents with their latest version to a folder, named "Copy of ..." like single copies. The quota is checked for all copies at once; with atomic nothing is copied unless every document can be copied.
+// @Tags documents
+// @Accept json
+// @Produce json
+// @Param request body BulkTargetDocumentsRequest true "Documents and target folder"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "All documents copied"
+// @Success 207 {object} map[string]interface{} "Some documents failed, see data.results"
+// @Failure 400 {object} map[string]string "Invalid request data"
+// @Failure 402 {object} map[string]interface{} "Document quota exceeded"
+// @Failure 403 {object} map[string]string "Access to the target folder denied"
+// @Failure 404 {object} map[string]string "Target folder not found"
+// @Failure 409 {object} map[string]interface{} "Atomic batch rejected"
+// @Failure 413 {object} map[string]interface{} "Storage quota exceeded"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /documents/bulk/copy [post]
+func BulkCopyDocuments(ctx *gin.Context) {
+	var req BulkTargetDocumentsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apperrors.Respond(ctx, validation.Error(err, "Invalid request body"))
+		return
+	}
+
+	db := requestDB(ctx)
+
+	targetFolder, ok := findBulkTargetFolder(ctx, db, req.TargetFolderID)
+	if !ok {
+		return
+	}
+
+	results := newBulkResults(req.DocumentIDs)
+	docs, ok := loadBulkDocuments(ctx, db, results, document.AccessLevelRead)
+	if !ok {
+		return
+	}
+
+	for i, result := range results {
+		if !results.pending(i) {
+			continue
+		}
+		if status, message := scanStatusError(docs[result.ID].ScanStatus); status != 0 {
+			results.fail(i, status, message)
+		}
+	}
+
+	if results.rejectAtomic(ctx, req.Atomic) {
+		return
+	}
+
+	// The copies count towards the quota together
+	var totalSize int64
+	copies := 0
+	for i, result := range results {
+		if results.pending(i) {
+			totalSize += docs[result.ID].FileSize
+			copies++
+		}
+	}
+	if copies > 0 && !checkFolderQuota(ctx, targetFolder, totalSize, copies) {
+		return
+	}
+
+	for i, result := range results {
+		if !results.pending(i) {
+			continue
+		}
+		doc := docs[result.ID]
+		newFileName := generateCopyName(db, doc.OriginalName, targetFolder.ID)
+		copiedDoc, err := copyDocument(db, doc, targetFolder, newFileName)
+		if err != nil {
+			results.fail(i, http.StatusInternalServerError, err.Error())
+			continue
+		}
+		recordFolderChange(ctx, []uuid.UUID{copiedDoc.ID}, document.FolderChangeAdded)
+		results.succeed(i, http.StatusCreated, gin.H{
+			"id":            copiedDoc.ID,
+			"original_name": copiedDoc.OriginalName,
+			"file_name":     copiedDoc.FileName,
+			"folder_id":     copiedDoc.FolderID,
+			"folder_name":   targetFolder.Name,
+			"file_size":     copiedDoc.FileSize,
+			"created_at":    copiedDoc.CreatedAt,
+		})
+	}
+
+	results.respond(ctx, "Documents copied")
+}
+
+// BulkDeleteDocuments moves documents to the trash
+// @Summary Delete documents
+// @Description Move up to 500 documents to the trash in one transaction. With atomic nothing is deleted unless every document can be deleted.
+// @Tags documents
+// @Accept json
+// @Produce json
+// @Param request body BulkDocumentsRequest true "Documents to delete"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "All documents moved to trash"
+// @Success 207 {object} map[string]interface{} "Some documents failed, see data.results"
+// @Failure 400 {object} map[string]string "Invalid request data"
+// @Failure 409 {object} map[string]interface{} "Atomic batch rejected"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /documents/bulk/delete [post]
+func BulkDeleteDocuments(ctx *gin.Context) {
+	var req BulkDocumentsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apperrors.Respond(ctx, validation.Error(err, "Invalid request body"))
+		return
+	}
+
+	db := requestDB(ctx)
+
+	results := newBulkResults(req.DocumentIDs)
+	docs, ok := loadBulkDocuments(ctx, db, results, document.AccessLevelWrite)
+	if !ok {
+		return
+	}
+
+	for i, result := range results {
+		if !results.pending(i) {
+			continue
+		}
+		reason, err := services.DocumentDeletionBlock(db, docs[result.ID])
+		if err != nil {
+			results.fail(i, http.StatusInternalServerError, "Failed to check retention")
+		} else if reason != "" {
+			results.fail(i, http.StatusLocked, reason)
+		}
+	}
+
+	if results.rejectAtomic(ctx, req.Atomic) {
+		return
+	}
+
+	// Move to the trash, the stored files are removed when the trash is purged
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		for i, result := range results {
+			if !results.pending(i) {
+				continue
+			}
+			if err := moveToTrash(ctx, tx, docs[result.ID]); err != nil {
+				return err
+			}
+			results.succeed(i, http.StatusOK, nil)
+		}
+		return nil
+	}); err != nil {
+		results.failUncommitted("Failed to delete document")
+		results.respond(ctx, "Documents not deleted")
+		return
+	}
+
+	folderIDs := map[uuid.UUID]bool{}
+	for _, result := range results {
+		if result.Success {
+			doc := docs[result.ID]
+			publishDocumentDeleted(ctx, doc)
+			folderIDs[doc.FolderID] = true
+		}
+	}
+	for folderID := range folderIDs {
+		services.QueueFolderStats(folderID)
+	}
+
+	results.respond(ctx, "Documents moved to trash")
+}
+
+// BulkTagDocuments adds, removes or replaces tags of documents
+// @Summary Tag documents
+// @Description Add tags to, remove tags from or replace the tags of up to 500 documents in one transaction. Tags are compared case-insensitively. With atomic nothing is changed unless every document can be tagged.
+// @Tags documents
+// @Accept json
+// @Produce json
+// @Param request body BulkTagDocumentsRequest true "Documents, tags and mode (add, remove, replace)"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "All documents tagged"
+// @Success 207 {object} map[string]interface{} "Some documents failed, see data.results"
+// @Failure 400 {object} map[string]string "Invalid request data"
+// @Failure 409 {object} map[string]interface{} "Atomic batch rejected"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /documents/bulk/tag [post]
+func BulkTagDocuments(ctx *gin.Context) {
+	var req BulkTagDocumentsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apperrors.Respond(ctx, validation.Error(err, "Invalid request body"))
+		return
+	}
+
+	db := requestDB(ctx)
+	tags := docUtils.NormalizeTags(req.Tags)
+	if err := docUtils.ValidateTags(tags); err != nil {
+		apperrors.Respond(ctx, apperrors.BadRequest(err.Error()))
+		return
+	}
+
+	results := newBulkResults(req.DocumentIDs)
+	docs, ok := loadBulkDocuments(ctx, db, results, document.AccessLevelWrite)
+	if !ok {
+		return
+	}
+
+	newTags := make(map[uuid.UUID][]string, len(docs))
+	for i, result := range results {
+		if !results.pending(i) {
+			continue
+		}
+		documentTags := applyTagMode(docs[result.ID].Tags, tags, req.Mode)
+		if err := docUtils.ValidateTags(documentTags); err != nil {
+			results.fail(i, http.StatusBadRequest, err.Error())
+			continue
+		}
+		newTags[result.ID] = documentTags
+	}
+
+	if results.rejectAtomic(ctx, req.Atomic) {
+		return
+	}
+
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		for i, result := range results {
+			if !results.pending(i) {
+				continue
+			}
+			if err := tx.Model(docs[result.ID]).Update("tags", models.StringArray(newTags[result.ID])).Error; err != nil {
+				return err
+			}
+			results.succeed(i, http.StatusOK, gin.H{"tags": newTags[result.ID]})
+		}
+		return nil
+	}); err != nil {
+		results.failUncommitted("Failed to update document")
+		results.respond(ctx, "Documents not tagged")
+		return
+	}
+
+	// Tags are searchable
+	for _, result := range results {
+		if result.Success {
+			if err := services.RefreshSearchVector(db, result.ID); err != nil {
+				fmt.Printf("Warning: Failed to refresh search index: %v\n", err)
+			}
+		}
+	}
+
+	results.respond(ctx, "Documents tagged")
+}
+
+// applyTagMode adds tags to, removes them from or replaces the current tags
+func applyTagMode(current, tags []string, mode string) []string {
+	switch mode {
+	case "replace":
+		return tags
+	case "remove":
+		removed := make(map[string]bool, len(tags))
+		for _, tag := range tags {
+			removed[strings.ToLower(tag)] = true
+		}
+		kept := make([]string, 0, len(current))
+		for _, tag := range current {
+			if !removed[strings.ToLower(tag)] {
+				kept = append(kept, tag)
+			}
+		}
+		return kept
+	default:
+		return docUtils.NormalizeTags(append(current, tags...))
+	}
+}
+
+// BulkMoveFolders moves folders to another parent folder
+// @Summary Move folders to another parent
+// @Description Move up to 500 folders with their contents to a parent folder, or to the root when target_parent_id is null. Each folder is reported separately; with atomic nothing is moved unless every folder can be moved.
+// @Tags folders
+// @Accept json
+// @Produce json
+// @Param request body BulkMoveFoldersRequest true "Folders and target parent folder"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "All folders moved"
+// @Success 207 {object} map[string]interface{} "Some folders failed, see data.results"
+// @Failure 400 {object} map[string]string "Invalid request data"
+// @Failure 403 {object} map[string]string "Access to the target folder denied"
+// @Failure 404 {object} map[string]string "Target folder not found"
+// @Failure 409 {object} map[string]interface{} "Atomic batch rejected"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /folders/bulk/move [post]
+func BulkMoveFolders(ctx *gin.Context) {
+	var req BulkMoveFoldersRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apperrors.Respond(ctx, validation.Error(err, "Invalid request body"))
+		return
+	}
+
+	db := requestDB(ctx)
+
+	var targetParent *document.Folder
+	if req.TargetParentID != nil {
+		var ok bool
+		if targetParent, ok = findBulkTargetFolder(ctx, db, *req.TargetParentID); !ok {
+			return
+		}
+	}
+
+	results := newBulkResults(req.FolderIDs)
+	folders, ok := loadBulkFolders(ctx, db, results, document.AccessLevelWrite)
+	if !ok {
+		return
+	}
+
+	for i, result := range results {
+		if !results.pending(i) {
+			continue
+		}
+		if status, message := folderMoveConflict(db, folders[result.ID], targetParent); status != 0 {
+			results.fail(i, status, message)
+		}
+	}
+
+	if results.rejectAtomic(ctx, req.Atomic) {
+		return
+	}
+
+	// Folders are moved one by one as their files are moved in storage. A folder is reloaded first
+	// in case moving an earlier folder of the batch changed its path.
+	for i, result := range results {
+		if !results.pending(i) {
+			continue
+		}
+		folder := folders[result.ID]
+		if err := db.First(folder, folder.ID).Error; err != nil {
+			results.fail(i, http.StatusNotFound, "Folder not found")
+			continue
+		}
+		if status, message := folderMoveConflict(db, folder, targetParent); status != 0 {
+			results.fail(i, status, message)
+			continue
+		}
+		if err := moveFolder(db, folder, targetParent); err != nil {
+			results.fail(i, http.StatusInternalServerError, err.Error())
+			continue
+		}
+		db.First(folder, folder.ID)
+		results.succeed(i, http.StatusOK, docUtils.BuildFolderResponse(folder))
+	}
+
+	results.respond(ctx, "Folders moved")
+}
+
+// folderMoveConflict returns the status and message of the error when the folder cannot be moved
+// under the target parent, zero when it can
+func folderMoveConflict(db *gorm.DB, folder, targetParent *document.Folder) (int, string) {
+	nameQuery := db.Model(&document.Folder{}).Where("owner_id = ? AND owner_type = ? AND name = ? AND id != ?",
+		folder.OwnerID, folder.OwnerType, folder.Name, folder.ID)
+
+	if targetParent == nil {
+		if folder.ParentID == nil {
+			return http.StatusConflict, "Folder is already in the target location"
+		}
+		nameQuery = nameQuery.Where("parent_id IS NULL")
+	} else {
+		if targetParent.ID == folder.ID {
+			return http.StatusBadRequest, "Cannot move folder to itself"
+		}
+		if targetParent.OwnerID != folder.OwnerID || targetParent.OwnerType != folder.OwnerType {
+			return http.StatusBadRequest, "Target parent folder must have the same owner"
+		}
+		if isSubfolderOf(db, targetParent.ID, folder.ID) {
+			return http.StatusBadRequest, "Cannot move folder to its own subfolder"
+		}
+		if folder.ParentID != nil && *folder.ParentID == targetParent.ID {
+			return http.StatusConflict, "Folder is already in the target location"
+		}
+		nameQuery = nameQuery.Where("parent_id = ?", targetParent.ID)
+	}
+
+	var count int64
+	nameQuery.Count(&count)
+	if count > 0 {
+		return http.StatusConflict, "A folder with this name already exists in the target directory"
+	}
+
+	reason, err := services.FolderMoveBlock(db, folder, targetParent)
+	if err != nil {
+		return http.StatusInternalServerError, "Failed to check legal holds"
+	}
+	if reason != "" {
+		return http.StatusLocked, reason
+	}
+	return 0, ""
+}
+
+// BulkDeleteFolders moves empty folders to the trash
+// @Summary Delete folders
+// @Description Move up to 500 folders to the trash in one transaction. A folder must be empty apart from subfolders deleted in the same batch; subfolders are deleted first. With atomic nothing is deleted unless every folder can be deleted.
+// @Tags folders
+// @Accept json
+// @Produce json
+// @Param request body BulkFoldersRequest true "Folders to delete"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "All folders moved to trash"
+// @Success 207 {object} map[string]interface{} "Some folders failed, see data.results"
+// @Failure 400 {object} map[string]string "Invalid request data"
+// @Failure 409 {object} map[string]interface{} "Atomic batch rejected"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /folders/bulk/delete [post]
+func BulkDeleteFolders(ctx *gin.Context) {
+	var req BulkFoldersRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apperrors.Respond(ctx, validation.Error(err, "Invalid request body"))
+		return
+	}
+
+	db := requestDB(ctx)
+
+	results := newBulkResults(req.FolderIDs)
+	folders, ok := loadBulkFolders(ctx, db, results, document.AccessLevelWrite)
+	if !ok {
+		return
+	}
+
+	if results.rejectAtomic(ctx, req.Atomic) {
+		return
+	}
+
+	// Delete the deepest folders first so their parents are empty when they are deleted
+	order := make([]int, 0, len(results))
+	for i := range results {
+		if results.pending(i) {
+			order = append(order, i)
+		}
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return strings.Count(folders[results[order[a]].ID].Path, "/") > strings.Count(folders[results[order[b]].ID].Path, "/")
+	})
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, i := range order {
+			folder := folders[results[i].ID]
+
+			var subfolderCount, documentCount int64
+			tx.Model(&document.Folder{}).Where("parent_id = ?", folder.ID).Count(&subfolderCount)
+			tx.Model(&document.Document{}).Where("folder_id = ?", folder.ID).Count(&documentCount)
+			reason, err := services.FolderDeletionBlock(tx, folder)
+			if err != nil {
+				return err
+			}
+			if subfolderCount > 0 {
+				results.fail(i, http.StatusConflict, "Cannot delete folder that contains subfolders")
+			} else if documentCount > 0 {
+				results.fail(i, http.StatusConflict, "Cannot delete folder that contains documents")
+			} else if reason != "" {
+				results.fail(i, http.StatusLocked, reason)
+			} else if err := moveToTrash(ctx, tx, folder); err != nil {
+				return err
+			} else {
+				results.succeed(i, http.StatusOK, nil)
+				continue
+			}
+			if req.Atomic {
+				return errBulkRejected
+			}
+		}
+		return nil
+	})
+	if err == errBulkRejected {
+		results.rejectAtomic(ctx, true)
+		return
+	}
+	if err != nil {
+		results.failUncommitted("Failed to delete folder")
+		results.respond(ctx, "Folders not deleted")
+		return
+	}
+
+	for _, result := range results {
+		if result.Success {
+			publishFolderDeleted(ctx, folders[result.ID])
+		}
+	}
+
+	results.respond(ctx, "Folders moved to trash")
+}
@@ -2,18 +2,28 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
 	"net/http"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
 	"forgecrud-backend/document-service/services"
-	"forgecrud-backend/shared/clients"
+	"forgecrud-backend/shared/apperrors"
 	"forgecrud-backend/shared/database"
 	"forgecrud-backend/shared/database/models"
 	"forgecrud-backend/shared/database/models/document"
+	"forgecrud-backend/shared/ids"
+	"forgecrud-backend/shared/messaging"
+	utils "forgecrud-backend/shared/utils/auth"
 	docUtils "forgecrud-backend/shared/utils/document"
+	"forgecrud-backend/shared/utils/query"
+	"forgecrud-backend/shared/utils/validation"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -22,180 +32,310 @@ import (
 
 // UploadDocument uploads a new document
 // @Summary Upload a new document
-// @Description Upload a new document to a specified folder
+// @Description Upload a new document to a specified folder. With extract=true a ZIP archive is expanded into the folder instead, recreating its folders and creating a document for every file; the archive is rejected when it has more than ARCHIVE_MAX_ENTRIES files, is nested deeper than ARCHIVE_MAX_DEPTH folders, expands to more than ARCHIVE_MAX_EXTRACTED_BYTES or has entries pointing outside the folder.
+// @Description The MIME type is sniffed from the content, not taken from the client, and the file must pass the platform's blocked types and the file type policy of the folder's organization; archive entries that do not are skipped or reported as failed.
 // @Tags documents
 // @Accept multipart/form-data
 // @Produce json
 // @Param folder_id formData string true "Folder ID where the document will be uploaded"
-// @Param user_id formData string false "User ID (for testing purposes)"
+// @Param user_id formData string false "Uploader when the request does not come through the gateway"
 // @Param file formData file true "Document file to upload"
-// @Param tags formData string false "Document tags"
+// @Param tags formData string false "Comma separated document tags"
 // @Param description formData string false "Document description"
+// @Param metadata formData string false "JSON object with values of the folder's metadata fields"
+// @Param extract formData bool false "Expand the uploaded ZIP archive into the folder"
 // @Security BearerAuth
-// @Success 201 {object} map[string]interface{} "Document uploaded successfully"
-// @Failure 400 {object} map[string]string "Invalid request data"
+// @Success 201 {object} map[string]interface{} "Document uploaded successfully, or the created folders and documents of an extracted archive"
+// @Failure 400 {object} map[string]string "Invalid request data or archive"
+// @Failure 402 {object} map[string]interface{} "Document quota exceeded"
+// @Failure 403 {object} map[string]string "Access denied"
 // @Failure 404 {object} map[string]string "Folder not found"
+// @Failure 413 {object} map[string]interface{} "Storage quota or size limit of the file type exceeded"
+// @Failure 415 {object} map[string]interface{} "File type not allowed"
 // @Failure 500 {object} map[string]string "Server error"
 // @Router /documents [post]
 func UploadDocument(ctx *gin.Context) {
-	db := database.GetDB()
+	db := requestDB(ctx)
+
+	// The uploader is the caller forwarded by the gateway
+	uploadedBy := requestUserID(ctx, ctx.PostForm("user_id"))
+	if uploadedBy == nil {
+		apperrors.Respond(ctx, apperrors.BadRequest("user_id is required"))
+		return
+	}
 
 	// Get folder ID
 	folderID := ctx.PostForm("folder_id")
 	if folderID == "" {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "folder_id is required"})
+		apperrors.Respond(ctx, apperrors.BadRequest("folder_id is required"))
 		return
 	}
 
 	// Validate folder exists
 	var folder document.Folder
 	if err := db.First(&folder, "id = ?", folderID).Error; err != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{"error": "Folder not found"})
+		apperrors.Respond(ctx, apperrors.NotFound("Folder not found"))
+		return
+	}
+
+	if !checkFolderAccess(ctx, &folder, document.AccessLevelWrite) {
 		return
 	}
 
 	// Get file from request
 	file, header, err := ctx.Request.FormFile("file")
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "File is required"})
+		apperrors.Respond(ctx, apperrors.BadRequest("File is required"))
 		return
 	}
 	defer file.Close()
 
 	// Validate file
 	if err := docUtils.ValidateUploadedFile(header); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apperrors.Respond(ctx, apperrors.BadRequest(err.Error()))
 		return
 	}
 
-	// Calculate checksum
-	checksum, err := docUtils.CalculateFileChecksum(file)
+	// Tags and metadata apply to every document of an extracted archive
+	tags := docUtils.ParseTags(ctx.PostForm("tags"))
+	if err := docUtils.ValidateTags(tags); err != nil {
+		apperrors.Respond(ctx, apperrors.BadRequest(err.Error()))
+		return
+	}
+	metadata, err := docUtils.ParseMetadata(ctx.PostForm("metadata"))
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate checksum"})
+		apperrors.Respond(ctx, apperrors.BadRequest(err.Error()))
 		return
 	}
 
-	// Reset file pointer after checksum calculation
-	file.Seek(0, 0)
-
-	// Calculate next version for this filename in this folder
-	version := 1
-	var existingDoc document.Document
-	if err := db.Where("folder_id = ? AND file_name = ?", folderID, header.Filename).First(&existingDoc).Error; err == nil {
-		// Same filename exists, get max version
-		var maxVersion int
-		db.Model(&document.DocumentVersion{}).
-			Where("document_id = ?", existingDoc.ID).
-			Select("COALESCE(MAX(version), 0)").
-			Scan(&maxVersion)
-		version = maxVersion + 1
+	// ZIP archives are expanded into the folder on request
+	if ctx.PostForm("extract") == "true" {
+		extractArchive(ctx, &folder, file, header, *uploadedBy, tags, metadata)
+		return
 	}
 
-	// Generate paths
-	minioPath := docUtils.GenerateMinIOPath(folder.Path, header.Filename, version)
-	displayPath := docUtils.GenerateDisplayPath(folder.Path, header.Filename, version)
-
-	// Upload to MinIO
-	minioService, err := services.NewMinIOService()
+	fields, err := folderMetadataFields(db, &folder)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Storage service unavailable"})
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to fetch metadata fields"))
 		return
 	}
-
-	if err := minioService.UploadFile(context.Background(), file, header.Filename, folder.Path, header.Size); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload file"})
+	validMetadata, err := docUtils.ValidateMetadata(fields, metadata)
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid metadata"))
 		return
 	}
 
-	// Create document record
-	doc := document.Document{
-		ID:            uuid.New(),
-		FileName:      header.Filename,
-		OriginalName:  header.Filename,
-		Path:          displayPath,
-		FileSize:      header.Size,
-		MimeType:      header.Header.Get("Content-Type"),
-		FileExtension: filepath.Ext(header.Filename),
-		FolderID:      uuid.MustParse(folderID),
-		UploadedBy:    uuid.MustParse(ctx.PostForm("user_id")),
-		ObjectKey:     minioPath,
-		Checksum:      checksum,
-		Tags:          ctx.PostForm("tags"),
-		Description:   ctx.PostForm("description"),
+	// The client's Content-Type is not trusted, the type is sniffed from the content
+	mimeType, err := docUtils.DetectFileType(file, header.Filename)
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to read file"))
+		return
+	}
+	header.Header.Set("Content-Type", mimeType)
+	if !checkFileType(ctx, &folder, header.Filename, mimeType, header.Size) {
+		return
 	}
 
-	if err := db.Create(&doc).Error; err != nil {
-		// Cleanup MinIO file
-		minioService.RemoveFile(context.Background(), header.Filename, folder.Path)
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save document"})
+	// Enforce the owning organization's storage and document quota
+	if !checkFolderQuota(ctx, &folder, header.Size, 1) {
 		return
 	}
 
-	// Create version record
-	docVersion := document.DocumentVersion{
-		ID:         uuid.New(),
-		DocumentID: doc.ID,
-		Version:    version,
-		ObjectKey:  minioPath,
-		FileSize:   header.Size,
-		Checksum:   checksum,
-		CreatedBy:  doc.UploadedBy,
+	storage, err := services.NewStorageProvider()
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Storage service unavailable"))
+		return
 	}
 
-	if err := db.Create(&docVersion).Error; err != nil {
-		fmt.Printf("Warning: Failed to create version record: %v\n", err)
+	doc, err := createDocument(db, storage, &folder, file, header, *uploadedBy, tags, ctx.PostForm("description"), validMetadata)
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Internal(err))
+		return
 	}
 
 	// Update folder statistics after successful upload
-	if err := updateFolderStats(db, uuid.MustParse(folderID)); err != nil {
-		fmt.Printf("Warning: Failed to update folder stats: %v\n", err)
-	}
+	services.QueueFolderStats(folder.ID)
+	recordDocumentActivity(ctx, doc.ID, document.DocumentActionUpload, "")
+	recordFolderChange(ctx, []uuid.UUID{doc.ID}, document.FolderChangeAdded)
 
 	// Load folder info for response
-	db.Preload("Folder").First(&doc, doc.ID)
+	db.Preload("Folder").First(doc, doc.ID)
+
+	docResponse := docUtils.BuildDocumentResponse(doc, db)
+	database.EnqueueWebhookEvent(models.WebhookEventDocumentUploaded, docResponse)
+	messaging.Publish(ctx.Request.Context(), messaging.EventDocumentUploaded, utils.GetActorID(ctx), docResponse)
 
 	ctx.JSON(http.StatusCreated, gin.H{
 		"success": true,
 		"message": "Document uploaded successfully",
-		"data":    docUtils.BuildDocumentResponse(&doc, db),
+		"data":    docResponse,
 	})
 }
 
-// GetDocuments lists documents in a folder
-// @Summary Get documents in a folder
-// @Description Retrieve all documents in a specified folder
+// GetDocuments lists documents
+// @Summary Get documents
+// @Description List the documents the caller may read with pagination, filtering, sorting and search, optionally limited to a folder
 // @Tags documents
 // @Accept json
 // @Produce json
-// @Param folder_id query string true "Folder ID to list documents from"
+// @Param folder_id query string false "Folder ID to list documents from"
+// @Param page query int false "Page number (default: 1)"
+// @Param limit query int false "Items per page (default: 10, max: 100)"
+// @Param search query string false "Search term across name, tags and description"
+// @Param filters[mime_type] query string false "Filter by MIME type, e.g. filters[mime_type][in]=image/png,image/jpeg"
+// @Param filters[extension] query string false "Filter by file extension, e.g. .pdf"
+// @Param filters[size] query string false "Filter by size in bytes, e.g. filters[size][gte]=1024&filters[size][lt]=1048576"
+// @Param filters[uploaded_by] query string false "Filter by uploader ID"
+// @Param filters[created_at] query string false "Filter by upload date, e.g. filters[created_at][gte]=2025-01-01"
+// @Param filters[updated_at] query string false "Filter by last update date"
+// @Param filters[scan_status] query string false "Filter by malware scan status"
+// @Param filters[metadata.key] query string false "Filter by a metadata field of the folder given in folder_id, e.g. filters[metadata.invoice_date][gte]=2025-01-01"
+// @Param tags query string false "Comma separated tags the documents must all have"
+// @Param sort[field] query string false "Sort field (name, size, mime_type, extension, created_at, updated_at)"
+// @Param sort[order] query string false "Sort order (asc, desc)"
+// @Param pagination query string false "Set to cursor for keyset pagination (ordered by created_at, no total count)"
+// @Param cursor query string false "Opaque cursor from pagination.next_cursor of the previous page"
+// @Param fields query string false "Comma separated fields to return, e.g. id,name,size (default: all)"
+// @Param expand query string false "Comma separated relations to embed: folder (default: folder; empty for none)"
 // @Security BearerAuth
-// @Success 200 {object} map[string]interface{} "List of documents"
-// @Failure 400 {object} map[string]string "Missing or invalid folder_id"
+// @Success 200 {object} map[string]interface{} "List of documents with pagination"
+// @Failure 400 {object} map[string]string "Invalid folder_id or cursor"
 // @Failure 500 {object} map[string]string "Server error"
 // @Router /documents [get]
 func GetDocuments(ctx *gin.Context) {
-	db := database.GetDB()
+	db := requestDB(ctx)
+
+	params := query.ParseQueryParams(ctx)
+
+	// Response fields mapped to their columns
+	allowedFields := map[string]string{
+		"name":          "file_name",
+		"original_name": "original_name",
+		"path":          "path",
+		"size":          "file_size",
+		"mime_type":     "mime_type",
+		"extension":     "file_extension",
+		"owner_id":      "uploaded_by",
+		"tags":          "tags",
+		"metadata":      "metadata",
+		"description":   "description",
+		"scan_status":   "scan_status",
+		"index_status":  "index_status",
+		"created_at":    "created_at",
+		"updated_at":    "updated_at",
+	}
+	allowedExpand := map[string]string{
+		"folder": "Folder",
+	}
+
+	// Define allowed filter fields
+	allowedFilters := map[string]string{
+		"folder_id":   "documents.folder_id",
+		"mime_type":   "documents.mime_type",
+		"extension":   "documents.file_extension",
+		"size":        "documents.file_size",
+		"uploaded_by": "documents.uploaded_by",
+		"scan_status": "documents.scan_status",
+		"created_at":  "documents.created_at",
+		"updated_at":  "documents.updated_at",
+	}
+
+	// Define allowed sort fields
+	allowedSortFields := map[string]string{
+		"name":       "documents.original_name",
+		"size":       "documents.file_size",
+		"mime_type":  "documents.mime_type",
+		"extension":  "documents.file_extension",
+		"created_at": "documents.created_at",
+		"updated_at": "documents.updated_at",
+	}
+
+	// Define search fields
+	searchFields := []string{"documents.original_name", "documents.file_name", "documents.tags", "documents.description"}
+
+	dbQuery := readableDocuments(ctx, db.Model(&document.Document{}))
+	dbQuery = filterByTags(dbQuery, ctx.Query("tags"))
+
+	if folderID := ctx.Query("folder_id"); folderID != "" {
+		folderUUID, err := uuid.Parse(folderID)
+		if err != nil {
+			apperrors.Respond(ctx, apperrors.BadRequest("Invalid folder_id format"))
+			return
+		}
+		dbQuery = dbQuery.Where("documents.folder_id = ?", folderUUID)
 
-	folderID := ctx.Query("folder_id")
-	if folderID == "" {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "folder_id is required"})
-		return
+		// The metadata fields of the folder are filterable as filters[metadata.<key>]
+		var folder document.Folder
+		if err := db.First(&folder, "id = ?", folderUUID).Error; err == nil {
+			fields, err := folderMetadataFields(db, &folder)
+			if err != nil {
+				apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to fetch metadata fields"))
+				return
+			}
+			for _, field := range fields {
+				if docUtils.MetadataKeyPattern.MatchString(field.Key) {
+					allowedFilters["metadata."+field.Key] = docUtils.MetadataColumn("documents", field)
+				}
+			}
+		}
 	}
 
+	// Apply filters and search
+	dbQuery = query.ApplyFilters(dbQuery, params.Filters, allowedFilters)
+	dbQuery = query.ApplySearch(dbQuery, params.Search, searchFields)
+
+	// Keyset pagination skips the total count, which gets expensive on large tables
+	var total int64
+	if params.CursorMode {
+		cursorQuery, err := query.ApplyCursor(dbQuery, params, "documents")
+		if err != nil {
+			apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid cursor"))
+			return
+		}
+		dbQuery = cursorQuery
+	} else {
+		if err := dbQuery.Count(&total).Error; err != nil {
+			apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to count documents"))
+			return
+		}
+		dbQuery = query.ApplySort(dbQuery, params.Sort, allowedSortFields)
+		dbQuery = query.ApplyPagination(dbQuery, params.Page, params.Limit)
+	}
+
+	relations := query.ResolveExpand(params.Expand, allowedExpand, "folder")
+	dbQuery = query.ApplyExpand(dbQuery, relations, allowedExpand)
+	// created_at is kept for the cursor of the next page
+	dbQuery = query.ApplyFieldSelection(dbQuery, params.Fields, allowedFields, "id", "folder_id", "created_at")
+
 	var documents []document.Document
-	if err := db.Preload("Folder").Where("folder_id = ?", folderID).Find(&documents).Error; err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch documents"})
+	if err := dbQuery.Find(&documents).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to fetch documents"))
 		return
 	}
 
-	var response []docUtils.DocumentResponse
+	var pagination interface{}
+	if params.CursorMode {
+		documents, pagination = query.TrimCursorPage(documents, params.Limit, func(doc document.Document) (time.Time, string) {
+			return doc.CreatedAt, doc.ID.String()
+		})
+	} else {
+		pagination = query.BuildPaginationResponse(params.Page, params.Limit, total)
+	}
+
+	response := []docUtils.DocumentResponse{}
 	for _, doc := range documents {
-		response = append(response, docUtils.BuildDocumentResponse(&doc, db))
+		docResponse := docUtils.BuildDocumentResponse(&doc, db)
+		if doc.Folder.ID != uuid.Nil {
+			folderResponse := docUtils.BuildFolderResponse(&doc.Folder)
+			docResponse.Folder = &folderResponse
+		}
+		response = append(response, docResponse)
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data":    response,
+		"success":    true,
+		"data":       query.SparseFieldset(response, params.Fields, relations),
+		"pagination": pagination,
 	})
 }
 
@@ -209,20 +349,28 @@ func GetDocuments(ctx *gin.Context) {
 // @Security BearerAuth
 // @Success 200 {object} map[string]interface{} "Document details"
 // @Failure 400 {object} map[string]string "Invalid document ID format"
+// @Failure 403 {object} map[string]string "Access denied"
 // @Failure 404 {object} map[string]string "Document not found"
 // @Failure 500 {object} map[string]string "Server error"
 // @Router /documents/{id} [get]
 func GetDocument(ctx *gin.Context) {
-	db := database.GetDB()
+	db := requestDB(ctx)
 
 	documentID := ctx.Param("id")
 
 	var doc document.Document
 	if err := db.Preload("Folder").First(&doc, "id = ?", documentID).Error; err != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
+		apperrors.Respond(ctx, apperrors.NotFound("Document not found"))
+		return
+	}
+
+	if !checkDocumentAccess(ctx, &doc, document.AccessLevelRead) {
 		return
 	}
 
+	recordDocumentAccess(ctx, doc.ID)
+	recordDocumentActivity(ctx, doc.ID, document.DocumentActionView, "")
+
 	ctx.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"data":    docUtils.BuildDocumentResponse(&doc, db),
@@ -231,77 +379,131 @@ func GetDocument(ctx *gin.Context) {
 
 // DownloadDocument downloads a document file
 // @Summary Download document file
-// @Description Download the actual file content of a document
+// @Description Download the actual file content of a document. Single byte ranges (Range header) are answered with 206 so media players can seek and interrupted downloads can resume.
 // @Tags documents
 // @Accept json
 // @Produce application/octet-stream
 // @Param id path string true "Document ID" format(uuid)
+// @Param inline query bool false "Display in the browser instead of saving (Content-Disposition inline); only for video, audio, images other than SVG and PDF"
+// @Param Range header string false "Byte range, e.g. bytes=0-1023"
 // @Security BearerAuth
 // @Success 200 {file} file "Document file content"
+// @Success 206 {file} file "Requested byte range"
 // @Failure 400 {object} map[string]string "Invalid document ID format"
+// @Failure 403 {object} map[string]string "Access denied"
 // @Failure 404 {object} map[string]string "Document not found"
+// @Failure 416 {object} map[string]string "Range not satisfiable"
 // @Failure 500 {object} map[string]string "Server error or storage unavailable"
 // @Router /documents/{id}/download [get]
 func DownloadDocument(ctx *gin.Context) {
-	db := database.GetDB()
+	db := requestDB(ctx)
 
 	documentID := ctx.Param("id")
 
 	var doc document.Document
 	if err := db.Preload("Folder").First(&doc, "id = ?", documentID).Error; err != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
+		apperrors.Respond(ctx, apperrors.NotFound("Document not found"))
+		return
+	}
+
+	if !checkDocumentAccess(ctx, &doc, document.AccessLevelRead) {
+		return
+	}
+
+	if !checkScanStatus(ctx, doc.ScanStatus) {
+		return
+	}
+
+	ctx.Header("Content-Disposition", docUtils.ContentDisposition(doc.OriginalName, doc.MimeType, ctx.Query("inline") == "true"))
+
+	if !isSeekRequest(ctx) {
+		recordDocumentAccess(ctx, doc.ID)
+		recordDocumentActivity(ctx, doc.ID, document.DocumentActionDownload, "")
+	}
+
+	serveStoredFile(ctx, docUtils.StorageKey(doc.ObjectKey, doc.ContentHash), doc.FileSize, doc.MimeType, doc.Checksum)
+}
+
+// serveStoredFile streams a stored file. A single byte range in the Range header is answered with 206
+// and only that range is read from storage. The checksum is the ETag, so If-Range requests for a file
+// that has changed get the whole file.
+func serveStoredFile(ctx *gin.Context, storageKey string, size int64, mimeType, checksum string) {
+	etag := fmt.Sprintf("\"%s\"", checksum)
+	// Browsers must not guess a type that can run scripts from the stored content
+	ctx.Header("X-Content-Type-Options", "nosniff")
+	ctx.Header("Accept-Ranges", "bytes")
+	ctx.Header("ETag", etag)
+
+	rangeHeader := ctx.GetHeader("Range")
+	if ifRange := ctx.GetHeader("If-Range"); ifRange != "" && ifRange != etag {
+		rangeHeader = ""
+	}
+
+	byteRange, err := docUtils.ParseRange(rangeHeader, size)
+	if err != nil {
+		ctx.Header("Content-Range", fmt.Sprintf("bytes */%d", size))
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeRangeNotSatisfiable, "Range not satisfiable"))
 		return
 	}
 
-	// Download from MinIO
-	minioService, err := services.NewMinIOService()
+	storage, err := services.NewStorageProvider()
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Storage service unavailable"})
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Storage service unavailable"))
 		return
 	}
 
-	fileName := filepath.Base(doc.ObjectKey)
-	folderPath := filepath.Dir(doc.ObjectKey)
+	if byteRange == nil {
+		fileReader, _, err := storage.GetObject(ctx.Request.Context(), storageKey)
+		if err != nil {
+			apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to download file"))
+			return
+		}
+		defer fileReader.Close()
+
+		ctx.DataFromReader(http.StatusOK, size, mimeType, fileReader, nil)
+		return
+	}
 
-	fileReader, err := minioService.DownloadFile(context.Background(), fileName, folderPath)
+	fileReader, err := storage.GetObjectRange(ctx.Request.Context(), storageKey, byteRange.Start, byteRange.End)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to download file"})
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to download file"))
 		return
 	}
 	defer fileReader.Close()
 
-	// Set response headers
-	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", doc.OriginalName))
-	ctx.Header("Content-Type", doc.MimeType)
-	ctx.Header("Content-Length", fmt.Sprintf("%d", doc.FileSize))
-
-	// Stream file to response
-	ctx.DataFromReader(http.StatusOK, doc.FileSize, doc.MimeType, fileReader, nil)
+	ctx.Header("Content-Range", byteRange.ContentRange(size))
+	ctx.DataFromReader(http.StatusPartialContent, byteRange.Length(), mimeType, fileReader, nil)
 }
 
 // UpdateDocument updates document metadata
 // @Summary Update document metadata
-// @Description Update document tags and description
+// @Description Update document tags, description and metadata values, validated against the metadata fields of the folder
 // @Tags documents
 // @Accept multipart/form-data
 // @Produce json
 // @Param id path string true "Document ID" format(uuid)
-// @Param tags formData string false "Updated document tags"
+// @Param tags formData string false "Updated comma separated document tags"
 // @Param description formData string false "Updated document description"
+// @Param metadata formData string false "JSON object merged into the metadata, null removes a value"
 // @Security BearerAuth
 // @Success 200 {object} map[string]interface{} "Document updated successfully"
-// @Failure 400 {object} map[string]string "Invalid document ID format"
+// @Failure 400 {object} map[string]string "Invalid tags or metadata"
+// @Failure 403 {object} map[string]string "Access denied"
 // @Failure 404 {object} map[string]string "Document not found"
 // @Failure 500 {object} map[string]string "Server error"
 // @Router /documents/{id} [put]
 func UpdateDocument(ctx *gin.Context) {
-	db := database.GetDB()
+	db := requestDB(ctx)
 
 	documentID := ctx.Param("id")
 
 	var doc document.Document
 	if err := db.Preload("Folder").First(&doc, "id = ?", documentID).Error; err != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
+		apperrors.Respond(ctx, apperrors.NotFound("Document not found"))
+		return
+	}
+
+	if !checkDocumentAccess(ctx, &doc, document.AccessLevelWrite) {
 		return
 	}
 
@@ -309,18 +511,61 @@ func UpdateDocument(ctx *gin.Context) {
 	updateData := map[string]interface{}{}
 
 	if tags := ctx.PostForm("tags"); tags != "" {
-		updateData["tags"] = tags
+		parsedTags := docUtils.ParseTags(tags)
+		if err := docUtils.ValidateTags(parsedTags); err != nil {
+			apperrors.Respond(ctx, apperrors.BadRequest(err.Error()))
+			return
+		}
+		updateData["tags"] = models.StringArray(parsedTags)
 	}
 
 	if description := ctx.PostForm("description"); description != "" {
 		updateData["description"] = description
 	}
 
+	if value := ctx.PostForm("metadata"); value != "" {
+		metadata, err := docUtils.ParseMetadata(value)
+		if err != nil {
+			apperrors.Respond(ctx, apperrors.BadRequest(err.Error()))
+			return
+		}
+
+		fields, err := folderMetadataFields(db, &doc.Folder)
+		if err != nil {
+			apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to fetch metadata fields"))
+			return
+		}
+
+		// Merged into the current values, null removes a value. Values of fields that no longer
+		// apply to the folder are dropped.
+		merged := map[string]interface{}{}
+		for _, field := range fields {
+			if value, exists := doc.Metadata[field.Key]; exists {
+				merged[field.Key] = value
+			}
+		}
+		for key, value := range metadata {
+			merged[key] = value
+		}
+
+		validMetadata, err := docUtils.ValidateMetadata(fields, merged)
+		if err != nil {
+			apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid metadata"))
+			return
+		}
+		updateData["metadata"] = validMetadata
+	}
+
 	if len(updateData) > 0 {
 		if err := db.Model(&doc).Updates(updateData).Error; err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update document"})
+			apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to update document"))
 			return
 		}
+
+		// Tags and description are searchable
+		if err := services.RefreshSearchVector(db, doc.ID); err != nil {
+			fmt.Printf("Warning: Failed to refresh search index: %v\n", err)
+		}
 	}
 
 	// Reload document
@@ -335,104 +580,53 @@ func UpdateDocument(ctx *gin.Context) {
 
 // DeleteDocument deletes a document
 // @Summary Delete a document
-// @Description Delete a document and all its versions from storage and database
+// @Description Move a document to the trash. It can be restored until it is purged with its versions after TRASH_RETENTION_DAYS. Documents under legal hold or younger than the minimum retention of their folders cannot be deleted.
 // @Tags documents
 // @Accept json
 // @Produce json
 // @Param id path string true "Document ID" format(uuid)
 // @Security BearerAuth
-// @Success 200 {object} map[string]interface{} "Document deleted successfully"
+// @Success 200 {object} map[string]interface{} "Document moved to trash"
 // @Failure 400 {object} map[string]string "Invalid document ID format"
+// @Failure 403 {object} map[string]string "Access denied"
 // @Failure 404 {object} map[string]string "Document not found"
+// @Failure 423 {object} map[string]string "Document under legal hold or retention"
 // @Failure 500 {object} map[string]string "Server error"
 // @Router /documents/{id} [delete]
 func DeleteDocument(ctx *gin.Context) {
-	db := database.GetDB()
+	db := requestDB(ctx)
 
 	documentID := ctx.Param("id")
 
 	var doc document.Document
 	if err := db.First(&doc, "id = ?", documentID).Error; err != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
+		apperrors.Respond(ctx, apperrors.NotFound("Document not found"))
 		return
 	}
 
-	// Delete from MinIO
-	minioService, err := services.NewMinIOService()
-	if err == nil {
-		var versions []document.DocumentVersion
-		if err := db.Where("document_id = ?", doc.ID).Find(&versions).Error; err == nil {
-			for _, version := range versions {
-				if version.ObjectKey != "" {
-					fileName := filepath.Base(version.ObjectKey)
-					folderPath := filepath.Dir(version.ObjectKey)
-					minioService.RemoveFile(context.Background(), fileName, folderPath)
-				}
-			}
-		}
-
-		// Delete main file if exists
-		if doc.ObjectKey != "" {
-			fileName := filepath.Base(doc.ObjectKey)
-			folderPath := filepath.Dir(doc.ObjectKey)
-			minioService.RemoveFile(context.Background(), fileName, folderPath)
-		}
+	if !checkDocumentAccess(ctx, &doc, document.AccessLevelWrite) {
+		return
 	}
 
-	if err := db.Delete(&doc).Error; err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete document"})
+	if !checkDocumentDeletable(ctx, db, &doc) {
 		return
 	}
 
-	// After successful deletion, get user info for notification
-	var user models.User
-	if err := db.Where("id = ?", doc.Folder.OwnerID).First(&user).Error; err != nil {
-		fmt.Printf("Warning: Could not fetch user info for notification: %v\n", err)
-	} else {
-		notificationClient := clients.NewNotificationClient()
-
-		go func() {
-			err := notificationClient.SendUserActionEmail(clients.UserActionEmailRequest{
-				AdminName:    "System Admin",
-				UserName:     fmt.Sprintf("%s %s", user.FirstName, user.LastName),
-				UserEmail:    user.Email,
-				UserRole:     "",
-				IPAddress:    ctx.ClientIP(),
-				ActionType:   "Document Deletion",
-				ResourceName: doc.OriginalName,
-				Status:       "Completed",
-				Priority:     "medium",
-				PriorityText: "Medium",
-				Description:  fmt.Sprintf("Document '%s' (%.2f KB) deleted from folder", doc.OriginalName, float64(doc.FileSize)/1024),
-				Changes: []clients.UserActionChange{
-					{
-						Field:    "Document Status",
-						OldValue: "Active",
-						NewValue: "Deleted",
-					},
-					{
-						Field:    "File Size",
-						OldValue: fmt.Sprintf("%d bytes", doc.FileSize),
-						NewValue: "0 bytes",
-					},
-				},
-				Timestamp: time.Now().Format(time.RFC3339),
-			})
-
-			if err != nil {
-				fmt.Printf("Warning: Failed to send document deletion notification: %v\n", err)
-			}
-		}()
+	// Move to the trash, the stored files are removed when the trash is purged
+	if err := moveToTrash(ctx, db, &doc); err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to delete document"))
+		return
 	}
 
+	// Notify the folder owner through the notification service
+	publishDocumentDeleted(ctx, &doc)
+
 	// Update folder statistics after successful deletion
-	if err := updateFolderStats(db, doc.FolderID); err != nil {
-		fmt.Printf("Warning: Failed to update folder stats: %v\n", err)
-	}
+	services.QueueFolderStats(doc.FolderID)
 
 	ctx.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "Document deleted successfully",
+		"message": "Document moved to trash",
 	})
 }
 
@@ -452,39 +646,62 @@ type MoveDocumentRequest struct {
 // @Security BearerAuth
 // @Success 200 {object} map[string]interface{} "Document moved successfully"
 // @Failure 400 {object} map[string]string "Invalid request data or document ID format"
+// @Failure 403 {object} map[string]string "Access denied"
 // @Failure 404 {object} map[string]string "Document or target folder not found"
+// @Failure 423 {object} map[string]string "Document in a folder under legal hold"
 // @Failure 500 {object} map[string]string "Server error"
 // @Router /documents/{id}/move [post]
 func MoveDocument(ctx *gin.Context) {
-	db := database.GetDB()
+	db := requestDB(ctx)
 
 	documentID := ctx.Param("id")
 
 	var req MoveDocumentRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apperrors.Respond(ctx, validation.Error(err, "Invalid request body"))
 		return
 	}
 
 	// Get document
 	var doc document.Document
 	if err := db.Preload("Folder").First(&doc, "id = ?", documentID).Error; err != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
+		apperrors.Respond(ctx, apperrors.NotFound("Document not found"))
+		return
+	}
+
+	if !checkDocumentAccess(ctx, &doc, document.AccessLevelWrite) {
+		return
+	}
+
+	// The scan worker looks the quarantined file up by its object key
+	if doc.ScanStatus == document.ScanStatusPending || doc.ScanStatus == document.ScanStatusScanning {
+		apperrors.Respond(ctx, apperrors.Conflict("Document is being scanned for malware, try again shortly"))
 		return
 	}
 
 	// Get target folder
 	var targetFolder document.Folder
 	if err := db.First(&targetFolder, "id = ?", req.TargetFolderID).Error; err != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{"error": "Target folder not found"})
+		apperrors.Respond(ctx, apperrors.NotFound("Target folder not found"))
+		return
+	}
+
+	if !checkFolderAccess(ctx, &targetFolder, document.AccessLevelWrite) {
+		return
+	}
+
+	if reason, err := services.DocumentMoveBlock(db, &doc, &targetFolder); err != nil || reason != "" {
+		respondMoveBlocked(ctx, reason, err)
 		return
 	}
 
 	// Move document
+	fromPath := doc.Folder.Path
 	if err := moveDocument(db, &doc, &targetFolder); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		apperrors.Respond(ctx, apperrors.Internal(err))
 		return
 	}
+	recordDocumentActivity(ctx, doc.ID, document.DocumentActionMove, documentMoveDetails(fromPath, targetFolder.Path))
 
 	// Reload document
 	db.Preload("Folder").First(&doc, documentID)
@@ -496,13 +713,13 @@ func MoveDocument(ctx *gin.Context) {
 	})
 }
 
-// moveDocument helper function to move document and its versions
+// moveDocument helper function to move document and its versions. Files follow the document into the
+// storage of the target folder's organization.
 func moveDocument(db *gorm.DB, doc *document.Document, targetFolder *document.Folder) error {
 	// Store original folder ID before updating
 	oldFolderID := doc.FolderID
-	oldFolderPath := doc.Folder.Path
 
-	minioService, err := services.NewMinIOService()
+	storage, err := services.NewStorageProvider()
 	if err != nil {
 		return fmt.Errorf("storage service unavailable: %v", err)
 	}
@@ -513,44 +730,43 @@ func moveDocument(db *gorm.DB, doc *document.Document, targetFolder *document.Fo
 		return fmt.Errorf("failed to get document versions: %v", err)
 	}
 
-	// Store version updates before DB changes
-	type VersionUpdate struct {
-		Version      document.DocumentVersion
-		OldMinIOPath string
-		NewMinIOPath string
-		NewObjectKey string
-	}
-
-	var versionUpdates []VersionUpdate
-
-	// Prepare version updates using simple folder path + filename
+	// Move each version's file, then its record, so a failure leaves the moved versions consistent
+	newObjectKeys := map[string]string{}
 	for _, version := range versions {
-		oldMinIOPath := filepath.Join(oldFolderPath, doc.FileName)
-		newMinIOPath := filepath.Join(targetFolder.Path, doc.FileName)
-
-		fileName := filepath.Base(version.ObjectKey)
-		newObjectKey := filepath.Join(targetFolder.Path, fileName)
-
-		versionUpdates = append(versionUpdates, VersionUpdate{
-			Version:      version,
-			OldMinIOPath: oldMinIOPath,
-			NewMinIOPath: newMinIOPath,
-			NewObjectKey: newObjectKey,
-		})
+		newObjectKey, err := services.OrganizationObjectKey(db, targetFolder, filepath.Join(targetFolder.Path, filepath.Base(version.ObjectKey)))
+		if err != nil {
+			return err
+		}
+		newObjectKeys[version.ObjectKey] = newObjectKey
+
+		updates := map[string]interface{}{"object_key": newObjectKey}
+		releaseContent := false
+		switch {
+		case version.ContentHash == "":
+			oldKey, newKey := storedVersionKey(version.ObjectKey, version.ScanStatus), storedVersionKey(newObjectKey, version.ScanStatus)
+			if oldKey != newKey {
+				if err := storage.MoveObject(oldKey, newKey); err != nil {
+					return fmt.Errorf("failed to move version %d: %v", version.Version, err)
+				}
+			}
+		case !services.UsesContentStore(newObjectKey):
+			// Files in the content store are copied out of it into the own storage of an organization
+			if err := storage.CopyObject(docUtils.ContentKey(version.ContentHash), newObjectKey); err != nil {
+				return fmt.Errorf("failed to move version %d: %v", version.Version, err)
+			}
+			updates["content_hash"] = ""
+			releaseContent = true
+		}
+		// Other files in the content store are stored by their hash and stay where they are
 
-		// Update version record in DB
-		if err := db.Model(&version).Update("object_key", newObjectKey).Error; err != nil {
+		if err := db.Model(&version).Updates(updates).Error; err != nil {
 			return fmt.Errorf("failed to update version %d: %v", version.Version, err)
 		}
-	}
-
-	// Now move files in MinIO after DB is updated
-	for _, update := range versionUpdates {
-		if err := minioService.MoveObject(update.OldMinIOPath, update.NewMinIOPath); err != nil {
-			return fmt.Errorf("failed to move version %d: %v", update.Version.Version, err)
+		if releaseContent {
+			if err := services.ReleaseContent(db, storage, version.ContentHash); err != nil {
+				log.Printf("⚠️  Failed to release content of document %s version %d: %v", doc.ID, version.Version, err)
+			}
 		}
-
-		fmt.Printf("Moved version %d from %s to %s\n", update.Version.Version, update.OldMinIOPath, update.NewMinIOPath)
 	}
 
 	// Update document record
@@ -563,19 +779,17 @@ func moveDocument(db *gorm.DB, doc *document.Document, targetFolder *document.Fo
 	}
 
 	newDisplayPath := docUtils.GenerateDisplayPath(targetFolder.Path, doc.FileName, latestVersion)
-	newObjectKey := ""
-	if doc.ObjectKey != "" {
-		fileName := filepath.Base(doc.ObjectKey)
-		newObjectKey = filepath.Join(targetFolder.Path, fileName)
-	}
 
 	updateData := map[string]interface{}{
 		"folder_id": targetFolder.ID,
 		"path":      newDisplayPath,
 	}
 
-	if newObjectKey != "" {
+	if newObjectKey, ok := newObjectKeys[doc.ObjectKey]; ok {
 		updateData["object_key"] = newObjectKey
+		if doc.ContentHash != "" && !services.UsesContentStore(newObjectKey) {
+			updateData["content_hash"] = ""
+		}
 	}
 
 	if err := db.Model(doc).Updates(updateData).Error; err != nil {
@@ -583,12 +797,8 @@ func moveDocument(db *gorm.DB, doc *document.Document, targetFolder *document.Fo
 	}
 
 	// Update folder statistics for both old and new folders
-	if err := updateFolderStats(db, oldFolderID); err != nil {
-		fmt.Printf("Warning: Failed to update old folder stats: %v\n", err)
-	}
-	if err := updateFolderStats(db, targetFolder.ID); err != nil {
-		fmt.Printf("Warning: Failed to update target folder stats: %v\n", err)
-	}
+	services.QueueFolderStats(oldFolderID)
+	services.QueueFolderStats(targetFolder.ID)
 
 	return nil
 }
@@ -602,25 +812,30 @@ func moveDocument(db *gorm.DB, doc *document.Document, targetFolder *document.Fo
 // @Param id path string true "Document ID" format(uuid)
 // @Security BearerAuth
 // @Success 200 {object} map[string]interface{} "List of document versions"
+// @Failure 403 {object} map[string]string "Access denied"
 // @Failure 404 {object} map[string]string "Document not found"
 // @Failure 500 {object} map[string]string "Server error"
 // @Router /documents/{id}/versions [get]
 func GetDocumentVersions(ctx *gin.Context) {
-	db := database.GetDB()
+	db := requestDB(ctx)
 
 	documentID := ctx.Param("id")
 
 	// Check if document exists
 	var doc document.Document
 	if err := db.First(&doc, "id = ?", documentID).Error; err != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
+		apperrors.Respond(ctx, apperrors.NotFound("Document not found"))
+		return
+	}
+
+	if !checkDocumentAccess(ctx, &doc, document.AccessLevelRead) {
 		return
 	}
 
 	// Get all versions
 	var versions []document.DocumentVersion
 	if err := db.Where("document_id = ?", documentID).Order("version DESC").Find(&versions).Error; err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch document versions"})
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to fetch document versions"))
 		return
 	}
 
@@ -639,25 +854,30 @@ func GetDocumentVersions(ctx *gin.Context) {
 // @Param id path string true "Document ID" format(uuid)
 // @Security BearerAuth
 // @Success 200 {object} map[string]interface{} "Latest document version"
+// @Failure 403 {object} map[string]string "Access denied"
 // @Failure 404 {object} map[string]string "Document or version not found"
 // @Failure 500 {object} map[string]string "Server error"
 // @Router /documents/{id}/versions/latest [get]
 func GetLatestDocumentVersion(ctx *gin.Context) {
-	db := database.GetDB()
+	db := requestDB(ctx)
 
 	documentID := ctx.Param("id")
 
 	// Check if document exists
 	var doc document.Document
 	if err := db.First(&doc, "id = ?", documentID).Error; err != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
+		apperrors.Respond(ctx, apperrors.NotFound("Document not found"))
+		return
+	}
+
+	if !checkDocumentAccess(ctx, &doc, document.AccessLevelRead) {
 		return
 	}
 
 	// Get latest version
 	var version document.DocumentVersion
 	if err := db.Where("document_id = ?", documentID).Order("version DESC").First(&version).Error; err != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{"error": "No versions found"})
+		apperrors.Respond(ctx, apperrors.NotFound("No versions found"))
 		return
 	}
 
@@ -675,101 +895,102 @@ func GetLatestDocumentVersion(ctx *gin.Context) {
 // @Produce json
 // @Param id path string true "Document ID" format(uuid)
 // @Param file formData file true "Document file to upload"
-// @Param user_id formData string false "User ID (for testing purposes)"
+// @Param user_id formData string false "Author when the request does not come through the gateway"
+// @Param base_version formData int false "Version the new version is based on, rejected with 409 when the document has a newer version"
 // @Security BearerAuth
 // @Success 201 {object} map[string]interface{} "Document version uploaded successfully"
 // @Failure 400 {object} map[string]string "Invalid request data"
+// @Failure 403 {object} map[string]string "Access denied"
 // @Failure 404 {object} map[string]string "Document not found"
+// @Failure 409 {object} map[string]interface{} "Document has been changed since the base version"
+// @Failure 413 {object} map[string]interface{} "Storage quota or size limit of the file type exceeded"
+// @Failure 415 {object} map[string]interface{} "File type not allowed"
+// @Failure 423 {object} map[string]interface{} "Document is locked by another user"
 // @Failure 500 {object} map[string]string "Server error"
 // @Router /documents/{id}/versions [post]
 func UploadDocumentVersion(ctx *gin.Context) {
-	db := database.GetDB()
+	db := requestDB(ctx)
 
 	documentID := ctx.Param("id")
 
 	// Get existing document
 	var doc document.Document
 	if err := db.Preload("Folder").First(&doc, "id = ?", documentID).Error; err != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
+		apperrors.Respond(ctx, apperrors.NotFound("Document not found"))
 		return
 	}
 
-	// Get file from request
-	file, header, err := ctx.Request.FormFile("file")
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "File is required"})
+	if !checkDocumentAccess(ctx, &doc, document.AccessLevelWrite) {
 		return
 	}
-	defer file.Close()
 
-	// Validate file
-	if err := docUtils.ValidateUploadedFile(header); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	// The author of the version is the caller forwarded by the gateway
+	uploadedBy := requestUserID(ctx, ctx.PostForm("user_id"))
+	if uploadedBy == nil {
+		apperrors.Respond(ctx, apperrors.BadRequest("user_id is required"))
 		return
 	}
 
-	// Calculate checksum
-	checksum, err := docUtils.CalculateFileChecksum(file)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate checksum"})
+	// Only the holder of the lock can add versions to a checked out document
+	if !checkDocumentLock(ctx, &doc, uploadedBy) {
 		return
 	}
 
-	// Reset file pointer after checksum calculation
-	file.Seek(0, 0)
-
-	// Get next version number
-	var maxVersion int
-	db.Model(&document.DocumentVersion{}).
-		Where("document_id = ?", doc.ID).
-		Select("COALESCE(MAX(version), 0)").
-		Scan(&maxVersion)
-	newVersion := maxVersion + 1
-
-	// Generate paths for new version
-	minioPath := docUtils.GenerateMinIOPath(doc.Folder.Path, header.Filename, newVersion)
+	// Clients that edited a given version are told when someone else added a version in the meantime
+	baseVersion := 0
+	if value := ctx.PostForm("base_version"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			apperrors.Respond(ctx, apperrors.BadRequest("base_version must be a version number"))
+			return
+		}
+		baseVersion = parsed
+	}
 
-	// Upload to MinIO
-	minioService, err := services.NewMinIOService()
+	// Get file from request
+	file, header, err := ctx.Request.FormFile("file")
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Storage service unavailable"})
+		apperrors.Respond(ctx, apperrors.BadRequest("File is required"))
 		return
 	}
+	defer file.Close()
 
-	if err := minioService.UploadFile(context.Background(), file, header.Filename, doc.Folder.Path, header.Size); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload file"})
+	// Validate file
+	if err := docUtils.ValidateUploadedFile(header); err != nil {
+		apperrors.Respond(ctx, apperrors.BadRequest(err.Error()))
 		return
 	}
 
-	// Create version record
-	docVersion := document.DocumentVersion{
-		ID:         uuid.New(),
-		DocumentID: doc.ID,
-		Version:    newVersion,
-		ObjectKey:  minioPath,
-		FileSize:   header.Size,
-		Checksum:   checksum,
-		CreatedBy:  uuid.MustParse(ctx.PostForm("user_id")),
+	mimeType, err := docUtils.DetectFileType(file, header.Filename)
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to read file"))
+		return
+	}
+	header.Header.Set("Content-Type", mimeType)
+	if !checkFileType(ctx, &doc.Folder, header.Filename, mimeType, header.Size) {
+		return
 	}
 
-	if err := db.Create(&docVersion).Error; err != nil {
-		minioService.RemoveFile(context.Background(), header.Filename, doc.Folder.Path)
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save version"})
+	// A new version adds storage but no document
+	if !checkFolderQuota(ctx, &doc.Folder, header.Size, 0) {
 		return
 	}
 
-	// Update main document to point to latest version
-	newDisplayPath := docUtils.GenerateDisplayPath(doc.Folder.Path, header.Filename, newVersion)
-	updateData := map[string]interface{}{
-		"path":       newDisplayPath,
-		"object_key": minioPath,
-		"file_size":  header.Size,
-		"checksum":   checksum,
+	storage, err := services.NewStorageProvider()
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Storage service unavailable"))
+		return
 	}
 
-	if err := db.Model(&doc).Updates(updateData).Error; err != nil {
-		fmt.Printf("Warning: Failed to update main document record: %v\n", err)
+	docVersion, err := addDocumentVersion(db, storage, &doc, file, header, *uploadedBy, baseVersion)
+	if err != nil {
+		if !respondVersionConflict(ctx, err) {
+			apperrors.Respond(ctx, apperrors.Internal(err))
+		}
+		return
 	}
+	recordDocumentActivity(ctx, doc.ID, document.DocumentActionUpload, fmt.Sprintf("Version %d", docVersion.Version))
+	recordFolderChange(ctx, []uuid.UUID{doc.ID}, document.FolderChangeUpdated)
 
 	ctx.JSON(http.StatusCreated, gin.H{
 		"success": true,
@@ -794,22 +1015,25 @@ type CopyDocumentRequest struct {
 // @Security BearerAuth
 // @Success 201 {object} map[string]interface{} "Document copied successfully"
 // @Failure 400 {object} map[string]string "Invalid request data or document ID format"
+// @Failure 402 {object} map[string]interface{} "Document quota exceeded"
+// @Failure 403 {object} map[string]string "Access denied"
 // @Failure 404 {object} map[string]string "Document or target folder not found"
+// @Failure 413 {object} map[string]interface{} "Storage quota exceeded"
 // @Failure 500 {object} map[string]string "Server error"
 // @Router /documents/{id}/copy [post]
 func CopyDocument(ctx *gin.Context) {
-	db := database.GetDB()
+	db := requestDB(ctx)
 
 	documentID := ctx.Param("id")
 	docUUID, err := uuid.Parse(documentID)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid document ID format"})
+		apperrors.Respond(ctx, apperrors.BadRequest("Invalid document ID format"))
 		return
 	}
 
 	var req CopyDocumentRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apperrors.Respond(ctx, validation.Error(err, "Invalid request body"))
 		return
 	}
 
@@ -817,27 +1041,43 @@ func CopyDocument(ctx *gin.Context) {
 	var originalDoc document.Document
 	if err := db.Preload("Folder").First(&originalDoc, docUUID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
+			apperrors.Respond(ctx, apperrors.NotFound("Document not found"))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch document"})
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to fetch document"))
+		return
+	}
+
+	if !checkDocumentAccess(ctx, &originalDoc, document.AccessLevelRead) {
 		return
 	}
 
 	// Get target folder
 	targetFolderUUID, err := uuid.Parse(req.TargetFolderID)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid target folder ID format"})
+		apperrors.Respond(ctx, apperrors.BadRequest("Invalid target folder ID format"))
 		return
 	}
 
 	var targetFolder document.Folder
 	if err := db.First(&targetFolder, targetFolderUUID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Target folder not found"})
+			apperrors.Respond(ctx, apperrors.NotFound("Target folder not found"))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch target folder"})
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to fetch target folder"))
+		return
+	}
+
+	if !checkFolderAccess(ctx, &targetFolder, document.AccessLevelWrite) {
+		return
+	}
+
+	if !checkScanStatus(ctx, originalDoc.ScanStatus) {
+		return
+	}
+
+	if !checkFolderQuota(ctx, &targetFolder, originalDoc.FileSize, 1) {
 		return
 	}
 
@@ -847,9 +1087,10 @@ func CopyDocument(ctx *gin.Context) {
 	// Copy document
 	copiedDoc, err := copyDocument(db, &originalDoc, &targetFolder, newFileName)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		apperrors.Respond(ctx, apperrors.Internal(err))
 		return
 	}
+	recordFolderChange(ctx, []uuid.UUID{copiedDoc.ID}, document.FolderChangeAdded)
 
 	ctx.JSON(http.StatusCreated, gin.H{
 		"success": true,
@@ -911,24 +1152,37 @@ func generateCopyName(db *gorm.DB, originalName string, targetFolderID uuid.UUID
 
 // copyDocument helper function
 func copyDocument(db *gorm.DB, originalDoc *document.Document, targetFolder *document.Folder, newFileName string) (*document.Document, error) {
-	minioService, err := services.NewMinIOService()
+	storage, err := services.NewStorageProvider()
 	if err != nil {
 		return nil, fmt.Errorf("storage service unavailable: %v", err)
 	}
 
 	// Generate new paths
-	newMinIOPath := docUtils.GenerateMinIOPath(targetFolder.Path, newFileName, 1)
+	newMinIOPath, err := services.OrganizationObjectKey(db, targetFolder, docUtils.GenerateMinIOPath(targetFolder.Path, newFileName, 1))
+	if err != nil {
+		return nil, err
+	}
 	newDisplayPath := docUtils.GenerateDisplayPath(targetFolder.Path, newFileName, 1)
 
+	// Files in the content store are shared with the copy instead of copied, unless the copy goes to
+	// the own storage of an organization
+	shared := originalDoc.ContentHash != "" && services.UsesContentStore(newMinIOPath)
+	contentHash := ""
+	if shared {
+		contentHash = originalDoc.ContentHash
+	}
+
 	// Copy file in MinIO
-	oldObjectKey := originalDoc.ObjectKey
-	if err := minioService.CopyObject(oldObjectKey, newMinIOPath); err != nil {
-		return nil, fmt.Errorf("failed to copy file in storage: %v", err)
+	if !shared {
+		oldObjectKey := docUtils.StorageKey(originalDoc.ObjectKey, originalDoc.ContentHash)
+		if err := storage.CopyObject(oldObjectKey, newMinIOPath); err != nil {
+			return nil, fmt.Errorf("failed to copy file in storage: %v", err)
+		}
 	}
 
 	// Create new document record
 	copiedDoc := document.Document{
-		ID:            uuid.New(),
+		ID:            ids.New(),
 		FileName:      newFileName,
 		OriginalName:  newFileName,
 		Path:          newDisplayPath,
@@ -938,38 +1192,497 @@ func copyDocument(db *gorm.DB, originalDoc *document.Document, targetFolder *doc
 		FolderID:      targetFolder.ID,
 		UploadedBy:    originalDoc.UploadedBy,
 		ObjectKey:     newMinIOPath,
+		ContentHash:   contentHash,
 		Checksum:      originalDoc.Checksum,
 		Tags:          originalDoc.Tags,
+		Metadata:      originalDoc.Metadata,
 		Description:   fmt.Sprintf("Copy of: %s", originalDoc.Description),
 	}
 
-	if err := db.Create(&copiedDoc).Error; err != nil {
+	// Create version record
+	docVersion := document.DocumentVersion{
+		ID:          ids.New(),
+		DocumentID:  copiedDoc.ID,
+		Version:     1,
+		ObjectKey:   newMinIOPath,
+		ContentHash: contentHash,
+		FileSize:    originalDoc.FileSize,
+		Checksum:    originalDoc.Checksum,
+		CreatedBy:   originalDoc.UploadedBy,
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if shared {
+			if err := services.AddContentReference(tx, storage, originalDoc.ContentHash, "", originalDoc.FileSize); err != nil {
+				return err
+			}
+		}
+		if err := tx.Create(&copiedDoc).Error; err != nil {
+			return err
+		}
+		return tx.Create(&docVersion).Error
+	})
+	if err != nil {
 		// Cleanup MinIO if database save fails
-		fileName := filepath.Base(newMinIOPath)
-		folderPath := filepath.Dir(newMinIOPath)
-		minioService.RemoveFile(context.Background(), fileName, folderPath)
+		if !shared {
+			storage.RemoveObject(context.Background(), newMinIOPath)
+		}
 		return nil, fmt.Errorf("failed to save copied document: %v", err)
 	}
 
+	// Update folder statistics
+	services.QueueFolderStats(targetFolder.ID)
+
+	return &copiedDoc, nil
+}
+
+// publishDocumentDeleted notifies the folder owner through the notification service, and the subscribers of
+// the folder, that a document moved to the trash
+func publishDocumentDeleted(ctx *gin.Context, doc *document.Document) {
+	recordFolderChange(ctx, []uuid.UUID{doc.ID}, document.FolderChangeDeleted)
+	messaging.Publish(ctx.Request.Context(), messaging.EventDocumentDeleted, utils.GetActorID(ctx), messaging.ActivityData{
+		OwnerID:      doc.Folder.OwnerID,
+		ActionType:   "Document Deletion",
+		ResourceType: "document",
+		ResourceID:   doc.ID,
+		ResourceName: doc.OriginalName,
+		Description:  fmt.Sprintf("Document '%s' (%.2f KB) moved to trash", doc.OriginalName, float64(doc.FileSize)/1024),
+		Priority:     "normal",
+		IPAddress:    ctx.ClientIP(),
+		Changes: []messaging.ActivityChange{
+			{
+				Field:    "Document Status",
+				OldValue: "Active",
+				NewValue: "Trash",
+			},
+			{
+				Field:    "File Size",
+				OldValue: fmt.Sprintf("%d bytes", doc.FileSize),
+				NewValue: "0 bytes",
+			},
+		},
+	})
+}
+
+// createDocument stores an uploaded file as a new document in the folder, as the next version of the
+// file name in the folder
+func createDocument(db *gorm.DB, storage services.StorageProvider, folder *document.Folder, file multipart.File, header *multipart.FileHeader, uploadedBy uuid.UUID, tags []string, description string, metadata models.JSONMap) (*document.Document, error) {
+	// Calculate checksum
+	checksum, err := docUtils.CalculateFileChecksum(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate checksum: %v", err)
+	}
+
+	contentHash, err := docUtils.CalculateContentHash(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate checksum: %v", err)
+	}
+
+	// Calculate next version for this filename in this folder
+	version := nextFileVersion(db, folder.ID, header.Filename)
+
+	// Generate paths
+	minioPath, err := services.OrganizationObjectKey(db, folder, docUtils.GenerateMinIOPath(folder.Path, header.Filename, version))
+	if err != nil {
+		return nil, err
+	}
+	displayPath := docUtils.GenerateDisplayPath(folder.Path, header.Filename, version)
+
+	// Upload to MinIO
+	scanStatus := uploadScanStatus()
+	if err := storeUploadedFile(storage, file, header, minioPath, scanStatus); err != nil {
+		return nil, fmt.Errorf("failed to upload file: %v", err)
+	}
+
+	// Create document record
+	doc := document.Document{
+		ID:            ids.New(),
+		FileName:      header.Filename,
+		OriginalName:  header.Filename,
+		Path:          displayPath,
+		FileSize:      header.Size,
+		MimeType:      header.Header.Get("Content-Type"),
+		FileExtension: filepath.Ext(header.Filename),
+		FolderID:      folder.ID,
+		UploadedBy:    uploadedBy,
+		ObjectKey:     minioPath,
+		Checksum:      checksum,
+		Tags:          tags,
+		Description:   description,
+		Metadata:      metadata,
+		ScanStatus:    scanStatus,
+	}
+
 	// Create version record
 	docVersion := document.DocumentVersion{
-		ID:         uuid.New(),
-		DocumentID: copiedDoc.ID,
-		Version:    1,
-		ObjectKey:  newMinIOPath,
-		FileSize:   originalDoc.FileSize,
-		Checksum:   originalDoc.Checksum,
-		CreatedBy:  originalDoc.UploadedBy,
+		ID:         ids.New(),
+		DocumentID: doc.ID,
+		Version:    version,
+		ObjectKey:  minioPath,
+		FileSize:   header.Size,
+		Checksum:   checksum,
+		CreatedBy:  doc.UploadedBy,
+		ScanStatus: scanStatus,
 	}
 
-	if err := db.Create(&docVersion).Error; err != nil {
-		return nil, fmt.Errorf("failed to create version record: %v", err)
+	err = db.Transaction(func(tx *gorm.DB) error {
+		storedHash, err := addUploadToContentStore(tx, storage, minioPath, contentHash, scanStatus, header.Size)
+		if err != nil {
+			return err
+		}
+		doc.ContentHash = storedHash
+		docVersion.ContentHash = storedHash
+
+		if err := tx.Create(&doc).Error; err != nil {
+			return err
+		}
+		return tx.Create(&docVersion).Error
+	})
+	if err != nil {
+		// Cleanup MinIO file
+		removeUploadedFile(storage, minioPath, scanStatus)
+		return nil, fmt.Errorf("failed to save document: %v", err)
+	}
+	if doc.ContentHash != "" {
+		// The content store holds the file now
+		removeUploadedFile(storage, minioPath, scanStatus)
 	}
 
-	// Update folder statistics
-	if err := updateFolderStats(db, targetFolder.ID); err != nil {
-		fmt.Printf("Warning: Failed to update folder stats: %v", err)
+	return &doc, nil
+}
+
+// addDocumentVersion stores an uploaded file as the next version of the document and makes it the
+// current version. A base version above 0 requires the document to still be at that version, a
+// *versionConflictError is returned otherwise.
+func addDocumentVersion(db *gorm.DB, storage services.StorageProvider, doc *document.Document, file multipart.File, header *multipart.FileHeader, createdBy uuid.UUID, baseVersion int) (*document.DocumentVersion, error) {
+	// Calculate checksum
+	checksum, err := docUtils.CalculateFileChecksum(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate checksum: %v", err)
 	}
 
-	return &copiedDoc, nil
+	contentHash, err := docUtils.CalculateContentHash(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate checksum: %v", err)
+	}
+
+	// Create version record
+	scanStatus := uploadScanStatus()
+	docVersion := document.DocumentVersion{
+		ID:         ids.New(),
+		DocumentID: doc.ID,
+		FileSize:   header.Size,
+		Checksum:   checksum,
+		CreatedBy:  createdBy,
+		ScanStatus: scanStatus,
+	}
+
+	// The file is stored once its version number is claimed, so concurrent uploads never share a key
+	stored := false
+	objectKey := func(version int) (string, error) {
+		return services.OrganizationObjectKey(db, &doc.Folder, docUtils.GenerateMinIOPath(doc.Folder.Path, header.Filename, version))
+	}
+	err = claimDocumentVersion(db, &docVersion, baseVersion, objectKey, func(tx *gorm.DB) (map[string]interface{}, error) {
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		if err := storeUploadedFile(storage, file, header, docVersion.ObjectKey, scanStatus); err != nil {
+			return nil, fmt.Errorf("failed to upload file: %v", err)
+		}
+		stored = true
+
+		storedHash, err := addUploadToContentStore(tx, storage, docVersion.ObjectKey, contentHash, scanStatus, header.Size)
+		if err != nil {
+			return nil, err
+		}
+		docVersion.ContentHash = storedHash
+
+		// Update main document to point to latest version
+		return map[string]interface{}{
+			"path":         docUtils.GenerateDisplayPath(doc.Folder.Path, header.Filename, docVersion.Version),
+			"object_key":   docVersion.ObjectKey,
+			"content_hash": storedHash,
+			"file_size":    header.Size,
+			"checksum":     checksum,
+			"scan_status":  scanStatus,
+			"index_status": document.IndexStatusPending,
+		}, nil
+	})
+	if err != nil {
+		if stored {
+			removeUploadedFile(storage, docVersion.ObjectKey, scanStatus)
+		}
+		var conflictErr *versionConflictError
+		if errors.As(err, &conflictErr) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to save version: %v", err)
+	}
+	if docVersion.ContentHash != "" {
+		// The content store holds the file now
+		removeUploadedFile(storage, docVersion.ObjectKey, scanStatus)
+	}
+
+	return &docVersion, nil
+}
+
+// versionClaimAttempts is how often a new version claims a number before giving up when concurrent
+// requests keep claiming the same one
+const versionClaimAttempts = 5
+
+// versionConflictError reports that a document is no longer at the version a change was based on
+type versionConflictError struct {
+	BaseVersion    int
+	CurrentVersion int
+}
+
+func (e *versionConflictError) Error() string {
+	return fmt.Sprintf("document is at version %d, not %d", e.CurrentVersion, e.BaseVersion)
+}
+
+// claimDocumentVersion saves a new version of a document and makes it the current version in one
+// transaction. The version keeps its number while it is above the current version, otherwise it claims
+// the next number; objectKey gives the object key of the number. The version is inserted before store puts
+// its file in place and returns the fields of the document to update, as the unique index on the document
+// and version number makes a concurrent request claiming the same number wait for this transaction and
+// then fail. The number is then claimed again. A base version above 0 must be the current version.
+func claimDocumentVersion(db *gorm.DB, docVersion *document.DocumentVersion, baseVersion int, objectKey func(version int) (string, error), store func(tx *gorm.DB) (map[string]interface{}, error)) error {
+	var err error
+	for attempt := 1; attempt <= versionClaimAttempts; attempt++ {
+		err = db.Transaction(func(tx *gorm.DB) error {
+			current := currentDocumentVersion(tx, docVersion.DocumentID)
+			if baseVersion > 0 && baseVersion != current {
+				return &versionConflictError{BaseVersion: baseVersion, CurrentVersion: current}
+			}
+			if docVersion.Version <= current {
+				docVersion.Version = nextDocumentVersion(tx, docVersion.DocumentID)
+			}
+
+			key, err := objectKey(docVersion.Version)
+			if err != nil {
+				return err
+			}
+			docVersion.ObjectKey = key
+			if err := tx.Create(docVersion).Error; err != nil {
+				return err
+			}
+
+			updates, err := store(tx)
+			if err != nil {
+				return err
+			}
+			if docVersion.ContentHash != "" {
+				if err := tx.Model(docVersion).Update("content_hash", docVersion.ContentHash).Error; err != nil {
+					return err
+				}
+			}
+			return tx.Model(&document.Document{}).Where("id = ?", docVersion.DocumentID).Updates(updates).Error
+		})
+		if !database.IsUniqueViolation(err) {
+			return err
+		}
+	}
+	return err
+}
+
+// respondVersionConflict writes the response when a document is no longer at the base version of a change
+func respondVersionConflict(ctx *gin.Context, err error) bool {
+	var conflictErr *versionConflictError
+	if !errors.As(err, &conflictErr) {
+		return false
+	}
+	apperrors.Respond(ctx, apperrors.Conflict("Document has been changed since the base version").
+		With("base_version", conflictErr.BaseVersion).With("current_version", conflictErr.CurrentVersion))
+	return true
+}
+
+// nextFileVersion returns the version a new upload of the file name gets in the folder. Documents in
+// the trash are counted so their object keys are never reused.
+func nextFileVersion(db *gorm.DB, folderID uuid.UUID, fileName string) int {
+	var maxVersion int
+	db.Model(&document.DocumentVersion{}).
+		Joins("JOIN documents ON documents.id = document_versions.document_id").
+		Where("documents.folder_id = ? AND documents.file_name = ?", folderID, fileName).
+		Select("COALESCE(MAX(document_versions.version), 0)").
+		Scan(&maxVersion)
+	return maxVersion + 1
+}
+
+// currentDocumentVersion returns the number of the latest version of a document
+func currentDocumentVersion(db *gorm.DB, documentID uuid.UUID) int {
+	var maxVersion int
+	db.Model(&document.DocumentVersion{}).
+		Where("document_id = ?", documentID).
+		Select("COALESCE(MAX(version), 0)").
+		Scan(&maxVersion)
+	return maxVersion
+}
+
+// nextDocumentVersion returns the number of the next version of a document. Numbers of chunked uploads
+// in progress are skipped, their parts are assembled under the object key of their number.
+func nextDocumentVersion(db *gorm.DB, documentID uuid.UUID) int {
+	var uploadVersion int
+	db.Model(&document.UploadSession{}).
+		Where("document_id = ? AND status IN ?", documentID, []string{document.UploadSessionUploading, document.UploadSessionCompleting}).
+		Select("COALESCE(MAX(version), 0)").
+		Scan(&uploadVersion)
+	return max(currentDocumentVersion(db, documentID), uploadVersion) + 1
+}
+
+// uploadScanStatus returns the scan status of a new upload. While malware scanning is enabled the file
+// goes to quarantine under its object key until the scan worker releases it.
+func uploadScanStatus() string {
+	if services.ScanningEnabled() {
+		return document.ScanStatusPending
+	}
+	return document.ScanStatusNotScanned
+}
+
+// storeUploadedFile stores an uploaded file with the scan status of uploadScanStatus under its object key
+func storeUploadedFile(storage services.StorageProvider, file multipart.File, header *multipart.FileHeader, objectKey, scanStatus string) error {
+	return storage.PutObject(context.Background(), storedObjectKey(objectKey, scanStatus), file, header.Size, header.Header.Get("Content-Type"))
+}
+
+// removeUploadedFile removes a file stored by storeUploadedFile
+func removeUploadedFile(storage services.StorageProvider, objectKey, scanStatus string) {
+	storage.RemoveObject(context.Background(), storedObjectKey(objectKey, scanStatus))
+}
+
+// addUploadToContentStore adds a file stored by storeUploadedFile to the content store when it needs no
+// malware scan, sharing the stored copy of identical files. Quarantined files are added by the scan worker
+// once they are found clean, files in the own storage of an organization stay under their object key.
+// Returns the content hash to record, empty while the file is not in the content store. The caller removes
+// the uploaded file once the transaction committed.
+func addUploadToContentStore(tx *gorm.DB, storage services.StorageProvider, objectKey, contentHash, scanStatus string, size int64) (string, error) {
+	if scanStatus == document.ScanStatusPending || !services.UsesContentStore(objectKey) {
+		return "", nil
+	}
+	if err := services.AddContentReference(tx, storage, contentHash, objectKey, size); err != nil {
+		return "", err
+	}
+	return contentHash, nil
+}
+
+// storedVersionKey returns where the file of a version outside the content store is stored: in quarantine
+// until the malware scan released it
+func storedVersionKey(objectKey, scanStatus string) string {
+	if !document.ScanStatusAllowsDownload(scanStatus) {
+		return docUtils.QuarantineKey(objectKey)
+	}
+	return objectKey
+}
+
+// storedObjectKey returns where a file with the given scan status is stored
+func storedObjectKey(objectKey, scanStatus string) string {
+	if scanStatus == document.ScanStatusPending {
+		return docUtils.QuarantineKey(objectKey)
+	}
+	return objectKey
+}
+
+// checkScanStatus writes the error response when a file is blocked by the malware scan
+func checkScanStatus(ctx *gin.Context, scanStatus string) bool {
+	if status, message := scanStatusError(scanStatus); status != 0 {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeForStatus(status), message))
+		return false
+	}
+	return true
+}
+
+// scanStatusError returns the status and message of the error for a file blocked by the malware scan,
+// zero when the file is not blocked
+func scanStatusError(scanStatus string) (int, string) {
+	switch scanStatus {
+	case document.ScanStatusPending, document.ScanStatusScanning:
+		return http.StatusConflict, "Document is being scanned for malware, try again shortly"
+	case document.ScanStatusInfected:
+		return http.StatusForbidden, "Document is infected with malware and cannot be downloaded"
+	case document.ScanStatusFailed:
+		return http.StatusForbidden, "Document could not be scanned for malware and cannot be downloaded"
+	}
+	return 0, ""
+}
+
+// requestDB returns the database handle scoped to the caller's organization
+func requestDB(ctx *gin.Context) *gorm.DB {
+	return database.GetDB().WithContext(ctx.Request.Context())
+}
+
+// checkFolderQuota checks the storage quota of the user owning the folder and the storage and document
+// quota of the organization owning it. It writes the 413 response when a storage limit would be exceeded
+// and the 402 response for the document limit.
+func checkFolderQuota(ctx *gin.Context, folder *document.Folder, addBytes int64, addDocuments int) bool {
+	err := folderQuotaError(folder, addBytes, addDocuments)
+	if err == nil {
+		return true
+	}
+
+	var quotaErr *database.QuotaExceededError
+	if errors.As(err, &quotaErr) {
+		status := http.StatusPaymentRequired
+		if quotaErr.IsStorageQuota() {
+			status = http.StatusRequestEntityTooLarge
+		}
+		apperrors.Respond(ctx, apperrors.Wrap(quotaErr, apperrors.CodeForStatus(status), "Quota exceeded").With("quota", quotaErr))
+		return false
+	}
+
+	apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to check quota"))
+	return false
+}
+
+// checkFileType checks a file against the platform's blocked types and the file type policy of the
+// organization owning the folder, responding with 415, or 413 over the size limit of its type
+func checkFileType(ctx *gin.Context, folder *document.Folder, fileName, mimeType string, size int64) bool {
+	policy, err := folderFileTypePolicy(folder)
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to check file type"))
+		return false
+	}
+	return respondFileTypeError(ctx, database.CheckFileType(policy, fileName, mimeType, size))
+}
+
+// respondFileTypeError writes the response for a *database.FileTypeError and reports whether err was nil
+func respondFileTypeError(ctx *gin.Context, err error) bool {
+	if err == nil {
+		return true
+	}
+
+	var typeErr *database.FileTypeError
+	if errors.As(err, &typeErr) {
+		code, message := apperrors.CodeUnsupportedMedia, "File type not allowed"
+		if typeErr.IsSizeLimit() {
+			code, message = apperrors.CodePayloadTooLarge, "File too large for its type"
+		}
+		apperrors.Respond(ctx, apperrors.Wrap(typeErr, code, message).WithDetails(typeErr.Reason).With("file", typeErr))
+		return false
+	}
+
+	apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to check file type"))
+	return false
+}
+
+// folderFileTypePolicy returns the file type policy of the organization owning the folder, or of
+// the organization of the user owning it
+func folderFileTypePolicy(folder *document.Folder) (models.FileTypePolicy, error) {
+	db := database.GetDB()
+	return database.GetFileTypePolicy(db, database.FolderOrganizationID(db, folder))
+}
+
+// folderQuotaError checks the quotas of the folder's owners like checkFolderQuota, returning a
+// *database.QuotaExceededError when a limit would be exceeded
+func folderQuotaError(folder *document.Folder, addBytes int64, addDocuments int) error {
+	db := database.GetDB()
+
+	if folder.OwnerType == "user" {
+		if err := database.CheckUserStorageQuota(db, folder.OwnerID, addBytes); err != nil {
+			return err
+		}
+	}
+
+	// Users outside an organization only have their own quota
+	if organizationID := database.FolderOrganizationID(db, folder); organizationID != nil {
+		return database.CheckStorageQuota(db, *organizationID, addBytes, addDocuments)
+	}
+	return nil
 }
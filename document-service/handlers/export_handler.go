@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"forgecrud-backend/document-service/services"
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/database/models/document"
+	documentUtils "forgecrud-backend/shared/utils/document"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ExportFolder handles POST /folders/:id/export - Export a folder as ZIP in the background
+// @Summary Export folder as ZIP
+// @Description Build a ZIP archive of a folder and its subfolders in the background, for folders too large to download directly. Only documents the caller may read are included. Poll GET /folder-exports/{id} until the export is COMPLETED, then download it from GET /folder-exports/{id}/download before it expires. An export of the folder still in progress is returned instead of starting another.
+// @Tags folders
+// @Produce json
+// @Param id path string true "Folder ID" format(uuid)
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Export already in progress"
+// @Success 202 {object} map[string]interface{} "Export started"
+// @Failure 400 {object} map[string]string "Invalid folder ID format"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Access denied"
+// @Failure 404 {object} map[string]string "Folder not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /folders/{id}/export [post]
+func ExportFolder(ctx *gin.Context) {
+	folderUUID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid folder ID format"))
+		return
+	}
+
+	userID := requestUserID(ctx, "")
+	if userID == nil {
+		apperrors.Respond(ctx, apperrors.Unauthorized("User ID is required"))
+		return
+	}
+
+	db := requestDB(ctx)
+
+	var folder document.Folder
+	if err := db.First(&folder, folderUUID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			apperrors.Respond(ctx, apperrors.NotFound("Folder not found").WithDetails("Folder with the given ID does not exist"))
+			return
+		}
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to fetch folder"))
+		return
+	}
+
+	if !checkFolderAccess(ctx, &folder, document.AccessLevelRead) {
+		return
+	}
+
+	var existing document.FolderExport
+	if err := db.Where("folder_id = ? AND requested_by = ? AND status IN ?",
+		folder.ID, *userID, []string{document.FolderExportPending, document.FolderExportRunning}).
+		First(&existing).Error; err == nil {
+		ctx.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "Folder export already in progress",
+			"data":    existing,
+		})
+		return
+	}
+
+	_, accessChecked := accessCheckedUser(ctx)
+	export := document.FolderExport{
+		FolderID:      folder.ID,
+		RequestedBy:   *userID,
+		AccessChecked: accessChecked,
+		Status:        document.FolderExportPending,
+		FileName:      fmt.Sprintf("%s.zip", documentUtils.SanitizeFileName(folder.Name)),
+	}
+	if err := db.Create(&export).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to start folder export"))
+		return
+	}
+
+	// An export whose job could not be queued is picked up by the exporter's recovery
+	if err := services.QueueFolderExport(ctx.Request.Context(), export.ID); err != nil {
+		log.Printf("⚠️  Failed to queue folder export %s: %v", export.ID, err)
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"message": "Folder export started",
+		"data":    export,
+	})
+}
+
+// GetFolderExport handles GET /folder-exports/:id - Get the status of a folder export
+// @Summary Get folder export
+// @Description Get the status of a folder export started by the caller, with its file count and archive size once completed
+// @Tags folders
+// @Produce json
+// @Param id path string true "Export ID" format(uuid)
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Folder export"
+// @Failure 400 {object} map[string]string "Invalid export ID format"
+// @Failure 404 {object} map[string]string "Export not found"
+// @Router /folder-exports/{id} [get]
+func GetFolderExport(ctx *gin.Context) {
+	export, ok := findFolderExport(ctx)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    export,
+	})
+}
+
+// DownloadFolderExport handles GET /folder-exports/:id/download - Download the archive of a folder export
+// @Summary Download folder export
+// @Description Download the ZIP archive of a completed folder export started by the caller. Range requests are supported.
+// @Tags folders
+// @Produce application/zip
+// @Param id path string true "Export ID" format(uuid)
+// @Security BearerAuth
+// @Success 200 {file} file "ZIP archive"
+// @Success 206 {file} file "Partial content"
+// @Failure 400 {object} map[string]string "Invalid export ID format"
+// @Failure 404 {object} map[string]string "Export not found"
+// @Failure 409 {object} map[string]string "Export not completed"
+// @Failure 410 {object} map[string]string "Export expired"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /folder-exports/{id}/download [get]
+func DownloadFolderExport(ctx *gin.Context) {
+	export, ok := findFolderExport(ctx)
+	if !ok {
+		return
+	}
+
+	if export.Status == document.FolderExportExpired ||
+		(export.ExpiresAt != nil && export.ExpiresAt.Before(time.Now())) {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeGone, "Export expired").WithDetails("Export the folder again to download it"))
+		return
+	}
+	if export.Status != document.FolderExportCompleted {
+		apperrors.Respond(ctx, apperrors.Conflict("Export not completed").WithDetails(fmt.Sprintf("The export is %s", export.Status)))
+		return
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", export.FileName))
+	serveStoredFile(ctx, export.ObjectKey, export.ArchiveSize, "application/zip", export.ID.String())
+}
+
+// findFolderExport loads the export in the path, writing the error response when it does not exist or
+// was started by another user
+func findFolderExport(ctx *gin.Context) (*document.FolderExport, bool) {
+	exportID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid export ID format"))
+		return nil, false
+	}
+
+	userID := requestUserID(ctx, "")
+
+	var export document.FolderExport
+	if err := requestDB(ctx).First(&export, exportID).Error; err != nil || userID == nil || export.RequestedBy != *userID {
+		apperrors.Respond(ctx, apperrors.NotFound("Folder export not found"))
+		return nil, false
+	}
+	return &export, true
+}
@@ -0,0 +1,327 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/database/models/document"
+	docUtils "forgecrud-backend/shared/utils/document"
+	"forgecrud-backend/shared/utils/query"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RecentDocumentResult is a document the caller accessed recently
+type RecentDocumentResult struct {
+	Document    docUtils.DocumentResponse `json:"document"`
+	AccessedAt  time.Time                 `json:"accessed_at"`
+	AccessCount int                       `json:"access_count"`
+}
+
+// FavoriteDocumentResult is a document the caller has starred
+type FavoriteDocumentResult struct {
+	Document  docUtils.DocumentResponse `json:"document"`
+	StarredAt time.Time                 `json:"starred_at"`
+}
+
+// recentHit is a recently accessed document before it is loaded
+type recentHit struct {
+	ID          uuid.UUID
+	AccessedAt  time.Time
+	AccessCount int
+}
+
+// favoriteHit is a starred document before it is loaded
+type favoriteHit struct {
+	ID        uuid.UUID
+	StarredAt time.Time
+}
+
+// GetRecentDocuments lists the documents the caller opened or downloaded last
+// @Summary Recently accessed documents
+// @Description List the documents the caller viewed or downloaded, most recent first. Documents in the trash or no longer readable are left out.
+// @Tags documents
+// @Produce json
+// @Param page query int false "Page number (default: 1)"
+// @Param limit query int false "Results per page (default: 10, max: 100)"
+// @Param user_id query string false "User ID, for testing purposes"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Recently accessed documents"
+// @Failure 400 {object} map[string]string "Missing user"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /documents/recent [get]
+func GetRecentDocuments(ctx *gin.Context) {
+	db := requestDB(ctx)
+
+	userID := requestUserID(ctx, ctx.Query("user_id"))
+	if userID == nil {
+		apperrors.Respond(ctx, apperrors.BadRequest("user_id is required"))
+		return
+	}
+
+	params := query.ParseQueryParams(ctx)
+
+	dbQuery := readableDocuments(ctx, db.Model(&document.Document{}).
+		Joins("JOIN recent_documents ON recent_documents.document_id = documents.id").
+		Where("recent_documents.user_id = ?", *userID))
+
+	var total int64
+	if err := dbQuery.Count(&total).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to fetch recent documents"))
+		return
+	}
+
+	var hits []recentHit
+	if err := query.ApplyPagination(dbQuery, params.Page, params.Limit).
+		Select("documents.id, recent_documents.accessed_at, recent_documents.access_count").
+		Order("recent_documents.accessed_at DESC").
+		Scan(&hits).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to fetch recent documents"))
+		return
+	}
+
+	ids := make([]uuid.UUID, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.ID
+	}
+	documentsByID, err := loadDocumentsByID(db, ids)
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to fetch documents"))
+		return
+	}
+
+	results := make([]RecentDocumentResult, 0, len(hits))
+	for _, hit := range hits {
+		doc, ok := documentsByID[hit.ID]
+		if !ok {
+			continue
+		}
+		results = append(results, RecentDocumentResult{
+			Document:    docUtils.BuildDocumentResponse(doc, db),
+			AccessedAt:  hit.AccessedAt,
+			AccessCount: hit.AccessCount,
+		})
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"data":       results,
+		"pagination": query.BuildPaginationResponse(params.Page, params.Limit, total),
+	})
+}
+
+// GetFavoriteDocuments lists the documents the caller has starred
+// @Summary Favorite documents
+// @Description List the documents the caller has starred, last starred first. Documents in the trash or no longer readable are left out.
+// @Tags documents
+// @Produce json
+// @Param page query int false "Page number (default: 1)"
+// @Param limit query int false "Results per page (default: 10, max: 100)"
+// @Param user_id query string false "User ID, for testing purposes"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Favorite documents"
+// @Failure 400 {object} map[string]string "Missing user"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /documents/favorites [get]
+func GetFavoriteDocuments(ctx *gin.Context) {
+	db := requestDB(ctx)
+
+	userID := requestUserID(ctx, ctx.Query("user_id"))
+	if userID == nil {
+		apperrors.Respond(ctx, apperrors.BadRequest("user_id is required"))
+		return
+	}
+
+	params := query.ParseQueryParams(ctx)
+
+	dbQuery := readableDocuments(ctx, db.Model(&document.Document{}).
+		Joins("JOIN favorite_documents ON favorite_documents.document_id = documents.id").
+		Where("favorite_documents.user_id = ?", *userID))
+
+	var total int64
+	if err := dbQuery.Count(&total).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to fetch favorite documents"))
+		return
+	}
+
+	var hits []favoriteHit
+	if err := query.ApplyPagination(dbQuery, params.Page, params.Limit).
+		Select("documents.id, favorite_documents.created_at AS starred_at").
+		Order("favorite_documents.created_at DESC").
+		Scan(&hits).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to fetch favorite documents"))
+		return
+	}
+
+	ids := make([]uuid.UUID, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.ID
+	}
+	documentsByID, err := loadDocumentsByID(db, ids)
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to fetch documents"))
+		return
+	}
+
+	results := make([]FavoriteDocumentResult, 0, len(hits))
+	for _, hit := range hits {
+		doc, ok := documentsByID[hit.ID]
+		if !ok {
+			continue
+		}
+		results = append(results, FavoriteDocumentResult{
+			Document:  docUtils.BuildDocumentResponse(doc, db),
+			StarredAt: hit.StarredAt,
+		})
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"data":       results,
+		"pagination": query.BuildPaginationResponse(params.Page, params.Limit, total),
+	})
+}
+
+// StarDocument adds a document to the caller's favorites
+// @Summary Star a document
+// @Description Add a document to the caller's favorites. Starring a starred document changes nothing.
+// @Tags documents
+// @Produce json
+// @Param id path string true "Document ID" format(uuid)
+// @Param user_id query string false "User ID, for testing purposes"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Document starred"
+// @Failure 400 {object} map[string]string "Missing user"
+// @Failure 403 {object} map[string]string "Access denied"
+// @Failure 404 {object} map[string]string "Document not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /documents/{id}/star [post]
+func StarDocument(ctx *gin.Context) {
+	db := requestDB(ctx)
+
+	userID := requestUserID(ctx, ctx.Query("user_id"))
+	if userID == nil {
+		apperrors.Respond(ctx, apperrors.BadRequest("user_id is required"))
+		return
+	}
+
+	var doc document.Document
+	if err := db.First(&doc, "id = ?", ctx.Param("id")).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.NotFound("Document not found"))
+		return
+	}
+
+	if !checkDocumentAccess(ctx, &doc, document.AccessLevelRead) {
+		return
+	}
+
+	favorite := document.FavoriteDocument{
+		UserID:     *userID,
+		DocumentID: doc.ID,
+	}
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&favorite).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to star document"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Document starred successfully",
+	})
+}
+
+// UnstarDocument removes a document from the caller's favorites
+// @Summary Unstar a document
+// @Description Remove a document from the caller's favorites
+// @Tags documents
+// @Produce json
+// @Param id path string true "Document ID" format(uuid)
+// @Param user_id query string false "User ID, for testing purposes"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Document unstarred"
+// @Failure 400 {object} map[string]string "Missing user or invalid document ID"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /documents/{id}/star [delete]
+func UnstarDocument(ctx *gin.Context) {
+	db := requestDB(ctx)
+
+	userID := requestUserID(ctx, ctx.Query("user_id"))
+	if userID == nil {
+		apperrors.Respond(ctx, apperrors.BadRequest("user_id is required"))
+		return
+	}
+
+	documentID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.BadRequest("Invalid document ID format"))
+		return
+	}
+
+	if err := db.Where("user_id = ? AND document_id = ?", *userID, documentID).
+		Delete(&document.FavoriteDocument{}).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to unstar document"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Document unstarred successfully",
+	})
+}
+
+// recordDocumentAccess moves the document to the top of the caller's recently accessed documents.
+// Requests without a forwarded user are not recorded.
+func recordDocumentAccess(ctx *gin.Context, documentID uuid.UUID) {
+	userID := requestUserID(ctx, "")
+	if userID == nil {
+		return
+	}
+
+	now := time.Now()
+	access := document.RecentDocument{
+		UserID:      *userID,
+		DocumentID:  documentID,
+		AccessedAt:  now,
+		AccessCount: 1,
+	}
+	err := requestDB(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "document_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"accessed_at":  now,
+			"access_count": gorm.Expr("recent_documents.access_count + 1"),
+		}),
+	}).Create(&access).Error
+	if err != nil {
+		fmt.Printf("Warning: Failed to record document access: %v\n", err)
+	}
+}
+
+// isSeekRequest reports whether a download continues an earlier one at a later position, which is
+// not another access of the document
+func isSeekRequest(ctx *gin.Context) bool {
+	rangeHeader := ctx.GetHeader("Range")
+	return rangeHeader != "" && !strings.HasPrefix(rangeHeader, "bytes=0-")
+}
+
+// loadDocumentsByID loads documents with their folders, keyed by ID
+func loadDocumentsByID(db *gorm.DB, ids []uuid.UUID) (map[uuid.UUID]*document.Document, error) {
+	documentsByID := make(map[uuid.UUID]*document.Document, len(ids))
+	if len(ids) == 0 {
+		return documentsByID, nil
+	}
+
+	var documents []document.Document
+	if err := db.Preload("Folder").Where("id IN ?", ids).Find(&documents).Error; err != nil {
+		return nil, err
+	}
+	for i := range documents {
+		documentsByID[documents[i].ID] = &documents[i]
+	}
+	return documentsByID, nil
+}
@@ -2,21 +2,20 @@ package handlers
 
 import (
 	"archive/zip"
-	"context"
 	"fmt"
-	"io"
 	"net/http"
 	"path/filepath"
-	"strings"
 	"time"
 
 	"forgecrud-backend/document-service/services"
-	"forgecrud-backend/shared/clients"
-	"forgecrud-backend/shared/database"
-	"forgecrud-backend/shared/database/models"
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/config"
 	"forgecrud-backend/shared/database/models/document"
+	"forgecrud-backend/shared/messaging"
+	utils "forgecrud-backend/shared/utils/auth"
 	documentUtils "forgecrud-backend/shared/utils/document"
 	"forgecrud-backend/shared/utils/query"
+	"forgecrud-backend/shared/utils/validation"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -53,12 +52,15 @@ type MoveFolderRequest struct {
 // @Param filters[parent_id] query string false "Filter by parent folder ID"
 // @Param sort[field] query string false "Sort field (name, path, created_at, updated_at, file_count, total_size)"
 // @Param sort[order] query string false "Sort order (asc, desc)"
+// @Param pagination query string false "Set to cursor for keyset pagination (ordered by created_at, no total count)"
+// @Param cursor query string false "Opaque cursor from pagination.next_cursor of the previous page"
 // @Security BearerAuth
 // @Success 200 {object} map[string]interface{} "List of folders with pagination"
+// @Failure 400 {object} map[string]string "Invalid cursor"
 // @Failure 500 {object} map[string]string "Server error"
 // @Router /folders [get]
 func GetFolders(ctx *gin.Context) {
-	db := database.DB
+	db := requestDB(ctx)
 
 	// Parse query parameters
 	params := query.ParseQueryParams(ctx)
@@ -68,6 +70,7 @@ func GetFolders(ctx *gin.Context) {
 		"owner_id":   "owner_id",
 		"owner_type": "owner_type",
 		"parent_id":  "parent_id",
+		"created_at": "created_at",
 	}
 
 	// Define allowed sort fields
@@ -84,20 +87,44 @@ func GetFolders(ctx *gin.Context) {
 	searchFields := []string{"name", "path"}
 
 	// Build query
-	dbQuery := db.Model(&document.Folder{})
+	dbQuery := readableFolders(ctx, db.Model(&document.Folder{}))
 
 	// Apply filters, search, sorting, and pagination
 	dbQuery = query.ApplyFilters(dbQuery, params.Filters, allowedFilters)
 	dbQuery = query.ApplySearch(dbQuery, params.Search, searchFields)
+
+	// Keyset pagination skips the total count, which gets expensive on large tables
+	if params.CursorMode {
+		cursorQuery, err := query.ApplyCursor(dbQuery, params, "folders")
+		if err != nil {
+			apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid cursor"))
+			return
+		}
+
+		var folders []document.Folder
+		if err := cursorQuery.Find(&folders).Error; err != nil {
+			apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to fetch folders"))
+			return
+		}
+
+		folders, pagination := query.TrimCursorPage(folders, params.Limit, func(folder document.Folder) (time.Time, string) {
+			return folder.CreatedAt, folder.ID.String()
+		})
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"success":    true,
+			"data":       documentUtils.BuildFolderListResponse(folders),
+			"pagination": pagination,
+		})
+		return
+	}
+
 	dbQuery = query.ApplySort(dbQuery, params.Sort, allowedSortFields)
 
 	// Get total count for pagination
 	var total int64
 	if err := dbQuery.Count(&total).Error; err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to count folders",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to count folders"))
 		return
 	}
 
@@ -107,10 +134,7 @@ func GetFolders(ctx *gin.Context) {
 	// Execute query
 	var folders []document.Folder
 	if err := dbQuery.Find(&folders).Error; err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to fetch folders",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to fetch folders"))
 		return
 	}
 
@@ -139,6 +163,7 @@ func GetFolders(ctx *gin.Context) {
 // @Security BearerAuth
 // @Success 200 {object} map[string]interface{} "Folder details"
 // @Failure 400 {object} map[string]string "Invalid folder ID format"
+// @Failure 403 {object} map[string]string "Access denied"
 // @Failure 404 {object} map[string]string "Folder not found"
 // @Failure 500 {object} map[string]string "Server error"
 // @Router /folders/{id} [get]
@@ -146,28 +171,23 @@ func GetFolder(ctx *gin.Context) {
 	folderID := ctx.Param("id")
 	folderUUID, err := uuid.Parse(folderID)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid folder ID format",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid folder ID format"))
 		return
 	}
 
-	db := database.DB
+	db := requestDB(ctx)
 
 	var folder document.Folder
 	if err := db.First(&folder, folderUUID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			ctx.JSON(http.StatusNotFound, gin.H{
-				"error":   "Folder not found",
-				"message": "Folder with the given ID does not exist",
-			})
+			apperrors.Respond(ctx, apperrors.NotFound("Folder not found").WithDetails("Folder with the given ID does not exist"))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to fetch folder",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to fetch folder"))
+		return
+	}
+
+	if !checkFolderAccess(ctx, &folder, document.AccessLevelRead) {
 		return
 	}
 
@@ -190,6 +210,7 @@ func GetFolder(ctx *gin.Context) {
 // @Security BearerAuth
 // @Success 200 {object} map[string]interface{} "Folder contents"
 // @Failure 400 {object} map[string]string "Invalid folder ID format"
+// @Failure 403 {object} map[string]string "Access denied"
 // @Failure 404 {object} map[string]string "Folder not found"
 // @Failure 500 {object} map[string]string "Server error"
 // @Router /folders/{id}/contents [get]
@@ -197,49 +218,38 @@ func GetFolderContents(ctx *gin.Context) {
 	folderID := ctx.Param("id")
 	folderUUID, err := uuid.Parse(folderID)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid folder ID format",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid folder ID format"))
 		return
 	}
 
-	db := database.DB
+	db := requestDB(ctx)
 
 	// Check if folder exists
 	var folder document.Folder
 	if err := db.First(&folder, folderUUID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			ctx.JSON(http.StatusNotFound, gin.H{
-				"error":   "Folder not found",
-				"message": "Folder with the given ID does not exist",
-			})
+			apperrors.Respond(ctx, apperrors.NotFound("Folder not found").WithDetails("Folder with the given ID does not exist"))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to fetch folder",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to fetch folder"))
+		return
+	}
+
+	if !checkFolderAccess(ctx, &folder, document.AccessLevelRead) {
 		return
 	}
 
 	// Get subfolders
 	var subfolders []document.Folder
-	if err := db.Where("parent_id = ?", folderUUID).Find(&subfolders).Error; err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to fetch subfolders",
-			"message": err.Error(),
-		})
+	if err := readableFolders(ctx, db.Where("parent_id = ?", folderUUID)).Find(&subfolders).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to fetch subfolders"))
 		return
 	}
 
 	// Get documents
 	var documents []document.Document
-	if err := db.Where("folder_id = ?", folderUUID).Find(&documents).Error; err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to fetch documents",
-			"message": err.Error(),
-		})
+	if err := readableDocuments(ctx, db.Where("folder_id = ?", folderUUID)).Find(&documents).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to fetch documents"))
 		return
 	}
 
@@ -268,46 +278,35 @@ func GetFolderContents(ctx *gin.Context) {
 // @Security BearerAuth
 // @Success 201 {object} map[string]interface{} "Created folder"
 // @Failure 400 {object} map[string]string "Invalid request data"
+// @Failure 403 {object} map[string]string "Access denied"
 // @Failure 409 {object} map[string]string "Folder already exists"
 // @Failure 500 {object} map[string]string "Server error"
 // @Router /folders [post]
 func CreateFolder(ctx *gin.Context) {
 	var req CreateFolderRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request body",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, validation.Error(err, "Invalid request body"))
 		return
 	}
 
-	db := database.DB
+	db := requestDB(ctx)
 
 	// Validate folder name
 	if err := documentUtils.ValidateFolderName(req.Name); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid folder name",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid folder name"))
 		return
 	}
 
 	// Parse owner ID
 	ownerUUID, err := uuid.Parse(req.OwnerID)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid owner ID format",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid owner ID format"))
 		return
 	}
 
 	// Validate owner type
 	if req.OwnerType != "user" && req.OwnerType != "organization" {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid owner type",
-			"message": "Owner type must be 'user' or 'organization'",
-		})
+		apperrors.Respond(ctx, apperrors.BadRequest("Invalid owner type").WithDetails("Owner type must be 'user' or 'organization'"))
 		return
 	}
 
@@ -318,35 +317,27 @@ func CreateFolder(ctx *gin.Context) {
 	if req.ParentID != nil {
 		parentUUID, err := uuid.Parse(*req.ParentID)
 		if err != nil {
-			ctx.JSON(http.StatusBadRequest, gin.H{
-				"error":   "Invalid parent ID format",
-				"message": err.Error(),
-			})
+			apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid parent ID format"))
 			return
 		}
 
 		parentFolder = &document.Folder{}
 		if err := db.First(parentFolder, parentUUID).Error; err != nil {
 			if err == gorm.ErrRecordNotFound {
-				ctx.JSON(http.StatusBadRequest, gin.H{
-					"error":   "Parent folder not found",
-					"message": "The specified parent folder does not exist",
-				})
+				apperrors.Respond(ctx, apperrors.BadRequest("Parent folder not found").WithDetails("The specified parent folder does not exist"))
 				return
 			}
-			ctx.JSON(http.StatusInternalServerError, gin.H{
-				"error":   "Failed to validate parent folder",
-				"message": err.Error(),
-			})
+			apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to validate parent folder"))
+			return
+		}
+
+		if !checkFolderAccess(ctx, parentFolder, document.AccessLevelWrite) {
 			return
 		}
 
 		// Check owner consistency
 		if parentFolder.OwnerID != ownerUUID || parentFolder.OwnerType != req.OwnerType {
-			ctx.JSON(http.StatusBadRequest, gin.H{
-				"error":   "Owner mismatch",
-				"message": "Folder owner must match parent folder owner",
-			})
+			apperrors.Respond(ctx, apperrors.BadRequest("Owner mismatch").WithDetails("Folder owner must match parent folder owner"))
 			return
 		}
 
@@ -367,10 +358,13 @@ func CreateFolder(ctx *gin.Context) {
 	}
 
 	if err := query.First(&existingFolder).Error; err == nil {
-		ctx.JSON(http.StatusConflict, gin.H{
-			"error":   "Folder already exists",
-			"message": "A folder with this name already exists in the parent directory",
-		})
+		apperrors.Respond(ctx, apperrors.Conflict("Folder already exists").WithDetails("A folder with this name already exists in the parent directory"))
+		return
+	}
+
+	// Paths stay taken while a folder is in the trash
+	if err := db.Unscoped().Where("path = ? AND deleted_at IS NOT NULL", folderPath).First(&existingFolder).Error; err == nil {
+		apperrors.Respond(ctx, apperrors.Conflict("Folder is in the trash").WithDetails("A folder with this name is in the trash, restore it or empty the trash first"))
 		return
 	}
 
@@ -389,36 +383,30 @@ func CreateFolder(ctx *gin.Context) {
 		folder.ParentID = &parentUUID
 	}
 
-	if err := db.Create(&folder).Error; err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to create folder",
-			"message": err.Error(),
-		})
-		return
-	}
-
-	// Create folder in MinIO
-	minioService, err := services.NewMinIOService()
+	storage, err := services.NewStorageProvider()
 	if err != nil {
-		// Cleanup database record
-		db.Delete(&folder)
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Storage service unavailable",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Storage service unavailable"))
 		return
 	}
 
-	if err := minioService.CreateFolder(folder.Path); err != nil {
-		// Cleanup database record
-		db.Delete(&folder)
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to create folder in storage",
-			"message": err.Error(),
-		})
+	// Create the folder and queue it for storage together, so a storage failure is retried instead of
+	// leaving a folder without its storage counterpart
+	var operation *document.StorageOperation
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&folder).Error; err != nil {
+			return err
+		}
+		queued, err := services.QueueCreateFolder(tx, &folder)
+		operation = queued
+		return err
+	}); err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to create folder"))
 		return
 	}
 
+	// Create folder in storage, retried in the background when it fails
+	services.NewStorageOutbox(storage).Run(operation)
+
 	// Build response
 	folderResponse := documentUtils.BuildFolderResponse(&folder)
 
@@ -440,6 +428,7 @@ func CreateFolder(ctx *gin.Context) {
 // @Security BearerAuth
 // @Success 200 {object} map[string]interface{} "Updated folder"
 // @Failure 400 {object} map[string]string "Invalid request data or ID format"
+// @Failure 403 {object} map[string]string "Access denied"
 // @Failure 404 {object} map[string]string "Folder not found"
 // @Failure 409 {object} map[string]string "Folder name conflict"
 // @Failure 500 {object} map[string]string "Server error"
@@ -448,30 +437,21 @@ func UpdateFolder(ctx *gin.Context) {
 	folderID := ctx.Param("id")
 	folderUUID, err := uuid.Parse(folderID)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid folder ID format",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid folder ID format"))
 		return
 	}
 
 	var req UpdateFolderRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request body",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, validation.Error(err, "Invalid request body"))
 		return
 	}
 
-	db := database.DB
+	db := requestDB(ctx)
 
 	// Validate folder name
 	if err := documentUtils.ValidateFolderName(req.Name); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid folder name",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid folder name"))
 		return
 	}
 
@@ -479,25 +459,20 @@ func UpdateFolder(ctx *gin.Context) {
 	var folder document.Folder
 	if err := db.First(&folder, folderUUID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			ctx.JSON(http.StatusNotFound, gin.H{
-				"error":   "Folder not found",
-				"message": "Folder with the given ID does not exist",
-			})
+			apperrors.Respond(ctx, apperrors.NotFound("Folder not found").WithDetails("Folder with the given ID does not exist"))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to fetch folder",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to fetch folder"))
+		return
+	}
+
+	if !checkFolderAccess(ctx, &folder, document.AccessLevelWrite) {
 		return
 	}
 
 	// Check if name is different
 	if folder.Name == req.Name {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error":   "No changes",
-			"message": "Folder name is already the same",
-		})
+		apperrors.Respond(ctx, apperrors.BadRequest("No changes").WithDetails("Folder name is already the same"))
 		return
 	}
 
@@ -513,10 +488,7 @@ func UpdateFolder(ctx *gin.Context) {
 	}
 
 	if err := query.First(&existingFolder).Error; err == nil {
-		ctx.JSON(http.StatusConflict, gin.H{
-			"error":   "Folder name conflict",
-			"message": "A folder with this name already exists in the parent directory",
-		})
+		apperrors.Respond(ctx, apperrors.Conflict("Folder name conflict").WithDetails("A folder with this name already exists in the parent directory"))
 		return
 	}
 
@@ -530,6 +502,7 @@ func UpdateFolder(ctx *gin.Context) {
 	}
 
 	newPath := documentUtils.GenerateFolderPath(parentPath, req.Name)
+	oldPath := folder.Path
 
 	// Start transaction for updating folder and all subfolders
 	tx := db.Begin()
@@ -545,10 +518,7 @@ func UpdateFolder(ctx *gin.Context) {
 		"path": newPath,
 	}).Error; err != nil {
 		tx.Rollback()
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to update folder",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to update folder"))
 		return
 	}
 
@@ -563,10 +533,7 @@ func UpdateFolder(ctx *gin.Context) {
 
 			if err := tx.Model(&subfolder).Update("path", newSubfolderPath).Error; err != nil {
 				tx.Rollback()
-				ctx.JSON(http.StatusInternalServerError, gin.H{
-					"error":   "Failed to update subfolder paths",
-					"message": err.Error(),
-				})
+				apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to update subfolder paths"))
 				return
 			}
 		}
@@ -580,24 +547,31 @@ func UpdateFolder(ctx *gin.Context) {
 			newDocPath := filepath.Join(newPath, doc.FileName)
 			if err := tx.Model(&doc).Update("path", newDocPath).Error; err != nil {
 				tx.Rollback()
-				ctx.JSON(http.StatusInternalServerError, gin.H{
-					"error":   "Failed to update document paths",
-					"message": err.Error(),
-				})
+				apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to update document paths"))
 				return
 			}
 		}
 	}
 
+	// Queue the rename for storage together with the new paths
+	operation, err := services.QueueMoveFolder(tx, folder.ID, oldPath, newPath)
+	if err != nil {
+		tx.Rollback()
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to update folder"))
+		return
+	}
+
 	// Commit transaction
 	if err := tx.Commit().Error; err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to commit updates",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to commit updates"))
 		return
 	}
 
+	// Rename folder in storage, retried in the background when it fails
+	if storage, err := services.NewStorageProvider(); err == nil {
+		services.NewStorageOutbox(storage).Run(operation)
+	}
+
 	// Refresh folder data
 	db.First(&folder, folderUUID)
 	folderResponse := documentUtils.BuildFolderResponse(&folder)
@@ -620,116 +594,90 @@ func UpdateFolder(ctx *gin.Context) {
 // @Security BearerAuth
 // @Success 200 {object} map[string]interface{} "Folder moved successfully"
 // @Failure 400 {object} map[string]string "Invalid request data or folder ID format"
+// @Failure 403 {object} map[string]string "Access denied"
 // @Failure 404 {object} map[string]string "Folder not found"
 // @Failure 409 {object} map[string]string "Folder name conflict or circular dependency"
+// @Failure 423 {object} map[string]string "Folder in a folder under legal hold"
 // @Failure 500 {object} map[string]string "Server error"
 // @Router /folders/{id}/move [post]
 func MoveFolder(ctx *gin.Context) {
 	folderID := ctx.Param("id")
 	folderUUID, err := uuid.Parse(folderID)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid folder ID format",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid folder ID format"))
 		return
 	}
 
 	var req MoveFolderRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request body",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, validation.Error(err, "Invalid request body"))
 		return
 	}
 
-	db := database.DB
+	db := requestDB(ctx)
 
 	// Check if folder exists
 	var folder document.Folder
 	if err := db.First(&folder, folderUUID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			ctx.JSON(http.StatusNotFound, gin.H{
-				"error":   "Folder not found",
-				"message": "Folder with the given ID does not exist",
-			})
+			apperrors.Respond(ctx, apperrors.NotFound("Folder not found").WithDetails("Folder with the given ID does not exist"))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to fetch folder",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to fetch folder"))
+		return
+	}
+
+	if !checkFolderAccess(ctx, &folder, document.AccessLevelWrite) {
 		return
 	}
 
 	var targetParentFolder *document.Folder
-	var targetParentPath string
 
 	// Validate target parent if provided
 	if req.TargetParentID != nil {
 		targetParentUUID, err := uuid.Parse(*req.TargetParentID)
 		if err != nil {
-			ctx.JSON(http.StatusBadRequest, gin.H{
-				"error":   "Invalid target parent ID format",
-				"message": err.Error(),
-			})
+			apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid target parent ID format"))
 			return
 		}
 
 		// Prevent moving to self or subfolder
 		if targetParentUUID == folderUUID {
-			ctx.JSON(http.StatusBadRequest, gin.H{
-				"error":   "Invalid move operation",
-				"message": "Cannot move folder to itself",
-			})
+			apperrors.Respond(ctx, apperrors.BadRequest("Invalid move operation").WithDetails("Cannot move folder to itself"))
 			return
 		}
 
 		targetParentFolder = &document.Folder{}
 		if err := db.First(targetParentFolder, targetParentUUID).Error; err != nil {
 			if err == gorm.ErrRecordNotFound {
-				ctx.JSON(http.StatusBadRequest, gin.H{
-					"error":   "Target parent folder not found",
-					"message": "The specified target parent folder does not exist",
-				})
+				apperrors.Respond(ctx, apperrors.BadRequest("Target parent folder not found").WithDetails("The specified target parent folder does not exist"))
 				return
 			}
-			ctx.JSON(http.StatusInternalServerError, gin.H{
-				"error":   "Failed to validate target parent folder",
-				"message": err.Error(),
-			})
+			apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to validate target parent folder"))
+			return
+		}
+
+		if !checkFolderAccess(ctx, targetParentFolder, document.AccessLevelWrite) {
 			return
 		}
 
 		// Check owner consistency
 		if targetParentFolder.OwnerID != folder.OwnerID || targetParentFolder.OwnerType != folder.OwnerType {
-			ctx.JSON(http.StatusBadRequest, gin.H{
-				"error":   "Owner mismatch",
-				"message": "Target parent folder must have the same owner",
-			})
+			apperrors.Respond(ctx, apperrors.BadRequest("Owner mismatch").WithDetails("Target parent folder must have the same owner"))
 			return
 		}
 
 		// Prevent circular dependency - check if target is a subfolder
 		if isSubfolderOf(db, targetParentUUID, folderUUID) {
-			ctx.JSON(http.StatusBadRequest, gin.H{
-				"error":   "Circular dependency",
-				"message": "Cannot move folder to its own subfolder",
-			})
+			apperrors.Respond(ctx, apperrors.BadRequest("Circular dependency").WithDetails("Cannot move folder to its own subfolder"))
 			return
 		}
-
-		targetParentPath = targetParentFolder.Path
 	}
 
 	// Check if same parent (no move needed)
 	if (req.TargetParentID == nil && folder.ParentID == nil) ||
 		(req.TargetParentID != nil && folder.ParentID != nil && *req.TargetParentID == folder.ParentID.String()) {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error":   "No move needed",
-			"message": "Folder is already in the target location",
-		})
+		apperrors.Respond(ctx, apperrors.BadRequest("No move needed").WithDetails("Folder is already in the target location"))
 		return
 	}
 
@@ -746,92 +694,17 @@ func MoveFolder(ctx *gin.Context) {
 	}
 
 	if err := query.First(&existingFolder).Error; err == nil {
-		ctx.JSON(http.StatusConflict, gin.H{
-			"error":   "Folder name conflict",
-			"message": "A folder with this name already exists in the target directory",
-		})
+		apperrors.Respond(ctx, apperrors.Conflict("Folder name conflict").WithDetails("A folder with this name already exists in the target directory"))
 		return
 	}
 
-	// Generate new path
-	newPath := documentUtils.GenerateFolderPath(targetParentPath, folder.Name)
-
-	// Store original path before updating
-	oldPath := folder.Path
-
-	// Start transaction for moving folder and updating all subfolders
-	tx := db.Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-		}
-	}()
-
-	// Update folder
-	updateData := map[string]interface{}{
-		"path": newPath,
-	}
-
-	if req.TargetParentID != nil {
-		targetParentUUID, _ := uuid.Parse(*req.TargetParentID)
-		updateData["parent_id"] = targetParentUUID
-	} else {
-		updateData["parent_id"] = nil
-	}
-
-	if err := tx.Model(&folder).Updates(updateData).Error; err != nil {
-		tx.Rollback()
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to move folder",
-			"message": err.Error(),
-		})
-		return
-	}
-
-	// Update all subfolders' paths
-	if err := updateSubfolderPaths(tx, folder.Path, newPath); err != nil {
-		tx.Rollback()
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to update subfolder paths",
-			"message": err.Error(),
-		})
-		return
-	}
-
-	// Update documents' paths in this folder and subfolders
-	if err := updateDocumentPaths(tx, folder.Path, newPath); err != nil {
-		tx.Rollback()
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to update document paths",
-			"message": err.Error(),
-		})
+	if reason, err := services.FolderMoveBlock(db, &folder, targetParentFolder); err != nil || reason != "" {
+		respondMoveBlocked(ctx, reason, err)
 		return
 	}
 
-	// Commit transaction
-	if err := tx.Commit().Error; err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to commit move operation",
-			"message": err.Error(),
-		})
-		return
-	}
-
-	// Move folder in MinIO after successful database update
-	minioService, err := services.NewMinIOService()
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Storage service unavailable",
-			"message": err.Error(),
-		})
-		return
-	}
-
-	if err := minioService.MoveFolder(oldPath, newPath); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to move folder in storage",
-			"message": err.Error(),
-		})
+	if err := moveFolder(db, &folder, targetParentFolder); err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to move folder"))
 		return
 	}
 
@@ -848,45 +721,42 @@ func MoveFolder(ctx *gin.Context) {
 
 // DeleteFolder handles DELETE /folders/:id - Delete folder
 // @Summary Delete a folder
-// @Description Delete an empty folder (folder must not contain any subfolders or documents)
+// @Description Move an empty folder to the trash (folder must not contain any subfolders or documents outside the trash)
 // @Tags folders
 // @Accept json
 // @Produce json
 // @Param id path string true "Folder ID" format(uuid)
 // @Security BearerAuth
-// @Success 200 {object} map[string]interface{} "Folder deleted successfully"
+// @Success 200 {object} map[string]interface{} "Folder moved to trash"
 // @Failure 400 {object} map[string]string "Invalid folder ID format"
+// @Failure 403 {object} map[string]string "Access denied"
 // @Failure 404 {object} map[string]string "Folder not found"
 // @Failure 409 {object} map[string]string "Folder contains subfolders or documents"
+// @Failure 423 {object} map[string]string "Folder under legal hold"
 // @Failure 500 {object} map[string]string "Server error"
 // @Router /folders/{id} [delete]
 func DeleteFolder(ctx *gin.Context) {
 	folderID := ctx.Param("id")
 	folderUUID, err := uuid.Parse(folderID)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid folder ID format",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid folder ID format"))
 		return
 	}
 
-	db := database.DB
+	db := requestDB(ctx)
 
 	// Check if folder exists
 	var folder document.Folder
 	if err := db.First(&folder, folderUUID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			ctx.JSON(http.StatusNotFound, gin.H{
-				"error":   "Folder not found",
-				"message": "Folder with the given ID does not exist",
-			})
+			apperrors.Respond(ctx, apperrors.NotFound("Folder not found").WithDetails("Folder with the given ID does not exist"))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to fetch folder",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to fetch folder"))
+		return
+	}
+
+	if !checkFolderAccess(ctx, &folder, document.AccessLevelWrite) {
 		return
 	}
 
@@ -894,10 +764,7 @@ func DeleteFolder(ctx *gin.Context) {
 	var subfolderCount int64
 	db.Model(&document.Folder{}).Where("parent_id = ?", folderUUID).Count(&subfolderCount)
 	if subfolderCount > 0 {
-		ctx.JSON(http.StatusConflict, gin.H{
-			"error":   "Folder has subfolders",
-			"message": "Cannot delete folder that contains subfolders",
-		})
+		apperrors.Respond(ctx, apperrors.Conflict("Folder has subfolders").WithDetails("Cannot delete folder that contains subfolders"))
 		return
 	}
 
@@ -905,96 +772,132 @@ func DeleteFolder(ctx *gin.Context) {
 	var documentCount int64
 	db.Model(&document.Document{}).Where("folder_id = ?", folderUUID).Count(&documentCount)
 	if documentCount > 0 {
-		ctx.JSON(http.StatusConflict, gin.H{
-			"error":   "Folder has documents",
-			"message": "Cannot delete folder that contains documents",
-		})
+		apperrors.Respond(ctx, apperrors.Conflict("Folder has documents").WithDetails("Cannot delete folder that contains documents"))
 		return
 	}
 
-	minioService, err := services.NewMinIOService()
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Storage service unavailable",
-			"message": err.Error(),
-		})
+	if !checkFolderDeletable(ctx, db, &folder) {
 		return
 	}
 
-	// MinIO'dan folder'ı sil
-	if err := minioService.DeleteFolder(folder.Path); err != nil {
-		fmt.Printf("Warning: Failed to delete folder from MinIO: %v\n", err)
-	}
-
-	// Delete folder
-	if err := db.Delete(&folder).Error; err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to delete folder",
-			"message": err.Error(),
-		})
+	// Move to the trash, the MinIO folder is removed when the trash is purged
+	if err := moveToTrash(ctx, db, &folder); err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to delete folder"))
 		return
 	}
 
-	// After successful deletion, get user info for notification
-	var user models.User
-	if err := db.Where("id = ?", folder.OwnerID).First(&user).Error; err != nil {
-		fmt.Printf("Warning: Could not fetch user info for notification: %v\n", err)
-	} else {
-		notificationClient := clients.NewNotificationClient()
-
-		go func() { // Async olarak gönder, response'u bloklamasın
-			err := notificationClient.SendUserActionEmail(clients.UserActionEmailRequest{
-				AdminName:    "System Admin",
-				UserName:     fmt.Sprintf("%s %s", user.FirstName, user.LastName),
-				UserEmail:    user.Email,
-				UserRole:     folder.OwnerType,
-				IPAddress:    ctx.ClientIP(),
-				ActionType:   "Folder Deletion",
-				ResourceName: folder.Name,
-				Status:       "Completed",
-				Priority:     "high",
-				PriorityText: "High",
-				Description: fmt.Sprintf("Folder '%s' deleted from path '%s' (contained %d files, %.2f KB total)",
-					folder.Name, folder.Path, folder.FileCount, float64(folder.TotalSize)/1024),
-				Changes: []clients.UserActionChange{
-					{
-						Field:    "Folder Status",
-						OldValue: "Active",
-						NewValue: "Deleted",
-					},
-					{
-						Field:    "Folder Path",
-						OldValue: folder.Path,
-						NewValue: "N/A",
-					},
-					{
-						Field:    "File Count",
-						OldValue: fmt.Sprintf("%d files", folder.FileCount),
-						NewValue: "0 files",
-					},
-					{
-						Field:    "Total Size",
-						OldValue: fmt.Sprintf("%d bytes", folder.TotalSize),
-						NewValue: "0 bytes",
-					},
-				},
-				Timestamp: time.Now().Format(time.RFC3339),
-			})
-
-			if err != nil {
-				fmt.Printf("Warning: Failed to send folder deletion notification: %v\n", err)
-			}
-		}()
-	}
+	// Notify the folder owner through the notification service
+	publishFolderDeleted(ctx, &folder)
 
 	ctx.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "Folder deleted successfully",
+		"message": "Folder moved to trash",
 	})
 }
 
 // Helper functions
 
+// moveFolder moves a folder with its subfolders and documents under the target parent folder, to the
+// root when targetParent is nil
+func moveFolder(db *gorm.DB, folder *document.Folder, targetParent *document.Folder) error {
+	targetParentPath := ""
+	var targetParentID *uuid.UUID
+	if targetParent != nil {
+		targetParentPath = targetParent.Path
+		targetParentID = &targetParent.ID
+	}
+
+	// Generate new path
+	newPath := documentUtils.GenerateFolderPath(targetParentPath, folder.Name)
+
+	// Store original path and parent before updating
+	oldPath := folder.Path
+	oldParentID := folder.ParentID
+
+	storage, err := services.NewStorageProvider()
+	if err != nil {
+		return fmt.Errorf("storage service unavailable: %v", err)
+	}
+
+	// Move the folder, update all subfolders and documents and queue the storage move in one transaction
+	var operation *document.StorageOperation
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(folder).Updates(map[string]interface{}{
+			"path":      newPath,
+			"parent_id": targetParentID,
+		}).Error; err != nil {
+			return err
+		}
+
+		// Update all subfolders' paths
+		if err := updateSubfolderPaths(tx, oldPath, newPath); err != nil {
+			return fmt.Errorf("failed to update subfolder paths: %v", err)
+		}
+
+		// Update documents' paths in this folder and subfolders
+		if err := updateDocumentPaths(tx, oldPath, newPath); err != nil {
+			return fmt.Errorf("failed to update document paths: %v", err)
+		}
+
+		queued, err := services.QueueMoveFolder(tx, folder.ID, oldPath, newPath)
+		operation = queued
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	// Move folder in storage, retried in the background when it fails
+	services.NewStorageOutbox(storage).Run(operation)
+
+	// The stats of the folder leave the old ancestors and join the new ones
+	if oldParentID != nil {
+		services.QueueFolderStats(*oldParentID)
+	}
+	services.QueueFolderStats(folder.ID)
+
+	return nil
+}
+
+// publishFolderDeleted notifies the folder owner through the notification service, and the subscribers of
+// its parent, that a folder moved to the trash
+func publishFolderDeleted(ctx *gin.Context, folder *document.Folder) {
+	services.RecordFolderDeleted(requestDB(ctx), folder, utils.GetActorID(ctx))
+	messaging.Publish(ctx.Request.Context(), messaging.EventFolderDeleted, utils.GetActorID(ctx), messaging.ActivityData{
+		OwnerID:      folder.OwnerID,
+		ActionType:   "Folder Deletion",
+		ResourceType: "folder",
+		ResourceID:   folder.ID,
+		ResourceName: folder.Name,
+		Description: fmt.Sprintf("Folder '%s' at path '%s' moved to trash (contained %d files, %.2f KB total)",
+			folder.Name, folder.Path, folder.FileCount, float64(folder.TotalSize)/1024),
+		Priority:  "high",
+		IPAddress: ctx.ClientIP(),
+		Changes: []messaging.ActivityChange{
+			{
+				Field:    "Folder Status",
+				OldValue: "Active",
+				NewValue: "Trash",
+			},
+			{
+				Field:    "Folder Path",
+				OldValue: folder.Path,
+				NewValue: "N/A",
+			},
+			{
+				Field:    "File Count",
+				OldValue: fmt.Sprintf("%d files", folder.FileCount),
+				NewValue: "0 files",
+			},
+			{
+				Field:    "Total Size",
+				OldValue: fmt.Sprintf("%d bytes", folder.TotalSize),
+				NewValue: "0 bytes",
+			},
+		},
+	})
+}
+
 // isSubfolderOf checks if targetID is a subfolder of parentID
 func isSubfolderOf(db *gorm.DB, targetID, parentID uuid.UUID) bool {
 	var folder document.Folder
@@ -1015,14 +918,15 @@ func isSubfolderOf(db *gorm.DB, targetID, parentID uuid.UUID) bool {
 
 // updateSubfolderPaths updates paths for all subfolders
 func updateSubfolderPaths(tx *gorm.DB, oldParentPath, newParentPath string) error {
+	// Subfolders in the trash move along so they can be restored in place
 	var subfolders []document.Folder
-	if err := tx.Where("path LIKE ?", oldParentPath+"/%").Find(&subfolders).Error; err != nil {
+	if err := tx.Unscoped().Where("path LIKE ?", oldParentPath+"/%").Find(&subfolders).Error; err != nil {
 		return err
 	}
 
 	for _, subfolder := range subfolders {
 		newSubfolderPath := newParentPath + subfolder.Path[len(oldParentPath):]
-		if err := tx.Model(&subfolder).Update("path", newSubfolderPath).Error; err != nil {
+		if err := tx.Unscoped().Model(&subfolder).Update("path", newSubfolderPath).Error; err != nil {
 			return err
 		}
 	}
@@ -1034,8 +938,8 @@ func updateSubfolderPaths(tx *gorm.DB, oldParentPath, newParentPath string) erro
 func updateDocumentPaths(tx *gorm.DB, oldFolderPath, newFolderPath string) error {
 	var documents []document.Document
 
-	// Get documents in the folder and all subfolders
-	if err := tx.Joins("JOIN folders ON documents.folder_id = folders.id").
+	// Get documents in the folder and all subfolders, including the trash
+	if err := tx.Unscoped().Joins("JOIN folders ON documents.folder_id = folders.id").
 		Where("folders.path = ? OR folders.path LIKE ?", oldFolderPath, oldFolderPath+"/%").
 		Find(&documents).Error; err != nil {
 		return err
@@ -1044,13 +948,13 @@ func updateDocumentPaths(tx *gorm.DB, oldFolderPath, newFolderPath string) error
 	for _, doc := range documents {
 		// Get the folder path for this document
 		var docFolder document.Folder
-		if err := tx.First(&docFolder, doc.FolderID).Error; err != nil {
+		if err := tx.Unscoped().First(&docFolder, doc.FolderID).Error; err != nil {
 			continue
 		}
 
 		// Calculate new document path
 		newDocPath := filepath.Join(docFolder.Path, doc.FileName)
-		if err := tx.Model(&doc).Update("path", newDocPath).Error; err != nil {
+		if err := tx.Unscoped().Model(&doc).Update("path", newDocPath).Error; err != nil {
 			return err
 		}
 	}
@@ -1058,41 +962,66 @@ func updateDocumentPaths(tx *gorm.DB, oldFolderPath, newFolderPath string) error
 	return nil
 }
 
-// updateFolderStats recalculates and updates folder statistics (file_count and total_size)
-// Includes files from this folder AND all subfolders recursively
-func updateFolderStats(db *gorm.DB, folderID uuid.UUID) error {
-	var stats struct {
-		FileCount int64
-		TotalSize int64
+// GetFolderSize handles GET /folders/:id/size - Estimate the size of a folder download
+// @Summary Get folder size
+// @Description Count the documents the caller may read in a folder and its subfolders, and their total size. Reports whether the folder can be downloaded as ZIP directly or must be exported with POST /folders/{id}/export.
+// @Tags folders
+// @Produce json
+// @Param id path string true "Folder ID" format(uuid)
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Folder size"
+// @Failure 400 {object} map[string]string "Invalid folder ID format"
+// @Failure 403 {object} map[string]string "Access denied"
+// @Failure 404 {object} map[string]string "Folder not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /folders/{id}/size [get]
+func GetFolderSize(ctx *gin.Context) {
+	folderUUID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid folder ID format"))
+		return
 	}
 
-	// Get folder path first
+	db := requestDB(ctx)
+
 	var folder document.Folder
-	if err := db.First(&folder, folderID).Error; err != nil {
-		return err
+	if err := db.First(&folder, folderUUID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			apperrors.Respond(ctx, apperrors.NotFound("Folder not found").WithDetails("Folder with the given ID does not exist"))
+			return
+		}
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to fetch folder"))
+		return
 	}
 
-	// Calculate stats for this folder AND all subfolders recursively
-	if err := db.Model(&document.Document{}).
-		Joins("JOIN folders ON documents.folder_id = folders.id").
-		Where("folders.path = ? OR folders.path LIKE ?", folder.Path, folder.Path+"/%").
-		Select("COUNT(*) as file_count, COALESCE(SUM(documents.file_size), 0) as total_size").
-		Scan(&stats).Error; err != nil {
-		return err
+	if !checkFolderAccess(ctx, &folder, document.AccessLevelRead) {
+		return
 	}
 
-	// Update folder with recursive stats
-	return db.Model(&document.Folder{}).
-		Where("id = ?", folderID).
-		Updates(map[string]interface{}{
-			"file_count": stats.FileCount,
-			"total_size": stats.TotalSize,
-		}).Error
+	estimate, err := estimateFolderDownload(ctx, db, folderUUID)
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to get folder size"))
+		return
+	}
+
+	cfg := config.GetConfig()
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"folder_id":          folder.ID,
+			"file_count":         estimate.FileCount,
+			"folder_count":       estimate.FolderCount,
+			"total_size":         estimate.TotalSize,
+			"download_allowed":   folderDownloadLimitExceeded(estimate) == "",
+			"download_max_bytes": cfg.FolderDownloadMaxBytes,
+			"download_max_files": cfg.FolderDownloadMaxFiles,
+		},
+	})
 }
 
 // DownloadFolder downloads folder as ZIP archive
 // @Summary Download folder as ZIP
-// @Description Download a folder and all its contents as a ZIP archive (recursive)
+// @Description Download a folder and all its contents as a ZIP archive (recursive). Folders beyond FOLDER_DOWNLOAD_MAX_BYTES or FOLDER_DOWNLOAD_MAX_FILES are rejected with 413, export them with POST /folders/{id}/export instead.
 // @Tags folders
 // @Accept json
 // @Produce application/zip
@@ -1100,64 +1029,62 @@ func updateFolderStats(db *gorm.DB, folderID uuid.UUID) error {
 // @Security BearerAuth
 // @Success 200 {file} file "ZIP archive containing folder contents"
 // @Failure 400 {object} map[string]string "Invalid folder ID format"
+// @Failure 403 {object} map[string]string "Access denied"
 // @Failure 404 {object} map[string]string "Folder not found"
+// @Failure 413 {object} map[string]interface{} "Folder too large, export it instead"
 // @Failure 500 {object} map[string]string "Server error"
 // @Router /folders/{id}/download [get]
 func DownloadFolder(ctx *gin.Context) {
 	folderID := ctx.Param("id")
 	folderUUID, err := uuid.Parse(folderID)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid folder ID format",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid folder ID format"))
 		return
 	}
 
-	db := database.DB
+	db := requestDB(ctx)
 
 	// Get folder
 	var folder document.Folder
 	if err := db.First(&folder, folderUUID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			ctx.JSON(http.StatusNotFound, gin.H{
-				"error":   "Folder not found",
-				"message": "Folder with the given ID does not exist",
-			})
+			apperrors.Respond(ctx, apperrors.NotFound("Folder not found").WithDetails("Folder with the given ID does not exist"))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to fetch folder",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to fetch folder"))
+		return
+	}
+
+	if !checkFolderAccess(ctx, &folder, document.AccessLevelRead) {
+		return
+	}
+
+	// Large folders are exported in the background instead of zipped while downloading
+	estimate, err := estimateFolderDownload(ctx, db, folderUUID)
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to get folder size"))
+		return
+	}
+	if !checkFolderDownloadLimit(ctx, &folder, estimate) {
 		return
 	}
 
 	// Get all documents in folder and subfolders recursively
-	documents, err := getAllDocumentsInFolder(db, folderUUID)
+	documents, err := getAllDocumentsInFolder(ctx, db, folderUUID)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to get folder contents",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to get folder contents"))
 		return
 	}
 
 	if len(documents) == 0 {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Empty folder",
-			"message": "Folder contains no documents to download",
-		})
+		apperrors.Respond(ctx, apperrors.BadRequest("Empty folder").WithDetails("Folder contains no documents to download"))
 		return
 	}
 
 	// Initialize MinIO service
-	minioService, err := services.NewMinIOService()
+	storage, err := services.NewStorageProvider()
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Storage service unavailable",
-			"message": err.Error(),
-		})
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Storage service unavailable"))
 		return
 	}
 
@@ -1180,7 +1107,7 @@ func DownloadFolder(ctx *gin.Context) {
 
 	// Add each document to ZIP with proper folder structure
 	for _, doc := range documents {
-		if err := addDocumentToZip(zipWriter, minioService, &doc, folder.Path); err != nil {
+		if err := services.AddDocumentToZip(zipWriter, storage, &doc, folder.Path); err != nil {
 			errorMsg := fmt.Sprintf("Failed to add %s: %v", doc.OriginalName, err)
 			errors = append(errors, errorMsg)
 			fmt.Printf("Warning: %s\n", errorMsg)
@@ -1196,12 +1123,72 @@ func DownloadFolder(ctx *gin.Context) {
 
 }
 
-// getAllDocumentsInFolder gets all documents in folder and subfolders recursively
-func getAllDocumentsInFolder(db *gorm.DB, folderID uuid.UUID) ([]document.Document, error) {
+// folderDownloadEstimate is the content of a folder download
+type folderDownloadEstimate struct {
+	FileCount   int64
+	TotalSize   int64
+	FolderCount int
+}
+
+// estimateFolderDownload counts the documents the caller may read in the folder and its subfolders
+func estimateFolderDownload(ctx *gin.Context, db *gorm.DB, folderID uuid.UUID) (folderDownloadEstimate, error) {
+	folderIDs, err := services.FolderTreeIDs(db, folderID)
+	if err != nil {
+		return folderDownloadEstimate{}, err
+	}
+
+	var totals struct {
+		FileCount int64
+		TotalSize int64
+	}
+	if err := readableDocuments(ctx, db.Model(&document.Document{})).
+		Select("COUNT(*) AS file_count, COALESCE(SUM(file_size), 0) AS total_size").
+		Where("folder_id IN ?", folderIDs).
+		Scan(&totals).Error; err != nil {
+		return folderDownloadEstimate{}, err
+	}
+
+	return folderDownloadEstimate{
+		FileCount:   totals.FileCount,
+		TotalSize:   totals.TotalSize,
+		FolderCount: len(folderIDs) - 1,
+	}, nil
+}
+
+// folderDownloadLimitExceeded returns which download limit the folder exceeds, empty when none
+func folderDownloadLimitExceeded(estimate folderDownloadEstimate) string {
+	cfg := config.GetConfig()
+	if cfg.FolderDownloadMaxBytes > 0 && estimate.TotalSize > cfg.FolderDownloadMaxBytes {
+		return fmt.Sprintf("The folder holds %d bytes, more than the %d bytes that can be downloaded directly", estimate.TotalSize, cfg.FolderDownloadMaxBytes)
+	}
+	if cfg.FolderDownloadMaxFiles > 0 && estimate.FileCount > int64(cfg.FolderDownloadMaxFiles) {
+		return fmt.Sprintf("The folder holds %d files, more than the %d files that can be downloaded directly", estimate.FileCount, cfg.FolderDownloadMaxFiles)
+	}
+	return ""
+}
+
+// checkFolderDownloadLimit writes the 413 response, pointing to the export, when the folder is too
+// large to be zipped while downloading
+func checkFolderDownloadLimit(ctx *gin.Context, folder *document.Folder, estimate folderDownloadEstimate) bool {
+	reason := folderDownloadLimitExceeded(estimate)
+	if reason == "" {
+		return true
+	}
+
+	apperrors.Respond(ctx, apperrors.New(apperrors.CodePayloadTooLarge, "Folder too large to download").WithDetails(reason+". Export it with POST /api/folders/"+folder.ID.String()+"/export and download the archive when the export completed.").With("details", gin.H{
+		"file_count": estimate.FileCount,
+		"total_size": estimate.TotalSize,
+		"export_url": "/api/folders/" + folder.ID.String() + "/export",
+	}))
+	return false
+}
+
+// getAllDocumentsInFolder gets all documents the caller may read in folder and subfolders recursively
+func getAllDocumentsInFolder(ctx *gin.Context, db *gorm.DB, folderID uuid.UUID) ([]document.Document, error) {
 	var documents []document.Document
 
 	// Get documents directly in this folder
-	if err := db.Preload("Folder").Where("folder_id = ?", folderID).Find(&documents).Error; err != nil {
+	if err := readableDocuments(ctx, db.Preload("Folder")).Where("folder_id = ?", folderID).Find(&documents).Error; err != nil {
 		return nil, fmt.Errorf("failed to get documents in folder: %v", err)
 	}
 
@@ -1214,7 +1201,7 @@ func getAllDocumentsInFolder(db *gorm.DB, folderID uuid.UUID) ([]document.Docume
 	// Get documents from all subfolders
 	for _, subfolder := range subfolders {
 		var subDocuments []document.Document
-		if err := db.Preload("Folder").Where("folder_id = ?", subfolder.ID).Find(&subDocuments).Error; err == nil {
+		if err := readableDocuments(ctx, db.Preload("Folder")).Where("folder_id = ?", subfolder.ID).Find(&subDocuments).Error; err == nil {
 			documents = append(documents, subDocuments...)
 		}
 	}
@@ -1245,58 +1232,3 @@ func getAllSubfolders(db *gorm.DB, parentID uuid.UUID) ([]document.Folder, error
 
 	return allSubfolders, nil
 }
-
-// addDocumentToZip adds a document to the ZIP archive with proper folder structure
-func addDocumentToZip(zipWriter *zip.Writer, minioService *services.MinIOService, doc *document.Document, baseFolderPath string) error {
-	// Download file from MinIO
-	fileName := filepath.Base(doc.ObjectKey)
-	folderPath := filepath.Dir(doc.ObjectKey)
-
-	fileReader, err := minioService.DownloadFile(context.Background(), fileName, folderPath)
-	if err != nil {
-		return fmt.Errorf("failed to download file from storage: %v", err)
-	}
-	defer fileReader.Close()
-
-	// Calculate relative path for ZIP (preserve folder structure)
-	relativePath := calculateRelativePath(doc.Folder.Path, baseFolderPath, doc.OriginalName)
-
-	// Create file entry in ZIP
-	zipFileHeader := &zip.FileHeader{
-		Name:   relativePath,
-		Method: zip.Deflate,
-	}
-
-	// Set modification time if available
-	if !doc.CreatedAt.IsZero() {
-		zipFileHeader.Modified = doc.CreatedAt
-	}
-
-	zipFile, err := zipWriter.CreateHeader(zipFileHeader)
-	if err != nil {
-		return fmt.Errorf("failed to create ZIP entry: %v", err)
-	}
-
-	// Copy file content to ZIP
-	_, err = io.Copy(zipFile, fileReader)
-	if err != nil {
-		return fmt.Errorf("failed to write file to ZIP: %v", err)
-	}
-
-	return nil
-}
-
-// calculateRelativePath calculates the relative path for a file in the ZIP
-func calculateRelativePath(documentFolderPath, baseFolderPath, fileName string) string {
-	// Remove base folder path from document folder path
-	relativeFolderPath := strings.TrimPrefix(documentFolderPath, baseFolderPath)
-	relativeFolderPath = strings.TrimPrefix(relativeFolderPath, "/")
-
-	// If document is in a subfolder, include the subfolder path
-	if relativeFolderPath != "" {
-		return filepath.Join(relativeFolderPath, fileName)
-	}
-
-	// Document is directly in the base folder
-	return fileName
-}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"net/http"
+
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/database/models/document"
+	"forgecrud-backend/shared/utils/query"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// OwnerStorageStats is the storage used by the folders of one owner
+type OwnerStorageStats struct {
+	OwnerType   string    `json:"owner_type"`
+	OwnerID     uuid.UUID `json:"owner_id"`
+	FolderCount int64     `json:"folder_count"`
+	FileCount   int64     `json:"file_count"`
+	TotalSize   int64     `json:"total_size"`
+}
+
+// GetFolderStats handles GET /folders/:id/stats - Get the rolled up stats of a folder
+// @Summary Get folder stats
+// @Description Get the number and size of the documents in a folder and its subfolders, and of those directly in the folder. The stats are kept up to date a few seconds after every change,
+// @Description count every document whatever the caller may read and are recalculated every FOLDER_STATS_ROLLUP_INTERVAL_HOURS. Use GET /folders/{id}/size to count the readable documents only.
+// @Tags folders
+// @Produce json
+// @Param id path string true "Folder ID" format(uuid)
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Folder stats"
+// @Failure 400 {object} map[string]string "Invalid folder ID format"
+// @Failure 403 {object} map[string]string "Access denied"
+// @Failure 404 {object} map[string]string "Folder not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /folders/{id}/stats [get]
+func GetFolderStats(ctx *gin.Context) {
+	folderUUID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid folder ID format"))
+		return
+	}
+
+	db := requestDB(ctx)
+
+	var folder document.Folder
+	if err := db.First(&folder, folderUUID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			apperrors.Respond(ctx, apperrors.NotFound("Folder not found").WithDetails("Folder with the given ID does not exist"))
+			return
+		}
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to fetch folder"))
+		return
+	}
+
+	if !checkFolderAccess(ctx, &folder, document.AccessLevelRead) {
+		return
+	}
+
+	var subfolderCount int64
+	if err := db.Model(&document.Folder{}).Where("parent_id = ?", folder.ID).Count(&subfolderCount).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to get folder stats"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"folder_id":         folder.ID,
+			"path":              folder.Path,
+			"owner_type":        folder.OwnerType,
+			"owner_id":          folder.OwnerID,
+			"file_count":        folder.FileCount,
+			"total_size":        folder.TotalSize,
+			"direct_file_count": folder.DirectFileCount,
+			"direct_size":       folder.DirectSize,
+			"subfolder_count":   subfolderCount,
+			"stats_updated_at":  folder.StatsUpdatedAt,
+		},
+	})
+}
+
+// GetStorageStats handles GET /storage/stats - Get the storage used per folder owner
+// @Summary Get storage stats per owner
+// @Description Get the number of folders, documents and bytes of every user and organization owning folders, largest first, from the rolled up folder stats.
+// @Description Callers scoped to an organization see the organization and its users only.
+// @Tags documents
+// @Produce json
+// @Param owner_type query string false "Filter by owner type (user, organization)"
+// @Param owner_id query string false "Filter by owner ID" format(uuid)
+// @Param page query int false "Page number (default: 1)"
+// @Param limit query int false "Items per page (default: 10)"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Storage per owner"
+// @Failure 400 {object} map[string]string "Invalid filter"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /storage/stats [get]
+func GetStorageStats(ctx *gin.Context) {
+	db := requestDB(ctx)
+	params := query.ParseQueryParams(ctx)
+
+	statsQuery := db.Model(&document.Folder{})
+	if ownerType := ctx.Query("owner_type"); ownerType != "" {
+		if ownerType != "user" && ownerType != "organization" {
+			apperrors.Respond(ctx, apperrors.BadRequest("Invalid owner type").WithDetails("Owner type must be user or organization"))
+			return
+		}
+		statsQuery = statsQuery.Where("owner_type = ?", ownerType)
+	}
+	if ownerID := ctx.Query("owner_id"); ownerID != "" {
+		ownerUUID, err := uuid.Parse(ownerID)
+		if err != nil {
+			apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid owner ID format"))
+			return
+		}
+		statsQuery = statsQuery.Where("owner_id = ?", ownerUUID)
+	}
+
+	var total int64
+	if err := statsQuery.Session(&gorm.Session{}).Distinct("owner_type", "owner_id").Count(&total).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to get storage stats"))
+		return
+	}
+
+	// Every folder adds the documents directly in it, so nested folders are not counted twice
+	stats := []OwnerStorageStats{}
+	err := query.ApplyPagination(statsQuery, params.Page, params.Limit).
+		Select("owner_type, owner_id, COUNT(*) AS folder_count, SUM(direct_file_count) AS file_count, SUM(direct_size) AS total_size").
+		Group("owner_type, owner_id").
+		Order("total_size DESC, owner_type, owner_id").
+		Scan(&stats).Error
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to get storage stats"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"items":      stats,
+			"pagination": query.BuildPaginationResponse(params.Page, params.Limit, total),
+		},
+	})
+}
@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/database/models/document"
+	"forgecrud-backend/shared/utils/query"
+	"forgecrud-backend/shared/utils/validation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FolderSubscriptionRequest represents the settings of a folder subscription
+type FolderSubscriptionRequest struct {
+	Frequency         string `json:"frequency" binding:"omitempty,oneof=instant hourly daily"` // default instant
+	IncludeSubfolders *bool  `json:"include_subfolders"`                                       // default true
+}
+
+// GetFolderSubscriptions lists the folders the caller watches
+// @Summary List folder subscriptions
+// @Description List the caller's folder subscriptions with their folders, newest first
+// @Tags folders
+// @Produce json
+// @Param page query int false "Page number (default: 1)"
+// @Param limit query int false "Results per page (default: 10, max: 100)"
+// @Param user_id query string false "User ID, for testing purposes"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Folder subscriptions"
+// @Failure 400 {object} map[string]string "Missing user"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /folders/subscriptions [get]
+func GetFolderSubscriptions(ctx *gin.Context) {
+	db := requestDB(ctx)
+
+	userID := requestUserID(ctx, ctx.Query("user_id"))
+	if userID == nil {
+		apperrors.Respond(ctx, apperrors.BadRequest("user_id is required"))
+		return
+	}
+
+	params := query.ParseQueryParams(ctx)
+	dbQuery := db.Model(&document.FolderSubscription{}).Where("user_id = ?", *userID)
+
+	var total int64
+	if err := dbQuery.Count(&total).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to fetch folder subscriptions"))
+		return
+	}
+
+	var subscriptions []document.FolderSubscription
+	if err := query.ApplyPagination(dbQuery, params.Page, params.Limit).
+		Preload("Folder").
+		Order("created_at DESC").
+		Find(&subscriptions).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to fetch folder subscriptions"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"data":       subscriptions,
+		"pagination": query.BuildPaginationResponse(params.Page, params.Limit, total),
+	})
+}
+
+// GetFolderSubscription gets the caller's subscription to a folder
+// @Summary Get folder subscription
+// @Description Get the caller's subscription to a folder
+// @Tags folders
+// @Produce json
+// @Param id path string true "Folder ID" format(uuid)
+// @Param user_id query string false "User ID, for testing purposes"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Folder subscription"
+// @Failure 400 {object} map[string]string "Missing user or invalid folder ID"
+// @Failure 404 {object} map[string]string "Not subscribed to the folder"
+// @Router /folders/{id}/subscription [get]
+func GetFolderSubscription(ctx *gin.Context) {
+	userID := requestUserID(ctx, ctx.Query("user_id"))
+	if userID == nil {
+		apperrors.Respond(ctx, apperrors.BadRequest("user_id is required"))
+		return
+	}
+
+	folderID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.BadRequest("Invalid folder ID format"))
+		return
+	}
+
+	var subscription document.FolderSubscription
+	if err := requestDB(ctx).Where("user_id = ? AND folder_id = ?", *userID, folderID).First(&subscription).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.NotFound("Not subscribed to this folder"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    subscription,
+	})
+}
+
+// SubscribeFolder subscribes the caller to changes in a folder
+// @Summary Subscribe to a folder
+// @Description Get notified in-app and by email, following the caller's notification preferences for documents, when documents are added, updated or deleted in a folder and, with include_subfolders, in its subfolders. instant notifies within a minute, hourly and daily send one summary per period. Subscribing again changes the settings.
+// @Tags folders
+// @Accept json
+// @Produce json
+// @Param id path string true "Folder ID" format(uuid)
+// @Param request body FolderSubscriptionRequest true "Subscription settings"
+// @Param user_id query string false "User ID, for testing purposes"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Folder subscription"
+// @Failure 400 {object} map[string]string "Invalid request data"
+// @Failure 403 {object} map[string]string "Access denied"
+// @Failure 404 {object} map[string]string "Folder not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /folders/{id}/subscription [put]
+func SubscribeFolder(ctx *gin.Context) {
+	db := requestDB(ctx)
+
+	userID := requestUserID(ctx, ctx.Query("user_id"))
+	if userID == nil {
+		apperrors.Respond(ctx, apperrors.BadRequest("user_id is required"))
+		return
+	}
+
+	var req FolderSubscriptionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apperrors.Respond(ctx, validation.Error(err, "Invalid request body"))
+		return
+	}
+
+	var folder document.Folder
+	if err := db.First(&folder, "id = ?", ctx.Param("id")).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.NotFound("Folder not found"))
+		return
+	}
+
+	if !checkFolderAccess(ctx, &folder, document.AccessLevelRead) {
+		return
+	}
+
+	// Hourly and daily summaries start their first period now
+	now := time.Now()
+	subscription := document.FolderSubscription{
+		UserID:            *userID,
+		FolderID:          folder.ID,
+		IncludeSubfolders: req.IncludeSubfolders == nil || *req.IncludeSubfolders,
+		Frequency:         req.Frequency,
+		LastNotifiedAt:    &now,
+	}
+	if subscription.Frequency == "" {
+		subscription.Frequency = document.SubscriptionInstant
+	}
+
+	if err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "folder_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"include_subfolders", "frequency", "updated_at"}),
+	}).Create(&subscription).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to subscribe to folder"))
+		return
+	}
+
+	if err := db.Where("user_id = ? AND folder_id = ?", *userID, folder.ID).First(&subscription).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to fetch folder subscription"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Subscribed to folder successfully",
+		"data":    subscription,
+	})
+}
+
+// UnsubscribeFolder removes the caller's subscription to a folder with its pending changes
+// @Summary Unsubscribe from a folder
+// @Description Stop notifications about a folder; changes not sent yet are discarded
+// @Tags folders
+// @Produce json
+// @Param id path string true "Folder ID" format(uuid)
+// @Param user_id query string false "User ID, for testing purposes"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Unsubscribed"
+// @Failure 400 {object} map[string]string "Missing user or invalid folder ID"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /folders/{id}/subscription [delete]
+func UnsubscribeFolder(ctx *gin.Context) {
+	db := requestDB(ctx)
+
+	userID := requestUserID(ctx, ctx.Query("user_id"))
+	if userID == nil {
+		apperrors.Respond(ctx, apperrors.BadRequest("user_id is required"))
+		return
+	}
+
+	folderID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.BadRequest("Invalid folder ID format"))
+		return
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		subscriptions := tx.Model(&document.FolderSubscription{}).Select("id").Where("user_id = ? AND folder_id = ?", *userID, folderID)
+		if err := tx.Where("subscription_id IN (?)", subscriptions).Delete(&document.FolderChange{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ? AND folder_id = ?", *userID, folderID).Delete(&document.FolderSubscription{}).Error
+	})
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to unsubscribe from folder"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Unsubscribed from folder successfully",
+	})
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"forgecrud-backend/document-service/services"
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/config"
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models/document"
+	"forgecrud-backend/shared/tenancy"
+	"forgecrud-backend/shared/utils/query"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetIntegrityReport returns a storage integrity report with its issues
+// @Summary Get storage integrity report
+// @Description Get the latest storage integrity report, or the report with report_id, together with the missing objects, checksum mismatches and orphaned objects it found. Storage is shared by every organization, so only callers outside an organization can see it.
+// @Tags documents
+// @Produce json
+// @Param report_id query string false "Report ID, the latest report when empty" format(uuid)
+// @Param type query string false "Issue type" Enums(missing_object, checksum_mismatch, orphaned_object)
+// @Param page query int false "Page number (default: 1)"
+// @Param limit query int false "Issues per page (default: 10, max: 100)"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Report and issues"
+// @Failure 403 {object} map[string]string "Access denied"
+// @Failure 404 {object} map[string]string "No integrity report"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /storage/integrity [get]
+func GetIntegrityReport(ctx *gin.Context) {
+	if !checkPlatformCaller(ctx) {
+		return
+	}
+
+	db := database.GetDB()
+
+	var report document.IntegrityReport
+	reportQuery := db.Order("started_at DESC")
+	if reportID := ctx.Query("report_id"); reportID != "" {
+		reportQuery = db.Where("id = ?", reportID)
+	}
+	if err := reportQuery.First(&report).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.NotFound("Integrity report not found"))
+		return
+	}
+
+	params := query.ParseQueryParams(ctx)
+
+	issueQuery := db.Model(&document.IntegrityIssue{}).Where("report_id = ?", report.ID)
+	if issueType := ctx.Query("type"); issueType != "" {
+		issueQuery = issueQuery.Where("type = ?", issueType)
+	}
+
+	var total int64
+	if err := issueQuery.Count(&total).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to fetch integrity issues"))
+		return
+	}
+
+	var issues []document.IntegrityIssue
+	if err := query.ApplyPagination(issueQuery, params.Page, params.Limit).
+		Order("created_at ASC").
+		Find(&issues).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to fetch integrity issues"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"report": report,
+			"issues": issues,
+		},
+		"pagination": query.BuildPaginationResponse(params.Page, params.Limit, total),
+	})
+}
+
+// RunIntegrityCheck starts a storage integrity check
+// @Summary Run storage integrity check
+// @Description Start a storage integrity check in the background. Orphaned objects are removed when INTEGRITY_REPAIR_ORPHANS is enabled. Only callers outside an organization can start it.
+// @Tags documents
+// @Produce json
+// @Security BearerAuth
+// @Success 202 {object} map[string]interface{} "Check started"
+// @Failure 403 {object} map[string]string "Access denied"
+// @Failure 409 {object} map[string]string "A check is already running"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /storage/integrity [post]
+func RunIntegrityCheck(ctx *gin.Context) {
+	if !checkPlatformCaller(ctx) {
+		return
+	}
+
+	storage, err := services.NewStorageProvider()
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Storage service unavailable"))
+		return
+	}
+
+	cfg := config.GetConfig()
+	report, err := services.StartIntegrityCheck(database.GetDB(), storage, services.IntegrityOptions{
+		RepairOrphans: cfg.IntegrityRepairOrphans,
+		OrphanGrace:   time.Duration(cfg.IntegrityOrphanGraceHours) * time.Hour,
+	})
+	if errors.Is(err, services.ErrIntegrityCheckRunning) {
+		apperrors.Respond(ctx, apperrors.Conflict("A storage integrity check is already running"))
+		return
+	}
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to start integrity check"))
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"message": "Storage integrity check started",
+		"data":    report,
+	})
+}
+
+// checkPlatformCaller writes the 403 response when the caller is scoped to an organization. Storage
+// is shared by all organizations, so its reports would reveal other tenants' files.
+func checkPlatformCaller(ctx *gin.Context) bool {
+	if tenant, ok := tenancy.FromRequest(ctx); ok && tenant.OrganizationID != nil && !tenant.Bypass {
+		apperrors.Respond(ctx, apperrors.Forbidden("Access denied").WithDetails("Storage maintenance is only available outside an organization"))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/config"
+	"forgecrud-backend/shared/database/models/document"
+	utils "forgecrud-backend/shared/utils/auth"
+	docUtils "forgecrud-backend/shared/utils/document"
+	"forgecrud-backend/shared/utils/validation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// LockDocumentRequest represents the request to check out a document
+type LockDocumentRequest struct {
+	DurationMinutes int    `json:"duration_minutes"` // DOCUMENT_LOCK_TTL_MINUTES when empty
+	UserID          string `json:"user_id"`          // for testing purposes, the gateway forwards the caller
+}
+
+// LockDocument checks out a document for the caller
+// @Summary Lock a document
+// @Description Check out a document so only the caller can upload or restore versions until the lock is released or expires. Locking a document again extends the caller's lock.
+// @Tags documents
+// @Accept json
+// @Produce json
+// @Param id path string true "Document ID" format(uuid)
+// @Param request body LockDocumentRequest false "Lock duration"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Document locked"
+// @Failure 400 {object} map[string]string "Invalid request"
+// @Failure 403 {object} map[string]string "Access denied"
+// @Failure 404 {object} map[string]string "Document not found"
+// @Failure 423 {object} map[string]interface{} "Document is locked by another user"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /documents/{id}/lock [post]
+func LockDocument(ctx *gin.Context) {
+	db := requestDB(ctx)
+	cfg := config.GetConfig()
+
+	var req LockDocumentRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			apperrors.Respond(ctx, validation.Error(err, "Invalid request format"))
+			return
+		}
+	}
+
+	duration := req.DurationMinutes
+	if duration == 0 {
+		duration = cfg.DocumentLockTTLMinutes
+	}
+	if duration < 1 || duration > cfg.DocumentLockMaxMinutes {
+		apperrors.Respond(ctx, apperrors.Newf(apperrors.CodeBadRequest, "duration_minutes must be between 1 and %d", cfg.DocumentLockMaxMinutes))
+		return
+	}
+
+	userID := requestUserID(ctx, req.UserID)
+	if userID == nil {
+		apperrors.Respond(ctx, apperrors.BadRequest("user_id is required"))
+		return
+	}
+
+	var doc document.Document
+	if err := db.First(&doc, "id = ?", ctx.Param("id")).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.NotFound("Document not found"))
+		return
+	}
+
+	if !checkDocumentAccess(ctx, &doc, document.AccessLevelWrite) {
+		return
+	}
+
+	// Only take the lock when it is free, expired or already held by the caller, so two users
+	// locking at the same time cannot both succeed
+	now := time.Now()
+	updates := map[string]interface{}{
+		"locked_by":       *userID,
+		"lock_expires_at": now.Add(time.Duration(duration) * time.Minute),
+	}
+	if holder := doc.ActiveLockHolder(now); holder == nil || *holder != *userID {
+		updates["locked_at"] = now
+	}
+	result := db.Model(&doc).
+		Where("locked_by IS NULL OR locked_by = ? OR lock_expires_at <= ?", *userID, now).
+		Updates(updates)
+	if result.Error != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to lock document"))
+		return
+	}
+	if result.RowsAffected == 0 {
+		db.First(&doc, "id = ?", doc.ID)
+		respondDocumentLocked(ctx, &doc)
+		return
+	}
+
+	db.First(&doc, "id = ?", doc.ID)
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Document locked successfully",
+		"data":    docUtils.BuildDocumentLock(&doc, now),
+	})
+}
+
+// UnlockDocument releases the caller's lock on a document
+// @Summary Unlock a document
+// @Description Release the caller's check-out lock on a document
+// @Tags documents
+// @Produce json
+// @Param id path string true "Document ID" format(uuid)
+// @Param user_id query string false "User ID, for testing purposes"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Document unlocked"
+// @Failure 400 {object} map[string]string "Invalid request"
+// @Failure 404 {object} map[string]string "Document not found"
+// @Failure 409 {object} map[string]string "Document is not locked"
+// @Failure 423 {object} map[string]interface{} "Document is locked by another user"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /documents/{id}/lock [delete]
+func UnlockDocument(ctx *gin.Context) {
+	db := requestDB(ctx)
+
+	userID := requestUserID(ctx, ctx.Query("user_id"))
+	if userID == nil {
+		apperrors.Respond(ctx, apperrors.BadRequest("user_id is required"))
+		return
+	}
+
+	var doc document.Document
+	if err := db.First(&doc, "id = ?", ctx.Param("id")).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.NotFound("Document not found"))
+		return
+	}
+
+	holder := doc.ActiveLockHolder(time.Now())
+	if holder == nil {
+		apperrors.Respond(ctx, apperrors.Conflict("Document is not locked"))
+		return
+	}
+	if *holder != *userID {
+		respondDocumentLocked(ctx, &doc)
+		return
+	}
+
+	result := db.Model(&doc).Where("locked_by = ?", *userID).Updates(map[string]interface{}{
+		"locked_by":       nil,
+		"locked_at":       nil,
+		"lock_expires_at": nil,
+	})
+	if result.Error != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to unlock document"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Document unlocked successfully",
+	})
+}
+
+// ForceUnlockDocument releases the lock on a document whoever holds it
+// @Summary Force unlock a document
+// @Description Release the check-out lock of another user, requires manage access on the document
+// @Tags documents
+// @Produce json
+// @Param id path string true "Document ID" format(uuid)
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Document unlocked"
+// @Failure 403 {object} map[string]string "Access denied"
+// @Failure 404 {object} map[string]string "Document not found"
+// @Failure 409 {object} map[string]string "Document is not locked"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /documents/{id}/lock/force [delete]
+func ForceUnlockDocument(ctx *gin.Context) {
+	db := requestDB(ctx)
+
+	var doc document.Document
+	if err := db.First(&doc, "id = ?", ctx.Param("id")).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.NotFound("Document not found"))
+		return
+	}
+
+	if !checkDocumentAccess(ctx, &doc, document.AccessLevelManage) {
+		return
+	}
+
+	holder := doc.ActiveLockHolder(time.Now())
+	if holder == nil {
+		apperrors.Respond(ctx, apperrors.Conflict("Document is not locked"))
+		return
+	}
+
+	result := db.Model(&doc).Updates(map[string]interface{}{
+		"locked_by":       nil,
+		"locked_at":       nil,
+		"lock_expires_at": nil,
+	})
+	if result.Error != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to unlock document"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Document unlocked successfully",
+		"data":    gin.H{"previous_holder": *holder},
+	})
+}
+
+// checkDocumentLock writes the 423 response when another user holds the lock on the document
+func checkDocumentLock(ctx *gin.Context, doc *document.Document, userID *uuid.UUID) bool {
+	holder := doc.ActiveLockHolder(time.Now())
+	if holder == nil || (userID != nil && *holder == *userID) {
+		return true
+	}
+	respondDocumentLocked(ctx, doc)
+	return false
+}
+
+func respondDocumentLocked(ctx *gin.Context, doc *document.Document) {
+	apperrors.Respond(ctx, apperrors.New(apperrors.CodeLocked, "Document is locked").WithDetails("The document is checked out by another user").With("data", docUtils.BuildDocumentLock(doc, time.Now())))
+}
+
+// requestUserID returns the caller forwarded by the gateway, falling back to the user_id of the request
+func requestUserID(ctx *gin.Context, userID string) *uuid.UUID {
+	if actorID := utils.GetActorID(ctx); actorID != nil {
+		return actorID
+	}
+	parsed, err := uuid.Parse(userID)
+	if err != nil {
+		return nil
+	}
+	return &parsed
+}
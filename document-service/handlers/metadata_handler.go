@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/database/models/document"
+	docUtils "forgecrud-backend/shared/utils/document"
+	"forgecrud-backend/shared/utils/validation"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// CreateMetadataFieldRequest represents the request to define a metadata field on a folder
+type CreateMetadataFieldRequest struct {
+	Key      string   `json:"key" binding:"required"`
+	Label    string   `json:"label" binding:"required"`
+	Type     string   `json:"type" binding:"required,oneof=string number boolean date enum"`
+	Required bool     `json:"required"`
+	Options  []string `json:"options"` // Allowed values of enum fields
+}
+
+// UpdateMetadataFieldRequest represents the request to update a metadata field.
+// Key and type are immutable because existing document values depend on them.
+type UpdateMetadataFieldRequest struct {
+	Label    string   `json:"label"`
+	Required *bool    `json:"required"`
+	Options  []string `json:"options"`
+}
+
+// GetFolderMetadataFields lists the metadata fields that apply to the documents of a folder
+// @Summary Get folder metadata fields
+// @Description Get the metadata fields of a folder including the ones inherited from its parent folders. A field of a subfolder overrides a parent folder field with the same key.
+// @Tags folders
+// @Produce json
+// @Param id path string true "Folder ID" format(uuid)
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Metadata fields"
+// @Failure 403 {object} map[string]string "Access denied"
+// @Failure 404 {object} map[string]string "Folder not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /folders/{id}/metadata-fields [get]
+func GetFolderMetadataFields(ctx *gin.Context) {
+	db := requestDB(ctx)
+
+	var folder document.Folder
+	if err := db.First(&folder, "id = ?", ctx.Param("id")).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.NotFound("Folder not found"))
+		return
+	}
+
+	if !checkFolderAccess(ctx, &folder, document.AccessLevelRead) {
+		return
+	}
+
+	fields, err := folderMetadataFields(db, &folder)
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to fetch metadata fields"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    fields,
+	})
+}
+
+// CreateFolderMetadataField defines a metadata field on a folder
+// @Summary Create folder metadata field
+// @Description Define a metadata field (key, type, allowed values, required) for the documents of a folder and its subfolders. Existing documents are not checked; their metadata is validated on their next update.
+// @Tags folders
+// @Accept json
+// @Produce json
+// @Param id path string true "Folder ID" format(uuid)
+// @Param field body CreateMetadataFieldRequest true "Field definition"
+// @Security BearerAuth
+// @Success 201 {object} map[string]interface{} "Created field definition"
+// @Failure 400 {object} map[string]string "Invalid request data"
+// @Failure 403 {object} map[string]string "Access denied"
+// @Failure 404 {object} map[string]string "Folder not found"
+// @Failure 409 {object} map[string]string "Field key already exists on the folder"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /folders/{id}/metadata-fields [post]
+func CreateFolderMetadataField(ctx *gin.Context) {
+	db := requestDB(ctx)
+
+	var req CreateMetadataFieldRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apperrors.Respond(ctx, validation.Error(err, "Invalid request format"))
+		return
+	}
+
+	var folder document.Folder
+	if err := db.First(&folder, "id = ?", ctx.Param("id")).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.NotFound("Folder not found"))
+		return
+	}
+
+	if !checkFolderAccess(ctx, &folder, document.AccessLevelManage) {
+		return
+	}
+
+	field := document.MetadataField{
+		FolderID: folder.ID,
+		Key:      req.Key,
+		Label:    req.Label,
+		Type:     req.Type,
+		Required: req.Required,
+		Options:  strings.Join(req.Options, ","),
+	}
+	if err := docUtils.ValidateMetadataField(&field); err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid field definition"))
+		return
+	}
+
+	var existing document.MetadataField
+	if err := db.Where("folder_id = ? AND key = ?", folder.ID, field.Key).First(&existing).Error; err == nil {
+		apperrors.Respond(ctx, apperrors.Conflict("A metadata field with this key already exists on the folder"))
+		return
+	}
+
+	if err := db.Create(&field).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to create metadata field"))
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Metadata field created successfully",
+		"data":    field,
+	})
+}
+
+// UpdateFolderMetadataField updates a metadata field of a folder
+// @Summary Update folder metadata field
+// @Description Update the label, required flag or allowed values of a metadata field. Key and type cannot be changed.
+// @Tags folders
+// @Accept json
+// @Produce json
+// @Param id path string true "Folder ID" format(uuid)
+// @Param field_id path string true "Field ID" format(uuid)
+// @Param field body UpdateMetadataFieldRequest true "Updated field definition"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Updated field definition"
+// @Failure 400 {object} map[string]string "Invalid request data"
+// @Failure 403 {object} map[string]string "Access denied"
+// @Failure 404 {object} map[string]string "Folder or field not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /folders/{id}/metadata-fields/{field_id} [put]
+func UpdateFolderMetadataField(ctx *gin.Context) {
+	db := requestDB(ctx)
+
+	var req UpdateMetadataFieldRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apperrors.Respond(ctx, validation.Error(err, "Invalid request format"))
+		return
+	}
+
+	field, ok := findMetadataField(ctx, db)
+	if !ok {
+		return
+	}
+
+	if req.Label != "" {
+		field.Label = req.Label
+	}
+	if req.Required != nil {
+		field.Required = *req.Required
+	}
+	if req.Options != nil {
+		field.Options = strings.Join(req.Options, ",")
+	}
+	if err := docUtils.ValidateMetadataField(field); err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid field definition"))
+		return
+	}
+
+	if err := db.Save(field).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to update metadata field"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Metadata field updated successfully",
+		"data":    field,
+	})
+}
+
+// DeleteFolderMetadataField removes a metadata field from a folder
+// @Summary Delete folder metadata field
+// @Description Delete a metadata field. Existing values stay in the document metadata until the next update.
+// @Tags folders
+// @Produce json
+// @Param id path string true "Folder ID" format(uuid)
+// @Param field_id path string true "Field ID" format(uuid)
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Metadata field deleted"
+// @Failure 403 {object} map[string]string "Access denied"
+// @Failure 404 {object} map[string]string "Folder or field not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /folders/{id}/metadata-fields/{field_id} [delete]
+func DeleteFolderMetadataField(ctx *gin.Context) {
+	db := requestDB(ctx)
+
+	field, ok := findMetadataField(ctx, db)
+	if !ok {
+		return
+	}
+
+	if err := db.Delete(field).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to delete metadata field"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Metadata field deleted successfully",
+	})
+}
+
+// findMetadataField loads a field by the :id and :field_id path parameters after checking that the
+// caller manages the folder, and writes the error response if needed
+func findMetadataField(ctx *gin.Context, db *gorm.DB) (*document.MetadataField, bool) {
+	var folder document.Folder
+	if err := db.First(&folder, "id = ?", ctx.Param("id")).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.NotFound("Folder not found"))
+		return nil, false
+	}
+
+	if !checkFolderAccess(ctx, &folder, document.AccessLevelManage) {
+		return nil, false
+	}
+
+	var field document.MetadataField
+	if err := db.Where("id = ? AND folder_id = ?", ctx.Param("field_id"), folder.ID).First(&field).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.NotFound("Metadata field not found"))
+		return nil, false
+	}
+	return &field, true
+}
+
+// folderMetadataFields returns the metadata fields of a folder and its parent folders, the nearest
+// folder's field winning when a key is defined more than once
+func folderMetadataFields(db *gorm.DB, folder *document.Folder) ([]document.MetadataField, error) {
+	var fields []document.MetadataField
+	if err := db.Model(&document.MetadataField{}).
+		Joins("JOIN folders ON folders.id = metadata_fields.folder_id").
+		Where("(? = folders.path OR ? LIKE folders.path || '/%')", folder.Path, folder.Path).
+		Order("length(folders.path) DESC, metadata_fields.created_at ASC").
+		Find(&fields).Error; err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(fields))
+	effective := make([]document.MetadataField, 0, len(fields))
+	for _, field := range fields {
+		if seen[field.Key] {
+			continue
+		}
+		seen[field.Key] = true
+		effective = append(effective, field)
+	}
+	return effective, nil
+}
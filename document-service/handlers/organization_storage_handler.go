@@ -0,0 +1,335 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"forgecrud-backend/document-service/services"
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/database/models"
+	utils "forgecrud-backend/shared/utils/auth"
+	docUtils "forgecrud-backend/shared/utils/document"
+	"forgecrud-backend/shared/utils/validation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	// bucketNamePattern matches an S3 bucket name
+	bucketNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9.-]{1,61}[a-z0-9]$`)
+	// storagePrefixPattern matches a prefix of slash separated segments
+	storagePrefixPattern = regexp.MustCompile(`^[A-Za-z0-9!_.*'()-]+(/[A-Za-z0-9!_.*'()-]+)*$`)
+)
+
+// UpdateOrganizationStorageRequest represents the own storage of an organization. Empty endpoint, region
+// and access key use the ones of the default storage; an empty secret keeps the current secret of the
+// same access key.
+type UpdateOrganizationStorageRequest struct {
+	Endpoint        string `json:"endpoint" binding:"omitempty,url" example:"https://s3.eu-central-1.amazonaws.com"`
+	UseSSL          bool   `json:"use_ssl" example:"true"`
+	Region          string `json:"region" example:"eu-central-1"`
+	Bucket          string `json:"bucket" binding:"required" example:"acme-documents"`
+	Prefix          string `json:"prefix" example:"documents"`
+	AccessKeyID     string `json:"access_key_id"`
+	SecretAccessKey string `json:"secret_access_key"`
+}
+
+// OrganizationStorageResponse is the storage of an organization with what it stores there
+type OrganizationStorageResponse struct {
+	OrganizationID uuid.UUID                         `json:"organization_id"`
+	Storage        *models.OrganizationStorage       `json:"storage"` // Null while the organization uses the default storage
+	Usage          services.OrganizationStorageUsage `json:"usage"`
+}
+
+// findStorageOrganization loads the organization of the :id param, writing the error response when it
+// does not exist or is outside the caller's organization
+func findStorageOrganization(ctx *gin.Context) (models.Organization, bool) {
+	var organization models.Organization
+	organizationID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid organization ID format"))
+		return organization, false
+	}
+
+	if err := requestDB(ctx).First(&organization, organizationID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apperrors.Respond(ctx, apperrors.NotFound("Organization not found"))
+			return organization, false
+		}
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to fetch organization"))
+		return organization, false
+	}
+	return organization, true
+}
+
+// loadOrganizationStorage returns the storage settings of an organization, nil when it has none
+func loadOrganizationStorage(db *gorm.DB, organizationID uuid.UUID) (*models.OrganizationStorage, error) {
+	var storage models.OrganizationStorage
+	err := db.Where("organization_id = ?", organizationID).Take(&storage).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &storage, nil
+}
+
+// buildOrganizationStorageResponse accounts the storage of an organization for API responses
+func buildOrganizationStorageResponse(db *gorm.DB, organizationID uuid.UUID, storage *models.OrganizationStorage) (OrganizationStorageResponse, error) {
+	usage, err := services.GetOrganizationStorageUsage(db, organizationID)
+	return OrganizationStorageResponse{OrganizationID: organizationID, Storage: storage, Usage: usage}, err
+}
+
+// GetOrganizationStorage returns the own storage of an organization
+// @Summary Get organization storage
+// @Description Get the own bucket, or prefix in a shared bucket, where the files of an organization and its users are stored, without its secret, and what the organization stores there.
+// @Description The usage also counts the files still in the default storage, which cmd/migrate-storage moves. Organizations without their own storage use the default storage.
+// @Tags organizations
+// @Produce json
+// @Param id path string true "Organization ID" format(uuid)
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Organization storage and usage"
+// @Failure 400 {object} map[string]string "Invalid organization ID format"
+// @Failure 404 {object} map[string]string "Organization not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /organizations/{id}/storage [get]
+func GetOrganizationStorage(ctx *gin.Context) {
+	organization, ok := findStorageOrganization(ctx)
+	if !ok {
+		return
+	}
+	db := requestDB(ctx)
+
+	storage, err := loadOrganizationStorage(db, organization.ID)
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to retrieve organization storage"))
+		return
+	}
+
+	response, err := buildOrganizationStorageResponse(db, organization.ID, storage)
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to account organization storage"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    response,
+	})
+}
+
+// UpdateOrganizationStorage sets the own storage of an organization
+// @Summary Update organization storage
+// @Description Store the files uploaded from now on into the folders of an organization and its users in its own S3 compatible bucket, or under a prefix of a shared bucket with credentials limited to it,
+// @Description so the organization's files are isolated, can have their own lifecycle rules and are accounted separately. The storage is tested by writing an object before it is saved.
+// @Description In the bucket of the default storage the prefix must start with orgs/ and defaults to orgs/<organization ID>. The location can only change while the storage holds no files. Super admins only.
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Param id path string true "Organization ID" format(uuid)
+// @Param request body UpdateOrganizationStorageRequest true "Organization storage"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Updated organization storage"
+// @Failure 400 {object} map[string]string "Invalid request or storage not reachable"
+// @Failure 403 {object} map[string]string "Caller scoped to an organization"
+// @Failure 404 {object} map[string]string "Organization not found"
+// @Failure 409 {object} map[string]string "Storage holds files or overlaps the storage of another organization"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /organizations/{id}/storage [put]
+func UpdateOrganizationStorage(ctx *gin.Context) {
+	if !checkPlatformCaller(ctx) {
+		return
+	}
+	organization, ok := findStorageOrganization(ctx)
+	if !ok {
+		return
+	}
+
+	var req UpdateOrganizationStorageRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apperrors.Respond(ctx, validation.Error(err, "Invalid request"))
+		return
+	}
+
+	db := requestDB(ctx)
+	current, err := loadOrganizationStorage(db, organization.ID)
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to retrieve organization storage"))
+		return
+	}
+
+	storage := models.OrganizationStorage{OrganizationID: organization.ID}
+	if current != nil {
+		storage = *current
+	}
+	storage.Endpoint = strings.TrimRight(strings.TrimSpace(req.Endpoint), "/")
+	storage.UseSSL = req.UseSSL
+	storage.Region = strings.TrimSpace(req.Region)
+	storage.Bucket = strings.TrimSpace(req.Bucket)
+	storage.Prefix = strings.Trim(strings.TrimSpace(req.Prefix), "/")
+	storage.AccessKeyID = strings.TrimSpace(req.AccessKeyID)
+	if req.SecretAccessKey != "" || current == nil || current.AccessKeyID != storage.AccessKeyID {
+		storage.SecretAccessKey = req.SecretAccessKey
+	}
+	storage.UpdatedBy = utils.GetActorID(ctx)
+
+	invalid := func(details string) {
+		apperrors.Respond(ctx, apperrors.BadRequest("Invalid organization storage").WithDetails(details))
+	}
+	if !bucketNamePattern.MatchString(storage.Bucket) {
+		invalid(fmt.Sprintf("%q is not a valid bucket name", storage.Bucket))
+		return
+	}
+	if storage.AccessKeyID != "" && storage.SecretAccessKey == "" {
+		invalid("secret_access_key is required with access_key_id")
+		return
+	}
+
+	resolved, err := services.ResolveOrganizationStorage(storage)
+	if err != nil {
+		invalid(err.Error())
+		return
+	}
+	if services.SharesDefaultBucket(resolved) {
+		// Files of the default storage are never stored under orgs/
+		if storage.Prefix == "" {
+			storage.Prefix = strings.TrimSuffix(docUtils.OrganizationStorageKey(organization.ID, ""), "/")
+		}
+		if !strings.HasPrefix(storage.Prefix+"/", docUtils.OrganizationStoragePrefix) || storage.Prefix+"/" == docUtils.OrganizationStoragePrefix {
+			invalid("the prefix must start with " + docUtils.OrganizationStoragePrefix + " in the bucket of the default storage")
+			return
+		}
+		resolved.Prefix = storage.Prefix
+	}
+	if storage.Prefix != "" && (!storagePrefixPattern.MatchString(storage.Prefix) || strings.Contains(storage.Prefix, "..")) {
+		invalid(fmt.Sprintf("%q is not a valid prefix", storage.Prefix))
+		return
+	}
+
+	if conflict, err := organizationStorageConflict(db, resolved); err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to check organization storage"))
+		return
+	} else if conflict != nil {
+		apperrors.Respond(ctx, apperrors.Conflict("Storage overlaps the storage of another organization").
+			WithDetails(fmt.Sprintf("Organization %s stores its files in the same bucket under an overlapping prefix", *conflict)))
+		return
+	}
+
+	// Files already stored would be left behind in the previous location
+	if current != nil {
+		previous, err := services.ResolveOrganizationStorage(*current)
+		if err != nil || previous.Endpoint != resolved.Endpoint || previous.Bucket != resolved.Bucket || previous.Prefix != resolved.Prefix {
+			usage, err := services.GetOrganizationStorageUsage(db, organization.ID)
+			if err != nil {
+				apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to account organization storage"))
+				return
+			}
+			if usage.Objects > 0 || usage.Uploads > 0 {
+				apperrors.Respond(ctx, apperrors.Conflict("Organization storage holds files").
+					WithDetails("Move them back to the default storage with cmd/migrate-storage before changing the location").
+					With("usage", usage))
+				return
+			}
+		}
+	}
+
+	if err := services.TestOrganizationStorage(storage); err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Organization storage not reachable").WithDetails(err.Error()))
+		return
+	}
+
+	if err := db.Save(&storage).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to update organization storage"))
+		return
+	}
+	services.InvalidateOrganizationStorage(organization.ID)
+
+	response, err := buildOrganizationStorageResponse(db, organization.ID, &storage)
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to account organization storage"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Organization storage updated successfully",
+		"data":    response,
+	})
+}
+
+// DeleteOrganizationStorage removes the own storage of an organization
+// @Summary Delete organization storage
+// @Description Store the files uploaded from now on into the folders of an organization in the default storage again. Refused while the organization's storage holds files,
+// @Description move them back with cmd/migrate-storage first. The bucket itself is left as it is. Super admins only.
+// @Tags organizations
+// @Produce json
+// @Param id path string true "Organization ID" format(uuid)
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Organization storage removed"
+// @Failure 400 {object} map[string]string "Invalid organization ID format"
+// @Failure 403 {object} map[string]string "Caller scoped to an organization"
+// @Failure 404 {object} map[string]string "Organization not found"
+// @Failure 409 {object} map[string]string "Storage holds files"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /organizations/{id}/storage [delete]
+func DeleteOrganizationStorage(ctx *gin.Context) {
+	if !checkPlatformCaller(ctx) {
+		return
+	}
+	organization, ok := findStorageOrganization(ctx)
+	if !ok {
+		return
+	}
+	db := requestDB(ctx)
+
+	usage, err := services.GetOrganizationStorageUsage(db, organization.ID)
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to account organization storage"))
+		return
+	}
+	if usage.Objects > 0 || usage.Uploads > 0 {
+		apperrors.Respond(ctx, apperrors.Conflict("Organization storage holds files").
+			WithDetails("Move them back to the default storage with cmd/migrate-storage first").
+			With("usage", usage))
+		return
+	}
+
+	if err := db.Where("organization_id = ?", organization.ID).Delete(&models.OrganizationStorage{}).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to delete organization storage"))
+		return
+	}
+	services.InvalidateOrganizationStorage(organization.ID)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Organization storage removed",
+		"data":    OrganizationStorageResponse{OrganizationID: organization.ID, Usage: usage},
+	})
+}
+
+// organizationStorageConflict returns the other organization whose storage is in the same bucket under
+// an overlapping prefix, nil when there is none
+func organizationStorageConflict(db *gorm.DB, resolved models.OrganizationStorage) (*uuid.UUID, error) {
+	var others []models.OrganizationStorage
+	if err := db.Where("organization_id <> ?", resolved.OrganizationID).Find(&others).Error; err != nil {
+		return nil, err
+	}
+
+	for _, other := range others {
+		other, err := services.ResolveOrganizationStorage(other)
+		if err != nil || other.Endpoint != resolved.Endpoint || other.Bucket != resolved.Bucket {
+			continue
+		}
+		a, b := other.Prefix, resolved.Prefix
+		if a == "" || b == "" || a == b || strings.HasPrefix(a, b+"/") || strings.HasPrefix(b, a+"/") {
+			return &other.OrganizationID, nil
+		}
+	}
+	return nil, nil
+}
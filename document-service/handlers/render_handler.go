@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"forgecrud-backend/document-service/services"
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/config"
+	"forgecrud-backend/shared/database/models/document"
+	docUtils "forgecrud-backend/shared/utils/document"
+
+	"github.com/gin-gonic/gin"
+)
+
+const defaultRenderQuality = 85
+
+// renderableMimeTypes are the image types that can be rendered, with their default output format
+var renderableMimeTypes = map[string]string{
+	"image/jpeg": services.ImageFormatJPEG,
+	"image/png":  services.ImageFormatPNG,
+	"image/gif":  services.ImageFormatPNG,
+}
+
+// RenderDocument serves a resized or converted variant of an image document
+// @Summary Render image document
+// @Description Resize and convert a JPEG, PNG or GIF document on the fly, e.g. to embed user uploaded images in a UI. Rendered variants are cached in storage and regenerated when the document gets a new version. Images are never enlarged with fit=contain. WebP output needs IMAGE_WEBP_COMMAND.
+// @Tags documents
+// @Produce image/jpeg,image/png,image/webp
+// @Param id path string true "Document ID" format(uuid)
+// @Param w query int false "Width in pixels, follows the aspect ratio when empty"
+// @Param h query int false "Height in pixels, follows the aspect ratio when empty"
+// @Param fit query string false "contain (default) fits within w x h, cover crops to fill it, fill stretches" Enums(contain, cover, fill)
+// @Param format query string false "Output format, the source format by default (PNG for GIF)" Enums(jpeg, png, webp)
+// @Param q query int false "JPEG and WebP quality 1-100 (default: 85)"
+// @Security BearerAuth
+// @Success 200 {file} file "Rendered image"
+// @Success 304 "Not modified"
+// @Failure 400 {object} map[string]string "Invalid render options"
+// @Failure 403 {object} map[string]string "Access denied"
+// @Failure 404 {object} map[string]string "Document not found"
+// @Failure 413 {object} map[string]string "Image too large to render"
+// @Failure 415 {object} map[string]string "Document is not a supported image"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /documents/{id}/render [get]
+func RenderDocument(ctx *gin.Context) {
+	db := requestDB(ctx)
+
+	var doc document.Document
+	if err := db.First(&doc, "id = ?", ctx.Param("id")).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.NotFound("Document not found"))
+		return
+	}
+
+	if !checkDocumentAccess(ctx, &doc, document.AccessLevelRead) {
+		return
+	}
+
+	if !checkScanStatus(ctx, doc.ScanStatus) {
+		return
+	}
+
+	sourceFormat, ok := renderableMimeTypes[doc.MimeType]
+	if !ok {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeUnsupportedMedia, "Document is not a supported image").WithDetails("Only JPEG, PNG and GIF documents can be rendered"))
+		return
+	}
+
+	opts, err := parseRenderOptions(ctx, sourceFormat)
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid render options"))
+		return
+	}
+
+	if maxBytes := config.GetConfig().ImageRenderMaxSourceBytes; maxBytes > 0 && doc.FileSize > maxBytes {
+		apperrors.Respond(ctx, apperrors.Newf(apperrors.CodePayloadTooLarge, "Images over %d bytes cannot be rendered, download the document instead", maxBytes))
+		return
+	}
+
+	storage, err := services.NewStorageProvider()
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Storage service unavailable"))
+		return
+	}
+
+	renderKey := services.ImageRenderKey(doc.ID, doc.Checksum, opts)
+	etag := `"` + strings.TrimPrefix(renderKey, services.ImageRenderPrefix+doc.ID.String()+"/") + `"`
+	ctx.Header("ETag", etag)
+	ctx.Header("Cache-Control", "private, max-age=86400")
+	if ctx.GetHeader("If-None-Match") == etag {
+		ctx.Status(http.StatusNotModified)
+		return
+	}
+
+	// Serve the cached variant when it was rendered before
+	if cached, info, err := storage.GetObject(ctx.Request.Context(), renderKey); err == nil {
+		defer cached.Close()
+		ctx.DataFromReader(http.StatusOK, info.Size, opts.ContentType(), cached, nil)
+		return
+	}
+
+	source, _, err := storage.GetObject(ctx.Request.Context(), docUtils.StorageKey(doc.ObjectKey, doc.ContentHash))
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to read image"))
+		return
+	}
+	data, err := io.ReadAll(source)
+	source.Close()
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to read image"))
+		return
+	}
+
+	img, _, err := docUtils.DecodeImage(bytes.NewReader(data))
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeUnsupportedMedia, "Document is not a supported image"))
+		return
+	}
+
+	rendered, err := services.RenderImage(img, opts)
+	if errors.Is(err, services.ErrWebPDisabled) {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid render options"))
+		return
+	}
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to render image"))
+		return
+	}
+
+	// Caching is best effort, the variant is rendered again when it could not be stored
+	if err := storage.PutObject(context.Background(), renderKey, bytes.NewReader(rendered), int64(len(rendered)), opts.ContentType()); err == nil {
+		services.RemoveImageRenders(storage, doc.ID, doc.Checksum)
+	}
+
+	ctx.Data(http.StatusOK, opts.ContentType(), rendered)
+}
+
+// parseRenderOptions reads and validates the render query parameters
+func parseRenderOptions(ctx *gin.Context, sourceFormat string) (services.ImageRenderOptions, error) {
+	opts := services.ImageRenderOptions{
+		Fit:     services.ImageFitContain,
+		Format:  sourceFormat,
+		Quality: defaultRenderQuality,
+	}
+	maxDimension := config.GetConfig().ImageRenderMaxDimension
+
+	var err error
+	if opts.Width, err = renderDimension(ctx.Query("w"), "w", maxDimension); err != nil {
+		return opts, err
+	}
+	if opts.Height, err = renderDimension(ctx.Query("h"), "h", maxDimension); err != nil {
+		return opts, err
+	}
+
+	if fit := ctx.Query("fit"); fit != "" {
+		switch fit {
+		case services.ImageFitContain, services.ImageFitCover, services.ImageFitFill:
+			opts.Fit = fit
+		default:
+			return opts, fmt.Errorf("fit must be contain, cover or fill")
+		}
+	}
+
+	if format := strings.ToLower(ctx.Query("format")); format != "" {
+		if format == "jpg" {
+			format = services.ImageFormatJPEG
+		}
+		switch format {
+		case services.ImageFormatJPEG, services.ImageFormatPNG, services.ImageFormatWebP:
+			opts.Format = format
+		default:
+			return opts, fmt.Errorf("format must be jpeg, png or webp")
+		}
+	}
+
+	if quality := ctx.Query("q"); quality != "" {
+		opts.Quality, err = strconv.Atoi(quality)
+		if err != nil || opts.Quality < 1 || opts.Quality > 100 {
+			return opts, fmt.Errorf("q must be between 1 and 100")
+		}
+	}
+	// PNG is lossless, the quality would only multiply the cached variants
+	if opts.Format == services.ImageFormatPNG {
+		opts.Quality = 0
+	}
+
+	return opts, nil
+}
+
+// renderDimension parses a width or height, zero when empty
+func renderDimension(value, name string, maxDimension int) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	dimension, err := strconv.Atoi(value)
+	if err != nil || dimension < 1 {
+		return 0, fmt.Errorf("%s must be a positive number of pixels", name)
+	}
+	if maxDimension > 0 && dimension > maxDimension {
+		return 0, fmt.Errorf("%s must not exceed %d pixels", name, maxDimension)
+	}
+	return dimension, nil
+}
@@ -0,0 +1,435 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"forgecrud-backend/document-service/services"
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/database/models/document"
+	"forgecrud-backend/shared/database/models/notification"
+	utils "forgecrud-backend/shared/utils/auth"
+	"forgecrud-backend/shared/utils/query"
+	"forgecrud-backend/shared/utils/validation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RetentionPolicyRequest represents the retention rules of a folder, 0 disables a rule
+type RetentionPolicyRequest struct {
+	MinRetentionDays int `json:"min_retention_days" binding:"min=0"`
+	DeleteAfterDays  int `json:"delete_after_days" binding:"min=0"`
+}
+
+// PlaceLegalHoldRequest represents the request to place a legal hold on a folder or document
+type PlaceLegalHoldRequest struct {
+	ObjectType string `json:"object_type" binding:"required,oneof=folder document"`
+	ObjectID   string `json:"object_id" binding:"required,uuid"`
+	Reason     string `json:"reason" binding:"required"`
+}
+
+// folderRetentionPolicy is a retention policy with the path of its folder
+type folderRetentionPolicy struct {
+	document.RetentionPolicy
+	FolderPath string `json:"folder_path"`
+	Inherited  bool   `json:"inherited"`
+}
+
+// GetFolderRetention gets the retention rules and legal holds that apply to a folder
+// @Summary Get folder retention
+// @Description Get the retention policies of a folder and its parent folders, which all apply to its documents, and the active legal holds on them
+// @Tags folders
+// @Produce json
+// @Param id path string true "Folder ID" format(uuid)
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Retention policies and legal holds"
+// @Failure 404 {object} map[string]string "Folder not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /folders/{id}/retention [get]
+func GetFolderRetention(ctx *gin.Context) {
+	db := requestDB(ctx)
+
+	var folder document.Folder
+	if err := db.First(&folder, "id = ?", ctx.Param("id")).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.NotFound("Folder not found"))
+		return
+	}
+
+	chain := db.Model(&document.Folder{}).Select("id").Where("? = path OR ? LIKE path || '/%'", folder.Path, folder.Path)
+
+	policies := []folderRetentionPolicy{}
+	if err := db.Model(&document.RetentionPolicy{}).
+		Select("retention_policies.*, folders.path AS folder_path, retention_policies.folder_id <> ? AS inherited", folder.ID).
+		Joins("JOIN folders ON folders.id = retention_policies.folder_id").
+		Where("retention_policies.folder_id IN (?)", chain).
+		Order("folders.path").
+		Scan(&policies).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to fetch retention policies"))
+		return
+	}
+
+	holds := []document.LegalHold{}
+	if err := db.Where("released_at IS NULL AND object_type = ? AND object_id IN (?)", document.AccessObjectFolder, chain).
+		Order("created_at").
+		Find(&holds).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to fetch legal holds"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"policies":    policies,
+			"legal_holds": holds,
+		},
+	})
+}
+
+// SetFolderRetention sets the retention policy of a folder
+// @Summary Set folder retention
+// @Description Set the retention policy of a folder, applying to the documents of the folder and its subfolders. Documents cannot be deleted before they are min_retention_days old and are moved to the trash once they are delete_after_days old. Policies of parent folders keep applying. The change is audit logged.
+// @Tags folders
+// @Accept json
+// @Produce json
+// @Param id path string true "Folder ID" format(uuid)
+// @Param policy body RetentionPolicyRequest true "Retention rules"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Retention policy"
+// @Failure 400 {object} map[string]string "Invalid request data"
+// @Failure 404 {object} map[string]string "Folder not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /folders/{id}/retention [put]
+func SetFolderRetention(ctx *gin.Context) {
+	db := requestDB(ctx)
+
+	var req RetentionPolicyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apperrors.Respond(ctx, validation.Error(err, "Invalid request format"))
+		return
+	}
+	if req.MinRetentionDays == 0 && req.DeleteAfterDays == 0 {
+		apperrors.Respond(ctx, apperrors.BadRequest("Set min_retention_days or delete_after_days, or delete the policy"))
+		return
+	}
+	if req.DeleteAfterDays > 0 && req.DeleteAfterDays < req.MinRetentionDays {
+		apperrors.Respond(ctx, apperrors.BadRequest("delete_after_days must not be shorter than min_retention_days"))
+		return
+	}
+
+	userID := requestUserID(ctx, "")
+	if userID == nil {
+		apperrors.Respond(ctx, apperrors.Unauthorized("User ID is required"))
+		return
+	}
+
+	var folder document.Folder
+	if err := db.First(&folder, "id = ?", ctx.Param("id")).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.NotFound("Folder not found"))
+		return
+	}
+
+	var policy document.RetentionPolicy
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("folder_id = ?", folder.ID).First(&policy).Error; err != nil {
+			if err != gorm.ErrRecordNotFound {
+				return err
+			}
+			policy = document.RetentionPolicy{FolderID: folder.ID, CreatedBy: *userID}
+		}
+		previous := gin.H{"min_retention_days": policy.MinRetentionDays, "delete_after_days": policy.DeleteAfterDays}
+
+		policy.MinRetentionDays = req.MinRetentionDays
+		policy.DeleteAfterDays = req.DeleteAfterDays
+		policy.UpdatedBy = *userID
+		if err := tx.Save(&policy).Error; err != nil {
+			return err
+		}
+
+		return recordRetentionAudit(ctx, tx, "RETENTION", gin.H{
+			"folder_id": folder.ID,
+			"previous":  previous,
+			"policy":    req,
+		})
+	})
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to save retention policy"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Retention policy saved",
+		"data":    policy,
+	})
+}
+
+// DeleteFolderRetention removes the retention policy of a folder
+// @Summary Delete folder retention
+// @Description Remove the retention policy of a folder. Policies of parent folders keep applying. The change is audit logged.
+// @Tags folders
+// @Produce json
+// @Param id path string true "Folder ID" format(uuid)
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Retention policy deleted"
+// @Failure 404 {object} map[string]string "Folder or policy not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /folders/{id}/retention [delete]
+func DeleteFolderRetention(ctx *gin.Context) {
+	db := requestDB(ctx)
+
+	var folder document.Folder
+	if err := db.First(&folder, "id = ?", ctx.Param("id")).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.NotFound("Folder not found"))
+		return
+	}
+
+	var policy document.RetentionPolicy
+	if err := db.Where("folder_id = ?", folder.ID).First(&policy).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.NotFound("Folder has no retention policy"))
+		return
+	}
+
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&policy).Error; err != nil {
+			return err
+		}
+		return recordRetentionAudit(ctx, tx, "RETENTION", gin.H{
+			"folder_id": folder.ID,
+			"previous":  gin.H{"min_retention_days": policy.MinRetentionDays, "delete_after_days": policy.DeleteAfterDays},
+			"policy":    nil,
+		})
+	}); err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to delete retention policy"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Retention policy deleted",
+	})
+}
+
+// GetLegalHolds lists legal holds
+// @Summary List legal holds
+// @Description List legal holds, newest first. Released holds are kept for the record and listed with active=false.
+// @Tags documents
+// @Produce json
+// @Param active query bool false "Only active (true) or released (false) holds"
+// @Param object_type query string false "Object type" Enums(folder, document)
+// @Param object_id query string false "Folder or document ID" format(uuid)
+// @Param page query int false "Page number (default: 1)"
+// @Param limit query int false "Holds per page (default: 10, max: 100)"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Legal holds"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /legal-holds [get]
+func GetLegalHolds(ctx *gin.Context) {
+	params := query.ParseQueryParams(ctx)
+
+	holdQuery := requestDB(ctx).Model(&document.LegalHold{})
+	switch ctx.Query("active") {
+	case "true":
+		holdQuery = holdQuery.Where("released_at IS NULL")
+	case "false":
+		holdQuery = holdQuery.Where("released_at IS NOT NULL")
+	}
+	if objectType := ctx.Query("object_type"); objectType != "" {
+		holdQuery = holdQuery.Where("object_type = ?", objectType)
+	}
+	if objectID := ctx.Query("object_id"); objectID != "" {
+		holdQuery = holdQuery.Where("object_id = ?", objectID)
+	}
+
+	var total int64
+	if err := holdQuery.Count(&total).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to fetch legal holds"))
+		return
+	}
+
+	holds := []document.LegalHold{}
+	if err := query.ApplyPagination(holdQuery, params.Page, params.Limit).
+		Order("created_at DESC").
+		Find(&holds).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to fetch legal holds"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"data":       holds,
+		"pagination": query.BuildPaginationResponse(params.Page, params.Limit, total),
+	})
+}
+
+// PlaceLegalHold places a legal hold on a folder or document
+// @Summary Place legal hold
+// @Description Place a legal hold on a document, or on a folder with everything in it. Held documents and folders cannot be deleted or purged from the trash and the versions of held documents are never pruned, regardless of retention policies. The hold is audit logged.
+// @Tags documents
+// @Accept json
+// @Produce json
+// @Param hold body PlaceLegalHoldRequest true "Object and reason"
+// @Security BearerAuth
+// @Success 201 {object} map[string]interface{} "Legal hold"
+// @Failure 400 {object} map[string]string "Invalid request data"
+// @Failure 404 {object} map[string]string "Folder or document not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /legal-holds [post]
+func PlaceLegalHold(ctx *gin.Context) {
+	db := requestDB(ctx)
+
+	var req PlaceLegalHoldRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apperrors.Respond(ctx, validation.Error(err, "Invalid request format"))
+		return
+	}
+
+	userID := requestUserID(ctx, "")
+	if userID == nil {
+		apperrors.Respond(ctx, apperrors.Unauthorized("User ID is required"))
+		return
+	}
+
+	// Objects in the trash can be held too, which keeps them from being purged
+	objectID := uuid.MustParse(req.ObjectID)
+	var count int64
+	if req.ObjectType == document.AccessObjectFolder {
+		db.Unscoped().Model(&document.Folder{}).Where("id = ?", objectID).Count(&count)
+	} else {
+		db.Unscoped().Model(&document.Document{}).Where("id = ?", objectID).Count(&count)
+	}
+	if count == 0 {
+		apperrors.Respond(ctx, apperrors.NotFound("Folder or document not found"))
+		return
+	}
+
+	hold := document.LegalHold{
+		ObjectType: req.ObjectType,
+		ObjectID:   objectID,
+		Reason:     req.Reason,
+		PlacedBy:   *userID,
+	}
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&hold).Error; err != nil {
+			return err
+		}
+		return recordRetentionAudit(ctx, tx, "HOLD", hold)
+	}); err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to place legal hold"))
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Legal hold placed",
+		"data":    hold,
+	})
+}
+
+// ReleaseLegalHold releases a legal hold
+// @Summary Release legal hold
+// @Description Release an active legal hold. The object stays protected by other active holds on it or its folders. The release is audit logged.
+// @Tags documents
+// @Produce json
+// @Param id path string true "Legal hold ID" format(uuid)
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Released legal hold"
+// @Failure 404 {object} map[string]string "Legal hold not found"
+// @Failure 409 {object} map[string]string "Legal hold already released"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /legal-holds/{id}/release [post]
+func ReleaseLegalHold(ctx *gin.Context) {
+	db := requestDB(ctx)
+
+	userID := requestUserID(ctx, "")
+	if userID == nil {
+		apperrors.Respond(ctx, apperrors.Unauthorized("User ID is required"))
+		return
+	}
+
+	var hold document.LegalHold
+	if err := db.First(&hold, "id = ?", ctx.Param("id")).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.NotFound("Legal hold not found"))
+		return
+	}
+	if !hold.IsActive() {
+		apperrors.Respond(ctx, apperrors.Conflict("Legal hold already released"))
+		return
+	}
+
+	now := time.Now()
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&hold).Where("released_at IS NULL").Updates(map[string]interface{}{
+			"released_at": now,
+			"released_by": *userID,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		hold.ReleasedAt = &now
+		hold.ReleasedBy = userID
+		return recordRetentionAudit(ctx, tx, "RELEASE", hold)
+	}); err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to release legal hold"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Legal hold released",
+		"data":    hold,
+	})
+}
+
+// recordRetentionAudit writes an audit log entry for a retention or legal hold change in its transaction
+func recordRetentionAudit(ctx *gin.Context, tx *gorm.DB, method string, body interface{}) error {
+	return tx.Create(&notification.AuditLog{
+		UserID:      utils.GetActorID(ctx),
+		Method:      method,
+		Path:        ctx.Request.URL.Path,
+		StatusCode:  http.StatusOK,
+		RequestBody: body,
+		IPAddress:   ctx.ClientIP(),
+		UserAgent:   ctx.Request.UserAgent(),
+	}).Error
+}
+
+// checkDocumentDeletable writes the 423 response when the document is under legal hold or within its
+// minimum retention
+func checkDocumentDeletable(ctx *gin.Context, db *gorm.DB, doc *document.Document) bool {
+	reason, err := services.DocumentDeletionBlock(db, doc)
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to check retention"))
+		return false
+	}
+	if reason != "" {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeLocked, "Document cannot be deleted").WithDetails(reason))
+		return false
+	}
+	return true
+}
+
+// checkFolderDeletable writes the 423 response when the folder is under legal hold
+func checkFolderDeletable(ctx *gin.Context, db *gorm.DB, folder *document.Folder) bool {
+	reason, err := services.FolderDeletionBlock(db, folder)
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to check retention"))
+		return false
+	}
+	if reason != "" {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeLocked, "Folder cannot be deleted").WithDetails(reason))
+		return false
+	}
+	return true
+}
+
+// respondMoveBlocked writes the 423 response for a move out of a folder under legal hold, or the 500
+// response when the hold could not be checked
+func respondMoveBlocked(ctx *gin.Context, reason string, err error) {
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to check legal holds"))
+		return
+	}
+	apperrors.Respond(ctx, apperrors.New(apperrors.CodeLocked, "Cannot move out of a folder under legal hold").WithDetails(reason))
+}
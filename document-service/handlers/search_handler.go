@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"html"
+	"net/http"
+	"strings"
+
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/config"
+	"forgecrud-backend/shared/database/models"
+	"forgecrud-backend/shared/database/models/document"
+	docUtils "forgecrud-backend/shared/utils/document"
+	"forgecrud-backend/shared/utils/query"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Markers ts_headline puts around matches, replaced by <mark> once the snippet is HTML-escaped
+const (
+	snippetStartMarker = "[[[mark]]]"
+	snippetStopMarker  = "[[[/mark]]]"
+)
+
+// SearchResult is a document matching a full-text search
+type SearchResult struct {
+	Document docUtils.DocumentResponse `json:"document"`
+	Rank     float64                   `json:"rank"`
+	Snippet  string                    `json:"snippet"` // HTML-escaped excerpt with matches in <mark>
+}
+
+// searchHit is a ranked search match before its document is loaded
+type searchHit struct {
+	ID      uuid.UUID
+	Rank    float64
+	Snippet string
+}
+
+// SearchDocuments searches documents by content and metadata
+// @Summary Search documents
+// @Description Full-text search over document names, tags, descriptions and extracted content (PDF, DOCX, text files and OCR of images), ranked by relevance. The query supports "quoted phrases", OR and -exclusions.
+// @Tags documents
+// @Accept json
+// @Produce json
+// @Param q query string true "Search query"
+// @Param folder_id query string false "Only search this folder"
+// @Param include_subfolders query bool false "Also search the subfolders of folder_id"
+// @Param tags query string false "Comma separated tags the documents must all have"
+// @Param page query int false "Page number (default: 1)"
+// @Param limit query int false "Results per page (default: 10, max: 100)"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Matching documents with rank and snippet"
+// @Failure 400 {object} map[string]string "Missing query or invalid folder_id"
+// @Failure 404 {object} map[string]string "Folder not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /documents/search [get]
+func SearchDocuments(ctx *gin.Context) {
+	db := requestDB(ctx)
+	language := config.GetConfig().SearchLanguage
+
+	searchQuery := strings.TrimSpace(ctx.Query("q"))
+	if searchQuery == "" {
+		apperrors.Respond(ctx, apperrors.BadRequest("q is required"))
+		return
+	}
+
+	params := query.ParseQueryParams(ctx)
+
+	dbQuery := readableDocuments(ctx, db.Model(&document.Document{}).
+		Where("documents.search_vector @@ websearch_to_tsquery(?::regconfig, ?)", language, searchQuery))
+
+	if folderID := ctx.Query("folder_id"); folderID != "" {
+		folderUUID, err := uuid.Parse(folderID)
+		if err != nil {
+			apperrors.Respond(ctx, apperrors.BadRequest("Invalid folder ID format"))
+			return
+		}
+
+		var folder document.Folder
+		if err := db.First(&folder, folderUUID).Error; err != nil {
+			apperrors.Respond(ctx, apperrors.NotFound("Folder not found"))
+			return
+		}
+
+		if ctx.Query("include_subfolders") == "true" {
+			dbQuery = dbQuery.Where("documents.folder_id IN (SELECT id FROM folders WHERE (path = ? OR path LIKE ?) AND deleted_at IS NULL)",
+				folder.Path, folder.Path+"/%")
+		} else {
+			dbQuery = dbQuery.Where("documents.folder_id = ?", folder.ID)
+		}
+	}
+
+	dbQuery = filterByTags(dbQuery, ctx.Query("tags"))
+
+	var total int64
+	if err := dbQuery.Count(&total).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to search documents"))
+		return
+	}
+
+	var hits []searchHit
+	if err := query.ApplyPagination(dbQuery, params.Page, params.Limit).
+		Select(`documents.id,
+			ts_rank_cd(documents.search_vector, websearch_to_tsquery(?::regconfig, ?)) AS rank,
+			ts_headline(?::regconfig, coalesce(nullif(documents.content, ''), documents.description, ''),
+				websearch_to_tsquery(?::regconfig, ?), ?) AS snippet`,
+			language, searchQuery, language, language, searchQuery,
+			"StartSel="+snippetStartMarker+", StopSel="+snippetStopMarker+", MaxFragments=2, MaxWords=30, MinWords=10").
+		Order("rank DESC, documents.updated_at DESC").
+		Scan(&hits).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to search documents"))
+		return
+	}
+
+	ids := make([]uuid.UUID, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.ID
+	}
+
+	var documents []document.Document
+	if len(ids) > 0 {
+		if err := db.Preload("Folder").Where("id IN ?", ids).Find(&documents).Error; err != nil {
+			apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to fetch documents"))
+			return
+		}
+	}
+	documentsByID := make(map[uuid.UUID]*document.Document, len(documents))
+	for i := range documents {
+		documentsByID[documents[i].ID] = &documents[i]
+	}
+
+	results := make([]SearchResult, 0, len(hits))
+	for _, hit := range hits {
+		doc, ok := documentsByID[hit.ID]
+		if !ok {
+			continue
+		}
+		results = append(results, SearchResult{
+			Document: docUtils.BuildDocumentResponse(doc, db),
+			Rank:     hit.Rank,
+			Snippet:  highlightSnippet(hit.Snippet),
+		})
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"data":       results,
+		"pagination": query.BuildPaginationResponse(params.Page, params.Limit, total),
+	})
+}
+
+// filterByTags limits a document query to the documents having every one of the comma separated tags,
+// compared case-insensitively through the tag index
+func filterByTags(dbQuery *gorm.DB, tags string) *gorm.DB {
+	keys := docUtils.TagKeys(docUtils.ParseTags(tags))
+	if len(keys) == 0 {
+		return dbQuery
+	}
+	return dbQuery.Where("document_tag_keys(documents.tags) @> ?", models.StringArray(keys))
+}
+
+// highlightSnippet escapes a ts_headline snippet and marks its matches with <mark>
+func highlightSnippet(snippet string) string {
+	snippet = html.EscapeString(snippet)
+	snippet = strings.ReplaceAll(snippet, snippetStartMarker, "<mark>")
+	return strings.ReplaceAll(snippet, snippetStopMarker, "</mark>")
+}
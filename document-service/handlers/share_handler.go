@@ -0,0 +1,382 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"forgecrud-backend/document-service/services"
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/database/models/document"
+	utils "forgecrud-backend/shared/utils/auth"
+	docUtils "forgecrud-backend/shared/utils/document"
+	"forgecrud-backend/shared/utils/validation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SharePasswordHeader carries the password of a password protected share link
+const SharePasswordHeader = "X-Share-Password"
+
+// CreateShareRequest represents request body for sharing a document
+type CreateShareRequest struct {
+	Password     string     `json:"password"`
+	ExpiresAt    *time.Time `json:"expires_at"`
+	MaxDownloads *int       `json:"max_downloads" binding:"omitempty,min=1"`
+	ViewOnly     bool       `json:"view_only"`
+	UserID       string     `json:"user_id"` // for testing purposes, the gateway forwards the caller
+}
+
+// ShareResponse is a share with its public link
+type ShareResponse struct {
+	document.DocumentShare
+	URL               string `json:"url"`
+	PasswordProtected bool   `json:"password_protected"`
+}
+
+// PublicShareResponse describes a shared document to the holder of the link
+type PublicShareResponse struct {
+	Name               string     `json:"name"`
+	Size               int64      `json:"size"`
+	MimeType           string     `json:"mime_type"`
+	Extension          string     `json:"extension"`
+	ViewOnly           bool       `json:"view_only"`
+	ExpiresAt          *time.Time `json:"expires_at"`
+	DownloadsRemaining *int       `json:"downloads_remaining"` // Null for unlimited downloads
+}
+
+// CreateShare creates a public link to a document
+// @Summary Share a document
+// @Description Create a signed public link to the document's current version, optionally protected by a password and limited by an expiry date and a number of downloads. View-only links serve the file inline for preview instead of as a download.
+// @Tags documents
+// @Accept json
+// @Produce json
+// @Param id path string true "Document ID" format(uuid)
+// @Param request body CreateShareRequest false "Share restrictions"
+// @Security BearerAuth
+// @Success 201 {object} map[string]interface{} "Share with its public URL"
+// @Failure 400 {object} map[string]string "Invalid request data"
+// @Failure 403 {object} map[string]string "Access denied"
+// @Failure 404 {object} map[string]string "Document not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /documents/{id}/share [post]
+func CreateShare(ctx *gin.Context) {
+	db := requestDB(ctx)
+
+	var doc document.Document
+	if err := db.First(&doc, "id = ?", ctx.Param("id")).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.NotFound("Document not found"))
+		return
+	}
+
+	if !checkDocumentAccess(ctx, &doc, document.AccessLevelManage) {
+		return
+	}
+
+	// All restrictions are optional, so is the body
+	var req CreateShareRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		apperrors.Respond(ctx, validation.Error(err, "Invalid request body"))
+		return
+	}
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		apperrors.Respond(ctx, apperrors.BadRequest("expires_at must be in the future"))
+		return
+	}
+
+	createdBy := utils.GetActorID(ctx)
+	if createdBy == nil {
+		userID, err := uuid.Parse(req.UserID)
+		if err != nil {
+			apperrors.Respond(ctx, apperrors.BadRequest("user_id is required"))
+			return
+		}
+		createdBy = &userID
+	}
+
+	share := document.DocumentShare{
+		DocumentID:   doc.ID,
+		ExpiresAt:    req.ExpiresAt,
+		MaxDownloads: req.MaxDownloads,
+		ViewOnly:     req.ViewOnly,
+		CreatedBy:    *createdBy,
+	}
+	if req.Password != "" {
+		passwordHash, err := utils.HashPassword(req.Password)
+		if err != nil {
+			apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to hash password"))
+			return
+		}
+		share.PasswordHash = passwordHash
+	}
+
+	if err := db.Create(&share).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to create share"))
+		return
+	}
+	recordDocumentActivity(ctx, doc.ID, document.DocumentActionShare, "Share link "+share.ID.String())
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Share link created successfully",
+		"data":    buildShareResponse(share),
+	})
+}
+
+// GetDocumentShares lists the share links of a document
+// @Summary List document shares
+// @Description List the share links of a document, including expired and revoked ones, newest first
+// @Tags documents
+// @Accept json
+// @Produce json
+// @Param id path string true "Document ID" format(uuid)
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Shares with their public URLs"
+// @Failure 403 {object} map[string]string "Access denied"
+// @Failure 404 {object} map[string]string "Document not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /documents/{id}/shares [get]
+func GetDocumentShares(ctx *gin.Context) {
+	db := requestDB(ctx)
+
+	var doc document.Document
+	if err := db.First(&doc, "id = ?", ctx.Param("id")).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.NotFound("Document not found"))
+		return
+	}
+
+	if !checkDocumentAccess(ctx, &doc, document.AccessLevelManage) {
+		return
+	}
+
+	var shares []document.DocumentShare
+	if err := db.Where("document_id = ?", doc.ID).Order("created_at DESC").Find(&shares).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to fetch shares"))
+		return
+	}
+
+	response := make([]ShareResponse, len(shares))
+	for i, share := range shares {
+		response[i] = buildShareResponse(share)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    response,
+	})
+}
+
+// RevokeShare disables a share link
+// @Summary Revoke a document share
+// @Description Revoke a share link so it can no longer be opened
+// @Tags documents
+// @Accept json
+// @Produce json
+// @Param id path string true "Document ID" format(uuid)
+// @Param share_id path string true "Share ID" format(uuid)
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Revoked share"
+// @Failure 403 {object} map[string]string "Access denied"
+// @Failure 404 {object} map[string]string "Share not found"
+// @Failure 409 {object} map[string]string "Share is already revoked"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /documents/{id}/shares/{share_id} [delete]
+func RevokeShare(ctx *gin.Context) {
+	db := requestDB(ctx)
+
+	var doc document.Document
+	if err := db.First(&doc, "id = ?", ctx.Param("id")).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.NotFound("Document not found"))
+		return
+	}
+
+	if !checkDocumentAccess(ctx, &doc, document.AccessLevelManage) {
+		return
+	}
+
+	var share document.DocumentShare
+	if err := db.Where("id = ? AND document_id = ?", ctx.Param("share_id"), ctx.Param("id")).First(&share).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.NotFound("Share not found"))
+		return
+	}
+
+	if share.RevokedAt != nil {
+		apperrors.Respond(ctx, apperrors.Conflict("Share is already revoked"))
+		return
+	}
+
+	now := time.Now()
+	if err := db.Model(&share).Update("revoked_at", now).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to revoke share"))
+		return
+	}
+	share.RevokedAt = &now
+	recordDocumentActivity(ctx, doc.ID, document.DocumentActionUnshare, "Share link "+share.ID.String())
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Share link revoked successfully",
+		"data":    buildShareResponse(share),
+	})
+}
+
+// GetPublicShare describes the document behind a share link
+// @Summary Open a share link
+// @Description Public endpoint describing the shared document. Password protected links need the password in the X-Share-Password header.
+// @Tags shares
+// @Produce json
+// @Param token path string true "Share token"
+// @Param X-Share-Password header string false "Password of a protected link"
+// @Success 200 {object} map[string]interface{} "Shared document"
+// @Failure 401 {object} map[string]interface{} "Password required or invalid"
+// @Failure 404 {object} map[string]string "Share link not found"
+// @Failure 410 {object} map[string]string "Share link expired or revoked"
+// @Router /shares/{token} [get]
+func GetPublicShare(ctx *gin.Context) {
+	share, doc, ok := findPublicShare(ctx)
+	if !ok {
+		return
+	}
+
+	var remaining *int
+	if share.MaxDownloads != nil {
+		count := *share.MaxDownloads - share.DownloadCount
+		if count < 0 {
+			count = 0
+		}
+		remaining = &count
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": PublicShareResponse{
+			Name:               doc.OriginalName,
+			Size:               doc.FileSize,
+			MimeType:           doc.MimeType,
+			Extension:          doc.FileExtension,
+			ViewOnly:           share.ViewOnly,
+			ExpiresAt:          share.ExpiresAt,
+			DownloadsRemaining: remaining,
+		},
+	})
+}
+
+// DownloadPublicShare serves the document behind a share link
+// @Summary Download through a share link
+// @Description Public endpoint serving the shared file, inline for view-only links. Every request counts towards the download limit.
+// @Tags shares
+// @Produce application/octet-stream
+// @Param token path string true "Share token"
+// @Param X-Share-Password header string false "Password of a protected link"
+// @Success 200 {file} file "Document file content"
+// @Failure 401 {object} map[string]interface{} "Password required or invalid"
+// @Failure 404 {object} map[string]string "Share link not found"
+// @Failure 409 {object} map[string]string "Document is being scanned for malware"
+// @Failure 410 {object} map[string]string "Share link expired, revoked or out of downloads"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /shares/{token}/download [get]
+func DownloadPublicShare(ctx *gin.Context) {
+	share, doc, ok := findPublicShare(ctx)
+	if !ok {
+		return
+	}
+
+	if !checkScanStatus(ctx, doc.ScanStatus) {
+		return
+	}
+
+	storage, err := services.NewStorageProvider()
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Storage service unavailable"))
+		return
+	}
+
+	object, info, err := storage.GetObject(ctx.Request.Context(), docUtils.StorageKey(doc.ObjectKey, doc.ContentHash))
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to download file"))
+		return
+	}
+	defer object.Close()
+
+	// Count the download, the condition keeps concurrent downloads within the limit
+	result := requestDB(ctx).Model(&share).
+		Where("max_downloads IS NULL OR download_count < max_downloads").
+		Updates(map[string]interface{}{
+			"download_count":   gorm.Expr("download_count + 1"),
+			"last_accessed_at": time.Now(),
+		})
+	if result.Error != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to download file"))
+		return
+	}
+	if result.RowsAffected == 0 {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeGone, "Share link has reached its download limit"))
+		return
+	}
+
+	ctx.Header("Content-Disposition", docUtils.ContentDisposition(doc.OriginalName, doc.MimeType, share.ViewOnly))
+	ctx.Header("X-Content-Type-Options", "nosniff")
+	ctx.Header("Cache-Control", "private, no-store")
+	recordDocumentActivity(ctx, doc.ID, document.DocumentActionDownload, "Share link "+share.ID.String())
+
+	ctx.DataFromReader(http.StatusOK, info.Size, doc.MimeType, object, nil)
+}
+
+// findPublicShare resolves the share link of a public request and writes the error response when it
+// cannot be opened
+func findPublicShare(ctx *gin.Context) (document.DocumentShare, document.Document, bool) {
+	var share document.DocumentShare
+	var doc document.Document
+
+	shareID, err := docUtils.ParseShareToken(ctx.Param("token"))
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.NotFound("Share link not found"))
+		return share, doc, false
+	}
+
+	db := requestDB(ctx)
+	if err := db.First(&share, shareID).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.NotFound("Share link not found"))
+		return share, doc, false
+	}
+
+	if share.RevokedAt != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeGone, "Share link has been revoked"))
+		return share, doc, false
+	}
+	if share.ExpiresAt != nil && share.ExpiresAt.Before(time.Now()) {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeGone, "Share link has expired"))
+		return share, doc, false
+	}
+
+	// Documents in the trash are not shared
+	if err := db.First(&doc, share.DocumentID).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.NotFound("Share link not found"))
+		return share, doc, false
+	}
+
+	if share.PasswordHash != "" {
+		password := ctx.GetHeader(SharePasswordHeader)
+		if password == "" {
+			apperrors.Respond(ctx, apperrors.Unauthorized("Password required").With("password_required", true))
+			return share, doc, false
+		}
+		if !utils.CheckPasswordHash(password, share.PasswordHash) {
+			apperrors.Respond(ctx, apperrors.Unauthorized("Invalid password").With("password_required", true))
+			return share, doc, false
+		}
+	}
+
+	return share, doc, true
+}
+
+func buildShareResponse(share document.DocumentShare) ShareResponse {
+	return ShareResponse{
+		DocumentShare:     share,
+		URL:               docUtils.ShareURL(share.ID),
+		PasswordProtected: share.PasswordHash != "",
+	}
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"net/http"
+
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models"
+	utils "forgecrud-backend/shared/utils/auth"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// StorageUsageResponse summarizes the storage quotas that apply to the caller's uploads
+type StorageUsageResponse struct {
+	User           database.StorageQuotaStatus  `json:"user"` // Folders the caller owns
+	OrganizationID *uuid.UUID                   `json:"organization_id"`
+	Organization   *database.StorageQuotaStatus `json:"organization"` // Null outside an organization
+}
+
+// GetStorageUsage returns the caller's storage quota and usage
+// @Summary Get storage usage
+// @Description Get the storage limit, used and remaining bytes of the caller's own folders and of their organization. Uploads over either limit are rejected with 413.
+// @Tags documents
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Storage usage"
+// @Failure 400 {object} map[string]string "User ID is required"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /storage/usage [get]
+func GetStorageUsage(ctx *gin.Context) {
+	userID := utils.GetActorID(ctx)
+	if userID == nil {
+		apperrors.Respond(ctx, apperrors.BadRequest("User ID is required"))
+		return
+	}
+
+	db := database.GetDB()
+
+	var user models.User
+	if err := db.Select("id", "organization_id").First(&user, *userID).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.NotFound("User not found"))
+		return
+	}
+
+	var response StorageUsageResponse
+	var err error
+	if response.User, err = database.GetUserStorageStatus(db, user.ID); err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to retrieve storage usage"))
+		return
+	}
+
+	if user.OrganizationID != nil {
+		organization, err := database.GetOrganizationStorageStatus(db, *user.OrganizationID)
+		if err != nil {
+			apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to retrieve storage usage"))
+			return
+		}
+		response.OrganizationID = user.OrganizationID
+		response.Organization = &organization
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    response,
+	})
+}
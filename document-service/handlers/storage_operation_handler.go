@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"net/http"
+
+	"forgecrud-backend/document-service/services"
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models/document"
+	"forgecrud-backend/shared/utils/query"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// GetStorageOperations lists queued folder operations for storage
+// @Summary List storage operations
+// @Description List the folder creates and moves queued for storage, newest first. Pending operations are retried with exponential backoff, failed ones reached STORAGE_OUTBOX_MAX_ATTEMPTS. Only callers outside an organization can see them.
+// @Tags documents
+// @Produce json
+// @Param status query string false "Operation status" Enums(pending, succeeded, failed)
+// @Param folder_id query string false "Folder ID" format(uuid)
+// @Param page query int false "Page number (default: 1)"
+// @Param limit query int false "Operations per page (default: 10, max: 100)"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Storage operations"
+// @Failure 403 {object} map[string]string "Access denied"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /storage/operations [get]
+func GetStorageOperations(ctx *gin.Context) {
+	if !checkPlatformCaller(ctx) {
+		return
+	}
+
+	params := query.ParseQueryParams(ctx)
+
+	operationQuery := database.GetDB().Model(&document.StorageOperation{})
+	if status := ctx.Query("status"); status != "" {
+		operationQuery = operationQuery.Where("status = ?", status)
+	}
+	if folderID := ctx.Query("folder_id"); folderID != "" {
+		operationQuery = operationQuery.Where("folder_id = ?", folderID)
+	}
+
+	var total int64
+	if err := operationQuery.Count(&total).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to fetch storage operations"))
+		return
+	}
+
+	var operations []document.StorageOperation
+	if err := query.ApplyPagination(operationQuery, params.Page, params.Limit).
+		Order("created_at DESC").
+		Find(&operations).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to fetch storage operations"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"data":       operations,
+		"pagination": query.BuildPaginationResponse(params.Page, params.Limit, total),
+	})
+}
+
+// RetryStorageOperation applies a failed storage operation again
+// @Summary Retry storage operation
+// @Description Queue a failed storage operation again with a fresh attempt count and apply it right away. Only callers outside an organization can retry operations.
+// @Tags documents
+// @Produce json
+// @Param id path string true "Storage operation ID" format(uuid)
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Operation after the attempt"
+// @Failure 400 {object} map[string]string "Invalid ID format"
+// @Failure 403 {object} map[string]string "Access denied"
+// @Failure 404 {object} map[string]string "Operation not found"
+// @Failure 409 {object} map[string]string "Operation has not failed"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /storage/operations/{id}/retry [post]
+func RetryStorageOperation(ctx *gin.Context) {
+	if !checkPlatformCaller(ctx) {
+		return
+	}
+
+	operationID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.BadRequest("Invalid operation ID format"))
+		return
+	}
+
+	var operation document.StorageOperation
+	if err := database.GetDB().First(&operation, operationID).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.NotFound("Storage operation not found"))
+		return
+	}
+
+	if operation.Status != document.StorageOperationFailed {
+		apperrors.Respond(ctx, apperrors.Conflict("Operation has not failed").WithDetails("Only failed storage operations can be retried"))
+		return
+	}
+
+	storage, err := services.NewStorageProvider()
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Storage service unavailable"))
+		return
+	}
+
+	if err := services.NewStorageOutbox(storage).Retry(&operation); err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to retry storage operation"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Storage operation retried",
+		"data":    operation,
+	})
+}
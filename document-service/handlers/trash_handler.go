@@ -0,0 +1,310 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"forgecrud-backend/document-service/services"
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/config"
+	"forgecrud-backend/shared/database/models/document"
+	utils "forgecrud-backend/shared/utils/auth"
+	docUtils "forgecrud-backend/shared/utils/document"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TrashItem is a document or folder in the trash
+type TrashItem struct {
+	Type      string     `json:"type"` // "document" or "folder"
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Path      string     `json:"path"`
+	Size      int64      `json:"size"`
+	FolderID  *string    `json:"folder_id"` // Folder of a document or parent of a folder
+	DeletedAt time.Time  `json:"deleted_at"`
+	DeletedBy *uuid.UUID `json:"deleted_by"`
+	PurgeAt   *time.Time `json:"purge_at"` // Null when purging is disabled
+}
+
+// GetTrash lists the documents and folders in the trash
+// @Summary List the trash
+// @Description List deleted documents and folders, newest first, with the date they are purged permanently
+// @Tags documents
+// @Accept json
+// @Produce json
+// @Param type query string false "Only list documents or folders (document, folder)"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Trash items"
+// @Failure 400 {object} map[string]string "Invalid type"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /trash [get]
+func GetTrash(ctx *gin.Context) {
+	db := requestDB(ctx)
+
+	itemType := ctx.Query("type")
+	if itemType != "" && itemType != "document" && itemType != "folder" {
+		apperrors.Respond(ctx, apperrors.BadRequest("type must be document or folder"))
+		return
+	}
+
+	items := []TrashItem{}
+
+	if itemType != "folder" {
+		var documents []document.Document
+		if err := readableDocuments(ctx, db.Unscoped().Where("deleted_at IS NOT NULL")).Find(&documents).Error; err != nil {
+			apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to fetch trash"))
+			return
+		}
+		for _, doc := range documents {
+			folderID := doc.FolderID.String()
+			items = append(items, TrashItem{
+				Type:      "document",
+				ID:        doc.ID.String(),
+				Name:      doc.OriginalName,
+				Path:      doc.Path,
+				Size:      doc.FileSize,
+				FolderID:  &folderID,
+				DeletedAt: doc.DeletedAt.Time,
+				DeletedBy: doc.DeletedBy,
+				PurgeAt:   trashPurgeAt(doc.DeletedAt.Time),
+			})
+		}
+	}
+
+	if itemType != "document" {
+		var folders []document.Folder
+		if err := readableFolders(ctx, db.Unscoped().Where("deleted_at IS NOT NULL")).Find(&folders).Error; err != nil {
+			apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to fetch trash"))
+			return
+		}
+		for _, folder := range folders {
+			var parentID *string
+			if folder.ParentID != nil {
+				id := folder.ParentID.String()
+				parentID = &id
+			}
+			items = append(items, TrashItem{
+				Type:      "folder",
+				ID:        folder.ID.String(),
+				Name:      folder.Name,
+				Path:      folder.Path,
+				Size:      folder.TotalSize,
+				FolderID:  parentID,
+				DeletedAt: folder.DeletedAt.Time,
+				DeletedBy: folder.DeletedBy,
+				PurgeAt:   trashPurgeAt(folder.DeletedAt.Time),
+			})
+		}
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].DeletedAt.After(items[j].DeletedAt)
+	})
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    items,
+	})
+}
+
+// RestoreDocument restores a document from the trash
+// @Summary Restore a document
+// @Description Restore a document from the trash into its folder. The folder must not be in the trash.
+// @Tags documents
+// @Accept json
+// @Produce json
+// @Param id path string true "Document ID" format(uuid)
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Restored document"
+// @Failure 400 {object} map[string]string "Invalid document ID format"
+// @Failure 402 {object} map[string]interface{} "Document quota exceeded"
+// @Failure 403 {object} map[string]string "Access denied"
+// @Failure 404 {object} map[string]string "Document not found"
+// @Failure 409 {object} map[string]string "Document is not in the trash or its folder is"
+// @Failure 413 {object} map[string]interface{} "Storage quota exceeded"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /documents/{id}/restore [post]
+func RestoreDocument(ctx *gin.Context) {
+	documentUUID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.BadRequest("Invalid document ID format"))
+		return
+	}
+
+	db := requestDB(ctx)
+
+	var doc document.Document
+	if err := db.Unscoped().First(&doc, documentUUID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			apperrors.Respond(ctx, apperrors.NotFound("Document not found"))
+			return
+		}
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to fetch document"))
+		return
+	}
+
+	if !checkDocumentAccess(ctx, &doc, document.AccessLevelWrite) {
+		return
+	}
+
+	if !doc.DeletedAt.Valid {
+		apperrors.Respond(ctx, apperrors.Conflict("Document is not in the trash"))
+		return
+	}
+
+	// The folder must be restored first
+	var folder document.Folder
+	if err := db.First(&folder, doc.FolderID).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.Conflict("The document's folder is in the trash, restore the folder first"))
+		return
+	}
+
+	// Documents in the trash do not count towards the quota
+	if !checkFolderQuota(ctx, &folder, doc.FileSize, 1) {
+		return
+	}
+
+	if err := db.Unscoped().Model(&doc).Updates(map[string]interface{}{
+		"deleted_at": nil,
+		"deleted_by": nil,
+	}).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to restore document"))
+		return
+	}
+
+	services.QueueFolderStats(doc.FolderID)
+
+	db.Preload("Folder").First(&doc, documentUUID)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Document restored successfully",
+		"data":    docUtils.BuildDocumentResponse(&doc, db),
+	})
+}
+
+// RestoreFolder restores a folder from the trash
+// @Summary Restore a folder
+// @Description Restore a folder from the trash. Its parent folder must not be in the trash; documents and subfolders deleted before the folder stay in the trash.
+// @Tags folders
+// @Accept json
+// @Produce json
+// @Param id path string true "Folder ID" format(uuid)
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Restored folder"
+// @Failure 400 {object} map[string]string "Invalid folder ID format"
+// @Failure 403 {object} map[string]string "Access denied"
+// @Failure 404 {object} map[string]string "Folder not found"
+// @Failure 409 {object} map[string]string "Folder is not in the trash or its parent is"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /folders/{id}/restore [post]
+func RestoreFolder(ctx *gin.Context) {
+	folderUUID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid folder ID format"))
+		return
+	}
+
+	db := requestDB(ctx)
+
+	var folder document.Folder
+	if err := db.Unscoped().First(&folder, folderUUID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			apperrors.Respond(ctx, apperrors.NotFound("Folder not found").WithDetails("Folder with the given ID does not exist"))
+			return
+		}
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to fetch folder"))
+		return
+	}
+
+	if !checkFolderAccess(ctx, &folder, document.AccessLevelWrite) {
+		return
+	}
+
+	if !folder.DeletedAt.Valid {
+		apperrors.Respond(ctx, apperrors.Conflict("Folder is not in the trash").WithDetails("Only deleted folders can be restored"))
+		return
+	}
+
+	// The parent folder must be restored first
+	if folder.ParentID != nil {
+		var parent document.Folder
+		if err := db.First(&parent, *folder.ParentID).Error; err != nil {
+			apperrors.Respond(ctx, apperrors.Conflict("Parent folder is in the trash").WithDetails("Restore the parent folder before restoring this folder"))
+			return
+		}
+	}
+
+	if err := db.Unscoped().Model(&folder).Updates(map[string]interface{}{
+		"deleted_at": nil,
+		"deleted_by": nil,
+	}).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to restore folder"))
+		return
+	}
+
+	// The restored folder counts for its ancestors again
+	services.QueueFolderStats(folder.ID)
+
+	db.First(&folder, folderUUID)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Folder restored successfully",
+		"data":    docUtils.BuildFolderResponse(&folder),
+	})
+}
+
+// EmptyTrash permanently deletes everything in the trash
+// @Summary Empty the trash
+// @Description Permanently delete all documents and folders in the trash together with their stored files, without waiting for the retention period. Documents and folders under legal hold or within their minimum retention stay in the trash.
+// @Tags documents
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Number of purged documents and folders"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /trash [delete]
+func EmptyTrash(ctx *gin.Context) {
+	storage, err := services.NewStorageProvider()
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Storage service unavailable"))
+		return
+	}
+
+	purged, err := services.PurgeTrash(requestDB(ctx), storage, time.Now())
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to empty trash"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Trash emptied successfully",
+		"data":    purged,
+	})
+}
+
+// moveToTrash soft-deletes a document or folder and records who deleted it
+func moveToTrash(ctx *gin.Context, db *gorm.DB, value interface{}) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(value).Update("deleted_by", utils.GetActorID(ctx)).Error; err != nil {
+			return err
+		}
+		return tx.Delete(value).Error
+	})
+}
+
+// trashPurgeAt returns when an item deleted at the given time is purged
+func trashPurgeAt(deletedAt time.Time) *time.Time {
+	retentionDays := config.GetConfig().TrashRetentionDays
+	if retentionDays <= 0 {
+		return nil
+	}
+	purgeAt := deletedAt.Add(time.Duration(retentionDays) * 24 * time.Hour)
+	return &purgeAt
+}
@@ -0,0 +1,812 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"forgecrud-backend/document-service/services"
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/config"
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models"
+	"forgecrud-backend/shared/database/models/document"
+	"forgecrud-backend/shared/ids"
+	"forgecrud-backend/shared/messaging"
+	utils "forgecrud-backend/shared/utils/auth"
+	docUtils "forgecrud-backend/shared/utils/document"
+	"forgecrud-backend/shared/utils/validation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// minUploadChunkSize is the smallest part S3 multipart uploads accept (except for the last part)
+	minUploadChunkSize = 5 * 1024 * 1024
+	// maxUploadParts is the largest number of parts of an S3 multipart upload
+	maxUploadParts = 10000
+
+	// UploadOffsetHeader carries the offset of a chunk and the bytes received so far
+	UploadOffsetHeader = "Upload-Offset"
+)
+
+// InitiateUploadRequest represents request body for starting a chunked upload
+type InitiateUploadRequest struct {
+	FolderID    string `json:"folder_id"`   // folder of a new document
+	DocumentID  string `json:"document_id"` // or the document to add a new version to
+	FileName    string `json:"file_name" binding:"required"`
+	FileSize    int64  `json:"file_size" binding:"required,min=1"`
+	MimeType    string `json:"mime_type"`
+	Tags        string `json:"tags"` // comma separated
+	Description string `json:"description"`
+	UserID      string `json:"user_id"` // for testing purposes, the gateway forwards the caller
+
+	Metadata map[string]interface{} `json:"metadata"` // values of the folder's metadata fields, ignored for new versions
+
+	BaseVersion int `json:"base_version" binding:"min=0"` // version a new version is based on, rejected when the document has a newer version
+}
+
+// InitiateUpload starts a resumable chunked upload
+// @Summary Start a chunked upload
+// @Description Start a resumable upload of a large file into a folder (folder_id) or as a new version of a document (document_id). Upload the file in chunks of the returned chunk_size with PATCH /uploads/{id}, then complete the upload.
+// @Tags documents
+// @Accept json
+// @Produce json
+// @Param request body InitiateUploadRequest true "File information"
+// @Security BearerAuth
+// @Success 201 {object} map[string]interface{} "Upload session"
+// @Failure 400 {object} map[string]string "Invalid request data"
+// @Failure 402 {object} map[string]interface{} "Document quota exceeded"
+// @Failure 403 {object} map[string]string "Access denied"
+// @Failure 404 {object} map[string]string "Folder or document not found"
+// @Failure 409 {object} map[string]interface{} "Document has been changed since the base version"
+// @Failure 413 {object} map[string]interface{} "Storage quota or size limit of the file type exceeded"
+// @Failure 415 {object} map[string]interface{} "File extension not allowed"
+// @Failure 423 {object} map[string]interface{} "Document is locked by another user"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /uploads [post]
+func InitiateUpload(ctx *gin.Context) {
+	db := requestDB(ctx)
+	cfg := config.GetConfig()
+
+	var req InitiateUploadRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apperrors.Respond(ctx, validation.Error(err, "Invalid request body"))
+		return
+	}
+
+	if (req.FolderID == "") == (req.DocumentID == "") {
+		apperrors.Respond(ctx, apperrors.BadRequest("Exactly one of folder_id and document_id is required"))
+		return
+	}
+	if req.BaseVersion > 0 && req.DocumentID == "" {
+		apperrors.Respond(ctx, apperrors.BadRequest("base_version requires document_id"))
+		return
+	}
+
+	if cfg.UploadMaxFileSize > 0 && req.FileSize > cfg.UploadMaxFileSize {
+		apperrors.Respond(ctx, apperrors.Newf(apperrors.CodeBadRequest, "file size exceeds %d bytes limit", cfg.UploadMaxFileSize))
+		return
+	}
+
+	createdBy := utils.GetActorID(ctx)
+	if createdBy == nil {
+		userID, err := uuid.Parse(req.UserID)
+		if err != nil {
+			apperrors.Respond(ctx, apperrors.BadRequest("user_id is required"))
+			return
+		}
+		createdBy = &userID
+	}
+
+	tags := docUtils.ParseTags(req.Tags)
+	if err := docUtils.ValidateTags(tags); err != nil {
+		apperrors.Respond(ctx, apperrors.BadRequest(err.Error()))
+		return
+	}
+
+	fileName := filepath.Base(req.FileName)
+	session := document.UploadSession{
+		FileName:    fileName,
+		MimeType:    req.MimeType,
+		FileSize:    req.FileSize,
+		ChunkSize:   uploadChunkSize(req.FileSize),
+		Tags:        tags,
+		Description: req.Description,
+		Status:      document.UploadSessionUploading,
+		CreatedBy:   *createdBy,
+		ExpiresAt:   uploadSessionExpiry(),
+	}
+	if session.MimeType == "" {
+		session.MimeType = "application/octet-stream"
+	}
+
+	var folder document.Folder
+	if req.DocumentID != "" {
+		var doc document.Document
+		if err := db.Preload("Folder").First(&doc, "id = ?", req.DocumentID).Error; err != nil {
+			apperrors.Respond(ctx, apperrors.NotFound("Document not found"))
+			return
+		}
+
+		if !checkDocumentAccess(ctx, &doc, document.AccessLevelWrite) {
+			return
+		}
+		if !checkDocumentLock(ctx, &doc, createdBy) {
+			return
+		}
+		// Fail before gigabytes were uploaded, the base version is checked again on completion
+		if current := currentDocumentVersion(db, doc.ID); req.BaseVersion > 0 && req.BaseVersion != current {
+			respondVersionConflict(ctx, &versionConflictError{BaseVersion: req.BaseVersion, CurrentVersion: current})
+			return
+		}
+		folder = doc.Folder
+		session.DocumentID = &doc.ID
+		session.Version = nextDocumentVersion(db, doc.ID)
+		session.BaseVersion = req.BaseVersion
+	} else {
+		if err := db.First(&folder, "id = ?", req.FolderID).Error; err != nil {
+			apperrors.Respond(ctx, apperrors.NotFound("Folder not found"))
+			return
+		}
+
+		if !checkFolderAccess(ctx, &folder, document.AccessLevelWrite) {
+			return
+		}
+
+		fields, err := folderMetadataFields(db, &folder)
+		if err != nil {
+			apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to fetch metadata fields"))
+			return
+		}
+		session.Metadata, err = docUtils.ValidateMetadata(fields, req.Metadata)
+		if err != nil {
+			apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid metadata"))
+			return
+		}
+		session.Version = nextFileVersion(db, folder.ID, fileName)
+	}
+	session.FolderID = folder.ID
+	objectKey, err := services.OrganizationObjectKey(db, &folder, docUtils.GenerateMinIOPath(folder.Path, fileName, session.Version))
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Storage service unavailable"))
+		return
+	}
+	session.ObjectKey = objectKey
+	session.StorageKey = session.ObjectKey
+	if services.ScanningEnabled() {
+		// Assemble in quarantine until the malware scan finds the file clean
+		session.StorageKey = docUtils.QuarantineKey(session.ObjectKey)
+	}
+
+	// The content is checked with the first chunk
+	if !checkFileType(ctx, &folder, fileName, "", req.FileSize) {
+		return
+	}
+
+	// Reserve the quota up front instead of failing after gigabytes were uploaded
+	addDocuments := 1
+	if session.DocumentID != nil {
+		addDocuments = 0
+	}
+	if !checkFolderQuota(ctx, &folder, req.FileSize, addDocuments) {
+		return
+	}
+
+	hashState, err := marshalHash(md5.New())
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to start upload"))
+		return
+	}
+	session.HashState = hashState
+
+	contentHashState, err := marshalHash(sha256.New())
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to start upload"))
+		return
+	}
+	session.ContentHashState = contentHashState
+
+	storage, err := services.NewStorageProvider()
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Storage service unavailable"))
+		return
+	}
+
+	uploadID, err := storage.NewMultipartUpload(context.Background(), session.StorageKey, session.MimeType)
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to start upload"))
+		return
+	}
+	session.UploadID = uploadID
+
+	if err := db.Create(&session).Error; err != nil {
+		storage.AbortMultipartUpload(context.Background(), session.StorageKey, uploadID)
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to save upload session"))
+		return
+	}
+
+	ctx.Header(UploadOffsetHeader, "0")
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Upload started",
+		"data":    session,
+	})
+}
+
+// GetUpload returns the state of a chunked upload
+// @Summary Get chunked upload status
+// @Description Get an upload session; offset is the number of bytes received, so an interrupted upload resumes from there
+// @Tags documents
+// @Produce json
+// @Param id path string true "Upload ID" format(uuid)
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Upload session"
+// @Failure 404 {object} map[string]string "Upload not found"
+// @Router /uploads/{id} [get]
+func GetUpload(ctx *gin.Context) {
+	session, ok := findUploadSession(ctx)
+	if !ok {
+		return
+	}
+
+	ctx.Header(UploadOffsetHeader, strconv.FormatInt(session.Offset, 10))
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    session,
+	})
+}
+
+// UploadChunk appends a chunk to a chunked upload
+// @Summary Upload a chunk
+// @Description Upload the raw bytes of the next chunk. The offset (Upload-Offset header or offset query parameter) must equal the bytes received so far, and every chunk but the last must be exactly chunk_size bytes. A chunk that failed can simply be sent again. The type of the file is sniffed from the first chunk and checked against the file type policy.
+// @Tags documents
+// @Accept application/octet-stream
+// @Produce json
+// @Param id path string true "Upload ID" format(uuid)
+// @Param Upload-Offset header int false "Offset of the chunk"
+// @Param offset query int false "Offset of the chunk (when the header cannot be sent)"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Upload session"
+// @Failure 400 {object} map[string]string "Invalid chunk"
+// @Failure 404 {object} map[string]string "Upload not found"
+// @Failure 409 {object} map[string]interface{} "Offset mismatch"
+// @Failure 410 {object} map[string]string "Upload expired"
+// @Failure 413 {object} map[string]interface{} "Size limit of the file type exceeded"
+// @Failure 415 {object} map[string]interface{} "File type not allowed"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /uploads/{id} [patch]
+func UploadChunk(ctx *gin.Context) {
+	session, ok := findActiveUploadSession(ctx)
+	if !ok {
+		return
+	}
+
+	offsetValue := ctx.GetHeader(UploadOffsetHeader)
+	if offsetValue == "" {
+		offsetValue = ctx.Query("offset")
+	}
+	offset, err := strconv.ParseInt(offsetValue, 10, 64)
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.BadRequest("Upload-Offset header or offset query parameter is required"))
+		return
+	}
+
+	if offset != session.Offset {
+		ctx.Header(UploadOffsetHeader, strconv.FormatInt(session.Offset, 10))
+		apperrors.Respond(ctx, apperrors.Conflict("Offset mismatch").With("offset", session.Offset))
+		return
+	}
+
+	remaining := session.FileSize - session.Offset
+	if remaining == 0 {
+		apperrors.Respond(ctx, apperrors.Conflict("All bytes were received, complete the upload"))
+		return
+	}
+
+	expected := session.ChunkSize
+	if remaining < expected {
+		expected = remaining
+	}
+	if ctx.Request.ContentLength != expected {
+		apperrors.Respond(ctx, apperrors.Newf(apperrors.CodeBadRequest, "Chunk at offset %d must be exactly %d bytes", offset, expected))
+		return
+	}
+
+	chunk := io.LimitReader(ctx.Request.Body, expected)
+	progress := map[string]interface{}{
+		"upload_offset": offset + expected,
+		"expires_at":    uploadSessionExpiry(),
+	}
+
+	// The type of the file is sniffed from its first chunk, the client's mime_type is not trusted
+	if offset == 0 {
+		buffered := bufio.NewReaderSize(chunk, docUtils.SniffLength)
+		head, err := buffered.Peek(docUtils.SniffLength)
+		if err != nil && err != io.EOF {
+			apperrors.Respond(ctx, apperrors.BadRequest("Failed to read chunk"))
+			return
+		}
+		mimeType := docUtils.SniffMimeType(head, session.FileName)
+
+		var folder document.Folder
+		if err := requestDB(ctx).First(&folder, "id = ?", session.FolderID).Error; err != nil {
+			apperrors.Respond(ctx, apperrors.NotFound("Folder not found"))
+			return
+		}
+		if !checkFileType(ctx, &folder, session.FileName, mimeType, session.FileSize) {
+			return
+		}
+		progress["mime_type"] = mimeType
+		session.MimeType = mimeType
+		chunk = buffered
+	}
+
+	hasher, contentHasher, err := restoreUploadHashes(&session)
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to restore upload checksum"))
+		return
+	}
+
+	storage, err := services.NewStorageProvider()
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Storage service unavailable"))
+		return
+	}
+
+	// Parts are numbered by position, so re-sending a chunk replaces the part instead of duplicating it
+	partNumber := int(offset/session.ChunkSize) + 1
+	var hashes io.Writer = hasher
+	if contentHasher != nil {
+		hashes = io.MultiWriter(hasher, contentHasher)
+	}
+	body := io.TeeReader(chunk, hashes)
+	if err := storage.PutObjectPart(ctx.Request.Context(), session.StorageKey, session.UploadID, partNumber, body, expected); err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to store chunk"))
+		return
+	}
+
+	hashState, err := marshalHash(hasher)
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to save upload checksum"))
+		return
+	}
+	progress["hash_state"] = hashState
+	if contentHasher != nil {
+		contentHashState, err := marshalHash(contentHasher)
+		if err != nil {
+			apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to save upload checksum"))
+			return
+		}
+		progress["content_hash_state"] = contentHashState
+	}
+
+	// Only advance if no other request advanced the upload in the meantime
+	result := requestDB(ctx).Model(&session).
+		Where("upload_offset = ? AND status = ?", offset, document.UploadSessionUploading).
+		Updates(progress)
+	if result.Error != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to save upload progress"))
+		return
+	}
+	if result.RowsAffected == 0 {
+		apperrors.Respond(ctx, apperrors.Conflict("Upload was changed by another request, check its offset"))
+		return
+	}
+	session.Offset = offset + expected
+
+	ctx.Header(UploadOffsetHeader, strconv.FormatInt(session.Offset, 10))
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    session,
+	})
+}
+
+// CompleteUpload turns a fully received chunked upload into a document
+// @Summary Complete a chunked upload
+// @Description Assemble the uploaded chunks and create the document, or the new document version for uploads started with document_id
+// @Tags documents
+// @Produce json
+// @Param id path string true "Upload ID" format(uuid)
+// @Security BearerAuth
+// @Success 201 {object} map[string]interface{} "Document uploaded successfully"
+// @Failure 404 {object} map[string]string "Upload not found"
+// @Failure 409 {object} map[string]interface{} "Upload incomplete or document changed since the base version"
+// @Failure 410 {object} map[string]string "Upload expired"
+// @Failure 423 {object} map[string]interface{} "Document is locked by another user"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /uploads/{id}/complete [post]
+func CompleteUpload(ctx *gin.Context) {
+	session, ok := findActiveUploadSession(ctx)
+	if !ok {
+		return
+	}
+	db := requestDB(ctx)
+
+	if session.Offset != session.FileSize {
+		apperrors.Respond(ctx, apperrors.Conflict("Upload incomplete").With("offset", session.Offset).With("size", session.FileSize))
+		return
+	}
+
+	hasher, contentHasher, err := restoreUploadHashes(&session)
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to restore upload checksum"))
+		return
+	}
+	checksum := fmt.Sprintf("%x", hasher.Sum(nil))
+	contentHash := ""
+	if contentHasher != nil {
+		contentHash = fmt.Sprintf("%x", contentHasher.Sum(nil))
+	}
+
+	var folder document.Folder
+	if err := db.First(&folder, "id = ?", session.FolderID).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.NotFound("Folder not found"))
+		return
+	}
+
+	// The document may have been checked out by another user since the upload started
+	if session.DocumentID != nil {
+		var doc document.Document
+		if err := db.First(&doc, "id = ?", *session.DocumentID).Error; err != nil {
+			apperrors.Respond(ctx, apperrors.NotFound("Document not found"))
+			return
+		}
+		if !checkDocumentLock(ctx, &doc, &session.CreatedBy) {
+			return
+		}
+	}
+
+	// Completing twice would create the document twice
+	result := db.Model(&session).Where("status = ?", document.UploadSessionUploading).
+		Update("status", document.UploadSessionCompleting)
+	if result.Error != nil || result.RowsAffected == 0 {
+		apperrors.Respond(ctx, apperrors.Conflict("Upload is already being completed"))
+		return
+	}
+
+	storage, err := services.NewStorageProvider()
+	if err != nil {
+		db.Model(&session).Update("status", document.UploadSessionUploading)
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Storage service unavailable"))
+		return
+	}
+
+	if err := storage.CompleteMultipartUpload(context.Background(), session.StorageKey, session.UploadID); err != nil {
+		db.Model(&session).Update("status", document.UploadSessionUploading)
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to assemble upload"))
+		return
+	}
+
+	scanStatus := document.ScanStatusNotScanned
+	if session.StorageKey != session.ObjectKey {
+		scanStatus = document.ScanStatusPending
+	}
+
+	if session.DocumentID != nil {
+		completeVersionUpload(ctx, storage, &session, &folder, checksum, contentHash, scanStatus)
+		return
+	}
+
+	doc := document.Document{
+		ID:            ids.New(),
+		FileName:      session.FileName,
+		OriginalName:  session.FileName,
+		Path:          docUtils.GenerateDisplayPath(folder.Path, session.FileName, session.Version),
+		FileSize:      session.FileSize,
+		MimeType:      session.MimeType,
+		FileExtension: filepath.Ext(session.FileName),
+		FolderID:      session.FolderID,
+		UploadedBy:    session.CreatedBy,
+		ObjectKey:     session.ObjectKey,
+		Checksum:      checksum,
+		Tags:          session.Tags,
+		Description:   session.Description,
+		Metadata:      session.Metadata,
+		ScanStatus:    scanStatus,
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		storedHash, err := addCompletedUploadToContentStore(tx, storage, &session, contentHash, scanStatus)
+		if err != nil {
+			return err
+		}
+		doc.ContentHash = storedHash
+
+		if err := tx.Create(&doc).Error; err != nil {
+			return err
+		}
+		return tx.Create(&document.DocumentVersion{
+			ID:          ids.New(),
+			DocumentID:  doc.ID,
+			Version:     session.Version,
+			ObjectKey:   session.ObjectKey,
+			ContentHash: storedHash,
+			FileSize:    session.FileSize,
+			Checksum:    checksum,
+			CreatedBy:   session.CreatedBy,
+			ScanStatus:  scanStatus,
+		}).Error
+	})
+	if err != nil {
+		failCompletedUpload(ctx, storage, &session)
+		return
+	}
+	if doc.ContentHash != "" {
+		// The content store holds the file now
+		storage.RemoveObject(context.Background(), session.StorageKey)
+	}
+
+	db.Model(&session).Updates(map[string]interface{}{
+		"status":      document.UploadSessionCompleted,
+		"document_id": doc.ID,
+	})
+
+	services.QueueFolderStats(session.FolderID)
+	recordDocumentActivity(ctx, doc.ID, document.DocumentActionUpload, "")
+	recordFolderChange(ctx, []uuid.UUID{doc.ID}, document.FolderChangeAdded)
+
+	// Load folder info for response
+	db.Preload("Folder").First(&doc, doc.ID)
+
+	docResponse := docUtils.BuildDocumentResponse(&doc, db)
+	database.EnqueueWebhookEvent(models.WebhookEventDocumentUploaded, docResponse)
+	messaging.Publish(ctx.Request.Context(), messaging.EventDocumentUploaded, utils.GetActorID(ctx), docResponse)
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Document uploaded successfully",
+		"data":    docResponse,
+	})
+}
+
+// completeVersionUpload records a completed chunked upload as the latest version of its document. When
+// the document got a newer version while the upload was in progress, the assembled file is moved to the
+// next version number.
+func completeVersionUpload(ctx *gin.Context, storage services.StorageProvider, session *document.UploadSession, folder *document.Folder, checksum, contentHash, scanStatus string) {
+	db := requestDB(ctx)
+
+	docVersion := document.DocumentVersion{
+		ID:         ids.New(),
+		DocumentID: *session.DocumentID,
+		Version:    session.Version,
+		FileSize:   session.FileSize,
+		Checksum:   checksum,
+		CreatedBy:  session.CreatedBy,
+		ScanStatus: scanStatus,
+	}
+
+	objectKey := func(version int) (string, error) {
+		if version == session.Version {
+			return session.ObjectKey, nil
+		}
+		return services.OrganizationObjectKey(db, folder, docUtils.GenerateMinIOPath(folder.Path, session.FileName, version))
+	}
+	err := claimDocumentVersion(db, &docVersion, session.BaseVersion, objectKey, func(tx *gorm.DB) (map[string]interface{}, error) {
+		if docVersion.ObjectKey != session.ObjectKey {
+			storageKey := storedObjectKey(docVersion.ObjectKey, scanStatus)
+			if err := storage.MoveObject(session.StorageKey, storageKey); err != nil {
+				return nil, err
+			}
+			session.Version, session.ObjectKey, session.StorageKey = docVersion.Version, docVersion.ObjectKey, storageKey
+			if err := tx.Model(session).Updates(map[string]interface{}{
+				"version":     session.Version,
+				"object_key":  session.ObjectKey,
+				"storage_key": session.StorageKey,
+			}).Error; err != nil {
+				return nil, err
+			}
+		}
+
+		storedHash, err := addCompletedUploadToContentStore(tx, storage, session, contentHash, scanStatus)
+		if err != nil {
+			return nil, err
+		}
+		docVersion.ContentHash = storedHash
+
+		// Update main document to point to latest version
+		return map[string]interface{}{
+			"path":         docUtils.GenerateDisplayPath(folder.Path, session.FileName, docVersion.Version),
+			"object_key":   docVersion.ObjectKey,
+			"content_hash": storedHash,
+			"file_size":    session.FileSize,
+			"checksum":     checksum,
+			"scan_status":  scanStatus,
+			"index_status": document.IndexStatusPending,
+		}, nil
+	})
+	if err != nil {
+		var conflictErr *versionConflictError
+		if errors.As(err, &conflictErr) {
+			// The multipart upload no longer exists, the file has to be uploaded again
+			storage.RemoveObject(context.Background(), session.StorageKey)
+			db.Model(session).Update("status", document.UploadSessionAborted)
+			respondVersionConflict(ctx, err)
+			return
+		}
+		failCompletedUpload(ctx, storage, session)
+		return
+	}
+	if docVersion.ContentHash != "" {
+		// The content store holds the file now
+		storage.RemoveObject(context.Background(), session.StorageKey)
+	}
+
+	db.Model(session).Update("status", document.UploadSessionCompleted)
+	recordDocumentActivity(ctx, docVersion.DocumentID, document.DocumentActionUpload, fmt.Sprintf("Version %d", docVersion.Version))
+	recordFolderChange(ctx, []uuid.UUID{docVersion.DocumentID}, document.FolderChangeUpdated)
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Document version uploaded successfully",
+		"data":    docVersion,
+	})
+}
+
+// addCompletedUploadToContentStore adds an assembled upload to the content store when it needs no malware
+// scan and returns the content hash to record. Uploads started before content hashing have no content
+// hash and stay under their object key, like uploads into the own storage of an organization.
+func addCompletedUploadToContentStore(tx *gorm.DB, storage services.StorageProvider, session *document.UploadSession, contentHash, scanStatus string) (string, error) {
+	if contentHash == "" || scanStatus == document.ScanStatusPending || !services.UsesContentStore(session.ObjectKey) {
+		return "", nil
+	}
+	if err := services.AddContentReference(tx, storage, contentHash, session.StorageKey, session.FileSize); err != nil {
+		return "", err
+	}
+	return contentHash, nil
+}
+
+// failCompletedUpload removes the assembled object when its document could not be saved. The multipart
+// upload no longer exists at this point, so the session cannot be resumed.
+func failCompletedUpload(ctx *gin.Context, storage services.StorageProvider, session *document.UploadSession) {
+	storage.RemoveObject(context.Background(), session.StorageKey)
+	requestDB(ctx).Model(session).Update("status", document.UploadSessionAborted)
+	apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to save document"))
+}
+
+// AbortUpload cancels a chunked upload
+// @Summary Abort a chunked upload
+// @Description Cancel an upload and discard the chunks received so far
+// @Tags documents
+// @Produce json
+// @Param id path string true "Upload ID" format(uuid)
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Upload aborted"
+// @Failure 404 {object} map[string]string "Upload not found"
+// @Failure 409 {object} map[string]string "Upload is no longer in progress"
+// @Router /uploads/{id} [delete]
+func AbortUpload(ctx *gin.Context) {
+	session, ok := findUploadSession(ctx)
+	if !ok {
+		return
+	}
+
+	result := requestDB(ctx).Model(&session).Where("status = ?", document.UploadSessionUploading).
+		Update("status", document.UploadSessionAborted)
+	if result.Error != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to abort upload"))
+		return
+	}
+	if result.RowsAffected == 0 {
+		apperrors.Respond(ctx, apperrors.Conflict("Upload is no longer in progress"))
+		return
+	}
+
+	if storage, err := services.NewStorageProvider(); err == nil {
+		if err := storage.AbortMultipartUpload(context.Background(), session.StorageKey, session.UploadID); err != nil {
+			fmt.Printf("Warning: Failed to abort multipart upload %s: %v\n", session.ID, err)
+		}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Upload aborted",
+	})
+}
+
+// findUploadSession loads the caller's upload session from the :id path parameter and writes the error response if needed
+func findUploadSession(ctx *gin.Context) (document.UploadSession, bool) {
+	var session document.UploadSession
+
+	sessionID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.BadRequest("Invalid upload ID format"))
+		return session, false
+	}
+
+	if err := requestDB(ctx).First(&session, sessionID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			apperrors.Respond(ctx, apperrors.NotFound("Upload not found"))
+			return session, false
+		}
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to retrieve upload"))
+		return session, false
+	}
+
+	// Uploads belong to the user who started them
+	if actorID := utils.GetActorID(ctx); actorID != nil && *actorID != session.CreatedBy {
+		apperrors.Respond(ctx, apperrors.NotFound("Upload not found"))
+		return session, false
+	}
+
+	return session, true
+}
+
+// findActiveUploadSession loads an upload session that still accepts chunks
+func findActiveUploadSession(ctx *gin.Context) (document.UploadSession, bool) {
+	session, ok := findUploadSession(ctx)
+	if !ok {
+		return session, false
+	}
+
+	if session.Status != document.UploadSessionUploading {
+		apperrors.Respond(ctx, apperrors.Newf(apperrors.CodeConflict, "Upload is %s", session.Status))
+		return session, false
+	}
+	if time.Now().After(session.ExpiresAt) {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeGone, "Upload expired"))
+		return session, false
+	}
+
+	return session, true
+}
+
+// uploadChunkSize returns the chunk size for a file, at least the S3 minimum and large enough to stay within the part limit
+func uploadChunkSize(fileSize int64) int64 {
+	chunkSize := config.GetConfig().UploadChunkSize
+	if chunkSize < minUploadChunkSize {
+		chunkSize = minUploadChunkSize
+	}
+	if minimum := (fileSize + maxUploadParts - 1) / maxUploadParts; chunkSize < minimum {
+		chunkSize = minimum
+	}
+	return chunkSize
+}
+
+// uploadSessionExpiry returns when an upload without further chunks expires
+func uploadSessionExpiry() time.Time {
+	return time.Now().Add(time.Duration(config.GetConfig().UploadSessionTTLHours) * time.Hour)
+}
+
+// marshalHash serializes the state of a running checksum so it can continue with the next chunk
+func marshalHash(h hash.Hash) ([]byte, error) {
+	return h.(encoding.BinaryMarshaler).MarshalBinary()
+}
+
+// unmarshalHash restores the state of a running checksum into h
+func unmarshalHash(h hash.Hash, state []byte) (hash.Hash, error) {
+	if err := h.(encoding.BinaryUnmarshaler).UnmarshalBinary(state); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// restoreUploadHashes restores the running MD5 checksum and SHA-256 content hash of an upload. The
+// content hash is nil for uploads started before content hashing.
+func restoreUploadHashes(session *document.UploadSession) (hash.Hash, hash.Hash, error) {
+	checksum, err := unmarshalHash(md5.New(), session.HashState)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(session.ContentHashState) == 0 {
+		return checksum, nil, nil
+	}
+
+	contentHash, err := unmarshalHash(sha256.New(), session.ContentHashState)
+	if err != nil {
+		return nil, nil, err
+	}
+	return checksum, contentHash, nil
+}
@@ -0,0 +1,414 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"forgecrud-backend/document-service/services"
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/database/models/document"
+	"forgecrud-backend/shared/ids"
+	utils "forgecrud-backend/shared/utils/auth"
+	docUtils "forgecrud-backend/shared/utils/document"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DownloadDocumentVersion downloads the file of a document version
+// @Summary Download a document version
+// @Description Download the file of a specific version of a document, single byte ranges (Range header) are answered with 206
+// @Tags documents
+// @Produce application/octet-stream
+// @Param id path string true "Document ID" format(uuid)
+// @Param version path int true "Version number"
+// @Param Range header string false "Byte range, e.g. bytes=0-1023"
+// @Security BearerAuth
+// @Success 200 {file} file "Version file content"
+// @Success 206 {file} file "Requested byte range"
+// @Failure 400 {object} map[string]string "Invalid version number"
+// @Failure 403 {object} map[string]string "Access denied or version blocked by the malware scan"
+// @Failure 404 {object} map[string]string "Document or version not found"
+// @Failure 409 {object} map[string]string "Version is being scanned for malware"
+// @Failure 416 {object} map[string]string "Range not satisfiable"
+// @Failure 500 {object} map[string]string "Server error or storage unavailable"
+// @Router /documents/{id}/versions/{version}/download [get]
+func DownloadDocumentVersion(ctx *gin.Context) {
+	doc, version, ok := findDocumentVersion(ctx, document.AccessLevelRead)
+	if !ok {
+		return
+	}
+
+	if !checkScanStatus(ctx, version.ScanStatus) {
+		return
+	}
+
+	fileName := docUtils.GenerateVersionedFileName(doc.OriginalName, version.Version)
+	ctx.Header("Content-Disposition", docUtils.ContentDisposition(fileName, doc.MimeType, false))
+
+	if !isSeekRequest(ctx) {
+		recordDocumentActivity(ctx, doc.ID, document.DocumentActionDownload, fmt.Sprintf("Version %d", version.Version))
+	}
+
+	serveStoredFile(ctx, docUtils.StorageKey(version.ObjectKey, version.ContentHash), version.FileSize, doc.MimeType, version.Checksum)
+}
+
+// maxDiffContext bounds the unchanged lines shown around each change of a version comparison
+const maxDiffContext = 20
+
+// CompareDocumentVersions returns the line-level differences between two versions of a text document
+// @Summary Compare document versions
+// @Description Line-level diff of two versions of a TXT, MD, JSON or CSV document of up to 2MB each, with the changes grouped in hunks of
+// @Description `context` unchanged lines around them. Diffs with too many changes to find the shortest one are returned as a replacement
+// @Description of the changed region and marked approximate. format=unified downloads the diff in the format of diff -u.
+// @Tags documents
+// @Produce json
+// @Produce text/x-diff
+// @Param id path string true "Document ID" format(uuid)
+// @Param from query int true "Old version number"
+// @Param to query int true "New version number"
+// @Param context query int false "Unchanged lines around each change (default 3, max 20)"
+// @Param format query string false "json (default) or unified"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Differences between the versions"
+// @Failure 400 {object} map[string]string "Invalid version numbers, context or format"
+// @Failure 403 {object} map[string]string "Access denied or version blocked by the malware scan"
+// @Failure 404 {object} map[string]string "Document or version not found"
+// @Failure 409 {object} map[string]string "Version is being scanned for malware"
+// @Failure 413 {object} map[string]string "Version too large to compare"
+// @Failure 415 {object} map[string]string "Document is not a text format or not UTF-8"
+// @Failure 500 {object} map[string]string "Server error or storage unavailable"
+// @Router /documents/{id}/versions/compare [get]
+func CompareDocumentVersions(ctx *gin.Context) {
+	fromNumber, fromErr := strconv.Atoi(ctx.Query("from"))
+	toNumber, toErr := strconv.Atoi(ctx.Query("to"))
+	if fromErr != nil || toErr != nil || fromNumber < 1 || toNumber < 1 {
+		apperrors.Respond(ctx, apperrors.BadRequest("Invalid version numbers").WithDetails("from and to must be version numbers"))
+		return
+	}
+	contextLines := 3
+	if value := ctx.Query("context"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 || parsed > maxDiffContext {
+			apperrors.Respond(ctx, apperrors.BadRequest(fmt.Sprintf("context must be a number from 0 to %d", maxDiffContext)))
+			return
+		}
+		contextLines = parsed
+	}
+	format := ctx.DefaultQuery("format", "json")
+	if format != "json" && format != "unified" {
+		apperrors.Respond(ctx, apperrors.BadRequest("format must be json or unified"))
+		return
+	}
+
+	db := requestDB(ctx)
+
+	var doc document.Document
+	if err := db.Preload("Folder").First(&doc, "id = ?", ctx.Param("id")).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.NotFound("Document not found"))
+		return
+	}
+	if !checkDocumentAccess(ctx, &doc, document.AccessLevelRead) {
+		return
+	}
+
+	extension := strings.ToLower(filepath.Ext(doc.OriginalName))
+	if !docUtils.DiffableExtensions[extension] {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeUnsupportedMedia, "Versions of this document format cannot be compared").
+			WithDetails("Only TXT, MD, JSON and CSV documents are compared line by line").With("extension", extension))
+		return
+	}
+
+	var versions []document.DocumentVersion
+	if err := db.Where("document_id = ? AND version IN ?", doc.ID, []int{fromNumber, toNumber}).Find(&versions).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to retrieve versions"))
+		return
+	}
+	compared := make([]document.DocumentVersion, 0, 2)
+	for _, number := range []int{fromNumber, toNumber} {
+		index := slices.IndexFunc(versions, func(version document.DocumentVersion) bool { return version.Version == number })
+		if index < 0 {
+			apperrors.Respond(ctx, apperrors.NotFound("Version not found").With("version", number))
+			return
+		}
+		version := versions[index]
+		if !checkScanStatus(ctx, version.ScanStatus) {
+			return
+		}
+		if version.FileSize > docUtils.MaxDiffBytes {
+			apperrors.Respond(ctx, apperrors.New(apperrors.CodePayloadTooLarge, "Version is too large to compare").
+				With("version", number).With("max_bytes", docUtils.MaxDiffBytes))
+			return
+		}
+		compared = append(compared, version)
+	}
+
+	storage, err := services.NewStorageProvider()
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Storage service unavailable"))
+		return
+	}
+	texts := make([]string, 0, 2)
+	for _, version := range compared {
+		text, err := readVersionText(ctx.Request.Context(), storage, version)
+		if err != nil {
+			apperrors.Respond(ctx, err)
+			return
+		}
+		texts = append(texts, text)
+	}
+
+	diff := docUtils.DiffText(texts[0], texts[1], contextLines)
+
+	if format == "unified" {
+		oldName := docUtils.GenerateVersionedFileName(doc.OriginalName, fromNumber)
+		newName := docUtils.GenerateVersionedFileName(doc.OriginalName, toNumber)
+		fileName := fmt.Sprintf("%s-v%d-v%d.diff", strings.TrimSuffix(doc.OriginalName, filepath.Ext(doc.OriginalName)), fromNumber, toNumber)
+		ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", fileName))
+		ctx.Data(http.StatusOK, "text/x-diff; charset=utf-8", []byte(docUtils.UnifiedDiff(diff, oldName, newName)))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"document_id": doc.ID,
+			"from":        compared[0],
+			"to":          compared[1],
+			"identical":   diff.Identical(),
+			"diff":        diff,
+		},
+	})
+}
+
+// readVersionText reads the file of a version as UTF-8 text
+func readVersionText(ctx context.Context, storage services.StorageProvider, version document.DocumentVersion) (string, error) {
+	reader, _, err := storage.GetObject(ctx, docUtils.StorageKey(version.ObjectKey, version.ContentHash))
+	if err != nil {
+		return "", apperrors.Wrap(err, apperrors.CodeInternal, "Failed to read version file").With("version", version.Version)
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(io.LimitReader(reader, docUtils.MaxDiffBytes+1))
+	if err != nil {
+		return "", apperrors.Wrap(err, apperrors.CodeInternal, "Failed to read version file").With("version", version.Version)
+	}
+	if len(data) > docUtils.MaxDiffBytes {
+		return "", apperrors.New(apperrors.CodePayloadTooLarge, "Version is too large to compare").
+			With("version", version.Version).With("max_bytes", docUtils.MaxDiffBytes)
+	}
+	if !utf8.Valid(data) {
+		return "", apperrors.New(apperrors.CodeUnsupportedMedia, "Version is not UTF-8 text").With("version", version.Version)
+	}
+	return strings.TrimPrefix(string(data), "\uFEFF"), nil
+}
+
+// RestoreDocumentVersion makes an old version the latest version of its document
+// @Summary Restore a document version
+// @Description Restore an old version by adding a copy of it as the new latest version. The versions in between are kept.
+// @Tags documents
+// @Produce json
+// @Param id path string true "Document ID" format(uuid)
+// @Param version path int true "Version number"
+// @Security BearerAuth
+// @Success 201 {object} map[string]interface{} "Document version restored successfully"
+// @Failure 400 {object} map[string]string "Invalid version number"
+// @Failure 403 {object} map[string]string "Access denied or version blocked by the malware scan"
+// @Failure 404 {object} map[string]string "Document or version not found"
+// @Failure 409 {object} map[string]string "Version is the current version or being scanned"
+// @Failure 413 {object} map[string]interface{} "Storage quota exceeded"
+// @Failure 423 {object} map[string]interface{} "Document is locked by another user"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /documents/{id}/versions/{version}/restore [post]
+func RestoreDocumentVersion(ctx *gin.Context) {
+	doc, version, ok := findDocumentVersion(ctx, document.AccessLevelWrite)
+	if !ok {
+		return
+	}
+	db := requestDB(ctx)
+
+	if !checkDocumentLock(ctx, &doc, utils.GetActorID(ctx)) {
+		return
+	}
+
+	if version.ObjectKey == doc.ObjectKey {
+		apperrors.Respond(ctx, apperrors.Conflict("Version is already the current version"))
+		return
+	}
+
+	if !checkScanStatus(ctx, version.ScanStatus) {
+		return
+	}
+
+	if !checkFolderQuota(ctx, &doc.Folder, version.FileSize, 0) {
+		return
+	}
+
+	storage, err := services.NewStorageProvider()
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Storage service unavailable"))
+		return
+	}
+
+	createdBy := doc.UploadedBy
+	if actorID := utils.GetActorID(ctx); actorID != nil {
+		createdBy = *actorID
+	}
+
+	docVersion := document.DocumentVersion{
+		ID:         ids.New(),
+		DocumentID: doc.ID,
+		FileSize:   version.FileSize,
+		Checksum:   version.Checksum,
+		CreatedBy:  createdBy,
+		ScanStatus: version.ScanStatus,
+		ScannedAt:  version.ScannedAt,
+	}
+
+	// Files in the content store are shared with the restored version instead of copied, unless the
+	// document is in the own storage of an organization
+	copied := false
+	objectKey := func(number int) (string, error) {
+		return services.OrganizationObjectKey(db, &doc.Folder, docUtils.GenerateMinIOPath(doc.Folder.Path, doc.FileName, number))
+	}
+	err = claimDocumentVersion(db, &docVersion, 0, objectKey, func(tx *gorm.DB) (map[string]interface{}, error) {
+		if version.ContentHash != "" && services.UsesContentStore(docVersion.ObjectKey) {
+			if err := services.AddContentReference(tx, storage, version.ContentHash, "", version.FileSize); err != nil {
+				return nil, err
+			}
+			docVersion.ContentHash = version.ContentHash
+		} else {
+			if err := storage.CopyObject(docUtils.StorageKey(version.ObjectKey, version.ContentHash), docVersion.ObjectKey); err != nil {
+				return nil, err
+			}
+			copied = true
+		}
+
+		// Update main document to point to the restored version
+		return map[string]interface{}{
+			"path":         docUtils.GenerateDisplayPath(doc.Folder.Path, doc.FileName, docVersion.Version),
+			"object_key":   docVersion.ObjectKey,
+			"content_hash": docVersion.ContentHash,
+			"file_size":    version.FileSize,
+			"checksum":     version.Checksum,
+			"scan_status":  version.ScanStatus,
+			"index_status": document.IndexStatusPending,
+		}, nil
+	})
+	if err != nil {
+		if copied {
+			storage.RemoveObject(context.Background(), docVersion.ObjectKey)
+		}
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to restore version"))
+		return
+	}
+	newVersion := docVersion.Version
+
+	services.QueueFolderStats(doc.FolderID)
+	recordDocumentActivity(ctx, doc.ID, document.DocumentActionUpload, fmt.Sprintf("Version %d restored as version %d", version.Version, newVersion))
+	recordFolderChange(ctx, []uuid.UUID{doc.ID}, document.FolderChangeUpdated)
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": fmt.Sprintf("Version %d restored as version %d", version.Version, newVersion),
+		"data":    docVersion,
+	})
+}
+
+// PinDocumentVersion protects a version from the version retention rules
+// @Summary Pin a document version
+// @Description Pin a version so the version retention rules never remove it
+// @Tags documents
+// @Produce json
+// @Param id path string true "Document ID" format(uuid)
+// @Param version path int true "Version number"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Version pinned"
+// @Failure 400 {object} map[string]string "Invalid version number"
+// @Failure 403 {object} map[string]string "Access denied"
+// @Failure 404 {object} map[string]string "Document or version not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /documents/{id}/versions/{version}/pin [post]
+func PinDocumentVersion(ctx *gin.Context) {
+	setVersionPinned(ctx, true)
+}
+
+// UnpinDocumentVersion lets the version retention rules remove a version again
+// @Summary Unpin a document version
+// @Description Unpin a version so the version retention rules may remove it
+// @Tags documents
+// @Produce json
+// @Param id path string true "Document ID" format(uuid)
+// @Param version path int true "Version number"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Version unpinned"
+// @Failure 400 {object} map[string]string "Invalid version number"
+// @Failure 403 {object} map[string]string "Access denied"
+// @Failure 404 {object} map[string]string "Document or version not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /documents/{id}/versions/{version}/pin [delete]
+func UnpinDocumentVersion(ctx *gin.Context) {
+	setVersionPinned(ctx, false)
+}
+
+func setVersionPinned(ctx *gin.Context, pinned bool) {
+	_, version, ok := findDocumentVersion(ctx, document.AccessLevelWrite)
+	if !ok {
+		return
+	}
+
+	if err := requestDB(ctx).Model(&version).Update("pinned", pinned).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Failed to update version"))
+		return
+	}
+	version.Pinned = pinned
+
+	message := "Version pinned"
+	if !pinned {
+		message = "Version unpinned"
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": message,
+		"data":    version,
+	})
+}
+
+// findDocumentVersion loads the document from the :id and the version from the :version path parameters,
+// checks the caller's access level on the document and writes the error response if needed
+func findDocumentVersion(ctx *gin.Context, level string) (document.Document, document.DocumentVersion, bool) {
+	db := requestDB(ctx)
+
+	var doc document.Document
+	var version document.DocumentVersion
+
+	versionNumber, err := strconv.Atoi(ctx.Param("version"))
+	if err != nil || versionNumber < 1 {
+		apperrors.Respond(ctx, apperrors.BadRequest("Invalid version number"))
+		return doc, version, false
+	}
+
+	if err := db.Preload("Folder").First(&doc, "id = ?", ctx.Param("id")).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.NotFound("Document not found"))
+		return doc, version, false
+	}
+
+	if !checkDocumentAccess(ctx, &doc, level) {
+		return doc, version, false
+	}
+
+	if err := db.Where("document_id = ? AND version = ?", doc.ID, versionNumber).First(&version).Error; err != nil {
+		apperrors.Respond(ctx, apperrors.NotFound("Version not found"))
+		return doc, version, false
+	}
+
+	return doc, version, true
+}
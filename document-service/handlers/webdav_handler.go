@@ -0,0 +1,933 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"forgecrud-backend/document-service/services"
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models"
+	"forgecrud-backend/shared/database/models/document"
+	"forgecrud-backend/shared/messaging"
+	utils "forgecrud-backend/shared/utils/auth"
+	docUtils "forgecrud-backend/shared/utils/document"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"golang.org/x/net/webdav"
+	"gorm.io/gorm"
+)
+
+// webdavLocks holds the locks WebDAV clients take while they write a file; Finder and Office do not
+// save without them. They are not the check-out locks of the documents.
+var webdavLocks = webdav.NewMemLS()
+
+// ServeWebDAV serves the folder hierarchy over WebDAV so it can be mounted in Finder and Explorer.
+// Root folders are the top level, documents appear under their file name. Folder and document access
+// grants apply as in the API: objects the caller cannot read are not listed, writes need write access.
+// Writing a file adds a version to the document with its name, or uploads a new document.
+func ServeWebDAV(ctx *gin.Context) {
+	userID := requestUserID(ctx, "")
+	if userID == nil {
+		apperrors.Respond(ctx, apperrors.Unauthorized("WebDAV is only available to signed in users"))
+		return
+	}
+
+	storage, err := services.NewStorageProvider()
+	if err != nil {
+		apperrors.Respond(ctx, apperrors.New(apperrors.CodeInternal, "Storage service unavailable"))
+		return
+	}
+
+	fs := &webdavFileSystem{
+		ctx:     ctx,
+		db:      requestDB(ctx),
+		storage: storage,
+		userID:  *userID,
+	}
+
+	// Reject uploads of types that are not allowed or over the quota before the client sends the file
+	if ctx.Request.Method == http.MethodPut && !fs.checkUpload(ctx.Request.URL.Path, ctx.Request.ContentLength) {
+		return
+	}
+
+	handler := &webdav.Handler{
+		Prefix:     docUtils.WebDAVPrefix,
+		FileSystem: fs,
+		LockSystem: webdavLocks,
+		Logger: func(r *http.Request, err error) {
+			if err != nil && !os.IsNotExist(err) {
+				fmt.Printf("Warning: WebDAV %s %s failed: %v\n", r.Method, r.URL.Path, err)
+			}
+		},
+	}
+	handler.ServeHTTP(ctx.Writer, ctx.Request)
+}
+
+// webdavFileSystem maps WebDAV paths to the folders and documents the caller can access
+type webdavFileSystem struct {
+	ctx     *gin.Context
+	db      *gorm.DB
+	storage services.StorageProvider
+	userID  uuid.UUID
+}
+
+// webdavEntry is a resolved WebDAV path: a document with its folder, a folder, or the root when both
+// are nil
+type webdavEntry struct {
+	folder *document.Folder
+	doc    *document.Document
+}
+
+// webdavSegments splits a WebDAV path into folder and file names
+func webdavSegments(name string) []string {
+	cleaned := strings.Trim(path.Clean("/"+name), "/")
+	if cleaned == "" {
+		return nil
+	}
+	return strings.Split(cleaned, "/")
+}
+
+// lookup resolves a WebDAV path, returning os.ErrNotExist for paths the caller cannot read
+func (fs *webdavFileSystem) lookup(name string) (webdavEntry, error) {
+	var entry webdavEntry
+	for _, segment := range webdavSegments(name) {
+		if entry.doc != nil {
+			return webdavEntry{}, os.ErrNotExist
+		}
+
+		folder, err := fs.findFolder(entry.folder, segment)
+		if err == nil {
+			entry.folder = folder
+			continue
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return webdavEntry{}, err
+		}
+
+		// Documents are always in a folder
+		if entry.folder == nil {
+			return webdavEntry{}, os.ErrNotExist
+		}
+		doc, err := fs.findDocument(entry.folder, segment)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return webdavEntry{}, os.ErrNotExist
+		}
+		if err != nil {
+			return webdavEntry{}, err
+		}
+		entry.doc = doc
+	}
+	return entry, nil
+}
+
+// findFolder returns the readable folder with the name in the parent folder, a root folder when parent is nil
+func (fs *webdavFileSystem) findFolder(parent *document.Folder, name string) (*document.Folder, error) {
+	dbQuery := readableFolders(fs.ctx, fs.db.Where("name = ?", name))
+	if parent == nil {
+		dbQuery = dbQuery.Where("parent_id IS NULL")
+	} else {
+		dbQuery = dbQuery.Where("parent_id = ?", parent.ID)
+	}
+
+	var folder document.Folder
+	if err := dbQuery.Order("created_at").First(&folder).Error; err != nil {
+		return nil, err
+	}
+	return &folder, nil
+}
+
+// findDocument returns the readable document with the file name in the folder, the latest upload when
+// several documents share the name
+func (fs *webdavFileSystem) findDocument(folder *document.Folder, name string) (*document.Document, error) {
+	var doc document.Document
+	if err := readableDocuments(fs.ctx, fs.db.Preload("Folder")).
+		Where("folder_id = ? AND file_name = ?", folder.ID, name).
+		Order("created_at DESC").
+		First(&doc).Error; err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// lookupParent resolves the folder a new file or folder at the path goes in, nil for the root
+func (fs *webdavFileSystem) lookupParent(name string) (*document.Folder, error) {
+	parent, err := fs.lookup(path.Dir(path.Clean("/" + name)))
+	if err != nil {
+		return nil, err
+	}
+	if parent.doc != nil {
+		return nil, os.ErrNotExist
+	}
+	return parent.folder, nil
+}
+
+// checkWrite returns os.ErrPermission when the caller lacks write access to the folder or document
+func (fs *webdavFileSystem) checkWrite(folder *document.Folder, doc *document.Document) error {
+	var denied string
+	var err error
+	if doc != nil {
+		denied, err = documentAccessDenied(fs.ctx, doc, document.AccessLevelWrite)
+	} else {
+		denied, err = folderAccessDenied(fs.ctx, folder, document.AccessLevelWrite)
+	}
+	if err != nil {
+		return err
+	}
+	if denied != "" {
+		return os.ErrPermission
+	}
+	return nil
+}
+
+// checkUnlocked returns os.ErrPermission when another user has the document checked out
+func (fs *webdavFileSystem) checkUnlocked(doc *document.Document) error {
+	if holder := doc.ActiveLockHolder(time.Now()); holder != nil && *holder != fs.userID {
+		return os.ErrPermission
+	}
+	return nil
+}
+
+// checkDeletable returns os.ErrPermission when the folder or document is under legal hold or the
+// document is within its minimum retention
+func (fs *webdavFileSystem) checkDeletable(folder *document.Folder, doc *document.Document) error {
+	var reason string
+	var err error
+	if doc != nil {
+		reason, err = services.DocumentDeletionBlock(fs.db, doc)
+	} else {
+		reason, err = services.FolderDeletionBlock(fs.db, folder)
+	}
+	if err != nil {
+		return err
+	}
+	if reason != "" {
+		return os.ErrPermission
+	}
+	return nil
+}
+
+// checkUpload writes the 415 response when the extension of a file uploaded to the WebDAV path is not
+// allowed in the folder, and the 507 response when an upload of the size would exceed the quota of the
+// folder's owners
+func (fs *webdavFileSystem) checkUpload(requestPath string, size int64) bool {
+	name := strings.TrimPrefix(requestPath, docUtils.WebDAVPrefix)
+	var folder *document.Folder
+	addDocuments := 0
+	if entry, err := fs.lookup(name); err == nil {
+		if entry.doc == nil {
+			return true
+		}
+		folder = &entry.doc.Folder
+	} else if parent, err := fs.lookupParent(name); err == nil && parent != nil {
+		folder, addDocuments = parent, 1
+	} else {
+		// The upload fails later on
+		return true
+	}
+
+	// The content is checked once it is written
+	if !checkFileType(fs.ctx, folder, path.Base(name), "", size) {
+		return false
+	}
+	if size <= 0 {
+		return true
+	}
+
+	err := folderQuotaError(folder, size, addDocuments)
+	if err == nil {
+		return true
+	}
+
+	var quotaErr *database.QuotaExceededError
+	if errors.As(err, &quotaErr) {
+		apperrors.Respond(fs.ctx, apperrors.Wrap(quotaErr, apperrors.CodeInsufficientStorage, "Quota exceeded").With("quota", quotaErr))
+		return false
+	}
+
+	apperrors.Respond(fs.ctx, apperrors.New(apperrors.CodeInternal, "Failed to check quota"))
+	return false
+}
+
+// Mkdir creates a folder, owned by the caller at the root and by the parent's owner elsewhere
+func (fs *webdavFileSystem) Mkdir(ctx context.Context, name string, perm os.FileMode) error {
+	if _, err := fs.lookup(name); err == nil {
+		return os.ErrExist
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	parent, err := fs.lookupParent(name)
+	if err != nil {
+		return err
+	}
+
+	folderName := path.Base(path.Clean("/" + name))
+	if err := docUtils.ValidateFolderName(folderName); err != nil {
+		return os.ErrPermission
+	}
+
+	if parent != nil {
+		if err := fs.checkWrite(parent, nil); err != nil {
+			return err
+		}
+		// A folder the caller cannot read may already have the name
+		if _, created, err := ensureSubfolder(fs.db, fs.storage, parent, folderName); err != nil {
+			return err
+		} else if !created {
+			return os.ErrExist
+		}
+		return nil
+	}
+
+	folder := document.Folder{
+		Name:      folderName,
+		Path:      docUtils.GenerateFolderPath("", folderName),
+		OwnerID:   fs.userID,
+		OwnerType: "user",
+	}
+
+	// Paths stay taken while a folder is in the trash
+	var existing document.Folder
+	if err := fs.db.Unscoped().Where("path = ?", folder.Path).First(&existing).Error; err == nil {
+		return os.ErrExist
+	}
+
+	var operation *document.StorageOperation
+	if err := fs.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&folder).Error; err != nil {
+			return err
+		}
+		queued, err := services.QueueCreateFolder(tx, &folder)
+		operation = queued
+		return err
+	}); err != nil {
+		return err
+	}
+
+	services.NewStorageOutbox(fs.storage).Run(operation)
+	return nil
+}
+
+// OpenFile opens a folder or document for reading, or a document for writing. Writes are stored when
+// the file is closed.
+func (fs *webdavFileSystem) OpenFile(ctx context.Context, name string, flag int, perm os.FileMode) (webdav.File, error) {
+	entry, err := fs.lookup(name)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE|os.O_TRUNC) == 0 {
+		if err != nil {
+			return nil, err
+		}
+		if entry.doc == nil {
+			return &webdavDir{fs: fs, entry: entry}, nil
+		}
+		// Files held back by the malware scan cannot be downloaded
+		if status, _ := scanStatusError(entry.doc.ScanStatus); status != 0 {
+			return nil, os.ErrPermission
+		}
+		return &webdavReader{fs: fs, doc: entry.doc}, nil
+	}
+
+	var folder *document.Folder
+	if err == nil {
+		if entry.doc == nil {
+			return nil, os.ErrPermission
+		}
+		if flag&os.O_EXCL != 0 {
+			return nil, os.ErrExist
+		}
+		if err := fs.checkWrite(nil, entry.doc); err != nil {
+			return nil, err
+		}
+		if err := fs.checkUnlocked(entry.doc); err != nil {
+			return nil, err
+		}
+		folder = &entry.doc.Folder
+	} else {
+		if flag&os.O_CREATE == 0 {
+			return nil, os.ErrNotExist
+		}
+		if folder, err = fs.lookupParent(name); err != nil {
+			return nil, err
+		}
+		// Documents are always in a folder
+		if folder == nil {
+			return nil, os.ErrPermission
+		}
+		if err := fs.checkWrite(folder, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	tempFile, err := os.CreateTemp("", "webdav-upload-*")
+	if err != nil {
+		return nil, err
+	}
+	return &webdavWriter{
+		fs:       fs,
+		folder:   folder,
+		doc:      entry.doc,
+		fileName: path.Base(path.Clean("/" + name)),
+		tempFile: tempFile,
+	}, nil
+}
+
+// RemoveAll moves a document, or a folder with everything in it, to the trash
+func (fs *webdavFileSystem) RemoveAll(ctx context.Context, name string) error {
+	entry, err := fs.lookup(name)
+	if err != nil {
+		return err
+	}
+
+	if entry.doc != nil {
+		if err := fs.checkWrite(nil, entry.doc); err != nil {
+			return err
+		}
+		if err := fs.checkUnlocked(entry.doc); err != nil {
+			return err
+		}
+		if err := fs.checkDeletable(nil, entry.doc); err != nil {
+			return err
+		}
+		if err := moveToTrash(fs.ctx, fs.db, entry.doc); err != nil {
+			return err
+		}
+		publishDocumentDeleted(fs.ctx, entry.doc)
+		services.QueueFolderStats(entry.doc.FolderID)
+		return nil
+	}
+
+	folder := entry.folder
+	if folder == nil {
+		return os.ErrPermission
+	}
+
+	// Everything in the folder must be writable, nothing is deleted otherwise
+	subfolders, err := getAllSubfolders(fs.db, folder.ID)
+	if err != nil {
+		return err
+	}
+	folderIDs := []uuid.UUID{folder.ID}
+	for i := range subfolders {
+		if err := fs.checkWrite(&subfolders[i], nil); err != nil {
+			return err
+		}
+		if err := fs.checkDeletable(&subfolders[i], nil); err != nil {
+			return err
+		}
+		folderIDs = append(folderIDs, subfolders[i].ID)
+	}
+	if err := fs.checkWrite(folder, nil); err != nil {
+		return err
+	}
+	if err := fs.checkDeletable(folder, nil); err != nil {
+		return err
+	}
+
+	var documents []document.Document
+	if err := fs.db.Where("folder_id IN ?", folderIDs).Find(&documents).Error; err != nil {
+		return err
+	}
+	for i := range documents {
+		if err := fs.checkWrite(nil, &documents[i]); err != nil {
+			return err
+		}
+		if err := fs.checkUnlocked(&documents[i]); err != nil {
+			return err
+		}
+		if err := fs.checkDeletable(nil, &documents[i]); err != nil {
+			return err
+		}
+	}
+
+	// Delete the deepest folders first, the trash restores parents before their subfolders
+	sort.SliceStable(subfolders, func(a, b int) bool {
+		return strings.Count(subfolders[a].Path, "/") > strings.Count(subfolders[b].Path, "/")
+	})
+
+	err = fs.db.Transaction(func(tx *gorm.DB) error {
+		for i := range documents {
+			if err := moveToTrash(fs.ctx, tx, &documents[i]); err != nil {
+				return err
+			}
+		}
+		for i := range subfolders {
+			if err := moveToTrash(fs.ctx, tx, &subfolders[i]); err != nil {
+				return err
+			}
+		}
+		return moveToTrash(fs.ctx, tx, folder)
+	})
+	if err != nil {
+		return err
+	}
+
+	publishFolderDeleted(fs.ctx, folder)
+	if folder.ParentID != nil {
+		services.QueueFolderStats(*folder.ParentID)
+	}
+	return nil
+}
+
+// Rename moves and renames a folder or document
+func (fs *webdavFileSystem) Rename(ctx context.Context, oldName, newName string) error {
+	entry, err := fs.lookup(oldName)
+	if err != nil {
+		return err
+	}
+	if entry.folder == nil && entry.doc == nil {
+		return os.ErrPermission
+	}
+
+	target, err := fs.lookupParent(newName)
+	if err != nil {
+		return err
+	}
+	if target != nil {
+		if err := fs.checkWrite(target, nil); err != nil {
+			return err
+		}
+	}
+	newBase := path.Base(path.Clean("/" + newName))
+
+	if entry.doc != nil {
+		return fs.renameDocument(entry.doc, target, newBase)
+	}
+	return fs.renameFolder(entry.folder, target, newBase)
+}
+
+// renameDocument moves the document to the target folder under the file name
+func (fs *webdavFileSystem) renameDocument(doc *document.Document, target *document.Folder, fileName string) error {
+	// Documents are always in a folder
+	if target == nil {
+		return os.ErrPermission
+	}
+	if err := fs.checkWrite(nil, doc); err != nil {
+		return err
+	}
+	if err := fs.checkUnlocked(doc); err != nil {
+		return err
+	}
+	// The scan worker looks the quarantined file up by its object key
+	if doc.ScanStatus == document.ScanStatusPending || doc.ScanStatus == document.ScanStatusScanning {
+		return os.ErrPermission
+	}
+
+	if target.ID != doc.FolderID {
+		if reason, err := services.DocumentMoveBlock(fs.db, doc, target); err != nil {
+			return err
+		} else if reason != "" {
+			return os.ErrPermission
+		}
+		fromPath := doc.Folder.Path
+		if err := moveDocument(fs.db, doc, target); err != nil {
+			return err
+		}
+		recordDocumentActivity(fs.ctx, doc.ID, document.DocumentActionMove, documentMoveDetails(fromPath, target.Path))
+	}
+
+	if fileName != doc.FileName {
+		latestVersion := currentDocumentVersion(fs.db, doc.ID)
+		if err := fs.db.Model(doc).Updates(map[string]interface{}{
+			"file_name":      fileName,
+			"original_name":  fileName,
+			"file_extension": filepath.Ext(fileName),
+			"path":           docUtils.GenerateDisplayPath(target.Path, fileName, latestVersion),
+		}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renameFolder moves the folder under the target parent folder, to the root when target is nil, under
+// the name
+func (fs *webdavFileSystem) renameFolder(folder, target *document.Folder, name string) error {
+	if err := docUtils.ValidateFolderName(name); err != nil {
+		return os.ErrPermission
+	}
+	if err := fs.checkWrite(folder, nil); err != nil {
+		return err
+	}
+	if target != nil {
+		if target.ID == folder.ID || isSubfolderOf(fs.db, target.ID, folder.ID) {
+			return os.ErrPermission
+		}
+		if target.OwnerID != folder.OwnerID || target.OwnerType != folder.OwnerType {
+			return os.ErrPermission
+		}
+	}
+	if reason, err := services.FolderMoveBlock(fs.db, folder, target); err != nil {
+		return err
+	} else if reason != "" {
+		return os.ErrPermission
+	}
+
+	oldName := folder.Name
+	if name != oldName {
+		if err := fs.db.Model(folder).Update("name", name).Error; err != nil {
+			return err
+		}
+	}
+
+	if err := moveFolder(fs.db, folder, target); err != nil {
+		if name != oldName {
+			fs.db.Model(folder).Update("name", oldName)
+		}
+		return err
+	}
+	return nil
+}
+
+// Stat describes the folder or document at the path
+func (fs *webdavFileSystem) Stat(ctx context.Context, name string) (os.FileInfo, error) {
+	entry, err := fs.lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	return entry.fileInfo(), nil
+}
+
+// fileInfo describes the entry
+func (e webdavEntry) fileInfo() *webdavFileInfo {
+	switch {
+	case e.doc != nil:
+		return &webdavFileInfo{
+			name:     e.doc.FileName,
+			size:     e.doc.FileSize,
+			modTime:  e.doc.UpdatedAt,
+			mimeType: e.doc.MimeType,
+			checksum: e.doc.Checksum,
+		}
+	case e.folder != nil:
+		return &webdavFileInfo{name: e.folder.Name, modTime: e.folder.UpdatedAt, dir: true}
+	default:
+		return &webdavFileInfo{name: "/", dir: true}
+	}
+}
+
+// webdavFileInfo describes a folder or document. It supplies the content type and ETag so they are
+// not computed by reading the file.
+type webdavFileInfo struct {
+	name     string
+	size     int64
+	modTime  time.Time
+	dir      bool
+	mimeType string
+	checksum string
+}
+
+func (fi *webdavFileInfo) Name() string       { return fi.name }
+func (fi *webdavFileInfo) Size() int64        { return fi.size }
+func (fi *webdavFileInfo) ModTime() time.Time { return fi.modTime }
+func (fi *webdavFileInfo) IsDir() bool        { return fi.dir }
+func (fi *webdavFileInfo) Sys() interface{}   { return nil }
+
+func (fi *webdavFileInfo) Mode() os.FileMode {
+	if fi.dir {
+		return os.ModeDir | 0755
+	}
+	return 0644
+}
+
+// ContentType implements webdav.ContentTyper
+func (fi *webdavFileInfo) ContentType(ctx context.Context) (string, error) {
+	if fi.mimeType == "" {
+		return "", webdav.ErrNotImplemented
+	}
+	return fi.mimeType, nil
+}
+
+// ETag implements webdav.ETager, the checksum like the ETag of document downloads
+func (fi *webdavFileInfo) ETag(ctx context.Context) (string, error) {
+	if fi.checksum == "" {
+		return "", webdav.ErrNotImplemented
+	}
+	return `"` + fi.checksum + `"`, nil
+}
+
+// webdavDir lists the subfolders and documents of a folder, the root folders for the root
+type webdavDir struct {
+	fs       *webdavFileSystem
+	entry    webdavEntry
+	children []os.FileInfo
+	loaded   bool
+}
+
+func (d *webdavDir) Close() error                                 { return nil }
+func (d *webdavDir) Read(p []byte) (int, error)                   { return 0, os.ErrInvalid }
+func (d *webdavDir) Write(p []byte) (int, error)                  { return 0, os.ErrInvalid }
+func (d *webdavDir) Seek(offset int64, whence int) (int64, error) { return 0, os.ErrInvalid }
+func (d *webdavDir) Stat() (os.FileInfo, error)                   { return d.entry.fileInfo(), nil }
+
+// Readdir returns the next count children, all remaining ones when count is not positive
+func (d *webdavDir) Readdir(count int) ([]os.FileInfo, error) {
+	if !d.loaded {
+		children, err := d.loadChildren()
+		if err != nil {
+			return nil, err
+		}
+		d.children = children
+		d.loaded = true
+	}
+
+	if count <= 0 {
+		children := d.children
+		d.children = nil
+		return children, nil
+	}
+	if len(d.children) == 0 {
+		return nil, io.EOF
+	}
+	if count > len(d.children) {
+		count = len(d.children)
+	}
+	children := d.children[:count]
+	d.children = d.children[count:]
+	return children, nil
+}
+
+// loadChildren lists the readable subfolders and documents. Documents sharing a name with a subfolder
+// or a later upload are hidden, as the path resolves to those.
+func (d *webdavDir) loadChildren() ([]os.FileInfo, error) {
+	folderQuery := readableFolders(d.fs.ctx, d.fs.db.Model(&document.Folder{}))
+	if d.entry.folder == nil {
+		folderQuery = folderQuery.Where("parent_id IS NULL")
+	} else {
+		folderQuery = folderQuery.Where("parent_id = ?", d.entry.folder.ID)
+	}
+
+	var folders []document.Folder
+	if err := folderQuery.Order("name, created_at").Find(&folders).Error; err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]bool, len(folders))
+	children := make([]os.FileInfo, 0, len(folders))
+	for i := range folders {
+		if names[folders[i].Name] {
+			continue
+		}
+		names[folders[i].Name] = true
+		children = append(children, webdavEntry{folder: &folders[i]}.fileInfo())
+	}
+
+	// Documents are always in a folder
+	if d.entry.folder == nil {
+		return children, nil
+	}
+
+	var documents []document.Document
+	if err := readableDocuments(d.fs.ctx, d.fs.db.Model(&document.Document{})).
+		Where("folder_id = ?", d.entry.folder.ID).
+		Order("file_name, created_at DESC").
+		Find(&documents).Error; err != nil {
+		return nil, err
+	}
+	for i := range documents {
+		if names[documents[i].FileName] {
+			continue
+		}
+		names[documents[i].FileName] = true
+		children = append(children, webdavEntry{doc: &documents[i]}.fileInfo())
+	}
+	return children, nil
+}
+
+// webdavReader reads a document from storage. Only the bytes from the current offset are requested,
+// so range requests do not read the whole file.
+type webdavReader struct {
+	fs     *webdavFileSystem
+	doc    *document.Document
+	offset int64
+	body   io.ReadCloser
+}
+
+func (r *webdavReader) Write(p []byte) (int, error)              { return 0, os.ErrInvalid }
+func (r *webdavReader) Readdir(count int) ([]os.FileInfo, error) { return nil, os.ErrInvalid }
+func (r *webdavReader) Stat() (os.FileInfo, error)               { return webdavEntry{doc: r.doc}.fileInfo(), nil }
+
+func (r *webdavReader) Read(p []byte) (int, error) {
+	if r.offset >= r.doc.FileSize {
+		return 0, io.EOF
+	}
+
+	if r.body == nil {
+		storageKey := docUtils.StorageKey(r.doc.ObjectKey, r.doc.ContentHash)
+		requestCtx := r.fs.ctx.Request.Context()
+
+		var err error
+		if r.offset == 0 {
+			r.body, _, err = r.fs.storage.GetObject(requestCtx, storageKey)
+			if err == nil {
+				recordDocumentActivity(r.fs.ctx, r.doc.ID, document.DocumentActionDownload, "WebDAV")
+			}
+		} else {
+			r.body, err = r.fs.storage.GetObjectRange(requestCtx, storageKey, r.offset, r.doc.FileSize-1)
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.body.Read(p)
+	r.offset += int64(n)
+	return n, err
+}
+
+func (r *webdavReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += r.offset
+	case io.SeekEnd:
+		offset += r.doc.FileSize
+	}
+	if offset < 0 {
+		return 0, os.ErrInvalid
+	}
+
+	if offset != r.offset && r.body != nil {
+		r.body.Close()
+		r.body = nil
+	}
+	r.offset = offset
+	return offset, nil
+}
+
+func (r *webdavReader) Close() error {
+	if r.body != nil {
+		return r.body.Close()
+	}
+	return nil
+}
+
+// webdavWriter spools a written file to a temporary file and stores it on Close, as a new version of
+// the document or a new document in the folder
+type webdavWriter struct {
+	fs       *webdavFileSystem
+	folder   *document.Folder
+	doc      *document.Document
+	fileName string
+	tempFile *os.File
+}
+
+func (w *webdavWriter) Read(p []byte) (int, error)                   { return 0, os.ErrInvalid }
+func (w *webdavWriter) Seek(offset int64, whence int) (int64, error) { return 0, os.ErrInvalid }
+func (w *webdavWriter) Readdir(count int) ([]os.FileInfo, error)     { return nil, os.ErrInvalid }
+func (w *webdavWriter) Write(p []byte) (int, error)                  { return w.tempFile.Write(p) }
+
+func (w *webdavWriter) Stat() (os.FileInfo, error) {
+	tempInfo, err := w.tempFile.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return &webdavFileInfo{
+		name:     w.fileName,
+		size:     tempInfo.Size(),
+		modTime:  tempInfo.ModTime(),
+		mimeType: webdavMimeType(w.fileName),
+	}, nil
+}
+
+func (w *webdavWriter) Close() error {
+	defer os.Remove(w.tempFile.Name())
+	defer w.tempFile.Close()
+
+	tempInfo, err := w.tempFile.Stat()
+	if err != nil {
+		return err
+	}
+	if _, err := w.tempFile.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	header := &multipart.FileHeader{
+		Filename: w.fileName,
+		Size:     tempInfo.Size(),
+		Header:   textproto.MIMEHeader{"Content-Type": {webdavMimeType(w.fileName)}},
+	}
+	// Clients create empty files before writing them, their type is known by extension only
+	mimeType := ""
+	if header.Size > 0 {
+		if err := docUtils.ValidateUploadedFile(header); err != nil {
+			return err
+		}
+		if mimeType, err = docUtils.DetectFileType(w.tempFile, w.fileName); err != nil {
+			return err
+		}
+		header.Header.Set("Content-Type", mimeType)
+	}
+	policy, err := folderFileTypePolicy(w.folder)
+	if err != nil {
+		return err
+	}
+	if err := database.CheckFileType(policy, w.fileName, mimeType, header.Size); err != nil {
+		return err
+	}
+
+	if w.doc != nil {
+		if err := folderQuotaError(w.folder, header.Size, 0); err != nil {
+			return err
+		}
+		version, err := addDocumentVersion(w.fs.db, w.fs.storage, w.doc, w.tempFile, header, w.fs.userID, 0)
+		if err != nil {
+			return err
+		}
+		recordDocumentActivity(w.fs.ctx, w.doc.ID, document.DocumentActionUpload, fmt.Sprintf("Version %d", version.Version))
+		recordFolderChange(w.fs.ctx, []uuid.UUID{w.doc.ID}, document.FolderChangeUpdated)
+		return nil
+	}
+
+	if err := folderQuotaError(w.folder, header.Size, 1); err != nil {
+		return err
+	}
+
+	// Required metadata fields of the folder cannot be set over WebDAV
+	fields, err := folderMetadataFields(w.fs.db, w.folder)
+	if err != nil {
+		return err
+	}
+	metadata, err := docUtils.ValidateMetadata(fields, models.JSONMap{})
+	if err != nil {
+		return err
+	}
+
+	doc, err := createDocument(w.fs.db, w.fs.storage, w.folder, w.tempFile, header, w.fs.userID, nil, "", metadata)
+	if err != nil {
+		return err
+	}
+
+	services.QueueFolderStats(w.folder.ID)
+	recordDocumentActivity(w.fs.ctx, doc.ID, document.DocumentActionUpload, "")
+	recordFolderChange(w.fs.ctx, []uuid.UUID{doc.ID}, document.FolderChangeAdded)
+
+	w.fs.db.Preload("Folder").First(doc, doc.ID)
+	docResponse := docUtils.BuildDocumentResponse(doc, w.fs.db)
+	database.EnqueueWebhookEvent(models.WebhookEventDocumentUploaded, docResponse)
+	messaging.Publish(w.fs.ctx.Request.Context(), messaging.EventDocumentUploaded, utils.GetActorID(w.fs.ctx), docResponse)
+	return nil
+}
+
+// webdavMimeType returns the content type of a file written over WebDAV, by its extension
+func webdavMimeType(fileName string) string {
+	if mimeType := mime.TypeByExtension(filepath.Ext(fileName)); mimeType != "" {
+		return mimeType
+	}
+	return "application/octet-stream"
+}
@@ -1,40 +1,176 @@
 package main
 
 import (
+	_ "forgecrud-backend/document-service/docs"
 	"forgecrud-backend/document-service/services"
+	"forgecrud-backend/shared/clientip"
 	"forgecrud-backend/shared/config"
 	"log"
 	"strings"
+	"time"
 
 	"forgecrud-backend/document-service/handlers"
 	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/health"
+	"forgecrud-backend/shared/jobs"
+	"forgecrud-backend/shared/messaging"
+	"forgecrud-backend/shared/readonly"
+	"forgecrud-backend/shared/scheduler"
+	"forgecrud-backend/shared/server"
+	"forgecrud-backend/shared/tenancy"
+	docUtils "forgecrud-backend/shared/utils/document"
 
 	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
+// @title Document Service API
+// @version 1.0
+// @description Folders, documents, versions, uploads, shares and retention
+// @BasePath /api
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+
 func main() {
 	// Load configuration
 	config.LoadConfig()
 
-	// Initialize MinIO service
-	minioService, err := services.NewMinIOService()
+	// Initialize the storage provider selected by STORAGE_DRIVER
+	storage, err := services.NewStorageProvider()
 	if err != nil {
-		log.Fatalf("❌ Failed to initialize MinIO service: %v", err)
+		log.Fatalf("❌ Failed to initialize storage: %v", err)
 	}
 
-	// Test MinIO connection
-	if err := minioService.TestConnection(); err != nil {
-		log.Fatalf("❌ MinIO connection test failed: %v", err)
+	// Test storage connection
+	if err := storage.TestConnection(); err != nil {
+		log.Fatalf("❌ Storage connection test failed: %v", err)
 	}
+	server.OnShutdown("storage", storage.Close)
+	health.AddCheck("storage", health.Ping(storage.Ping))
 
 	// Initialize database
 	if err := database.InitDatabase(); err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
-	defer database.CloseDatabase()
+	server.OnShutdown("database", database.CloseDatabase)
+	health.AddCheck("database", health.CheckDatabase)
+
+	// Publish document events for other services
+	if err := messaging.Init("document-service"); err != nil {
+		log.Printf("⚠️  Warning: Event bus not available: %v", err)
+	} else {
+		server.OnShutdown("event bus", messaging.Close)
+		health.AddOptionalCheck("event bus", health.Ping(messaging.Ping))
+	}
+
+	// Pause the background writers while the service is read-only
+	if err := readonly.Init("document-service"); err != nil {
+		log.Printf("⚠️  Warning: Read-only switch not available: %v", err)
+	} else {
+		server.OnShutdown("read-only switch", readonly.Close)
+		health.AddOptionalCheck("read-only switch", health.Ping(readonly.Ping))
+	}
+
+	// Scan quarantined uploads for malware when a scanner is configured
+	scanner, err := services.NewScanner()
+	if err != nil {
+		log.Fatalf("❌ Failed to initialize malware scanner: %v", err)
+	}
+	if scanner != nil {
+		interval := time.Duration(config.GetConfig().ScanIntervalSeconds) * time.Second
+		services.NewScanWorker(storage, scanner).Start(interval)
+	}
+
+	// Extract document text for full-text search
+	indexInterval := time.Duration(config.GetConfig().IndexIntervalSeconds) * time.Second
+	services.NewIndexWorker(storage).Start(indexInterval)
+
+	// Purge documents and folders from the trash past the retention period
+	if retentionDays := config.GetConfig().TrashRetentionDays; retentionDays > 0 {
+		services.NewTrashPurger(storage, time.Duration(retentionDays)*24*time.Hour).Start(time.Hour)
+	}
+
+	// Remove old document versions past the version retention rules
+	if cfg := config.GetConfig(); cfg.VersionRetentionKeepLast > 0 || cfg.VersionRetentionDays > 0 {
+		maxAge := time.Duration(cfg.VersionRetentionDays) * 24 * time.Hour
+		services.NewVersionPruner(storage, cfg.VersionRetentionKeepLast, maxAge).Start(time.Hour)
+	}
+
+	// Move documents past the auto-delete age of their retention policies to the trash
+	if hours := config.GetConfig().RetentionIntervalHours; hours > 0 {
+		services.NewRetentionEnforcer().Start(time.Duration(hours) * time.Hour)
+	}
+
+	// Apply queued folder operations to storage and retry failed ones
+	outbox := services.NewStorageOutbox(storage)
+	outbox.Start(time.Duration(config.GetConfig().StorageOutboxIntervalSeconds) * time.Second)
+
+	// Verify stored files against their records, find orphaned objects and queue folders missing in
+	// storage, on one instance
+	if err := scheduler.Init("document-service"); err != nil {
+		log.Printf("⚠️  Warning: Scheduler lock not available, every instance runs the schedule: %v", err)
+	}
+	if cfg := config.GetConfig(); cfg.IntegrityCheckIntervalHours > 0 {
+		services.NewIntegrityChecker(storage, services.IntegrityOptions{
+			RepairOrphans: cfg.IntegrityRepairOrphans,
+			OrphanGrace:   time.Duration(cfg.IntegrityOrphanGraceHours) * time.Hour,
+		}).Register(time.Duration(cfg.IntegrityCheckIntervalHours) * time.Hour)
+	}
+	// Repair folder stats that missed an update
+	if hours := config.GetConfig().FolderStatsRollupIntervalHours; hours > 0 {
+		services.RegisterFolderStatsRollup(time.Duration(hours) * time.Hour)
+	}
+	if hours := config.GetConfig().StorageReconcileIntervalHours; hours > 0 {
+		outbox.RegisterReconciler(time.Duration(hours) * time.Hour)
+	}
+	// Archive the audit logs past their retention before core-service deletes them
+	if cfg := config.GetConfig(); cfg.AuditLogArchiveEnabled {
+		services.NewAuditLogArchiver(storage, time.Duration(cfg.AuditLogRetentionDays)*24*time.Hour).Register()
+	}
+	// Back up the database with the document storage on request and, when configured, on a schedule
+	backups, err := services.NewBackupManager(storage)
+	if err != nil {
+		log.Printf("⚠️  Warning: Backups not available: %v", err)
+	} else {
+		backups.Register(time.Duration(config.GetConfig().BackupIntervalHours) * time.Hour)
+	}
+	scheduler.Start()
+	server.OnShutdown("scheduler", scheduler.Close)
+
+	// Build folder exports too large to download directly and folder statistics on job workers
+	if err := jobs.Init("document-service"); err != nil {
+		log.Printf("⚠️  Warning: Job queue not available, running jobs in-process: %v", err)
+	} else {
+		health.AddOptionalCheck("job queue", health.Ping(jobs.Ping))
+	}
+	exportRetention := time.Duration(config.GetConfig().FolderExportRetentionHours) * time.Hour
+	exporter := services.NewFolderExporter(storage, exportRetention)
+	exporter.RegisterJobs()
+	services.RegisterFolderStatsJob()
+	services.BackfillFolderStats()
+	jobs.Start()
+	server.OnShutdown("job queue", jobs.Close)
+
+	// Queue lost folder exports again and remove expired ones
+	exporter.Start(time.Minute)
+
+	// Discard chunked uploads abandoned past their expiry
+	services.NewUploadJanitor(storage).Start(30 * time.Minute)
+
+	// Notify the subscribers of folders about changes in them
+	services.NewFolderWatchNotifier().Start(time.Minute)
 
 	// Initialize Gin router
-	router := gin.Default()
+	router := gin.New()
+	router.Use(server.Logger(), gin.Recovery())
+
+	// Client IPs forwarded by the gateway
+	clientip.ConfigureService(router)
+
+	// Scope queries to the caller's organization forwarded by the gateway
+	router.Use(tenancy.Middleware())
 
 	//Folder Routes
 	router.GET("/api/folders", handlers.GetFolders)
@@ -44,35 +180,147 @@ func main() {
 	router.PUT("/api/folders/:id", handlers.UpdateFolder)
 	router.POST("/api/folders/:id/move", handlers.MoveFolder)
 	router.DELETE("/api/folders/:id", handlers.DeleteFolder)
+	router.POST("/api/folders/:id/restore", handlers.RestoreFolder)
 	router.GET("/api/folders/:id/download", handlers.DownloadFolder)
+	router.GET("/api/folders/:id/size", handlers.GetFolderSize)
+	router.GET("/api/folders/:id/stats", handlers.GetFolderStats)
+	router.POST("/api/folders/:id/export", handlers.ExportFolder)
+	router.GET("/api/folder-exports/:id", handlers.GetFolderExport)
+	router.GET("/api/folder-exports/:id/download", handlers.DownloadFolderExport)
+	router.POST("/api/folders/bulk/move", handlers.BulkMoveFolders)
+	router.POST("/api/folders/bulk/delete", handlers.BulkDeleteFolders)
 
 	// Document Routes
 	router.POST("/api/documents", handlers.UploadDocument)
 	router.GET("/api/documents", handlers.GetDocuments)
+	router.GET("/api/documents/search", handlers.SearchDocuments)
 	router.GET("/api/documents/:id", handlers.GetDocument)
 	router.GET("/api/documents/:id/download", handlers.DownloadDocument)
+	router.GET("/api/documents/:id/render", handlers.RenderDocument)
 	router.PUT("/api/documents/:id", handlers.UpdateDocument)
 	router.POST("/api/documents/:id/move", handlers.MoveDocument)
 	router.DELETE("/api/documents/:id", handlers.DeleteDocument)
+	router.POST("/api/documents/:id/restore", handlers.RestoreDocument)
 	router.POST("/documents/:id/copy", handlers.CopyDocument)
+	router.POST("/api/documents/bulk/move", handlers.BulkMoveDocuments)
+	router.POST("/api/documents/bulk/copy", handlers.BulkCopyDocuments)
+	router.POST("/api/documents/bulk/delete", handlers.BulkDeleteDocuments)
+	router.POST("/api/documents/bulk/tag", handlers.BulkTagDocuments)
+
+	// Recent and Favorite Document Routes
+	router.GET("/api/documents/recent", handlers.GetRecentDocuments)
+	router.GET("/api/documents/favorites", handlers.GetFavoriteDocuments)
+	router.POST("/api/documents/:id/star", handlers.StarDocument)
+	router.DELETE("/api/documents/:id/star", handlers.UnstarDocument)
+
+	// Document Activity Routes
+	router.GET("/api/documents/:id/activity", handlers.GetDocumentActivity)
+	router.GET("/api/documents/activity/export", handlers.ExportDocumentActivity)
 
 	// Document Version Routes
 	router.GET("/api/documents/:id/versions", handlers.GetDocumentVersions)
 	router.GET("/api/documents/:id/versions/latest", handlers.GetLatestDocumentVersion)
+	router.GET("/api/documents/:id/versions/compare", handlers.CompareDocumentVersions)
 	router.POST("/api/documents/:id/versions", handlers.UploadDocumentVersion)
+	router.GET("/api/documents/:id/versions/:version/download", handlers.DownloadDocumentVersion)
+	router.POST("/api/documents/:id/versions/:version/restore", handlers.RestoreDocumentVersion)
+	router.POST("/api/documents/:id/versions/:version/pin", handlers.PinDocumentVersion)
+	router.DELETE("/api/documents/:id/versions/:version/pin", handlers.UnpinDocumentVersion)
+
+	// Document Lock Routes
+	router.POST("/api/documents/:id/lock", handlers.LockDocument)
+	router.DELETE("/api/documents/:id/lock", handlers.UnlockDocument)
+	router.DELETE("/api/documents/:id/lock/force", handlers.ForceUnlockDocument)
+
+	// Chunked Upload Routes
+	router.POST("/api/uploads", handlers.InitiateUpload)
+	router.GET("/api/uploads/:id", handlers.GetUpload)
+	router.PATCH("/api/uploads/:id", handlers.UploadChunk)
+	router.POST("/api/uploads/:id/complete", handlers.CompleteUpload)
+	router.DELETE("/api/uploads/:id", handlers.AbortUpload)
+
+	// Share Routes
+	router.POST("/api/documents/:id/share", handlers.CreateShare)
+	router.GET("/api/documents/:id/shares", handlers.GetDocumentShares)
+	router.DELETE("/api/documents/:id/shares/:share_id", handlers.RevokeShare)
+	router.GET("/api/shares/:token", handlers.GetPublicShare)
+	router.GET("/api/shares/:token/download", handlers.DownloadPublicShare)
+
+	// Access Control Routes
+	router.GET("/api/folders/:id/acl", handlers.GetFolderAccess)
+	router.POST("/api/folders/:id/acl", handlers.GrantFolderAccess)
+	router.DELETE("/api/folders/:id/acl/:grant_id", handlers.RevokeFolderAccess)
+	router.GET("/api/documents/:id/acl", handlers.GetDocumentAccess)
+	router.POST("/api/documents/:id/acl", handlers.GrantDocumentAccess)
+	router.DELETE("/api/documents/:id/acl/:grant_id", handlers.RevokeDocumentAccess)
+
+	// Metadata Field Routes
+	router.GET("/api/folders/:id/metadata-fields", handlers.GetFolderMetadataFields)
+	router.POST("/api/folders/:id/metadata-fields", handlers.CreateFolderMetadataField)
+	router.PUT("/api/folders/:id/metadata-fields/:field_id", handlers.UpdateFolderMetadataField)
+	router.DELETE("/api/folders/:id/metadata-fields/:field_id", handlers.DeleteFolderMetadataField)
+
+	// Retention and Legal Hold Routes
+	router.GET("/api/folders/:id/retention", handlers.GetFolderRetention)
+	router.PUT("/api/folders/:id/retention", handlers.SetFolderRetention)
+	router.DELETE("/api/folders/:id/retention", handlers.DeleteFolderRetention)
+	router.GET("/api/legal-holds", handlers.GetLegalHolds)
+	router.POST("/api/legal-holds", handlers.PlaceLegalHold)
+	router.POST("/api/legal-holds/:id/release", handlers.ReleaseLegalHold)
+
+	// Folder Subscription Routes
+	router.GET("/api/folders/subscriptions", handlers.GetFolderSubscriptions)
+	router.GET("/api/folders/:id/subscription", handlers.GetFolderSubscription)
+	router.PUT("/api/folders/:id/subscription", handlers.SubscribeFolder)
+	router.DELETE("/api/folders/:id/subscription", handlers.UnsubscribeFolder)
+
+	// Storage Routes
+	router.GET("/api/storage/usage", handlers.GetStorageUsage)
+	router.GET("/api/storage/stats", handlers.GetStorageStats)
+	router.GET("/api/storage/integrity", handlers.GetIntegrityReport)
+	router.POST("/api/storage/integrity", handlers.RunIntegrityCheck)
+	router.GET("/api/storage/operations", handlers.GetStorageOperations)
+	router.POST("/api/storage/operations/:id/retry", handlers.RetryStorageOperation)
+
+	// Organization Storage Routes
+	router.GET("/api/organizations/:id/storage", handlers.GetOrganizationStorage)
+	router.PUT("/api/organizations/:id/storage", handlers.UpdateOrganizationStorage)
+	router.DELETE("/api/organizations/:id/storage", handlers.DeleteOrganizationStorage)
+
+	// Audit Log Archive Routes
+	router.GET("/api/audit-logs/archives", handlers.GetAuditLogArchives)
+	router.POST("/api/audit-logs/archives", handlers.ArchiveAuditLogs)
+	router.POST("/api/audit-logs/archives/:id/verify", handlers.VerifyAuditLogArchive)
+
+	// Backup Routes
+	router.GET("/api/backups", handlers.GetBackups)
+	router.POST("/api/backups", handlers.CreateBackup)
+	router.GET("/api/backups/:id", handlers.GetBackup)
+	router.DELETE("/api/backups/:id", handlers.DeleteBackup)
+
+	// Trash Routes
+	router.GET("/api/trash", handlers.GetTrash)
+	router.DELETE("/api/trash", handlers.EmptyTrash)
+
+	// Avatar Routes
+	router.POST("/api/users/:id/avatar", handlers.UploadAvatar)
+	router.DELETE("/api/users/:id/avatar", handlers.DeleteAvatar)
+	router.GET("/api/avatars/:user_id/:file", handlers.GetAvatar)
+
+	// WebDAV Routes
+	for _, method := range docUtils.WebDAVMethods {
+		router.Handle(method, docUtils.WebDAVPrefix+"/*path", handlers.ServeWebDAV)
+	}
+
+	// Liveness and readiness probes, and metrics
+	health.Register(router, "document-service")
 
-	// Health check endpoint
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{
-			"status":  "healthy",
-			"service": "document-service",
-			"message": "Document service is running",
-		})
-	})
+	// Swagger documentation, merged by the gateway into its OpenAPI document
+	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	// Start server
 	// Parse port from config URL
 	port := strings.Split(config.GetConfig().DocumentServiceURL, ":")[2]
 	log.Printf("Document Service starting on port %s...", port)
-	router.Run(":" + port)
+	server.Run(router, ":"+port)
 }
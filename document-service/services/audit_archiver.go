@@ -0,0 +1,318 @@
+package services
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models/notification"
+	"forgecrud-backend/shared/jobs"
+	"forgecrud-backend/shared/scheduler"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AuditLogArchivePrefix holds the archived audit logs in storage, one object per UTC day
+const AuditLogArchivePrefix = "audit-logs/"
+
+// JobAuditLogArchive archives the days of audit logs past their retention, on the schedule and when
+// started through the API
+const JobAuditLogArchive = "documents.audit_log_archive"
+
+// ErrAuditLogArchiveRunning is returned when archival is started while it already runs on this instance
+var ErrAuditLogArchiveRunning = errors.New("audit log archival is already running")
+
+// auditLogArchiveRun allows one archival at a time, scheduled or queued through the API
+var auditLogArchiveRun sync.Mutex
+
+// AuditLogArchiver writes the audit logs past their retention to storage as gzipped JSON lines, one
+// object per UTC day, so core-service may delete them. Its runs are ahead of the 02:30 purge.
+type AuditLogArchiver struct {
+	storage   StorageProvider
+	retention time.Duration
+}
+
+func NewAuditLogArchiver(storage StorageProvider, retention time.Duration) *AuditLogArchiver {
+	return &AuditLogArchiver{storage: storage, retention: retention}
+}
+
+// Register schedules the archival and registers the job running it on request; call before jobs.Start
+func (a *AuditLogArchiver) Register() {
+	scheduler.Register(JobAuditLogArchive, "0 1 * * *", a.Run)
+	jobs.Handle(JobAuditLogArchive, func(ctx context.Context, job *jobs.Job) error {
+		return a.Run(ctx)
+	})
+	log.Printf("🗃️  Audit log archival scheduled (logs older than %s)", a.retention)
+}
+
+// QueueAuditLogArchival queues an archival run, unless one is already waiting
+func QueueAuditLogArchival(ctx context.Context) error {
+	_, err := jobs.Enqueue(ctx, JobAuditLogArchive, nil, jobs.WithPriority(jobs.PriorityLow), jobs.Unique(JobAuditLogArchive))
+	if errors.Is(err, jobs.ErrDuplicate) {
+		return nil
+	}
+	return err
+}
+
+// Run archives the days that are past the retention and not archived yet, or whose archive failed
+// verification
+func (a *AuditLogArchiver) Run(ctx context.Context) error {
+	archived, err := a.ArchiveDue(ctx)
+	switch {
+	case errors.Is(err, ErrAuditLogArchiveRunning):
+		return nil
+	case archived > 0:
+		log.Printf("🗃️  Archived %d days of audit logs", archived)
+	}
+	return err
+}
+
+// ArchiveDue archives every day whose logs are all past the retention and returns the number of
+// archived days
+func (a *AuditLogArchiver) ArchiveDue(ctx context.Context) (int, error) {
+	if !auditLogArchiveRun.TryLock() {
+		return 0, ErrAuditLogArchiveRunning
+	}
+	defer auditLogArchiveRun.Unlock()
+
+	// Only whole days, so no log is written to a day after it was archived
+	cutoff := time.Now().UTC().Add(-a.retention).Truncate(24 * time.Hour)
+
+	archived := 0
+	var from time.Time
+	for {
+		if err := ctx.Err(); err != nil {
+			return archived, err
+		}
+
+		var oldest sql.NullTime
+		if err := database.DB.WithContext(ctx).Model(&notification.AuditLog{}).
+			Where("created_at >= ? AND created_at < ?", from, cutoff).
+			Where("NOT " + database.AuditLogArchivedCondition).
+			Select("MIN(created_at)").
+			Row().Scan(&oldest); err != nil {
+			return archived, err
+		}
+		if !oldest.Valid {
+			return archived, nil
+		}
+
+		day := oldest.Time.UTC().Truncate(24 * time.Hour)
+		if _, err := a.archiveDay(ctx, day); err != nil {
+			return archived, fmt.Errorf("failed to archive the audit logs of %s: %v", day.Format("2006-01-02"), err)
+		}
+		archived++
+		from = day.Add(24 * time.Hour)
+	}
+}
+
+// AuditLogArchiveKey returns the storage key of the archive of a day
+func AuditLogArchiveKey(day time.Time) string {
+	return AuditLogArchivePrefix + day.Format("2006/01/02") + ".jsonl.gz"
+}
+
+// archiveDay writes the logs of the day to a temporary file, stores it and records the archive
+func (a *AuditLogArchiver) archiveDay(ctx context.Context, day time.Time) (*notification.AuditLogArchive, error) {
+	tempFile, err := os.CreateTemp("", "audit-logs-*.jsonl.gz")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary file: %v", err)
+	}
+	defer os.Remove(tempFile.Name())
+	defer tempFile.Close()
+
+	checksum := sha256.New()
+	gzipWriter := gzip.NewWriter(io.MultiWriter(tempFile, checksum))
+	count, err := writeAuditLogDay(ctx, gzipWriter, day)
+	if err != nil {
+		return nil, err
+	}
+	if err := gzipWriter.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish archive: %v", err)
+	}
+
+	size, err := tempFile.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tempFile.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	objectKey := AuditLogArchiveKey(day)
+	if err := a.storage.PutObject(ctx, objectKey, tempFile, size, "application/gzip"); err != nil {
+		return nil, fmt.Errorf("failed to store archive: %v", err)
+	}
+
+	archive := &notification.AuditLogArchive{
+		Day:       day,
+		ObjectKey: objectKey,
+		LogCount:  count,
+		SizeBytes: size,
+		Checksum:  hex.EncodeToString(checksum.Sum(nil)),
+		Status:    notification.AuditLogArchiveArchived,
+	}
+	// Archiving a day again replaces its failed archive
+	if err := database.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "day"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"object_key":   archive.ObjectKey,
+			"log_count":    archive.LogCount,
+			"size_bytes":   archive.SizeBytes,
+			"checksum":     archive.Checksum,
+			"status":       archive.Status,
+			"verified_at":  nil,
+			"verify_error": "",
+			"updated_at":   time.Now(),
+		}),
+	}).Create(archive).Error; err != nil {
+		return nil, err
+	}
+	return archive, nil
+}
+
+// writeAuditLogDay writes the logs of the day as JSON lines, oldest first, and returns their number
+func writeAuditLogDay(ctx context.Context, w io.Writer, day time.Time) (int64, error) {
+	rows, err := database.DB.WithContext(ctx).Model(&notification.AuditLog{}).
+		Where("created_at >= ? AND created_at < ?", day, day.Add(24*time.Hour)).
+		Order("created_at, id").
+		Rows()
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	encoder := json.NewEncoder(w)
+	var count int64
+	for rows.Next() {
+		var entry notification.AuditLog
+		if err := database.DB.ScanRows(rows, &entry); err != nil {
+			return count, err
+		}
+		entry.RequestBody = rawJSONColumn(entry.RequestBody)
+		entry.ResponseBody = rawJSONColumn(entry.ResponseBody)
+		if err := encoder.Encode(&entry); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, rows.Err()
+}
+
+// rawJSONColumn keeps a jsonb column read from the database as JSON instead of a base64 string
+func rawJSONColumn(value interface{}) interface{} {
+	var raw []byte
+	switch v := value.(type) {
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	default:
+		return value
+	}
+	if !json.Valid(raw) {
+		return string(raw)
+	}
+	return json.RawMessage(raw)
+}
+
+// VerifyAuditLogArchive reads an archive back from storage and checks its checksum, that every line
+// is a JSON log and that it holds as many logs as recorded and as are still in the database for its
+// day. The archive is marked verified, or failed so the next archival writes it again and its logs
+// are not deleted meanwhile.
+func VerifyAuditLogArchive(ctx context.Context, db *gorm.DB, storage StorageProvider, archive *notification.AuditLogArchive) error {
+	problem, err := checkAuditLogArchive(ctx, db, storage, archive)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{
+		"status":       notification.AuditLogArchiveVerified,
+		"verified_at":  now,
+		"verify_error": "",
+	}
+	if problem != "" {
+		updates["status"] = notification.AuditLogArchiveFailed
+		updates["verify_error"] = problem
+	}
+	return db.Model(archive).Updates(updates).Error
+}
+
+// checkAuditLogArchive returns what is wrong with the archive, empty when it is intact. The error
+// is only set when the check itself failed.
+func checkAuditLogArchive(ctx context.Context, db *gorm.DB, storage StorageProvider, archive *notification.AuditLogArchive) (string, error) {
+	object, _, err := storage.GetObject(ctx, archive.ObjectKey)
+	if errors.Is(err, ErrObjectNotFound) {
+		return "archive object is missing from storage", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	defer object.Close()
+
+	checksum := sha256.New()
+	count, problem := countAuditLogArchive(io.TeeReader(object, checksum))
+	if problem != "" {
+		return problem, nil
+	}
+	if sum := hex.EncodeToString(checksum.Sum(nil)); sum != archive.Checksum {
+		return fmt.Sprintf("checksum %s does not match the recorded %s", sum, archive.Checksum), nil
+	}
+	if count != archive.LogCount {
+		return fmt.Sprintf("archive holds %d logs, %d were archived", count, archive.LogCount), nil
+	}
+
+	// Logs not purged yet must all be in the archive
+	var stored int64
+	if err := db.Model(&notification.AuditLog{}).
+		Where("created_at >= ? AND created_at < ?", archive.Day, archive.Day.Add(24*time.Hour)).
+		Count(&stored).Error; err != nil {
+		return "", err
+	}
+	if stored > 0 && stored != count {
+		return fmt.Sprintf("archive holds %d logs, the database still has %d for its day", count, stored), nil
+	}
+	return "", nil
+}
+
+// countAuditLogArchive decompresses the archive and counts its logs, reading it to the end so the
+// checksum covers the whole object
+func countAuditLogArchive(reader io.Reader) (int64, string) {
+	gzipReader, err := gzip.NewReader(reader)
+	if err != nil {
+		return 0, fmt.Sprintf("archive is not gzip compressed: %v", err)
+	}
+	defer gzipReader.Close()
+
+	decoder := json.NewDecoder(gzipReader)
+	var count int64
+	for {
+		var entry json.RawMessage
+		err := decoder.Decode(&entry)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return count, fmt.Sprintf("log %d is not valid JSON: %v", count+1, err)
+		}
+		count++
+	}
+
+	// Trailing bytes after the gzip stream still count towards the checksum
+	if _, err := io.Copy(io.Discard, reader); err != nil {
+		return count, fmt.Sprintf("failed to read archive: %v", err)
+	}
+	return count, ""
+}
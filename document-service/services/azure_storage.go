@@ -0,0 +1,588 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// azureAPIVersion is the Blob service REST API version the requests are signed for
+const azureAPIVersion = "2021-08-06"
+
+// azureCopyPollInterval is how often a pending server-side copy is checked
+const azureCopyPollInterval = 500 * time.Millisecond
+
+// AzureBlobStorage stores files as block blobs in a container of an Azure storage account. It talks
+// to the Blob service REST API directly, authorized with the account's shared key.
+type AzureBlobStorage struct {
+	account   string
+	key       []byte
+	container string
+	baseURL   string // URL of the container
+	client    *http.Client
+}
+
+// NewAzureBlobStorage connects to the container of the storage account and creates it if needed. An
+// empty endpoint uses the public Azure endpoint of the account; set it for Azurite or sovereign clouds.
+func NewAzureBlobStorage(account, accountKey, container, endpoint string) (*AzureBlobStorage, error) {
+	if account == "" || accountKey == "" {
+		return nil, fmt.Errorf("AZURE_STORAGE_ACCOUNT and AZURE_STORAGE_KEY are required")
+	}
+	key, err := base64.StdEncoding.DecodeString(accountKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Azure storage key: %v", err)
+	}
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://%s.blob.core.windows.net", account)
+	}
+
+	log.Printf("🔗 Connecting to Azure Blob Storage: %s", endpoint)
+
+	service := &AzureBlobStorage{
+		account:   account,
+		key:       key,
+		container: container,
+		baseURL:   strings.TrimSuffix(endpoint, "/") + "/" + container,
+		client:    &http.Client{},
+	}
+
+	// Create the container unless it exists
+	resp, err := service.do(context.Background(), http.MethodPut, "", url.Values{"restype": {"container"}}, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create container: %v", err)
+	}
+	resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusCreated:
+		log.Printf("✅ Azure container '%s' created successfully", container)
+	case http.StatusConflict:
+		log.Printf("✅ Azure container '%s' already exists", container)
+	default:
+		return nil, fmt.Errorf("failed to create container: %s", resp.Status)
+	}
+
+	return service, nil
+}
+
+// TestConnection checks that the container is reachable
+func (s *AzureBlobStorage) TestConnection() error {
+	if err := s.Ping(context.Background()); err != nil {
+		return err
+	}
+
+	log.Printf("✅ Azure Blob Storage connection successful. Container: %s", s.container)
+	return nil
+}
+
+// Ping checks that the container is reachable
+func (s *AzureBlobStorage) Ping(ctx context.Context) error {
+	resp, err := s.do(ctx, http.MethodHead, "", url.Values{"restype": {"container"}}, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to connect to Azure Blob Storage: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to connect to Azure Blob Storage: %s", resp.Status)
+	}
+	return nil
+}
+
+// Close closes the idle connections to the storage account
+func (s *AzureBlobStorage) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
+
+func (s *AzureBlobStorage) CreateFolder(folderPath string) error {
+	cleanPath := strings.Trim(folderPath, "/")
+	if cleanPath != "" {
+		cleanPath = cleanPath + "/"
+	}
+	if err := s.PutObject(context.Background(), cleanPath+folderMarker, strings.NewReader(""), 0, "text/plain"); err != nil {
+		return fmt.Errorf("failed to create folder marker: %v", err)
+	}
+	return nil
+}
+
+// DeleteFolder removes a folder and ALL its contents
+func (s *AzureBlobStorage) DeleteFolder(folderPath string) error {
+	ctx := context.Background()
+
+	var keys []string
+	if err := s.WalkObjects(ctx, folderPrefix(folderPath), func(object ObjectInfo) error {
+		keys = append(keys, object.Key)
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	var errors []string
+	for _, key := range keys {
+		if err := s.RemoveObject(ctx, key); err != nil {
+			errors = append(errors, fmt.Sprintf("failed to delete %s: %v", key, err))
+		}
+	}
+	if len(errors) > 0 {
+		return fmt.Errorf("failed to delete some objects: %v", errors)
+	}
+	return nil
+}
+
+// MoveFolder copies every blob of the folder to the new path and removes the original
+func (s *AzureBlobStorage) MoveFolder(oldPath, newPath string) error {
+	oldPrefix := folderPrefix(oldPath)
+	newPrefix := folderPrefix(newPath)
+	if oldPrefix == "" || newPrefix == "" {
+		return fmt.Errorf("invalid folder paths")
+	}
+
+	var keys []string
+	if err := s.WalkObjects(context.Background(), oldPrefix, func(object ObjectInfo) error {
+		keys = append(keys, object.Key)
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		newKey := newPrefix + strings.TrimPrefix(key, oldPrefix)
+		if err := s.MoveObject(key, newKey); err != nil {
+			return fmt.Errorf("failed to move object %s to %s: %v", key, newKey, err)
+		}
+	}
+	return nil
+}
+
+func (s *AzureBlobStorage) FolderExists(folderPath string) (bool, error) {
+	_, err := s.StatObject(context.Background(), folderPrefix(folderPath)+folderMarker)
+	return err == nil, nil
+}
+
+// ListFolderContents lists the keys directly in a folder, subfolders end with a slash
+func (s *AzureBlobStorage) ListFolderContents(folderPath string) ([]string, error) {
+	var objects []string
+	err := s.list(context.Background(), folderPrefix(folderPath), "/", func(result azureListResult) error {
+		for _, prefix := range result.Blobs.Prefixes {
+			objects = append(objects, prefix.Name)
+		}
+		for _, blob := range result.Blobs.Blobs {
+			objects = append(objects, blob.Name)
+		}
+		return nil
+	})
+	return objects, err
+}
+
+// PutObject uploads a block blob in one request
+func (s *AzureBlobStorage) PutObject(ctx context.Context, objectKey string, reader io.Reader, size int64, contentType string) error {
+	headers := http.Header{"X-Ms-Blob-Type": {"BlockBlob"}}
+	if contentType != "" {
+		headers.Set("Content-Type", contentType)
+	}
+
+	resp, err := s.doSized(ctx, http.MethodPut, objectKey, nil, headers, reader, size)
+	if err != nil {
+		return fmt.Errorf("failed to upload object: %v", err)
+	}
+	return expectStatus(resp, "failed to upload object", http.StatusCreated)
+}
+
+func (s *AzureBlobStorage) GetObject(ctx context.Context, objectKey string) (io.ReadCloser, ObjectInfo, error) {
+	resp, err := s.do(ctx, http.MethodGet, objectKey, nil, nil, nil)
+	if err != nil {
+		return nil, ObjectInfo{}, fmt.Errorf("failed to get object: %v", err)
+	}
+	if err := checkStatus(resp, "failed to get object", http.StatusOK); err != nil {
+		return nil, ObjectInfo{}, err
+	}
+	return resp.Body, azureObjectInfo(objectKey, resp.Header, resp.ContentLength), nil
+}
+
+func (s *AzureBlobStorage) GetObjectRange(ctx context.Context, objectKey string, start, end int64) (io.ReadCloser, error) {
+	headers := http.Header{"X-Ms-Range": {fmt.Sprintf("bytes=%d-%d", start, end)}}
+	resp, err := s.do(ctx, http.MethodGet, objectKey, nil, headers, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object: %v", err)
+	}
+	if err := checkStatus(resp, "failed to get object", http.StatusPartialContent); err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (s *AzureBlobStorage) StatObject(ctx context.Context, objectKey string) (ObjectInfo, error) {
+	resp, err := s.do(ctx, http.MethodHead, objectKey, nil, nil, nil)
+	if err != nil {
+		return ObjectInfo{}, fmt.Errorf("failed to stat object: %v", err)
+	}
+	if err := expectStatus(resp, "failed to stat object", http.StatusOK); err != nil {
+		return ObjectInfo{}, err
+	}
+	return azureObjectInfo(objectKey, resp.Header, resp.ContentLength), nil
+}
+
+func (s *AzureBlobStorage) WalkObjects(ctx context.Context, prefix string, fn func(ObjectInfo) error) error {
+	return s.list(ctx, prefix, "", func(result azureListResult) error {
+		for _, blob := range result.Blobs.Blobs {
+			if err := fn(blob.objectInfo()); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// CopyObject copies a blob within the container, waiting for the copy when Azure runs it asynchronously
+func (s *AzureBlobStorage) CopyObject(sourceKey, destKey string) error {
+	ctx := context.Background()
+
+	headers := http.Header{"X-Ms-Copy-Source": {s.blobURL(sourceKey)}}
+	resp, err := s.do(ctx, http.MethodPut, destKey, nil, headers, nil)
+	if err != nil {
+		return fmt.Errorf("failed to copy object: %v", err)
+	}
+	status := resp.Header.Get("X-Ms-Copy-Status")
+	if err := expectStatus(resp, "failed to copy object", http.StatusAccepted); err != nil {
+		return err
+	}
+
+	for status == "pending" {
+		time.Sleep(azureCopyPollInterval)
+
+		resp, err := s.do(ctx, http.MethodHead, destKey, nil, nil, nil)
+		if err != nil {
+			return fmt.Errorf("failed to copy object: %v", err)
+		}
+		status = resp.Header.Get("X-Ms-Copy-Status")
+		if err := expectStatus(resp, "failed to copy object", http.StatusOK); err != nil {
+			return err
+		}
+	}
+	if status != "success" {
+		return fmt.Errorf("failed to copy object: copy status %s", status)
+	}
+	return nil
+}
+
+func (s *AzureBlobStorage) MoveObject(sourceKey, destKey string) error {
+	if err := s.CopyObject(sourceKey, destKey); err != nil {
+		return err
+	}
+	return s.RemoveObject(context.Background(), sourceKey)
+}
+
+// RemoveObject deletes a blob, removing a missing blob is not an error
+func (s *AzureBlobStorage) RemoveObject(ctx context.Context, objectKey string) error {
+	resp, err := s.do(ctx, http.MethodDelete, objectKey, nil, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to remove object: %v", err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil
+	}
+	return expectStatus(resp, "failed to remove object", http.StatusAccepted)
+}
+
+// NewMultipartUpload returns an upload ID; the parts are staged as uncommitted blocks of the blob. The
+// content type travels in the upload ID as it is only set when the blocks are committed.
+func (s *AzureBlobStorage) NewMultipartUpload(ctx context.Context, objectKey, contentType string) (string, error) {
+	return uuid.New().String() + "|" + contentType, nil
+}
+
+func (s *AzureBlobStorage) PutObjectPart(ctx context.Context, objectKey, uploadID string, partNumber int, reader io.Reader, size int64) error {
+	query := url.Values{"comp": {"block"}, "blockid": {azureBlockID(uploadID, partNumber)}}
+	resp, err := s.doSized(ctx, http.MethodPut, objectKey, query, nil, reader, size)
+	if err != nil {
+		return fmt.Errorf("failed to upload part %d: %v", partNumber, err)
+	}
+	return expectStatus(resp, fmt.Sprintf("failed to upload part %d", partNumber), http.StatusCreated)
+}
+
+// CompleteMultipartUpload commits the staged blocks of the upload in part number order
+func (s *AzureBlobStorage) CompleteMultipartUpload(ctx context.Context, objectKey, uploadID string) error {
+	query := url.Values{"comp": {"blocklist"}, "blocklisttype": {"uncommitted"}}
+	resp, err := s.do(ctx, http.MethodGet, objectKey, query, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to list uploaded parts: %v", err)
+	}
+	if err := checkStatus(resp, "failed to list uploaded parts", http.StatusOK); err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var blocks struct {
+		Uncommitted []struct {
+			Name string `xml:"Name"`
+		} `xml:"UncommittedBlocks>Block"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&blocks); err != nil {
+		return fmt.Errorf("failed to list uploaded parts: %v", err)
+	}
+
+	uploadPrefix := azureUploadPrefix(uploadID)
+	var ids []string
+	for _, block := range blocks.Uncommitted {
+		if decoded, err := base64.StdEncoding.DecodeString(block.Name); err == nil && strings.HasPrefix(string(decoded), uploadPrefix) {
+			ids = append(ids, block.Name)
+		}
+	}
+	// Block IDs end with the zero-padded part number, so they sort in part order
+	sort.Slice(ids, func(i, j int) bool {
+		a, _ := base64.StdEncoding.DecodeString(ids[i])
+		b, _ := base64.StdEncoding.DecodeString(ids[j])
+		return string(a) < string(b)
+	})
+
+	var body bytes.Buffer
+	body.WriteString(`<?xml version="1.0" encoding="utf-8"?><BlockList>`)
+	for _, id := range ids {
+		body.WriteString("<Uncommitted>" + id + "</Uncommitted>")
+	}
+	body.WriteString("</BlockList>")
+
+	headers := http.Header{}
+	if _, contentType, ok := strings.Cut(uploadID, "|"); ok && contentType != "" {
+		headers.Set("X-Ms-Blob-Content-Type", contentType)
+	}
+	resp, err = s.doSized(ctx, http.MethodPut, objectKey, url.Values{"comp": {"blocklist"}}, headers, &body, int64(body.Len()))
+	if err != nil {
+		return fmt.Errorf("failed to complete multipart upload: %v", err)
+	}
+	return expectStatus(resp, "failed to complete multipart upload", http.StatusCreated)
+}
+
+// AbortMultipartUpload leaves the staged blocks to Azure, which discards uncommitted blocks after a week
+func (s *AzureBlobStorage) AbortMultipartUpload(ctx context.Context, objectKey, uploadID string) error {
+	return nil
+}
+
+// azureListResult is a page of the List Blobs operation
+type azureListResult struct {
+	Blobs struct {
+		Blobs    []azureBlob `xml:"Blob"`
+		Prefixes []struct {
+			Name string `xml:"Name"`
+		} `xml:"BlobPrefix"`
+	} `xml:"Blobs"`
+	NextMarker string `xml:"NextMarker"`
+}
+
+type azureBlob struct {
+	Name       string `xml:"Name"`
+	Properties struct {
+		LastModified  string `xml:"Last-Modified"`
+		Etag          string `xml:"Etag"`
+		ContentLength int64  `xml:"Content-Length"`
+		ContentType   string `xml:"Content-Type"`
+	} `xml:"Properties"`
+}
+
+func (b azureBlob) objectInfo() ObjectInfo {
+	lastModified, _ := time.Parse(http.TimeFormat, b.Properties.LastModified)
+	return ObjectInfo{
+		Key:          b.Name,
+		Size:         b.Properties.ContentLength,
+		ETag:         strings.Trim(b.Properties.Etag, `"`),
+		ContentType:  b.Properties.ContentType,
+		LastModified: lastModified,
+	}
+}
+
+// list calls fn for every page of blobs under the prefix. With a delimiter, blobs in subfolders are
+// returned as prefixes instead.
+func (s *AzureBlobStorage) list(ctx context.Context, prefix, delimiter string, fn func(azureListResult) error) error {
+	marker := ""
+	for {
+		query := url.Values{"restype": {"container"}, "comp": {"list"}}
+		if prefix != "" {
+			query.Set("prefix", prefix)
+		}
+		if delimiter != "" {
+			query.Set("delimiter", delimiter)
+		}
+		if marker != "" {
+			query.Set("marker", marker)
+		}
+
+		resp, err := s.do(ctx, http.MethodGet, "", query, nil, nil)
+		if err != nil {
+			return fmt.Errorf("failed to list objects: %v", err)
+		}
+		if err := checkStatus(resp, "failed to list objects", http.StatusOK); err != nil {
+			return err
+		}
+
+		var result azureListResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("failed to list objects: %v", err)
+		}
+
+		if err := fn(result); err != nil {
+			return err
+		}
+		if result.NextMarker == "" {
+			return nil
+		}
+		marker = result.NextMarker
+	}
+}
+
+func (s *AzureBlobStorage) blobURL(objectKey string) string {
+	escaped := strings.Split(objectKey, "/")
+	for i, segment := range escaped {
+		escaped[i] = url.PathEscape(segment)
+	}
+	return s.baseURL + "/" + strings.Join(escaped, "/")
+}
+
+// do sends a signed request without a body, or with a body of unknown length
+func (s *AzureBlobStorage) do(ctx context.Context, method, objectKey string, query url.Values, headers http.Header, body io.Reader) (*http.Response, error) {
+	return s.doSized(ctx, method, objectKey, query, headers, body, 0)
+}
+
+// doSized sends a request signed with the shared key. An empty key addresses the container.
+func (s *AzureBlobStorage) doSized(ctx context.Context, method, objectKey string, query url.Values, headers http.Header, body io.Reader, size int64) (*http.Response, error) {
+	target := s.baseURL
+	if objectKey != "" {
+		target = s.blobURL(objectKey)
+	}
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range headers {
+		req.Header[name] = values
+	}
+	req.ContentLength = size
+	if body == nil || size == 0 {
+		req.Body = http.NoBody
+		req.ContentLength = 0
+	}
+	req.Header.Set("X-Ms-Date", time.Now().UTC().Format(http.TimeFormat))
+	req.Header.Set("X-Ms-Version", azureAPIVersion)
+	req.Header.Set("Authorization", "SharedKey "+s.account+":"+s.sign(req))
+
+	return s.client.Do(req)
+}
+
+// sign returns the shared key signature of a request
+// (https://learn.microsoft.com/rest/api/storageservices/authorize-with-shared-key)
+func (s *AzureBlobStorage) sign(req *http.Request) string {
+	contentLength := ""
+	if req.ContentLength > 0 {
+		contentLength = strconv.FormatInt(req.ContentLength, 10)
+	}
+
+	var msHeaders []string
+	for name := range req.Header {
+		if lower := strings.ToLower(name); strings.HasPrefix(lower, "x-ms-") {
+			msHeaders = append(msHeaders, lower)
+		}
+	}
+	sort.Strings(msHeaders)
+
+	var canonical strings.Builder
+	for _, name := range []string{"Content-Encoding", "Content-Language"} {
+		canonical.WriteString(req.Header.Get(name) + "\n")
+	}
+	canonical.WriteString(contentLength + "\n")
+	for _, name := range []string{"Content-MD5", "Content-Type", "Date", "If-Modified-Since", "If-Match", "If-None-Match", "If-Unmodified-Since", "Range"} {
+		canonical.WriteString(req.Header.Get(name) + "\n")
+	}
+	for _, name := range msHeaders {
+		canonical.WriteString(name + ":" + strings.TrimSpace(req.Header.Get(name)) + "\n")
+	}
+
+	canonical.WriteString("/" + s.account + req.URL.EscapedPath())
+	query := req.URL.Query()
+	params := make([]string, 0, len(query))
+	for name := range query {
+		params = append(params, name)
+	}
+	sort.Strings(params)
+	for _, name := range params {
+		values := query[name]
+		sort.Strings(values)
+		canonical.WriteString("\n" + strings.ToLower(name) + ":" + strings.Join(values, ","))
+	}
+
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(canonical.String()))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// azureUploadPrefix is the start of the block IDs of a multipart upload
+func azureUploadPrefix(uploadID string) string {
+	id, _, _ := strings.Cut(uploadID, "|")
+	return id + "-"
+}
+
+// azureBlockID returns the ID of a part's block. Every block ID of a blob must have the same length.
+func azureBlockID(uploadID string, partNumber int) string {
+	return base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s%06d", azureUploadPrefix(uploadID), partNumber)))
+}
+
+func azureObjectInfo(objectKey string, header http.Header, size int64) ObjectInfo {
+	lastModified, _ := time.Parse(http.TimeFormat, header.Get("Last-Modified"))
+	return ObjectInfo{
+		Key:          objectKey,
+		Size:         size,
+		ETag:         strings.Trim(header.Get("ETag"), `"`),
+		ContentType:  header.Get("Content-Type"),
+		LastModified: lastModified,
+	}
+}
+
+// folderPrefix returns the key prefix of the objects in a folder
+func folderPrefix(folderPath string) string {
+	cleanPath := strings.Trim(folderPath, "/")
+	if cleanPath == "" {
+		return ""
+	}
+	return cleanPath + "/"
+}
+
+// checkStatus closes the response and returns an error unless it has the expected status. Missing
+// blobs are reported as ErrObjectNotFound.
+func checkStatus(resp *http.Response, action string, expected int) error {
+	if resp.StatusCode == expected {
+		return nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%s: %w", action, ErrObjectNotFound)
+	}
+	message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("%s: %s %s", action, resp.Status, strings.TrimSpace(string(message)))
+}
+
+// expectStatus is checkStatus for responses whose body is not needed
+func expectStatus(resp *http.Response, action string, expected int) error {
+	if err := checkStatus(resp, action, expected); err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
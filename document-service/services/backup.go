@@ -0,0 +1,751 @@
+package services
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"forgecrud-backend/shared/config"
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/jobs"
+	"forgecrud-backend/shared/scheduler"
+)
+
+// JobBackup backs up the database and the document storage, on the schedule and when started
+// through the API
+const JobBackup = "documents.backup"
+
+// Layout of BACKUP_PATH: each backup is a directory under backups/ named by its ID, and the stored
+// objects are shared by every backup under objects/, so unchanged objects are copied once
+const (
+	backupsDir           = "backups"
+	backupObjectsDir     = "objects"
+	backupTempDir        = "tmp"
+	backupManifestFile   = "manifest.json" // Written last, a backup without it is incomplete
+	backupDatabaseFile   = "database.dump.gz"
+	backupObjectListFile = "objects.jsonl.gz"
+	backupEncryptedExt   = ".enc"
+	backupIDLayout       = "20060102T150405Z"
+)
+
+var (
+	// ErrBackupRunning is returned when a backup or restore starts while another one runs
+	ErrBackupRunning = errors.New("a backup or restore is already running")
+	// ErrBackupNotFound is returned for a backup that does not exist or is incomplete
+	ErrBackupNotFound = errors.New("backup not found")
+	// ErrBackupKeyMissing is returned when reading an encrypted backup without BACKUP_ENCRYPTION_KEY
+	ErrBackupKeyMissing = errors.New("backup is encrypted and BACKUP_ENCRYPTION_KEY is not set")
+)
+
+// BackupFile is a file of a backup with the checksum of its stored, possibly encrypted, content
+type BackupFile struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// BackupManifest describes a complete backup
+type BackupManifest struct {
+	ID             string     `json:"id"`
+	StartedAt      time.Time  `json:"started_at"`
+	SnapshotAt     time.Time  `json:"snapshot_at"` // The database snapshot, the point in time a restore returns to
+	CompletedAt    time.Time  `json:"completed_at"`
+	Encrypted      bool       `json:"encrypted"`
+	Database       BackupFile `json:"database"`
+	ObjectList     BackupFile `json:"object_list"`
+	ObjectCount    int        `json:"object_count"`
+	ObjectBytes    int64      `json:"object_bytes"`
+	CopiedObjects  int        `json:"copied_objects"`            // Objects no earlier backup had stored
+	MissingObjects []string   `json:"missing_objects,omitempty"` // Listed, but deleted before they were copied
+}
+
+// BackupObject is an object of the document storage as a backup stored it
+type BackupObject struct {
+	Key          string    `json:"key"`
+	StoredAs     string    `json:"stored_as"`
+	Size         int64     `json:"size"`
+	ETag         string    `json:"etag,omitempty"`
+	ContentType  string    `json:"content_type,omitempty"`
+	LastModified time.Time `json:"last_modified"`
+}
+
+// RestoreOptions selects what a restore replaces
+type RestoreOptions struct {
+	Database     bool // Replace the rows of every table
+	Objects      bool // Put back the objects missing from storage or changed since the backup
+	PruneObjects bool // Remove the objects the backup does not have
+}
+
+// RestoreResult counts what a restore changed
+type RestoreResult struct {
+	Manifest        *BackupManifest `json:"manifest"`
+	RestoredObjects int             `json:"restored_objects"`
+	SkippedObjects  int             `json:"skipped_objects"`
+	RemovedObjects  int             `json:"removed_objects"`
+}
+
+// BackupManager backs up the database with the document storage to BACKUP_PATH and restores them.
+// The database is dumped first, from one snapshot; objects are copied after it, so a backup holds
+// every object its rows refer to, except objects deleted in the meantime, which are listed as missing.
+type BackupManager struct {
+	storage   StorageProvider
+	root      string
+	key       []byte
+	retention time.Duration
+	keepMin   int
+}
+
+// NewBackupManager creates a BackupManager with the backup configuration. The storage is only used to
+// create and restore backups.
+func NewBackupManager(storage StorageProvider) (*BackupManager, error) {
+	cfg := config.GetConfig()
+	root, err := filepath.Abs(cfg.BackupPath)
+	if err != nil {
+		return nil, fmt.Errorf("invalid backup path: %v", err)
+	}
+	for _, dir := range []string{backupsDir, backupObjectsDir, backupTempDir} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o750); err != nil {
+			return nil, fmt.Errorf("failed to create backup directory: %v", err)
+		}
+	}
+
+	m := &BackupManager{
+		storage:   storage,
+		root:      root,
+		retention: time.Duration(cfg.BackupRetentionDays) * 24 * time.Hour,
+		keepMin:   cfg.BackupKeepMin,
+	}
+	if cfg.BackupEncryptionKey != "" {
+		if m.key, err = base64.StdEncoding.DecodeString(cfg.BackupEncryptionKey); err != nil {
+			return nil, fmt.Errorf("invalid backup encryption key: %v", err)
+		}
+	}
+	return m, nil
+}
+
+// Register registers the job running a backup on request and schedules it every interval, unless the
+// interval is 0; call before jobs.Start
+func (m *BackupManager) Register(interval time.Duration) {
+	jobs.Handle(JobBackup, func(ctx context.Context, job *jobs.Job) error {
+		return m.Run(ctx)
+	})
+	if interval > 0 {
+		scheduler.Register(JobBackup, scheduler.Every(interval), m.Run)
+		log.Printf("💾 Backups scheduled every %s to %s (encrypted: %t)", interval, m.root, m.key != nil)
+	}
+}
+
+// QueueBackup queues a backup, unless one is already waiting
+func QueueBackup(ctx context.Context) error {
+	_, err := jobs.Enqueue(ctx, JobBackup, nil, jobs.WithPriority(jobs.PriorityLow), jobs.Unique(JobBackup))
+	if errors.Is(err, jobs.ErrDuplicate) {
+		return nil
+	}
+	return err
+}
+
+// Run creates a backup, then removes the backups past their retention
+func (m *BackupManager) Run(ctx context.Context) error {
+	manifest, err := m.Create(ctx)
+	if errors.Is(err, ErrBackupRunning) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	log.Printf("💾 Backup %s completed: %d objects, %d copied, %d missing",
+		manifest.ID, manifest.ObjectCount, manifest.CopiedObjects, len(manifest.MissingObjects))
+
+	removed, err := m.Prune(ctx)
+	if removed > 0 {
+		log.Printf("💾 Removed %d backups past their retention", removed)
+	}
+	return err
+}
+
+// Create backs up the database and the document storage
+func (m *BackupManager) Create(ctx context.Context) (*BackupManifest, error) {
+	release, err := acquireBackupLock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	started := time.Now().UTC()
+	manifest := &BackupManifest{ID: started.Format(backupIDLayout), StartedAt: started, Encrypted: m.key != nil}
+	dir := filepath.Join(m.root, backupsDir, manifest.ID)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %v", err)
+	}
+
+	manifest.Database, err = m.writeFile(dir, backupDatabaseFile, func(w io.Writer) error {
+		snapshotAt, err := database.DumpData(ctx, w)
+		manifest.SnapshotAt = snapshotAt
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to dump the database: %v", err)
+	}
+
+	var objects []BackupObject
+	err = m.storage.WalkObjects(ctx, "", func(info ObjectInfo) error {
+		objects = append(objects, BackupObject{
+			Key:          info.Key,
+			StoredAs:     m.storedName(info),
+			Size:         info.Size,
+			ETag:         info.ETag,
+			ContentType:  info.ContentType,
+			LastModified: info.LastModified,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the stored objects: %v", err)
+	}
+
+	var stored []BackupObject
+	for _, object := range objects {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		copied, err := m.copyObject(ctx, object)
+		if errors.Is(err, ErrObjectNotFound) {
+			manifest.MissingObjects = append(manifest.MissingObjects, object.Key)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to back up %s: %v", object.Key, err)
+		}
+		if copied {
+			manifest.CopiedObjects++
+		}
+		manifest.ObjectCount++
+		manifest.ObjectBytes += object.Size
+		stored = append(stored, object)
+	}
+
+	manifest.ObjectList, err = m.writeFile(dir, backupObjectListFile, func(w io.Writer) error {
+		encoder := json.NewEncoder(w)
+		for _, object := range stored {
+			if err := encoder.Encode(object); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to write the object list: %v", err)
+	}
+
+	manifest.CompletedAt = time.Now().UTC()
+	if err := m.writeManifest(dir, manifest); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// List returns the complete backups, the most recent snapshot first
+func (m *BackupManager) List() ([]BackupManifest, error) {
+	entries, err := os.ReadDir(filepath.Join(m.root, backupsDir))
+	if err != nil {
+		return nil, err
+	}
+	manifests := []BackupManifest{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		manifest, err := m.Get(entry.Name())
+		if errors.Is(err, ErrBackupNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		manifests = append(manifests, *manifest)
+	}
+	sort.Slice(manifests, func(i, j int) bool { return manifests[i].SnapshotAt.After(manifests[j].SnapshotAt) })
+	return manifests, nil
+}
+
+// Get returns the manifest of a complete backup
+func (m *BackupManager) Get(id string) (*BackupManifest, error) {
+	if _, err := time.Parse(backupIDLayout, id); err != nil {
+		return nil, ErrBackupNotFound
+	}
+	data, err := os.ReadFile(filepath.Join(m.root, backupsDir, id, backupManifestFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrBackupNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var manifest BackupManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid manifest of backup %s: %v", id, err)
+	}
+	return &manifest, nil
+}
+
+// Latest returns the backup with the most recent snapshot taken at or before the time
+func (m *BackupManager) Latest(at time.Time) (*BackupManifest, error) {
+	manifests, err := m.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, manifest := range manifests {
+		if !manifest.SnapshotAt.After(at) {
+			return &manifest, nil
+		}
+	}
+	return nil, ErrBackupNotFound
+}
+
+// Delete removes a backup and the stored objects no other backup uses
+func (m *BackupManager) Delete(ctx context.Context, id string) error {
+	release, err := acquireBackupLock(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	if _, err := m.Get(id); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(filepath.Join(m.root, backupsDir, id)); err != nil {
+		return err
+	}
+	return m.collectGarbage()
+}
+
+// Prune removes the backups past the retention, always keeping the newest BACKUP_KEEP_MIN, and the
+// incomplete ones, then the stored objects no remaining backup uses. It returns the number of removed
+// backups.
+func (m *BackupManager) Prune(ctx context.Context) (int, error) {
+	release, err := acquireBackupLock(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+
+	manifests, err := m.List()
+	if err != nil {
+		return 0, err
+	}
+	keep := map[string]bool{}
+	removed := 0
+	for i, manifest := range manifests {
+		if i < m.keepMin || m.retention == 0 || time.Since(manifest.SnapshotAt) < m.retention {
+			keep[manifest.ID] = true
+			continue
+		}
+		removed++
+	}
+
+	// Backups left incomplete by a crash are removed too, no backup runs while the lock is held
+	entries, err := os.ReadDir(filepath.Join(m.root, backupsDir))
+	if err != nil {
+		return 0, err
+	}
+	for _, entry := range entries {
+		if !keep[entry.Name()] {
+			if err := os.RemoveAll(filepath.Join(m.root, backupsDir, entry.Name())); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return removed, m.collectGarbage()
+}
+
+// Restore puts the database and the document storage back to the state of a backup. Services should
+// be stopped meanwhile, the rows and objects they cache or write would not match. Objects are
+// restored before the rows, whose restore is one transaction.
+func (m *BackupManager) Restore(ctx context.Context, id string, options RestoreOptions) (*RestoreResult, error) {
+	release, err := acquireBackupLock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	manifest, err := m.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if manifest.Encrypted && m.key == nil {
+		return nil, ErrBackupKeyMissing
+	}
+	dir := filepath.Join(m.root, backupsDir, id)
+	for _, file := range []BackupFile{manifest.Database, manifest.ObjectList} {
+		if err := verifyBackupFile(dir, file); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &RestoreResult{Manifest: manifest}
+	if options.Objects || options.PruneObjects {
+		keys := map[string]bool{}
+		err := m.readFile(dir, manifest.ObjectList.Name, func(r io.Reader) error {
+			decoder := json.NewDecoder(r)
+			for {
+				var object BackupObject
+				if err := decoder.Decode(&object); errors.Is(err, io.EOF) {
+					return nil
+				} else if err != nil {
+					return err
+				}
+				keys[object.Key] = true
+				if !options.Objects {
+					continue
+				}
+				restored, err := m.restoreObject(ctx, object)
+				if err != nil {
+					return fmt.Errorf("failed to restore %s: %v", object.Key, err)
+				}
+				if restored {
+					result.RestoredObjects++
+				} else {
+					result.SkippedObjects++
+				}
+			}
+		})
+		if err != nil {
+			return result, err
+		}
+
+		if options.PruneObjects {
+			var extra []string
+			if err := m.storage.WalkObjects(ctx, "", func(info ObjectInfo) error {
+				if !keys[info.Key] {
+					extra = append(extra, info.Key)
+				}
+				return nil
+			}); err != nil {
+				return result, err
+			}
+			for _, key := range extra {
+				if err := m.storage.RemoveObject(ctx, key); err != nil {
+					return result, fmt.Errorf("failed to remove %s: %v", key, err)
+				}
+				result.RemovedObjects++
+			}
+		}
+	}
+
+	if options.Database {
+		err := m.readFile(dir, manifest.Database.Name, func(r io.Reader) error {
+			_, err := database.RestoreData(ctx, r)
+			return err
+		})
+		if err != nil {
+			return result, fmt.Errorf("failed to restore the database: %v", err)
+		}
+	}
+	return result, nil
+}
+
+// storedName names the copy of an object after its key and version, and the encryption key, so a
+// changed object or a new key gets a new copy
+func (m *BackupManager) storedName(info ObjectInfo) string {
+	hash := sha256.New()
+	for _, part := range []string{info.Key, strconv.FormatInt(info.Size, 10), info.ETag, strconv.FormatInt(info.LastModified.UnixNano(), 10)} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	if m.key != nil {
+		hash.Write(m.key)
+	}
+	name := hex.EncodeToString(hash.Sum(nil))
+	if m.key != nil {
+		name += backupEncryptedExt
+	}
+	return name
+}
+
+// objectPath returns the path of a stored object, spread over subdirectories by its first characters
+func (m *BackupManager) objectPath(storedAs string) string {
+	return filepath.Join(m.root, backupObjectsDir, storedAs[:2], storedAs)
+}
+
+// copyObject stores a copy of the object unless an earlier backup did, and reports whether it copied
+func (m *BackupManager) copyObject(ctx context.Context, object BackupObject) (bool, error) {
+	path := m.objectPath(object.StoredAs)
+	if _, err := os.Stat(path); err == nil {
+		return false, nil
+	}
+
+	reader, _, err := m.storage.GetObject(ctx, object.Key)
+	if err != nil {
+		return false, err
+	}
+	defer reader.Close()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return false, err
+	}
+	err = m.writeTemp(path, func(w io.Writer) error {
+		target, closeTarget, err := m.encrypt(w)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(target, reader); err != nil {
+			return err
+		}
+		return closeTarget()
+	})
+	return err == nil, err
+}
+
+// restoreObject puts an object back unless storage has it with the same size and ETag, and reports
+// whether it did
+func (m *BackupManager) restoreObject(ctx context.Context, object BackupObject) (bool, error) {
+	info, err := m.storage.StatObject(ctx, object.Key)
+	if err == nil && info.Size == object.Size && (info.ETag == "" || object.ETag == "" || info.ETag == object.ETag) {
+		return false, nil
+	}
+	if err != nil && !errors.Is(err, ErrObjectNotFound) {
+		return false, err
+	}
+
+	file, err := os.Open(m.objectPath(object.StoredAs))
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	var reader io.Reader = file
+	if filepath.Ext(object.StoredAs) == backupEncryptedExt {
+		if m.key == nil {
+			return false, ErrBackupKeyMissing
+		}
+		if reader, err = newBackupDecrypter(file, m.key); err != nil {
+			return false, err
+		}
+	}
+	if err := m.storage.PutObject(ctx, object.Key, reader, object.Size, object.ContentType); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// writeFile writes a gzipped, and encrypted when a key is set, file of a backup and returns its size
+// and checksum
+func (m *BackupManager) writeFile(dir, name string, fn func(w io.Writer) error) (BackupFile, error) {
+	file := BackupFile{Name: name}
+	if m.key != nil {
+		file.Name += backupEncryptedExt
+	}
+
+	checksum := sha256.New()
+	counter := &countingWriter{}
+	err := m.writeTemp(filepath.Join(dir, file.Name), func(w io.Writer) error {
+		target, closeTarget, err := m.encrypt(io.MultiWriter(w, checksum, counter))
+		if err != nil {
+			return err
+		}
+		gzipWriter := gzip.NewWriter(target)
+		if err := fn(gzipWriter); err != nil {
+			return err
+		}
+		if err := gzipWriter.Close(); err != nil {
+			return err
+		}
+		return closeTarget()
+	})
+	if err != nil {
+		return file, err
+	}
+	file.Size = counter.n
+	file.SHA256 = hex.EncodeToString(checksum.Sum(nil))
+	return file, nil
+}
+
+// readFile passes the decrypted and decompressed content of a backup file to fn
+func (m *BackupManager) readFile(dir, name string, fn func(r io.Reader) error) error {
+	file, err := os.Open(filepath.Join(dir, name))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var reader io.Reader = bufio.NewReaderSize(file, 1<<20)
+	if filepath.Ext(name) == backupEncryptedExt {
+		if m.key == nil {
+			return ErrBackupKeyMissing
+		}
+		if reader, err = newBackupDecrypter(reader, m.key); err != nil {
+			return err
+		}
+	}
+	gzipReader, err := gzip.NewReader(reader)
+	if err != nil {
+		return err
+	}
+	defer gzipReader.Close()
+	return fn(gzipReader)
+}
+
+// encrypt wraps the writer in an encrypter when a key is set; the returned function finishes it
+func (m *BackupManager) encrypt(w io.Writer) (io.Writer, func() error, error) {
+	if m.key == nil {
+		return w, func() error { return nil }, nil
+	}
+	encrypter, err := newBackupEncrypter(w, m.key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return encrypter, encrypter.Close, nil
+}
+
+// writeTemp writes a file through a temporary one, so it only appears complete
+func (m *BackupManager) writeTemp(path string, fn func(w io.Writer) error) error {
+	temp, err := os.CreateTemp(filepath.Join(m.root, backupTempDir), "backup-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+
+	buffered := bufio.NewWriterSize(temp, 1<<20)
+	err = fn(buffered)
+	if err == nil {
+		err = buffered.Flush()
+	}
+	if err == nil {
+		err = temp.Sync()
+	}
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), path)
+}
+
+func (m *BackupManager) writeManifest(dir string, manifest *BackupManifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return m.writeTemp(filepath.Join(dir, backupManifestFile), func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// collectGarbage removes the stored objects and temporary files no complete backup uses; call with
+// the lock held
+func (m *BackupManager) collectGarbage() error {
+	manifests, err := m.List()
+	if err != nil {
+		return err
+	}
+	used := map[string]bool{}
+	for _, manifest := range manifests {
+		err := m.readFile(filepath.Join(m.root, backupsDir, manifest.ID), manifest.ObjectList.Name, func(r io.Reader) error {
+			decoder := json.NewDecoder(r)
+			for {
+				var object BackupObject
+				if err := decoder.Decode(&object); errors.Is(err, io.EOF) {
+					return nil
+				} else if err != nil {
+					return err
+				}
+				used[object.StoredAs] = true
+			}
+		})
+		if err != nil {
+			// Without the object list of every backup, no object is known to be unused
+			return fmt.Errorf("failed to read the object list of backup %s: %v", manifest.ID, err)
+		}
+	}
+
+	if err := filepath.WalkDir(filepath.Join(m.root, backupObjectsDir), func(path string, entry os.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || used[entry.Name()] {
+			return err
+		}
+		return os.Remove(path)
+	}); err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(filepath.Join(m.root, backupTempDir))
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		os.Remove(filepath.Join(m.root, backupTempDir, entry.Name()))
+	}
+	return nil
+}
+
+// verifyBackupFile checks the checksum of a backup file before it is restored
+func verifyBackupFile(dir string, expected BackupFile) error {
+	file, err := os.Open(filepath.Join(dir, expected.Name))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	checksum := sha256.New()
+	if _, err := io.Copy(checksum, file); err != nil {
+		return err
+	}
+	if actual := hex.EncodeToString(checksum.Sum(nil)); actual != expected.SHA256 {
+		return fmt.Errorf("backup file %s is corrupt: checksum %s, expected %s", expected.Name, actual, expected.SHA256)
+	}
+	return nil
+}
+
+// acquireBackupLock takes the session-level advisory lock held during backups and restores, on a
+// connection of its own, so only one runs across instances and tools
+func acquireBackupLock(ctx context.Context) (func(), error) {
+	sqlDB, err := database.DB.DB()
+	if err != nil {
+		return nil, err
+	}
+	hash := fnv.New64a()
+	hash.Write([]byte(JobBackup))
+	key := int64(hash.Sum64())
+
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&acquired); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if !acquired {
+		conn.Close()
+		return nil, ErrBackupRunning
+	}
+	return func() {
+		conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", key)
+		conn.Close()
+	}, nil
+}
+
+// countingWriter counts the bytes written to it
+type countingWriter struct {
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	c.n += int64(len(p))
+	return len(p), nil
+}
@@ -0,0 +1,170 @@
+package services
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Encrypted backup files start with backupCipherMagic and a random nonce prefix, followed by chunks
+// of at most backupChunkSize bytes, each sealed with AES-256-GCM and preceded by its sealed length.
+// A chunk's nonce is the prefix and its index, and the last chunk is sealed with a different
+// additional data byte, so reordered, dropped or truncated chunks fail to decrypt.
+const (
+	backupCipherMagic = "FCBE1"
+	backupChunkSize   = 64 * 1024
+	backupNonceSize   = 12
+	backupPrefixSize  = backupNonceSize - 4
+)
+
+var (
+	backupChunkMore = []byte{0}
+	backupChunkLast = []byte{1}
+)
+
+// ErrBackupDecrypt is returned for encrypted backup files that are corrupt, truncated or encrypted
+// with another key
+var ErrBackupDecrypt = errors.New("backup file is corrupt or encrypted with another key")
+
+func newBackupCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// backupEncrypter encrypts what is written to it; Close seals the last chunk
+type backupEncrypter struct {
+	w       io.Writer
+	aead    cipher.AEAD
+	prefix  []byte
+	counter uint32
+	buffer  []byte
+}
+
+func newBackupEncrypter(w io.Writer, key []byte) (*backupEncrypter, error) {
+	aead, err := newBackupCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	prefix := make([]byte, backupPrefixSize)
+	if _, err := rand.Read(prefix); err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(append([]byte(backupCipherMagic), prefix...)); err != nil {
+		return nil, err
+	}
+	return &backupEncrypter{w: w, aead: aead, prefix: prefix, buffer: make([]byte, 0, backupChunkSize)}, nil
+}
+
+func (e *backupEncrypter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		n := copy(e.buffer[len(e.buffer):cap(e.buffer)], p)
+		e.buffer = e.buffer[:len(e.buffer)+n]
+		p = p[n:]
+		written += n
+		// A full chunk is only sealed once more data follows, the last one is sealed by Close
+		if len(e.buffer) == cap(e.buffer) && len(p) > 0 {
+			if err := e.seal(backupChunkMore); err != nil {
+				return written, err
+			}
+		}
+	}
+	return written, nil
+}
+
+func (e *backupEncrypter) Close() error {
+	return e.seal(backupChunkLast)
+}
+
+func (e *backupEncrypter) seal(additionalData []byte) error {
+	sealed := e.aead.Seal(nil, backupNonce(e.prefix, e.counter), e.buffer, additionalData)
+	e.counter++
+	e.buffer = e.buffer[:0]
+
+	var length [4]byte
+	binary.BigEndian.PutUint32(length[:], uint32(len(sealed)))
+	if _, err := e.w.Write(length[:]); err != nil {
+		return err
+	}
+	_, err := e.w.Write(sealed)
+	return err
+}
+
+// backupDecrypter decrypts a file written by backupEncrypter
+type backupDecrypter struct {
+	r       *bufio.Reader
+	aead    cipher.AEAD
+	prefix  []byte
+	counter uint32
+	plain   []byte
+	last    bool
+}
+
+func newBackupDecrypter(r io.Reader, key []byte) (*backupDecrypter, error) {
+	aead, err := newBackupCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	reader := bufio.NewReader(r)
+	header := make([]byte, len(backupCipherMagic)+backupPrefixSize)
+	if _, err := io.ReadFull(reader, header); err != nil || string(header[:len(backupCipherMagic)]) != backupCipherMagic {
+		return nil, fmt.Errorf("%w: missing header", ErrBackupDecrypt)
+	}
+	return &backupDecrypter{r: reader, aead: aead, prefix: header[len(backupCipherMagic):]}, nil
+}
+
+func (d *backupDecrypter) Read(p []byte) (int, error) {
+	for len(d.plain) == 0 {
+		if d.last {
+			return 0, io.EOF
+		}
+		if err := d.open(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, d.plain)
+	d.plain = d.plain[n:]
+	return n, nil
+}
+
+func (d *backupDecrypter) open() error {
+	var length [4]byte
+	if _, err := io.ReadFull(d.r, length[:]); err != nil {
+		return fmt.Errorf("%w: truncated", ErrBackupDecrypt)
+	}
+	size := binary.BigEndian.Uint32(length[:])
+	if size > backupChunkSize+uint32(d.aead.Overhead()) {
+		return fmt.Errorf("%w: chunk too large", ErrBackupDecrypt)
+	}
+	sealed := make([]byte, size)
+	if _, err := io.ReadFull(d.r, sealed); err != nil {
+		return fmt.Errorf("%w: truncated", ErrBackupDecrypt)
+	}
+
+	nonce := backupNonce(d.prefix, d.counter)
+	d.counter++
+	plain, err := d.aead.Open(nil, nonce, sealed, backupChunkMore)
+	if err != nil {
+		if plain, err = d.aead.Open(nil, nonce, sealed, backupChunkLast); err != nil {
+			return ErrBackupDecrypt
+		}
+		d.last = true
+	}
+	d.plain = plain
+	return nil
+}
+
+func backupNonce(prefix []byte, counter uint32) []byte {
+	nonce := make([]byte, backupNonceSize)
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[backupPrefixSize:], counter)
+	return nonce
+}
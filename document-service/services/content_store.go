@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"forgecrud-backend/shared/database/models/document"
+	docUtils "forgecrud-backend/shared/utils/document"
+
+	"gorm.io/gorm"
+)
+
+// ErrContentNotFound is returned when content is referenced without a file to store it from
+var ErrContentNotFound = errors.New("content not found in the content store")
+
+// AddContentReference references the file with the given SHA-256 from a new document version. Content
+// that is stored already is shared as is, otherwise the file at sourceKey is copied into the content
+// store. It runs in the caller's transaction, which holds the lock on the content until it commits,
+// and the caller removes sourceKey once the transaction committed.
+func AddContentReference(tx *gorm.DB, storage StorageProvider, contentHash, sourceKey string, size int64) error {
+	if err := lockContent(tx, contentHash); err != nil {
+		return err
+	}
+
+	var object document.ContentObject
+	err := tx.Where("content_hash = ?", contentHash).Take(&object).Error
+	if err == nil {
+		return tx.Model(&object).Update("ref_count", gorm.Expr("ref_count + 1")).Error
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if sourceKey == "" {
+		return ErrContentNotFound
+	}
+
+	object = document.ContentObject{
+		ContentHash: contentHash,
+		ObjectKey:   docUtils.ContentKey(contentHash),
+		FileSize:    size,
+		RefCount:    1,
+	}
+	if err := storage.CopyObject(sourceKey, object.ObjectKey); err != nil {
+		return err
+	}
+	return tx.Create(&object).Error
+}
+
+// ReleaseContent drops a reference to stored content and removes the file with its last reference.
+// The file is removed while the content is locked, so a concurrent upload of the same content stores
+// it again instead of referencing the removed file. A storage failure leaves an orphaned object behind.
+func ReleaseContent(db *gorm.DB, storage StorageProvider, contentHash string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := lockContent(tx, contentHash); err != nil {
+			return err
+		}
+
+		var object document.ContentObject
+		if err := tx.Where("content_hash = ?", contentHash).Take(&object).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+
+		if object.RefCount > 1 {
+			return tx.Model(&object).Update("ref_count", gorm.Expr("ref_count - 1")).Error
+		}
+
+		if err := tx.Delete(&object).Error; err != nil {
+			return err
+		}
+		if err := storage.RemoveObject(context.Background(), object.ObjectKey); err != nil {
+			log.Printf("⚠️  Failed to remove unreferenced content %s: %v", object.ObjectKey, err)
+		}
+		return nil
+	})
+}
+
+// lockContent serializes changes to the references of one content hash until the transaction ends
+func lockContent(tx *gorm.DB, contentHash string) error {
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "content:"+contentHash).Error; err != nil {
+		return fmt.Errorf("failed to lock content %s: %v", contentHash, err)
+	}
+	return nil
+}
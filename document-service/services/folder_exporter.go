@@ -0,0 +1,344 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models/document"
+	"forgecrud-backend/shared/jobs"
+	"forgecrud-backend/shared/readonly"
+	docUtils "forgecrud-backend/shared/utils/document"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// FolderExportPrefix holds the archives of folder exports in storage
+const FolderExportPrefix = "exports/"
+
+const (
+	folderExportRequeueBatch = 20
+	folderExportDocumentPage = 500
+	// A running export not finished after this long was interrupted and is started again
+	folderExportStaleAfter = 2 * time.Hour
+	// A pending export still waiting after this long lost its job, e.g. because the queue was unreachable
+	folderExportLostAfter = 30 * time.Minute
+)
+
+// JobExportFolder builds the archive of a folder export
+const JobExportFolder = "documents.folder_export"
+
+type folderExportJob struct {
+	ExportID uuid.UUID `json:"export_id"`
+}
+
+// FolderExporter builds the ZIP archives of folders too large to be zipped while downloading and
+// removes them once they expired
+type FolderExporter struct {
+	storage   StorageProvider
+	retention time.Duration
+}
+
+func NewFolderExporter(storage StorageProvider, retention time.Duration) *FolderExporter {
+	return &FolderExporter{storage: storage, retention: retention}
+}
+
+// RegisterJobs registers the handler building the archives; call before jobs.Start
+func (e *FolderExporter) RegisterJobs() {
+	jobs.Handle(JobExportFolder, e.runExportJob)
+}
+
+// QueueFolderExport queues building the archive of a pending export
+func QueueFolderExport(ctx context.Context, exportID uuid.UUID) error {
+	_, err := jobs.Enqueue(ctx, JobExportFolder, folderExportJob{ExportID: exportID},
+		jobs.WithPriority(jobs.PriorityLow), jobs.Unique("folder-export:"+exportID.String()))
+	if errors.Is(err, jobs.ErrDuplicate) {
+		return nil
+	}
+	return err
+}
+
+// Start queues lost exports again and removes expired ones in the background
+func (e *FolderExporter) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if readonly.Active() {
+				continue
+			}
+			if err := e.RequeueLost(); err != nil {
+				log.Printf("⚠️  Requeueing lost folder exports failed: %v", err)
+			}
+			if err := e.RemoveExpired(); err != nil {
+				log.Printf("⚠️  Expired folder export cleanup failed: %v", err)
+			}
+		}
+	}()
+
+	log.Printf("📦 Folder exporter started (interval: %s)", interval)
+}
+
+// RequeueLost queues the exports that waited or ran far longer than their job would take. An export
+// queued twice is built once, the job claiming it first wins.
+func (e *FolderExporter) RequeueLost() error {
+	now := time.Now()
+	var exportIDs []uuid.UUID
+	if err := database.DB.Model(&document.FolderExport{}).
+		Where("(status = ? AND created_at < ?) OR (status = ? AND started_at < ?)",
+			document.FolderExportPending, now.Add(-folderExportLostAfter),
+			document.FolderExportRunning, now.Add(-folderExportStaleAfter)).
+		Order("created_at").
+		Limit(folderExportRequeueBatch).
+		Pluck("id", &exportIDs).Error; err != nil {
+		return err
+	}
+
+	for _, exportID := range exportIDs {
+		if err := QueueFolderExport(context.Background(), exportID); err != nil {
+			return err
+		}
+	}
+	if len(exportIDs) > 0 {
+		log.Printf("📦 Requeued %d lost folder exports", len(exportIDs))
+	}
+	return nil
+}
+
+// runExportJob builds the archive of the export unless another job claimed it
+func (e *FolderExporter) runExportJob(ctx context.Context, job *jobs.Job) error {
+	var payload folderExportJob
+	if err := job.Decode(&payload); err != nil {
+		return fmt.Errorf("invalid payload: %v: %w", err, jobs.ErrSkipRetry)
+	}
+
+	export, err := claimFolderExport(payload.ExportID)
+	if err != nil || export == nil {
+		return err
+	}
+
+	if err := e.export(export); err != nil {
+		// Further attempts start over from pending, the last one leaves the export failed
+		updates := map[string]interface{}{"status": document.FolderExportPending, "error": err.Error()}
+		if job.Attempts >= job.MaxAttempts {
+			updates["status"] = document.FolderExportFailed
+			updates["completed_at"] = time.Now()
+		}
+		if updateErr := database.DB.Model(export).Updates(updates).Error; updateErr != nil {
+			log.Printf("⚠️  Failed to record the failure of folder export %s: %v", export.ID, updateErr)
+		}
+		return fmt.Errorf("failed to export folder %s: %v", export.FolderID, err)
+	}
+	return nil
+}
+
+// claimFolderExport marks a pending or stale export as running and returns it, or nil when it is
+// done, gone or running elsewhere
+func claimFolderExport(exportID uuid.UUID) (*document.FolderExport, error) {
+	now := time.Now()
+	claim := database.DB.Model(&document.FolderExport{}).
+		Where("id = ? AND (status = ? OR (status = ? AND started_at < ?))", exportID,
+			document.FolderExportPending, document.FolderExportRunning, now.Add(-folderExportStaleAfter)).
+		Updates(map[string]interface{}{"status": document.FolderExportRunning, "started_at": now})
+	if claim.Error != nil {
+		return nil, claim.Error
+	}
+	if claim.RowsAffected == 0 {
+		return nil, nil
+	}
+
+	var export document.FolderExport
+	if err := database.DB.First(&export, "id = ?", exportID).Error; err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+// RemoveExpired removes the archives of exports past their expiry
+func (e *FolderExporter) RemoveExpired() error {
+	var exports []document.FolderExport
+	if err := database.DB.
+		Where("status = ? AND expires_at < ?", document.FolderExportCompleted, time.Now()).
+		Limit(100).
+		Find(&exports).Error; err != nil {
+		return err
+	}
+
+	for _, export := range exports {
+		if err := e.storage.RemoveObject(context.Background(), export.ObjectKey); err != nil {
+			log.Printf("⚠️  Failed to remove expired folder export %s: %v", export.ID, err)
+			continue
+		}
+		if err := database.DB.Model(&export).Update("status", document.FolderExportExpired).Error; err != nil {
+			return err
+		}
+	}
+
+	if len(exports) > 0 {
+		log.Printf("📦 Removed %d expired folder exports", len(exports))
+	}
+	return nil
+}
+
+// export builds the archive in a temporary file and stores it
+func (e *FolderExporter) export(export *document.FolderExport) error {
+	var folder document.Folder
+	if err := database.DB.First(&folder, export.FolderID).Error; err != nil {
+		return fmt.Errorf("folder no longer exists")
+	}
+
+	folderIDs, err := FolderTreeIDs(database.DB, folder.ID)
+	if err != nil {
+		return err
+	}
+
+	tempFile, err := os.CreateTemp("", "folder-export-*.zip")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %v", err)
+	}
+	defer os.Remove(tempFile.Name())
+	defer tempFile.Close()
+
+	zipWriter := zip.NewWriter(tempFile)
+	fileCount, skipped := 0, 0
+	totalSize := int64(0)
+
+	documentQuery := database.DB.Preload("Folder").Where("folder_id IN ?", folderIDs)
+	if export.AccessChecked {
+		documentQuery = documentQuery.Where(database.DocumentAccessCondition("documents", export.RequestedBy, document.AccessLevelRead))
+	}
+
+	var batch []document.Document
+	result := documentQuery.FindInBatches(&batch, folderExportDocumentPage, func(tx *gorm.DB, _ int) error {
+		for i := range batch {
+			if err := AddDocumentToZip(zipWriter, e.storage, &batch[i], folder.Path); err != nil {
+				log.Printf("⚠️  Folder export %s skipped %s: %v", export.ID, batch[i].OriginalName, err)
+				skipped++
+				continue
+			}
+			fileCount++
+			totalSize += batch[i].FileSize
+		}
+		return nil
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if err := zipWriter.Close(); err != nil {
+		return fmt.Errorf("failed to finish archive: %v", err)
+	}
+	archiveSize, err := tempFile.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err := tempFile.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	objectKey := FolderExportPrefix + export.ID.String() + ".zip"
+	if err := e.storage.PutObject(context.Background(), objectKey, tempFile, archiveSize, "application/zip"); err != nil {
+		return fmt.Errorf("failed to store archive: %v", err)
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(e.retention)
+	if err := database.DB.Model(export).Updates(map[string]interface{}{
+		"status":        document.FolderExportCompleted,
+		"error":         "",
+		"file_count":    fileCount,
+		"skipped_files": skipped,
+		"total_size":    totalSize,
+		"archive_size":  archiveSize,
+		"object_key":    objectKey,
+		"completed_at":  now,
+		"expires_at":    expiresAt,
+	}).Error; err != nil {
+		return err
+	}
+
+	log.Printf("📦 Folder '%s' exported: %d files, %.2f MB", folder.Name, fileCount, float64(totalSize)/(1024*1024))
+	return nil
+}
+
+// FolderTreeIDs returns the IDs of the folder and all its subfolders
+func FolderTreeIDs(db *gorm.DB, folderID uuid.UUID) ([]uuid.UUID, error) {
+	ids := []uuid.UUID{folderID}
+	level := []uuid.UUID{folderID}
+	for len(level) > 0 {
+		var children []uuid.UUID
+		if err := db.Model(&document.Folder{}).Where("parent_id IN ?", level).Pluck("id", &children).Error; err != nil {
+			return nil, err
+		}
+		ids = append(ids, children...)
+		level = children
+	}
+	return ids, nil
+}
+
+// AddDocumentToZip adds a document to the ZIP archive with proper folder structure. The document's
+// folder must be loaded.
+func AddDocumentToZip(zipWriter *zip.Writer, storage StorageProvider, doc *document.Document, baseFolderPath string) error {
+	if !document.ScanStatusAllowsDownload(doc.ScanStatus) {
+		return fmt.Errorf("blocked by malware scan (%s)", doc.ScanStatus)
+	}
+
+	// Download file from MinIO
+	fileReader, _, err := storage.GetObject(context.Background(), docUtils.StorageKey(doc.ObjectKey, doc.ContentHash))
+	if err != nil {
+		return fmt.Errorf("failed to download file from storage: %v", err)
+	}
+	defer fileReader.Close()
+
+	// Calculate relative path for ZIP (preserve folder structure)
+	relativePath := calculateRelativePath(doc.Folder.Path, baseFolderPath, doc.OriginalName)
+
+	// Create file entry in ZIP
+	zipFileHeader := &zip.FileHeader{
+		Name:   relativePath,
+		Method: zip.Deflate,
+	}
+
+	// Set modification time if available
+	if !doc.CreatedAt.IsZero() {
+		zipFileHeader.Modified = doc.CreatedAt
+	}
+
+	zipFile, err := zipWriter.CreateHeader(zipFileHeader)
+	if err != nil {
+		return fmt.Errorf("failed to create ZIP entry: %v", err)
+	}
+
+	// Copy file content to ZIP
+	_, err = io.Copy(zipFile, fileReader)
+	if err != nil {
+		return fmt.Errorf("failed to write file to ZIP: %v", err)
+	}
+
+	return nil
+}
+
+// calculateRelativePath calculates the relative path for a file in the ZIP
+func calculateRelativePath(documentFolderPath, baseFolderPath, fileName string) string {
+	// Remove base folder path from document folder path
+	relativeFolderPath := strings.TrimPrefix(documentFolderPath, baseFolderPath)
+	relativeFolderPath = strings.TrimPrefix(relativeFolderPath, "/")
+
+	// If document is in a subfolder, include the subfolder path
+	if relativeFolderPath != "" {
+		return filepath.Join(relativeFolderPath, fileName)
+	}
+
+	// Document is directly in the base folder
+	return fileName
+}
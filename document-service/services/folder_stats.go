@@ -0,0 +1,196 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models/document"
+	"forgecrud-backend/shared/jobs"
+	"forgecrud-backend/shared/readonly"
+	"forgecrud-backend/shared/scheduler"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// maxFolderDepth stops rolling up stats through a broken, cyclic parent chain
+const maxFolderDepth = 256
+
+// UpdateFolderStats recounts the documents directly in a folder and rolls its stats up the ancestor
+// chain: the stats of a folder are the stats of its own documents plus those of its subfolders. Only
+// the folder's documents and the subfolders of each ancestor are read, never a whole subtree.
+func UpdateFolderStats(db *gorm.DB, folderID uuid.UUID) error {
+	var folder document.Folder
+	if err := db.Select("id", "parent_id").First(&folder, folderID).Error; err != nil {
+		return err
+	}
+
+	if err := db.Exec(`UPDATE folders SET
+		direct_file_count = (SELECT COUNT(*) FROM documents WHERE folder_id = folders.id AND deleted_at IS NULL),
+		direct_size = (SELECT COALESCE(SUM(file_size), 0) FROM documents WHERE folder_id = folders.id AND deleted_at IS NULL)
+		WHERE id = ?`, folder.ID).Error; err != nil {
+		return err
+	}
+
+	return rollUpFolderStats(db, folder)
+}
+
+// rollUpFolderStats recalculates the stats of a folder and of its ancestors from their own documents
+// and the stats of their subfolders, nearest first. Each folder is recalculated in one statement, so
+// concurrent roll-ups through a common ancestor both see each other's subfolder.
+func rollUpFolderStats(db *gorm.DB, folder document.Folder) error {
+	for depth := 0; depth < maxFolderDepth; depth++ {
+		if err := db.Exec(`UPDATE folders SET
+			file_count = direct_file_count + COALESCE((SELECT SUM(file_count) FROM folders AS subfolders WHERE subfolders.parent_id = folders.id AND subfolders.deleted_at IS NULL), 0),
+			total_size = direct_size + COALESCE((SELECT SUM(total_size) FROM folders AS subfolders WHERE subfolders.parent_id = folders.id AND subfolders.deleted_at IS NULL), 0),
+			stats_updated_at = ?
+			WHERE id = ?`, time.Now(), folder.ID).Error; err != nil {
+			return err
+		}
+
+		if folder.ParentID == nil {
+			return nil
+		}
+		// Folders in the trash keep their stats but no longer count for their parent
+		parentID := *folder.ParentID
+		folder = document.Folder{}
+		if err := db.Select("id", "parent_id").First(&folder, parentID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+	}
+	return fmt.Errorf("folder %s is nested deeper than %d folders", folder.ID, maxFolderDepth)
+}
+
+// FolderStatsRollup is the outcome of recalculating the stats of every folder
+type FolderStatsRollup struct {
+	DirectCorrected int64 `json:"direct_corrected"` // Folders whose own document counters had drifted
+	Corrected       int64 `json:"corrected"`        // Folders whose rolled up stats had drifted
+}
+
+// RollUpAllFolderStats recalculates the stats of every folder from the documents, in one transaction,
+// repairing counters that missed an update. Only folders whose stats changed, or were never
+// calculated, are written.
+func RollUpAllFolderStats(db *gorm.DB) (FolderStatsRollup, error) {
+	var rollup FolderStatsRollup
+	err := db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Exec(`UPDATE folders SET direct_file_count = counts.file_count, direct_size = counts.total_size
+			FROM (
+				SELECT folders.id, COUNT(documents.id) AS file_count, COALESCE(SUM(documents.file_size), 0) AS total_size
+				FROM folders LEFT JOIN documents ON documents.folder_id = folders.id AND documents.deleted_at IS NULL
+				GROUP BY folders.id
+			) AS counts
+			WHERE folders.id = counts.id
+				AND (folders.direct_file_count <> counts.file_count OR folders.direct_size <> counts.total_size)`)
+		if result.Error != nil {
+			return result.Error
+		}
+		rollup.DirectCorrected = result.RowsAffected
+
+		// Every folder adds up the direct counters of the folders below it, itself included; UNION
+		// rather than UNION ALL ends the recursion on a cyclic parent chain
+		result = tx.Exec(`WITH RECURSIVE subtree AS (
+				SELECT id AS root_id, id FROM folders WHERE deleted_at IS NULL
+				UNION
+				SELECT subtree.root_id, subfolders.id FROM subtree
+				JOIN folders AS subfolders ON subfolders.parent_id = subtree.id AND subfolders.deleted_at IS NULL
+			)
+			UPDATE folders SET file_count = totals.file_count, total_size = totals.total_size, stats_updated_at = ?
+			FROM (
+				SELECT subtree.root_id, SUM(folders.direct_file_count) AS file_count, SUM(folders.direct_size) AS total_size
+				FROM subtree JOIN folders ON folders.id = subtree.id
+				GROUP BY subtree.root_id
+			) AS totals
+			WHERE folders.id = totals.root_id
+				AND (folders.file_count <> totals.file_count OR folders.total_size <> totals.total_size OR folders.stats_updated_at IS NULL)`,
+			time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		rollup.Corrected = result.RowsAffected
+		return nil
+	})
+	return rollup, err
+}
+
+// JobFolderStatsRollup recalculates the stats of every folder
+const JobFolderStatsRollup = "documents.folder_stats_rollup"
+
+// RegisterFolderStatsRollup schedules recalculating the stats of every folder
+func RegisterFolderStatsRollup(interval time.Duration) {
+	scheduler.Register(JobFolderStatsRollup, scheduler.Every(interval), runFolderStatsRollup)
+	log.Printf("📊 Folder stats rollup scheduled (interval: %s)", interval)
+}
+
+// BackfillFolderStats recalculates the stats of every folder in the background when some were never
+// rolled up, as after upgrading from stats counted on each folder alone
+func BackfillFolderStats() {
+	go func() {
+		var pending int64
+		if err := database.GetDB().Model(&document.Folder{}).Where("stats_updated_at IS NULL").Count(&pending).Error; err != nil || pending == 0 || readonly.Active() {
+			return
+		}
+		if err := runFolderStatsRollup(context.Background()); err != nil {
+			log.Printf("⚠️  Folder stats backfill failed: %v", err)
+		}
+	}()
+}
+
+// runFolderStatsRollup recalculates the stats of every folder and logs the drifted ones
+func runFolderStatsRollup(ctx context.Context) error {
+	rollup, err := RollUpAllFolderStats(database.GetDB().WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	if rollup.DirectCorrected+rollup.Corrected > 0 {
+		log.Printf("📊 Folder stats rollup corrected %d folder counters and %d rolled up stats", rollup.DirectCorrected, rollup.Corrected)
+	}
+	return nil
+}
+
+// JobFolderStats recalculates the statistics of a folder
+const JobFolderStats = "documents.folder_stats"
+
+// folderStatsDelay lets the changes of a burst of uploads or deletes collapse into one recalculation
+const folderStatsDelay = 2 * time.Second
+
+type folderStatsJob struct {
+	FolderID uuid.UUID `json:"folder_id"`
+}
+
+// RegisterFolderStatsJob registers the handler recalculating folder statistics; call before jobs.Start
+func RegisterFolderStatsJob() {
+	jobs.Handle(JobFolderStats, func(ctx context.Context, job *jobs.Job) error {
+		var payload folderStatsJob
+		if err := job.Decode(&payload); err != nil {
+			return fmt.Errorf("invalid payload: %v: %w", err, jobs.ErrSkipRetry)
+		}
+
+		err := UpdateFolderStats(database.GetDB().WithContext(ctx), payload.FolderID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	})
+}
+
+// QueueFolderStats queues recalculating the statistics of a folder. Requests for a folder already
+// waiting to be recalculated are merged; when the queue is unavailable the folder is recalculated at once.
+func QueueFolderStats(folderID uuid.UUID) {
+	_, err := jobs.Enqueue(context.Background(), JobFolderStats, folderStatsJob{FolderID: folderID},
+		jobs.WithDelay(folderStatsDelay), jobs.Unique("folder-stats:"+folderID.String()))
+	if err == nil || errors.Is(err, jobs.ErrDuplicate) {
+		return
+	}
+
+	log.Printf("⚠️  Failed to queue stats of folder %s, updating them now: %v", folderID, err)
+	if err := UpdateFolderStats(database.GetDB(), folderID); err != nil {
+		log.Printf("⚠️  Failed to update folder stats: %v", err)
+	}
+}
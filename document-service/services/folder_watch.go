@@ -0,0 +1,254 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models/document"
+	"forgecrud-backend/shared/messaging"
+	"forgecrud-backend/shared/readonly"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// folderWatchBatchSize is the number of subscriptions notified per run
+	folderWatchBatchSize = 100
+	// folderWatchMaxListed is the number of changes listed in one notification, the rest are only counted
+	folderWatchMaxListed = 50
+)
+
+// folderWatchers joins the subscriptions watching the folder f: those on f itself and those including
+// subfolders on one of its ancestors. The actor of a change is not notified about it.
+const folderWatchers = `JOIN folders w ON w.id = f.id OR f.path LIKE w.path || '/%'
+	JOIN folder_subscriptions s ON s.folder_id = w.id AND (w.id = f.id OR s.include_subfolders)
+	WHERE (CAST(? AS uuid) IS NULL OR s.user_id <> ?)`
+
+// RecordDocumentChanges queues a change of documents, in the trash or not, for the subscriptions
+// watching their folders. Failures are logged, as the change itself already happened.
+func RecordDocumentChanges(db *gorm.DB, documentIDs []uuid.UUID, change string, actorID *uuid.UUID) {
+	if len(documentIDs) == 0 {
+		return
+	}
+
+	err := db.Exec(`INSERT INTO folder_changes (id, subscription_id, folder_id, document_id, name, change, actor_id, created_at)
+		SELECT gen_random_uuid(), s.id, d.folder_id, d.id, d.original_name, ?, CAST(? AS uuid), NOW()
+		FROM documents d
+		JOIN folders f ON f.id = d.folder_id
+		`+folderWatchers+` AND d.id IN ?`,
+		change, actorID, actorID, actorID, documentIDs).Error
+	if err != nil {
+		log.Printf("⚠️  Failed to record %s change of %d documents for folder subscriptions: %v", change, len(documentIDs), err)
+	}
+}
+
+// RecordFolderDeleted queues a subfolder moved to the trash for the subscriptions watching its parent
+func RecordFolderDeleted(db *gorm.DB, folder *document.Folder, actorID *uuid.UUID) {
+	if folder.ParentID == nil {
+		return
+	}
+
+	err := db.Exec(`INSERT INTO folder_changes (id, subscription_id, folder_id, name, change, actor_id, created_at)
+		SELECT gen_random_uuid(), s.id, f.id, ?, ?, CAST(? AS uuid), NOW()
+		FROM folders f
+		`+folderWatchers+` AND f.id = ?`,
+		folder.Name, document.FolderChangeDeleted, actorID, actorID, actorID, *folder.ParentID).Error
+	if err != nil {
+		log.Printf("⚠️  Failed to record deletion of folder %s for folder subscriptions: %v", folder.ID, err)
+	}
+}
+
+// FolderWatchNotifier sends the changes collected for folder subscriptions to their users, right away
+// or as an hourly or daily summary
+type FolderWatchNotifier struct{}
+
+func NewFolderWatchNotifier() *FolderWatchNotifier {
+	return &FolderWatchNotifier{}
+}
+
+// Start sends due notifications in the background
+func (n *FolderWatchNotifier) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if readonly.Active() {
+				continue
+			}
+			if err := n.NotifyDue(); err != nil {
+				log.Printf("⚠️  Folder subscription notifications failed: %v", err)
+			}
+		}
+	}()
+
+	log.Printf("👀 Folder watch notifier started (interval: %s)", interval)
+}
+
+// NotifyDue notifies a batch of subscriptions with pending changes whose period is over. Each
+// subscription is notified in its own transaction holding its row with SKIP LOCKED, so several
+// document-service instances never send the same changes twice.
+func (n *FolderWatchNotifier) NotifyDue() error {
+	for i := 0; i < folderWatchBatchSize; i++ {
+		found := false
+
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			now := time.Now()
+			var subscription document.FolderSubscription
+			result := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+				Where("EXISTS (SELECT 1 FROM folder_changes WHERE folder_changes.subscription_id = folder_subscriptions.id)").
+				Where("(frequency = ? OR last_notified_at IS NULL OR (frequency = ? AND last_notified_at <= ?) OR (frequency = ? AND last_notified_at <= ?))",
+					document.SubscriptionInstant,
+					document.SubscriptionHourly, now.Add(-time.Hour),
+					document.SubscriptionDaily, now.Add(-24*time.Hour)).
+				Order("last_notified_at NULLS FIRST").
+				Limit(1).
+				Find(&subscription)
+			if result.Error != nil || result.RowsAffected == 0 {
+				return result.Error
+			}
+			found = true
+
+			return n.notify(tx, &subscription, now)
+		})
+		if err != nil {
+			return err
+		}
+		if !found {
+			return nil
+		}
+	}
+	return nil
+}
+
+// notify sends the pending changes of a subscription the user may still read and removes them
+func (n *FolderWatchNotifier) notify(tx *gorm.DB, subscription *document.FolderSubscription, now time.Time) error {
+	var changes []document.FolderChange
+	if err := tx.Where("subscription_id = ?", subscription.ID).Order("created_at").Find(&changes).Error; err != nil {
+		return err
+	}
+
+	ids := make([]uuid.UUID, len(changes))
+	for i, change := range changes {
+		ids[i] = change.ID
+	}
+	if err := tx.Where("id IN ?", ids).Delete(&document.FolderChange{}).Error; err != nil {
+		return err
+	}
+
+	var folder document.Folder
+	if err := tx.Unscoped().First(&folder, subscription.FolderID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			// The folder was purged from the trash
+			return tx.Delete(subscription).Error
+		}
+		return err
+	}
+
+	readable := make([]document.FolderChange, 0, len(changes))
+	for _, change := range changes {
+		allowed, err := changeReadable(tx, subscription.UserID, &change)
+		if err != nil {
+			return err
+		}
+		if allowed {
+			readable = append(readable, change)
+		}
+	}
+
+	if len(readable) == 0 || folder.DeletedAt.Valid {
+		return nil
+	}
+	if err := tx.Model(subscription).Update("last_notified_at", now).Error; err != nil {
+		return err
+	}
+	messaging.Publish(context.Background(), messaging.EventFolderChanged, changesActor(readable), folderChangesActivity(subscription, &folder, readable))
+	return nil
+}
+
+// changeReadable reports whether the user may read the document or subfolder of a change
+func changeReadable(db *gorm.DB, userID uuid.UUID, change *document.FolderChange) (bool, error) {
+	var access database.ObjectAccess
+	var err error
+	if change.DocumentID != nil {
+		var doc document.Document
+		if err := db.Unscoped().Select("id", "folder_id", "uploaded_by").First(&doc, *change.DocumentID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return false, nil
+			}
+			return false, err
+		}
+		access, err = database.DocumentAccess(db, userID, &doc)
+	} else {
+		var folder document.Folder
+		if err := db.Unscoped().Select("id", "path").First(&folder, change.FolderID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return false, nil
+			}
+			return false, err
+		}
+		access, err = database.FolderAccess(db, userID, &folder)
+	}
+	if err != nil {
+		return false, err
+	}
+	return access.Allows(document.AccessLevelRead), nil
+}
+
+// changesActor returns the user who made every change, nil when several users made them
+func changesActor(changes []document.FolderChange) *uuid.UUID {
+	actorID := changes[0].ActorID
+	for _, change := range changes[1:] {
+		if actorID == nil || change.ActorID == nil || *change.ActorID != *actorID {
+			return nil
+		}
+	}
+	return actorID
+}
+
+// folderChangesActivity describes the changes of a subscription, listing the first of them
+func folderChangesActivity(subscription *document.FolderSubscription, folder *document.Folder, changes []document.FolderChange) messaging.ActivityData {
+	counts := map[string]int{}
+	listed := make([]messaging.ActivityChange, 0, min(len(changes), folderWatchMaxListed))
+	for _, change := range changes {
+		counts[change.Change]++
+		if len(listed) < folderWatchMaxListed {
+			listed = append(listed, messaging.ActivityChange{Field: change.Name, NewValue: change.Change})
+		}
+	}
+
+	var summary []string
+	for _, change := range []string{document.FolderChangeAdded, document.FolderChangeUpdated, document.FolderChangeDeleted} {
+		if counts[change] > 0 {
+			summary = append(summary, fmt.Sprintf("%d %s", counts[change], change))
+		}
+	}
+	description := fmt.Sprintf("Documents in folder '%s' changed: %s", folder.Path, strings.Join(summary, ", "))
+	if len(changes) > len(listed) {
+		description += fmt.Sprintf(", %d more changes are not listed", len(changes)-len(listed))
+	}
+
+	return messaging.ActivityData{
+		OwnerID:      subscription.UserID,
+		ActionType:   "Folder Changed",
+		ResourceType: "folder",
+		ResourceID:   folder.ID,
+		ResourceName: folder.Name,
+		Description:  description,
+		Priority:     "normal",
+		Changes:      listed,
+		Data: map[string]interface{}{
+			"folder_path": folder.Path,
+			"frequency":   subscription.Frequency,
+			"added":       counts[document.FolderChangeAdded],
+			"updated":     counts[document.FolderChangeUpdated],
+			"deleted":     counts[document.FolderChangeDeleted],
+		},
+	}
+}
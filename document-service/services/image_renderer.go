@@ -0,0 +1,181 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"log"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"forgecrud-backend/shared/config"
+	docUtils "forgecrud-backend/shared/utils/document"
+
+	"github.com/google/uuid"
+)
+
+// ImageRenderPrefix holds the cached variants of rendered images in storage
+const ImageRenderPrefix = "renders/"
+
+// Output formats of rendered images
+const (
+	ImageFormatJPEG = "jpeg"
+	ImageFormatPNG  = "png"
+	ImageFormatWebP = "webp"
+)
+
+// How a rendered image fills the requested size
+const (
+	ImageFitContain = "contain" // Scaled to fit within the size, keeping the aspect ratio
+	ImageFitCover   = "cover"   // Scaled and cropped to fill the size exactly
+	ImageFitFill    = "fill"    // Stretched to the size
+)
+
+const webpTimeout = time.Minute
+
+// ErrWebPDisabled is returned for WebP output when IMAGE_WEBP_COMMAND is not configured
+var ErrWebPDisabled = errors.New("WebP output is not enabled")
+
+// imageContentTypes maps output formats to their content type and file extension
+var imageContentTypes = map[string][2]string{
+	ImageFormatJPEG: {"image/jpeg", ".jpg"},
+	ImageFormatPNG:  {"image/png", ".png"},
+	ImageFormatWebP: {"image/webp", ".webp"},
+}
+
+// ImageRenderOptions describe a rendered variant of an image. Zero width or height follow the aspect ratio.
+type ImageRenderOptions struct {
+	Width   int
+	Height  int
+	Fit     string
+	Format  string
+	Quality int
+}
+
+// ContentType returns the content type of the output format
+func (o ImageRenderOptions) ContentType() string {
+	return imageContentTypes[o.Format][0]
+}
+
+// ImageRenderKey returns the storage key of a cached variant. The checksum of the source is part of
+// the key, so a new version of the document never serves variants of the previous one.
+func ImageRenderKey(documentID uuid.UUID, checksum string, opts ImageRenderOptions) string {
+	return fmt.Sprintf("%s%s/%s-%dx%d-%s-q%d%s", ImageRenderPrefix, documentID, imageRenderVersion(checksum),
+		opts.Width, opts.Height, opts.Fit, opts.Quality, imageContentTypes[opts.Format][1])
+}
+
+// RenderImage resizes the image and encodes it in the output format
+func RenderImage(img image.Image, opts ImageRenderOptions) ([]byte, error) {
+	bounds := img.Bounds()
+	width, height := opts.Width, opts.Height
+
+	switch {
+	case width == 0 && height == 0:
+		width, height = bounds.Dx(), bounds.Dy()
+	case opts.Fit == ImageFitContain || width == 0 || height == 0:
+		width, height = docUtils.FitWithin(bounds.Dx(), bounds.Dy(), width, height)
+	case opts.Fit == ImageFitCover:
+		img = docUtils.CropToAspect(img, width, height)
+	}
+
+	var resized image.Image = img
+	if width != bounds.Dx() || height != bounds.Dy() || opts.Fit == ImageFitCover {
+		resized = docUtils.ResizeImage(img, width, height)
+	}
+
+	var buf bytes.Buffer
+	switch opts.Format {
+	case ImageFormatJPEG:
+		if err := docUtils.EncodeJPEG(&buf, resized, opts.Quality); err != nil {
+			return nil, err
+		}
+	case ImageFormatPNG:
+		if err := docUtils.EncodePNG(&buf, resized); err != nil {
+			return nil, err
+		}
+	case ImageFormatWebP:
+		if err := docUtils.EncodePNG(&buf, resized); err != nil {
+			return nil, err
+		}
+		return encodeWebP(buf.Bytes(), opts.Quality)
+	default:
+		return nil, fmt.Errorf("unsupported output format %q", opts.Format)
+	}
+	return buf.Bytes(), nil
+}
+
+// RemoveImageRenders removes the cached variants of a document, except those rendered from the
+// source with keepChecksum (empty removes all)
+func RemoveImageRenders(storage StorageProvider, documentID uuid.UUID, keepChecksum string) {
+	prefix := ImageRenderPrefix + documentID.String() + "/"
+	keep := ""
+	if keepChecksum != "" {
+		keep = prefix + imageRenderVersion(keepChecksum) + "-"
+	}
+
+	var stale []string
+	if err := storage.WalkObjects(context.Background(), prefix, func(object ObjectInfo) error {
+		if keep == "" || !strings.HasPrefix(object.Key, keep) {
+			stale = append(stale, object.Key)
+		}
+		return nil
+	}); err != nil {
+		log.Printf("⚠️  Failed to list rendered images of document %s: %v", documentID, err)
+		return
+	}
+
+	for _, key := range stale {
+		if err := storage.RemoveObject(context.Background(), key); err != nil {
+			log.Printf("⚠️  Failed to remove rendered image %s: %v", key, err)
+		}
+	}
+}
+
+// imageRenderVersion shortens the source checksum used in variant keys
+func imageRenderVersion(checksum string) string {
+	if len(checksum) > 16 {
+		return checksum[:16]
+	}
+	return checksum
+}
+
+// encodeWebP converts a PNG image to WebP with the configured encoder, called as
+// "<command> -q <quality> <input> -o <output>" like cwebp
+func encodeWebP(pngData []byte, quality int) ([]byte, error) {
+	command := config.GetConfig().ImageWebPCommand
+	if command == "" {
+		return nil, ErrWebPDisabled
+	}
+
+	input, err := os.CreateTemp("", "render-*.png")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(input.Name())
+
+	if _, err := input.Write(pngData); err != nil {
+		input.Close()
+		return nil, err
+	}
+	if err := input.Close(); err != nil {
+		return nil, err
+	}
+
+	output := strings.TrimSuffix(input.Name(), ".png") + ".webp"
+	defer os.Remove(output)
+
+	ctx, cancel := context.WithTimeout(context.Background(), webpTimeout)
+	defer cancel()
+
+	fields := strings.Fields(command)
+	args := append(fields[1:], "-q", strconv.Itoa(quality), input.Name(), "-o", output)
+	if out, err := exec.CommandContext(ctx, fields[0], args...).CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("WebP command failed: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return os.ReadFile(output)
+}
@@ -0,0 +1,217 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"forgecrud-backend/shared/config"
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models/document"
+	"forgecrud-backend/shared/readonly"
+	docUtils "forgecrud-backend/shared/utils/document"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	indexBatchSize = 10
+	ocrTimeout     = 2 * time.Minute
+)
+
+// errIndexRetry marks failures of the storage backend, the document is indexed again on the next run
+var errIndexRetry = errors.New("storage unavailable")
+
+// IndexWorker extracts the text of uploaded documents for full-text search
+type IndexWorker struct {
+	storage StorageProvider
+}
+
+func NewIndexWorker(storage StorageProvider) *IndexWorker {
+	return &IndexWorker{storage: storage}
+}
+
+// Start indexes pending documents in the background
+func (w *IndexWorker) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if readonly.Active() {
+				continue
+			}
+			if err := w.IndexPending(); err != nil {
+				log.Printf("⚠️  Document indexing failed: %v", err)
+			}
+		}
+	}()
+
+	log.Printf("🔎 Document index worker started (interval: %s)", interval)
+}
+
+// IndexPending indexes a batch of pending documents. Each document is indexed in its own transaction
+// holding its row with SKIP LOCKED, so several document-service instances can index in parallel.
+// Documents waiting for their malware scan are picked up once the scan finished.
+func (w *IndexWorker) IndexPending() error {
+	for i := 0; i < indexBatchSize; i++ {
+		found := false
+
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			var doc document.Document
+			result := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+				Select("id", "object_key", "content_hash", "file_extension", "file_size", "scan_status").
+				Where("index_status = ? AND scan_status NOT IN ?",
+					document.IndexStatusPending, []string{document.ScanStatusPending, document.ScanStatusScanning}).
+				Order("updated_at").
+				Limit(1).
+				Find(&doc)
+			if result.Error != nil || result.RowsAffected == 0 {
+				return result.Error
+			}
+			found = true
+
+			return w.indexDocument(tx, &doc)
+		})
+		if errors.Is(err, errIndexRetry) {
+			// Leave the remaining documents for the next run
+			log.Printf("⚠️  Document indexing postponed: %v", err)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !found {
+			return nil
+		}
+	}
+	return nil
+}
+
+// indexDocument stores the text of a document and rebuilds its search vector
+func (w *IndexWorker) indexDocument(tx *gorm.DB, doc *document.Document) error {
+	updates := map[string]interface{}{
+		"index_status": document.IndexStatusIndexed,
+		"indexed_at":   time.Now(),
+		"content":      "",
+	}
+
+	content, err := w.extractContent(doc, updates)
+	switch {
+	case errors.Is(err, errIndexRetry):
+		return err
+	case errors.Is(err, docUtils.ErrUnsupportedFormat):
+		updates["index_status"] = document.IndexStatusSkipped
+	case err != nil:
+		log.Printf("⚠️  Failed to extract text of document %s: %v", doc.ID, err)
+		updates["index_status"] = document.IndexStatusFailed
+	default:
+		updates["content"] = content
+	}
+
+	// Only the indexing columns change, the document keeps its update time
+	if err := tx.Model(doc).UpdateColumns(updates).Error; err != nil {
+		return err
+	}
+	return RefreshSearchVector(tx, doc.ID)
+}
+
+// extractContent returns the text of the document's current version. Images are read with OCR and the
+// outcome is recorded in the OCR columns through updates.
+func (w *IndexWorker) extractContent(doc *document.Document, updates map[string]interface{}) (string, error) {
+	cfg := config.GetConfig()
+	extension := strings.ToLower(doc.FileExtension)
+	isImage := docUtils.ImageExtensions[extension]
+
+	// Blocked files are never read, large files and images without OCR are searchable by their metadata
+	if !document.ScanStatusAllowsDownload(doc.ScanStatus) || doc.FileSize > cfg.IndexMaxFileBytes ||
+		(isImage && cfg.OCRCommand == "") {
+		return "", docUtils.ErrUnsupportedFormat
+	}
+
+	data, err := w.readObject(docUtils.StorageKey(doc.ObjectKey, doc.ContentHash))
+	if err != nil {
+		return "", err
+	}
+
+	if !isImage {
+		return docUtils.ExtractText(data, extension)
+	}
+
+	text, err := runOCR(cfg.OCRCommand, data, extension)
+	if err != nil {
+		updates["ocr_status"] = "failed"
+		return "", err
+	}
+	text = docUtils.TruncateText(text, docUtils.MaxExtractedTextBytes)
+	updates["ocr_status"] = "completed"
+	updates["ocr_text"] = text
+	return text, nil
+}
+
+// readObject reads a stored file. A missing object is an extraction failure, other storage errors are retried.
+func (w *IndexWorker) readObject(objectKey string) ([]byte, error) {
+	object, _, err := w.storage.GetObject(context.Background(), objectKey)
+	if err != nil {
+		if errors.Is(err, ErrObjectNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", errIndexRetry, err)
+	}
+	defer object.Close()
+
+	data, err := io.ReadAll(object)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errIndexRetry, err)
+	}
+	return data, nil
+}
+
+// runOCR recognizes the text of an image with the configured OCR command, called as
+// "<command> <image> stdout" like tesseract
+func runOCR(command string, image []byte, extension string) (string, error) {
+	file, err := os.CreateTemp("", "ocr-*"+extension)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := file.Write(image); err != nil {
+		file.Close()
+		return "", err
+	}
+	if err := file.Close(); err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), ocrTimeout)
+	defer cancel()
+
+	fields := strings.Fields(command)
+	args := append(fields[1:], file.Name(), "stdout")
+	output, err := exec.CommandContext(ctx, fields[0], args...).Output()
+	if err != nil {
+		return "", fmt.Errorf("OCR command failed: %v", err)
+	}
+	return string(output), nil
+}
+
+// RefreshSearchVector rebuilds the search vector of a document from its name, tags, description and
+// extracted content, ranked in that order
+func RefreshSearchVector(db *gorm.DB, documentID uuid.UUID) error {
+	language := config.GetConfig().SearchLanguage
+
+	return db.Exec(`UPDATE documents SET search_vector =
+		setweight(to_tsvector(?::regconfig, coalesce(original_name, '') || ' ' || regexp_replace(coalesce(original_name, ''), '[._-]+', ' ', 'g')), 'A') ||
+		setweight(to_tsvector(?::regconfig, array_to_string(tags, ' ') || ' ' || coalesce(description, '')), 'B') ||
+		setweight(to_tsvector(?::regconfig, coalesce(content, '')), 'C')
+		WHERE id = ?`, language, language, language, documentID).Error
+}
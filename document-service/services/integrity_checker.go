@@ -0,0 +1,388 @@
+package services
+
+import (
+	"context"
+	"crypto/md5"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models/document"
+	"forgecrud-backend/shared/scheduler"
+	docUtils "forgecrud-backend/shared/utils/document"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const integrityBatchSize = 100
+
+// integrityIgnoredPrefixes hold stored objects that are not tracked by document records
+var integrityIgnoredPrefixes = []string{"avatars/", FolderExportPrefix, ImageRenderPrefix, AuditLogArchivePrefix}
+
+// ErrIntegrityCheckRunning is returned when an integrity check is started while another one runs
+var ErrIntegrityCheckRunning = errors.New("a storage integrity check is already running")
+
+// integrityRun allows one integrity check at a time, scheduled or started through the API
+var integrityRun sync.Mutex
+
+// IntegrityOptions configures what an integrity check repairs
+type IntegrityOptions struct {
+	RepairOrphans bool          // Remove stored objects no record references
+	OrphanGrace   time.Duration // Objects younger than this may belong to an upload in progress and are skipped
+}
+
+// IntegrityChecker verifies on a schedule that stored files and document records match
+type IntegrityChecker struct {
+	storage StorageProvider
+	options IntegrityOptions
+}
+
+func NewIntegrityChecker(storage StorageProvider, options IntegrityOptions) *IntegrityChecker {
+	return &IntegrityChecker{storage: storage, options: options}
+}
+
+// JobIntegrityCheck is the scheduled storage integrity check
+const JobIntegrityCheck = "documents.integrity_check"
+
+// Register schedules the integrity check
+func (c *IntegrityChecker) Register(interval time.Duration) {
+	scheduler.Register(JobIntegrityCheck, scheduler.Every(interval), c.Run)
+	log.Printf("🔍 Storage integrity check scheduled (repair orphans: %t, interval: %s)", c.options.RepairOrphans, interval)
+}
+
+// Run checks the storage integrity once, unless a check started through the API is running
+func (c *IntegrityChecker) Run(ctx context.Context) error {
+	report, err := CheckStorageIntegrity(database.DB, c.storage, c.options)
+	switch {
+	case errors.Is(err, ErrIntegrityCheckRunning):
+		// Started through the API, the next run checks again
+		return nil
+	case err != nil:
+		return err
+	case report.MissingObjects+report.ChecksumMismatches+report.OrphanedObjects > 0:
+		log.Printf("⚠️  Storage integrity check found %d missing objects, %d checksum mismatches, %d orphaned objects (%d removed)",
+			report.MissingObjects, report.ChecksumMismatches, report.OrphanedObjects, report.RepairedObjects)
+	}
+	return nil
+}
+
+// CheckStorageIntegrity runs an integrity check and returns its report once it finished
+func CheckStorageIntegrity(db *gorm.DB, storage StorageProvider, options IntegrityOptions) (*document.IntegrityReport, error) {
+	report, err := beginIntegrityCheck(db)
+	if err != nil {
+		return nil, err
+	}
+	err = runIntegrityCheck(db, storage, report, options)
+	return report, err
+}
+
+// StartIntegrityCheck starts an integrity check in the background and returns its running report
+func StartIntegrityCheck(db *gorm.DB, storage StorageProvider, options IntegrityOptions) (*document.IntegrityReport, error) {
+	report, err := beginIntegrityCheck(db)
+	if err != nil {
+		return nil, err
+	}
+	started := *report
+
+	go func() {
+		if err := runIntegrityCheck(db, storage, report, options); err != nil {
+			log.Printf("❌ Storage integrity check failed: %v", err)
+		}
+	}()
+	return &started, nil
+}
+
+// beginIntegrityCheck takes the integrity check lock and records the new report. Reports still
+// running were interrupted by a restart, as the lock is held while a check runs.
+func beginIntegrityCheck(db *gorm.DB) (*document.IntegrityReport, error) {
+	if !integrityRun.TryLock() {
+		return nil, ErrIntegrityCheckRunning
+	}
+
+	if err := db.Model(&document.IntegrityReport{}).
+		Where("status = ?", document.IntegrityStatusRunning).
+		Updates(map[string]interface{}{
+			"status": document.IntegrityStatusFailed,
+			"error":  "interrupted before it finished",
+		}).Error; err != nil {
+		integrityRun.Unlock()
+		return nil, err
+	}
+
+	report := &document.IntegrityReport{
+		Status:    document.IntegrityStatusRunning,
+		StartedAt: time.Now(),
+	}
+	if err := db.Create(report).Error; err != nil {
+		integrityRun.Unlock()
+		return nil, err
+	}
+	return report, nil
+}
+
+// runIntegrityCheck verifies that the file of every document version is stored with the size and
+// checksum of its record, then looks for stored objects that no version, content object or upload in
+// progress references. Missing and corrupted files are only reported, orphaned objects are removed
+// when the options allow it. It releases the integrity check lock when done.
+func runIntegrityCheck(db *gorm.DB, storage StorageProvider, report *document.IntegrityReport, options IntegrityOptions) error {
+	defer integrityRun.Unlock()
+
+	check := &integrityCheck{
+		db:      db,
+		storage: storage,
+		report:  report,
+		known:   map[string]bool{},
+		content: map[string]*storedObject{},
+	}
+
+	err := check.verifyVersions()
+	if err == nil {
+		err = check.collectKnownKeys()
+	}
+	if err == nil {
+		err = check.findOrphans(options)
+	}
+
+	finishedAt := time.Now()
+	report.FinishedAt = &finishedAt
+	report.Status = document.IntegrityStatusCompleted
+	if err != nil {
+		report.Status = document.IntegrityStatusFailed
+		report.Error = err.Error()
+	}
+	if saveErr := db.Save(report).Error; saveErr != nil && err == nil {
+		err = saveErr
+	}
+	return err
+}
+
+// storedObject is what an integrity check learned about a stored file
+type storedObject struct {
+	missing bool
+	size    int64
+	etag    string
+	md5     string // Computed on demand when the ETag is not the MD5 of the content
+}
+
+// integrityCheck is the state of one integrity check run
+type integrityCheck struct {
+	db      *gorm.DB
+	storage StorageProvider
+	report  *document.IntegrityReport
+
+	known   map[string]bool          // Keys referenced by records
+	content map[string]*storedObject // Content store files, shared by many versions
+}
+
+// verifyVersions checks the stored file of every document version, including versions of documents
+// in the trash and versions still in quarantine
+func (c *integrityCheck) verifyVersions() error {
+	var lastID uuid.UUID
+	for {
+		var versions []document.DocumentVersion
+		if err := c.db.Where("id > ?", lastID).Order("id").Limit(integrityBatchSize).Find(&versions).Error; err != nil {
+			return err
+		}
+
+		for _, version := range versions {
+			if err := c.verifyVersion(version); err != nil {
+				return err
+			}
+			c.report.VersionsChecked++
+		}
+		if err := c.db.Save(c.report).Error; err != nil {
+			return err
+		}
+
+		if len(versions) < integrityBatchSize {
+			return nil
+		}
+		lastID = versions[len(versions)-1].ID
+	}
+}
+
+func (c *integrityCheck) verifyVersion(version document.DocumentVersion) error {
+	objectKey := versionStorageKey(version)
+	c.known[objectKey] = true
+
+	object, err := c.stat(objectKey)
+	if err != nil {
+		return err
+	}
+
+	issue := document.IntegrityIssue{
+		ObjectKey:  objectKey,
+		DocumentID: &version.DocumentID,
+		VersionID:  &version.ID,
+		Version:    version.Version,
+	}
+	switch {
+	case object.missing:
+		// The version may have been removed while the check ran
+		var count int64
+		if err := c.db.Model(&document.DocumentVersion{}).Where("id = ?", version.ID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return nil
+		}
+		issue.Type = document.IntegrityIssueMissingObject
+		issue.Details = "the file of the version is not in storage"
+		c.report.MissingObjects++
+	case object.size != version.FileSize:
+		issue.Type = document.IntegrityIssueChecksumMismatch
+		issue.Details = fmt.Sprintf("stored size %d, expected %d", object.size, version.FileSize)
+		c.report.ChecksumMismatches++
+	default:
+		checksum, err := c.checksum(objectKey, object, version.Checksum)
+		if err != nil {
+			return err
+		}
+		if checksum == "" || strings.EqualFold(checksum, version.Checksum) {
+			return nil
+		}
+		issue.Type = document.IntegrityIssueChecksumMismatch
+		issue.Details = fmt.Sprintf("stored MD5 %s, expected %s", checksum, version.Checksum)
+		c.report.ChecksumMismatches++
+	}
+
+	return c.record(&issue)
+}
+
+// stat looks up a stored file. Content store files are looked up once per run.
+func (c *integrityCheck) stat(objectKey string) (*storedObject, error) {
+	if object, ok := c.content[objectKey]; ok {
+		return object, nil
+	}
+
+	info, err := c.storage.StatObject(context.Background(), objectKey)
+	var object *storedObject
+	switch {
+	case errors.Is(err, ErrObjectNotFound):
+		object = &storedObject{missing: true}
+	case err != nil:
+		return nil, err
+	default:
+		object = &storedObject{size: info.Size, etag: info.ETag}
+	}
+
+	if strings.HasPrefix(objectKey, docUtils.ContentPrefix) {
+		c.content[objectKey] = object
+	}
+	return object, nil
+}
+
+// checksum returns the MD5 of a stored file. The ETag of files uploaded in one part is their MD5, so
+// the file is only read when the ETag differs from the expected checksum. Records without a checksum
+// are not verified and return an empty checksum.
+func (c *integrityCheck) checksum(objectKey string, object *storedObject, expected string) (string, error) {
+	if expected == "" {
+		return "", nil
+	}
+	if strings.EqualFold(object.etag, expected) {
+		return object.etag, nil
+	}
+	if object.md5 != "" {
+		return object.md5, nil
+	}
+
+	reader, _, err := c.storage.GetObject(context.Background(), objectKey)
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	hash := md5.New()
+	if _, err := io.Copy(hash, reader); err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", objectKey, err)
+	}
+	object.md5 = fmt.Sprintf("%x", hash.Sum(nil))
+	return object.md5, nil
+}
+
+// collectKnownKeys adds the keys of content store files and of uploads in progress to the keys
+// referenced by versions
+func (c *integrityCheck) collectKnownKeys() error {
+	var contentKeys []string
+	if err := c.db.Model(&document.ContentObject{}).Pluck("object_key", &contentKeys).Error; err != nil {
+		return err
+	}
+	for _, key := range contentKeys {
+		c.known[key] = true
+	}
+
+	var uploadKeys []string
+	if err := c.db.Model(&document.UploadSession{}).
+		Where("status IN ?", []string{document.UploadSessionUploading, document.UploadSessionCompleting}).
+		Pluck("storage_key", &uploadKeys).Error; err != nil {
+		return err
+	}
+	for _, key := range uploadKeys {
+		c.known[key] = true
+	}
+	return nil
+}
+
+// findOrphans lists the storage for objects that no record references. Folder markers and objects
+// that are not document files are left alone.
+func (c *integrityCheck) findOrphans(options IntegrityOptions) error {
+	cutoff := time.Now().Add(-options.OrphanGrace)
+
+	return c.storage.WalkObjects(context.Background(), "", func(object ObjectInfo) error {
+		if strings.HasSuffix(object.Key, folderMarker) || hasIgnoredPrefix(object.Key) {
+			return nil
+		}
+		c.report.ObjectsChecked++
+
+		if c.known[object.Key] || object.LastModified.After(cutoff) {
+			return nil
+		}
+
+		issue := document.IntegrityIssue{
+			Type:      document.IntegrityIssueOrphanedObject,
+			ObjectKey: object.Key,
+			Details:   fmt.Sprintf("%d bytes, last modified %s", object.Size, object.LastModified.Format(time.RFC3339)),
+		}
+		c.report.OrphanedObjects++
+
+		if options.RepairOrphans {
+			if err := c.storage.RemoveObject(context.Background(), object.Key); err != nil {
+				log.Printf("⚠️  Failed to remove orphaned object %s: %v", object.Key, err)
+			} else {
+				issue.Repaired = true
+				c.report.RepairedObjects++
+			}
+		}
+
+		return c.record(&issue)
+	})
+}
+
+func (c *integrityCheck) record(issue *document.IntegrityIssue) error {
+	issue.ReportID = c.report.ID
+	return c.db.Create(issue).Error
+}
+
+// versionStorageKey returns where the file of a version is stored: in quarantine until it passed the
+// malware scan, then in the content store or under its object key
+func versionStorageKey(version document.DocumentVersion) string {
+	if version.ContentHash == "" && !document.ScanStatusAllowsDownload(version.ScanStatus) {
+		return docUtils.QuarantineKey(version.ObjectKey)
+	}
+	return docUtils.StorageKey(version.ObjectKey, version.ContentHash)
+}
+
+func hasIgnoredPrefix(objectKey string) bool {
+	for _, prefix := range integrityIgnoredPrefixes {
+		if strings.HasPrefix(objectKey, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,462 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// localUploadsDir holds the parts of multipart uploads in progress, outside of the stored objects
+const localUploadsDir = ".multipart"
+
+// LocalStorage stores files in a directory of the document service's filesystem, for single node
+// deployments and development. Keys are paths relative to the directory.
+type LocalStorage struct {
+	root string
+}
+
+// NewLocalStorage stores files under the directory, which is created if needed
+func NewLocalStorage(root string) (*LocalStorage, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("invalid local storage path: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, localUploadsDir), 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create local storage directory: %v", err)
+	}
+	return &LocalStorage{root: root}, nil
+}
+
+// TestConnection checks that the storage directory is writable
+func (s *LocalStorage) TestConnection() error {
+	if err := s.Ping(context.Background()); err != nil {
+		return err
+	}
+
+	log.Printf("✅ Local storage ready: %s", s.root)
+	return nil
+}
+
+// Ping checks that the storage directory is writable
+func (s *LocalStorage) Ping(ctx context.Context) error {
+	probe, err := os.CreateTemp(filepath.Join(s.root, localUploadsDir), "probe-*")
+	if err != nil {
+		return fmt.Errorf("local storage %s is not writable: %v", s.root, err)
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+	return nil
+}
+
+// Close does nothing, files are opened and closed per operation
+func (s *LocalStorage) Close() error {
+	return nil
+}
+
+// filePath returns the file of a key. Keys leaving the storage directory are rejected.
+func (s *LocalStorage) filePath(key string) (string, error) {
+	cleaned := path.Clean("/" + key)
+	if cleaned == "/" || strings.HasPrefix(cleaned, "/"+localUploadsDir+"/") || cleaned == "/"+localUploadsDir {
+		return "", fmt.Errorf("invalid object key %q", key)
+	}
+	return filepath.Join(s.root, filepath.FromSlash(cleaned)), nil
+}
+
+// folderPath returns the directory of a folder path
+func (s *LocalStorage) folderPath(folderPath string) (string, error) {
+	cleanPath := strings.Trim(folderPath, "/")
+	if cleanPath == "" {
+		return "", fmt.Errorf("invalid folder path %q", folderPath)
+	}
+	return s.filePath(cleanPath)
+}
+
+func (s *LocalStorage) CreateFolder(folderPath string) error {
+	dir, err := s.folderPath(folderPath)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return fmt.Errorf("failed to create folder: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, folderMarker), nil, 0o640); err != nil {
+		return fmt.Errorf("failed to create folder marker: %v", err)
+	}
+	return nil
+}
+
+// DeleteFolder removes a folder and ALL its contents
+func (s *LocalStorage) DeleteFolder(folderPath string) error {
+	dir, err := s.folderPath(folderPath)
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("failed to delete folder: %v", err)
+	}
+	return nil
+}
+
+// MoveFolder moves a folder with all its contents, merging it into an existing folder at the new path
+func (s *LocalStorage) MoveFolder(oldPath, newPath string) error {
+	oldDir, err := s.folderPath(oldPath)
+	if err != nil {
+		return err
+	}
+	newDir, err := s.folderPath(newPath)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(newDir); errors.Is(err, fs.ErrNotExist) {
+		if err := os.MkdirAll(filepath.Dir(newDir), 0o750); err != nil {
+			return fmt.Errorf("failed to create parent folder: %v", err)
+		}
+		if err := os.Rename(oldDir, newDir); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to move folder: %v", err)
+		}
+		return nil
+	}
+
+	err = filepath.WalkDir(oldDir, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		relative, err := filepath.Rel(oldDir, name)
+		if err != nil {
+			return err
+		}
+		return moveFile(name, filepath.Join(newDir, relative))
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to move folder: %v", err)
+	}
+	return os.RemoveAll(oldDir)
+}
+
+func (s *LocalStorage) FolderExists(folderPath string) (bool, error) {
+	dir, err := s.folderPath(folderPath)
+	if err != nil {
+		return false, err
+	}
+	_, err = os.Stat(filepath.Join(dir, folderMarker))
+	return err == nil, nil
+}
+
+// ListFolderContents lists the keys directly in a folder, subfolders end with a slash
+func (s *LocalStorage) ListFolderContents(folderPath string) ([]string, error) {
+	dir, err := s.folderPath(folderPath)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := strings.Trim(folderPath, "/") + "/"
+	objects := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			objects = append(objects, prefix+entry.Name()+"/")
+		} else {
+			objects = append(objects, prefix+entry.Name())
+		}
+	}
+	return objects, nil
+}
+
+// PutObject stores a file under the key. It is written to a temporary file first so readers never
+// see a partly written file.
+func (s *LocalStorage) PutObject(ctx context.Context, objectKey string, reader io.Reader, size int64, contentType string) error {
+	name, err := s.filePath(objectKey)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0o750); err != nil {
+		return fmt.Errorf("failed to upload object: %v", err)
+	}
+
+	temp, err := os.CreateTemp(filepath.Join(s.root, localUploadsDir), "object-*")
+	if err != nil {
+		return fmt.Errorf("failed to upload object: %v", err)
+	}
+	defer os.Remove(temp.Name())
+
+	written, err := io.Copy(temp, reader)
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to upload object: %v", err)
+	}
+	if size >= 0 && written != size {
+		return fmt.Errorf("failed to upload object: received %d bytes, expected %d", written, size)
+	}
+
+	if err := os.Rename(temp.Name(), name); err != nil {
+		return fmt.Errorf("failed to upload object: %v", err)
+	}
+	return nil
+}
+
+func (s *LocalStorage) GetObject(ctx context.Context, objectKey string) (io.ReadCloser, ObjectInfo, error) {
+	file, info, err := s.open(objectKey)
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+	return file, info, nil
+}
+
+func (s *LocalStorage) GetObjectRange(ctx context.Context, objectKey string, start, end int64) (io.ReadCloser, error) {
+	file, info, err := s.open(objectKey)
+	if err != nil {
+		return nil, err
+	}
+	if start < 0 || end < start || end >= info.Size {
+		file.Close()
+		return nil, fmt.Errorf("invalid range: %d-%d of %d bytes", start, end, info.Size)
+	}
+	if _, err := file.Seek(start, io.SeekStart); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to get object: %v", err)
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.LimitReader(file, end-start+1), file}, nil
+}
+
+func (s *LocalStorage) StatObject(ctx context.Context, objectKey string) (ObjectInfo, error) {
+	name, err := s.filePath(objectKey)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	stat, err := os.Stat(name)
+	if err != nil || stat.IsDir() {
+		return ObjectInfo{}, localObjectError(err)
+	}
+	return localObjectInfo(objectKey, stat), nil
+}
+
+func (s *LocalStorage) open(objectKey string) (*os.File, ObjectInfo, error) {
+	name, err := s.filePath(objectKey)
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, ObjectInfo{}, localObjectError(err)
+	}
+	stat, err := file.Stat()
+	if err != nil || stat.IsDir() {
+		file.Close()
+		return nil, ObjectInfo{}, localObjectError(err)
+	}
+	return file, localObjectInfo(objectKey, stat), nil
+}
+
+// WalkObjects calls fn for every file under the prefix, in key order
+func (s *LocalStorage) WalkObjects(ctx context.Context, prefix string, fn func(ObjectInfo) error) error {
+	err := filepath.WalkDir(s.root, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		relative, err := filepath.Rel(s.root, name)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(relative)
+		if entry.IsDir() {
+			if key == localUploadsDir || (key != "." && !strings.HasPrefix(prefix, key+"/") && !strings.HasPrefix(key+"/", prefix)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+
+		stat, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		return fn(localObjectInfo(key, stat))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list objects: %w", err)
+	}
+	return nil
+}
+
+func (s *LocalStorage) CopyObject(sourceKey, destKey string) error {
+	source, _, err := s.open(sourceKey)
+	if err != nil {
+		return fmt.Errorf("failed to copy object: %w", err)
+	}
+	defer source.Close()
+
+	if err := s.PutObject(context.Background(), destKey, source, -1, ""); err != nil {
+		return fmt.Errorf("failed to copy object: %v", err)
+	}
+	return nil
+}
+
+func (s *LocalStorage) MoveObject(sourceKey, destKey string) error {
+	source, err := s.filePath(sourceKey)
+	if err != nil {
+		return err
+	}
+	dest, err := s.filePath(destKey)
+	if err != nil {
+		return err
+	}
+	return moveFile(source, dest)
+}
+
+// RemoveObject removes a file, removing a missing file is not an error
+func (s *LocalStorage) RemoveObject(ctx context.Context, objectKey string) error {
+	name, err := s.filePath(objectKey)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to remove object: %v", err)
+	}
+	return nil
+}
+
+// NewMultipartUpload creates the directory the parts of the upload are stored in until it is completed
+func (s *LocalStorage) NewMultipartUpload(ctx context.Context, objectKey, contentType string) (string, error) {
+	if _, err := s.filePath(objectKey); err != nil {
+		return "", err
+	}
+	uploadID := uuid.New().String()
+	if err := os.MkdirAll(s.uploadPath(uploadID), 0o750); err != nil {
+		return "", fmt.Errorf("failed to start multipart upload: %v", err)
+	}
+	return uploadID, nil
+}
+
+func (s *LocalStorage) PutObjectPart(ctx context.Context, objectKey, uploadID string, partNumber int, reader io.Reader, size int64) error {
+	if _, err := uuid.Parse(uploadID); err != nil {
+		return fmt.Errorf("invalid upload ID %q", uploadID)
+	}
+
+	part, err := os.Create(filepath.Join(s.uploadPath(uploadID), strconv.Itoa(partNumber)))
+	if err != nil {
+		return fmt.Errorf("failed to upload part %d: %v", partNumber, err)
+	}
+	written, err := io.Copy(part, reader)
+	if closeErr := part.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to upload part %d: %v", partNumber, err)
+	}
+	if written != size {
+		return fmt.Errorf("failed to upload part %d: received %d bytes, expected %d", partNumber, written, size)
+	}
+	return nil
+}
+
+// CompleteMultipartUpload concatenates the parts in part number order into the object
+func (s *LocalStorage) CompleteMultipartUpload(ctx context.Context, objectKey, uploadID string) error {
+	if _, err := uuid.Parse(uploadID); err != nil {
+		return fmt.Errorf("invalid upload ID %q", uploadID)
+	}
+	dir := s.uploadPath(uploadID)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to list uploaded parts: %v", err)
+	}
+	parts := make([]int, 0, len(entries))
+	for _, entry := range entries {
+		if number, err := strconv.Atoi(entry.Name()); err == nil {
+			parts = append(parts, number)
+		}
+	}
+	sort.Ints(parts)
+
+	readers := make([]io.Reader, 0, len(parts))
+	for _, number := range parts {
+		part, err := os.Open(filepath.Join(dir, strconv.Itoa(number)))
+		if err != nil {
+			return fmt.Errorf("failed to read part %d: %v", number, err)
+		}
+		defer part.Close()
+		readers = append(readers, part)
+	}
+
+	if err := s.PutObject(ctx, objectKey, io.MultiReader(readers...), -1, ""); err != nil {
+		return fmt.Errorf("failed to complete multipart upload: %v", err)
+	}
+	return os.RemoveAll(dir)
+}
+
+func (s *LocalStorage) AbortMultipartUpload(ctx context.Context, objectKey, uploadID string) error {
+	if _, err := uuid.Parse(uploadID); err != nil {
+		return fmt.Errorf("invalid upload ID %q", uploadID)
+	}
+	if err := os.RemoveAll(s.uploadPath(uploadID)); err != nil {
+		return fmt.Errorf("failed to abort multipart upload: %v", err)
+	}
+	return nil
+}
+
+func (s *LocalStorage) uploadPath(uploadID string) string {
+	return filepath.Join(s.root, localUploadsDir, uploadID)
+}
+
+// moveFile renames a file, creating the directory of the destination
+func moveFile(source, dest string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0o750); err != nil {
+		return err
+	}
+	return os.Rename(source, dest)
+}
+
+// localObjectError reports missing files as ErrObjectNotFound
+func localObjectError(err error) error {
+	if err == nil || errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to get object: %w", ErrObjectNotFound)
+	}
+	return fmt.Errorf("failed to get object: %v", err)
+}
+
+// localObjectInfo describes a stored file. Files have no ETag of their own, the modification time and
+// size change whenever the file is replaced.
+func localObjectInfo(key string, stat fs.FileInfo) ObjectInfo {
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	return ObjectInfo{
+		Key:          key,
+		Size:         stat.Size(),
+		ETag:         fmt.Sprintf("%x-%x", stat.ModTime().UnixNano(), stat.Size()),
+		ContentType:  contentType,
+		LastModified: stat.ModTime(),
+	}
+}
@@ -2,9 +2,11 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"net/http"
 	"net/url"
 	"strings"
 
@@ -14,45 +16,69 @@ import (
 	"github.com/minio/minio-go/v7/pkg/credentials"
 )
 
+// MinIOService stores files in MinIO or another S3 compatible storage: AWS S3 and Cloud Storage
 type MinIOService struct {
 	client     *minio.Client
+	transport  *http.Transport
 	bucketName string
+	name       string // Storage name for the logs
 }
 
+// NewMinIOService connects to the MinIO server of MINIO_SERVER_URL
 func NewMinIOService() (*MinIOService, error) {
 	cfg := config.GetConfig()
+	return newS3CompatibleService("MinIO", cfg.MinIOServerURL, cfg.MinIOUseSSL, "", cfg.MinIORootUser, cfg.MinIORootPassword, cfg.MinIOBucketName)
+}
+
+// newS3CompatibleService connects to S3 compatible storage and creates the bucket if needed
+func newS3CompatibleService(name, endpointURL string, useSSL bool, region, accessKey, secretKey, bucketName string) (*MinIOService, error) {
+	service, err := connectS3Compatible(name, endpointURL, useSSL, region, accessKey, secretKey, bucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Test connection and create bucket if needed
+	if err := service.initializeBucket(); err != nil {
+		return nil, err
+	}
+
+	return service, nil
+}
 
+// connectS3Compatible creates the client of S3 compatible storage without checking the bucket, for
+// credentials that are limited to a prefix
+func connectS3Compatible(name, endpointURL string, useSSL bool, region, accessKey, secretKey, bucketName string) (*MinIOService, error) {
 	// Parse endpoint URL to get host
-	parsedURL, err := url.Parse(cfg.MinIOServerURL)
+	parsedURL, err := url.Parse(endpointURL)
 	if err != nil {
-		return nil, fmt.Errorf("invalid MinIO endpoint: %v", err)
+		return nil, fmt.Errorf("invalid %s endpoint: %v", name, err)
 	}
 
 	endpoint := parsedURL.Host
-	useSSL := cfg.MinIOUseSSL
 
-	log.Printf("🔗 Connecting to MinIO: %s (SSL: %v)", endpoint, useSSL)
+	log.Printf("🔗 Connecting to %s: %s (SSL: %v)", name, endpoint, useSSL)
 
-	// Initialize MinIO client
+	// Initialize MinIO client with its own transport, so its connections can be closed on shutdown
+	transport, err := minio.DefaultTransport(useSSL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s transport: %v", name, err)
+	}
 	minioClient, err := minio.New(endpoint, &minio.Options{
-		Creds:  credentials.NewStaticV4(cfg.MinIORootUser, cfg.MinIORootPassword, ""),
-		Secure: useSSL,
+		Creds:     credentials.NewStaticV4(accessKey, secretKey, ""),
+		Secure:    useSSL,
+		Region:    region,
+		Transport: transport,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create MinIO client: %v", err)
+		return nil, fmt.Errorf("failed to create %s client: %v", name, err)
 	}
 
-	service := &MinIOService{
+	return &MinIOService{
 		client:     minioClient,
-		bucketName: cfg.MinIOBucketName,
-	}
-
-	// Test connection and create bucket if needed
-	if err := service.initializeBucket(); err != nil {
-		return nil, err
-	}
-
-	return service, nil
+		transport:  transport,
+		bucketName: bucketName,
+		name:       name,
+	}, nil
 }
 
 func (s *MinIOService) initializeBucket() error {
@@ -72,9 +98,9 @@ func (s *MinIOService) initializeBucket() error {
 		if err != nil {
 			return fmt.Errorf("failed to create bucket: %v", err)
 		}
-		log.Printf("✅ MinIO bucket '%s' created successfully", s.bucketName)
+		log.Printf("✅ %s bucket '%s' created successfully", s.name, s.bucketName)
 	} else {
-		log.Printf("✅ MinIO bucket '%s' already exists", s.bucketName)
+		log.Printf("✅ %s bucket '%s' already exists", s.name, s.bucketName)
 	}
 
 	return nil
@@ -82,19 +108,26 @@ func (s *MinIOService) initializeBucket() error {
 
 // Test connection
 func (s *MinIOService) TestConnection() error {
-	ctx := context.Background()
-
-	// List buckets to test connection
-	buckets, err := s.client.ListBuckets(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to connect to MinIO: %v", err)
+	if err := s.Ping(context.Background()); err != nil {
+		return err
 	}
 
-	log.Printf("✅ MinIO connection successful. Found %d buckets", len(buckets))
-	for _, bucket := range buckets {
-		log.Printf("   📁 Bucket: %s (created: %s)", bucket.Name, bucket.CreationDate)
+	log.Printf("✅ %s connection successful. Bucket: %s", s.name, s.bucketName)
+	return nil
+}
+
+// Ping checks that the bucket is reachable
+func (s *MinIOService) Ping(ctx context.Context) error {
+	// Only the bucket is checked, credentials of AWS and Cloud Storage are usually not allowed to list buckets
+	if _, err := s.client.BucketExists(ctx, s.bucketName); err != nil {
+		return fmt.Errorf("failed to connect to %s: %v", s.name, err)
 	}
+	return nil
+}
 
+// Close closes the idle connections to the storage
+func (s *MinIOService) Close() error {
+	s.transport.CloseIdleConnections()
 	return nil
 }
 
@@ -393,3 +426,158 @@ func (s *MinIOService) CopyObject(sourceKey, destKey string) error {
 	log.Printf("✅ Object copied: %s -> %s", sourceKey, destKey)
 	return nil
 }
+
+// PutObject uploads an object under the given key with its content type
+func (s *MinIOService) PutObject(ctx context.Context, objectKey string, reader io.Reader, size int64, contentType string) error {
+	_, err := s.client.PutObject(ctx, s.bucketName, objectKey, reader, size, minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload object: %v", err)
+	}
+	return nil
+}
+
+// GetObject returns an object by key together with its metadata
+func (s *MinIOService) GetObject(ctx context.Context, objectKey string) (io.ReadCloser, ObjectInfo, error) {
+	object, err := s.client.GetObject(ctx, s.bucketName, objectKey, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, ObjectInfo{}, fmt.Errorf("failed to get object: %w", objectError(err))
+	}
+
+	info, err := object.Stat()
+	if err != nil {
+		object.Close()
+		return nil, ObjectInfo{}, fmt.Errorf("failed to stat object: %w", objectError(err))
+	}
+	return object, toObjectInfo(info), nil
+}
+
+// GetObjectRange returns the bytes from start to end (both included) of an object by key
+func (s *MinIOService) GetObjectRange(ctx context.Context, objectKey string, start, end int64) (io.ReadCloser, error) {
+	opts := minio.GetObjectOptions{}
+	if err := opts.SetRange(start, end); err != nil {
+		return nil, fmt.Errorf("invalid range: %w", err)
+	}
+
+	object, err := s.client.GetObject(ctx, s.bucketName, objectKey, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object: %w", objectError(err))
+	}
+	if _, err := object.Stat(); err != nil {
+		object.Close()
+		return nil, fmt.Errorf("failed to stat object: %w", objectError(err))
+	}
+	return object, nil
+}
+
+// StatObject returns the metadata of an object by key
+func (s *MinIOService) StatObject(ctx context.Context, objectKey string) (ObjectInfo, error) {
+	info, err := s.client.StatObject(ctx, s.bucketName, objectKey, minio.StatObjectOptions{})
+	if err != nil {
+		return ObjectInfo{}, fmt.Errorf("failed to stat object: %w", objectError(err))
+	}
+	return toObjectInfo(info), nil
+}
+
+// WalkObjects calls fn for every object of the bucket under the prefix, including nested ones
+func (s *MinIOService) WalkObjects(ctx context.Context, prefix string, fn func(ObjectInfo) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	for object := range s.client.ListObjects(ctx, s.bucketName, minio.ListObjectsOptions{
+		Prefix:    prefix,
+		Recursive: true,
+	}) {
+		if object.Err != nil {
+			return fmt.Errorf("failed to list objects: %v", object.Err)
+		}
+		if err := fn(toObjectInfo(object)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// objectError reports missing objects as ErrObjectNotFound
+func objectError(err error) error {
+	var response minio.ErrorResponse
+	if errors.As(err, &response) && response.Code == "NoSuchKey" {
+		return fmt.Errorf("%w: %v", ErrObjectNotFound, err)
+	}
+	return err
+}
+
+func toObjectInfo(info minio.ObjectInfo) ObjectInfo {
+	return ObjectInfo{
+		Key:          info.Key,
+		Size:         info.Size,
+		ETag:         strings.Trim(info.ETag, `"`),
+		ContentType:  info.ContentType,
+		LastModified: info.LastModified,
+	}
+}
+
+// RemoveObject removes an object by key
+func (s *MinIOService) RemoveObject(ctx context.Context, objectKey string) error {
+	if err := s.client.RemoveObject(ctx, s.bucketName, objectKey, minio.RemoveObjectOptions{}); err != nil {
+		return fmt.Errorf("failed to remove object: %v", err)
+	}
+	return nil
+}
+
+// NewMultipartUpload starts a multipart upload for the object and returns its upload ID
+func (s *MinIOService) NewMultipartUpload(ctx context.Context, objectKey, contentType string) (string, error) {
+	core := minio.Core{Client: s.client}
+	uploadID, err := core.NewMultipartUpload(ctx, s.bucketName, objectKey, minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to start multipart upload: %v", err)
+	}
+	return uploadID, nil
+}
+
+// PutObjectPart uploads one part of a multipart upload. Uploading a part number again replaces it.
+func (s *MinIOService) PutObjectPart(ctx context.Context, objectKey, uploadID string, partNumber int, reader io.Reader, size int64) error {
+	core := minio.Core{Client: s.client}
+	if _, err := core.PutObjectPart(ctx, s.bucketName, objectKey, uploadID, partNumber, reader, size, minio.PutObjectPartOptions{}); err != nil {
+		return fmt.Errorf("failed to upload part %d: %v", partNumber, err)
+	}
+	return nil
+}
+
+// CompleteMultipartUpload assembles every uploaded part into the object
+func (s *MinIOService) CompleteMultipartUpload(ctx context.Context, objectKey, uploadID string) error {
+	core := minio.Core{Client: s.client}
+
+	var parts []minio.CompletePart
+	marker := 0
+	for {
+		result, err := core.ListObjectParts(ctx, s.bucketName, objectKey, uploadID, marker, 1000)
+		if err != nil {
+			return fmt.Errorf("failed to list uploaded parts: %v", err)
+		}
+		for _, part := range result.ObjectParts {
+			parts = append(parts, minio.CompletePart{PartNumber: part.PartNumber, ETag: part.ETag})
+		}
+		if !result.IsTruncated {
+			break
+		}
+		marker = result.NextPartNumberMarker
+	}
+
+	if _, err := core.CompleteMultipartUpload(ctx, s.bucketName, objectKey, uploadID, parts, minio.PutObjectOptions{}); err != nil {
+		return fmt.Errorf("failed to complete multipart upload: %v", err)
+	}
+	return nil
+}
+
+// AbortMultipartUpload discards a multipart upload and its uploaded parts
+func (s *MinIOService) AbortMultipartUpload(ctx context.Context, objectKey, uploadID string) error {
+	core := minio.Core{Client: s.client}
+	if err := core.AbortMultipartUpload(ctx, s.bucketName, objectKey, uploadID); err != nil {
+		return fmt.Errorf("failed to abort multipart upload: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,496 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"forgecrud-backend/shared/config"
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models"
+	"forgecrud-backend/shared/database/models/document"
+	docUtils "forgecrud-backend/shared/utils/document"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// organizationStorageTTL is how long the storage settings of an organization are used before they are
+// read again, so changes made through another replica are picked up
+const organizationStorageTTL = time.Minute
+
+// organizationStorageProbe is the object written and removed to test the storage of an organization
+const organizationStorageProbe = ".forgecrud-probe"
+
+// organizationStorageEntry is the cached storage of an organization
+type organizationStorageEntry struct {
+	settings *models.OrganizationStorage // nil while the organization uses the default storage
+	provider *MinIOService               // Connected on first use
+	loadedAt time.Time
+}
+
+// organizationStorages caches the storage of the organizations for the providers of all requests
+var organizationStorages = struct {
+	sync.Mutex
+	entries map[uuid.UUID]*organizationStorageEntry
+}{entries: map[uuid.UUID]*organizationStorageEntry{}}
+
+// OrganizationStorageUsage is what an organization stores in its own storage
+type OrganizationStorageUsage struct {
+	Objects     int64 `json:"objects"`      // Document versions, the ones in the trash included
+	StoredBytes int64 `json:"stored_bytes"` // Size of those versions
+	Uploads     int64 `json:"uploads"`      // Chunked uploads in progress
+	// Versions of the organization's documents still in the default storage, moved by cmd/migrate-storage
+	DefaultStorageObjects int64 `json:"default_storage_objects"`
+	DefaultStorageBytes   int64 `json:"default_storage_bytes"`
+}
+
+// GetOrganizationStorage returns the storage settings of an organization, nil when it uses the default storage
+func GetOrganizationStorage(organizationID uuid.UUID) (*models.OrganizationStorage, error) {
+	organizationStorages.Lock()
+	defer organizationStorages.Unlock()
+
+	entry, err := loadOrganizationStorage(organizationID)
+	if err != nil {
+		return nil, err
+	}
+	return entry.settings, nil
+}
+
+// InvalidateOrganizationStorage drops the cached storage of an organization after its settings changed
+func InvalidateOrganizationStorage(organizationID uuid.UUID) {
+	organizationStorages.Lock()
+	defer organizationStorages.Unlock()
+
+	if entry, ok := organizationStorages.entries[organizationID]; ok {
+		if entry.provider != nil {
+			entry.provider.Close()
+		}
+		delete(organizationStorages.entries, organizationID)
+	}
+}
+
+// OrganizationObjectKey returns the key a file uploaded into the folder is stored under: in the own
+// storage of the folder's organization when it has one, otherwise the key itself
+func OrganizationObjectKey(db *gorm.DB, folder *document.Folder, objectKey string) (string, error) {
+	organizationID := database.FolderOrganizationID(db, folder)
+	if organizationID == nil {
+		return objectKey, nil
+	}
+
+	settings, err := GetOrganizationStorage(*organizationID)
+	if err != nil {
+		return "", fmt.Errorf("failed to load the storage of organization %s: %v", *organizationID, err)
+	}
+	if settings == nil {
+		return objectKey, nil
+	}
+	return docUtils.OrganizationStorageKey(*organizationID, objectKey), nil
+}
+
+// UsesContentStore reports whether the file stored at a key is shared through the content store. Files
+// in the own storage of an organization stay there, isolated from the files of other organizations.
+func UsesContentStore(objectKey string) bool {
+	return !docUtils.IsOrganizationStorageKey(objectKey)
+}
+
+// ResolveOrganizationStorage fills the empty endpoint, region and credentials of an organization's
+// storage from the default storage. Credentials are only taken over on the endpoint of the default storage.
+func ResolveOrganizationStorage(settings models.OrganizationStorage) (models.OrganizationStorage, error) {
+	defaults, ok := defaultS3Storage()
+	if settings.Endpoint == "" {
+		if !ok {
+			return settings, fmt.Errorf("an endpoint is required while STORAGE_DRIVER is %s", config.GetConfig().StorageDriver)
+		}
+		settings.Endpoint, settings.UseSSL = defaults.Endpoint, defaults.UseSSL
+	}
+
+	onDefaultEndpoint := ok && settings.Endpoint == defaults.Endpoint
+	if settings.Region == "" && onDefaultEndpoint {
+		settings.Region = defaults.Region
+	}
+	if settings.AccessKeyID == "" {
+		if !onDefaultEndpoint {
+			return settings, errors.New("credentials are required for an endpoint other than the default storage's")
+		}
+		settings.AccessKeyID, settings.SecretAccessKey = defaults.AccessKeyID, defaults.SecretAccessKey
+	}
+	return settings, nil
+}
+
+// SharesDefaultBucket reports whether the resolved storage of an organization is the bucket of the
+// default storage
+func SharesDefaultBucket(settings models.OrganizationStorage) bool {
+	defaults, ok := defaultS3Storage()
+	return ok && settings.Endpoint == defaults.Endpoint && settings.Bucket == defaults.Bucket
+}
+
+// TestOrganizationStorage connects to the storage of an organization and writes and removes an object
+// under its prefix. A bucket of its own is created when missing.
+func TestOrganizationStorage(settings models.OrganizationStorage) error {
+	provider, err := connectOrganizationStorage(settings)
+	if err != nil {
+		return err
+	}
+	defer provider.Close()
+
+	ctx := context.Background()
+	probeKey := prefixedObjectKey(settings.Prefix, organizationStorageProbe)
+	if err := provider.PutObject(ctx, probeKey, strings.NewReader(""), 0, "text/plain"); err != nil {
+		return fmt.Errorf("failed to write to bucket %s: %v", settings.Bucket, err)
+	}
+	if err := provider.RemoveObject(ctx, probeKey); err != nil {
+		return fmt.Errorf("failed to remove from bucket %s: %v", settings.Bucket, err)
+	}
+	return nil
+}
+
+// GetOrganizationStorageUsage accounts what an organization stores in its own storage and what it still
+// stores in the default storage
+func GetOrganizationStorageUsage(db *gorm.DB, organizationID uuid.UUID) (OrganizationStorageUsage, error) {
+	var usage OrganizationStorageUsage
+	pattern := docUtils.OrganizationStorageKey(organizationID, "") + "%"
+
+	var stored struct {
+		Objects     int64
+		StoredBytes int64
+	}
+	if err := db.Raw(`
+		SELECT COUNT(*) AS objects, COALESCE(SUM(file_size), 0) AS stored_bytes
+		FROM document_versions
+		WHERE object_key LIKE ?`, pattern).Scan(&stored).Error; err != nil {
+		return usage, err
+	}
+	usage.Objects, usage.StoredBytes = stored.Objects, stored.StoredBytes
+
+	if err := db.Model(&document.UploadSession{}).
+		Where("storage_key LIKE ? AND status IN ?", pattern, []string{document.UploadSessionUploading, document.UploadSessionCompleting}).
+		Count(&usage.Uploads).Error; err != nil {
+		return usage, err
+	}
+
+	var remaining struct {
+		Objects     int64
+		StoredBytes int64
+	}
+	if err := db.Raw(`
+		SELECT COUNT(*) AS objects, COALESCE(SUM(v.file_size), 0) AS stored_bytes
+		FROM document_versions v
+		JOIN documents d ON d.id = v.document_id
+		JOIN folders f ON f.id = d.folder_id
+		WHERE v.object_key NOT LIKE ? AND (
+			(f.owner_type = 'organization' AND f.owner_id = ?) OR
+			(f.owner_type = 'user' AND f.owner_id IN (SELECT id FROM users WHERE organization_id = ?))
+		)`, docUtils.OrganizationStoragePrefix+"%", organizationID, organizationID).Scan(&remaining).Error; err != nil {
+		return usage, err
+	}
+	usage.DefaultStorageObjects, usage.DefaultStorageBytes = remaining.Objects, remaining.StoredBytes
+	return usage, nil
+}
+
+// loadOrganizationStorage returns the cached storage of an organization, reading its settings again once
+// they expired. The connection is kept while the settings did not change. Callers hold the lock.
+func loadOrganizationStorage(organizationID uuid.UUID) (*organizationStorageEntry, error) {
+	entry := organizationStorages.entries[organizationID]
+	if entry != nil && time.Since(entry.loadedAt) < organizationStorageTTL {
+		return entry, nil
+	}
+
+	db := database.GetDB()
+	if db == nil {
+		return nil, errors.New("database not initialized")
+	}
+
+	var settings *models.OrganizationStorage
+	var row models.OrganizationStorage
+	err := db.Where("organization_id = ?", organizationID).Take(&row).Error
+	switch {
+	case err == nil:
+		settings = &row
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, err
+	}
+
+	var provider *MinIOService
+	if entry != nil && entry.provider != nil {
+		if settings != nil && entry.settings != nil && settings.UpdatedAt.Equal(entry.settings.UpdatedAt) {
+			provider = entry.provider
+		} else {
+			entry.provider.Close()
+		}
+	}
+
+	entry = &organizationStorageEntry{settings: settings, provider: provider, loadedAt: time.Now()}
+	organizationStorages.entries[organizationID] = entry
+	return entry, nil
+}
+
+// organizationStorageProvider returns the connected storage of an organization and its settings
+func organizationStorageProvider(organizationID uuid.UUID) (*MinIOService, *models.OrganizationStorage, error) {
+	organizationStorages.Lock()
+	defer organizationStorages.Unlock()
+
+	entry, err := loadOrganizationStorage(organizationID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load the storage of organization %s: %v", organizationID, err)
+	}
+	if entry.settings == nil {
+		return nil, nil, fmt.Errorf("organization %s has no storage of its own", organizationID)
+	}
+	if entry.provider == nil {
+		provider, err := connectOrganizationStorage(*entry.settings)
+		if err != nil {
+			return nil, nil, err
+		}
+		entry.provider = provider
+	}
+	return entry.provider, entry.settings, nil
+}
+
+// connectOrganizationStorage connects to the storage of an organization. Buckets of their own are created
+// when missing; prefixes in a shared bucket are used as they are, their credentials may not reach the bucket.
+func connectOrganizationStorage(settings models.OrganizationStorage) (*MinIOService, error) {
+	resolved, err := ResolveOrganizationStorage(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	name := fmt.Sprintf("storage of organization %s", settings.OrganizationID)
+	provider, err := connectS3Compatible(name, resolved.Endpoint, resolved.UseSSL, resolved.Region, resolved.AccessKeyID, resolved.SecretAccessKey, resolved.Bucket)
+	if err != nil {
+		return nil, err
+	}
+	if strings.Trim(resolved.Prefix, "/") == "" {
+		if err := provider.initializeBucket(); err != nil {
+			provider.Close()
+			return nil, err
+		}
+	}
+	return provider, nil
+}
+
+// defaultS3Storage returns the connection of the default storage when it is S3 compatible
+func defaultS3Storage() (models.OrganizationStorage, bool) {
+	cfg := config.GetConfig()
+	switch cfg.StorageDriver {
+	case StorageDriverMinIO, "":
+		return models.OrganizationStorage{Endpoint: cfg.MinIOServerURL, UseSSL: cfg.MinIOUseSSL, Bucket: cfg.MinIOBucketName,
+			AccessKeyID: cfg.MinIORootUser, SecretAccessKey: cfg.MinIORootPassword}, true
+	case StorageDriverS3:
+		return models.OrganizationStorage{Endpoint: cfg.S3Endpoint, UseSSL: cfg.S3UseSSL, Region: cfg.S3Region, Bucket: cfg.S3BucketName,
+			AccessKeyID: cfg.S3AccessKeyID, SecretAccessKey: cfg.S3SecretAccessKey}, true
+	case StorageDriverGCS:
+		return models.OrganizationStorage{Endpoint: "https://storage.googleapis.com", UseSSL: true, Region: "auto", Bucket: cfg.GCSBucketName,
+			AccessKeyID: cfg.GCSAccessKeyID, SecretAccessKey: cfg.GCSSecretAccessKey}, true
+	}
+	return models.OrganizationStorage{}, false
+}
+
+// prefixedObjectKey returns the key of an object under the prefix of an organization's storage
+func prefixedObjectKey(prefix, objectKey string) string {
+	if prefix = strings.Trim(prefix, "/"); prefix == "" {
+		return objectKey
+	}
+	return prefix + "/" + objectKey
+}
+
+// storageRouter stores the files of organizations with their own storage in it, by the organization in
+// their key, and everything else in the default storage. Folders only exist in the default storage.
+type storageRouter struct {
+	StorageProvider // The default storage
+}
+
+// route returns the storage holding an object and the object's key in it
+func (r *storageRouter) route(objectKey string) (StorageProvider, string, error) {
+	organizationID, key, ok := docUtils.SplitOrganizationStorageKey(objectKey)
+	if !ok {
+		return r.StorageProvider, objectKey, nil
+	}
+
+	provider, settings, err := organizationStorageProvider(organizationID)
+	if err != nil {
+		return nil, "", err
+	}
+	return provider, prefixedObjectKey(settings.Prefix, key), nil
+}
+
+func (r *storageRouter) PutObject(ctx context.Context, objectKey string, reader io.Reader, size int64, contentType string) error {
+	provider, key, err := r.route(objectKey)
+	if err != nil {
+		return err
+	}
+	return provider.PutObject(ctx, key, reader, size, contentType)
+}
+
+func (r *storageRouter) GetObject(ctx context.Context, objectKey string) (io.ReadCloser, ObjectInfo, error) {
+	provider, key, err := r.route(objectKey)
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+	reader, info, err := provider.GetObject(ctx, key)
+	info.Key = objectKey
+	return reader, info, err
+}
+
+func (r *storageRouter) GetObjectRange(ctx context.Context, objectKey string, start, end int64) (io.ReadCloser, error) {
+	provider, key, err := r.route(objectKey)
+	if err != nil {
+		return nil, err
+	}
+	return provider.GetObjectRange(ctx, key, start, end)
+}
+
+func (r *storageRouter) StatObject(ctx context.Context, objectKey string) (ObjectInfo, error) {
+	provider, key, err := r.route(objectKey)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	info, err := provider.StatObject(ctx, key)
+	info.Key = objectKey
+	return info, err
+}
+
+// WalkObjects walks the storage holding the prefix. Walking everything walks the default storage and
+// then the storage of every organization that has its own.
+func (r *storageRouter) WalkObjects(ctx context.Context, prefix string, fn func(ObjectInfo) error) error {
+	if organizationID, key, ok := docUtils.SplitOrganizationStorageKey(prefix); ok {
+		return r.walkOrganization(ctx, organizationID, key, fn)
+	}
+
+	// Organizations sharing the default bucket keep their files under orgs/
+	err := r.StorageProvider.WalkObjects(ctx, prefix, func(info ObjectInfo) error {
+		if strings.HasPrefix(info.Key, docUtils.OrganizationStoragePrefix) {
+			return nil
+		}
+		return fn(info)
+	})
+	if err != nil || prefix != "" {
+		return err
+	}
+
+	db := database.GetDB()
+	if db == nil {
+		return nil
+	}
+	var organizationIDs []uuid.UUID
+	if err := db.Model(&models.OrganizationStorage{}).Pluck("organization_id", &organizationIDs).Error; err != nil {
+		return err
+	}
+	for _, organizationID := range organizationIDs {
+		if err := r.walkOrganization(ctx, organizationID, "", fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// walkOrganization walks the own storage of an organization, reporting the objects by their routed key
+func (r *storageRouter) walkOrganization(ctx context.Context, organizationID uuid.UUID, prefix string, fn func(ObjectInfo) error) error {
+	provider, settings, err := organizationStorageProvider(organizationID)
+	if err != nil {
+		return err
+	}
+
+	storagePrefix := prefixedObjectKey(settings.Prefix, "")
+	return provider.WalkObjects(ctx, storagePrefix+prefix, func(info ObjectInfo) error {
+		if strings.HasSuffix(info.Key, organizationStorageProbe) {
+			return nil
+		}
+		info.Key = docUtils.OrganizationStorageKey(organizationID, strings.TrimPrefix(info.Key, storagePrefix))
+		return fn(info)
+	})
+}
+
+func (r *storageRouter) CopyObject(sourceKey, destKey string) error {
+	source, sourceStorageKey, err := r.route(sourceKey)
+	if err != nil {
+		return err
+	}
+	dest, destStorageKey, err := r.route(destKey)
+	if err != nil {
+		return err
+	}
+	if source == dest {
+		return source.CopyObject(sourceStorageKey, destStorageKey)
+	}
+	return copyBetweenStorages(source, sourceStorageKey, dest, destStorageKey)
+}
+
+func (r *storageRouter) MoveObject(sourceKey, destKey string) error {
+	source, sourceStorageKey, err := r.route(sourceKey)
+	if err != nil {
+		return err
+	}
+	dest, destStorageKey, err := r.route(destKey)
+	if err != nil {
+		return err
+	}
+	if source == dest {
+		return source.MoveObject(sourceStorageKey, destStorageKey)
+	}
+
+	if err := copyBetweenStorages(source, sourceStorageKey, dest, destStorageKey); err != nil {
+		return err
+	}
+	if err := source.RemoveObject(context.Background(), sourceStorageKey); err != nil {
+		log.Printf("⚠️  Failed to remove %s after moving it to %s: %v", sourceKey, destKey, err)
+	}
+	return nil
+}
+
+// copyBetweenStorages streams an object from one storage into another
+func copyBetweenStorages(source StorageProvider, sourceKey string, dest StorageProvider, destKey string) error {
+	ctx := context.Background()
+	reader, info, err := source.GetObject(ctx, sourceKey)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	return dest.PutObject(ctx, destKey, reader, info.Size, info.ContentType)
+}
+
+func (r *storageRouter) RemoveObject(ctx context.Context, objectKey string) error {
+	provider, key, err := r.route(objectKey)
+	if err != nil {
+		return err
+	}
+	return provider.RemoveObject(ctx, key)
+}
+
+func (r *storageRouter) NewMultipartUpload(ctx context.Context, objectKey, contentType string) (string, error) {
+	provider, key, err := r.route(objectKey)
+	if err != nil {
+		return "", err
+	}
+	return provider.NewMultipartUpload(ctx, key, contentType)
+}
+
+func (r *storageRouter) PutObjectPart(ctx context.Context, objectKey, uploadID string, partNumber int, reader io.Reader, size int64) error {
+	provider, key, err := r.route(objectKey)
+	if err != nil {
+		return err
+	}
+	return provider.PutObjectPart(ctx, key, uploadID, partNumber, reader, size)
+}
+
+func (r *storageRouter) CompleteMultipartUpload(ctx context.Context, objectKey, uploadID string) error {
+	provider, key, err := r.route(objectKey)
+	if err != nil {
+		return err
+	}
+	return provider.CompleteMultipartUpload(ctx, key, uploadID)
+}
+
+func (r *storageRouter) AbortMultipartUpload(ctx context.Context, objectKey, uploadID string) error {
+	provider, key, err := r.route(objectKey)
+	if err != nil {
+		return err
+	}
+	return provider.AbortMultipartUpload(ctx, key, uploadID)
+}
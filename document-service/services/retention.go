@@ -0,0 +1,191 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models/document"
+	"forgecrud-backend/shared/database/models/notification"
+	"forgecrud-backend/shared/readonly"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const retentionBatchSize = 100
+
+// DocumentDeletionBlock returns why the document may not be deleted or purged, empty when it may:
+// it is under legal hold or younger than the minimum retention of a policy on one of its folders
+func DocumentDeletionBlock(db *gorm.DB, doc *document.Document) (string, error) {
+	var held int64
+	if err := db.Unscoped().Model(&document.Document{}).
+		Where("documents.id = ?", doc.ID).
+		Where(database.DocumentLegalHoldCondition("documents")).
+		Count(&held).Error; err != nil {
+		return "", err
+	}
+	if held > 0 {
+		return "Document is under legal hold", nil
+	}
+
+	var days sql.NullInt64
+	if err := db.Raw("SELECT MAX(rp.min_retention_days) FROM retention_policies rp "+
+		"JOIN folders a ON a.id = rp.folder_id JOIN folders f ON f.path = a.path OR f.path LIKE a.path || '/%' "+
+		"WHERE f.id = ?", doc.FolderID).Row().Scan(&days); err != nil {
+		return "", err
+	}
+	if days.Valid && days.Int64 > 0 {
+		retainedUntil := doc.CreatedAt.Add(time.Duration(days.Int64) * 24 * time.Hour)
+		if retainedUntil.After(time.Now()) {
+			return fmt.Sprintf("Document is retained until %s by a retention policy", retainedUntil.Format("2006-01-02")), nil
+		}
+	}
+	return "", nil
+}
+
+// FolderDeletionBlock returns why the folder may not be deleted or purged, empty when it may: it or
+// one of its parent folders is under legal hold
+func FolderDeletionBlock(db *gorm.DB, folder *document.Folder) (string, error) {
+	var held int64
+	if err := db.Unscoped().Model(&document.Folder{}).
+		Where("folders.id = ?", folder.ID).
+		Where(database.FolderLegalHoldCondition("folders")).
+		Count(&held).Error; err != nil {
+		return "", err
+	}
+	if held > 0 {
+		return "Folder is under legal hold", nil
+	}
+	return "", nil
+}
+
+// DocumentMoveBlock returns why the document may not be moved to the target folder, empty when it
+// may: moving it would take it out of a folder under legal hold
+func DocumentMoveBlock(db *gorm.DB, doc *document.Document, target *document.Folder) (string, error) {
+	leaves, err := leavesLegalHold(db, doc.FolderID, uuid.Nil, target.Path)
+	if err != nil || !leaves {
+		return "", err
+	}
+	return "Document is in a folder under legal hold and cannot be moved out of it", nil
+}
+
+// FolderMoveBlock returns why the folder may not be moved under the target parent (the root when nil),
+// empty when it may: moving it would take it out of a parent folder under legal hold. Holds on the
+// folder itself move with it.
+func FolderMoveBlock(db *gorm.DB, folder, targetParent *document.Folder) (string, error) {
+	targetPath := ""
+	if targetParent != nil {
+		targetPath = targetParent.Path
+	}
+	leaves, err := leavesLegalHold(db, folder.ID, folder.ID, targetPath)
+	if err != nil || !leaves {
+		return "", err
+	}
+	return "Folder is in a folder under legal hold and cannot be moved out of it", nil
+}
+
+// leavesLegalHold reports whether a held folder contains the source folder but not the target path,
+// ignoring holds on the excluded folder
+func leavesLegalHold(db *gorm.DB, sourceFolderID, excludeFolderID uuid.UUID, targetPath string) (bool, error) {
+	var count int64
+	err := db.Raw("SELECT COUNT(*) FROM legal_holds h JOIN folders a ON a.id = h.object_id JOIN folders s ON s.id = ? "+
+		"WHERE h.released_at IS NULL AND h.object_type = 'folder' AND a.id <> ? AND (s.path = a.path OR s.path LIKE a.path || '/%') "+
+		"AND NOT (? = a.path OR ? LIKE a.path || '/%')", sourceFolderID, excludeFolderID, targetPath, targetPath).
+		Row().Scan(&count)
+	return count > 0, err
+}
+
+// RetentionEnforcer moves documents past the auto-delete age of their retention policies to the trash
+type RetentionEnforcer struct{}
+
+func NewRetentionEnforcer() *RetentionEnforcer {
+	return &RetentionEnforcer{}
+}
+
+// Start deletes expired documents in the background
+func (e *RetentionEnforcer) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if readonly.Active() {
+				<-ticker.C
+				continue
+			}
+			deleted, err := DeleteExpiredDocuments(database.DB)
+			if err != nil {
+				log.Printf("❌ Retention enforcement failed: %v", err)
+			} else if deleted > 0 {
+				log.Printf("🗄️  Retention policies moved %d documents to the trash", deleted)
+			}
+
+			<-ticker.C
+		}
+	}()
+
+	log.Printf("🗄️  Retention enforcer started (interval: %s)", interval)
+}
+
+// DeleteExpiredDocuments moves the documents older than the auto-delete age of a policy on one of
+// their folders to the trash, where they are purged after the trash retention. Documents under legal
+// hold or within the minimum retention of another policy are kept. Every deletion is audited.
+func DeleteExpiredDocuments(db *gorm.DB) (int, error) {
+	deleted := 0
+	lastID := uuid.Nil
+
+	for {
+		var documents []document.Document
+		if err := db.Where("documents.id > ?", lastID).
+			Where(database.DocumentExpiredCondition("documents")).
+			Not(database.DocumentDeletionBlockedCondition("documents")).
+			Order("documents.id").
+			Limit(retentionBatchSize).
+			Find(&documents).Error; err != nil {
+			return deleted, err
+		}
+		if len(documents) == 0 {
+			break
+		}
+		lastID = documents[len(documents)-1].ID
+
+		folderIDs := map[uuid.UUID]bool{}
+		if err := db.Transaction(func(tx *gorm.DB) error {
+			for i := range documents {
+				if err := tx.Delete(&documents[i]).Error; err != nil {
+					return err
+				}
+				if err := tx.Create(&notification.AuditLog{
+					Method:     "RETENTION",
+					Path:       "/api/documents/" + documents[i].ID.String(),
+					StatusCode: http.StatusOK,
+					RequestBody: map[string]interface{}{
+						"action":     "auto_delete",
+						"file_name":  documents[i].OriginalName,
+						"folder_id":  documents[i].FolderID,
+						"created_at": documents[i].CreatedAt,
+					},
+				}).Error; err != nil {
+					return err
+				}
+				folderIDs[documents[i].FolderID] = true
+			}
+			return nil
+		}); err != nil {
+			return deleted, err
+		}
+		deleted += len(documents)
+
+		for folderID := range folderIDs {
+			if err := UpdateFolderStats(db, folderID); err != nil {
+				log.Printf("⚠️  Failed to update folder stats of %s: %v", folderID, err)
+			}
+		}
+	}
+
+	return deleted, nil
+}
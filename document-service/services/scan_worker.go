@@ -0,0 +1,257 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"forgecrud-backend/shared/config"
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models"
+	"forgecrud-backend/shared/database/models/document"
+	"forgecrud-backend/shared/messaging"
+	"forgecrud-backend/shared/readonly"
+	docUtils "forgecrud-backend/shared/utils/document"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	scanBatchSize = 10
+	// scanStaleAfter is how long a scan may run before another worker picks the version up again
+	scanStaleAfter = 30 * time.Minute
+)
+
+// ScanWorker scans quarantined uploads and releases clean files to the content store
+type ScanWorker struct {
+	storage StorageProvider
+	scanner Scanner
+}
+
+func NewScanWorker(storage StorageProvider, scanner Scanner) *ScanWorker {
+	return &ScanWorker{storage: storage, scanner: scanner}
+}
+
+// Start scans pending uploads in the background
+func (w *ScanWorker) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if readonly.Active() {
+				continue
+			}
+			if err := w.ScanPending(); err != nil {
+				log.Printf("⚠️  Malware scan failed: %v", err)
+			}
+		}
+	}()
+
+	log.Printf("🦠 Malware scan worker started (interval: %s)", interval)
+}
+
+// ScanPending claims a batch of pending versions and scans them. Versions are claimed with SKIP LOCKED
+// so several document-service instances can scan in parallel.
+func (w *ScanWorker) ScanPending() error {
+	var versions []document.DocumentVersion
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("scan_status = ? OR (scan_status = ? AND scan_started_at < ?)",
+				document.ScanStatusPending, document.ScanStatusScanning, time.Now().Add(-scanStaleAfter)).
+			Order("created_at").
+			Limit(scanBatchSize).
+			Find(&versions).Error; err != nil {
+			return err
+		}
+		if len(versions) == 0 {
+			return nil
+		}
+
+		ids := make([]uuid.UUID, len(versions))
+		for i, version := range versions {
+			ids[i] = version.ID
+		}
+		return tx.Model(&document.DocumentVersion{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+			"scan_status":     document.ScanStatusScanning,
+			"scan_started_at": time.Now(),
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, version := range versions {
+		w.scanVersion(version)
+	}
+	return nil
+}
+
+// scanVersion scans one quarantined version and records the verdict
+func (w *ScanWorker) scanVersion(version document.DocumentVersion) {
+	quarantineKey := docUtils.QuarantineKey(version.ObjectKey)
+
+	result, contentHash, err := w.scan(quarantineKey)
+	if err != nil && !errors.Is(err, ErrScanRejected) {
+		// Scanner or storage unavailable, retry on the next run
+		log.Printf("⚠️  Scan of document %s version %d failed, retrying: %v", version.DocumentID, version.Version, err)
+		w.setScanStatus(version, document.ScanStatusPending, "")
+		return
+	}
+
+	switch {
+	case err != nil:
+		w.setScanStatus(version, document.ScanStatusFailed, err.Error())
+		w.notifyAdmins(version, fmt.Sprintf("could not be scanned for malware (%v) and is blocked from download", err))
+	case result.Infected:
+		log.Printf("🦠 Document %s version %d is infected: %s", version.DocumentID, version.Version, result.Signature)
+		w.setScanStatus(version, document.ScanStatusInfected, result.Signature)
+		w.notifyAdmins(version, fmt.Sprintf("is infected with %s and is blocked from download", result.Signature))
+	default:
+		if err := w.release(version, quarantineKey, contentHash); err != nil {
+			log.Printf("⚠️  Failed to release document %s version %d from quarantine: %v", version.DocumentID, version.Version, err)
+			w.setScanStatus(version, document.ScanStatusPending, "")
+		}
+	}
+}
+
+// scan scans a stored file and returns the SHA-256 of its content when it is clean
+func (w *ScanWorker) scan(objectKey string) (ScanResult, string, error) {
+	object, _, err := w.storage.GetObject(context.Background(), objectKey)
+	if err != nil {
+		return ScanResult{}, "", err
+	}
+	defer object.Close()
+
+	hash := sha256.New()
+	reader := io.TeeReader(object, hash)
+
+	result, err := w.scanner.Scan(context.Background(), reader)
+	if err != nil || result.Infected {
+		return result, "", err
+	}
+
+	// Hash whatever the scanner left unread
+	if _, err := io.Copy(io.Discard, reader); err != nil {
+		return ScanResult{}, "", err
+	}
+	return result, fmt.Sprintf("%x", hash.Sum(nil)), nil
+}
+
+// release moves a clean version from quarantine into the content store and marks it and its document
+// clean. Identical files released before are shared instead of stored again. Versions in the own storage
+// of an organization are moved to their object key instead.
+func (w *ScanWorker) release(version document.DocumentVersion, quarantineKey, contentHash string) error {
+	isolated := !UsesContentStore(version.ObjectKey)
+	if isolated {
+		if err := w.storage.MoveObject(quarantineKey, version.ObjectKey); err != nil {
+			return err
+		}
+		contentHash = ""
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if !isolated {
+			if err := AddContentReference(tx, w.storage, contentHash, quarantineKey, version.FileSize); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&version).Updates(map[string]interface{}{
+			"scan_status":  document.ScanStatusClean,
+			"scan_result":  "",
+			"scanned_at":   time.Now(),
+			"content_hash": contentHash,
+		}).Error; err != nil {
+			return err
+		}
+
+		// Documents in the trash are released too, they may be restored
+		return tx.Unscoped().Model(&document.Document{}).
+			Where("id = ? AND object_key = ?", version.DocumentID, version.ObjectKey).
+			Updates(map[string]interface{}{
+				"scan_status":  document.ScanStatusClean,
+				"content_hash": contentHash,
+			}).Error
+	})
+	if err != nil {
+		if isolated {
+			// Back to quarantine, where the next run scans it again
+			w.storage.MoveObject(version.ObjectKey, quarantineKey)
+		}
+		return err
+	}
+
+	if isolated {
+		return nil
+	}
+	if err := w.storage.RemoveObject(context.Background(), quarantineKey); err != nil {
+		log.Printf("⚠️  Failed to remove released upload %s from quarantine: %v", quarantineKey, err)
+	}
+	return nil
+}
+
+// setScanStatus records the scan status of a version and of its document while it is the current version
+func (w *ScanWorker) setScanStatus(version document.DocumentVersion, status, result string) {
+	updates := map[string]interface{}{
+		"scan_status": status,
+		"scan_result": result,
+	}
+	if status != document.ScanStatusPending {
+		updates["scanned_at"] = time.Now()
+	}
+
+	if err := database.DB.Model(&version).Updates(updates).Error; err != nil {
+		log.Printf("⚠️  Failed to save scan status of document %s version %d: %v", version.DocumentID, version.Version, err)
+		return
+	}
+
+	database.DB.Model(&document.Document{}).
+		Where("id = ? AND object_key = ?", version.DocumentID, version.ObjectKey).
+		Update("scan_status", status)
+}
+
+// notifyAdmins tells the owner of the document's organization and the super admin about a blocked file
+func (w *ScanWorker) notifyAdmins(version document.DocumentVersion, problem string) {
+	db := database.DB
+
+	var doc document.Document
+	if err := db.Unscoped().Preload("Folder").First(&doc, version.DocumentID).Error; err != nil {
+		log.Printf("⚠️  Document %s of blocked upload not found: %v", version.DocumentID, err)
+		return
+	}
+
+	recipients := map[uuid.UUID]bool{}
+	if organizationID := database.FolderOrganizationID(db, &doc.Folder); organizationID != nil {
+		var organization models.Organization
+		if err := db.Select("owner_id").First(&organization, *organizationID).Error; err == nil {
+			recipients[organization.OwnerID] = true
+		}
+	}
+	var superAdmin models.User
+	if err := db.Select("id").Where("email = ?", config.GetConfig().SuperAdminEmail).First(&superAdmin).Error; err == nil {
+		recipients[superAdmin.ID] = true
+	}
+
+	for recipient := range recipients {
+		messaging.Publish(context.Background(), messaging.EventDocumentInfected, &version.CreatedBy, messaging.ActivityData{
+			OwnerID:      recipient,
+			ActionType:   "Malware Detected",
+			ResourceType: "document",
+			ResourceID:   doc.ID,
+			ResourceName: doc.OriginalName,
+			Description:  fmt.Sprintf("Version %d of document '%s' in folder '%s' %s.", version.Version, doc.OriginalName, doc.Folder.Path, problem),
+			Priority:     "high",
+			Changes: []messaging.ActivityChange{
+				{Field: "Scan Status", OldValue: document.ScanStatusPending, NewValue: "Blocked"},
+			},
+		})
+	}
+}
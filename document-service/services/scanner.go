@@ -0,0 +1,139 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"forgecrud-backend/shared/config"
+)
+
+// ErrScanRejected is returned when the scanner refuses a file, e.g. because it exceeds the scanner's size limit.
+// Retrying does not help, so such files are marked as failed.
+var ErrScanRejected = errors.New("file rejected by scanner")
+
+// ScanResult is the verdict of a malware scan
+type ScanResult struct {
+	Infected  bool
+	Signature string // Name of the malware found
+}
+
+// Scanner scans file contents for malware
+type Scanner interface {
+	Scan(ctx context.Context, r io.Reader) (ScanResult, error)
+}
+
+// NewScanner returns the scanner selected by SCANNER_DRIVER, or nil when scanning is disabled
+func NewScanner() (Scanner, error) {
+	cfg := config.GetConfig()
+
+	switch cfg.ScannerDriver {
+	case "", "none":
+		return nil, nil
+	case "clamav":
+		return &ClamAVScanner{
+			address: cfg.ClamAVAddress,
+			timeout: time.Duration(cfg.ClamAVTimeoutSeconds) * time.Second,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown scanner driver %q", cfg.ScannerDriver)
+	}
+}
+
+// ScanningEnabled reports whether uploads go through the malware scan
+func ScanningEnabled() bool {
+	driver := config.GetConfig().ScannerDriver
+	return driver != "" && driver != "none"
+}
+
+const clamAVChunkSize = 64 * 1024
+
+// errClamdWrite marks failures writing to clamd, after which its reply may still explain the failure
+var errClamdWrite = errors.New("failed to stream file to clamd")
+
+// ClamAVScanner streams files to clamd using the INSTREAM command
+type ClamAVScanner struct {
+	address string
+	timeout time.Duration
+}
+
+// Scan sends the file to clamd and parses its verdict
+func (s *ClamAVScanner) Scan(ctx context.Context, r io.Reader) (ScanResult, error) {
+	dialer := net.Dialer{Timeout: 10 * time.Second}
+	conn, err := dialer.DialContext(ctx, "tcp", s.address)
+	if err != nil {
+		return ScanResult{}, fmt.Errorf("failed to connect to clamd: %v", err)
+	}
+	defer conn.Close()
+
+	if s.timeout > 0 {
+		conn.SetDeadline(time.Now().Add(s.timeout))
+	}
+
+	// clamd closes the stream early when the file exceeds its limit, the reply then tells why
+	writeErr := s.stream(conn, r)
+	if writeErr != nil && !errors.Is(writeErr, errClamdWrite) {
+		return ScanResult{}, writeErr
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && reply == "" {
+		if writeErr != nil {
+			return ScanResult{}, writeErr
+		}
+		return ScanResult{}, fmt.Errorf("failed to read clamd reply: %v", err)
+	}
+	reply = strings.TrimSpace(strings.TrimRight(reply, "\x00"))
+
+	switch {
+	case strings.HasSuffix(reply, " OK"):
+		return ScanResult{}, nil
+	case strings.HasSuffix(reply, " FOUND"):
+		signature := strings.TrimSuffix(strings.TrimPrefix(reply, "stream: "), " FOUND")
+		return ScanResult{Infected: true, Signature: signature}, nil
+	case strings.HasSuffix(reply, " ERROR"):
+		return ScanResult{}, fmt.Errorf("%w: %s", ErrScanRejected, strings.TrimSuffix(reply, " ERROR"))
+	default:
+		return ScanResult{}, fmt.Errorf("unexpected clamd reply %q", reply)
+	}
+}
+
+// stream writes the file as INSTREAM chunks: a 4-byte big-endian length followed by the data, ended by a zero length
+func (s *ClamAVScanner) stream(conn net.Conn, r io.Reader) error {
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return fmt.Errorf("%w: %v", errClamdWrite, err)
+	}
+
+	buf := make([]byte, clamAVChunkSize)
+	size := make([]byte, 4)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			binary.BigEndian.PutUint32(size, uint32(n))
+			if _, err := conn.Write(size); err != nil {
+				return fmt.Errorf("%w: %v", errClamdWrite, err)
+			}
+			if _, err := conn.Write(buf[:n]); err != nil {
+				return fmt.Errorf("%w: %v", errClamdWrite, err)
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read file: %v", err)
+		}
+	}
+
+	binary.BigEndian.PutUint32(size, 0)
+	if _, err := conn.Write(size); err != nil {
+		return fmt.Errorf("%w: %v", errClamdWrite, err)
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"forgecrud-backend/shared/database/models/document"
+	docUtils "forgecrud-backend/shared/utils/document"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// storageMigrationBatchSize is the number of versions loaded at once by a storage migration
+const storageMigrationBatchSize = 100
+
+// StorageMigrationOptions selects what MigrateOrganizationStorage moves
+type StorageMigrationOptions struct {
+	ToDefault bool // Move the files out of the organization's own storage back into the default storage
+	DryRun    bool // Count the files without moving them
+}
+
+// StorageMigrationResult counts the files of a storage migration
+type StorageMigrationResult struct {
+	Moved   int   `json:"moved"`
+	Bytes   int64 `json:"bytes"`
+	Skipped int   `json:"skipped"` // Versions being scanned for malware, moved by a later run
+	Failed  int   `json:"failed"`
+}
+
+// MigrateOrganizationStorage moves the files of the organization's documents, versions in the trash
+// included, stored before the organization had its own storage into it, or back into the default
+// storage. Each version's file is copied, its records point to the copy once it is stored, and then the
+// original is removed; files shared through the content store are copied out of it. A failure leaves the
+// version where it was, so the migration can simply run again.
+func MigrateOrganizationStorage(db *gorm.DB, storage StorageProvider, organizationID uuid.UUID, options StorageMigrationOptions) (StorageMigrationResult, error) {
+	var result StorageMigrationResult
+
+	organizationPattern := docUtils.OrganizationStorageKey(organizationID, "") + "%"
+	query := db.Model(&document.DocumentVersion{})
+	if options.ToDefault {
+		query = query.Where("object_key LIKE ?", organizationPattern)
+	} else {
+		settings, err := GetOrganizationStorage(organizationID)
+		if err != nil {
+			return result, err
+		}
+		if settings == nil {
+			return result, fmt.Errorf("organization %s has no storage of its own", organizationID)
+		}
+		query = query.Where("object_key NOT LIKE ?", organizationPattern).
+			Where(`document_id IN (
+				SELECT d.id FROM documents d
+				JOIN folders f ON f.id = d.folder_id
+				WHERE (f.owner_type = 'organization' AND f.owner_id = ?) OR
+					(f.owner_type = 'user' AND f.owner_id IN (SELECT id FROM users WHERE organization_id = ?)))`, organizationID, organizationID)
+	}
+
+	var versions []document.DocumentVersion
+	err := query.FindInBatches(&versions, storageMigrationBatchSize, func(tx *gorm.DB, batch int) error {
+		for _, version := range versions {
+			// The scan worker looks the quarantined file up by its object key
+			if version.ScanStatus == document.ScanStatusPending || version.ScanStatus == document.ScanStatusScanning {
+				result.Skipped++
+				continue
+			}
+			if options.DryRun {
+				result.Moved++
+				result.Bytes += version.FileSize
+				continue
+			}
+
+			if err := migrateVersion(db, storage, version, organizationID, options.ToDefault); err != nil {
+				log.Printf("⚠️  Failed to migrate document %s version %d: %v", version.DocumentID, version.Version, err)
+				result.Failed++
+				continue
+			}
+			result.Moved++
+			result.Bytes += version.FileSize
+		}
+		return nil
+	}).Error
+	return result, err
+}
+
+// migrateVersion moves the file of a version into or out of the own storage of an organization
+func migrateVersion(db *gorm.DB, storage StorageProvider, version document.DocumentVersion, organizationID uuid.UUID, toDefault bool) error {
+	objectKey := version.ObjectKey
+	if _, key, ok := docUtils.SplitOrganizationStorageKey(objectKey); ok {
+		objectKey = "/" + key
+	}
+	newObjectKey := objectKey
+	if !toDefault {
+		newObjectKey = docUtils.OrganizationStorageKey(organizationID, objectKey)
+	}
+
+	// Blocked files stay in quarantine, clean ones outside the content store are stored under their key
+	quarantined := version.ContentHash == "" && !document.ScanStatusAllowsDownload(version.ScanStatus)
+	sourceKey, destKey := docUtils.StorageKey(version.ObjectKey, version.ContentHash), newObjectKey
+	if quarantined {
+		sourceKey, destKey = docUtils.QuarantineKey(version.ObjectKey), docUtils.QuarantineKey(newObjectKey)
+	}
+
+	if err := storage.CopyObject(sourceKey, destKey); err != nil {
+		return err
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&version).Updates(map[string]interface{}{
+			"object_key":   newObjectKey,
+			"content_hash": "",
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Model(&document.Document{}).
+			Where("id = ? AND object_key = ?", version.DocumentID, version.ObjectKey).
+			Updates(map[string]interface{}{
+				"object_key":   newObjectKey,
+				"content_hash": "",
+			}).Error
+	})
+	if err != nil {
+		storage.RemoveObject(context.Background(), destKey)
+		return err
+	}
+
+	if version.ContentHash != "" {
+		if err := ReleaseContent(db, storage, version.ContentHash); err != nil {
+			log.Printf("⚠️  Failed to release migrated content %s: %v", version.ContentHash, err)
+		}
+	} else if err := storage.RemoveObject(context.Background(), sourceKey); err != nil {
+		log.Printf("⚠️  Failed to remove migrated file %s: %v", sourceKey, err)
+	}
+	return nil
+}
@@ -0,0 +1,298 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"forgecrud-backend/shared/config"
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models/document"
+	"forgecrud-backend/shared/readonly"
+	"forgecrud-backend/shared/scheduler"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	storageOutboxBatchSize    = 50
+	storageOutboxBaseBackoff  = 30 * time.Second
+	storageReconcileBatchSize = 200
+)
+
+// QueueCreateFolder queues creating the folder in storage. Call it in the transaction that creates the
+// folder so the operation is only queued when the folder is committed.
+func QueueCreateFolder(tx *gorm.DB, folder *document.Folder) (*document.StorageOperation, error) {
+	return queueStorageOperation(tx, &document.StorageOperation{
+		Type:     document.StorageOperationCreateFolder,
+		FolderID: folder.ID,
+		Path:     folder.Path,
+	})
+}
+
+// QueueMoveFolder queues moving a folder with its contents in storage. Call it in the transaction that
+// changes the folder path.
+func QueueMoveFolder(tx *gorm.DB, folderID uuid.UUID, oldPath, newPath string) (*document.StorageOperation, error) {
+	return queueStorageOperation(tx, &document.StorageOperation{
+		Type:       document.StorageOperationMoveFolder,
+		FolderID:   folderID,
+		Path:       oldPath,
+		TargetPath: newPath,
+	})
+}
+
+// queueStorageOperation stores a pending operation. The worker picks it up after the first backoff,
+// leaving the first attempt to StorageOutbox.Run after the commit.
+func queueStorageOperation(tx *gorm.DB, op *document.StorageOperation) (*document.StorageOperation, error) {
+	op.Status = document.StorageOperationPending
+	op.NextAttemptAt = time.Now().UTC().Add(storageOutboxBaseBackoff)
+	if err := tx.Create(op).Error; err != nil {
+		return nil, fmt.Errorf("failed to queue storage operation: %v", err)
+	}
+	return op, nil
+}
+
+// StorageOutbox applies queued folder operations to storage and retries failed ones with exponential
+// backoff, so storage follows the database instead of silently diverging from it
+type StorageOutbox struct {
+	storage     StorageProvider
+	maxAttempts int
+}
+
+func NewStorageOutbox(storage StorageProvider) *StorageOutbox {
+	return &StorageOutbox{
+		storage:     storage,
+		maxAttempts: config.GetConfig().StorageOutboxMaxAttempts,
+	}
+}
+
+// Start applies due operations in the background
+func (o *StorageOutbox) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if readonly.Active() {
+				continue
+			}
+			if err := o.ProcessDue(); err != nil {
+				log.Printf("⚠️  Storage outbox processing failed: %v", err)
+			}
+		}
+	}()
+
+	log.Printf("📮 Storage outbox started (interval: %s)", interval)
+}
+
+// JobStorageReconcile is the scheduled queueing of folders missing in storage
+const JobStorageReconcile = "documents.storage_reconcile"
+
+// RegisterReconciler schedules the reconciliation of folders with storage
+func (o *StorageOutbox) RegisterReconciler(interval time.Duration) {
+	scheduler.Register(JobStorageReconcile, scheduler.Every(interval), func(ctx context.Context) error {
+		return o.Reconcile()
+	})
+	log.Printf("📮 Storage reconciliation scheduled (interval: %s)", interval)
+}
+
+// Run applies a queued operation right after its transaction committed. An operation that fails, or
+// that waits for an earlier operation on an overlapping path, is left to the background worker.
+func (o *StorageOutbox) Run(op *document.StorageOperation) {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var locked document.StorageOperation
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("id = ? AND status = ?", op.ID, document.StorageOperationPending).
+			First(&locked).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				// Already applied, or being applied by the worker
+				return nil
+			}
+			return err
+		}
+		return o.attempt(tx, &locked)
+	})
+	if err != nil {
+		log.Printf("⚠️  Failed to apply storage operation %s: %v", op.ID, err)
+	}
+}
+
+// ProcessDue applies a batch of pending operations whose next attempt is due, in the order they were
+// queued. Rows are locked with SKIP LOCKED so several document-service instances can process concurrently.
+func (o *StorageOutbox) ProcessDue() error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		var operations []document.StorageOperation
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", document.StorageOperationPending, time.Now().UTC()).
+			Order("created_at").
+			Limit(storageOutboxBatchSize).
+			Find(&operations).Error; err != nil {
+			return err
+		}
+
+		for i := range operations {
+			if err := o.attempt(tx, &operations[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Retry queues a failed operation again with a fresh attempt count and applies it
+func (o *StorageOutbox) Retry(op *document.StorageOperation) error {
+	result := database.DB.Model(op).
+		Where("status = ?", document.StorageOperationFailed).
+		Updates(map[string]interface{}{
+			"status":          document.StorageOperationPending,
+			"attempts":        0,
+			"next_attempt_at": time.Now().UTC().Add(storageOutboxBaseBackoff),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("only failed operations can be retried")
+	}
+
+	o.Run(op)
+	return database.DB.First(op, op.ID).Error
+}
+
+// Reconcile queues a create operation for every folder whose marker is missing from storage and that
+// has no pending operation. Folders in the trash are included, they keep their storage until purged.
+func (o *StorageOutbox) Reconcile() error {
+	queued := 0
+	lastID := uuid.Nil
+
+	for {
+		var folders []document.Folder
+		if err := database.DB.Unscoped().
+			Where("id > ?", lastID).
+			Where("id NOT IN (SELECT folder_id FROM storage_operations WHERE status = ?)", document.StorageOperationPending).
+			Order("id").
+			Limit(storageReconcileBatchSize).
+			Find(&folders).Error; err != nil {
+			return err
+		}
+		if len(folders) == 0 {
+			break
+		}
+		lastID = folders[len(folders)-1].ID
+
+		for i := range folders {
+			exists, err := o.storage.FolderExists(folders[i].Path)
+			if err != nil {
+				return fmt.Errorf("failed to check folder %s: %v", folders[i].Path, err)
+			}
+			if exists {
+				continue
+			}
+
+			op, err := QueueCreateFolder(database.DB, &folders[i])
+			if err != nil {
+				return err
+			}
+			o.Run(op)
+			queued++
+		}
+	}
+
+	if queued > 0 {
+		log.Printf("📮 Queued %d folders missing from storage", queued)
+	}
+	return nil
+}
+
+// attempt applies one operation and records its outcome
+func (o *StorageOutbox) attempt(tx *gorm.DB, op *document.StorageOperation) error {
+	// Operations on overlapping paths must reach storage in the order they were queued
+	blocked, err := o.waitsForEarlier(tx, op)
+	if err != nil {
+		return err
+	}
+	if blocked {
+		return tx.Model(op).Update("next_attempt_at", time.Now().UTC().Add(storageOutboxBaseBackoff)).Error
+	}
+
+	now := time.Now().UTC()
+	op.Attempts++
+
+	if err := o.apply(tx, op); err != nil {
+		op.LastError = err.Error()
+		if op.Attempts >= o.maxAttempts {
+			op.Status = document.StorageOperationFailed
+			log.Printf("❌ Storage operation %s (%s %s) failed after %d attempts: %v", op.ID, op.Type, op.Path, op.Attempts, err)
+		} else {
+			// 30s, 1m, 2m, 4m, ...
+			op.NextAttemptAt = now.Add(storageOutboxBaseBackoff << (op.Attempts - 1))
+		}
+	} else {
+		op.Status = document.StorageOperationSucceeded
+		op.CompletedAt = &now
+		op.LastError = ""
+	}
+
+	return tx.Model(op).Select("status", "attempts", "next_attempt_at", "last_error", "completed_at").Updates(op).Error
+}
+
+// apply performs the storage change. Both operations are safe to repeat: a folder marker is simply
+// written again and a move only copies the objects still left under the old path.
+func (o *StorageOutbox) apply(tx *gorm.DB, op *document.StorageOperation) error {
+	// Folders purged from the trash meanwhile need no storage anymore
+	var count int64
+	if err := tx.Unscoped().Model(&document.Folder{}).Where("id = ?", op.FolderID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return nil
+	}
+
+	switch op.Type {
+	case document.StorageOperationCreateFolder:
+		return o.storage.CreateFolder(op.Path)
+	case document.StorageOperationMoveFolder:
+		if err := o.storage.MoveFolder(op.Path, op.TargetPath); err != nil {
+			return err
+		}
+		// The marker is missing at the new path when the folder was never created in storage
+		return o.storage.CreateFolder(op.TargetPath)
+	default:
+		return fmt.Errorf("unknown storage operation type %q", op.Type)
+	}
+}
+
+// waitsForEarlier reports whether an earlier pending operation touches a path that overlaps the
+// operation's paths
+func (o *StorageOutbox) waitsForEarlier(tx *gorm.DB, op *document.StorageOperation) (bool, error) {
+	var earlier []document.StorageOperation
+	if err := tx.Select("path", "target_path").
+		Where("status = ? AND created_at < ? AND id <> ?", document.StorageOperationPending, op.CreatedAt, op.ID).
+		Find(&earlier).Error; err != nil {
+		return false, err
+	}
+
+	for _, other := range earlier {
+		for _, a := range []string{op.Path, op.TargetPath} {
+			for _, b := range []string{other.Path, other.TargetPath} {
+				if pathsOverlap(a, b) {
+					return true, nil
+				}
+			}
+		}
+	}
+	return false, nil
+}
+
+// pathsOverlap reports whether one folder path is the other or one of its ancestors
+func pathsOverlap(a, b string) bool {
+	if a == "" || b == "" {
+		return false
+	}
+	return a == b || strings.HasPrefix(a, b+"/") || strings.HasPrefix(b, a+"/")
+}
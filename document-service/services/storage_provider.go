@@ -0,0 +1,99 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"forgecrud-backend/shared/config"
+)
+
+// Storage drivers selectable with STORAGE_DRIVER
+const (
+	StorageDriverMinIO = "minio"
+	StorageDriverS3    = "s3"
+	StorageDriverGCS   = "gcs"
+	StorageDriverAzure = "azure"
+	StorageDriverLocal = "local"
+)
+
+// folderMarker is the empty object that keeps an empty folder in storage
+const folderMarker = ".foldermarker"
+
+// ErrObjectNotFound is returned when an object does not exist in storage
+var ErrObjectNotFound = errors.New("object not found")
+
+// ObjectInfo describes a stored object
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	ETag         string // The MD5 of the content for objects uploaded in one part to S3 compatible storage
+	ContentType  string
+	LastModified time.Time
+}
+
+// StorageProvider stores the document files. Keys are slash separated paths relative to the bucket,
+// container or directory of the provider; folders only exist through the objects under them and
+// their folder marker.
+type StorageProvider interface {
+	// TestConnection checks that the storage is reachable
+	TestConnection() error
+	// Ping checks that the storage is reachable without logging, for health checks
+	Ping(ctx context.Context) error
+	// Close releases the connections to the storage when the service shuts down
+	Close() error
+
+	CreateFolder(folderPath string) error
+	DeleteFolder(folderPath string) error
+	MoveFolder(oldPath, newPath string) error
+	FolderExists(folderPath string) (bool, error)
+	// ListFolderContents lists the keys directly in a folder, subfolders end with a slash
+	ListFolderContents(folderPath string) ([]string, error)
+
+	PutObject(ctx context.Context, objectKey string, reader io.Reader, size int64, contentType string) error
+	GetObject(ctx context.Context, objectKey string) (io.ReadCloser, ObjectInfo, error)
+	// GetObjectRange returns the bytes from start to end (both included) of an object
+	GetObjectRange(ctx context.Context, objectKey string, start, end int64) (io.ReadCloser, error)
+	StatObject(ctx context.Context, objectKey string) (ObjectInfo, error)
+	// WalkObjects calls fn for every object under the prefix, including nested ones
+	WalkObjects(ctx context.Context, prefix string, fn func(ObjectInfo) error) error
+	CopyObject(sourceKey, destKey string) error
+	MoveObject(sourceKey, destKey string) error
+	RemoveObject(ctx context.Context, objectKey string) error
+
+	// Multipart uploads assemble an object from parts uploaded separately, in any order
+	NewMultipartUpload(ctx context.Context, objectKey, contentType string) (string, error)
+	PutObjectPart(ctx context.Context, objectKey, uploadID string, partNumber int, reader io.Reader, size int64) error
+	CompleteMultipartUpload(ctx context.Context, objectKey, uploadID string) error
+	AbortMultipartUpload(ctx context.Context, objectKey, uploadID string) error
+}
+
+// NewStorageProvider returns the storage provider selected by STORAGE_DRIVER. Files of organizations
+// with their own storage are routed to it by their key.
+func NewStorageProvider() (StorageProvider, error) {
+	cfg := config.GetConfig()
+
+	var provider StorageProvider
+	var err error
+	switch cfg.StorageDriver {
+	case StorageDriverMinIO, "":
+		provider, err = NewMinIOService()
+	case StorageDriverS3:
+		provider, err = newS3CompatibleService("S3", cfg.S3Endpoint, cfg.S3UseSSL, cfg.S3Region, cfg.S3AccessKeyID, cfg.S3SecretAccessKey, cfg.S3BucketName)
+	case StorageDriverGCS:
+		// Cloud Storage serves the S3 API to HMAC keys of a service account
+		provider, err = newS3CompatibleService("GCS", "https://storage.googleapis.com", true, "auto", cfg.GCSAccessKeyID, cfg.GCSSecretAccessKey, cfg.GCSBucketName)
+	case StorageDriverAzure:
+		provider, err = NewAzureBlobStorage(cfg.AzureStorageAccount, cfg.AzureStorageKey, cfg.AzureStorageContainer, cfg.AzureStorageEndpoint)
+	case StorageDriverLocal:
+		provider, err = NewLocalStorage(cfg.LocalStoragePath)
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.StorageDriver)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &storageRouter{StorageProvider: provider}, nil
+}
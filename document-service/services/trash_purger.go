@@ -0,0 +1,197 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models/document"
+	"forgecrud-backend/shared/readonly"
+	docUtils "forgecrud-backend/shared/utils/document"
+
+	"gorm.io/gorm"
+)
+
+const trashPurgeBatchSize = 100
+
+// TrashPurgeResult counts the documents and folders removed from the trash
+type TrashPurgeResult struct {
+	Documents int `json:"documents"`
+	Folders   int `json:"folders"`
+}
+
+// TrashPurger permanently removes documents and folders that stayed in the trash past the retention period
+type TrashPurger struct {
+	storage   StorageProvider
+	retention time.Duration
+}
+
+func NewTrashPurger(storage StorageProvider, retention time.Duration) *TrashPurger {
+	return &TrashPurger{storage: storage, retention: retention}
+}
+
+// Start purges expired trash in the background
+func (p *TrashPurger) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if readonly.Active() {
+				<-ticker.C
+				continue
+			}
+			purged, err := PurgeTrash(database.DB, p.storage, time.Now().Add(-p.retention))
+			if err != nil {
+				log.Printf("❌ Trash purge failed: %v", err)
+			} else if purged.Documents+purged.Folders > 0 {
+				log.Printf("🧹 Purged trash: %d documents, %d folders", purged.Documents, purged.Folders)
+			}
+
+			<-ticker.C
+		}
+	}()
+
+	log.Printf("🗑️  Trash purger started (retention: %s, interval: %s)", p.retention, interval)
+}
+
+// PurgeTrash permanently deletes the documents and folders moved to the trash before the cutoff,
+// together with their stored files. Folders are purged once nothing references them anymore.
+// Documents and folders under legal hold or within their minimum retention stay in the trash.
+func PurgeTrash(db *gorm.DB, storage StorageProvider, cutoff time.Time) (TrashPurgeResult, error) {
+	var result TrashPurgeResult
+
+	for {
+		var documents []document.Document
+		if err := db.Unscoped().
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+			Not(database.DocumentDeletionBlockedCondition("documents")).
+			Limit(trashPurgeBatchSize).
+			Find(&documents).Error; err != nil {
+			return result, err
+		}
+
+		for i := range documents {
+			if err := purgeDocument(db, storage, &documents[i]); err != nil {
+				return result, err
+			}
+			result.Documents++
+		}
+		if len(documents) < trashPurgeBatchSize {
+			break
+		}
+	}
+
+	// Subfolders go before their parents, one level per pass
+	for {
+		var folders []document.Folder
+		if err := db.Unscoped().
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+			Where("NOT EXISTS (SELECT 1 FROM documents d WHERE d.folder_id = folders.id)").
+			Where("NOT EXISTS (SELECT 1 FROM folders sub WHERE sub.parent_id = folders.id)").
+			Not(database.FolderLegalHoldCondition("folders")).
+			Find(&folders).Error; err != nil {
+			return result, err
+		}
+		if len(folders) == 0 {
+			break
+		}
+
+		for _, folder := range folders {
+			if err := db.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Where("object_type = ? AND object_id = ?", document.AccessObjectFolder, folder.ID).Delete(&document.AccessGrant{}).Error; err != nil {
+					return err
+				}
+				if err := tx.Where("folder_id = ?", folder.ID).Delete(&document.MetadataField{}).Error; err != nil {
+					return err
+				}
+				if err := tx.Where("folder_id = ?", folder.ID).Delete(&document.RetentionPolicy{}).Error; err != nil {
+					return err
+				}
+				subscriptions := tx.Model(&document.FolderSubscription{}).Select("id").Where("folder_id = ?", folder.ID)
+				if err := tx.Where("subscription_id IN (?)", subscriptions).Delete(&document.FolderChange{}).Error; err != nil {
+					return err
+				}
+				if err := tx.Where("folder_id = ?", folder.ID).Delete(&document.FolderSubscription{}).Error; err != nil {
+					return err
+				}
+				return tx.Unscoped().Delete(&folder).Error
+			}); err != nil {
+				return result, err
+			}
+			if err := storage.DeleteFolder(folder.Path); err != nil {
+				log.Printf("⚠️  Failed to remove purged folder %s from storage: %v", folder.Path, err)
+			}
+			result.Folders++
+		}
+	}
+
+	return result, nil
+}
+
+// purgeDocument deletes a document with its versions and then its stored files. A storage failure
+// leaves an orphaned object behind but never a document without its file.
+func purgeDocument(db *gorm.DB, storage StorageProvider, doc *document.Document) error {
+	var versions []document.DocumentVersion
+	if err := db.Where("document_id = ?", doc.ID).Find(&versions).Error; err != nil {
+		return err
+	}
+
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("document_id = ?", doc.ID).Delete(&document.DocumentVersion{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("document_id = ?", doc.ID).Delete(&document.DocumentShare{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("object_type = ? AND object_id = ?", document.AccessObjectDocument, doc.ID).Delete(&document.AccessGrant{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("document_id = ?", doc.ID).Delete(&document.RecentDocument{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("document_id = ?", doc.ID).Delete(&document.FavoriteDocument{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Delete(doc).Error; err != nil {
+			return err
+		}
+
+		// Files in the content store are removed with their last reference
+		for _, version := range versions {
+			if version.ContentHash == "" {
+				continue
+			}
+			if err := ReleaseContent(tx, storage, version.ContentHash); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	objectKeys := map[string]bool{}
+	if doc.ObjectKey != "" && doc.ContentHash == "" {
+		objectKeys[doc.ObjectKey] = true
+	}
+	for _, version := range versions {
+		if version.ContentHash != "" {
+			continue
+		}
+		objectKeys[version.ObjectKey] = true
+		// Files that never passed the malware scan are still in quarantine
+		if !document.ScanStatusAllowsDownload(version.ScanStatus) {
+			objectKeys[docUtils.QuarantineKey(version.ObjectKey)] = true
+		}
+	}
+
+	for objectKey := range objectKeys {
+		if err := storage.RemoveObject(context.Background(), objectKey); err != nil {
+			log.Printf("⚠️  Failed to remove %s of purged document %s: %v", objectKey, doc.ID, err)
+		}
+	}
+	RemoveImageRenders(storage, doc.ID, "")
+	return nil
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models/document"
+	"forgecrud-backend/shared/readonly"
+)
+
+const uploadJanitorBatchSize = 100
+
+// UploadJanitor discards chunked uploads that were abandoned before they were completed
+type UploadJanitor struct {
+	storage StorageProvider
+}
+
+func NewUploadJanitor(storage StorageProvider) *UploadJanitor {
+	return &UploadJanitor{storage: storage}
+}
+
+// Start removes expired uploads in the background
+func (j *UploadJanitor) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if readonly.Active() {
+				continue
+			}
+			if err := j.AbortExpired(); err != nil {
+				log.Printf("⚠️  Expired upload cleanup failed: %v", err)
+			}
+		}
+	}()
+
+	log.Printf("🧹 Upload janitor started (interval: %s)", interval)
+}
+
+// AbortExpired aborts the multipart uploads of expired sessions so MinIO frees their parts
+func (j *UploadJanitor) AbortExpired() error {
+	var sessions []document.UploadSession
+	if err := database.DB.
+		Where("status = ? AND expires_at < ?", document.UploadSessionUploading, time.Now()).
+		Limit(uploadJanitorBatchSize).
+		Find(&sessions).Error; err != nil {
+		return err
+	}
+
+	for _, session := range sessions {
+		// Skip sessions that were resumed or completed since they were loaded
+		result := database.DB.Model(&session).
+			Where("status = ? AND expires_at < ?", document.UploadSessionUploading, time.Now()).
+			Update("status", document.UploadSessionExpired)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+
+		if err := j.storage.AbortMultipartUpload(context.Background(), session.StorageKey, session.UploadID); err != nil {
+			log.Printf("⚠️  Failed to abort expired upload %s: %v", session.ID, err)
+		}
+	}
+
+	if len(sessions) > 0 {
+		log.Printf("🧹 Expired %d abandoned uploads", len(sessions))
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models/document"
+	"forgecrud-backend/shared/readonly"
+	docUtils "forgecrud-backend/shared/utils/document"
+
+	"gorm.io/gorm"
+)
+
+const versionPruneBatchSize = 100
+
+// VersionPruner removes old document versions according to the version retention rules
+type VersionPruner struct {
+	storage  StorageProvider
+	keepLast int
+	maxAge   time.Duration
+}
+
+// NewVersionPruner keeps the last keepLast versions of every document and the versions younger than
+// maxAge. A zero value disables that rule.
+func NewVersionPruner(storage StorageProvider, keepLast int, maxAge time.Duration) *VersionPruner {
+	return &VersionPruner{storage: storage, keepLast: keepLast, maxAge: maxAge}
+}
+
+// Start prunes old versions in the background
+func (p *VersionPruner) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if readonly.Active() {
+				<-ticker.C
+				continue
+			}
+			pruned, err := PruneVersions(database.DB, p.storage, p.keepLast, p.maxAge)
+			if err != nil {
+				log.Printf("❌ Version pruning failed: %v", err)
+			} else if pruned > 0 {
+				log.Printf("🧹 Pruned %d old document versions", pruned)
+			}
+
+			<-ticker.C
+		}
+	}()
+
+	log.Printf("🗂️  Version pruner started (keep last: %d, max age: %s, interval: %s)", p.keepLast, p.maxAge, interval)
+}
+
+// PruneVersions deletes the versions that are neither among the last keepLast versions of their document
+// nor younger than maxAge, together with their stored files. The current version of a document, pinned
+// versions, versions waiting for their malware scan and the versions of documents under legal hold are
+// always kept.
+func PruneVersions(db *gorm.DB, storage StorageProvider, keepLast int, maxAge time.Duration) (int, error) {
+	if keepLast <= 0 && maxAge <= 0 {
+		return 0, nil
+	}
+
+	query := db.Model(&document.DocumentVersion{}).
+		Where("NOT pinned AND scan_status NOT IN ?", []string{document.ScanStatusPending, document.ScanStatusScanning}).
+		Where("NOT EXISTS (SELECT 1 FROM documents d WHERE d.id = document_versions.document_id AND d.object_key = document_versions.object_key)").
+		Where("document_id NOT IN (SELECT d.id FROM documents d WHERE ?)", database.DocumentLegalHoldCondition("d"))
+	if keepLast > 0 {
+		query = query.Where("(SELECT COUNT(*) FROM document_versions newer WHERE newer.document_id = document_versions.document_id AND newer.version > document_versions.version) >= ?", keepLast)
+	}
+	if maxAge > 0 {
+		query = query.Where("created_at < ?", time.Now().Add(-maxAge))
+	}
+
+	pruned := 0
+	for {
+		var versions []document.DocumentVersion
+		if err := query.Session(&gorm.Session{}).Order("created_at").Limit(versionPruneBatchSize).Find(&versions).Error; err != nil {
+			return pruned, err
+		}
+
+		for _, version := range versions {
+			if err := pruneVersion(db, storage, version); err != nil {
+				return pruned, err
+			}
+			pruned++
+		}
+		if len(versions) < versionPruneBatchSize {
+			return pruned, nil
+		}
+	}
+}
+
+// pruneVersion deletes a version and then its stored file. A storage failure leaves an orphaned
+// object behind but never a version without its file.
+func pruneVersion(db *gorm.DB, storage StorageProvider, version document.DocumentVersion) error {
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&version).Error; err != nil {
+			return err
+		}
+		if version.ContentHash != "" {
+			return ReleaseContent(tx, storage, version.ContentHash)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	if version.ContentHash != "" {
+		return nil
+	}
+
+	objectKey := version.ObjectKey
+	// Files that never passed the malware scan are still in quarantine
+	if !document.ScanStatusAllowsDownload(version.ScanStatus) {
+		objectKey = docUtils.QuarantineKey(version.ObjectKey)
+	}
+	if err := storage.RemoveObject(context.Background(), objectKey); err != nil {
+		log.Printf("⚠️  Failed to remove %s of pruned version %d of document %s: %v", objectKey, version.Version, version.DocumentID, err)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/database/models/notification"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// NotificationDeliveriesResponse is the delivery state of a notification on every channel
+type NotificationDeliveriesResponse struct {
+	NotificationID uuid.UUID                           `json:"notification_id"`
+	UserID         *uuid.UUID                          `json:"user_id,omitempty"`
+	Channels       []notification.NotificationDelivery `json:"channels"`
+	PushDevices    []notification.PushDelivery         `json:"push_devices"`           // Push attempts per device
+	Emails         []notification.EmailMessage         `json:"emails"`                 // Queued emails of the email channel
+	EmailEvents    []notification.EmailEvent           `json:"email_events"`           // Provider events of those emails
+	SMSMessages    []notification.SMSMessage           `json:"sms_messages,omitempty"` // Texts of the SMS channel
+}
+
+// @Summary Get notification deliveries
+// @Description Get the delivery receipts of a notification per channel (in_app, email, push, sms, webhook, digest) with their status: queued, sent, delivered, read, failed or skipped (quiet hours, suppressed address, unverified phone), and why a delivery failed or was skipped. The push attempts per device, the queued emails with their provider events and the texts are included, to trace reports of notifications that never arrived. Only notifications that were stored in-app have receipts.
+// @Tags notifications
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Notification ID" format(uuid)
+// @Success 200 {object} NotificationDeliveriesResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /notifications/{id}/deliveries [get]
+func GetNotificationDeliveries(c *gin.Context) {
+	notificationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apperrors.Respond(c, apperrors.BadRequest("Invalid notification ID"))
+		return
+	}
+
+	db := requestDB(c)
+	var notif notification.Notification
+	if err := db.Select("id", "user_id").First(&notif, "id = ?", notificationID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apperrors.Respond(c, apperrors.NotFound("Notification not found"))
+			return
+		}
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to fetch notification"))
+		return
+	}
+
+	response := NotificationDeliveriesResponse{
+		NotificationID: notif.ID,
+		UserID:         notif.UserID,
+		Channels:       []notification.NotificationDelivery{},
+		PushDevices:    []notification.PushDelivery{},
+		Emails:         []notification.EmailMessage{},
+		EmailEvents:    []notification.EmailEvent{},
+	}
+	if err := db.Where("notification_id = ?", notif.ID).Order("created_at").Find(&response.Channels).Error; err != nil {
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to fetch deliveries"))
+		return
+	}
+	if err := db.Where("notification_id = ?", notif.ID).Order("created_at").Find(&response.PushDevices).Error; err != nil {
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to fetch push deliveries"))
+		return
+	}
+
+	emailIDs, smsIDs := []uuid.UUID{}, []uuid.UUID{}
+	for _, delivery := range response.Channels {
+		if delivery.ReferenceID == nil {
+			continue
+		}
+		switch delivery.Channel {
+		case notification.ChannelEmail:
+			emailIDs = append(emailIDs, *delivery.ReferenceID)
+		case notification.ChannelSMS:
+			smsIDs = append(smsIDs, *delivery.ReferenceID)
+		}
+	}
+	if len(emailIDs) > 0 {
+		if err := db.Where("id IN ?", emailIDs).Find(&response.Emails).Error; err != nil {
+			apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to fetch emails"))
+			return
+		}
+		if err := db.Where("message_id IN ?", emailIDs).Order("occurred_at").Find(&response.EmailEvents).Error; err != nil {
+			apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to fetch email events"))
+			return
+		}
+	}
+	if len(smsIDs) > 0 {
+		if err := db.Where("id IN ?", smsIDs).Find(&response.SMSMessages).Error; err != nil {
+			apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to fetch text messages"))
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
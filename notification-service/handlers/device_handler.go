@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"net/http"
+
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models/notification"
+	utils "forgecrud-backend/shared/utils/auth"
+	"forgecrud-backend/shared/utils/validation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RegisterDeviceRequest represents the request to register a device for push notifications
+type RegisterDeviceRequest struct {
+	Provider   string `json:"provider" binding:"required,oneof=fcm apns"`
+	Token      string `json:"token" binding:"required,max=512"`
+	Platform   string `json:"platform" binding:"omitempty,oneof=android ios web"`
+	DeviceName string `json:"device_name" binding:"max=100"`
+}
+
+// @Summary Register device
+// @Description Register a device of the current user for push notifications, with its FCM registration token or APNs device token. Registering a known token again updates it and assigns it to the current user, so apps can register on every start.
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param device body RegisterDeviceRequest true "Device token"
+// @Success 201 {object} notification.DeviceToken
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /notifications/devices [post]
+func RegisterDevice(c *gin.Context) {
+	userID := utils.GetActorID(c)
+	if userID == nil {
+		apperrors.Respond(c, apperrors.Unauthorized("User ID is required"))
+		return
+	}
+
+	var req RegisterDeviceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperrors.Respond(c, validation.Error(err, "Invalid request body"))
+		return
+	}
+
+	// Tokens are unique across users, a device signed in with another account is taken over
+	db := database.GetDB()
+	var device notification.DeviceToken
+	if err := db.Where("token = ?", req.Token).First(&device).Error; err == nil {
+		if err := db.Model(&device).Updates(map[string]interface{}{
+			"user_id":     *userID,
+			"provider":    req.Provider,
+			"platform":    req.Platform,
+			"device_name": req.DeviceName,
+			"disabled_at": nil,
+		}).Error; err != nil {
+			apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to register device"))
+			return
+		}
+		db.First(&device, device.ID)
+		c.JSON(http.StatusOK, device)
+		return
+	}
+
+	device = notification.DeviceToken{
+		UserID:     *userID,
+		Provider:   req.Provider,
+		Token:      req.Token,
+		Platform:   req.Platform,
+		DeviceName: req.DeviceName,
+	}
+	if err := db.Create(&device).Error; err != nil {
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to register device"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, device)
+}
+
+// @Summary Get devices
+// @Description Get the devices of the current user registered for push notifications, including devices disabled after their token was rejected
+// @Tags notifications
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} notification.DeviceToken
+// @Failure 401 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /notifications/devices [get]
+func GetDevices(c *gin.Context) {
+	userID := utils.GetActorID(c)
+	if userID == nil {
+		apperrors.Respond(c, apperrors.Unauthorized("User ID is required"))
+		return
+	}
+
+	devices := []notification.DeviceToken{}
+	if err := requestDB(c).Where("user_id = ?", *userID).Order("created_at DESC").Find(&devices).Error; err != nil {
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to fetch devices"))
+		return
+	}
+
+	c.JSON(http.StatusOK, devices)
+}
+
+// @Summary Unregister device
+// @Description Stop push notifications to a device of the current user, e.g. on sign out
+// @Tags notifications
+// @Security BearerAuth
+// @Param id path string true "Device ID" format(uuid)
+// @Success 204
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /notifications/devices/{id} [delete]
+func DeleteDevice(c *gin.Context) {
+	userID := utils.GetActorID(c)
+	if userID == nil {
+		apperrors.Respond(c, apperrors.Unauthorized("User ID is required"))
+		return
+	}
+
+	deviceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apperrors.Respond(c, apperrors.BadRequest("Invalid device ID"))
+		return
+	}
+
+	result := requestDB(c).Where("id = ? AND user_id = ?", deviceID, *userID).Delete(&notification.DeviceToken{})
+	if result.Error != nil {
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to unregister device"))
+		return
+	}
+	if result.RowsAffected == 0 {
+		apperrors.Respond(c, apperrors.NotFound("Device not found"))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
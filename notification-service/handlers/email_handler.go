@@ -3,15 +3,29 @@ package handlers
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
 	"forgecrud-backend/notification-service/services"
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/clients"
 	"forgecrud-backend/shared/config"
+	"forgecrud-backend/shared/database/models"
+	"forgecrud-backend/shared/messaging"
+	"forgecrud-backend/shared/tenancy"
+	utils "forgecrud-backend/shared/utils/auth"
+	"forgecrud-backend/shared/utils/validation"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
+// authClient calls the auth service for new verification tokens
+var authClient = clients.NewHTTPClient("auth", 10*time.Second)
+
 // EmailHandler handles email-related HTTP requests
 type EmailHandler struct {
 	emailService *services.EmailService
@@ -28,12 +42,12 @@ func NewEmailHandler(emailService *services.EmailService, cfg *config.Config) *E
 
 // SendEmail godoc
 // @Summary Send email
-// @Description Send an email through the notification service
+// @Description Queue an email for delivery through the notification service. Documents listed in attachment_document_ids are attached if the caller can read them and they are within the size and content type limits.
 // @Tags email
 // @Accept json
 // @Produce json
 // @Param email body services.EmailRequest true "Email request"
-// @Success 200 {object} services.EmailResponse
+// @Success 202 {object} notification.EmailMessage
 // @Failure 400 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
 // @Router /api/notifications/email/send [post]
@@ -41,23 +55,30 @@ func (eh *EmailHandler) SendEmail(c *gin.Context) {
 	var request services.EmailRequest
 
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request format",
-			"details": err.Error(),
-		})
+		apperrors.Respond(c, validation.Error(err, "Invalid request format"))
 		return
 	}
 
-	response, err := eh.emailService.SendEmail(request)
+	// Queue the email under the caller's organization so it shows up in its delivery status
+	if tenant, ok := tenancy.FromContext(c.Request.Context()); ok {
+		if !tenant.Bypass {
+			request.OrganizationID = tenant.OrganizationID
+		}
+		// Only documents the caller may read can be attached
+		request.Requester = &tenant
+	}
+
+	message, err := eh.emailService.QueueEmail(request)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to send email",
-			"details": err.Error(),
-		})
+		if errors.Is(err, services.ErrInvalidAttachment) {
+			apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid attachment"))
+			return
+		}
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to queue email"))
 		return
 	}
 
-	c.JSON(http.StatusOK, response)
+	c.JSON(http.StatusAccepted, message)
 }
 
 // SendWelcomeEmail godoc
@@ -67,7 +88,7 @@ func (eh *EmailHandler) SendEmail(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param email body WelcomeEmailRequest true "Welcome email request"
-// @Success 200 {object} services.EmailResponse
+// @Success 202 {object} notification.EmailMessage
 // @Failure 400 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
 // @Router /api/notifications/email/welcome [post]
@@ -75,23 +96,17 @@ func (eh *EmailHandler) SendWelcomeEmail(c *gin.Context) {
 	var request WelcomeEmailRequest
 
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request format",
-			"details": err.Error(),
-		})
+		apperrors.Respond(c, validation.Error(err, "Invalid request format"))
 		return
 	}
 
-	response, err := eh.emailService.SendWelcomeEmail(request.To, request.Name, request.VerificationCode)
+	message, err := eh.emailService.SendWelcomeEmail(request.To, request.Name, request.VerificationCode, request.Locale)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to send welcome email",
-			"details": err.Error(),
-		})
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to queue welcome email"))
 		return
 	}
 
-	c.JSON(http.StatusOK, response)
+	c.JSON(http.StatusAccepted, message)
 }
 
 // SendPasswordResetEmail godoc
@@ -101,7 +116,7 @@ func (eh *EmailHandler) SendWelcomeEmail(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param email body PasswordResetEmailRequest true "Password reset email request"
-// @Success 200 {object} services.EmailResponse
+// @Success 202 {object} notification.EmailMessage
 // @Failure 400 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
 // @Router /api/notifications/email/password-reset [post]
@@ -109,23 +124,65 @@ func (eh *EmailHandler) SendPasswordResetEmail(c *gin.Context) {
 	var request PasswordResetEmailRequest
 
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request format",
-			"details": err.Error(),
-		})
+		apperrors.Respond(c, validation.Error(err, "Invalid request format"))
 		return
 	}
 
-	response, err := eh.emailService.SendPasswordResetEmail(request.To, request.Name, request.ResetCode)
+	message, err := eh.emailService.SendPasswordResetEmail(request.To, request.Name, request.ResetCode, request.Locale)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to send password reset email",
-			"details": err.Error(),
-		})
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to queue password reset email"))
 		return
 	}
 
-	c.JSON(http.StatusOK, response)
+	c.JSON(http.StatusAccepted, message)
+}
+
+// SendUserActionEmail godoc
+// @Summary Send user action email
+// @Description Email the owner of a resource about an action on it. The email is rendered with the user_action.<action> template when there is one (e.g. user_action.document.deleted), otherwise with the generic user_action template; the action's name and priority label are translated into the owner's language. Extra template variables go in data.
+// @Tags email
+// @Accept json
+// @Produce json
+// @Param email body UserActionEmailRequest true "User action"
+// @Success 202 {object} notification.EmailMessage
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/notifications/email/user-action [post]
+func (eh *EmailHandler) SendUserActionEmail(c *gin.Context) {
+	var request UserActionEmailRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		apperrors.Respond(c, validation.Error(err, "Invalid request format"))
+		return
+	}
+	if request.OwnerID == uuid.Nil {
+		apperrors.Respond(c, apperrors.BadRequest("Invalid request format").WithDetails("owner_id is required"))
+		return
+	}
+
+	db := requestDB(c)
+	var owner models.User
+	if err := db.First(&owner, "id = ?", request.OwnerID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apperrors.Respond(c, apperrors.NotFound("Owner not found"))
+			return
+		}
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to load owner"))
+		return
+	}
+
+	message, err := eh.emailService.QueueEmail(services.UserActionEmail(db, &owner, services.UserAction{
+		Action:       request.Action,
+		ActorID:      utils.GetActorID(c),
+		ActivityData: request.ActivityData,
+	}))
+	if err != nil {
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to queue user action email"))
+		return
+	}
+
+	c.JSON(http.StatusAccepted, message)
 }
 
 // VerificationEmailRequest represents the request for sending verification email
@@ -147,7 +204,7 @@ type ResendVerificationRequest struct {
 // @Accept json
 // @Produce json
 // @Param request body VerificationEmailRequest true "Verification email request"
-// @Success 200 {object} map[string]interface{}
+// @Success 202 {object} map[string]interface{}
 // @Failure 400 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
 // @Router /api/notifications/email/verification [post]
@@ -155,10 +212,7 @@ func (eh *EmailHandler) SendVerificationEmail(c *gin.Context) {
 	var request VerificationEmailRequest
 
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request format",
-			"details": err.Error(),
-		})
+		apperrors.Respond(c, validation.Error(err, "Invalid request format"))
 		return
 	}
 
@@ -168,7 +222,6 @@ func (eh *EmailHandler) SendVerificationEmail(c *gin.Context) {
 	// Send welcome email with verification link
 	emailRequest := services.EmailRequest{
 		To:         []string{request.Email},
-		Subject:    "Welcome! Please verify your email",
 		TemplateID: "welcome_verification",
 		TemplateVars: map[string]interface{}{
 			"FirstName":       request.FirstName,
@@ -177,18 +230,16 @@ func (eh *EmailHandler) SendVerificationEmail(c *gin.Context) {
 		IsHTML: true,
 	}
 
-	response, err := eh.emailService.SendEmail(emailRequest)
+	message, err := eh.emailService.QueueEmail(emailRequest)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to send verification email",
-			"details": err.Error(),
-		})
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to queue verification email"))
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Verification email sent successfully",
-		"sent_at": response.SentAt,
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "Verification email queued",
+		"id":      message.ID,
+		"status":  message.Status,
 	})
 }
 
@@ -199,7 +250,7 @@ func (eh *EmailHandler) SendVerificationEmail(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param request body ResendVerificationRequest true "Resend verification request"
-// @Success 200 {object} map[string]interface{}
+// @Success 202 {object} map[string]interface{}
 // @Failure 400 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
 // @Router /api/notifications/email/resend-verification [post]
@@ -207,10 +258,7 @@ func (eh *EmailHandler) ResendVerificationEmail(c *gin.Context) {
 	var request ResendVerificationRequest
 
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request format",
-			"details": err.Error(),
-		})
+		apperrors.Respond(c, validation.Error(err, "Invalid request format"))
 		return
 	}
 
@@ -220,16 +268,17 @@ func (eh *EmailHandler) ResendVerificationEmail(c *gin.Context) {
 	}
 
 	tokenRequestBytes, _ := json.Marshal(tokenRequest)
-	resp, err := http.Post(
+	resp, err := authClient.Post(
 		fmt.Sprintf("%s/api/auth/create-verification-token", eh.config.AuthServiceURL),
 		"application/json",
 		bytes.NewBuffer(tokenRequestBytes),
 	)
+	if err == nil {
+		defer resp.Body.Close()
+	}
 
 	if err != nil || resp.StatusCode != http.StatusOK {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to create new verification token",
-		})
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to create new verification token"))
 		return
 	}
 
@@ -239,9 +288,7 @@ func (eh *EmailHandler) ResendVerificationEmail(c *gin.Context) {
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(&tokenResponse); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to parse token response",
-		})
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to parse token response"))
 		return
 	}
 
@@ -257,7 +304,6 @@ func (eh *EmailHandler) ResendVerificationEmail(c *gin.Context) {
 
 	emailRequest := services.EmailRequest{
 		To:         []string{verificationRequest.Email},
-		Subject:    "Verification Email Resent",
 		TemplateID: "welcome_verification",
 		TemplateVars: map[string]interface{}{
 			"FirstName":       verificationRequest.FirstName,
@@ -266,18 +312,16 @@ func (eh *EmailHandler) ResendVerificationEmail(c *gin.Context) {
 		IsHTML: true,
 	}
 
-	response, err := eh.emailService.SendEmail(emailRequest)
+	message, err := eh.emailService.QueueEmail(emailRequest)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to send verification email",
-			"details": err.Error(),
-		})
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to queue verification email"))
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Verification email resent successfully",
-		"sent_at": response.SentAt,
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "Verification email queued",
+		"id":      message.ID,
+		"status":  message.Status,
 	})
 }
 
@@ -286,10 +330,19 @@ type WelcomeEmailRequest struct {
 	To               string `json:"to" binding:"required,email"`
 	Name             string `json:"name" binding:"required"`
 	VerificationCode string `json:"verification_code" binding:"required"`
+	Locale           string `json:"locale"` // Defaults to the recipient's preferred language
 }
 
 type PasswordResetEmailRequest struct {
 	To        string `json:"to" binding:"required,email"`
 	Name      string `json:"name" binding:"required"`
 	ResetCode string `json:"reset_code" binding:"required"`
+	Locale    string `json:"locale"` // Defaults to the recipient's preferred language
+}
+
+// UserActionEmailRequest is an action on a resource emailed to the resource owner. Action is the
+// event type, e.g. document.deleted, and selects the template.
+type UserActionEmailRequest struct {
+	Action string `json:"action" binding:"required,max=100"`
+	messaging.ActivityData
 }
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"net/http"
+
+	"forgecrud-backend/notification-service/services"
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/database/models/notification"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// @Summary Get queued emails
+// @Description Get the latest outbound emails with their delivery status, attempts and last error
+// @Tags email
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "Filter by status (queued, sent, dead_lettered)"
+// @Param to query string false "Filter by recipient address"
+// @Success 200 {array} notification.EmailMessage
+// @Failure 500 {object} map[string]interface{}
+// @Router /notifications/email/messages [get]
+func GetEmailMessages(c *gin.Context) {
+	query := requestDB(c)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if to := c.Query("to"); to != "" {
+		query = query.Where("? = ANY(string_to_array(\"to\", ','))", to)
+	}
+
+	messages := []notification.EmailMessage{}
+	if err := query.Order("created_at DESC").Limit(100).Find(&messages).Error; err != nil {
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to fetch emails"))
+		return
+	}
+
+	c.JSON(http.StatusOK, messages)
+}
+
+// @Summary Get email delivery status
+// @Description Get a queued email by the ID returned when it was sent
+// @Tags email
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Email ID" format(uuid)
+// @Success 200 {object} notification.EmailMessage
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /notifications/email/messages/{id} [get]
+func GetEmailMessage(c *gin.Context) {
+	messageID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apperrors.Respond(c, apperrors.BadRequest("Invalid email ID"))
+		return
+	}
+
+	var message notification.EmailMessage
+	if err := requestDB(c).First(&message, "id = ?", messageID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			apperrors.Respond(c, apperrors.NotFound("Email not found"))
+			return
+		}
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to fetch email"))
+		return
+	}
+
+	c.JSON(http.StatusOK, message)
+}
+
+// @Summary Get dead-lettered emails
+// @Description Get the emails that could not be delivered after all attempts or were rejected by the mail server
+// @Tags email
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} notification.EmailDeadLetter
+// @Failure 500 {object} map[string]interface{}
+// @Router /notifications/email/dead-letters [get]
+func GetEmailDeadLetters(c *gin.Context) {
+	deadLetters := []notification.EmailDeadLetter{}
+	if err := requestDB(c).Order("created_at DESC").Limit(100).Find(&deadLetters).Error; err != nil {
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to fetch dead-lettered emails"))
+		return
+	}
+
+	c.JSON(http.StatusOK, deadLetters)
+}
+
+// @Summary Retry dead-lettered email
+// @Description Queue a dead-lettered email again with a fresh set of attempts
+// @Tags email
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Dead letter ID" format(uuid)
+// @Success 202 {object} notification.EmailMessage
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /notifications/email/dead-letters/{id}/retry [post]
+func RetryEmailDeadLetter(c *gin.Context) {
+	deadLetterID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apperrors.Respond(c, apperrors.BadRequest("Invalid dead letter ID"))
+		return
+	}
+
+	db := requestDB(c)
+	var deadLetter notification.EmailDeadLetter
+	if err := db.First(&deadLetter, "id = ?", deadLetterID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			apperrors.Respond(c, apperrors.NotFound("Dead letter not found"))
+			return
+		}
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to fetch dead letter"))
+		return
+	}
+
+	message, err := services.RequeueDeadLetter(db, &deadLetter)
+	if err != nil {
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to requeue email"))
+		return
+	}
+
+	c.JSON(http.StatusAccepted, message)
+}
@@ -0,0 +1,507 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"forgecrud-backend/notification-service/services"
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models/notification"
+	"forgecrud-backend/shared/i18n"
+	"forgecrud-backend/shared/tenancy"
+	utils "forgecrud-backend/shared/utils/auth"
+	"forgecrud-backend/shared/utils/validation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var errTemplateChanged = errors.New("template was changed meanwhile")
+
+// CreateEmailTemplateRequest represents the request to create an email template. Organization admins
+// create overrides of a global template for their organization; super admins may create global templates.
+type CreateEmailTemplateRequest struct {
+	Key         string `json:"key" binding:"required,max=100"`
+	Subject     string `json:"subject" binding:"required,max=500"`
+	HTMLBody    string `json:"html_body" binding:"required"`
+	TextBody    string `json:"text_body"`
+	Description string `json:"description"`
+	// Language of the template, e.g. "tr". Omit for a template translating its texts with {{t "key"}}.
+	Locale string `json:"locale" binding:"omitempty,bcp47_language_tag"`
+	// Organization of the override, defaults to the caller's organization. Omit as super admin for a global template.
+	OrganizationID *uuid.UUID `json:"organization_id"`
+}
+
+// UpdateEmailTemplateRequest represents the request to change a template; every change creates a new version
+type UpdateEmailTemplateRequest struct {
+	Subject     *string `json:"subject" binding:"omitempty,max=500"`
+	HTMLBody    *string `json:"html_body"`
+	TextBody    *string `json:"text_body"`
+	Description *string `json:"description"`
+}
+
+// RenderEmailTemplateRequest represents the variables a template is previewed or test-sent with
+type RenderEmailTemplateRequest struct {
+	Variables map[string]interface{} `json:"variables"`
+	Locale    string                 `json:"locale"` // Language the texts are translated into, the default locale otherwise
+}
+
+// TestSendEmailTemplateRequest represents the request to send a rendered template to a test address
+type TestSendEmailTemplateRequest struct {
+	To        string                 `json:"to" binding:"required,email"`
+	Variables map[string]interface{} `json:"variables"`
+	Locale    string                 `json:"locale"`
+}
+
+// EmailTemplateHandler handles email template management
+type EmailTemplateHandler struct {
+	emailService *services.EmailService
+}
+
+// NewEmailTemplateHandler creates a new email template handler
+func NewEmailTemplateHandler(emailService *services.EmailService) *EmailTemplateHandler {
+	return &EmailTemplateHandler{emailService: emailService}
+}
+
+// canChangeEmailTemplate reports whether the caller may change the template. Global templates are
+// shared by every organization and can only be changed by super admins.
+func canChangeEmailTemplate(c *gin.Context, tmpl *notification.EmailTemplate) bool {
+	if tmpl.OrganizationID != nil {
+		return true
+	}
+	tenant, ok := tenancy.FromContext(c.Request.Context())
+	return !ok || tenant.Bypass
+}
+
+// findEmailTemplate loads a template by the :id path parameter and writes the error response on failure
+func findEmailTemplate(c *gin.Context) (*notification.EmailTemplate, bool) {
+	templateID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apperrors.Respond(c, apperrors.BadRequest("Invalid template ID"))
+		return nil, false
+	}
+
+	var tmpl notification.EmailTemplate
+	if err := requestDB(c).First(&tmpl, "id = ?", templateID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			apperrors.Respond(c, apperrors.NotFound("Email template not found"))
+			return nil, false
+		}
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to fetch email template"))
+		return nil, false
+	}
+	return &tmpl, true
+}
+
+// @Summary Get email templates
+// @Description Get the global email templates and the overrides of the caller's organization
+// @Tags email-templates
+// @Produce json
+// @Security BearerAuth
+// @Param key query string false "Filter by template key"
+// @Param locale query string false "Filter by locale, empty for locale neutral templates"
+// @Success 200 {array} notification.EmailTemplate
+// @Failure 500 {object} map[string]interface{}
+// @Router /notifications/email/templates [get]
+func (th *EmailTemplateHandler) GetEmailTemplates(c *gin.Context) {
+	query := requestDB(c).Order("key").Order("organization_id NULLS FIRST").Order("locale")
+	if key := c.Query("key"); key != "" {
+		query = query.Where("key = ?", key)
+	}
+	if locale, ok := c.GetQuery("locale"); ok {
+		query = query.Where("locale = ?", i18n.NormalizeLocale(locale))
+	}
+
+	templates := []notification.EmailTemplate{}
+	if err := query.Find(&templates).Error; err != nil {
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to fetch email templates"))
+		return
+	}
+
+	c.JSON(http.StatusOK, templates)
+}
+
+// @Summary Get email template
+// @Description Get an email template by ID
+// @Tags email-templates
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Template ID" format(uuid)
+// @Success 200 {object} notification.EmailTemplate
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /notifications/email/templates/{id} [get]
+func (th *EmailTemplateHandler) GetEmailTemplate(c *gin.Context) {
+	tmpl, ok := findEmailTemplate(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, tmpl)
+}
+
+// @Summary Create email template
+// @Description Create an email template. Subject and text body are Go text/templates, the HTML body an html/template; placeholders like {{.Name}} are filled from template_vars when sending and {{t "key"}} translates a catalog text into the recipient's language. An organization template with the key of a global template overrides it for that organization; a template with a locale is used for recipients of that language.
+// @Tags email-templates
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param template body CreateEmailTemplateRequest true "Email template"
+// @Success 201 {object} notification.EmailTemplate
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /notifications/email/templates [post]
+func (th *EmailTemplateHandler) CreateEmailTemplate(c *gin.Context) {
+	var req CreateEmailTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperrors.Respond(c, validation.Error(err, "Invalid request body"))
+		return
+	}
+
+	variables, err := services.ParseEmailTemplate(req.Subject, req.HTMLBody, req.TextBody)
+	if err != nil {
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid template"))
+		return
+	}
+
+	organizationID := req.OrganizationID
+	if organizationID == nil {
+		if tenant, ok := tenancy.FromContext(c.Request.Context()); ok && !tenant.Bypass {
+			organizationID = tenant.OrganizationID
+		}
+	}
+
+	locale := i18n.NormalizeLocale(req.Locale)
+	db := requestDB(c)
+	exists := db.Where("key = ? AND locale = ?", req.Key, locale)
+	if organizationID == nil {
+		exists = exists.Where("organization_id IS NULL")
+	} else {
+		exists = exists.Where("organization_id = ?", *organizationID)
+	}
+	var count int64
+	if err := exists.Model(&notification.EmailTemplate{}).Count(&count).Error; err != nil {
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to create email template"))
+		return
+	}
+	if count > 0 {
+		apperrors.Respond(c, apperrors.Conflict("A template with this key and locale already exists, update it instead"))
+		return
+	}
+
+	actorID := utils.GetActorID(c)
+	tmpl := notification.EmailTemplate{
+		Key:            req.Key,
+		OrganizationID: organizationID,
+		Locale:         locale,
+		Subject:        req.Subject,
+		HTMLBody:       req.HTMLBody,
+		TextBody:       req.TextBody,
+		Variables:      strings.Join(variables, ","),
+		Description:    req.Description,
+		Version:        1,
+		CreatedBy:      actorID,
+		UpdatedBy:      actorID,
+	}
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&tmpl).Error; err != nil {
+			return err
+		}
+		snapshot := tmpl.Snapshot()
+		return tx.Create(&snapshot).Error
+	}); err != nil {
+		if errors.Is(err, database.ErrTenantMismatch) {
+			apperrors.Respond(c, apperrors.Forbidden("Cannot create templates for another organization"))
+			return
+		}
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to create email template"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, tmpl)
+}
+
+// @Summary Update email template
+// @Description Change an email template. The previous content stays available as a version.
+// @Tags email-templates
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Template ID" format(uuid)
+// @Param template body UpdateEmailTemplateRequest true "Email template"
+// @Success 200 {object} notification.EmailTemplate
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /notifications/email/templates/{id} [put]
+func (th *EmailTemplateHandler) UpdateEmailTemplate(c *gin.Context) {
+	tmpl, ok := findEmailTemplate(c)
+	if !ok {
+		return
+	}
+	if !canChangeEmailTemplate(c, tmpl) {
+		apperrors.Respond(c, apperrors.Forbidden("Global templates can only be changed by super admins, create an override for your organization instead"))
+		return
+	}
+
+	var req UpdateEmailTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperrors.Respond(c, validation.Error(err, "Invalid request body"))
+		return
+	}
+
+	content := *tmpl
+	if req.Subject != nil {
+		content.Subject = *req.Subject
+	}
+	if req.HTMLBody != nil {
+		content.HTMLBody = *req.HTMLBody
+	}
+	if req.TextBody != nil {
+		content.TextBody = *req.TextBody
+	}
+	if req.Description != nil {
+		content.Description = *req.Description
+	}
+	if content.Subject == "" || content.HTMLBody == "" {
+		apperrors.Respond(c, apperrors.BadRequest("Subject and html_body cannot be empty"))
+		return
+	}
+
+	th.saveEmailTemplate(c, tmpl, content)
+}
+
+// @Summary Get email template versions
+// @Description Get the versions of an email template, newest first
+// @Tags email-templates
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Template ID" format(uuid)
+// @Success 200 {array} notification.EmailTemplateVersion
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /notifications/email/templates/{id}/versions [get]
+func (th *EmailTemplateHandler) GetEmailTemplateVersions(c *gin.Context) {
+	tmpl, ok := findEmailTemplate(c)
+	if !ok {
+		return
+	}
+
+	versions := []notification.EmailTemplateVersion{}
+	if err := requestDB(c).Where("template_id = ?", tmpl.ID).Order("version DESC").Find(&versions).Error; err != nil {
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to fetch template versions"))
+		return
+	}
+
+	c.JSON(http.StatusOK, versions)
+}
+
+// @Summary Restore email template version
+// @Description Make the content of an earlier version current again, as a new version
+// @Tags email-templates
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Template ID" format(uuid)
+// @Param version path int true "Version"
+// @Success 200 {object} notification.EmailTemplate
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /notifications/email/templates/{id}/versions/{version}/restore [post]
+func (th *EmailTemplateHandler) RestoreEmailTemplateVersion(c *gin.Context) {
+	tmpl, ok := findEmailTemplate(c)
+	if !ok {
+		return
+	}
+	if !canChangeEmailTemplate(c, tmpl) {
+		apperrors.Respond(c, apperrors.Forbidden("Global templates can only be changed by super admins, create an override for your organization instead"))
+		return
+	}
+
+	versionNumber, err := strconv.Atoi(c.Param("version"))
+	if err != nil {
+		apperrors.Respond(c, apperrors.BadRequest("Invalid version"))
+		return
+	}
+
+	var version notification.EmailTemplateVersion
+	if err := requestDB(c).Where("template_id = ? AND version = ?", tmpl.ID, versionNumber).First(&version).Error; err != nil {
+		apperrors.Respond(c, apperrors.NotFound("Template version not found"))
+		return
+	}
+
+	content := *tmpl
+	content.Subject = version.Subject
+	content.HTMLBody = version.HTMLBody
+	content.TextBody = version.TextBody
+
+	th.saveEmailTemplate(c, tmpl, content)
+}
+
+// saveEmailTemplate stores new content of a template as its next version and writes the response
+func (th *EmailTemplateHandler) saveEmailTemplate(c *gin.Context, tmpl *notification.EmailTemplate, content notification.EmailTemplate) {
+	variables, err := services.ParseEmailTemplate(content.Subject, content.HTMLBody, content.TextBody)
+	if err != nil {
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid template"))
+		return
+	}
+
+	content.Variables = strings.Join(variables, ",")
+	content.Version = tmpl.Version + 1
+	content.UpdatedBy = utils.GetActorID(c)
+
+	if err := requestDB(c).Transaction(func(tx *gorm.DB) error {
+		// The version check keeps concurrent edits from both becoming the same version
+		result := tx.Model(&notification.EmailTemplate{}).
+			Where("id = ? AND version = ?", tmpl.ID, tmpl.Version).
+			Updates(map[string]interface{}{
+				"subject":     content.Subject,
+				"html_body":   content.HTMLBody,
+				"text_body":   content.TextBody,
+				"variables":   content.Variables,
+				"description": content.Description,
+				"version":     content.Version,
+				"updated_by":  content.UpdatedBy,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errTemplateChanged
+		}
+		snapshot := content.Snapshot()
+		return tx.Create(&snapshot).Error
+	}); err != nil {
+		if err == errTemplateChanged {
+			apperrors.Respond(c, apperrors.Conflict("Template was changed meanwhile, reload and try again"))
+			return
+		}
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to update email template"))
+		return
+	}
+
+	requestDB(c).First(&content, "id = ?", tmpl.ID)
+	c.JSON(http.StatusOK, content)
+}
+
+// @Summary Delete email template
+// @Description Delete an organization override with its versions, the organization uses the global template again. Global templates cannot be deleted.
+// @Tags email-templates
+// @Security BearerAuth
+// @Param id path string true "Template ID" format(uuid)
+// @Success 204
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /notifications/email/templates/{id} [delete]
+func (th *EmailTemplateHandler) DeleteEmailTemplate(c *gin.Context) {
+	tmpl, ok := findEmailTemplate(c)
+	if !ok {
+		return
+	}
+	if tmpl.OrganizationID == nil {
+		apperrors.Respond(c, apperrors.Conflict("Global templates are used by every organization and cannot be deleted"))
+		return
+	}
+
+	if err := requestDB(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("template_id = ?", tmpl.ID).Delete(&notification.EmailTemplateVersion{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(tmpl).Error
+	}); err != nil {
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to delete email template"))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Preview email template
+// @Description Render an email template with the given variables without sending it
+// @Tags email-templates
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Template ID" format(uuid)
+// @Param variables body RenderEmailTemplateRequest true "Template variables"
+// @Success 200 {object} services.RenderedEmail
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /notifications/email/templates/{id}/preview [post]
+func (th *EmailTemplateHandler) PreviewEmailTemplate(c *gin.Context) {
+	tmpl, ok := findEmailTemplate(c)
+	if !ok {
+		return
+	}
+
+	var req RenderEmailTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperrors.Respond(c, validation.Error(err, "Invalid request body"))
+		return
+	}
+
+	rendered, err := services.RenderEmailTemplate(tmpl, req.Variables, req.Locale)
+	if err != nil {
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeBadRequest, "Failed to render template"))
+		return
+	}
+
+	c.JSON(http.StatusOK, rendered)
+}
+
+// @Summary Test-send email template
+// @Description Render an email template with the given variables and send it to a test address
+// @Tags email-templates
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Template ID" format(uuid)
+// @Param request body TestSendEmailTemplateRequest true "Recipient and template variables"
+// @Success 200 {object} services.EmailResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /notifications/email/templates/{id}/test-send [post]
+func (th *EmailTemplateHandler) TestSendEmailTemplate(c *gin.Context) {
+	tmpl, ok := findEmailTemplate(c)
+	if !ok {
+		return
+	}
+
+	var req TestSendEmailTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperrors.Respond(c, validation.Error(err, "Invalid request body"))
+		return
+	}
+
+	rendered, err := services.RenderEmailTemplate(tmpl, req.Variables, req.Locale)
+	if err != nil {
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeBadRequest, "Failed to render template"))
+		return
+	}
+
+	response, err := th.emailService.SendEmail(services.EmailRequest{
+		To:       []string{req.To},
+		Subject:  "[Test] " + rendered.Subject,
+		Body:     rendered.HTMLBody,
+		TextBody: rendered.TextBody,
+		IsHTML:   true,
+	})
+	if err != nil {
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to send test email"))
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
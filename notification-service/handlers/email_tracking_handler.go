@@ -0,0 +1,468 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"forgecrud-backend/notification-service/services"
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models/notification"
+	"forgecrud-backend/shared/tenancy"
+	utils "forgecrud-backend/shared/utils/auth"
+	"forgecrud-backend/shared/utils/validation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Transparent 1x1 GIF served by the open tracking endpoint
+var trackingPixel = []byte{
+	0x47, 0x49, 0x46, 0x38, 0x39, 0x61, 0x01, 0x00, 0x01, 0x00, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0xff, 0xff, 0xff, 0x21, 0xf9, 0x04, 0x01, 0x00, 0x00, 0x00, 0x00, 0x2c, 0x00, 0x00, 0x00, 0x00,
+	0x01, 0x00, 0x01, 0x00, 0x00, 0x02, 0x02, 0x44, 0x01, 0x00, 0x3b,
+}
+
+// CreateEmailSuppressionRequest represents the request to stop sending emails to an address
+type CreateEmailSuppressionRequest struct {
+	Email   string `json:"email" binding:"required,email"`
+	Details string `json:"details"`
+}
+
+// EmailAnalyticsResponse summarizes the delivery of the emails queued in a period
+type EmailAnalyticsResponse struct {
+	From          time.Time `json:"from"`
+	To            time.Time `json:"to"`
+	Queued        int64     `json:"queued"`
+	Sent          int64     `json:"sent"`
+	DeadLettered  int64     `json:"dead_lettered"`
+	Suppressed    int64     `json:"suppressed"`
+	Delivered     int64     `json:"delivered"`
+	Bounced       int64     `json:"bounced"`
+	Complained    int64     `json:"complained"`
+	Opened        int64     `json:"opened"`         // Emails opened at least once
+	Clicked       int64     `json:"clicked"`        // Emails with at least one clicked link
+	TotalOpens    int64     `json:"total_opens"`    // Every open, including repeated ones
+	TotalClicks   int64     `json:"total_clicks"`   // Every click, including repeated ones
+	BounceRate    float64   `json:"bounce_rate"`    // Of the sent emails
+	ComplaintRate float64   `json:"complaint_rate"` // Of the sent emails
+	OpenRate      float64   `json:"open_rate"`      // Of the sent emails
+	ClickRate     float64   `json:"click_rate"`     // Of the sent emails
+}
+
+// canManageSuppressions reports whether the caller may see and change the suppression list. It is
+// shared by every organization, so only super admins can.
+func canManageSuppressions(c *gin.Context) bool {
+	tenant, ok := tenancy.FromContext(c.Request.Context())
+	return !ok || tenant.Bypass
+}
+
+// recordEmailEvents stores events reported by a provider webhook. Webhooks are not tenant scoped,
+// the event belongs to the organization of the email.
+func recordEmailEvents(events []services.EmailEventInput) {
+	for _, event := range events {
+		if err := services.RecordEmailEvent(database.GetDB(), event); err != nil {
+			log.Printf("❌ Failed to record %s email event %s: %v", event.Provider, event.Type, err)
+		}
+	}
+}
+
+// @Summary SES event webhook
+// @Description Receives SES bounce, complaint, delivery, open and click events through an Amazon SNS subscription. Subscription confirmations are accepted automatically for the topic in SES_WEBHOOK_TOPIC_ARN.
+// @Tags email
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /notifications/email/webhooks/ses [post]
+func HandleSESWebhook(c *gin.Context) {
+	payload, err := io.ReadAll(io.LimitReader(c.Request.Body, 1<<20))
+	if err != nil {
+		apperrors.Respond(c, apperrors.BadRequest("Failed to read request body"))
+		return
+	}
+
+	var message services.SNSMessage
+	if err := json.Unmarshal(payload, &message); err != nil {
+		apperrors.Respond(c, apperrors.BadRequest("Invalid SNS message"))
+		return
+	}
+	if err := services.VerifySNSMessage(&message); err != nil {
+		apperrors.Respond(c, apperrors.Unauthorized("Invalid webhook signature"))
+		return
+	}
+
+	switch message.Type {
+	case "SubscriptionConfirmation":
+		if err := services.ConfirmSNSSubscription(&message); err != nil {
+			apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeBadGateway, "Failed to confirm subscription"))
+			return
+		}
+		log.Printf("📬 Confirmed SNS subscription to %s", message.TopicArn)
+	case "Notification":
+		events, err := services.ParseSESEvents(message.Message)
+		if err != nil {
+			apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid SES event"))
+			return
+		}
+		recordEmailEvents(events)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Received"})
+}
+
+// @Summary SendGrid event webhook
+// @Description Receives SendGrid bounce, spam report, delivery, open and click events. Requests must be signed with the key in SENDGRID_WEBHOOK_PUBLIC_KEY.
+// @Tags email
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /notifications/email/webhooks/sendgrid [post]
+func HandleSendGridWebhook(c *gin.Context) {
+	payload, err := io.ReadAll(io.LimitReader(c.Request.Body, 4<<20))
+	if err != nil {
+		apperrors.Respond(c, apperrors.BadRequest("Failed to read request body"))
+		return
+	}
+
+	if err := services.VerifySendGridSignature(payload,
+		c.GetHeader("X-Twilio-Email-Event-Webhook-Signature"),
+		c.GetHeader("X-Twilio-Email-Event-Webhook-Timestamp")); err != nil {
+		if !errors.Is(err, services.ErrInvalidWebhookSignature) {
+			log.Printf("⚠️  SendGrid webhook rejected: %v", err)
+		}
+		apperrors.Respond(c, apperrors.Unauthorized("Invalid webhook signature"))
+		return
+	}
+
+	events, err := services.ParseSendGridEvents(payload)
+	if err != nil {
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid SendGrid events"))
+		return
+	}
+	recordEmailEvents(events)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Received"})
+}
+
+// @Summary Mailgun event webhook
+// @Description Receives Mailgun failed, complained, delivered, opened and clicked events. Requests must be signed with the key in MAILGUN_WEBHOOK_SIGNING_KEY.
+// @Tags email
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /notifications/email/webhooks/mailgun [post]
+func HandleMailgunWebhook(c *gin.Context) {
+	var webhook services.MailgunWebhook
+	if err := c.ShouldBindJSON(&webhook); err != nil {
+		apperrors.Respond(c, validation.Error(err, "Invalid Mailgun webhook"))
+		return
+	}
+	if err := services.VerifyMailgunSignature(&webhook); err != nil {
+		apperrors.Respond(c, apperrors.Unauthorized("Invalid webhook signature"))
+		return
+	}
+
+	if event, ok := services.ParseMailgunEvent(&webhook); ok {
+		recordEmailEvents([]services.EmailEventInput{*event})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Received"})
+}
+
+// @Summary Track email open
+// @Description Serves the tracking pixel of an email and records the open
+// @Tags email
+// @Produce image/gif
+// @Param token path string true "Tracking token followed by .gif"
+// @Success 200 {file} binary
+// @Router /notifications/email/track/open/{token} [get]
+func TrackEmailOpen(c *gin.Context) {
+	// Mail clients show a broken image for errors, so the pixel is served either way
+	if messageID, err := services.ParseEmailTrackingToken(strings.TrimSuffix(c.Param("token"), ".gif")); err == nil {
+		recordEmailEvents([]services.EmailEventInput{{
+			MessageID: messageID,
+			Provider:  services.ProviderTracking,
+			Type:      notification.EmailEventOpened,
+			UserAgent: c.Request.UserAgent(),
+			IPAddress: c.ClientIP(),
+		}})
+	}
+
+	c.Header("Cache-Control", "no-store, no-cache, must-revalidate, private")
+	c.Data(http.StatusOK, "image/gif", trackingPixel)
+}
+
+// @Summary Track email click
+// @Description Records the click on a link of an email and redirects to the link
+// @Tags email
+// @Param token path string true "Tracking token"
+// @Param url query string true "Link target"
+// @Param sig query string true "Link signature"
+// @Success 302
+// @Failure 400 {object} map[string]interface{}
+// @Router /notifications/email/track/click/{token} [get]
+func TrackEmailClick(c *gin.Context) {
+	target := c.Query("url")
+	messageID, err := services.ParseEmailTrackingToken(c.Param("token"))
+	// Only links we signed are redirected to, otherwise the endpoint would be an open redirect
+	if err != nil || !services.VerifyClickSignature(messageID, target, c.Query("sig")) {
+		apperrors.Respond(c, apperrors.BadRequest("Invalid tracking link"))
+		return
+	}
+
+	recordEmailEvents([]services.EmailEventInput{{
+		MessageID: messageID,
+		Provider:  services.ProviderTracking,
+		Type:      notification.EmailEventClicked,
+		URL:       target,
+		UserAgent: c.Request.UserAgent(),
+		IPAddress: c.ClientIP(),
+	}})
+
+	c.Redirect(http.StatusFound, target)
+}
+
+// @Summary Get email events
+// @Description Get the delivery, bounce, complaint, open and click events of an email
+// @Tags email
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Email ID" format(uuid)
+// @Success 200 {array} notification.EmailEvent
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /notifications/email/messages/{id}/events [get]
+func GetEmailMessageEvents(c *gin.Context) {
+	messageID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apperrors.Respond(c, apperrors.BadRequest("Invalid email ID"))
+		return
+	}
+
+	db := requestDB(c)
+	var message notification.EmailMessage
+	if err := db.Select("id").First(&message, "id = ?", messageID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			apperrors.Respond(c, apperrors.NotFound("Email not found"))
+			return
+		}
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to fetch email"))
+		return
+	}
+
+	events := []notification.EmailEvent{}
+	if err := db.Where("message_id = ?", message.ID).Order("occurred_at").Find(&events).Error; err != nil {
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to fetch email events"))
+		return
+	}
+
+	c.JSON(http.StatusOK, events)
+}
+
+// @Summary Get email analytics
+// @Description Get delivery, bounce, complaint, open and click counts and rates of the emails queued in a period (default: the last 30 days)
+// @Tags email
+// @Produce json
+// @Security BearerAuth
+// @Param from query string false "Start of the period (RFC3339)"
+// @Param to query string false "End of the period (RFC3339)"
+// @Success 200 {object} EmailAnalyticsResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /notifications/email/analytics [get]
+func GetEmailAnalytics(c *gin.Context) {
+	response := EmailAnalyticsResponse{To: time.Now().UTC()}
+	if to := c.Query("to"); to != "" {
+		parsed, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			apperrors.Respond(c, apperrors.BadRequest("Invalid to, expected RFC3339"))
+			return
+		}
+		response.To = parsed
+	}
+	response.From = response.To.AddDate(0, 0, -30)
+	if from := c.Query("from"); from != "" {
+		parsed, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			apperrors.Respond(c, apperrors.BadRequest("Invalid from, expected RFC3339"))
+			return
+		}
+		response.From = parsed
+	}
+
+	db := requestDB(c)
+	var statuses []struct {
+		Status string
+		Count  int64
+	}
+	if err := db.Model(&notification.EmailMessage{}).
+		Select("status, COUNT(*) AS count").
+		Where("created_at BETWEEN ? AND ?", response.From, response.To).
+		Group("status").Scan(&statuses).Error; err != nil {
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to compute email analytics"))
+		return
+	}
+	for _, s := range statuses {
+		switch s.Status {
+		case notification.EmailStatusQueued:
+			response.Queued = s.Count
+		case notification.EmailStatusSent:
+			response.Sent += s.Count
+		case notification.EmailStatusBounced:
+			// Bounced emails were sent first
+			response.Sent += s.Count
+		case notification.EmailStatusDeadLettered:
+			response.DeadLettered = s.Count
+		case notification.EmailStatusSuppressed:
+			response.Suppressed = s.Count
+		}
+	}
+
+	// Events count once per email, except the totals of opens and clicks
+	var events []struct {
+		Type   string
+		Emails int64
+		Total  int64
+	}
+	if err := db.Model(&notification.EmailEvent{}).
+		Select("email_events.type, COUNT(DISTINCT email_events.message_id) AS emails, COUNT(*) AS total").
+		Joins("JOIN email_messages ON email_messages.id = email_events.message_id").
+		Where("email_messages.created_at BETWEEN ? AND ?", response.From, response.To).
+		Group("email_events.type").Scan(&events).Error; err != nil {
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to compute email analytics"))
+		return
+	}
+	for _, e := range events {
+		switch e.Type {
+		case notification.EmailEventDelivered:
+			response.Delivered = e.Emails
+		case notification.EmailEventBounced:
+			response.Bounced = e.Emails
+		case notification.EmailEventComplained:
+			response.Complained = e.Emails
+		case notification.EmailEventOpened:
+			response.Opened, response.TotalOpens = e.Emails, e.Total
+		case notification.EmailEventClicked:
+			response.Clicked, response.TotalClicks = e.Emails, e.Total
+		}
+	}
+
+	if response.Sent > 0 {
+		sent := float64(response.Sent)
+		response.BounceRate = float64(response.Bounced) / sent
+		response.ComplaintRate = float64(response.Complained) / sent
+		response.OpenRate = float64(response.Opened) / sent
+		response.ClickRate = float64(response.Clicked) / sent
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// @Summary Get suppressed email addresses
+// @Description Get the addresses emails are no longer sent to because they bounced, complained or were added manually. Super admins only.
+// @Tags email
+// @Produce json
+// @Security BearerAuth
+// @Param email query string false "Filter by address"
+// @Param reason query string false "Filter by reason (bounce, complaint, manual)"
+// @Success 200 {array} notification.EmailSuppression
+// @Failure 403 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /notifications/email/suppressions [get]
+func GetEmailSuppressions(c *gin.Context) {
+	if !canManageSuppressions(c) {
+		apperrors.Respond(c, apperrors.Forbidden("Only super admins can manage the suppression list"))
+		return
+	}
+
+	query := requestDB(c)
+	if email := c.Query("email"); email != "" {
+		query = query.Where("email = ?", strings.ToLower(email))
+	}
+	if reason := c.Query("reason"); reason != "" {
+		query = query.Where("reason = ?", reason)
+	}
+
+	suppressions := []notification.EmailSuppression{}
+	if err := query.Order("created_at DESC").Limit(500).Find(&suppressions).Error; err != nil {
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to fetch suppressions"))
+		return
+	}
+
+	c.JSON(http.StatusOK, suppressions)
+}
+
+// @Summary Suppress email address
+// @Description Stop sending emails to an address. Super admins only.
+// @Tags email
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body CreateEmailSuppressionRequest true "Address to suppress"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /notifications/email/suppressions [post]
+func CreateEmailSuppression(c *gin.Context) {
+	if !canManageSuppressions(c) {
+		apperrors.Respond(c, apperrors.Forbidden("Only super admins can manage the suppression list"))
+		return
+	}
+
+	var req CreateEmailSuppressionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperrors.Respond(c, validation.Error(err, "Invalid request format"))
+		return
+	}
+
+	if err := services.SuppressEmail(requestDB(c), req.Email, notification.SuppressionManual, "", nil, req.Details, utils.GetActorID(c)); err != nil {
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to suppress email address"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Email address suppressed", "email": strings.ToLower(req.Email)})
+}
+
+// @Summary Remove suppressed email address
+// @Description Send emails to a suppressed address again. Super admins only.
+// @Tags email
+// @Produce json
+// @Security BearerAuth
+// @Param email path string true "Email address"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /notifications/email/suppressions/{email} [delete]
+func DeleteEmailSuppression(c *gin.Context) {
+	if !canManageSuppressions(c) {
+		apperrors.Respond(c, apperrors.Forbidden("Only super admins can manage the suppression list"))
+		return
+	}
+
+	result := requestDB(c).Where("email = ?", strings.ToLower(c.Param("email"))).Delete(&notification.EmailSuppression{})
+	if result.Error != nil {
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to remove suppression"))
+		return
+	}
+	if result.RowsAffected == 0 {
+		apperrors.Respond(c, apperrors.NotFound("Email address is not suppressed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email address removed from the suppression list"})
+}
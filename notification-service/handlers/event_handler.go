@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"context"
+	"log"
+
+	"forgecrud-backend/notification-service/services"
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models"
+	"forgecrud-backend/shared/database/models/notification"
+	"forgecrud-backend/shared/messaging"
+
+	"gorm.io/gorm"
+)
+
+// ActivityEvents are the domain events turned into user notifications
+var ActivityEvents = []string{
+	messaging.EventDocumentDeleted,
+	messaging.EventDocumentInfected,
+	messaging.EventFolderDeleted,
+	messaging.EventFolderChanged,
+	messaging.EventUserRoleChanged,
+	messaging.EventUserOffboarded,
+	messaging.EventOrganizationAPIQuota,
+	messaging.EventOrganizationJoinRequested,
+	messaging.EventOrganizationJoinReviewed,
+}
+
+// SecurityEvents are the events users are alerted about in-app and by SMS
+var SecurityEvents = []string{
+	messaging.EventSecurityNewDeviceLogin,
+	messaging.EventSecurityPasswordChanged,
+}
+
+// EventHandler turns domain events from the event bus into notifications
+type EventHandler struct {
+	dispatcher *services.NotificationDispatcher
+	smsService *services.SMSService
+}
+
+// NewEventHandler creates a new event handler
+func NewEventHandler(dispatcher *services.NotificationDispatcher, smsService *services.SMSService) *EventHandler {
+	return &EventHandler{
+		dispatcher: dispatcher,
+		smsService: smsService,
+	}
+}
+
+// HandleActivityEvent notifies the owner of a resource about an activity on the channels they
+// selected for its category: in-app with a WebSocket push, mobile push and a user action email
+func (eh *EventHandler) HandleActivityEvent(ctx context.Context, event messaging.Event) error {
+	var data messaging.ActivityData
+	if err := event.Decode(&data); err != nil {
+		return err
+	}
+
+	db := database.GetDB()
+
+	var owner models.User
+	if err := db.First(&owner, "id = ?", data.OwnerID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			log.Printf("⚠️  %s event %s: owner %s not found, skipping notification", event.Type, event.ID, data.OwnerID)
+			return nil
+		}
+		return err
+	}
+
+	level := notification.NotificationLevelInfo
+	if data.Priority == "high" {
+		level = notification.NotificationLevelWarning
+	}
+
+	notif := notification.Notification{
+		UserID:   &owner.ID,
+		Type:     event.Type,
+		Level:    level,
+		Title:    data.ActionType,
+		Message:  data.Description,
+		Action:   event.Type,
+		EntityID: &data.ResourceID,
+		Entity:   data.ResourceType,
+	}
+	email := services.UserActionEmail(db, &owner, services.UserAction{
+		Action:       event.Type,
+		ActorID:      event.ActorID,
+		OccurredAt:   event.OccurredAt,
+		ActivityData: data,
+	})
+
+	_, err := eh.dispatcher.Dispatch(&notif, &email)
+	return err
+}
+
+// HandleSecurityEvent alerts the user about a security event with an urgent notification and a
+// text message to their verified phone
+func (eh *EventHandler) HandleSecurityEvent(ctx context.Context, event messaging.Event) error {
+	var data messaging.SecurityAlertData
+	if err := event.Decode(&data); err != nil {
+		return err
+	}
+
+	var user models.User
+	if err := database.GetDB().First(&user, "id = ?", data.UserID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			log.Printf("⚠️  %s event %s: user %s not found, skipping alert", event.Type, event.ID, data.UserID)
+			return nil
+		}
+		return err
+	}
+
+	return alertSecurityEvent(eh.dispatcher, eh.smsService, &user, event.Type, data)
+}
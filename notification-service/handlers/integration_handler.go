@@ -0,0 +1,369 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"forgecrud-backend/notification-service/services"
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models/notification"
+	"forgecrud-backend/shared/tenancy"
+	utils "forgecrud-backend/shared/utils/auth"
+	"forgecrud-backend/shared/utils/validation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CreateIntegrationRequest represents the request to connect a Slack, Teams or generic webhook integration
+type CreateIntegrationRequest struct {
+	Name       string   `json:"name" binding:"required,max=100"`
+	Kind       string   `json:"kind" binding:"required,oneof=slack teams webhook"`
+	URL        string   `json:"url" binding:"required,url,max=500"`
+	Secret     string   `json:"secret" binding:"omitempty,min=16,max=128"`
+	Categories []string `json:"categories" binding:"required,min=1"`
+	Template   string   `json:"template"`
+	IsActive   *bool    `json:"is_active"`
+	// Required for super admins acting outside an organization, defaults to the caller's organization
+	OrganizationID *uuid.UUID `json:"organization_id"`
+}
+
+// UpdateIntegrationRequest represents the request to update an integration. Omitted fields are kept.
+type UpdateIntegrationRequest struct {
+	Name       string   `json:"name" binding:"max=100"`
+	URL        string   `json:"url" binding:"omitempty,url,max=500"`
+	Secret     *string  `json:"secret" binding:"omitempty,max=128"`
+	Categories []string `json:"categories"`
+	Template   *string  `json:"template"`
+	IsActive   *bool    `json:"is_active"`
+}
+
+// validateIntegrationCategories checks the categories and returns them comma separated
+func validateIntegrationCategories(categories []string) (string, error) {
+	for _, category := range categories {
+		if category != notification.IntegrationCategoryAll && !notification.IsValidCategory(notification.NotificationCategory(category)) {
+			supported := make([]string, len(notification.NotificationCategories))
+			for i, c := range notification.NotificationCategories {
+				supported[i] = string(c)
+			}
+			return "", fmt.Errorf("unsupported category %q, supported categories: %s or %s",
+				category, strings.Join(supported, ", "), notification.IntegrationCategoryAll)
+		}
+	}
+	return strings.Join(categories, ","), nil
+}
+
+// validateIntegrationURL only allows https endpoints, which every chat provider uses
+func validateIntegrationURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" || parsed.Scheme != "https" {
+		return fmt.Errorf("url must be an absolute https URL")
+	}
+	return nil
+}
+
+// sampleIntegrationMessage is used to check templates and for test messages
+func sampleIntegrationMessage() notification.IntegrationMessage {
+	return notification.IntegrationMessage{
+		Title:     "Test notification",
+		Message:   "This is a test message from ForgeCRUD.",
+		Level:     string(notification.NotificationLevelInfo),
+		Type:      "integration.test",
+		Category:  string(notification.CategorySystem),
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+	}
+}
+
+// findIntegration loads an integration by the :id path parameter and writes the error response on failure
+func findIntegration(c *gin.Context) (*notification.NotificationIntegration, bool) {
+	integrationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apperrors.Respond(c, apperrors.BadRequest("Invalid integration ID"))
+		return nil, false
+	}
+
+	var integration notification.NotificationIntegration
+	if err := requestDB(c).First(&integration, "id = ?", integrationID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			apperrors.Respond(c, apperrors.NotFound("Integration not found"))
+			return nil, false
+		}
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to fetch integration"))
+		return nil, false
+	}
+	return &integration, true
+}
+
+// @Summary Get integrations
+// @Description Get the Slack, Teams and generic webhook integrations of the caller's organization
+// @Tags notification-integrations
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} notification.NotificationIntegration
+// @Failure 500 {object} map[string]interface{}
+// @Router /notifications/integrations [get]
+func GetIntegrations(c *gin.Context) {
+	integrations := []notification.NotificationIntegration{}
+	if err := requestDB(c).Order("created_at").Find(&integrations).Error; err != nil {
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to fetch integrations"))
+		return
+	}
+
+	c.JSON(http.StatusOK, integrations)
+}
+
+// @Summary Get integration
+// @Description Get an integration by ID
+// @Tags notification-integrations
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Integration ID" format(uuid)
+// @Success 200 {object} notification.NotificationIntegration
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /notifications/integrations/{id} [get]
+func GetIntegration(c *gin.Context) {
+	integration, ok := findIntegration(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, integration)
+}
+
+// @Summary Create integration
+// @Description Post the notifications of the organization's users in the given categories to a Slack or Teams incoming webhook, or to any https endpoint as JSON signed with the secret. The template is a Go text/template over Title, Message, Level, Type, Category, Entity, EntityID, UserName, UserEmail, Organization and Timestamp.
+// @Tags notification-integrations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param integration body CreateIntegrationRequest true "Integration"
+// @Success 201 {object} notification.NotificationIntegration
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /notifications/integrations [post]
+func CreateIntegration(c *gin.Context) {
+	var req CreateIntegrationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperrors.Respond(c, validation.Error(err, "Invalid request body"))
+		return
+	}
+
+	if err := validateIntegrationURL(req.URL); err != nil {
+		apperrors.Respond(c, apperrors.BadRequest(err.Error()))
+		return
+	}
+	categories, err := validateIntegrationCategories(req.Categories)
+	if err != nil {
+		apperrors.Respond(c, apperrors.BadRequest(err.Error()))
+		return
+	}
+
+	organizationID := req.OrganizationID
+	if organizationID == nil {
+		if tenant, ok := tenancy.FromContext(c.Request.Context()); ok {
+			organizationID = tenant.OrganizationID
+		}
+	}
+	if organizationID == nil {
+		apperrors.Respond(c, apperrors.BadRequest("organization_id is required"))
+		return
+	}
+
+	integration := notification.NotificationIntegration{
+		OrganizationID: *organizationID,
+		Name:           req.Name,
+		Kind:           req.Kind,
+		URL:            req.URL,
+		Secret:         req.Secret,
+		Categories:     categories,
+		Template:       req.Template,
+		IsActive:       req.IsActive == nil || *req.IsActive,
+	}
+	if actorID := utils.GetActorID(c); actorID != nil {
+		integration.CreatedBy = *actorID
+	}
+
+	if _, err := services.BuildIntegrationPayload(&integration, sampleIntegrationMessage()); err != nil {
+		apperrors.Respond(c, apperrors.BadRequest(err.Error()))
+		return
+	}
+
+	if err := requestDB(c).Create(&integration).Error; err != nil {
+		if errors.Is(err, database.ErrTenantMismatch) {
+			apperrors.Respond(c, apperrors.Forbidden("Cannot create integrations for another organization"))
+			return
+		}
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to create integration"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, integration)
+}
+
+// @Summary Update integration
+// @Description Update an integration. An empty secret stops signing generic webhook requests, an empty template restores the default.
+// @Tags notification-integrations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Integration ID" format(uuid)
+// @Param integration body UpdateIntegrationRequest true "Integration"
+// @Success 200 {object} notification.NotificationIntegration
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /notifications/integrations/{id} [put]
+func UpdateIntegration(c *gin.Context) {
+	integration, ok := findIntegration(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateIntegrationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperrors.Respond(c, validation.Error(err, "Invalid request body"))
+		return
+	}
+
+	if req.Name != "" {
+		integration.Name = req.Name
+	}
+	if req.URL != "" {
+		if err := validateIntegrationURL(req.URL); err != nil {
+			apperrors.Respond(c, apperrors.BadRequest(err.Error()))
+			return
+		}
+		integration.URL = req.URL
+	}
+	if req.Secret != nil {
+		if *req.Secret != "" && len(*req.Secret) < 16 {
+			apperrors.Respond(c, apperrors.BadRequest("secret must be at least 16 characters"))
+			return
+		}
+		integration.Secret = *req.Secret
+	}
+	if req.Categories != nil {
+		if len(req.Categories) == 0 {
+			apperrors.Respond(c, apperrors.BadRequest("At least one category is required"))
+			return
+		}
+		categories, err := validateIntegrationCategories(req.Categories)
+		if err != nil {
+			apperrors.Respond(c, apperrors.BadRequest(err.Error()))
+			return
+		}
+		integration.Categories = categories
+	}
+	if req.Template != nil {
+		integration.Template = *req.Template
+	}
+	if req.IsActive != nil {
+		integration.IsActive = *req.IsActive
+	}
+
+	if _, err := services.BuildIntegrationPayload(integration, sampleIntegrationMessage()); err != nil {
+		apperrors.Respond(c, apperrors.BadRequest(err.Error()))
+		return
+	}
+
+	if err := requestDB(c).Select("name", "url", "secret", "categories", "template", "is_active").Updates(integration).Error; err != nil {
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to update integration"))
+		return
+	}
+
+	c.JSON(http.StatusOK, integration)
+}
+
+// @Summary Delete integration
+// @Description Delete an integration together with its delivery log
+// @Tags notification-integrations
+// @Security BearerAuth
+// @Param id path string true "Integration ID" format(uuid)
+// @Success 204
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /notifications/integrations/{id} [delete]
+func DeleteIntegration(c *gin.Context) {
+	integration, ok := findIntegration(c)
+	if !ok {
+		return
+	}
+
+	if err := requestDB(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("integration_id = ?", integration.ID).Delete(&notification.IntegrationDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(integration).Error
+	}); err != nil {
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to delete integration"))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Send test message
+// @Description Queue a sample message to the integration; follow its outcome in the delivery log
+// @Tags notification-integrations
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Integration ID" format(uuid)
+// @Success 202 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /notifications/integrations/{id}/test [post]
+func TestIntegration(c *gin.Context) {
+	integration, ok := findIntegration(c)
+	if !ok {
+		return
+	}
+
+	if err := services.EnqueueIntegrationMessage(requestDB(c), integration, nil, sampleIntegrationMessage()); err != nil {
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to queue test message"))
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "Test message queued"})
+}
+
+// @Summary Get integration deliveries
+// @Description Get the latest messages posted to an integration with their status, attempts and last error
+// @Tags notification-integrations
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Integration ID" format(uuid)
+// @Param status query string false "Filter by status (pending, succeeded, failed)"
+// @Success 200 {array} notification.IntegrationDelivery
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /notifications/integrations/{id}/deliveries [get]
+func GetIntegrationDeliveries(c *gin.Context) {
+	integration, ok := findIntegration(c)
+	if !ok {
+		return
+	}
+
+	query := requestDB(c).Where("integration_id = ?", integration.ID)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	deliveries := []notification.IntegrationDelivery{}
+	if err := query.Order("created_at DESC").Limit(100).Find(&deliveries).Error; err != nil {
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to fetch integration deliveries"))
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
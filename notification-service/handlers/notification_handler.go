@@ -1,36 +1,328 @@
 package handlers
 
 import (
+	"io"
+	"log"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
+	"forgecrud-backend/notification-service/services"
+	"forgecrud-backend/shared/apperrors"
 	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models"
 	"forgecrud-backend/shared/database/models/notification"
+	"forgecrud-backend/shared/tenancy"
+	utils "forgecrud-backend/shared/utils/auth"
+	"forgecrud-backend/shared/utils/query"
+	"forgecrud-backend/shared/utils/validation"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
+// notificationFilters are the list filters, e.g. filters[category]=security or a date range with
+// filters[created_at][gte]=2024-01-01&filters[created_at][lt]=2024-02-01
+var notificationFilters = map[string]string{
+	"user_id":     "user_id",
+	"is_read":     "is_read",
+	"level":       "level",
+	"type":        "type",
+	"category":    "category",
+	"priority":    "priority",
+	"created_at":  "created_at",
+	"read_at":     "read_at",
+	"archived_at": "archived_at",
+}
+
 // @Summary Get all notifications
-// @Description Get all notifications for current user
+// @Description Get all notifications for current user. Archived notifications are left out unless filtered by archived_at, e.g. filters[archived_at][null]=false lists only archived ones.
 // @Tags notifications
 // @Accept json
 // @Produce json
 // @Security BearerAuth
+// @Param pagination query string false "Set to cursor for keyset pagination; the response is then wrapped in data.items/data.pagination"
+// @Param cursor query string false "Opaque cursor from pagination.next_cursor of the previous page"
+// @Param limit query int false "Items per page in cursor mode (default: 10, max: 100)"
+// @Param filters[user_id] query string false "Filter by recipient user ID"
+// @Param filters[is_read] query bool false "Filter by read status"
+// @Param filters[level] query string false "Filter by level"
+// @Param filters[category] query string false "Filter by category (documents, security, account, system)"
+// @Param filters[priority] query string false "Filter by priority (low, normal, high, urgent), e.g. filters[priority][in]=high,urgent"
+// @Param filters[created_at][gte] query string false "Created at or after (RFC 3339 or date)"
+// @Param filters[created_at][lt] query string false "Created before (RFC 3339 or date)"
+// @Param filters[archived_at][null] query bool false "false lists archived notifications only"
 // @Success 200 {array} notification.Notification
+// @Failure 400 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
 // @Router /notifications [get]
 func GetNotifications(c *gin.Context) {
 	var notifications []notification.Notification
 
-	db := database.GetDB()
-	if err := db.Find(&notifications).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch notifications"})
+	db := requestDB(c)
+
+	params := query.ParseQueryParams(c)
+	filteredQuery := query.ApplyFilters(db.Model(&notification.Notification{}), params.Filters, notificationFilters)
+	if !filtersArchived(params.Filters) {
+		filteredQuery = filteredQuery.Where("notifications.archived_at IS NULL")
+	}
+
+	if params.CursorMode {
+		cursorQuery, err := query.ApplyCursor(filteredQuery, params, "notifications")
+		if err != nil {
+			apperrors.Respond(c, apperrors.BadRequest("Invalid cursor"))
+			return
+		}
+
+		if err := cursorQuery.Find(&notifications).Error; err != nil {
+			apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to fetch notifications"))
+			return
+		}
+
+		notifications, pagination := query.TrimCursorPage(notifications, params.Limit, func(n notification.Notification) (time.Time, string) {
+			return n.CreatedAt, n.ID.String()
+		})
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data": gin.H{
+				"items":      notifications,
+				"pagination": pagination,
+			},
+		})
+		return
+	}
+
+	if err := filteredQuery.Order("created_at DESC").Find(&notifications).Error; err != nil {
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to fetch notifications"))
 		return
 	}
 
 	c.JSON(http.StatusOK, notifications)
 }
 
+// filtersArchived reports whether the list filters by archived_at, in which case archived
+// notifications are not left out
+func filtersArchived(filters map[string]string) bool {
+	for key := range filters {
+		if key == "archived_at" || strings.HasPrefix(key, "archived_at[") || strings.Contains(key, "[archived_at]") {
+			return true
+		}
+	}
+	return false
+}
+
+// @Summary Get archived notifications
+// @Description Search the notification history: notifications archived by the user or by retention, until they are purged
+// @Tags notifications
+// @Produce json
+// @Security BearerAuth
+// @Param search query string false "Search in title and message"
+// @Param page query int false "Page number (default: 1)"
+// @Param limit query int false "Results per page (default: 10, max: 100)"
+// @Param sort[field] query string false "created_at (default) or archived_at"
+// @Param sort[order] query string false "asc or desc (default)"
+// @Param filters[user_id] query string false "Filter by recipient user ID"
+// @Param filters[category] query string false "Filter by category"
+// @Param filters[created_at][gte] query string false "Created at or after (RFC 3339 or date)"
+// @Param filters[created_at][lt] query string false "Created before (RFC 3339 or date)"
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /notifications/archive [get]
+func GetArchivedNotifications(c *gin.Context) {
+	params := query.ParseQueryParams(c)
+
+	dbQuery := requestDB(c).Model(&notification.Notification{}).Where("archived_at IS NOT NULL")
+	dbQuery = query.ApplyFilters(dbQuery, params.Filters, notificationFilters)
+	dbQuery = query.ApplySearch(dbQuery, params.Search, []string{"title", "message"})
+
+	var total int64
+	if err := dbQuery.Count(&total).Error; err != nil {
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to fetch archived notifications"))
+		return
+	}
+
+	var notifications []notification.Notification
+	sortedQuery := query.ApplySort(dbQuery, params.Sort, map[string]string{
+		"created_at":  "created_at",
+		"archived_at": "archived_at",
+	})
+	if err := query.ApplyPagination(sortedQuery, params.Page, params.Limit).Find(&notifications).Error; err != nil {
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to fetch archived notifications"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"data":       notifications,
+		"pagination": query.BuildPaginationResponse(params.Page, params.Limit, total),
+	})
+}
+
+// @Summary Get unread notification count
+// @Description Get the number of unread, unarchived notifications of the current user, in total and per category
+// @Tags notifications
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /notifications/unread-count [get]
+func GetUnreadCount(c *gin.Context) {
+	userID := utils.GetActorID(c)
+	if userID == nil {
+		apperrors.Respond(c, apperrors.Unauthorized("User ID is required"))
+		return
+	}
+
+	var rows []struct {
+		Category notification.NotificationCategory
+		Count    int64
+	}
+	if err := requestDB(c).Model(&notification.Notification{}).
+		Select("category, COUNT(*) AS count").
+		Where("user_id = ? AND is_read = ? AND archived_at IS NULL", *userID, false).
+		Group("category").
+		Scan(&rows).Error; err != nil {
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to count notifications"))
+		return
+	}
+
+	categories := make(map[notification.NotificationCategory]int64, len(notification.NotificationCategories))
+	for _, category := range notification.NotificationCategories {
+		categories[category] = 0
+	}
+	var total int64
+	for _, row := range rows {
+		categories[row.Category] += row.Count
+		total += row.Count
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"unread_count": total,
+		"categories":   categories,
+	})
+}
+
+// MarkAllAsReadRequest narrows down the notifications marked as read; without a body every
+// unread notification of the current user is marked
+type MarkAllAsReadRequest struct {
+	Category notification.NotificationCategory `json:"category" binding:"omitempty,oneof=documents security account system"`
+	Before   *time.Time                        `json:"before"` // Only notifications created before this time
+}
+
+// @Summary Mark all notifications as read
+// @Description Mark every unread notification of the current user as read, optionally only those of a category or created before a time
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body MarkAllAsReadRequest false "Notifications to mark"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /notifications/read-all [post]
+func MarkAllAsRead(c *gin.Context) {
+	userID := utils.GetActorID(c)
+	if userID == nil {
+		apperrors.Respond(c, apperrors.Unauthorized("User ID is required"))
+		return
+	}
+
+	var req MarkAllAsReadRequest
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		apperrors.Respond(c, validation.Error(err, "Invalid request body"))
+		return
+	}
+
+	updateQuery := requestDB(c).Model(&notification.Notification{}).
+		Where("user_id = ? AND is_read = ?", *userID, false)
+	if req.Category != "" {
+		updateQuery = updateQuery.Where("category = ?", req.Category)
+	}
+	if req.Before != nil {
+		updateQuery = updateQuery.Where("created_at < ?", *req.Before)
+	}
+
+	result := updateQuery.Updates(map[string]interface{}{
+		"is_read": true,
+		"read_at": time.Now(),
+	})
+	if result.Error != nil {
+		apperrors.Respond(c, apperrors.Wrap(result.Error, apperrors.CodeInternal, "Failed to update notifications"))
+		return
+	}
+	if result.RowsAffected > 0 {
+		services.MarkInAppDeliveriesRead(requestDB(c), *userID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"updated": result.RowsAffected})
+}
+
+// BulkNotificationsRequest represents request body for a batch operation on notifications
+type BulkNotificationsRequest struct {
+	NotificationIDs []uuid.UUID `json:"notification_ids" binding:"required,min=1,max=500"`
+}
+
+// @Summary Delete notifications
+// @Description Delete up to 500 notifications. Unknown IDs are skipped.
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body BulkNotificationsRequest true "Notifications to delete"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /notifications/bulk/delete [post]
+func BulkDeleteNotifications(c *gin.Context) {
+	var req BulkNotificationsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperrors.Respond(c, validation.Error(err, "Invalid request body"))
+		return
+	}
+
+	result := requestDB(c).Where("id IN ?", req.NotificationIDs).Delete(&notification.Notification{})
+	if result.Error != nil {
+		apperrors.Respond(c, apperrors.Wrap(result.Error, apperrors.CodeInternal, "Failed to delete notifications"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"deleted": result.RowsAffected})
+}
+
+// @Summary Archive notifications
+// @Description Archive up to 500 notifications. Archived notifications are left out of the list and the unread count. Unknown and already archived IDs are skipped.
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body BulkNotificationsRequest true "Notifications to archive"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /notifications/bulk/archive [post]
+func BulkArchiveNotifications(c *gin.Context) {
+	var req BulkNotificationsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperrors.Respond(c, validation.Error(err, "Invalid request body"))
+		return
+	}
+
+	result := requestDB(c).Model(&notification.Notification{}).
+		Where("id IN ? AND archived_at IS NULL", req.NotificationIDs).
+		Update("archived_at", time.Now())
+	if result.Error != nil {
+		apperrors.Respond(c, apperrors.Wrap(result.Error, apperrors.CodeInternal, "Failed to archive notifications"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"archived": result.RowsAffected})
+}
+
 // @Summary Get notification by ID
 // @Description Get a specific notification by ID
 // @Tags notifications
@@ -46,22 +338,32 @@ func GetNotifications(c *gin.Context) {
 func GetNotification(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid notification ID"})
+		apperrors.Respond(c, apperrors.BadRequest("Invalid notification ID"))
 		return
 	}
 
 	var notif notification.Notification
-	db := database.GetDB()
+	db := requestDB(c)
 	if err := db.First(&notif, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Notification not found"})
+		apperrors.Respond(c, apperrors.NotFound("Notification not found"))
 		return
 	}
 
 	c.JSON(http.StatusOK, notif)
 }
 
+// NotificationHandler handles notification endpoints that deliver notifications
+type NotificationHandler struct {
+	dispatcher *services.NotificationDispatcher
+}
+
+// NewNotificationHandler creates a new notification handler
+func NewNotificationHandler(dispatcher *services.NotificationDispatcher) *NotificationHandler {
+	return &NotificationHandler{dispatcher: dispatcher}
+}
+
 // @Summary Create notification
-// @Description Create a new notification
+// @Description Create a notification for a user. It is always stored; WebSocket, push and webhook delivery follow the recipient's preferences for the category of its type.
 // @Tags notifications
 // @Accept json
 // @Produce json
@@ -71,55 +373,166 @@ func GetNotification(c *gin.Context) {
 // @Failure 400 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
 // @Router /notifications [post]
-func CreateNotification(c *gin.Context) {
+func (nh *NotificationHandler) CreateNotification(c *gin.Context) {
 	var notif notification.Notification
 
 	if err := c.ShouldBindJSON(&notif); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apperrors.Respond(c, validation.Error(err, "Invalid request body"))
 		return
 	}
 
-	db := database.GetDB()
+	db := requestDB(c)
 	if err := db.Create(&notif).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create notification"})
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to create notification"))
 		return
 	}
 
+	// The notification is stored either way, the recipient's preferences select the other channels
+	if err := services.QueueDispatch(c.Request.Context(), notif.ID); err != nil {
+		log.Printf("⚠️  Failed to queue dispatch of notification %s: %v", notif.ID, err)
+	}
+
 	c.JSON(http.StatusCreated, notif)
 }
 
+// BroadcastNotificationRequest represents the request to notify every user of an organization or,
+// for super admins, of every organization
+type BroadcastNotificationRequest struct {
+	OrganizationID   *uuid.UUID                        `json:"organization_id"`   // Defaults to the caller's organization
+	AllOrganizations bool                              `json:"all_organizations"` // Every user of every organization, super admins only
+	Type             string                            `json:"type" binding:"max=50"`
+	Category         notification.NotificationCategory `json:"category" binding:"omitempty,oneof=documents security account system"`
+	Priority         notification.NotificationPriority `json:"priority" binding:"omitempty,oneof=low normal high urgent"`
+	Level            notification.NotificationLevel    `json:"level" binding:"omitempty,oneof=success error warning info"`
+	Title            string                            `json:"title" binding:"required,max=200"`
+	Message          string                            `json:"message" binding:"required"`
+	Action           string                            `json:"action" binding:"max=100"`
+	Entity           string                            `json:"entity" binding:"max=100"`
+	EntityID         *uuid.UUID                        `json:"entity_id"`
+	Data             interface{}                       `json:"data"`
+}
+
+// @Summary Broadcast notification
+// @Description Notify every active user of an organization, by default the caller's, or with all_organizations every active user. Each user gets an own notification delivered on the channels of their preferences; delivery continues in the background.
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body BroadcastNotificationRequest true "Notification and audience"
+// @Success 202 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Failure 503 {object} map[string]interface{}
+// @Router /notifications/broadcast [post]
+func (nh *NotificationHandler) BroadcastNotification(c *gin.Context) {
+	var req BroadcastNotificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperrors.Respond(c, validation.Error(err, "Invalid request body"))
+		return
+	}
+
+	tenant, ok := tenancy.FromContext(c.Request.Context())
+	unrestricted := !ok || tenant.Bypass
+	switch {
+	case req.AllOrganizations && req.OrganizationID != nil:
+		apperrors.Respond(c, apperrors.BadRequest("organization_id and all_organizations are exclusive"))
+		return
+	case req.AllOrganizations && !unrestricted:
+		apperrors.Respond(c, apperrors.Forbidden("Only super admins can broadcast to every organization"))
+		return
+	case !req.AllOrganizations && req.OrganizationID == nil:
+		if ok {
+			req.OrganizationID = tenant.OrganizationID
+		}
+		if req.OrganizationID == nil {
+			apperrors.Respond(c, apperrors.BadRequest("organization_id is required"))
+			return
+		}
+	case req.OrganizationID != nil && !unrestricted && (tenant.OrganizationID == nil || *tenant.OrganizationID != *req.OrganizationID):
+		apperrors.Respond(c, apperrors.Forbidden("Cannot broadcast to another organization"))
+		return
+	}
+
+	usersQuery := requestDB(c).Model(&models.User{}).Where("status = ?", "ACTIVE")
+	if req.OrganizationID != nil {
+		usersQuery = usersQuery.Where("organization_id = ?", *req.OrganizationID)
+	}
+	var userIDs []uuid.UUID
+	if err := usersQuery.Pluck("id", &userIDs).Error; err != nil {
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to load recipients"))
+		return
+	}
+
+	notif := notification.Notification{
+		Type:     req.Type,
+		Category: req.Category,
+		Priority: req.Priority,
+		Level:    req.Level,
+		Title:    req.Title,
+		Message:  req.Message,
+		Action:   req.Action,
+		Entity:   req.Entity,
+		EntityID: req.EntityID,
+		Data:     req.Data,
+	}
+	if notif.Type == "" {
+		notif.Type = notification.TypeBroadcast
+	}
+	if notif.Level == "" {
+		notif.Level = notification.NotificationLevelInfo
+	}
+
+	queued, err := services.QueueBroadcast(c.Request.Context(), notif, userIDs)
+	if err != nil {
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeUnavailable, "Failed to queue the broadcast").With("queued", queued))
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":         "Broadcast queued",
+		"recipients":      len(userIDs),
+		"organization_id": req.OrganizationID,
+	})
+}
+
 // @Summary Mark notification as read
 // @Description Mark a notification as read
 // @Tags notifications
 // @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Param id path int true "Notification ID"
+// @Param id path string true "Notification ID" format(uuid)
 // @Success 200 {object} notification.Notification
 // @Failure 400 {object} map[string]interface{}
 // @Failure 404 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
 // @Router /notifications/{id}/read [put]
 func MarkAsRead(c *gin.Context) {
-	id, err := strconv.Atoi(c.Param("id"))
+	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid notification ID"})
+		apperrors.Respond(c, apperrors.BadRequest("Invalid notification ID"))
 		return
 	}
 
 	var notif notification.Notification
-	db := database.GetDB()
-	
-	if err := db.First(&notif, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Notification not found"})
+	db := requestDB(c)
+
+	if err := db.First(&notif, "id = ?", id).Error; err != nil {
+		apperrors.Respond(c, apperrors.NotFound("Notification not found"))
 		return
 	}
 
+	now := time.Now()
 	notif.IsRead = true
+	notif.ReadAt = &now
 	if err := db.Save(&notif).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notification"})
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to update notification"))
 		return
 	}
+	if notif.UserID != nil {
+		services.MarkInAppDeliveriesRead(db, *notif.UserID)
+	}
 
 	c.JSON(http.StatusOK, notif)
 }
@@ -139,15 +552,20 @@ func MarkAsRead(c *gin.Context) {
 func DeleteNotification(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid notification ID"})
+		apperrors.Respond(c, apperrors.BadRequest("Invalid notification ID"))
 		return
 	}
 
-	db := database.GetDB()
+	db := requestDB(c)
 	if err := db.Delete(&notification.Notification{}, id).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete notification"})
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to delete notification"))
 		return
 	}
 
 	c.Status(http.StatusNoContent)
 }
+
+// requestDB returns the database handle scoped to the caller's organization
+func requestDB(c *gin.Context) *gorm.DB {
+	return database.GetDB().WithContext(c.Request.Context())
+}
@@ -0,0 +1,278 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"forgecrud-backend/notification-service/services"
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/database/models/notification"
+	utils "forgecrud-backend/shared/utils/auth"
+	"forgecrud-backend/shared/utils/validation"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm/clause"
+)
+
+// NotificationPreferencesResponse lists the channels of every category and the quiet hours and
+// digest settings of a user
+type NotificationPreferencesResponse struct {
+	Categories []notification.NotificationPreference `json:"categories"`
+	QuietHours notification.NotificationSettings     `json:"quiet_hours"`
+}
+
+// UpdatePreferenceRequest represents the request to change the channels of a category.
+// Omitted channels keep their current setting.
+type UpdatePreferenceRequest struct {
+	InApp   *bool `json:"in_app"`
+	Email   *bool `json:"email"`
+	Push    *bool `json:"push"`
+	Webhook *bool `json:"webhook"`
+}
+
+// UpdateQuietHoursRequest represents the request to set the quiet hours. Empty start and end disable them.
+type UpdateQuietHoursRequest struct {
+	Start    string `json:"start" example:"22:00"`
+	End      string `json:"end" example:"07:00"`
+	Timezone string `json:"timezone" example:"Europe/Istanbul"`
+}
+
+// UpdateDigestRequest represents the request to collect low priority notifications in a digest.
+// Time is the "HH:MM" daily digests are sent at; an omitted timezone keeps the current one.
+type UpdateDigestRequest struct {
+	Frequency string `json:"frequency" binding:"required,oneof=off hourly daily" example:"daily"`
+	Time      string `json:"time" example:"09:00"`
+	Timezone  string `json:"timezone" example:"Europe/Istanbul"`
+}
+
+// @Summary Get notification preferences
+// @Description Get the channels (in_app, email, push, webhook) the current user receives each notification category on, and their quiet hours and digest settings. Categories the user has not configured show the defaults.
+// @Tags notifications
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} NotificationPreferencesResponse
+// @Failure 401 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /notifications/preferences [get]
+func GetNotificationPreferences(c *gin.Context) {
+	userID := utils.GetActorID(c)
+	if userID == nil {
+		apperrors.Respond(c, apperrors.Unauthorized("User ID is required"))
+		return
+	}
+
+	db := requestDB(c)
+	response := NotificationPreferencesResponse{Categories: []notification.NotificationPreference{}}
+	for _, category := range notification.NotificationCategories {
+		preference, err := services.GetNotificationPreference(db, *userID, category)
+		if err != nil {
+			apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to fetch notification preferences"))
+			return
+		}
+		response.Categories = append(response.Categories, preference)
+	}
+
+	settings, err := services.GetNotificationSettings(db, *userID)
+	if err != nil {
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to fetch notification preferences"))
+		return
+	}
+	response.QuietHours = settings
+
+	c.JSON(http.StatusOK, response)
+}
+
+// @Summary Update notification preference
+// @Description Select the channels the current user receives a notification category on: documents, security, account or system
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param category path string true "Notification category"
+// @Param preference body UpdatePreferenceRequest true "Channels"
+// @Success 200 {object} notification.NotificationPreference
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /notifications/preferences/{category} [put]
+func UpdateNotificationPreference(c *gin.Context) {
+	userID := utils.GetActorID(c)
+	if userID == nil {
+		apperrors.Respond(c, apperrors.Unauthorized("User ID is required"))
+		return
+	}
+
+	category := notification.NotificationCategory(c.Param("category"))
+	if !notification.IsValidCategory(category) {
+		apperrors.Respond(c, apperrors.BadRequest("Invalid notification category").With("categories", notification.NotificationCategories))
+		return
+	}
+
+	var req UpdatePreferenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperrors.Respond(c, validation.Error(err, "Invalid request body"))
+		return
+	}
+
+	db := requestDB(c)
+	preference, err := services.GetNotificationPreference(db, *userID, category)
+	if err != nil {
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to update notification preference"))
+		return
+	}
+	if req.InApp != nil {
+		preference.InApp = *req.InApp
+	}
+	if req.Email != nil {
+		preference.Email = *req.Email
+	}
+	if req.Push != nil {
+		preference.Push = *req.Push
+	}
+	if req.Webhook != nil {
+		preference.Webhook = *req.Webhook
+	}
+
+	// Categories still on the defaults get their first row here
+	if err := db.Save(&preference).Error; err != nil {
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to update notification preference"))
+		return
+	}
+
+	c.JSON(http.StatusOK, preference)
+}
+
+// @Summary Update quiet hours
+// @Description Set the quiet hours of the current user. Email and push notifications are held back between start and end in the given timezone, except error level notifications; in-app and webhook delivery continue. Empty start and end disable quiet hours.
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param quiet_hours body UpdateQuietHoursRequest true "Quiet hours"
+// @Success 200 {object} notification.NotificationSettings
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /notifications/preferences/quiet-hours [put]
+func UpdateQuietHours(c *gin.Context) {
+	userID := utils.GetActorID(c)
+	if userID == nil {
+		apperrors.Respond(c, apperrors.Unauthorized("User ID is required"))
+		return
+	}
+
+	var req UpdateQuietHoursRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperrors.Respond(c, validation.Error(err, "Invalid request body"))
+		return
+	}
+
+	if (req.Start == "") != (req.End == "") {
+		apperrors.Respond(c, apperrors.BadRequest("Start and end are both required"))
+		return
+	}
+	if req.Start != "" {
+		start, err := notification.ParseClock(req.Start)
+		if err != nil {
+			apperrors.Respond(c, apperrors.BadRequest(err.Error()))
+			return
+		}
+		end, err := notification.ParseClock(req.End)
+		if err != nil {
+			apperrors.Respond(c, apperrors.BadRequest(err.Error()))
+			return
+		}
+		if start == end {
+			apperrors.Respond(c, apperrors.BadRequest("Start and end must differ"))
+			return
+		}
+	}
+	if req.Timezone == "" {
+		req.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(req.Timezone); err != nil {
+		apperrors.Respond(c, apperrors.BadRequest("Invalid timezone"))
+		return
+	}
+
+	settings := notification.NotificationSettings{
+		UserID:          *userID,
+		QuietHoursStart: req.Start,
+		QuietHoursEnd:   req.End,
+		Timezone:        req.Timezone,
+	}
+	if err := requestDB(c).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"quiet_hours_start", "quiet_hours_end", "timezone", "updated_at"}),
+	}).Create(&settings).Error; err != nil {
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to update quiet hours"))
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// @Summary Update digest
+// @Description Collect the current user's low priority (info and success) notifications and email and push them as one hourly or daily summary instead of one message per notification. In-app notifications are still stored right away; a summary notification is added with each digest. Daily digests are sent at time in the user's timezone, no digest is sent during quiet hours. Turning the digest off sends the pending notifications.
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param digest body UpdateDigestRequest true "Digest"
+// @Success 200 {object} notification.NotificationSettings
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /notifications/preferences/digest [put]
+func UpdateDigest(c *gin.Context) {
+	userID := utils.GetActorID(c)
+	if userID == nil {
+		apperrors.Respond(c, apperrors.Unauthorized("User ID is required"))
+		return
+	}
+
+	var req UpdateDigestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperrors.Respond(c, validation.Error(err, "Invalid request body"))
+		return
+	}
+
+	if req.Time == "" {
+		req.Time = "09:00"
+	}
+	if _, err := notification.ParseClock(req.Time); err != nil {
+		apperrors.Respond(c, apperrors.BadRequest(err.Error()))
+		return
+	}
+	columns := []string{"digest_frequency", "digest_time", "updated_at"}
+	if req.Timezone != "" {
+		if _, err := time.LoadLocation(req.Timezone); err != nil {
+			apperrors.Respond(c, apperrors.BadRequest("Invalid timezone"))
+			return
+		}
+		columns = append(columns, "timezone")
+	}
+
+	db := requestDB(c)
+	settings := notification.NotificationSettings{
+		UserID:          *userID,
+		Timezone:        req.Timezone,
+		DigestFrequency: req.Frequency,
+		DigestTime:      req.Time,
+	}
+	if err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns(columns),
+	}).Create(&settings).Error; err != nil {
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to update digest"))
+		return
+	}
+
+	updated, err := services.GetNotificationSettings(db, *userID)
+	if err != nil {
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to update digest"))
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
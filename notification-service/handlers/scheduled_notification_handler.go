@@ -0,0 +1,309 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"forgecrud-backend/notification-service/services"
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models"
+	"forgecrud-backend/shared/database/models/notification"
+	"forgecrud-backend/shared/tenancy"
+	utils "forgecrud-backend/shared/utils/auth"
+	"forgecrud-backend/shared/utils/validation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// upcomingScheduledRunCount is the number of next runs shown with a schedule
+const upcomingScheduledRunCount = 5
+
+// CreateScheduledNotificationRequest represents the request to schedule a notification or email,
+// either once at run_at or on a cron recurrence
+type CreateScheduledNotificationRequest struct {
+	Kind string `json:"kind" binding:"required,oneof=notification email"`
+	Name string `json:"name" binding:"max=200"`
+
+	// Notification content
+	UserID   *uuid.UUID                     `json:"user_id"`
+	Type     string                         `json:"type" binding:"max=50"`
+	Level    notification.NotificationLevel `json:"level" binding:"omitempty,oneof=success error warning info"`
+	Title    string                         `json:"title" binding:"max=200"`
+	Message  string                         `json:"message"`
+	Action   string                         `json:"action" binding:"max=100"`
+	EntityID *uuid.UUID                     `json:"entity_id"`
+	Entity   string                         `json:"entity" binding:"max=100"`
+	Data     map[string]interface{}         `json:"data"`
+
+	// Email content
+	To           []string               `json:"to" binding:"omitempty,dive,email"`
+	Subject      string                 `json:"subject"`
+	Body         string                 `json:"body"`
+	IsHTML       bool                   `json:"is_html"`
+	TemplateID   string                 `json:"template_id" binding:"max=100"`
+	TemplateVars map[string]interface{} `json:"template_vars"`
+
+	// Schedule
+	RunAt    *time.Time `json:"run_at"`
+	Cron     string     `json:"cron" binding:"max=100"`
+	Timezone string     `json:"timezone" binding:"max=64"` // Defaults to the recipient's notification timezone, UTC for emails
+	EndsAt   *time.Time `json:"ends_at"`
+	MaxRuns  int        `json:"max_runs" binding:"min=0"`
+	// Required for super admins acting outside an organization, defaults to the caller's organization
+	OrganizationID *uuid.UUID `json:"organization_id"`
+}
+
+// ScheduledNotificationResponse is a schedule with its next runs
+type ScheduledNotificationResponse struct {
+	notification.ScheduledNotification
+	UpcomingRuns []time.Time `json:"upcoming_runs"`
+}
+
+// validateScheduledNotification checks the content of the request for its kind and returns the
+// error message, or an empty string when it is valid
+func validateScheduledNotification(req *CreateScheduledNotificationRequest) string {
+	switch {
+	case (req.RunAt == nil) == (req.Cron == ""):
+		return "Exactly one of run_at or cron is required"
+	case req.RunAt != nil && !req.RunAt.After(time.Now()):
+		return "run_at must be in the future"
+	case req.RunAt != nil && (req.EndsAt != nil || req.MaxRuns > 0):
+		return "ends_at and max_runs only apply to cron schedules"
+	case req.Kind == notification.ScheduledKindNotification && req.UserID == nil:
+		return "user_id is required for notifications"
+	case req.Kind == notification.ScheduledKindNotification && (req.Type == "" || req.Title == "" || req.Message == ""):
+		return "type, title and message are required for notifications"
+	case req.Kind == notification.ScheduledKindEmail && len(req.To) == 0:
+		return "to is required for emails"
+	case req.Kind == notification.ScheduledKindEmail && req.TemplateID == "" && (req.Subject == "" || req.Body == ""):
+		return "subject and body are required for emails without a template"
+	}
+	return ""
+}
+
+// findScheduledNotification loads a schedule by the :id path parameter and writes the error response on failure
+func findScheduledNotification(c *gin.Context) (*notification.ScheduledNotification, bool) {
+	scheduleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apperrors.Respond(c, apperrors.BadRequest("Invalid scheduled notification ID"))
+		return nil, false
+	}
+
+	var schedule notification.ScheduledNotification
+	if err := requestDB(c).First(&schedule, "id = ?", scheduleID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			apperrors.Respond(c, apperrors.NotFound("Scheduled notification not found"))
+			return nil, false
+		}
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to fetch scheduled notification"))
+		return nil, false
+	}
+	return &schedule, true
+}
+
+// @Summary Get scheduled notifications
+// @Description Get the scheduled notifications and emails of the caller's organization
+// @Tags scheduled-notifications
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "Filter by status (active, completed, cancelled, failed)"
+// @Param kind query string false "Filter by kind (notification, email)"
+// @Param user_id query string false "Filter by recipient" format(uuid)
+// @Success 200 {array} notification.ScheduledNotification
+// @Failure 500 {object} map[string]interface{}
+// @Router /notifications/scheduled [get]
+func GetScheduledNotifications(c *gin.Context) {
+	query := requestDB(c)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if kind := c.Query("kind"); kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+	if userID := c.Query("user_id"); userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+
+	schedules := []notification.ScheduledNotification{}
+	if err := query.Order("created_at DESC").Find(&schedules).Error; err != nil {
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to fetch scheduled notifications"))
+		return
+	}
+
+	c.JSON(http.StatusOK, schedules)
+}
+
+// @Summary Get scheduled notification
+// @Description Get a scheduled notification or email by ID with its next runs
+// @Tags scheduled-notifications
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Scheduled notification ID" format(uuid)
+// @Success 200 {object} ScheduledNotificationResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /notifications/scheduled/{id} [get]
+func GetScheduledNotification(c *gin.Context) {
+	schedule, ok := findScheduledNotification(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, ScheduledNotificationResponse{
+		ScheduledNotification: *schedule,
+		UpcomingRuns:          services.UpcomingScheduledRuns(schedule, upcomingScheduledRunCount),
+	})
+}
+
+// @Summary Schedule notification
+// @Description Schedule a notification to a user or an email, once at run_at or recurring on a cron expression (minute hour day-of-month month day-of-week, or @hourly, @daily, @weekly, @monthly, @yearly) evaluated in the timezone, e.g. "0 9 * * MON" for a weekly digest on Monday at 9:00
+// @Tags scheduled-notifications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param schedule body CreateScheduledNotificationRequest true "Schedule"
+// @Success 201 {object} ScheduledNotificationResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /notifications/scheduled [post]
+func CreateScheduledNotification(c *gin.Context) {
+	var req CreateScheduledNotificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperrors.Respond(c, validation.Error(err, "Invalid request body"))
+		return
+	}
+	if message := validateScheduledNotification(&req); message != "" {
+		apperrors.Respond(c, apperrors.BadRequest(message))
+		return
+	}
+
+	db := requestDB(c)
+
+	organizationID := req.OrganizationID
+	if organizationID == nil {
+		if tenant, ok := tenancy.FromContext(c.Request.Context()); ok {
+			organizationID = tenant.OrganizationID
+		}
+	}
+
+	timezone := req.Timezone
+	if timezone == "" {
+		timezone = "UTC"
+		if req.UserID != nil {
+			settings, err := services.GetNotificationSettings(db, *req.UserID)
+			if err != nil {
+				apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to fetch notification settings"))
+				return
+			}
+			timezone = settings.Timezone
+		}
+	}
+	if _, err := time.LoadLocation(timezone); err != nil {
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid timezone"))
+		return
+	}
+
+	level := req.Level
+	if level == "" {
+		level = notification.NotificationLevelInfo
+	}
+
+	schedule := notification.ScheduledNotification{
+		OrganizationID:  organizationID,
+		Kind:            req.Kind,
+		Name:            req.Name,
+		UserID:          req.UserID,
+		Type:            req.Type,
+		Level:           level,
+		Title:           req.Title,
+		Message:         req.Message,
+		Action:          req.Action,
+		EntityID:        req.EntityID,
+		Entity:          req.Entity,
+		Data:            models.JSONMap(req.Data),
+		EmailTo:         strings.Join(req.To, ","),
+		EmailSubject:    req.Subject,
+		EmailBody:       req.Body,
+		EmailIsHTML:     req.IsHTML,
+		EmailTemplateID: req.TemplateID,
+		TemplateVars:    models.JSONMap(req.TemplateVars),
+		RunAt:           req.RunAt,
+		Cron:            strings.TrimSpace(req.Cron),
+		Timezone:        timezone,
+		EndsAt:          req.EndsAt,
+		MaxRuns:         req.MaxRuns,
+		Status:          notification.ScheduleStatusActive,
+		CreatedBy:       utils.GetActorID(c),
+	}
+	if schedule.RunAt != nil {
+		runAt := schedule.RunAt.UTC()
+		schedule.RunAt = &runAt
+	}
+
+	nextRunAt, err := services.NextScheduledRun(&schedule, time.Now())
+	if err != nil {
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid cron expression"))
+		return
+	}
+	if nextRunAt == nil {
+		apperrors.Respond(c, apperrors.BadRequest("The schedule never runs before it ends"))
+		return
+	}
+	schedule.NextRunAt = nextRunAt
+
+	if err := db.Create(&schedule).Error; err != nil {
+		if errors.Is(err, database.ErrTenantMismatch) {
+			apperrors.Respond(c, apperrors.Forbidden("Cannot schedule notifications for another organization"))
+			return
+		}
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to create scheduled notification"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, ScheduledNotificationResponse{
+		ScheduledNotification: schedule,
+		UpcomingRuns:          services.UpcomingScheduledRuns(&schedule, upcomingScheduledRunCount),
+	})
+}
+
+// @Summary Cancel scheduled notification
+// @Description Cancel an active scheduled notification or email so it doesn't run again
+// @Tags scheduled-notifications
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Scheduled notification ID" format(uuid)
+// @Success 200 {object} notification.ScheduledNotification
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /notifications/scheduled/{id} [delete]
+func CancelScheduledNotification(c *gin.Context) {
+	schedule, ok := findScheduledNotification(c)
+	if !ok {
+		return
+	}
+
+	// Only cancel the schedule if the scheduler hasn't finished it in the meantime
+	result := requestDB(c).Model(schedule).
+		Where("status = ?", notification.ScheduleStatusActive).
+		Updates(map[string]interface{}{"status": notification.ScheduleStatusCancelled, "next_run_at": nil})
+	if result.Error != nil {
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to cancel scheduled notification"))
+		return
+	}
+	if result.RowsAffected == 0 {
+		apperrors.Respond(c, apperrors.Conflict("Only active schedules can be cancelled").WithDetails("status is "+schedule.Status))
+		return
+	}
+
+	schedule.Status = notification.ScheduleStatusCancelled
+	schedule.NextRunAt = nil
+	c.JSON(http.StatusOK, schedule)
+}
@@ -0,0 +1,282 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"forgecrud-backend/notification-service/services"
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models"
+	"forgecrud-backend/shared/database/models/notification"
+	"forgecrud-backend/shared/i18n"
+	"forgecrud-backend/shared/messaging"
+	utils "forgecrud-backend/shared/utils/auth"
+	"forgecrud-backend/shared/utils/validation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SMSHandler handles phone verification and SMS security alert endpoints
+type SMSHandler struct {
+	dispatcher *services.NotificationDispatcher
+	smsService *services.SMSService
+}
+
+// NewSMSHandler creates a new SMS handler
+func NewSMSHandler(dispatcher *services.NotificationDispatcher, smsService *services.SMSService) *SMSHandler {
+	return &SMSHandler{dispatcher: dispatcher, smsService: smsService}
+}
+
+// StartPhoneVerificationRequest represents the request to text a verification code to a phone
+type StartPhoneVerificationRequest struct {
+	Phone string `json:"phone" binding:"required,e164"`
+}
+
+// ConfirmPhoneVerificationRequest represents the request to confirm a phone with the texted code
+type ConfirmPhoneVerificationRequest struct {
+	Code string `json:"code" binding:"required,len=6,numeric"`
+}
+
+// SecurityAlertRequest represents the request to alert a user about a security event
+type SecurityAlertRequest struct {
+	UserID    uuid.UUID `json:"user_id" binding:"required"`
+	Type      string    `json:"type" binding:"required,oneof=security.new_device_login security.password_changed"`
+	IPAddress string    `json:"ip_address"`
+	UserAgent string    `json:"user_agent"`
+}
+
+// PhoneStatusResponse is the phone verification state of the current user
+type PhoneStatusResponse struct {
+	Phone                 string `json:"phone"`
+	PhoneVerified         bool   `json:"phone_verified"`
+	PendingPhone          string `json:"pending_phone,omitempty"`
+	PendingExpiresAt      string `json:"pending_expires_at,omitempty"`
+	SMSEnabled            bool   `json:"sms_enabled"`
+	SecurityAlertsEnabled bool   `json:"security_alerts_enabled"`
+}
+
+// @Summary Get phone verification state
+// @Description Get the phone of the current user, whether it is verified and a pending verification. Security alerts are only texted to a verified phone.
+// @Tags notifications
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} PhoneStatusResponse
+// @Failure 401 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /notifications/sms/phone [get]
+func (h *SMSHandler) GetPhoneStatus(c *gin.Context) {
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+
+	response := PhoneStatusResponse{
+		Phone:         user.Phone,
+		PhoneVerified: user.PhoneVerified,
+		SMSEnabled:    h.smsService.Enabled(),
+	}
+	response.SecurityAlertsEnabled = response.SMSEnabled && user.PhoneVerified && user.Phone != ""
+
+	var pending notification.PhoneVerification
+	if err := requestDB(c).Where("user_id = ?", user.ID).First(&pending).Error; err == nil {
+		response.PendingPhone = pending.Phone
+		response.PendingExpiresAt = pending.ExpiresAt.Format("2006-01-02T15:04:05Z07:00")
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// @Summary Start phone verification
+// @Description Text a six digit verification code to a phone number in E.164 format, e.g. +905551234567. The number becomes the verified phone of the current user once the code is confirmed. Codes are rate limited per user and per phone number.
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body StartPhoneVerificationRequest true "Phone number"
+// @Success 202 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 429 {object} map[string]interface{}
+// @Failure 503 {object} map[string]interface{}
+// @Router /notifications/sms/phone/verification [post]
+func (h *SMSHandler) StartPhoneVerification(c *gin.Context) {
+	var req StartPhoneVerificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperrors.Respond(c, validation.Error(err, "Invalid request data"))
+		return
+	}
+
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+
+	verification, err := h.smsService.StartPhoneVerification(requestDB(c), user, req.Phone)
+	if err != nil {
+		h.respondSMSError(c, "Failed to send verification code", err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":    "Verification code sent",
+		"phone":      verification.Phone,
+		"expires_at": verification.ExpiresAt,
+	})
+}
+
+// @Summary Confirm phone verification
+// @Description Confirm the pending phone verification of the current user with the texted code. A code is invalidated after five wrong attempts.
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body ConfirmPhoneVerificationRequest true "Verification code"
+// @Success 200 {object} PhoneStatusResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /notifications/sms/phone/verify [post]
+func (h *SMSHandler) ConfirmPhoneVerification(c *gin.Context) {
+	var req ConfirmPhoneVerificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperrors.Respond(c, validation.Error(err, "Invalid request data"))
+		return
+	}
+
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+
+	if err := h.smsService.ConfirmPhoneVerification(requestDB(c), user, req.Code); err != nil {
+		h.respondSMSError(c, "Failed to verify phone", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, PhoneStatusResponse{
+		Phone:                 user.Phone,
+		PhoneVerified:         true,
+		SMSEnabled:            h.smsService.Enabled(),
+		SecurityAlertsEnabled: h.smsService.Enabled(),
+	})
+}
+
+// @Summary Send security alert
+// @Description Alert a user about a security event, such as a login from a new device or a password change. The user gets an urgent in-app notification and, with a verified phone, a text message. Used by services that can't publish the event on the event bus.
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body SecurityAlertRequest true "Security event"
+// @Success 202 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /notifications/sms/security-alerts [post]
+func (h *SMSHandler) SendSecurityAlert(c *gin.Context) {
+	var req SecurityAlertRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperrors.Respond(c, validation.Error(err, "Invalid request data"))
+		return
+	}
+
+	var user models.User
+	if err := requestDB(c).First(&user, "id = ?", req.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apperrors.Respond(c, apperrors.NotFound("User not found"))
+			return
+		}
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to load user"))
+		return
+	}
+
+	data := messaging.SecurityAlertData{UserID: user.ID, IPAddress: req.IPAddress, UserAgent: req.UserAgent}
+	if err := alertSecurityEvent(h.dispatcher, h.smsService, &user, req.Type, data); err != nil {
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to send security alert"))
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "Security alert sent"})
+}
+
+// currentUser loads the calling user, responding with an error when there is none
+func (h *SMSHandler) currentUser(c *gin.Context) (*models.User, bool) {
+	userID := utils.GetActorID(c)
+	if userID == nil {
+		apperrors.Respond(c, apperrors.Unauthorized("User ID is required"))
+		return nil, false
+	}
+
+	var user models.User
+	if err := requestDB(c).First(&user, "id = ?", *userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apperrors.Respond(c, apperrors.NotFound("User not found"))
+			return nil, false
+		}
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to load user"))
+		return nil, false
+	}
+	return &user, true
+}
+
+// respondSMSError maps SMS service errors to responses
+func (h *SMSHandler) respondSMSError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, services.ErrSMSRateLimited):
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeRateLimited, message))
+	case errors.Is(err, services.ErrInvalidVerificationCode):
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeBadRequest, message))
+	case errors.Is(err, services.ErrNoSMSProvider):
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeUnavailable, message))
+	default:
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeInternal, message))
+	}
+}
+
+// alertSecurityEvent notifies the user about a security event in-app, on the channels of the
+// security category, and texts their verified phone. A failed or skipped text doesn't fail the
+// alert since the in-app notification is already stored.
+func alertSecurityEvent(dispatcher *services.NotificationDispatcher, smsService *services.SMSService, user *models.User, eventType string, data messaging.SecurityAlertData) error {
+	locale := i18n.Resolve(user.Language)
+	ipAddress := data.IPAddress
+	if ipAddress == "" {
+		ipAddress = "?"
+	}
+
+	notif := notification.Notification{
+		UserID:   &user.ID,
+		Type:     eventType,
+		Level:    notification.NotificationLevelWarning,
+		Priority: notification.NotificationPriorityUrgent,
+		Title:    i18n.T(locale, "notification."+eventType+".title"),
+		Message:  i18n.T(locale, "notification."+eventType+".message", ipAddress),
+		Action:   eventType,
+		Data: models.JSONMap{
+			"ip_address": data.IPAddress,
+			"user_agent": data.UserAgent,
+		},
+	}
+	if _, err := dispatcher.Dispatch(&notif, nil); err != nil {
+		return err
+	}
+
+	db := database.GetDB()
+	message, err := smsService.SendSecurityAlert(db, user, eventType, data)
+	switch {
+	case err == nil:
+		services.RecordDelivery(db, &notif, notification.ChannelSMS, notification.DeliveryStatusSent, &message.ID, "")
+	case errors.Is(err, services.ErrPhoneNotVerified), errors.Is(err, services.ErrNoSMSProvider), errors.Is(err, services.ErrSMSRateLimited):
+		services.RecordDelivery(db, &notif, notification.ChannelSMS, notification.DeliveryStatusSkipped, nil, err.Error())
+	default:
+		log.Printf("⚠️  %s SMS alert to user %s not sent: %v", eventType, user.ID, err)
+		var messageID *uuid.UUID
+		if message != nil {
+			messageID = &message.ID
+		}
+		services.RecordDelivery(db, &notif, notification.ChannelSMS, notification.DeliveryStatusFailed, messageID, err.Error())
+	}
+	return nil
+}
@@ -1,28 +1,176 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"strings"
 
 	"forgecrud-backend/notification-service/services"
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models/auth"
 	"forgecrud-backend/shared/database/models/notification"
+	utils "forgecrud-backend/shared/utils/auth"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
 )
 
+// authenticateRealtimeClient verifies the access token of a WebSocket handshake or event stream
+// request. Browsers can't set headers on WebSocket and EventSource requests, so besides the
+// Authorization header the token is accepted as the subprotocol following "bearer" or as the
+// access_token query parameter.
+func authenticateRealtimeClient(c *gin.Context) (*utils.Claims, error) {
+	token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if token == c.GetHeader("Authorization") {
+		token = ""
+	}
+	if token == "" {
+		protocols := websocket.Subprotocols(c.Request)
+		for i := 0; i+1 < len(protocols); i++ {
+			if protocols[i] == services.WebSocketSubprotocol {
+				token = protocols[i+1]
+				break
+			}
+		}
+	}
+	if token == "" {
+		token = c.Query("access_token")
+	}
+	if token == "" {
+		return nil, errors.New("access token is required")
+	}
+
+	claims, err := utils.ValidateJWT(token)
+	if err != nil {
+		return nil, err
+	}
+	// Refresh tokens carry no role and can't open connections
+	if claims.RoleID == "" {
+		return nil, errors.New("an access token is required")
+	}
+	if claims.MustResetPassword {
+		return nil, errors.New("password must be changed first")
+	}
+
+	// Tokens revoked at logout are rejected like the auth service does
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		return nil, errors.New("invalid user in token")
+	}
+	var blacklisted int64
+	database.GetDB().Model(&auth.BlacklistedToken{}).
+		Where("user_id = ? AND token_hash = ?", userID, utils.HashToken(token)).Count(&blacklisted)
+	if blacklisted > 0 {
+		return nil, errors.New("token has been revoked")
+	}
+	// So are the tokens of terminated sessions
+	if claims.SessionID != "" {
+		if active, err := database.IsSessionActive(database.GetDB(), claims.UserID, claims.SessionID); err == nil && !active {
+			return nil, errors.New("session has been terminated")
+		}
+	}
+	return claims, nil
+}
+
 // HandleWebSocket handles WebSocket connection requests
 // @Summary WebSocket Connection
-// @Description Establish WebSocket connection for real-time notifications
+// @Description Establish WebSocket connection for real-time notifications. The connection belongs to the user of the access token, sent as Authorization header, as the subprotocol after "bearer" or as access_token query parameter. The connection starts subscribed to the notifications and system channels; send {"type":"subscribe","channel":"organization","request_id":"1"} to also receive broadcasts to your organization, or "unsubscribe" to stop a channel. A subscribe request may carry "categories" and "priorities" lists to only receive notifications of those categories and priorities on the channel. Every request is answered with an ack or error carrying its request_id.
 // @Tags websocket
-// @Param user_id path string true "User ID"
+// @Param user_id path string false "User ID, must match the token when given"
+// @Param access_token query string false "Access token"
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Router /ws/notifications [get]
 // @Router /ws/notifications/{user_id} [get]
 func HandleWebSocket(c *gin.Context) {
+	claims, err := authenticateRealtimeClient(c)
+	if err != nil {
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeUnauthorized, "Invalid or missing token"))
+		return
+	}
+
+	// The path parameter is kept for existing clients but can't select another user
+	if userID := c.Param("user_id"); userID != "" && userID != claims.UserID {
+		apperrors.Respond(c, apperrors.Forbidden("Cannot connect as another user"))
+		return
+	}
+
 	wsManager := services.GetWebSocketManager()
-	wsManager.HandleWebSocketConnection(c)
+	wsManager.HandleWebSocketConnection(c, claims.UserID, claimsOrganization(claims), claims.SessionID)
+}
+
+// claimsOrganization returns the organization of the token, empty for users without one
+func claimsOrganization(claims *utils.Claims) string {
+	if claims.OrganizationID == uuid.Nil.String() {
+		return ""
+	}
+	return claims.OrganizationID
+}
+
+// HandleEventStream handles Server-Sent Events requests
+// @Summary Notification event stream
+// @Description Receive the real-time messages of the WebSocket connection as Server-Sent Events, for clients behind proxies that block WebSockets. Authenticate with the Authorization header or, from EventSource, the access_token query parameter. channels selects the subscriptions (notifications, organization, system), notifications and system by default; categories and priorities limit them to notifications of those categories and priorities. Every event is a data line with the JSON message including its channel.
+// @Tags websocket
+// @Produce text/event-stream
+// @Security BearerAuth
+// @Param channels query string false "Comma separated channels"
+// @Param categories query string false "Comma separated categories (documents, security, account, system)"
+// @Param priorities query string false "Comma separated priorities (low, normal, high, urgent)"
+// @Param access_token query string false "Access token"
+// @Success 200 {string} string "Event stream"
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /notifications/stream [get]
+func HandleEventStream(c *gin.Context) {
+	claims, err := authenticateRealtimeClient(c)
+	if err != nil {
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeUnauthorized, "Invalid or missing token"))
+		return
+	}
+	organizationID := claimsOrganization(claims)
+
+	channels := services.DefaultWebSocketChannels()
+	if requested := c.Query("channels"); requested != "" {
+		channels = splitQueryList(requested)
+		for _, channel := range channels {
+			if err := services.ValidateWebSocketChannel(channel, organizationID); err != nil {
+				apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid channel"))
+				return
+			}
+		}
+	}
+
+	var filter services.SubscriptionFilter
+	for _, category := range splitQueryList(c.Query("categories")) {
+		filter.Categories = append(filter.Categories, notification.NotificationCategory(category))
+	}
+	for _, priority := range splitQueryList(c.Query("priorities")) {
+		filter.Priorities = append(filter.Priorities, notification.NotificationPriority(priority))
+	}
+	if err := filter.Validate(); err != nil {
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid filter"))
+		return
+	}
+
+	services.GetWebSocketManager().HandleEventStream(c, claims.UserID, organizationID, claims.SessionID, channels, filter)
+}
+
+// splitQueryList parses a comma separated query value, dropping empty entries
+func splitQueryList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
 }
 
 // SendWebSocketMessage sends message via WebSocket service (for API Gateway)
 // @Summary Send WebSocket Message
-// @Description Send real-time message to specific user via WebSocket
+// @Description Send real-time message to specific user via WebSocket, or with organization_id instead of user_id broadcast it to the organization's users subscribed to the organization channel. The message is relayed to the notification-service instance holding the user's connection; without fan-out, sending to a user not connected to this instance fails.
 // @Tags websocket
 // @Accept json
 // @Produce json
@@ -33,16 +181,25 @@ func HandleWebSocket(c *gin.Context) {
 // @Router /ws/send [post]
 func SendWebSocketMessage(c *gin.Context) {
 	var request SendMessageRequest
-	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+	if err := c.ShouldBindJSON(&request); err != nil || (request.UserID == "") == (request.OrganizationID == "") {
+		apperrors.Respond(c, apperrors.BadRequest("Invalid request payload").WithDetails("message and exactly one of user_id or organization_id are required"))
 		return
 	}
 
 	wsManager := services.GetWebSocketManager()
 
+	if request.OrganizationID != "" {
+		wsManager.BroadcastToOrganization(request.OrganizationID, request.Message)
+		c.JSON(http.StatusOK, gin.H{
+			"message":         "WebSocket message broadcast successfully",
+			"organization_id": request.OrganizationID,
+		})
+		return
+	}
+
 	// Send message to specific user
 	if err := wsManager.SendToUser(request.UserID, request.Message); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		apperrors.Respond(c, apperrors.Internal(err))
 		return
 	}
 
@@ -52,8 +209,10 @@ func SendWebSocketMessage(c *gin.Context) {
 	})
 }
 
-// SendMessageRequest represents the request payload for sending WebSocket messages
+// SendMessageRequest represents the request payload for sending WebSocket messages to a user or
+// an organization
 type SendMessageRequest struct {
-	UserID  string                         `json:"user_id" binding:"required"`
-	Message *notification.WebSocketMessage `json:"message" binding:"required"`
+	UserID         string                         `json:"user_id"`
+	OrganizationID string                         `json:"organization_id"`
+	Message        *notification.WebSocketMessage `json:"message" binding:"required"`
 }
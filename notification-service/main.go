@@ -1,16 +1,30 @@
 package main
 
 import (
+	"context"
 	"log"
-	"net/http"
 	"strings"
+	"time"
 
+	_ "forgecrud-backend/notification-service/docs"
 	"forgecrud-backend/notification-service/handlers"
 	"forgecrud-backend/notification-service/services"
+	"forgecrud-backend/shared/clientip"
 	"forgecrud-backend/shared/config"
 	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/health"
+	"forgecrud-backend/shared/i18n"
+	"forgecrud-backend/shared/jobs"
+	"forgecrud-backend/shared/messaging"
+	"forgecrud-backend/shared/readonly"
+	"forgecrud-backend/shared/revocation"
+	"forgecrud-backend/shared/scheduler"
+	"forgecrud-backend/shared/server"
+	"forgecrud-backend/shared/tenancy"
 
 	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
 // @title Notification Service API
@@ -18,6 +32,9 @@ import (
 // @description Unified Response & Real-time Notifications
 // @host localhost:8004
 // @BasePath /api
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
 
 func main() {
 	// Load configuration
@@ -27,20 +44,122 @@ func main() {
 	if err := database.InitDatabase(); err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
-	defer database.CloseDatabase()
+	server.OnShutdown("database", database.CloseDatabase)
+	health.AddCheck("database", health.CheckDatabase)
 
-	router := gin.Default()
+	router := gin.New()
+	router.Use(server.Logger(), gin.Recovery())
+
+	// Client IPs forwarded by the gateway
+	clientip.ConfigureService(router)
+
+	// Scope queries to the caller's organization forwarded by the gateway
+	router.Use(tenancy.Middleware())
 
 	// Initialize email service
 	emailService := services.NewEmailService(config.GetConfig())
 
-	// Health check endpoint
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{
-			"service": "notification-service",
-			"status":  "healthy",
-		})
-	})
+	// Load the translations emails and notifications are rendered with
+	i18n.DefaultLocale = config.GetConfig().DefaultLocale
+	if err := i18n.Load("./shared/locales"); err != nil {
+		log.Printf("⚠️  Warning: Failed to load translation catalogs: %v", err)
+	}
+
+	// Create the default email templates on first start
+	if err := services.NewTemplateService(config.GetConfig()).SeedDefaults(); err != nil {
+		log.Printf("⚠️  Warning: Failed to seed email templates: %v", err)
+	}
+
+	// Route notifications to the channels selected in user preferences
+	dispatcher := services.NewNotificationDispatcher(emailService)
+
+	// Pause the background writers while the service is read-only
+	if err := readonly.Init("notification-service"); err != nil {
+		log.Printf("⚠️  Warning: Read-only switch not available: %v", err)
+	} else {
+		server.OnShutdown("read-only switch", readonly.Close)
+		health.AddOptionalCheck("read-only switch", health.Ping(readonly.Ping))
+	}
+
+	// Deliver created and broadcast notifications on background job workers
+	if err := jobs.Init("notification-service"); err != nil {
+		log.Printf("⚠️  Warning: Job queue not available, running jobs in-process: %v", err)
+	} else {
+		health.AddOptionalCheck("job queue", health.Ping(jobs.Ping))
+	}
+	dispatcher.RegisterJobs()
+	jobs.Start()
+	server.OnShutdown("job queue", jobs.Close)
+
+	// Text phone verification codes and security alerts through the configured SMS provider
+	smsService := services.NewSMSService(config.GetConfig())
+
+	// Turn domain events from other services into notifications
+	if err := messaging.Init("notification-service"); err != nil {
+		log.Printf("⚠️  Warning: Event bus not available: %v", err)
+	} else {
+		server.OnShutdown("event bus", messaging.Close)
+		health.AddOptionalCheck("event bus", health.Ping(messaging.Ping))
+		eventHandler := handlers.NewEventHandler(dispatcher, smsService)
+		if err := messaging.Subscribe(context.Background(), "notification-service",
+			eventHandler.HandleActivityEvent, handlers.ActivityEvents...); err != nil {
+			log.Printf("⚠️  Warning: Failed to subscribe to events: %v", err)
+		}
+		// A separate consumer group, so security events aren't split with the activity handler
+		if err := messaging.Subscribe(context.Background(), "notification-service.security",
+			eventHandler.HandleSecurityEvent, handlers.SecurityEvents...); err != nil {
+			log.Printf("⚠️  Warning: Failed to subscribe to security events: %v", err)
+		}
+	}
+
+	// Relay WebSocket messages between instances so users are reached on the instance holding their connection
+	if err := services.GetWebSocketManager().EnableFanout(config.GetConfig()); err != nil {
+		log.Printf("⚠️  Warning: WebSocket fan-out not available, delivering to local connections only: %v", err)
+	}
+	// Disconnect real-time clients on shutdown so they reconnect to another instance
+	server.OnDrain("real-time connections", services.GetWebSocketManager().CloseConnections)
+	server.OnShutdown("websocket fan-out", services.GetWebSocketManager().Close)
+	health.AddOptionalCheck("websocket fan-out", health.Ping(services.GetWebSocketManager().Ping))
+
+	// Disconnect the real-time clients of sessions terminated at the auth service
+	if err := revocation.Init(); err != nil {
+		log.Printf("⚠️  Warning: Session revocations not available: %v", err)
+	} else if err := revocation.Subscribe(services.GetWebSocketManager().CloseSessions); err != nil {
+		log.Printf("⚠️  Warning: Session revocations not available: %v", err)
+	} else {
+		server.OnShutdown("session revocations", revocation.Close)
+		health.AddOptionalCheck("session revocations", health.Ping(revocation.Ping))
+	}
+
+	// Post queued integration messages, retrying failures with backoff
+	services.NewIntegrationDispatcher().Start(5 * time.Second)
+
+	// Send queued emails, retrying failures with backoff and dead-lettering the rest
+	services.NewEmailQueueWorker(emailService).Start(5 * time.Second)
+
+	// Send scheduled and recurring notifications when they are due
+	services.NewNotificationScheduler(dispatcher, emailService).Start(30 * time.Second)
+
+	// Send the hourly and daily digests of low priority notifications on one instance
+	if err := scheduler.Init("notification-service"); err != nil {
+		log.Printf("⚠️  Warning: Scheduler lock not available, every instance runs the schedule: %v", err)
+	}
+	services.NewDigestWorker(emailService).Register()
+	scheduler.Start()
+	server.OnShutdown("scheduler", scheduler.Close)
+
+	// Archive old notifications and delete archived ones past the purge period
+	if cfg := config.GetConfig(); cfg.NotificationArchiveAfterDays > 0 || cfg.NotificationPurgeAfterDays > 0 {
+		archiveAfter := time.Duration(cfg.NotificationArchiveAfterDays) * 24 * time.Hour
+		purgeAfter := time.Duration(cfg.NotificationPurgeAfterDays) * 24 * time.Hour
+		services.NewNotificationRetention(archiveAfter, purgeAfter).Start(time.Hour)
+	}
+
+	// Liveness and readiness probes, and metrics
+	health.Register(router, "notification-service")
+
+	// Swagger documentation, merged by the gateway into its OpenAPI document
+	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	// Email routes
 	emailHandler := handlers.NewEmailHandler(emailService, config.GetConfig())
@@ -49,18 +168,93 @@ func main() {
 		emailRoutes.POST("/send", emailHandler.SendEmail)
 		emailRoutes.POST("/welcome", emailHandler.SendWelcomeEmail)
 		emailRoutes.POST("/password-reset", emailHandler.SendPasswordResetEmail)
+		emailRoutes.POST("/user-action", emailHandler.SendUserActionEmail)
 		emailRoutes.POST("/verification", emailHandler.SendVerificationEmail)
 		emailRoutes.POST("/resend-verification", emailHandler.ResendVerificationEmail)
+		emailRoutes.GET("/messages", handlers.GetEmailMessages)
+		emailRoutes.GET("/messages/:id", handlers.GetEmailMessage)
+		emailRoutes.GET("/messages/:id/events", handlers.GetEmailMessageEvents)
+		emailRoutes.GET("/analytics", handlers.GetEmailAnalytics)
+		emailRoutes.GET("/dead-letters", handlers.GetEmailDeadLetters)
+		emailRoutes.POST("/dead-letters/:id/retry", handlers.RetryEmailDeadLetter)
+		emailRoutes.GET("/suppressions", handlers.GetEmailSuppressions)
+		emailRoutes.POST("/suppressions", handlers.CreateEmailSuppression)
+		emailRoutes.DELETE("/suppressions/:email", handlers.DeleteEmailSuppression)
+
+		// Provider event webhooks and open/click tracking, verified by their signatures
+		emailRoutes.POST("/webhooks/ses", handlers.HandleSESWebhook)
+		emailRoutes.POST("/webhooks/sendgrid", handlers.HandleSendGridWebhook)
+		emailRoutes.POST("/webhooks/mailgun", handlers.HandleMailgunWebhook)
+		emailRoutes.GET("/track/open/:token", handlers.TrackEmailOpen)
+		emailRoutes.GET("/track/click/:token", handlers.TrackEmailClick)
+	}
+
+	// Email template routes
+	templateHandler := handlers.NewEmailTemplateHandler(emailService)
+	templateRoutes := router.Group("/api/notifications/email/templates")
+	{
+		templateRoutes.GET("", templateHandler.GetEmailTemplates)
+		templateRoutes.POST("", templateHandler.CreateEmailTemplate)
+		templateRoutes.GET("/:id", templateHandler.GetEmailTemplate)
+		templateRoutes.PUT("/:id", templateHandler.UpdateEmailTemplate)
+		templateRoutes.DELETE("/:id", templateHandler.DeleteEmailTemplate)
+		templateRoutes.GET("/:id/versions", templateHandler.GetEmailTemplateVersions)
+		templateRoutes.POST("/:id/versions/:version/restore", templateHandler.RestoreEmailTemplateVersion)
+		templateRoutes.POST("/:id/preview", templateHandler.PreviewEmailTemplate)
+		templateRoutes.POST("/:id/test-send", templateHandler.TestSendEmailTemplate)
 	}
 
 	// Notification routes
 	router.GET("/api/notifications", handlers.GetNotifications)
+	router.GET("/api/notifications/stream", handlers.HandleEventStream)
+	router.GET("/api/notifications/unread-count", handlers.GetUnreadCount)
+	router.GET("/api/notifications/archive", handlers.GetArchivedNotifications)
+	router.POST("/api/notifications/read-all", handlers.MarkAllAsRead)
+	router.POST("/api/notifications/bulk/delete", handlers.BulkDeleteNotifications)
+	router.POST("/api/notifications/bulk/archive", handlers.BulkArchiveNotifications)
 	router.GET("/api/notifications/:id", handlers.GetNotification)
-	router.POST("/api/notifications", handlers.CreateNotification)
+	notificationHandler := handlers.NewNotificationHandler(dispatcher)
+	router.POST("/api/notifications", notificationHandler.CreateNotification)
+	router.POST("/api/notifications/broadcast", notificationHandler.BroadcastNotification)
 	router.PUT("/api/notifications/:id/read", handlers.MarkAsRead)
 	router.DELETE("/api/notifications/:id", handlers.DeleteNotification)
+	router.GET("/api/notifications/:id/deliveries", handlers.GetNotificationDeliveries)
+
+	// Preference routes of the current user
+	router.GET("/api/notifications/preferences", handlers.GetNotificationPreferences)
+	router.PUT("/api/notifications/preferences/quiet-hours", handlers.UpdateQuietHours)
+	router.PUT("/api/notifications/preferences/digest", handlers.UpdateDigest)
+	router.PUT("/api/notifications/preferences/:category", handlers.UpdateNotificationPreference)
+
+	// Scheduled notification routes (one-off and cron recurrences)
+	router.GET("/api/notifications/scheduled", handlers.GetScheduledNotifications)
+	router.POST("/api/notifications/scheduled", handlers.CreateScheduledNotification)
+	router.GET("/api/notifications/scheduled/:id", handlers.GetScheduledNotification)
+	router.DELETE("/api/notifications/scheduled/:id", handlers.CancelScheduledNotification)
+
+	// Integration routes (Slack, Teams and generic webhooks of the organization)
+	router.GET("/api/notifications/integrations", handlers.GetIntegrations)
+	router.POST("/api/notifications/integrations", handlers.CreateIntegration)
+	router.GET("/api/notifications/integrations/:id", handlers.GetIntegration)
+	router.PUT("/api/notifications/integrations/:id", handlers.UpdateIntegration)
+	router.DELETE("/api/notifications/integrations/:id", handlers.DeleteIntegration)
+	router.POST("/api/notifications/integrations/:id/test", handlers.TestIntegration)
+	router.GET("/api/notifications/integrations/:id/deliveries", handlers.GetIntegrationDeliveries)
+
+	// SMS routes: phone verification of the current user and security alerts
+	smsHandler := handlers.NewSMSHandler(dispatcher, smsService)
+	router.GET("/api/notifications/sms/phone", smsHandler.GetPhoneStatus)
+	router.POST("/api/notifications/sms/phone/verification", smsHandler.StartPhoneVerification)
+	router.POST("/api/notifications/sms/phone/verify", smsHandler.ConfirmPhoneVerification)
+	router.POST("/api/notifications/sms/security-alerts", smsHandler.SendSecurityAlert)
+
+	// Device routes for push notifications
+	router.GET("/api/notifications/devices", handlers.GetDevices)
+	router.POST("/api/notifications/devices", handlers.RegisterDevice)
+	router.DELETE("/api/notifications/devices/:id", handlers.DeleteDevice)
 
-	// WebSocket endpoint
+	// WebSocket endpoint, authenticated with the caller's access token
+	router.GET("/ws/notifications", handlers.HandleWebSocket)
 	router.GET("/ws/notifications/:user_id", handlers.HandleWebSocket)
 
 	// WebSocket message sending endpoint (for API Gateway)
@@ -68,5 +262,5 @@ func main() {
 
 	port := strings.Split(config.GetConfig().NotificationServiceURL, ":")[2]
 	log.Printf("🔔 Notification Service starting on port %s...", port)
-	log.Fatal(router.Run(":" + port))
+	server.Run(router, ":"+port)
 }
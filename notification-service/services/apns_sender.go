@@ -0,0 +1,146 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	apnsProductionURL = "https://api.push.apple.com"
+	apnsSandboxURL    = "https://api.sandbox.push.apple.com"
+	// APNs rejects provider tokens older than an hour and throttles ones renewed more often than every 20 minutes
+	apnsTokenLifetime = 50 * time.Minute
+)
+
+// APNSSender sends push notifications through the APNs HTTP/2 API, authorized with provider tokens
+// signed by the team's auth key
+type APNSSender struct {
+	key     *ecdsa.PrivateKey
+	keyID   string
+	teamID  string
+	topic   string
+	baseURL string
+	client  *http.Client
+
+	mutex    sync.Mutex
+	token    string
+	issuedAt time.Time
+}
+
+// NewAPNSSender loads the .p8 auth key. topic is the bundle ID of the app.
+func NewAPNSSender(keyFile, keyID, teamID, topic string, production bool) (*APNSSender, error) {
+	if keyID == "" || teamID == "" || topic == "" {
+		return nil, fmt.Errorf("APNS_KEY_ID, APNS_TEAM_ID and APNS_TOPIC are required")
+	}
+	data, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read APNs key: %v", err)
+	}
+	key, err := jwt.ParseECPrivateKeyFromPEM(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid APNs key: %v", err)
+	}
+
+	baseURL := apnsSandboxURL
+	if production {
+		baseURL = apnsProductionURL
+	}
+
+	return &APNSSender{
+		key:     key,
+		keyID:   keyID,
+		teamID:  teamID,
+		topic:   topic,
+		baseURL: baseURL,
+		// Go negotiates HTTP/2, which APNs requires, on TLS connections
+		client: &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// Send pushes the message to the device token and returns the apns-id of the notification
+func (s *APNSSender) Send(ctx context.Context, token string, message PushMessage) (string, error) {
+	providerToken, err := s.providerToken()
+	if err != nil {
+		return "", err
+	}
+
+	payload := map[string]interface{}{
+		"aps": map[string]interface{}{
+			"alert": map[string]string{
+				"title": message.Title,
+				"body":  message.Body,
+			},
+			"sound": "default",
+		},
+	}
+	for key, value := range message.Data {
+		payload[key] = value
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.baseURL+"/3/device/"+token, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("authorization", "bearer "+providerToken)
+	req.Header.Set("apns-topic", s.topic)
+	req.Header.Set("apns-push-type", "alert")
+	req.Header.Set("content-type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var apnsError struct {
+			Reason string `json:"reason"`
+		}
+		json.NewDecoder(resp.Body).Decode(&apnsError)
+		// 410 means the app was uninstalled, BadDeviceToken a token of another environment or app
+		if resp.StatusCode == http.StatusGone || apnsError.Reason == "BadDeviceToken" || apnsError.Reason == "DeviceTokenNotForTopic" {
+			return "", fmt.Errorf("%w: %s", ErrInvalidDeviceToken, apnsError.Reason)
+		}
+		return "", fmt.Errorf("APNs returned %d: %s", resp.StatusCode, apnsError.Reason)
+	}
+
+	return resp.Header.Get("apns-id"), nil
+}
+
+// providerToken returns the cached provider token, signing a new one once it is about to expire
+func (s *APNSSender) providerToken() (string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.token != "" && time.Since(s.issuedAt) < apnsTokenLifetime {
+		return s.token, nil
+	}
+
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"iss": s.teamID,
+		"iat": now.Unix(),
+	})
+	token.Header["kid"] = s.keyID
+
+	signed, err := token.SignedString(s.key)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign APNs token: %v", err)
+	}
+	s.token = signed
+	s.issuedAt = now
+	return s.token, nil
+}
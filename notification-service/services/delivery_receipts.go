@@ -0,0 +1,110 @@
+package services
+
+import (
+	"log"
+	"time"
+
+	"forgecrud-backend/shared/database/models/notification"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RecordDelivery stores the delivery receipt of a notification on a channel, replacing an earlier
+// one of the channel. Notifications that were not stored have no receipts. Failures are only logged,
+// receipts never hold back a delivery.
+func RecordDelivery(db *gorm.DB, notif *notification.Notification, channel, status string, referenceID *uuid.UUID, detail string) {
+	if notif.ID == uuid.Nil || notif.UserID == nil {
+		return
+	}
+
+	now := time.Now().UTC()
+	delivery := notification.NotificationDelivery{
+		NotificationID: notif.ID,
+		UserID:         *notif.UserID,
+		Channel:        channel,
+		Status:         status,
+		ReferenceID:    referenceID,
+		Detail:         detail,
+	}
+	columns := []string{"status", "reference_id", "detail", "updated_at"}
+	if column := notification.DeliveryTimestampColumn(status); column != "" {
+		setDeliveryTimestamp(&delivery, column, now)
+		columns = append(columns, column)
+	}
+
+	if err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "notification_id"}, {Name: "channel"}},
+		DoUpdates: clause.AssignmentColumns(columns),
+	}).Create(&delivery).Error; err != nil {
+		log.Printf("⚠️  Failed to record %s delivery of notification %s: %v", channel, notif.ID, err)
+	}
+}
+
+// recordEmailDelivery stores the email receipt of a notification from the outcome of queuing its email
+func recordEmailDelivery(db *gorm.DB, notif *notification.Notification, message *notification.EmailMessage, err error) {
+	switch {
+	case err != nil:
+		RecordDelivery(db, notif, notification.ChannelEmail, notification.DeliveryStatusFailed, nil, err.Error())
+	case message.Status == notification.EmailStatusSuppressed:
+		RecordDelivery(db, notif, notification.ChannelEmail, notification.DeliveryStatusSkipped, &message.ID, message.LastError)
+	default:
+		RecordDelivery(db, notif, notification.ChannelEmail, notification.DeliveryStatusQueued, &message.ID, "")
+	}
+}
+
+// UpdateEmailDeliveries moves the receipts of the notifications emailed with the queued message
+func UpdateEmailDeliveries(db *gorm.DB, messageID uuid.UUID, status, detail string) {
+	query := db.Where("channel = ? AND reference_id = ?", notification.ChannelEmail, messageID)
+	if err := advanceDeliveries(query, status, detail); err != nil {
+		log.Printf("⚠️  Failed to update email deliveries of message %s: %v", messageID, err)
+	}
+}
+
+// UpdateDigestDeliveries moves the receipts of notifications held back for a digest
+func UpdateDigestDeliveries(db *gorm.DB, notificationIDs []uuid.UUID, status, detail string) {
+	if len(notificationIDs) == 0 {
+		return
+	}
+	query := db.Where("channel = ? AND notification_id IN ?", notification.ChannelDigest, notificationIDs)
+	if err := advanceDeliveries(query, status, detail); err != nil {
+		log.Printf("⚠️  Failed to update digest deliveries: %v", err)
+	}
+}
+
+// MarkInAppDeliveriesRead marks the in-app receipts of the user's read notifications as read
+func MarkInAppDeliveriesRead(db *gorm.DB, userID uuid.UUID) {
+	readNotifications := db.Model(&notification.Notification{}).Select("id").Where("user_id = ? AND is_read = ?", userID, true)
+	query := db.Where("channel = ? AND user_id = ? AND notification_id IN (?)", notification.ChannelInApp, userID, readNotifications)
+	if err := advanceDeliveries(query, notification.DeliveryStatusRead, ""); err != nil {
+		log.Printf("⚠️  Failed to mark in-app deliveries of user %s read: %v", userID, err)
+	}
+}
+
+// advanceDeliveries moves the receipts matched by the query to the status, leaving those that are
+// already past it, e.g. an email opened before its delivery event arrived stays read
+func advanceDeliveries(query *gorm.DB, status, detail string) error {
+	updates := map[string]interface{}{"status": status, "detail": detail}
+	if column := notification.DeliveryTimestampColumn(status); column != "" {
+		updates[column] = time.Now().UTC()
+	}
+	return query.Model(&notification.NotificationDelivery{}).
+		Where("status IN ?", notification.DeliveryPrecedingStatuses[status]).
+		Updates(updates).Error
+}
+
+func setDeliveryTimestamp(delivery *notification.NotificationDelivery, column string, t time.Time) {
+	switch column {
+	case "queued_at":
+		delivery.QueuedAt = &t
+	case "sent_at":
+		delivery.SentAt = &t
+	case "delivered_at":
+		delivery.DeliveredAt = &t
+	case "read_at":
+		delivery.ReadAt = &t
+	case "failed_at":
+		delivery.FailedAt = &t
+	}
+}
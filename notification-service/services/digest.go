@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models"
+	"forgecrud-backend/shared/database/models/notification"
+	"forgecrud-backend/shared/i18n"
+	"forgecrud-backend/shared/scheduler"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	digestBatchSize = 100
+	// At most this many notifications are listed in a digest, the rest are only counted
+	digestMaxListedItems = 20
+)
+
+// DigestWorker sends the digests of users who collect low priority notifications hourly or daily
+type DigestWorker struct {
+	emailService *EmailService
+}
+
+// NewDigestWorker creates a digest worker emailing digests through the email service
+func NewDigestWorker(emailService *EmailService) *DigestWorker {
+	return &DigestWorker{emailService: emailService}
+}
+
+// JobSendDigests is the scheduled job sending the due digests
+const JobSendDigests = "notifications.digests"
+
+// Register schedules the due digests to be sent every minute
+func (w *DigestWorker) Register() {
+	scheduler.Register(JobSendDigests, "* * * * *", func(ctx context.Context) error {
+		return w.SendDue()
+	})
+}
+
+// SendDue sends the digest of every user with pending notifications whose digest is due.
+// Settings rows are locked with SKIP LOCKED so a digest is only sent by one notification-service instance.
+func (w *DigestWorker) SendDue() error {
+	now := time.Now().UTC()
+
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		var batch []notification.NotificationSettings
+		return tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("user_id IN (SELECT DISTINCT user_id FROM notification_digest_items)").
+			FindInBatches(&batch, digestBatchSize, func(batchTx *gorm.DB, _ int) error {
+				for i := range batch {
+					if !batch[i].DigestDue(now) {
+						continue
+					}
+					if err := w.send(tx, &batch[i], now); err != nil {
+						return err
+					}
+				}
+				return nil
+			}).Error
+	})
+}
+
+// send delivers one digest summarizing the user's pending notifications and clears them
+func (w *DigestWorker) send(db *gorm.DB, settings *notification.NotificationSettings, now time.Time) error {
+	var items []notification.DigestItem
+	if err := db.Where("user_id = ?", settings.UserID).Order("created_at").Find(&items).Error; err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		return nil
+	}
+
+	itemIDs := make([]uuid.UUID, len(items))
+	for i := range items {
+		itemIDs[i] = items[i].ID
+	}
+
+	var user models.User
+	if err := db.First(&user, "id = ?", settings.UserID).Error; err != nil {
+		if err != gorm.ErrRecordNotFound {
+			return err
+		}
+		// Nobody to send the digest to anymore
+		return db.Delete(&notification.DigestItem{}, "id IN ?", itemIDs).Error
+	}
+
+	summary := digestSummary(settings, items, i18n.Resolve(user.Language))
+	if err := db.Create(summary).Error; err != nil {
+		return err
+	}
+	GetWebSocketManager().SendToUser(user.ID.String(), summary.WebSocketMessage())
+	RecordDelivery(db, summary, notification.ChannelInApp, notification.DeliveryStatusDelivered, nil, "")
+
+	push, email := false, false
+	for _, item := range items {
+		push = push || item.Push
+		email = email || item.Email
+	}
+	if push {
+		if GetPushService().PushNotification(summary) > 0 {
+			RecordDelivery(db, summary, notification.ChannelPush, notification.DeliveryStatusSent, nil, "")
+		} else {
+			RecordDelivery(db, summary, notification.ChannelPush, notification.DeliveryStatusFailed, nil, "not sent to any device")
+		}
+	}
+	if email {
+		// The email queue retries delivery; only rendering failures are reported here
+		message, err := w.emailService.QueueEmail(digestEmail(&user, settings, items, now))
+		recordEmailDelivery(db, summary, message, err)
+		if err != nil {
+			log.Printf("⚠️  Failed to queue digest email to %s: %v", user.Email, err)
+		}
+	}
+
+	heldIDs := []uuid.UUID{}
+	for _, item := range items {
+		if item.NotificationID != nil {
+			heldIDs = append(heldIDs, *item.NotificationID)
+		}
+	}
+	UpdateDigestDeliveries(db, heldIDs, notification.DeliveryStatusSent, "")
+
+	if err := db.Delete(&notification.DigestItem{}, "id IN ?", itemIDs).Error; err != nil {
+		return err
+	}
+	return db.Model(settings).Update("last_digest_at", now).Error
+}
+
+// digestSummary builds the in-app notification summarizing the pending notifications in the user's language
+func digestSummary(settings *notification.NotificationSettings, items []notification.DigestItem, locale string) *notification.Notification {
+	title := i18n.T(locale, "notification.digest.title.other", len(items))
+	if len(items) == 1 {
+		title = i18n.T(locale, "notification.digest.title.one")
+	}
+
+	var message strings.Builder
+	notificationIDs := []string{}
+	for i, item := range items {
+		if item.NotificationID != nil {
+			notificationIDs = append(notificationIDs, item.NotificationID.String())
+		}
+		if i < digestMaxListedItems {
+			fmt.Fprintf(&message, "• %s\n", item.Title)
+		}
+	}
+	if len(items) > digestMaxListedItems {
+		message.WriteString(i18n.T(locale, "notification.digest.more", len(items)-digestMaxListedItems))
+	}
+
+	userID := settings.UserID
+	return &notification.Notification{
+		UserID:  &userID,
+		Type:    notification.TypeDigest,
+		Level:   notification.NotificationLevelInfo,
+		Title:   title,
+		Message: strings.TrimSpace(message.String()),
+		Data: models.JSONMap{
+			"count":            len(items),
+			"frequency":        settings.DigestFrequency,
+			"notification_ids": notificationIDs,
+		},
+	}
+}
+
+// digestEmail builds the notification_digest email listing the pending notifications
+func digestEmail(user *models.User, settings *notification.NotificationSettings, items []notification.DigestItem, now time.Time) EmailRequest {
+	location, err := time.LoadLocation(settings.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+
+	listed := items
+	if len(listed) > digestMaxListedItems {
+		listed = listed[:digestMaxListedItems]
+	}
+	entries := make([]map[string]interface{}, len(listed))
+	for i, item := range listed {
+		entries[i] = map[string]interface{}{
+			"Title":    item.Title,
+			"Message":  item.Message,
+			"Category": item.Category,
+			"Time":     item.CreatedAt.In(location).Format("Jan 2, 15:04"),
+		}
+	}
+
+	period := "daily"
+	if settings.DigestFrequency == notification.DigestHourly {
+		period = "hourly"
+	}
+
+	return EmailRequest{
+		To:             []string{user.Email},
+		TemplateID:     "notification_digest",
+		OrganizationID: user.OrganizationID,
+		Locale:         user.Language,
+		TemplateVars: map[string]interface{}{
+			"UserName":  strings.TrimSpace(user.FirstName + " " + user.LastName),
+			"Count":     len(items),
+			"Period":    period,
+			"Items":     entries,
+			"MoreCount": len(items) - len(listed),
+			"Timestamp": now.In(location).Format(time.RFC1123),
+		},
+	}
+}
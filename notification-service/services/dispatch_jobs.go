@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models/notification"
+	"forgecrud-backend/shared/jobs"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Job types of the notification dispatcher
+const (
+	JobDispatchNotification  = "notifications.dispatch"
+	JobBroadcastNotification = "notifications.broadcast"
+)
+
+// dispatchJob delivers a stored notification
+type dispatchJob struct {
+	NotificationID uuid.UUID `json:"notification_id"`
+}
+
+// broadcastJob delivers a copy of a broadcast notification to one user
+type broadcastJob struct {
+	Notification notification.Notification `json:"notification"`
+	UserID       uuid.UUID                 `json:"user_id"`
+}
+
+// RegisterJobs registers the handlers of the dispatcher's jobs; call before jobs.Start
+func (d *NotificationDispatcher) RegisterJobs() {
+	jobs.Handle(JobDispatchNotification, d.runDispatchJob)
+	jobs.Handle(JobBroadcastNotification, d.runBroadcastJob)
+}
+
+// QueueDispatch queues the delivery of a stored notification on the channels of its recipient
+func QueueDispatch(ctx context.Context, notificationID uuid.UUID) error {
+	_, err := jobs.Enqueue(ctx, JobDispatchNotification, dispatchJob{NotificationID: notificationID},
+		jobs.WithPriority(jobs.PriorityCritical))
+	return err
+}
+
+// QueueBroadcast queues a copy of the notification for every user, each delivered following the
+// user's own preferences, and returns how many copies were queued
+func QueueBroadcast(ctx context.Context, notif notification.Notification, userIDs []uuid.UUID) (int, error) {
+	notif.ID = uuid.Nil
+	notif.UserID = nil
+
+	for i, userID := range userIDs {
+		if _, err := jobs.Enqueue(ctx, JobBroadcastNotification, broadcastJob{Notification: notif, UserID: userID},
+			jobs.WithPriority(jobs.PriorityLow)); err != nil {
+			return i, err
+		}
+	}
+	return len(userIDs), nil
+}
+
+func (d *NotificationDispatcher) runDispatchJob(ctx context.Context, job *jobs.Job) error {
+	var payload dispatchJob
+	if err := job.Decode(&payload); err != nil {
+		return fmt.Errorf("invalid payload: %v: %w", err, jobs.ErrSkipRetry)
+	}
+
+	var notif notification.Notification
+	if err := database.GetDB().WithContext(ctx).First(&notif, "id = ?", payload.NotificationID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("notification %s no longer exists: %w", payload.NotificationID, jobs.ErrSkipRetry)
+		}
+		return err
+	}
+
+	_, err := d.Dispatch(&notif, nil)
+	return err
+}
+
+func (d *NotificationDispatcher) runBroadcastJob(ctx context.Context, job *jobs.Job) error {
+	var payload broadcastJob
+	if err := job.Decode(&payload); err != nil {
+		return fmt.Errorf("invalid payload: %v: %w", err, jobs.ErrSkipRetry)
+	}
+
+	copied := payload.Notification
+	copied.ID = uuid.Nil
+	copied.UserID = &payload.UserID
+
+	_, err := d.Dispatch(&copied, nil)
+	return err
+}
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"log"
+	"time"
+
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models"
+	"forgecrud-backend/shared/database/models/notification"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// NotificationDispatcher routes notifications to the channels the recipient selected for their
+// category, holding back email and push during the recipient's quiet hours or until their digest,
+// and to the integrations of the recipient's organization
+type NotificationDispatcher struct {
+	emailService *EmailService
+}
+
+// NewNotificationDispatcher creates a dispatcher sending emails through the email service
+func NewNotificationDispatcher(emailService *EmailService) *NotificationDispatcher {
+	return &NotificationDispatcher{emailService: emailService}
+}
+
+// GetNotificationPreference returns the user's preference for a category, or the default when
+// the user has not configured it
+func GetNotificationPreference(db *gorm.DB, userID uuid.UUID, category notification.NotificationCategory) (notification.NotificationPreference, error) {
+	var preference notification.NotificationPreference
+	err := db.Where("user_id = ? AND category = ?", userID, category).First(&preference).Error
+	if err == gorm.ErrRecordNotFound {
+		return notification.DefaultNotificationPreference(userID, category), nil
+	}
+	return preference, err
+}
+
+// GetNotificationSettings returns the user's quiet hours and digest settings; users without settings
+// have no quiet hours and no digest
+func GetNotificationSettings(db *gorm.DB, userID uuid.UUID) (notification.NotificationSettings, error) {
+	settings := notification.NotificationSettings{UserID: userID, Timezone: "UTC", DigestFrequency: notification.DigestOff, DigestTime: "09:00"}
+	err := db.Where("user_id = ?", userID).First(&settings).Error
+	if err == gorm.ErrRecordNotFound {
+		return settings, nil
+	}
+	return settings, err
+}
+
+// Dispatch delivers a notification on the channels selected by its recipient and returns them.
+// A notification that is not stored yet is only stored when the in-app channel is selected, so
+// callers must not rely on its ID afterwards. email is the message for the email channel, nil when
+// the notification has no email. Urgent notifications are not held back by quiet hours. Stored
+// notifications get a delivery receipt for every selected channel.
+func (d *NotificationDispatcher) Dispatch(notif *notification.Notification, email *EmailRequest) ([]string, error) {
+	if notif.UserID == nil {
+		return nil, nil
+	}
+
+	db := database.GetDB()
+	category := notif.ResolveCategory()
+	priority := notif.ResolvePriority()
+
+	preference, err := GetNotificationPreference(db, *notif.UserID, category)
+	if err != nil {
+		return nil, err
+	}
+	settings, err := GetNotificationSettings(db, *notif.UserID)
+	if err != nil {
+		return nil, err
+	}
+	quiet := priority != notification.NotificationPriorityUrgent && settings.InQuietHours(time.Now())
+	// Low priority notifications are emailed and pushed with the recipient's next digest
+	digest := settings.DigestFrequency != notification.DigestOff && notification.IsLowPriority(priority) &&
+		(preference.Push || (preference.Email && email != nil))
+
+	channels := []string{}
+
+	if preference.InApp {
+		if notif.ID == uuid.Nil {
+			if err := db.Create(notif).Error; err != nil {
+				return nil, err
+			}
+		}
+		// Real-time push is best effort, the user may not be connected
+		GetWebSocketManager().SendToUser(notif.UserID.String(), notif.WebSocketMessage())
+		RecordDelivery(db, notif, notification.ChannelInApp, notification.DeliveryStatusDelivered, nil, "")
+		channels = append(channels, notification.ChannelInApp)
+	}
+
+	if digest {
+		item := notification.DigestItem{
+			UserID:   *notif.UserID,
+			Type:     notif.Type,
+			Category: category,
+			Level:    notif.Level,
+			Title:    notif.Title,
+			Message:  notif.Message,
+			Entity:   notif.Entity,
+			EntityID: notif.EntityID,
+			Email:    preference.Email && email != nil,
+			Push:     preference.Push,
+		}
+		if notif.ID != uuid.Nil {
+			item.NotificationID = &notif.ID
+		}
+		if err := db.Create(&item).Error; err != nil {
+			return channels, err
+		}
+		RecordDelivery(db, notif, notification.ChannelDigest, notification.DeliveryStatusQueued, nil, "held for the "+settings.DigestFrequency+" digest")
+		channels = append(channels, notification.ChannelDigest)
+	}
+
+	if preference.Push && !digest {
+		if quiet {
+			RecordDelivery(db, notif, notification.ChannelPush, notification.DeliveryStatusSkipped, nil, "quiet hours")
+		} else {
+			if GetPushService().PushNotification(notif) > 0 {
+				RecordDelivery(db, notif, notification.ChannelPush, notification.DeliveryStatusSent, nil, "")
+			} else {
+				RecordDelivery(db, notif, notification.ChannelPush, notification.DeliveryStatusFailed, nil, "not sent to any device")
+			}
+			channels = append(channels, notification.ChannelPush)
+		}
+	}
+
+	if preference.Email && !digest && email != nil {
+		if quiet {
+			RecordDelivery(db, notif, notification.ChannelEmail, notification.DeliveryStatusSkipped, nil, "quiet hours")
+		} else {
+			// The email queue retries delivery; only rendering failures are reported here
+			message, err := d.emailService.QueueEmail(*email)
+			recordEmailDelivery(db, notif, message, err)
+			if err != nil {
+				log.Printf("⚠️  Failed to queue %s notification email to %v: %v", notif.Type, email.To, err)
+			} else {
+				channels = append(channels, notification.ChannelEmail)
+			}
+		}
+	}
+
+	if preference.Webhook {
+		database.EnqueueWebhookEvent(models.WebhookEventNotificationCreated, notif)
+		RecordDelivery(db, notif, notification.ChannelWebhook, notification.DeliveryStatusQueued, nil, "")
+		channels = append(channels, notification.ChannelWebhook)
+	}
+
+	// Organization integrations are configured by admins and don't follow personal preferences
+	EnqueueIntegrations(notif)
+
+	if quiet && !digest {
+		log.Printf("🌙 Quiet hours of user %s, %s notification not emailed or pushed", notif.UserID, notif.Type)
+	}
+
+	return channels, nil
+}
@@ -0,0 +1,213 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"forgecrud-backend/shared/config"
+	"forgecrud-backend/shared/database/models/document"
+	"forgecrud-backend/shared/database/models/notification"
+	"forgecrud-backend/shared/tenancy"
+
+	"github.com/google/uuid"
+)
+
+const attachmentFetchTimeout = 60 * time.Second
+
+// ErrInvalidAttachment is returned when a document can't be attached to an email: it doesn't exist,
+// the requester can't read it, or its size or content type is not allowed
+var ErrInvalidAttachment = errors.New("invalid attachment")
+
+// AttachmentFile is the content of an attachment, downloaded right before the email is sent
+type AttachmentFile struct {
+	FileName    string
+	ContentType string
+	Content     []byte
+}
+
+// DocumentClient reads the documents attached to emails from document-service
+type DocumentClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewDocumentClient creates a client for the configured document-service
+func NewDocumentClient(cfg *config.Config) *DocumentClient {
+	return &DocumentClient{
+		baseURL:    strings.TrimRight(cfg.DocumentServiceURL, "/"),
+		httpClient: &http.Client{Timeout: attachmentFetchTimeout},
+	}
+}
+
+// Describe returns the attachment for a document. With a requester, document-service checks their
+// read access like for a download through the API gateway; without one the document is read on
+// behalf of the service.
+func (dc *DocumentClient) Describe(ctx context.Context, documentID uuid.UUID, requester *tenancy.Tenant) (*notification.EmailAttachment, error) {
+	resp, err := dc.get(ctx, fmt.Sprintf("/api/documents/%s", documentID), requester)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("%w: document %s not found", ErrInvalidAttachment, documentID)
+	case http.StatusForbidden:
+		return nil, fmt.Errorf("%w: no read access to document %s", ErrInvalidAttachment, documentID)
+	default:
+		return nil, fmt.Errorf("document-service returned %d for document %s", resp.StatusCode, documentID)
+	}
+
+	var body struct {
+		Data struct {
+			OriginalName string `json:"original_name"`
+			Size         int64  `json:"size"`
+			MimeType     string `json:"mime_type"`
+			ScanStatus   string `json:"scan_status"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("invalid document-service response for document %s: %v", documentID, err)
+	}
+
+	// Pending scans are waited for when sending, blocked files are never attached
+	if body.Data.ScanStatus == document.ScanStatusInfected || body.Data.ScanStatus == document.ScanStatusFailed {
+		return nil, fmt.Errorf("%w: document %s is blocked by the malware scan", ErrInvalidAttachment, documentID)
+	}
+
+	return &notification.EmailAttachment{
+		DocumentID:  documentID,
+		FileName:    body.Data.OriginalName,
+		ContentType: body.Data.MimeType,
+		Size:        body.Data.Size,
+	}, nil
+}
+
+// Download reads the content of an attachment, refusing files larger than maxBytes. Documents that
+// are gone or blocked are reported as ErrEmailRejected since retrying won't bring them back.
+func (dc *DocumentClient) Download(ctx context.Context, attachment notification.EmailAttachment, maxBytes int64) ([]byte, error) {
+	resp, err := dc.get(ctx, fmt.Sprintf("/api/documents/%s/download", attachment.DocumentID), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusForbidden:
+		return nil, fmt.Errorf("%w: attachment %s can't be downloaded (status %d)", ErrEmailRejected, attachment.DocumentID, resp.StatusCode)
+	default:
+		// 409 while the malware scan is running, retried with the email
+		return nil, fmt.Errorf("document-service returned %d for attachment %s", resp.StatusCode, attachment.DocumentID)
+	}
+
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(content)) > maxBytes {
+		return nil, fmt.Errorf("%w: attachment %s is larger than %d bytes", ErrEmailRejected, attachment.DocumentID, maxBytes)
+	}
+	return content, nil
+}
+
+func (dc *DocumentClient) get(ctx context.Context, path string, requester *tenancy.Tenant) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, dc.baseURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if requester != nil {
+		tenancy.Forward(req, *requester)
+	}
+
+	resp, err := dc.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("document-service unavailable: %v", err)
+	}
+	return resp, nil
+}
+
+// checkAttachments describes the documents of the request and checks them against the size limits
+// and allowed content types
+func (es *EmailService) checkAttachments(request EmailRequest) (notification.EmailAttachments, error) {
+	if len(request.AttachmentDocumentIDs) == 0 {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), attachmentFetchTimeout)
+	defer cancel()
+
+	attachments := make(notification.EmailAttachments, 0, len(request.AttachmentDocumentIDs))
+	seen := map[uuid.UUID]bool{}
+	var total int64
+	for _, documentID := range request.AttachmentDocumentIDs {
+		if seen[documentID] {
+			continue
+		}
+		seen[documentID] = true
+
+		attachment, err := es.documents.Describe(ctx, documentID, request.Requester)
+		if err != nil {
+			return nil, err
+		}
+		if attachment.Size > es.config.EmailAttachmentMaxBytes {
+			return nil, fmt.Errorf("%w: %s is larger than %d bytes", ErrInvalidAttachment, attachment.FileName, es.config.EmailAttachmentMaxBytes)
+		}
+		if !attachmentTypeAllowed(attachment.ContentType, es.config.EmailAttachmentAllowedTypes) {
+			return nil, fmt.Errorf("%w: content type %s of %s is not allowed", ErrInvalidAttachment, attachment.ContentType, attachment.FileName)
+		}
+		if total += attachment.Size; total > es.config.EmailAttachmentTotalMaxBytes {
+			return nil, fmt.Errorf("%w: attachments are larger than %d bytes together", ErrInvalidAttachment, es.config.EmailAttachmentTotalMaxBytes)
+		}
+		attachments = append(attachments, *attachment)
+	}
+	return attachments, nil
+}
+
+// downloadAttachments fetches the content of the attachments of an email
+func (es *EmailService) downloadAttachments(attachments notification.EmailAttachments) ([]AttachmentFile, error) {
+	if len(attachments) == 0 {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), attachmentFetchTimeout)
+	defer cancel()
+
+	files := make([]AttachmentFile, len(attachments))
+	for i, attachment := range attachments {
+		content, err := es.documents.Download(ctx, attachment, es.config.EmailAttachmentMaxBytes)
+		if err != nil {
+			return nil, err
+		}
+		files[i] = AttachmentFile{FileName: attachment.FileName, ContentType: attachment.ContentType, Content: content}
+	}
+	return files, nil
+}
+
+// attachmentTypeAllowed reports whether the content type is in the comma separated list of allowed
+// types, where "image/*" allows every image type
+func attachmentTypeAllowed(contentType, allowed string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	for _, pattern := range strings.Split(allowed, ",") {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern == mediaType || pattern == "*/*" {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(pattern, "/*"); ok && strings.HasPrefix(mediaType, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,216 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"forgecrud-backend/shared/config"
+)
+
+const emailSendTimeout = 30 * time.Second
+
+// Email provider names used in EMAIL_PROVIDER and EMAIL_FAILOVER_PROVIDER
+const (
+	EmailProviderSMTP     = "smtp"
+	EmailProviderSES      = "ses"
+	EmailProviderSendGrid = "sendgrid"
+	EmailProviderMailgun  = "mailgun"
+)
+
+// ErrEmailRejected is returned by a provider when it refuses the message itself, e.g. for an invalid
+// recipient. Sending it again to the same provider won't succeed.
+var ErrEmailRejected = errors.New("email rejected by provider")
+
+// ErrNoEmailProvider is returned when no email provider is configured
+var ErrNoEmailProvider = errors.New("no email provider is configured")
+
+// EmailProvider delivers rendered emails through a mail service
+type EmailProvider interface {
+	// Send delivers the message and returns the provider's message ID
+	Send(ctx context.Context, request EmailRequest) (string, error)
+}
+
+// EmailDelivery is the outcome of a successful send
+type EmailDelivery struct {
+	Provider  string
+	MessageID string
+}
+
+// emailProviderSlot is a configured provider with its rate limit
+type emailProviderSlot struct {
+	name     string
+	provider EmailProvider
+	limiter  *emailRateLimiter
+}
+
+// EmailProviders sends through the primary provider and fails over to the secondary one when the
+// primary rejects a message, is unavailable or is out of its rate limit
+type EmailProviders struct {
+	primary   *emailProviderSlot
+	secondary *emailProviderSlot
+}
+
+// NewEmailProviders sets up the providers selected by EMAIL_PROVIDER and EMAIL_FAILOVER_PROVIDER.
+// A provider that is not configured correctly is disabled with a warning.
+func NewEmailProviders(cfg *config.Config) *EmailProviders {
+	providers := &EmailProviders{}
+	providers.primary = newEmailProviderSlot(cfg, cfg.EmailProvider)
+	if cfg.EmailFailoverProvider != "" && cfg.EmailFailoverProvider != cfg.EmailProvider {
+		providers.secondary = newEmailProviderSlot(cfg, cfg.EmailFailoverProvider)
+	}
+
+	// Without a working primary the failover provider sends everything
+	if providers.primary == nil {
+		providers.primary, providers.secondary = providers.secondary, nil
+	}
+	if providers.primary == nil {
+		log.Printf("⚠️  No email provider configured, emails can't be sent")
+	}
+	return providers
+}
+
+func newEmailProviderSlot(cfg *config.Config, name string) *emailProviderSlot {
+	var provider EmailProvider
+	var rateLimit int
+	var err error
+
+	switch strings.ToLower(name) {
+	case EmailProviderSMTP:
+		provider, err = NewSMTPProvider(cfg)
+		rateLimit = cfg.SMTPRateLimit
+	case EmailProviderSES:
+		provider, err = NewSESProvider(cfg)
+		rateLimit = cfg.SESRateLimit
+	case EmailProviderSendGrid:
+		provider, err = NewSendGridProvider(cfg)
+		rateLimit = cfg.SendGridRateLimit
+	case EmailProviderMailgun:
+		provider, err = NewMailgunProvider(cfg)
+		rateLimit = cfg.MailgunRateLimit
+	case "":
+		return nil
+	default:
+		err = fmt.Errorf("unknown provider")
+	}
+	if err != nil {
+		log.Printf("⚠️  Email provider %s disabled: %v", name, err)
+		return nil
+	}
+
+	log.Printf("📧 Email provider %s enabled", name)
+	return &emailProviderSlot{
+		name:     strings.ToLower(name),
+		provider: provider,
+		limiter:  newEmailRateLimiter(float64(rateLimit)),
+	}
+}
+
+// Send delivers the message through the primary provider, or the secondary one if the primary can't.
+// The secondary provider also takes over while the primary is out of its rate limit.
+func (ep *EmailProviders) Send(ctx context.Context, request EmailRequest) (*EmailDelivery, error) {
+	if ep.primary == nil {
+		return nil, ErrNoEmailProvider
+	}
+
+	first, second := ep.primary, ep.secondary
+	if !first.limiter.Allow() {
+		if second != nil && second.limiter.Allow() {
+			return ep.failover(ctx, second, first, request)
+		}
+		if err := first.limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	return ep.failover(ctx, first, second, request)
+}
+
+// failover sends through the first provider, whose rate limit is already taken, and tries the second
+// one when that fails
+func (ep *EmailProviders) failover(ctx context.Context, first, second *emailProviderSlot, request EmailRequest) (*EmailDelivery, error) {
+	messageID, err := first.provider.Send(ctx, request)
+	if err == nil {
+		return &EmailDelivery{Provider: first.name, MessageID: messageID}, nil
+	}
+	if second == nil {
+		return nil, fmt.Errorf("%s: %w", first.name, err)
+	}
+
+	log.Printf("⚠️  Email provider %s failed, failing over to %s: %v", first.name, second.name, err)
+	if waitErr := second.limiter.Wait(ctx); waitErr != nil {
+		return nil, fmt.Errorf("%s: %w", first.name, err)
+	}
+	messageID, secondErr := second.provider.Send(ctx, request)
+	if secondErr != nil {
+		return nil, fmt.Errorf("%s: %v; %s: %w", first.name, err, second.name, secondErr)
+	}
+	return &EmailDelivery{Provider: second.name, MessageID: messageID}, nil
+}
+
+// emailRateLimiter is a token bucket allowing rate messages per second with bursts of up to one
+// second's worth. A rate of zero doesn't limit.
+type emailRateLimiter struct {
+	mutex  sync.Mutex
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+func newEmailRateLimiter(rate float64) *emailRateLimiter {
+	return &emailRateLimiter{rate: rate, tokens: burstOf(rate), last: time.Now()}
+}
+
+func burstOf(rate float64) float64 {
+	if rate < 1 {
+		return 1
+	}
+	return rate
+}
+
+// reserve takes a token if one is available, otherwise returns how long until the next one
+func (l *emailRateLimiter) reserve() time.Duration {
+	if l.rate <= 0 {
+		return 0
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if burst := burstOf(l.rate); l.tokens > burst {
+		l.tokens = burst
+	}
+	l.last = now
+
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0
+	}
+	return time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+}
+
+// Allow takes a token if one is available right away
+func (l *emailRateLimiter) Allow() bool {
+	return l.reserve() == 0
+}
+
+// Wait blocks until a token is available or the context is done
+func (l *emailRateLimiter) Wait(ctx context.Context) error {
+	for {
+		delay := l.reserve()
+		if delay == 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"forgecrud-backend/shared/config"
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models/notification"
+	"forgecrud-backend/shared/readonly"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	emailQueueBatchSize   = 20
+	emailQueueBaseBackoff = 30 * time.Second
+)
+
+// EmailQueueWorker sends queued emails and retries failed ones with exponential backoff.
+// Messages that run out of attempts are moved to the dead-letter table.
+type EmailQueueWorker struct {
+	emailService *EmailService
+	maxAttempts  int
+}
+
+func NewEmailQueueWorker(emailService *EmailService) *EmailQueueWorker {
+	return &EmailQueueWorker{
+		emailService: emailService,
+		maxAttempts:  config.GetConfig().EmailQueueMaxAttempts,
+	}
+}
+
+// Start polls for due messages in the background
+func (w *EmailQueueWorker) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if readonly.Active() {
+				continue
+			}
+			if err := w.ProcessDue(); err != nil {
+				log.Printf("⚠️  Email queue processing failed: %v", err)
+			}
+		}
+	}()
+
+	log.Printf("📮 Email queue worker started (interval: %s)", interval)
+}
+
+// ProcessDue sends a batch of queued messages whose next attempt is due.
+// Rows are locked with SKIP LOCKED so several notification-service instances can send concurrently.
+func (w *EmailQueueWorker) ProcessDue() error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		var messages []notification.EmailMessage
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", notification.EmailStatusQueued, time.Now().UTC()).
+			Order("next_attempt_at").
+			Limit(emailQueueBatchSize).
+			Find(&messages).Error; err != nil {
+			return err
+		}
+
+		for i := range messages {
+			if err := w.send(tx, &messages[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// send performs one attempt and records its outcome
+func (w *EmailQueueWorker) send(db *gorm.DB, message *notification.EmailMessage) error {
+	now := time.Now().UTC()
+	message.Attempts++
+
+	request := EmailRequest{
+		To:       splitAddresses(message.To),
+		CC:       splitAddresses(message.CC),
+		BCC:      splitAddresses(message.BCC),
+		Subject:  message.Subject,
+		Body:     message.Body,
+		TextBody: message.TextBody,
+		IsHTML:   message.IsHTML,
+	}
+	var delivery *EmailDelivery
+	files, err := w.emailService.downloadAttachments(message.Attachments)
+	if err == nil {
+		request.AttachmentFiles = files
+		delivery, err = w.emailService.deliver(request)
+	}
+
+	if err == nil {
+		// Bodies may hold one-time codes, there's no reason to keep them once delivered
+		message.Status = notification.EmailStatusSent
+		message.Provider = delivery.Provider
+		message.ProviderMessageID = delivery.MessageID
+		message.SentAt = &now
+		message.LastError = ""
+		message.Body = ""
+		message.TextBody = ""
+		if err := db.Model(message).Select(
+			"status", "attempts", "provider", "provider_message_id", "sent_at", "last_error", "body", "text_body",
+		).Updates(message).Error; err != nil {
+			return err
+		}
+		UpdateEmailDeliveries(db, message.ID, notification.DeliveryStatusSent, "")
+		return nil
+	}
+
+	message.LastError = err.Error()
+	if errors.Is(err, ErrEmailRejected) || message.Attempts >= w.maxAttempts {
+		log.Printf("❌ Email %s to %s dead-lettered after %d attempts: %v", message.ID, message.To, message.Attempts, err)
+		message.Status = notification.EmailStatusDeadLettered
+		deadLetter := notification.EmailDeadLetter{
+			MessageID:      message.ID,
+			OrganizationID: message.OrganizationID,
+			To:             message.To,
+			Subject:        message.Subject,
+			TemplateID:     message.TemplateID,
+			Attempts:       message.Attempts,
+			Error:          message.LastError,
+		}
+		if err := db.Create(&deadLetter).Error; err != nil {
+			return err
+		}
+		UpdateEmailDeliveries(db, message.ID, notification.DeliveryStatusFailed, message.LastError)
+	} else {
+		// 30s, 1m, 2m, 4m, ...
+		message.NextAttemptAt = now.Add(emailQueueBaseBackoff << (message.Attempts - 1))
+	}
+
+	return db.Model(message).Select(
+		"status", "attempts", "next_attempt_at", "last_error",
+	).Updates(message).Error
+}
+
+// RequeueDeadLetter queues a dead-lettered message again with a fresh set of attempts
+func RequeueDeadLetter(db *gorm.DB, deadLetter *notification.EmailDeadLetter) (*notification.EmailMessage, error) {
+	var message notification.EmailMessage
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&message, "id = ?", deadLetter.MessageID).Error; err != nil {
+			return err
+		}
+
+		message.Status = notification.EmailStatusQueued
+		message.Attempts = 0
+		message.NextAttemptAt = time.Now().UTC()
+		if err := tx.Model(&message).Select("status", "attempts", "next_attempt_at").Updates(&message).Error; err != nil {
+			return err
+		}
+		UpdateEmailDeliveries(tx, message.ID, notification.DeliveryStatusQueued, "")
+		return tx.Delete(deadLetter).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
+
+func splitAddresses(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
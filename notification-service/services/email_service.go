@@ -1,14 +1,21 @@
 package services
 
 import (
-	"crypto/tls"
+	"context"
 	"fmt"
 	"log"
-	"net/smtp"
 	"strings"
 	"time"
 
 	"forgecrud-backend/shared/config"
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models"
+	"forgecrud-backend/shared/database/models/notification"
+	"forgecrud-backend/shared/i18n"
+	"forgecrud-backend/shared/ids"
+	"forgecrud-backend/shared/tenancy"
+
+	"github.com/google/uuid"
 )
 
 // EmailRequest represents a simple email request
@@ -16,24 +23,40 @@ type EmailRequest struct {
 	To           []string               `json:"to" binding:"required"`
 	CC           []string               `json:"cc,omitempty"`
 	BCC          []string               `json:"bcc,omitempty"`
-	Subject      string                 `json:"subject" binding:"required"`
+	Subject      string                 `json:"subject"` // Defaults to the template's subject
 	Body         string                 `json:"body"`
+	TextBody     string                 `json:"text_body,omitempty"` // Plain text alternative of an HTML body
 	IsHTML       bool                   `json:"is_html"`
-	TemplateID   string                 `json:"template_id,omitempty"`
+	TemplateID   string                 `json:"template_id,omitempty"` // Key of the email template
 	TemplateVars map[string]interface{} `json:"template_vars,omitempty"`
+	// Organization whose template override is used, the global template otherwise
+	OrganizationID *uuid.UUID `json:"organization_id,omitempty"`
+	// Language the template is rendered in, defaults to the preferred language of the first recipient
+	Locale string `json:"locale,omitempty"`
+	// Documents attached to the email, fetched from document-service when it is sent
+	AttachmentDocumentIDs []uuid.UUID `json:"attachment_document_ids,omitempty" binding:"omitempty,max=10"`
+
+	// Caller whose read access to the attached documents is checked, nil for the service itself
+	Requester *tenancy.Tenant `json:"-"`
+	// Content of the attachments, set right before sending
+	AttachmentFiles []AttachmentFile `json:"-"`
 }
 
 // EmailResponse represents the response after sending an email
 type EmailResponse struct {
-	Success bool   `json:"success"`
-	Message string `json:"message"`
-	SentAt  string `json:"sent_at"`
+	Success   bool   `json:"success"`
+	Message   string `json:"message"`
+	SentAt    string `json:"sent_at"`
+	Provider  string `json:"provider,omitempty"`
+	MessageID string `json:"message_id,omitempty"`
 }
 
 // EmailService handles sending emails
 type EmailService struct {
 	config          *config.Config
 	templateService *TemplateService
+	providers       *EmailProviders
+	documents       *DocumentClient
 }
 
 // NewEmailService creates a new email service
@@ -41,197 +64,198 @@ func NewEmailService(cfg *config.Config) *EmailService {
 	return &EmailService{
 		config:          cfg,
 		templateService: NewTemplateService(cfg),
+		providers:       NewEmailProviders(cfg),
+		documents:       NewDocumentClient(cfg),
 	}
 }
 
-// SendEmail sends an email immediately using SMTP
-func (es *EmailService) SendEmail(request EmailRequest) (*EmailResponse, error) {
-	startTime := time.Now()
-
+// prepareEmail validates the request and renders its template into subject and bodies
+func (es *EmailService) prepareEmail(request EmailRequest) (EmailRequest, error) {
 	// Validate email request
 	if len(request.To) == 0 {
-		return nil, fmt.Errorf("recipient list cannot be empty")
-	}
-
-	if request.Subject == "" {
-		return nil, fmt.Errorf("subject cannot be empty")
+		return request, fmt.Errorf("recipient list cannot be empty")
 	}
 
 	// If template is specified, render it
-	if request.TemplateID != "" && request.TemplateVars != nil {
-		renderedBody, err := es.templateService.RenderTemplate(request.TemplateID, request.TemplateVars)
+	if request.TemplateID != "" {
+		rendered, err := es.templateService.RenderTemplate(request.TemplateID, request.OrganizationID, recipientLocale(request), request.TemplateVars)
 		if err != nil {
 			log.Printf("Failed to render template: %v", err)
-			return nil, fmt.Errorf("failed to render template: %v", err)
+			return request, fmt.Errorf("failed to render template: %v", err)
+		}
+		if request.Subject == "" {
+			request.Subject = rendered.Subject
 		}
-		request.Body = renderedBody
+		request.Body = rendered.HTMLBody
+		request.TextBody = rendered.TextBody
 		request.IsHTML = true // Templates are HTML by default
 	}
 
-	if request.Body == "" {
-		return nil, fmt.Errorf("body cannot be empty")
+	if request.Subject == "" {
+		return request, fmt.Errorf("subject cannot be empty")
 	}
 
-	// Send email immediately
-	err := es.sendSMTPEmail(request)
-	if err != nil {
-		log.Printf("Failed to send email to %v: %v", request.To, err)
-		return &EmailResponse{
-			Success: false,
-			Message: fmt.Sprintf("Failed to send email: %v", err),
-			SentAt:  startTime.Format(time.RFC3339),
-		}, err
+	if request.Body == "" {
+		return request, fmt.Errorf("body cannot be empty")
 	}
 
-	log.Printf("Email sent successfully to %v", request.To)
-	return &EmailResponse{
-		Success: true,
-		Message: "Email sent successfully",
-		SentAt:  startTime.Format(time.RFC3339),
-	}, nil
+	return request, nil
 }
 
-// sendSMTPEmail sends email via SMTP
-func (es *EmailService) sendSMTPEmail(request EmailRequest) error {
-	// Build message
-	message := es.buildEmailMessage(request)
-
-	// SMTP configuration from config
-	host := es.config.SMTPHost
-	port := es.config.SMTPPort
-	username := es.config.SMTPUsername
-	password := es.config.SMTPPassword
-	from := es.config.EmailFrom
-
-	// Validate SMTP config
-	if host == "" || username == "" || password == "" {
-		return fmt.Errorf("SMTP configuration is incomplete")
+// recipientLocale returns the language an email is rendered in: the requested locale, otherwise the
+// preferred language of the first recipient if they are a user, otherwise the default locale
+func recipientLocale(request EmailRequest) string {
+	if request.Locale != "" {
+		return i18n.Resolve(request.Locale)
 	}
 
-	// SMTP auth
-	auth := smtp.PlainAuth("", username, password, host)
-
-	// Connect to server
-	addr := fmt.Sprintf("%s:%s", host, port)
-
-	// Recipients
-	recipients := append(request.To, request.CC...)
-	recipients = append(recipients, request.BCC...)
-
-	// Port 465 uses implicit TLS (SSL), other ports may use explicit TLS (STARTTLS)
-	if port == "465" || es.config.SMTPUseTLS {
-		return es.sendWithTLS(addr, auth, from, recipients, []byte(message))
+	var languages []string
+	if err := database.GetDB().Model(&models.User{}).Where("LOWER(email) = LOWER(?)", request.To[0]).
+		Limit(1).Pluck("language", &languages).Error; err != nil {
+		log.Printf("⚠️  Failed to look up the language of %s: %v", request.To[0], err)
 	}
-
-	// Regular SMTP without TLS
-	return smtp.SendMail(addr, auth, from, recipients, []byte(message))
+	return i18n.Resolve(languages...)
 }
 
-// sendWithTLS sends email with TLS
-func (es *EmailService) sendWithTLS(addr string, auth smtp.Auth, from string, to []string, msg []byte) error {
-	// Connect to server
-	conn, err := tls.Dial("tcp", addr, &tls.Config{
-		InsecureSkipVerify: true,
-		ServerName:         strings.Split(addr, ":")[0],
-	})
+// QueueEmail renders the email and stores it in the outbound queue, from which the email queue
+// worker sends it with retries. Only invalid requests, templates and attachments fail here. Suppressed
+// recipients are dropped; if none is left the email is stored as suppressed and never sent.
+func (es *EmailService) QueueEmail(request EmailRequest) (*notification.EmailMessage, error) {
+	request, err := es.prepareEmail(request)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer conn.Close()
-
-	// Create SMTP client
-	client, err := smtp.NewClient(conn, strings.Split(addr, ":")[0])
+	attachments, err := es.checkAttachments(request)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer client.Quit()
 
-	// Auth
-	if err = client.Auth(auth); err != nil {
-		return err
+	db := database.GetDB()
+	message := notification.EmailMessage{
+		ID:             ids.New(),
+		OrganizationID: request.OrganizationID,
+		Subject:        request.Subject,
+		IsHTML:         request.IsHTML,
+		TemplateID:     request.TemplateID,
+		Attachments:    attachments,
+		Status:         notification.EmailStatusQueued,
+		NextAttemptAt:  time.Now().UTC(),
 	}
 
-	// Set sender
-	if err = client.Mail(from); err != nil {
-		return err
+	var suppressed, skipped []string
+	if request.To, skipped, err = FilterSuppressed(db, request.To); err != nil {
+		return nil, err
+	}
+	suppressed = append(suppressed, skipped...)
+	if request.CC, skipped, err = FilterSuppressed(db, request.CC); err != nil {
+		return nil, err
 	}
+	suppressed = append(suppressed, skipped...)
+	if request.BCC, skipped, err = FilterSuppressed(db, request.BCC); err != nil {
+		return nil, err
+	}
+	suppressed = append(suppressed, skipped...)
 
-	// Set recipients
-	for _, recipient := range to {
-		if err = client.Rcpt(recipient); err != nil {
-			return err
+	if len(request.To) == 0 {
+		message.To = strings.Join(suppressed, ",")
+		message.Status = notification.EmailStatusSuppressed
+		message.LastError = "every recipient is on the suppression list"
+	} else {
+		message.To = strings.Join(request.To, ",")
+		message.CC = strings.Join(request.CC, ",")
+		message.BCC = strings.Join(request.BCC, ",")
+		message.Body = request.Body
+		message.TextBody = request.TextBody
+		if request.IsHTML && es.config.EmailTrackingEnabled {
+			message.Body = AddEmailTracking(request.Body, message.ID)
+		}
+		if len(suppressed) > 0 {
+			log.Printf("🚫 Skipped suppressed recipients of email %s: %v", message.ID, suppressed)
 		}
 	}
 
-	// Send message
-	w, err := client.Data()
-	if err != nil {
-		return err
+	if err := db.Create(&message).Error; err != nil {
+		return nil, fmt.Errorf("failed to queue email: %v", err)
 	}
-	defer w.Close()
 
-	_, err = w.Write(msg)
-	return err
+	return &message, nil
 }
 
-// buildEmailMessage builds email message
-func (es *EmailService) buildEmailMessage(request EmailRequest) string {
-	from := es.config.EmailFrom
-	fromName := es.config.EmailFromName
-
-	var msg strings.Builder
-
-	// Headers
-	msg.WriteString(fmt.Sprintf("From: %s <%s>\r\n", fromName, from))
-	msg.WriteString(fmt.Sprintf("To: %s\r\n", strings.Join(request.To, ", ")))
+// SendEmail sends an email immediately through the configured providers
+func (es *EmailService) SendEmail(request EmailRequest) (*EmailResponse, error) {
+	startTime := time.Now()
 
-	if len(request.CC) > 0 {
-		msg.WriteString(fmt.Sprintf("CC: %s\r\n", strings.Join(request.CC, ", ")))
+	request, err := es.prepareEmail(request)
+	if err != nil {
+		return nil, err
+	}
+	attachments, err := es.checkAttachments(request)
+	if err != nil {
+		return nil, err
+	}
+	if request.AttachmentFiles, err = es.downloadAttachments(attachments); err != nil {
+		return nil, err
 	}
 
-	msg.WriteString(fmt.Sprintf("Subject: %s\r\n", request.Subject))
-	msg.WriteString("MIME-Version: 1.0\r\n")
-
-	if request.IsHTML {
-		msg.WriteString("Content-Type: text/html; charset=UTF-8\r\n")
-	} else {
-		msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	// Send email immediately
+	delivery, err := es.deliver(request)
+	if err != nil {
+		log.Printf("Failed to send email to %v: %v", request.To, err)
+		return &EmailResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to send email: %v", err),
+			SentAt:  startTime.Format(time.RFC3339),
+		}, err
 	}
 
-	msg.WriteString("\r\n")
-	msg.WriteString(request.Body)
+	log.Printf("Email sent successfully to %v via %s", request.To, delivery.Provider)
+	return &EmailResponse{
+		Success:   true,
+		Message:   "Email sent successfully",
+		SentAt:    startTime.Format(time.RFC3339),
+		Provider:  delivery.Provider,
+		MessageID: delivery.MessageID,
+	}, nil
+}
+
+// deliver sends a rendered email through the primary provider, failing over to the secondary one
+func (es *EmailService) deliver(request EmailRequest) (*EmailDelivery, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), emailSendTimeout)
+	defer cancel()
 
-	return msg.String()
+	return es.providers.Send(ctx, request)
 }
 
 // Helper methods for common email templates
 
-// SendWelcomeEmail sends a welcome email with verification code
-func (es *EmailService) SendWelcomeEmail(to, name, verificationCode string) (*EmailResponse, error) {
+// SendWelcomeEmail queues a welcome email with verification code. An empty locale sends it in the
+// recipient's preferred language.
+func (es *EmailService) SendWelcomeEmail(to, name, verificationCode, locale string) (*notification.EmailMessage, error) {
 	request := EmailRequest{
 		To:         []string{to},
-		Subject:    "Welcome to ForgeCRUD - Please Verify Your Email",
 		TemplateID: "welcome_verification",
+		Locale:     locale,
 		TemplateVars: map[string]interface{}{
 			"Name":             name,
 			"VerificationCode": verificationCode,
 		},
 	}
 
-	return es.SendEmail(request)
+	return es.QueueEmail(request)
 }
 
-// SendPasswordResetEmail sends password reset email
-func (es *EmailService) SendPasswordResetEmail(to, name, resetCode string) (*EmailResponse, error) {
+// SendPasswordResetEmail queues a password reset email, in the recipient's preferred language unless
+// a locale is given
+func (es *EmailService) SendPasswordResetEmail(to, name, resetCode, locale string) (*notification.EmailMessage, error) {
 	request := EmailRequest{
 		To:         []string{to},
-		Subject:    "Password Reset Request - ForgeCRUD",
 		TemplateID: "password_reset",
+		Locale:     locale,
 		TemplateVars: map[string]interface{}{
 			"Name":      name,
 			"ResetCode": resetCode,
 		},
 	}
 
-	return es.SendEmail(request)
+	return es.QueueEmail(request)
 }
@@ -0,0 +1,237 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"forgecrud-backend/shared/config"
+	"forgecrud-backend/shared/database/models/notification"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ProviderTracking is the provider name of events recorded by our own open pixel and click links
+const ProviderTracking = "tracking"
+
+// ErrInvalidTrackingToken is returned for tracking links that were not signed by this deployment
+var ErrInvalidTrackingToken = errors.New("invalid tracking token")
+
+var trackedLinkPattern = regexp.MustCompile(`href="(https?://[^"]+)"`)
+
+// EmailEventInput is an event reported for a sent email. The email is identified by our ID, or by
+// the provider and the message ID the provider assigned.
+type EmailEventInput struct {
+	MessageID         uuid.UUID
+	Provider          string
+	ProviderMessageID string
+	Type              string
+	Recipient         string
+	Permanent         bool
+	URL               string
+	UserAgent         string
+	IPAddress         string
+	Details           string
+	OccurredAt        time.Time
+}
+
+// RecordEmailEvent stores the event of a sent email. Hard bounces and complaints put the recipient on
+// the suppression list, and hard bounces mark the email as bounced. Events of unknown emails, e.g.
+// sent by another system through the same provider account, are ignored.
+func RecordEmailEvent(db *gorm.DB, input EmailEventInput) error {
+	var message notification.EmailMessage
+	query := db.Select("id", "organization_id", "status")
+	if input.MessageID != uuid.Nil {
+		query = query.Where("id = ?", input.MessageID)
+	} else if input.ProviderMessageID != "" {
+		query = query.Where("provider = ? AND provider_message_id = ?", input.Provider, input.ProviderMessageID)
+	} else {
+		return nil
+	}
+	if err := query.First(&message).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+		return err
+	}
+
+	if input.OccurredAt.IsZero() {
+		input.OccurredAt = time.Now().UTC()
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		event := notification.EmailEvent{
+			MessageID:      message.ID,
+			OrganizationID: message.OrganizationID,
+			Type:           input.Type,
+			Recipient:      strings.ToLower(input.Recipient),
+			Provider:       input.Provider,
+			Permanent:      input.Permanent,
+			URL:            input.URL,
+			UserAgent:      input.UserAgent,
+			IPAddress:      input.IPAddress,
+			Details:        input.Details,
+			OccurredAt:     input.OccurredAt,
+		}
+		if err := tx.Create(&event).Error; err != nil {
+			return err
+		}
+
+		switch input.Type {
+		case notification.EmailEventDelivered:
+			UpdateEmailDeliveries(tx, message.ID, notification.DeliveryStatusDelivered, "")
+		case notification.EmailEventOpened, notification.EmailEventClicked:
+			UpdateEmailDeliveries(tx, message.ID, notification.DeliveryStatusRead, "")
+		}
+
+		switch {
+		case input.Type == notification.EmailEventBounced && input.Permanent:
+			if err := tx.Model(&notification.EmailMessage{}).Where("id = ?", message.ID).
+				Update("status", notification.EmailStatusBounced).Error; err != nil {
+				return err
+			}
+			UpdateEmailDeliveries(tx, message.ID, notification.DeliveryStatusFailed, "bounced: "+input.Details)
+			return SuppressEmail(tx, input.Recipient, notification.SuppressionBounce, input.Provider, &message.ID, input.Details, nil)
+		case input.Type == notification.EmailEventComplained:
+			return SuppressEmail(tx, input.Recipient, notification.SuppressionComplaint, input.Provider, &message.ID, input.Details, nil)
+		}
+		return nil
+	})
+}
+
+// SuppressEmail puts an address on the suppression list, keeping the original entry if it is there already
+func SuppressEmail(db *gorm.DB, address, reason, provider string, messageID *uuid.UUID, details string, createdBy *uuid.UUID) error {
+	address = strings.ToLower(strings.TrimSpace(address))
+	if address == "" {
+		return nil
+	}
+
+	suppression := notification.EmailSuppression{
+		Email:     address,
+		Reason:    reason,
+		Provider:  provider,
+		MessageID: messageID,
+		Details:   details,
+		CreatedBy: createdBy,
+	}
+	if err := db.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "email"}}, DoNothing: true}).
+		Create(&suppression).Error; err != nil {
+		return err
+	}
+	log.Printf("🚫 Suppressed email address %s (%s)", address, reason)
+	return nil
+}
+
+// FilterSuppressed splits the addresses into those emails can be sent to and the suppressed ones
+func FilterSuppressed(db *gorm.DB, addresses []string) ([]string, []string, error) {
+	if len(addresses) == 0 {
+		return addresses, nil, nil
+	}
+
+	lower := make([]string, len(addresses))
+	for i, address := range addresses {
+		lower[i] = strings.ToLower(strings.TrimSpace(address))
+	}
+
+	var suppressed []string
+	if err := db.Model(&notification.EmailSuppression{}).Where("email IN ?", lower).Pluck("email", &suppressed).Error; err != nil {
+		return nil, nil, err
+	}
+	if len(suppressed) == 0 {
+		return addresses, nil, nil
+	}
+
+	isSuppressed := map[string]bool{}
+	for _, address := range suppressed {
+		isSuppressed[address] = true
+	}
+	allowed := []string{}
+	for i, address := range addresses {
+		if !isSuppressed[lower[i]] {
+			allowed = append(allowed, address)
+		}
+	}
+	return allowed, suppressed, nil
+}
+
+// AddEmailTracking rewrites the links of an HTML body to the click tracking endpoint and appends the
+// open tracking pixel
+func AddEmailTracking(body string, messageID uuid.UUID) string {
+	base := strings.TrimSuffix(config.GetConfig().EmailTrackingBaseURL, "/")
+	token := SignEmailTrackingToken(messageID)
+
+	body = trackedLinkPattern.ReplaceAllStringFunc(body, func(match string) string {
+		// html/template escapes & in attributes, the signature covers the URL as the browser reads it
+		target := html.UnescapeString(trackedLinkPattern.FindStringSubmatch(match)[1])
+		tracked := fmt.Sprintf("%s/click/%s?url=%s&sig=%s", base, token, url.QueryEscape(target), clickSignature(messageID, target))
+		return `href="` + html.EscapeString(tracked) + `"`
+	})
+
+	pixel := fmt.Sprintf(`<img src="%s/open/%s.gif" width="1" height="1" alt="" style="display:none">`, base, token)
+	if i := strings.LastIndex(strings.ToLower(body), "</body>"); i >= 0 {
+		return body[:i] + pixel + body[i:]
+	}
+	return body + pixel
+}
+
+// SignEmailTrackingToken returns the token identifying an email in tracking links: its ID and an HMAC of the ID
+func SignEmailTrackingToken(messageID uuid.UUID) string {
+	return base64.RawURLEncoding.EncodeToString(messageID[:]) + "." +
+		base64.RawURLEncoding.EncodeToString(trackingSignature("email-open:", messageID[:])[:16])
+}
+
+// ParseEmailTrackingToken verifies a tracking token and returns the email ID it carries
+func ParseEmailTrackingToken(token string) (uuid.UUID, error) {
+	encodedID, encodedSignature, found := strings.Cut(token, ".")
+	if !found {
+		return uuid.Nil, ErrInvalidTrackingToken
+	}
+
+	rawID, err := base64.RawURLEncoding.DecodeString(encodedID)
+	if err != nil {
+		return uuid.Nil, ErrInvalidTrackingToken
+	}
+	messageID, err := uuid.FromBytes(rawID)
+	if err != nil {
+		return uuid.Nil, ErrInvalidTrackingToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil || !hmac.Equal(signature, trackingSignature("email-open:", messageID[:])[:16]) {
+		return uuid.Nil, ErrInvalidTrackingToken
+	}
+	return messageID, nil
+}
+
+// VerifyClickSignature reports whether the click link to the target URL was generated for the email,
+// so the click endpoint can't be used to redirect anywhere
+func VerifyClickSignature(messageID uuid.UUID, target, signature string) bool {
+	return hmac.Equal([]byte(signature), []byte(clickSignature(messageID, target)))
+}
+
+func clickSignature(messageID uuid.UUID, target string) string {
+	return base64.RawURLEncoding.EncodeToString(trackingSignature("email-click:", append(messageID[:], target...))[:16])
+}
+
+func trackingSignature(purpose string, data []byte) []byte {
+	cfg := config.GetConfig()
+	secret := cfg.EmailTrackingSecret
+	if secret == "" {
+		secret = cfg.JWTSecret
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(purpose))
+	mac.Write(data)
+	return mac.Sum(nil)
+}
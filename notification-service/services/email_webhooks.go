@@ -0,0 +1,429 @@
+package services
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"forgecrud-backend/shared/config"
+	"forgecrud-backend/shared/database/models/notification"
+)
+
+// ErrInvalidWebhookSignature is returned for provider webhooks that are not signed with the configured key
+var ErrInvalidWebhookSignature = errors.New("invalid webhook signature")
+
+// Provider webhooks older than this are rejected as replays
+const emailWebhookMaxAge = 15 * time.Minute
+
+var snsHostPattern = regexp.MustCompile(`^sns\.[a-z0-9-]+\.amazonaws\.com(\.cn)?$`)
+
+// SNSMessage is the envelope Amazon SNS posts to HTTPS subscriptions
+type SNSMessage struct {
+	Type             string `json:"Type"`
+	MessageID        string `json:"MessageId"`
+	Token            string `json:"Token"`
+	TopicArn         string `json:"TopicArn"`
+	Subject          string `json:"Subject"`
+	Message          string `json:"Message"`
+	SubscribeURL     string `json:"SubscribeURL"`
+	Timestamp        string `json:"Timestamp"`
+	SignatureVersion string `json:"SignatureVersion"`
+	Signature        string `json:"Signature"`
+	SigningCertURL   string `json:"SigningCertURL"`
+}
+
+var snsCertificates = map[string]*rsa.PublicKey{}
+var snsCertificatesMutex sync.Mutex
+
+// VerifySNSMessage checks that the message was signed by Amazon SNS for the configured topic
+func VerifySNSMessage(message *SNSMessage) error {
+	topicARN := config.GetConfig().SESWebhookTopicARN
+	if topicARN == "" || message.TopicArn != topicARN {
+		return ErrInvalidWebhookSignature
+	}
+
+	var fields []string
+	switch message.Type {
+	case "Notification":
+		fields = []string{"Message", message.Message, "MessageId", message.MessageID}
+		if message.Subject != "" {
+			fields = append(fields, "Subject", message.Subject)
+		}
+		fields = append(fields, "Timestamp", message.Timestamp, "TopicArn", message.TopicArn, "Type", message.Type)
+	case "SubscriptionConfirmation", "UnsubscribeConfirmation":
+		fields = []string{"Message", message.Message, "MessageId", message.MessageID, "SubscribeURL", message.SubscribeURL,
+			"Timestamp", message.Timestamp, "Token", message.Token, "TopicArn", message.TopicArn, "Type", message.Type}
+	default:
+		return ErrInvalidWebhookSignature
+	}
+	signed := []byte(strings.Join(fields, "\n") + "\n")
+
+	signature, err := base64.StdEncoding.DecodeString(message.Signature)
+	if err != nil {
+		return ErrInvalidWebhookSignature
+	}
+	key, err := snsCertificate(message.SigningCertURL)
+	if err != nil {
+		return err
+	}
+
+	if message.SignatureVersion == "2" {
+		digest := sha256.Sum256(signed)
+		err = rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature)
+	} else {
+		digest := sha1.Sum(signed)
+		err = rsa.VerifyPKCS1v15(key, crypto.SHA1, digest[:], signature)
+	}
+	if err != nil {
+		return ErrInvalidWebhookSignature
+	}
+	return nil
+}
+
+// snsCertificate downloads and caches the certificate SNS signed the message with. Only certificates
+// served by SNS itself are trusted.
+func snsCertificate(certURL string) (*rsa.PublicKey, error) {
+	parsed, err := url.Parse(certURL)
+	if err != nil || parsed.Scheme != "https" || !snsHostPattern.MatchString(parsed.Host) {
+		return nil, ErrInvalidWebhookSignature
+	}
+
+	snsCertificatesMutex.Lock()
+	defer snsCertificatesMutex.Unlock()
+	if key, ok := snsCertificates[certURL]; ok {
+		return key, nil
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(certURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch SNS certificate: %v", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch SNS certificate: %v", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("invalid SNS certificate")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SNS certificate: %v", err)
+	}
+	key, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("SNS certificate has no RSA key")
+	}
+
+	snsCertificates[certURL] = key
+	return key, nil
+}
+
+// ConfirmSNSSubscription visits the subscribe URL of a verified subscription confirmation
+func ConfirmSNSSubscription(message *SNSMessage) error {
+	parsed, err := url.Parse(message.SubscribeURL)
+	if err != nil || parsed.Scheme != "https" || !snsHostPattern.MatchString(parsed.Host) {
+		return ErrInvalidWebhookSignature
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(message.SubscribeURL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("SNS returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// ParseSESEvents converts an SES event (configuration set event publishing or identity notification)
+// carried by an SNS notification into email events
+func ParseSESEvents(message string) ([]EmailEventInput, error) {
+	var event struct {
+		EventType        string `json:"eventType"`
+		NotificationType string `json:"notificationType"`
+		Mail             struct {
+			MessageID string `json:"messageId"`
+		} `json:"mail"`
+		Bounce struct {
+			BounceType        string `json:"bounceType"`
+			BouncedRecipients []struct {
+				EmailAddress   string `json:"emailAddress"`
+				DiagnosticCode string `json:"diagnosticCode"`
+			} `json:"bouncedRecipients"`
+			Timestamp time.Time `json:"timestamp"`
+		} `json:"bounce"`
+		Complaint struct {
+			ComplainedRecipients []struct {
+				EmailAddress string `json:"emailAddress"`
+			} `json:"complainedRecipients"`
+			ComplaintFeedbackType string    `json:"complaintFeedbackType"`
+			Timestamp             time.Time `json:"timestamp"`
+		} `json:"complaint"`
+		Delivery struct {
+			Recipients []string  `json:"recipients"`
+			Timestamp  time.Time `json:"timestamp"`
+		} `json:"delivery"`
+		Open struct {
+			IPAddress string    `json:"ipAddress"`
+			UserAgent string    `json:"userAgent"`
+			Timestamp time.Time `json:"timestamp"`
+		} `json:"open"`
+		Click struct {
+			IPAddress string    `json:"ipAddress"`
+			UserAgent string    `json:"userAgent"`
+			Link      string    `json:"link"`
+			Timestamp time.Time `json:"timestamp"`
+		} `json:"click"`
+	}
+	if err := json.Unmarshal([]byte(message), &event); err != nil {
+		return nil, err
+	}
+
+	eventType := event.EventType
+	if eventType == "" {
+		eventType = event.NotificationType
+	}
+	base := EmailEventInput{Provider: EmailProviderSES, ProviderMessageID: event.Mail.MessageID}
+
+	var events []EmailEventInput
+	switch eventType {
+	case "Bounce":
+		for _, recipient := range event.Bounce.BouncedRecipients {
+			e := base
+			e.Type = notification.EmailEventBounced
+			e.Recipient = recipient.EmailAddress
+			e.Permanent = event.Bounce.BounceType == "Permanent"
+			e.Details = recipient.DiagnosticCode
+			e.OccurredAt = event.Bounce.Timestamp
+			events = append(events, e)
+		}
+	case "Complaint":
+		for _, recipient := range event.Complaint.ComplainedRecipients {
+			e := base
+			e.Type = notification.EmailEventComplained
+			e.Recipient = recipient.EmailAddress
+			e.Details = event.Complaint.ComplaintFeedbackType
+			e.OccurredAt = event.Complaint.Timestamp
+			events = append(events, e)
+		}
+	case "Delivery":
+		for _, recipient := range event.Delivery.Recipients {
+			e := base
+			e.Type = notification.EmailEventDelivered
+			e.Recipient = recipient
+			e.OccurredAt = event.Delivery.Timestamp
+			events = append(events, e)
+		}
+	case "Open":
+		e := base
+		e.Type = notification.EmailEventOpened
+		e.UserAgent = event.Open.UserAgent
+		e.IPAddress = event.Open.IPAddress
+		e.OccurredAt = event.Open.Timestamp
+		events = append(events, e)
+	case "Click":
+		e := base
+		e.Type = notification.EmailEventClicked
+		e.URL = event.Click.Link
+		e.UserAgent = event.Click.UserAgent
+		e.IPAddress = event.Click.IPAddress
+		e.OccurredAt = event.Click.Timestamp
+		events = append(events, e)
+	}
+	return events, nil
+}
+
+// VerifySendGridSignature checks the ECDSA signature of a SendGrid signed event webhook
+func VerifySendGridSignature(payload []byte, signature, timestamp string) error {
+	publicKey := config.GetConfig().SendGridWebhookPublicKey
+	if publicKey == "" || signature == "" || !recentWebhookTimestamp(timestamp) {
+		return ErrInvalidWebhookSignature
+	}
+
+	der, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil {
+		return fmt.Errorf("invalid SENDGRID_WEBHOOK_PUBLIC_KEY: %v", err)
+	}
+	parsed, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return fmt.Errorf("invalid SENDGRID_WEBHOOK_PUBLIC_KEY: %v", err)
+	}
+	key, ok := parsed.(*ecdsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("SENDGRID_WEBHOOK_PUBLIC_KEY is not an ECDSA key")
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return ErrInvalidWebhookSignature
+	}
+	digest := sha256.Sum256(append([]byte(timestamp), payload...))
+	if !ecdsa.VerifyASN1(key, digest[:], sig) {
+		return ErrInvalidWebhookSignature
+	}
+	return nil
+}
+
+// ParseSendGridEvents converts a batch of SendGrid event webhook events into email events
+func ParseSendGridEvents(payload []byte) ([]EmailEventInput, error) {
+	var batch []struct {
+		Email       string `json:"email"`
+		Event       string `json:"event"`
+		Type        string `json:"type"`
+		SGMessageID string `json:"sg_message_id"`
+		Reason      string `json:"reason"`
+		URL         string `json:"url"`
+		UserAgent   string `json:"useragent"`
+		IP          string `json:"ip"`
+		Timestamp   int64  `json:"timestamp"`
+	}
+	if err := json.Unmarshal(payload, &batch); err != nil {
+		return nil, err
+	}
+
+	var events []EmailEventInput
+	for _, item := range batch {
+		// sg_message_id is the X-Message-Id returned on send followed by a filter suffix
+		messageID, _, _ := strings.Cut(item.SGMessageID, ".")
+		e := EmailEventInput{
+			Provider:          EmailProviderSendGrid,
+			ProviderMessageID: messageID,
+			Recipient:         item.Email,
+			UserAgent:         item.UserAgent,
+			IPAddress:         item.IP,
+			Details:           item.Reason,
+			OccurredAt:        time.Unix(item.Timestamp, 0).UTC(),
+		}
+
+		switch item.Event {
+		case "delivered":
+			e.Type = notification.EmailEventDelivered
+		case "bounce":
+			// Blocks are temporary refusals of the receiving server, bounces are permanent
+			e.Type = notification.EmailEventBounced
+			e.Permanent = item.Type != "blocked"
+		case "spamreport":
+			e.Type = notification.EmailEventComplained
+		case "open":
+			e.Type = notification.EmailEventOpened
+		case "click":
+			e.Type = notification.EmailEventClicked
+			e.URL = item.URL
+		default:
+			continue
+		}
+		events = append(events, e)
+	}
+	return events, nil
+}
+
+// MailgunWebhook is the payload of a Mailgun webhook
+type MailgunWebhook struct {
+	Signature struct {
+		Timestamp string `json:"timestamp"`
+		Token     string `json:"token"`
+		Signature string `json:"signature"`
+	} `json:"signature"`
+	EventData struct {
+		Event     string  `json:"event"`
+		Severity  string  `json:"severity"`
+		Recipient string  `json:"recipient"`
+		URL       string  `json:"url"`
+		IP        string  `json:"ip"`
+		Timestamp float64 `json:"timestamp"`
+		Reason    string  `json:"reason"`
+		Message   struct {
+			Headers struct {
+				MessageID string `json:"message-id"`
+			} `json:"headers"`
+		} `json:"message"`
+		DeliveryStatus struct {
+			Description string `json:"description"`
+			Message     string `json:"message"`
+		} `json:"delivery-status"`
+		ClientInfo struct {
+			UserAgent string `json:"user-agent"`
+		} `json:"client-info"`
+	} `json:"event-data"`
+}
+
+// VerifyMailgunSignature checks the HMAC Mailgun signs every webhook with
+func VerifyMailgunSignature(webhook *MailgunWebhook) error {
+	signingKey := config.GetConfig().MailgunWebhookSigningKey
+	if signingKey == "" || !recentWebhookTimestamp(webhook.Signature.Timestamp) {
+		return ErrInvalidWebhookSignature
+	}
+
+	mac := hmac.New(sha256.New, []byte(signingKey))
+	mac.Write([]byte(webhook.Signature.Timestamp + webhook.Signature.Token))
+	if !hmac.Equal([]byte(hex.EncodeToString(mac.Sum(nil))), []byte(webhook.Signature.Signature)) {
+		return ErrInvalidWebhookSignature
+	}
+	return nil
+}
+
+// ParseMailgunEvent converts a Mailgun webhook into an email event
+func ParseMailgunEvent(webhook *MailgunWebhook) (*EmailEventInput, bool) {
+	data := webhook.EventData
+	seconds := int64(data.Timestamp)
+	e := EmailEventInput{
+		Provider:          EmailProviderMailgun,
+		ProviderMessageID: strings.Trim(data.Message.Headers.MessageID, "<>"),
+		Recipient:         data.Recipient,
+		IPAddress:         data.IP,
+		UserAgent:         data.ClientInfo.UserAgent,
+		OccurredAt:        time.Unix(seconds, int64((data.Timestamp-float64(seconds))*1e9)).UTC(),
+	}
+
+	switch data.Event {
+	case "delivered":
+		e.Type = notification.EmailEventDelivered
+	case "failed":
+		e.Type = notification.EmailEventBounced
+		e.Permanent = data.Severity == "permanent"
+		e.Details = strings.TrimSpace(data.DeliveryStatus.Message + " " + data.DeliveryStatus.Description)
+	case "complained":
+		e.Type = notification.EmailEventComplained
+	case "opened":
+		e.Type = notification.EmailEventOpened
+	case "clicked":
+		e.Type = notification.EmailEventClicked
+		e.URL = data.URL
+	default:
+		return nil, false
+	}
+	return &e, true
+}
+
+func recentWebhookTimestamp(timestamp string) bool {
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	age := time.Since(time.Unix(seconds, 0))
+	return age < emailWebhookMaxAge && age > -emailWebhookMaxAge
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"forgecrud-backend/shared/apperrors"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// Comments are sent this often so proxies don't close idle streams
+	eventStreamHeartbeat = 25 * time.Second
+	eventStreamBuffer    = 64
+	// Clients reconnect after this many milliseconds when the stream drops
+	eventStreamRetryMillis = 5000
+)
+
+var errEventStreamClosed = errors.New("event stream closed")
+
+// eventStream is the transport of a Server-Sent Events client. Messages are buffered so a slow
+// client can't block delivery to the others; a client whose buffer is full is disconnected.
+type eventStream struct {
+	events    chan []byte
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newEventStream() *eventStream {
+	return &eventStream{
+		events: make(chan []byte, eventStreamBuffer),
+		done:   make(chan struct{}),
+	}
+}
+
+// WriteJSON queues the message for the stream
+func (es *eventStream) WriteJSON(v interface{}) error {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case <-es.done:
+		return errEventStreamClosed
+	default:
+	}
+	select {
+	case es.events <- payload:
+		return nil
+	default:
+		return errors.New("event stream client is too slow")
+	}
+}
+
+// Close ends the stream
+func (es *eventStream) Close() error {
+	es.closeOnce.Do(func() { close(es.done) })
+	return nil
+}
+
+// HandleEventStream streams the real-time messages of the channels passing the filter to an
+// authenticated user as Server-Sent Events until the client disconnects. Each message is a "data:"
+// line with the same JSON the WebSocket connection receives.
+func (wsm *WebSocketManager) HandleEventStream(c *gin.Context, userID, organizationID, sessionID string, channels []string, filter SubscriptionFilter) {
+	flusher, ok := c.Writer.(http.Flusher)
+	if !ok {
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Streaming is not supported"))
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Disable response buffering in nginx
+	c.Status(http.StatusOK)
+	fmt.Fprintf(c.Writer, "retry: %d\n\n", eventStreamRetryMillis)
+	flusher.Flush()
+
+	stream := newEventStream()
+	client := newClientConnection(userID, organizationID, sessionID, stream, channels, filter)
+	wsm.register <- client
+	defer func() {
+		wsm.unregister <- client
+	}()
+
+	heartbeat := time.NewTicker(eventStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case payload := <-stream.events:
+			if _, err := fmt.Fprintf(c.Writer, "data: %s\n\n", payload); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(c.Writer, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-stream.done:
+			log.Printf("🔌 Event stream of user %s closed by the server", userID)
+			return
+		case <-c.Request.Context().Done():
+			return
+		}
+	}
+}
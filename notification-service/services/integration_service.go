@@ -0,0 +1,280 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"forgecrud-backend/shared/config"
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models"
+	"forgecrud-backend/shared/database/models/notification"
+	"forgecrud-backend/shared/readonly"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	integrationBatchSize   = 20
+	integrationBaseBackoff = 30 * time.Second
+)
+
+// Default message templates of each integration kind. Teams cards show the title separately.
+var defaultIntegrationTemplates = map[string]string{
+	notification.IntegrationSlack:   "*{{.Title}}*\n{{.Message}}",
+	notification.IntegrationTeams:   "{{.Message}}",
+	notification.IntegrationWebhook: "{{.Title}}: {{.Message}}",
+}
+
+// Teams card colors of notification levels
+var teamsThemeColors = map[string]string{
+	string(notification.NotificationLevelSuccess): "107C10",
+	string(notification.NotificationLevelError):   "D13438",
+	string(notification.NotificationLevelWarning): "FFB900",
+	string(notification.NotificationLevelInfo):    "0078D4",
+}
+
+// BuildIntegrationPayload renders the message with the integration's template into the JSON body
+// its kind expects: a Slack message, a Teams message card or a generic notification envelope
+func BuildIntegrationPayload(integration *notification.NotificationIntegration, message notification.IntegrationMessage) ([]byte, error) {
+	source := integration.Template
+	if source == "" {
+		source = defaultIntegrationTemplates[integration.Kind]
+	}
+	tmpl, err := template.New("integration").Option("missingkey=error").Parse(source)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %v", err)
+	}
+	var text strings.Builder
+	if err := tmpl.Execute(&text, message); err != nil {
+		return nil, fmt.Errorf("invalid template: %v", err)
+	}
+
+	switch integration.Kind {
+	case notification.IntegrationSlack:
+		return json.Marshal(map[string]interface{}{"text": text.String()})
+	case notification.IntegrationTeams:
+		return json.Marshal(map[string]interface{}{
+			"@type":      "MessageCard",
+			"@context":   "https://schema.org/extensions",
+			"summary":    message.Title,
+			"themeColor": teamsThemeColors[message.Level],
+			"title":      message.Title,
+			"text":       text.String(),
+		})
+	case notification.IntegrationWebhook:
+		return json.Marshal(map[string]interface{}{
+			"event":        models.WebhookEventNotificationCreated,
+			"text":         text.String(),
+			"notification": message,
+		})
+	}
+	return nil, fmt.Errorf("unsupported integration kind %q", integration.Kind)
+}
+
+// EnqueueIntegrations queues a message for every active integration of the recipient's organization
+// subscribed to the notification's category. Failures are only logged, the notification itself was delivered.
+func EnqueueIntegrations(notif *notification.Notification) int {
+	if notif.UserID == nil {
+		return 0
+	}
+
+	db := database.GetDB()
+
+	var user models.User
+	if err := db.Preload("Organization").First(&user, "id = ?", *notif.UserID).Error; err != nil || user.OrganizationID == nil {
+		return 0
+	}
+
+	var integrations []notification.NotificationIntegration
+	if err := db.Where("organization_id = ? AND is_active = ?", *user.OrganizationID, true).Find(&integrations).Error; err != nil {
+		log.Printf("⚠️  Failed to load integrations of organization %s: %v", *user.OrganizationID, err)
+		return 0
+	}
+
+	category := notif.ResolveCategory()
+	message := notification.IntegrationMessage{
+		Title:        notif.Title,
+		Message:      notif.Message,
+		Level:        string(notif.Level),
+		Type:         notif.Type,
+		Category:     string(category),
+		Entity:       notif.Entity,
+		UserName:     strings.TrimSpace(user.FirstName + " " + user.LastName),
+		UserEmail:    user.Email,
+		Organization: user.Organization.Name,
+		Timestamp:    time.Now().UTC().Format(time.RFC3339),
+	}
+	if notif.EntityID != nil {
+		message.EntityID = notif.EntityID.String()
+	}
+
+	var notificationID *uuid.UUID
+	if notif.ID != uuid.Nil {
+		notificationID = &notif.ID
+	}
+
+	queued := 0
+	for i := range integrations {
+		if !integrations[i].Subscribes(category) {
+			continue
+		}
+		if err := EnqueueIntegrationMessage(db, &integrations[i], notificationID, message); err != nil {
+			log.Printf("⚠️  Failed to queue %s notification for integration %s: %v", notif.Type, integrations[i].ID, err)
+			continue
+		}
+		queued++
+	}
+	return queued
+}
+
+// EnqueueIntegrationMessage renders the message for the integration and queues its delivery
+func EnqueueIntegrationMessage(db *gorm.DB, integration *notification.NotificationIntegration, notificationID *uuid.UUID, message notification.IntegrationMessage) error {
+	payload, err := BuildIntegrationPayload(integration, message)
+	if err != nil {
+		return err
+	}
+
+	return db.Create(&notification.IntegrationDelivery{
+		IntegrationID:  integration.ID,
+		NotificationID: notificationID,
+		Payload:        string(payload),
+		Status:         notification.IntegrationDeliveryPending,
+		NextAttemptAt:  time.Now().UTC(),
+	}).Error
+}
+
+// IntegrationDispatcher posts queued integration messages and retries failed ones with exponential backoff
+type IntegrationDispatcher struct {
+	httpClient  *http.Client
+	maxAttempts int
+}
+
+func NewIntegrationDispatcher() *IntegrationDispatcher {
+	cfg := config.GetConfig()
+	return &IntegrationDispatcher{
+		httpClient: &http.Client{
+			Timeout: time.Duration(cfg.IntegrationTimeoutSeconds) * time.Second,
+		},
+		maxAttempts: cfg.IntegrationMaxAttempts,
+	}
+}
+
+// Start polls for due deliveries in the background
+func (d *IntegrationDispatcher) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if readonly.Active() {
+				continue
+			}
+			if err := d.DispatchDue(); err != nil {
+				log.Printf("⚠️  Integration dispatch failed: %v", err)
+			}
+		}
+	}()
+
+	log.Printf("💬 Integration dispatcher started (interval: %s)", interval)
+}
+
+// DispatchDue posts a batch of pending deliveries whose next attempt is due.
+// Rows are locked with SKIP LOCKED so several notification-service instances can dispatch concurrently.
+func (d *IntegrationDispatcher) DispatchDue() error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		var deliveries []notification.IntegrationDelivery
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Preload("Integration").
+			Where("status = ? AND next_attempt_at <= ?", notification.IntegrationDeliveryPending, time.Now().UTC()).
+			Order("next_attempt_at").
+			Limit(integrationBatchSize).
+			Find(&deliveries).Error; err != nil {
+			return err
+		}
+
+		for i := range deliveries {
+			if err := d.deliver(tx, &deliveries[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// deliver performs one attempt and records its outcome
+func (d *IntegrationDispatcher) deliver(db *gorm.DB, delivery *notification.IntegrationDelivery) error {
+	now := time.Now().UTC()
+	delivery.Attempts++
+
+	statusCode, err := d.send(delivery)
+	delivery.ResponseStatus = statusCode
+
+	switch {
+	case err == nil && statusCode >= 200 && statusCode < 300:
+		delivery.Status = notification.IntegrationDeliverySucceeded
+		delivery.DeliveredAt = &now
+		delivery.LastError = ""
+	default:
+		if err != nil {
+			delivery.LastError = err.Error()
+		} else {
+			delivery.LastError = fmt.Sprintf("endpoint responded with status %d", statusCode)
+		}
+
+		// Client errors other than rate limiting mean the URL or message is wrong, retrying won't help
+		permanent := statusCode >= 400 && statusCode < 500 && statusCode != http.StatusTooManyRequests
+		if permanent || delivery.Attempts >= d.maxAttempts {
+			delivery.Status = notification.IntegrationDeliveryFailed
+		} else {
+			// 30s, 1m, 2m, 4m, ...
+			delivery.NextAttemptAt = now.Add(integrationBaseBackoff << (delivery.Attempts - 1))
+		}
+	}
+
+	return db.Model(delivery).Select(
+		"status", "attempts", "next_attempt_at", "response_status", "last_error", "delivered_at",
+	).Updates(delivery).Error
+}
+
+// send posts the payload to the integration, signing generic webhooks that have a secret
+func (d *IntegrationDispatcher) send(delivery *notification.IntegrationDelivery) (int, error) {
+	integration := delivery.Integration
+	if !integration.IsActive {
+		return 0, fmt.Errorf("integration is disabled")
+	}
+
+	payload := []byte(delivery.Payload)
+	req, err := http.NewRequest(http.MethodPost, integration.URL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "ForgeCRUD-Notifications/1.0")
+
+	if integration.Kind == notification.IntegrationWebhook && integration.Secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(database.WebhookEventHeader, models.WebhookEventNotificationCreated)
+		req.Header.Set(database.WebhookDeliveryHeader, delivery.ID.String())
+		req.Header.Set(database.WebhookTimestampHeader, timestamp)
+		req.Header.Set(database.WebhookSignatureHeader, database.SignWebhookPayload(integration.Secret, timestamp, payload))
+	}
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strings"
+
+	"forgecrud-backend/shared/config"
+)
+
+// MailgunProvider sends emails through the Mailgun messages API
+type MailgunProvider struct {
+	apiURL   string
+	domain   string
+	apiKey   string
+	from     string
+	fromName string
+	client   *http.Client
+}
+
+// NewMailgunProvider creates a Mailgun provider from the Mailgun settings
+func NewMailgunProvider(cfg *config.Config) (*MailgunProvider, error) {
+	if cfg.MailgunDomain == "" || cfg.MailgunAPIKey == "" {
+		return nil, fmt.Errorf("Mailgun configuration is incomplete")
+	}
+
+	return &MailgunProvider{
+		apiURL:   strings.TrimRight(cfg.MailgunAPIURL, "/"),
+		domain:   cfg.MailgunDomain,
+		apiKey:   cfg.MailgunAPIKey,
+		from:     cfg.EmailFrom,
+		fromName: cfg.EmailFromName,
+		client:   &http.Client{Timeout: emailSendTimeout},
+	}, nil
+}
+
+// Send delivers the message and returns the message ID assigned by Mailgun
+func (p *MailgunProvider) Send(ctx context.Context, request EmailRequest) (string, error) {
+	form := url.Values{}
+	form.Set("from", fmt.Sprintf("%s <%s>", p.fromName, p.from))
+	form["to"] = request.To
+	if len(request.CC) > 0 {
+		form["cc"] = request.CC
+	}
+	if len(request.BCC) > 0 {
+		form["bcc"] = request.BCC
+	}
+	form.Set("subject", request.Subject)
+	if request.IsHTML {
+		form.Set("html", request.Body)
+		if request.TextBody != "" {
+			form.Set("text", request.TextBody)
+		}
+	} else {
+		form.Set("text", request.Body)
+	}
+
+	var body io.Reader = strings.NewReader(form.Encode())
+	contentType := "application/x-www-form-urlencoded"
+	if len(request.AttachmentFiles) > 0 {
+		var err error
+		if body, contentType, err = mailgunMultipart(form, request.AttachmentFiles); err != nil {
+			return "", err
+		}
+	}
+
+	endpoint := fmt.Sprintf("%s/v3/%s/messages", p.apiURL, p.domain)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, body)
+	if err != nil {
+		return "", err
+	}
+	req.SetBasicAuth("api", p.apiKey)
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode != http.StatusOK {
+		// A malformed message is rejected for good, auth and rate limit errors are retried
+		if resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusRequestEntityTooLarge {
+			return "", fmt.Errorf("%w: Mailgun returned %d: %s", ErrEmailRejected, resp.StatusCode, strings.TrimSpace(string(respBody)))
+		}
+		return "", fmt.Errorf("Mailgun returned %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	var sent struct {
+		ID string `json:"id"`
+	}
+	json.Unmarshal(respBody, &sent)
+	// Mailgun reports IDs in angle brackets like a Message-ID header
+	return strings.Trim(sent.ID, "<>"), nil
+}
+
+// mailgunMultipart encodes the form with the attachments as multipart/form-data, which Mailgun
+// requires for files
+func mailgunMultipart(form url.Values, files []AttachmentFile) (io.Reader, string, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for key, values := range form {
+		for _, value := range values {
+			if err := writer.WriteField(key, value); err != nil {
+				return nil, "", err
+			}
+		}
+	}
+
+	for _, file := range files {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Disposition", mime.FormatMediaType("form-data", map[string]string{"name": "attachment", "filename": file.FileName}))
+		header.Set("Content-Type", file.ContentType)
+		part, err := writer.CreatePart(header)
+		if err != nil {
+			return nil, "", err
+		}
+		if _, err := part.Write(file.Content); err != nil {
+			return nil, "", err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, "", err
+	}
+	return &body, writer.FormDataContentType(), nil
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"log"
+	"time"
+
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models/notification"
+	"forgecrud-backend/shared/readonly"
+
+	"gorm.io/gorm"
+)
+
+const notificationRetentionBatchSize = 1000
+
+// NotificationRetention archives old notifications and deletes archived notifications past the
+// purge period. A zero duration disables the rule.
+type NotificationRetention struct {
+	archiveAfter time.Duration
+	purgeAfter   time.Duration
+}
+
+// NewNotificationRetention creates a retention job archiving notifications older than archiveAfter
+// and deleting notifications archived longer than purgeAfter ago
+func NewNotificationRetention(archiveAfter, purgeAfter time.Duration) *NotificationRetention {
+	return &NotificationRetention{archiveAfter: archiveAfter, purgeAfter: purgeAfter}
+}
+
+// Start applies the retention rules in the background
+func (r *NotificationRetention) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if readonly.Active() {
+				<-ticker.C
+				continue
+			}
+			archived, purged, err := r.Run(database.DB)
+			if err != nil {
+				log.Printf("❌ Notification retention failed: %v", err)
+			} else if archived+purged > 0 {
+				log.Printf("🧹 Notification retention: %d archived, %d purged", archived, purged)
+			}
+
+			<-ticker.C
+		}
+	}()
+
+	log.Printf("🗄️  Notification retention started (archive after: %s, purge after: %s, interval: %s)",
+		r.archiveAfter, r.purgeAfter, interval)
+}
+
+// Run archives and purges notifications in batches, so a large backlog doesn't hold long locks
+func (r *NotificationRetention) Run(db *gorm.DB) (archived, purged int64, err error) {
+	now := time.Now()
+
+	if r.archiveAfter > 0 {
+		cutoff := now.Add(-r.archiveAfter)
+		for {
+			result := db.Model(&notification.Notification{}).
+				Where("id IN (?)", db.Model(&notification.Notification{}).Select("id").
+					Where("archived_at IS NULL AND created_at < ?", cutoff).
+					Limit(notificationRetentionBatchSize)).
+				Update("archived_at", now)
+			if result.Error != nil {
+				return archived, purged, result.Error
+			}
+			archived += result.RowsAffected
+			if result.RowsAffected < notificationRetentionBatchSize {
+				break
+			}
+		}
+	}
+
+	if r.purgeAfter > 0 {
+		cutoff := now.Add(-r.purgeAfter)
+		for {
+			result := db.Where("id IN (?)", db.Model(&notification.Notification{}).Select("id").
+				Where("archived_at IS NOT NULL AND archived_at < ?", cutoff).
+				Limit(notificationRetentionBatchSize)).
+				Delete(&notification.Notification{})
+			if result.Error != nil {
+				return archived, purged, result.Error
+			}
+			purged += result.RowsAffected
+			if result.RowsAffected < notificationRetentionBatchSize {
+				break
+			}
+		}
+	}
+
+	return archived, purged, nil
+}
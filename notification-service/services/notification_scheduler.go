@@ -0,0 +1,192 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models/notification"
+	"forgecrud-backend/shared/readonly"
+	"forgecrud-backend/shared/scheduler"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const scheduledNotificationBatchSize = 50
+
+// NotificationScheduler sends scheduled notifications and emails when they are due
+type NotificationScheduler struct {
+	dispatcher   *NotificationDispatcher
+	emailService *EmailService
+}
+
+// NewNotificationScheduler creates a scheduler delivering through the dispatcher and email service
+func NewNotificationScheduler(dispatcher *NotificationDispatcher, emailService *EmailService) *NotificationScheduler {
+	return &NotificationScheduler{dispatcher: dispatcher, emailService: emailService}
+}
+
+// Start polls for due schedules in the background
+func (s *NotificationScheduler) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if readonly.Active() {
+				continue
+			}
+			if err := s.RunDue(); err != nil {
+				log.Printf("⚠️  Scheduled notification run failed: %v", err)
+			}
+		}
+	}()
+
+	log.Printf("⏰ Notification scheduler started (interval: %s)", interval)
+}
+
+// RunDue sends the schedules whose next run is due and advances them to their following run.
+// Rows are locked with SKIP LOCKED so a schedule is only sent by one notification-service instance.
+func (s *NotificationScheduler) RunDue() error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		var schedules []notification.ScheduledNotification
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_run_at <= ?", notification.ScheduleStatusActive, time.Now().UTC()).
+			Order("next_run_at").
+			Limit(scheduledNotificationBatchSize).
+			Find(&schedules).Error; err != nil {
+			return err
+		}
+
+		for i := range schedules {
+			if err := s.run(tx, &schedules[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// run sends one occurrence of a schedule and records its outcome
+func (s *NotificationScheduler) run(db *gorm.DB, schedule *notification.ScheduledNotification) error {
+	now := time.Now().UTC()
+	sendErr := s.send(schedule)
+
+	updates := map[string]interface{}{
+		"run_count":   schedule.RunCount + 1,
+		"last_run_at": now,
+		"last_error":  "",
+	}
+	if sendErr != nil {
+		log.Printf("⚠️  Scheduled %s %s failed: %v", schedule.Kind, schedule.ID, sendErr)
+		updates["last_error"] = sendErr.Error()
+	}
+
+	if schedule.Cron == "" {
+		// One-off schedules are done after their single run
+		updates["next_run_at"] = nil
+		updates["status"] = notification.ScheduleStatusCompleted
+		if sendErr != nil {
+			updates["status"] = notification.ScheduleStatusFailed
+		}
+	} else {
+		// A failed occurrence of a recurring schedule doesn't stop the following ones
+		schedule.RunCount++
+		next, err := NextScheduledRun(schedule, now)
+		if err != nil {
+			updates["last_error"] = err.Error()
+			updates["status"] = notification.ScheduleStatusFailed
+		} else if next == nil {
+			updates["status"] = notification.ScheduleStatusCompleted
+		}
+		updates["next_run_at"] = next
+	}
+
+	return db.Model(schedule).Updates(updates).Error
+}
+
+// send delivers one occurrence of the schedule's notification or email
+func (s *NotificationScheduler) send(schedule *notification.ScheduledNotification) error {
+	switch schedule.Kind {
+	case notification.ScheduledKindNotification:
+		notif := notification.Notification{
+			UserID:   schedule.UserID,
+			Type:     schedule.Type,
+			Level:    schedule.Level,
+			Title:    schedule.Title,
+			Message:  schedule.Message,
+			Action:   schedule.Action,
+			EntityID: schedule.EntityID,
+			Entity:   schedule.Entity,
+		}
+		if len(schedule.Data) > 0 {
+			notif.Data = schedule.Data
+		}
+		_, err := s.dispatcher.Dispatch(&notif, nil)
+		return err
+	case notification.ScheduledKindEmail:
+		_, err := s.emailService.QueueEmail(EmailRequest{
+			To:             splitAddresses(schedule.EmailTo),
+			Subject:        schedule.EmailSubject,
+			Body:           schedule.EmailBody,
+			IsHTML:         schedule.EmailIsHTML,
+			TemplateID:     schedule.EmailTemplateID,
+			TemplateVars:   schedule.TemplateVars,
+			OrganizationID: schedule.OrganizationID,
+		})
+		return err
+	default:
+		return fmt.Errorf("unknown scheduled notification kind %q", schedule.Kind)
+	}
+}
+
+// NextScheduledRun returns the schedule's first run after the given time, or nil when it has no
+// more runs because it is a one-off schedule, it ended or it reached its maximum number of runs
+func NextScheduledRun(schedule *notification.ScheduledNotification, after time.Time) (*time.Time, error) {
+	if schedule.Cron == "" {
+		if schedule.RunAt == nil || schedule.RunCount > 0 || !schedule.RunAt.After(after) {
+			return nil, nil
+		}
+		return schedule.RunAt, nil
+	}
+	if schedule.MaxRuns > 0 && schedule.RunCount >= schedule.MaxRuns {
+		return nil, nil
+	}
+
+	cron, err := scheduler.ParseCronSchedule(schedule.Cron)
+	if err != nil {
+		return nil, err
+	}
+	location, err := time.LoadLocation(schedule.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q", schedule.Timezone)
+	}
+
+	next := cron.Next(after, location)
+	if next.IsZero() || (schedule.EndsAt != nil && next.After(*schedule.EndsAt)) {
+		return nil, nil
+	}
+	next = next.UTC()
+	return &next, nil
+}
+
+// UpcomingScheduledRuns returns up to count next runs of the schedule, starting at its next run
+func UpcomingScheduledRuns(schedule *notification.ScheduledNotification, count int) []time.Time {
+	runs := []time.Time{}
+	if schedule.Status != notification.ScheduleStatusActive || schedule.NextRunAt == nil {
+		return runs
+	}
+
+	preview := *schedule
+	next := schedule.NextRunAt
+	for len(runs) < count && next != nil {
+		runs = append(runs, *next)
+		preview.RunCount++
+		var err error
+		if next, err = NextScheduledRun(&preview, *next); err != nil {
+			break
+		}
+	}
+	return runs
+}
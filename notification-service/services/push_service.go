@@ -0,0 +1,172 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"forgecrud-backend/shared/config"
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models/notification"
+
+	"github.com/google/uuid"
+)
+
+const pushSendTimeout = 30 * time.Second
+
+// ErrInvalidDeviceToken is returned by a sender when the provider no longer accepts the device token
+var ErrInvalidDeviceToken = errors.New("device token is no longer valid")
+
+// PushMessage is a push notification as shown on the device. Data is passed to the app.
+type PushMessage struct {
+	Title string
+	Body  string
+	Data  map[string]string
+}
+
+// PushSender delivers push notifications through a provider
+type PushSender interface {
+	// Send pushes the message to the device token and returns the provider's message ID
+	Send(ctx context.Context, token string, message PushMessage) (string, error)
+}
+
+// PushService pushes notifications to the registered devices of users and tracks each delivery
+type PushService struct {
+	senders map[string]PushSender
+}
+
+var pushService *PushService
+var pushOnce sync.Once
+
+// GetPushService returns the push service with a sender for every configured provider
+func GetPushService() *PushService {
+	pushOnce.Do(func() {
+		cfg := config.GetConfig()
+		pushService = &PushService{senders: map[string]PushSender{}}
+
+		if cfg.FCMCredentialsFile != "" {
+			if sender, err := NewFCMSender(cfg.FCMCredentialsFile); err != nil {
+				log.Printf("⚠️  FCM push disabled: %v", err)
+			} else {
+				pushService.senders[notification.PushProviderFCM] = sender
+				log.Printf("📲 FCM push enabled")
+			}
+		}
+		if cfg.APNSKeyFile != "" {
+			if sender, err := NewAPNSSender(cfg.APNSKeyFile, cfg.APNSKeyID, cfg.APNSTeamID, cfg.APNSTopic, cfg.APNSProduction); err != nil {
+				log.Printf("⚠️  APNs push disabled: %v", err)
+			} else {
+				pushService.senders[notification.PushProviderAPNS] = sender
+				log.Printf("📲 APNs push enabled")
+			}
+		}
+	})
+	return pushService
+}
+
+// PushNotification pushes a notification to the devices of its recipient. Deliveries of notifications
+// that were not stored, e.g. with the in-app channel disabled, are recorded without notification.
+func (ps *PushService) PushNotification(notif *notification.Notification) int {
+	if notif.UserID == nil {
+		return 0
+	}
+
+	data := map[string]string{
+		"type":  notif.Type,
+		"level": string(notif.Level),
+	}
+	var notificationID *uuid.UUID
+	if notif.ID != uuid.Nil {
+		notificationID = &notif.ID
+		data["notification_id"] = notif.ID.String()
+	}
+	if notif.Action != "" {
+		data["action"] = notif.Action
+	}
+	if notif.Entity != "" {
+		data["entity"] = notif.Entity
+	}
+	if notif.EntityID != nil {
+		data["entity_id"] = notif.EntityID.String()
+	}
+
+	return ps.SendToUser(*notif.UserID, notificationID, PushMessage{
+		Title: notif.Title,
+		Body:  notif.Message,
+		Data:  data,
+	})
+}
+
+// SendToUser pushes the message to every active device of the user and returns the number of devices
+// it reached. Each attempt is recorded as a delivery; devices whose token was rejected are disabled.
+func (ps *PushService) SendToUser(userID uuid.UUID, notificationID *uuid.UUID, message PushMessage) int {
+	db := database.GetDB()
+
+	var devices []notification.DeviceToken
+	if err := db.Where("user_id = ? AND disabled_at IS NULL", userID).Find(&devices).Error; err != nil {
+		log.Printf("❌ Failed to load devices of user %s: %v", userID, err)
+		return 0
+	}
+
+	sent := 0
+	for i := range devices {
+		if ps.deliver(&devices[i], notificationID, message) {
+			sent++
+		}
+	}
+	return sent
+}
+
+// deliver pushes the message to one device and records the outcome
+func (ps *PushService) deliver(device *notification.DeviceToken, notificationID *uuid.UUID, message PushMessage) bool {
+	db := database.GetDB()
+
+	delivery := notification.PushDelivery{
+		NotificationID: notificationID,
+		DeviceTokenID:  device.ID,
+		UserID:         device.UserID,
+		Provider:       device.Provider,
+		Status:         notification.PushDeliveryPending,
+	}
+	if err := db.Create(&delivery).Error; err != nil {
+		log.Printf("❌ Failed to record push delivery to device %s: %v", device.ID, err)
+		return false
+	}
+
+	var messageID string
+	var err error
+	if sender, ok := ps.senders[device.Provider]; ok {
+		ctx, cancel := context.WithTimeout(context.Background(), pushSendTimeout)
+		messageID, err = sender.Send(ctx, device.Token, message)
+		cancel()
+	} else {
+		err = fmt.Errorf("%s push is not configured", device.Provider)
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{}
+	switch {
+	case err == nil:
+		updates["status"] = notification.PushDeliverySent
+		updates["message_id"] = messageID
+		updates["sent_at"] = now
+		db.Model(device).Update("last_used_at", now)
+	case errors.Is(err, ErrInvalidDeviceToken):
+		updates["status"] = notification.PushDeliveryInvalidToken
+		updates["error"] = err.Error()
+		db.Model(device).Update("disabled_at", now)
+		log.Printf("📲 Disabled device %s of user %s: %v", device.ID, device.UserID, err)
+	default:
+		updates["status"] = notification.PushDeliveryFailed
+		updates["error"] = err.Error()
+		log.Printf("⚠️  Push to device %s of user %s failed: %v", device.ID, device.UserID, err)
+	}
+
+	if err := db.Model(&delivery).Updates(updates).Error; err != nil {
+		log.Printf("❌ Failed to update push delivery %s: %v", delivery.ID, err)
+	}
+	return updates["status"] == notification.PushDeliverySent
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"forgecrud-backend/shared/config"
+)
+
+// SendGridProvider sends emails through the SendGrid v3 mail send API
+type SendGridProvider struct {
+	apiKey   string
+	from     string
+	fromName string
+	client   *http.Client
+}
+
+// NewSendGridProvider creates a SendGrid provider from the SendGrid settings
+func NewSendGridProvider(cfg *config.Config) (*SendGridProvider, error) {
+	if cfg.SendGridAPIKey == "" {
+		return nil, fmt.Errorf("SENDGRID_API_KEY is not set")
+	}
+
+	return &SendGridProvider{
+		apiKey:   cfg.SendGridAPIKey,
+		from:     cfg.EmailFrom,
+		fromName: cfg.EmailFromName,
+		client:   &http.Client{Timeout: emailSendTimeout},
+	}, nil
+}
+
+// Send delivers the message and returns the X-Message-Id assigned by SendGrid
+func (p *SendGridProvider) Send(ctx context.Context, request EmailRequest) (string, error) {
+	personalization := map[string]interface{}{"to": sendGridAddresses(request.To)}
+	if len(request.CC) > 0 {
+		personalization["cc"] = sendGridAddresses(request.CC)
+	}
+	if len(request.BCC) > 0 {
+		personalization["bcc"] = sendGridAddresses(request.BCC)
+	}
+
+	// SendGrid wants the plain text part first
+	content := []map[string]string{}
+	if !request.IsHTML {
+		content = append(content, map[string]string{"type": "text/plain", "value": request.Body})
+	} else {
+		if request.TextBody != "" {
+			content = append(content, map[string]string{"type": "text/plain", "value": request.TextBody})
+		}
+		content = append(content, map[string]string{"type": "text/html", "value": request.Body})
+	}
+
+	message := map[string]interface{}{
+		"personalizations": []interface{}{personalization},
+		"from":             map[string]string{"email": p.from, "name": p.fromName},
+		"subject":          request.Subject,
+		"content":          content,
+	}
+	if len(request.AttachmentFiles) > 0 {
+		attachments := make([]map[string]string, len(request.AttachmentFiles))
+		for i, file := range request.AttachmentFiles {
+			attachments[i] = map[string]string{
+				"content":     base64.StdEncoding.EncodeToString(file.Content),
+				"type":        file.ContentType,
+				"filename":    file.FileName,
+				"disposition": "attachment",
+			}
+		}
+		message["attachments"] = attachments
+	}
+
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://api.sendgrid.com/v3/mail/send", bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		// A malformed or oversized message is rejected for good, auth and rate limit errors are retried
+		if resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusRequestEntityTooLarge {
+			return "", fmt.Errorf("%w: SendGrid returned %d: %s", ErrEmailRejected, resp.StatusCode, strings.TrimSpace(string(respBody)))
+		}
+		return "", fmt.Errorf("SendGrid returned %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	return resp.Header.Get("X-Message-Id"), nil
+}
+
+func sendGridAddresses(addresses []string) []map[string]string {
+	result := make([]map[string]string, len(addresses))
+	for i, address := range addresses {
+		result[i] = map[string]string{"email": address}
+	}
+	return result
+}
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"forgecrud-backend/shared/config"
+
+	"github.com/google/uuid"
+)
+
+// SESProvider sends emails through the Amazon SES v2 API, signing requests with AWS Signature Version 4
+type SESProvider struct {
+	region          string
+	accessKeyID     string
+	secretAccessKey string
+	from            string
+	fromName        string
+	client          *http.Client
+}
+
+// NewSESProvider creates an SES provider from the SES settings
+func NewSESProvider(cfg *config.Config) (*SESProvider, error) {
+	if cfg.SESRegion == "" || cfg.SESAccessKeyID == "" || cfg.SESSecretAccessKey == "" {
+		return nil, fmt.Errorf("SES configuration is incomplete")
+	}
+
+	return &SESProvider{
+		region:          cfg.SESRegion,
+		accessKeyID:     cfg.SESAccessKeyID,
+		secretAccessKey: cfg.SESSecretAccessKey,
+		from:            cfg.EmailFrom,
+		fromName:        cfg.EmailFromName,
+		client:          &http.Client{Timeout: emailSendTimeout},
+	}, nil
+}
+
+// Send delivers the message and returns the message ID assigned by SES
+func (p *SESProvider) Send(ctx context.Context, request EmailRequest) (string, error) {
+	body := map[string]interface{}{}
+	if request.IsHTML {
+		body["Html"] = map[string]string{"Data": request.Body, "Charset": "UTF-8"}
+		if request.TextBody != "" {
+			body["Text"] = map[string]string{"Data": request.TextBody, "Charset": "UTF-8"}
+		}
+	} else {
+		body["Text"] = map[string]string{"Data": request.Body, "Charset": "UTF-8"}
+	}
+
+	destination := map[string][]string{"ToAddresses": request.To}
+	if len(request.CC) > 0 {
+		destination["CcAddresses"] = request.CC
+	}
+	if len(request.BCC) > 0 {
+		destination["BccAddresses"] = request.BCC
+	}
+
+	content := map[string]interface{}{
+		"Simple": map[string]interface{}{
+			"Subject": map[string]string{"Data": request.Subject, "Charset": "UTF-8"},
+			"Body":    body,
+		},
+	}
+	// Attachments need a raw MIME message, built like the one sent over SMTP
+	if len(request.AttachmentFiles) > 0 {
+		raw := buildEmailMessage(p.from, p.fromName, fmt.Sprintf("%s@%s", uuid.NewString(), domainOf(p.from)), request)
+		content = map[string]interface{}{
+			"Raw": map[string]string{"Data": base64.StdEncoding.EncodeToString([]byte(raw))},
+		}
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"FromEmailAddress": fmt.Sprintf("%s <%s>", p.fromName, p.from),
+		"Destination":      destination,
+		"Content":          content,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	host := fmt.Sprintf("email.%s.amazonaws.com", p.region)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://"+host+"/v2/email/outbound-emails", bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	p.sign(req, host, payload, time.Now().UTC())
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode != http.StatusOK {
+		var sesError struct {
+			Message string `json:"message"`
+		}
+		json.Unmarshal(respBody, &sesError)
+		errorType := resp.Header.Get("X-Amzn-ErrorType")
+		if i := strings.Index(errorType, ":"); i >= 0 {
+			errorType = errorType[:i]
+		}
+		// Throttling and account problems are worth retrying, a rejected or malformed message is not
+		if errorType == "MessageRejected" || errorType == "BadRequestException" {
+			return "", fmt.Errorf("%w: SES %s: %s", ErrEmailRejected, errorType, sesError.Message)
+		}
+		return "", fmt.Errorf("SES returned %d %s: %s", resp.StatusCode, errorType, strings.TrimSpace(string(respBody)))
+	}
+
+	var sent struct {
+		MessageID string `json:"MessageId"`
+	}
+	json.Unmarshal(respBody, &sent)
+	return sent.MessageID, nil
+}
+
+// sign adds an AWS Signature Version 4 Authorization header for the SES service
+func (p *SESProvider) sign(req *http.Request, host string, payload []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	payloadHash := sha256.Sum256(payload)
+	signedHeaders := "content-type;host;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		"",
+		"content-type:" + req.Header.Get("Content-Type"),
+		"host:" + host,
+		"x-amz-date:" + amzDate,
+		"",
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+
+	scope := fmt.Sprintf("%s/%s/ses/aws4_request", date, p.region)
+	canonicalHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, hex.EncodeToString(canonicalHash[:])}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+p.secretAccessKey), date)
+	key = hmacSHA256(key, p.region)
+	key = hmacSHA256(key, "ses")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		p.accessKeyID, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,39 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"forgecrud-backend/shared/config"
+	"forgecrud-backend/shared/database/models/notification"
+)
+
+const smsSendTimeout = 15 * time.Second
+
+// ErrNoSMSProvider is returned when SMS is not configured
+var ErrNoSMSProvider = errors.New("no SMS provider is configured")
+
+// SMSProvider delivers text messages through an SMS gateway
+type SMSProvider interface {
+	// Name returns the provider name recorded with sent messages
+	Name() string
+	// Send delivers the text to a phone number in E.164 format and returns the provider's message ID
+	Send(ctx context.Context, to, text string) (string, error)
+}
+
+// NewSMSProvider returns the provider selected by SMS_PROVIDER, nil when SMS is disabled
+func NewSMSProvider(cfg *config.Config) (SMSProvider, error) {
+	switch strings.ToLower(cfg.SMSProvider) {
+	case notification.SMSProviderTwilio:
+		return NewTwilioProvider(cfg)
+	case notification.SMSProviderVonage:
+		return NewVonageProvider(cfg)
+	case "":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown SMS provider %q", cfg.SMSProvider)
+	}
+}
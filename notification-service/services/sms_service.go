@@ -0,0 +1,245 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"time"
+
+	"forgecrud-backend/shared/config"
+	"forgecrud-backend/shared/database/models"
+	"forgecrud-backend/shared/database/models/notification"
+	"forgecrud-backend/shared/i18n"
+	"forgecrud-backend/shared/messaging"
+
+	"gorm.io/gorm"
+)
+
+// phoneVerificationMaxAttempts is how often a code may be entered wrong before a new one is needed
+const phoneVerificationMaxAttempts = 5
+
+var (
+	// ErrSMSRateLimited is returned when the hourly SMS limit of a user or phone number is reached
+	ErrSMSRateLimited = errors.New("too many text messages, try again later")
+	// ErrInvalidVerificationCode is returned for a wrong, expired or missing phone verification code
+	ErrInvalidVerificationCode = errors.New("invalid or expired verification code")
+	// ErrPhoneNotVerified is returned when texting a user without a verified phone
+	ErrPhoneNotVerified = errors.New("user has no verified phone")
+)
+
+// securityAlertTexts are the catalog keys of the SMS text of each security event
+var securityAlertTexts = map[string]string{
+	messaging.EventSecurityNewDeviceLogin:  "sms.alert.new_device_login",
+	messaging.EventSecurityPasswordChanged: "sms.alert.password_changed",
+}
+
+// IsSecurityAlert reports whether users are texted about the event type
+func IsSecurityAlert(eventType string) bool {
+	_, ok := securityAlertTexts[eventType]
+	return ok
+}
+
+// SMSService sends phone verification codes and security alerts by SMS. Every text is recorded in
+// sms_messages, which the hourly rate limits are counted from.
+type SMSService struct {
+	config   *config.Config
+	provider SMSProvider
+}
+
+// NewSMSService creates an SMS service with the provider selected by SMS_PROVIDER. A provider that
+// is not configured correctly is disabled with a warning.
+func NewSMSService(cfg *config.Config) *SMSService {
+	provider, err := NewSMSProvider(cfg)
+	if err != nil {
+		log.Printf("⚠️  SMS provider %s disabled: %v", cfg.SMSProvider, err)
+		provider = nil
+	} else if provider != nil {
+		log.Printf("📱 SMS provider %s enabled", provider.Name())
+	}
+
+	return &SMSService{config: cfg, provider: provider}
+}
+
+// Enabled reports whether an SMS provider is configured
+func (s *SMSService) Enabled() bool {
+	return s.provider != nil
+}
+
+// StartPhoneVerification texts a new verification code to the phone, replacing a pending one. The
+// phone becomes the user's verified phone once the code is confirmed.
+func (s *SMSService) StartPhoneVerification(db *gorm.DB, user *models.User, phone string) (*notification.PhoneVerification, error) {
+	if !s.Enabled() {
+		return nil, ErrNoSMSProvider
+	}
+	if err := s.checkRateLimit(db, user, phone, notification.SMSKindVerification); err != nil {
+		return nil, err
+	}
+
+	code, err := generateVerificationCode()
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := s.config.SMSVerificationCodeTTLMinutes
+	verification := notification.PhoneVerification{
+		UserID:    user.ID,
+		Phone:     phone,
+		CodeHash:  hashVerificationCode(code),
+		ExpiresAt: time.Now().UTC().Add(time.Duration(ttl) * time.Minute),
+	}
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", user.ID).Delete(&notification.PhoneVerification{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&verification).Error
+	}); err != nil {
+		return nil, err
+	}
+
+	text := i18n.T(i18n.Resolve(user.Language), "sms.verification_code", code, ttl)
+	if _, err := s.send(db, user, phone, notification.SMSKindVerification, text); err != nil {
+		return nil, err
+	}
+	return &verification, nil
+}
+
+// ConfirmPhoneVerification checks the code of the user's pending verification and, when it matches,
+// stores the phone as the user's verified phone
+func (s *SMSService) ConfirmPhoneVerification(db *gorm.DB, user *models.User, code string) error {
+	var verification notification.PhoneVerification
+	if err := db.Where("user_id = ?", user.ID).First(&verification).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidVerificationCode
+		}
+		return err
+	}
+
+	if time.Now().After(verification.ExpiresAt) || verification.Attempts >= phoneVerificationMaxAttempts {
+		db.Delete(&verification)
+		return ErrInvalidVerificationCode
+	}
+	if subtle.ConstantTimeCompare([]byte(hashVerificationCode(code)), []byte(verification.CodeHash)) != 1 {
+		if err := db.Model(&verification).Update("attempts", gorm.Expr("attempts + 1")).Error; err != nil {
+			return err
+		}
+		return ErrInvalidVerificationCode
+	}
+
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Updates(map[string]interface{}{
+			"phone":          verification.Phone,
+			"phone_verified": true,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&verification).Error
+	}); err != nil {
+		return err
+	}
+
+	user.Phone, user.PhoneVerified = verification.Phone, true
+	return nil
+}
+
+// SendSecurityAlert texts the user about a security event and returns the logged message. Users
+// without a verified phone are not texted and get ErrPhoneNotVerified.
+func (s *SMSService) SendSecurityAlert(db *gorm.DB, user *models.User, eventType string, data messaging.SecurityAlertData) (*notification.SMSMessage, error) {
+	textKey, ok := securityAlertTexts[eventType]
+	if !ok {
+		return nil, fmt.Errorf("%s is not a security alert", eventType)
+	}
+	if !s.Enabled() {
+		return nil, ErrNoSMSProvider
+	}
+	if !user.PhoneVerified || user.Phone == "" {
+		return nil, ErrPhoneNotVerified
+	}
+	if err := s.checkRateLimit(db, user, user.Phone, notification.SMSKindSecurityAlert); err != nil {
+		return nil, err
+	}
+
+	ipAddress := data.IPAddress
+	if ipAddress == "" {
+		ipAddress = "?"
+	}
+	return s.send(db, user, user.Phone, notification.SMSKindSecurityAlert, i18n.T(i18n.Resolve(user.Language), textKey, ipAddress))
+}
+
+// checkRateLimit fails with ErrSMSRateLimited when the user already got the hourly limit of texts of
+// the kind. Verification codes are limited per phone number too, so nobody can flood a stranger's
+// phone from several accounts.
+func (s *SMSService) checkRateLimit(db *gorm.DB, user *models.User, phone, kind string) error {
+	limit := s.config.SMSSecurityAlertMaxPerHour
+	if kind == notification.SMSKindVerification {
+		limit = s.config.SMSVerificationMaxPerHour
+	}
+	if limit <= 0 {
+		return nil
+	}
+
+	query := db.Model(&notification.SMSMessage{}).Where("kind = ? AND created_at > ?", kind, time.Now().UTC().Add(-time.Hour))
+	if kind == notification.SMSKindVerification {
+		query = query.Where("user_id = ? OR phone = ?", user.ID, phone)
+	} else {
+		query = query.Where("user_id = ?", user.ID)
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return err
+	}
+	if count >= int64(limit) {
+		return ErrSMSRateLimited
+	}
+	return nil
+}
+
+// send texts the phone and records the outcome in the SMS log
+func (s *SMSService) send(db *gorm.DB, user *models.User, phone, kind, text string) (*notification.SMSMessage, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), smsSendTimeout)
+	defer cancel()
+
+	messageID, err := s.provider.Send(ctx, phone, text)
+	record := notification.SMSMessage{
+		UserID:            user.ID,
+		OrganizationID:    user.OrganizationID,
+		Phone:             phone,
+		Kind:              kind,
+		Status:            notification.SMSStatusSent,
+		Provider:          s.provider.Name(),
+		ProviderMessageID: messageID,
+	}
+	if err != nil {
+		record.Status = notification.SMSStatusFailed
+		record.Error = err.Error()
+	}
+	if createErr := db.Create(&record).Error; createErr != nil {
+		log.Printf("⚠️  Failed to record %s SMS to user %s: %v", kind, user.ID, createErr)
+	}
+
+	if err != nil {
+		log.Printf("❌ %s SMS to user %s failed: %v", kind, user.ID, err)
+		return &record, fmt.Errorf("failed to send SMS: %v", err)
+	}
+	return &record, nil
+}
+
+// generateVerificationCode returns a random six digit code
+func generateVerificationCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+func hashVerificationCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
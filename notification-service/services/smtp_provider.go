@@ -0,0 +1,211 @@
+package services
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mime"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+
+	"forgecrud-backend/shared/config"
+
+	"github.com/google/uuid"
+)
+
+// SMTPProvider sends emails through an SMTP server
+type SMTPProvider struct {
+	host     string
+	port     string
+	username string
+	password string
+	from     string
+	fromName string
+	useTLS   bool
+}
+
+// NewSMTPProvider creates an SMTP provider from the SMTP settings
+func NewSMTPProvider(cfg *config.Config) (*SMTPProvider, error) {
+	if cfg.SMTPHost == "" || cfg.SMTPUsername == "" || cfg.SMTPPassword == "" {
+		return nil, fmt.Errorf("SMTP configuration is incomplete")
+	}
+
+	return &SMTPProvider{
+		host:     cfg.SMTPHost,
+		port:     cfg.SMTPPort,
+		username: cfg.SMTPUsername,
+		password: cfg.SMTPPassword,
+		from:     cfg.EmailFrom,
+		fromName: cfg.EmailFromName,
+		useTLS:   cfg.SMTPUseTLS,
+	}, nil
+}
+
+// Send delivers the message and returns the Message-ID it was sent with. Permanent (5xx) replies
+// other than authentication failures are reported as ErrEmailRejected.
+func (p *SMTPProvider) Send(ctx context.Context, request EmailRequest) (string, error) {
+	messageID := fmt.Sprintf("%s@%s", uuid.NewString(), domainOf(p.from))
+	message := buildEmailMessage(p.from, p.fromName, messageID, request)
+
+	// SMTP auth
+	auth := smtp.PlainAuth("", p.username, p.password, p.host)
+
+	// Connect to server
+	addr := fmt.Sprintf("%s:%s", p.host, p.port)
+
+	// Recipients
+	recipients := append(append(append([]string{}, request.To...), request.CC...), request.BCC...)
+
+	var err error
+	// Port 465 uses implicit TLS (SSL), other ports may use explicit TLS (STARTTLS)
+	if p.port == "465" || p.useTLS {
+		err = p.sendWithTLS(addr, auth, recipients, []byte(message))
+	} else {
+		// Regular SMTP without TLS
+		err = smtp.SendMail(addr, auth, p.from, recipients, []byte(message))
+	}
+
+	if err != nil {
+		var smtpErr *textproto.Error
+		// Authentication failures are fixed in our configuration, not the message
+		if errors.As(err, &smtpErr) && smtpErr.Code >= 500 && smtpErr.Code != 530 && smtpErr.Code != 534 && smtpErr.Code != 535 {
+			return "", fmt.Errorf("%w: %v", ErrEmailRejected, err)
+		}
+		return "", err
+	}
+	return messageID, nil
+}
+
+// sendWithTLS sends email with TLS
+func (p *SMTPProvider) sendWithTLS(addr string, auth smtp.Auth, to []string, msg []byte) error {
+	// Connect to server
+	conn, err := tls.Dial("tcp", addr, &tls.Config{
+		InsecureSkipVerify: true,
+		ServerName:         p.host,
+	})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// Create SMTP client
+	client, err := smtp.NewClient(conn, p.host)
+	if err != nil {
+		return err
+	}
+	defer client.Quit()
+
+	// Auth
+	if err = client.Auth(auth); err != nil {
+		return err
+	}
+
+	// Set sender
+	if err = client.Mail(p.from); err != nil {
+		return err
+	}
+
+	// Set recipients
+	for _, recipient := range to {
+		if err = client.Rcpt(recipient); err != nil {
+			return err
+		}
+	}
+
+	// Send message
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+
+	if _, err = w.Write(msg); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// buildEmailMessage builds email message
+func buildEmailMessage(from, fromName, messageID string, request EmailRequest) string {
+	var msg strings.Builder
+
+	// Headers
+	msg.WriteString(fmt.Sprintf("From: %s <%s>\r\n", mime.QEncoding.Encode("utf-8", fromName), from))
+	msg.WriteString(fmt.Sprintf("To: %s\r\n", strings.Join(request.To, ", ")))
+
+	if len(request.CC) > 0 {
+		msg.WriteString(fmt.Sprintf("CC: %s\r\n", strings.Join(request.CC, ", ")))
+	}
+
+	// Translated subjects may contain non-ASCII characters, which headers must encode
+	msg.WriteString(fmt.Sprintf("Subject: %s\r\n", mime.QEncoding.Encode("utf-8", request.Subject)))
+	msg.WriteString(fmt.Sprintf("Message-ID: <%s>\r\n", messageID))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+
+	if len(request.AttachmentFiles) == 0 {
+		writeEmailBody(&msg, request)
+		return msg.String()
+	}
+
+	// Attachments follow the body in a multipart/mixed message
+	boundary := "forgecrud-mixed-" + uuid.NewString()
+	msg.WriteString(fmt.Sprintf("Content-Type: multipart/mixed; boundary=%q\r\n\r\n", boundary))
+	msg.WriteString(fmt.Sprintf("--%s\r\n", boundary))
+	writeEmailBody(&msg, request)
+	msg.WriteString("\r\n")
+	for _, file := range request.AttachmentFiles {
+		msg.WriteString(fmt.Sprintf("--%s\r\n", boundary))
+		msg.WriteString(fmt.Sprintf("Content-Type: %s\r\n", mime.FormatMediaType(file.ContentType, map[string]string{"name": file.FileName})))
+		msg.WriteString("Content-Transfer-Encoding: base64\r\n")
+		msg.WriteString(fmt.Sprintf("Content-Disposition: %s\r\n\r\n", mime.FormatMediaType("attachment", map[string]string{"filename": file.FileName})))
+		writeBase64Lines(&msg, file.Content)
+	}
+	msg.WriteString(fmt.Sprintf("--%s--\r\n", boundary))
+
+	return msg.String()
+}
+
+// writeEmailBody writes the Content-Type header and the body: HTML with a plain text alternative for
+// clients that don't render HTML, or a single HTML or text part
+func writeEmailBody(msg *strings.Builder, request EmailRequest) {
+	if request.IsHTML && request.TextBody != "" {
+		boundary := "forgecrud-" + uuid.NewString()
+		msg.WriteString(fmt.Sprintf("Content-Type: multipart/alternative; boundary=%q\r\n\r\n", boundary))
+		msg.WriteString(fmt.Sprintf("--%s\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s\r\n", boundary, request.TextBody))
+		msg.WriteString(fmt.Sprintf("--%s\r\nContent-Type: text/html; charset=UTF-8\r\n\r\n%s\r\n", boundary, request.Body))
+		msg.WriteString(fmt.Sprintf("--%s--\r\n", boundary))
+		return
+	}
+
+	if request.IsHTML {
+		msg.WriteString("Content-Type: text/html; charset=UTF-8\r\n")
+	} else {
+		msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	}
+
+	msg.WriteString("\r\n")
+	msg.WriteString(request.Body)
+}
+
+// writeBase64Lines writes the content base64 encoded in lines of 76 characters as MIME requires
+func writeBase64Lines(msg *strings.Builder, content []byte) {
+	encoded := base64.StdEncoding.EncodeToString(content)
+	for len(encoded) > 76 {
+		msg.WriteString(encoded[:76])
+		msg.WriteString("\r\n")
+		encoded = encoded[76:]
+	}
+	msg.WriteString(encoded)
+	msg.WriteString("\r\n")
+}
+
+// domainOf returns the domain of an email address
+func domainOf(address string) string {
+	if at := strings.LastIndex(address, "@"); at >= 0 {
+		return address[at+1:]
+	}
+	return "localhost"
+}
@@ -3,117 +3,280 @@ package services
 import (
 	"bytes"
 	"fmt"
-	"html/template"
+	htmltemplate "html/template"
 	"log"
 	"os"
 	"path/filepath"
-	"sync"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
 
 	"forgecrud-backend/shared/config"
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models/notification"
+	"forgecrud-backend/shared/i18n"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
-// TemplateService handles rendering of email templates
+// defaultEmailTemplates are seeded as global templates from the mail template files on startup
+var defaultEmailTemplates = []struct {
+	Key         string
+	Subject     string
+	Description string
+}{
+	{"welcome_verification", `{{t "email.welcome.subject"}}`, "Sent after registration and when a verification email is requested"},
+	{"password_reset", `{{t "email.password_reset.subject"}}`, "Sent with the reset code when a user forgets their password"},
+	{"user_action", "{{.ActionType}}: {{.ResourceName}}", "Sent to the owner of a resource when it is changed by someone else"},
+	{"critical_error", "Critical Error: {{.ErrorType}}", "Sent to administrators when a service fails"},
+	{"system_alert", "System Alert: {{.AlertTypeText}}", "Sent to users about maintenance and incidents"},
+	{"notification_digest", "Your {{.Period}} ForgeCRUD digest: {{.Count}} new notifications", "Sent hourly or daily to users who collect low priority notifications in a digest"},
+}
+
+// RenderedEmail is an email template rendered with its variables
+type RenderedEmail struct {
+	Subject    string    `json:"subject"`
+	HTMLBody   string    `json:"html_body"`
+	TextBody   string    `json:"text_body,omitempty"`
+	TemplateID uuid.UUID `json:"template_id"`
+	Version    int       `json:"version"`
+	Locale     string    `json:"locale"`
+}
+
+// TemplateService renders the email templates stored in the database
 type TemplateService struct {
-	config        *config.Config
-	templateCache map[string]*template.Template
-	templateDir   string
-	templateMutex sync.RWMutex
+	config      *config.Config
+	templateDir string
 }
 
 // NewTemplateService creates a new template service
 func NewTemplateService(cfg *config.Config) *TemplateService {
 	return &TemplateService{
-		config:        cfg,
-		templateCache: make(map[string]*template.Template),
-		templateDir:   "./shared/mail_templates", // Default template location
+		config:      cfg,
+		templateDir: "./shared/mail_templates", // Default templates seeded on startup
 	}
 }
 
-// RenderTemplate renders an email template with provided data
-func (ts *TemplateService) RenderTemplate(templateID string, data map[string]interface{}) (string, error) {
-	// Check if template is in cache
-	ts.templateMutex.RLock()
-	tmpl, exists := ts.templateCache[templateID]
-	ts.templateMutex.RUnlock()
-
-	if !exists {
-		// Load template from file
-		filename := ts.getTemplateFilename(templateID)
-		templatePath := filepath.Join(ts.templateDir, filename)
-
-		// Check if file exists
-		if _, err := os.Stat(templatePath); os.IsNotExist(err) {
-			return "", fmt.Errorf("template file not found: %s", templatePath)
+// SeedDefaults creates the global templates missing from the database from the mail template files.
+// Existing templates are left alone so changes made through the API survive restarts.
+func (ts *TemplateService) SeedDefaults() error {
+	db := database.GetDB()
+
+	for _, def := range defaultEmailTemplates {
+		var count int64
+		if err := db.Model(&notification.EmailTemplate{}).Where("key = ? AND organization_id IS NULL AND locale = ''", def.Key).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			continue
 		}
 
-		// Parse template
-		var err error
-		tmpl, err = template.ParseFiles(templatePath)
+		htmlBody, err := os.ReadFile(filepath.Join(ts.templateDir, def.Key+".html"))
 		if err != nil {
-			return "", fmt.Errorf("failed to parse template %s: %v", templateID, err)
+			log.Printf("⚠️  Email template %s not seeded: %v", def.Key, err)
+			continue
 		}
 
-		// Add to cache
-		ts.templateMutex.Lock()
-		ts.templateCache[templateID] = tmpl
-		ts.templateMutex.Unlock()
-	}
+		variables, err := ParseEmailTemplate(def.Subject, string(htmlBody), "")
+		if err != nil {
+			return fmt.Errorf("default email template %s: %v", def.Key, err)
+		}
 
-	// Render template
-	var rendered bytes.Buffer
-	if err := tmpl.Execute(&rendered, data); err != nil {
-		return "", fmt.Errorf("failed to render template %s: %v", templateID, err)
+		tmpl := notification.EmailTemplate{
+			Key:         def.Key,
+			Subject:     def.Subject,
+			HTMLBody:    string(htmlBody),
+			Variables:   strings.Join(variables, ","),
+			Description: def.Description,
+			Version:     1,
+		}
+		if err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&tmpl).Error; err != nil {
+				return err
+			}
+			snapshot := tmpl.Snapshot()
+			return tx.Create(&snapshot).Error
+		}); err != nil {
+			return err
+		}
+		log.Printf("📧 Seeded email template %s", def.Key)
 	}
+	return nil
+}
 
-	return rendered.String(), nil
+// FindEmailTemplate returns the template used for the key, organization and locale. Templates written
+// for the locale or one of its fallbacks are preferred, then the locale neutral templates that
+// translate their texts with {{t "key"}}. Within a locale the organization's override wins over the
+// global default.
+func FindEmailTemplate(db *gorm.DB, key string, organizationID *uuid.UUID, locale string) (*notification.EmailTemplate, error) {
+	for _, candidate := range append(i18n.Fallbacks(locale), "") {
+		var tmpl notification.EmailTemplate
+		if organizationID != nil {
+			err := db.Where("key = ? AND organization_id = ? AND locale = ?", key, *organizationID, candidate).First(&tmpl).Error
+			if err == nil {
+				return &tmpl, nil
+			}
+			if err != gorm.ErrRecordNotFound {
+				return nil, err
+			}
+		}
+
+		err := db.Where("key = ? AND organization_id IS NULL AND locale = ?", key, candidate).First(&tmpl).Error
+		if err == nil {
+			return &tmpl, nil
+		}
+		if err != gorm.ErrRecordNotFound {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("email template %s not found", key)
 }
 
-// getTemplateFilename maps template ID to filename
-func (ts *TemplateService) getTemplateFilename(templateID string) string {
-	switch templateID {
-	case "welcome_verification":
-		return "welcome_verification.html"
-	case "password_reset":
-		return "password_reset.html"
-	case "critical_error":
-		return "critical_error.html"
-	case "user_action":
-		return "user_action.html"
-	case "system_alert":
-		return "system_alert.html"
-	default:
-		log.Printf("Unknown template ID: %s, using as filename", templateID)
-		return templateID + ".html"
+// RenderTemplate renders the template the organization uses for the key in the locale with the provided data
+func (ts *TemplateService) RenderTemplate(key string, organizationID *uuid.UUID, locale string, data map[string]interface{}) (*RenderedEmail, error) {
+	tmpl, err := FindEmailTemplate(database.GetDB(), key, organizationID, locale)
+	if err != nil {
+		return nil, err
 	}
+	return RenderEmailTemplate(tmpl, data, locale)
 }
 
-// ReloadTemplate forces reload of a specific template
-func (ts *TemplateService) ReloadTemplate(templateID string) error {
-	filename := ts.getTemplateFilename(templateID)
-	templatePath := filepath.Join(ts.templateDir, filename)
+// RenderEmailTemplate renders the subject, HTML and text body of a template, translating the {{t "key"}}
+// texts into the locale. Templates written for a locale are always rendered in it.
+func RenderEmailTemplate(tmpl *notification.EmailTemplate, data map[string]interface{}, locale string) (*RenderedEmail, error) {
+	if tmpl.Locale != "" {
+		locale = tmpl.Locale
+	}
+	locale = i18n.Resolve(locale)
+	funcs := templateFuncs(locale)
 
-	// Check if file exists
-	if _, err := os.Stat(templatePath); os.IsNotExist(err) {
-		return fmt.Errorf("template file not found: %s", templatePath)
+	subject, err := renderText(tmpl.Key+":subject", tmpl.Subject, data, funcs)
+	if err != nil {
+		return nil, err
 	}
 
-	// Parse template
-	tmpl, err := template.ParseFiles(templatePath)
+	htmlTmpl, err := htmltemplate.New(tmpl.Key).Funcs(htmltemplate.FuncMap(funcs)).Parse(tmpl.HTMLBody)
 	if err != nil {
-		return fmt.Errorf("failed to parse template %s: %v", templateID, err)
+		return nil, fmt.Errorf("failed to parse template %s: %v", tmpl.Key, err)
+	}
+	var htmlBody bytes.Buffer
+	if err := htmlTmpl.Execute(&htmlBody, data); err != nil {
+		return nil, fmt.Errorf("failed to render template %s: %v", tmpl.Key, err)
 	}
 
-	// Update cache
-	ts.templateMutex.Lock()
-	ts.templateCache[templateID] = tmpl
-	ts.templateMutex.Unlock()
+	textBody := ""
+	if tmpl.TextBody != "" {
+		if textBody, err = renderText(tmpl.Key+":text", tmpl.TextBody, data, funcs); err != nil {
+			return nil, err
+		}
+	}
 
-	return nil
+	return &RenderedEmail{
+		// Subjects are a single header line
+		Subject:    strings.Join(strings.Fields(subject), " "),
+		HTMLBody:   htmlBody.String(),
+		TextBody:   textBody,
+		TemplateID: tmpl.ID,
+		Version:    tmpl.Version,
+		Locale:     locale,
+	}, nil
 }
 
-// ClearCache clears the template cache
-func (ts *TemplateService) ClearCache() {
-	ts.templateMutex.Lock()
-	ts.templateCache = make(map[string]*template.Template)
-	ts.templateMutex.Unlock()
+// templateFuncs are the functions available in email templates: t translates a catalog key, with
+// optional format arguments, into the locale and locale returns it, e.g. for <html lang="{{locale}}">
+func templateFuncs(locale string) template.FuncMap {
+	return template.FuncMap{
+		"t": func(key string, args ...interface{}) string {
+			return i18n.T(locale, key, args...)
+		},
+		"locale": func() string {
+			return locale
+		},
+	}
+}
+
+func renderText(name, source string, data map[string]interface{}, funcs template.FuncMap) (string, error) {
+	tmpl, err := template.New(name).Funcs(funcs).Parse(source)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse template %s: %v", name, err)
+	}
+	var rendered bytes.Buffer
+	if err := tmpl.Execute(&rendered, data); err != nil {
+		return "", fmt.Errorf("failed to render template %s: %v", name, err)
+	}
+	return rendered.String(), nil
+}
+
+// ParseEmailTemplate checks the syntax of a template and returns the variables it uses
+func ParseEmailTemplate(subject, htmlBody, textBody string) ([]string, error) {
+	funcs := templateFuncs(i18n.DefaultLocale)
+	if _, err := htmltemplate.New("html_body").Funcs(htmltemplate.FuncMap(funcs)).Parse(htmlBody); err != nil {
+		return nil, fmt.Errorf("html_body: %v", err)
+	}
+
+	variables := map[string]bool{}
+	for _, part := range []struct{ name, source string }{
+		{"subject", subject},
+		{"html_body", htmlBody},
+		{"text_body", textBody},
+	} {
+		tmpl, err := template.New(part.name).Funcs(funcs).Parse(part.source)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", part.name, err)
+		}
+		if tmpl.Tree != nil {
+			collectTemplateVariables(tmpl.Tree.Root, variables)
+		}
+	}
+
+	names := make([]string, 0, len(variables))
+	for name := range variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// collectTemplateVariables records the top-level fields a template reads. Inside range and with
+// blocks the dot is another value, so their fields are not variables of the template.
+func collectTemplateVariables(node parse.Node, variables map[string]bool) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			collectTemplateVariables(child, variables)
+		}
+	case *parse.ActionNode:
+		collectPipeVariables(n.Pipe, variables)
+	case *parse.IfNode:
+		collectPipeVariables(n.Pipe, variables)
+		collectTemplateVariables(n.List, variables)
+		collectTemplateVariables(n.ElseList, variables)
+	case *parse.RangeNode:
+		collectPipeVariables(n.Pipe, variables)
+		collectTemplateVariables(n.ElseList, variables)
+	case *parse.WithNode:
+		collectPipeVariables(n.Pipe, variables)
+		collectTemplateVariables(n.ElseList, variables)
+	}
+}
+
+func collectPipeVariables(pipe *parse.PipeNode, variables map[string]bool) {
+	if pipe == nil {
+		return
+	}
+	for _, cmd := range pipe.Cmds {
+		for _, arg := range cmd.Args {
+			switch a := arg.(type) {
+			case *parse.FieldNode:
+				variables[a.Ident[0]] = true
+			case *parse.PipeNode:
+				collectPipeVariables(a, variables)
+			}
+		}
+	}
 }
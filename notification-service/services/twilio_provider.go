@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"forgecrud-backend/shared/config"
+	"forgecrud-backend/shared/database/models/notification"
+)
+
+// TwilioProvider sends SMS through the Twilio Programmable Messaging API
+type TwilioProvider struct {
+	accountSID string
+	authToken  string
+	from       string
+	client     *http.Client
+}
+
+// NewTwilioProvider creates a Twilio provider from the Twilio settings
+func NewTwilioProvider(cfg *config.Config) (*TwilioProvider, error) {
+	if cfg.TwilioAccountSID == "" || cfg.TwilioAuthToken == "" || cfg.TwilioFromNumber == "" {
+		return nil, fmt.Errorf("Twilio configuration is incomplete")
+	}
+
+	return &TwilioProvider{
+		accountSID: cfg.TwilioAccountSID,
+		authToken:  cfg.TwilioAuthToken,
+		from:       cfg.TwilioFromNumber,
+		client:     &http.Client{Timeout: smsSendTimeout},
+	}, nil
+}
+
+// Name returns the provider name
+func (p *TwilioProvider) Name() string {
+	return notification.SMSProviderTwilio
+}
+
+// Send delivers the text and returns the message SID assigned by Twilio
+func (p *TwilioProvider) Send(ctx context.Context, to, text string) (string, error) {
+	form := url.Values{}
+	form.Set("To", to)
+	form.Set("From", p.from)
+	form.Set("Body", text)
+
+	endpoint := fmt.Sprintf("https://api.twilio.com/2010-04-01/Accounts/%s/Messages.json", url.PathEscape(p.accountSID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.SetBasicAuth(p.accountSID, p.authToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		var twilioError struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		}
+		json.Unmarshal(respBody, &twilioError)
+		return "", fmt.Errorf("Twilio returned %d (error %d): %s", resp.StatusCode, twilioError.Code, twilioError.Message)
+	}
+
+	var sent struct {
+		SID string `json:"sid"`
+	}
+	json.Unmarshal(respBody, &sent)
+	return sent.SID, nil
+}
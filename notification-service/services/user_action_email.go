@@ -0,0 +1,133 @@
+package services
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"forgecrud-backend/shared/database/models"
+	"forgecrud-backend/shared/database/models/notification"
+	"forgecrud-backend/shared/i18n"
+	"forgecrud-backend/shared/messaging"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// UserActionTemplate is the generic template of user action emails. An action gets its own email by
+// creating a template keyed user_action.<action>, e.g. user_action.document.deleted.
+const UserActionTemplate = "user_action"
+
+// UserAction is an action on a resource, emailed to the owner of the resource
+type UserAction struct {
+	Action     string     // Event type, e.g. document.deleted
+	ActorID    *uuid.UUID // User who acted, nil for the system
+	OccurredAt time.Time
+	messaging.ActivityData
+}
+
+// UserActionEmail builds the email about an action to the owner of the resource. Callers only
+// describe the action; its name, priority label and layout come from the translation catalogs and
+// the user_action templates, so new actions need no changes in the services reporting them.
+func UserActionEmail(db *gorm.DB, owner *models.User, action UserAction) EmailRequest {
+	locale := i18n.Resolve(owner.Language)
+	priority := userActionPriority(action.Priority)
+	if action.OccurredAt.IsZero() {
+		action.OccurredAt = time.Now().UTC()
+	}
+
+	actorName, actorEmail, actorRole := i18n.T(locale, "email.user_action.system"), "", ""
+	if action.ActorID != nil {
+		var actor models.User
+		if err := db.Preload("Role").First(&actor, "id = ?", *action.ActorID).Error; err == nil {
+			actorName = strings.TrimSpace(actor.FirstName + " " + actor.LastName)
+			actorEmail = actor.Email
+			if actor.RoleID != nil {
+				actorRole = actor.Role.Name
+			}
+		}
+	}
+
+	changes := action.Changes
+	if changes == nil {
+		changes = []messaging.ActivityChange{}
+	}
+	data := action.Data
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+
+	return EmailRequest{
+		To:             []string{owner.Email},
+		TemplateID:     userActionTemplateKey(db, action.Action, owner.OrganizationID),
+		OrganizationID: owner.OrganizationID,
+		Locale:         owner.Language,
+		TemplateVars: map[string]interface{}{
+			"Action":            action.Action,
+			"AdminName":         strings.TrimSpace(owner.FirstName + " " + owner.LastName),
+			"UserName":          actorName,
+			"UserEmail":         actorEmail,
+			"UserRole":          actorRole,
+			"IPAddress":         action.IPAddress,
+			"ActionType":        userActionName(locale, action),
+			"ResourceType":      action.ResourceType,
+			"ResourceID":        action.ResourceID,
+			"ResourceName":      action.ResourceName,
+			"Status":            i18n.T(locale, "email.user_action.completed"),
+			"Priority":          priority,
+			"PriorityText":      i18n.T(locale, "email.priority."+priority),
+			"RequiresAttention": priority == string(notification.NotificationPriorityHigh) || priority == string(notification.NotificationPriorityUrgent),
+			"Description":       action.Description,
+			"Changes":           changes,
+			"Data":              data,
+			"Timestamp":         action.OccurredAt.Format(time.RFC3339),
+		},
+	}
+}
+
+// userActionTemplateKey returns the key of the action's own template when the organization or the
+// global templates have one, otherwise the generic user action template
+func userActionTemplateKey(db *gorm.DB, action string, organizationID *uuid.UUID) string {
+	if action == "" {
+		return UserActionTemplate
+	}
+
+	key := UserActionTemplate + "." + action
+	query := db.Model(&notification.EmailTemplate{}).Where("key = ?", key)
+	if organizationID != nil {
+		query = query.Where("organization_id IS NULL OR organization_id = ?", *organizationID)
+	} else {
+		query = query.Where("organization_id IS NULL")
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		log.Printf("⚠️  Failed to look up email template %s: %v", key, err)
+		return UserActionTemplate
+	}
+	if count == 0 {
+		return UserActionTemplate
+	}
+	return key
+}
+
+// userActionName returns the translated name of the action, the name given by the caller when the
+// catalogs have none
+func userActionName(locale string, action UserAction) string {
+	if name, ok := i18n.Lookup(locale, "email.user_action.actions."+action.Action); ok {
+		return name
+	}
+	if action.ActionType != "" {
+		return action.ActionType
+	}
+	return action.Action
+}
+
+// userActionPriority maps the priority of an action to one of the notification priorities; unknown
+// ones such as "medium" are normal
+func userActionPriority(priority string) string {
+	if p := notification.NotificationPriority(strings.ToLower(priority)); notification.IsValidPriority(p) {
+		return string(p)
+	}
+	return string(notification.NotificationPriorityNormal)
+}
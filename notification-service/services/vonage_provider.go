@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"forgecrud-backend/shared/config"
+	"forgecrud-backend/shared/database/models/notification"
+)
+
+// VonageProvider sends SMS through the Vonage (Nexmo) SMS API
+type VonageProvider struct {
+	apiKey    string
+	apiSecret string
+	from      string
+	client    *http.Client
+}
+
+// NewVonageProvider creates a Vonage provider from the Vonage settings
+func NewVonageProvider(cfg *config.Config) (*VonageProvider, error) {
+	if cfg.VonageAPIKey == "" || cfg.VonageAPISecret == "" || cfg.VonageFrom == "" {
+		return nil, fmt.Errorf("Vonage configuration is incomplete")
+	}
+
+	return &VonageProvider{
+		apiKey:    cfg.VonageAPIKey,
+		apiSecret: cfg.VonageAPISecret,
+		from:      cfg.VonageFrom,
+		client:    &http.Client{Timeout: smsSendTimeout},
+	}, nil
+}
+
+// Name returns the provider name
+func (p *VonageProvider) Name() string {
+	return notification.SMSProviderVonage
+}
+
+// Send delivers the text and returns the message ID assigned by Vonage. Vonage answers 200 for
+// rejected messages too, the outcome is in the status of the message.
+func (p *VonageProvider) Send(ctx context.Context, to, text string) (string, error) {
+	form := url.Values{}
+	form.Set("api_key", p.apiKey)
+	form.Set("api_secret", p.apiSecret)
+	form.Set("from", p.from)
+	// Vonage expects the number without the leading plus
+	form.Set("to", strings.TrimPrefix(to, "+"))
+	form.Set("text", text)
+	// Texts are translated and may contain characters outside the GSM alphabet
+	form.Set("type", "unicode")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://rest.nexmo.com/sms/json", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Vonage returned %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	var sent struct {
+		Messages []struct {
+			Status    string `json:"status"`
+			MessageID string `json:"message-id"`
+			ErrorText string `json:"error-text"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal(respBody, &sent); err != nil || len(sent.Messages) == 0 {
+		return "", fmt.Errorf("invalid Vonage response: %s", strings.TrimSpace(string(respBody)))
+	}
+	// Long texts are split into several messages, which all have to be accepted
+	for _, message := range sent.Messages {
+		if message.Status != "0" {
+			return "", fmt.Errorf("Vonage status %s: %s", message.Status, message.ErrorText)
+		}
+	}
+	return sent.Messages[0].MessageID, nil
+}
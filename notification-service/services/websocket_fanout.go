@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"forgecrud-backend/shared/config"
+	"forgecrud-backend/shared/database/models/notification"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const fanoutPublishTimeout = 5 * time.Second
+
+// WebSocketFanout relays WebSocket messages between notification-service instances over Redis
+// pub/sub. Every instance receives every message and delivers it to the connections it holds, so a
+// user is reached whichever instance they are connected to.
+type WebSocketFanout struct {
+	client       *redis.Client
+	subscription *redis.PubSub
+	channel      string
+}
+
+// fanoutEnvelope is a relayed message for a user, or a broadcast to an organization or, with neither,
+// to everyone
+type fanoutEnvelope struct {
+	UserID         string                         `json:"user_id,omitempty"`
+	OrganizationID string                         `json:"organization_id,omitempty"`
+	Message        *notification.WebSocketMessage `json:"message"`
+}
+
+// EnableFanout relays the manager's messages through the configured fan-out driver ("redis" or
+// "none"). Without fan-out, messages only reach users connected to this instance.
+func (wsm *WebSocketManager) EnableFanout(cfg *config.Config) error {
+	if cfg.WebSocketFanoutDriver != "redis" {
+		log.Printf("🔌 WebSocket fan-out disabled, delivering to local connections only")
+		return nil
+	}
+
+	client := redis.NewClient(&redis.Options{
+		Addr:     fmt.Sprintf("%s:%s", cfg.RedisHost, cfg.RedisPort),
+		Password: cfg.RedisPassword,
+		DB:       cfg.RedisDB,
+	})
+
+	ctx := context.Background()
+	subscription := client.Subscribe(ctx, cfg.WebSocketFanoutChannel)
+	// Wait for the subscription so messages published right after startup are not missed
+	if _, err := subscription.Receive(ctx); err != nil {
+		subscription.Close()
+		client.Close()
+		return fmt.Errorf("failed to subscribe to WebSocket fan-out channel: %v", err)
+	}
+
+	wsm.mutex.Lock()
+	wsm.fanout = &WebSocketFanout{client: client, subscription: subscription, channel: cfg.WebSocketFanoutChannel}
+	wsm.mutex.Unlock()
+
+	go wsm.relay(subscription)
+
+	log.Printf("✅ WebSocket fan-out enabled (channel: %s)", cfg.WebSocketFanoutChannel)
+	return nil
+}
+
+// getFanout returns the fan-out, nil when delivering locally only
+func (wsm *WebSocketManager) getFanout() *WebSocketFanout {
+	wsm.mutex.RLock()
+	defer wsm.mutex.RUnlock()
+	return wsm.fanout
+}
+
+// relay delivers the messages published by any instance to the connections of this instance.
+// The subscription reconnects by itself when the connection to Redis drops.
+func (wsm *WebSocketManager) relay(subscription *redis.PubSub) {
+	for published := range subscription.Channel() {
+		var envelope fanoutEnvelope
+		if err := json.Unmarshal([]byte(published.Payload), &envelope); err != nil || envelope.Message == nil {
+			log.Printf("⚠️  Invalid WebSocket fan-out message: %v", err)
+			continue
+		}
+
+		if envelope.UserID == "" {
+			wsm.queueBroadcast(&envelope)
+			continue
+		}
+		// Only the instance holding the user's connection delivers the message
+		if wsm.isConnected(envelope.UserID) {
+			wsm.deliverToUser(envelope.UserID, envelope.Message)
+		}
+	}
+}
+
+// publish sends the message to every instance, including this one
+func (f *WebSocketFanout) publish(envelope fanoutEnvelope) error {
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), fanoutPublishTimeout)
+	defer cancel()
+	return f.client.Publish(ctx, f.channel, payload).Err()
+}
+
+// Ping checks the connection to Redis of the fan-out; delivering locally only needs no connection
+func (wsm *WebSocketManager) Ping(ctx context.Context) error {
+	fanout := wsm.getFanout()
+	if fanout == nil {
+		return nil
+	}
+	return fanout.client.Ping(ctx).Err()
+}
+
+// close stops relaying and closes the connection to Redis
+func (f *WebSocketFanout) close() error {
+	f.subscription.Close()
+	return f.client.Close()
+}
+
+// isConnected reports whether the user has a connection on this instance
+func (wsm *WebSocketManager) isConnected(userID string) bool {
+	wsm.mutex.RLock()
+	defer wsm.mutex.RUnlock()
+	return len(wsm.clients[userID]) > 0
+}
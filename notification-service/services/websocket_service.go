@@ -4,30 +4,146 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"sort"
 	"sync"
+	"time"
 
 	"forgecrud-backend/shared/config"
 	"forgecrud-backend/shared/database/models/notification"
+	"forgecrud-backend/shared/revocation"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 )
 
+// Channels a WebSocket connection can subscribe to
+const (
+	WebSocketChannelNotifications = "notifications" // The user's own notifications
+	WebSocketChannelOrganization  = "organization"  // Broadcasts to the user's organization
+	WebSocketChannelSystem        = "system"        // Broadcasts to every user
+)
+
+// WebSocketSubprotocol is the subprotocol browsers offer with the access token, e.g.
+// new WebSocket(url, ["bearer", token]), since they can't set an Authorization header
+const WebSocketSubprotocol = "bearer"
+
+const (
+	webSocketWriteTimeout = 10 * time.Second
+	webSocketReadLimit    = 4096
+)
+
+// defaultWebSocketChannels are subscribed when a connection is established
+var defaultWebSocketChannels = []string{WebSocketChannelNotifications, WebSocketChannelSystem}
+
 // WebSocketManager handles all WebSocket connections
 type WebSocketManager struct {
-	clients    map[string]*websocket.Conn // userID -> connection
+	clients    map[string]map[*ClientConnection]bool // userID -> WebSocket and event stream connections
 	mutex      sync.RWMutex
 	upgrader   websocket.Upgrader
 	register   chan *ClientConnection
 	unregister chan *ClientConnection
-	broadcast  chan *notification.WebSocketMessage
+	broadcast  chan *fanoutEnvelope
+	fanout     *WebSocketFanout // Relays messages to the other instances, nil when delivering locally only
+}
+
+// clientTransport writes messages to a connected client, a *websocket.Conn or an event stream
+type clientTransport interface {
+	WriteJSON(v interface{}) error
+	Close() error
 }
 
-// ClientConnection represents a client WebSocket connection
+// ClientConnection represents an authenticated client connection and its subscriptions
 type ClientConnection struct {
-	UserID     string
-	Connection *websocket.Conn
+	UserID         string
+	OrganizationID string
+	SessionID      string // Session of the access token, empty for tokens without one
+	Connection     clientTransport
+
+	mutex         sync.Mutex // Serializes writes, which gorilla/websocket doesn't allow concurrently
+	subscriptions map[string]SubscriptionFilter
+}
+
+// SubscriptionFilter narrows a channel subscription down to notifications of some categories and
+// priorities; an empty list matches every category or priority. Messages without a category or
+// priority, like connection messages, are not filtered.
+type SubscriptionFilter struct {
+	Categories []notification.NotificationCategory `json:"categories,omitempty"`
+	Priorities []notification.NotificationPriority `json:"priorities,omitempty"`
+}
+
+// Validate checks that the filter only names known categories and priorities
+func (f SubscriptionFilter) Validate() error {
+	for _, category := range f.Categories {
+		if !notification.IsValidCategory(category) {
+			return fmt.Errorf("unknown category %q", category)
+		}
+	}
+	for _, priority := range f.Priorities {
+		if !notification.IsValidPriority(priority) {
+			return fmt.Errorf("unknown priority %q", priority)
+		}
+	}
+	return nil
+}
+
+// Matches reports whether the message passes the filter
+func (f SubscriptionFilter) Matches(message *notification.WebSocketMessage) bool {
+	if len(f.Categories) > 0 && message.Category != "" {
+		found := false
+		for _, category := range f.Categories {
+			found = found || category == message.Category
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(f.Priorities) > 0 && message.Priority != "" {
+		found := false
+		for _, priority := range f.Priorities {
+			found = found || priority == message.Priority
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// newClientConnection creates a connection subscribed to the channels with the filter
+func newClientConnection(userID, organizationID, sessionID string, transport clientTransport, channels []string, filter SubscriptionFilter) *ClientConnection {
+	client := &ClientConnection{
+		UserID:         userID,
+		OrganizationID: organizationID,
+		SessionID:      sessionID,
+		Connection:     transport,
+		subscriptions:  map[string]SubscriptionFilter{},
+	}
+	for _, channel := range channels {
+		client.subscriptions[channel] = filter
+	}
+	return client
+}
+
+// webSocketRequest is a message sent by the client. A subscribe request with categories or
+// priorities replaces the filter of the channel.
+type webSocketRequest struct {
+	Type      string `json:"type"` // ping, subscribe or unsubscribe
+	RequestID string `json:"request_id"`
+	Channel   string `json:"channel"`
+	SubscriptionFilter
+}
+
+// webSocketReply acknowledges or rejects a client request
+type webSocketReply struct {
+	Type      string              `json:"type"` // ack or error
+	RequestID string              `json:"request_id,omitempty"`
+	Action    string              `json:"action,omitempty"`
+	Channel   string              `json:"channel,omitempty"`
+	Channels  []string            `json:"channels,omitempty"` // Subscriptions after the request
+	Filter    *SubscriptionFilter `json:"filter,omitempty"`   // Filter of the subscribed channel
+	Error     string              `json:"error,omitempty"`
+	Timestamp time.Time           `json:"timestamp"`
 }
 
 // Global WebSocket manager instance
@@ -38,7 +154,7 @@ var once sync.Once
 func GetWebSocketManager() *WebSocketManager {
 	once.Do(func() {
 		wsManager = &WebSocketManager{
-			clients: make(map[string]*websocket.Conn),
+			clients: make(map[string]map[*ClientConnection]bool),
 			upgrader: websocket.Upgrader{
 				CheckOrigin: func(r *http.Request) bool {
 					origin := r.Header.Get("Origin")
@@ -57,10 +173,11 @@ func GetWebSocketManager() *WebSocketManager {
 					log.Printf("🚫 WebSocket connection rejected from origin: %s", origin)
 					return false
 				},
+				Subprotocols: []string{WebSocketSubprotocol},
 			},
 			register:   make(chan *ClientConnection, 100),
 			unregister: make(chan *ClientConnection, 100),
-			broadcast:  make(chan *notification.WebSocketMessage, 1000),
+			broadcast:  make(chan *fanoutEnvelope, 1000),
 		}
 		go wsManager.run()
 	})
@@ -77,8 +194,8 @@ func (wsm *WebSocketManager) run() {
 		case client := <-wsm.unregister:
 			wsm.unregisterClient(client)
 
-		case message := <-wsm.broadcast:
-			wsm.broadcastMessage(message)
+		case envelope := <-wsm.broadcast:
+			wsm.broadcastMessage(envelope)
 		}
 	}
 }
@@ -86,26 +203,27 @@ func (wsm *WebSocketManager) run() {
 // registerClient adds a new client connection
 func (wsm *WebSocketManager) registerClient(client *ClientConnection) {
 	wsm.mutex.Lock()
-	defer wsm.mutex.Unlock()
-
-	// Close existing connection if any
-	if existingConn, exists := wsm.clients[client.UserID]; exists {
-		existingConn.Close()
+	// A user may be connected from several tabs and devices
+	if wsm.clients[client.UserID] == nil {
+		wsm.clients[client.UserID] = map[*ClientConnection]bool{}
 	}
+	wsm.clients[client.UserID][client] = true
+	total := len(wsm.clients)
+	wsm.mutex.Unlock()
 
-	wsm.clients[client.UserID] = client.Connection
-	log.Printf("🔌 WebSocket client connected: %s (Total: %d)", client.UserID, len(wsm.clients))
+	log.Printf("🔌 Real-time client connected: %s (Users: %d)", client.UserID, total)
 
 	// Send welcome message
 	welcomeMsg := &notification.WebSocketMessage{
 		Type:      "connection",
 		Level:     notification.NotificationLevelInfo,
 		Title:     "🔌 Connected",
-		Message:   "WebSocket connection established",
+		Message:   "Real-time connection established",
 		Timestamp: notification.GetCurrentTime(),
 		UserID:    parseUUID(client.UserID),
+		Data:      gin.H{"channels": client.Channels()},
 	}
-	wsm.sendToClient(client.UserID, welcomeMsg)
+	wsm.sendToClient(client, welcomeMsg)
 }
 
 // unregisterClient removes a client connection
@@ -113,107 +231,241 @@ func (wsm *WebSocketManager) unregisterClient(client *ClientConnection) {
 	wsm.mutex.Lock()
 	defer wsm.mutex.Unlock()
 
-	if _, exists := wsm.clients[client.UserID]; exists {
-		delete(wsm.clients, client.UserID)
+	client.Connection.Close()
+	// Failed writes may unregister a connection more than once
+	if connections := wsm.clients[client.UserID]; connections[client] {
+		delete(connections, client)
+		if len(connections) == 0 {
+			delete(wsm.clients, client.UserID)
+		}
+		log.Printf("🔌 Real-time client disconnected: %s (Users: %d)", client.UserID, len(wsm.clients))
+	}
+}
+
+// CloseConnections disconnects every WebSocket and event stream client of this instance when the
+// service shuts down, so clients reconnect to another instance instead of holding the shutdown.
+// WebSocket clients are told the server is going away.
+func (wsm *WebSocketManager) CloseConnections() error {
+	wsm.mutex.RLock()
+	clients := make([]*ClientConnection, 0, len(wsm.clients))
+	for _, connections := range wsm.clients {
+		for client := range connections {
+			clients = append(clients, client)
+		}
+	}
+	wsm.mutex.RUnlock()
+
+	closeMessage := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down")
+	for _, client := range clients {
+		if conn, ok := client.Connection.(*websocket.Conn); ok {
+			client.mutex.Lock()
+			conn.WriteControl(websocket.CloseMessage, closeMessage, time.Now().Add(webSocketWriteTimeout))
+			client.mutex.Unlock()
+		}
 		client.Connection.Close()
-		log.Printf("🔌 WebSocket client disconnected: %s (Total: %d)", client.UserID, len(wsm.clients))
 	}
+
+	log.Printf("🔌 Closed %d real-time connections for shutdown", len(clients))
+	return nil
 }
 
-// broadcastMessage sends message to all connected clients
-func (wsm *WebSocketManager) broadcastMessage(message *notification.WebSocketMessage) {
+// CloseSessions disconnects the WebSocket and event stream clients of revoked sessions, or of every
+// session of the user. WebSocket clients are told their session was terminated, so they sign in again
+// instead of reconnecting with the revoked token.
+func (wsm *WebSocketManager) CloseSessions(r revocation.Revocation) {
+	revoked := map[string]bool{}
+	for _, sessionID := range r.SessionIDs {
+		revoked[sessionID] = true
+	}
+
 	wsm.mutex.RLock()
-	defer wsm.mutex.RUnlock()
+	var clients []*ClientConnection
+	for client := range wsm.clients[r.UserID] {
+		if r.All() || revoked[client.SessionID] {
+			clients = append(clients, client)
+		}
+	}
+	wsm.mutex.RUnlock()
+
+	closeMessage := websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "session terminated")
+	for _, client := range clients {
+		if conn, ok := client.Connection.(*websocket.Conn); ok {
+			client.mutex.Lock()
+			conn.WriteControl(websocket.CloseMessage, closeMessage, time.Now().Add(webSocketWriteTimeout))
+			client.mutex.Unlock()
+		}
+		client.Connection.Close()
+	}
+
+	if len(clients) > 0 {
+		log.Printf("🔑 Closed %d real-time connections of terminated sessions of user %s", len(clients), r.UserID)
+	}
+}
+
+// Close stops relaying messages between instances
+func (wsm *WebSocketManager) Close() error {
+	wsm.mutex.Lock()
+	fanout := wsm.fanout
+	wsm.fanout = nil
+	wsm.mutex.Unlock()
+
+	if fanout == nil {
+		return nil
+	}
+	return fanout.close()
+}
+
+// broadcastMessage sends message to the connected clients subscribed to its channel
+func (wsm *WebSocketManager) broadcastMessage(envelope *fanoutEnvelope) {
+	channel := WebSocketChannelSystem
+	if envelope.OrganizationID != "" {
+		channel = WebSocketChannelOrganization
+	}
+	message := *envelope.Message
+	message.Channel = channel
+
+	wsm.mutex.RLock()
+	recipients := make([]*ClientConnection, 0, len(wsm.clients))
+	for _, connections := range wsm.clients {
+		for client := range connections {
+			if envelope.OrganizationID != "" && client.OrganizationID != envelope.OrganizationID {
+				continue
+			}
+			if client.Accepts(channel, &message) {
+				recipients = append(recipients, client)
+			}
+		}
+	}
+	wsm.mutex.RUnlock()
 
 	successCount := 0
 	failCount := 0
-
-	for userID, conn := range wsm.clients {
-		err := conn.WriteJSON(message)
-		if err != nil {
-			log.Printf("❌ Failed to send message to user %s: %v", userID, err)
-			// Remove failed connection
-			go func(uid string, connection *websocket.Conn) {
-				wsm.unregister <- &ClientConnection{UserID: uid, Connection: connection}
-			}(userID, conn)
+	for _, client := range recipients {
+		if err := wsm.sendToClient(client, &message); err != nil {
 			failCount++
 		} else {
 			successCount++
 		}
 	}
 
-	log.Printf("📡 Broadcast sent: %d success, %d failed (Message: %s)",
-		successCount, failCount, message.Message)
+	log.Printf("📡 Broadcast sent on %s: %d success, %d failed (Message: %s)",
+		channel, successCount, failCount, message.Message)
 }
 
-// SendToUser sends message to specific user
+// SendToUser sends message to specific user. With fan-out enabled the message is published to every
+// instance and delivered by the one holding the user's connection, so no error is returned when the
+// user is not connected.
 func (wsm *WebSocketManager) SendToUser(userID string, message *notification.WebSocketMessage) error {
-	wsm.mutex.RLock()
-	_, exists := wsm.clients[userID]
-	wsm.mutex.RUnlock()
-
-	if !exists {
-		return fmt.Errorf("user %s not connected", userID)
+	if fanout := wsm.getFanout(); fanout != nil {
+		err := fanout.publish(fanoutEnvelope{UserID: userID, Message: message})
+		if err == nil {
+			return nil
+		}
+		log.Printf("⚠️  WebSocket fan-out failed, delivering locally: %v", err)
 	}
-
-	return wsm.sendToClient(userID, message)
+	return wsm.deliverToUser(userID, message)
 }
 
-// sendToClient sends message to specific client connection
-func (wsm *WebSocketManager) sendToClient(userID string, message *notification.WebSocketMessage) error {
+// deliverToUser sends message to the user's connections on this instance that are subscribed to
+// the user's notifications
+func (wsm *WebSocketManager) deliverToUser(userID string, message *notification.WebSocketMessage) error {
 	wsm.mutex.RLock()
-	conn, exists := wsm.clients[userID]
+	connections := make([]*ClientConnection, 0, len(wsm.clients[userID]))
+	for client := range wsm.clients[userID] {
+		connections = append(connections, client)
+	}
 	wsm.mutex.RUnlock()
 
-	if !exists {
+	if len(connections) == 0 {
 		return fmt.Errorf("user %s not connected", userID)
 	}
 
-	err := conn.WriteJSON(message)
-	if err != nil {
-		log.Printf("❌ Failed to send message to user %s: %v", userID, err)
+	delivered := *message
+	delivered.Channel = WebSocketChannelNotifications
+
+	subscribed := false
+	var err error
+	for _, client := range connections {
+		if !client.Subscribed(WebSocketChannelNotifications) {
+			continue
+		}
+		subscribed = true
+		if !client.Accepts(WebSocketChannelNotifications, &delivered) {
+			continue
+		}
+		if sendErr := wsm.sendToClient(client, &delivered); sendErr != nil {
+			err = sendErr
+		}
+	}
+	if !subscribed {
+		return fmt.Errorf("user %s is not subscribed to %s", userID, WebSocketChannelNotifications)
+	}
+	return err
+}
+
+// sendToClient writes a message to the client, dropping the connection when it fails
+func (wsm *WebSocketManager) sendToClient(client *ClientConnection, message interface{}) error {
+	if err := client.write(message); err != nil {
+		log.Printf("❌ Failed to send message to user %s: %v", client.UserID, err)
 		// Remove failed connection
 		go func() {
-			wsm.unregister <- &ClientConnection{UserID: userID, Connection: conn}
+			wsm.unregister <- client
 		}()
 		return err
 	}
 
-	log.Printf("📱 Message sent to user %s: %s", userID, message.Message)
+	if msg, ok := message.(*notification.WebSocketMessage); ok {
+		log.Printf("📱 Message sent to user %s: %s", client.UserID, msg.Message)
+	}
 	return nil
 }
 
-// BroadcastToAll sends message to all connected clients
+// BroadcastToAll sends message to the clients of every instance subscribed to system broadcasts
 func (wsm *WebSocketManager) BroadcastToAll(message *notification.WebSocketMessage) {
+	wsm.publishBroadcast(&fanoutEnvelope{Message: message})
+}
+
+// BroadcastToOrganization sends message to the clients of the organization's users that are
+// subscribed to organization broadcasts, on every instance
+func (wsm *WebSocketManager) BroadcastToOrganization(organizationID string, message *notification.WebSocketMessage) {
+	wsm.publishBroadcast(&fanoutEnvelope{OrganizationID: organizationID, Message: message})
+}
+
+func (wsm *WebSocketManager) publishBroadcast(envelope *fanoutEnvelope) {
+	if fanout := wsm.getFanout(); fanout != nil {
+		err := fanout.publish(*envelope)
+		if err == nil {
+			return
+		}
+		log.Printf("⚠️  WebSocket fan-out failed, broadcasting locally: %v", err)
+	}
+	wsm.queueBroadcast(envelope)
+}
+
+// queueBroadcast queues a broadcast for the clients connected to this instance
+func (wsm *WebSocketManager) queueBroadcast(envelope *fanoutEnvelope) {
 	select {
-	case wsm.broadcast <- message:
+	case wsm.broadcast <- envelope:
 		// Message queued successfully
 	default:
-		log.Printf("⚠️ Broadcast queue full, dropping message: %s", message.Message)
+		log.Printf("⚠️ Broadcast queue full, dropping message: %s", envelope.Message.Message)
 	}
 }
 
-// HandleWebSocketConnection upgrades HTTP connection to WebSocket
-func (wsm *WebSocketManager) HandleWebSocketConnection(c *gin.Context) {
-	userID := c.Param("user_id")
-	if userID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "User ID required"})
-		return
-	}
-
+// HandleWebSocketConnection upgrades HTTP connection to WebSocket for an authenticated user and
+// serves the client's ping, subscribe and unsubscribe requests until it disconnects
+func (wsm *WebSocketManager) HandleWebSocketConnection(c *gin.Context, userID, organizationID, sessionID string) {
 	// Upgrade HTTP connection to WebSocket
 	conn, err := wsm.upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
+		// The upgrader has already written the error response
 		log.Printf("❌ Failed to upgrade WebSocket: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upgrade connection"})
 		return
 	}
+	conn.SetReadLimit(webSocketReadLimit)
 
 	// Register client
-	client := &ClientConnection{
-		UserID:     userID,
-		Connection: conn,
-	}
+	client := newClientConnection(userID, organizationID, sessionID, conn, defaultWebSocketChannels, SubscriptionFilter{})
 
 	wsm.register <- client
 
@@ -224,8 +476,8 @@ func (wsm *WebSocketManager) HandleWebSocketConnection(c *gin.Context) {
 
 	// Keep connection alive and handle incoming messages
 	for {
-		var message map[string]interface{}
-		err := conn.ReadJSON(&message)
+		var request webSocketRequest
+		err := conn.ReadJSON(&request)
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
 				log.Printf("❌ WebSocket error for user %s: %v", userID, err)
@@ -233,23 +485,120 @@ func (wsm *WebSocketManager) HandleWebSocketConnection(c *gin.Context) {
 			break
 		}
 
-		// Handle incoming messages (ping, pong, etc.)
-		if msgType, ok := message["type"].(string); ok {
-			switch msgType {
-			case "ping":
-				pongMsg := &notification.WebSocketMessage{
-					Type:      "pong",
-					Level:     notification.NotificationLevelInfo,
-					Message:   "pong",
-					Timestamp: notification.GetCurrentTime(),
-					UserID:    parseUUID(userID),
-				}
-				wsm.sendToClient(userID, pongMsg)
+		switch request.Type {
+		case "ping":
+			pongMsg := &notification.WebSocketMessage{
+				Type:      "pong",
+				Level:     notification.NotificationLevelInfo,
+				Message:   "pong",
+				Timestamp: notification.GetCurrentTime(),
+				UserID:    parseUUID(userID),
 			}
+			wsm.sendToClient(client, pongMsg)
+		case "subscribe", "unsubscribe":
+			wsm.sendToClient(client, client.handleSubscription(request))
+		default:
+			wsm.sendToClient(client, &webSocketReply{
+				Type:      "error",
+				RequestID: request.RequestID,
+				Error:     fmt.Sprintf("unknown message type %q", request.Type),
+				Timestamp: notification.GetCurrentTime(),
+			})
 		}
 	}
 }
 
+// ValidateWebSocketChannel checks that a user of the organization (empty for none) can subscribe to the channel
+func ValidateWebSocketChannel(channel, organizationID string) error {
+	switch channel {
+	case WebSocketChannelNotifications, WebSocketChannelSystem:
+		return nil
+	case WebSocketChannelOrganization:
+		if organizationID == "" {
+			return fmt.Errorf("user does not belong to an organization")
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown channel %q, channels: %s, %s, %s", channel,
+		WebSocketChannelNotifications, WebSocketChannelOrganization, WebSocketChannelSystem)
+}
+
+// DefaultWebSocketChannels returns the channels connections are subscribed to when they are established
+func DefaultWebSocketChannels() []string {
+	return append([]string(nil), defaultWebSocketChannels...)
+}
+
+// handleSubscription subscribes or unsubscribes the client and returns the reply
+func (cc *ClientConnection) handleSubscription(request webSocketRequest) *webSocketReply {
+	reply := &webSocketReply{
+		Type:      "ack",
+		RequestID: request.RequestID,
+		Action:    request.Type,
+		Channel:   request.Channel,
+		Timestamp: notification.GetCurrentTime(),
+	}
+
+	err := ValidateWebSocketChannel(request.Channel, cc.OrganizationID)
+	if err == nil && request.Type == "subscribe" {
+		err = request.SubscriptionFilter.Validate()
+	}
+	if err != nil {
+		reply.Type = "error"
+		reply.Error = err.Error()
+		return reply
+	}
+
+	cc.mutex.Lock()
+	if request.Type == "subscribe" {
+		cc.subscriptions[request.Channel] = request.SubscriptionFilter
+		reply.Filter = &request.SubscriptionFilter
+	} else {
+		delete(cc.subscriptions, request.Channel)
+	}
+	cc.mutex.Unlock()
+
+	reply.Channels = cc.Channels()
+	return reply
+}
+
+// Subscribed reports whether the client receives messages of the channel
+func (cc *ClientConnection) Subscribed(channel string) bool {
+	cc.mutex.Lock()
+	defer cc.mutex.Unlock()
+	_, subscribed := cc.subscriptions[channel]
+	return subscribed
+}
+
+// Accepts reports whether the client is subscribed to the channel with a filter the message passes
+func (cc *ClientConnection) Accepts(channel string, message *notification.WebSocketMessage) bool {
+	cc.mutex.Lock()
+	filter, subscribed := cc.subscriptions[channel]
+	cc.mutex.Unlock()
+	return subscribed && filter.Matches(message)
+}
+
+// Channels returns the channels the client is subscribed to
+func (cc *ClientConnection) Channels() []string {
+	cc.mutex.Lock()
+	defer cc.mutex.Unlock()
+
+	channels := make([]string, 0, len(cc.subscriptions))
+	for channel := range cc.subscriptions {
+		channels = append(channels, channel)
+	}
+	sort.Strings(channels)
+	return channels
+}
+
+func (cc *ClientConnection) write(message interface{}) error {
+	cc.mutex.Lock()
+	defer cc.mutex.Unlock()
+	if conn, ok := cc.Connection.(*websocket.Conn); ok {
+		conn.SetWriteDeadline(time.Now().Add(webSocketWriteTimeout))
+	}
+	return cc.Connection.WriteJSON(message)
+}
+
 // GetConnectedUsers returns list of connected user IDs
 func (wsm *WebSocketManager) GetConnectedUsers() []string {
 	wsm.mutex.RLock()
@@ -266,7 +615,12 @@ func (wsm *WebSocketManager) GetConnectedUsers() []string {
 func (wsm *WebSocketManager) GetConnectionCount() int {
 	wsm.mutex.RLock()
 	defer wsm.mutex.RUnlock()
-	return len(wsm.clients)
+
+	count := 0
+	for _, connections := range wsm.clients {
+		count += len(connections)
+	}
+	return count
 }
 
 // parseUUID safely parses UUID string
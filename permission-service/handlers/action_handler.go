@@ -4,9 +4,11 @@ import (
 	"net/http"
 	"strings"
 
+	"forgecrud-backend/shared/apperrors"
 	"forgecrud-backend/shared/database"
 	"forgecrud-backend/shared/database/models"
 	"forgecrud-backend/shared/utils/query"
+	"forgecrud-backend/shared/utils/validation"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -67,10 +69,7 @@ type SingleActionResponse struct {
 func CreateAction(c *gin.Context) {
 	var req CreateActionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request body",
-			"details": err.Error(),
-		})
+		apperrors.Respond(c, validation.Error(err, "Invalid request body"))
 		return
 	}
 
@@ -81,9 +80,7 @@ func CreateAction(c *gin.Context) {
 	// Validate slug uniqueness
 	var existingAction models.Action
 	if err := database.DB.Where("slug = ?", req.Slug).First(&existingAction).Error; err == nil {
-		c.JSON(http.StatusConflict, gin.H{
-			"error": "Action with this slug already exists",
-		})
+		apperrors.Respond(c, apperrors.Conflict("Action with this slug already exists"))
 		return
 	}
 
@@ -94,10 +91,7 @@ func CreateAction(c *gin.Context) {
 	}
 
 	if err := database.DB.Create(&action).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to create action",
-			"details": err.Error(),
-		})
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to create action"))
 		return
 	}
 
@@ -167,10 +161,7 @@ func GetActions(c *gin.Context) {
 	// Get actions
 	var actions []models.Action
 	if err := finalQuery.Find(&actions).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to fetch actions",
-			"details": err.Error(),
-		})
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to fetch actions"))
 		return
 	}
 
@@ -203,17 +194,13 @@ func GetAction(c *gin.Context) {
 
 	actionID, err := uuid.Parse(id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid action ID format",
-		})
+		apperrors.Respond(c, apperrors.BadRequest("Invalid action ID format"))
 		return
 	}
 
 	var action models.Action
 	if err := database.DB.First(&action, actionID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "Action not found",
-		})
+		apperrors.Respond(c, apperrors.NotFound("Action not found"))
 		return
 	}
 
@@ -242,26 +229,19 @@ func UpdateAction(c *gin.Context) {
 
 	actionID, err := uuid.Parse(id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid action ID format",
-		})
+		apperrors.Respond(c, apperrors.BadRequest("Invalid action ID format"))
 		return
 	}
 
 	var req UpdateActionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request body",
-			"details": err.Error(),
-		})
+		apperrors.Respond(c, validation.Error(err, "Invalid request body"))
 		return
 	}
 
 	var action models.Action
 	if err := database.DB.First(&action, actionID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "Action not found",
-		})
+		apperrors.Respond(c, apperrors.NotFound("Action not found"))
 		return
 	}
 
@@ -269,10 +249,7 @@ func UpdateAction(c *gin.Context) {
 	if action.IsSystem {
 		// System actions can only have their description updated
 		if req.Name != "" || req.Slug != "" {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error":   "Cannot modify system action",
-				"message": "System actions name and slug cannot be modified. Only description can be updated.",
-			})
+			apperrors.Respond(c, apperrors.Forbidden("Cannot modify system action").WithDetails("System actions name and slug cannot be modified. Only description can be updated."))
 			return
 		}
 	}
@@ -283,9 +260,7 @@ func UpdateAction(c *gin.Context) {
 	if req.Slug != "" {
 		var existingAction models.Action
 		if err := database.DB.Where("slug = ? AND id != ?", req.Slug, actionID).First(&existingAction).Error; err == nil {
-			c.JSON(http.StatusConflict, gin.H{
-				"error": "Action with this slug already exists",
-			})
+			apperrors.Respond(c, apperrors.Conflict("Action with this slug already exists"))
 			return
 		}
 		action.Slug = req.Slug
@@ -295,10 +270,7 @@ func UpdateAction(c *gin.Context) {
 	}
 
 	if err := database.DB.Save(&action).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to update action",
-			"details": err.Error(),
-		})
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to update action"))
 		return
 	}
 
@@ -327,45 +299,31 @@ func DeleteAction(c *gin.Context) {
 
 	actionID, err := uuid.Parse(id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid action ID format",
-		})
+		apperrors.Respond(c, apperrors.BadRequest("Invalid action ID format"))
 		return
 	}
 
 	var action models.Action
 	if err := database.DB.First(&action, actionID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "Action not found",
-		})
+		apperrors.Respond(c, apperrors.NotFound("Action not found"))
 		return
 	}
 
 	// Check if it's a system action
 	if action.IsSystem {
-		c.JSON(http.StatusForbidden, gin.H{
-			"error":   "Cannot delete system action",
-			"message": "System actions are protected and cannot be deleted",
-		})
+		apperrors.Respond(c, apperrors.Forbidden("Cannot delete system action").WithDetails("System actions are protected and cannot be deleted"))
 		return
 	}
 
 	var permissionActionCount int64
 	database.DB.Model(&models.PermissionAction{}).Where("action_id = ?", actionID).Count(&permissionActionCount)
 	if permissionActionCount > 0 {
-		c.JSON(http.StatusConflict, gin.H{
-			"error":   "Cannot delete action",
-			"message": "Action is being used in permission actions",
-			"count":   permissionActionCount,
-		})
+		apperrors.Respond(c, apperrors.Conflict("Cannot delete action").WithDetails("Action is being used in permission actions").With("count", permissionActionCount))
 		return
 	}
 
 	if err := database.DB.Delete(&action).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to delete action",
-			"details": err.Error(),
-		})
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to delete action"))
 		return
 	}
 
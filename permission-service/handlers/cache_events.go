@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"context"
+	"log"
+
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models"
+	"forgecrud-backend/shared/messaging"
+	"forgecrud-backend/shared/utils/cache"
+
+	"github.com/google/uuid"
+)
+
+// CacheInvalidationEvents are the domain events that can change the outcome of a permission check
+var CacheInvalidationEvents = []string{
+	messaging.EventUserUpdated,
+	messaging.EventUserDeleted,
+	messaging.EventRoleUpdated,
+	messaging.EventRoleDeleted,
+	messaging.EventOrganizationUpdated,
+	messaging.EventOrganizationDeleted,
+	messaging.EventTeamMembersChanged,
+	messaging.EventPermissionCreated,
+	messaging.EventPermissionUpdated,
+	messaging.EventPermissionDeleted,
+}
+
+// HandleCacheInvalidationEvent invalidates the cached permission checks of every user affected by the event
+func HandleCacheInvalidationEvent(ctx context.Context, event messaging.Event) error {
+	cacheManager := cache.GetCacheManager()
+	if cacheManager == nil {
+		return nil
+	}
+
+	var userIDs []uuid.UUID
+
+	switch event.Type {
+	case messaging.EventTeamMembersChanged:
+		var data messaging.TeamMembersChangedData
+		if err := event.Decode(&data); err != nil {
+			return err
+		}
+		userIDs = data.UserIDs
+
+	default:
+		var ref messaging.EntityRef
+		if err := event.Decode(&ref); err != nil {
+			return err
+		}
+
+		var err error
+		switch event.Type {
+		case messaging.EventUserUpdated, messaging.EventUserDeleted:
+			userIDs = []uuid.UUID{ref.ID}
+		case messaging.EventRoleUpdated, messaging.EventRoleDeleted:
+			userIDs, err = permissionTargetUsers(models.PermissionTargetRole, nil, nil, &ref.ID, nil)
+		case messaging.EventOrganizationUpdated, messaging.EventOrganizationDeleted:
+			userIDs, err = permissionTargetUsers(models.PermissionTargetOrganization, nil, nil, nil, &ref.ID)
+		default:
+			userIDs, err = permissionTargetUsers(ref.Target, ref.UserID, ref.TeamID, ref.RoleID, ref.OrganizationID)
+			if err == nil && ref.Previous != nil {
+				var previousUserIDs []uuid.UUID
+				previousUserIDs, err = permissionTargetUsers(ref.Previous.Target, ref.Previous.UserID, ref.Previous.TeamID, ref.Previous.RoleID, ref.Previous.OrganizationID)
+				userIDs = append(userIDs, previousUserIDs...)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, userID := range userIDs {
+		if err := cacheManager.InvalidateUserPermissions(cache.UserCacheID(userID)); err != nil {
+			return err
+		}
+	}
+
+	if len(userIDs) > 0 {
+		log.Printf("🗑️  %s: invalidated permission cache of %d user(s)", event.Type, len(userIDs))
+	}
+	return nil
+}
+
+// permissionTargetUsers resolves the users a permission target applies to
+func permissionTargetUsers(target string, userID, teamID, roleID, organizationID *uuid.UUID) ([]uuid.UUID, error) {
+	db := database.GetDB()
+	var userIDs []uuid.UUID
+
+	switch {
+	case target == models.PermissionTargetUser && userID != nil:
+		return []uuid.UUID{*userID}, nil
+	case target == models.PermissionTargetTeam && teamID != nil:
+		err := db.Model(&models.TeamMember{}).Where("team_id = ?", *teamID).Pluck("user_id", &userIDs).Error
+		return userIDs, err
+	case target == models.PermissionTargetRole && roleID != nil:
+		err := db.Model(&models.User{}).Where("role_id = ?", *roleID).Pluck("id", &userIDs).Error
+		return userIDs, err
+	case target == models.PermissionTargetOrganization && organizationID != nil:
+		err := db.Model(&models.User{}).Where("organization_id = ?", *organizationID).Pluck("id", &userIDs).Error
+		return userIDs, err
+	}
+
+	return nil, nil
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"strconv"
 
+	"forgecrud-backend/shared/apperrors"
 	"forgecrud-backend/shared/utils/cache"
 
 	"github.com/gin-gonic/gin"
@@ -23,18 +24,13 @@ import (
 func GetCacheStats(c *gin.Context) {
 	cacheManager := cache.GetCacheManager()
 	if cacheManager == nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"error": "Cache manager not available",
-		})
+		apperrors.Respond(c, apperrors.New(apperrors.CodeUnavailable, "Cache manager not available"))
 		return
 	}
 
 	stats, err := cacheManager.GetCacheStats()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to get cache stats",
-			"details": err.Error(),
-		})
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to get cache stats"))
 		return
 	}
 
@@ -61,28 +57,20 @@ func InvalidateUserPermissions(c *gin.Context) {
 	userIDStr := c.Param("user_id")
 	cacheManager := cache.GetCacheManager()
 	if cacheManager == nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"error": "Cache manager not available",
-		})
+		apperrors.Respond(c, apperrors.New(apperrors.CodeUnavailable, "Cache manager not available"))
 		return
 	}
 
 	// Parse user ID
 	userID, err := strconv.ParseUint(userIDStr, 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid user ID",
-			"details": "User ID must be a valid number",
-		})
+		apperrors.Respond(c, apperrors.BadRequest("Invalid user ID").WithDetails("User ID must be a valid number"))
 		return
 	}
 
 	// Invalidate all permissions for this user
 	if err := cacheManager.InvalidateUserPermissions(uint(userID)); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to invalidate user permissions",
-			"details": err.Error(),
-		})
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to invalidate user permissions"))
 		return
 	}
 
@@ -110,26 +98,18 @@ func InvalidateRolePermissions(c *gin.Context) {
 	roleIDStr := c.Param("role_id")
 	cacheManager := cache.GetCacheManager()
 	if cacheManager == nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"error": "Cache manager not available",
-		})
+		apperrors.Respond(c, apperrors.New(apperrors.CodeUnavailable, "Cache manager not available"))
 		return
 	}
 
 	roleID, err := strconv.ParseUint(roleIDStr, 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid role ID",
-			"details": "Role ID must be a valid number",
-		})
+		apperrors.Respond(c, apperrors.BadRequest("Invalid role ID").WithDetails("Role ID must be a valid number"))
 		return
 	}
 
 	if err := cacheManager.InvalidateRolePermissions(uint(roleID)); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to invalidate role permissions",
-			"details": err.Error(),
-		})
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to invalidate role permissions"))
 		return
 	}
 
@@ -157,26 +137,18 @@ func InvalidateOrgPermissions(c *gin.Context) {
 	orgIDStr := c.Param("org_id")
 	cacheManager := cache.GetCacheManager()
 	if cacheManager == nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"error": "Cache manager not available",
-		})
+		apperrors.Respond(c, apperrors.New(apperrors.CodeUnavailable, "Cache manager not available"))
 		return
 	}
 
 	orgID, err := strconv.ParseUint(orgIDStr, 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid organization ID",
-			"details": "Organization ID must be a valid number",
-		})
+		apperrors.Respond(c, apperrors.BadRequest("Invalid organization ID").WithDetails("Organization ID must be a valid number"))
 		return
 	}
 
 	if err := cacheManager.InvalidateOrgPermissions(uint(orgID)); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to invalidate organization permissions",
-			"details": err.Error(),
-		})
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to invalidate organization permissions"))
 		return
 	}
 
@@ -201,17 +173,12 @@ func InvalidateOrgPermissions(c *gin.Context) {
 func InvalidateAllPermissions(c *gin.Context) {
 	cacheManager := cache.GetCacheManager()
 	if cacheManager == nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"error": "Cache manager not available",
-		})
+		apperrors.Respond(c, apperrors.New(apperrors.CodeUnavailable, "Cache manager not available"))
 		return
 	}
 
 	if err := cacheManager.InvalidateAllPermissions(); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to invalidate all permissions",
-			"details": err.Error(),
-		})
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to invalidate all permissions"))
 		return
 	}
 
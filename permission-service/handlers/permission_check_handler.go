@@ -3,9 +3,11 @@ package handlers
 import (
 	"net/http"
 
+	"forgecrud-backend/shared/apperrors"
 	"forgecrud-backend/shared/database"
 	"forgecrud-backend/shared/database/models"
 	"forgecrud-backend/shared/utils/cache"
+	"forgecrud-backend/shared/utils/validation"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -17,6 +19,10 @@ type PermissionCheckRequest struct {
 	UserID       string `json:"user_id" binding:"required"`
 	ResourceSlug string `json:"resource_slug" binding:"required"`
 	ActionSlug   string `json:"action_slug" binding:"required"`
+
+	// Optional folder or document the action targets. Its access grants decide when it has any.
+	ObjectType string `json:"object_type" binding:"omitempty,oneof=folder document"`
+	ObjectID   string `json:"object_id" binding:"required_with=ObjectType"`
 }
 
 // PermissionCheckResponse represents the response from permission check
@@ -43,7 +49,7 @@ type BatchPermissionCheckResponse struct {
 
 // CheckPermission checks if user has permission for specific resource and action
 // @Summary Check single permission
-// @Description Check if a user has permission for a specific resource and action
+// @Description Check if a user has permission for a specific resource and action. With object_type and object_id the check targets a folder or document; when it has access grants they decide instead of the resource permissions.
 // @Tags permission-checks
 // @Accept json
 // @Produce json
@@ -55,21 +61,27 @@ type BatchPermissionCheckResponse struct {
 func CheckPermission(c *gin.Context) {
 	var req PermissionCheckRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request body",
-			"details": err.Error(),
-		})
+		apperrors.Respond(c, validation.Error(err, "Invalid request body"))
 		return
 	}
 
 	// Parse user ID
 	userID, err := uuid.Parse(req.UserID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		apperrors.Respond(c, apperrors.BadRequest("Invalid user ID"))
 		return
 	}
 
-	// Check permission using 3-level hierarchy
+	// Access grants on the folder or document replace the resource permission check. Malformed
+	// object IDs are left to the owning service to reject.
+	if objectID, err := uuid.Parse(req.ObjectID); err == nil && req.ObjectType != "" {
+		if response, decided := checkObjectAccess(userID, req.ObjectType, objectID, req.ActionSlug); decided {
+			c.JSON(http.StatusOK, response)
+			return
+		}
+	}
+
+	// Check permission using the user/team/role/organization hierarchy
 	allowed, reason := checkPermissionHierarchy(userID, req.ResourceSlug, req.ActionSlug)
 
 	response := PermissionCheckResponse{
@@ -94,17 +106,14 @@ func CheckPermission(c *gin.Context) {
 func BatchCheckPermissions(c *gin.Context) {
 	var req BatchPermissionCheckRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request body",
-			"details": err.Error(),
-		})
+		apperrors.Respond(c, validation.Error(err, "Invalid request body"))
 		return
 	}
 
 	// Parse user ID
 	userID, err := uuid.Parse(req.UserID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		apperrors.Respond(c, apperrors.BadRequest("Invalid user ID"))
 		return
 	}
 
@@ -124,10 +133,10 @@ func BatchCheckPermissions(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
-// checkPermissionHierarchy implements 3-level permission check logic with Redis cache
-// Priority: 1. Cache lookup 2. User permissions 3. Role permissions 4. Organization permissions
+// checkPermissionHierarchy implements 4-level permission check logic with Redis cache
+// Priority: 1. Cache lookup 2. User permissions 3. Team permissions 4. Role permissions 5. Organization permissions
 func checkPermissionHierarchy(userID uuid.UUID, resourceSlug, actionSlug string) (bool, string) {
-	userIDUint := uuidToUint(userID)
+	userIDUint := cache.UserCacheID(userID)
 
 	// Try to get from cache first
 	cacheManager := cache.GetCacheManager()
@@ -145,12 +154,16 @@ func checkPermissionHierarchy(userID uuid.UUID, resourceSlug, actionSlug string)
 	if hasDirectUserPermission(db, userID, resourceSlug, actionSlug) {
 		allowed = true
 		foundAt = "user"
+	} else if hasTeamPermission(db, userID, resourceSlug, actionSlug) {
+		// 2. Check team-based permissions
+		allowed = true
+		foundAt = "team"
 	} else if hasRolePermission(db, userID, resourceSlug, actionSlug) {
-		// 2. Check role-based permissions
+		// 3. Check role-based permissions
 		allowed = true
 		foundAt = "role"
 	} else if hasOrganizationPermission(db, userID, resourceSlug, actionSlug) {
-		// 3. Check organization permissions (lowest priority)
+		// 4. Check organization permissions (lowest priority)
 		allowed = true
 		foundAt = "organization"
 	} else {
@@ -177,15 +190,23 @@ func checkPermissionHierarchy(userID uuid.UUID, resourceSlug, actionSlug string)
 	return false, "no_permission"
 }
 
-// uuidToUint converts UUID to uint for cache key
-func uuidToUint(id uuid.UUID) uint {
-	var hash uint32
-	bytes := id[:]
-	for i := 0; i < len(bytes); i += 4 {
-		chunk := uint32(bytes[i])<<24 | uint32(bytes[i+1])<<16 | uint32(bytes[i+2])<<8 | uint32(bytes[i+3])
-		hash ^= chunk
+// checkObjectAccess decides a check on a folder or document with access grants. decided is false
+// for objects without grants and unknown objects, which fall back to the resource permissions.
+// Super admins are never limited by access grants.
+func checkObjectAccess(userID uuid.UUID, objectType string, objectID uuid.UUID, actionSlug string) (PermissionCheckResponse, bool) {
+	access, err := database.ResolveObjectAccess(database.GetDB(), userID, objectType, objectID)
+	if err != nil || !access.Decisive() {
+		return PermissionCheckResponse{}, false
+	}
+
+	if superAdmin, _ := checkPermissionHierarchy(userID, "ALL", "manage"); superAdmin {
+		return PermissionCheckResponse{}, false
 	}
-	return uint(hash)
+
+	if access.Allows(database.AccessLevelForAction(actionSlug)) {
+		return PermissionCheckResponse{Allowed: true, Reason: "object_" + access.Level + "_access"}, true
+	}
+	return PermissionCheckResponse{Allowed: false, Reason: "no_object_access"}, true
 }
 
 // hasDirectUserPermission checks if user has direct permission
@@ -208,6 +229,27 @@ func hasDirectUserPermission(db *gorm.DB, userID uuid.UUID, resourceSlug, action
 	return count > 0
 }
 
+// hasTeamPermission checks if user has permission through any team they belong to
+func hasTeamPermission(db *gorm.DB, userID uuid.UUID, resourceSlug, actionSlug string) bool {
+	var count int64
+
+	// Check for specific resource permission or ALL resource permission
+	err := db.Table("permissions p").
+		Joins("JOIN resources r ON p.resource_id = r.id").
+		Joins("JOIN permission_actions pa ON p.id = pa.permission_id").
+		Joins("JOIN actions a ON pa.action_id = a.id").
+		Joins("JOIN team_members tm ON p.team_id = tm.team_id").
+		Where("p.target = ? AND tm.user_id = ? AND (r.slug = ? OR r.slug = ?) AND a.slug = ?",
+			"TEAM", userID, resourceSlug, "ALL", actionSlug).
+		Count(&count).Error
+
+	if err != nil {
+		return false
+	}
+
+	return count > 0
+}
+
 // hasRolePermission checks if user has permission through their role
 func hasRolePermission(db *gorm.DB, userID uuid.UUID, resourceSlug, actionSlug string) bool {
 	var count int64
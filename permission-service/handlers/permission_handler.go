@@ -1,11 +1,18 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"time"
 
+	"forgecrud-backend/shared/apperrors"
 	"forgecrud-backend/shared/database"
 	"forgecrud-backend/shared/database/models"
+	"forgecrud-backend/shared/messaging"
+	utils "forgecrud-backend/shared/utils/auth"
+	"forgecrud-backend/shared/utils/concurrency"
 	"forgecrud-backend/shared/utils/query"
+	"forgecrud-backend/shared/utils/validation"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -15,8 +22,9 @@ import (
 // CreatePermissionRequest represents the request body for creating a permission
 type CreatePermissionRequest struct {
 	ResourceID     uuid.UUID   `json:"resource_id" binding:"required"`
-	Target         string      `json:"target" binding:"required,oneof=USER ROLE ORGANIZATION"`
+	Target         string      `json:"target" binding:"required,oneof=USER TEAM ROLE ORGANIZATION"`
 	UserID         *uuid.UUID  `json:"user_id,omitempty"`
+	TeamID         *uuid.UUID  `json:"team_id,omitempty"`
 	RoleID         *uuid.UUID  `json:"role_id,omitempty"`
 	OrganizationID *uuid.UUID  `json:"organization_id,omitempty"`
 	ActionIDs      []uuid.UUID `json:"action_ids" binding:"required,min=1"`
@@ -27,15 +35,24 @@ type UpdatePermissionRequest struct {
 	ResourceID     *uuid.UUID  `json:"resource_id,omitempty"`
 	Target         *string     `json:"target,omitempty"`
 	UserID         *uuid.UUID  `json:"user_id,omitempty"`
+	TeamID         *uuid.UUID  `json:"team_id,omitempty"`
 	RoleID         *uuid.UUID  `json:"role_id,omitempty"`
 	OrganizationID *uuid.UUID  `json:"organization_id,omitempty"`
 	ActionIDs      []uuid.UUID `json:"action_ids,omitempty"`
+	Version        string      `json:"version,omitempty"` // Optional precondition, alternative to If-Match
 }
 
 // PermissionResponse represents the response structure with actions included
 type PermissionResponse struct {
 	models.Permission
 	Actions []models.Action `json:"actions"`
+	Version string          `json:"version"` // Send back as If-Match or version to detect concurrent updates
+}
+
+// permissionUpdatedData is the permission.updated event payload
+type permissionUpdatedData struct {
+	PermissionResponse
+	Previous messaging.EntityRef `json:"previous"`
 }
 
 // Resource represents a resource in the system
@@ -60,16 +77,17 @@ type Action struct {
 
 // Permission represents a permission in the system
 type Permission struct {
-	ID                uuid.UUID          `json:"id"`
-	Target            string             `json:"target"`
-	ResourceID        uuid.UUID          `json:"resource_id"`
-	UserID            *uuid.UUID         `json:"user_id,omitempty"`
-	RoleID            *uuid.UUID         `json:"role_id,omitempty"`
-	OrganizationID    *uuid.UUID         `json:"organization_id,omitempty"`
-	Resource          Resource           `json:"resource"`
-	Actions           []Action           `json:"actions"`
-	CreatedAt         string             `json:"created_at"`
-	UpdatedAt         string             `json:"updated_at"`
+	ID             uuid.UUID  `json:"id"`
+	Target         string     `json:"target"`
+	ResourceID     uuid.UUID  `json:"resource_id"`
+	UserID         *uuid.UUID `json:"user_id,omitempty"`
+	TeamID         *uuid.UUID `json:"team_id,omitempty"`
+	RoleID         *uuid.UUID `json:"role_id,omitempty"`
+	OrganizationID *uuid.UUID `json:"organization_id,omitempty"`
+	Resource       Resource   `json:"resource"`
+	Actions        []Action   `json:"actions"`
+	CreatedAt      string     `json:"created_at"`
+	UpdatedAt      string     `json:"updated_at"`
 }
 
 // PaginationResponse represents pagination information
@@ -111,23 +129,17 @@ type SinglePermissionResponse struct {
 func CreatePermission(c *gin.Context) {
 	var req CreatePermissionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request body",
-			"details": err.Error(),
-		})
+		apperrors.Respond(c, validation.Error(err, "Invalid request body"))
 		return
 	}
 
 	// Validate target-specific requirements
-	if err := validatePermissionTarget(req.Target, req.UserID, req.RoleID, req.OrganizationID); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid target configuration",
-			"details": err.Error(),
-		})
+	if err := validatePermissionTarget(req.Target, req.UserID, req.TeamID, req.RoleID, req.OrganizationID); err != nil {
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid target configuration"))
 		return
 	}
 
-	db := database.GetDB()
+	db := requestDB(c)
 
 	// Start transaction
 	tx := db.Begin()
@@ -142,9 +154,9 @@ func CreatePermission(c *gin.Context) {
 	if err := tx.First(&resource, "id = ?", req.ResourceID).Error; err != nil {
 		tx.Rollback()
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Resource not found"})
+			apperrors.Respond(c, apperrors.NotFound("Resource not found"))
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Database error"))
 		}
 		return
 	}
@@ -153,13 +165,13 @@ func CreatePermission(c *gin.Context) {
 	var actions []models.Action
 	if err := tx.Find(&actions, "id IN ?", req.ActionIDs).Error; err != nil {
 		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Database error"))
 		return
 	}
 
 	if len(actions) != len(req.ActionIDs) {
 		tx.Rollback()
-		c.JSON(http.StatusBadRequest, gin.H{"error": "One or more actions not found"})
+		apperrors.Respond(c, apperrors.BadRequest("One or more actions not found"))
 		return
 	}
 
@@ -168,16 +180,14 @@ func CreatePermission(c *gin.Context) {
 		ResourceID:     req.ResourceID,
 		Target:         req.Target,
 		UserID:         req.UserID,
+		TeamID:         req.TeamID,
 		RoleID:         req.RoleID,
 		OrganizationID: req.OrganizationID,
 	}
 
 	if err := tx.Create(&permission).Error; err != nil {
 		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to create permission",
-			"details": err.Error(),
-		})
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to create permission"))
 		return
 	}
 
@@ -189,17 +199,14 @@ func CreatePermission(c *gin.Context) {
 		}
 		if err := tx.Create(&permissionAction).Error; err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error":   "Failed to create permission actions",
-				"details": err.Error(),
-			})
+			apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to create permission actions"))
 			return
 		}
 	}
 
 	// Commit transaction
 	if err := tx.Commit().Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to commit transaction"))
 		return
 	}
 
@@ -207,6 +214,7 @@ func CreatePermission(c *gin.Context) {
 	var createdPermission models.Permission
 	db.Preload("Resource").
 		Preload("User").
+		Preload("Team").
 		Preload("Role").
 		Preload("Organization").
 		First(&createdPermission, "id = ?", permission.ID)
@@ -223,8 +231,11 @@ func CreatePermission(c *gin.Context) {
 	response := PermissionResponse{
 		Permission: createdPermission,
 		Actions:    responseActions,
+		Version:    concurrency.Version(createdPermission.UpdatedAt),
 	}
 
+	messaging.Publish(c.Request.Context(), messaging.EventPermissionCreated, utils.GetActorID(c), response)
+
 	c.JSON(http.StatusCreated, response)
 }
 
@@ -237,19 +248,23 @@ func CreatePermission(c *gin.Context) {
 // @Security BearerAuth
 // @Param page query int false "Page number (default: 1)"
 // @Param limit query int false "Results per page (default: 10)"
-// @Param filters[target] query string false "Filter by target (USER, ROLE, ORGANIZATION)"
+// @Param filters[target] query string false "Filter by target (USER, TEAM, ROLE, ORGANIZATION)"
 // @Param filters[resource_id] query string false "Filter by resource ID"
 // @Param filters[user_id] query string false "Filter by user ID"
+// @Param filters[team_id] query string false "Filter by team ID"
 // @Param filters[role_id] query string false "Filter by role ID"
 // @Param filters[organization_id] query string false "Filter by organization ID"
 // @Param sort[field] query string false "Sort field (target, created_at, updated_at)"
 // @Param sort[order] query string false "Sort order (asc, desc)"
 // @Param search query string false "Search term"
+// @Param pagination query string false "Set to cursor for keyset pagination (ordered by created_at, no total count)"
+// @Param cursor query string false "Opaque cursor from pagination.next_cursor of the previous page"
 // @Success 200 {object} handlers.PermissionListResponse "List of permissions"
+// @Failure 400 {object} map[string]string "Invalid cursor"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Router /permissions [get]
 func GetPermissions(c *gin.Context) {
-	db := database.GetDB()
+	db := requestDB(c)
 
 	// Parse standardized query parameters
 	params := query.ParseQueryParams(c)
@@ -259,8 +274,10 @@ func GetPermissions(c *gin.Context) {
 		"target":          "target",
 		"resource_id":     "resource_id",
 		"user_id":         "user_id",
+		"team_id":         "team_id",
 		"role_id":         "role_id",
 		"organization_id": "organization_id",
+		"created_at":      "created_at",
 	}
 
 	// Define allowed sort fields
@@ -277,6 +294,7 @@ func GetPermissions(c *gin.Context) {
 	baseQuery := db.Model(&models.Permission{}).
 		Preload("Resource").
 		Preload("User").
+		Preload("Team").
 		Preload("Role").
 		Preload("Organization")
 
@@ -286,21 +304,41 @@ func GetPermissions(c *gin.Context) {
 	// Apply search
 	searchedQuery := query.ApplySearch(filteredQuery, params.Search, searchFields)
 
-	// Get total count
-	var total int64
-	searchedQuery.Count(&total)
+	var finalQuery *gorm.DB
+	var pagination interface{}
+
+	if params.CursorMode {
+		// Keyset pagination skips the total count, which gets expensive on large tables
+		cursorQuery, err := query.ApplyCursor(searchedQuery, params, "permissions")
+		if err != nil {
+			apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid cursor"))
+			return
+		}
+		finalQuery = cursorQuery
+	} else {
+		// Get total count
+		var total int64
+		searchedQuery.Count(&total)
 
-	// Apply sorting and pagination
-	finalQuery := query.ApplySort(searchedQuery, params.Sort, allowedSortFields)
-	finalQuery = query.ApplyPagination(finalQuery, params.Page, params.Limit)
+		// Apply sorting and pagination
+		finalQuery = query.ApplySort(searchedQuery, params.Sort, allowedSortFields)
+		finalQuery = query.ApplyPagination(finalQuery, params.Page, params.Limit)
+		pagination = query.BuildPaginationResponse(params.Page, params.Limit, total)
+	}
 
 	// Get permissions
 	var permissions []models.Permission
 	if err := finalQuery.Find(&permissions).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Database error"))
 		return
 	}
 
+	if params.CursorMode {
+		permissions, pagination = query.TrimCursorPage(permissions, params.Limit, func(permission models.Permission) (time.Time, string) {
+			return permission.CreatedAt, permission.ID.String()
+		})
+	}
+
 	// Get actions for each permission
 	var responses []PermissionResponse
 	for _, permission := range permissions {
@@ -315,12 +353,10 @@ func GetPermissions(c *gin.Context) {
 		responses = append(responses, PermissionResponse{
 			Permission: permission,
 			Actions:    actions,
+			Version:    concurrency.Version(permission.UpdatedAt),
 		})
 	}
 
-	// Build pagination response
-	pagination := query.BuildPaginationResponse(params.Page, params.Limit, total)
-
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"data": gin.H{
@@ -347,22 +383,23 @@ func GetPermission(c *gin.Context) {
 	id := c.Param("id")
 	permissionID, err := uuid.Parse(id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid permission ID"})
+		apperrors.Respond(c, apperrors.BadRequest("Invalid permission ID"))
 		return
 	}
 
-	db := database.GetDB()
+	db := requestDB(c)
 
 	var permission models.Permission
 	if err := db.Preload("Resource").
 		Preload("User").
+		Preload("Team").
 		Preload("Role").
 		Preload("Organization").
 		First(&permission, "id = ?", permissionID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Permission not found"})
+			apperrors.Respond(c, apperrors.NotFound("Permission not found"))
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Database error"))
 		}
 		return
 	}
@@ -379,8 +416,10 @@ func GetPermission(c *gin.Context) {
 	response := PermissionResponse{
 		Permission: permission,
 		Actions:    actions,
+		Version:    concurrency.Version(permission.UpdatedAt),
 	}
 
+	concurrency.SetETag(c, permission.UpdatedAt)
 	c.JSON(http.StatusOK, response)
 }
 
@@ -393,30 +432,35 @@ func GetPermission(c *gin.Context) {
 // @Produce json
 // @Security BearerAuth
 // @Param id path string true "Permission ID" format(uuid)
+// @Param If-Match header string false "Version (ETag) the update is based on"
 // @Param permission body UpdatePermissionRequest true "Updated permission data"
 // @Success 200 {object} handlers.SinglePermissionResponse "Updated permission"
 // @Failure 400 {object} map[string]interface{} "Invalid request format or validation error"
 // @Failure 404 {object} map[string]string "Permission, resource, or action not found"
+// @Failure 409 {object} map[string]string "Version conflict"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Router /permissions/{id} [put]
 func UpdatePermission(c *gin.Context) {
 	id := c.Param("id")
 	permissionID, err := uuid.Parse(id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid permission ID"})
+		apperrors.Respond(c, apperrors.BadRequest("Invalid permission ID"))
 		return
 	}
 
 	var req UpdatePermissionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request body",
-			"details": err.Error(),
-		})
+		apperrors.Respond(c, validation.Error(err, "Invalid request body"))
+		return
+	}
+
+	expectedVersion, err := concurrency.ExpectedVersion(c, req.Version)
+	if err != nil {
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid version"))
 		return
 	}
 
-	db := database.GetDB()
+	db := requestDB(c)
 
 	// Start transaction
 	tx := db.Begin()
@@ -431,13 +475,29 @@ func UpdatePermission(c *gin.Context) {
 	if err := tx.First(&permission, "id = ?", permissionID).Error; err != nil {
 		tx.Rollback()
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Permission not found"})
+			apperrors.Respond(c, apperrors.NotFound("Permission not found"))
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Database error"))
 		}
 		return
 	}
 
+	if err := concurrency.CheckVersion(permission.UpdatedAt, expectedVersion); err != nil {
+		tx.Rollback()
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeConflict, "Version conflict"))
+		return
+	}
+
+	// Keep the original target so caches of users who lose the permission are refreshed too
+	previous := messaging.EntityRef{
+		ID:             permission.ID,
+		Target:         permission.Target,
+		UserID:         permission.UserID,
+		TeamID:         permission.TeamID,
+		RoleID:         permission.RoleID,
+		OrganizationID: permission.OrganizationID,
+	}
+
 	// Update permission fields
 	updates := make(map[string]interface{})
 
@@ -447,9 +507,9 @@ func UpdatePermission(c *gin.Context) {
 		if err := tx.First(&resource, "id = ?", *req.ResourceID).Error; err != nil {
 			tx.Rollback()
 			if err == gorm.ErrRecordNotFound {
-				c.JSON(http.StatusNotFound, gin.H{"error": "Resource not found"})
+				apperrors.Respond(c, apperrors.NotFound("Resource not found"))
 			} else {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+				apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Database error"))
 			}
 			return
 		}
@@ -459,6 +519,7 @@ func UpdatePermission(c *gin.Context) {
 	if req.Target != nil {
 		// Validate target with current/new IDs
 		targetUserID := req.UserID
+		targetTeamID := req.TeamID
 		targetRoleID := req.RoleID
 		targetOrgID := req.OrganizationID
 
@@ -466,6 +527,9 @@ func UpdatePermission(c *gin.Context) {
 		if targetUserID == nil {
 			targetUserID = permission.UserID
 		}
+		if targetTeamID == nil {
+			targetTeamID = permission.TeamID
+		}
 		if targetRoleID == nil {
 			targetRoleID = permission.RoleID
 		}
@@ -473,12 +537,9 @@ func UpdatePermission(c *gin.Context) {
 			targetOrgID = permission.OrganizationID
 		}
 
-		if err := validatePermissionTarget(*req.Target, targetUserID, targetRoleID, targetOrgID); err != nil {
+		if err := validatePermissionTarget(*req.Target, targetUserID, targetTeamID, targetRoleID, targetOrgID); err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error":   "Invalid target configuration",
-				"details": err.Error(),
-			})
+			apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeBadRequest, "Invalid target configuration"))
 			return
 		}
 		updates["target"] = *req.Target
@@ -487,6 +548,9 @@ func UpdatePermission(c *gin.Context) {
 	if req.UserID != nil {
 		updates["user_id"] = *req.UserID
 	}
+	if req.TeamID != nil {
+		updates["team_id"] = *req.TeamID
+	}
 	if req.RoleID != nil {
 		updates["role_id"] = *req.RoleID
 	}
@@ -494,14 +558,20 @@ func UpdatePermission(c *gin.Context) {
 		updates["organization_id"] = *req.OrganizationID
 	}
 
+	// Actions live in their own table; bump the permission's version when only they change
+	if len(updates) == 0 && len(req.ActionIDs) > 0 {
+		updates["updated_at"] = time.Now()
+	}
+
 	// Update permission
 	if len(updates) > 0 {
-		if err := tx.Model(&permission).Updates(updates).Error; err != nil {
+		if err := concurrency.Updates(tx, &permission, expectedVersion, updates); err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error":   "Failed to update permission",
-				"details": err.Error(),
-			})
+			if errors.Is(err, concurrency.ErrVersionConflict) {
+				apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeConflict, "Version conflict"))
+				return
+			}
+			apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to update permission"))
 			return
 		}
 	}
@@ -512,20 +582,20 @@ func UpdatePermission(c *gin.Context) {
 		var actions []models.Action
 		if err := tx.Find(&actions, "id IN ?", req.ActionIDs).Error; err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Database error"))
 			return
 		}
 
 		if len(actions) != len(req.ActionIDs) {
 			tx.Rollback()
-			c.JSON(http.StatusBadRequest, gin.H{"error": "One or more actions not found"})
+			apperrors.Respond(c, apperrors.BadRequest("One or more actions not found"))
 			return
 		}
 
 		// Delete existing permission actions
 		if err := tx.Delete(&models.PermissionAction{}, "permission_id = ?", permissionID).Error; err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update permission actions"})
+			apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to update permission actions"))
 			return
 		}
 
@@ -537,10 +607,7 @@ func UpdatePermission(c *gin.Context) {
 			}
 			if err := tx.Create(&permissionAction).Error; err != nil {
 				tx.Rollback()
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"error":   "Failed to create permission actions",
-					"details": err.Error(),
-				})
+				apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to create permission actions"))
 				return
 			}
 		}
@@ -548,7 +615,7 @@ func UpdatePermission(c *gin.Context) {
 
 	// Commit transaction
 	if err := tx.Commit().Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to commit transaction"))
 		return
 	}
 
@@ -556,6 +623,7 @@ func UpdatePermission(c *gin.Context) {
 	var updatedPermission models.Permission
 	db.Preload("Resource").
 		Preload("User").
+		Preload("Team").
 		Preload("Role").
 		Preload("Organization").
 		First(&updatedPermission, "id = ?", permissionID)
@@ -572,8 +640,15 @@ func UpdatePermission(c *gin.Context) {
 	response := PermissionResponse{
 		Permission: updatedPermission,
 		Actions:    responseActions,
+		Version:    concurrency.Version(updatedPermission.UpdatedAt),
 	}
 
+	messaging.Publish(c.Request.Context(), messaging.EventPermissionUpdated, utils.GetActorID(c), permissionUpdatedData{
+		PermissionResponse: response,
+		Previous:           previous,
+	})
+
+	concurrency.SetETag(c, updatedPermission.UpdatedAt)
 	c.JSON(http.StatusOK, response)
 }
 
@@ -595,11 +670,11 @@ func DeletePermission(c *gin.Context) {
 	id := c.Param("id")
 	permissionID, err := uuid.Parse(id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid permission ID"})
+		apperrors.Respond(c, apperrors.BadRequest("Invalid permission ID"))
 		return
 	}
 
-	db := database.GetDB()
+	db := requestDB(c)
 
 	// Start transaction
 	tx := db.Begin()
@@ -614,9 +689,9 @@ func DeletePermission(c *gin.Context) {
 	if err := tx.First(&permission, "id = ?", permissionID).Error; err != nil {
 		tx.Rollback()
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Permission not found"})
+			apperrors.Respond(c, apperrors.NotFound("Permission not found"))
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Database error"))
 		}
 		return
 	}
@@ -624,52 +699,108 @@ func DeletePermission(c *gin.Context) {
 	// Delete associated permission actions first
 	if err := tx.Delete(&models.PermissionAction{}, "permission_id = ?", permissionID).Error; err != nil {
 		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete permission actions"})
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to delete permission actions"))
 		return
 	}
 
 	// Delete permission
 	if err := tx.Delete(&permission).Error; err != nil {
 		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete permission"})
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to delete permission"))
 		return
 	}
 
 	// Commit transaction
 	if err := tx.Commit().Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		apperrors.Respond(c, apperrors.New(apperrors.CodeInternal, "Failed to commit transaction"))
 		return
 	}
 
+	messaging.Publish(c.Request.Context(), messaging.EventPermissionDeleted, utils.GetActorID(c), permission)
+
 	c.JSON(http.StatusOK, gin.H{"message": "Permission deleted successfully"})
 }
 
+// GetPermissionHistory returns the change history of a permission
+// @Summary Get permission change history
+// @Description Get revisions of a permission (who changed what and when), newest first. History is kept after deletion.
+// @Tags permissions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Permission ID" format(uuid)
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Success 200 {object} map[string]interface{} "Revisions with pagination"
+// @Failure 400 {object} map[string]string "Invalid permission ID"
+// @Failure 404 {object} map[string]string "No history found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /permissions/{id}/history [get]
+func GetPermissionHistory(c *gin.Context) {
+	permissionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apperrors.Respond(c, apperrors.BadRequest("Invalid permission ID"))
+		return
+	}
+
+	params := query.ParseQueryParams(c)
+	revisions, total, err := database.GetRevisions(database.GetDB(), models.Permission{}.RevisionEntityType(), permissionID, params.Page, params.Limit)
+	if err != nil {
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to fetch history"))
+		return
+	}
+	if total == 0 {
+		apperrors.Respond(c, apperrors.NotFound("No history found for permission"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"history":    revisions,
+			"pagination": query.BuildPaginationResponse(params.Page, params.Limit, total),
+		},
+	})
+}
+
+// requestDB returns a database handle that records the requesting user as the actor of any change
+func requestDB(c *gin.Context) *gorm.DB {
+	return database.GetDB().WithContext(database.WithActor(c.Request.Context(), utils.GetActorID(c)))
+}
+
 // Helper function to validate permission target configuration
-func validatePermissionTarget(target string, userID, roleID, organizationID *uuid.UUID) error {
+func validatePermissionTarget(target string, userID, teamID, roleID, organizationID *uuid.UUID) error {
 	switch target {
 	case "USER":
 		if userID == nil {
 			return &ValidationError{Field: "user_id", Message: "user_id is required for USER target"}
 		}
-		if roleID != nil || organizationID != nil {
+		if teamID != nil || roleID != nil || organizationID != nil {
 			return &ValidationError{Field: "target", Message: "only user_id should be set for USER target"}
 		}
+	case "TEAM":
+		if teamID == nil {
+			return &ValidationError{Field: "team_id", Message: "team_id is required for TEAM target"}
+		}
+		if userID != nil || roleID != nil || organizationID != nil {
+			return &ValidationError{Field: "target", Message: "only team_id should be set for TEAM target"}
+		}
 	case "ROLE":
 		if roleID == nil {
 			return &ValidationError{Field: "role_id", Message: "role_id is required for ROLE target"}
 		}
-		if userID != nil || organizationID != nil {
+		if userID != nil || teamID != nil || organizationID != nil {
 			return &ValidationError{Field: "target", Message: "only role_id should be set for ROLE target"}
 		}
 	case "ORGANIZATION":
 		if organizationID == nil {
 			return &ValidationError{Field: "organization_id", Message: "organization_id is required for ORGANIZATION target"}
 		}
-		if userID != nil || roleID != nil {
+		if userID != nil || teamID != nil || roleID != nil {
 			return &ValidationError{Field: "target", Message: "only organization_id should be set for ORGANIZATION target"}
 		}
 	default:
-		return &ValidationError{Field: "target", Message: "target must be USER, ROLE, or ORGANIZATION"}
+		return &ValidationError{Field: "target", Message: "target must be USER, TEAM, ROLE, or ORGANIZATION"}
 	}
 	return nil
 }
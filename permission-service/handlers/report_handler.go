@@ -0,0 +1,381 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models"
+	"forgecrud-backend/shared/tenancy"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Grant sources of a matrix cell, in the priority order of the permission check
+const (
+	grantSourceUser         = "user"
+	grantSourceTeam         = "team"
+	grantSourceRole         = "role"
+	grantSourceOrganization = "organization"
+)
+
+// MatrixEntry is a resource or action of the matrix
+type MatrixEntry struct {
+	ID   uuid.UUID `json:"id"`
+	Slug string    `json:"slug"`
+	Name string    `json:"name"`
+}
+
+// MatrixRole is a row of the matrix: the actions a role allows on each resource
+type MatrixRole struct {
+	ID             uuid.UUID           `json:"id"`
+	Name           string              `json:"name"`
+	OrganizationID *uuid.UUID          `json:"organization_id"`
+	Permissions    map[string][]string `json:"permissions"` // Resource slug -> action slugs
+}
+
+// MatrixUser is a row of the matrix: the actions a user may perform on each resource and what grants them
+type MatrixUser struct {
+	ID             uuid.UUID                    `json:"id"`
+	Email          string                       `json:"email"`
+	Name           string                       `json:"name"`
+	Status         string                       `json:"status"`
+	RoleID         *uuid.UUID                   `json:"role_id"`
+	OrganizationID *uuid.UUID                   `json:"organization_id"`
+	Permissions    map[string]map[string]string `json:"permissions"` // Resource slug -> action slug -> user, team, role or organization
+}
+
+// PermissionMatrix is the permission matrix report
+type PermissionMatrix struct {
+	GeneratedAt time.Time     `json:"generated_at"`
+	Resources   []MatrixEntry `json:"resources"`
+	Actions     []MatrixEntry `json:"actions"`
+	Roles       []MatrixRole  `json:"roles"`
+	Users       []MatrixUser  `json:"users,omitempty"`
+}
+
+// matrixGrant is a permission action of the permissions table
+type matrixGrant struct {
+	Target         string
+	UserID         *uuid.UUID
+	TeamID         *uuid.UUID
+	RoleID         *uuid.UUID
+	OrganizationID *uuid.UUID
+	ResourceSlug   string
+	ActionSlug     string
+}
+
+// GetPermissionMatrix reports which roles, and optionally which users, may perform which actions on which resources
+// @Summary Permission matrix report
+// @Description Roles × resources × actions of the caller's organization, for security reviews. A permission on the ALL resource counts for every resource, as in permission checks. With include_users=true every user is added with their effective permissions and whether each comes from a direct, team, role or organization permission. CSV has a row per role and user and a column per resource:action.
+// @Tags reports
+// @Produce json
+// @Produce text/csv
+// @Security BearerAuth
+// @Param format query string false "json (default) or csv"
+// @Param include_users query bool false "Add the effective permissions of every user"
+// @Param resource query string false "Comma separated resource slugs to report on"
+// @Param role_id query string false "Only report on this role, and with include_users on its users" format(uuid)
+// @Success 200 {object} handlers.PermissionMatrix "Permission matrix"
+// @Failure 400 {object} map[string]string "Unknown format or invalid role ID"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /reports/permission-matrix [get]
+func GetPermissionMatrix(c *gin.Context) {
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		apperrors.Respond(c, apperrors.BadRequest("Unknown report format: "+format).WithDetails(gin.H{"allowed_formats": []string{"json", "csv"}}))
+		return
+	}
+
+	var roleID *uuid.UUID
+	if value := c.Query("role_id"); value != "" {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			apperrors.Respond(c, apperrors.BadRequest("Invalid role ID"))
+			return
+		}
+		roleID = &id
+	}
+
+	// Roles and users are limited to the caller's organization like in the core service
+	db := database.GetDB()
+	if tenant, ok := tenancy.FromRequest(c); ok {
+		db = db.WithContext(tenancy.WithTenant(c.Request.Context(), tenant))
+	}
+
+	matrix, err := buildPermissionMatrix(db, roleID, splitList(c.Query("resource")), c.Query("include_users") == "true")
+	if err != nil {
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to build permission matrix"))
+		return
+	}
+
+	if format == "json" {
+		c.JSON(http.StatusOK, gin.H{"success": true, "data": matrix})
+		return
+	}
+
+	fileName := fmt.Sprintf("permission-matrix-%s.csv", matrix.GeneratedAt.Format("20060102-150405"))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", fileName))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Status(http.StatusOK)
+	if err := writePermissionMatrixCSV(c, matrix); err != nil {
+		log.Printf("❌ Permission matrix export failed: %v", err)
+	}
+}
+
+// buildPermissionMatrix loads the resources, actions, roles and grants and fills the matrix
+func buildPermissionMatrix(db *gorm.DB, roleID *uuid.UUID, resourceSlugs []string, includeUsers bool) (*PermissionMatrix, error) {
+	matrix := &PermissionMatrix{GeneratedAt: time.Now().UTC(), Roles: []MatrixRole{}}
+
+	var resources []models.Resource
+	resourceQuery := db.Model(&models.Resource{}).Order("slug")
+	if len(resourceSlugs) > 0 {
+		resourceQuery = resourceQuery.Where("slug IN ?", resourceSlugs)
+	}
+	if err := resourceQuery.Find(&resources).Error; err != nil {
+		return nil, err
+	}
+	var actions []models.Action
+	if err := db.Model(&models.Action{}).Order("slug").Find(&actions).Error; err != nil {
+		return nil, err
+	}
+	for _, resource := range resources {
+		matrix.Resources = append(matrix.Resources, MatrixEntry{ID: resource.ID, Slug: resource.Slug, Name: resource.Name})
+	}
+	for _, action := range actions {
+		matrix.Actions = append(matrix.Actions, MatrixEntry{ID: action.ID, Slug: action.Slug, Name: action.Name})
+	}
+
+	var roles []models.Role
+	roleQuery := db.Model(&models.Role{}).Order("name")
+	if roleID != nil {
+		roleQuery = roleQuery.Where("id = ?", *roleID)
+	}
+	if err := roleQuery.Find(&roles).Error; err != nil {
+		return nil, err
+	}
+
+	var users []models.User
+	teams := map[uuid.UUID][]uuid.UUID{} // User -> teams
+	if includeUsers {
+		userQuery := db.Model(&models.User{}).Order("email")
+		if roleID != nil {
+			userQuery = userQuery.Where("role_id = ?", *roleID)
+		}
+		if err := userQuery.Find(&users).Error; err != nil {
+			return nil, err
+		}
+		if len(users) > 0 {
+			var members []models.TeamMember
+			if err := db.Model(&models.TeamMember{}).Where("user_id IN ?", userIDs(users)).Find(&members).Error; err != nil {
+				return nil, err
+			}
+			for _, member := range members {
+				teams[member.UserID] = append(teams[member.UserID], member.TeamID)
+			}
+		}
+	}
+
+	var grants []matrixGrant
+	if err := db.Table("permissions p").
+		Select("p.target, p.user_id, p.team_id, p.role_id, p.organization_id, r.slug AS resource_slug, a.slug AS action_slug").
+		Joins("JOIN resources r ON p.resource_id = r.id").
+		Joins("JOIN permission_actions pa ON p.id = pa.permission_id").
+		Joins("JOIN actions a ON pa.action_id = a.id").
+		Scan(&grants).Error; err != nil {
+		return nil, err
+	}
+
+	// Grants on the ALL resource count for every resource, like in checkPermissionHierarchy
+	reported := map[string]bool{}
+	for _, resource := range resources {
+		reported[resource.Slug] = true
+	}
+	granted := func(grant matrixGrant, add func(resourceSlug string)) {
+		if grant.ResourceSlug == "ALL" {
+			for _, resource := range resources {
+				add(resource.Slug)
+			}
+		} else if reported[grant.ResourceSlug] {
+			add(grant.ResourceSlug)
+		}
+	}
+
+	for _, role := range roles {
+		allowed := map[string]map[string]bool{}
+		for _, grant := range grants {
+			if grant.Target != models.PermissionTargetRole || grant.RoleID == nil || *grant.RoleID != role.ID {
+				continue
+			}
+			granted(grant, func(resourceSlug string) {
+				if allowed[resourceSlug] == nil {
+					allowed[resourceSlug] = map[string]bool{}
+				}
+				allowed[resourceSlug][grant.ActionSlug] = true
+			})
+		}
+
+		permissions := map[string][]string{}
+		for _, resource := range resources {
+			for _, action := range actions {
+				if allowed[resource.Slug][action.Slug] {
+					permissions[resource.Slug] = append(permissions[resource.Slug], action.Slug)
+				}
+			}
+		}
+		matrix.Roles = append(matrix.Roles, MatrixRole{ID: role.ID, Name: role.Name, OrganizationID: role.OrganizationID, Permissions: permissions})
+	}
+
+	if includeUsers {
+		matrix.Users = []MatrixUser{}
+	}
+	for _, user := range users {
+		permissions := map[string]map[string]string{}
+		for _, grant := range grants {
+			source := grantSource(grant, user, teams[user.ID])
+			if source == "" {
+				continue
+			}
+			granted(grant, func(resourceSlug string) {
+				if permissions[resourceSlug] == nil {
+					permissions[resourceSlug] = map[string]string{}
+				}
+				if current, ok := permissions[resourceSlug][grant.ActionSlug]; !ok || grantPriority(source) < grantPriority(current) {
+					permissions[resourceSlug][grant.ActionSlug] = source
+				}
+			})
+		}
+
+		matrix.Users = append(matrix.Users, MatrixUser{
+			ID:             user.ID,
+			Email:          user.Email,
+			Name:           strings.TrimSpace(user.FirstName + " " + user.LastName),
+			Status:         user.Status,
+			RoleID:         user.RoleID,
+			OrganizationID: user.OrganizationID,
+			Permissions:    permissions,
+		})
+	}
+
+	return matrix, nil
+}
+
+// grantSource returns how a grant applies to a user, or "" when it does not
+func grantSource(grant matrixGrant, user models.User, teams []uuid.UUID) string {
+	switch grant.Target {
+	case models.PermissionTargetUser:
+		if grant.UserID != nil && *grant.UserID == user.ID {
+			return grantSourceUser
+		}
+	case models.PermissionTargetTeam:
+		for _, teamID := range teams {
+			if grant.TeamID != nil && *grant.TeamID == teamID {
+				return grantSourceTeam
+			}
+		}
+	case models.PermissionTargetRole:
+		if grant.RoleID != nil && user.RoleID != nil && *grant.RoleID == *user.RoleID {
+			return grantSourceRole
+		}
+	case models.PermissionTargetOrganization:
+		if grant.OrganizationID != nil && user.OrganizationID != nil && *grant.OrganizationID == *user.OrganizationID {
+			return grantSourceOrganization
+		}
+	}
+	return ""
+}
+
+// grantPriority orders grant sources like checkPermissionHierarchy, which reports the first that allows
+func grantPriority(source string) int {
+	switch source {
+	case grantSourceUser:
+		return 0
+	case grantSourceTeam:
+		return 1
+	case grantSourceRole:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// writePermissionMatrixCSV writes a row per role and user with a column per resource and action.
+// Role cells are "x"; user cells name what grants the permission.
+func writePermissionMatrixCSV(c *gin.Context, matrix *PermissionMatrix) error {
+	writer := csv.NewWriter(c.Writer)
+
+	header := []string{"type", "id", "name", "email", "role_id"}
+	for _, resource := range matrix.Resources {
+		for _, action := range matrix.Actions {
+			header = append(header, resource.Slug+":"+action.Slug)
+		}
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, role := range matrix.Roles {
+		row := []string{"role", role.ID.String(), role.Name, "", ""}
+		for _, resource := range matrix.Resources {
+			allowed := map[string]bool{}
+			for _, action := range role.Permissions[resource.Slug] {
+				allowed[action] = true
+			}
+			for _, action := range matrix.Actions {
+				cell := ""
+				if allowed[action.Slug] {
+					cell = "x"
+				}
+				row = append(row, cell)
+			}
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	for _, user := range matrix.Users {
+		roleID := ""
+		if user.RoleID != nil {
+			roleID = user.RoleID.String()
+		}
+		row := []string{"user", user.ID.String(), user.Name, user.Email, roleID}
+		for _, resource := range matrix.Resources {
+			for _, action := range matrix.Actions {
+				row = append(row, user.Permissions[resource.Slug][action.Slug])
+			}
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func userIDs(users []models.User) []uuid.UUID {
+	ids := make([]uuid.UUID, len(users))
+	for i, user := range users {
+		ids[i] = user.ID
+	}
+	return ids
+}
+
+// splitList splits a comma separated query value, dropping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
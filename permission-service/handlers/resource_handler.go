@@ -4,9 +4,11 @@ import (
 	"net/http"
 	"strings"
 
+	"forgecrud-backend/shared/apperrors"
 	"forgecrud-backend/shared/database"
 	"forgecrud-backend/shared/database/models"
 	"forgecrud-backend/shared/utils/query"
+	"forgecrud-backend/shared/utils/validation"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -67,10 +69,7 @@ type SingleResourceResponse struct {
 func CreateResource(c *gin.Context) {
 	var req CreateResourceRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request body",
-			"details": err.Error(),
-		})
+		apperrors.Respond(c, validation.Error(err, "Invalid request body"))
 		return
 	}
 
@@ -81,9 +80,7 @@ func CreateResource(c *gin.Context) {
 	// Validate slug uniqueness
 	var existingResource models.Resource
 	if err := database.DB.Where("slug = ?", req.Slug).First(&existingResource).Error; err == nil {
-		c.JSON(http.StatusConflict, gin.H{
-			"error": "Resource with this slug already exists",
-		})
+		apperrors.Respond(c, apperrors.Conflict("Resource with this slug already exists"))
 		return
 	}
 
@@ -94,10 +91,7 @@ func CreateResource(c *gin.Context) {
 	}
 
 	if err := database.DB.Create(&resource).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to create resource",
-			"details": err.Error(),
-		})
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to create resource"))
 		return
 	}
 
@@ -167,10 +161,7 @@ func GetResources(c *gin.Context) {
 	// Get resources
 	var resources []models.Resource
 	if err := finalQuery.Find(&resources).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to fetch resources",
-			"details": err.Error(),
-		})
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to fetch resources"))
 		return
 	}
 
@@ -203,17 +194,13 @@ func GetResource(c *gin.Context) {
 
 	resourceID, err := uuid.Parse(id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid resource ID format",
-		})
+		apperrors.Respond(c, apperrors.BadRequest("Invalid resource ID format"))
 		return
 	}
 
 	var resource models.Resource
 	if err := database.DB.First(&resource, resourceID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "Resource not found",
-		})
+		apperrors.Respond(c, apperrors.NotFound("Resource not found"))
 		return
 	}
 
@@ -242,26 +229,19 @@ func UpdateResource(c *gin.Context) {
 
 	resourceID, err := uuid.Parse(id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid resource ID format",
-		})
+		apperrors.Respond(c, apperrors.BadRequest("Invalid resource ID format"))
 		return
 	}
 
 	var req UpdateResourceRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request body",
-			"details": err.Error(),
-		})
+		apperrors.Respond(c, validation.Error(err, "Invalid request body"))
 		return
 	}
 
 	var resource models.Resource
 	if err := database.DB.First(&resource, resourceID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "Resource not found",
-		})
+		apperrors.Respond(c, apperrors.NotFound("Resource not found"))
 		return
 	}
 
@@ -269,10 +249,7 @@ func UpdateResource(c *gin.Context) {
 	if resource.IsSystem {
 		// System resources can only have their description updated
 		if req.Name != "" || req.Slug != "" {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error":   "Cannot modify system resource",
-				"message": "System resources name and slug cannot be modified. Only description can be updated.",
-			})
+			apperrors.Respond(c, apperrors.Forbidden("Cannot modify system resource").WithDetails("System resources name and slug cannot be modified. Only description can be updated."))
 			return
 		}
 	}
@@ -283,9 +260,7 @@ func UpdateResource(c *gin.Context) {
 	if req.Slug != "" {
 		var existingResource models.Resource
 		if err := database.DB.Where("slug = ? AND id != ?", req.Slug, resourceID).First(&existingResource).Error; err == nil {
-			c.JSON(http.StatusConflict, gin.H{
-				"error": "Resource with this slug already exists",
-			})
+			apperrors.Respond(c, apperrors.Conflict("Resource with this slug already exists"))
 			return
 		}
 		resource.Slug = req.Slug
@@ -295,10 +270,7 @@ func UpdateResource(c *gin.Context) {
 	}
 
 	if err := database.DB.Save(&resource).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to update resource",
-			"details": err.Error(),
-		})
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to update resource"))
 		return
 	}
 
@@ -327,45 +299,31 @@ func DeleteResource(c *gin.Context) {
 
 	resourceID, err := uuid.Parse(id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid resource ID format",
-		})
+		apperrors.Respond(c, apperrors.BadRequest("Invalid resource ID format"))
 		return
 	}
 
 	var resource models.Resource
 	if err := database.DB.First(&resource, resourceID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "Resource not found",
-		})
+		apperrors.Respond(c, apperrors.NotFound("Resource not found"))
 		return
 	}
 
 	// Check if it's a system resource
 	if resource.IsSystem {
-		c.JSON(http.StatusForbidden, gin.H{
-			"error":   "Cannot delete system resource",
-			"message": "System resources are protected and cannot be deleted",
-		})
+		apperrors.Respond(c, apperrors.Forbidden("Cannot delete system resource").WithDetails("System resources are protected and cannot be deleted"))
 		return
 	}
 
 	var permissionCount int64
 	database.DB.Model(&models.Permission{}).Where("resource_id = ?", resourceID).Count(&permissionCount)
 	if permissionCount > 0 {
-		c.JSON(http.StatusConflict, gin.H{
-			"error":   "Cannot delete resource",
-			"message": "Resource is being used in permissions",
-			"count":   permissionCount,
-		})
+		apperrors.Respond(c, apperrors.Conflict("Cannot delete resource").WithDetails("Resource is being used in permissions").With("count", permissionCount))
 		return
 	}
 
 	if err := database.DB.Delete(&resource).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to delete resource",
-			"details": err.Error(),
-		})
+		apperrors.Respond(c, apperrors.Wrap(err, apperrors.CodeInternal, "Failed to delete resource"))
 		return
 	}
 
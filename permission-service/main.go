@@ -1,13 +1,19 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"strings"
 
+	_ "forgecrud-backend/permission-service/docs"
 	"forgecrud-backend/permission-service/handlers"
+	"forgecrud-backend/shared/clientip"
 	"forgecrud-backend/shared/config"
 	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/health"
+	"forgecrud-backend/shared/messaging"
+	"forgecrud-backend/shared/server"
 	"forgecrud-backend/shared/utils/cache"
 
 	"github.com/gin-gonic/gin"
@@ -15,6 +21,14 @@ import (
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
+// @title Permission Service API
+// @version 1.0
+// @description Permissions, resources, actions and permission checks
+// @BasePath /api
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+
 func main() {
 
 	// Load configuration
@@ -24,7 +38,8 @@ func main() {
 	if err := database.InitDatabase(); err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
-	defer database.CloseDatabase()
+	server.OnShutdown("database", database.CloseDatabase)
+	health.AddCheck("database", health.CheckDatabase)
 
 	// Initialize Redis Cache Manager
 	if err := cache.InitCacheManager(); err != nil {
@@ -37,10 +52,28 @@ func main() {
 			if err := cacheManager.TestConnection(); err != nil {
 				log.Printf("⚠️  Warning: Redis connection test failed: %v", err)
 			}
+			server.OnShutdown("redis cache", cacheManager.Close)
+			health.AddOptionalCheck("redis cache", health.Ping(cacheManager.Ping))
+		}
+	}
+
+	// Refresh permission caches when users, roles, teams or permissions change
+	if err := messaging.Init("permission-service"); err != nil {
+		log.Printf("⚠️  Warning: Event bus not available: %v", err)
+	} else {
+		server.OnShutdown("event bus", messaging.Close)
+		health.AddOptionalCheck("event bus", health.Ping(messaging.Ping))
+		if err := messaging.Subscribe(context.Background(), "permission-service",
+			handlers.HandleCacheInvalidationEvent, handlers.CacheInvalidationEvents...); err != nil {
+			log.Printf("⚠️  Warning: Failed to subscribe to events: %v", err)
 		}
 	}
 
-	router := gin.Default()
+	router := gin.New()
+	router.Use(server.Logger(), gin.Recovery())
+
+	// Client IPs forwarded by the gateway
+	clientip.ConfigureService(router)
 
 	// Resource Management Routes
 	router.GET("/api/permissions/resources", handlers.GetResources)
@@ -62,11 +95,15 @@ func main() {
 	router.GET("/api/permissions/:id", handlers.GetPermission)
 	router.PUT("/api/permissions/:id", handlers.UpdatePermission)
 	router.DELETE("/api/permissions/:id", handlers.DeletePermission)
+	router.GET("/api/permissions/:id/history", handlers.GetPermissionHistory)
 
 	// Permission Check Routes
 	router.POST("/api/permissions/check", handlers.CheckPermission)
 	router.POST("/api/permissions/batch-check", handlers.BatchCheckPermissions)
 
+	// Report Routes
+	router.GET("/api/reports/permission-matrix", handlers.GetPermissionMatrix)
+
 	// Cache Management Routes
 	router.GET("/api/permissions/cache/stats", handlers.GetCacheStats)
 	router.POST("/api/permissions/cache/invalidate/:user_id", handlers.InvalidateUserPermissions)
@@ -84,18 +121,13 @@ func main() {
 		})
 	})
 
-	// Health check
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{
-			"status":  "healthy",
-			"service": "permission",
-		})
-	})
+	// Liveness and readiness probes, and metrics
+	health.Register(router, "permission")
 
-	// Swagger documentation
+	// Swagger documentation, merged by the gateway into its OpenAPI document
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	port := strings.Split(config.GetConfig().PermissionServiceURL, ":")[2]
 	log.Printf("Permission Service starting on port %s...", port)
-	router.Run(":" + port)
+	server.Run(router, ":"+port)
 }
@@ -27,28 +27,43 @@ fi
 export PATH=$PATH:$(go env GOPATH)/bin
 SWAG_CMD="swag"
 
+FAILED=0
+
+# Generate the documentation of each service into its docs package. The gateway merges them into one
+# OpenAPI document at /docs/openapi.json, so every service documents its own routes
+for SERVICE in auth-service core-service permission-service notification-service document-service; do
+    echo -e "${YELLOW}Generating $SERVICE Swagger documentation...${NC}"
+    cd "$PROJECT_ROOT/$SERVICE" || exit
+    $SWAG_CMD init \
+        --generalInfo main.go \
+        --parseDependency \
+        --parseInternal \
+        --parseDepth=10 \
+        --exclude ".*shared/docs/responses.*" \
+        --dir ./ \
+        --output ./docs \
+        --quiet || FAILED=1
+done
+
 # Go to API Gateway main directory
 cd "$PROJECT_ROOT/api-gateway" || exit
 
-# Generate Swagger directly through API Gateway
+# Generate the gateway's own documentation (audit logs, jobs, scheduler, search). Its general info
+# (title, tags, security) is also the cover of the merged document
 echo -e "${YELLOW}Generating API Gateway Swagger documentation...${NC}"
-
-# Generate Swagger - parse all services
-# Parse all dependencies in depth with --parseDepth=10 parameter
-# Exclude types under shared/docs/responses with --exclude parameter
-# Specify all service directories with --dir parameter
 $SWAG_CMD init \
     --generalInfo ../docs/swagger.go \
     --parseDependency \
     --parseInternal \
     --parseDepth=10 \
     --exclude ".*shared/docs/responses.*" \
-    --dir ../api-gateway,../auth-service,../core-service,../permission-service,../notification-service,../document-service \
-    --output ../docs/swagger
+    --dir ../api-gateway \
+    --output ../docs/swagger \
+    --quiet || FAILED=1
 
-if [ $? -eq 0 ]; then
+if [ $FAILED -eq 0 ]; then
     echo -e "${GREEN}✅ Swagger documentation successfully generated${NC}"
-    echo -e "${GREEN}✅ Documentation saved to: $DOCS_DIR/swagger/${NC}"
+    echo -e "${GREEN}✅ Documentation saved to: $DOCS_DIR/swagger/ and <service>/docs/${NC}"
 else
     echo -e "${RED}❌ Failed to generate Swagger documentation${NC}"
     # Show recent errors to see the cause of the failure
@@ -58,4 +73,6 @@ fi
 
 echo -e "${GREEN}Process completed!${NC}"
 echo -e "${YELLOW}You can access Swagger UI at:${NC}"
-echo -e "${GREEN}http://localhost:8000/swagger/index.html${NC}"
\ No newline at end of file
+echo -e "${GREEN}http://localhost:8000/swagger/index.html${NC}"
+echo -e "${YELLOW}The merged OpenAPI document is served at:${NC}"
+echo -e "${GREEN}http://localhost:8000/docs/openapi.json${NC}"
\ No newline at end of file
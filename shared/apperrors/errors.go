@@ -0,0 +1,177 @@
+package apperrors
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Code identifies the kind of an error for clients, independent of the message
+type Code string
+
+// Error codes. The gateway's unified response passes them on as error.code.
+const (
+	CodeBadRequest          Code = "BAD_REQUEST"
+	CodeUnauthorized        Code = "UNAUTHORIZED"
+	CodeQuotaExceeded       Code = "QUOTA_EXCEEDED"
+	CodeForbidden           Code = "FORBIDDEN"
+	CodeNotFound            Code = "NOT_FOUND"
+	CodeConflict            Code = "CONFLICT"
+	CodeGone                Code = "GONE"
+	CodePreconditionFailed  Code = "PRECONDITION_FAILED"
+	CodePayloadTooLarge     Code = "PAYLOAD_TOO_LARGE"
+	CodeUnsupportedMedia    Code = "UNSUPPORTED_MEDIA_TYPE"
+	CodeRangeNotSatisfiable Code = "RANGE_NOT_SATISFIABLE"
+	CodeValidation          Code = "VALIDATION_ERROR"
+	CodeLocked              Code = "LOCKED"
+	CodeRateLimited         Code = "RATE_LIMITED"
+	CodeInternal            Code = "INTERNAL_ERROR"
+	CodeNotImplemented      Code = "NOT_IMPLEMENTED"
+	CodeBadGateway          Code = "BAD_GATEWAY"
+	CodeUnavailable         Code = "SERVICE_UNAVAILABLE"
+	CodeInsufficientStorage Code = "INSUFFICIENT_STORAGE"
+	CodeUnknown             Code = "UNKNOWN_ERROR"
+)
+
+// codeStatuses maps the codes to their HTTP status
+var codeStatuses = map[Code]int{
+	CodeBadRequest:          http.StatusBadRequest,
+	CodeUnauthorized:        http.StatusUnauthorized,
+	CodeQuotaExceeded:       http.StatusPaymentRequired,
+	CodeForbidden:           http.StatusForbidden,
+	CodeNotFound:            http.StatusNotFound,
+	CodeConflict:            http.StatusConflict,
+	CodeGone:                http.StatusGone,
+	CodePreconditionFailed:  http.StatusPreconditionFailed,
+	CodePayloadTooLarge:     http.StatusRequestEntityTooLarge,
+	CodeUnsupportedMedia:    http.StatusUnsupportedMediaType,
+	CodeRangeNotSatisfiable: http.StatusRequestedRangeNotSatisfiable,
+	CodeValidation:          http.StatusUnprocessableEntity,
+	CodeLocked:              http.StatusLocked,
+	CodeRateLimited:         http.StatusTooManyRequests,
+	CodeInternal:            http.StatusInternalServerError,
+	CodeNotImplemented:      http.StatusNotImplemented,
+	CodeBadGateway:          http.StatusBadGateway,
+	CodeUnavailable:         http.StatusServiceUnavailable,
+	CodeInsufficientStorage: http.StatusInsufficientStorage,
+}
+
+// Status returns the HTTP status of the code, 500 for unknown codes
+func (c Code) Status() int {
+	if status, ok := codeStatuses[c]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// CodeForStatus returns the code of an HTTP status, for errors answered by other services
+func CodeForStatus(status int) Code {
+	for code, codeStatus := range codeStatuses {
+		if codeStatus == status {
+			return code
+		}
+	}
+	if status >= 500 {
+		return CodeInternal
+	}
+	return CodeUnknown
+}
+
+// Error is an error with a code and a message safe to show to users. The wrapped error is logged,
+// and only shown as details for client errors, so internal errors don't leak database or storage
+// internals.
+type Error struct {
+	Code    Code
+	Message string
+	Details interface{}            // Shown with the message, like the fields failing validation
+	Fields  map[string]interface{} // Extra fields of the response, like retry_after
+	Err     error                  // The cause, if any
+}
+
+// Error returns the message and the cause
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", e.Message, e.Err)
+	}
+	return e.Message
+}
+
+// Unwrap returns the cause
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Status returns the HTTP status of the error
+func (e *Error) Status() int {
+	return e.Code.Status()
+}
+
+// WithDetails sets the details shown with the message
+func (e *Error) WithDetails(details interface{}) *Error {
+	e.Details = details
+	return e
+}
+
+// With adds a field to the response
+func (e *Error) With(key string, value interface{}) *Error {
+	if e.Fields == nil {
+		e.Fields = map[string]interface{}{}
+	}
+	e.Fields[key] = value
+	return e
+}
+
+// New returns an error with the code and user-safe message
+func New(code Code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+// Newf returns an error with the code and a formatted user-safe message
+func Newf(code Code, format string, args ...interface{}) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// Wrap returns an error with the code and user-safe message caused by err. For client errors the
+// cause is shown as details, since it explains what is wrong with the request.
+func Wrap(err error, code Code, message string) *Error {
+	e := &Error{Code: code, Message: message, Err: err}
+	if err != nil && code.Status() < http.StatusInternalServerError {
+		e.Details = err.Error()
+	}
+	return e
+}
+
+// Shorthands of the common errors
+
+// BadRequest returns a BAD_REQUEST error
+func BadRequest(message string) *Error { return New(CodeBadRequest, message) }
+
+// Unauthorized returns an UNAUTHORIZED error
+func Unauthorized(message string) *Error { return New(CodeUnauthorized, message) }
+
+// Forbidden returns a FORBIDDEN error
+func Forbidden(message string) *Error { return New(CodeForbidden, message) }
+
+// NotFound returns a NOT_FOUND error
+func NotFound(message string) *Error { return New(CodeNotFound, message) }
+
+// Conflict returns a CONFLICT error
+func Conflict(message string) *Error { return New(CodeConflict, message) }
+
+// Internal returns an INTERNAL_ERROR caused by err, with a generic message
+func Internal(err error) *Error { return Wrap(err, CodeInternal, "Internal server error") }
+
+// From returns err as an *Error; errors without a code are internal errors
+func From(err error) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	return Internal(err)
+}
+
+// Is reports whether err is an *Error with the code
+func Is(err error, code Code) bool {
+	var appErr *Error
+	return errors.As(err, &appErr) && appErr.Code == code
+}
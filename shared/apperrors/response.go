@@ -0,0 +1,35 @@
+package apperrors
+
+import (
+	"log"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Body returns the JSON response of the error: the message as "error", the "code", the "details"
+// if any and the extra fields
+func (e *Error) Body() gin.H {
+	body := gin.H{"error": e.Message, "code": e.Code}
+	for key, value := range e.Fields {
+		body[key] = value
+	}
+	if e.Details != nil {
+		body["details"] = e.Details
+	}
+	return body
+}
+
+// Respond writes the error with its status. Server errors are logged with their cause.
+func Respond(c *gin.Context, err error) {
+	appErr := From(err)
+	if appErr.Status() >= 500 && appErr.Err != nil {
+		log.Printf("❌ %s %s: %v", c.Request.Method, c.Request.URL.Path, appErr)
+	}
+	c.JSON(appErr.Status(), appErr.Body())
+}
+
+// Abort writes the error like Respond and stops the handler chain, for middleware
+func Abort(c *gin.Context, err error) {
+	Respond(c, err)
+	c.Abort()
+}
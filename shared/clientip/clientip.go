@@ -0,0 +1,89 @@
+// Package clientip makes c.ClientIP() return the address of the client that called the API, not that
+// of a load balancer or the API gateway. The gateway only reads X-Forwarded-For from the proxies of
+// TRUSTED_PROXIES and forwards the resulting address to the services in a signed header; services
+// take the address from that header when its signature is valid and ignore X-Forwarded-For.
+package clientip
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"strings"
+
+	"forgecrud-backend/shared/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Headers set by the API gateway. Client supplied values are always dropped.
+const (
+	Header          = "X-Client-IP"
+	SignatureHeader = "X-Client-IP-Signature" // hex(HMAC-SHA256(FORWARDED_IP_SECRET, client IP))
+)
+
+// ConfigureGateway trusts X-Forwarded-For only from the proxies of TRUSTED_PROXIES. Without any, the
+// address of the connection is the client's.
+func ConfigureGateway(router *gin.Engine) {
+	proxies := trustedProxies()
+	if err := router.SetTrustedProxies(proxies); err != nil {
+		log.Printf("⚠️  Warning: Invalid TRUSTED_PROXIES, trusting no proxy: %v", err)
+		_ = router.SetTrustedProxies(nil)
+	}
+}
+
+// ConfigureService makes c.ClientIP() return the client IP forwarded by the gateway, and the address
+// of the connection for requests without a valid signature, such as calls between services
+func ConfigureService(router *gin.Engine) {
+	_ = router.SetTrustedProxies(nil)
+	router.TrustedPlatform = Header
+	router.Use(verify)
+}
+
+// Forward sets the client IP headers of a request to a service
+func Forward(req *http.Request, ip string) {
+	req.Header.Del(Header)
+	req.Header.Del(SignatureHeader)
+	if ip == "" {
+		return
+	}
+	req.Header.Set(Header, ip)
+	req.Header.Set(SignatureHeader, sign(ip))
+}
+
+// verify drops the client IP header when it was not signed by the gateway, so gin falls back to the
+// address of the connection
+func verify(c *gin.Context) {
+	ip := c.GetHeader(Header)
+	signature, err := hex.DecodeString(c.GetHeader(SignatureHeader))
+	if ip != "" && (err != nil || !hmac.Equal(signature, mac(ip))) {
+		c.Request.Header.Del(Header)
+	}
+	c.Next()
+}
+
+func sign(ip string) string {
+	return hex.EncodeToString(mac(ip))
+}
+
+func mac(ip string) []byte {
+	cfg := config.GetConfig()
+	secret := cfg.ForwardedIPSecret
+	if secret == "" {
+		secret = cfg.JWTSecret
+	}
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(ip))
+	return h.Sum(nil)
+}
+
+func trustedProxies() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(config.GetConfig().TrustedProxies, ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
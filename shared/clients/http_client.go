@@ -0,0 +1,277 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"forgecrud-backend/shared/config"
+)
+
+// ErrCircuitOpen is returned without calling a service whose circuit breaker is open
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// Circuit breaker states
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half-open" // One trial call is let through
+)
+
+// NewHTTPClient returns a client calling the named service, e.g. "permissions". Calls share the pooled
+// connections of every client, are retried when idempotent and fail fast while the service's circuit
+// breaker is open. The timeout bounds a whole call, retries included.
+func NewHTTPClient(service string, timeout time.Duration) *http.Client {
+	return &http.Client{Timeout: timeout, Transport: Transport(service)}
+}
+
+// Transport returns the round tripper of the named service, for callers bounding calls themselves,
+// like the gateway's proxy whose downloads may stream for minutes
+func Transport(service string) http.RoundTripper {
+	return &transport{target: targetOf(service)}
+}
+
+type retryableKey struct{}
+
+// Retryable marks the requests made with the context as safe to repeat whatever their method, like a
+// POST that only reads
+func Retryable(ctx context.Context) context.Context {
+	return context.WithValue(ctx, retryableKey{}, true)
+}
+
+var (
+	sharedTransport     *http.Transport
+	sharedTransportOnce sync.Once
+)
+
+// pooledTransport is the connection pool of all clients, made on the first call so clients may be
+// declared before the configuration is loaded
+func pooledTransport() *http.Transport {
+	sharedTransportOnce.Do(func() {
+		cfg := config.GetConfig()
+		sharedTransport = http.DefaultTransport.(*http.Transport).Clone()
+		sharedTransport.DialContext = (&net.Dialer{Timeout: 5 * time.Second, KeepAlive: 30 * time.Second}).DialContext
+		sharedTransport.TLSHandshakeTimeout = 5 * time.Second
+		sharedTransport.MaxIdleConns = 0
+		sharedTransport.MaxIdleConnsPerHost = cfg.HTTPClientMaxIdleConnsPerHost
+		sharedTransport.IdleConnTimeout = 90 * time.Second
+	})
+	return sharedTransport
+}
+
+// transport retries idempotent calls and keeps the breaker and the statistics of its service
+type transport struct {
+	target *target
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	cfg := config.GetConfig()
+	base := pooledTransport()
+	retries := 0
+	if retryable(req) {
+		retries = cfg.HTTPClientMaxRetries
+	}
+
+	if !t.target.allow() {
+		t.target.rejected.Add(1)
+		return nil, fmt.Errorf("%s: %w", t.target.name, ErrCircuitOpen)
+	}
+	for attempt := 0; ; attempt++ {
+		started := time.Now()
+		resp, err := base.RoundTrip(req)
+		t.target.requests.Add(1)
+		t.target.latencyMicros.Add(time.Since(started).Microseconds())
+
+		// Calls the caller gave up on say nothing about the service, unlike timeouts
+		if err != nil && errors.Is(req.Context().Err(), context.Canceled) {
+			t.target.record(outcomeIgnored)
+			return nil, err
+		}
+		failed := err != nil || retryStatus(resp.StatusCode)
+		if failed {
+			t.target.failures.Add(1)
+			t.target.record(outcomeFailure)
+		} else {
+			t.target.record(outcomeSuccess)
+		}
+		if !failed || attempt >= retries || !t.target.allow() {
+			return resp, err
+		}
+
+		if resp != nil {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+			resp.Body.Close()
+		}
+		t.target.retries.Add(1)
+
+		// Exponential backoff with jitter, so callers failing together do not retry together
+		backoff := time.Duration(cfg.HTTPClientRetryBackoffMs) * time.Millisecond << attempt
+		backoff += time.Duration(rand.Int63n(int64(backoff)/2 + 1))
+		timer := time.NewTimer(backoff)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			t.target.record(outcomeIgnored)
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				t.target.record(outcomeIgnored)
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// retryable reports whether a request may be sent again: idempotent, or marked with Retryable, and
+// with a body that can be read again
+func retryable(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	marked, _ := req.Context().Value(retryableKey{}).(bool)
+	return marked
+}
+
+// retryStatus reports whether the status says the service, or a proxy before it, is unavailable
+func retryStatus(status int) bool {
+	return status == http.StatusBadGateway || status == http.StatusServiceUnavailable || status == http.StatusGatewayTimeout
+}
+
+type outcome int
+
+const (
+	outcomeSuccess outcome = iota
+	outcomeFailure
+	outcomeIgnored
+)
+
+// target is a called service with its circuit breaker and statistics
+type target struct {
+	name string
+
+	mutex         sync.Mutex
+	state         string
+	failed        int // Consecutive failures
+	openedAt      time.Time
+	requests      atomic.Int64
+	failures      atomic.Int64
+	retries       atomic.Int64
+	rejected      atomic.Int64
+	latencyMicros atomic.Int64
+}
+
+var (
+	targetsMutex sync.Mutex
+	targets      = map[string]*target{}
+)
+
+// targetOf returns the target of a service; clients of the same service share its breaker
+func targetOf(name string) *target {
+	targetsMutex.Lock()
+	defer targetsMutex.Unlock()
+	if t, ok := targets[name]; ok {
+		return t
+	}
+	t := &target{name: name, state: CircuitClosed}
+	targets[name] = t
+	return t
+}
+
+// allow reports whether a call may be made, letting one trial call through once an open breaker
+// cooled down
+func (t *target) allow() bool {
+	cfg := config.GetConfig()
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	switch {
+	case cfg.HTTPClientBreakerFailures <= 0 || t.state == CircuitClosed:
+		return true
+	case t.state == CircuitOpen && time.Since(t.openedAt) >= time.Duration(cfg.HTTPClientBreakerCooldownSeconds)*time.Second:
+		t.state = CircuitHalfOpen
+		return true
+	default:
+		return false
+	}
+}
+
+func (t *target) record(result outcome) {
+	cfg := config.GetConfig()
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	switch {
+	case result == outcomeSuccess:
+		if t.state != CircuitClosed {
+			log.Printf("✅ Circuit breaker of %s closed", t.name)
+		}
+		t.state, t.failed = CircuitClosed, 0
+	case result == outcomeIgnored:
+		// A trial that ended without an answer lets the next call try again
+		if t.state == CircuitHalfOpen {
+			t.state, t.openedAt = CircuitOpen, time.Time{}
+		}
+	case t.state == CircuitHalfOpen:
+		t.state, t.openedAt = CircuitOpen, time.Now()
+	default:
+		t.failed++
+		if cfg.HTTPClientBreakerFailures > 0 && t.failed >= cfg.HTTPClientBreakerFailures && t.state == CircuitClosed {
+			log.Printf("⚠️  Circuit breaker of %s opened after %d consecutive failures", t.name, t.failed)
+			t.state, t.openedAt = CircuitOpen, time.Now()
+		}
+	}
+}
+
+// TargetStats counts the calls made to a service since the process started
+type TargetStats struct {
+	Service   string  `json:"service"`
+	Circuit   string  `json:"circuit"`
+	Requests  int64   `json:"requests"` // Attempts, retries included
+	Failures  int64   `json:"failures"` // Attempts without an answer or answered with 502, 503 or 504
+	Retries   int64   `json:"retries"`
+	Rejected  int64   `json:"rejected"` // Calls failed at once by the open breaker
+	LatencyMs float64 `json:"latency_ms"`
+}
+
+// Stats returns the statistics of every called service, by name
+func Stats() []TargetStats {
+	targetsMutex.Lock()
+	defer targetsMutex.Unlock()
+
+	stats := make([]TargetStats, 0, len(targets))
+	for _, t := range targets {
+		t.mutex.Lock()
+		state := t.state
+		t.mutex.Unlock()
+		stats = append(stats, TargetStats{
+			Service:   t.name,
+			Circuit:   state,
+			Requests:  t.requests.Load(),
+			Failures:  t.failures.Load(),
+			Retries:   t.retries.Load(),
+			Rejected:  t.rejected.Load(),
+			LatencyMs: float64(t.latencyMicros.Load()) / 1000,
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Service < stats[j].Service })
+	return stats
+}
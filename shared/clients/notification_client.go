@@ -8,6 +8,9 @@ import (
 	"time"
 
 	"forgecrud-backend/shared/config"
+	"forgecrud-backend/shared/messaging"
+
+	"github.com/google/uuid"
 )
 
 // NotificationClient handles communication with notification service
@@ -20,10 +23,8 @@ type NotificationClient struct {
 func NewNotificationClient() *NotificationClient {
 	cfg := config.GetConfig()
 	return &NotificationClient{
-		baseURL: cfg.APIGatewayURL,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		baseURL:    cfg.APIGatewayURL,
+		httpClient: NewHTTPClient("notification", 30*time.Second),
 	}
 }
 
@@ -69,26 +70,28 @@ type SystemAlertEmailRequest struct {
 	Timestamp        string   `json:"timestamp"`
 }
 
+// EmailRequest is a custom email, with a body or rendered from a template, optionally with documents
+// of document-service attached (e.g. an exported report)
+type EmailRequest struct {
+	To                    []string               `json:"to"`
+	CC                    []string               `json:"cc,omitempty"`
+	BCC                   []string               `json:"bcc,omitempty"`
+	Subject               string                 `json:"subject,omitempty"`
+	Body                  string                 `json:"body,omitempty"`
+	IsHTML                bool                   `json:"is_html"`
+	TemplateID            string                 `json:"template_id,omitempty"`
+	TemplateVars          map[string]interface{} `json:"template_vars,omitempty"`
+	Locale                string                 `json:"locale,omitempty"`
+	AttachmentDocumentIDs []uuid.UUID            `json:"attachment_document_ids,omitempty"`
+}
+
+// UserActionEmailRequest is an action on a resource, emailed to the resource owner. The notification
+// service renders it with the user_action.<action> template, or the generic user_action template, and
+// translates the action's name and priority, so new action types need no new fields here; extra
+// template variables go in Data.
 type UserActionEmailRequest struct {
-	AdminName    string             `json:"admin_name"`
-	UserName     string             `json:"user_name"`
-	UserEmail    string             `json:"user_email"`
-	UserRole     string             `json:"user_role"`
-	IPAddress    string             `json:"ip_address"`
-	ActionType   string             `json:"action_type"`
-	ResourceName string             `json:"resource_name"`
-	Status       string             `json:"status"`
-	Priority     string             `json:"priority"`
-	PriorityText string             `json:"priority_text"`
-	Description  string             `json:"description,omitempty"`
-	Changes      []UserActionChange `json:"changes,omitempty"`
-	Timestamp    string             `json:"timestamp"`
-}
-
-type UserActionChange struct {
-	Field    string `json:"field"`
-	OldValue string `json:"old_value"`
-	NewValue string `json:"new_value"`
+	Action string `json:"action"` // Event type, e.g. document.deleted
+	messaging.ActivityData
 }
 
 // EmailResponse represents email service response
@@ -120,6 +123,11 @@ func (nc *NotificationClient) SendPasswordResetEmail(to, name, token string) err
 	return nc.sendEmailRequest("/api/notifications/email/password-reset", request)
 }
 
+// SendEmail queues a custom email, attaching the listed documents
+func (nc *NotificationClient) SendEmail(req EmailRequest) error {
+	return nc.sendEmailRequest("/api/notifications/email/send", req)
+}
+
 // SendCriticalErrorEmail sends critical error notification to admins
 func (nc *NotificationClient) SendCriticalErrorEmail(req CriticalErrorEmailRequest) error {
 	return nc.sendEmailRequest("/api/notifications/email/critical-error", req)
@@ -130,7 +138,7 @@ func (nc *NotificationClient) SendSystemAlertEmail(req SystemAlertEmailRequest)
 	return nc.sendEmailRequest("/api/notifications/email/system-alert", req)
 }
 
-// SendUserActionEmail sends user action notifications
+// SendUserActionEmail emails the owner of a resource about an action on it
 func (nc *NotificationClient) SendUserActionEmail(req UserActionEmailRequest) error {
 	return nc.sendEmailRequest("/api/notifications/email/user-action", req)
 }
@@ -149,7 +157,8 @@ func (nc *NotificationClient) sendEmailRequest(endpoint string, payload interfac
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
+	// Emails are queued, the service answers 202 Accepted
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		return fmt.Errorf("notification service returned status: %d", resp.StatusCode)
 	}
 
@@ -1,14 +1,51 @@
 package config
 
 import (
+	"fmt"
 	"log"
 	"os"
+	"slices"
+	"sort"
 	"strconv"
-
-	"github.com/joho/godotenv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
+// RateLimit is the request budget of a client within a window, after which it is blocked
+type RateLimit struct {
+	MaxRequests int
+	Window      time.Duration
+	Block       time.Duration
+}
+
+// RateLimits are the limits of the gateway and of the auth endpoints
+type RateLimits struct {
+	Global        RateLimit
+	Login         RateLimit
+	Register      RateLimit
+	PasswordReset RateLimit
+}
+
+// ReadOnly switches the whole platform or single services to read-only mode
+type ReadOnly struct {
+	Global   bool     // Every service
+	Services []string // Names of read-only services, e.g. core-service
+}
+
+// Enabled reports whether the service is read-only
+func (r ReadOnly) Enabled(service string) bool {
+	return r.Global || slices.Contains(r.Services, service)
+}
+
 type Config struct {
+	// Deployment environment selecting the .env overlay (dev, stage or prod)
+	Environment string
+
+	// Interval of re-reading the reloadable values from the environment files (0 = only on SIGHUP)
+	ConfigReloadIntervalSeconds int
+
 	// Database
 	DBHost     string
 	DBPort     string
@@ -17,13 +54,26 @@ type Config struct {
 	DBName     string
 	DBSSLMode  string
 
+	// Database connection pool and readiness thresholds
+	DBMaxOpenConns           int
+	DBMaxIdleConns           int
+	DBConnMaxLifetimeMinutes int
+	DBConnMaxIdleTimeMinutes int
+	DBPoolSaturationPercent  int // Readiness fails when this share of the open connection limit is in use
+	DBHealthMaxLatencyMs     int // Readiness fails when a ping takes longer
+
 	// JWT
 	JWTSecret            string
-	JWTExpireHours       string
-	JWTRefreshExpireDays string
+	JWTExpireHours       int
+	JWTRefreshExpireDays int
 
 	// API Gateway URL
-	APIGatewayURL string
+	APIGatewayURL    string
+	OpenAPIServerURL string // Public URL of the API in the merged OpenAPI document, API_GATEWAY_URL when empty
+
+	// Client IP
+	TrustedProxies    string // Comma separated IPs or CIDRs of the load balancers in front of the gateway whose X-Forwarded-For is trusted
+	ForwardedIPSecret string // Signs the client IP and tenant the gateway forwards to the services, JWT_SECRET when empty
 
 	// Super Admin
 	SuperAdminEmail    string
@@ -33,7 +83,7 @@ type Config struct {
 	RedisHost     string
 	RedisPort     string
 	RedisPassword string
-	RedisDB       string
+	RedisDB       int
 
 	// Email Configuration
 	EmailFrom     string
@@ -44,25 +94,86 @@ type Config struct {
 	SMTPPassword  string
 	SMTPUseTLS    bool
 
-	// Rate Limiting
-	RateLimitMaxRequests          string
-	RateLimitTimeWindowSeconds    string
-	RateLimitBlockDurationMinutes string
-
-	// Login Rate Limiting
-	LoginRateLimitMaxAttempts   string
-	LoginRateLimitWindowSeconds string
-	LoginRateLimitBlockMinutes  string
-
-	// Register Rate Limiting
-	RegisterRateLimitMaxAttempts string
-	RegisterRateLimitWindowHours string
-	RegisterRateLimitBlockHours  string
-
-	// Password Reset Rate Limiting
-	PasswordResetMaxAttempts   string
-	PasswordResetWindowMinutes string
-	PasswordResetBlockHours    string
+	// Email Provider Configuration
+	EmailProvider         string
+	EmailFailoverProvider string
+	SMTPRateLimit         int
+	SESRegion             string
+	SESAccessKeyID        string
+	SESSecretAccessKey    string
+	SESRateLimit          int
+	SendGridAPIKey        string
+	SendGridRateLimit     int
+	MailgunDomain         string
+	MailgunAPIKey         string
+	MailgunAPIURL         string
+	MailgunRateLimit      int
+
+	// Email Queue Configuration
+	EmailQueueMaxAttempts int
+
+	// Email Attachment Configuration
+	EmailAttachmentMaxBytes      int64
+	EmailAttachmentTotalMaxBytes int64
+	EmailAttachmentAllowedTypes  string
+
+	// Email Tracking Configuration
+	EmailTrackingEnabled     bool
+	EmailTrackingBaseURL     string
+	EmailTrackingSecret      string
+	SESWebhookTopicARN       string
+	SendGridWebhookPublicKey string
+	MailgunWebhookSigningKey string
+
+	// Push Notification Configuration
+	FCMCredentialsFile string
+	APNSKeyFile        string
+	APNSKeyID          string
+	APNSTeamID         string
+	APNSTopic          string
+	APNSProduction     bool
+
+	// SMS Configuration
+	SMSProvider                   string
+	TwilioAccountSID              string
+	TwilioAuthToken               string
+	TwilioFromNumber              string
+	VonageAPIKey                  string
+	VonageAPISecret               string
+	VonageFrom                    string
+	SMSVerificationCodeTTLMinutes int
+	SMSVerificationMaxPerHour     int
+	SMSSecurityAlertMaxPerHour    int
+
+	// Notification Integration Configuration
+	IntegrationMaxAttempts    int
+	IntegrationTimeoutSeconds int
+
+	// Notification Retention Configuration
+	NotificationArchiveAfterDays int
+	NotificationPurgeAfterDays   int
+
+	// Localization Configuration
+	DefaultLocale string
+
+	// Rate Limiting (reloadable at runtime)
+	RateLimits RateLimits
+
+	// Feature flags (reloadable at runtime)
+	FeatureFlags map[string]bool
+
+	// Read-only mode (reloadable at runtime)
+	ReadOnly ReadOnly
+
+	// IP Reputation (gateway): failed requests score client IPs, which are banned at the threshold
+	IPReputationEnabled         bool
+	IPReputationBanScore        int    // Score at which an address is banned
+	IPReputationWeight401       int    // Score of an unauthorized response
+	IPReputationWeight403       int    // Score of a forbidden response
+	IPReputationWeight429       int    // Score of a rate limited response
+	IPReputationHalfLifeMinutes int    // Time in which a score halves
+	IPBanMinutes                int    // Duration of an automatic ban
+	IPReputationAllowlist       string // Comma separated IPs or CIDRs that are never banned
 
 	// Frontend URL
 	FrontendURL string
@@ -74,6 +185,23 @@ type Config struct {
 	NotificationServiceURL string
 	DocumentServiceURL     string
 
+	// Graceful shutdown of the services
+	ShutdownDrainSeconds   int // /health/ready fails this long before the listener closes, so load balancers stop routing first
+	ShutdownTimeoutSeconds int // In-flight requests get this long to finish
+
+	// Readiness checks of the dependencies
+	HealthCheckTimeoutMs int // A dependency not answering in time is reported down
+
+	// Calls between the services
+	HTTPClientMaxRetries             int // Retries of idempotent calls that failed or got 502, 503 or 504
+	HTTPClientRetryBackoffMs         int // Wait before the first retry, doubled for each further one
+	HTTPClientBreakerFailures        int // Consecutive failures opening a service's circuit breaker, 0 disables it
+	HTTPClientBreakerCooldownSeconds int // An open breaker lets a trial call through after this long
+	HTTPClientMaxIdleConnsPerHost    int // Kept-alive connections reused per service
+
+	// Storage Configuration
+	StorageDriver string
+
 	// MinIO Configuration
 	MinIOServerURL    string
 	MinIORootUser     string
@@ -81,35 +209,205 @@ type Config struct {
 	MinIOUseSSL       bool
 	MinIOBucketName   string
 
+	// AWS S3 Configuration
+	S3Endpoint        string
+	S3UseSSL          bool
+	S3Region          string
+	S3AccessKeyID     string
+	S3SecretAccessKey string
+	S3BucketName      string
+
+	// Google Cloud Storage Configuration (HMAC keys)
+	GCSAccessKeyID     string
+	GCSSecretAccessKey string
+	GCSBucketName      string
+
+	// Azure Blob Storage Configuration
+	AzureStorageAccount   string
+	AzureStorageKey       string
+	AzureStorageContainer string
+	AzureStorageEndpoint  string
+
+	// Local Filesystem Storage Configuration
+	LocalStoragePath string
+
 	// Document Service Configuration
 	DocumentServiceMaxFileSize  string
 	DocumentServiceAllowedTypes string
+
+	// Avatar Configuration
+	AvatarBaseURL        string
+	AvatarMaxUploadBytes int64
+
+	// Image Rendering Configuration
+	ImageRenderMaxDimension   int
+	ImageRenderMaxSourceBytes int64
+	ImageWebPCommand          string
+
+	// Share Link Configuration
+	ShareBaseURL    string
+	ShareLinkSecret string
+
+	// Chunked Upload Configuration
+	UploadChunkSize       int64
+	UploadMaxFileSize     int64
+	UploadSessionTTLHours int
+
+	// Archive Extraction Configuration
+	ArchiveMaxEntries        int
+	ArchiveMaxDepth          int
+	ArchiveMaxExtractedBytes int64
+
+	// Upload File Type Configuration
+	UploadBlockedExtensions string // Comma separated, blocked unless an organization's file type policy allows executables
+	UploadBlockedMimeTypes  string // Comma separated sniffed MIME types, like UploadBlockedExtensions
+
+	// Folder Download Configuration
+	FolderDownloadMaxBytes     int64
+	FolderDownloadMaxFiles     int
+	FolderExportRetentionHours int
+
+	// Document Lock Configuration
+	DocumentLockTTLMinutes int
+	DocumentLockMaxMinutes int
+
+	// Malware Scanning Configuration
+	ScannerDriver        string
+	ClamAVAddress        string
+	ClamAVTimeoutSeconds int
+	ScanIntervalSeconds  int
+
+	// Full-text Search Configuration
+	SearchLanguage       string
+	OCRCommand           string
+	IndexMaxFileBytes    int64
+	IndexIntervalSeconds int
+
+	// Soft Delete Configuration
+	SoftDeleteRetentionDays int
+	TrashRetentionDays      int
+
+	// Document Version Retention Configuration
+	VersionRetentionKeepLast int
+	VersionRetentionDays     int
+
+	// Document Retention Configuration
+	RetentionIntervalHours int
+
+	// Storage Integrity Configuration
+	IntegrityCheckIntervalHours int
+	IntegrityRepairOrphans      bool
+	IntegrityOrphanGraceHours   int
+
+	// Folder Stats Configuration
+	FolderStatsRollupIntervalHours int
+
+	// Storage Outbox Configuration
+	StorageOutboxMaxAttempts      int
+	StorageOutboxIntervalSeconds  int
+	StorageReconcileIntervalHours int
+
+	// Backup Configuration
+	BackupPath          string // Directory holding the backups, best a volume of its own
+	BackupEncryptionKey string // Base64 of a 32 byte AES key; backups are not encrypted when empty
+	BackupIntervalHours int
+	BackupRetentionDays int
+	BackupKeepMin       int // Newest backups kept whatever their age
+
+	// Webhook Configuration
+	WebhookMaxAttempts    int
+	WebhookTimeoutSeconds int
+
+	// Event Bus Configuration
+	EventBusDriver string
+	EventBusStream string
+	EventBusMaxLen int
+
+	// WebSocket Fan-out Configuration
+	WebSocketFanoutDriver  string
+	WebSocketFanoutChannel string
+
+	// Session Revocation Configuration
+	SessionRevocationDriver       string
+	SessionRevocationChannel      string
+	SessionValidationCacheSeconds int // How long the gateway trusts a checked session
+
+	// Read-only Switch Configuration
+	ReadOnlyDriver         string
+	ReadOnlyKey            string
+	ReadOnlyRefreshSeconds int // How long an instance takes to pick up a switch set through the admin API
+
+	// Background Job Configuration
+	JobQueueDriver       string
+	JobQueuePrefix       string
+	JobWorkers           int
+	JobMaxAttempts       int
+	JobLeaseSeconds      int // A job whose worker stopped renewing its lease this long ago is run again
+	JobDeadRetentionDays int
+
+	// Scheduler Configuration
+	SchedulerLockDriver           string
+	SchedulerLockKey              string
+	SchedulerLeaseSeconds         int // The leader must renew its lock within this time or another instance takes over
+	SchedulerHistoryRetentionDays int
+	AuditLogRetentionDays         int
+	AuditLogArchiveEnabled        bool // Archive the audit logs of each day to storage before they are deleted
+
+	// Audit Log Writer Configuration (gateway)
+	AuditLogBufferSize      int    // Logs waiting to be inserted; further logs are dropped by AuditLogDropPolicy
+	AuditLogBatchSize       int    // Logs inserted per statement
+	AuditLogFlushIntervalMs int    // A partial batch is inserted after this long
+	AuditLogDropPolicy      string // "newest" drops incoming logs when the buffer is full, "oldest" the oldest buffered one
+	AuditLogMaxBodyBytes    int    // JSON request bodies up to this size are kept in the audit log, 0 keeps none
+
+	// Redaction Configuration
+	RedactFields string // Comma separated field names and JSON paths masked in audit logs and error details
+
+	// Unified Response Configuration (gateway)
+	RawResponsePaths string // Comma separated path prefixes whose responses are passed through unwrapped
+
+	// Quota Configuration (defaults for organizations without their own quota, 0 = unlimited)
+	QuotaDefaultMaxUsers                int
+	QuotaDefaultMaxStorageBytes         int64
+	QuotaDefaultMaxDocuments            int
+	QuotaDefaultMaxAPIRequestsPerDay    int
+	QuotaDefaultMaxAPIRequestsPerMinute int
+
+	// Shares of the daily API request budget, in percent and ascending, at which the organization owner is notified
+	QuotaAPIAlertPercents []int
+
+	// Counters of the API request budgets: "redis" shares them between the gateway instances, with "none"
+	// each instance counts on its own
+	APIQuotaCounterDriver string
+	APIQuotaKeyPrefix     string
+	APIQuotaFlushSeconds  int // How often a gateway instance adds the requests it metered to the daily usage
+
+	// Default storage quota of users without their own quota, counted over the folders they own (0 = unlimited)
+	QuotaDefaultUserMaxStorageBytes int64
 }
 
-var cfg *Config
+var (
+	current atomic.Pointer[Config]
+	loadMu  sync.Mutex
+)
 
-// LoadConfig loads configuration from environment variables
+// LoadConfig loads configuration from the environment files and environment variables, and exits
+// when a required value is missing or invalid
 func LoadConfig() {
-	envPaths := []string{
-		".env",
-		"../.env",
-		"../../.env",
-	}
-
-	envLoaded := false
-	for _, path := range envPaths {
-		if err := godotenv.Load(path); err == nil {
-			log.Printf("✅ Environment loaded from: %s", path)
-			envLoaded = true
-			break
-		}
-	}
+	loadMu.Lock()
+	defer loadMu.Unlock()
 
-	if !envLoaded {
+	if loaded := loadEnvFiles(); len(loaded) > 0 {
+		log.Printf("✅ Environment loaded from: %s", strings.Join(loaded, ", "))
+	} else {
 		log.Println("Warning: .env file not found, using system environment variables")
 	}
+	envProblems = nil
+
+	cfg := &Config{
+		Environment:                 environment(),
+		ConfigReloadIntervalSeconds: getEnvAsInt("CONFIG_RELOAD_INTERVAL_SECONDS", 0),
 
-	cfg = &Config{
 		// Database
 		DBHost:     getEnv("DB_HOST", "localhost"),
 		DBPort:     getEnv("DB_PORT", "5432"),
@@ -118,13 +416,25 @@ func LoadConfig() {
 		DBName:     getEnv("DB_NAME", "forgecrud"),
 		DBSSLMode:  getEnv("DB_SSLMODE", "disable"),
 
+		DBMaxOpenConns:           getEnvAsInt("DB_MAX_OPEN_CONNS", 100),
+		DBMaxIdleConns:           getEnvAsInt("DB_MAX_IDLE_CONNS", 10),
+		DBConnMaxLifetimeMinutes: getEnvAsInt("DB_CONN_MAX_LIFETIME_MINUTES", 60),
+		DBConnMaxIdleTimeMinutes: getEnvAsInt("DB_CONN_MAX_IDLE_TIME_MINUTES", 10),
+		DBPoolSaturationPercent:  getEnvAsInt("DB_POOL_SATURATION_PERCENT", 90),
+		DBHealthMaxLatencyMs:     getEnvAsInt("DB_HEALTH_MAX_LATENCY_MS", 500),
+
 		// JWT
 		JWTSecret:            getEnv("JWT_SECRET", "your-secret-key-change-this"),
-		JWTExpireHours:       getEnv("JWT_EXPIRE_HOURS", "3"),
-		JWTRefreshExpireDays: getEnv("JWT_REFRESH_EXPIRE_DAYS", "1"),
+		JWTExpireHours:       getEnvAsInt("JWT_EXPIRE_HOURS", 3),
+		JWTRefreshExpireDays: getEnvAsInt("JWT_REFRESH_EXPIRE_DAYS", 1),
 
 		// API Gateway URL
-		APIGatewayURL: getEnv("API_GATEWAY_URL", "http://localhost:8000"),
+		APIGatewayURL:    getEnv("API_GATEWAY_URL", "http://localhost:8000"),
+		OpenAPIServerURL: getEnv("OPENAPI_SERVER_URL", ""),
+
+		// Client IP
+		TrustedProxies:    getEnv("TRUSTED_PROXIES", ""),
+		ForwardedIPSecret: getEnv("FORWARDED_IP_SECRET", ""),
 
 		// Super Admin
 		SuperAdminEmail:    getEnv("SUPER_ADMIN_EMAIL", "admin@forgecrud.com"),
@@ -134,7 +444,7 @@ func LoadConfig() {
 		RedisHost:     getEnv("REDIS_HOST", "localhost"),
 		RedisPort:     getEnv("REDIS_PORT", "6379"),
 		RedisPassword: getEnv("REDIS_PASSWORD", ""),
-		RedisDB:       getEnv("REDIS_DB", "0"),
+		RedisDB:       getEnvAsInt("REDIS_DB", 0),
 
 		// Email Configuration
 		EmailFrom:     getEnv("EMAIL_FROM", "noreply@forgecrud.com"),
@@ -145,25 +455,82 @@ func LoadConfig() {
 		SMTPPassword:  getEnv("SMTP_PASSWORD", ""),
 		SMTPUseTLS:    getEnvAsBool("SMTP_USE_TLS", false),
 
-		// Rate Limiting - Genel
-		RateLimitMaxRequests:          getEnv("RATE_LIMIT_MAX_REQUESTS", "100"),
-		RateLimitTimeWindowSeconds:    getEnv("RATE_LIMIT_TIME_WINDOW_SECONDS", "60"),
-		RateLimitBlockDurationMinutes: getEnv("RATE_LIMIT_BLOCK_DURATION_MINUTES", "15"),
-
-		// Login Rate Limiting
-		LoginRateLimitMaxAttempts:   getEnv("LOGIN_RATE_LIMIT_MAX_ATTEMPTS", "5"),
-		LoginRateLimitWindowSeconds: getEnv("LOGIN_RATE_LIMIT_WINDOW_SECONDS", "300"),
-		LoginRateLimitBlockMinutes:  getEnv("LOGIN_RATE_LIMIT_BLOCK_MINUTES", "30"),
-
-		// Register Rate Limiting
-		RegisterRateLimitMaxAttempts: getEnv("REGISTER_RATE_LIMIT_MAX_ATTEMPTS", "3"),
-		RegisterRateLimitWindowHours: getEnv("REGISTER_RATE_LIMIT_WINDOW_HOURS", "24"),
-		RegisterRateLimitBlockHours:  getEnv("REGISTER_RATE_LIMIT_BLOCK_HOURS", "48"),
-
-		// Password Reset Rate Limiting
-		PasswordResetMaxAttempts:   getEnv("PASSWORD_RESET_MAX_ATTEMPTS", "3"),
-		PasswordResetWindowMinutes: getEnv("PASSWORD_RESET_WINDOW_MINUTES", "60"),
-		PasswordResetBlockHours:    getEnv("PASSWORD_RESET_BLOCK_HOURS", "24"),
+		// Email Provider Configuration (rate limits are messages per second, 0 for no limit)
+		EmailProvider:         getEnv("EMAIL_PROVIDER", "smtp"),
+		EmailFailoverProvider: getEnv("EMAIL_FAILOVER_PROVIDER", ""),
+		SMTPRateLimit:         getEnvAsInt("SMTP_RATE_LIMIT", 0),
+		SESRegion:             getEnv("SES_REGION", ""),
+		SESAccessKeyID:        getEnv("SES_ACCESS_KEY_ID", ""),
+		SESSecretAccessKey:    getEnv("SES_SECRET_ACCESS_KEY", ""),
+		SESRateLimit:          getEnvAsInt("SES_RATE_LIMIT", 14),
+		SendGridAPIKey:        getEnv("SENDGRID_API_KEY", ""),
+		SendGridRateLimit:     getEnvAsInt("SENDGRID_RATE_LIMIT", 0),
+		MailgunDomain:         getEnv("MAILGUN_DOMAIN", ""),
+		MailgunAPIKey:         getEnv("MAILGUN_API_KEY", ""),
+		MailgunAPIURL:         getEnv("MAILGUN_API_URL", "https://api.mailgun.net"),
+		MailgunRateLimit:      getEnvAsInt("MAILGUN_RATE_LIMIT", 0),
+
+		// Email Queue Configuration
+		EmailQueueMaxAttempts: getEnvAsInt("EMAIL_QUEUE_MAX_ATTEMPTS", 6),
+
+		// Email Attachment Configuration (MIME types, "image/*" allows every image type)
+		EmailAttachmentMaxBytes:      int64(getEnvAsInt("EMAIL_ATTACHMENT_MAX_BYTES", 10*1024*1024)),
+		EmailAttachmentTotalMaxBytes: int64(getEnvAsInt("EMAIL_ATTACHMENT_TOTAL_MAX_BYTES", 15*1024*1024)),
+		EmailAttachmentAllowedTypes:  getEnv("EMAIL_ATTACHMENT_ALLOWED_TYPES", "application/pdf,text/csv,text/plain,application/zip,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet,application/vnd.openxmlformats-officedocument.wordprocessingml.document,application/vnd.ms-excel,application/msword,image/*"),
+
+		// Email Tracking Configuration (provider webhooks are rejected while their key is empty)
+		EmailTrackingEnabled:     getEnvAsBool("EMAIL_TRACKING_ENABLED", false),
+		EmailTrackingBaseURL:     getEnv("EMAIL_TRACKING_BASE_URL", "http://localhost:8000/api/notifications/email/track"),
+		EmailTrackingSecret:      getEnv("EMAIL_TRACKING_SECRET", ""),
+		SESWebhookTopicARN:       getEnv("SES_WEBHOOK_TOPIC_ARN", ""),
+		SendGridWebhookPublicKey: getEnv("SENDGRID_WEBHOOK_PUBLIC_KEY", ""),
+		MailgunWebhookSigningKey: getEnv("MAILGUN_WEBHOOK_SIGNING_KEY", ""),
+
+		// Push Notification Configuration (a provider is disabled while its credentials are empty)
+		FCMCredentialsFile: getEnv("FCM_CREDENTIALS_FILE", ""),
+		APNSKeyFile:        getEnv("APNS_KEY_FILE", ""),
+		APNSKeyID:          getEnv("APNS_KEY_ID", ""),
+		APNSTeamID:         getEnv("APNS_TEAM_ID", ""),
+		APNSTopic:          getEnv("APNS_TOPIC", ""),
+		APNSProduction:     getEnvAsBool("APNS_PRODUCTION", false),
+
+		// SMS Configuration ("twilio" or "vonage", empty disables SMS)
+		SMSProvider:                   getEnv("SMS_PROVIDER", ""),
+		TwilioAccountSID:              getEnv("TWILIO_ACCOUNT_SID", ""),
+		TwilioAuthToken:               getEnv("TWILIO_AUTH_TOKEN", ""),
+		TwilioFromNumber:              getEnv("TWILIO_FROM_NUMBER", ""),
+		VonageAPIKey:                  getEnv("VONAGE_API_KEY", ""),
+		VonageAPISecret:               getEnv("VONAGE_API_SECRET", ""),
+		VonageFrom:                    getEnv("VONAGE_FROM", "ForgeCRUD"),
+		SMSVerificationCodeTTLMinutes: getEnvAsInt("SMS_VERIFICATION_CODE_TTL_MINUTES", 10),
+		SMSVerificationMaxPerHour:     getEnvAsInt("SMS_VERIFICATION_MAX_PER_HOUR", 5),
+		SMSSecurityAlertMaxPerHour:    getEnvAsInt("SMS_SECURITY_ALERT_MAX_PER_HOUR", 10),
+
+		// Notification Integration Configuration
+		IntegrationMaxAttempts:    getEnvAsInt("INTEGRATION_MAX_ATTEMPTS", 6),
+		IntegrationTimeoutSeconds: getEnvAsInt("INTEGRATION_TIMEOUT_SECONDS", 10),
+
+		// Notification Retention Configuration (0 disables a rule)
+		NotificationArchiveAfterDays: getEnvAsInt("NOTIFICATION_ARCHIVE_AFTER_DAYS", 90),
+		NotificationPurgeAfterDays:   getEnvAsInt("NOTIFICATION_PURGE_AFTER_DAYS", 365),
+
+		// Localization Configuration
+		DefaultLocale: getEnv("DEFAULT_LOCALE", "en"),
+
+		// Rate Limiting, feature flags and read-only mode
+		RateLimits:   loadRateLimits(),
+		FeatureFlags: loadFeatureFlags(),
+		ReadOnly:     loadReadOnly(),
+
+		// IP Reputation
+		IPReputationEnabled:         getEnvAsBool("IP_REPUTATION_ENABLED", true),
+		IPReputationBanScore:        getEnvAsInt("IP_REPUTATION_BAN_SCORE", 100),
+		IPReputationWeight401:       getEnvAsInt("IP_REPUTATION_WEIGHT_401", 10),
+		IPReputationWeight403:       getEnvAsInt("IP_REPUTATION_WEIGHT_403", 5),
+		IPReputationWeight429:       getEnvAsInt("IP_REPUTATION_WEIGHT_429", 2),
+		IPReputationHalfLifeMinutes: getEnvAsInt("IP_REPUTATION_HALF_LIFE_MINUTES", 10),
+		IPBanMinutes:                getEnvAsInt("IP_BAN_MINUTES", 60),
+		IPReputationAllowlist:       getEnv("IP_REPUTATION_ALLOWLIST", ""),
 
 		// Frontend URL
 		FrontendURL: getEnv("FRONTEND_URL", "http://localhost:3000"),
@@ -175,6 +542,23 @@ func LoadConfig() {
 		NotificationServiceURL: getEnv("NOTIFICATION_SERVICE_URL", "http://localhost:8004"),
 		DocumentServiceURL:     getEnv("DOCUMENT_SERVICE_URL", "http://localhost:8005"),
 
+		// Graceful shutdown
+		ShutdownDrainSeconds:   getEnvAsInt("SHUTDOWN_DRAIN_SECONDS", 5),
+		ShutdownTimeoutSeconds: getEnvAsInt("SHUTDOWN_TIMEOUT_SECONDS", 30),
+
+		// Readiness checks
+		HealthCheckTimeoutMs: getEnvAsInt("HEALTH_CHECK_TIMEOUT_MS", 2000),
+
+		// Calls between the services
+		HTTPClientMaxRetries:             getEnvAsInt("HTTP_CLIENT_MAX_RETRIES", 2),
+		HTTPClientRetryBackoffMs:         getEnvAsInt("HTTP_CLIENT_RETRY_BACKOFF_MS", 100),
+		HTTPClientBreakerFailures:        getEnvAsInt("HTTP_CLIENT_BREAKER_FAILURES", 5),
+		HTTPClientBreakerCooldownSeconds: getEnvAsInt("HTTP_CLIENT_BREAKER_COOLDOWN_SECONDS", 30),
+		HTTPClientMaxIdleConnsPerHost:    getEnvAsInt("HTTP_CLIENT_MAX_IDLE_CONNS_PER_HOST", 32),
+
+		// Storage Configuration ("minio", "s3", "gcs", "azure" or "local")
+		StorageDriver: getEnv("STORAGE_DRIVER", "minio"),
+
 		// MinIO Configuration
 		MinIOServerURL:    getEnv("MINIO_SERVER_URL", "http://localhost:9000"),
 		MinIORootUser:     getEnv("MINIO_ROOT_USER", "minioadmin"),
@@ -182,137 +566,313 @@ func LoadConfig() {
 		MinIOUseSSL:       getEnvAsBool("MINIO_USE_SSL", false),
 		MinIOBucketName:   getEnv("MINIO_BUCKET_NAME", "forgecrud-documents"),
 
+		// AWS S3 Configuration
+		S3Endpoint:        getEnv("S3_ENDPOINT", "https://s3.amazonaws.com"),
+		S3UseSSL:          getEnvAsBool("S3_USE_SSL", true),
+		S3Region:          getEnv("S3_REGION", "us-east-1"),
+		S3AccessKeyID:     getEnv("S3_ACCESS_KEY_ID", ""),
+		S3SecretAccessKey: getEnv("S3_SECRET_ACCESS_KEY", ""),
+		S3BucketName:      getEnv("S3_BUCKET_NAME", "forgecrud-documents"),
+
+		// Google Cloud Storage Configuration (HMAC keys of a service account)
+		GCSAccessKeyID:     getEnv("GCS_ACCESS_KEY_ID", ""),
+		GCSSecretAccessKey: getEnv("GCS_SECRET_ACCESS_KEY", ""),
+		GCSBucketName:      getEnv("GCS_BUCKET_NAME", "forgecrud-documents"),
+
+		// Azure Blob Storage Configuration (empty endpoint uses https://<account>.blob.core.windows.net)
+		AzureStorageAccount:   getEnv("AZURE_STORAGE_ACCOUNT", ""),
+		AzureStorageKey:       getEnv("AZURE_STORAGE_KEY", ""),
+		AzureStorageContainer: getEnv("AZURE_STORAGE_CONTAINER", "forgecrud-documents"),
+		AzureStorageEndpoint:  getEnv("AZURE_STORAGE_ENDPOINT", ""),
+
+		// Local Filesystem Storage Configuration
+		LocalStoragePath: getEnv("LOCAL_STORAGE_PATH", "./data/documents"),
+
 		// Document Service Configuration
 		DocumentServiceMaxFileSize:  getEnv("DOCUMENT_SERVICE_MAX_FILE_SIZE", "100MB"),
 		DocumentServiceAllowedTypes: getEnv("DOCUMENT_SERVICE_ALLOWED_TYPES", ".pdf,.doc,.docx,.txt,.jpg,.jpeg,.png"),
+
+		// Avatar Configuration (public base URL the avatar object keys are appended to, e.g. a CDN in front of the gateway)
+		AvatarBaseURL:        getEnv("AVATAR_BASE_URL", "http://localhost:8000/api/avatars"),
+		AvatarMaxUploadBytes: int64(getEnvAsInt("AVATAR_MAX_UPLOAD_BYTES", 5*1024*1024)),
+
+		// Image Rendering Configuration (empty WebP command disables WebP output)
+		ImageRenderMaxDimension:   getEnvAsInt("IMAGE_RENDER_MAX_DIMENSION", 4096),
+		ImageRenderMaxSourceBytes: int64(getEnvAsInt("IMAGE_RENDER_MAX_SOURCE_BYTES", 25*1024*1024)),
+		ImageWebPCommand:          getEnv("IMAGE_WEBP_COMMAND", ""),
+
+		// Share Link Configuration (links are signed with the JWT secret unless SHARE_LINK_SECRET is set)
+		ShareBaseURL:    getEnv("SHARE_BASE_URL", "http://localhost:8000/api/shares"),
+		ShareLinkSecret: getEnv("SHARE_LINK_SECRET", ""),
+
+		// Chunked Upload Configuration (chunks below 5MB are raised to the S3 multipart minimum)
+		UploadChunkSize:       int64(getEnvAsInt("UPLOAD_CHUNK_SIZE", 8*1024*1024)),
+		UploadMaxFileSize:     int64(getEnvAsInt("UPLOAD_MAX_FILE_SIZE", 10*1024*1024*1024)),
+		UploadSessionTTLHours: getEnvAsInt("UPLOAD_SESSION_TTL_HOURS", 24),
+
+		// Archive Extraction Configuration (limits of ZIP archives uploaded with extract=true)
+		ArchiveMaxEntries:        getEnvAsInt("ARCHIVE_MAX_ENTRIES", 1000),
+		ArchiveMaxDepth:          getEnvAsInt("ARCHIVE_MAX_DEPTH", 10),
+		ArchiveMaxExtractedBytes: int64(getEnvAsInt("ARCHIVE_MAX_EXTRACTED_BYTES", 1024*1024*1024)),
+
+		// Upload File Type Configuration (execution-prone types, matched against the sniffed type, not the client's Content-Type)
+		UploadBlockedExtensions: getEnv("UPLOAD_BLOCKED_EXTENSIONS", ".exe,.dll,.com,.scr,.msi,.bat,.cmd,.ps1,.vbs,.js,.jar,.sh,.apk,.app,.cpl,.hta,.lnk,.pif,.wsf,.reg"),
+		UploadBlockedMimeTypes:  getEnv("UPLOAD_BLOCKED_MIME_TYPES", "application/x-msdownload,application/x-executable,application/x-mach-binary,application/x-msi,application/java-archive,application/java-vm,application/vnd.android.package-archive,text/x-shellscript,application/x-bat,text/x-powershell,text/vbscript,text/javascript,application/hta,application/x-wsf,text/x-ms-regedit"),
+
+		// Folder Download Configuration (limits of ZIP downloads, larger folders are exported, 0 = unlimited)
+		FolderDownloadMaxBytes:     int64(getEnvAsInt("FOLDER_DOWNLOAD_MAX_BYTES", 1024*1024*1024)),
+		FolderDownloadMaxFiles:     getEnvAsInt("FOLDER_DOWNLOAD_MAX_FILES", 5000),
+		FolderExportRetentionHours: getEnvAsInt("FOLDER_EXPORT_RETENTION_HOURS", 24),
+
+		// Document Lock Configuration (default and longest check-out duration)
+		DocumentLockTTLMinutes: getEnvAsInt("DOCUMENT_LOCK_TTL_MINUTES", 60),
+		DocumentLockMaxMinutes: getEnvAsInt("DOCUMENT_LOCK_MAX_MINUTES", 1440),
+
+		// Malware Scanning Configuration ("clamav" or "none")
+		ScannerDriver:        getEnv("SCANNER_DRIVER", "none"),
+		ClamAVAddress:        getEnv("CLAMAV_ADDRESS", "localhost:3310"),
+		ClamAVTimeoutSeconds: getEnvAsInt("CLAMAV_TIMEOUT_SECONDS", 300),
+		ScanIntervalSeconds:  getEnvAsInt("SCAN_INTERVAL_SECONDS", 10),
+
+		// Full-text Search Configuration (empty OCR command disables OCR of images)
+		SearchLanguage:       getEnv("SEARCH_LANGUAGE", "simple"),
+		OCRCommand:           getEnv("OCR_COMMAND", ""),
+		IndexMaxFileBytes:    int64(getEnvAsInt("INDEX_MAX_FILE_BYTES", 20*1024*1024)),
+		IndexIntervalSeconds: getEnvAsInt("INDEX_INTERVAL_SECONDS", 15),
+
+		// Soft Delete Configuration (0 disables purging)
+		SoftDeleteRetentionDays: getEnvAsInt("SOFT_DELETE_RETENTION_DAYS", 30),
+		TrashRetentionDays:      getEnvAsInt("TRASH_RETENTION_DAYS", 30),
+
+		// Document Version Retention Configuration (0 disables a rule, both 0 keep every version)
+		VersionRetentionKeepLast: getEnvAsInt("VERSION_RETENTION_KEEP_LAST", 0),
+		VersionRetentionDays:     getEnvAsInt("VERSION_RETENTION_DAYS", 0),
+
+		// Document Retention Configuration (0 disables auto-delete)
+		RetentionIntervalHours: getEnvAsInt("RETENTION_INTERVAL_HOURS", 6),
+
+		// Storage Integrity Configuration (0 disables the scheduled check)
+		IntegrityCheckIntervalHours: getEnvAsInt("INTEGRITY_CHECK_INTERVAL_HOURS", 24),
+		IntegrityRepairOrphans:      getEnvAsBool("INTEGRITY_REPAIR_ORPHANS", false),
+		IntegrityOrphanGraceHours:   getEnvAsInt("INTEGRITY_ORPHAN_GRACE_HOURS", 24),
+
+		// Folder Stats Configuration (0 disables the scheduled rollup)
+		FolderStatsRollupIntervalHours: getEnvAsInt("FOLDER_STATS_ROLLUP_INTERVAL_HOURS", 24),
+
+		// Storage Outbox Configuration (0 disables the scheduled reconciliation)
+		StorageOutboxMaxAttempts:      getEnvAsInt("STORAGE_OUTBOX_MAX_ATTEMPTS", 10),
+		StorageOutboxIntervalSeconds:  getEnvAsInt("STORAGE_OUTBOX_INTERVAL_SECONDS", 30),
+		StorageReconcileIntervalHours: getEnvAsInt("STORAGE_RECONCILE_INTERVAL_HOURS", 6),
+
+		// Backup Configuration (0 disables scheduled backups, 0 days keeps every backup)
+		BackupPath:          getEnv("BACKUP_PATH", "./backups"),
+		BackupEncryptionKey: getEnv("BACKUP_ENCRYPTION_KEY", ""),
+		BackupIntervalHours: getEnvAsInt("BACKUP_INTERVAL_HOURS", 0),
+		BackupRetentionDays: getEnvAsInt("BACKUP_RETENTION_DAYS", 30),
+		BackupKeepMin:       getEnvAsInt("BACKUP_KEEP_MIN", 3),
+
+		// Webhook Configuration
+		WebhookMaxAttempts:    getEnvAsInt("WEBHOOK_MAX_ATTEMPTS", 6),
+		WebhookTimeoutSeconds: getEnvAsInt("WEBHOOK_TIMEOUT_SECONDS", 10),
+
+		// Event Bus Configuration ("redis" or "none")
+		EventBusDriver: getEnv("EVENT_BUS_DRIVER", "redis"),
+		EventBusStream: getEnv("EVENT_BUS_STREAM", "forgecrud:events"),
+		EventBusMaxLen: getEnvAsInt("EVENT_BUS_MAX_LEN", 100000),
+
+		// WebSocket Fan-out Configuration ("redis" or "none")
+		WebSocketFanoutDriver:  getEnv("WEBSOCKET_FANOUT_DRIVER", "redis"),
+		WebSocketFanoutChannel: getEnv("WEBSOCKET_FANOUT_CHANNEL", "forgecrud:websocket"),
+
+		// Session Revocation Configuration ("redis" or "none")
+		SessionRevocationDriver:       getEnv("SESSION_REVOCATION_DRIVER", "redis"),
+		SessionRevocationChannel:      getEnv("SESSION_REVOCATION_CHANNEL", "forgecrud:session-revocations"),
+		SessionValidationCacheSeconds: getEnvAsInt("SESSION_VALIDATION_CACHE_SECONDS", 60),
+
+		// Read-only Switch Configuration ("redis" or "none")
+		ReadOnlyDriver:         getEnv("READ_ONLY_DRIVER", "redis"),
+		ReadOnlyKey:            getEnv("READ_ONLY_KEY", "forgecrud:read-only"),
+		ReadOnlyRefreshSeconds: getEnvAsInt("READ_ONLY_REFRESH_SECONDS", 5),
+
+		// Background Job Configuration ("redis" or "none")
+		JobQueueDriver:       getEnv("JOB_QUEUE_DRIVER", "redis"),
+		JobQueuePrefix:       getEnv("JOB_QUEUE_PREFIX", "forgecrud:jobs"),
+		JobWorkers:           getEnvAsInt("JOB_WORKERS", 5),
+		JobMaxAttempts:       getEnvAsInt("JOB_MAX_ATTEMPTS", 5),
+		JobLeaseSeconds:      getEnvAsInt("JOB_LEASE_SECONDS", 60),
+		JobDeadRetentionDays: getEnvAsInt("JOB_DEAD_RETENTION_DAYS", 7),
+
+		// Scheduler Configuration ("postgres", "redis" or "none"; 0 disables a retention)
+		SchedulerLockDriver:           getEnv("SCHEDULER_LOCK_DRIVER", "postgres"),
+		SchedulerLockKey:              getEnv("SCHEDULER_LOCK_KEY", "forgecrud:scheduler"),
+		SchedulerLeaseSeconds:         getEnvAsInt("SCHEDULER_LEASE_SECONDS", 30),
+		SchedulerHistoryRetentionDays: getEnvAsInt("SCHEDULER_HISTORY_RETENTION_DAYS", 30),
+		AuditLogRetentionDays:         getEnvAsInt("AUDIT_LOG_RETENTION_DAYS", 365),
+		AuditLogArchiveEnabled:        getEnvAsBool("AUDIT_LOG_ARCHIVE_ENABLED", false),
+
+		// Audit Log Writer Configuration ("newest" or "oldest")
+		AuditLogBufferSize:      getEnvAsInt("AUDIT_LOG_BUFFER_SIZE", 10000),
+		AuditLogBatchSize:       getEnvAsInt("AUDIT_LOG_BATCH_SIZE", 500),
+		AuditLogFlushIntervalMs: getEnvAsInt("AUDIT_LOG_FLUSH_INTERVAL_MS", 1000),
+		AuditLogDropPolicy:      getEnv("AUDIT_LOG_DROP_POLICY", "newest"),
+		AuditLogMaxBodyBytes:    getEnvAsInt("AUDIT_LOG_MAX_BODY_BYTES", 65536),
+
+		// Redaction Configuration (field names match at any depth, dotted paths from the root)
+		RedactFields: getEnv("REDACT_FIELDS", "password,current_password,new_password,confirm_password,token,access_token,refresh_token,"+
+			"secret,client_secret,private_key,api_key,authorization,verification_code,reset_code"),
+
+		// Unified Response Configuration (e.g. "/api/partners,/api/legacy")
+		RawResponsePaths: getEnv("RAW_RESPONSE_PATHS", ""),
+
+		// Quota Configuration (0 = unlimited)
+		QuotaDefaultMaxUsers:                getEnvAsInt("QUOTA_DEFAULT_MAX_USERS", 0),
+		QuotaDefaultMaxStorageBytes:         int64(getEnvAsInt("QUOTA_DEFAULT_MAX_STORAGE_BYTES", 0)),
+		QuotaDefaultMaxDocuments:            getEnvAsInt("QUOTA_DEFAULT_MAX_DOCUMENTS", 0),
+		QuotaDefaultMaxAPIRequestsPerDay:    getEnvAsInt("QUOTA_DEFAULT_MAX_API_REQUESTS_PER_DAY", 0),
+		QuotaDefaultMaxAPIRequestsPerMinute: getEnvAsInt("QUOTA_DEFAULT_MAX_API_REQUESTS_PER_MINUTE", 0),
+		QuotaAPIAlertPercents:               loadAlertPercents(),
+		APIQuotaCounterDriver:               getEnv("API_QUOTA_COUNTER_DRIVER", "redis"),
+		APIQuotaKeyPrefix:                   getEnv("API_QUOTA_KEY_PREFIX", "forgecrud:api-quota"),
+		APIQuotaFlushSeconds:                getEnvAsInt("API_QUOTA_FLUSH_SECONDS", 10),
+		QuotaDefaultUserMaxStorageBytes:     int64(getEnvAsInt("QUOTA_DEFAULT_USER_MAX_STORAGE_BYTES", 0)),
+	}
+
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("❌ Invalid configuration: %v", err)
 	}
 
-	log.Println("✅ Configuration loaded successfully")
+	current.Store(cfg)
+	log.Printf("✅ Configuration loaded successfully (environment: %s)", cfg.Environment)
 }
 
-// GetConfig returns the current configuration
+// GetConfig returns the current configuration. A reload swaps in a new value, so read the reloadable
+// fields through GetConfig on every use instead of keeping the pointer.
 func GetConfig() *Config {
-	if cfg == nil {
-		LoadConfig()
+	if c := current.Load(); c != nil {
+		return c
 	}
-	return cfg
+	LoadConfig()
+	return current.Load()
 }
 
-// GetField returns a configuration field by name
-func (c *Config) GetField(key string) string {
-	switch key {
-	// Database
-	case "DBHost":
-		return c.DBHost
-	case "DBPort":
-		return c.DBPort
-	case "DBUser":
-		return c.DBUser
-	case "DBPassword":
-		return c.DBPassword
-	case "DBName":
-		return c.DBName
-	case "DBSSLMode":
-		return c.DBSSLMode
-
-	// Services
-	case "APIGatewayURL":
-		return c.APIGatewayURL
-
-	// JWT
-	case "JWTSecret":
-		return c.JWTSecret
-	case "JWTExpireHours":
-		return c.JWTExpireHours
-
-	// Rate Limiting
-	case "RateLimitMaxRequests":
-		return c.RateLimitMaxRequests
-	case "RateLimitTimeWindowSeconds":
-		return c.RateLimitTimeWindowSeconds
-	case "RateLimitBlockDurationMinutes":
-		return c.RateLimitBlockDurationMinutes
-	case "LoginRateLimitMaxAttempts":
-		return c.LoginRateLimitMaxAttempts
-	case "LoginRateLimitWindowSeconds":
-		return c.LoginRateLimitWindowSeconds
-	case "LoginRateLimitBlockMinutes":
-		return c.LoginRateLimitBlockMinutes
-	case "RegisterRateLimitMaxAttempts":
-		return c.RegisterRateLimitMaxAttempts
-	case "RegisterRateLimitWindowHours":
-		return c.RegisterRateLimitWindowHours
-	case "RegisterRateLimitBlockHours":
-		return c.RegisterRateLimitBlockHours
-	case "PasswordResetMaxAttempts":
-		return c.PasswordResetMaxAttempts
-	case "PasswordResetWindowMinutes":
-		return c.PasswordResetWindowMinutes
-	case "PasswordResetBlockHours":
-		return c.PasswordResetBlockHours
-
-	// Service URLs
-	case "AuthServiceURL":
-		return c.AuthServiceURL
-	case "PermissionServiceURL":
-		return c.PermissionServiceURL
-	case "CoreServiceURL":
-		return c.CoreServiceURL
-	case "NotificationServiceURL":
-		return c.NotificationServiceURL
-
-	default:
-		return ""
+// loadRateLimits reads the rate limits of the services
+func loadRateLimits() RateLimits {
+	return RateLimits{
+		Global: RateLimit{
+			MaxRequests: getEnvAsInt("RATE_LIMIT_MAX_REQUESTS", 100),
+			Window:      time.Duration(getEnvAsInt("RATE_LIMIT_TIME_WINDOW_SECONDS", 60)) * time.Second,
+			Block:       time.Duration(getEnvAsInt("RATE_LIMIT_BLOCK_DURATION_MINUTES", 15)) * time.Minute,
+		},
+		Login: RateLimit{
+			MaxRequests: getEnvAsInt("LOGIN_RATE_LIMIT_MAX_ATTEMPTS", 5),
+			Window:      time.Duration(getEnvAsInt("LOGIN_RATE_LIMIT_WINDOW_SECONDS", 300)) * time.Second,
+			Block:       time.Duration(getEnvAsInt("LOGIN_RATE_LIMIT_BLOCK_MINUTES", 30)) * time.Minute,
+		},
+		Register: RateLimit{
+			MaxRequests: getEnvAsInt("REGISTER_RATE_LIMIT_MAX_ATTEMPTS", 3),
+			Window:      time.Duration(getEnvAsInt("REGISTER_RATE_LIMIT_WINDOW_HOURS", 24)) * time.Hour,
+			Block:       time.Duration(getEnvAsInt("REGISTER_RATE_LIMIT_BLOCK_HOURS", 48)) * time.Hour,
+		},
+		PasswordReset: RateLimit{
+			MaxRequests: getEnvAsInt("PASSWORD_RESET_MAX_ATTEMPTS", 3),
+			Window:      time.Duration(getEnvAsInt("PASSWORD_RESET_WINDOW_MINUTES", 60)) * time.Minute,
+			Block:       time.Duration(getEnvAsInt("PASSWORD_RESET_BLOCK_HOURS", 24)) * time.Hour,
+		},
 	}
 }
 
-// getEnv gets environment variable with default value
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// loadAlertPercents reads QUOTA_API_ALERT_PERCENTS, a comma separated list of percentages from 1 to 100,
+// in ascending order
+func loadAlertPercents() []int {
+	var percents []int
+	for _, entry := range strings.Split(getEnv("QUOTA_API_ALERT_PERCENTS", "80,100"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		percent, err := strconv.Atoi(entry)
+		if err != nil || percent < 1 || percent > 100 {
+			envProblems = append(envProblems, fmt.Sprintf("QUOTA_API_ALERT_PERCENTS: %q is not a percentage from 1 to 100", entry))
+			continue
+		}
+		percents = append(percents, percent)
 	}
-	return defaultValue
+	sort.Ints(percents)
+	return percents
 }
 
-// getEnvAsInt gets environment variable as integer with default value
-func getEnvAsInt(key string, defaultValue int) int {
-	if value := os.Getenv(key); value != "" {
-		if intValue, err := strconv.Atoi(value); err == nil {
-			return intValue
+// loadFeatureFlags reads FEATURE_FLAGS, a comma separated list of flag names, each optionally followed
+// by =true or =false (e.g. "webdav,ocr=false")
+func loadFeatureFlags() map[string]bool {
+	flags := make(map[string]bool)
+	for _, entry := range strings.Split(getEnv("FEATURE_FLAGS", ""), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
 		}
+
+		name, value, hasValue := strings.Cut(entry, "=")
+		name = strings.TrimSpace(name)
+		enabled := true
+		if hasValue {
+			parsed, err := strconv.ParseBool(strings.TrimSpace(value))
+			if err != nil {
+				envProblems = append(envProblems, fmt.Sprintf("FEATURE_FLAGS: %q is not true or false", entry))
+				continue
+			}
+			enabled = parsed
+		}
+		flags[name] = enabled
 	}
-	return defaultValue
+	return flags
 }
 
-// GetRateLimitMaxRequests returns the rate limit max requests as integer
-func (c *Config) GetRateLimitMaxRequests() int {
-	if value, err := strconv.Atoi(c.RateLimitMaxRequests); err == nil {
-		return value
+// loadReadOnly reads READ_ONLY_MODE and READ_ONLY_SERVICES, a comma separated list of service names
+func loadReadOnly() ReadOnly {
+	readOnly := ReadOnly{Global: getEnvAsBool("READ_ONLY_MODE", false)}
+	for _, entry := range strings.Split(getEnv("READ_ONLY_SERVICES", ""), ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			readOnly.Services = append(readOnly.Services, entry)
+		}
 	}
-	return 100
+	return readOnly
 }
 
-// GetRateLimitTimeWindowSeconds returns the rate limit time window as integer
-func (c *Config) GetRateLimitTimeWindowSeconds() int {
-	if value, err := strconv.Atoi(c.RateLimitTimeWindowSeconds); err == nil {
+// FeatureEnabled reports whether a feature flag is switched on; unknown flags are off
+func (c *Config) FeatureEnabled(name string) bool {
+	return c.FeatureFlags[name]
+}
+
+// getEnv gets environment variable with default value
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
 		return value
 	}
-	return 60
+	return defaultValue
 }
 
-// GetRateLimitBlockDurationMinutes returns the rate limit block duration as integer
-func (c *Config) GetRateLimitBlockDurationMinutes() int {
-	if value, err := strconv.Atoi(c.RateLimitBlockDurationMinutes); err == nil {
-		return value
+// getEnvAsInt gets environment variable as integer with default value; a value that is not an
+// integer is reported by Validate
+func getEnvAsInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		intValue, err := strconv.Atoi(value)
+		if err == nil {
+			return intValue
+		}
+		envProblems = append(envProblems, fmt.Sprintf("%s: %q is not an integer", key, value))
 	}
-	return 15
+	return defaultValue
 }
 
+// getEnvAsBool gets environment variable as boolean with default value; a value that is not a
+// boolean is reported by Validate
 func getEnvAsBool(key string, defaultValue bool) bool {
 	if value := os.Getenv(key); value != "" {
-		if boolValue, err := strconv.ParseBool(value); err == nil {
+		boolValue, err := strconv.ParseBool(value)
+		if err == nil {
 			return boolValue
 		}
+		envProblems = append(envProblems, fmt.Sprintf("%s: %q is not true or false", key, value))
 	}
 	return defaultValue
 }
@@ -0,0 +1,131 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/joho/godotenv"
+)
+
+// Deployment environments, each with an optional .env.<name> overlay next to the .env file
+const (
+	EnvDev   = "dev"
+	EnvStage = "stage"
+	EnvProd  = "prod"
+)
+
+// envDirs are searched in order for the environment files; services run from their own directory
+var envDirs = []string{".", "..", "../.."}
+
+var (
+	// externalEnv holds the variables set before the first load; the environment files never override them
+	externalEnv     map[string]bool
+	externalEnvOnce sync.Once
+
+	// fileEnv holds the variables last set from the environment files
+	fileEnv = map[string]string{}
+)
+
+// environment returns the deployment environment named by APP_ENV, defaulting to dev
+func environment() string {
+	name, _ := normalizeEnvironment(os.Getenv("APP_ENV"))
+	return name
+}
+
+// normalizeEnvironment maps APP_ENV to one of the deployment environments, accepting the long names too
+func normalizeEnvironment(name string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", EnvDev, "development", "local":
+		return EnvDev, true
+	case EnvStage, "staging":
+		return EnvStage, true
+	case EnvProd, "production":
+		return EnvProd, true
+	default:
+		return EnvDev, false
+	}
+}
+
+// loadEnvFiles reads .env and the overlay of the deployment environment from the first directory
+// holding either, and sets their variables. The overlay wins over .env, and variables set outside the
+// files win over both. Variables removed from the files since the last load are unset again. It returns the files read.
+func loadEnvFiles() []string {
+	captureExternalEnv()
+
+	values, loaded := readEnvFiles()
+
+	for name := range fileEnv {
+		if _, ok := values[name]; !ok {
+			os.Unsetenv(name)
+		}
+	}
+	for name, value := range values {
+		os.Setenv(name, value)
+	}
+	fileEnv = values
+	return loaded
+}
+
+func captureExternalEnv() {
+	externalEnvOnce.Do(func() {
+		externalEnv = make(map[string]bool)
+		for _, entry := range os.Environ() {
+			name, _, _ := strings.Cut(entry, "=")
+			externalEnv[name] = true
+		}
+	})
+}
+
+// Setenv sets a variable like one set before the process started, which the environment files never
+// override, for tools and tests that configure the process themselves. The next LoadConfig reads it.
+func Setenv(name, value string) error {
+	loadMu.Lock()
+	defer loadMu.Unlock()
+
+	captureExternalEnv()
+	externalEnv[name] = true
+	delete(fileEnv, name)
+	return os.Setenv(name, value)
+}
+
+// readEnvFiles returns the merged variables of the environment files, leaving out the external ones,
+// and the paths it read them from
+func readEnvFiles() (map[string]string, []string) {
+	for _, dir := range envDirs {
+		values := make(map[string]string)
+		var loaded []string
+
+		basePath := filepath.Join(dir, ".env")
+		if base, err := godotenv.Read(basePath); err == nil {
+			values = base
+			loaded = append(loaded, basePath)
+		}
+
+		appEnv := values["APP_ENV"]
+		if externalEnv["APP_ENV"] {
+			appEnv = os.Getenv("APP_ENV")
+		}
+		envName, _ := normalizeEnvironment(appEnv)
+		overlayPath := filepath.Join(dir, ".env."+envName)
+		if overlay, err := godotenv.Read(overlayPath); err == nil {
+			for key, value := range overlay {
+				values[key] = value
+			}
+			loaded = append(loaded, overlayPath)
+		}
+
+		if len(loaded) == 0 {
+			continue
+		}
+
+		for name := range values {
+			if externalEnv[name] {
+				delete(values, name)
+			}
+		}
+		return values, loaded
+	}
+	return map[string]string{}, nil
+}
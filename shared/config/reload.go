@@ -0,0 +1,102 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+var (
+	reloadMu    sync.Mutex
+	reloadHooks []func(*Config)
+)
+
+// OnReload registers a hook called with the new configuration after a reload changed a value
+func OnReload(fn func(*Config)) {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+	reloadHooks = append(reloadHooks, fn)
+}
+
+// Reload re-reads the environment files and applies the values that are safe to change while the
+// service runs: the rate limits, the feature flags and the read-only mode. Everything else keeps its startup value until
+// a restart. Invalid values are refused and the running configuration stays in place.
+func Reload() (bool, error) {
+	loadMu.Lock()
+	defer loadMu.Unlock()
+
+	loadEnvFiles()
+	envProblems = nil
+
+	previous := current.Load()
+	if previous == nil {
+		return false, errors.New("configuration not loaded")
+	}
+	next := *previous
+	next.RateLimits = loadRateLimits()
+	next.FeatureFlags = loadFeatureFlags()
+	next.ReadOnly = loadReadOnly()
+
+	problems := append(envProblems, next.RateLimits.validate()...)
+	if len(problems) > 0 {
+		return false, errors.New(strings.Join(problems, "; "))
+	}
+
+	if next.RateLimits == previous.RateLimits && reflect.DeepEqual(next.FeatureFlags, previous.FeatureFlags) &&
+		reflect.DeepEqual(next.ReadOnly, previous.ReadOnly) {
+		return false, nil
+	}
+	current.Store(&next)
+
+	reloadMu.Lock()
+	hooks := append([]func(*Config){}, reloadHooks...)
+	reloadMu.Unlock()
+	for _, hook := range hooks {
+		hook(&next)
+	}
+	return true, nil
+}
+
+// Watch reloads the configuration on SIGHUP and every CONFIG_RELOAD_INTERVAL_SECONDS until the
+// context is done
+func Watch(ctx context.Context) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	var tick <-chan time.Time
+	if interval := GetConfig().ConfigReloadIntervalSeconds; interval > 0 {
+		ticker := time.NewTicker(time.Duration(interval) * time.Second)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangup:
+			reload()
+		case <-tick:
+			reload()
+		}
+	}
+}
+
+// reload runs Reload and logs its outcome
+func reload() {
+	changed, err := Reload()
+	switch {
+	case err != nil:
+		log.Printf("❌ Configuration reload refused: %v", err)
+	case changed:
+		log.Println("🔄 Configuration reloaded: rate limits, feature flags and read-only mode updated")
+	}
+}
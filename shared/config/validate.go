@@ -0,0 +1,170 @@
+package config
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// envProblems collects the values the getEnv helpers could not parse during a load
+var envProblems []string
+
+// Placeholder secrets of .env.example and of the defaults, refused in prod
+var placeholderSecrets = map[string]bool{
+	"your-secret-key-change-this":    true,
+	"your-super-secret-jwt-key-here": true,
+	"admin123":                       true,
+}
+
+// minProdSecretLength is the shortest JWT secret accepted in prod
+const minProdSecretLength = 32
+
+// Validate reports every missing or invalid value at once, so a misconfigured service fails at startup
+// instead of on the first request that needs the value
+func (c *Config) Validate() error {
+	problems := append([]string(nil), envProblems...)
+
+	if _, known := normalizeEnvironment(os.Getenv("APP_ENV")); !known {
+		problems = append(problems, fmt.Sprintf("APP_ENV: %q is not dev, stage or prod", os.Getenv("APP_ENV")))
+	}
+
+	required := []struct {
+		name  string
+		value string
+	}{
+		{"DB_HOST", c.DBHost},
+		{"DB_PORT", c.DBPort},
+		{"DB_USER", c.DBUser},
+		{"DB_NAME", c.DBName},
+		{"JWT_SECRET", c.JWTSecret},
+		{"REDIS_HOST", c.RedisHost},
+		{"REDIS_PORT", c.RedisPort},
+	}
+	for _, field := range required {
+		if strings.TrimSpace(field.value) == "" {
+			problems = append(problems, field.name+": required")
+		}
+	}
+
+	// The services listen on the port of their own URL
+	serviceURLs := []struct {
+		name  string
+		value string
+	}{
+		{"API_GATEWAY_URL", c.APIGatewayURL},
+		{"AUTH_SERVICE_URL", c.AuthServiceURL},
+		{"PERMISSION_SERVICE_URL", c.PermissionServiceURL},
+		{"CORE_SERVICE_URL", c.CoreServiceURL},
+		{"NOTIFICATION_SERVICE_URL", c.NotificationServiceURL},
+		{"DOCUMENT_SERVICE_URL", c.DocumentServiceURL},
+	}
+	for _, service := range serviceURLs {
+		parsed, err := url.Parse(service.value)
+		if err != nil || parsed.Scheme == "" || parsed.Hostname() == "" || parsed.Port() == "" {
+			problems = append(problems, fmt.Sprintf("%s: %q must be an absolute URL with a port", service.name, service.value))
+		}
+	}
+
+	positive := []struct {
+		name  string
+		value int
+	}{
+		{"JWT_EXPIRE_HOURS", c.JWTExpireHours},
+		{"JWT_REFRESH_EXPIRE_DAYS", c.JWTRefreshExpireDays},
+		{"DB_MAX_OPEN_CONNS", c.DBMaxOpenConns},
+		{"SHUTDOWN_TIMEOUT_SECONDS", c.ShutdownTimeoutSeconds},
+		{"HEALTH_CHECK_TIMEOUT_MS", c.HealthCheckTimeoutMs},
+		{"HTTP_CLIENT_RETRY_BACKOFF_MS", c.HTTPClientRetryBackoffMs},
+		{"HTTP_CLIENT_BREAKER_COOLDOWN_SECONDS", c.HTTPClientBreakerCooldownSeconds},
+		{"HTTP_CLIENT_MAX_IDLE_CONNS_PER_HOST", c.HTTPClientMaxIdleConnsPerHost},
+		{"JOB_WORKERS", c.JobWorkers},
+		{"JOB_MAX_ATTEMPTS", c.JobMaxAttempts},
+		{"JOB_LEASE_SECONDS", c.JobLeaseSeconds},
+		{"SCHEDULER_LEASE_SECONDS", c.SchedulerLeaseSeconds},
+		{"READ_ONLY_REFRESH_SECONDS", c.ReadOnlyRefreshSeconds},
+		{"AUDIT_LOG_BUFFER_SIZE", c.AuditLogBufferSize},
+		{"AUDIT_LOG_BATCH_SIZE", c.AuditLogBatchSize},
+		{"AUDIT_LOG_FLUSH_INTERVAL_MS", c.AuditLogFlushIntervalMs},
+	}
+	for _, field := range positive {
+		if field.value <= 0 {
+			problems = append(problems, fmt.Sprintf("%s: must be greater than 0", field.name))
+		}
+	}
+	if c.RedisDB < 0 {
+		problems = append(problems, "REDIS_DB: must not be negative")
+	}
+	if c.HTTPClientMaxRetries < 0 || c.HTTPClientBreakerFailures < 0 {
+		problems = append(problems, "HTTP_CLIENT_MAX_RETRIES, HTTP_CLIENT_BREAKER_FAILURES: must not be negative")
+	}
+	if c.IPReputationEnabled && (c.IPReputationBanScore <= 0 || c.IPReputationHalfLifeMinutes <= 0 || c.IPBanMinutes <= 0) {
+		problems = append(problems, "IP_REPUTATION_BAN_SCORE, IP_REPUTATION_HALF_LIFE_MINUTES, IP_BAN_MINUTES: must be greater than 0")
+	}
+	if c.IPReputationWeight401 < 0 || c.IPReputationWeight403 < 0 || c.IPReputationWeight429 < 0 {
+		problems = append(problems, "IP_REPUTATION_WEIGHT_401, IP_REPUTATION_WEIGHT_403, IP_REPUTATION_WEIGHT_429: must not be negative")
+	}
+	if c.SessionValidationCacheSeconds < 0 {
+		problems = append(problems, "SESSION_VALIDATION_CACHE_SECONDS: must not be negative")
+	}
+	if c.AuditLogMaxBodyBytes < 0 {
+		problems = append(problems, "AUDIT_LOG_MAX_BODY_BYTES: must not be negative")
+	}
+	if c.ConfigReloadIntervalSeconds < 0 {
+		problems = append(problems, "CONFIG_RELOAD_INTERVAL_SECONDS: must not be negative")
+	}
+	if c.AuditLogDropPolicy != "newest" && c.AuditLogDropPolicy != "oldest" {
+		problems = append(problems, fmt.Sprintf("AUDIT_LOG_DROP_POLICY: %q is not newest or oldest", c.AuditLogDropPolicy))
+	}
+	if c.AuditLogArchiveEnabled && c.AuditLogRetentionDays <= 0 {
+		problems = append(problems, "AUDIT_LOG_ARCHIVE_ENABLED: archives the logs past AUDIT_LOG_RETENTION_DAYS, which must be greater than 0")
+	}
+
+	if c.BackupEncryptionKey != "" {
+		if key, err := base64.StdEncoding.DecodeString(c.BackupEncryptionKey); err != nil || len(key) != 32 {
+			problems = append(problems, "BACKUP_ENCRYPTION_KEY: must be the base64 encoding of 32 bytes, e.g. from openssl rand -base64 32")
+		}
+	}
+	if c.BackupIntervalHours < 0 || c.BackupRetentionDays < 0 || c.BackupKeepMin < 0 {
+		problems = append(problems, "BACKUP_INTERVAL_HOURS, BACKUP_RETENTION_DAYS, BACKUP_KEEP_MIN: must not be negative")
+	}
+
+	problems = append(problems, c.RateLimits.validate()...)
+
+	if c.Environment == EnvProd {
+		if placeholderSecrets[c.JWTSecret] || len(c.JWTSecret) < minProdSecretLength {
+			problems = append(problems, fmt.Sprintf("JWT_SECRET: must be set to a secret of at least %d characters in prod", minProdSecretLength))
+		}
+		if placeholderSecrets[c.SuperAdminPassword] {
+			problems = append(problems, "SUPER_ADMIN_PASSWORD: must not be the default password in prod")
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// validate reports the rate limits that would block every client or never block anyone
+func (r RateLimits) validate() []string {
+	limits := []struct {
+		prefix string
+		limit  RateLimit
+	}{
+		{"RATE_LIMIT", r.Global},
+		{"LOGIN_RATE_LIMIT", r.Login},
+		{"REGISTER_RATE_LIMIT", r.Register},
+		{"PASSWORD_RESET", r.PasswordReset},
+	}
+
+	var problems []string
+	for _, l := range limits {
+		if l.limit.MaxRequests <= 0 || l.limit.Window <= 0 || l.limit.Block < 0 {
+			problems = append(problems, fmt.Sprintf("%s_*: the maximum and the window must be greater than 0 and the block must not be negative", l.prefix))
+		}
+	}
+	return problems
+}
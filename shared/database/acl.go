@@ -0,0 +1,182 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"forgecrud-backend/shared/database/models/document"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ObjectAccess is a user's access to a folder or document
+type ObjectAccess struct {
+	// Restricted is true when the object or one of its parent folders has access grants
+	Restricted bool `json:"restricted"`
+	// Level is the highest level granted to the user, manage for owners. Empty without a grant.
+	Level string `json:"level"`
+}
+
+// Allows reports whether the user has the required level. Objects without access grants are open
+// to everyone with the matching file-management permission.
+func (a ObjectAccess) Allows(level string) bool {
+	if a.Level != "" {
+		return document.AccessLevelIncludes(a.Level, level)
+	}
+	return !a.Restricted
+}
+
+// Decisive reports whether the access list alone decides the user's access, which is the case for
+// restricted objects and for owners
+func (a ObjectAccess) Decisive() bool {
+	return a.Restricted || a.Level != ""
+}
+
+// AccessLevelForAction maps a permission action to the access level it needs on an object
+func AccessLevelForAction(action string) string {
+	switch action {
+	case "read", "export":
+		return document.AccessLevelRead
+	case "manage":
+		return document.AccessLevelManage
+	default:
+		return document.AccessLevelWrite
+	}
+}
+
+// ResolveObjectAccess returns the user's access to the folder or document with the given ID,
+// including objects in the trash
+func ResolveObjectAccess(db *gorm.DB, userID uuid.UUID, objectType string, objectID uuid.UUID) (ObjectAccess, error) {
+	switch objectType {
+	case document.AccessObjectFolder:
+		var folder document.Folder
+		if err := db.Unscoped().First(&folder, objectID).Error; err != nil {
+			return ObjectAccess{}, err
+		}
+		return FolderAccess(db, userID, &folder)
+	case document.AccessObjectDocument:
+		var doc document.Document
+		if err := db.Unscoped().First(&doc, objectID).Error; err != nil {
+			return ObjectAccess{}, err
+		}
+		return DocumentAccess(db, userID, &doc)
+	default:
+		return ObjectAccess{}, fmt.Errorf("unknown object type %q", objectType)
+	}
+}
+
+// FolderAccess returns the user's access to the folder, inheriting the grants of its parent folders
+func FolderAccess(db *gorm.DB, userID uuid.UUID, folder *document.Folder) (ObjectAccess, error) {
+	return resolveAccess(db, userID, folder.Path, nil, false)
+}
+
+// DocumentAccess returns the user's access to the document, inheriting the grants of its folders
+func DocumentAccess(db *gorm.DB, userID uuid.UUID, doc *document.Document) (ObjectAccess, error) {
+	var folder document.Folder
+	if err := db.Unscoped().Select("path").First(&folder, doc.FolderID).Error; err != nil {
+		return ObjectAccess{}, err
+	}
+	return resolveAccess(db, userID, folder.Path, &doc.ID, doc.UploadedBy == userID)
+}
+
+// resolveAccess combines the grants on the folder at folderPath, its parent folders and the document
+func resolveAccess(db *gorm.DB, userID uuid.UUID, folderPath string, documentID *uuid.UUID, owner bool) (ObjectAccess, error) {
+	var folders []document.Folder
+	if err := db.Unscoped().Select("id", "owner_id", "owner_type").
+		Where("(? = path OR ? LIKE path || '/%')", folderPath, folderPath).
+		Find(&folders).Error; err != nil {
+		return ObjectAccess{}, err
+	}
+
+	folderIDs := make([]uuid.UUID, 0, len(folders))
+	for _, folder := range folders {
+		folderIDs = append(folderIDs, folder.ID)
+		if folder.OwnerType == "user" && folder.OwnerID == userID {
+			owner = true
+		}
+	}
+
+	grantsQuery := db.Model(&document.AccessGrant{})
+	if documentID != nil {
+		grantsQuery = grantsQuery.Where("((object_type = ? AND object_id IN ?) OR (object_type = ? AND object_id = ?))",
+			document.AccessObjectFolder, folderIDs, document.AccessObjectDocument, *documentID)
+	} else {
+		grantsQuery = grantsQuery.Where("object_type = ? AND object_id IN ?", document.AccessObjectFolder, folderIDs)
+	}
+
+	var grants []document.AccessGrant
+	if err := grantsQuery.Find(&grants).Error; err != nil {
+		return ObjectAccess{}, err
+	}
+
+	access := ObjectAccess{Restricted: len(grants) > 0}
+	if owner {
+		access.Level = document.AccessLevelManage
+		return access, nil
+	}
+	if len(grants) == 0 {
+		return access, nil
+	}
+
+	var matching []document.AccessGrant
+	if err := db.Model(&document.AccessGrant{}).
+		Where("id IN ?", grantIDs(grants)).
+		Where(grantedTo("access_grants", userID)).
+		Find(&matching).Error; err != nil {
+		return ObjectAccess{}, err
+	}
+	for _, grant := range matching {
+		if !document.AccessLevelIncludes(access.Level, grant.Level) {
+			access.Level = grant.Level
+		}
+	}
+	return access, nil
+}
+
+func grantIDs(grants []document.AccessGrant) []uuid.UUID {
+	ids := make([]uuid.UUID, len(grants))
+	for i, grant := range grants {
+		ids[i] = grant.ID
+	}
+	return ids
+}
+
+// grantedTo matches the grants given to the user directly, to their role or to one of their teams
+func grantedTo(table string, userID uuid.UUID) clause.Expression {
+	return clause.NamedExpr{
+		SQL: fmt.Sprintf("((%[1]s.principal_type = 'user' AND %[1]s.principal_id = @user) OR "+
+			"(%[1]s.principal_type = 'role' AND %[1]s.principal_id IN (SELECT role_id FROM users WHERE id = @user AND role_id IS NOT NULL)) OR "+
+			"(%[1]s.principal_type = 'team' AND %[1]s.principal_id IN (SELECT team_id FROM team_members WHERE user_id = @user)))", table),
+		Vars: []interface{}{sql.Named("user", userID)},
+	}
+}
+
+// FolderAccessCondition matches the folders the user has at least the given level on, for listing
+// queries. table is the name of the folders table in the statement.
+func FolderAccessCondition(table string, userID uuid.UUID, level string) clause.Expression {
+	chain := fmt.Sprintf("SELECT a.id FROM folders a WHERE %[1]s.path = a.path OR %[1]s.path LIKE a.path || '/%%'", table)
+	return accessCondition(chain, fmt.Sprintf("g.object_type = 'folder' AND g.object_id IN (%s)", chain), "FALSE", userID, level)
+}
+
+// DocumentAccessCondition matches the documents the user has at least the given level on, for
+// listing queries. table is the name of the documents table in the statement.
+func DocumentAccessCondition(table string, userID uuid.UUID, level string) clause.Expression {
+	chain := fmt.Sprintf("SELECT a.id FROM folders a JOIN folders f ON f.path = a.path OR f.path LIKE a.path || '/%%' WHERE f.id = %s.folder_id", table)
+	grants := fmt.Sprintf("((g.object_type = 'folder' AND g.object_id IN (%s)) OR (g.object_type = 'document' AND g.object_id = %s.id))", chain, table)
+	return accessCondition(chain, grants, table+".uploaded_by = @user", userID, level)
+}
+
+// accessCondition builds the access check shared by folders and documents: the object has no
+// grants, the user owns it or one of its folders, or the user holds a grant of the level
+func accessCondition(chain, grants, ownerSQL string, userID uuid.UUID, level string) clause.Expression {
+	principal := grantedTo("g", userID).(clause.NamedExpr)
+	return clause.NamedExpr{
+		SQL: fmt.Sprintf("(NOT EXISTS (SELECT 1 FROM access_grants g WHERE %[1]s) OR %[2]s OR "+
+			"EXISTS (SELECT 1 FROM folders o WHERE o.id IN (%[3]s) AND o.owner_type = 'user' AND o.owner_id = @user) OR "+
+			"EXISTS (SELECT 1 FROM access_grants g WHERE %[1]s AND g.level IN @levels AND %[4]s))",
+			grants, ownerSQL, chain, principal.SQL),
+		Vars: []interface{}{sql.Named("user", userID), sql.Named("levels", document.AccessLevelsIncluding(level))},
+	}
+}
@@ -1,10 +1,12 @@
 package database
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"time"
 
+	"github.com/jackc/pgx/v5/pgconn"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -52,6 +54,21 @@ func InitDatabase() error {
 		return fmt.Errorf("failed to connect to database: %w", err)
 	}
 
+	// Generate time-ordered primary keys
+	if err := registerIDCallbacks(DB); err != nil {
+		return fmt.Errorf("failed to register ID callbacks: %w", err)
+	}
+
+	// Track entity change history
+	if err := registerRevisionCallbacks(DB); err != nil {
+		return fmt.Errorf("failed to register revision callbacks: %w", err)
+	}
+
+	// Scope organization-owned tables to the caller's tenant
+	if err := registerTenancyCallbacks(DB); err != nil {
+		return fmt.Errorf("failed to register tenancy callbacks: %w", err)
+	}
+
 	// Configure connection pool
 	sqlDB, err := DB.DB()
 	if err != nil {
@@ -59,16 +76,17 @@ func InitDatabase() error {
 	}
 
 	// Connection pool settings
-	sqlDB.SetMaxIdleConns(10)
-	sqlDB.SetMaxOpenConns(100)
-	sqlDB.SetConnMaxLifetime(time.Hour)
+	sqlDB.SetMaxOpenConns(cfg.DBMaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.DBMaxIdleConns)
+	sqlDB.SetConnMaxLifetime(time.Duration(cfg.DBConnMaxLifetimeMinutes) * time.Minute)
+	sqlDB.SetConnMaxIdleTime(time.Duration(cfg.DBConnMaxIdleTimeMinutes) * time.Minute)
 
 	// Test connection
 	if err := sqlDB.Ping(); err != nil {
 		return fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	log.Println("✅ Database connection established successfully")
+	log.Printf("✅ Database connection established successfully (pool: %d open, %d idle)", cfg.DBMaxOpenConns, cfg.DBMaxIdleConns)
 
 	// Run migrations
 	if err := runMigrations(); err != nil {
@@ -90,17 +108,79 @@ func runMigrations() error {
 		&models.Action{},
 		&models.Permission{},
 		&models.PermissionAction{},
+		&models.Team{},
+		&models.TeamMember{},
+		&models.UserFieldDefinition{},
+		&models.Revision{},
+		&models.Webhook{},
+		&models.WebhookDelivery{},
+		&models.OrganizationQuota{},
+		&models.UserQuota{},
+		&models.FileTypePolicy{},
+		&models.OrganizationStorage{},
+		&models.OrganizationAPIUsage{},
+		&models.IPReputation{},
+		&models.RoleAssignment{},
+		&models.ScheduledRoleChange{},
+		&models.RegistrationRule{},
+		&models.OrganizationDomain{},
+		&models.OrganizationJoinRequest{},
+		&models.ScheduledJob{},
+		&models.ScheduledJobRun{},
 		&auth.UserSession{},
 		&auth.PasswordResetToken{},
 		&auth.PasswordResetAttempt{},
 		&auth.EmailVerificationToken{},
 		&auth.LoginAttempt{},
 		&auth.BlacklistedToken{},
+		&auth.AppPassword{},
 		&notification.AuditLog{},
+		&notification.AuditLogArchive{},
 		&notification.Notification{},
+		&notification.DeviceToken{},
+		&notification.PushDelivery{},
+		&notification.NotificationDelivery{},
+		&notification.SMSMessage{},
+		&notification.PhoneVerification{},
+		&notification.NotificationPreference{},
+		&notification.NotificationSettings{},
+		&notification.DigestItem{},
+		&notification.NotificationIntegration{},
+		&notification.IntegrationDelivery{},
+		&notification.EmailTemplate{},
+		&notification.EmailTemplateVersion{},
+		&notification.EmailMessage{},
+		&notification.EmailDeadLetter{},
+		&notification.EmailEvent{},
+		&notification.EmailSuppression{},
+		&notification.ScheduledNotification{},
 		&document.Folder{},
 		&document.Document{},
 		&document.DocumentVersion{},
+		&document.UploadSession{},
+		&document.DocumentShare{},
+		&document.AccessGrant{},
+		&document.ContentObject{},
+		&document.RecentDocument{},
+		&document.FavoriteDocument{},
+		&document.DocumentActivity{},
+		&document.MetadataField{},
+		&document.IntegrityReport{},
+		&document.IntegrityIssue{},
+		&document.StorageOperation{},
+		&document.FolderExport{},
+		&document.RetentionPolicy{},
+		&document.LegalHold{},
+		&document.FolderSubscription{},
+		&document.FolderChange{},
+	}
+
+	// Indexes added to existing tables are created even when no table is missing
+	if err := ensureUniqueDocumentVersions(DB); err != nil {
+		return fmt.Errorf("failed to make document versions unique: %w", err)
+	}
+	if err := ensureDocumentTags(DB); err != nil {
+		return fmt.Errorf("failed to migrate document tags: %w", err)
 	}
 
 	// Check if all tables exist
@@ -135,6 +215,11 @@ func runMigrations() error {
 		}
 	}
 
+	// The tag index needs the documents table
+	if err := ensureDocumentTags(DB); err != nil {
+		return fmt.Errorf("failed to migrate document tags: %w", err)
+	}
+
 	if migratedCount > 0 {
 		log.Printf("✅ Database migrations completed (%d tables created/updated)", migratedCount)
 	} else {
@@ -144,6 +229,13 @@ func runMigrations() error {
 	return nil
 }
 
+// IsUniqueViolation reports whether a statement failed on a unique index, e.g. because a concurrent
+// request inserted the same row first
+func IsUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
 // GetDB returns the database instance
 func GetDB() *gorm.DB {
 	return DB
@@ -0,0 +1,54 @@
+package database
+
+import (
+	"fmt"
+	"log"
+
+	"forgecrud-backend/shared/database/models/document"
+
+	"gorm.io/gorm"
+)
+
+// documentTagIndex lets documents be filtered by tag, ignoring case
+const documentTagIndex = "idx_documents_tag_keys"
+
+// ensureDocumentTags converts the tags of documents and upload sessions in databases migrated while
+// tags were a comma separated text column to text arrays, splitting the stored values, and creates
+// the index filtering documents by tag. Tags match ignoring case through document_tag_keys, which
+// lowercases every tag of an array.
+func ensureDocumentTags(db *gorm.DB) error {
+	for _, table := range []string{"documents", "upload_sessions"} {
+		var dataType string
+		if err := db.Raw(`SELECT data_type FROM information_schema.columns
+			WHERE table_schema = current_schema() AND table_name = ? AND column_name = 'tags'`, table).
+			Scan(&dataType).Error; err != nil {
+			return err
+		}
+		if dataType != "text" {
+			continue
+		}
+
+		log.Printf("🏷️  Converting the tags of %s to arrays", table)
+		if err := db.Exec(fmt.Sprintf(`ALTER TABLE %s
+			ALTER COLUMN tags DROP DEFAULT,
+			ALTER COLUMN tags TYPE text[] USING COALESCE(
+				array_remove(string_to_array(regexp_replace(btrim(tags), '\s*,\s*', ',', 'g'), ','), ''), '{}'),
+			ALTER COLUMN tags SET DEFAULT '{}',
+			ALTER COLUMN tags SET NOT NULL`, table)).Error; err != nil {
+			return fmt.Errorf("failed to convert the tags of %s: %w", table, err)
+		}
+	}
+
+	migrator := db.Migrator()
+	if !migrator.HasTable(&document.Document{}) || migrator.HasIndex(&document.Document{}, documentTagIndex) {
+		return nil
+	}
+
+	if err := db.Exec(`CREATE OR REPLACE FUNCTION document_tag_keys(tags text[]) RETURNS text[]
+		LANGUAGE sql IMMUTABLE PARALLEL SAFE
+		AS $$ SELECT lower(tags::text)::text[] $$`).Error; err != nil {
+		return err
+	}
+	log.Printf("📦 Creating index: %s", documentTagIndex)
+	return db.Exec("CREATE INDEX IF NOT EXISTS " + documentTagIndex + " ON documents USING gin (document_tag_keys(tags))").Error
+}
@@ -0,0 +1,46 @@
+package database
+
+import (
+	"log"
+
+	"forgecrud-backend/shared/database/models/document"
+
+	"gorm.io/gorm"
+)
+
+// documentVersionIndex makes the version numbers of a document unique
+const documentVersionIndex = "idx_document_versions_document_version"
+
+// ensureUniqueDocumentVersions creates the unique index on the document and number of versions in
+// databases migrated before it existed. Versions that concurrent uploads gave the same number are
+// renumbered after the document's last version first, keeping their files.
+func ensureUniqueDocumentVersions(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if !migrator.HasTable(&document.DocumentVersion{}) || migrator.HasIndex(&document.DocumentVersion{}, documentVersionIndex) {
+		return nil
+	}
+
+	result := db.Exec(`
+		WITH numbered AS (
+			SELECT id, document_id, version,
+				ROW_NUMBER() OVER (PARTITION BY document_id, version ORDER BY created_at, id) AS copy,
+				MAX(version) OVER (PARTITION BY document_id) AS last_version
+			FROM document_versions
+		), duplicates AS (
+			SELECT id, last_version + ROW_NUMBER() OVER (PARTITION BY document_id ORDER BY version, copy, id) AS new_version
+			FROM numbered
+			WHERE copy > 1
+		)
+		UPDATE document_versions v SET version = d.new_version
+		FROM duplicates d
+		WHERE v.id = d.id`)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("🔢 Renumbered %d document versions that shared their number", result.RowsAffected)
+	}
+
+	log.Printf("📦 Creating index: %s", documentVersionIndex)
+	return migrator.CreateIndex(&document.DocumentVersion{}, documentVersionIndex)
+}
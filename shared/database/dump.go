@@ -0,0 +1,334 @@
+package database
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// DumpFormat identifies the data dumps written by DumpData
+const DumpFormat = "forgecrud-dump/1"
+
+// dumpEnd terminates the rows of a table in a dump, like in the COPY text format
+const dumpEnd = "\\.\n"
+
+// dumpHeader is the first line of a dump
+type dumpHeader struct {
+	Format     string    `json:"format"`
+	SnapshotAt time.Time `json:"snapshot_at"`
+	Tables     []string  `json:"tables"`
+}
+
+// dumpTable starts the rows of a table in a dump
+type dumpTable struct {
+	Table   string   `json:"table"`
+	Columns []string `json:"columns"`
+}
+
+// DumpData writes the rows of every table as of one snapshot and returns the time of the snapshot.
+// The schema is not dumped, it is the one AutoMigrate creates. A dump is a JSON header line, then
+// for each table a JSON line naming its columns followed by its rows in the COPY text format, ended
+// by a "\." line.
+func DumpData(ctx context.Context, w io.Writer) (time.Time, error) {
+	var snapshotAt time.Time
+	err := withPgxConn(ctx, func(conn *pgx.Conn) error {
+		tx, err := conn.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback(ctx)
+
+		// The snapshot is taken by the first query of the transaction
+		if err := tx.QueryRow(ctx, "SELECT now()").Scan(&snapshotAt); err != nil {
+			return err
+		}
+		tables, err := dumpTables(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		names := make([]string, len(tables))
+		for i, table := range tables {
+			names[i] = table.Table
+		}
+		if err := writeDumpLine(w, dumpHeader{Format: DumpFormat, SnapshotAt: snapshotAt.UTC(), Tables: names}); err != nil {
+			return err
+		}
+
+		for _, table := range tables {
+			if err := writeDumpLine(w, table); err != nil {
+				return err
+			}
+			statement := fmt.Sprintf("COPY (SELECT %s FROM %s) TO STDOUT", quoteColumns(table.Columns), pgx.Identifier{table.Table}.Sanitize())
+			if _, err := tx.Conn().PgConn().CopyTo(ctx, w, statement); err != nil {
+				return fmt.Errorf("failed to dump %s: %w", table.Table, err)
+			}
+			if _, err := io.WriteString(w, dumpEnd); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return snapshotAt.UTC(), err
+}
+
+// RestoreData replaces the rows of every table with those of a dump written by DumpData, in one
+// transaction, and returns the time of the dump's snapshot. The schema must already be migrated.
+// Tables and columns of the dump that no longer exist are skipped; tables that did not exist when
+// the dump was taken are emptied.
+func RestoreData(ctx context.Context, r io.Reader) (time.Time, error) {
+	reader := bufio.NewReaderSize(r, 1<<20)
+
+	var header dumpHeader
+	if err := readDumpLine(reader, &header); err != nil {
+		return time.Time{}, fmt.Errorf("failed to read the dump header: %w", err)
+	}
+	if header.Format != DumpFormat {
+		return time.Time{}, fmt.Errorf("unsupported dump format %q", header.Format)
+	}
+
+	err := withPgxConn(ctx, func(conn *pgx.Conn) error {
+		tx, err := conn.Begin(ctx)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback(ctx)
+
+		current, err := dumpTables(ctx, tx)
+		if err != nil {
+			return err
+		}
+		columns := map[string]map[string]bool{}
+		names := make([]string, len(current))
+		for i, table := range current {
+			columns[table.Table] = map[string]bool{}
+			for _, column := range table.Columns {
+				columns[table.Table][column] = true
+			}
+			names[i] = pgx.Identifier{table.Table}.Sanitize()
+		}
+
+		// Foreign keys are dropped while the rows load, in no particular order, and added back after,
+		// which checks every row
+		constraints, err := foreignKeys(ctx, tx)
+		if err != nil {
+			return err
+		}
+		for _, constraint := range constraints {
+			if _, err := tx.Exec(ctx, fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s", constraint.table, pgx.Identifier{constraint.name}.Sanitize())); err != nil {
+				return err
+			}
+		}
+		if len(names) > 0 {
+			if _, err := tx.Exec(ctx, "TRUNCATE TABLE "+strings.Join(names, ", ")); err != nil {
+				return err
+			}
+		}
+
+		restored := map[string]bool{}
+		for {
+			var table dumpTable
+			if err := readDumpLine(reader, &table); err != nil {
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				return fmt.Errorf("failed to read the dump: %w", err)
+			}
+			section := &dumpSection{reader: reader}
+
+			existing, ok := columns[table.Table]
+			if !ok {
+				log.Printf("⚠️  Skipping table %s of the dump, it no longer exists", table.Table)
+				if _, err := io.Copy(io.Discard, section); err != nil {
+					return fmt.Errorf("failed to read the dump of %s: %w", table.Table, err)
+				}
+				continue
+			}
+			var kept []string
+			for i, column := range table.Columns {
+				if existing[column] {
+					kept = append(kept, column)
+					section.keep = append(section.keep, i)
+				}
+			}
+			if len(kept) == len(table.Columns) {
+				section.keep = nil
+			}
+
+			statement := fmt.Sprintf("COPY %s (%s) FROM STDIN", pgx.Identifier{table.Table}.Sanitize(), quoteColumns(kept))
+			if _, err := tx.Conn().PgConn().CopyFrom(ctx, section, statement); err != nil {
+				return fmt.Errorf("failed to restore %s: %w", table.Table, err)
+			}
+			restored[table.Table] = true
+		}
+		for _, table := range header.Tables {
+			if !restored[table] && columns[table] != nil {
+				return fmt.Errorf("the dump is incomplete, table %s is missing", table)
+			}
+		}
+
+		for _, constraint := range constraints {
+			if _, err := tx.Exec(ctx, fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s %s", constraint.table, pgx.Identifier{constraint.name}.Sanitize(), constraint.definition)); err != nil {
+				return fmt.Errorf("restored rows break constraint %s of %s: %w", constraint.name, constraint.table, err)
+			}
+		}
+		return tx.Commit(ctx)
+	})
+	return header.SnapshotAt, err
+}
+
+// withPgxConn runs fn on a pgx connection of the pool, for COPY
+func withPgxConn(ctx context.Context, fn func(conn *pgx.Conn) error) error {
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(driverConn interface{}) error {
+		stdlibConn, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return fmt.Errorf("unexpected database driver %T", driverConn)
+		}
+		return fn(stdlibConn.Conn())
+	})
+}
+
+// dumpTables lists the tables of the schema with their columns, leaving out generated ones
+func dumpTables(ctx context.Context, tx pgx.Tx) ([]dumpTable, error) {
+	rows, err := tx.Query(ctx, `SELECT table_name, column_name FROM information_schema.columns
+		WHERE table_schema = current_schema() AND is_generated = 'NEVER'
+		AND table_name IN (SELECT table_name FROM information_schema.tables WHERE table_schema = current_schema() AND table_type = 'BASE TABLE')
+		ORDER BY table_name, ordinal_position`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tables []dumpTable
+	for rows.Next() {
+		var table, column string
+		if err := rows.Scan(&table, &column); err != nil {
+			return nil, err
+		}
+		if len(tables) == 0 || tables[len(tables)-1].Table != table {
+			tables = append(tables, dumpTable{Table: table})
+		}
+		tables[len(tables)-1].Columns = append(tables[len(tables)-1].Columns, column)
+	}
+	return tables, rows.Err()
+}
+
+// foreignKey is a foreign key constraint as pg_get_constraintdef prints it
+type foreignKey struct {
+	table      string
+	name       string
+	definition string
+}
+
+func foreignKeys(ctx context.Context, tx pgx.Tx) ([]foreignKey, error) {
+	rows, err := tx.Query(ctx, `SELECT c.conrelid::regclass::text, c.conname, pg_get_constraintdef(c.oid)
+		FROM pg_constraint c JOIN pg_namespace n ON n.oid = c.connamespace
+		WHERE c.contype = 'f' AND n.nspname = current_schema()`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var constraints []foreignKey
+	for rows.Next() {
+		var constraint foreignKey
+		if err := rows.Scan(&constraint.table, &constraint.name, &constraint.definition); err != nil {
+			return nil, err
+		}
+		constraints = append(constraints, constraint)
+	}
+	return constraints, rows.Err()
+}
+
+func quoteColumns(columns []string) string {
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = pgx.Identifier{column}.Sanitize()
+	}
+	return strings.Join(quoted, ", ")
+}
+
+func writeDumpLine(w io.Writer, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// readDumpLine decodes the next JSON line, returning io.EOF at the end of the dump
+func readDumpLine(reader *bufio.Reader, v interface{}) error {
+	line, err := reader.ReadBytes('\n')
+	if err != nil {
+		if errors.Is(err, io.EOF) && len(line) > 0 {
+			return io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	return json.Unmarshal(line, v)
+}
+
+// dumpSection reads the rows of one table up to its "\." line, keeping only the columns at the
+// keep indexes when set
+type dumpSection struct {
+	reader  *bufio.Reader
+	keep    []int
+	pending []byte
+	done    bool
+}
+
+func (s *dumpSection) Read(p []byte) (int, error) {
+	if len(s.pending) == 0 {
+		if s.done {
+			return 0, io.EOF
+		}
+		line, err := s.reader.ReadBytes('\n')
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return 0, io.ErrUnexpectedEOF
+			}
+			return 0, err
+		}
+		if string(line) == dumpEnd {
+			s.done = true
+			return 0, io.EOF
+		}
+		if s.keep != nil {
+			// Tabs within values are escaped, so fields split on every tab
+			fields := bytes.Split(bytes.TrimSuffix(line, []byte{'\n'}), []byte{'\t'})
+			kept := make([][]byte, len(s.keep))
+			for i, index := range s.keep {
+				if index >= len(fields) {
+					return 0, fmt.Errorf("malformed row with %d columns", len(fields))
+				}
+				kept[i] = fields[index]
+			}
+			line = append(bytes.Join(kept, []byte{'\t'}), '\n')
+		}
+		s.pending = line
+	}
+	n := copy(p, s.pending)
+	s.pending = s.pending[n:]
+	return n, nil
+}
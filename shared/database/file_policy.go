@@ -0,0 +1,180 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"forgecrud-backend/shared/config"
+	"forgecrud-backend/shared/database/models"
+	docUtils "forgecrud-backend/shared/utils/document"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// FileTypeError reports why a file was rejected by its type, or by the size limit of its type
+type FileTypeError struct {
+	FileName  string `json:"file_name"`
+	MimeType  string `json:"mime_type,omitempty"`
+	Extension string `json:"extension"`
+	Reason    string `json:"reason"`
+	Size      int64  `json:"size,omitempty"`
+	MaxSize   int64  `json:"max_size,omitempty"` // Set when the file exceeds the size limit of its type
+}
+
+func (e *FileTypeError) Error() string {
+	return e.Reason
+}
+
+// IsSizeLimit reports whether the file was rejected by the size limit of its type
+func (e *FileTypeError) IsSizeLimit() bool {
+	return e.MaxSize > 0
+}
+
+// GetFileTypePolicy returns the file type policy of the organization, an empty policy when it has none
+// or no organization is given
+func GetFileTypePolicy(db *gorm.DB, organizationID *uuid.UUID) (models.FileTypePolicy, error) {
+	var policy models.FileTypePolicy
+	if organizationID == nil {
+		return policy, nil
+	}
+
+	err := db.Where("organization_id = ?", *organizationID).First(&policy).Error
+	if err == gorm.ErrRecordNotFound {
+		return models.FileTypePolicy{OrganizationID: *organizationID, MaxSizes: models.JSONMap{}}, nil
+	}
+	return policy, err
+}
+
+// CheckFileType checks a file against the platform's blocked types and the file type policy, returning
+// a *FileTypeError when it is not allowed. An empty mimeType checks the extension only, for files whose
+// content has not been received yet.
+func CheckFileType(policy models.FileTypePolicy, fileName, mimeType string, size int64) error {
+	extension := docUtils.FileExtension(fileName)
+	reject := func(reason string) *FileTypeError {
+		return &FileTypeError{FileName: fileName, MimeType: mimeType, Extension: extension, Reason: reason}
+	}
+	describe := func() string {
+		if extension == "" {
+			return "files without an extension"
+		}
+		return extension + " files"
+	}
+
+	if listContainsExtension(policy.DeniedExtensions, extension) {
+		return reject(describe() + " are not allowed")
+	}
+	if policy.AllowedExtensions != "" && !listContainsExtension(policy.AllowedExtensions, extension) {
+		return reject(describe() + " are not allowed")
+	}
+	if mimeType != "" {
+		if listMatchesMimeType(policy.DeniedMimeTypes, mimeType) {
+			return reject(mimeType + " content is not allowed")
+		}
+		if policy.AllowedMimeTypes != "" && !listMatchesMimeType(policy.AllowedMimeTypes, mimeType) {
+			return reject(mimeType + " content is not allowed")
+		}
+	}
+
+	if !policy.AllowExecutables {
+		cfg := config.GetConfig()
+		if listContainsExtension(cfg.UploadBlockedExtensions, extension) {
+			return reject(describe() + " are blocked as they can be executed")
+		}
+		if mimeType != "" && listMatchesMimeType(cfg.UploadBlockedMimeTypes, mimeType) {
+			return reject(mimeType + " content is blocked as it can be executed")
+		}
+	}
+
+	if maxSize := fileTypeMaxSize(policy.MaxSizes, extension, mimeType); maxSize > 0 && size > maxSize {
+		err := reject(fmt.Sprintf("file size exceeds the %d bytes limit of its type", maxSize))
+		err.Size, err.MaxSize = size, maxSize
+		return err
+	}
+	return nil
+}
+
+// fileTypeMaxSize returns the size limit of the most specific key matching the file: its extension,
+// its exact MIME type, its MIME class and finally *. 0 means unlimited.
+func fileTypeMaxSize(maxSizes models.JSONMap, extension, mimeType string) int64 {
+	best, bestRank := int64(0), 0
+	for key, value := range maxSizes {
+		limit, ok := FileTypeSizeLimit(value)
+		if !ok {
+			continue
+		}
+
+		rank := 0
+		pattern := strings.ToLower(strings.TrimSpace(key))
+		switch {
+		case strings.HasPrefix(pattern, "."):
+			if pattern == extension {
+				rank = 4
+			}
+		case pattern == "*" || pattern == "*/*":
+			rank = 1
+		case mimeType == "":
+		case strings.HasSuffix(pattern, "/*"):
+			if docUtils.MimeTypeMatches(mimeType, pattern) {
+				rank = 2
+			}
+		case pattern == strings.ToLower(mimeType):
+			rank = 3
+		}
+		if rank > bestRank {
+			best, bestRank = limit, rank
+		}
+	}
+	return best
+}
+
+// FileTypeSizeLimit reads a size limit of FileTypePolicy.MaxSizes, which must be a positive whole number
+func FileTypeSizeLimit(value interface{}) (int64, bool) {
+	var limit float64
+	switch v := value.(type) {
+	case float64:
+		limit = v
+	case int:
+		limit = float64(v)
+	case int64:
+		limit = float64(v)
+	case json.Number:
+		parsed, err := v.Float64()
+		if err != nil {
+			return 0, false
+		}
+		limit = parsed
+	default:
+		return 0, false
+	}
+	if limit < 1 || limit != float64(int64(limit)) {
+		return 0, false
+	}
+	return int64(limit), true
+}
+
+// listContainsExtension reports whether a comma separated list of extensions, with or without their
+// dot, contains the extension
+func listContainsExtension(list, extension string) bool {
+	if extension == "" {
+		return false
+	}
+	for _, item := range strings.Split(list, ",") {
+		item = strings.ToLower(strings.TrimSpace(item))
+		if item != "" && "."+strings.TrimPrefix(item, ".") == extension {
+			return true
+		}
+	}
+	return false
+}
+
+// listMatchesMimeType reports whether a comma separated list of MIME type patterns matches the type
+func listMatchesMimeType(list, mimeType string) bool {
+	for _, pattern := range strings.Split(list, ",") {
+		if strings.TrimSpace(pattern) != "" && docUtils.MimeTypeMatches(mimeType, pattern) {
+			return true
+		}
+	}
+	return false
+}
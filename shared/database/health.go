@@ -0,0 +1,110 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"forgecrud-backend/shared/config"
+)
+
+// healthPingTimeout bounds the ping of a health check, well above any sensible latency threshold
+const healthPingTimeout = 5 * time.Second
+
+// PoolStats are the connection pool statistics of the database handle
+type PoolStats struct {
+	MaxOpenConnections int     `json:"max_open_connections"` // 0 is unlimited
+	OpenConnections    int     `json:"open_connections"`
+	InUse              int     `json:"in_use"`
+	Idle               int     `json:"idle"`
+	WaitCount          int64   `json:"wait_count"`       // Connections waited for, since startup
+	WaitDurationMs     float64 `json:"wait_duration_ms"` // Total time waited for connections
+	MaxIdleClosed      int64   `json:"max_idle_closed"`
+	MaxIdleTimeClosed  int64   `json:"max_idle_time_closed"`
+	MaxLifetimeClosed  int64   `json:"max_lifetime_closed"`
+}
+
+// HealthReport is the state of the database connection. The database is ready when a ping succeeds
+// within DB_HEALTH_MAX_LATENCY_MS and less than DB_POOL_SATURATION_PERCENT of the pool is in use.
+type HealthReport struct {
+	Status    string    `json:"status"` // up, degraded or down
+	Ready     bool      `json:"ready"`
+	LatencyMs float64   `json:"latency_ms"`
+	Pool      PoolStats `json:"pool"`
+	Problems  []string  `json:"problems,omitempty"`
+}
+
+// Health status values
+const (
+	HealthUp       = "up"
+	HealthDegraded = "degraded" // Reachable, but too slow or out of connections to take more requests
+	HealthDown     = "down"
+)
+
+// GetPoolStats returns the connection pool statistics, zero before the database is initialized
+func GetPoolStats() PoolStats {
+	if DB == nil {
+		return PoolStats{}
+	}
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return PoolStats{}
+	}
+
+	stats := sqlDB.Stats()
+	return PoolStats{
+		MaxOpenConnections: stats.MaxOpenConnections,
+		OpenConnections:    stats.OpenConnections,
+		InUse:              stats.InUse,
+		Idle:               stats.Idle,
+		WaitCount:          stats.WaitCount,
+		WaitDurationMs:     float64(stats.WaitDuration) / float64(time.Millisecond),
+		MaxIdleClosed:      stats.MaxIdleClosed,
+		MaxIdleTimeClosed:  stats.MaxIdleTimeClosed,
+		MaxLifetimeClosed:  stats.MaxLifetimeClosed,
+	}
+}
+
+// CheckHealth pings the database and checks the pool against the readiness thresholds
+func CheckHealth(ctx context.Context) HealthReport {
+	cfg := config.GetConfig()
+	report := HealthReport{Status: HealthUp, Ready: true, Pool: GetPoolStats()}
+
+	fail := func(status, problem string) {
+		report.Ready = false
+		if report.Status != HealthDown {
+			report.Status = status
+		}
+		report.Problems = append(report.Problems, problem)
+	}
+
+	if DB == nil {
+		fail(HealthDown, "database is not initialized")
+		return report
+	}
+	sqlDB, err := DB.DB()
+	if err != nil {
+		fail(HealthDown, err.Error())
+		return report
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, healthPingTimeout)
+	defer cancel()
+	started := time.Now()
+	err = sqlDB.PingContext(ctx)
+	report.LatencyMs = float64(time.Since(started)) / float64(time.Millisecond)
+	if err != nil {
+		fail(HealthDown, fmt.Sprintf("ping failed: %v", err))
+		return report
+	}
+
+	if limit := cfg.DBHealthMaxLatencyMs; limit > 0 && report.LatencyMs > float64(limit) {
+		fail(HealthDegraded, fmt.Sprintf("ping took %.0fms, more than %dms", report.LatencyMs, limit))
+	}
+	if maxOpen := report.Pool.MaxOpenConnections; maxOpen > 0 && cfg.DBPoolSaturationPercent > 0 &&
+		report.Pool.InUse*100 >= maxOpen*cfg.DBPoolSaturationPercent {
+		fail(HealthDegraded, fmt.Sprintf("%d of %d connections in use", report.Pool.InUse, maxOpen))
+	}
+
+	return report
+}
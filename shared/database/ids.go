@@ -0,0 +1,54 @@
+package database
+
+import (
+	"reflect"
+
+	"forgecrud-backend/shared/ids"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// registerIDCallbacks gives new rows time-ordered IDs (see package ids) instead of the random IDs of the
+// gen_random_uuid() column defaults. The defaults stay for rows inserted with plain SQL; IDs set by
+// the caller are kept.
+func registerIDCallbacks(db *gorm.DB) error {
+	return db.Callback().Create().Before("gorm:create").Register("ids:create", assignIDsOnCreate)
+}
+
+func assignIDsOnCreate(tx *gorm.DB) {
+	if tx.Error != nil || tx.Statement.Schema == nil {
+		return
+	}
+
+	var fields []*schema.Field
+	for _, field := range tx.Statement.Schema.PrimaryFields {
+		if field.FieldType == reflect.TypeOf(uuid.UUID{}) {
+			fields = append(fields, field)
+		}
+	}
+	if len(fields) == 0 {
+		return
+	}
+
+	assign := func(value reflect.Value) {
+		for _, field := range fields {
+			if _, isZero := field.ValueOf(tx.Statement.Context, value); !isZero {
+				continue
+			}
+			if err := field.Set(tx.Statement.Context, value, ids.New()); err != nil {
+				tx.AddError(err)
+			}
+		}
+	}
+
+	switch rv := tx.Statement.ReflectValue; rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			assign(reflect.Indirect(rv.Index(i)))
+		}
+	case reflect.Struct:
+		assign(rv)
+	}
+}
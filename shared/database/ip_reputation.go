@@ -0,0 +1,107 @@
+package database
+
+import (
+	"math"
+	"time"
+
+	"forgecrud-backend/shared/database/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// IPFailures are failed requests of an address, with their weighted score
+type IPFailures struct {
+	Unauthorized int64
+	Forbidden    int64
+	RateLimited  int64
+	Score        float64
+	LastAt       time.Time
+}
+
+// RecordIPFailures adds failed requests to the reputation of an address and returns it. The previous
+// score halves every halfLife since the last failure, so occasional mistakes never add up to a ban.
+func RecordIPFailures(db *gorm.DB, ipAddress string, failures IPFailures, halfLife time.Duration) (models.IPReputation, error) {
+	reputation := models.IPReputation{
+		IPAddress:     ipAddress,
+		Score:         failures.Score,
+		Unauthorized:  failures.Unauthorized,
+		Forbidden:     failures.Forbidden,
+		RateLimited:   failures.RateLimited,
+		LastFailureAt: &failures.LastAt,
+	}
+
+	err := db.Clauses(
+		clause.OnConflict{
+			Columns: []clause.Column{{Name: "ip_address"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"score": gorm.Expr("ip_reputations.score * POWER(0.5, GREATEST(EXTRACT(EPOCH FROM excluded.last_failure_at - COALESCE(ip_reputations.last_failure_at, excluded.last_failure_at)), 0) / ?) + excluded.score",
+					math.Max(halfLife.Seconds(), 1)),
+				"unauthorized":    gorm.Expr("ip_reputations.unauthorized + excluded.unauthorized"),
+				"forbidden":       gorm.Expr("ip_reputations.forbidden + excluded.forbidden"),
+				"rate_limited":    gorm.Expr("ip_reputations.rate_limited + excluded.rate_limited"),
+				"last_failure_at": gorm.Expr("excluded.last_failure_at"),
+				"updated_at":      gorm.Expr("excluded.updated_at"),
+			}),
+		},
+		clause.Returning{},
+	).Create(&reputation).Error
+
+	return reputation, err
+}
+
+// BanIP bans an address until the given time, counting the ban in its reputation. bannedBy is the
+// admin banning it, nil for automatic bans.
+func BanIP(db *gorm.DB, ipAddress string, until time.Time, reason string, bannedBy *uuid.UUID) (models.IPReputation, error) {
+	reputation := models.IPReputation{
+		IPAddress:   ipAddress,
+		BannedUntil: &until,
+		BanReason:   reason,
+		BannedBy:    bannedBy,
+		Bans:        1,
+	}
+
+	err := db.Clauses(
+		clause.OnConflict{
+			Columns: []clause.Column{{Name: "ip_address"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"banned_until": gorm.Expr("excluded.banned_until"),
+				"ban_reason":   gorm.Expr("excluded.ban_reason"),
+				"banned_by":    gorm.Expr("excluded.banned_by"),
+				"bans":         gorm.Expr("ip_reputations.bans + 1"),
+				"updated_at":   gorm.Expr("excluded.updated_at"),
+			}),
+		},
+		clause.Returning{},
+	).Create(&reputation).Error
+
+	return reputation, err
+}
+
+// UnbanIP lifts the ban of an address and resets its score, so it is not banned again by its past
+// failures. It reports false for addresses without a reputation.
+func UnbanIP(db *gorm.DB, ipAddress string) (bool, error) {
+	result := db.Model(&models.IPReputation{}).Where("ip_address = ?", ipAddress).
+		Updates(map[string]interface{}{"banned_until": nil, "score": 0})
+	return result.RowsAffected > 0, result.Error
+}
+
+// ActiveIPBans returns the addresses banned at t
+func ActiveIPBans(db *gorm.DB, t time.Time) ([]models.IPReputation, error) {
+	var bans []models.IPReputation
+	err := db.Where("banned_until > ?", t).Find(&bans).Error
+	return bans, err
+}
+
+// DecayedIPScore returns the score of a reputation at t
+func DecayedIPScore(reputation models.IPReputation, halfLife time.Duration, t time.Time) float64 {
+	if reputation.LastFailureAt == nil || halfLife <= 0 {
+		return reputation.Score
+	}
+	elapsed := t.Sub(*reputation.LastFailureAt)
+	if elapsed <= 0 {
+		return reputation.Score
+	}
+	return reputation.Score * math.Pow(0.5, elapsed.Seconds()/halfLife.Seconds())
+}
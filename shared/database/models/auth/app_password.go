@@ -0,0 +1,18 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AppPassword is a password generated for an application that cannot sign in with a token, such as
+// a WebDAV client. It is shown once when created; only its hash is stored.
+type AppPassword struct {
+	ID           uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	UserID       uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	Name         string     `json:"name" gorm:"size:100;not null"`
+	PasswordHash string     `json:"-" gorm:"size:255;not null"`
+	LastUsedAt   *time.Time `json:"last_used_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
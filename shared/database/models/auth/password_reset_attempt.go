@@ -3,6 +3,8 @@ package auth
 import (
 	"time"
 
+	"forgecrud-backend/shared/ids"
+
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -20,7 +22,7 @@ type PasswordResetAttempt struct {
 // BeforeCreate will set ID if not set
 func (a *PasswordResetAttempt) BeforeCreate(tx *gorm.DB) error {
 	if a.ID == uuid.Nil {
-		a.ID = uuid.New()
+		a.ID = ids.New()
 	}
 	return nil
 }
@@ -0,0 +1,72 @@
+package document
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Objects an access grant applies to. Grants on a folder are inherited by its subfolders and documents.
+const (
+	AccessObjectFolder   = "folder"
+	AccessObjectDocument = "document"
+)
+
+// Principals an access grant can be given to
+const (
+	AccessPrincipalUser = "user"
+	AccessPrincipalRole = "role"
+	AccessPrincipalTeam = "team"
+)
+
+// Access levels, each including the levels before it
+const (
+	AccessLevelRead   = "read"   // View and download
+	AccessLevelWrite  = "write"  // Upload versions, edit, move and delete
+	AccessLevelManage = "manage" // Change the access list and share links
+)
+
+// accessLevels lists the levels from lowest to highest
+var accessLevels = []string{AccessLevelRead, AccessLevelWrite, AccessLevelManage}
+
+var accessLevelRanks = map[string]int{
+	AccessLevelRead:   1,
+	AccessLevelWrite:  2,
+	AccessLevelManage: 3,
+}
+
+// IsValidAccessLevel reports whether level is read, write or manage
+func IsValidAccessLevel(level string) bool {
+	_, ok := accessLevelRanks[level]
+	return ok
+}
+
+// AccessLevelIncludes reports whether the granted level allows the required one
+func AccessLevelIncludes(granted, required string) bool {
+	return granted != "" && accessLevelRanks[granted] >= accessLevelRanks[required]
+}
+
+// AccessLevelsIncluding returns the levels that allow the required one
+func AccessLevelsIncluding(required string) []string {
+	var levels []string
+	for _, level := range accessLevels {
+		if AccessLevelIncludes(level, required) {
+			levels = append(levels, level)
+		}
+	}
+	return levels
+}
+
+// AccessGrant gives a user, role or team access to a folder or document. Once a folder or document
+// has grants, directly or on one of its parent folders, only the owners and the grantees can access it.
+type AccessGrant struct {
+	ID            uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	ObjectType    string    `gorm:"size:20;not null;uniqueIndex:idx_access_grant" json:"object_type"`
+	ObjectID      uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_access_grant" json:"object_id"`
+	PrincipalType string    `gorm:"size:20;not null;uniqueIndex:idx_access_grant" json:"principal_type"`
+	PrincipalID   uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_access_grant;index" json:"principal_id"`
+	Level         string    `gorm:"size:20;not null" json:"level"`
+	GrantedBy     uuid.UUID `gorm:"type:uuid;not null" json:"granted_by"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
@@ -0,0 +1,47 @@
+package document
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RecentDocument records when a user last opened or downloaded a document
+type RecentDocument struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID      uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_recent_document" json:"user_id"`
+	DocumentID  uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_recent_document;index" json:"document_id"`
+	AccessedAt  time.Time `gorm:"not null;index" json:"accessed_at"`
+	AccessCount int       `gorm:"not null;default:1" json:"access_count"`
+}
+
+// FavoriteDocument is a document a user has starred
+type FavoriteDocument struct {
+	ID         uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID     uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_favorite_document" json:"user_id"`
+	DocumentID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_favorite_document;index" json:"document_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Document activity actions
+const (
+	DocumentActionView     = "view"
+	DocumentActionDownload = "download"
+	DocumentActionUpload   = "upload" // The document or a new version of it
+	DocumentActionMove     = "move"
+	DocumentActionShare    = "share"   // A share link or an access grant
+	DocumentActionUnshare  = "unshare" // A share link or an access grant removed
+)
+
+// DocumentActivity records who viewed, downloaded, uploaded, moved or shared a document, and from
+// where. Activities stay when the document is purged, for compliance exports.
+type DocumentActivity struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	DocumentID uuid.UUID  `gorm:"type:uuid;not null;index:idx_document_activity,priority:1" json:"document_id"`
+	ActorID    *uuid.UUID `gorm:"type:uuid;index" json:"actor_id"` // Null for downloads through public share links
+	Action     string     `gorm:"type:varchar(20);not null" json:"action"`
+	Details    string     `gorm:"type:text" json:"details,omitempty"`
+	IPAddress  string     `gorm:"type:varchar(45)" json:"ip_address"`
+	UserAgent  string     `gorm:"type:text" json:"user_agent"`
+	CreatedAt  time.Time  `gorm:"not null;index:idx_document_activity,priority:2" json:"created_at"`
+}
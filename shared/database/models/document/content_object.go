@@ -0,0 +1,14 @@
+package document
+
+import "time"
+
+// ContentObject is a file stored once under the SHA-256 of its content. Documents and versions with
+// identical content reference the same object, which is removed from storage with its last reference.
+type ContentObject struct {
+	ContentHash string    `gorm:"size:64;primary_key" json:"content_hash"`
+	ObjectKey   string    `gorm:"not null" json:"object_key"`
+	FileSize    int64     `gorm:"not null" json:"file_size"`
+	RefCount    int       `gorm:"not null;default:0" json:"ref_count"` // Document versions referencing the file
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
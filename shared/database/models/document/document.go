@@ -3,6 +3,8 @@ package document
 import (
 	"time"
 
+	"forgecrud-backend/shared/database/models"
+
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -12,21 +14,26 @@ type Folder struct {
 	ID       uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
 	Name     string     `gorm:"not null" json:"name"`
 	Path     string     `gorm:"not null;unique" json:"path"`
-	ParentID *uuid.UUID `gorm:"type:uuid" json:"parent_id"`
+	ParentID *uuid.UUID `gorm:"type:uuid;index" json:"parent_id"`
 	Parent   *Folder    `gorm:"foreignKey:ParentID" json:"parent,omitempty"`
 
 	// Owner context
 	OwnerID   uuid.UUID `gorm:"type:uuid;not null" json:"owner_id"`
 	OwnerType string    `gorm:"not null" json:"owner_type"` // "user", "organization"
 
-	// Stats
-	FileCount int   `gorm:"default:0" json:"file_count"`
-	TotalSize int64 `gorm:"default:0" json:"total_size"`
+	// Stats of the documents in the folder and its subfolders, rolled up from the stats of the
+	// documents directly in each folder
+	FileCount       int        `gorm:"default:0" json:"file_count"`
+	TotalSize       int64      `gorm:"default:0" json:"total_size"`
+	DirectFileCount int        `gorm:"not null;default:0" json:"direct_file_count"`
+	DirectSize      int64      `gorm:"not null;default:0" json:"direct_size"`
+	StatsUpdatedAt  *time.Time `json:"stats_updated_at,omitempty"`
 
 	// Timestamps
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"` // Moved to the trash
+	DeletedBy *uuid.UUID     `gorm:"type:uuid" json:"deleted_by,omitempty"`
 }
 
 // Document represents a document file
@@ -48,9 +55,14 @@ type Document struct {
 	ObjectKey  string    `gorm:"not null;unique" json:"object_key"`
 	Path       string    `gorm:"not null" json:"path"`
 
+	// SHA-256 of the current version once its file is in the content store, stored under this hash
+	// instead of ObjectKey
+	ContentHash string `gorm:"size:64;index" json:"-"`
+
 	// Metadata
-	Description string `gorm:"type:text" json:"description"`
-	Tags        string `gorm:"type:text" json:"tags"`
+	Description string             `gorm:"type:text" json:"description"`
+	Tags        models.StringArray `gorm:"type:text[];not null;default:'{}'" json:"tags"`           // Matched ignoring case, see document_tag_keys
+	Metadata    models.JSONMap     `gorm:"type:jsonb;default:'{}';index:,type:gin" json:"metadata"` // Values of the folder's metadata fields
 
 	// OCR
 	OCRStatus string `gorm:"default:'pending'" json:"ocr_status"` // pending, processing, completed, failed
@@ -59,25 +71,59 @@ type Document struct {
 	// Processing
 	HasThumbnail  bool   `gorm:"default:false" json:"has_thumbnail"`
 	ThumbnailPath string `json:"thumbnail_path"`
+	ScanStatus    string `gorm:"size:20;default:'NOT_SCANNED'" json:"scan_status"` // Scan status of the current version
+
+	// Full-text search
+	IndexStatus  string     `gorm:"size:20;default:'PENDING';index" json:"index_status"`
+	IndexedAt    *time.Time `json:"indexed_at,omitempty"`
+	Content      string     `gorm:"type:text" json:"-"`                                       // Text extracted from the current version
+	SearchVector string     `gorm:"type:tsvector;index:,type:gin;->:false;<-:false" json:"-"` // Maintained by the index worker
 
 	// Owner
 	UploadedBy uuid.UUID `gorm:"type:uuid;not null" json:"uploaded_by"`
 
+	// Check-out lock, only the holder can add versions until it is released or expires
+	LockedBy      *uuid.UUID `gorm:"type:uuid;index" json:"locked_by,omitempty"`
+	LockedAt      *time.Time `json:"locked_at,omitempty"`
+	LockExpiresAt *time.Time `json:"lock_expires_at,omitempty"`
+
 	// Timestamps
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"` // Moved to the trash
+	DeletedBy *uuid.UUID     `gorm:"type:uuid" json:"deleted_by,omitempty"`
+}
+
+// ActiveLockHolder returns the user holding the check-out lock, nil when the document is not
+// locked or the lock has expired
+func (d *Document) ActiveLockHolder(now time.Time) *uuid.UUID {
+	if d.LockedBy == nil || d.LockExpiresAt == nil || !d.LockExpiresAt.After(now) {
+		return nil
+	}
+	return d.LockedBy
 }
 
 // DocumentVersion represents version history
 type DocumentVersion struct {
 	ID         uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	DocumentID uuid.UUID `gorm:"type:uuid;not null" json:"document_id"`
+	DocumentID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_document_versions_document_version" json:"document_id"`
 	Document   Document  `gorm:"foreignKey:DocumentID" json:"document,omitempty"`
-	Version    int       `gorm:"not null" json:"version"`
+	Version    int       `gorm:"not null;uniqueIndex:idx_document_versions_document_version" json:"version"`
 	ObjectKey  string    `gorm:"not null" json:"object_key"`
 	FileSize   int64     `gorm:"not null" json:"file_size"`
 	Checksum   string    `gorm:"not null" json:"checksum"`
 	CreatedBy  uuid.UUID `gorm:"type:uuid;not null" json:"created_by"`
 	CreatedAt  time.Time `json:"created_at"`
+
+	// SHA-256 of the file once it is in the content store, stored under this hash instead of ObjectKey
+	ContentHash string `gorm:"size:64;index" json:"-"`
+
+	// Pinned versions are never removed by the version retention rules
+	Pinned bool `gorm:"not null;default:false" json:"pinned"`
+
+	// Malware scan
+	ScanStatus    string     `gorm:"size:20;default:'NOT_SCANNED';index" json:"scan_status"`
+	ScanResult    string     `json:"scan_result,omitempty"` // Signature found or scanner error
+	ScanStartedAt *time.Time `json:"-"`
+	ScannedAt     *time.Time `json:"scanned_at,omitempty"`
 }
@@ -0,0 +1,39 @@
+package document
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Folder export statuses
+const (
+	FolderExportPending   = "PENDING"
+	FolderExportRunning   = "RUNNING"
+	FolderExportCompleted = "COMPLETED"
+	FolderExportFailed    = "FAILED"
+	FolderExportExpired   = "EXPIRED"
+)
+
+// FolderExport is a ZIP archive of a folder built in the background, for folders too large to be
+// zipped while downloading. The archive is stored until ExpiresAt and only its requester can fetch it.
+type FolderExport struct {
+	ID            uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	FolderID      uuid.UUID `gorm:"type:uuid;not null;index" json:"folder_id"`
+	RequestedBy   uuid.UUID `gorm:"type:uuid;not null;index" json:"requested_by"`
+	AccessChecked bool      `gorm:"not null;default:false" json:"-"` // Only documents the requester may read are included
+	Status        string    `gorm:"size:20;not null;index" json:"status"`
+	Error         string    `gorm:"type:text" json:"error,omitempty"`
+
+	FileCount    int    `gorm:"not null;default:0" json:"file_count"`
+	SkippedFiles int    `gorm:"not null;default:0" json:"skipped_files"` // Files blocked by the malware scan or missing in storage
+	TotalSize    int64  `gorm:"not null;default:0" json:"total_size"`    // Bytes of the files in the archive
+	ArchiveSize  int64  `gorm:"not null;default:0" json:"archive_size"`
+	FileName     string `gorm:"not null" json:"file_name"`
+	ObjectKey    string `json:"-"`
+
+	CreatedAt   time.Time  `json:"created_at"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time `gorm:"index" json:"expires_at,omitempty"`
+}
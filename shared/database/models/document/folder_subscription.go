@@ -0,0 +1,49 @@
+package document
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// How often a folder subscription notifies its user
+const (
+	SubscriptionInstant = "instant" // Within a minute of a change
+	SubscriptionHourly  = "hourly"  // At most one summary an hour
+	SubscriptionDaily   = "daily"   // At most one summary a day
+)
+
+// Changes in a folder its subscribers are notified about
+const (
+	FolderChangeAdded   = "added"   // A document uploaded or extracted into the folder
+	FolderChangeUpdated = "updated" // A new or restored version of a document
+	FolderChangeDeleted = "deleted" // A document or subfolder moved to the trash
+)
+
+// FolderSubscription watches a folder, and with IncludeSubfolders every folder below it, for documents
+// being added, updated and deleted. Changes are collected as FolderChanges and sent to the user
+// according to Frequency.
+type FolderSubscription struct {
+	ID                uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID            uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_folder_subscription" json:"user_id"`
+	FolderID          uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_folder_subscription;index" json:"folder_id"`
+	IncludeSubfolders bool       `gorm:"not null" json:"include_subfolders"`
+	Frequency         string     `gorm:"size:20;not null" json:"frequency"`
+	LastNotifiedAt    *time.Time `json:"last_notified_at,omitempty"` // Start of the period of hourly and daily subscriptions
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+
+	Folder *Folder `gorm:"foreignKey:FolderID" json:"folder,omitempty"`
+}
+
+// FolderChange is a change in a watched folder waiting to be sent to the subscriber
+type FolderChange struct {
+	ID             uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	SubscriptionID uuid.UUID  `gorm:"type:uuid;not null;index" json:"subscription_id"`
+	FolderID       uuid.UUID  `gorm:"type:uuid;not null" json:"folder_id"`    // Folder the change happened in
+	DocumentID     *uuid.UUID `gorm:"type:uuid" json:"document_id,omitempty"` // Empty for subfolders
+	Name           string     `gorm:"not null" json:"name"`
+	Change         string     `gorm:"size:20;not null" json:"change"`
+	ActorID        *uuid.UUID `gorm:"type:uuid" json:"actor_id,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
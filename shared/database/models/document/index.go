@@ -0,0 +1,10 @@
+package document
+
+// Full-text index statuses of documents. New uploads and versions are PENDING until the index worker
+// has extracted their text.
+const (
+	IndexStatusPending = "PENDING"
+	IndexStatusIndexed = "INDEXED"
+	IndexStatusSkipped = "SKIPPED" // Only name, tags and description are searchable
+	IndexStatusFailed  = "FAILED"
+)
@@ -0,0 +1,51 @@
+package document
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Status of a storage integrity check
+const (
+	IntegrityStatusRunning   = "RUNNING"
+	IntegrityStatusCompleted = "COMPLETED"
+	IntegrityStatusFailed    = "FAILED"
+)
+
+// Problems a storage integrity check finds
+const (
+	IntegrityIssueMissingObject    = "missing_object"    // A version or content record whose file is not in storage
+	IntegrityIssueChecksumMismatch = "checksum_mismatch" // A stored file whose content differs from its record
+	IntegrityIssueOrphanedObject   = "orphaned_object"   // A stored file no record references
+)
+
+// IntegrityReport is the result of one run of the storage integrity check
+type IntegrityReport struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Status     string     `gorm:"size:20;not null;index" json:"status"`
+	Error      string     `gorm:"type:text" json:"error,omitempty"`
+	StartedAt  time.Time  `gorm:"not null;index" json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+
+	VersionsChecked    int `gorm:"not null;default:0" json:"versions_checked"`
+	ObjectsChecked     int `gorm:"not null;default:0" json:"objects_checked"`
+	MissingObjects     int `gorm:"not null;default:0" json:"missing_objects"`
+	ChecksumMismatches int `gorm:"not null;default:0" json:"checksum_mismatches"`
+	OrphanedObjects    int `gorm:"not null;default:0" json:"orphaned_objects"`
+	RepairedObjects    int `gorm:"not null;default:0" json:"repaired_objects"` // Orphaned objects removed
+}
+
+// IntegrityIssue is a problem found by a storage integrity check
+type IntegrityIssue struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	ReportID   uuid.UUID  `gorm:"type:uuid;not null;index" json:"report_id"`
+	Type       string     `gorm:"size:30;not null;index" json:"type"`
+	ObjectKey  string     `gorm:"not null" json:"object_key"`
+	DocumentID *uuid.UUID `gorm:"type:uuid" json:"document_id,omitempty"`
+	VersionID  *uuid.UUID `gorm:"type:uuid" json:"version_id,omitempty"`
+	Version    int        `json:"version,omitempty"`
+	Details    string     `gorm:"type:text" json:"details,omitempty"`
+	Repaired   bool       `gorm:"not null;default:false" json:"repaired"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
@@ -0,0 +1,31 @@
+package document
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Supported metadata field types
+const (
+	MetadataFieldTypeString  = "string"
+	MetadataFieldTypeNumber  = "number"
+	MetadataFieldTypeBoolean = "boolean"
+	MetadataFieldTypeDate    = "date"
+	MetadataFieldTypeEnum    = "enum"
+)
+
+// MetadataField describes a metadata value the documents of a folder and its subfolders carry.
+// Values are stored in Document.Metadata under Key. A subfolder field overrides a parent folder
+// field with the same key.
+type MetadataField struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	FolderID  uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_folder_metadata_key" json:"folder_id"`
+	Key       string    `gorm:"size:64;not null;uniqueIndex:idx_folder_metadata_key" json:"key"`
+	Label     string    `gorm:"size:100;not null" json:"label"`
+	Type      string    `gorm:"size:20;not null" json:"type"` // string, number, boolean, date, enum
+	Required  bool      `gorm:"not null;default:false" json:"required"`
+	Options   string    `gorm:"type:text" json:"options"` // Comma separated allowed values of enum fields
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,41 @@
+package document
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RetentionPolicy sets retention rules on a folder, applying to the documents in the folder and its
+// subfolders. When several folders on the path have a policy every rule applies, so the longest
+// minimum retention wins over an earlier auto-delete.
+type RetentionPolicy struct {
+	ID       uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	FolderID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex" json:"folder_id"`
+	// Documents cannot be deleted until they are this many days old (0: no minimum)
+	MinRetentionDays int `gorm:"not null;default:0" json:"min_retention_days"`
+	// Documents are moved to the trash once they are this many days old (0: kept forever)
+	DeleteAfterDays int       `gorm:"not null;default:0" json:"delete_after_days"`
+	CreatedBy       uuid.UUID `gorm:"type:uuid;not null" json:"created_by"`
+	UpdatedBy       uuid.UUID `gorm:"type:uuid;not null" json:"updated_by"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// LegalHold blocks deleting, purging and pruning the versions of a document, or of everything in a
+// folder and its subfolders, regardless of retention rules, until it is released
+type LegalHold struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	ObjectType string     `gorm:"size:20;not null;index:idx_legal_hold_object" json:"object_type"` // folder or document, see AccessObjectFolder
+	ObjectID   uuid.UUID  `gorm:"type:uuid;not null;index:idx_legal_hold_object" json:"object_id"`
+	Reason     string     `gorm:"type:text;not null" json:"reason"`
+	PlacedBy   uuid.UUID  `gorm:"type:uuid;not null" json:"placed_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ReleasedAt *time.Time `gorm:"index" json:"released_at,omitempty"` // Active while empty
+	ReleasedBy *uuid.UUID `gorm:"type:uuid" json:"released_by,omitempty"`
+}
+
+// IsActive reports whether the hold has not been released
+func (h *LegalHold) IsActive() bool {
+	return h.ReleasedAt == nil
+}
@@ -0,0 +1,17 @@
+package document
+
+// Malware scan statuses of documents and document versions. Files uploaded while scanning is enabled
+// stay in quarantine until the scan worker finds them clean.
+const (
+	ScanStatusNotScanned = "NOT_SCANNED" // Uploaded while scanning was disabled
+	ScanStatusPending    = "PENDING"
+	ScanStatusScanning   = "SCANNING"
+	ScanStatusClean      = "CLEAN"
+	ScanStatusInfected   = "INFECTED"
+	ScanStatusFailed     = "FAILED" // The scanner rejected the file, e.g. over its size limit
+)
+
+// ScanStatusAllowsDownload reports whether a file with the scan status may be served
+func ScanStatusAllowsDownload(status string) bool {
+	return status == ScanStatusClean || status == ScanStatusNotScanned || status == ""
+}
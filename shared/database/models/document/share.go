@@ -0,0 +1,27 @@
+package document
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DocumentShare is a public link to a document. The link carries the share ID signed by the
+// document service, so no token is stored; revoking or expiring the share disables the link.
+type DocumentShare struct {
+	ID         uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	DocumentID uuid.UUID `gorm:"type:uuid;not null;index" json:"document_id"`
+
+	// Restrictions
+	PasswordHash  string     `json:"-"`
+	ExpiresAt     *time.Time `json:"expires_at"`
+	MaxDownloads  *int       `json:"max_downloads"`
+	DownloadCount int        `gorm:"not null;default:0" json:"download_count"`
+	ViewOnly      bool       `gorm:"not null;default:false" json:"view_only"` // Served inline for preview instead of as an attachment
+
+	CreatedBy      uuid.UUID  `gorm:"type:uuid;not null" json:"created_by"`
+	RevokedAt      *time.Time `json:"revoked_at,omitempty"`
+	LastAccessedAt *time.Time `json:"last_accessed_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
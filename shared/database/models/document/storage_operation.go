@@ -0,0 +1,38 @@
+package document
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Storage operation types
+const (
+	StorageOperationCreateFolder = "create_folder"
+	StorageOperationMoveFolder   = "move_folder"
+)
+
+// Storage operation statuses
+const (
+	StorageOperationPending   = "pending"
+	StorageOperationSucceeded = "succeeded"
+	StorageOperationFailed    = "failed"
+)
+
+// StorageOperation is a folder change queued for storage in the same transaction as the database
+// change. Operations are applied right after the commit and retried with exponential backoff until
+// they succeed, so storage follows the database even when it is briefly unavailable.
+type StorageOperation struct {
+	ID            uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Type          string     `gorm:"size:30;not null" json:"type"`
+	FolderID      uuid.UUID  `gorm:"type:uuid;not null;index" json:"folder_id"`
+	Path          string     `gorm:"not null" json:"path"`  // Folder path, the old path of a move
+	TargetPath    string     `json:"target_path,omitempty"` // New path of a move
+	Status        string     `gorm:"size:20;not null;default:'pending';index:idx_storage_operation_due" json:"status"`
+	Attempts      int        `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt time.Time  `gorm:"index:idx_storage_operation_due" json:"next_attempt_at"`
+	LastError     string     `gorm:"type:text" json:"last_error,omitempty"`
+	CompletedAt   *time.Time `json:"completed_at,omitempty"`
+	CreatedAt     time.Time  `gorm:"index" json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,54 @@
+package document
+
+import (
+	"time"
+
+	"forgecrud-backend/shared/database/models"
+
+	"github.com/google/uuid"
+)
+
+// Upload session statuses
+const (
+	UploadSessionUploading  = "UPLOADING"
+	UploadSessionCompleting = "COMPLETING"
+	UploadSessionCompleted  = "COMPLETED"
+	UploadSessionAborted    = "ABORTED"
+	UploadSessionExpired    = "EXPIRED"
+)
+
+// UploadSession tracks a resumable chunked upload backed by a MinIO multipart upload.
+// Chunks are appended in order at Offset; the upload becomes a document (or a new version
+// of DocumentID) once every byte has been received and the session is completed.
+type UploadSession struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	FolderID   uuid.UUID  `gorm:"type:uuid;not null;index" json:"folder_id"`
+	DocumentID *uuid.UUID `gorm:"type:uuid" json:"document_id"` // set for new versions and once completed
+
+	// File information
+	FileName    string `gorm:"not null" json:"file_name"`
+	MimeType    string `gorm:"not null" json:"mime_type"`
+	FileSize    int64  `gorm:"not null" json:"file_size"`
+	ChunkSize   int64  `gorm:"not null" json:"chunk_size"`
+	Offset      int64  `gorm:"column:upload_offset;not null;default:0" json:"offset"`
+	Version     int    `gorm:"not null" json:"version"`
+	BaseVersion int    `gorm:"not null;default:0" json:"base_version,omitempty"` // version a new version is based on, checked on completion
+	Description string `gorm:"type:text" json:"description"`
+
+	Tags     models.StringArray `gorm:"type:text[];not null;default:'{}'" json:"tags"`
+	Metadata models.JSONMap     `gorm:"type:jsonb;default:'{}'" json:"metadata"` // Validated when the upload starts
+
+	// Storage
+	ObjectKey  string `gorm:"not null" json:"object_key"`
+	StorageKey string `json:"-"` // Key the chunks are assembled at, the quarantine key while scanning is enabled
+	UploadID   string `gorm:"not null" json:"-"`
+	HashState  []byte `json:"-"` // MD5 state of the bytes received so far
+
+	ContentHashState []byte `json:"-"` // SHA-256 state of the bytes received so far, for the content store
+
+	Status    string    `gorm:"size:20;not null;default:'UPLOADING';index" json:"status"`
+	CreatedBy uuid.UUID `gorm:"type:uuid;not null" json:"created_by"`
+	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FileTypePolicy restricts the files uploaded into the folders of an organization and its users by
+// their sniffed MIME type and their extension. Lists are comma separated and empty lists allow
+// everything; MIME types may name a whole class like image/*. Execution-prone types blocked by
+// UPLOAD_BLOCKED_EXTENSIONS and UPLOAD_BLOCKED_MIME_TYPES stay blocked unless AllowExecutables is set.
+// Organizations without a row only have the platform's blocked types.
+type FileTypePolicy struct {
+	ID                uuid.UUID `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	OrganizationID    uuid.UUID `json:"organization_id" gorm:"type:uuid;not null;uniqueIndex"`
+	AllowedMimeTypes  string    `json:"allowed_mime_types" gorm:"type:text"`
+	DeniedMimeTypes   string    `json:"denied_mime_types" gorm:"type:text"`
+	AllowedExtensions string    `json:"allowed_extensions" gorm:"type:text"`
+	DeniedExtensions  string    `json:"denied_extensions" gorm:"type:text"`
+	// Maximum size in bytes by extension (.mp4) or MIME type (video/*); the most specific match applies
+	MaxSizes         JSONMap    `json:"max_sizes" gorm:"type:jsonb;default:'{}'"`
+	AllowExecutables bool       `json:"allow_executables" gorm:"not null;default:false"`
+	UpdatedBy        *uuid.UUID `json:"updated_by" gorm:"type:uuid"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+
+	// Relations
+	Organization Organization `json:"-" gorm:"foreignKey:OrganizationID"`
+}
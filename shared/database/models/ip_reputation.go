@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IPReputation scores a client IP by its failed requests (401, 403 and 429) through the gateway.
+// The score decays over time; addresses whose score reaches the threshold are banned at the gateway
+// until BannedUntil.
+type IPReputation struct {
+	IPAddress     string     `json:"ip_address" gorm:"type:varchar(45);primaryKey"`
+	Score         float64    `json:"score" gorm:"not null;default:0"` // Weighted failures, decayed up to LastFailureAt
+	Unauthorized  int64      `json:"unauthorized" gorm:"not null;default:0"`
+	Forbidden     int64      `json:"forbidden" gorm:"not null;default:0"`
+	RateLimited   int64      `json:"rate_limited" gorm:"not null;default:0"`
+	LastFailureAt *time.Time `json:"last_failure_at"`
+	BannedUntil   *time.Time `json:"banned_until" gorm:"index"`
+	BanReason     string     `json:"ban_reason" gorm:"type:text"`
+	BannedBy      *uuid.UUID `json:"banned_by" gorm:"type:uuid"` // Admin who banned the address, nil for automatic bans
+	Bans          int        `json:"bans" gorm:"not null;default:0"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// IsBanned reports whether the address is banned at t
+func (r IPReputation) IsBanned(t time.Time) bool {
+	return r.BannedUntil != nil && r.BannedUntil.After(t)
+}
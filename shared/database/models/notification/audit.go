@@ -26,3 +26,31 @@ type AuditLog struct {
 func (AuditLog) TableName() string {
 	return "audit_logs"
 }
+
+// Audit log archive statuses
+const (
+	AuditLogArchiveArchived = "ARCHIVED"
+	AuditLogArchiveVerified = "VERIFIED"
+	AuditLogArchiveFailed   = "FAILED"
+)
+
+// AuditLogArchive records the audit logs of one UTC day written to storage as gzipped JSON lines.
+// Logs past their retention are only deleted once their day is archived and not failed.
+type AuditLogArchive struct {
+	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Day         time.Time  `json:"day" gorm:"not null;uniqueIndex"` // Midnight UTC of the archived day
+	ObjectKey   string     `json:"object_key" gorm:"type:varchar(255);not null"`
+	LogCount    int64      `json:"log_count" gorm:"not null"`
+	SizeBytes   int64      `json:"size_bytes" gorm:"not null"`
+	Checksum    string     `json:"checksum" gorm:"type:varchar(64);not null"` // SHA-256 of the stored object
+	Status      string     `json:"status" gorm:"type:varchar(20);not null;index"`
+	VerifiedAt  *time.Time `json:"verified_at,omitempty"`
+	VerifyError string     `json:"verify_error,omitempty" gorm:"type:text"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// TableName returns the table name for AuditLogArchive
+func (AuditLogArchive) TableName() string {
+	return "audit_log_archives"
+}
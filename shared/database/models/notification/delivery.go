@@ -0,0 +1,69 @@
+package notification
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Delivery statuses of a notification on one channel. A delivery only moves forward, from queued
+// through sent and delivered to read, or to failed; skipped deliveries were held back on purpose.
+const (
+	DeliveryStatusQueued    = "queued"    // Waiting in the email queue, the webhook outbox or the digest
+	DeliveryStatusSent      = "sent"      // Accepted by the provider
+	DeliveryStatusDelivered = "delivered" // Reported delivered by the provider, or stored in the inbox
+	DeliveryStatusRead      = "read"      // Marked read in-app, or the email was opened
+	DeliveryStatusFailed    = "failed"    // Rejected, bounced, dead-lettered or not reaching any device
+	DeliveryStatusSkipped   = "skipped"   // Held back by quiet hours or a suppressed address
+)
+
+// DeliveryPrecedingStatuses are the statuses a delivery can move to each status from
+var DeliveryPrecedingStatuses = map[string][]string{
+	DeliveryStatusQueued:    {DeliveryStatusFailed}, // A dead-lettered email queued again
+	DeliveryStatusSent:      {DeliveryStatusQueued},
+	DeliveryStatusDelivered: {DeliveryStatusQueued, DeliveryStatusSent},
+	DeliveryStatusRead:      {DeliveryStatusQueued, DeliveryStatusSent, DeliveryStatusDelivered},
+	DeliveryStatusFailed:    {DeliveryStatusQueued, DeliveryStatusSent},
+}
+
+// NotificationDelivery is the delivery receipt of a stored notification on one channel. Emails and
+// SMS link the queued message through ReferenceID, push deliveries per device are in push_deliveries.
+type NotificationDelivery struct {
+	ID             uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	NotificationID uuid.UUID  `json:"notification_id" gorm:"type:uuid;not null;uniqueIndex:idx_notification_delivery_channel"`
+	UserID         uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	Channel        string     `json:"channel" gorm:"type:varchar(20);not null;uniqueIndex:idx_notification_delivery_channel"`
+	Status         string     `json:"status" gorm:"type:varchar(20);not null;index"`
+	ReferenceID    *uuid.UUID `json:"reference_id,omitempty" gorm:"type:uuid;index"` // Queued email or SMS message
+	Detail         string     `json:"detail,omitempty" gorm:"type:text"`             // Why it failed or was skipped
+	QueuedAt       *time.Time `json:"queued_at,omitempty"`
+	SentAt         *time.Time `json:"sent_at,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	ReadAt         *time.Time `json:"read_at,omitempty"`
+	FailedAt       *time.Time `json:"failed_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt      time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName returns the table name for NotificationDelivery
+func (NotificationDelivery) TableName() string {
+	return "notification_deliveries"
+}
+
+// DeliveryTimestampColumn returns the column holding the time a delivery reached the status
+func DeliveryTimestampColumn(status string) string {
+	switch status {
+	case DeliveryStatusQueued:
+		return "queued_at"
+	case DeliveryStatusSent:
+		return "sent_at"
+	case DeliveryStatusDelivered:
+		return "delivered_at"
+	case DeliveryStatusRead:
+		return "read_at"
+	case DeliveryStatusFailed:
+		return "failed_at"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,76 @@
+package notification
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Digest frequencies a user can select. With a digest, low priority notifications are collected
+// and emailed and pushed as one summary instead of one message per notification.
+const (
+	DigestOff    = "off"
+	DigestHourly = "hourly"
+	DigestDaily  = "daily"
+)
+
+// TypeDigest is the type of the in-app notification summarizing a digest
+const TypeDigest = "notification.digest"
+
+// IsLowPriority reports whether notifications of the priority can wait for the recipient's digest.
+// High and urgent notifications are always delivered right away.
+func IsLowPriority(priority NotificationPriority) bool {
+	return priority == NotificationPriorityLow || priority == NotificationPriorityNormal || priority == ""
+}
+
+// DigestDue reports whether the user's next digest is due at t. Users who turned the digest off
+// get their pending notifications right away, and no digest is sent during quiet hours.
+func (s NotificationSettings) DigestDue(t time.Time) bool {
+	if s.DigestFrequency == DigestOff || s.DigestFrequency == "" {
+		return true
+	}
+	if s.InQuietHours(t) {
+		return false
+	}
+
+	switch s.DigestFrequency {
+	case DigestHourly:
+		return s.LastDigestAt == nil || t.Truncate(time.Hour).After(*s.LastDigestAt)
+	case DigestDaily:
+		location, err := time.LoadLocation(s.Timezone)
+		if err != nil {
+			location = time.UTC
+		}
+		digestTime, err := ParseClock(s.DigestTime)
+		if err != nil {
+			digestTime = 9 * 60
+		}
+		local := t.In(location)
+		today := time.Date(local.Year(), local.Month(), local.Day(), digestTime/60, digestTime%60, 0, 0, location)
+		return !local.Before(today) && (s.LastDigestAt == nil || s.LastDigestAt.Before(today))
+	}
+	return false
+}
+
+// DigestItem is a notification waiting for its recipient's next digest. Email and Push record
+// whether the recipient selected those channels for the notification's category.
+type DigestItem struct {
+	ID             uuid.UUID            `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID         uuid.UUID            `json:"user_id" gorm:"type:uuid;not null;index"`
+	NotificationID *uuid.UUID           `json:"notification_id,omitempty" gorm:"type:uuid"`
+	Type           string               `json:"type" gorm:"type:varchar(50)"`
+	Category       NotificationCategory `json:"category" gorm:"type:varchar(20)"`
+	Level          NotificationLevel    `json:"level" gorm:"type:varchar(20)"`
+	Title          string               `json:"title" gorm:"type:varchar(200)"`
+	Message        string               `json:"message" gorm:"type:text"`
+	Entity         string               `json:"entity,omitempty" gorm:"type:varchar(100)"`
+	EntityID       *uuid.UUID           `json:"entity_id,omitempty" gorm:"type:uuid"`
+	Email          bool                 `json:"email"`
+	Push           bool                 `json:"push"`
+	CreatedAt      time.Time            `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TableName returns the table name for DigestItem
+func (DigestItem) TableName() string {
+	return "notification_digest_items"
+}
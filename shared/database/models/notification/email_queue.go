@@ -0,0 +1,108 @@
+package notification
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Outbound email statuses
+const (
+	EmailStatusQueued       = "queued"
+	EmailStatusSent         = "sent"
+	EmailStatusDeadLettered = "dead_lettered" // Attempts exhausted or rejected for good, see EmailDeadLetter
+	EmailStatusSuppressed   = "suppressed"    // Every recipient is on the suppression list
+	EmailStatusBounced      = "bounced"       // Accepted by the provider, then bounced by the recipient's server
+)
+
+// EmailMessage is a rendered email in the outbound queue. Bodies are cleared once the message is
+// sent since they may contain one-time codes.
+type EmailMessage struct {
+	ID                uuid.UUID        `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	OrganizationID    *uuid.UUID       `json:"organization_id,omitempty" gorm:"type:uuid;index"`
+	To                string           `json:"to" gorm:"type:text;not null"` // Comma separated addresses
+	CC                string           `json:"cc,omitempty" gorm:"type:text"`
+	BCC               string           `json:"bcc,omitempty" gorm:"type:text"`
+	Subject           string           `json:"subject" gorm:"type:text;not null"`
+	Body              string           `json:"-" gorm:"type:text"`
+	TextBody          string           `json:"-" gorm:"type:text"`
+	IsHTML            bool             `json:"is_html"`
+	TemplateID        string           `json:"template_id,omitempty" gorm:"type:varchar(100)"`
+	Attachments       EmailAttachments `json:"attachments,omitempty" gorm:"type:jsonb"`
+	Status            string           `json:"status" gorm:"type:varchar(20);not null;default:'queued';index:idx_email_message_due"`
+	Attempts          int              `json:"attempts" gorm:"default:0"`
+	NextAttemptAt     time.Time        `json:"next_attempt_at" gorm:"index:idx_email_message_due"`
+	LastError         string           `json:"last_error,omitempty" gorm:"type:text"`
+	Provider          string           `json:"provider,omitempty" gorm:"type:varchar(20)"` // Provider that accepted the message
+	ProviderMessageID string           `json:"provider_message_id,omitempty" gorm:"type:varchar(255);index"`
+	SentAt            *time.Time       `json:"sent_at,omitempty"`
+	CreatedAt         time.Time        `json:"created_at" gorm:"autoCreateTime;index"`
+	UpdatedAt         time.Time        `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName returns the table name for EmailMessage
+func (EmailMessage) TableName() string {
+	return "email_messages"
+}
+
+// EmailAttachment is a document attached to an email. Only the reference is queued, the content is
+// fetched from document-service when the email is sent.
+type EmailAttachment struct {
+	DocumentID  uuid.UUID `json:"document_id"`
+	FileName    string    `json:"file_name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+}
+
+// EmailAttachments are the attachments of an email, stored as a jsonb column
+type EmailAttachments []EmailAttachment
+
+// Value implements driver.Valuer
+func (a EmailAttachments) Value() (driver.Value, error) {
+	if a == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(a)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan implements sql.Scanner
+func (a *EmailAttachments) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*a = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return errors.New("unsupported type for EmailAttachments")
+	}
+	return json.Unmarshal(data, a)
+}
+
+// EmailDeadLetter records a message that could not be sent. Retrying it queues the message again.
+type EmailDeadLetter struct {
+	ID             uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	MessageID      uuid.UUID  `json:"message_id" gorm:"type:uuid;not null;uniqueIndex"`
+	OrganizationID *uuid.UUID `json:"organization_id,omitempty" gorm:"type:uuid;index"`
+	To             string     `json:"to" gorm:"type:text;not null"`
+	Subject        string     `json:"subject" gorm:"type:text;not null"`
+	TemplateID     string     `json:"template_id,omitempty" gorm:"type:varchar(100)"`
+	Attempts       int        `json:"attempts"`
+	Error          string     `json:"error" gorm:"type:text"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"autoCreateTime;index"`
+}
+
+// TableName returns the table name for EmailDeadLetter
+func (EmailDeadLetter) TableName() string {
+	return "email_dead_letters"
+}
@@ -0,0 +1,63 @@
+package notification
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EmailTemplate is the current version of an email template. Templates without an organization are
+// the global defaults; an organization overrides one by creating a template with the same key.
+// Subject and text body are Go text/templates, the HTML body an html/template. Templates without a
+// locale translate their texts with {{t "key"}}; a template with a locale replaces them for recipients
+// of that language.
+type EmailTemplate struct {
+	ID             uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Key            string     `json:"key" gorm:"type:varchar(100);not null;index"`
+	OrganizationID *uuid.UUID `json:"organization_id,omitempty" gorm:"type:uuid;index"`
+	Locale         string     `json:"locale,omitempty" gorm:"type:varchar(10);not null;default:''"`
+	Subject        string     `json:"subject" gorm:"type:varchar(500);not null"`
+	HTMLBody       string     `json:"html_body" gorm:"type:text;not null"`
+	TextBody       string     `json:"text_body" gorm:"type:text"`
+	Variables      string     `json:"variables" gorm:"type:text"` // Comma separated placeholders used by the template
+	Description    string     `json:"description" gorm:"type:text"`
+	Version        int        `json:"version" gorm:"not null;default:1"`
+	CreatedBy      *uuid.UUID `json:"created_by,omitempty" gorm:"type:uuid"`
+	UpdatedBy      *uuid.UUID `json:"updated_by,omitempty" gorm:"type:uuid"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt      time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName returns the table name for EmailTemplate
+func (EmailTemplate) TableName() string {
+	return "email_templates"
+}
+
+// EmailTemplateVersion is a snapshot of a template, recorded whenever it is created or changed
+type EmailTemplateVersion struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	TemplateID uuid.UUID  `json:"template_id" gorm:"type:uuid;not null;uniqueIndex:idx_email_template_version"`
+	Version    int        `json:"version" gorm:"not null;uniqueIndex:idx_email_template_version"`
+	Subject    string     `json:"subject" gorm:"type:varchar(500);not null"`
+	HTMLBody   string     `json:"html_body" gorm:"type:text;not null"`
+	TextBody   string     `json:"text_body" gorm:"type:text"`
+	CreatedBy  *uuid.UUID `json:"created_by,omitempty" gorm:"type:uuid"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TableName returns the table name for EmailTemplateVersion
+func (EmailTemplateVersion) TableName() string {
+	return "email_template_versions"
+}
+
+// Snapshot returns the version record of the template's current content
+func (t EmailTemplate) Snapshot() EmailTemplateVersion {
+	return EmailTemplateVersion{
+		TemplateID: t.ID,
+		Version:    t.Version,
+		Subject:    t.Subject,
+		HTMLBody:   t.HTMLBody,
+		TextBody:   t.TextBody,
+		CreatedBy:  t.UpdatedBy,
+	}
+}
@@ -0,0 +1,63 @@
+package notification
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Email event types reported by provider webhooks and the tracking endpoints
+const (
+	EmailEventDelivered  = "delivered"
+	EmailEventBounced    = "bounced"
+	EmailEventComplained = "complained"
+	EmailEventOpened     = "opened"
+	EmailEventClicked    = "clicked"
+)
+
+// Reasons an address is on the suppression list
+const (
+	SuppressionBounce    = "bounce"
+	SuppressionComplaint = "complaint"
+	SuppressionManual    = "manual"
+)
+
+// EmailEvent is something that happened to a sent email after the provider accepted it
+type EmailEvent struct {
+	ID             uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	MessageID      uuid.UUID  `json:"message_id" gorm:"type:uuid;not null;index"`
+	OrganizationID *uuid.UUID `json:"organization_id,omitempty" gorm:"type:uuid;index"`
+	Type           string     `json:"type" gorm:"type:varchar(20);not null;index"`
+	Recipient      string     `json:"recipient,omitempty" gorm:"type:varchar(255)"`
+	Provider       string     `json:"provider" gorm:"type:varchar(20)"` // Provider webhook or "tracking" for our own pixel and links
+	Permanent      bool       `json:"permanent,omitempty"`              // Hard bounce
+	URL            string     `json:"url,omitempty" gorm:"type:text"`
+	UserAgent      string     `json:"user_agent,omitempty" gorm:"type:text"`
+	IPAddress      string     `json:"ip_address,omitempty" gorm:"type:varchar(45)"`
+	Details        string     `json:"details,omitempty" gorm:"type:text"`
+	OccurredAt     time.Time  `json:"occurred_at" gorm:"index"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TableName returns the table name for EmailEvent
+func (EmailEvent) TableName() string {
+	return "email_events"
+}
+
+// EmailSuppression is an address that emails are no longer sent to, because it bounced, its owner
+// marked an email as spam or an administrator added it. Addresses are stored lowercase.
+type EmailSuppression struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Email     string     `json:"email" gorm:"type:varchar(255);not null;uniqueIndex"`
+	Reason    string     `json:"reason" gorm:"type:varchar(20);not null"`
+	Provider  string     `json:"provider,omitempty" gorm:"type:varchar(20)"`
+	MessageID *uuid.UUID `json:"message_id,omitempty" gorm:"type:uuid"` // Email that caused the suppression
+	Details   string     `json:"details,omitempty" gorm:"type:text"`
+	CreatedBy *uuid.UUID `json:"created_by,omitempty" gorm:"type:uuid"`
+	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TableName returns the table name for EmailSuppression
+func (EmailSuppression) TableName() string {
+	return "email_suppressions"
+}
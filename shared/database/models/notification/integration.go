@@ -0,0 +1,96 @@
+package notification
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Integration kinds, each posting its own message format
+const (
+	IntegrationSlack   = "slack"   // Slack incoming webhook
+	IntegrationTeams   = "teams"   // Microsoft Teams incoming webhook
+	IntegrationWebhook = "webhook" // Any endpoint accepting signed JSON
+)
+
+// IntegrationCategoryAll subscribes an integration to every category
+const IntegrationCategoryAll = "*"
+
+// Integration delivery statuses
+const (
+	IntegrationDeliveryPending   = "pending"
+	IntegrationDeliverySucceeded = "succeeded"
+	IntegrationDeliveryFailed    = "failed"
+)
+
+// NotificationIntegration posts the notifications of an organization's users to a chat channel or endpoint
+type NotificationIntegration struct {
+	ID             uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	OrganizationID uuid.UUID `json:"organization_id" gorm:"type:uuid;not null;index"`
+	Name           string    `json:"name" gorm:"type:varchar(100);not null"`
+	Kind           string    `json:"kind" gorm:"type:varchar(20);not null"`
+	URL            string    `json:"-" gorm:"type:varchar(500);not null"`  // Incoming webhook URLs embed their credentials
+	Secret         string    `json:"-" gorm:"type:varchar(128)"`           // HMAC-SHA256 signing key of generic webhooks
+	Categories     string    `json:"categories" gorm:"type:text;not null"` // Comma separated categories, "*" for all
+	Template       string    `json:"template,omitempty" gorm:"type:text"`  // text/template over IntegrationMessage, empty for the default
+	IsActive       bool      `json:"is_active" gorm:"not null"`
+	CreatedBy      uuid.UUID `json:"created_by" gorm:"type:uuid"`
+	CreatedAt      time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt      time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName returns the table name for NotificationIntegration
+func (NotificationIntegration) TableName() string {
+	return "notification_integrations"
+}
+
+// Subscribes reports whether the integration receives notifications of the category
+func (i NotificationIntegration) Subscribes(category NotificationCategory) bool {
+	for _, subscribed := range strings.Split(i.Categories, ",") {
+		subscribed = strings.TrimSpace(subscribed)
+		if subscribed == IntegrationCategoryAll || subscribed == string(category) {
+			return true
+		}
+	}
+	return false
+}
+
+// IntegrationMessage is the data integration templates are rendered with
+type IntegrationMessage struct {
+	Title        string `json:"title"`
+	Message      string `json:"message"`
+	Level        string `json:"level"`
+	Type         string `json:"type"`
+	Category     string `json:"category"`
+	Entity       string `json:"entity,omitempty"`
+	EntityID     string `json:"entity_id,omitempty"`
+	UserName     string `json:"user_name,omitempty"`
+	UserEmail    string `json:"user_email,omitempty"`
+	Organization string `json:"organization,omitempty"`
+	Timestamp    string `json:"timestamp"`
+}
+
+// IntegrationDelivery is a message posted (or to be posted) to an integration, with its attempt log
+type IntegrationDelivery struct {
+	ID             uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	IntegrationID  uuid.UUID  `json:"integration_id" gorm:"type:uuid;not null;index"`
+	NotificationID *uuid.UUID `json:"notification_id,omitempty" gorm:"type:uuid;index"`
+	Payload        string     `json:"payload" gorm:"type:jsonb;not null"`
+	Status         string     `json:"status" gorm:"type:varchar(20);not null;default:'pending';index:idx_integration_delivery_due"`
+	Attempts       int        `json:"attempts" gorm:"default:0"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at" gorm:"index:idx_integration_delivery_due"`
+	ResponseStatus int        `json:"response_status"`
+	LastError      string     `json:"last_error" gorm:"type:text"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"autoCreateTime;index"`
+	UpdatedAt      time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+
+	// Relations
+	Integration NotificationIntegration `json:"-" gorm:"foreignKey:IntegrationID"`
+}
+
+// TableName returns the table name for IntegrationDelivery
+func (IntegrationDelivery) TableName() string {
+	return "integration_deliveries"
+}
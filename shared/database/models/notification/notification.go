@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 // NotificationLevel represents the severity level of a notification
@@ -16,21 +17,110 @@ const (
 	NotificationLevelInfo    NotificationLevel = "info"
 )
 
+// TypeBroadcast is the type of notifications broadcast to an organization or every user
+const TypeBroadcast = "system.broadcast"
+
+// NotificationPriority selects how urgently a notification is delivered. Low and normal priority
+// notifications wait for the recipient's digest, urgent ones are delivered during quiet hours too.
+type NotificationPriority string
+
+const (
+	NotificationPriorityLow    NotificationPriority = "low"
+	NotificationPriorityNormal NotificationPriority = "normal"
+	NotificationPriorityHigh   NotificationPriority = "high"
+	NotificationPriorityUrgent NotificationPriority = "urgent"
+)
+
+// NotificationPriorities lists every priority from lowest to highest
+var NotificationPriorities = []NotificationPriority{
+	NotificationPriorityLow,
+	NotificationPriorityNormal,
+	NotificationPriorityHigh,
+	NotificationPriorityUrgent,
+}
+
+// IsValidPriority reports whether priority is one of NotificationPriorities
+func IsValidPriority(priority NotificationPriority) bool {
+	for _, p := range NotificationPriorities {
+		if p == priority {
+			return true
+		}
+	}
+	return false
+}
+
+// PriorityForLevel returns the priority of notifications created without one: errors are urgent,
+// warnings high and everything else normal
+func PriorityForLevel(level NotificationLevel) NotificationPriority {
+	switch level {
+	case NotificationLevelError:
+		return NotificationPriorityUrgent
+	case NotificationLevelWarning:
+		return NotificationPriorityHigh
+	default:
+		return NotificationPriorityNormal
+	}
+}
+
 // Notification represents a real-time notification
 type Notification struct {
-	ID        uuid.UUID         `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	UserID    *uuid.UUID        `json:"user_id,omitempty" gorm:"type:uuid;index"`
-	Type      string            `json:"type" gorm:"type:varchar(50);not null"`
-	Level     NotificationLevel `json:"level" gorm:"type:varchar(20);not null;default:'info'"`
-	Title     string            `json:"title" gorm:"type:varchar(200);not null"`
-	Message   string            `json:"message" gorm:"type:text;not null"`
-	Action    string            `json:"action,omitempty" gorm:"type:varchar(100)"`
-	EntityID  *uuid.UUID        `json:"entity_id,omitempty" gorm:"type:uuid"`
-	Entity    string            `json:"entity,omitempty" gorm:"type:varchar(100)"`
-	Data      interface{}       `json:"data,omitempty" gorm:"type:jsonb"`
-	IsRead    bool              `json:"is_read" gorm:"default:false;index"`
-	CreatedAt time.Time         `json:"created_at" gorm:"autoCreateTime;index"`
-	ReadAt    *time.Time        `json:"read_at,omitempty"`
+	ID         uuid.UUID            `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID     *uuid.UUID           `json:"user_id,omitempty" gorm:"type:uuid;index"`
+	Type       string               `json:"type" gorm:"type:varchar(50);not null"`
+	Category   NotificationCategory `json:"category" gorm:"type:varchar(50);not null;default:'system';index"` // Derived from the type unless given, see CategoryForType
+	Level      NotificationLevel    `json:"level" gorm:"type:varchar(20);not null;default:'info'"`
+	Priority   NotificationPriority `json:"priority" gorm:"type:varchar(20);not null;default:'normal';index"` // Derived from the level unless given, see PriorityForLevel
+	Title      string               `json:"title" gorm:"type:varchar(200);not null"`
+	Message    string               `json:"message" gorm:"type:text;not null"`
+	Action     string               `json:"action,omitempty" gorm:"type:varchar(100)"`
+	EntityID   *uuid.UUID           `json:"entity_id,omitempty" gorm:"type:uuid"`
+	Entity     string               `json:"entity,omitempty" gorm:"type:varchar(100)"`
+	Data       interface{}          `json:"data,omitempty" gorm:"type:jsonb"`
+	IsRead     bool                 `json:"is_read" gorm:"default:false;index"`
+	CreatedAt  time.Time            `json:"created_at" gorm:"autoCreateTime;index"`
+	ReadAt     *time.Time           `json:"read_at,omitempty"`
+	ArchivedAt *time.Time           `json:"archived_at,omitempty" gorm:"index"` // Archived notifications are hidden from the list and unread count
+}
+
+// ResolveCategory derives the category from the type unless a valid one was given, and returns it
+func (n *Notification) ResolveCategory() NotificationCategory {
+	if !IsValidCategory(n.Category) {
+		n.Category = CategoryForType(n.Type)
+	}
+	return n.Category
+}
+
+// ResolvePriority derives the priority from the level unless a valid one was given, and returns it
+func (n *Notification) ResolvePriority() NotificationPriority {
+	if !IsValidPriority(n.Priority) {
+		n.Priority = PriorityForLevel(n.Level)
+	}
+	return n.Priority
+}
+
+// BeforeCreate stores the resolved category and priority
+func (n *Notification) BeforeCreate(tx *gorm.DB) error {
+	n.ResolveCategory()
+	n.ResolvePriority()
+	return nil
+}
+
+// WebSocketMessage returns the real-time message of the notification
+func (n *Notification) WebSocketMessage() *WebSocketMessage {
+	return &WebSocketMessage{
+		Type:      n.Type,
+		Level:     n.Level,
+		Category:  n.ResolveCategory(),
+		Priority:  n.ResolvePriority(),
+		Title:     n.Title,
+		Message:   n.Message,
+		Timestamp: GetCurrentTime(),
+		Action:    n.Action,
+		EntityID:  n.EntityID,
+		Entity:    n.Entity,
+		UserID:    n.UserID,
+		Data:      n.Data,
+	}
 }
 
 // TableName returns the table name for Notification
@@ -40,16 +130,19 @@ func (Notification) TableName() string {
 
 // WebSocketMessage represents a WebSocket message format
 type WebSocketMessage struct {
-	Type      string            `json:"type"`
-	Level     NotificationLevel `json:"level"`
-	Title     string            `json:"title"`
-	Message   string            `json:"message"`
-	Timestamp time.Time         `json:"timestamp"`
-	Action    string            `json:"action,omitempty"`
-	EntityID  *uuid.UUID        `json:"entity_id,omitempty"`
-	Entity    string            `json:"entity,omitempty"`
-	UserID    *uuid.UUID        `json:"user_id,omitempty"`
-	Data      interface{}       `json:"data,omitempty"`
+	Type      string               `json:"type"`
+	Level     NotificationLevel    `json:"level"`
+	Category  NotificationCategory `json:"category,omitempty"`
+	Priority  NotificationPriority `json:"priority,omitempty"`
+	Title     string               `json:"title"`
+	Message   string               `json:"message"`
+	Timestamp time.Time            `json:"timestamp"`
+	Action    string               `json:"action,omitempty"`
+	EntityID  *uuid.UUID           `json:"entity_id,omitempty"`
+	Entity    string               `json:"entity,omitempty"`
+	UserID    *uuid.UUID           `json:"user_id,omitempty"`
+	Data      interface{}          `json:"data,omitempty"`
+	Channel   string               `json:"channel,omitempty"` // Subscription the message was delivered on
 }
 
 // GetCurrentTime returns current time for WebSocket messages
@@ -0,0 +1,162 @@
+package notification
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Delivery channels a notification can be routed to
+const (
+	ChannelInApp   = "in_app"  // Stored in the notification list and sent over WebSocket
+	ChannelEmail   = "email"   // Emailed to the user's address
+	ChannelPush    = "push"    // Pushed to the user's registered devices
+	ChannelWebhook = "webhook" // Forwarded to the webhooks subscribed to notification.created
+	ChannelDigest  = "digest"  // Held back for the user's digest instead of emailed and pushed
+	ChannelSMS     = "sms"     // Texted to the user's verified phone, only for security alerts
+)
+
+// NotificationCategory groups notifications users set preferences for
+type NotificationCategory string
+
+const (
+	CategoryDocuments NotificationCategory = "documents" // Changes to the user's documents and folders
+	CategorySecurity  NotificationCategory = "security"  // Infected uploads, new device logins and other threats
+	CategoryAccount   NotificationCategory = "account"   // Role and account changes
+	CategorySystem    NotificationCategory = "system"    // Everything else, e.g. notifications created through the API
+)
+
+// NotificationCategories lists every category in display order
+var NotificationCategories = []NotificationCategory{
+	CategoryDocuments,
+	CategorySecurity,
+	CategoryAccount,
+	CategorySystem,
+}
+
+// IsValidCategory reports whether category is one of NotificationCategories
+func IsValidCategory(category NotificationCategory) bool {
+	for _, c := range NotificationCategories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+// CategoryForType returns the category of a notification type, e.g. "document.deleted" is a documents notification
+func CategoryForType(notificationType string) NotificationCategory {
+	switch {
+	case notificationType == "document.infected", strings.HasPrefix(notificationType, "security."):
+		return CategorySecurity
+	case strings.HasPrefix(notificationType, "document."), strings.HasPrefix(notificationType, "folder."):
+		return CategoryDocuments
+	case strings.HasPrefix(notificationType, "user."), strings.HasPrefix(notificationType, "role."):
+		return CategoryAccount
+	default:
+		return CategorySystem
+	}
+}
+
+// NotificationPreference selects the channels a user receives a category of notifications on.
+// Categories without a row use DefaultNotificationPreference.
+type NotificationPreference struct {
+	ID        uuid.UUID            `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID    uuid.UUID            `json:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_notification_preference_user_category"`
+	Category  NotificationCategory `json:"category" gorm:"type:varchar(50);not null;uniqueIndex:idx_notification_preference_user_category"`
+	InApp     bool                 `json:"in_app" gorm:"not null"`
+	Email     bool                 `json:"email" gorm:"not null"`
+	Push      bool                 `json:"push" gorm:"not null"`
+	Webhook   bool                 `json:"webhook" gorm:"not null"`
+	CreatedAt time.Time            `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time            `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName returns the table name for NotificationPreference
+func (NotificationPreference) TableName() string {
+	return "notification_preferences"
+}
+
+// DefaultNotificationPreference is used for categories the user has not configured:
+// every channel except webhooks
+func DefaultNotificationPreference(userID uuid.UUID, category NotificationCategory) NotificationPreference {
+	return NotificationPreference{
+		UserID:   userID,
+		Category: category,
+		InApp:    true,
+		Email:    true,
+		Push:     true,
+		Webhook:  false,
+	}
+}
+
+// Enabled reports whether the channel is selected
+func (p NotificationPreference) Enabled(channel string) bool {
+	switch channel {
+	case ChannelInApp:
+		return p.InApp
+	case ChannelEmail:
+		return p.Email
+	case ChannelPush:
+		return p.Push
+	case ChannelWebhook:
+		return p.Webhook
+	}
+	return false
+}
+
+// NotificationSettings holds the quiet hours and digest mode of a user. Start and end are "HH:MM"
+// in the user's timezone; an end before the start spans midnight. Without start and end there are
+// no quiet hours. Daily digests are sent at DigestTime ("HH:MM") in the user's timezone.
+type NotificationSettings struct {
+	UserID          uuid.UUID  `json:"user_id" gorm:"type:uuid;primary_key"`
+	QuietHoursStart string     `json:"quiet_hours_start" gorm:"type:varchar(5)"`
+	QuietHoursEnd   string     `json:"quiet_hours_end" gorm:"type:varchar(5)"`
+	Timezone        string     `json:"timezone" gorm:"type:varchar(64);not null;default:'UTC'"`
+	DigestFrequency string     `json:"digest_frequency" gorm:"type:varchar(10);not null;default:'off'"`
+	DigestTime      string     `json:"digest_time" gorm:"type:varchar(5);not null;default:'09:00'"`
+	LastDigestAt    *time.Time `json:"last_digest_at,omitempty"`
+	UpdatedAt       time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName returns the table name for NotificationSettings
+func (NotificationSettings) TableName() string {
+	return "notification_settings"
+}
+
+// ParseClock parses an "HH:MM" time of day into minutes since midnight
+func ParseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// InQuietHours reports whether t falls within the quiet hours
+func (s NotificationSettings) InQuietHours(t time.Time) bool {
+	if s.QuietHoursStart == "" || s.QuietHoursEnd == "" {
+		return false
+	}
+	start, err := ParseClock(s.QuietHoursStart)
+	if err != nil {
+		return false
+	}
+	end, err := ParseClock(s.QuietHoursEnd)
+	if err != nil || start == end {
+		return false
+	}
+
+	if location, err := time.LoadLocation(s.Timezone); err == nil {
+		t = t.In(location)
+	}
+	now := t.Hour()*60 + t.Minute()
+
+	if start < end {
+		return now >= start && now < end
+	}
+	// Overnight, e.g. 22:00 - 07:00
+	return now >= start || now < end
+}
@@ -0,0 +1,62 @@
+package notification
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Push providers a device token belongs to
+const (
+	PushProviderFCM  = "fcm"  // Firebase Cloud Messaging, Android and web
+	PushProviderAPNS = "apns" // Apple Push Notification service, iOS and macOS
+)
+
+// Push delivery statuses
+const (
+	PushDeliveryPending = "pending"
+	PushDeliverySent    = "sent"
+	PushDeliveryFailed  = "failed"
+	// The provider rejected the token, the device is disabled
+	PushDeliveryInvalidToken = "invalid_token"
+)
+
+// DeviceToken is a mobile or web device registered by a user to receive push notifications.
+// Devices whose token the provider rejects are disabled instead of deleted, so their deliveries stay traceable.
+type DeviceToken struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID     uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	Provider   string     `json:"provider" gorm:"type:varchar(10);not null"`
+	Token      string     `json:"-" gorm:"type:varchar(512);not null;uniqueIndex"`
+	Platform   string     `json:"platform,omitempty" gorm:"type:varchar(20)"`
+	DeviceName string     `json:"device_name,omitempty" gorm:"type:varchar(100)"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	DisabledAt *time.Time `json:"disabled_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt  time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName returns the table name for DeviceToken
+func (DeviceToken) TableName() string {
+	return "device_tokens"
+}
+
+// PushDelivery tracks a notification pushed to one device
+type PushDelivery struct {
+	ID             uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	NotificationID *uuid.UUID `json:"notification_id,omitempty" gorm:"type:uuid;index"`
+	DeviceTokenID  uuid.UUID  `json:"device_token_id" gorm:"type:uuid;not null;index"`
+	UserID         uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	Provider       string     `json:"provider" gorm:"type:varchar(10);not null"`
+	Status         string     `json:"status" gorm:"type:varchar(20);not null;default:'pending';index"`
+	MessageID      string     `json:"message_id,omitempty" gorm:"type:varchar(255)"` // ID assigned by the provider
+	Error          string     `json:"error,omitempty" gorm:"type:text"`
+	SentAt         *time.Time `json:"sent_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"autoCreateTime;index"`
+	UpdatedAt      time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName returns the table name for PushDelivery
+func (PushDelivery) TableName() string {
+	return "push_deliveries"
+}
@@ -0,0 +1,72 @@
+package notification
+
+import (
+	"time"
+
+	"forgecrud-backend/shared/database/models"
+
+	"github.com/google/uuid"
+)
+
+// What a scheduled notification sends when it runs
+const (
+	ScheduledKindNotification = "notification" // Delivered to a user following their preferences
+	ScheduledKindEmail        = "email"        // Queued as an email to the given addresses
+)
+
+// Scheduled notification statuses
+const (
+	ScheduleStatusActive    = "active"
+	ScheduleStatusCompleted = "completed"
+	ScheduleStatusCancelled = "cancelled"
+	ScheduleStatusFailed    = "failed"
+)
+
+// ScheduledNotification sends a notification or email once at RunAt, or repeatedly on a cron
+// schedule evaluated in Timezone until EndsAt or MaxRuns
+type ScheduledNotification struct {
+	ID             uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	OrganizationID *uuid.UUID `json:"organization_id,omitempty" gorm:"type:uuid;index"`
+	Kind           string     `json:"kind" gorm:"type:varchar(20);not null"`
+	Name           string     `json:"name,omitempty" gorm:"type:varchar(200)"`
+
+	// Notification content
+	UserID   *uuid.UUID        `json:"user_id,omitempty" gorm:"type:uuid;index"`
+	Type     string            `json:"type,omitempty" gorm:"type:varchar(50)"`
+	Level    NotificationLevel `json:"level,omitempty" gorm:"type:varchar(20)"`
+	Title    string            `json:"title,omitempty" gorm:"type:varchar(200)"`
+	Message  string            `json:"message,omitempty" gorm:"type:text"`
+	Action   string            `json:"action,omitempty" gorm:"type:varchar(100)"`
+	EntityID *uuid.UUID        `json:"entity_id,omitempty" gorm:"type:uuid"`
+	Entity   string            `json:"entity,omitempty" gorm:"type:varchar(100)"`
+	Data     models.JSONMap    `json:"data,omitempty" gorm:"type:jsonb"`
+
+	// Email content
+	EmailTo         string         `json:"email_to,omitempty" gorm:"type:text"` // Comma separated addresses
+	EmailSubject    string         `json:"email_subject,omitempty" gorm:"type:text"`
+	EmailBody       string         `json:"email_body,omitempty" gorm:"type:text"`
+	EmailIsHTML     bool           `json:"email_is_html,omitempty"`
+	EmailTemplateID string         `json:"email_template_id,omitempty" gorm:"type:varchar(100)"`
+	TemplateVars    models.JSONMap `json:"template_vars,omitempty" gorm:"type:jsonb"`
+
+	// Schedule
+	RunAt     *time.Time `json:"run_at,omitempty"`                               // One-off schedules
+	Cron      string     `json:"cron,omitempty" gorm:"type:varchar(100)"`        // Recurring schedules
+	Timezone  string     `json:"timezone" gorm:"type:varchar(64);default:'UTC'"` // IANA name the cron expression is evaluated in
+	EndsAt    *time.Time `json:"ends_at,omitempty"`
+	MaxRuns   int        `json:"max_runs,omitempty"` // 0 for no limit
+	NextRunAt *time.Time `json:"next_run_at,omitempty" gorm:"index:idx_scheduled_notification_due"`
+	Status    string     `json:"status" gorm:"type:varchar(20);not null;default:'active';index:idx_scheduled_notification_due"`
+	RunCount  int        `json:"run_count" gorm:"default:0"`
+	LastRunAt *time.Time `json:"last_run_at,omitempty"`
+	LastError string     `json:"last_error,omitempty" gorm:"type:text"`
+
+	CreatedBy *uuid.UUID `json:"created_by,omitempty" gorm:"type:uuid"`
+	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName returns the table name for ScheduledNotification
+func (ScheduledNotification) TableName() string {
+	return "scheduled_notifications"
+}
@@ -0,0 +1,62 @@
+package notification
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SMS providers
+const (
+	SMSProviderTwilio = "twilio"
+	SMSProviderVonage = "vonage"
+)
+
+// Kinds of SMS, each with its own rate limit
+const (
+	SMSKindVerification  = "verification"   // Phone verification code
+	SMSKindSecurityAlert = "security_alert" // New device login, password changed
+)
+
+// SMS statuses
+const (
+	SMSStatusSent   = "sent"
+	SMSStatusFailed = "failed"
+)
+
+// SMSMessage records an SMS sent to a user. The log backs the hourly rate limits and never holds
+// the text, which may contain a verification code.
+type SMSMessage struct {
+	ID                uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID            uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index:idx_sms_message_user_kind"`
+	OrganizationID    *uuid.UUID `json:"organization_id,omitempty" gorm:"type:uuid;index"`
+	Phone             string     `json:"phone" gorm:"type:varchar(20);not null;index"`
+	Kind              string     `json:"kind" gorm:"type:varchar(30);not null;index:idx_sms_message_user_kind"`
+	Status            string     `json:"status" gorm:"type:varchar(20);not null"`
+	Provider          string     `json:"provider,omitempty" gorm:"type:varchar(20)"`
+	ProviderMessageID string     `json:"provider_message_id,omitempty" gorm:"type:varchar(255)"`
+	Error             string     `json:"error,omitempty" gorm:"type:text"`
+	CreatedAt         time.Time  `json:"created_at" gorm:"autoCreateTime;index:idx_sms_message_user_kind"`
+}
+
+// TableName returns the table name for SMSMessage
+func (SMSMessage) TableName() string {
+	return "sms_messages"
+}
+
+// PhoneVerification is the pending verification of a phone number, one per user. Confirming the
+// code makes the number the user's verified phone.
+type PhoneVerification struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID    uuid.UUID `json:"user_id" gorm:"type:uuid;not null;uniqueIndex"`
+	Phone     string    `json:"phone" gorm:"type:varchar(20);not null"`
+	CodeHash  string    `json:"-" gorm:"type:varchar(64);not null"` // SHA-256 of the code
+	Attempts  int       `json:"attempts" gorm:"not null;default:0"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TableName returns the table name for PhoneVerification
+func (PhoneVerification) TableName() string {
+	return "phone_verifications"
+}
@@ -4,15 +4,17 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type Organization struct {
-	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
-	Name      string     `json:"name" gorm:"size:200;not null"`
-	Slug      string     `json:"slug" gorm:"size:100;uniqueIndex;not null"`
-	Status    string     `json:"status" gorm:"default:'ACTIVE'"`
-	OwnerID   uuid.UUID  `json:"owner_id" gorm:"type:uuid;not null"`
-	ParentID  *uuid.UUID `json:"parent_id" gorm:"type:uuid"`
-	CreatedAt time.Time  `json:"created_at"`
-	UpdatedAt time.Time  `json:"updated_at"`
+	ID        uuid.UUID      `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	Name      string         `json:"name" gorm:"size:200;not null"`
+	Slug      string         `json:"slug" gorm:"size:100;uniqueIndex;not null"`
+	Status    string         `json:"status" gorm:"default:'ACTIVE'"`
+	OwnerID   uuid.UUID      `json:"owner_id" gorm:"type:uuid;not null"`
+	ParentID  *uuid.UUID     `json:"parent_id" gorm:"type:uuid"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
 }
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Organization domain verification statuses
+const (
+	DomainStatusPending  = "PENDING"
+	DomainStatusVerified = "VERIFIED"
+)
+
+// What happens to users who verify an email address of a verified domain
+const (
+	DomainJoinNone    = "NONE"      // Ownership only
+	DomainJoinAuto    = "AUTO_JOIN" // They join the organization right away
+	DomainJoinRequest = "REQUEST"   // They request to join and an organization admin decides
+)
+
+// DomainVerificationRecordPrefix is prepended to the domain to form the name of the TXT record
+// that proves ownership, e.g. _forgecrud-challenge.example.com
+const DomainVerificationRecordPrefix = "_forgecrud-challenge."
+
+// DomainVerificationValuePrefix is prepended to the token to form the value of the TXT record
+const DomainVerificationValuePrefix = "forgecrud-domain-verification="
+
+// OrganizationDomain is an email domain claimed by an organization. Once its TXT record proves the
+// organization owns it, new users of the domain can join the organization.
+type OrganizationDomain struct {
+	ID                uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	OrganizationID    uuid.UUID  `json:"organization_id" gorm:"type:uuid;not null;index"`
+	Domain            string     `json:"domain" gorm:"size:255;not null;index"` // Lowercase
+	Status            string     `json:"status" gorm:"size:20;not null;default:'PENDING'"`
+	VerificationToken string     `json:"verification_token" gorm:"size:64;not null"`
+	JoinPolicy        string     `json:"join_policy" gorm:"size:20;not null;default:'REQUEST'"`
+	RoleID            *uuid.UUID `json:"role_id" gorm:"type:uuid"` // Role of users joining through the domain
+	VerifiedAt        *time.Time `json:"verified_at"`
+	LastCheckedAt     *time.Time `json:"last_checked_at"`
+	LastCheckError    string     `json:"last_check_error,omitempty" gorm:"type:text"`
+	CreatedBy         *uuid.UUID `json:"created_by" gorm:"type:uuid"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+
+	// Relations
+	Organization *Organization `json:"organization,omitempty" gorm:"foreignKey:OrganizationID"`
+	Role         *Role         `json:"role,omitempty" gorm:"foreignKey:RoleID"`
+}
+
+// RecordName returns the name of the TXT record proving ownership of the domain
+func (d *OrganizationDomain) RecordName() string {
+	return DomainVerificationRecordPrefix + d.Domain
+}
+
+// RecordValue returns the value of the TXT record proving ownership of the domain
+func (d *OrganizationDomain) RecordValue() string {
+	return DomainVerificationValuePrefix + d.VerificationToken
+}
+
+// Join request statuses
+const (
+	JoinRequestPending  = "PENDING"
+	JoinRequestApproved = "APPROVED"
+	JoinRequestRejected = "REJECTED"
+)
+
+// OrganizationJoinRequest asks an organization admin to let a user of a verified domain join
+type OrganizationJoinRequest struct {
+	ID             uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	OrganizationID uuid.UUID  `json:"organization_id" gorm:"type:uuid;not null;index"`
+	UserID         uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	DomainID       *uuid.UUID `json:"domain_id" gorm:"type:uuid"`
+	Status         string     `json:"status" gorm:"size:20;not null;default:'PENDING';index"`
+	RoleID         *uuid.UUID `json:"role_id" gorm:"type:uuid"` // Role given on approval
+	ReviewedBy     *uuid.UUID `json:"reviewed_by" gorm:"type:uuid"`
+	ReviewedAt     *time.Time `json:"reviewed_at"`
+	Note           string     `json:"note" gorm:"type:text"` // Reason of the decision
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	// Relations
+	User   *User               `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	Domain *OrganizationDomain `json:"domain,omitempty" gorm:"foreignKey:DomainID"`
+	Role   *Role               `json:"role,omitempty" gorm:"foreignKey:RoleID"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OrganizationStorage is the own S3 compatible bucket of an organization, or a prefix in a shared bucket
+// with credentials limited to it. Files uploaded into the folders of the organization and its users are
+// stored there under keys starting with orgs/<organization ID>/, so the bucket can have its own lifecycle
+// rules and the organization's usage can be accounted separately. An empty endpoint, region or access key
+// uses the one of the default storage. Organizations without a row use the default storage.
+type OrganizationStorage struct {
+	ID             uuid.UUID `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	OrganizationID uuid.UUID `json:"organization_id" gorm:"type:uuid;not null;uniqueIndex"`
+	Endpoint       string    `json:"endpoint"`
+	UseSSL         bool      `json:"use_ssl" gorm:"not null;default:false"`
+	Region         string    `json:"region"`
+	Bucket         string    `json:"bucket" gorm:"not null"`
+	// Prefix of the organization's files in the bucket, required in the bucket of the default storage
+	Prefix          string     `json:"prefix"`
+	AccessKeyID     string     `json:"access_key_id"`
+	SecretAccessKey string     `json:"-"`
+	UpdatedBy       *uuid.UUID `json:"updated_by" gorm:"type:uuid"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+
+	// Relations
+	Organization Organization `json:"-" gorm:"foreignKey:OrganizationID"`
+}
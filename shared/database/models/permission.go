@@ -6,6 +6,14 @@ import (
 	"github.com/google/uuid"
 )
 
+// Permission targets
+const (
+	PermissionTargetUser         = "USER"
+	PermissionTargetTeam         = "TEAM"
+	PermissionTargetRole         = "ROLE"
+	PermissionTargetOrganization = "ORGANIZATION"
+)
+
 // Resources table
 type Resource struct {
 	ID          uuid.UUID `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
@@ -32,8 +40,9 @@ type Action struct {
 type Permission struct {
 	ID             uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
 	ResourceID     uuid.UUID  `json:"resource_id" gorm:"type:uuid;not null"`
-	Target         string     `json:"target" gorm:"type:varchar(20);not null"` // USER, ROLE, ORGANIZATION
+	Target         string     `json:"target" gorm:"type:varchar(20);not null"` // USER, TEAM, ROLE, ORGANIZATION
 	UserID         *uuid.UUID `json:"user_id" gorm:"type:uuid"`
+	TeamID         *uuid.UUID `json:"team_id" gorm:"type:uuid"`
 	RoleID         *uuid.UUID `json:"role_id" gorm:"type:uuid"`
 	OrganizationID *uuid.UUID `json:"organization_id" gorm:"type:uuid"`
 	CreatedAt      time.Time  `json:"created_at"`
@@ -42,6 +51,7 @@ type Permission struct {
 	// Relations
 	Resource          Resource           `json:"resource" gorm:"foreignKey:ResourceID"`
 	User              *User              `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	Team              *Team              `json:"team,omitempty" gorm:"foreignKey:TeamID"`
 	Role              *Role              `json:"role,omitempty" gorm:"foreignKey:RoleID"`
 	Organization      *Organization      `json:"organization,omitempty" gorm:"foreignKey:OrganizationID"`
 	PermissionActions []PermissionAction `json:"permission_actions" gorm:"foreignKey:PermissionID"`
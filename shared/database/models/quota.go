@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OrganizationQuota holds the limits of an organization. A zero limit means unlimited.
+// Organizations without a row use the QUOTA_DEFAULT_* configuration.
+type OrganizationQuota struct {
+	ID                      uuid.UUID `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	OrganizationID          uuid.UUID `json:"organization_id" gorm:"type:uuid;not null;uniqueIndex"`
+	MaxUsers                int       `json:"max_users" gorm:"default:0"`
+	MaxStorageBytes         int64     `json:"max_storage_bytes" gorm:"default:0"`
+	MaxDocuments            int       `json:"max_documents" gorm:"default:0"`
+	MaxAPIRequestsPerDay    int       `json:"max_api_requests_per_day" gorm:"default:0"`
+	MaxAPIRequestsPerMinute int       `json:"max_api_requests_per_minute" gorm:"default:0"`
+	CreatedAt               time.Time `json:"created_at"`
+	UpdatedAt               time.Time `json:"updated_at"`
+
+	// Relations
+	Organization Organization `json:"-" gorm:"foreignKey:OrganizationID"`
+}
+
+// UserQuota holds the storage limit of a user over the folders they own. A zero limit means unlimited.
+// Users without a row use QUOTA_DEFAULT_USER_MAX_STORAGE_BYTES.
+type UserQuota struct {
+	ID              uuid.UUID `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	UserID          uuid.UUID `json:"user_id" gorm:"type:uuid;not null;uniqueIndex"`
+	MaxStorageBytes int64     `json:"max_storage_bytes" gorm:"default:0"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+
+	// Relations
+	User User `json:"-" gorm:"foreignKey:UserID"`
+}
+
+// OrganizationAPIUsage meters the API requests an organization made on a (UTC) day, and those of the
+// current minute of the day for the per-minute budget
+type OrganizationAPIUsage struct {
+	OrganizationID     uuid.UUID  `json:"organization_id" gorm:"type:uuid;primaryKey"`
+	Day                time.Time  `json:"day" gorm:"type:date;primaryKey"`
+	Requests           int64      `json:"requests" gorm:"not null;default:0"`
+	MinuteStart        *time.Time `json:"minute_start"` // Minute counted in MinuteRequests
+	MinuteRequests     int64      `json:"minute_requests" gorm:"not null;default:0"`
+	PeakMinuteRequests int64      `json:"peak_minute_requests" gorm:"not null;default:0"` // Most requests in one minute of the day
+	AlertedPercent     int        `json:"alerted_percent" gorm:"not null;default:0"`      // Highest share of the daily budget the organization was notified about
+}
+
+// TableName returns the table name for OrganizationAPIUsage
+func (OrganizationAPIUsage) TableName() string {
+	return "organization_api_usage"
+}
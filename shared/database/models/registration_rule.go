@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Registration rule types
+const (
+	RegistrationRuleEmailDomain = "EMAIL_DOMAIN" // Applied once a user verifies an email address of the domain
+	RegistrationRuleInvite      = "INVITE"       // Applied when a user registers with the invite token
+)
+
+// RegistrationRule assigns an organization and a role to self-registered users, so they do not
+// start without any permissions. Either may be left empty.
+type RegistrationRule struct {
+	ID             uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	Name           string     `json:"name" gorm:"size:100;not null"`
+	Type           string     `json:"type" gorm:"size:20;not null"`
+	EmailDomain    string     `json:"email_domain,omitempty" gorm:"size:255;index"` // Lowercase, EMAIL_DOMAIN rules only
+	InviteToken    *string    `json:"-" gorm:"size:64;uniqueIndex"`                 // INVITE rules only
+	OrganizationID *uuid.UUID `json:"organization_id" gorm:"type:uuid;index"`
+	RoleID         *uuid.UUID `json:"role_id" gorm:"type:uuid"`
+	MaxUses        int        `json:"max_uses" gorm:"not null;default:0"` // 0 = unlimited
+	UseCount       int        `json:"use_count" gorm:"not null;default:0"`
+	ExpiresAt      *time.Time `json:"expires_at"`
+	IsActive       bool       `json:"is_active" gorm:"default:true"`
+	CreatedBy      *uuid.UUID `json:"created_by" gorm:"type:uuid"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	// Relations
+	Organization *Organization `json:"organization,omitempty" gorm:"foreignKey:OrganizationID"`
+	Role         *Role         `json:"role,omitempty" gorm:"foreignKey:RoleID"`
+}
+
+// Usable reports whether the rule can still assign users
+func (r *RegistrationRule) Usable(now time.Time) bool {
+	if !r.IsActive || (r.ExpiresAt != nil && !now.Before(*r.ExpiresAt)) {
+		return false
+	}
+	return r.MaxUses == 0 || r.UseCount < r.MaxUses
+}
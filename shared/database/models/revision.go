@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Revision actions
+const (
+	RevisionActionCreate  = "create"
+	RevisionActionUpdate  = "update"
+	RevisionActionDelete  = "delete"
+	RevisionActionRestore = "restore"
+)
+
+// Revisioned is implemented by models whose changes are recorded in the revisions table
+type Revisioned interface {
+	RevisionEntityType() string
+}
+
+// Revision records a single change to a tracked entity (old/new values of the changed fields)
+type Revision struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	EntityType string     `json:"entity_type" gorm:"size:50;not null;index:idx_revision_entity"`
+	EntityID   uuid.UUID  `json:"entity_id" gorm:"type:uuid;not null;index:idx_revision_entity"`
+	Action     string     `json:"action" gorm:"size:20;not null"`
+	ActorID    *uuid.UUID `json:"actor_id" gorm:"type:uuid;index"`
+	OldValues  JSONMap    `json:"old_values" gorm:"type:jsonb"`
+	NewValues  JSONMap    `json:"new_values" gorm:"type:jsonb"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"index"`
+}
+
+// RevisionEntityType implements Revisioned
+func (User) RevisionEntityType() string { return "user" }
+
+// RevisionEntityType implements Revisioned
+func (Role) RevisionEntityType() string { return "role" }
+
+// RevisionEntityType implements Revisioned
+func (Organization) RevisionEntityType() string { return "organization" }
+
+// RevisionEntityType implements Revisioned
+func (Permission) RevisionEntityType() string { return "permission" }
@@ -4,16 +4,20 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type Role struct {
-	ID             uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
-	Name           string     `json:"name" gorm:"size:100;not null"`
-	Description    string     `json:"description" gorm:"type:text"`
-	IsDefault      bool       `json:"is_default" gorm:"default:false"`
-	OrganizationID *uuid.UUID `json:"organization_id" gorm:"type:uuid"`
-	CreatedAt      time.Time  `json:"created_at"`
-	UpdatedAt      time.Time  `json:"updated_at"`
+	ID             uuid.UUID      `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	Name           string         `json:"name" gorm:"size:100;not null"`
+	Description    string         `json:"description" gorm:"type:text"`
+	IsDefault      bool           `json:"is_default" gorm:"default:false"`
+	OrganizationID *uuid.UUID     `json:"organization_id" gorm:"type:uuid"`
+	InCatalog      bool           `json:"in_catalog" gorm:"default:false"`       // Global role stamped into every new organization
+	SourceRoleID   *uuid.UUID     `json:"source_role_id" gorm:"type:uuid;index"` // Role this one was cloned from
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
 
 	// Relations
 	Organization Organization `json:"organization" gorm:"foreignKey:OrganizationID"`
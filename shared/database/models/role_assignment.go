@@ -0,0 +1,67 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Role assignment actions
+const (
+	RoleAssignmentAssigned = "ASSIGNED" // User had no role before
+	RoleAssignmentChanged  = "CHANGED"
+	RoleAssignmentRemoved  = "REMOVED"
+)
+
+// Sources of a role assignment
+const (
+	RoleAssignmentSourceAPI          = "api"
+	RoleAssignmentSourceSchedule     = "schedule"
+	RoleAssignmentSourceCLI          = "cli"          // forgectl
+	RoleAssignmentSourceRegistration = "registration" // Registration rule
+)
+
+// RoleAssignment records a change of a user's role
+type RoleAssignment struct {
+	ID                uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	UserID            uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	PreviousRoleID    *uuid.UUID `json:"previous_role_id" gorm:"type:uuid"`
+	RoleID            *uuid.UUID `json:"role_id" gorm:"type:uuid"`
+	Action            string     `json:"action" gorm:"size:20;not null"`
+	Source            string     `json:"source" gorm:"size:20;not null"`
+	ActorID           *uuid.UUID `json:"actor_id" gorm:"type:uuid"`
+	ScheduledChangeID *uuid.UUID `json:"scheduled_change_id,omitempty" gorm:"type:uuid"`
+	Reason            string     `json:"reason" gorm:"type:text"`
+	CreatedAt         time.Time  `json:"created_at" gorm:"index"`
+
+	// Relations
+	PreviousRole *Role `json:"previous_role,omitempty" gorm:"foreignKey:PreviousRoleID"`
+	Role         *Role `json:"role,omitempty" gorm:"foreignKey:RoleID"`
+}
+
+// Scheduled role change statuses
+const (
+	ScheduledRoleChangePending   = "PENDING"
+	ScheduledRoleChangeExecuted  = "EXECUTED"
+	ScheduledRoleChangeCancelled = "CANCELLED"
+	ScheduledRoleChangeFailed    = "FAILED"
+)
+
+// ScheduledRoleChange sets a user's role at a future date, e.g. downgrading a contractor when
+// their project ends. A nil RoleID removes the user's role.
+type ScheduledRoleChange struct {
+	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	UserID      uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	RoleID      *uuid.UUID `json:"role_id" gorm:"type:uuid"`
+	EffectiveAt time.Time  `json:"effective_at" gorm:"not null;index"`
+	Status      string     `json:"status" gorm:"size:20;not null;default:'PENDING';index"`
+	Reason      string     `json:"reason" gorm:"type:text"`
+	CreatedBy   *uuid.UUID `json:"created_by" gorm:"type:uuid"`
+	ExecutedAt  *time.Time `json:"executed_at"`
+	LastError   string     `json:"last_error,omitempty" gorm:"type:text"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+
+	// Relations
+	Role *Role `json:"role,omitempty" gorm:"foreignKey:RoleID"`
+}
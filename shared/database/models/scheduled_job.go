@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Scheduled job run statuses
+const (
+	ScheduledJobRunRunning   = "RUNNING"
+	ScheduledJobRunSucceeded = "SUCCEEDED"
+	ScheduledJobRunFailed    = "FAILED"
+)
+
+// ScheduledJob is a periodic job of a service, kept up to date by the instance leading its scheduler
+type ScheduledJob struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	Service    string     `json:"service" gorm:"size:100;not null;uniqueIndex:idx_scheduled_job"`
+	Name       string     `json:"name" gorm:"size:100;not null;uniqueIndex:idx_scheduled_job"`
+	Schedule   string     `json:"schedule" gorm:"size:100;not null"`
+	Leader     string     `json:"leader" gorm:"size:255"` // Instance that runs the schedule
+	LastRunAt  *time.Time `json:"last_run_at"`
+	LastStatus string     `json:"last_status" gorm:"size:20"`
+	NextRunAt  *time.Time `json:"next_run_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// ScheduledJobRun records one run of a scheduled job. A run is created once per scheduled time, so a
+// job never runs twice for the same time, even when the leadership moves during the run.
+type ScheduledJobRun struct {
+	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	Service     string     `json:"service" gorm:"size:100;not null;uniqueIndex:idx_scheduled_job_run;index:idx_scheduled_job_run_started,priority:1"`
+	JobName     string     `json:"job_name" gorm:"size:100;not null;uniqueIndex:idx_scheduled_job_run"`
+	ScheduledAt time.Time  `json:"scheduled_at" gorm:"not null;uniqueIndex:idx_scheduled_job_run"`
+	Instance    string     `json:"instance" gorm:"size:255;not null"`
+	Status      string     `json:"status" gorm:"size:20;not null;index"`
+	Error       string     `json:"error,omitempty" gorm:"type:text"`
+	StartedAt   time.Time  `json:"started_at" gorm:"not null;index:idx_scheduled_job_run_started,priority:2"`
+	FinishedAt  *time.Time `json:"finished_at"`
+	DurationMs  int64      `json:"duration_ms"`
+}
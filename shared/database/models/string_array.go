@@ -0,0 +1,51 @@
+package models
+
+import (
+	"database/sql/driver"
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// StringArray is a Postgres text[] column
+type StringArray []string
+
+// Value implements driver.Valuer
+func (a StringArray) Value() (driver.Value, error) {
+	if a == nil {
+		return "{}", nil
+	}
+	data, err := pgtype.NewMap().Encode(pgtype.TextArrayOID, pgtype.TextFormatCode, []string(a), nil)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan implements sql.Scanner
+func (a *StringArray) Scan(value interface{}) error {
+	if value == nil {
+		*a = StringArray{}
+		return nil
+	}
+
+	var data []byte
+	switch v := value.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return errors.New("unsupported type for StringArray")
+	}
+
+	var values []string
+	if err := pgtype.NewMap().Scan(pgtype.TextArrayOID, pgtype.TextFormatCode, data, &values); err != nil {
+		return err
+	}
+	if values == nil {
+		values = []string{}
+	}
+	*a = values
+	return nil
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Team groups users inside an organization so permissions can be granted to the group
+type Team struct {
+	ID             uuid.UUID `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	Name           string    `json:"name" gorm:"size:100;not null"`
+	Description    string    `json:"description" gorm:"type:text"`
+	OrganizationID uuid.UUID `json:"organization_id" gorm:"type:uuid;not null;index"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+
+	// Relations
+	Organization Organization `json:"organization" gorm:"foreignKey:OrganizationID"`
+	Members      []TeamMember `json:"members,omitempty" gorm:"foreignKey:TeamID"`
+}
+
+// TeamMember links users to teams (Many-to-Many relationship between Teams and Users)
+type TeamMember struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	TeamID    uuid.UUID `json:"team_id" gorm:"type:uuid;not null;uniqueIndex:idx_team_member"`
+	UserID    uuid.UUID `json:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_team_member;index"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// Relations
+	Team Team `json:"-" gorm:"foreignKey:TeamID"`
+	User User `json:"user" gorm:"foreignKey:UserID"`
+}
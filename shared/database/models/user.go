@@ -4,22 +4,28 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type User struct {
-	ID             uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
-	Email          string     `json:"email" gorm:"uniqueIndex;not null"`
-	Password       string     `json:"-" gorm:"not null"`
-	FirstName      string     `json:"first_name" gorm:"size:100"`
-	LastName       string     `json:"last_name" gorm:"size:100"`
-	Phone          string     `json:"phone" gorm:"size:20"`
-	Avatar         string     `json:"avatar"`
-	Status         string     `json:"status" gorm:"default:'ACTIVE'"`
-	EmailVerified  bool       `json:"email_verified" gorm:"default:false"`
-	OrganizationID *uuid.UUID `json:"organization_id" gorm:"type:uuid"`
-	RoleID         *uuid.UUID `json:"role_id" gorm:"type:uuid"`
-	CreatedAt      time.Time  `json:"created_at"`
-	UpdatedAt      time.Time  `json:"updated_at"`
+	ID                uuid.UUID      `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	Email             string         `json:"email" gorm:"uniqueIndex;not null"`
+	Password          string         `json:"-" gorm:"not null"`
+	FirstName         string         `json:"first_name" gorm:"size:100"`
+	LastName          string         `json:"last_name" gorm:"size:100"`
+	Phone             string         `json:"phone" gorm:"size:20"`
+	PhoneVerified     bool           `json:"phone_verified" gorm:"default:false"` // Set by confirming an SMS code, security alerts are only texted to verified phones
+	Avatar            string         `json:"avatar"`
+	Language          string         `json:"language" gorm:"size:10"` // Preferred language of emails and notifications, the default locale when empty
+	Status            string         `json:"status" gorm:"default:'ACTIVE'"`
+	EmailVerified     bool           `json:"email_verified" gorm:"default:false"`
+	MustResetPassword bool           `json:"must_reset_password" gorm:"default:false"` // Set for admin-created accounts until the user changes the password
+	OrganizationID    *uuid.UUID     `json:"organization_id" gorm:"type:uuid"`
+	RoleID            *uuid.UUID     `json:"role_id" gorm:"type:uuid"`
+	Attributes        JSONMap        `json:"attributes" gorm:"type:jsonb;default:'{}'"` // Values for the organization's custom user fields
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+	DeletedAt         gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
 
 	// Relations
 	Organization Organization `json:"organization" gorm:"foreignKey:OrganizationID"`
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Supported custom user field types
+const (
+	UserFieldTypeString  = "string"
+	UserFieldTypeNumber  = "number"
+	UserFieldTypeBoolean = "boolean"
+	UserFieldTypeDate    = "date"
+	UserFieldTypeEnum    = "enum"
+)
+
+// UserFieldDefinition describes a custom profile attribute an organization collects for its users.
+// Values are stored in User.Attributes under Key.
+type UserFieldDefinition struct {
+	ID             uuid.UUID `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	OrganizationID uuid.UUID `json:"organization_id" gorm:"type:uuid;not null;uniqueIndex:idx_org_field_key"`
+	Key            string    `json:"key" gorm:"size:64;not null;uniqueIndex:idx_org_field_key"`
+	Label          string    `json:"label" gorm:"size:100;not null"`
+	Type           string    `json:"type" gorm:"type:varchar(20);not null"` // string, number, boolean, date, enum
+	Required       bool      `json:"required" gorm:"default:false"`
+	Options        string    `json:"options" gorm:"type:text"` // Comma separated values for enum fields
+	Pattern        string    `json:"pattern" gorm:"size:255"`  // Optional regex for string fields
+	MinValue       *float64  `json:"min_value"`
+	MaxValue       *float64  `json:"max_value"`
+	MaxLength      int       `json:"max_length" gorm:"default:0"`
+	Indexed        bool      `json:"indexed" gorm:"default:false"` // Indexed fields can be used for filtering/sorting
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+
+	// Relations
+	Organization Organization `json:"-" gorm:"foreignKey:OrganizationID"`
+}
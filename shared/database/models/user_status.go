@@ -0,0 +1,58 @@
+package models
+
+// User lifecycle states
+const (
+	UserStatusInvited             = "INVITED"              // Created by an admin, must set a password
+	UserStatusPendingVerification = "PENDING_VERIFICATION" // Must verify the email address
+	UserStatusActive              = "ACTIVE"
+	UserStatusSuspended           = "SUSPENDED" // Temporarily blocked, sessions are terminated
+	UserStatusDeactivated         = "DEACTIVATED"
+
+	// Set by delete and merge, not reachable through transitions
+	UserStatusDeleted = "DELETED"
+	UserStatusMerged  = "MERGED"
+
+	// Legacy value of DEACTIVATED
+	UserStatusInactive = "INACTIVE"
+)
+
+// UserStatusTransitions lists the states each lifecycle state may move to
+var UserStatusTransitions = map[string][]string{
+	UserStatusInvited:             {UserStatusPendingVerification, UserStatusActive, UserStatusDeactivated},
+	UserStatusPendingVerification: {UserStatusActive, UserStatusDeactivated},
+	UserStatusActive:              {UserStatusSuspended, UserStatusDeactivated},
+	UserStatusSuspended:           {UserStatusActive, UserStatusDeactivated},
+	UserStatusDeactivated:         {UserStatusActive},
+	UserStatusInactive:            {UserStatusActive, UserStatusDeactivated},
+}
+
+// CanTransitionUserStatus reports whether a user may move from one lifecycle state to another
+func CanTransitionUserStatus(from, to string) bool {
+	for _, allowed := range UserStatusTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// UserStatusCanSignIn reports whether users in the state may sign in. Invited and unverified
+// users sign in to finish onboarding (set a password, verify the email).
+func UserStatusCanSignIn(status string) bool {
+	switch status {
+	case UserStatusInvited, UserStatusPendingVerification, UserStatusActive:
+		return true
+	}
+	return false
+}
+
+// OnboardedUserStatus is the state a user reaches after finishing a step of onboarding
+func OnboardedUserStatus(user *User) string {
+	if user.MustResetPassword {
+		return UserStatusInvited
+	}
+	if !user.EmailVerified {
+		return UserStatusPendingVerification
+	}
+	return UserStatusActive
+}
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Webhook lifecycle events
+const (
+	WebhookEventAll                 = "*"
+	WebhookEventUserCreated         = "user.created"
+	WebhookEventUserUpdated         = "user.updated"
+	WebhookEventUserDeleted         = "user.deleted"
+	WebhookEventRoleCreated         = "role.created"
+	WebhookEventRoleUpdated         = "role.updated"
+	WebhookEventRoleDeleted         = "role.deleted"
+	WebhookEventOrganizationCreated = "organization.created"
+	WebhookEventOrganizationUpdated = "organization.updated"
+	WebhookEventOrganizationDeleted = "organization.deleted"
+	WebhookEventDocumentUploaded    = "document.uploaded"
+	// Sent for notifications of users who route a category to the webhook channel
+	WebhookEventNotificationCreated = "notification.created"
+)
+
+// SupportedWebhookEvents lists the events a webhook can subscribe to
+var SupportedWebhookEvents = []string{
+	WebhookEventUserCreated,
+	WebhookEventUserUpdated,
+	WebhookEventUserDeleted,
+	WebhookEventRoleCreated,
+	WebhookEventRoleUpdated,
+	WebhookEventRoleDeleted,
+	WebhookEventOrganizationCreated,
+	WebhookEventOrganizationUpdated,
+	WebhookEventOrganizationDeleted,
+	WebhookEventDocumentUploaded,
+	WebhookEventNotificationCreated,
+}
+
+// Webhook delivery statuses
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// Webhook is an external endpoint notified about entity lifecycle events
+type Webhook struct {
+	ID          uuid.UUID `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	Name        string    `json:"name" gorm:"size:100;not null"`
+	URL         string    `json:"url" gorm:"size:500;not null"`
+	Secret      string    `json:"-" gorm:"size:128;not null"`       // HMAC-SHA256 signing key
+	Events      string    `json:"events" gorm:"type:text;not null"` // Comma separated event names, "*" for all
+	IsActive    bool      `json:"is_active" gorm:"default:true"`
+	Description string    `json:"description" gorm:"type:text"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// WebhookDelivery is a single event sent (or to be sent) to a webhook, with its attempt log
+type WebhookDelivery struct {
+	ID             uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	WebhookID      uuid.UUID  `json:"webhook_id" gorm:"type:uuid;not null;index"`
+	Event          string     `json:"event" gorm:"size:100;not null"`
+	Payload        string     `json:"payload" gorm:"type:jsonb;not null"`
+	Status         string     `json:"status" gorm:"size:20;not null;default:'pending';index:idx_webhook_delivery_due"`
+	Attempts       int        `json:"attempts" gorm:"default:0"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at" gorm:"index:idx_webhook_delivery_due"`
+	ResponseStatus int        `json:"response_status"`
+	ResponseBody   string     `json:"response_body" gorm:"type:text"`
+	LastError      string     `json:"last_error" gorm:"type:text"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"index"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	// Relations
+	Webhook Webhook `json:"-" gorm:"foreignKey:WebhookID"`
+}
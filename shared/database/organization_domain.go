@@ -0,0 +1,56 @@
+package database
+
+import (
+	"errors"
+
+	"forgecrud-backend/shared/database/models"
+
+	"gorm.io/gorm"
+)
+
+// FindVerifiedDomain returns the verified organization domain of the email address, or nil when no
+// organization has verified it
+func FindVerifiedDomain(tx *gorm.DB, email string) (*models.OrganizationDomain, error) {
+	domain := EmailDomain(email)
+	if domain == "" {
+		return nil, nil
+	}
+
+	var verified models.OrganizationDomain
+	err := tx.Where("domain = ? AND status = ?", domain, models.DomainStatusVerified).
+		Where("organization_id IN (?)", tx.Model(&models.Organization{}).Select("id")).
+		First(&verified).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &verified, nil
+}
+
+// RequestToJoin records a pending request of the user to join the domain's organization. An
+// already pending request is returned instead of a new one, with created false.
+func RequestToJoin(tx *gorm.DB, user *models.User, domain *models.OrganizationDomain) (*models.OrganizationJoinRequest, bool, error) {
+	var request models.OrganizationJoinRequest
+	err := tx.Where("organization_id = ? AND user_id = ? AND status = ?", domain.OrganizationID, user.ID, models.JoinRequestPending).
+		First(&request).Error
+	if err == nil {
+		return &request, false, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, err
+	}
+
+	request = models.OrganizationJoinRequest{
+		OrganizationID: domain.OrganizationID,
+		UserID:         user.ID,
+		DomainID:       &domain.ID,
+		Status:         models.JoinRequestPending,
+		RoleID:         domain.RoleID,
+	}
+	if err := tx.Create(&request).Error; err != nil {
+		return nil, false, err
+	}
+	return &request, true, nil
+}
@@ -0,0 +1,132 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"forgecrud-backend/shared/database/models"
+	"forgecrud-backend/shared/database/models/auth"
+
+	"gorm.io/gorm"
+)
+
+// PurgeSoftDeleted permanently removes users, roles and organizations that were
+// soft-deleted before the given cutoff. Dependants are purged before their parents.
+func PurgeSoftDeleted(cutoff time.Time) (map[string]int64, error) {
+	purged := make(map[string]int64)
+
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		// Users and their dependent rows
+		expiredUsers := tx.Unscoped().Model(&models.User{}).Select("id").
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff)
+
+		if err := tx.Where("user_id IN (?)", expiredUsers).Delete(&models.TeamMember{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id IN (?)", expiredUsers).Delete(&auth.UserSession{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id IN (?)", expiredUsers).Delete(&models.RoleAssignment{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id IN (?)", expiredUsers).Delete(&models.ScheduledRoleChange{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id IN (?)", expiredUsers).Delete(&models.OrganizationJoinRequest{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("permission_id IN (?)",
+			tx.Model(&models.Permission{}).Select("id").Where("target = ? AND user_id IN (?)", "USER", expiredUsers),
+		).Delete(&models.PermissionAction{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("target = ? AND user_id IN (?)", "USER", expiredUsers).Delete(&models.Permission{}).Error; err != nil {
+			return err
+		}
+
+		result := tx.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).Delete(&models.User{})
+		if result.Error != nil {
+			return result.Error
+		}
+		purged["users"] = result.RowsAffected
+
+		// Roles and their permissions
+		expiredRoles := tx.Unscoped().Model(&models.Role{}).Select("id").
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff)
+
+		if err := tx.Where("permission_id IN (?)",
+			tx.Model(&models.Permission{}).Select("id").Where("target = ? AND role_id IN (?)", "ROLE", expiredRoles),
+		).Delete(&models.PermissionAction{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("target = ? AND role_id IN (?)", "ROLE", expiredRoles).Delete(&models.Permission{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("role_id IN (?)", expiredRoles).Delete(&models.RegistrationRule{}).Error; err != nil {
+			return err
+		}
+
+		result = tx.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).Delete(&models.Role{})
+		if result.Error != nil {
+			return result.Error
+		}
+		purged["roles"] = result.RowsAffected
+
+		// Registration rules, domains and join requests of organizations deleted before the cutoff can
+		// no longer let anyone join
+		expiredOrganizations := tx.Unscoped().Model(&models.Organization{}).Select("id").
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff)
+		for _, model := range []interface{}{&models.RegistrationRule{}, &models.OrganizationJoinRequest{}, &models.OrganizationDomain{}} {
+			if err := tx.Where("organization_id IN (?)", expiredOrganizations).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+
+		// Organizations that no longer have any (deleted or active) dependants
+		result = tx.Unscoped().
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+			Where("id NOT IN (?)", tx.Unscoped().Model(&models.User{}).Select("organization_id").Where("organization_id IS NOT NULL")).
+			Where("id NOT IN (?)", tx.Unscoped().Model(&models.Role{}).Select("organization_id").Where("organization_id IS NOT NULL")).
+			Where("id NOT IN (?)", tx.Unscoped().Model(&models.Organization{}).Select("parent_id").Where("parent_id IS NOT NULL")).
+			Delete(&models.Organization{})
+		if result.Error != nil {
+			return result.Error
+		}
+		purged["organizations"] = result.RowsAffected
+
+		return nil
+	})
+
+	return purged, err
+}
+
+// auditLogPurgeBatchSize is the number of audit logs deleted per statement
+const auditLogPurgeBatchSize = 10000
+
+// AuditLogArchivedCondition matches audit logs whose day has an archive that did not fail
+// verification
+const AuditLogArchivedCondition = "EXISTS (SELECT 1 FROM audit_log_archives a WHERE audit_logs.created_at >= a.day AND " +
+	"audit_logs.created_at < a.day + INTERVAL '1 day' AND a.status <> 'FAILED')"
+
+// PurgeAuditLogs deletes audit logs created before the cutoff, in batches so the table is not locked for
+// long, and returns the number of deleted logs. With archivedOnly, logs of days without an archive
+// are kept.
+func PurgeAuditLogs(ctx context.Context, cutoff time.Time, archivedOnly bool) (int64, error) {
+	condition := "created_at < ?"
+	if archivedOnly {
+		condition += " AND " + AuditLogArchivedCondition
+	}
+
+	var purged int64
+	for {
+		result := DB.WithContext(ctx).Exec("DELETE FROM audit_logs WHERE id IN (SELECT id FROM audit_logs WHERE "+condition+" LIMIT ?)",
+			cutoff, auditLogPurgeBatchSize)
+		if result.Error != nil {
+			return purged, result.Error
+		}
+		purged += result.RowsAffected
+		if result.RowsAffected < auditLogPurgeBatchSize {
+			return purged, nil
+		}
+	}
+}
@@ -0,0 +1,344 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"forgecrud-backend/shared/config"
+	"forgecrud-backend/shared/database/models"
+	"forgecrud-backend/shared/database/models/document"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Quota kinds reported in QuotaExceededError
+const (
+	QuotaUsers       = "users"
+	QuotaStorage     = "storage_bytes"
+	QuotaDocuments   = "documents"
+	QuotaAPIRequests = "api_requests_per_day"
+	QuotaUserStorage = "user_storage_bytes"
+)
+
+// QuotaExceededError reports which organization or user limit a change would exceed
+type QuotaExceededError struct {
+	Kind      string `json:"quota"`
+	Limit     int64  `json:"limit"`
+	Used      int64  `json:"used"`
+	Requested int64  `json:"requested"`
+	Remaining int64  `json:"remaining"`
+}
+
+func newQuotaExceededError(kind string, limit, used, requested int64) *QuotaExceededError {
+	remaining := limit - used
+	if remaining < 0 {
+		remaining = 0
+	}
+	return &QuotaExceededError{Kind: kind, Limit: limit, Used: used, Requested: requested, Remaining: remaining}
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("%s quota exceeded: %d of %d used, %d requested", e.Kind, e.Used, e.Limit, e.Requested)
+}
+
+// IsStorageQuota reports whether the exceeded limit is a storage limit
+func (e *QuotaExceededError) IsStorageQuota() bool {
+	return e.Kind == QuotaStorage || e.Kind == QuotaUserStorage
+}
+
+// OrganizationUsage is the current consumption of an organization
+type OrganizationUsage struct {
+	Users            int64 `json:"users"`
+	StorageBytes     int64 `json:"storage_bytes"`
+	Documents        int64 `json:"documents"`
+	APIRequestsToday int64 `json:"api_requests_today"`
+
+	APIRequestsThisMinute int64 `json:"api_requests_this_minute"`
+}
+
+// GetOrganizationQuota returns the organization's quota, falling back to the configured defaults
+func GetOrganizationQuota(db *gorm.DB, organizationID uuid.UUID) (models.OrganizationQuota, error) {
+	var quota models.OrganizationQuota
+	err := db.Where("organization_id = ?", organizationID).First(&quota).Error
+	if err == nil {
+		return quota, nil
+	}
+	if err != gorm.ErrRecordNotFound {
+		return quota, err
+	}
+
+	cfg := config.GetConfig()
+	return models.OrganizationQuota{
+		OrganizationID:          organizationID,
+		MaxUsers:                cfg.QuotaDefaultMaxUsers,
+		MaxStorageBytes:         cfg.QuotaDefaultMaxStorageBytes,
+		MaxDocuments:            cfg.QuotaDefaultMaxDocuments,
+		MaxAPIRequestsPerDay:    cfg.QuotaDefaultMaxAPIRequestsPerDay,
+		MaxAPIRequestsPerMinute: cfg.QuotaDefaultMaxAPIRequestsPerMinute,
+	}, nil
+}
+
+// GetOrganizationUsage counts the organization's users, the documents stored in folders owned by
+// the organization or its users, and today's API requests
+func GetOrganizationUsage(db *gorm.DB, organizationID uuid.UUID) (OrganizationUsage, error) {
+	var usage OrganizationUsage
+
+	if err := db.Model(&models.User{}).Where("organization_id = ?", organizationID).Count(&usage.Users).Error; err != nil {
+		return usage, err
+	}
+
+	var storage struct {
+		Documents    int64
+		StorageBytes int64
+	}
+	if err := db.Raw(`
+		SELECT COUNT(*) AS documents, COALESCE(SUM(d.file_size), 0) AS storage_bytes
+		FROM documents d
+		JOIN folders f ON f.id = d.folder_id
+		WHERE d.deleted_at IS NULL AND (
+			(f.owner_type = 'organization' AND f.owner_id = ?) OR
+			(f.owner_type = 'user' AND f.owner_id IN (SELECT id FROM users WHERE organization_id = ?))
+		)`, organizationID, organizationID).Scan(&storage).Error; err != nil {
+		return usage, err
+	}
+	usage.Documents = storage.Documents
+	usage.StorageBytes = storage.StorageBytes
+
+	now := time.Now()
+	var api struct {
+		Requests       int64
+		MinuteRequests int64
+	}
+	if err := db.Model(&models.OrganizationAPIUsage{}).
+		Where("organization_id = ? AND day = ?", organizationID, usageDay(now)).
+		Select("COALESCE(SUM(requests), 0) AS requests, COALESCE(SUM(CASE WHEN minute_start = ? THEN minute_requests END), 0) AS minute_requests", usageMinute(now)).
+		Scan(&api).Error; err != nil {
+		return usage, err
+	}
+	usage.APIRequestsToday = api.Requests
+	usage.APIRequestsThisMinute = api.MinuteRequests
+
+	return usage, nil
+}
+
+// CheckUserQuota returns a QuotaExceededError when the organization cannot take another user
+func CheckUserQuota(db *gorm.DB, organizationID uuid.UUID) error {
+	quota, err := GetOrganizationQuota(db, organizationID)
+	if err != nil || quota.MaxUsers <= 0 {
+		return err
+	}
+
+	var users int64
+	if err := db.Model(&models.User{}).Where("organization_id = ?", organizationID).Count(&users).Error; err != nil {
+		return err
+	}
+	if users+1 > int64(quota.MaxUsers) {
+		return newQuotaExceededError(QuotaUsers, int64(quota.MaxUsers), users, 1)
+	}
+	return nil
+}
+
+// CheckStorageQuota returns a QuotaExceededError when storing addBytes more in addDocuments new
+// documents would exceed the organization's storage or document limit
+func CheckStorageQuota(db *gorm.DB, organizationID uuid.UUID, addBytes int64, addDocuments int) error {
+	quota, err := GetOrganizationQuota(db, organizationID)
+	if err != nil || (quota.MaxStorageBytes <= 0 && quota.MaxDocuments <= 0) {
+		return err
+	}
+
+	usage, err := GetOrganizationUsage(db, organizationID)
+	if err != nil {
+		return err
+	}
+
+	if quota.MaxDocuments > 0 && addDocuments > 0 && usage.Documents+int64(addDocuments) > int64(quota.MaxDocuments) {
+		return newQuotaExceededError(QuotaDocuments, int64(quota.MaxDocuments), usage.Documents, int64(addDocuments))
+	}
+	if quota.MaxStorageBytes > 0 && usage.StorageBytes+addBytes > quota.MaxStorageBytes {
+		return newQuotaExceededError(QuotaStorage, quota.MaxStorageBytes, usage.StorageBytes, addBytes)
+	}
+	return nil
+}
+
+// StorageQuotaStatus is a storage limit together with the bytes used
+type StorageQuotaStatus struct {
+	Limit     int64  `json:"limit"` // 0 = unlimited
+	Used      int64  `json:"used"`
+	Remaining *int64 `json:"remaining"` // nil when unlimited
+}
+
+func newStorageQuotaStatus(limit, used int64) StorageQuotaStatus {
+	status := StorageQuotaStatus{Limit: limit, Used: used}
+	if limit > 0 {
+		remaining := limit - used
+		if remaining < 0 {
+			remaining = 0
+		}
+		status.Remaining = &remaining
+	}
+	return status
+}
+
+// GetUserQuota returns the user's quota, falling back to the configured default
+func GetUserQuota(db *gorm.DB, userID uuid.UUID) (models.UserQuota, error) {
+	var quota models.UserQuota
+	err := db.Where("user_id = ?", userID).First(&quota).Error
+	if err == nil {
+		return quota, nil
+	}
+	if err != gorm.ErrRecordNotFound {
+		return quota, err
+	}
+
+	return models.UserQuota{
+		UserID:          userID,
+		MaxStorageBytes: config.GetConfig().QuotaDefaultUserMaxStorageBytes,
+	}, nil
+}
+
+// GetUserStorageUsage sums the size of the documents in the folders the user owns
+func GetUserStorageUsage(db *gorm.DB, userID uuid.UUID) (int64, error) {
+	var storageBytes int64
+	err := db.Raw(`
+		SELECT COALESCE(SUM(d.file_size), 0)
+		FROM documents d
+		JOIN folders f ON f.id = d.folder_id
+		WHERE d.deleted_at IS NULL AND f.owner_type = 'user' AND f.owner_id = ?`, userID).Scan(&storageBytes).Error
+	return storageBytes, err
+}
+
+// CheckUserStorageQuota returns a QuotaExceededError when storing addBytes more in the user's folders
+// would exceed the user's storage limit
+func CheckUserStorageQuota(db *gorm.DB, userID uuid.UUID, addBytes int64) error {
+	quota, err := GetUserQuota(db, userID)
+	if err != nil || quota.MaxStorageBytes <= 0 {
+		return err
+	}
+
+	used, err := GetUserStorageUsage(db, userID)
+	if err != nil {
+		return err
+	}
+	if used+addBytes > quota.MaxStorageBytes {
+		return newQuotaExceededError(QuotaUserStorage, quota.MaxStorageBytes, used, addBytes)
+	}
+	return nil
+}
+
+// GetUserStorageStatus returns the user's storage limit and usage
+func GetUserStorageStatus(db *gorm.DB, userID uuid.UUID) (StorageQuotaStatus, error) {
+	quota, err := GetUserQuota(db, userID)
+	if err != nil {
+		return StorageQuotaStatus{}, err
+	}
+	used, err := GetUserStorageUsage(db, userID)
+	if err != nil {
+		return StorageQuotaStatus{}, err
+	}
+	return newStorageQuotaStatus(quota.MaxStorageBytes, used), nil
+}
+
+// GetOrganizationStorageStatus returns the organization's storage limit and usage
+func GetOrganizationStorageStatus(db *gorm.DB, organizationID uuid.UUID) (StorageQuotaStatus, error) {
+	quota, err := GetOrganizationQuota(db, organizationID)
+	if err != nil {
+		return StorageQuotaStatus{}, err
+	}
+	usage, err := GetOrganizationUsage(db, organizationID)
+	if err != nil {
+		return StorageQuotaStatus{}, err
+	}
+	return newStorageQuotaStatus(quota.MaxStorageBytes, usage.StorageBytes), nil
+}
+
+// FolderOrganizationID returns the organization owning a folder, directly or through the user owning it.
+// nil means the folder belongs to a user outside any organization.
+func FolderOrganizationID(db *gorm.DB, folder *document.Folder) *uuid.UUID {
+	if folder.OwnerType != "user" {
+		organizationID := folder.OwnerID
+		return &organizationID
+	}
+
+	var owner models.User
+	if err := db.Select("organization_id").First(&owner, folder.OwnerID).Error; err != nil {
+		return nil
+	}
+	return owner.OrganizationID
+}
+
+// APIUsage is the API requests of an organization on a day metered by a gateway instance since it last
+// recorded them
+type APIUsage struct {
+	Requests           int64
+	MinuteStart        time.Time // Latest minute counted
+	MinuteRequests     int64     // Requests of every gateway instance in MinuteStart
+	PeakMinuteRequests int64     // Most requests of every gateway instance in one minute
+}
+
+// RecordAPIUsage adds the API requests metered by a gateway instance to an organization's counters of the day
+func RecordAPIUsage(db *gorm.DB, organizationID uuid.UUID, day time.Time, metered APIUsage) error {
+	minuteStart := metered.MinuteStart.UTC()
+	usage := models.OrganizationAPIUsage{
+		OrganizationID:     organizationID,
+		Day:                usageDay(day),
+		Requests:           metered.Requests,
+		MinuteStart:        &minuteStart,
+		MinuteRequests:     metered.MinuteRequests,
+		PeakMinuteRequests: metered.PeakMinuteRequests,
+	}
+
+	// Minute counts are totals of every instance, so the highest count of the latest minute wins
+	minuteRequests := `CASE
+		WHEN organization_api_usage.minute_start = excluded.minute_start THEN GREATEST(organization_api_usage.minute_requests, excluded.minute_requests)
+		WHEN organization_api_usage.minute_start > excluded.minute_start THEN organization_api_usage.minute_requests
+		ELSE excluded.minute_requests END`
+	return db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "organization_id"}, {Name: "day"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"requests":             gorm.Expr("organization_api_usage.requests + excluded.requests"),
+			"minute_start":         gorm.Expr("GREATEST(organization_api_usage.minute_start, excluded.minute_start)"),
+			"minute_requests":      gorm.Expr(minuteRequests),
+			"peak_minute_requests": gorm.Expr("GREATEST(organization_api_usage.peak_minute_requests, excluded.peak_minute_requests)"),
+		}),
+	}).Create(&usage).Error
+}
+
+// MarkAPIQuotaAlert records that the organization was notified about using percent of today's API
+// request budget. It reports false when that or a higher share was already recorded, so with several
+// gateway instances only one notifies.
+func MarkAPIQuotaAlert(db *gorm.DB, organizationID uuid.UUID, percent int) (bool, error) {
+	usage := models.OrganizationAPIUsage{
+		OrganizationID: organizationID,
+		Day:            usageDay(time.Now()),
+		AlertedPercent: percent,
+	}
+	result := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "organization_id"}, {Name: "day"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"alerted_percent": percent}),
+		Where: clause.Where{Exprs: []clause.Expression{
+			gorm.Expr("organization_api_usage.alerted_percent < excluded.alerted_percent"),
+		}},
+	}).Create(&usage)
+	return result.RowsAffected > 0, result.Error
+}
+
+// GetOrganizationAPIUsageHistory returns the organization's API request counters of the last days, newest first
+func GetOrganizationAPIUsageHistory(db *gorm.DB, organizationID uuid.UUID, days int) ([]models.OrganizationAPIUsage, error) {
+	var history []models.OrganizationAPIUsage
+	err := db.Where("organization_id = ? AND day > ?", organizationID, usageDay(time.Now()).AddDate(0, 0, -days)).
+		Order("day DESC").
+		Find(&history).Error
+	return history, err
+}
+
+// usageDay truncates t to its UTC day, the metering period of API requests
+func usageDay(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
+
+// usageMinute truncates t to its UTC minute, the period of the per-minute API request budget
+func usageMinute(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Minute)
+}
@@ -0,0 +1,152 @@
+package database
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"forgecrud-backend/shared/database/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrInvalidInvite is returned for invite tokens that are unknown, inactive, expired or used up
+var ErrInvalidInvite = errors.New("invite token is invalid or expired")
+
+// EmailDomain returns the lowercase domain of an email address
+func EmailDomain(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(email[at+1:]))
+}
+
+// FindInviteRule returns the usable rule of the invite token. The rule stays locked until the
+// transaction ends, so concurrent registrations cannot use it more often than allowed.
+func FindInviteRule(tx *gorm.DB, token string, now time.Time) (*models.RegistrationRule, error) {
+	var rule models.RegistrationRule
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("type = ? AND invite_token = ?", models.RegistrationRuleInvite, token).
+		First(&rule).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvalidInvite
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	usable, err := registrationRuleUsable(tx, &rule, now)
+	if err != nil {
+		return nil, err
+	}
+	if !usable {
+		return nil, ErrInvalidInvite
+	}
+	return &rule, nil
+}
+
+// FindEmailDomainRule returns the oldest usable rule for the domain of the email address, locked
+// like an invite rule, or nil when no rule applies
+func FindEmailDomainRule(tx *gorm.DB, email string, now time.Time) (*models.RegistrationRule, error) {
+	domain := EmailDomain(email)
+	if domain == "" {
+		return nil, nil
+	}
+
+	var rules []models.RegistrationRule
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("type = ? AND email_domain = ? AND is_active = ?", models.RegistrationRuleEmailDomain, domain, true).
+		Order("created_at").
+		Find(&rules).Error; err != nil {
+		return nil, err
+	}
+
+	for i := range rules {
+		usable, err := registrationRuleUsable(tx, &rules[i], now)
+		if err != nil {
+			return nil, err
+		}
+		if usable {
+			return &rules[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// registrationRuleUsable reports whether the rule can still assign users and its organization and
+// role have not been deleted since it was created
+func registrationRuleUsable(tx *gorm.DB, rule *models.RegistrationRule, now time.Time) (bool, error) {
+	if !rule.Usable(now) {
+		return false, nil
+	}
+
+	if rule.OrganizationID != nil {
+		var count int64
+		if err := tx.Model(&models.Organization{}).Where("id = ?", *rule.OrganizationID).Count(&count).Error; err != nil || count == 0 {
+			return false, err
+		}
+	}
+	if rule.RoleID != nil {
+		var count int64
+		if err := tx.Model(&models.Role{}).Where("id = ?", *rule.RoleID).Count(&count).Error; err != nil || count == 0 {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// ApplyRegistrationRule assigns the rule's organization and role to the user, counts the use and
+// records the role assignment. It returns a QuotaExceededError when the organization cannot take
+// another user.
+func ApplyRegistrationRule(tx *gorm.DB, user *models.User, rule *models.RegistrationRule) (*models.RoleAssignment, error) {
+	assignment, err := JoinOrganization(tx, user, rule.OrganizationID, rule.RoleID, "Registration rule "+rule.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Model(rule).UpdateColumn("use_count", gorm.Expr("use_count + 1")).Error; err != nil {
+		return nil, err
+	}
+	rule.UseCount++
+
+	return assignment, nil
+}
+
+// JoinOrganization places a self-registered user in the organization with the role (either may be
+// nil) and records the role assignment with the reason. It returns a QuotaExceededError when the
+// organization cannot take another user.
+func JoinOrganization(tx *gorm.DB, user *models.User, organizationID, roleID *uuid.UUID, reason string) (*models.RoleAssignment, error) {
+	// Users already in the organization are counted in its quota
+	if organizationID != nil && (user.OrganizationID == nil || *user.OrganizationID != *organizationID) {
+		if err := CheckUserQuota(tx, *organizationID); err != nil {
+			return nil, err
+		}
+	}
+
+	previousRoleID := user.RoleID
+	updates := map[string]interface{}{}
+	if organizationID != nil {
+		user.OrganizationID = organizationID
+		updates["organization_id"] = *organizationID
+	}
+	if roleID != nil {
+		user.RoleID = roleID
+		updates["role_id"] = *roleID
+	}
+	if len(updates) > 0 {
+		if err := tx.Model(user).Updates(updates).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return RecordRoleAssignment(tx, RoleAssignmentEntry{
+		UserID:         user.ID,
+		PreviousRoleID: previousRoleID,
+		RoleID:         user.RoleID,
+		Source:         models.RoleAssignmentSourceRegistration,
+		Reason:         reason,
+	})
+}
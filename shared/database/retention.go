@@ -0,0 +1,50 @@
+package database
+
+import (
+	"fmt"
+
+	"gorm.io/gorm/clause"
+)
+
+// folderChainSQL selects the IDs of a document's folder and its parent folders. table is the name of
+// the documents table in the statement.
+func folderChainSQL(table string) string {
+	return fmt.Sprintf("SELECT a.id FROM folders a JOIN folders f ON f.path = a.path OR f.path LIKE a.path || '/%%' WHERE f.id = %s.folder_id", table)
+}
+
+// DocumentLegalHoldCondition matches documents under an active legal hold, placed on the document
+// itself or on one of its folders. table is the name of the documents table in the statement.
+func DocumentLegalHoldCondition(table string) clause.Expression {
+	return clause.Expr{SQL: fmt.Sprintf("EXISTS (SELECT 1 FROM legal_holds h WHERE h.released_at IS NULL AND "+
+		"((h.object_type = 'document' AND h.object_id = %[1]s.id) OR (h.object_type = 'folder' AND h.object_id IN (%[2]s))))",
+		table, folderChainSQL(table))}
+}
+
+// FolderLegalHoldCondition matches folders under an active legal hold, placed on the folder itself
+// or on one of its parent folders. table is the name of the folders table in the statement.
+func FolderLegalHoldCondition(table string) clause.Expression {
+	return clause.Expr{SQL: fmt.Sprintf("EXISTS (SELECT 1 FROM legal_holds h WHERE h.released_at IS NULL AND h.object_type = 'folder' AND "+
+		"h.object_id IN (SELECT a.id FROM folders a WHERE %[1]s.path = a.path OR %[1]s.path LIKE a.path || '/%%'))", table)}
+}
+
+// DocumentRetainedCondition matches documents younger than the minimum retention of a policy on one
+// of their folders. table is the name of the documents table in the statement.
+func DocumentRetainedCondition(table string) clause.Expression {
+	return clause.Expr{SQL: fmt.Sprintf("EXISTS (SELECT 1 FROM retention_policies rp WHERE rp.folder_id IN (%[2]s) AND "+
+		"rp.min_retention_days > 0 AND %[1]s.created_at > NOW() - rp.min_retention_days * INTERVAL '1 day')",
+		table, folderChainSQL(table))}
+}
+
+// DocumentExpiredCondition matches documents older than the auto-delete age of a policy on one of
+// their folders. table is the name of the documents table in the statement.
+func DocumentExpiredCondition(table string) clause.Expression {
+	return clause.Expr{SQL: fmt.Sprintf("EXISTS (SELECT 1 FROM retention_policies rp WHERE rp.folder_id IN (%[2]s) AND "+
+		"rp.delete_after_days > 0 AND %[1]s.created_at < NOW() - rp.delete_after_days * INTERVAL '1 day')",
+		table, folderChainSQL(table))}
+}
+
+// DocumentDeletionBlockedCondition matches documents that may not be deleted, being under legal hold
+// or within their minimum retention
+func DocumentDeletionBlockedCondition(table string) clause.Expression {
+	return clause.Or(DocumentLegalHoldCondition(table), DocumentRetainedCondition(table))
+}
@@ -0,0 +1,231 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"reflect"
+	"time"
+
+	"forgecrud-backend/shared/database/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type actorContextKey struct{}
+
+const revisionOldValuesKey = "revision:old_values"
+
+// Fields that must never be copied into revision history
+var revisionExcludedFields = map[string]bool{
+	"password":   true,
+	"updated_at": true,
+}
+
+// WithActor returns a context carrying the ID of the user performing a change.
+// Use it with DB.WithContext so revision history can record who changed what.
+func WithActor(ctx context.Context, actorID *uuid.UUID) context.Context {
+	return context.WithValue(ctx, actorContextKey{}, actorID)
+}
+
+// ActorFromContext returns the actor stored by WithActor, if any
+func ActorFromContext(ctx context.Context) *uuid.UUID {
+	if ctx == nil {
+		return nil
+	}
+	actorID, _ := ctx.Value(actorContextKey{}).(*uuid.UUID)
+	return actorID
+}
+
+// registerRevisionCallbacks hooks revision tracking into GORM for models implementing models.Revisioned
+func registerRevisionCallbacks(db *gorm.DB) error {
+	if err := db.Callback().Create().After("gorm:create").Register("revision:after_create", revisionAfterCreate); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().Before("gorm:update").Register("revision:before_update", revisionCaptureOld); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().After("gorm:update").Register("revision:after_update", revisionAfterUpdate); err != nil {
+		return err
+	}
+	if err := db.Callback().Delete().Before("gorm:delete").Register("revision:before_delete", revisionCaptureOld); err != nil {
+		return err
+	}
+	return db.Callback().Delete().After("gorm:delete").Register("revision:after_delete", revisionAfterDelete)
+}
+
+func revisionAfterCreate(tx *gorm.DB) {
+	entityType, entityID, ok := revisionTarget(tx)
+	if !ok || tx.Error != nil {
+		return
+	}
+
+	newValues := loadRevisionSnapshot(tx, entityID)
+	if newValues == nil {
+		return
+	}
+	saveRevision(tx, entityType, entityID, models.RevisionActionCreate, nil, newValues)
+}
+
+func revisionCaptureOld(tx *gorm.DB) {
+	_, entityID, ok := revisionTarget(tx)
+	if !ok {
+		return
+	}
+
+	if oldValues := loadRevisionSnapshot(tx, entityID); oldValues != nil {
+		tx.Statement.Settings.Store(revisionOldValuesKey, oldValues)
+	}
+}
+
+func revisionAfterUpdate(tx *gorm.DB) {
+	entityType, entityID, ok := revisionTarget(tx)
+	if !ok || tx.Error != nil {
+		return
+	}
+
+	stored, found := tx.Statement.Settings.Load(revisionOldValuesKey)
+	if !found {
+		return
+	}
+	oldValues := stored.(models.JSONMap)
+
+	newValues := loadRevisionSnapshot(tx, entityID)
+	if newValues == nil {
+		return
+	}
+
+	// Keep only the fields that actually changed
+	changedOld := models.JSONMap{}
+	changedNew := models.JSONMap{}
+	for key, value := range newValues {
+		if fmt.Sprint(oldValues[key]) != fmt.Sprint(value) {
+			changedOld[key] = oldValues[key]
+			changedNew[key] = value
+		}
+	}
+	if len(changedNew) == 0 {
+		return
+	}
+
+	action := models.RevisionActionUpdate
+	if _, restored := changedNew["deleted_at"]; restored && newValues["deleted_at"] == nil {
+		action = models.RevisionActionRestore
+	}
+
+	saveRevision(tx, entityType, entityID, action, changedOld, changedNew)
+}
+
+func revisionAfterDelete(tx *gorm.DB) {
+	entityType, entityID, ok := revisionTarget(tx)
+	if !ok || tx.Error != nil {
+		return
+	}
+
+	stored, found := tx.Statement.Settings.Load(revisionOldValuesKey)
+	if !found {
+		return
+	}
+	saveRevision(tx, entityType, entityID, models.RevisionActionDelete, stored.(models.JSONMap), nil)
+}
+
+// revisionTarget resolves the entity type and primary key of a single-record statement.
+// Batch statements (no primary key on the model) are not tracked.
+func revisionTarget(tx *gorm.DB) (string, uuid.UUID, bool) {
+	stmt := tx.Statement
+	if stmt.Schema == nil || stmt.Schema.PrioritizedPrimaryField == nil {
+		return "", uuid.Nil, false
+	}
+
+	revisioned, ok := reflect.New(stmt.Schema.ModelType).Interface().(models.Revisioned)
+	if !ok {
+		return "", uuid.Nil, false
+	}
+
+	value := stmt.ReflectValue
+	for value.Kind() == reflect.Ptr {
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return "", uuid.Nil, false
+	}
+
+	pk, isZero := stmt.Schema.PrioritizedPrimaryField.ValueOf(stmt.Context, value)
+	if isZero {
+		return "", uuid.Nil, false
+	}
+	entityID, ok := pk.(uuid.UUID)
+	if !ok {
+		return "", uuid.Nil, false
+	}
+
+	return revisioned.RevisionEntityType(), entityID, true
+}
+
+// loadRevisionSnapshot reads the current database row of the entity as a JSON-friendly map
+func loadRevisionSnapshot(tx *gorm.DB, entityID uuid.UUID) models.JSONMap {
+	row := map[string]interface{}{}
+	err := tx.Session(&gorm.Session{NewDB: true, SkipHooks: true}).
+		Unscoped().
+		Table(tx.Statement.Table).
+		Where("id = ?", entityID).
+		Take(&row).Error
+	if err != nil {
+		return nil
+	}
+
+	snapshot := models.JSONMap{}
+	for key, value := range row {
+		if revisionExcludedFields[key] {
+			continue
+		}
+		snapshot[key] = normalizeRevisionValue(value)
+	}
+	return snapshot
+}
+
+// normalizeRevisionValue converts driver values (uuid byte arrays, timestamps) to JSON-friendly values
+func normalizeRevisionValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case [16]byte:
+		return uuid.UUID(v).String()
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.UTC().Format(time.RFC3339)
+	default:
+		return v
+	}
+}
+
+func saveRevision(tx *gorm.DB, entityType string, entityID uuid.UUID, action string, oldValues, newValues models.JSONMap) {
+	revision := models.Revision{
+		EntityType: entityType,
+		EntityID:   entityID,
+		Action:     action,
+		ActorID:    ActorFromContext(tx.Statement.Context),
+		OldValues:  oldValues,
+		NewValues:  newValues,
+	}
+
+	if err := tx.Session(&gorm.Session{NewDB: true, SkipHooks: true}).Create(&revision).Error; err != nil {
+		log.Printf("⚠️  Failed to record %s revision for %s: %v", entityType, entityID, err)
+	}
+}
+
+// GetRevisions returns a page of an entity's revisions, newest first, along with the total count
+func GetRevisions(db *gorm.DB, entityType string, entityID uuid.UUID, page, limit int) ([]models.Revision, int64, error) {
+	var total int64
+	base := db.Model(&models.Revision{}).Where("entity_type = ? AND entity_id = ?", entityType, entityID)
+	if err := base.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var revisions []models.Revision
+	err := base.Order("created_at DESC").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&revisions).Error
+	return revisions, total, err
+}
@@ -0,0 +1,57 @@
+package database
+
+import (
+	"forgecrud-backend/shared/database/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RoleAssignmentEntry describes a change of a user's role to be recorded in the role assignment history
+type RoleAssignmentEntry struct {
+	UserID            uuid.UUID
+	PreviousRoleID    *uuid.UUID
+	RoleID            *uuid.UUID
+	Source            string
+	ActorID           *uuid.UUID
+	ScheduledChangeID *uuid.UUID
+	Reason            string
+}
+
+// RecordRoleAssignment adds the role change to the history. It returns nil without writing
+// anything when the role did not actually change.
+func RecordRoleAssignment(tx *gorm.DB, entry RoleAssignmentEntry) (*models.RoleAssignment, error) {
+	if sameRole(entry.PreviousRoleID, entry.RoleID) {
+		return nil, nil
+	}
+
+	action := models.RoleAssignmentChanged
+	switch {
+	case entry.PreviousRoleID == nil:
+		action = models.RoleAssignmentAssigned
+	case entry.RoleID == nil:
+		action = models.RoleAssignmentRemoved
+	}
+
+	assignment := models.RoleAssignment{
+		UserID:            entry.UserID,
+		PreviousRoleID:    entry.PreviousRoleID,
+		RoleID:            entry.RoleID,
+		Action:            action,
+		Source:            entry.Source,
+		ActorID:           entry.ActorID,
+		ScheduledChangeID: entry.ScheduledChangeID,
+		Reason:            entry.Reason,
+	}
+	if err := tx.Create(&assignment).Error; err != nil {
+		return nil, err
+	}
+	return &assignment, nil
+}
+
+func sameRole(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
@@ -0,0 +1,80 @@
+package database
+
+import (
+	"forgecrud-backend/shared/database/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CloneRole creates a copy of the role in the organization (nil for a global role) together with
+// every permission granted to the role and its actions. Run it in a transaction so a failure
+// leaves no partial copy behind.
+func CloneRole(tx *gorm.DB, source models.Role, organizationID *uuid.UUID, name, description string) (*models.Role, error) {
+	clone := models.Role{
+		Name:           name,
+		Description:    description,
+		IsDefault:      source.IsDefault,
+		OrganizationID: organizationID,
+		SourceRoleID:   &source.ID,
+	}
+	if err := tx.Create(&clone).Error; err != nil {
+		return nil, err
+	}
+
+	var permissions []models.Permission
+	if err := tx.Preload("PermissionActions").
+		Where("target = ? AND role_id = ?", models.PermissionTargetRole, source.ID).
+		Find(&permissions).Error; err != nil {
+		return nil, err
+	}
+
+	for _, permission := range permissions {
+		copied := models.Permission{
+			ResourceID: permission.ResourceID,
+			Target:     models.PermissionTargetRole,
+			RoleID:     &clone.ID,
+		}
+		if err := tx.Create(&copied).Error; err != nil {
+			return nil, err
+		}
+		for _, permissionAction := range permission.PermissionActions {
+			action := models.PermissionAction{PermissionID: copied.ID, ActionID: permissionAction.ActionID}
+			if err := tx.Create(&action).Error; err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return &clone, nil
+}
+
+// StampRoleCatalog clones every global catalog role into the organization. Catalog roles the
+// organization already has, cloned earlier or created with the same name, are skipped, so stamping
+// again only adds the roles added to the catalog since. It returns the roles created.
+func StampRoleCatalog(tx *gorm.DB, organizationID uuid.UUID) ([]models.Role, error) {
+	var catalog []models.Role
+	if err := tx.Where("organization_id IS NULL AND in_catalog = ?", true).Order("name").Find(&catalog).Error; err != nil {
+		return nil, err
+	}
+
+	stamped := []models.Role{}
+	for _, source := range catalog {
+		var count int64
+		if err := tx.Model(&models.Role{}).
+			Where("organization_id = ? AND (source_role_id = ? OR name = ?)", organizationID, source.ID, source.Name).
+			Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			continue
+		}
+
+		role, err := CloneRole(tx, source, &organizationID, source.Name, source.Description)
+		if err != nil {
+			return nil, err
+		}
+		stamped = append(stamped, *role)
+	}
+	return stamped, nil
+}
@@ -65,7 +65,9 @@ func seedResources() (int, error) {
 		{Name: "Users", Slug: "users", Description: "User management", IsSystem: true},
 		{Name: "Organizations", Slug: "organizations", Description: "Organization management", IsSystem: true},
 		{Name: "Roles", Slug: "roles", Description: "Role management", IsSystem: true},
+		{Name: "Teams", Slug: "teams", Description: "Team management", IsSystem: true},
 		{Name: "Permissions", Slug: "permissions", Description: "Permission management", IsSystem: true},
+		{Name: "Webhooks", Slug: "webhooks", Description: "Outbound webhook management", IsSystem: true},
 		{Name: "Notifications", Slug: "notifications", Description: "Notification management", IsSystem: true},
 		{Name: "Forms", Slug: "forms", Description: "Dynamic form management", IsSystem: true},
 		{Name: "Dashboard", Slug: "dashboard", Description: "Dashboard access", IsSystem: true},
@@ -0,0 +1,16 @@
+package database
+
+import (
+	"forgecrud-backend/shared/database/models/auth"
+
+	"gorm.io/gorm"
+)
+
+// IsSessionActive reports whether a session of the user has not been terminated
+func IsSessionActive(db *gorm.DB, userID, sessionID string) (bool, error) {
+	var count int64
+	err := db.Model(&auth.UserSession{}).
+		Where("session_id = ? AND user_id = ? AND is_active = ?", sessionID, userID, true).
+		Count(&count).Error
+	return count > 0, err
+}
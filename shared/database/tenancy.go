@@ -0,0 +1,311 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"forgecrud-backend/shared/tenancy"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const tenancyScopedKey = "tenancy:scoped"
+
+// tenantRule builds the condition limiting a table to the rows of an organization.
+// table is the name used in the statement so columns stay unambiguous in joined queries.
+type tenantRule func(table string, organizationID uuid.UUID) clause.Expression
+
+// folderOwnedBy matches folders owned by the organization itself or by one of its users
+func folderOwnedBy(table string, organizationID uuid.UUID) clause.Expr {
+	return clause.Expr{
+		SQL: fmt.Sprintf("((%[1]s.owner_type = 'organization' AND %[1]s.owner_id = ?) OR "+
+			"(%[1]s.owner_type = 'user' AND %[1]s.owner_id IN (SELECT id FROM users WHERE organization_id = ?)))", table),
+		Vars: []interface{}{organizationID, organizationID},
+	}
+}
+
+// tenantRules lists the organization-owned tables. Tables not listed here are never scoped.
+var tenantRules = map[string]tenantRule{
+	"users": func(table string, organizationID uuid.UUID) clause.Expression {
+		return clause.Expr{SQL: table + ".organization_id = ?", Vars: []interface{}{organizationID}}
+	},
+	// Roles without an organization are global templates shared by every tenant
+	"roles": func(table string, organizationID uuid.UUID) clause.Expression {
+		return clause.Expr{SQL: fmt.Sprintf("(%[1]s.organization_id = ? OR %[1]s.organization_id IS NULL)", table), Vars: []interface{}{organizationID}}
+	},
+	// An organization sees itself and its direct sub-organizations
+	"organizations": func(table string, organizationID uuid.UUID) clause.Expression {
+		return clause.Expr{SQL: fmt.Sprintf("(%[1]s.id = ? OR %[1]s.parent_id = ?)", table), Vars: []interface{}{organizationID, organizationID}}
+	},
+	"teams": func(table string, organizationID uuid.UUID) clause.Expression {
+		return clause.Expr{SQL: table + ".organization_id = ?", Vars: []interface{}{organizationID}}
+	},
+	"folders": func(table string, organizationID uuid.UUID) clause.Expression {
+		return folderOwnedBy(table, organizationID)
+	},
+	"documents": func(table string, organizationID uuid.UUID) clause.Expression {
+		folders := folderOwnedBy("folders", organizationID)
+		return clause.Expr{
+			SQL:  table + ".folder_id IN (SELECT id FROM folders WHERE " + folders.SQL + ")",
+			Vars: folders.Vars,
+		}
+	},
+	"upload_sessions": func(table string, organizationID uuid.UUID) clause.Expression {
+		folders := folderOwnedBy("folders", organizationID)
+		return clause.Expr{
+			SQL:  table + ".folder_id IN (SELECT id FROM folders WHERE " + folders.SQL + ")",
+			Vars: folders.Vars,
+		}
+	},
+	"metadata_fields": func(table string, organizationID uuid.UUID) clause.Expression {
+		folders := folderOwnedBy("folders", organizationID)
+		return clause.Expr{
+			SQL:  table + ".folder_id IN (SELECT id FROM folders WHERE " + folders.SQL + ")",
+			Vars: folders.Vars,
+		}
+	},
+	"document_shares": func(table string, organizationID uuid.UUID) clause.Expression {
+		folders := folderOwnedBy("folders", organizationID)
+		return clause.Expr{
+			SQL:  table + ".document_id IN (SELECT d.id FROM documents d JOIN folders ON folders.id = d.folder_id WHERE " + folders.SQL + ")",
+			Vars: folders.Vars,
+		}
+	},
+	"recent_documents": func(table string, organizationID uuid.UUID) clause.Expression {
+		folders := folderOwnedBy("folders", organizationID)
+		return clause.Expr{
+			SQL:  table + ".document_id IN (SELECT d.id FROM documents d JOIN folders ON folders.id = d.folder_id WHERE " + folders.SQL + ")",
+			Vars: folders.Vars,
+		}
+	},
+	"favorite_documents": func(table string, organizationID uuid.UUID) clause.Expression {
+		folders := folderOwnedBy("folders", organizationID)
+		return clause.Expr{
+			SQL:  table + ".document_id IN (SELECT d.id FROM documents d JOIN folders ON folders.id = d.folder_id WHERE " + folders.SQL + ")",
+			Vars: folders.Vars,
+		}
+	},
+	"document_activities": func(table string, organizationID uuid.UUID) clause.Expression {
+		folders := folderOwnedBy("folders", organizationID)
+		return clause.Expr{
+			SQL:  table + ".document_id IN (SELECT d.id FROM documents d JOIN folders ON folders.id = d.folder_id WHERE " + folders.SQL + ")",
+			Vars: folders.Vars,
+		}
+	},
+	"access_grants": func(table string, organizationID uuid.UUID) clause.Expression {
+		folders := folderOwnedBy("folders", organizationID)
+		return clause.Expr{
+			SQL: fmt.Sprintf("((%[1]s.object_type = 'folder' AND %[1]s.object_id IN (SELECT id FROM folders WHERE %[2]s)) OR "+
+				"(%[1]s.object_type = 'document' AND %[1]s.object_id IN (SELECT d.id FROM documents d JOIN folders ON folders.id = d.folder_id WHERE %[2]s)))",
+				table, folders.SQL),
+			Vars: append(append([]interface{}{}, folders.Vars...), folders.Vars...),
+		}
+	},
+	"retention_policies": func(table string, organizationID uuid.UUID) clause.Expression {
+		folders := folderOwnedBy("folders", organizationID)
+		return clause.Expr{SQL: table + ".folder_id IN (SELECT id FROM folders WHERE " + folders.SQL + ")", Vars: folders.Vars}
+	},
+	"folder_subscriptions": func(table string, organizationID uuid.UUID) clause.Expression {
+		folders := folderOwnedBy("folders", organizationID)
+		return clause.Expr{SQL: table + ".folder_id IN (SELECT id FROM folders WHERE " + folders.SQL + ")", Vars: folders.Vars}
+	},
+	"legal_holds": func(table string, organizationID uuid.UUID) clause.Expression {
+		folders := folderOwnedBy("folders", organizationID)
+		return clause.Expr{
+			SQL: fmt.Sprintf("((%[1]s.object_type = 'folder' AND %[1]s.object_id IN (SELECT id FROM folders WHERE %[2]s)) OR "+
+				"(%[1]s.object_type = 'document' AND %[1]s.object_id IN (SELECT d.id FROM documents d JOIN folders ON folders.id = d.folder_id WHERE %[2]s)))",
+				table, folders.SQL),
+			Vars: append(append([]interface{}{}, folders.Vars...), folders.Vars...),
+		}
+	},
+	"notifications": func(table string, organizationID uuid.UUID) clause.Expression {
+		return clause.Expr{SQL: table + ".user_id IN (SELECT id FROM users WHERE organization_id = ?)", Vars: []interface{}{organizationID}}
+	},
+	"device_tokens": func(table string, organizationID uuid.UUID) clause.Expression {
+		return clause.Expr{SQL: table + ".user_id IN (SELECT id FROM users WHERE organization_id = ?)", Vars: []interface{}{organizationID}}
+	},
+	"push_deliveries": func(table string, organizationID uuid.UUID) clause.Expression {
+		return clause.Expr{SQL: table + ".user_id IN (SELECT id FROM users WHERE organization_id = ?)", Vars: []interface{}{organizationID}}
+	},
+	"notification_preferences": func(table string, organizationID uuid.UUID) clause.Expression {
+		return clause.Expr{SQL: table + ".user_id IN (SELECT id FROM users WHERE organization_id = ?)", Vars: []interface{}{organizationID}}
+	},
+	"notification_settings": func(table string, organizationID uuid.UUID) clause.Expression {
+		return clause.Expr{SQL: table + ".user_id IN (SELECT id FROM users WHERE organization_id = ?)", Vars: []interface{}{organizationID}}
+	},
+	"notification_digest_items": func(table string, organizationID uuid.UUID) clause.Expression {
+		return clause.Expr{SQL: table + ".user_id IN (SELECT id FROM users WHERE organization_id = ?)", Vars: []interface{}{organizationID}}
+	},
+	"notification_integrations": func(table string, organizationID uuid.UUID) clause.Expression {
+		return clause.Expr{SQL: table + ".organization_id = ?", Vars: []interface{}{organizationID}}
+	},
+	"integration_deliveries": func(table string, organizationID uuid.UUID) clause.Expression {
+		return clause.Expr{SQL: table + ".integration_id IN (SELECT id FROM notification_integrations WHERE organization_id = ?)", Vars: []interface{}{organizationID}}
+	},
+	// Templates without an organization are the global defaults every tenant falls back to
+	"email_templates": func(table string, organizationID uuid.UUID) clause.Expression {
+		return clause.Expr{SQL: fmt.Sprintf("(%[1]s.organization_id = ? OR %[1]s.organization_id IS NULL)", table), Vars: []interface{}{organizationID}}
+	},
+	"email_template_versions": func(table string, organizationID uuid.UUID) clause.Expression {
+		return clause.Expr{SQL: table + ".template_id IN (SELECT id FROM email_templates WHERE organization_id = ? OR organization_id IS NULL)", Vars: []interface{}{organizationID}}
+	},
+	"email_messages": func(table string, organizationID uuid.UUID) clause.Expression {
+		return clause.Expr{SQL: table + ".organization_id = ?", Vars: []interface{}{organizationID}}
+	},
+	"email_dead_letters": func(table string, organizationID uuid.UUID) clause.Expression {
+		return clause.Expr{SQL: table + ".organization_id = ?", Vars: []interface{}{organizationID}}
+	},
+	"email_events": func(table string, organizationID uuid.UUID) clause.Expression {
+		return clause.Expr{SQL: table + ".organization_id = ?", Vars: []interface{}{organizationID}}
+	},
+	"role_assignments": func(table string, organizationID uuid.UUID) clause.Expression {
+		return clause.Expr{SQL: table + ".user_id IN (SELECT id FROM users WHERE organization_id = ?)", Vars: []interface{}{organizationID}}
+	},
+	"scheduled_role_changes": func(table string, organizationID uuid.UUID) clause.Expression {
+		return clause.Expr{SQL: table + ".user_id IN (SELECT id FROM users WHERE organization_id = ?)", Vars: []interface{}{organizationID}}
+	},
+	"user_field_definitions": func(table string, organizationID uuid.UUID) clause.Expression {
+		return clause.Expr{SQL: table + ".organization_id = ?", Vars: []interface{}{organizationID}}
+	},
+	"scheduled_notifications": func(table string, organizationID uuid.UUID) clause.Expression {
+		return clause.Expr{SQL: table + ".organization_id = ?", Vars: []interface{}{organizationID}}
+	},
+	"registration_rules": func(table string, organizationID uuid.UUID) clause.Expression {
+		return clause.Expr{SQL: table + ".organization_id = ?", Vars: []interface{}{organizationID}}
+	},
+	"organization_domains": func(table string, organizationID uuid.UUID) clause.Expression {
+		return clause.Expr{SQL: table + ".organization_id = ?", Vars: []interface{}{organizationID}}
+	},
+	"organization_join_requests": func(table string, organizationID uuid.UUID) clause.Expression {
+		return clause.Expr{SQL: table + ".organization_id = ?", Vars: []interface{}{organizationID}}
+	},
+}
+
+// tenantOwnedOnCreate lists tables whose new rows must belong to the tenant's organization
+var tenantOwnedOnCreate = map[string]bool{
+	"users":                     true,
+	"roles":                     true,
+	"teams":                     true,
+	"user_field_definitions":    true,
+	"notification_integrations": true,
+	"email_templates":           true,
+	"scheduled_notifications":   true,
+	"registration_rules":        true,
+	"organization_domains":      true,
+}
+
+// ErrTenantMismatch is returned when a record is created for another organization than the caller's
+var ErrTenantMismatch = errors.New("record belongs to another organization")
+
+// registerTenancyCallbacks scopes queries, updates and deletes on organization-owned tables to the
+// tenant stored in the statement context (see tenancy.WithTenant). Creates default organization_id
+// to the tenant and are rejected for other organizations.
+func registerTenancyCallbacks(db *gorm.DB) error {
+	if err := db.Callback().Create().Before("gorm:create").Register("tenancy:create", tenancyAssignOnCreate); err != nil {
+		return err
+	}
+	if err := db.Callback().Query().Before("gorm:query").Register("tenancy:query", tenancyScope); err != nil {
+		return err
+	}
+	if err := db.Callback().Row().Before("gorm:row").Register("tenancy:row", tenancyScope); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().Before("gorm:update").Register("tenancy:update", tenancyScope); err != nil {
+		return err
+	}
+	return db.Callback().Delete().Before("gorm:delete").Register("tenancy:delete", tenancyScope)
+}
+
+func tenancyScope(tx *gorm.DB) {
+	if tx.Error != nil || tx.Statement.Schema == nil {
+		return
+	}
+
+	tenant, ok := tenancy.FromContext(tx.Statement.Context)
+	if !ok || tenant.Bypass {
+		return
+	}
+
+	rule, scoped := tenantRules[tx.Statement.Schema.Table]
+	if !scoped {
+		return
+	}
+
+	// Chained queries reuse the statement, e.g. Count followed by Find
+	if _, done := tx.Statement.Settings.Load(tenancyScopedKey); done {
+		return
+	}
+	tx.Statement.Settings.Store(tenancyScopedKey, true)
+
+	table := tx.Statement.Table
+	if table == "" {
+		table = tx.Statement.Schema.Table
+	}
+
+	// Users outside any organization only see global rows, which no organization-owned table has
+	if tenant.OrganizationID == nil {
+		tx.Statement.AddClause(clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "1 = 0"}}})
+		return
+	}
+
+	tx.Statement.AddClause(clause.Where{Exprs: []clause.Expression{rule(table, *tenant.OrganizationID)}})
+}
+
+func tenancyAssignOnCreate(tx *gorm.DB) {
+	if tx.Error != nil || tx.Statement.Schema == nil || !tenantOwnedOnCreate[tx.Statement.Schema.Table] {
+		return
+	}
+
+	tenant, ok := tenancy.FromContext(tx.Statement.Context)
+	if !ok || tenant.Bypass {
+		return
+	}
+	if tenant.OrganizationID == nil {
+		tx.AddError(ErrTenantMismatch)
+		return
+	}
+
+	field := tx.Statement.Schema.LookUpField("organization_id")
+	if field == nil {
+		return
+	}
+
+	assign := func(value reflect.Value) {
+		current, isZero := field.ValueOf(tx.Statement.Context, value)
+		if isZero {
+			var err error
+			if field.FieldType.Kind() == reflect.Ptr {
+				err = field.Set(tx.Statement.Context, value, tenant.OrganizationID)
+			} else {
+				err = field.Set(tx.Statement.Context, value, *tenant.OrganizationID)
+			}
+			if err != nil {
+				tx.AddError(err)
+			}
+			return
+		}
+
+		switch organizationID := current.(type) {
+		case uuid.UUID:
+			if organizationID != *tenant.OrganizationID {
+				tx.AddError(ErrTenantMismatch)
+			}
+		case *uuid.UUID:
+			if organizationID != nil && *organizationID != *tenant.OrganizationID {
+				tx.AddError(ErrTenantMismatch)
+			}
+		}
+	}
+
+	switch rv := tx.Statement.ReflectValue; rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			assign(reflect.Indirect(rv.Index(i)))
+		}
+	case reflect.Struct:
+		assign(rv)
+	}
+}
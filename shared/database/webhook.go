@@ -0,0 +1,97 @@
+package database
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"strings"
+	"time"
+
+	"forgecrud-backend/shared/database/models"
+	"forgecrud-backend/shared/ids"
+
+	"github.com/google/uuid"
+)
+
+// Headers sent with every webhook request
+const (
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookSignatureHeader = "X-Webhook-Signature"
+)
+
+// SignWebhookPayload returns the signature header value: "sha256=" + hex(HMAC-SHA256(secret, timestamp + "." + payload))
+func SignWebhookPayload(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// WebhookEnvelope is the JSON body posted to webhook endpoints
+type WebhookEnvelope struct {
+	ID        uuid.UUID   `json:"id"`
+	Event     string      `json:"event"`
+	CreatedAt string      `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// WebhookSubscribes reports whether a comma separated event filter matches the event
+func WebhookSubscribes(events, event string) bool {
+	for _, subscribed := range strings.Split(events, ",") {
+		subscribed = strings.TrimSpace(subscribed)
+		if subscribed == models.WebhookEventAll || subscribed == event {
+			return true
+		}
+	}
+	return false
+}
+
+// EnqueueWebhookEvent queues a delivery of the event for every active webhook subscribed to it.
+// Deliveries are sent asynchronously by the core-service webhook dispatcher; failures are only logged
+// so that webhooks never break the originating request.
+func EnqueueWebhookEvent(event string, data interface{}) {
+	if DB == nil {
+		return
+	}
+
+	var webhooks []models.Webhook
+	if err := DB.Where("is_active = ?", true).Find(&webhooks).Error; err != nil {
+		log.Printf("⚠️  Failed to load webhooks for %s: %v", event, err)
+		return
+	}
+
+	now := time.Now().UTC()
+	for _, webhook := range webhooks {
+		if !WebhookSubscribes(webhook.Events, event) {
+			continue
+		}
+
+		deliveryID := ids.New()
+		payload, err := json.Marshal(WebhookEnvelope{
+			ID:        deliveryID,
+			Event:     event,
+			CreatedAt: now.Format("2006-01-02T15:04:05Z07:00"),
+			Data:      data,
+		})
+		if err != nil {
+			log.Printf("⚠️  Failed to encode %s webhook payload: %v", event, err)
+			return
+		}
+
+		delivery := models.WebhookDelivery{
+			ID:            deliveryID,
+			WebhookID:     webhook.ID,
+			Event:         event,
+			Payload:       string(payload),
+			Status:        models.WebhookDeliveryPending,
+			NextAttemptAt: now,
+		}
+		if err := DB.Create(&delivery).Error; err != nil {
+			log.Printf("⚠️  Failed to queue %s delivery for webhook %s: %v", event, webhook.ID, err)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"forgecrud-backend/shared/config"
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/server"
+)
+
+// Check reports whether a dependency is reachable. The details, like pool statistics, are included in
+// the readiness report.
+type Check func(ctx context.Context) (details interface{}, err error)
+
+// Readiness statuses
+const (
+	StatusReady       = "ready"
+	StatusDegraded    = "degraded"    // An optional dependency is down
+	StatusUnavailable = "unavailable" // A required dependency is down or the service is shutting down
+)
+
+// Dependency statuses
+const (
+	DependencyUp   = "up"
+	DependencyDown = "down"
+)
+
+type dependency struct {
+	name     string
+	check    Check
+	required bool
+}
+
+var (
+	mutex        sync.RWMutex
+	dependencies []dependency
+)
+
+// AddCheck registers a dependency the service cannot serve requests without; readiness fails while
+// it is unreachable
+func AddCheck(name string, check Check) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	dependencies = append(dependencies, dependency{name: name, check: check, required: true})
+}
+
+// AddOptionalCheck registers a dependency the service works without, like a cache or the event bus.
+// It is reported, but doesn't fail readiness.
+func AddOptionalCheck(name string, check Check) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	dependencies = append(dependencies, dependency{name: name, check: check, required: false})
+}
+
+// DependencyStatus is the result of a dependency check
+type DependencyStatus struct {
+	Name      string      `json:"name"`
+	Status    string      `json:"status"` // up or down
+	Required  bool        `json:"required"`
+	LatencyMs float64     `json:"latency_ms"`
+	Error     string      `json:"error,omitempty"`
+	Details   interface{} `json:"details,omitempty"`
+}
+
+// Report is the readiness of a service and its dependencies
+type Report struct {
+	Service      string             `json:"service"`
+	Status       string             `json:"status"` // ready, degraded or unavailable
+	Ready        bool               `json:"ready"`
+	ShuttingDown bool               `json:"shutting_down"`
+	Dependencies []DependencyStatus `json:"dependencies"`
+	CheckedAt    time.Time          `json:"checked_at"`
+}
+
+// CheckReadiness checks the registered dependencies concurrently, each within HEALTH_CHECK_TIMEOUT_MS
+func CheckReadiness(ctx context.Context, service string) Report {
+	mutex.RLock()
+	checks := append([]dependency(nil), dependencies...)
+	mutex.RUnlock()
+
+	timeout := time.Duration(config.GetConfig().HealthCheckTimeoutMs) * time.Millisecond
+	statuses := make([]DependencyStatus, len(checks))
+	var wg sync.WaitGroup
+	for i, dep := range checks {
+		wg.Add(1)
+		go func(i int, dep dependency) {
+			defer wg.Done()
+			statuses[i] = runCheck(ctx, dep, timeout)
+		}(i, dep)
+	}
+	wg.Wait()
+
+	report := Report{
+		Service:      service,
+		Status:       StatusReady,
+		Ready:        true,
+		ShuttingDown: server.ShuttingDown(),
+		Dependencies: statuses,
+		CheckedAt:    time.Now(),
+	}
+	for _, status := range statuses {
+		if status.Status == DependencyUp {
+			continue
+		}
+		if status.Required {
+			report.Ready = false
+		} else if report.Status == StatusReady {
+			report.Status = StatusDegraded
+		}
+	}
+	if report.ShuttingDown {
+		report.Ready = false
+	}
+	if !report.Ready {
+		report.Status = StatusUnavailable
+	}
+	return report
+}
+
+// runCheck runs the check, reporting it down when it doesn't return within the timeout
+func runCheck(ctx context.Context, dep dependency, timeout time.Duration) DependencyStatus {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	type result struct {
+		details interface{}
+		err     error
+	}
+	done := make(chan result, 1)
+	started := time.Now()
+	go func() {
+		details, err := dep.check(ctx)
+		done <- result{details: details, err: err}
+	}()
+
+	status := DependencyStatus{Name: dep.name, Status: DependencyUp, Required: dep.required}
+	select {
+	case res := <-done:
+		status.Details = res.details
+		if res.err != nil {
+			status.Status = DependencyDown
+			status.Error = res.err.Error()
+		}
+	case <-ctx.Done():
+		// Checks ignoring the context are left to finish in the background
+		status.Status = DependencyDown
+		status.Error = fmt.Sprintf("no answer within %s", timeout)
+	}
+	status.LatencyMs = float64(time.Since(started)) / float64(time.Millisecond)
+	return status
+}
+
+// CheckDatabase checks the database connection and its pool against the readiness thresholds
+func CheckDatabase(ctx context.Context) (interface{}, error) {
+	report := database.CheckHealth(ctx)
+	if !report.Ready {
+		return report, fmt.Errorf("%s", strings.Join(report.Problems, "; "))
+	}
+	return report, nil
+}
+
+// Ping adapts a function checking a connection, like a Redis ping, to a Check
+func Ping(ping func(ctx context.Context) error) Check {
+	return func(ctx context.Context) (interface{}, error) {
+		return nil, ping(ctx)
+	}
+}
+
+// HTTPCheck checks that the URL, usually the liveness endpoint of another service, answers with a 2xx status
+func HTTPCheck(url string) Check {
+	return func(ctx context.Context) (interface{}, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return nil, fmt.Errorf("%s answered %s", url, resp.Status)
+		}
+		return nil, nil
+	}
+}
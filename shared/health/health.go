@@ -0,0 +1,50 @@
+package health
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// startedAt is when the process started, for the uptime of the liveness probe
+var startedAt = time.Now()
+
+// Register adds the probes of a service, with the dependencies registered by AddCheck and
+// AddOptionalCheck:
+//
+//   - /health/live answers 200 while the process runs, without checking dependencies
+//   - /health/ready answers 503 while a required dependency is down or the service is shutting down
+//   - /health reports the dependencies like /health/ready, but always answers 200
+//   - /metrics exposes the same in the Prometheus text format
+//
+// /ready is kept as an alias of /health/ready.
+func Register(router *gin.Engine, service string) {
+	router.GET("/health/live", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"status":         "alive",
+			"service":        service,
+			"uptime_seconds": int64(time.Since(startedAt).Seconds()),
+		})
+	})
+
+	ready := func(c *gin.Context) {
+		report := CheckReadiness(c.Request.Context(), service)
+		status := http.StatusOK
+		if !report.Ready {
+			status = http.StatusServiceUnavailable
+		}
+		c.JSON(status, report)
+	}
+	router.GET("/health/ready", ready)
+	router.GET("/ready", ready)
+
+	router.GET("/health", func(c *gin.Context) {
+		c.JSON(http.StatusOK, CheckReadiness(c.Request.Context(), service))
+	})
+
+	router.GET("/metrics", func(c *gin.Context) {
+		report := CheckReadiness(c.Request.Context(), service)
+		c.Data(http.StatusOK, "text/plain; version=0.0.4; charset=utf-8", []byte(renderMetrics(report)))
+	})
+}
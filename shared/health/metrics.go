@@ -0,0 +1,90 @@
+package health
+
+import (
+	"fmt"
+	"strings"
+
+	"forgecrud-backend/shared/clients"
+	"forgecrud-backend/shared/database"
+)
+
+// renderMetrics writes the report in the Prometheus text exposition format
+func renderMetrics(report Report) string {
+	var b strings.Builder
+	service := fmt.Sprintf(`service=%q`, report.Service)
+
+	header := func(name, kind, help string) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	}
+	metric := func(name, kind, help string, value interface{}) {
+		header(name, kind, help)
+		fmt.Fprintf(&b, "%s{%s} %v\n", name, service, value)
+	}
+
+	metric("forgecrud_ready", "gauge", "Whether the service is ready to serve requests.", boolValue(report.Ready))
+
+	header("forgecrud_dependency_up", "gauge", "Whether the dependency answered its check.")
+	for _, dep := range report.Dependencies {
+		fmt.Fprintf(&b, "forgecrud_dependency_up{%s,dependency=%q,required=%q} %d\n",
+			service, dep.Name, fmt.Sprint(dep.Required), boolValue(dep.Status == DependencyUp))
+	}
+	header("forgecrud_dependency_latency_ms", "gauge", "Latency of the last dependency check in milliseconds.")
+	for _, dep := range report.Dependencies {
+		fmt.Fprintf(&b, "forgecrud_dependency_latency_ms{%s,dependency=%q} %.3f\n", service, dep.Name, dep.LatencyMs)
+	}
+
+	// Pool statistics of services backed by the database
+	for _, dep := range report.Dependencies {
+		db, ok := dep.Details.(database.HealthReport)
+		if !ok {
+			continue
+		}
+		pool := db.Pool
+		metric("forgecrud_db_up", "gauge", "Whether the database answered the health ping.", boolValue(db.Status != database.HealthDown))
+		metric("forgecrud_db_ready", "gauge", "Whether the database is within the readiness thresholds.", boolValue(db.Ready))
+		metric("forgecrud_db_ping_latency_ms", "gauge", "Latency of the last health ping in milliseconds.", fmt.Sprintf("%.3f", db.LatencyMs))
+		metric("forgecrud_db_pool_max_open_connections", "gauge", "Maximum number of open connections, 0 is unlimited.", pool.MaxOpenConnections)
+		metric("forgecrud_db_pool_open_connections", "gauge", "Open connections, in use and idle.", pool.OpenConnections)
+		metric("forgecrud_db_pool_in_use_connections", "gauge", "Connections currently in use.", pool.InUse)
+		metric("forgecrud_db_pool_idle_connections", "gauge", "Idle connections.", pool.Idle)
+		metric("forgecrud_db_pool_wait_count_total", "counter", "Connections waited for.", pool.WaitCount)
+		metric("forgecrud_db_pool_wait_duration_ms_total", "counter", "Total time waited for connections in milliseconds.", fmt.Sprintf("%.3f", pool.WaitDurationMs))
+		metric("forgecrud_db_pool_max_idle_closed_total", "counter", "Connections closed due to DB_MAX_IDLE_CONNS.", pool.MaxIdleClosed)
+		metric("forgecrud_db_pool_max_idle_time_closed_total", "counter", "Connections closed due to DB_CONN_MAX_IDLE_TIME_MINUTES.", pool.MaxIdleTimeClosed)
+		metric("forgecrud_db_pool_max_lifetime_closed_total", "counter", "Connections closed due to DB_CONN_MAX_LIFETIME_MINUTES.", pool.MaxLifetimeClosed)
+		break
+	}
+
+	// Calls to the other services
+	if stats := clients.Stats(); len(stats) > 0 {
+		counters := []struct {
+			name, help string
+			value      func(clients.TargetStats) interface{}
+		}{
+			{"forgecrud_http_client_requests_total", "Attempted calls to the service, retries included.", func(s clients.TargetStats) interface{} { return s.Requests }},
+			{"forgecrud_http_client_failures_total", "Attempts without an answer or answered with 502, 503 or 504.", func(s clients.TargetStats) interface{} { return s.Failures }},
+			{"forgecrud_http_client_retries_total", "Retried calls.", func(s clients.TargetStats) interface{} { return s.Retries }},
+			{"forgecrud_http_client_rejected_total", "Calls failed at once by the open circuit breaker.", func(s clients.TargetStats) interface{} { return s.Rejected }},
+			{"forgecrud_http_client_latency_ms_total", "Total time waited for answers in milliseconds.", func(s clients.TargetStats) interface{} { return fmt.Sprintf("%.3f", s.LatencyMs) }},
+		}
+		for _, counter := range counters {
+			header(counter.name, "counter", counter.help)
+			for _, target := range stats {
+				fmt.Fprintf(&b, "%s{%s,target=%q} %v\n", counter.name, service, target.Service, counter.value(target))
+			}
+		}
+		header("forgecrud_http_client_circuit_open", "gauge", "Whether the circuit breaker of the service is open or half-open.")
+		for _, target := range stats {
+			fmt.Fprintf(&b, "forgecrud_http_client_circuit_open{%s,target=%q} %d\n", service, target.Service, boolValue(target.Circuit != clients.CircuitClosed))
+		}
+	}
+
+	return b.String()
+}
+
+func boolValue(value bool) int {
+	if value {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,162 @@
+// Package i18n translates user facing texts, such as email templates and notification titles, into
+// the recipient's language. Translations are loaded from one JSON catalog per locale, e.g.
+// shared/locales/tr.json, mapping message keys to fmt format strings.
+package i18n
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultLocale is used when neither the request nor the recipient selects a supported language
+var DefaultLocale = "en"
+
+var (
+	catalogs   = map[string]map[string]string{} // locale -> key -> message
+	catalogsMu sync.RWMutex
+)
+
+// Load reads the catalogs of a directory, one <locale>.json file per locale. A missing directory
+// leaves the catalogs empty, texts are then rendered as their keys.
+func Load(dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+
+	loaded := map[string]map[string]string{}
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		messages := map[string]string{}
+		if err := json.Unmarshal(content, &messages); err != nil {
+			return fmt.Errorf("translation catalog %s: %v", filepath.Base(file), err)
+		}
+		loaded[NormalizeLocale(strings.TrimSuffix(filepath.Base(file), ".json"))] = messages
+	}
+
+	catalogsMu.Lock()
+	catalogs = loaded
+	catalogsMu.Unlock()
+
+	log.Printf("🌐 Loaded translation catalogs: %s", strings.Join(SupportedLocales(), ", "))
+	return nil
+}
+
+// NormalizeLocale turns tags like "pt_br" or "PT-BR" into "pt-BR"; empty input stays empty
+func NormalizeLocale(locale string) string {
+	locale = strings.TrimSpace(strings.ReplaceAll(locale, "_", "-"))
+	if locale == "" {
+		return ""
+	}
+	language, region, found := strings.Cut(locale, "-")
+	if !found {
+		return strings.ToLower(language)
+	}
+	return strings.ToLower(language) + "-" + strings.ToUpper(region)
+}
+
+// Fallbacks returns the locales tried for a locale, most specific first: "pt-BR" falls back to
+// "pt" and then to DefaultLocale
+func Fallbacks(locale string) []string {
+	locale = NormalizeLocale(locale)
+
+	chain := []string{}
+	add := func(candidate string) {
+		for _, existing := range chain {
+			if existing == candidate {
+				return
+			}
+		}
+		if candidate != "" {
+			chain = append(chain, candidate)
+		}
+	}
+
+	add(locale)
+	if language, _, found := strings.Cut(locale, "-"); found {
+		add(language)
+	}
+	add(NormalizeLocale(DefaultLocale))
+	return chain
+}
+
+// Resolve returns the first of the locales that has a catalog, DefaultLocale when none has
+func Resolve(locales ...string) string {
+	catalogsMu.RLock()
+	defer catalogsMu.RUnlock()
+
+	for _, locale := range locales {
+		if locale = NormalizeLocale(locale); locale == "" {
+			continue
+		}
+		for _, candidate := range Fallbacks(locale) {
+			if _, ok := catalogs[candidate]; ok {
+				return candidate
+			}
+		}
+	}
+	return NormalizeLocale(DefaultLocale)
+}
+
+// IsSupported reports whether texts of the locale, or of its language, can be translated
+func IsSupported(locale string) bool {
+	catalogsMu.RLock()
+	defer catalogsMu.RUnlock()
+
+	locale = NormalizeLocale(locale)
+	if _, ok := catalogs[locale]; ok {
+		return true
+	}
+	language, _, _ := strings.Cut(locale, "-")
+	_, ok := catalogs[language]
+	return ok
+}
+
+// SupportedLocales lists the locales with a catalog
+func SupportedLocales() []string {
+	catalogsMu.RLock()
+	defer catalogsMu.RUnlock()
+
+	locales := make([]string, 0, len(catalogs))
+	for locale := range catalogs {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// T translates the message key into the locale, formatting args into it. Keys missing from the
+// locale are looked up along its fallbacks; unknown keys are returned as they are.
+func T(locale, key string, args ...interface{}) string {
+	message, found := Lookup(locale, key)
+	if !found {
+		message = key
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(message, args...)
+	}
+	return message
+}
+
+// Lookup returns the unformatted message of the key in the locale or its fallbacks, and whether
+// any catalog has it
+func Lookup(locale, key string) (string, bool) {
+	catalogsMu.RLock()
+	defer catalogsMu.RUnlock()
+
+	for _, candidate := range Fallbacks(locale) {
+		if message, found := catalogs[candidate][key]; found {
+			return message, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,156 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Priority selects the pending list of a job; workers always take critical jobs first, then default, then low
+type Priority string
+
+const (
+	PriorityCritical Priority = "critical"
+	PriorityDefault  Priority = "default"
+	PriorityLow      Priority = "low"
+)
+
+// Priorities lists the priorities in the order workers take jobs
+var Priorities = []Priority{PriorityCritical, PriorityDefault, PriorityLow}
+
+// Valid reports whether p is a known priority
+func (p Priority) Valid() bool {
+	for _, priority := range Priorities {
+		if p == priority {
+			return true
+		}
+	}
+	return false
+}
+
+// Job states listed by the dashboard
+const (
+	StatePending   = "pending"
+	StateScheduled = "scheduled"
+	StateActive    = "active"
+	StateDead      = "dead"
+)
+
+// States lists the job states
+var States = []string{StatePending, StateScheduled, StateActive, StateDead}
+
+const (
+	retryBaseBackoff = 10 * time.Second
+	retryMaxBackoff  = time.Hour
+	// A unique job is not enqueued again until it started or this long passed
+	uniqueTTL = time.Hour
+)
+
+var (
+	// ErrSkipRetry makes a failed job dead at once; wrap it for failures a retry cannot fix, like a malformed payload
+	ErrSkipRetry = errors.New("job cannot succeed, not retrying")
+	// ErrDuplicate is returned by Enqueue when a job with the same unique key is still waiting
+	ErrDuplicate = errors.New("job with the same unique key is already queued")
+	// ErrNotFound is returned for a job that does not exist in the requested state
+	ErrNotFound = errors.New("job not found")
+	// ErrClosed is returned by Enqueue once the queue stopped
+	ErrClosed = errors.New("job queue is closed")
+)
+
+// Job is a unit of background work. The payload is the JSON encoded argument of the handler.
+type Job struct {
+	ID          string          `json:"id"`
+	Type        string          `json:"type"`
+	Payload     json.RawMessage `json:"payload"`
+	Priority    Priority        `json:"priority"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	UniqueKey   string          `json:"unique_key,omitempty"`
+	LastError   string          `json:"last_error,omitempty"`
+	EnqueuedAt  time.Time       `json:"enqueued_at"`
+	RunAt       time.Time       `json:"run_at"`
+	FailedAt    *time.Time      `json:"failed_at,omitempty"`
+}
+
+// Decode unmarshals the payload into v
+func (j *Job) Decode(v interface{}) error {
+	return json.Unmarshal(j.Payload, v)
+}
+
+// Handler runs a job. Jobs are delivered at least once, so handlers must be safe to run again.
+// Returning an error retries the job with exponential backoff until its attempts are used up.
+type Handler func(ctx context.Context, job *Job) error
+
+// Option customizes a job when it is enqueued
+type Option func(*Job)
+
+// WithPriority sets the priority of the job
+func WithPriority(priority Priority) Option {
+	return func(j *Job) { j.Priority = priority }
+}
+
+// WithMaxAttempts sets how often the job is tried before it is dead
+func WithMaxAttempts(attempts int) Option {
+	return func(j *Job) { j.MaxAttempts = attempts }
+}
+
+// WithDelay runs the job no earlier than d from now
+func WithDelay(d time.Duration) Option {
+	return func(j *Job) { j.RunAt = time.Now().Add(d) }
+}
+
+// At runs the job no earlier than t
+func At(t time.Time) Option {
+	return func(j *Job) { j.RunAt = t }
+}
+
+// Unique drops the job with ErrDuplicate while another job with the same key waits to run, so bursts
+// of the same work (e.g. recalculating one folder after many uploads) collapse into one job
+func Unique(key string) Option {
+	return func(j *Job) { j.UniqueKey = key }
+}
+
+// newJob builds a job with the defaults and the options applied
+func newJob(jobType string, payload interface{}, maxAttempts int, opts []Option) (*Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	job := &Job{
+		ID:          uuid.New().String(),
+		Type:        jobType,
+		Payload:     data,
+		Priority:    PriorityDefault,
+		MaxAttempts: maxAttempts,
+		EnqueuedAt:  now,
+		RunAt:       now,
+	}
+	for _, opt := range opts {
+		opt(job)
+	}
+
+	if !job.Priority.Valid() {
+		job.Priority = PriorityDefault
+	}
+	if job.MaxAttempts < 1 {
+		job.MaxAttempts = 1
+	}
+	return job, nil
+}
+
+// retryBackoff is the wait before the next attempt of a job that failed attempts times
+func retryBackoff(attempts int) time.Duration {
+	backoff := retryBaseBackoff
+	for i := 1; i < attempts && backoff < retryMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > retryMaxBackoff {
+		backoff = retryMaxBackoff
+	}
+	return backoff
+}
@@ -0,0 +1,247 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"forgecrud-backend/shared/config"
+)
+
+// Queue stores the jobs of a service and runs them on workers. Every service has its own queue, so a
+// job is only taken by instances of the service that enqueued it.
+type Queue interface {
+	Enqueue(ctx context.Context, job *Job) error
+	// Start runs jobs on the given number of workers until Close
+	Start(handlers map[string]Handler, workers int)
+	// Ping checks that the queue is reachable
+	Ping(ctx context.Context) error
+	// Close stops taking jobs and waits for the running ones until ctx is done
+	Close(ctx context.Context) error
+}
+
+// Inspector lists and manages the jobs of every service, for the dashboard
+type Inspector interface {
+	Stats(ctx context.Context) ([]QueueStats, error)
+	List(ctx context.Context, service, state string, offset, limit int) ([]Job, int64, error)
+	// Retry moves a dead or scheduled job to its pending list
+	Retry(ctx context.Context, service, id string) error
+	// Delete removes a dead or scheduled job
+	Delete(ctx context.Context, service, id string) error
+}
+
+// QueueStats are the job counts of one service
+type QueueStats struct {
+	Service   string             `json:"service"`
+	Pending   map[Priority]int64 `json:"pending"`
+	Scheduled int64              `json:"scheduled"`
+	Active    int64              `json:"active"`
+	Dead      int64              `json:"dead"`
+	Processed map[string]int64   `json:"processed"` // Succeeded jobs by type
+	Failed    map[string]int64   `json:"failed"`    // Failed attempts by type
+}
+
+var (
+	defaultQueue Queue
+	handlers     = map[string]Handler{}
+	queueMutex   sync.RWMutex
+)
+
+// Init creates the process-wide queue of a service for the configured driver
+func Init(service string) error {
+	cfg := config.GetConfig()
+
+	var queue Queue
+	switch cfg.JobQueueDriver {
+	case "redis":
+		redisQueue, err := NewRedisQueue(cfg, service)
+		if err != nil {
+			return err
+		}
+		queue = redisQueue
+	default:
+		queue = newInlineQueue()
+	}
+
+	queueMutex.Lock()
+	defaultQueue = queue
+	queueMutex.Unlock()
+
+	log.Printf("✅ Job queue initialized (driver: %s, service: %s)", cfg.JobQueueDriver, service)
+	return nil
+}
+
+// getQueue returns the process-wide queue, or an inline queue when Init was not called or failed
+func getQueue() Queue {
+	queueMutex.Lock()
+	defer queueMutex.Unlock()
+	if defaultQueue == nil {
+		defaultQueue = newInlineQueue()
+	}
+	return defaultQueue
+}
+
+// Handle registers the handler of a job type; register all handlers before Start
+func Handle(jobType string, handler Handler) {
+	queueMutex.Lock()
+	defer queueMutex.Unlock()
+	handlers[jobType] = handler
+}
+
+// Start runs the registered handlers on JOB_WORKERS workers
+func Start() {
+	queueMutex.RLock()
+	registered := make(map[string]Handler, len(handlers))
+	for jobType, handler := range handlers {
+		registered[jobType] = handler
+	}
+	queueMutex.RUnlock()
+
+	workers := config.GetConfig().JobWorkers
+	getQueue().Start(registered, workers)
+	log.Printf("🧰 Job workers started (workers: %d, types: %d)", workers, len(registered))
+}
+
+// Enqueue queues a job of the given type; the payload is JSON encoded and handed to its handler
+func Enqueue(ctx context.Context, jobType string, payload interface{}, opts ...Option) (*Job, error) {
+	job, err := newJob(jobType, payload, config.GetConfig().JobMaxAttempts, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s job: %v", jobType, err)
+	}
+	if err := getQueue().Enqueue(ctx, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// GetInspector returns the inspector of the process-wide queue, if its driver keeps jobs anywhere
+func GetInspector() (Inspector, bool) {
+	inspector, ok := getQueue().(Inspector)
+	return inspector, ok
+}
+
+// Ping checks that the process-wide queue is reachable
+func Ping(ctx context.Context) error {
+	return getQueue().Ping(ctx)
+}
+
+// Close stops the workers, giving running jobs SHUTDOWN_TIMEOUT_SECONDS to finish. Jobs still running
+// then are handed back to the queue and run again by another instance.
+func Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(config.GetConfig().ShutdownTimeoutSeconds)*time.Second)
+	defer cancel()
+	return getQueue().Close(ctx)
+}
+
+// inlineQueue runs jobs in goroutines of the enqueuing process, with retries but without persistence;
+// used when the job queue is disabled
+type inlineQueue struct {
+	mutex    sync.Mutex
+	handlers map[string]Handler
+	unique   map[string]bool
+	stopping chan struct{}
+	stopOnce sync.Once
+	ctx      context.Context // Canceled when running jobs did not finish in time on Close
+	cancel   context.CancelFunc
+	running  sync.WaitGroup
+}
+
+func newInlineQueue() *inlineQueue {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &inlineQueue{
+		handlers: map[string]Handler{},
+		unique:   map[string]bool{},
+		stopping: make(chan struct{}),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+func (q *inlineQueue) Enqueue(ctx context.Context, job *Job) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	select {
+	case <-q.stopping:
+		return ErrClosed
+	default:
+	}
+
+	if job.UniqueKey != "" {
+		if q.unique[job.UniqueKey] {
+			return ErrDuplicate
+		}
+		q.unique[job.UniqueKey] = true
+	}
+
+	q.running.Add(1)
+	go q.run(job)
+	return nil
+}
+
+// run waits until the job is due and tries it until it succeeds or its attempts are used up
+func (q *inlineQueue) run(job *Job) {
+	defer q.running.Done()
+
+	wait := time.Until(job.RunAt)
+	for {
+		select {
+		case <-q.stopping:
+			log.Printf("⚠️  Dropping %s job %s, the service is stopping", job.Type, job.ID)
+			return
+		case <-time.After(wait):
+		}
+
+		q.mutex.Lock()
+		if job.Attempts == 0 && job.UniqueKey != "" {
+			delete(q.unique, job.UniqueKey)
+		}
+		handler, ok := q.handlers[job.Type]
+		q.mutex.Unlock()
+
+		job.Attempts++
+		err := fmt.Errorf("no handler for job type %s", job.Type)
+		if ok {
+			err = handler(q.ctx, job)
+		}
+		if err == nil {
+			return
+		}
+
+		if job.Attempts >= job.MaxAttempts || errors.Is(err, ErrSkipRetry) {
+			log.Printf("❌ %s job %s failed after %d attempts: %v", job.Type, job.ID, job.Attempts, err)
+			return
+		}
+		job.LastError = err.Error()
+		wait = retryBackoff(job.Attempts)
+	}
+}
+
+func (q *inlineQueue) Start(handlers map[string]Handler, workers int) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.handlers = handlers
+}
+
+func (q *inlineQueue) Ping(ctx context.Context) error { return nil }
+
+func (q *inlineQueue) Close(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		q.running.Wait()
+		close(done)
+	}()
+
+	// Jobs waiting for their run time or a retry are dropped at once, running ones may finish
+	q.stopOnce.Do(func() { close(q.stopping) })
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		q.cancel()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,694 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"forgecrud-backend/shared/config"
+	"forgecrud-backend/shared/readonly"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// An idle worker looks for new jobs this often
+	redisPollInterval = time.Second
+	// Due scheduled jobs and jobs of stopped workers are moved to their pending list this often
+	redisMaintainInterval = time.Second
+	redisMaintainBatch    = 100
+)
+
+// dequeueScript pops the next job of the highest priority and leases it to the calling worker.
+// KEYS are the pending lists from the highest priority down, then the active set; ARGV[1] is the lease deadline.
+var dequeueScript = redis.NewScript(`
+for i = 1, #KEYS - 1 do
+	local id = redis.call('RPOP', KEYS[i])
+	if id then
+		redis.call('ZADD', KEYS[#KEYS], ARGV[1], id)
+		return id
+	end
+end
+return false
+`)
+
+// requeueScript moves the jobs of a sorted set whose score passed to the pending list of their priority.
+// KEYS[1] is the set; ARGV are the current time, the job key prefix, the pending key prefix and the batch size.
+var requeueScript = redis.NewScript(`
+local ids = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[4])
+for _, id in ipairs(ids) do
+	redis.call('ZREM', KEYS[1], id)
+	local data = redis.call('GET', ARGV[2] .. id)
+	if data then
+		redis.call('LPUSH', ARGV[3] .. cjson.decode(data)['priority'], id)
+	end
+end
+return #ids
+`)
+
+// RedisQueue implements Queue and Inspector on Redis. A job is stored under its ID and referenced
+// from one of the pending lists (one per priority), the scheduled set (by run time), the active set
+// (by lease deadline) or the dead set (by failure time). Workers renew the lease of running jobs, so
+// the jobs of a crashed instance are run again once their lease expired.
+type RedisQueue struct {
+	client        *redis.Client
+	prefix        string
+	service       string
+	lease         time.Duration
+	deadRetention time.Duration
+
+	stopping chan struct{}
+	stopOnce sync.Once
+	ctx      context.Context // Canceled when running jobs did not finish in time on Close
+	cancel   context.CancelFunc
+	workers  sync.WaitGroup
+
+	mutex      sync.Mutex
+	running    map[string]*Job
+	handedBack map[string]bool
+}
+
+// NewRedisQueue connects to the configured Redis server
+func NewRedisQueue(cfg *config.Config, service string) (*RedisQueue, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     fmt.Sprintf("%s:%s", cfg.RedisHost, cfg.RedisPort),
+		Password: cfg.RedisPassword,
+		DB:       cfg.RedisDB,
+	})
+
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		return nil, fmt.Errorf("failed to connect to Redis job queue: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &RedisQueue{
+		client:        client,
+		prefix:        cfg.JobQueuePrefix,
+		service:       service,
+		lease:         time.Duration(cfg.JobLeaseSeconds) * time.Second,
+		deadRetention: time.Duration(cfg.JobDeadRetentionDays) * 24 * time.Hour,
+		stopping:      make(chan struct{}),
+		ctx:           ctx,
+		cancel:        cancel,
+		running:       map[string]*Job{},
+		handedBack:    map[string]bool{},
+	}, nil
+}
+
+// key builds the Redis key of a part of a service's queue
+func (q *RedisQueue) key(service string, parts ...string) string {
+	return q.prefix + ":" + service + ":" + strings.Join(parts, ":")
+}
+
+func (q *RedisQueue) jobKey(service, id string) string {
+	return q.key(service, "job", id)
+}
+
+func (q *RedisQueue) pendingKey(service string, priority Priority) string {
+	return q.key(service, "pending", string(priority))
+}
+
+// servicesKey holds the names of the services running workers
+func (q *RedisQueue) servicesKey() string {
+	return q.prefix + ":services"
+}
+
+// Enqueue stores the job and adds it to its pending list, or to the scheduled set when it runs later
+func (q *RedisQueue) Enqueue(ctx context.Context, job *Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	uniqueKey := ""
+	if job.UniqueKey != "" {
+		uniqueKey = q.key(q.service, "unique", job.UniqueKey)
+		acquired, err := q.client.SetNX(ctx, uniqueKey, job.ID, uniqueTTL).Result()
+		if err != nil {
+			return fmt.Errorf("failed to enqueue %s job: %v", job.Type, err)
+		}
+		if !acquired {
+			return ErrDuplicate
+		}
+	}
+
+	_, err = q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, q.jobKey(q.service, job.ID), data, 0)
+		if job.RunAt.After(time.Now()) {
+			pipe.ZAdd(ctx, q.key(q.service, StateScheduled), redis.Z{Score: float64(job.RunAt.Unix()), Member: job.ID})
+		} else {
+			pipe.LPush(ctx, q.pendingKey(q.service, job.Priority), job.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		if uniqueKey != "" {
+			q.client.Del(ctx, uniqueKey)
+		}
+		return fmt.Errorf("failed to enqueue %s job: %v", job.Type, err)
+	}
+	return nil
+}
+
+// Start registers the service for the dashboard and runs the workers and the maintenance loop
+func (q *RedisQueue) Start(handlers map[string]Handler, workers int) {
+	if err := q.client.SAdd(context.Background(), q.servicesKey(), q.service).Err(); err != nil {
+		log.Printf("⚠️  Failed to register job queue of %s: %v", q.service, err)
+	}
+
+	q.workers.Add(workers + 1)
+	for i := 0; i < workers; i++ {
+		go q.work(handlers)
+	}
+	go q.maintain()
+}
+
+// work takes and runs jobs until the queue stops
+func (q *RedisQueue) work(handlers map[string]Handler) {
+	defer q.workers.Done()
+
+	for {
+		select {
+		case <-q.stopping:
+			return
+		default:
+		}
+
+		// Jobs of a read-only service stay queued until the mode ends
+		var job *Job
+		if !readonly.Enabled(q.service) {
+			var err error
+			if job, err = q.dequeue(); err != nil {
+				log.Printf("⚠️  Failed to take a job of %s: %v", q.service, err)
+			}
+		}
+		if job == nil {
+			select {
+			case <-q.stopping:
+				return
+			case <-time.After(redisPollInterval):
+			}
+			continue
+		}
+
+		q.process(job, handlers)
+	}
+}
+
+// dequeue leases the next pending job and counts the attempt, or returns nil when none is pending
+func (q *RedisQueue) dequeue() (*Job, error) {
+	ctx := context.Background()
+
+	keys := make([]string, 0, len(Priorities)+1)
+	for _, priority := range Priorities {
+		keys = append(keys, q.pendingKey(q.service, priority))
+	}
+	keys = append(keys, q.key(q.service, StateActive))
+
+	id, err := dequeueScript.Run(ctx, q.client, keys, time.Now().Add(q.lease).Unix()).Text()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := q.client.Get(ctx, q.jobKey(q.service, id)).Bytes()
+	if err != nil {
+		// Completed or handed back by a worker whose lease had expired
+		q.client.ZRem(ctx, q.key(q.service, StateActive), id)
+		if errors.Is(err, redis.Nil) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var job Job
+	if err := json.Unmarshal(data, &job); err != nil {
+		q.client.ZRem(ctx, q.key(q.service, StateActive), id)
+		return nil, fmt.Errorf("dropping malformed job %s: %v", id, err)
+	}
+
+	// The job may be queued again from now on
+	if job.UniqueKey != "" {
+		q.client.Del(ctx, q.key(q.service, "unique", job.UniqueKey))
+	}
+
+	// Count the attempt before running it, so a job crashing its worker does not run forever
+	job.Attempts++
+	if err := q.save(ctx, q.client, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// process runs a leased job and records its outcome
+func (q *RedisQueue) process(job *Job, handlers map[string]Handler) {
+	q.mutex.Lock()
+	q.running[job.ID] = job
+	q.mutex.Unlock()
+
+	var err error
+	if job.Attempts > job.MaxAttempts {
+		err = fmt.Errorf("worker stopped during the last attempt: %w", ErrSkipRetry)
+	} else if handler, ok := handlers[job.Type]; ok {
+		stopRenewing := q.renewLease(job.ID)
+		err = runHandler(q.ctx, handler, job)
+		stopRenewing()
+	} else {
+		err = fmt.Errorf("no handler for job type %s", job.Type)
+	}
+
+	q.mutex.Lock()
+	delete(q.running, job.ID)
+	handedBack := q.handedBack[job.ID]
+	q.mutex.Unlock()
+	if handedBack {
+		return
+	}
+
+	if err == nil {
+		err = q.complete(job)
+	} else {
+		err = q.fail(job, err)
+	}
+	if err != nil {
+		log.Printf("⚠️  Failed to record the outcome of %s job %s: %v", job.Type, job.ID, err)
+	}
+}
+
+// runHandler runs the handler, turning a panic into an error
+func runHandler(ctx context.Context, handler Handler, job *Job) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("handler panicked: %v", recovered)
+		}
+	}()
+	return handler(ctx, job)
+}
+
+// renewLease extends the lease of a running job until the returned function is called
+func (q *RedisQueue) renewLease(id string) func() {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(q.lease / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				deadline := float64(time.Now().Add(q.lease).Unix())
+				if err := q.client.ZAddXX(context.Background(), q.key(q.service, StateActive), redis.Z{Score: deadline, Member: id}).Err(); err != nil {
+					log.Printf("⚠️  Failed to renew the lease of job %s: %v", id, err)
+				}
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+// complete removes a succeeded job
+func (q *RedisQueue) complete(job *Job) error {
+	ctx := context.Background()
+	_, err := q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRem(ctx, q.key(q.service, StateActive), job.ID)
+		pipe.Del(ctx, q.jobKey(q.service, job.ID))
+		pipe.HIncrBy(ctx, q.key(q.service, "processed"), job.Type, 1)
+		return nil
+	})
+	return err
+}
+
+// fail schedules the next attempt of a failed job with exponential backoff, or moves it to the dead
+// set once its attempts are used up
+func (q *RedisQueue) fail(job *Job, cause error) error {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	job.LastError = cause.Error()
+
+	dead := job.Attempts >= job.MaxAttempts || errors.Is(cause, ErrSkipRetry)
+	if dead {
+		job.FailedAt = &now
+		log.Printf("❌ %s job %s failed after %d attempts: %v", job.Type, job.ID, job.Attempts, cause)
+	} else {
+		job.RunAt = now.Add(retryBackoff(job.Attempts))
+		log.Printf("⚠️  %s job %s failed (attempt %d/%d), retrying at %s: %v",
+			job.Type, job.ID, job.Attempts, job.MaxAttempts, job.RunAt.Format(time.RFC3339), cause)
+	}
+
+	_, err := q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRem(ctx, q.key(q.service, StateActive), job.ID)
+		if err := q.save(ctx, pipe, job); err != nil {
+			return err
+		}
+		if dead {
+			pipe.ZAdd(ctx, q.key(q.service, StateDead), redis.Z{Score: float64(now.Unix()), Member: job.ID})
+		} else {
+			pipe.ZAdd(ctx, q.key(q.service, StateScheduled), redis.Z{Score: float64(job.RunAt.Unix()), Member: job.ID})
+		}
+		pipe.HIncrBy(ctx, q.key(q.service, "failed"), job.Type, 1)
+		return nil
+	})
+	return err
+}
+
+// save stores the job under its ID
+func (q *RedisQueue) save(ctx context.Context, client redis.Cmdable, job *Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return client.Set(ctx, q.jobKey(q.service, job.ID), data, 0).Err()
+}
+
+// maintain moves due scheduled jobs and jobs with an expired lease to their pending lists, and
+// removes dead jobs past their retention
+func (q *RedisQueue) maintain() {
+	defer q.workers.Done()
+
+	ticker := time.NewTicker(redisMaintainInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-q.stopping:
+			return
+		case <-ticker.C:
+		}
+
+		ctx := context.Background()
+		now := time.Now()
+		for _, state := range []string{StateScheduled, StateActive} {
+			moved, err := requeueScript.Run(ctx, q.client, []string{q.key(q.service, state)},
+				now.Unix(), q.key(q.service, "job", ""), q.key(q.service, "pending", ""), redisMaintainBatch).Int()
+			if err != nil {
+				log.Printf("⚠️  Failed to requeue %s jobs of %s: %v", state, q.service, err)
+			} else if moved > 0 && state == StateActive {
+				log.Printf("♻️  Requeued %d jobs of %s whose worker stopped", moved, q.service)
+			}
+		}
+
+		if err := q.purgeDead(ctx, now.Add(-q.deadRetention)); err != nil {
+			log.Printf("⚠️  Failed to purge dead jobs of %s: %v", q.service, err)
+		}
+	}
+}
+
+// purgeDead removes a batch of dead jobs that failed before the cutoff
+func (q *RedisQueue) purgeDead(ctx context.Context, cutoff time.Time) error {
+	deadKey := q.key(q.service, StateDead)
+	ids, err := q.client.ZRangeByScore(ctx, deadKey, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   fmt.Sprint(cutoff.Unix()),
+		Count: redisMaintainBatch,
+	}).Result()
+	if err != nil || len(ids) == 0 {
+		return err
+	}
+
+	_, err = q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, id := range ids {
+			pipe.ZRem(ctx, deadKey, id)
+			pipe.Del(ctx, q.jobKey(q.service, id))
+		}
+		return nil
+	})
+	return err
+}
+
+// Ping checks the Redis connection
+func (q *RedisQueue) Ping(ctx context.Context) error {
+	return q.client.Ping(ctx).Err()
+}
+
+// Close stops taking jobs and waits for the running ones until ctx is done. Jobs still running then
+// are handed back to their pending list for another instance, and the connection is closed.
+func (q *RedisQueue) Close(ctx context.Context) error {
+	q.stopOnce.Do(func() { close(q.stopping) })
+
+	done := make(chan struct{})
+	go func() {
+		q.workers.Wait()
+		close(done)
+	}()
+
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		err = q.handBack()
+		q.cancel()
+	}
+
+	if closeErr := q.client.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// handBack returns the running jobs to the front of their pending lists
+func (q *RedisQueue) handBack() error {
+	q.mutex.Lock()
+	jobs := make([]*Job, 0, len(q.running))
+	for id, job := range q.running {
+		q.handedBack[id] = true
+		jobs = append(jobs, job)
+	}
+	q.mutex.Unlock()
+
+	if len(jobs) == 0 {
+		return nil
+	}
+
+	ctx := context.Background()
+	_, err := q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, job := range jobs {
+			// The interrupted attempt does not count
+			job.Attempts--
+			if err := q.save(ctx, pipe, job); err != nil {
+				return err
+			}
+			pipe.ZRem(ctx, q.key(q.service, StateActive), job.ID)
+			pipe.RPush(ctx, q.pendingKey(q.service, job.Priority), job.ID)
+		}
+		return nil
+	})
+	if err == nil {
+		log.Printf("♻️  Handed %d running jobs of %s back to the queue", len(jobs), q.service)
+	}
+	return err
+}
+
+// Stats returns the job counts of every service running workers
+func (q *RedisQueue) Stats(ctx context.Context) ([]QueueStats, error) {
+	services, err := q.client.SMembers(ctx, q.servicesKey()).Result()
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(services)
+
+	stats := make([]QueueStats, 0, len(services))
+	for _, service := range services {
+		pipe := q.client.Pipeline()
+		pending := make(map[Priority]*redis.IntCmd, len(Priorities))
+		for _, priority := range Priorities {
+			pending[priority] = pipe.LLen(ctx, q.pendingKey(service, priority))
+		}
+		scheduled := pipe.ZCard(ctx, q.key(service, StateScheduled))
+		active := pipe.ZCard(ctx, q.key(service, StateActive))
+		dead := pipe.ZCard(ctx, q.key(service, StateDead))
+		processed := pipe.HGetAll(ctx, q.key(service, "processed"))
+		failed := pipe.HGetAll(ctx, q.key(service, "failed"))
+		if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+			return nil, err
+		}
+
+		stat := QueueStats{
+			Service:   service,
+			Pending:   make(map[Priority]int64, len(Priorities)),
+			Scheduled: scheduled.Val(),
+			Active:    active.Val(),
+			Dead:      dead.Val(),
+			Processed: countsByType(processed.Val()),
+			Failed:    countsByType(failed.Val()),
+		}
+		for priority, cmd := range pending {
+			stat.Pending[priority] = cmd.Val()
+		}
+		stats = append(stats, stat)
+	}
+	return stats, nil
+}
+
+// countsByType parses the counters of a stats hash
+func countsByType(values map[string]string) map[string]int64 {
+	counts := make(map[string]int64, len(values))
+	for jobType, value := range values {
+		var count int64
+		fmt.Sscan(value, &count)
+		counts[jobType] = count
+	}
+	return counts
+}
+
+// List returns a page of the jobs of a service in a state: pending jobs by priority, scheduled and
+// active jobs by time, dead jobs most recent first
+func (q *RedisQueue) List(ctx context.Context, service, state string, offset, limit int) ([]Job, int64, error) {
+	if err := q.checkService(ctx, service); err != nil {
+		return nil, 0, err
+	}
+
+	var ids []string
+	var total int64
+	start, stop := int64(offset), int64(offset+limit-1)
+
+	switch state {
+	case StatePending:
+		for _, priority := range Priorities {
+			key := q.pendingKey(service, priority)
+			length, err := q.client.LLen(ctx, key).Result()
+			if err != nil {
+				return nil, 0, err
+			}
+			if start < total+length && stop >= total {
+				// The next job to run is at the tail of the list
+				from, to := -1-(stop-total), -1-max(start-total, 0)
+				page, err := q.client.LRange(ctx, key, from, to).Result()
+				if err != nil {
+					return nil, 0, err
+				}
+				for i := len(page) - 1; i >= 0; i-- {
+					ids = append(ids, page[i])
+				}
+			}
+			total += length
+		}
+	case StateScheduled, StateActive, StateDead:
+		key := q.key(service, state)
+		var err error
+		if total, err = q.client.ZCard(ctx, key).Result(); err != nil {
+			return nil, 0, err
+		}
+		if state == StateDead {
+			ids, err = q.client.ZRevRange(ctx, key, start, stop).Result()
+		} else {
+			ids, err = q.client.ZRange(ctx, key, start, stop).Result()
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+	default:
+		return nil, 0, fmt.Errorf("unknown job state %q", state)
+	}
+
+	jobs := make([]Job, 0, len(ids))
+	if len(ids) == 0 {
+		return jobs, total, nil
+	}
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = q.jobKey(service, id)
+	}
+	values, err := q.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			continue
+		}
+		var job Job
+		if err := json.Unmarshal([]byte(data), &job); err == nil {
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs, total, nil
+}
+
+// Retry moves a dead or scheduled job to the front of its pending list; a dead job gets all its
+// attempts again
+func (q *RedisQueue) Retry(ctx context.Context, service, id string) error {
+	if err := q.checkService(ctx, service); err != nil {
+		return err
+	}
+
+	state, err := q.takeWaiting(ctx, service, id)
+	if err != nil {
+		return err
+	}
+
+	data, err := q.client.Get(ctx, q.jobKey(service, id)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	var job Job
+	if err := json.Unmarshal(data, &job); err != nil {
+		return err
+	}
+	if state == StateDead {
+		job.Attempts = 0
+		job.FailedAt = nil
+	}
+	job.RunAt = time.Now().UTC()
+
+	if data, err = json.Marshal(job); err != nil {
+		return err
+	}
+	_, err = q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, q.jobKey(service, id), data, 0)
+		pipe.RPush(ctx, q.pendingKey(service, job.Priority), id)
+		return nil
+	})
+	return err
+}
+
+// Delete removes a dead or scheduled job
+func (q *RedisQueue) Delete(ctx context.Context, service, id string) error {
+	if err := q.checkService(ctx, service); err != nil {
+		return err
+	}
+	if _, err := q.takeWaiting(ctx, service, id); err != nil {
+		return err
+	}
+	return q.client.Del(ctx, q.jobKey(service, id)).Err()
+}
+
+// takeWaiting removes a job from the dead or the scheduled set and returns the state it was in
+func (q *RedisQueue) takeWaiting(ctx context.Context, service, id string) (string, error) {
+	for _, state := range []string{StateDead, StateScheduled} {
+		removed, err := q.client.ZRem(ctx, q.key(service, state), id).Result()
+		if err != nil {
+			return "", err
+		}
+		if removed > 0 {
+			return state, nil
+		}
+	}
+	return "", ErrNotFound
+}
+
+// checkService fails with ErrNotFound for a service that never ran workers
+func (q *RedisQueue) checkService(ctx context.Context, service string) error {
+	known, err := q.client.SIsMember(ctx, q.servicesKey(), service).Result()
+	if err != nil {
+		return err
+	}
+	if !known {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package messaging
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"forgecrud-backend/shared/config"
+
+	"github.com/google/uuid"
+)
+
+// Handler processes a consumed event. Returning an error retries the event.
+type Handler func(ctx context.Context, event Event) error
+
+// Bus publishes domain events and delivers them to consumer groups.
+// Every consumer group receives each event once; instances of the same group share the load.
+type Bus interface {
+	Publish(ctx context.Context, event Event) error
+	Subscribe(ctx context.Context, group string, handler Handler, eventTypes ...string) error
+	// Ping checks that the bus is reachable
+	Ping(ctx context.Context) error
+	Close() error
+}
+
+var (
+	defaultBus    Bus
+	defaultSource string
+	busMutex      sync.RWMutex
+)
+
+// Init creates the process-wide bus for the configured driver. source names the publishing service.
+func Init(source string) error {
+	cfg := config.GetConfig()
+
+	var bus Bus
+	switch cfg.EventBusDriver {
+	case "redis":
+		redisBus, err := NewRedisBus(cfg)
+		if err != nil {
+			return err
+		}
+		bus = redisBus
+	default:
+		bus = noopBus{}
+	}
+
+	busMutex.Lock()
+	defaultBus = bus
+	defaultSource = source
+	busMutex.Unlock()
+
+	log.Printf("✅ Event bus initialized (driver: %s, source: %s)", cfg.EventBusDriver, source)
+	return nil
+}
+
+// GetBus returns the process-wide bus, or a no-op bus when Init was not called or failed
+func GetBus() Bus {
+	busMutex.RLock()
+	defer busMutex.RUnlock()
+	if defaultBus == nil {
+		return noopBus{}
+	}
+	return defaultBus
+}
+
+// Publish publishes an event on the process-wide bus. Failures are logged and never
+// returned, so publishing cannot break the request that triggered it.
+func Publish(ctx context.Context, eventType string, actorID *uuid.UUID, data interface{}) {
+	busMutex.RLock()
+	source := defaultSource
+	busMutex.RUnlock()
+
+	event, err := NewEvent(eventType, source, actorID, data)
+	if err != nil {
+		log.Printf("⚠️  Failed to encode %s event: %v", eventType, err)
+		return
+	}
+
+	if err := GetBus().Publish(ctx, event); err != nil {
+		log.Printf("⚠️  Failed to publish %s event: %v", eventType, err)
+	}
+}
+
+// Subscribe registers a consumer on the process-wide bus
+func Subscribe(ctx context.Context, group string, handler Handler, eventTypes ...string) error {
+	return GetBus().Subscribe(ctx, group, handler, eventTypes...)
+}
+
+// Ping checks that the process-wide bus is reachable
+func Ping(ctx context.Context) error {
+	return GetBus().Ping(ctx)
+}
+
+// Close closes the process-wide bus
+func Close() error {
+	return GetBus().Close()
+}
+
+// noopBus drops published events; used when the event bus is disabled
+type noopBus struct{}
+
+func (noopBus) Publish(ctx context.Context, event Event) error { return nil }
+
+func (noopBus) Subscribe(ctx context.Context, group string, handler Handler, eventTypes ...string) error {
+	return nil
+}
+
+func (noopBus) Ping(ctx context.Context) error { return nil }
+
+func (noopBus) Close() error { return nil }
@@ -0,0 +1,119 @@
+package messaging
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Domain event types. Entity lifecycle events share their names with webhook events.
+const (
+	EventUserCreated               = "user.created"
+	EventUserUpdated               = "user.updated"
+	EventUserDeleted               = "user.deleted"
+	EventUserRoleChanged           = "user.role_changed"
+	EventUserOffboarded            = "user.offboarded"
+	EventRoleCreated               = "role.created"
+	EventRoleUpdated               = "role.updated"
+	EventRoleDeleted               = "role.deleted"
+	EventOrganizationCreated       = "organization.created"
+	EventOrganizationUpdated       = "organization.updated"
+	EventOrganizationDeleted       = "organization.deleted"
+	EventOrganizationAPIQuota      = "organization.api_quota_threshold"
+	EventOrganizationJoinRequested = "organization.join_requested"
+	EventOrganizationJoinReviewed  = "organization.join_reviewed"
+	EventTeamMembersChanged        = "team.members_changed"
+	EventPermissionCreated         = "permission.created"
+	EventPermissionUpdated         = "permission.updated"
+	EventPermissionDeleted         = "permission.deleted"
+	EventDocumentUploaded          = "document.uploaded"
+	EventDocumentDeleted           = "document.deleted"
+	EventDocumentInfected          = "document.infected"
+	EventFolderDeleted             = "folder.deleted"
+	EventFolderChanged             = "folder.changed" // Documents added, updated or deleted in a watched folder
+
+	EventSecurityNewDeviceLogin  = "security.new_device_login"
+	EventSecurityPasswordChanged = "security.password_changed"
+)
+
+// Event is the envelope published on the bus
+type Event struct {
+	ID         uuid.UUID       `json:"id"`
+	Type       string          `json:"type"`
+	Source     string          `json:"source"` // Publishing service
+	ActorID    *uuid.UUID      `json:"actor_id,omitempty"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// NewEvent builds an event with the given payload encoded as JSON
+func NewEvent(eventType, source string, actorID *uuid.UUID, data interface{}) (Event, error) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, err
+	}
+
+	return Event{
+		ID:         uuid.New(),
+		Type:       eventType,
+		Source:     source,
+		ActorID:    actorID,
+		OccurredAt: time.Now().UTC(),
+		Data:       encoded,
+	}, nil
+}
+
+// Decode unmarshals the event payload into v
+func (e Event) Decode(v interface{}) error {
+	return json.Unmarshal(e.Data, v)
+}
+
+// EntityRef is the subset of an entity payload consumers need to resolve whose permissions are affected.
+// User, role, organization and permission payloads all decode into it.
+type EntityRef struct {
+	ID             uuid.UUID  `json:"id"`
+	Target         string     `json:"target,omitempty"`
+	UserID         *uuid.UUID `json:"user_id,omitempty"`
+	TeamID         *uuid.UUID `json:"team_id,omitempty"`
+	RoleID         *uuid.UUID `json:"role_id,omitempty"`
+	OrganizationID *uuid.UUID `json:"organization_id,omitempty"`
+	Previous       *EntityRef `json:"previous,omitempty"` // Permission target before an update
+}
+
+// TeamMembersChangedData is the payload of EventTeamMembersChanged
+type TeamMembersChangedData struct {
+	TeamID  uuid.UUID   `json:"team_id"`
+	UserIDs []uuid.UUID `json:"user_ids"`
+}
+
+// ActivityChange describes a single field change in an ActivityData payload
+type ActivityChange struct {
+	Field    string `json:"field"`
+	OldValue string `json:"old_value"`
+	NewValue string `json:"new_value"`
+}
+
+// ActivityData is the payload of user-facing activity events (document and folder deletion, changes in watched folders, infected uploads, role changes,
+// API quota thresholds, organization join requests, offboarding handovers) that the notification service turns into emails
+// and in-app notifications. How an action is presented, e.g. its translated name and priority label, is up to the notification service and its templates.
+type ActivityData struct {
+	OwnerID      uuid.UUID              `json:"owner_id"`
+	ActionType   string                 `json:"action_type"` // Name of the action when the translation catalogs have none
+	ResourceType string                 `json:"resource_type"`
+	ResourceID   uuid.UUID              `json:"resource_id"`
+	ResourceName string                 `json:"resource_name"`
+	Description  string                 `json:"description"`
+	Priority     string                 `json:"priority"` // low, normal, high or urgent
+	IPAddress    string                 `json:"ip_address"`
+	Changes      []ActivityChange       `json:"changes,omitempty"`
+	Data         map[string]interface{} `json:"data,omitempty"` // Extra variables of the action's email template, as .Data
+}
+
+// SecurityAlertData is the payload of security events the notification service alerts users about,
+// by SMS when their phone is verified
+type SecurityAlertData struct {
+	UserID    uuid.UUID `json:"user_id"`
+	IPAddress string    `json:"ip_address"`
+	UserAgent string    `json:"user_agent,omitempty"`
+}
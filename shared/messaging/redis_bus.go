@@ -0,0 +1,162 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"forgecrud-backend/shared/config"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	redisEventField     = "event"
+	redisReadBlock      = 5 * time.Second
+	redisReadCount      = 50
+	redisHandlerRetries = 3
+)
+
+// RedisBus implements Bus on a Redis stream with one consumer group per subscribing service.
+// Unlike plain pub/sub, events published while a consumer is down are delivered when it comes back.
+type RedisBus struct {
+	client   *redis.Client
+	stream   string
+	maxLen   int64
+	consumer string
+}
+
+// NewRedisBus connects to the configured Redis server
+func NewRedisBus(cfg *config.Config) (*RedisBus, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     fmt.Sprintf("%s:%s", cfg.RedisHost, cfg.RedisPort),
+		Password: cfg.RedisPassword,
+		DB:       cfg.RedisDB,
+	})
+
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		return nil, fmt.Errorf("failed to connect to Redis event bus: %v", err)
+	}
+
+	hostname, _ := os.Hostname()
+
+	return &RedisBus{
+		client:   client,
+		stream:   cfg.EventBusStream,
+		maxLen:   int64(cfg.EventBusMaxLen),
+		consumer: fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+	}, nil
+}
+
+// Publish appends the event to the stream, trimming it to roughly maxLen entries
+func (b *RedisBus) Publish(ctx context.Context, event Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return b.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: b.stream,
+		MaxLen: b.maxLen,
+		Approx: true,
+		Values: map[string]interface{}{redisEventField: payload},
+	}).Err()
+}
+
+// Subscribe creates the consumer group if needed and consumes events in the background until ctx is done.
+// Events not listed in eventTypes are acknowledged without calling the handler; no types means all events.
+func (b *RedisBus) Subscribe(ctx context.Context, group string, handler Handler, eventTypes ...string) error {
+	err := b.client.XGroupCreateMkStream(ctx, b.stream, group, "$").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return fmt.Errorf("failed to create consumer group %s: %v", group, err)
+	}
+
+	wanted := make(map[string]bool, len(eventTypes))
+	for _, eventType := range eventTypes {
+		wanted[eventType] = true
+	}
+
+	go b.consume(ctx, group, handler, wanted)
+
+	log.Printf("📡 Subscribed to %s as group %s", b.stream, group)
+	return nil
+}
+
+func (b *RedisBus) consume(ctx context.Context, group string, handler Handler, wanted map[string]bool) {
+	// Start with events delivered to this consumer but never acknowledged (e.g. after a crash)
+	lastID := "0"
+
+	for ctx.Err() == nil {
+		streams, err := b.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    group,
+			Consumer: b.consumer,
+			Streams:  []string{b.stream, lastID},
+			Count:    redisReadCount,
+			Block:    redisReadBlock,
+		}).Result()
+		if err != nil {
+			if errors.Is(err, redis.Nil) || ctx.Err() != nil {
+				continue
+			}
+			log.Printf("⚠️  Event bus read failed for group %s: %v", group, err)
+			time.Sleep(time.Second)
+			continue
+		}
+
+		received := 0
+		for _, stream := range streams {
+			for _, message := range stream.Messages {
+				received++
+				b.handle(ctx, group, handler, wanted, message)
+			}
+		}
+
+		// Pending backlog drained, switch to new events
+		if lastID == "0" && received == 0 {
+			lastID = ">"
+		}
+	}
+}
+
+func (b *RedisBus) handle(ctx context.Context, group string, handler Handler, wanted map[string]bool, message redis.XMessage) {
+	defer b.client.XAck(ctx, b.stream, group, message.ID)
+
+	raw, _ := message.Values[redisEventField].(string)
+	var event Event
+	if err := json.Unmarshal([]byte(raw), &event); err != nil {
+		log.Printf("⚠️  Dropping malformed event %s: %v", message.ID, err)
+		return
+	}
+
+	if len(wanted) > 0 && !wanted[event.Type] {
+		return
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := handler(ctx, event)
+		if err == nil {
+			return
+		}
+		if attempt >= redisHandlerRetries {
+			log.Printf("❌ Group %s failed to handle %s event %s after %d attempts: %v",
+				group, event.Type, event.ID, attempt, err)
+			return
+		}
+		time.Sleep(time.Duration(attempt) * time.Second)
+	}
+}
+
+// Ping checks the Redis connection
+func (b *RedisBus) Ping(ctx context.Context) error {
+	return b.client.Ping(ctx).Err()
+}
+
+// Close closes the Redis connection
+func (b *RedisBus) Close() error {
+	return b.client.Close()
+}
@@ -0,0 +1,251 @@
+// Package readonly switches the whole platform, or single services, to read-only mode during
+// migrations and incident response. The mode is configured (READ_ONLY_MODE and READ_ONLY_SERVICES,
+// reloadable at runtime) or switched on by super admins through the gateway, which keeps the switch
+// in Redis for every instance to pick up within READ_ONLY_REFRESH_SECONDS. The gateway rejects write
+// requests to read-only services with 503; their scheduler and job workers pause.
+package readonly
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"forgecrud-backend/shared/apperrors"
+	"forgecrud-backend/shared/config"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const requestTimeout = 5 * time.Second
+
+// ErrUnavailable is returned when switching without Redis, where only the configuration applies
+var ErrUnavailable = errors.New("the read-only switch needs READ_ONLY_DRIVER=redis")
+
+// Switch is the read-only mode set at runtime, in addition to the configured one
+type Switch struct {
+	Global    bool      `json:"global"`   // Every service
+	Services  []string  `json:"services"` // Names of read-only services, e.g. core-service
+	Reason    string    `json:"reason,omitempty"`
+	UpdatedBy string    `json:"updated_by,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Enabled reports whether the switch makes the service read-only
+func (s Switch) Enabled(service string) bool {
+	return s.Global || slices.Contains(s.Services, service)
+}
+
+var (
+	ownService string // Service of this process
+	client     *redis.Client
+	key        string
+	current    Switch
+	stopping   chan struct{}
+	mutex      sync.RWMutex
+)
+
+// Init sets the service of this process, connects to the configured driver ("redis" or "none") and
+// follows the switch until Close. Without Redis, only the configured read-only mode applies.
+func Init(name string) error {
+	mutex.Lock()
+	ownService = name
+	mutex.Unlock()
+
+	cfg := config.GetConfig()
+	if cfg.ReadOnlyDriver != "redis" {
+		log.Printf("🔒 Read-only switch disabled, only READ_ONLY_MODE and READ_ONLY_SERVICES apply")
+		return nil
+	}
+
+	redisClient := redis.NewClient(&redis.Options{
+		Addr:     fmt.Sprintf("%s:%s", cfg.RedisHost, cfg.RedisPort),
+		Password: cfg.RedisPassword,
+		DB:       cfg.RedisDB,
+	})
+	if err := redisClient.Ping(context.Background()).Err(); err != nil {
+		redisClient.Close()
+		return fmt.Errorf("failed to connect to Redis for the read-only switch: %v", err)
+	}
+
+	stop := make(chan struct{})
+	mutex.Lock()
+	client = redisClient
+	key = cfg.ReadOnlyKey
+	stopping = stop
+	mutex.Unlock()
+
+	if err := refresh(); err != nil {
+		log.Printf("⚠️  Failed to read the read-only switch: %v", err)
+	}
+	go follow(time.Duration(cfg.ReadOnlyRefreshSeconds)*time.Second, stop)
+
+	log.Printf("✅ Read-only switch enabled (key: %s)", cfg.ReadOnlyKey)
+	return nil
+}
+
+// follow re-reads the switch every interval until stopped. While Redis is unreachable the last
+// switch read stays in place.
+func follow(interval time.Duration, stop chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if err := refresh(); err != nil {
+				log.Printf("⚠️  Failed to refresh the read-only switch: %v", err)
+			}
+		}
+	}
+}
+
+// refresh reads the switch from Redis
+func refresh() error {
+	mutex.RLock()
+	redisClient, redisKey := client, key
+	mutex.RUnlock()
+	if redisClient == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	var next Switch
+	data, err := redisClient.Get(ctx, redisKey).Bytes()
+	switch {
+	case errors.Is(err, redis.Nil):
+	case err != nil:
+		return err
+	default:
+		if err := json.Unmarshal(data, &next); err != nil {
+			return fmt.Errorf("invalid switch: %v", err)
+		}
+	}
+
+	apply(next)
+	return nil
+}
+
+// apply makes the switch current and logs when it changed
+func apply(next Switch) {
+	mutex.Lock()
+	changed := next.Global != current.Global || !slices.Equal(next.Services, current.Services)
+	current = next
+	mutex.Unlock()
+
+	if changed {
+		log.Printf("🔒 Read-only switch changed (global: %t, services: %v)", next.Global, next.Services)
+	}
+}
+
+// Enabled reports whether the service is read-only, by configuration or by the switch
+func Enabled(service string) bool {
+	if config.GetConfig().ReadOnly.Enabled(service) {
+		return true
+	}
+
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return current.Enabled(service)
+}
+
+// Active reports whether the service of this process is read-only. Background writers skip their
+// work while it is.
+func Active() bool {
+	mutex.RLock()
+	name := ownService
+	mutex.RUnlock()
+	return Enabled(name)
+}
+
+// Current returns the switch set at runtime
+func Current() Switch {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return current
+}
+
+// Set stores the switch for every instance and applies it to this one right away. A switch that
+// makes nothing read-only is removed.
+func Set(ctx context.Context, next Switch) error {
+	mutex.RLock()
+	redisClient, redisKey := client, key
+	mutex.RUnlock()
+	if redisClient == nil {
+		return ErrUnavailable
+	}
+
+	next.UpdatedAt = time.Now().UTC()
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), requestTimeout)
+	defer cancel()
+
+	if !next.Global && len(next.Services) == 0 {
+		if err := redisClient.Del(ctx, redisKey).Err(); err != nil {
+			return err
+		}
+	} else {
+		payload, err := json.Marshal(next)
+		if err != nil {
+			return err
+		}
+		if err := redisClient.Set(ctx, redisKey, payload, 0).Err(); err != nil {
+			return err
+		}
+	}
+
+	apply(next)
+	return nil
+}
+
+// Safe reports whether requests with the method only read
+func Safe(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// Error is the response to a write request rejected by a read-only service
+func Error(service string) *apperrors.Error {
+	err := apperrors.New(apperrors.CodeUnavailable, "Service is in read-only mode").
+		WithDetails("Only read requests are served until the read-only mode ends").
+		With("service", service)
+	if reason := Current().Reason; reason != "" {
+		err = err.With("reason", reason)
+	}
+	return err
+}
+
+// Ping checks the connection to Redis; without Redis there is nothing to check
+func Ping(ctx context.Context) error {
+	mutex.RLock()
+	redisClient := client
+	mutex.RUnlock()
+	if redisClient == nil {
+		return nil
+	}
+	return redisClient.Ping(ctx).Err()
+}
+
+// Close stops following the switch and closes the connection to Redis. The last switch read stays
+// in place.
+func Close() error {
+	mutex.Lock()
+	redisClient, stop := client, stopping
+	client, stopping = nil, nil
+	mutex.Unlock()
+
+	if stop != nil {
+		close(stop)
+	}
+	if redisClient == nil {
+		return nil
+	}
+	return redisClient.Close()
+}
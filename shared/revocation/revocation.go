@@ -0,0 +1,165 @@
+// Package revocation announces terminated sessions to every instance of the gateway and the
+// notification service over Redis pub/sub, so the access tokens and real-time connections of a
+// session stop working right away instead of when the token expires. Announcements are not kept:
+// an instance that is down when a session is terminated relies on the session check instead.
+package revocation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"forgecrud-backend/shared/config"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const publishTimeout = 5 * time.Second
+
+// Revocation announces terminated sessions of a user
+type Revocation struct {
+	UserID     string    `json:"user_id"`
+	SessionIDs []string  `json:"session_ids,omitempty"` // Empty for every session of the user
+	RevokedAt  time.Time `json:"revoked_at"`
+}
+
+// All reports whether every session of the user is revoked
+func (r Revocation) All() bool {
+	return len(r.SessionIDs) == 0
+}
+
+// Handler applies a revocation announced by any instance, including this one
+type Handler func(Revocation)
+
+var (
+	client        *redis.Client
+	channel       string
+	handlers      []Handler
+	subscriptions []*redis.PubSub
+	mutex         sync.RWMutex
+)
+
+// Init connects to the configured driver ("redis" or "none"). Without Redis, revocations only
+// reach the handlers of this process.
+func Init() error {
+	cfg := config.GetConfig()
+	if cfg.SessionRevocationDriver != "redis" {
+		log.Printf("🔑 Session revocation announcements disabled")
+		return nil
+	}
+
+	redisClient := redis.NewClient(&redis.Options{
+		Addr:     fmt.Sprintf("%s:%s", cfg.RedisHost, cfg.RedisPort),
+		Password: cfg.RedisPassword,
+		DB:       cfg.RedisDB,
+	})
+	if err := redisClient.Ping(context.Background()).Err(); err != nil {
+		redisClient.Close()
+		return fmt.Errorf("failed to connect to Redis for session revocations: %v", err)
+	}
+
+	mutex.Lock()
+	client = redisClient
+	channel = cfg.SessionRevocationChannel
+	mutex.Unlock()
+
+	log.Printf("✅ Session revocations enabled (channel: %s)", cfg.SessionRevocationChannel)
+	return nil
+}
+
+// Subscribe calls the handler for every revocation announced from now on
+func Subscribe(handler Handler) error {
+	mutex.Lock()
+	handlers = append(handlers, handler)
+	redisClient, redisChannel := client, channel
+	mutex.Unlock()
+
+	if redisClient == nil {
+		return nil
+	}
+
+	ctx := context.Background()
+	subscription := redisClient.Subscribe(ctx, redisChannel)
+	// Wait for the subscription so revocations announced right after startup are not missed
+	if _, err := subscription.Receive(ctx); err != nil {
+		subscription.Close()
+		return fmt.Errorf("failed to subscribe to session revocations: %v", err)
+	}
+	mutex.Lock()
+	subscriptions = append(subscriptions, subscription)
+	mutex.Unlock()
+
+	// The subscription reconnects by itself when the connection to Redis drops, and ends on Close
+	go func() {
+		for message := range subscription.Channel() {
+			var revocation Revocation
+			if err := json.Unmarshal([]byte(message.Payload), &revocation); err != nil || revocation.UserID == "" {
+				log.Printf("⚠️  Invalid session revocation: %v", err)
+				continue
+			}
+			handler(revocation)
+		}
+	}()
+	return nil
+}
+
+// Publish announces a revocation. Failures are logged and never returned, so announcing cannot
+// break the request that terminated the session; the session check still rejects its tokens.
+func Publish(ctx context.Context, revocation Revocation) {
+	if revocation.RevokedAt.IsZero() {
+		revocation.RevokedAt = time.Now().UTC()
+	}
+
+	mutex.RLock()
+	redisClient, redisChannel := client, channel
+	local := append([]Handler(nil), handlers...)
+	mutex.RUnlock()
+
+	if redisClient == nil {
+		for _, handler := range local {
+			handler(revocation)
+		}
+		return
+	}
+
+	payload, err := json.Marshal(revocation)
+	if err != nil {
+		log.Printf("⚠️  Failed to encode session revocation: %v", err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), publishTimeout)
+	defer cancel()
+	if err := redisClient.Publish(ctx, redisChannel, payload).Err(); err != nil {
+		log.Printf("⚠️  Failed to announce session revocation of user %s: %v", revocation.UserID, err)
+	}
+}
+
+// Ping checks the connection to Redis; without Redis there is nothing to check
+func Ping(ctx context.Context) error {
+	mutex.RLock()
+	redisClient := client
+	mutex.RUnlock()
+	if redisClient == nil {
+		return nil
+	}
+	return redisClient.Ping(ctx).Err()
+}
+
+// Close ends the subscriptions and closes the connection to Redis
+func Close() error {
+	mutex.Lock()
+	redisClient, closing := client, subscriptions
+	client, subscriptions = nil, nil
+	mutex.Unlock()
+
+	for _, subscription := range closing {
+		subscription.Close()
+	}
+	if redisClient == nil {
+		return nil
+	}
+	return redisClient.Close()
+}
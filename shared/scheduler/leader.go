@@ -0,0 +1,149 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"forgecrud-backend/shared/config"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Elector decides which instance of a service runs its schedule
+type Elector interface {
+	// Campaign takes the leadership, or keeps it when this instance already leads. It reports false
+	// while another instance leads.
+	Campaign(ctx context.Context) (bool, error)
+	// Resign gives up the leadership, so another instance takes over without waiting for a lease. The
+	// instance may campaign again afterwards.
+	Resign(ctx context.Context) error
+	// Close releases the connections of the elector when the scheduler stops
+	Close() error
+}
+
+// postgresElector holds a session-level advisory lock on a connection of its own. Postgres releases the
+// lock as soon as that connection ends, so a crashed leader is replaced at the next campaign.
+type postgresElector struct {
+	db   *sql.DB
+	key  int64
+	conn *sql.Conn
+}
+
+func newPostgresElector(db *sql.DB, name string) *postgresElector {
+	hash := fnv.New64a()
+	hash.Write([]byte(name))
+	return &postgresElector{db: db, key: int64(hash.Sum64())}
+}
+
+func (e *postgresElector) Campaign(ctx context.Context) (bool, error) {
+	if e.conn != nil {
+		// The lock lives as long as the connection
+		if _, err := e.conn.ExecContext(ctx, "SELECT 1"); err != nil {
+			e.conn.Close()
+			e.conn = nil
+			return false, fmt.Errorf("lost the scheduler lock connection: %v", err)
+		}
+		return true, nil
+	}
+
+	conn, err := e.db.Conn(ctx)
+	if err != nil {
+		return false, err
+	}
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", e.key).Scan(&acquired); err != nil {
+		conn.Close()
+		return false, err
+	}
+	if !acquired {
+		conn.Close()
+		return false, nil
+	}
+	e.conn = conn
+	return true, nil
+}
+
+func (e *postgresElector) Resign(ctx context.Context) error {
+	if e.conn == nil {
+		return nil
+	}
+	_, err := e.conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", e.key)
+	e.conn.Close()
+	e.conn = nil
+	return err
+}
+
+// Close has nothing left to release, the lock connection is closed by Resign
+func (e *postgresElector) Close() error {
+	return nil
+}
+
+// Renew the lease only while the key still holds the token of this instance
+var renewLockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// Delete the key only while it holds the token of this instance
+var releaseLockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// redisElector holds a key with a lease. A leader that stops renewing it is replaced once the lease
+// expired.
+type redisElector struct {
+	client *redis.Client
+	key    string
+	token  string
+	lease  time.Duration
+}
+
+func newRedisElector(cfg *config.Config, key, token string, lease time.Duration) (*redisElector, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     fmt.Sprintf("%s:%s", cfg.RedisHost, cfg.RedisPort),
+		Password: cfg.RedisPassword,
+		DB:       cfg.RedisDB,
+	})
+
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		return nil, fmt.Errorf("failed to connect to Redis scheduler lock: %v", err)
+	}
+
+	return &redisElector{client: client, key: key, token: token, lease: lease}, nil
+}
+
+func (e *redisElector) Campaign(ctx context.Context) (bool, error) {
+	renewed, err := renewLockScript.Run(ctx, e.client, []string{e.key}, e.token, e.lease.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	if renewed == 1 {
+		return true, nil
+	}
+	return e.client.SetNX(ctx, e.key, e.token, e.lease).Result()
+}
+
+func (e *redisElector) Resign(ctx context.Context) error {
+	return releaseLockScript.Run(ctx, e.client, []string{e.key}, e.token).Err()
+}
+
+func (e *redisElector) Close() error {
+	return e.client.Close()
+}
+
+// soleElector always leads; used when every service runs as a single instance
+type soleElector struct{}
+
+func (soleElector) Campaign(ctx context.Context) (bool, error) { return true, nil }
+
+func (soleElector) Resign(ctx context.Context) error { return nil }
+
+func (soleElector) Close() error { return nil }
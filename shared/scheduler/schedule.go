@@ -0,0 +1,59 @@
+package scheduler
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Schedule returns the run times of a job. Times are evaluated in UTC, so every instance computes the
+// same ones.
+type Schedule interface {
+	// Next returns the first run time after t, or the zero time if there is none
+	Next(t time.Time) time.Time
+}
+
+// Parse parses a cron expression (see ParseCronSchedule), like "30 3 * * *" or "@daily", or
+// "@every <duration>"
+func Parse(spec string) (Schedule, error) {
+	if rest, ok := strings.CutPrefix(strings.TrimSpace(spec), "@every "); ok {
+		interval, err := time.ParseDuration(strings.TrimSpace(rest))
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule %q: %v", spec, err)
+		}
+		if interval < time.Second {
+			return nil, fmt.Errorf("invalid schedule %q: the interval must be at least a second", spec)
+		}
+		return everySchedule{interval: interval}, nil
+	}
+
+	cron, err := ParseCronSchedule(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: %v", spec, err)
+	}
+	return utcSchedule{cron: cron}, nil
+}
+
+// Every returns the schedule "@every interval"
+func Every(interval time.Duration) string {
+	return "@every " + interval.String()
+}
+
+// everySchedule runs at multiples of the interval since the zero time, not since the start of the
+// instance, so a new leader keeps the run times of the previous one
+type everySchedule struct {
+	interval time.Duration
+}
+
+func (s everySchedule) Next(t time.Time) time.Time {
+	return t.UTC().Truncate(s.interval).Add(s.interval)
+}
+
+// utcSchedule evaluates a cron expression on the UTC clock
+type utcSchedule struct {
+	cron *CronSchedule
+}
+
+func (s utcSchedule) Next(t time.Time) time.Time {
+	return s.cron.Next(t, time.UTC)
+}
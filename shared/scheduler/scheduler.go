@@ -0,0 +1,438 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"forgecrud-backend/shared/config"
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models"
+	"forgecrud-backend/shared/readonly"
+
+	"gorm.io/gorm/clause"
+)
+
+// historyCleanupJob removes the run history past SCHEDULER_HISTORY_RETENTION_DAYS
+const historyCleanupJob = "scheduler.history_cleanup"
+
+// Func runs a scheduled job. The context is canceled when the instance loses the leadership or stops.
+type Func func(ctx context.Context) error
+
+// entry is a registered job and its next run time
+type entry struct {
+	name     string
+	spec     string
+	schedule Schedule
+	fn       Func
+	next     time.Time // Zero while this instance does not lead
+	running  bool
+}
+
+// Scheduler runs the periodic jobs of a service on the one instance leading it. Every run is recorded
+// for its scheduled time before it starts, so a run time is never run twice, not even by two instances
+// that both believe to lead.
+type Scheduler struct {
+	service  string
+	instance string
+	elector  Elector
+	lease    time.Duration
+
+	mutex     sync.Mutex
+	entries   []*entry
+	leading   bool
+	runCtx    context.Context // Canceled when the leadership ends
+	cancelRun context.CancelFunc
+
+	runs     sync.WaitGroup
+	started  bool
+	stopping chan struct{}
+	stopped  chan struct{}
+	stopOnce sync.Once
+}
+
+var (
+	defaultScheduler *Scheduler
+	schedulerMutex   sync.Mutex
+)
+
+// Init creates the process-wide scheduler of a service for the configured lock driver. The database
+// must be initialized first; it keeps the run history.
+func Init(service string) error {
+	cfg := config.GetConfig()
+	if database.DB == nil {
+		return errors.New("the scheduler needs the database")
+	}
+
+	hostname, _ := os.Hostname()
+	instance := fmt.Sprintf("%s-%d", hostname, os.Getpid())
+	lease := time.Duration(cfg.SchedulerLeaseSeconds) * time.Second
+	key := cfg.SchedulerLockKey + ":" + service
+
+	var elector Elector
+	switch cfg.SchedulerLockDriver {
+	case "postgres":
+		sqlDB, err := database.DB.DB()
+		if err != nil {
+			return err
+		}
+		elector = newPostgresElector(sqlDB, key)
+	case "redis":
+		redisElector, err := newRedisElector(cfg, key, instance, lease)
+		if err != nil {
+			return err
+		}
+		elector = redisElector
+	default:
+		elector = soleElector{}
+	}
+
+	schedulerMutex.Lock()
+	defaultScheduler = newScheduler(service, instance, elector, lease)
+	schedulerMutex.Unlock()
+
+	log.Printf("✅ Scheduler initialized (lock: %s, service: %s, instance: %s)", cfg.SchedulerLockDriver, service, instance)
+	return nil
+}
+
+func newScheduler(service, instance string, elector Elector, lease time.Duration) *Scheduler {
+	return &Scheduler{
+		service:  service,
+		instance: instance,
+		elector:  elector,
+		lease:    lease,
+		stopping: make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+}
+
+// getScheduler returns the process-wide scheduler. Without Init every instance runs the schedule, and
+// only the recorded runs keep a run time from running twice.
+func getScheduler() *Scheduler {
+	schedulerMutex.Lock()
+	defer schedulerMutex.Unlock()
+	if defaultScheduler == nil {
+		hostname, _ := os.Hostname()
+		defaultScheduler = newScheduler("", fmt.Sprintf("%s-%d", hostname, os.Getpid()), soleElector{},
+			time.Duration(config.GetConfig().SchedulerLeaseSeconds)*time.Second)
+	}
+	return defaultScheduler
+}
+
+// Register adds a job run on the given schedule (see Parse); register all jobs before Start. An
+// invalid schedule stops the service, schedules are part of the code or of the validated configuration.
+func Register(name, spec string, fn Func) {
+	schedule, err := Parse(spec)
+	if err != nil {
+		log.Fatalf("❌ Failed to register scheduled job %s: %v", name, err)
+	}
+
+	s := getScheduler()
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.entries = append(s.entries, &entry{name: name, spec: spec, schedule: schedule, fn: fn})
+}
+
+// Start campaigns for the leadership and runs the registered jobs while this instance leads
+func Start() {
+	s := getScheduler()
+	if days := config.GetConfig().SchedulerHistoryRetentionDays; days > 0 {
+		retention := time.Duration(days) * 24 * time.Hour
+		Register(historyCleanupJob, "@daily", func(ctx context.Context) error {
+			return s.purgeHistory(ctx, time.Now().Add(-retention))
+		})
+	}
+
+	s.mutex.Lock()
+	s.started = true
+	count := len(s.entries)
+	s.mutex.Unlock()
+
+	go s.loop()
+	log.Printf("⏰ Scheduler started (jobs: %d)", count)
+}
+
+// Close stops the scheduler, giving running jobs SHUTDOWN_TIMEOUT_SECONDS to finish, and gives up the
+// leadership
+func Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(config.GetConfig().ShutdownTimeoutSeconds)*time.Second)
+	defer cancel()
+	return getScheduler().close(ctx)
+}
+
+// loop campaigns every third of the lease and starts the due jobs every second
+func (s *Scheduler) loop() {
+	defer close(s.stopped)
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	var lastCampaign time.Time
+	for {
+		now := time.Now()
+		if now.Sub(lastCampaign) >= s.lease/3 {
+			lastCampaign = now
+			s.campaign()
+		}
+		s.runDue(now)
+
+		select {
+		case <-s.stopping:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// campaign takes or keeps the leadership, and steps down when another instance took over
+func (s *Scheduler) campaign() {
+	ctx, cancel := context.WithTimeout(context.Background(), s.lease/3)
+	defer cancel()
+
+	leading, err := s.elector.Campaign(ctx)
+	if err != nil {
+		log.Printf("⚠️  Scheduler leader election failed: %v", err)
+	}
+
+	s.mutex.Lock()
+	wasLeading := s.leading
+	s.mutex.Unlock()
+
+	switch {
+	case leading && !wasLeading:
+		if err := s.lead(ctx); err != nil {
+			log.Printf("⚠️  Failed to take over the schedule of %s: %v", s.service, err)
+			s.elector.Resign(ctx)
+			return
+		}
+		log.Printf("👑 Scheduler leadership taken (service: %s, instance: %s)", s.service, s.instance)
+	case !leading && wasLeading:
+		s.follow()
+		log.Printf("⚠️  Scheduler leadership lost (service: %s, instance: %s)", s.service, s.instance)
+	}
+}
+
+// lead takes over the schedule: runs left running by a previous leader are failed, and every job
+// continues after its last recorded run, so one run time missed during the handover runs at once
+func (s *Scheduler) lead(ctx context.Context) error {
+	now := time.Now().UTC()
+	if err := database.DB.WithContext(ctx).Model(&models.ScheduledJobRun{}).
+		Where("service = ? AND status = ? AND instance <> ?", s.service, models.ScheduledJobRunRunning, s.instance).
+		Updates(map[string]interface{}{
+			"status":      models.ScheduledJobRunFailed,
+			"error":       "the instance running the job stopped",
+			"finished_at": now,
+		}).Error; err != nil {
+		return err
+	}
+
+	var lastRuns []struct {
+		JobName     string
+		ScheduledAt time.Time
+	}
+	if err := database.DB.WithContext(ctx).Model(&models.ScheduledJobRun{}).
+		Select("job_name, MAX(scheduled_at) AS scheduled_at").
+		Where("service = ?", s.service).
+		Group("job_name").
+		Scan(&lastRuns).Error; err != nil {
+		return err
+	}
+	lastRunAt := make(map[string]time.Time, len(lastRuns))
+	for _, run := range lastRuns {
+		lastRunAt[run.JobName] = run.ScheduledAt
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, e := range s.entries {
+		if last, ok := lastRunAt[e.name]; ok {
+			e.next = e.schedule.Next(last)
+		} else {
+			e.next = e.schedule.Next(now)
+		}
+
+		job := models.ScheduledJob{Service: s.service, Name: e.name, Schedule: e.spec, Leader: s.instance, NextRunAt: timeOrNil(e.next)}
+		if err := database.DB.WithContext(ctx).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "service"}, {Name: "name"}},
+			DoUpdates: clause.AssignmentColumns([]string{"schedule", "leader", "next_run_at", "updated_at"}),
+		}).Create(&job).Error; err != nil {
+			return err
+		}
+	}
+
+	s.runCtx, s.cancelRun = context.WithCancel(context.Background())
+	s.leading = true
+	return nil
+}
+
+// follow steps down, canceling the running jobs
+func (s *Scheduler) follow() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.leading = false
+	s.cancelRun()
+	for _, e := range s.entries {
+		e.next = time.Time{}
+	}
+}
+
+// runDue starts the jobs whose run time came. A job still running from its previous run time skips
+// the run times that pass meanwhile. Jobs of a read-only service wait until the mode ends, then run
+// once for the run times they missed.
+func (s *Scheduler) runDue(now time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.leading || readonly.Enabled(s.service) {
+		return
+	}
+	for _, e := range s.entries {
+		if e.running || e.next.IsZero() || now.Before(e.next) {
+			continue
+		}
+		scheduledAt := e.next
+		e.next = e.schedule.Next(now)
+		e.running = true
+
+		s.runs.Add(1)
+		go s.run(s.runCtx, e, scheduledAt)
+	}
+}
+
+// run records the run of a job for its scheduled time and runs it, unless another instance recorded
+// that run time already
+func (s *Scheduler) run(ctx context.Context, e *entry, scheduledAt time.Time) {
+	defer s.runs.Done()
+	defer func() {
+		s.mutex.Lock()
+		e.running = false
+		s.mutex.Unlock()
+	}()
+
+	run := models.ScheduledJobRun{
+		Service:     s.service,
+		JobName:     e.name,
+		ScheduledAt: scheduledAt,
+		Instance:    s.instance,
+		Status:      models.ScheduledJobRunRunning,
+		StartedAt:   time.Now().UTC(),
+	}
+	result := database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&run)
+	if result.Error != nil {
+		log.Printf("⚠️  Failed to record the run of scheduled job %s: %v", e.name, result.Error)
+		return
+	}
+	if result.RowsAffected == 0 {
+		// Run by another instance
+		return
+	}
+
+	err := runJob(ctx, e.fn)
+
+	finishedAt := time.Now().UTC()
+	updates := map[string]interface{}{
+		"status":      models.ScheduledJobRunSucceeded,
+		"finished_at": finishedAt,
+		"duration_ms": finishedAt.Sub(run.StartedAt).Milliseconds(),
+	}
+	if err != nil {
+		updates["status"] = models.ScheduledJobRunFailed
+		updates["error"] = err.Error()
+		log.Printf("❌ Scheduled job %s failed: %v", e.name, err)
+	}
+	if err := database.DB.Model(&run).Updates(updates).Error; err != nil {
+		log.Printf("⚠️  Failed to record the result of scheduled job %s: %v", e.name, err)
+	}
+
+	s.mutex.Lock()
+	next := e.next
+	s.mutex.Unlock()
+	if err := database.DB.Model(&models.ScheduledJob{}).
+		Where("service = ? AND name = ?", s.service, e.name).
+		Updates(map[string]interface{}{
+			"last_run_at": run.StartedAt,
+			"last_status": updates["status"],
+			"next_run_at": timeOrNil(next),
+		}).Error; err != nil {
+		log.Printf("⚠️  Failed to update scheduled job %s: %v", e.name, err)
+	}
+}
+
+// runJob runs a job, turning a panic into an error
+func runJob(ctx context.Context, fn Func) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("panic: %v", recovered)
+		}
+	}()
+	return fn(ctx)
+}
+
+// purgeHistory removes the runs of the service that started before the cutoff
+func (s *Scheduler) purgeHistory(ctx context.Context, cutoff time.Time) error {
+	result := database.DB.WithContext(ctx).
+		Where("service = ? AND started_at < ? AND status <> ?", s.service, cutoff, models.ScheduledJobRunRunning).
+		Delete(&models.ScheduledJobRun{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("🧹 Removed %d scheduled job runs past the history retention", result.RowsAffected)
+	}
+	return nil
+}
+
+// close stops the loop, waits for the running jobs until ctx is done and resigns
+func (s *Scheduler) close(ctx context.Context) error {
+	s.stopOnce.Do(func() { close(s.stopping) })
+	s.mutex.Lock()
+	started := s.started
+	s.mutex.Unlock()
+	if started {
+		<-s.stopped
+	}
+
+	done := make(chan struct{})
+	go func() {
+		s.runs.Wait()
+		close(done)
+	}()
+
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	s.mutex.Lock()
+	if s.leading {
+		s.leading = false
+		s.cancelRun()
+	}
+	s.mutex.Unlock()
+
+	resignCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if resignErr := s.elector.Resign(resignCtx); err == nil {
+		err = resignErr
+	}
+	if closeErr := s.elector.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// timeOrNil returns nil for the zero time
+func timeOrNil(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
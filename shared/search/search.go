@@ -0,0 +1,308 @@
+// Package search runs a text search over users, organizations, roles, folders and documents and
+// returns ranked results grouped by entity. Queries are tenancy-scoped through the context of the
+// passed DB, so callers only see what their organization owns.
+package search
+
+import (
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+
+	"forgecrud-backend/shared/database/models"
+	"forgecrud-backend/shared/database/models/document"
+
+	"gorm.io/gorm"
+)
+
+// Searchable entities; each matches the permission resource slug needed to see it
+const (
+	EntityUsers         = "users"
+	EntityOrganizations = "organizations"
+	EntityRoles         = "roles"
+	EntityFolders       = "folders"
+	EntityDocuments     = "documents"
+)
+
+const (
+	DefaultLimit = 5
+	MaxLimit     = 50
+
+	// MinQueryLength avoids scanning every table for one or two characters
+	MinQueryLength = 2
+
+	// candidateFactor controls how many rows per entity are ranked before the top ones are returned
+	candidateFactor = 4
+)
+
+// Match quality multipliers, applied to the field weight
+const (
+	scoreExact    = 3.0
+	scorePrefix   = 2.0
+	scoreContains = 1.0
+)
+
+// field is a searchable column and its weight in the ranking
+type field struct {
+	Column string
+	Weight float64
+}
+
+// entity describes how one entity type is searched and presented
+type entity struct {
+	Model    interface{}
+	Fields   []field
+	Columns  []string
+	Title    func(row map[string]interface{}) string
+	Subtitle func(row map[string]interface{}) string
+	Texts    map[string]string // SQL of the text of fields that are no text columns, like arrays
+}
+
+// text returns the SQL of a field's text
+func (e entity) text(column string) string {
+	if text, ok := e.Texts[column]; ok {
+		return text
+	}
+	return column
+}
+
+var entities = map[string]entity{
+	EntityUsers: {
+		Model:   &models.User{},
+		Fields:  []field{{"email", 3}, {"first_name", 2}, {"last_name", 2}},
+		Columns: []string{"id", "email", "first_name", "last_name", "status"},
+		Title: func(row map[string]interface{}) string {
+			return strings.TrimSpace(stringValue(row["first_name"]) + " " + stringValue(row["last_name"]))
+		},
+		Subtitle: func(row map[string]interface{}) string { return stringValue(row["email"]) },
+	},
+	EntityOrganizations: {
+		Model:    &models.Organization{},
+		Fields:   []field{{"name", 3}, {"slug", 2}},
+		Columns:  []string{"id", "name", "slug", "status"},
+		Title:    func(row map[string]interface{}) string { return stringValue(row["name"]) },
+		Subtitle: func(row map[string]interface{}) string { return stringValue(row["slug"]) },
+	},
+	EntityRoles: {
+		Model:    &models.Role{},
+		Fields:   []field{{"name", 3}, {"description", 1}},
+		Columns:  []string{"id", "name", "description"},
+		Title:    func(row map[string]interface{}) string { return stringValue(row["name"]) },
+		Subtitle: func(row map[string]interface{}) string { return stringValue(row["description"]) },
+	},
+	EntityFolders: {
+		Model:    &document.Folder{},
+		Fields:   []field{{"name", 3}, {"path", 1}},
+		Columns:  []string{"id", "name", "path"},
+		Title:    func(row map[string]interface{}) string { return stringValue(row["name"]) },
+		Subtitle: func(row map[string]interface{}) string { return stringValue(row["path"]) },
+	},
+	EntityDocuments: {
+		Model:    &document.Document{},
+		Fields:   []field{{"original_name", 3}, {"tags", 2}, {"description", 1}},
+		Columns:  []string{"id", "original_name", "description", "array_to_string(tags, ', ') AS tags", "mime_type", "folder_id"},
+		Texts:    map[string]string{"tags": "array_to_string(tags, ', ')"},
+		Title:    func(row map[string]interface{}) string { return stringValue(row["original_name"]) },
+		Subtitle: func(row map[string]interface{}) string { return stringValue(row["mime_type"]) },
+	},
+}
+
+// Entities returns all searchable entity names in display order
+func Entities() []string {
+	return []string{EntityUsers, EntityOrganizations, EntityRoles, EntityFolders, EntityDocuments}
+}
+
+// Options configures a search
+type Options struct {
+	Query    string
+	Entities []string // Entities to search; must already be limited to what the caller may read
+	Limit    int      // Results per entity
+}
+
+// Hit is one matching record
+type Hit struct {
+	ID         interface{}       `json:"id"`
+	Title      string            `json:"title"`
+	Subtitle   string            `json:"subtitle,omitempty"`
+	Score      float64           `json:"score"`
+	Highlights map[string]string `json:"highlights"`
+}
+
+// Group holds the ranked hits of one entity type
+type Group struct {
+	Entity string `json:"entity"`
+	Count  int    `json:"count"`
+	Hits   []Hit  `json:"hits"`
+}
+
+// Search runs the query over the requested entities. Groups are returned in the order of
+// Entities() and only for entities with at least one hit.
+func Search(db *gorm.DB, opts Options) ([]Group, error) {
+	term := strings.TrimSpace(opts.Query)
+	if len([]rune(term)) < MinQueryLength {
+		return nil, fmt.Errorf("query must be at least %d characters", MinQueryLength)
+	}
+
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	if limit > MaxLimit {
+		limit = MaxLimit
+	}
+
+	requested := make(map[string]bool, len(opts.Entities))
+	for _, name := range opts.Entities {
+		requested[name] = true
+	}
+
+	groups := []Group{}
+	for _, name := range Entities() {
+		if !requested[name] {
+			continue
+		}
+
+		hits, err := searchEntity(db, entities[name], term, limit)
+		if err != nil {
+			return nil, fmt.Errorf("search %s: %w", name, err)
+		}
+		if len(hits) == 0 {
+			continue
+		}
+		groups = append(groups, Group{Entity: name, Count: len(hits), Hits: hits})
+	}
+
+	return groups, nil
+}
+
+// searchEntity loads candidate rows matching the term in any field and returns the best ranked ones
+func searchEntity(db *gorm.DB, e entity, term string, limit int) ([]Hit, error) {
+	pattern := "%" + escapeLike(term) + "%"
+
+	conditions := make([]string, len(e.Fields))
+	args := make([]interface{}, len(e.Fields))
+	for i, f := range e.Fields {
+		conditions[i] = fmt.Sprintf("%s ILIKE ?", e.text(f.Column))
+		args[i] = pattern
+	}
+
+	var rows []map[string]interface{}
+	if err := db.Model(e.Model).
+		Select(e.Columns).
+		Where(strings.Join(conditions, " OR "), args...).
+		Limit(limit * candidateFactor).
+		Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	hits := make([]Hit, 0, len(rows))
+	for _, row := range rows {
+		hit := Hit{
+			ID:         row["id"],
+			Title:      e.Title(row),
+			Subtitle:   e.Subtitle(row),
+			Highlights: map[string]string{},
+		}
+		for _, f := range e.Fields {
+			value := stringValue(row[f.Column])
+			quality := matchQuality(value, term)
+			if quality == 0 {
+				continue
+			}
+			hit.Score += f.Weight * quality
+			hit.Highlights[f.Column] = Highlight(value, term)
+		}
+		hits = append(hits, hit)
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].Title < hits[j].Title
+	})
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+
+	return hits, nil
+}
+
+// matchQuality rates how well value matches term: exact, prefix (of the value or one of its words) or substring
+func matchQuality(value, term string) float64 {
+	value = strings.ToLower(value)
+	term = strings.ToLower(term)
+
+	switch {
+	case value == "" || !strings.Contains(value, term):
+		return 0
+	case value == term:
+		return scoreExact
+	case strings.HasPrefix(value, term):
+		return scorePrefix
+	}
+
+	for _, word := range strings.FieldsFunc(value, isWordSeparator) {
+		if strings.HasPrefix(word, term) {
+			return scorePrefix
+		}
+	}
+	return scoreContains
+}
+
+// Highlight HTML-escapes value and wraps every case-insensitive occurrence of term in <mark> tags
+func Highlight(value, term string) string {
+	if term == "" {
+		return html.EscapeString(value)
+	}
+
+	lowerValue := strings.ToLower(value)
+	lowerTerm := strings.ToLower(term)
+
+	// Lower-casing can change byte lengths for some runes; skip highlighting rather than cut runes
+	if len(lowerValue) != len(value) {
+		return html.EscapeString(value)
+	}
+
+	var b strings.Builder
+	start := 0
+	for {
+		index := strings.Index(lowerValue[start:], lowerTerm)
+		if index < 0 {
+			break
+		}
+		matchStart := start + index
+		matchEnd := matchStart + len(lowerTerm)
+
+		b.WriteString(html.EscapeString(value[start:matchStart]))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(value[matchStart:matchEnd]))
+		b.WriteString("</mark>")
+		start = matchEnd
+	}
+	b.WriteString(html.EscapeString(value[start:]))
+
+	return b.String()
+}
+
+func isWordSeparator(r rune) bool {
+	return strings.ContainsRune(" \t-_.@/,;", r)
+}
+
+// escapeLike escapes LIKE wildcards so the term is matched literally
+func escapeLike(term string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(term)
+}
+
+func stringValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
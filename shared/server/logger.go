@@ -0,0 +1,36 @@
+package server
+
+import (
+	"fmt"
+	"time"
+
+	"forgecrud-backend/shared/config"
+	"forgecrud-backend/shared/utils/redact"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Logger logs requests like gin's default logger, with the sensitive query parameters masked.
+// Browsers send access tokens as the access_token parameter of WebSocket handshakes and event
+// streams, so it's masked even when REDACT_FIELDS leaves it out.
+func Logger() gin.HandlerFunc {
+	redactor := redact.New(config.GetConfig().RedactFields + ",access_token")
+	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
+		path := param.Request.URL.Path
+		if query := redactor.Query(param.Request.URL.RawQuery); query != "" {
+			path += "?" + query
+		}
+		if param.Latency > time.Minute {
+			param.Latency = param.Latency.Truncate(time.Second)
+		}
+		return fmt.Sprintf("[GIN] %v | %3d | %13v | %15s | %-7s %#v\n%s",
+			param.TimeStamp.Format("2006/01/02 - 15:04:05"),
+			param.StatusCode,
+			param.Latency,
+			param.ClientIP,
+			param.Method,
+			path,
+			param.ErrorMessage,
+		)
+	})
+}
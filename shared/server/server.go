@@ -0,0 +1,124 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"forgecrud-backend/shared/config"
+)
+
+// readHeaderTimeout bounds how long a client may take to send the request headers
+const readHeaderTimeout = 10 * time.Second
+
+type hook struct {
+	name string
+	fn   func() error
+}
+
+var (
+	mutex        sync.Mutex
+	drainHooks   []hook
+	cleanupHooks []hook
+	shuttingDown atomic.Bool
+)
+
+// OnDrain registers a hook run as soon as the listener closes, for long-lived connections like
+// WebSockets and event streams that would otherwise hold the shutdown until the timeout
+func OnDrain(name string, fn func() error) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	drainHooks = append(drainHooks, hook{name: name, fn: fn})
+}
+
+// OnShutdown registers a cleanup hook run once in-flight requests finished. Like defers, hooks
+// run in the reverse order of registration, so a resource is released after the ones using it.
+func OnShutdown(name string, fn func() error) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	cleanupHooks = append(cleanupHooks, hook{name: name, fn: fn})
+}
+
+// ShuttingDown reports whether the service received a termination signal; readiness checks fail
+// from then on so load balancers stop routing new requests to the instance
+func ShuttingDown() bool {
+	return shuttingDown.Load()
+}
+
+// Run serves the handler on the address until SIGINT or SIGTERM, then shuts down gracefully:
+// readiness fails for SHUTDOWN_DRAIN_SECONDS, the listener closes, in-flight requests get
+// SHUTDOWN_TIMEOUT_SECONDS to finish and the cleanup hooks run. A second signal stops at once.
+// SIGHUP reloads the runtime-changeable configuration instead of stopping the service.
+func Run(handler http.Handler, addr string) {
+	cfg := config.GetConfig()
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+	srv.RegisterOnShutdown(func() { runHooks("drain", &drainHooks, false) })
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- srv.ListenAndServe()
+	}()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Reload the rate limits and feature flags on SIGHUP and on the configured interval
+	go config.Watch(ctx)
+
+	select {
+	case err := <-serveErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("❌ Server failed: %v", err)
+			runHooks("cleanup", &cleanupHooks, true)
+			os.Exit(1)
+		}
+		return
+	case <-ctx.Done():
+	}
+	// Restore the default signal handling, so a second signal kills the process
+	stop()
+
+	shuttingDown.Store(true)
+	drain := time.Duration(cfg.ShutdownDrainSeconds) * time.Second
+	timeout := time.Duration(cfg.ShutdownTimeoutSeconds) * time.Second
+	log.Printf("🛑 Shutting down: draining for %s, then waiting up to %s for in-flight requests", drain, timeout)
+	time.Sleep(drain)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("⚠️  In-flight requests did not finish in time, closing connections: %v", err)
+		srv.Close()
+	}
+
+	runHooks("cleanup", &cleanupHooks, true)
+	log.Println("👋 Server stopped")
+}
+
+// runHooks runs the hooks, in reverse order if asked, logging the failures
+func runHooks(kind string, registered *[]hook, reverse bool) {
+	mutex.Lock()
+	hooks := append([]hook(nil), *registered...)
+	mutex.Unlock()
+
+	for i := range hooks {
+		h := hooks[i]
+		if reverse {
+			h = hooks[len(hooks)-1-i]
+		}
+		if err := h.fn(); err != nil {
+			log.Printf("⚠️  Failed to run %s hook %s: %v", kind, h.name, err)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+// Package tenancy carries the caller's organization from the API gateway to the services so their
+// database queries can be scoped to it. The gateway signs the forwarded tenant, so a client reaching a
+// service directly cannot act as another user, pick an organization or bypass the scope.
+package tenancy
+
+import (
+	"context"
+	"net/http"
+
+	utils "forgecrud-backend/shared/utils/auth"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Headers set by the API gateway from the verified JWT. Values without the gateway's signature are
+// always dropped.
+const (
+	OrganizationIDHeader = utils.OrganizationIDHeader
+	BypassHeader         = utils.TenantBypassHeader
+	SignatureHeader      = utils.ForwardedSignatureHeader
+)
+
+type tenantContextKey struct{}
+
+// Tenant is the organization scope of a request
+type Tenant struct {
+	UserID         *uuid.UUID
+	OrganizationID *uuid.UUID
+	Bypass         bool
+}
+
+// WithTenant returns a context carrying the tenant; use it with DB.WithContext to scope queries
+func WithTenant(ctx context.Context, tenant Tenant) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenant)
+}
+
+// FromContext returns the tenant stored by WithTenant. ok is false for contexts without a tenant,
+// such as background jobs and internal service calls, which are not scoped.
+func FromContext(ctx context.Context) (tenant Tenant, ok bool) {
+	if ctx == nil {
+		return Tenant{}, false
+	}
+	tenant, ok = ctx.Value(tenantContextKey{}).(Tenant)
+	return tenant, ok
+}
+
+// Forward sets the tenant headers of a request to a service, signed so the service can tell them from
+// client supplied values. A tenant without a user clears them.
+func Forward(req *http.Request, tenant Tenant) {
+	req.Header.Del(utils.UserIDHeader)
+	req.Header.Del(OrganizationIDHeader)
+	req.Header.Del(BypassHeader)
+	req.Header.Del(SignatureHeader)
+	if tenant.UserID == nil {
+		return
+	}
+
+	req.Header.Set(utils.UserIDHeader, tenant.UserID.String())
+	if tenant.OrganizationID != nil {
+		req.Header.Set(OrganizationIDHeader, tenant.OrganizationID.String())
+	}
+	if tenant.Bypass {
+		req.Header.Set(BypassHeader, "true")
+	}
+	utils.SignForwardedHeaders(req.Header)
+}
+
+// FromRequest reads the tenant forwarded by the API gateway. ok is false when the request did not
+// come through the gateway on behalf of a user, including requests whose headers lack the gateway's
+// signature.
+func FromRequest(c *gin.Context) (tenant Tenant, ok bool) {
+	if !utils.ForwardedHeadersVerified(c.Request.Header) {
+		return Tenant{}, false
+	}
+	userID, err := uuid.Parse(c.GetHeader(utils.UserIDHeader))
+	if err != nil {
+		return Tenant{}, false
+	}
+	tenant.UserID = &userID
+	if organizationID, err := uuid.Parse(c.GetHeader(OrganizationIDHeader)); err == nil {
+		tenant.OrganizationID = &organizationID
+	}
+	tenant.Bypass = c.GetHeader(BypassHeader) == "true"
+
+	return tenant, true
+}
+
+// Middleware stores the forwarded tenant in the request context and drops tenant headers without a
+// valid signature, so handlers reading them never see client supplied values
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !utils.ForwardedHeadersVerified(c.Request.Header) {
+			c.Request.Header.Del(utils.UserIDHeader)
+			c.Request.Header.Del(OrganizationIDHeader)
+			c.Request.Header.Del(BypassHeader)
+		}
+		if tenant, ok := FromRequest(c); ok {
+			c.Request = c.Request.WithContext(WithTenant(c.Request.Context(), tenant))
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,212 @@
+package testkit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Client calls the gateway, signed in once Login or Verify succeeded
+type Client struct {
+	BaseURL string
+	Token   string
+	HTTP    *http.Client
+}
+
+// Response is a buffered response
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// Client returns a client of the gateway that is not signed in
+func (e *Env) Client() *Client {
+	return &Client{BaseURL: e.GatewayURL, HTTP: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// AdminClient returns a client signed in as the super admin
+func (e *Env) AdminClient(t testing.TB) *Client {
+	t.Helper()
+	client := e.Client()
+	client.Login(t, AdminEmail, AdminPassword)
+	return client
+}
+
+// WithToken returns a copy of the client using another token
+func (c *Client) WithToken(token string) *Client {
+	copied := *c
+	copied.Token = token
+	return &copied
+}
+
+// Do sends a request; a body that is not an io.Reader or nil is sent as JSON
+func (c *Client) Do(t testing.TB, method, path string, body interface{}) *Response {
+	t.Helper()
+	var reader io.Reader
+	contentType := ""
+	switch value := body.(type) {
+	case nil:
+	case io.Reader:
+		reader = value
+	default:
+		data, err := json.Marshal(value)
+		if err != nil {
+			t.Fatalf("testkit: failed to encode the body of %s %s: %v", method, path, err)
+		}
+		reader, contentType = bytes.NewReader(data), "application/json"
+	}
+	return c.send(t, method, path, reader, contentType)
+}
+
+func (c *Client) send(t testing.TB, method, path string, body io.Reader, contentType string) *Response {
+	t.Helper()
+	request, err := http.NewRequest(method, c.BaseURL+path, body)
+	if err != nil {
+		t.Fatalf("testkit: %s %s: %v", method, path, err)
+	}
+	if contentType != "" {
+		request.Header.Set("Content-Type", contentType)
+	}
+	if c.Token != "" {
+		request.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	response, err := c.HTTP.Do(request)
+	if err != nil {
+		t.Fatalf("testkit: %s %s: %v", method, path, err)
+	}
+	defer response.Body.Close()
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		t.Fatalf("testkit: %s %s: failed to read the response: %v", method, path, err)
+	}
+	return &Response{StatusCode: response.StatusCode, Header: response.Header, Body: data}
+}
+
+// Get sends a GET request
+func (c *Client) Get(t testing.TB, path string) *Response {
+	t.Helper()
+	return c.Do(t, http.MethodGet, path, nil)
+}
+
+// Post sends a POST request with a JSON body
+func (c *Client) Post(t testing.TB, path string, body interface{}) *Response {
+	t.Helper()
+	return c.Do(t, http.MethodPost, path, body)
+}
+
+// Put sends a PUT request with a JSON body
+func (c *Client) Put(t testing.TB, path string, body interface{}) *Response {
+	t.Helper()
+	return c.Do(t, http.MethodPut, path, body)
+}
+
+// Delete sends a DELETE request
+func (c *Client) Delete(t testing.TB, path string) *Response {
+	t.Helper()
+	return c.Do(t, http.MethodDelete, path, nil)
+}
+
+// Upload posts a multipart form with the fields and one file, like POST /api/documents
+func (c *Client) Upload(t testing.TB, path string, fields map[string]string, fileField, fileName string, content []byte) *Response {
+	t.Helper()
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for name, value := range fields {
+		if err := writer.WriteField(name, value); err != nil {
+			t.Fatalf("testkit: failed to write field %s: %v", name, err)
+		}
+	}
+	part, err := writer.CreateFormFile(fileField, fileName)
+	if err != nil {
+		t.Fatalf("testkit: failed to add %s: %v", fileName, err)
+	}
+	part.Write(content)
+	if err := writer.Close(); err != nil {
+		t.Fatalf("testkit: failed to write the form: %v", err)
+	}
+	return c.send(t, http.MethodPost, path, &body, writer.FormDataContentType())
+}
+
+// Register registers an account through the auth service
+func (c *Client) Register(t testing.TB, email, password, firstName, lastName string) *Response {
+	t.Helper()
+	return c.Post(t, "/api/auth/register", map[string]string{
+		"email":      email,
+		"password":   password,
+		"first_name": firstName,
+		"last_name":  lastName,
+	}).Expect(t, http.StatusCreated)
+}
+
+// Verify verifies an email address with its token and signs the client in with the returned token
+func (c *Client) Verify(t testing.TB, token string) *Response {
+	t.Helper()
+	response := c.Get(t, "/api/auth/verify-email/"+token).Expect(t, http.StatusOK)
+	c.Token = response.String(t, "token")
+	return response
+}
+
+// Login signs the client in
+func (c *Client) Login(t testing.TB, email, password string) *Response {
+	t.Helper()
+	response := c.Post(t, "/api/auth/login", map[string]string{"email": email, "password": password}).Expect(t, http.StatusOK)
+	c.Token = response.String(t, "token")
+	return response
+}
+
+// Expect fails the test unless the response has the status
+func (r *Response) Expect(t testing.TB, status int) *Response {
+	t.Helper()
+	if r.StatusCode != status {
+		t.Fatalf("testkit: expected status %d, got %d: %s", status, r.StatusCode, r.Body)
+	}
+	return r
+}
+
+// JSON decodes the body into v
+func (r *Response) JSON(t testing.TB, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(r.Body, v); err != nil {
+		t.Fatalf("testkit: failed to decode %s: %v", r.Body, err)
+	}
+}
+
+// Value returns the value at a dotted path of the JSON body, e.g. "data.id" or "data.items.0.name"
+func (r *Response) Value(t testing.TB, path string) interface{} {
+	t.Helper()
+	var value interface{}
+	r.JSON(t, &value)
+	for _, key := range strings.Split(path, ".") {
+		switch node := value.(type) {
+		case map[string]interface{}:
+			value = node[key]
+		case []interface{}:
+			var index int
+			if _, err := fmt.Sscan(key, &index); err != nil || index < 0 || index >= len(node) {
+				t.Fatalf("testkit: no element %s at %s of %s", key, path, r.Body)
+			}
+			value = node[index]
+		default:
+			t.Fatalf("testkit: no %s in %s", path, r.Body)
+		}
+	}
+	return value
+}
+
+// String returns the string at a dotted path of the JSON body, failing the test when it is missing
+func (r *Response) String(t testing.TB, path string) string {
+	t.Helper()
+	value, ok := r.Value(t, path).(string)
+	if !ok || value == "" {
+		t.Fatalf("testkit: no string at %s of %s", path, r.Body)
+	}
+	return value
+}
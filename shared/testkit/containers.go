@@ -0,0 +1,116 @@
+package testkit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/docker/go-connections/nat"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+// Images match docker-compose.yml
+const (
+	postgresImage = "postgres:15-alpine"
+	redisImage    = "redis:7-alpine"
+	minioImage    = "minio/minio:latest"
+)
+
+// Credentials of the throwaway dependencies
+const (
+	dbUser      = "forgecrud"
+	dbPassword  = "forgecrud"
+	dbName      = "forgecrud_test"
+	minioUser   = "minioadmin"
+	minioSecret = "minioadmin"
+)
+
+// dependency is a running container and the address its port is published on
+type dependency struct {
+	name      string
+	container testcontainers.Container
+	host      string
+	port      string
+}
+
+func (d *dependency) terminate(ctx context.Context) error {
+	return d.container.Terminate(ctx)
+}
+
+// checkDocker fails when no Docker daemon answers; testcontainers panics in some setups without one
+func checkDocker(ctx context.Context) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	provider, err := testcontainers.NewDockerProvider()
+	if err != nil {
+		return err
+	}
+	return provider.Health(ctx)
+}
+
+func startPostgres(ctx context.Context) (*dependency, error) {
+	return startContainer(ctx, "postgres", "5432/tcp", testcontainers.ContainerRequest{
+		Image:        postgresImage,
+		ExposedPorts: []string{"5432/tcp"},
+		Env: map[string]string{
+			"POSTGRES_USER":     dbUser,
+			"POSTGRES_PASSWORD": dbPassword,
+			"POSTGRES_DB":       dbName,
+		},
+		// The entrypoint starts the server twice, first to run the init scripts
+		WaitingFor: wait.ForAll(
+			wait.ForLog("database system is ready to accept connections").WithOccurrence(2),
+			wait.ForListeningPort("5432/tcp"),
+		).WithDeadline(time.Minute),
+	})
+}
+
+func startRedis(ctx context.Context) (*dependency, error) {
+	return startContainer(ctx, "redis", "6379/tcp", testcontainers.ContainerRequest{
+		Image:        redisImage,
+		ExposedPorts: []string{"6379/tcp"},
+		WaitingFor:   wait.ForLog("Ready to accept connections").WithStartupTimeout(time.Minute),
+	})
+}
+
+func startMinIO(ctx context.Context) (*dependency, error) {
+	return startContainer(ctx, "minio", "9000/tcp", testcontainers.ContainerRequest{
+		Image:        minioImage,
+		ExposedPorts: []string{"9000/tcp"},
+		Env: map[string]string{
+			"MINIO_ROOT_USER":     minioUser,
+			"MINIO_ROOT_PASSWORD": minioSecret,
+		},
+		Cmd:        []string{"server", "/data"},
+		WaitingFor: wait.ForHTTP("/minio/health/ready").WithPort("9000/tcp").WithStartupTimeout(time.Minute),
+	})
+}
+
+func startContainer(ctx context.Context, name string, port nat.Port, request testcontainers.ContainerRequest) (*dependency, error) {
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: request,
+		Started:          true,
+	})
+	if err != nil {
+		if container != nil {
+			container.Terminate(context.Background())
+		}
+		return nil, err
+	}
+
+	host, err := container.Host(ctx)
+	if err != nil {
+		container.Terminate(context.Background())
+		return nil, err
+	}
+	mappedPort, err := container.MappedPort(ctx, port)
+	if err != nil {
+		container.Terminate(context.Background())
+		return nil, err
+	}
+	return &dependency{name: name, container: container, host: host, port: mappedPort.Port()}, nil
+}
@@ -0,0 +1,68 @@
+//go:build integration
+
+package testkit_test
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"testing"
+
+	"forgecrud-backend/shared/testkit"
+)
+
+var (
+	env *testkit.Env
+	// skipReason is set when the stack can't start here, so the tests report as skipped
+	skipReason string
+)
+
+func TestMain(m *testing.M) {
+	var err error
+	env, err = testkit.Start(context.Background(), testkit.Options{})
+	if errors.Is(err, testkit.ErrDockerUnavailable) {
+		skipReason = err.Error()
+		os.Exit(m.Run())
+	} else if err != nil {
+		log.Fatal(err)
+	}
+	code := m.Run()
+	env.Close()
+	os.Exit(code)
+}
+
+// requireEnv skips the test when the stack could not be started
+func requireEnv(t *testing.T) {
+	t.Helper()
+	if skipReason != "" {
+		t.Skipf("integration stack unavailable: %s", skipReason)
+	}
+}
+
+// TestUploadAndDownload registers and verifies an account, signs in, uploads a document into a new
+// folder and downloads it again through the gateway
+func TestUploadAndDownload(t *testing.T) {
+	requireEnv(t)
+	email := testkit.UniqueEmail(t)
+	client := env.Client()
+	client.Register(t, email, testkit.DefaultPassword, "Jane", "Doe")
+	client.Verify(t, env.VerificationToken(t, email))
+	client.Login(t, email, testkit.DefaultPassword)
+
+	user := env.User(t, email)
+	env.Grant(t, user, "file-management", "read", "create")
+	folder := client.Post(t, "/api/folders", map[string]string{
+		"name": "Reports", "owner_id": user.ID.String(), "owner_type": "user",
+	}).Expect(t, http.StatusCreated).String(t, "data.id")
+
+	content := []byte("Q1 revenue: 42")
+	document := client.Upload(t, "/api/documents", map[string]string{"folder_id": folder}, "file", "q1.txt", content).
+		Expect(t, http.StatusCreated).String(t, "data.id")
+
+	body := client.Get(t, "/api/documents/"+document+"/download").Expect(t, http.StatusOK).Body
+	if string(body) != string(content) {
+		t.Fatalf("downloaded %q, uploaded %q", body, content)
+	}
+}
@@ -0,0 +1,172 @@
+package testkit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/database/models"
+	"forgecrud-backend/shared/database/models/auth"
+	"forgecrud-backend/shared/messaging"
+	utils "forgecrud-backend/shared/utils/auth"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// seed creates the resources, actions and default roles, and the super admin
+func seed() error {
+	if err := database.SeedDatabase(); err != nil {
+		return err
+	}
+	return database.CreateSuperAdmin(AdminEmail, AdminPassword, "Super", "Admin")
+}
+
+// UniqueEmail returns an address no other test uses, derived from the test name for readable logs
+func UniqueEmail(t testing.TB) string {
+	name := strings.ToLower(strings.NewReplacer("/", "-", " ", "-", "_", "-").Replace(t.Name()))
+	if len(name) > 40 {
+		name = name[:40]
+	}
+	return fmt.Sprintf("%s-%s@example.test", name, uuid.NewString()[:8])
+}
+
+// UserFixture describes a user created directly in the database
+type UserFixture struct {
+	Email          string // UniqueEmail when empty
+	Password       string // "Password#1234" when empty
+	FirstName      string
+	LastName       string
+	OrganizationID *uuid.UUID
+	RoleID         *uuid.UUID
+	Unverified     bool // Leave the user pending the verification of their email
+}
+
+// DefaultPassword is the password of users created without one
+const DefaultPassword = "Password#1234"
+
+// CreateUser creates an active user, verified unless the fixture says otherwise
+func (e *Env) CreateUser(t testing.TB, fixture UserFixture) *models.User {
+	t.Helper()
+	if fixture.Email == "" {
+		fixture.Email = UniqueEmail(t)
+	}
+	if fixture.Password == "" {
+		fixture.Password = DefaultPassword
+	}
+	if fixture.FirstName == "" {
+		fixture.FirstName = "Test"
+	}
+	if fixture.LastName == "" {
+		fixture.LastName = "User"
+	}
+	hashedPassword, err := utils.HashPassword(fixture.Password)
+	if err != nil {
+		t.Fatalf("testkit: failed to hash the password: %v", err)
+	}
+
+	user := &models.User{
+		Email:          fixture.Email,
+		Password:       hashedPassword,
+		FirstName:      fixture.FirstName,
+		LastName:       fixture.LastName,
+		Status:         models.UserStatusActive,
+		EmailVerified:  !fixture.Unverified,
+		OrganizationID: fixture.OrganizationID,
+		RoleID:         fixture.RoleID,
+	}
+	if fixture.Unverified {
+		user.Status = models.UserStatusPendingVerification
+	}
+	if err := database.DB.Create(user).Error; err != nil {
+		t.Fatalf("testkit: failed to create user %s: %v", fixture.Email, err)
+	}
+	return user
+}
+
+// CreateOrganization creates an active organization owned by the user
+func (e *Env) CreateOrganization(t testing.TB, name string, owner *models.User) *models.Organization {
+	t.Helper()
+	slug := strings.ToLower(strings.ReplaceAll(name, " ", "-")) + "-" + uuid.NewString()[:8]
+	organization := &models.Organization{Name: name, Slug: slug, Status: "ACTIVE", OwnerID: owner.ID}
+	if err := database.DB.Create(organization).Error; err != nil {
+		t.Fatalf("testkit: failed to create organization %s: %v", name, err)
+	}
+	if owner.OrganizationID == nil {
+		if err := database.DB.Model(owner).Update("organization_id", organization.ID).Error; err != nil {
+			t.Fatalf("testkit: failed to add %s to organization %s: %v", owner.Email, name, err)
+		}
+		owner.OrganizationID = &organization.ID
+	}
+	return organization
+}
+
+// Grant gives the user the actions on a resource, like `forgectl permissions grant --user`. The
+// permission change is published, so the gateway does not answer from a cached permission set.
+func (e *Env) Grant(t testing.TB, user *models.User, resourceSlug string, actionSlugs ...string) {
+	t.Helper()
+	var resource models.Resource
+	if err := database.DB.First(&resource, "slug = ?", resourceSlug).Error; err != nil {
+		t.Fatalf("testkit: resource %q: %v", resourceSlug, err)
+	}
+
+	permission := models.Permission{ResourceID: resource.ID, Target: models.PermissionTargetUser, UserID: &user.ID}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("resource_id = ? AND target = ? AND user_id = ?", resource.ID, permission.Target, user.ID).First(&permission).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = tx.Create(&permission).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, slug := range actionSlugs {
+			var action models.Action
+			if err := tx.First(&action, "slug = ?", slug).Error; err != nil {
+				return fmt.Errorf("action %q: %w", slug, err)
+			}
+			var count int64
+			if err := tx.Model(&models.PermissionAction{}).
+				Where("permission_id = ? AND action_id = ?", permission.ID, action.ID).
+				Count(&count).Error; err != nil {
+				return err
+			}
+			if count == 0 {
+				if err := tx.Create(&models.PermissionAction{PermissionID: permission.ID, ActionID: action.ID}).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("testkit: failed to grant %s on %s to %s: %v", strings.Join(actionSlugs, ", "), resourceSlug, user.Email, err)
+	}
+	messaging.Publish(context.Background(), messaging.EventPermissionUpdated, nil, permission)
+}
+
+// VerificationToken returns the pending email verification token of a user, which registration
+// would have emailed
+func (e *Env) VerificationToken(t testing.TB, email string) string {
+	t.Helper()
+	var token auth.EmailVerificationToken
+	err := database.DB.Where("email = ? AND verified = ? AND expires_at > now()", email, false).
+		Order("created_at DESC").First(&token).Error
+	if err != nil {
+		t.Fatalf("testkit: no verification token for %s: %v", email, err)
+	}
+	return token.Token
+}
+
+// User reloads a user by email
+func (e *Env) User(t testing.TB, email string) *models.User {
+	t.Helper()
+	var user models.User
+	if err := database.DB.Where("email = ?", email).First(&user).Error; err != nil {
+		t.Fatalf("testkit: user %s: %v", email, err)
+	}
+	return &user
+}
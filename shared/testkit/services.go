@@ -0,0 +1,265 @@
+package testkit
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// urlVariables name the variable holding the URL of each service, which is also the port it listens on
+var urlVariables = map[string]string{
+	"api-gateway":          "API_GATEWAY_URL",
+	"auth-service":         "AUTH_SERVICE_URL",
+	"permission-service":   "PERMISSION_SERVICE_URL",
+	"core-service":         "CORE_SERVICE_URL",
+	"notification-service": "NOTIFICATION_SERVICE_URL",
+	"document-service":     "DOCUMENT_SERVICE_URL",
+}
+
+// environment returns the variables the services and the test process run with. Every service gets
+// a free port, also the ones not started, so their URLs never reach a service of the developer.
+func (e *Env) environment(postgres, redis, minio *dependency, overrides map[string]string) (map[string]string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	smtpPort, err := freePort()
+	if err != nil {
+		return nil, err
+	}
+
+	vars := map[string]string{
+		"APP_ENV": "dev",
+
+		"DB_HOST":     postgres.host,
+		"DB_PORT":     postgres.port,
+		"DB_USER":     dbUser,
+		"DB_PASSWORD": dbPassword,
+		"DB_NAME":     dbName,
+		"DB_SSLMODE":  "disable",
+
+		"REDIS_HOST":     redis.host,
+		"REDIS_PORT":     redis.port,
+		"REDIS_PASSWORD": "",
+
+		"STORAGE_DRIVER":      "minio",
+		"MINIO_SERVER_URL":    fmt.Sprintf("http://%s", net.JoinHostPort(minio.host, minio.port)),
+		"MINIO_ROOT_USER":     minioUser,
+		"MINIO_ROOT_PASSWORD": minioSecret,
+		"MINIO_USE_SSL":       "false",
+		"MINIO_BUCKET_NAME":   "forgecrud-test",
+
+		"JWT_SECRET":           hex.EncodeToString(secret),
+		"SUPER_ADMIN_EMAIL":    AdminEmail,
+		"SUPER_ADMIN_PASSWORD": AdminPassword,
+
+		// Nothing listens on the SMTP port, emails fail without reaching anyone
+		"EMAIL_PROVIDER": "smtp",
+		"SMTP_HOST":      "127.0.0.1",
+		"SMTP_PORT":      smtpPort,
+
+		// Tests sign in and register far more often than people
+		"RATE_LIMIT_MAX_REQUESTS":          "100000",
+		"LOGIN_RATE_LIMIT_MAX_ATTEMPTS":    "100000",
+		"REGISTER_RATE_LIMIT_MAX_ATTEMPTS": "100000",
+
+		"SHUTDOWN_DRAIN_SECONDS":   "0",
+		"SHUTDOWN_TIMEOUT_SECONDS": "5",
+		"LOCAL_STORAGE_PATH":       filepath.Join(e.workDir, "documents"),
+		"BACKUP_PATH":              filepath.Join(e.workDir, "backups"),
+	}
+	for service, variable := range urlVariables {
+		port, err := freePort()
+		if err != nil {
+			return nil, err
+		}
+		vars[variable] = "http://127.0.0.1:" + port
+		e.urls[service] = vars[variable]
+	}
+	e.GatewayURL = e.urls["api-gateway"]
+
+	for name, value := range overrides {
+		vars[name] = value
+	}
+	return vars, nil
+}
+
+// freePort returns a port nothing listens on at the moment
+func freePort() (string, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+	defer listener.Close()
+	_, port, err := net.SplitHostPort(listener.Addr().String())
+	return port, err
+}
+
+// buildServices compiles the services from the module the tests run in
+func buildServices(ctx context.Context, dir string, services []string) (map[string]string, error) {
+	root, err := moduleRoot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	binaries := map[string]string{}
+	for _, service := range services {
+		if _, ok := urlVariables[service]; !ok {
+			return nil, fmt.Errorf("testkit: unknown service %q", service)
+		}
+		binary := filepath.Join(dir, "bin", service)
+		build := exec.CommandContext(ctx, "go", "build", "-o", binary, "./"+service)
+		build.Dir = root
+		if output, err := build.CombinedOutput(); err != nil {
+			return nil, fmt.Errorf("testkit: failed to build %s: %v\n%s", service, err, output)
+		}
+		binaries[service] = binary
+	}
+	return binaries, nil
+}
+
+func moduleRoot(ctx context.Context) (string, error) {
+	output, err := exec.CommandContext(ctx, "go", "env", "GOMOD").Output()
+	if err != nil {
+		return "", fmt.Errorf("testkit: failed to find the module: %w", err)
+	}
+	goMod := strings.TrimSpace(string(output))
+	if goMod == "" || goMod == os.DevNull {
+		return "", fmt.Errorf("testkit: tests must run inside the module")
+	}
+	return filepath.Dir(goMod), nil
+}
+
+// process is a running service
+type process struct {
+	name   string
+	cmd    *exec.Cmd
+	output *logBuffer
+	exited chan struct{}
+}
+
+// runService starts a service and waits until it answers /health/ready. It runs in a directory of its
+// own, so no environment file of the developer is read.
+func (e *Env) runService(ctx context.Context, service, binary string, options Options) (*process, error) {
+	dir := filepath.Join(e.workDir, service)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command(binary)
+	cmd.Dir = dir
+	cmd.Env = os.Environ()
+	for name, value := range e.vars {
+		cmd.Env = append(cmd.Env, name+"="+value)
+	}
+	p := &process{name: service, cmd: cmd, output: &logBuffer{}, exited: make(chan struct{})}
+	var output io.Writer = p.output
+	if options.Verbose {
+		output = io.MultiWriter(output, os.Stderr)
+	}
+	cmd.Stdout, cmd.Stderr = output, output
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("testkit: failed to start %s: %w", service, err)
+	}
+	go func() {
+		cmd.Wait()
+		close(p.exited)
+	}()
+
+	if err := waitReady(ctx, p, e.urls[service]+"/health/ready", options.ReadyTimeout); err != nil {
+		return p, fmt.Errorf("testkit: %s: %w\n%s", service, err, p.logs())
+	}
+	log.Printf("🧪 %s ready on %s", service, e.urls[service])
+	return p, nil
+}
+
+// Logs returns the last output of a service, to explain a failing test
+func (e *Env) Logs(service string) string {
+	for _, p := range e.processes {
+		if p.name == service {
+			return p.logs()
+		}
+	}
+	return ""
+}
+
+func waitReady(ctx context.Context, p *process, url string, timeout time.Duration) error {
+	client := &http.Client{Timeout: 2 * time.Second}
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		select {
+		case <-p.exited:
+			return fmt.Errorf("exited with %s", p.cmd.ProcessState)
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		if response, err := client.Get(url); err == nil {
+			response.Body.Close()
+			if response.StatusCode == http.StatusOK {
+				return nil
+			}
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+	return fmt.Errorf("not ready after %s", timeout)
+}
+
+// stop shuts the service down gracefully, and kills it when it takes too long
+func (p *process) stop() {
+	select {
+	case <-p.exited:
+		return
+	default:
+	}
+	p.cmd.Process.Signal(syscall.SIGTERM)
+	select {
+	case <-p.exited:
+	case <-time.After(15 * time.Second):
+		log.Printf("⚠️  testkit: %s did not stop in time, killing it", p.name)
+		p.cmd.Process.Kill()
+		<-p.exited
+	}
+}
+
+// logs returns the last output of the service
+func (p *process) logs() string {
+	return p.output.String()
+}
+
+// logBuffer keeps the last logBufferSize bytes written to it
+type logBuffer struct {
+	mutex  sync.Mutex
+	buffer bytes.Buffer
+}
+
+const logBufferSize = 256 * 1024
+
+func (b *logBuffer) Write(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.buffer.Write(p)
+	if excess := b.buffer.Len() - logBufferSize; excess > 0 {
+		b.buffer.Next(excess)
+	}
+	return len(p), nil
+}
+
+func (b *logBuffer) String() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buffer.String()
+}
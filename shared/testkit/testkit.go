@@ -0,0 +1,202 @@
+// Package testkit runs the platform against throwaway dependencies for end-to-end tests. Start
+// launches Postgres, Redis and MinIO in containers with testcontainers, migrates and seeds the
+// database, then builds the services from the tree and runs them on free ports, so a test talks to
+// the gateway exactly like a client would. Tests using it carry the integration build tag, so
+// `go test ./...` stays fast and needs no Docker:
+//
+//	//go:build integration
+//
+//	var env *testkit.Env
+//
+//	func TestMain(m *testing.M) {
+//		var err error
+//		env, err = testkit.Start(context.Background(), testkit.Options{})
+//		if errors.Is(err, testkit.ErrDockerUnavailable) {
+//			log.Printf("skipping integration tests: %v", err)
+//			os.Exit(0)
+//		} else if err != nil {
+//			log.Fatal(err)
+//		}
+//		code := m.Run()
+//		env.Close()
+//		os.Exit(code)
+//	}
+//
+// Each service keeps process-wide state (configuration, database pool, event bus, scheduler), so the
+// services run as processes of their own; the test process shares the database with them through the
+// shared/database package, for fixtures. Only one Env may run per test binary.
+package testkit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"forgecrud-backend/shared/config"
+	"forgecrud-backend/shared/database"
+	"forgecrud-backend/shared/messaging"
+)
+
+// Services are the services Start runs by default, the gateway last since it proxies to the others
+var Services = []string{"auth-service", "permission-service", "core-service", "notification-service", "document-service", "api-gateway"}
+
+// Credentials of the super admin Start creates
+const (
+	AdminEmail    = "admin@forgecrud.test"
+	AdminPassword = "Admin#Test2024"
+)
+
+// ErrDockerUnavailable is returned by Start when no Docker daemon is reachable; callers usually skip
+// their tests then
+var ErrDockerUnavailable = errors.New("testkit: docker is not available")
+
+// Options configures Start
+type Options struct {
+	Services     []string          // Services to run, Services by default; the dependencies always start
+	Env          map[string]string // Variables set for the services and the test process, over the defaults
+	ReadyTimeout time.Duration     // How long each service may take to answer /health/ready, 60s by default
+	Verbose      bool              // Stream the service logs to stderr instead of only keeping them on failure
+}
+
+// Env is a running platform
+type Env struct {
+	GatewayURL string
+	urls       map[string]string // Base URL of each service
+	vars       map[string]string // Variables the services run with
+	workDir    string
+	messaging  bool
+	containers []*dependency
+	processes  []*process
+	closeOnce  sync.Once
+}
+
+var started sync.Mutex
+
+// Start launches the dependencies, prepares the database and runs the services. Close stops
+// everything it started, also after Start failed halfway.
+func Start(ctx context.Context, options Options) (*Env, error) {
+	if !started.TryLock() {
+		return nil, errors.New("testkit: an environment is already running in this process")
+	}
+	if err := checkDocker(ctx); err != nil {
+		started.Unlock()
+		return nil, fmt.Errorf("%w: %v", ErrDockerUnavailable, err)
+	}
+	if len(options.Services) == 0 {
+		options.Services = Services
+	}
+	if options.ReadyTimeout == 0 {
+		options.ReadyTimeout = 60 * time.Second
+	}
+
+	workDir, err := os.MkdirTemp("", "forgecrud-testkit-")
+	if err != nil {
+		started.Unlock()
+		return nil, err
+	}
+	env := &Env{urls: map[string]string{}, workDir: workDir}
+
+	if err := env.start(ctx, options); err != nil {
+		env.Close()
+		return nil, err
+	}
+	return env, nil
+}
+
+func (e *Env) start(ctx context.Context, options Options) error {
+	log.Println("🧪 Starting Postgres, Redis and MinIO...")
+	postgres, err := startPostgres(ctx)
+	if err != nil {
+		return fmt.Errorf("testkit: postgres: %w", err)
+	}
+	e.containers = append(e.containers, postgres)
+	redis, err := startRedis(ctx)
+	if err != nil {
+		return fmt.Errorf("testkit: redis: %w", err)
+	}
+	e.containers = append(e.containers, redis)
+	minio, err := startMinIO(ctx)
+	if err != nil {
+		return fmt.Errorf("testkit: minio: %w", err)
+	}
+	e.containers = append(e.containers, minio)
+
+	if e.vars, err = e.environment(postgres, redis, minio, options.Env); err != nil {
+		return err
+	}
+
+	// The test process uses the same configuration, for fixtures
+	for name, value := range e.vars {
+		if err := config.Setenv(name, value); err != nil {
+			return err
+		}
+	}
+	config.LoadConfig()
+	if err := database.InitDatabase(); err != nil {
+		return fmt.Errorf("testkit: migrate: %w", err)
+	}
+	if err := seed(); err != nil {
+		return fmt.Errorf("testkit: seed: %w", err)
+	}
+	// Fixtures publish their changes like the services, so caches of the services are invalidated
+	if err := messaging.Init("testkit"); err != nil {
+		return fmt.Errorf("testkit: event bus: %w", err)
+	}
+	e.messaging = true
+
+	log.Printf("🧪 Building %s...", strings.Join(options.Services, ", "))
+	binaries, err := buildServices(ctx, e.workDir, options.Services)
+	if err != nil {
+		return err
+	}
+	for _, service := range options.Services {
+		process, err := e.runService(ctx, service, binaries[service], options)
+		if process != nil {
+			e.processes = append(e.processes, process)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// URL returns the base URL of a service, e.g. URL("document-service")
+func (e *Env) URL(service string) string {
+	return e.urls[service]
+}
+
+// Var returns a variable the services run with, e.g. Var("MINIO_BUCKET_NAME")
+func (e *Env) Var(name string) string {
+	return e.vars[name]
+}
+
+// Close stops the services, closes the database and removes the containers
+func (e *Env) Close() {
+	e.closeOnce.Do(func() {
+		for i := len(e.processes) - 1; i >= 0; i-- {
+			e.processes[i].stop()
+		}
+		if e.messaging {
+			messaging.Close()
+		}
+		if database.DB != nil {
+			database.CloseDatabase()
+			database.DB = nil
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		for _, container := range e.containers {
+			if err := container.terminate(ctx); err != nil {
+				log.Printf("⚠️  testkit: failed to remove the %s container: %v", container.name, err)
+			}
+		}
+		os.RemoveAll(e.workDir)
+		started.Unlock()
+	})
+}
@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+
+	"forgecrud-backend/shared/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Headers set by the API gateway from the verified JWT and signed together, so a client reaching a
+// service directly cannot act as another user or organization
+const (
+	UserIDHeader             = "X-User-ID"
+	OrganizationIDHeader     = "X-Organization-ID"
+	TenantBypassHeader       = "X-Tenant-Bypass"    // "true" for super admins, who see every organization
+	ForwardedSignatureHeader = "X-Tenant-Signature" // hex(HMAC-SHA256(FORWARDED_IP_SECRET, user ID, organization ID and bypass))
+)
+
+// GetActorID returns the ID of the user performing the request, as forwarded by the gateway. Requests
+// without the gateway's signature have no actor.
+func GetActorID(c *gin.Context) *uuid.UUID {
+	if !ForwardedHeadersVerified(c.Request.Header) {
+		return nil
+	}
+	actorID, err := uuid.Parse(c.GetHeader(UserIDHeader))
+	if err != nil {
+		return nil
+	}
+	return &actorID
+}
+
+// SignForwardedHeaders signs the user, organization and bypass headers of a request to a service
+func SignForwardedHeaders(header http.Header) {
+	header.Set(ForwardedSignatureHeader, hex.EncodeToString(forwardedMAC(header)))
+}
+
+// ForwardedHeadersVerified reports whether the user, organization and bypass headers carry the
+// gateway's signature
+func ForwardedHeadersVerified(header http.Header) bool {
+	signature, err := hex.DecodeString(header.Get(ForwardedSignatureHeader))
+	return err == nil && hmac.Equal(signature, forwardedMAC(header))
+}
+
+// forwardedMAC signs the user, organization and bypass headers together, so none of them can be swapped
+func forwardedMAC(header http.Header) []byte {
+	cfg := config.GetConfig()
+	secret := cfg.ForwardedIPSecret
+	if secret == "" {
+		secret = cfg.JWTSecret
+	}
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(header.Get(UserIDHeader) + "\n" + header.Get(OrganizationIDHeader) + "\n" + header.Get(TenantBypassHeader)))
+	return h.Sum(nil)
+}
@@ -69,6 +69,10 @@ func VerifyEmailToken(db *gorm.DB, token string) (*models.User, error) {
 	}
 
 	verificationToken.User.EmailVerified = true
+	// Verifying the email finishes onboarding unless the user still has to set a password
+	if verificationToken.User.Status == models.UserStatusPendingVerification {
+		verificationToken.User.Status = models.OnboardedUserStatus(&verificationToken.User)
+	}
 	if err := db.Save(&verificationToken.User).Error; err != nil {
 		return nil, fmt.Errorf("failed to verify user email: %w", err)
 	}
@@ -1,8 +1,9 @@
 package utils
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
-	"strconv"
 	"time"
 
 	"forgecrud-backend/shared/config"
@@ -16,6 +17,9 @@ type Claims struct {
 	Email          string `json:"email"`
 	OrganizationID string `json:"organization_id"`
 	RoleID         string `json:"role_id"`
+	SessionID      string `json:"sid,omitempty"` // Session of the login, revoked when the session is terminated
+	// Set while the user must change their password, the token only works for changing it
+	MustResetPassword bool `json:"pwd_reset,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -31,43 +35,32 @@ func getJWTSecret() string {
 
 // GetJWTExpireDuration gets JWT expiration duration from config
 func GetJWTExpireDuration() time.Duration {
-	cfg := config.GetConfig()
-	if cfg.JWTExpireHours == "" {
-		return 24 * time.Hour
-	}
-
-	hours, err := strconv.Atoi(cfg.JWTExpireHours)
-	if err != nil {
-		return 24 * time.Hour
-	}
-
-	return time.Duration(hours) * time.Hour
+	return time.Duration(config.GetConfig().JWTExpireHours) * time.Hour
 }
 
 // GetJWTRefreshExpireDuration gets JWT refresh token expiration duration from config
 func GetJWTRefreshExpireDuration() time.Duration {
-	cfg := config.GetConfig()
-	if cfg.JWTRefreshExpireDays == "" {
-		return 7 * 24 * time.Hour
-	}
-
-	days, err := strconv.Atoi(cfg.JWTRefreshExpireDays)
-	if err != nil {
-		return 7 * 24 * time.Hour
-	}
-
-	return time.Duration(days) * 24 * time.Hour
+	return time.Duration(config.GetConfig().JWTRefreshExpireDays) * 24 * time.Hour
 }
 
 // Generate JWT token
 func GenerateJWT(userID uuid.UUID, email string, organizationID uuid.UUID, roleID uuid.UUID) (string, error) {
+	return GenerateSessionJWT(userID, email, organizationID, roleID, "", false)
+}
+
+// GenerateSessionJWT generates an access token of a session, which stops working when the session
+// is terminated. Tokens of users who must reset their password are refused everywhere except the
+// change-password endpoint.
+func GenerateSessionJWT(userID uuid.UUID, email string, organizationID uuid.UUID, roleID uuid.UUID, sessionID string, mustResetPassword bool) (string, error) {
 	expireDuration := GetJWTExpireDuration()
 
 	claims := Claims{
-		UserID:         userID.String(),
-		Email:          email,
-		OrganizationID: organizationID.String(),
-		RoleID:         roleID.String(),
+		UserID:            userID.String(),
+		Email:             email,
+		OrganizationID:    organizationID.String(),
+		RoleID:            roleID.String(),
+		SessionID:         sessionID,
+		MustResetPassword: mustResetPassword,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expireDuration)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -117,6 +110,13 @@ func ValidateJWT(tokenString string) (*Claims, error) {
 	return nil, errors.New("invalid token")
 }
 
+// HashToken returns the key sessions and blacklisted tokens are stored under. It hashes the whole
+// token: HS256 tokens all start with the same header, so a prefix can't tell them apart
+func HashToken(tokenString string) string {
+	sum := sha256.Sum256([]byte(tokenString))
+	return hex.EncodeToString(sum[:])
+}
+
 // Check if JWT token is expired
 func IsTokenExpired(tokenString string) bool {
 	claims, err := ValidateJWT(tokenString)
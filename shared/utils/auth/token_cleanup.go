@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"time"
+
+	"forgecrud-backend/shared/database/models/auth"
+
+	"gorm.io/gorm"
+)
+
+// CleanupExpiredAuthTokens removes expired email verification and password reset tokens, and
+// blacklisted tokens past their expiry, which the JWT check rejects on its own. It returns the number of
+// removed rows by table.
+func CleanupExpiredAuthTokens(db *gorm.DB) (map[string]int64, error) {
+	now := time.Now()
+	removed := make(map[string]int64)
+
+	tables := []struct {
+		name  string
+		model interface{}
+	}{
+		{"email_verification_tokens", &auth.EmailVerificationToken{}},
+		{"password_reset_tokens", &auth.PasswordResetToken{}},
+		{"blacklisted_tokens", &auth.BlacklistedToken{}},
+	}
+	for _, table := range tables {
+		result := db.Where("expires_at < ?", now).Delete(table.model)
+		if result.Error != nil {
+			return removed, result.Error
+		}
+		removed[table.name] = result.RowsAffected
+	}
+	return removed, nil
+}
@@ -5,9 +5,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
-	"strconv"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
 
 	"forgecrud-backend/shared/config"
@@ -40,17 +40,11 @@ var (
 func InitCacheManager() error {
 	cfg := config.GetConfig()
 
-	redisDB, err := strconv.Atoi(cfg.RedisDB)
-	if err != nil {
-		log.Printf("❌ Invalid Redis DB number: %s, using default 0", cfg.RedisDB)
-		redisDB = 0
-	}
-
 	// Create Redis client
 	client := redis.NewClient(&redis.Options{
 		Addr:     fmt.Sprintf("%s:%s", cfg.RedisHost, cfg.RedisPort),
 		Password: cfg.RedisPassword,
-		DB:       redisDB,
+		DB:       cfg.RedisDB,
 	})
 
 	// Test connection
@@ -65,7 +59,7 @@ func InitCacheManager() error {
 	}
 
 	log.Printf("✅ Redis Cache Manager initialized successfully - %s:%s DB:%d",
-		cfg.RedisHost, cfg.RedisPort, redisDB)
+		cfg.RedisHost, cfg.RedisPort, cfg.RedisDB)
 
 	return nil
 }
@@ -169,6 +163,17 @@ func (cm *CacheManager) GetPermissionCache(userID uint, resource, action string)
 	return &data, true
 }
 
+// UserCacheID converts a user UUID to the numeric ID of its permission cache keys
+func UserCacheID(id uuid.UUID) uint {
+	var hash uint32
+	bytes := id[:]
+	for i := 0; i < len(bytes); i += 4 {
+		chunk := uint32(bytes[i])<<24 | uint32(bytes[i+1])<<16 | uint32(bytes[i+2])<<8 | uint32(bytes[i+3])
+		hash ^= chunk
+	}
+	return uint(hash)
+}
+
 // InvalidateUserPermissions invalidates all permissions for a user
 func (cm *CacheManager) InvalidateUserPermissions(userID uint) error {
 	if cm == nil || cm.client == nil {
@@ -315,6 +320,14 @@ func (cm *CacheManager) TestConnection() error {
 	return nil
 }
 
+// Ping checks the Redis connection
+func (cm *CacheManager) Ping(ctx context.Context) error {
+	if cm == nil || cm.client == nil {
+		return fmt.Errorf("cache manager not initialized")
+	}
+	return cm.client.Ping(ctx).Err()
+}
+
 // Close closes the cache manager connection
 func (cm *CacheManager) Close() error {
 	if cm != nil && cm.client != nil {
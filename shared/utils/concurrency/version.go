@@ -0,0 +1,77 @@
+// Package concurrency implements optimistic locking on top of a record's updated_at column.
+// The version of a record is its updated_at timestamp in microseconds (the precision PostgreSQL
+// stores); clients send it back through If-Match or a version field so concurrent edits are
+// detected instead of silently overwriting each other.
+package concurrency
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ErrVersionConflict is returned when a record changed after the client read it
+var ErrVersionConflict = errors.New("the record was modified by another request, reload it and try again")
+
+// Version returns the version of a record with the given updated_at
+func Version(updatedAt time.Time) string {
+	return strconv.FormatInt(updatedAt.UnixMicro(), 10)
+}
+
+// SetETag sets the ETag response header to the record's version
+func SetETag(ctx *gin.Context, updatedAt time.Time) {
+	ctx.Header("ETag", `"`+Version(updatedAt)+`"`)
+}
+
+// ExpectedVersion returns the version the client based its update on, taken from the If-Match header
+// or else from the request body. nil means the client sent no precondition and the update is unconditional.
+func ExpectedVersion(ctx *gin.Context, bodyVersion string) (*time.Time, error) {
+	version := strings.TrimSpace(ctx.GetHeader("If-Match"))
+	if version == "*" {
+		return nil, nil
+	}
+	version = strings.Trim(strings.TrimPrefix(version, "W/"), `"`)
+	if version == "" {
+		version = strings.TrimSpace(bodyVersion)
+	}
+	if version == "" {
+		return nil, nil
+	}
+
+	micros, err := strconv.ParseInt(version, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid version %q", version)
+	}
+	expected := time.UnixMicro(micros).UTC()
+	return &expected, nil
+}
+
+// CheckVersion returns ErrVersionConflict when the record's current updated_at differs from the expected version
+func CheckVersion(updatedAt time.Time, expected *time.Time) error {
+	if expected != nil && updatedAt.UnixMicro() != expected.UnixMicro() {
+		return ErrVersionConflict
+	}
+	return nil
+}
+
+// Updates applies the updates to the model only if it still has the expected version. The check is part of
+// the UPDATE statement, so a concurrent write between reading and updating is detected as well.
+func Updates(tx *gorm.DB, model interface{}, expected *time.Time, updates interface{}) error {
+	if expected == nil {
+		return tx.Model(model).Updates(updates).Error
+	}
+
+	result := tx.Model(model).Where("updated_at = ?", *expected).Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrVersionConflict
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package document
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// ArchiveEntryPath splits the name of a ZIP entry into its folder names and file name. Entries that
+// would be extracted outside the target folder (absolute paths, "..", backslashes) are rejected
+// instead of cleaned, so a malicious archive fails as a whole.
+func ArchiveEntryPath(name string) ([]string, error) {
+	if strings.Contains(name, "\\") {
+		return nil, fmt.Errorf("entry %q contains a backslash", name)
+	}
+	if path.IsAbs(name) {
+		return nil, fmt.Errorf("entry %q has an absolute path", name)
+	}
+
+	var segments []string
+	for _, segment := range strings.Split(name, "/") {
+		switch segment {
+		case "", ".":
+			continue
+		case "..":
+			return nil, fmt.Errorf("entry %q points outside the folder", name)
+		}
+		segments = append(segments, segment)
+	}
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("entry %q has no name", name)
+	}
+
+	// Folder entries end with a slash, the last segment of other entries is the file name
+	folders := segments[:len(segments)-1]
+	if strings.HasSuffix(name, "/") {
+		folders = segments
+	} else if len(segments[len(segments)-1]) > 255 {
+		return nil, fmt.Errorf("entry %q: file name too long (max 255 characters)", name)
+	}
+	for _, folder := range folders {
+		if err := ValidateFolderName(folder); err != nil {
+			return nil, fmt.Errorf("entry %q: %v", name, err)
+		}
+	}
+	return segments, nil
+}
+
+// IsArchiveMetadata reports whether an entry holds metadata added by the archiver rather than a file
+// of the user, like the __MACOSX folder and .DS_Store files of macOS
+func IsArchiveMetadata(segments []string) bool {
+	if segments[0] == "__MACOSX" {
+		return true
+	}
+	name := segments[len(segments)-1]
+	return name == ".DS_Store" || name == "Thumbs.db"
+}
@@ -0,0 +1,61 @@
+package document
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestArchiveEntryPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		entry   string
+		want    []string
+		wantErr bool
+	}{
+		{"file at the root", "report.pdf", []string{"report.pdf"}, false},
+		{"nested file", "2024/q1/report.pdf", []string{"2024", "q1", "report.pdf"}, false},
+		{"folder entry", "2024/q1/", []string{"2024", "q1"}, false},
+		{"current directory segments", "./2024/./report.pdf", []string{"2024", "report.pdf"}, false},
+		{"repeated slashes", "2024//report.pdf", []string{"2024", "report.pdf"}, false},
+		{"parent directory", "../report.pdf", nil, true},
+		{"parent directory inside", "2024/../../report.pdf", nil, true},
+		{"absolute path", "/etc/passwd", nil, true},
+		{"backslash", "2024\\report.pdf", nil, true},
+		{"windows traversal", "..\\report.pdf", nil, true},
+		{"empty name", "", nil, true},
+		{"only dots", "./", nil, true},
+		{"file name too long", strings.Repeat("a", 256), nil, true},
+		{"file name at the limit", strings.Repeat("a", 255), []string{strings.Repeat("a", 255)}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ArchiveEntryPath(tt.entry)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ArchiveEntryPath(%q) error = %v, want error %v", tt.entry, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("ArchiveEntryPath(%q) = %q, want %q", tt.entry, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsArchiveMetadata(t *testing.T) {
+	tests := []struct {
+		segments []string
+		want     bool
+	}{
+		{[]string{"__MACOSX", "2024", "._report.pdf"}, true},
+		{[]string{"2024", ".DS_Store"}, true},
+		{[]string{"Thumbs.db"}, true},
+		{[]string{"2024", "report.pdf"}, false},
+	}
+
+	for _, tt := range tests {
+		if got := IsArchiveMetadata(tt.segments); got != tt.want {
+			t.Errorf("IsArchiveMetadata(%q) = %v, want %v", tt.segments, got, tt.want)
+		}
+	}
+}
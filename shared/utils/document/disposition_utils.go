@@ -0,0 +1,36 @@
+package document
+
+import (
+	"mime"
+	"strings"
+)
+
+// InlineAllowed reports whether a file of the MIME type may be displayed in the browser. Only media,
+// raster images and PDFs are; HTML, SVG and other types that can run scripts on the API origin are
+// always downloaded.
+func InlineAllowed(mimeType string) bool {
+	mediaType, _, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		return false
+	}
+	switch {
+	case strings.HasPrefix(mediaType, "video/"), strings.HasPrefix(mediaType, "audio/"):
+		return true
+	case strings.HasPrefix(mediaType, "image/"):
+		return mediaType != "image/svg+xml"
+	}
+	return mediaType == "application/pdf"
+}
+
+// ContentDisposition formats the Content-Disposition header of a file download. inline is only
+// honored for types InlineAllowed accepts. The file name is quoted, or encoded when it is not ASCII.
+func ContentDisposition(fileName, mimeType string, inline bool) string {
+	disposition := "attachment"
+	if inline && InlineAllowed(mimeType) {
+		disposition = "inline"
+	}
+	if header := mime.FormatMediaType(disposition, map[string]string{"filename": fileName}); header != "" {
+		return header
+	}
+	return disposition
+}
@@ -0,0 +1,33 @@
+package document
+
+import "testing"
+
+func TestContentDisposition(t *testing.T) {
+	tests := []struct {
+		name     string
+		fileName string
+		mimeType string
+		inline   bool
+		want     string
+	}{
+		{"download", "report.pdf", "application/pdf", false, `attachment; filename=report.pdf`},
+		{"inline pdf", "report.pdf", "application/pdf", true, `inline; filename=report.pdf`},
+		{"inline video", "clip.mp4", "video/mp4", true, `inline; filename=clip.mp4`},
+		{"inline audio with parameters", "a.ogg", "audio/ogg; codecs=opus", true, `inline; filename=a.ogg`},
+		{"inline image", "photo.png", "image/png", true, `inline; filename=photo.png`},
+		{"svg is downloaded", "logo.svg", "image/svg+xml", true, `attachment; filename=logo.svg`},
+		{"html is downloaded", "page.html", "text/html; charset=utf-8", true, `attachment; filename=page.html`},
+		{"invalid type is downloaded", "x", "not a type", true, `attachment; filename=x`},
+		{"quote in the name", `a"b.pdf`, "application/pdf", false, `attachment; filename="a\"b.pdf"`},
+		{"spaces in the name", "q1 report.pdf", "application/pdf", false, `attachment; filename="q1 report.pdf"`},
+		{"non-ASCII name", "rapor_ş.pdf", "application/pdf", false, `attachment; filename*=utf-8''rapor_%C5%9F.pdf`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ContentDisposition(tt.fileName, tt.mimeType, tt.inline); got != tt.want {
+				t.Fatalf("ContentDisposition(%q, %q, %v) = %s, want %s", tt.fileName, tt.mimeType, tt.inline, got, tt.want)
+			}
+		})
+	}
+}
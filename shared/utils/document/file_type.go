@@ -0,0 +1,142 @@
+package document
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+// SniffLength is the number of leading bytes the type of a file is detected from
+const SniffLength = 512
+
+// DefaultMimeType is the type of content that is not recognized
+const DefaultMimeType = "application/octet-stream"
+
+// executableSignatures are the leading bytes of executable formats http.DetectContentType does not know
+var executableSignatures = []struct {
+	prefix   []byte
+	mimeType string
+}{
+	{[]byte("MZ"), "application/x-msdownload"},
+	{[]byte("\x7fELF"), "application/x-executable"},
+	{[]byte("\xfe\xed\xfa\xce"), "application/x-mach-binary"},
+	{[]byte("\xfe\xed\xfa\xcf"), "application/x-mach-binary"},
+	{[]byte("\xce\xfa\xed\xfe"), "application/x-mach-binary"},
+	{[]byte("\xcf\xfa\xed\xfe"), "application/x-mach-binary"},
+	{[]byte("\xca\xfe\xba\xbe"), "application/java-vm"}, // Java class, or a universal Mach-O binary
+	{[]byte("#!"), "text/x-shellscript"},
+}
+
+// oleSignature starts the compound files of legacy Office documents and Windows installers
+var oleSignature = []byte("\xd0\xcf\x11\xe0\xa1\xb1\x1a\xe1")
+
+// zipTypes are the formats stored as ZIP archives, by extension
+var zipTypes = map[string]string{
+	".docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	".xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	".pptx": "application/vnd.openxmlformats-officedocument.presentationml.presentation",
+	".odt":  "application/vnd.oasis.opendocument.text",
+	".ods":  "application/vnd.oasis.opendocument.spreadsheet",
+	".odp":  "application/vnd.oasis.opendocument.presentation",
+	".epub": "application/epub+zip",
+	".jar":  "application/java-archive",
+	".apk":  "application/vnd.android.package-archive",
+}
+
+// oleTypes are the formats stored as OLE compound files, by extension
+var oleTypes = map[string]string{
+	".doc": "application/msword",
+	".xls": "application/vnd.ms-excel",
+	".ppt": "application/vnd.ms-powerpoint",
+	".msg": "application/vnd.ms-outlook",
+	".msi": "application/x-msi",
+}
+
+// textTypes are the text formats that cannot be told apart by their content, by extension
+var textTypes = map[string]string{
+	".csv":  "text/csv",
+	".md":   "text/markdown",
+	".json": "application/json",
+	".yaml": "application/yaml",
+	".yml":  "application/yaml",
+	".svg":  "image/svg+xml",
+	".js":   "text/javascript",
+	".mjs":  "text/javascript",
+	".vbs":  "text/vbscript",
+	".sh":   "text/x-shellscript",
+	".bat":  "application/x-bat",
+	".cmd":  "application/x-bat",
+	".ps1":  "text/x-powershell",
+	".hta":  "application/hta",
+	".wsf":  "application/x-wsf",
+	".reg":  "text/x-ms-regedit",
+}
+
+// SniffMimeType detects the MIME type of a file from its first bytes. The extension only refines
+// generic types like ZIP archives and plain text into the format they hold, it never turns binary
+// content into another type.
+func SniffMimeType(head []byte, fileName string) string {
+	extension := FileExtension(fileName)
+
+	for _, signature := range executableSignatures {
+		if bytes.HasPrefix(head, signature.prefix) {
+			return signature.mimeType
+		}
+	}
+	if bytes.HasPrefix(head, oleSignature) {
+		if mimeType, ok := oleTypes[extension]; ok {
+			return mimeType
+		}
+		return "application/x-ole-storage"
+	}
+
+	mimeType, _, err := mime.ParseMediaType(http.DetectContentType(head))
+	if err != nil {
+		return DefaultMimeType
+	}
+	switch mimeType {
+	case "application/zip":
+		if refined, ok := zipTypes[extension]; ok {
+			return refined
+		}
+	case "text/plain", "text/xml":
+		if refined, ok := textTypes[extension]; ok {
+			return refined
+		}
+	}
+	return mimeType
+}
+
+// DetectFileType sniffs the MIME type of a file from its first bytes and rewinds it
+func DetectFileType(file io.ReadSeeker, fileName string) (string, error) {
+	head := make([]byte, SniffLength)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return SniffMimeType(head[:n], fileName), nil
+}
+
+// FileExtension returns the lower case extension of a file name with its dot
+func FileExtension(fileName string) string {
+	return strings.ToLower(filepath.Ext(fileName))
+}
+
+// MimeTypeMatches reports whether a MIME type matches a pattern: the type itself, a whole class like
+// image/* or any type with *
+func MimeTypeMatches(mimeType, pattern string) bool {
+	mimeType, pattern = strings.ToLower(mimeType), strings.ToLower(strings.TrimSpace(pattern))
+	if pattern == "*" || pattern == "*/*" {
+		return true
+	}
+	if prefix, ok := strings.CutSuffix(pattern, "/*"); ok {
+		return strings.HasPrefix(mimeType, prefix+"/")
+	}
+	return mimeType == pattern
+}
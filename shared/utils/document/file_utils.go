@@ -2,11 +2,14 @@ package document
 
 import (
 	"crypto/md5"
+	"crypto/sha256"
 	"fmt"
 	"io"
 	"mime/multipart"
 	"path/filepath"
 	"strings"
+
+	"github.com/google/uuid"
 )
 
 // ValidateUploadedFile validates uploaded file
@@ -35,6 +38,19 @@ func CalculateFileChecksum(file multipart.File) (string, error) {
 	return fmt.Sprintf("%x", hash.Sum(nil)), nil
 }
 
+// CalculateContentHash calculates the SHA-256 the content store addresses files by
+func CalculateContentHash(file multipart.File) (string, error) {
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+
+	// Reset file pointer
+	file.Seek(0, 0)
+
+	return fmt.Sprintf("%x", hash.Sum(nil)), nil
+}
+
 // GenerateVersionedFileName generates versioned filename for MinIO
 func GenerateVersionedFileName(baseName string, version int) string {
 	ext := filepath.Ext(baseName)
@@ -48,6 +64,68 @@ func GenerateDisplayPath(folderPath, fileName string, version int) string {
 	return folderPath + versionedFileName
 }
 
+// QuarantinePrefix holds uploaded files until the malware scan finds them clean
+const QuarantinePrefix = "quarantine/"
+
+// QuarantineKey returns the key an object is stored at while it waits for its malware scan. Files of
+// an organization with its own storage are quarantined in that storage.
+func QuarantineKey(objectKey string) string {
+	if organizationID, key, ok := SplitOrganizationStorageKey(objectKey); ok {
+		return OrganizationStorageKey(organizationID, QuarantinePrefix+key)
+	}
+	return QuarantinePrefix + strings.TrimPrefix(objectKey, "/")
+}
+
+// OrganizationStoragePrefix holds the files stored in the own storage of an organization, under
+// orgs/<organization ID>/
+const OrganizationStoragePrefix = "orgs/"
+
+// OrganizationStorageKey returns the key of a file in the own storage of an organization
+func OrganizationStorageKey(organizationID uuid.UUID, objectKey string) string {
+	return OrganizationStoragePrefix + organizationID.String() + "/" + strings.TrimPrefix(objectKey, "/")
+}
+
+// SplitOrganizationStorageKey returns the organization and the key within its storage of a file in the
+// own storage of an organization. ok is false for files in the default storage.
+func SplitOrganizationStorageKey(objectKey string) (organizationID uuid.UUID, key string, ok bool) {
+	rest, found := strings.CutPrefix(objectKey, OrganizationStoragePrefix)
+	if !found {
+		return uuid.Nil, "", false
+	}
+	id, key, found := strings.Cut(rest, "/")
+	if !found {
+		return uuid.Nil, "", false
+	}
+	organizationID, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, "", false
+	}
+	return organizationID, key, true
+}
+
+// IsOrganizationStorageKey reports whether a file is stored in the own storage of an organization
+func IsOrganizationStorageKey(objectKey string) bool {
+	_, _, ok := SplitOrganizationStorageKey(objectKey)
+	return ok
+}
+
+// ContentPrefix holds files stored once by the SHA-256 of their content
+const ContentPrefix = "content/"
+
+// ContentKey returns the key of a file in the content store
+func ContentKey(contentHash string) string {
+	return ContentPrefix + contentHash[:2] + "/" + contentHash
+}
+
+// StorageKey returns where the file of a document or version is stored: under its content hash once
+// it is in the content store, otherwise under its object key
+func StorageKey(objectKey, contentHash string) string {
+	if contentHash != "" {
+		return ContentKey(contentHash)
+	}
+	return objectKey
+}
+
 // GenerateMinIOPath generates MinIO object key
 func GenerateMinIOPath(folderPath, fileName string, version int) string {
 	versionedFileName := GenerateVersionedFileName(fileName, version)
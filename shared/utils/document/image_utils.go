@@ -0,0 +1,161 @@
+package document
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif" // register GIF decoder
+	"image/jpeg"
+	"image/png"
+	"io"
+)
+
+// Supported input formats for processed images
+var SupportedImageFormats = map[string]bool{
+	"jpeg": true,
+	"png":  true,
+	"gif":  true,
+}
+
+// MaxImagePixels rejects decompression bombs before the image is decoded
+const MaxImagePixels = 40 * 1000 * 1000
+
+// DecodeImage decodes a JPEG, PNG or GIF image after checking its format and dimensions
+func DecodeImage(r io.ReadSeeker) (image.Image, string, error) {
+	imageConfig, format, err := image.DecodeConfig(r)
+	if err != nil {
+		return nil, "", fmt.Errorf("file is not a supported image")
+	}
+	if !SupportedImageFormats[format] {
+		return nil, "", fmt.Errorf("unsupported image format: %s", format)
+	}
+	if imageConfig.Width*imageConfig.Height > MaxImagePixels {
+		return nil, "", fmt.Errorf("image dimensions %dx%d are too large", imageConfig.Width, imageConfig.Height)
+	}
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, "", err
+	}
+
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode image: %v", err)
+	}
+	return img, format, nil
+}
+
+// CropSquare returns the centered square of the image
+func CropSquare(img image.Image) image.Image {
+	return CropToAspect(img, 1, 1)
+}
+
+// CropToAspect returns the largest centered area of the image with the aspect ratio width:height
+func CropToAspect(img image.Image, width, height int) image.Image {
+	bounds := img.Bounds()
+	cropWidth := bounds.Dx()
+	cropHeight := cropWidth * height / width
+	if cropHeight > bounds.Dy() {
+		cropHeight = bounds.Dy()
+		cropWidth = cropHeight * width / height
+	}
+	if cropWidth < 1 {
+		cropWidth = 1
+	}
+	if cropHeight < 1 {
+		cropHeight = 1
+	}
+
+	x0 := bounds.Min.X + (bounds.Dx()-cropWidth)/2
+	y0 := bounds.Min.Y + (bounds.Dy()-cropHeight)/2
+
+	cropped := image.NewRGBA(image.Rect(0, 0, cropWidth, cropHeight))
+	draw.Draw(cropped, cropped.Bounds(), img, image.Point{X: x0, Y: y0}, draw.Src)
+	return cropped
+}
+
+// FitWithin returns the largest size with the aspect ratio of srcWidth x srcHeight that fits into
+// maxWidth x maxHeight without enlarging the source. A zero bound is not limiting.
+func FitWithin(srcWidth, srcHeight, maxWidth, maxHeight int) (int, int) {
+	scale := 1.0
+	if maxWidth > 0 && float64(maxWidth)/float64(srcWidth) < scale {
+		scale = float64(maxWidth) / float64(srcWidth)
+	}
+	if maxHeight > 0 && float64(maxHeight)/float64(srcHeight) < scale {
+		scale = float64(maxHeight) / float64(srcHeight)
+	}
+
+	width := int(float64(srcWidth)*scale + 0.5)
+	height := int(float64(srcHeight)*scale + 0.5)
+	if width < 1 {
+		width = 1
+	}
+	if height < 1 {
+		height = 1
+	}
+	return width, height
+}
+
+// ResizeImage scales the image to width x height by averaging the source pixels covered by each
+// target pixel (area averaging), which keeps downscaled images smooth
+func ResizeImage(img image.Image, width, height int) *image.RGBA {
+	bounds := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	scaleX := float64(bounds.Dx()) / float64(width)
+	scaleY := float64(bounds.Dy()) / float64(height)
+
+	for y := 0; y < height; y++ {
+		sy0 := bounds.Min.Y + int(float64(y)*scaleY)
+		sy1 := bounds.Min.Y + int(float64(y+1)*scaleY)
+		if sy1 <= sy0 {
+			sy1 = sy0 + 1
+		}
+
+		for x := 0; x < width; x++ {
+			sx0 := bounds.Min.X + int(float64(x)*scaleX)
+			sx1 := bounds.Min.X + int(float64(x+1)*scaleX)
+			if sx1 <= sx0 {
+				sx1 = sx0 + 1
+			}
+
+			var r, g, b, a, count uint64
+			for sy := sy0; sy < sy1 && sy < bounds.Max.Y; sy++ {
+				for sx := sx0; sx < sx1 && sx < bounds.Max.X; sx++ {
+					pr, pg, pb, pa := img.At(sx, sy).RGBA()
+					r += uint64(pr)
+					g += uint64(pg)
+					b += uint64(pb)
+					a += uint64(pa)
+					count++
+				}
+			}
+			if count == 0 {
+				continue
+			}
+
+			dst.Set(x, y, color.RGBA64{
+				R: uint16(r / count),
+				G: uint16(g / count),
+				B: uint16(b / count),
+				A: uint16(a / count),
+			})
+		}
+	}
+
+	return dst
+}
+
+// EncodeJPEG writes the image as JPEG, flattening transparent areas onto a white background
+func EncodeJPEG(w io.Writer, img image.Image, quality int) error {
+	flattened := image.NewRGBA(img.Bounds())
+	draw.Draw(flattened, flattened.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(flattened, flattened.Bounds(), img, img.Bounds().Min, draw.Over)
+
+	return jpeg.Encode(w, flattened, &jpeg.Options{Quality: quality})
+}
+
+// EncodePNG writes the image as PNG, keeping transparency
+func EncodePNG(w io.Writer, img image.Image) error {
+	return png.Encode(w, img)
+}
@@ -0,0 +1,140 @@
+package document
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"forgecrud-backend/shared/database/models"
+	"forgecrud-backend/shared/database/models/document"
+)
+
+// MetadataKeyPattern restricts keys so they can be used safely inside jsonb filter expressions
+var MetadataKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,62}$`)
+
+// ValidateMetadataField checks that a metadata field definition is internally consistent
+func ValidateMetadataField(field *document.MetadataField) error {
+	if !MetadataKeyPattern.MatchString(field.Key) {
+		return fmt.Errorf("key must start with a lowercase letter and contain only lowercase letters, digits and underscores")
+	}
+
+	switch field.Type {
+	case document.MetadataFieldTypeString, document.MetadataFieldTypeNumber, document.MetadataFieldTypeBoolean,
+		document.MetadataFieldTypeDate:
+		if field.Options != "" {
+			return fmt.Errorf("options are only supported for enum fields")
+		}
+	case document.MetadataFieldTypeEnum:
+		if len(MetadataOptions(field.Options)) == 0 {
+			return fmt.Errorf("enum fields require at least one option")
+		}
+	default:
+		return fmt.Errorf("type must be one of string, number, boolean, date, enum")
+	}
+	return nil
+}
+
+// ParseMetadata parses the metadata of a form field, a JSON object. Empty values are no metadata.
+func ParseMetadata(value string) (map[string]interface{}, error) {
+	metadata := map[string]interface{}{}
+	if strings.TrimSpace(value) == "" {
+		return metadata, nil
+	}
+	if err := json.Unmarshal([]byte(value), &metadata); err != nil {
+		return nil, fmt.Errorf("metadata must be a JSON object")
+	}
+	return metadata, nil
+}
+
+// ValidateMetadata checks metadata values against the fields that apply to the document's folder.
+// Nil values are dropped. Returns the cleaned metadata.
+func ValidateMetadata(fields []document.MetadataField, values map[string]interface{}) (models.JSONMap, error) {
+	cleaned := models.JSONMap{}
+	for key, value := range values {
+		if value != nil {
+			cleaned[key] = value
+		}
+	}
+
+	definitions := make(map[string]document.MetadataField, len(fields))
+	for _, field := range fields {
+		definitions[field.Key] = field
+	}
+
+	for key, value := range cleaned {
+		field, exists := definitions[key]
+		if !exists {
+			return nil, fmt.Errorf("unknown metadata field: %s", key)
+		}
+		if err := validateMetadataValue(field, value); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, field := range fields {
+		if _, exists := cleaned[field.Key]; field.Required && !exists {
+			return nil, fmt.Errorf("metadata field %s is required", field.Key)
+		}
+	}
+
+	return cleaned, nil
+}
+
+// validateMetadataValue validates a single value against its field definition
+func validateMetadataValue(field document.MetadataField, value interface{}) error {
+	switch field.Type {
+	case document.MetadataFieldTypeString:
+		if _, ok := value.(string); !ok {
+			return fmt.Errorf("metadata field %s must be a string", field.Key)
+		}
+	case document.MetadataFieldTypeNumber:
+		if _, ok := value.(float64); !ok {
+			return fmt.Errorf("metadata field %s must be a number", field.Key)
+		}
+	case document.MetadataFieldTypeBoolean:
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("metadata field %s must be a boolean", field.Key)
+		}
+	case document.MetadataFieldTypeDate:
+		str, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("metadata field %s must be a date string (YYYY-MM-DD)", field.Key)
+		}
+		if _, err := time.Parse("2006-01-02", str); err != nil {
+			return fmt.Errorf("metadata field %s must be a date string (YYYY-MM-DD)", field.Key)
+		}
+	case document.MetadataFieldTypeEnum:
+		if str, ok := value.(string); ok {
+			for _, option := range MetadataOptions(field.Options) {
+				if option == str {
+					return nil
+				}
+			}
+		}
+		return fmt.Errorf("metadata field %s must be one of: %s", field.Key, field.Options)
+	}
+	return nil
+}
+
+// MetadataOptions parses the comma separated options of an enum field
+func MetadataOptions(options string) []string {
+	var result []string
+	for _, option := range strings.Split(options, ",") {
+		if option = strings.TrimSpace(option); option != "" {
+			result = append(result, option)
+		}
+	}
+	return result
+}
+
+// MetadataColumn returns the SQL expression of a metadata field for filtering, typed so numbers
+// compare as numbers
+func MetadataColumn(table string, field document.MetadataField) string {
+	column := fmt.Sprintf("%s.metadata->>'%s'", table, field.Key)
+	if field.Type == document.MetadataFieldTypeNumber {
+		return "(" + column + ")::numeric"
+	}
+	return column
+}
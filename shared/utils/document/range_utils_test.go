@@ -0,0 +1,60 @@
+package document
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseRange(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		size   int64
+		want   *ByteRange
+		err    error
+	}{
+		{"no header", "", 100, nil, nil},
+		{"first bytes", "bytes=0-9", 100, &ByteRange{Start: 0, End: 9}, nil},
+		{"open end", "bytes=90-", 100, &ByteRange{Start: 90, End: 99}, nil},
+		{"suffix", "bytes=-10", 100, &ByteRange{Start: 90, End: 99}, nil},
+		{"suffix longer than the file", "bytes=-500", 100, &ByteRange{Start: 0, End: 99}, nil},
+		{"zero suffix", "bytes=-0", 100, nil, ErrRangeNotSatisfiable},
+		{"end past the size", "bytes=50-500", 100, &ByteRange{Start: 50, End: 99}, nil},
+		{"single byte", "bytes=99-99", 100, &ByteRange{Start: 99, End: 99}, nil},
+		{"start at the size", "bytes=100-", 100, nil, ErrRangeNotSatisfiable},
+		{"start past the size", "bytes=200-300", 100, nil, ErrRangeNotSatisfiable},
+		{"start after end", "bytes=20-10", 100, nil, nil},
+		{"surrounding spaces", "  bytes= 5-6 ", 100, &ByteRange{Start: 5, End: 6}, nil},
+		{"other unit", "items=0-9", 100, nil, nil},
+		{"unit without equals", "bytes 0-9", 100, nil, nil},
+		{"uppercase unit", "Bytes=0-9", 100, nil, nil},
+		{"no dash", "bytes=10", 100, nil, nil},
+		{"not a number", "bytes=a-b", 100, nil, nil},
+		{"negative start", "bytes=--5", 100, nil, nil},
+		{"multiple ranges", "bytes=0-9,20-29", 100, nil, nil},
+		{"zero-length file", "bytes=0-9", 0, nil, nil},
+		{"zero-length file suffix", "bytes=-1", 0, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRange(tt.header, tt.size)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("ParseRange(%q, %d) error = %v, want %v", tt.header, tt.size, err, tt.err)
+			}
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Fatalf("ParseRange(%q, %d) = %+v, want %+v", tt.header, tt.size, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestByteRangeHeaders(t *testing.T) {
+	r := ByteRange{Start: 90, End: 99}
+	if r.Length() != 10 {
+		t.Errorf("Length() = %d, want 10", r.Length())
+	}
+	if got := r.ContentRange(100); got != "bytes 90-99/100" {
+		t.Errorf("ContentRange(100) = %q, want %q", got, "bytes 90-99/100")
+	}
+}
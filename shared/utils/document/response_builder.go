@@ -1,6 +1,8 @@
 package document
 
 import (
+	"time"
+
 	"forgecrud-backend/shared/database/models/document"
 
 	"gorm.io/gorm"
@@ -8,21 +10,51 @@ import (
 
 // DocumentResponse API response structure
 type DocumentResponse struct {
-	ID           string `json:"id"`
-	Name         string `json:"name"`
-	OriginalName string `json:"original_name"`
-	Path         string `json:"path"`
-	Size         int64  `json:"size"`
-	MimeType     string `json:"mime_type"`
-	Extension    string `json:"extension"`
-	FolderID     string `json:"folder_id"`
-	OwnerID      string `json:"owner_id"`
-	OwnerType    string `json:"owner_type"`
-	Version      int    `json:"version"`
-	Tags         string `json:"tags"`
-	Description  string `json:"description"`
-	CreatedAt    string `json:"created_at"`
-	UpdatedAt    string `json:"updated_at"`
+	ID           string                 `json:"id"`
+	Name         string                 `json:"name"`
+	OriginalName string                 `json:"original_name"`
+	Path         string                 `json:"path"`
+	Size         int64                  `json:"size"`
+	MimeType     string                 `json:"mime_type"`
+	Extension    string                 `json:"extension"`
+	FolderID     string                 `json:"folder_id"`
+	OwnerID      string                 `json:"owner_id"`
+	OwnerType    string                 `json:"owner_type"`
+	Version      int                    `json:"version"`
+	Tags         []string               `json:"tags"`
+	Metadata     map[string]interface{} `json:"metadata"`
+	Description  string                 `json:"description"`
+	ScanStatus   string                 `json:"scan_status"`
+	IndexStatus  string                 `json:"index_status"`
+	CreatedAt    string                 `json:"created_at"`
+	UpdatedAt    string                 `json:"updated_at"`
+
+	Lock *DocumentLock `json:"lock"` // Null when the document is not checked out
+
+	Folder *FolderResponse `json:"folder,omitempty"` // Embedded with expand=folder
+}
+
+// DocumentLock is the check-out lock of a document
+type DocumentLock struct {
+	LockedBy  string `json:"locked_by"`
+	LockedAt  string `json:"locked_at"`
+	ExpiresAt string `json:"expires_at"`
+}
+
+// BuildDocumentLock returns the active lock of a document, nil when it is not locked
+func BuildDocumentLock(doc *document.Document, now time.Time) *DocumentLock {
+	holder := doc.ActiveLockHolder(now)
+	if holder == nil {
+		return nil
+	}
+	lock := &DocumentLock{
+		LockedBy:  holder.String(),
+		ExpiresAt: doc.LockExpiresAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	if doc.LockedAt != nil {
+		lock.LockedAt = doc.LockedAt.Format("2006-01-02T15:04:05Z07:00")
+	}
+	return lock
 }
 
 // BuildDocumentResponse creates a standardized document response
@@ -49,9 +81,21 @@ func BuildDocumentResponse(doc *document.Document, db *gorm.DB) DocumentResponse
 		OwnerID:      doc.UploadedBy.String(),
 		OwnerType:    doc.Folder.OwnerType,
 		Version:      version,
-		Tags:         doc.Tags,
+		Tags:         NormalizeTags(doc.Tags),
+		Metadata:     documentMetadata(doc),
 		Description:  doc.Description,
+		ScanStatus:   doc.ScanStatus,
+		IndexStatus:  doc.IndexStatus,
 		CreatedAt:    doc.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
 		UpdatedAt:    doc.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		Lock:         BuildDocumentLock(doc, time.Now()),
+	}
+}
+
+// documentMetadata returns the metadata of a document, an empty object when it has none
+func documentMetadata(doc *document.Document) map[string]interface{} {
+	if doc.Metadata == nil {
+		return map[string]interface{}{}
 	}
+	return doc.Metadata
 }
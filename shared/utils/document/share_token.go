@@ -0,0 +1,63 @@
+package document
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+
+	"forgecrud-backend/shared/config"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidShareToken is returned for share tokens that were not signed by this deployment
+var ErrInvalidShareToken = errors.New("invalid share token")
+
+// SignShareToken returns the public token of a share: its ID and an HMAC of the ID
+func SignShareToken(shareID uuid.UUID) string {
+	return base64.RawURLEncoding.EncodeToString(shareID[:]) + "." +
+		base64.RawURLEncoding.EncodeToString(shareSignature(shareID))
+}
+
+// ParseShareToken verifies a share token and returns the share ID it carries
+func ParseShareToken(token string) (uuid.UUID, error) {
+	encodedID, encodedSignature, found := strings.Cut(token, ".")
+	if !found {
+		return uuid.Nil, ErrInvalidShareToken
+	}
+
+	rawID, err := base64.RawURLEncoding.DecodeString(encodedID)
+	if err != nil {
+		return uuid.Nil, ErrInvalidShareToken
+	}
+	shareID, err := uuid.FromBytes(rawID)
+	if err != nil {
+		return uuid.Nil, ErrInvalidShareToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil || !hmac.Equal(signature, shareSignature(shareID)) {
+		return uuid.Nil, ErrInvalidShareToken
+	}
+	return shareID, nil
+}
+
+// ShareURL returns the public link of a share
+func ShareURL(shareID uuid.UUID) string {
+	return strings.TrimSuffix(config.GetConfig().ShareBaseURL, "/") + "/" + SignShareToken(shareID)
+}
+
+func shareSignature(shareID uuid.UUID) []byte {
+	cfg := config.GetConfig()
+	secret := cfg.ShareLinkSecret
+	if secret == "" {
+		secret = cfg.JWTSecret
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("document-share:"))
+	mac.Write(shareID[:])
+	return mac.Sum(nil)
+}
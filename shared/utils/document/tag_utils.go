@@ -0,0 +1,56 @@
+package document
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Limits of the tags of a document
+const (
+	MaxTags      = 50
+	MaxTagLength = 50
+)
+
+// ParseTags splits comma separated tags, as form fields and query parameters take them, dropping empty
+// and duplicate (case-insensitive) tags
+func ParseTags(tags string) []string {
+	return NormalizeTags(strings.Split(tags, ","))
+}
+
+// NormalizeTags trims tags and drops empty and duplicate (case-insensitive) tags, keeping their order
+func NormalizeTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	result := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		key := strings.ToLower(tag)
+		if tag == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, tag)
+	}
+	return result
+}
+
+// ValidateTags checks normalized tags against the tag limits
+func ValidateTags(tags []string) error {
+	if len(tags) > MaxTags {
+		return fmt.Errorf("a document can have at most %d tags", MaxTags)
+	}
+	for _, tag := range tags {
+		if len(tag) > MaxTagLength {
+			return fmt.Errorf("tag %q is too long (max %d characters)", tag, MaxTagLength)
+		}
+	}
+	return nil
+}
+
+// TagKeys lowercases tags like document_tag_keys, for matching them against stored tags
+func TagKeys(tags []string) []string {
+	keys := make([]string, len(tags))
+	for i, tag := range tags {
+		keys[i] = strings.ToLower(tag)
+	}
+	return keys
+}
@@ -0,0 +1,324 @@
+package document
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Line types of a diff
+const (
+	DiffEqual  = "equal"
+	DiffInsert = "insert"
+	DiffDelete = "delete"
+)
+
+// MaxDiffBytes caps the size of each file compared line by line
+const MaxDiffBytes = 2 * 1024 * 1024
+
+// maxDiffEdits bounds the work of a diff; files differing in more lines are compared as a whole
+// replacement of the lines between their common start and end
+const maxDiffEdits = 2000
+
+// DiffableExtensions are the text formats versions can be compared line by line for
+var DiffableExtensions = map[string]bool{
+	".txt": true, ".md": true, ".json": true, ".csv": true,
+}
+
+// DiffLine is a line of a diff with its number in the old and in the new text, 0 where it is missing
+type DiffLine struct {
+	Type    string `json:"type"`
+	OldLine int    `json:"old_line,omitempty"`
+	NewLine int    `json:"new_line,omitempty"`
+	Text    string `json:"text"`
+}
+
+// DiffHunk is a run of changed lines with their surrounding context
+type DiffHunk struct {
+	OldStart int        `json:"old_start"`
+	OldLines int        `json:"old_lines"`
+	NewStart int        `json:"new_start"`
+	NewLines int        `json:"new_lines"`
+	Lines    []DiffLine `json:"lines"`
+}
+
+// TextDiff is the line-level difference between two texts
+type TextDiff struct {
+	Added       int        `json:"added"`
+	Removed     int        `json:"removed"`
+	Unchanged   int        `json:"unchanged"`
+	Approximate bool       `json:"approximate"` // Too many changes to find the shortest diff
+	Hunks       []DiffHunk `json:"hunks"`
+}
+
+// Identical reports whether the texts have the same lines
+func (d TextDiff) Identical() bool {
+	return d.Added == 0 && d.Removed == 0
+}
+
+// SplitLines splits text into lines, accepting \n and \r\n line endings; a final line ending does
+// not start another line
+func SplitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	text = strings.TrimSuffix(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	return strings.Split(text, "\n")
+}
+
+// DiffText compares two texts line by line, grouping the changes in hunks with the given number
+// of unchanged context lines around them
+func DiffText(oldText, newText string, context int) TextDiff {
+	oldLines, newLines := SplitLines(oldText), SplitLines(newText)
+
+	// The common start and end are not part of the search for the shortest diff
+	prefix := 0
+	for prefix < len(oldLines) && prefix < len(newLines) && oldLines[prefix] == newLines[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(oldLines)-prefix && suffix < len(newLines)-prefix &&
+		oldLines[len(oldLines)-1-suffix] == newLines[len(newLines)-1-suffix] {
+		suffix++
+	}
+
+	var diff TextDiff
+	lines := make([]DiffLine, 0, len(oldLines)+len(newLines)-prefix-suffix)
+	for i := 0; i < prefix; i++ {
+		lines = append(lines, DiffLine{Type: DiffEqual, OldLine: i + 1, NewLine: i + 1, Text: oldLines[i]})
+	}
+
+	oldMiddle := oldLines[prefix : len(oldLines)-suffix]
+	newMiddle := newLines[prefix : len(newLines)-suffix]
+	edits, ok := shortestEdits(lineIDs(oldMiddle, newMiddle))
+	if !ok {
+		diff.Approximate = true
+		edits = replaceEdits(len(oldMiddle), len(newMiddle))
+	}
+	oldNumber, newNumber := prefix, prefix
+	for _, edit := range edits {
+		switch edit {
+		case DiffEqual:
+			oldNumber++
+			newNumber++
+			lines = append(lines, DiffLine{Type: DiffEqual, OldLine: oldNumber, NewLine: newNumber, Text: oldLines[oldNumber-1]})
+		case DiffDelete:
+			oldNumber++
+			lines = append(lines, DiffLine{Type: DiffDelete, OldLine: oldNumber, Text: oldLines[oldNumber-1]})
+		case DiffInsert:
+			newNumber++
+			lines = append(lines, DiffLine{Type: DiffInsert, NewLine: newNumber, Text: newLines[newNumber-1]})
+		}
+	}
+
+	for i := 0; i < suffix; i++ {
+		oldNumber++
+		newNumber++
+		lines = append(lines, DiffLine{Type: DiffEqual, OldLine: oldNumber, NewLine: newNumber, Text: oldLines[oldNumber-1]})
+	}
+
+	for _, line := range lines {
+		switch line.Type {
+		case DiffEqual:
+			diff.Unchanged++
+		case DiffDelete:
+			diff.Removed++
+		case DiffInsert:
+			diff.Added++
+		}
+	}
+	diff.Hunks = groupHunks(lines, context)
+	return diff
+}
+
+// lineIDs numbers the distinct lines of both texts, so the diff compares numbers instead of strings
+func lineIDs(oldLines, newLines []string) ([]int, []int) {
+	ids := make(map[string]int)
+	number := func(lines []string) []int {
+		numbered := make([]int, len(lines))
+		for i, line := range lines {
+			id, ok := ids[line]
+			if !ok {
+				id = len(ids)
+				ids[line] = id
+			}
+			numbered[i] = id
+		}
+		return numbered
+	}
+	return number(oldLines), number(newLines)
+}
+
+// shortestEdits finds the shortest edit script turning a into b with Myers' algorithm, as a list of
+// equal, delete and insert steps. It gives up past maxDiffEdits changes.
+func shortestEdits(a, b []int) ([]string, bool) {
+	n, m := len(a), len(b)
+	limit := min(n+m, maxDiffEdits)
+
+	// v[offset+k] is the furthest x reached on diagonal k = x - y; trace keeps v before every round
+	offset := limit + 1
+	v := make([]int, 2*offset+1)
+	var trace [][]int
+
+	for d := 0; d <= limit; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+
+			if x >= n && y >= m {
+				return backtrackEdits(trace, n, m), true
+			}
+		}
+	}
+	return nil, false
+}
+
+// backtrackEdits walks the rounds of shortestEdits back from the end of both texts
+func backtrackEdits(trace [][]int, n, m int) []string {
+	var edits []string
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		// trace[d] covers the diagonals -d to d
+		previous := func(k int) int { return trace[d][k+d] }
+
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && previous(k-1) < previous(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := previous(prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			edits = append(edits, DiffEqual)
+			x--
+			y--
+		}
+		if x == prevX {
+			edits = append(edits, DiffInsert)
+		} else {
+			edits = append(edits, DiffDelete)
+		}
+		x, y = prevX, prevY
+	}
+	for x > 0 && y > 0 {
+		edits = append(edits, DiffEqual)
+		x--
+		y--
+	}
+
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+	return edits
+}
+
+// replaceEdits deletes every old line and inserts every new one
+func replaceEdits(n, m int) []string {
+	edits := make([]string, 0, n+m)
+	for i := 0; i < n; i++ {
+		edits = append(edits, DiffDelete)
+	}
+	for i := 0; i < m; i++ {
+		edits = append(edits, DiffInsert)
+	}
+	return edits
+}
+
+// groupHunks keeps the changed lines and up to context unchanged lines around them, starting a new
+// hunk where more unchanged lines separate two changes
+func groupHunks(lines []DiffLine, context int) []DiffHunk {
+	hunks := []DiffHunk{}
+	start, end := -1, -1
+	// Lines of both texts before the hunk, which give its start where it has no line, as in unified diffs
+	oldBefore, newBefore, counted := 0, 0, 0
+	flush := func() {
+		if start < 0 {
+			return
+		}
+		for ; counted < start; counted++ {
+			if lines[counted].OldLine > 0 {
+				oldBefore = lines[counted].OldLine
+			}
+			if lines[counted].NewLine > 0 {
+				newBefore = lines[counted].NewLine
+			}
+		}
+		hunk := DiffHunk{OldStart: oldBefore, NewStart: newBefore, Lines: lines[start:end]}
+		for _, line := range hunk.Lines {
+			if line.OldLine > 0 {
+				hunk.OldLines++
+			}
+			if line.NewLine > 0 {
+				hunk.NewLines++
+			}
+		}
+		if hunk.OldLines > 0 {
+			hunk.OldStart++
+		}
+		if hunk.NewLines > 0 {
+			hunk.NewStart++
+		}
+		hunks = append(hunks, hunk)
+		start, end = -1, -1
+	}
+
+	for i, line := range lines {
+		if line.Type == DiffEqual {
+			continue
+		}
+		from, to := max(i-context, 0), min(i+context+1, len(lines))
+		if start >= 0 && from > end {
+			flush()
+		}
+		if start < 0 {
+			start = from
+		}
+		end = max(end, to)
+	}
+	flush()
+	return hunks
+}
+
+// UnifiedDiff formats the diff like diff -u, with the names of the old and new text in its header
+func UnifiedDiff(diff TextDiff, oldName, newName string) string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "--- %s\n+++ %s\n", oldName, newName)
+	for _, hunk := range diff.Hunks {
+		fmt.Fprintf(&builder, "@@ -%s +%s @@\n", hunkRange(hunk.OldStart, hunk.OldLines), hunkRange(hunk.NewStart, hunk.NewLines))
+		for _, line := range hunk.Lines {
+			switch line.Type {
+			case DiffEqual:
+				builder.WriteString(" ")
+			case DiffDelete:
+				builder.WriteString("-")
+			case DiffInsert:
+				builder.WriteString("+")
+			}
+			builder.WriteString(line.Text)
+			builder.WriteString("\n")
+		}
+	}
+	return builder.String()
+}
+
+// hunkRange formats the start and length of a hunk, leaving out a length of 1
+func hunkRange(start, lines int) string {
+	if lines == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, lines)
+}
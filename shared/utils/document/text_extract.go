@@ -0,0 +1,233 @@
+package document
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"encoding/xml"
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrUnsupportedFormat is returned for files text cannot be extracted from
+var ErrUnsupportedFormat = errors.New("unsupported format for text extraction")
+
+// MaxExtractedTextBytes caps extracted text below PostgreSQL's 1MB tsvector limit
+const MaxExtractedTextBytes = 512 * 1024
+
+// plainTextExtensions are indexed as they are
+var plainTextExtensions = map[string]bool{
+	".txt": true, ".md": true, ".csv": true, ".tsv": true, ".json": true,
+	".xml": true, ".html": true, ".htm": true, ".log": true, ".yaml": true, ".yml": true,
+}
+
+// ImageExtensions are the file types text is extracted from with OCR
+var ImageExtensions = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".tif": true, ".tiff": true, ".bmp": true,
+}
+
+// ExtractText returns the text content of a TXT-like, DOCX or PDF file
+func ExtractText(data []byte, extension string) (string, error) {
+	extension = strings.ToLower(extension)
+
+	var text string
+	var err error
+	switch {
+	case plainTextExtensions[extension]:
+		text = string(data)
+	case extension == ".docx":
+		text, err = extractDOCXText(data)
+	case extension == ".pdf":
+		text, err = extractPDFText(data)
+	default:
+		return "", ErrUnsupportedFormat
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return TruncateText(text, MaxExtractedTextBytes), nil
+}
+
+// TruncateText returns valid UTF-8 text of at most maxBytes bytes
+func TruncateText(text string, maxBytes int) string {
+	text = strings.ToValidUTF8(text, " ")
+	if len(text) <= maxBytes {
+		return text
+	}
+	text = text[:maxBytes]
+	for !utf8.ValidString(text) {
+		text = text[:len(text)-1]
+	}
+	return text
+}
+
+// extractDOCXText reads the text runs of word/document.xml, one line per paragraph
+func extractDOCXText(data []byte) (string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", err
+	}
+
+	for _, file := range archive.File {
+		if file.Name != "word/document.xml" {
+			continue
+		}
+
+		reader, err := file.Open()
+		if err != nil {
+			return "", err
+		}
+		defer reader.Close()
+
+		var text strings.Builder
+		inText := false
+		decoder := xml.NewDecoder(io.LimitReader(reader, 16*MaxExtractedTextBytes))
+		for {
+			token, err := decoder.Token()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return "", err
+			}
+
+			switch t := token.(type) {
+			case xml.StartElement:
+				inText = t.Name.Local == "t"
+				if t.Name.Local == "tab" {
+					text.WriteString("\t")
+				}
+			case xml.EndElement:
+				inText = false
+				if t.Name.Local == "p" {
+					text.WriteString("\n")
+				}
+			case xml.CharData:
+				if inText {
+					text.Write(t)
+				}
+			}
+		}
+		return text.String(), nil
+	}
+
+	return "", errors.New("word/document.xml not found")
+}
+
+var (
+	pdfStreamPattern = regexp.MustCompile(`(?s)<<(.*?)>>\s*stream\r?\n`)
+	// pdfTextOperator matches string operands of the Tj, TJ, ' and " text operators
+	pdfTextOperator = regexp.MustCompile(`(?s)(\((?:\\.|[^\\)])*\)|\[(?:\\.|[^\]])*\])\s*(Tj|TJ|'|")|(T\*|Td|TD|ET)`)
+	pdfLiteral      = regexp.MustCompile(`\((?:\\.|[^\\)])*\)`)
+)
+
+// extractPDFText extracts the text shown by the content streams of a PDF. It handles uncompressed and
+// FlateDecode streams with literal strings, which covers PDFs with simple fonts; text in hex-encoded
+// (CID) fonts or scanned pages is not found.
+func extractPDFText(data []byte) (string, error) {
+	if !bytes.HasPrefix(data, []byte("%PDF")) {
+		return "", errors.New("not a PDF file")
+	}
+
+	var text strings.Builder
+	for _, match := range pdfStreamPattern.FindAllSubmatchIndex(data, -1) {
+		dictionary := data[match[2]:match[3]]
+		// The match may start at an earlier object's dictionary, keep only this stream's object
+		if i := bytes.LastIndex(dictionary, []byte(" obj")); i >= 0 {
+			dictionary = dictionary[i:]
+		}
+		start := match[1]
+		end := bytes.Index(data[start:], []byte("endstream"))
+		if end < 0 {
+			break
+		}
+		stream := data[start : start+end]
+
+		if bytes.Contains(dictionary, []byte("/FlateDecode")) {
+			inflated, err := io.ReadAll(io.LimitReader(flateReader(stream), 8*MaxExtractedTextBytes))
+			if err != nil && len(inflated) == 0 {
+				continue
+			}
+			stream = inflated
+		} else if bytes.Contains(dictionary, []byte("/Filter")) {
+			// Images and other encodings carry no text
+			continue
+		}
+
+		writePDFText(&text, stream)
+		if text.Len() > MaxExtractedTextBytes {
+			break
+		}
+	}
+
+	return text.String(), nil
+}
+
+func flateReader(stream []byte) io.Reader {
+	reader, err := zlib.NewReader(bytes.NewReader(stream))
+	if err != nil {
+		return bytes.NewReader(nil)
+	}
+	return reader
+}
+
+// writePDFText appends the strings shown by a content stream, breaking lines on text positioning operators
+func writePDFText(text *strings.Builder, stream []byte) {
+	for _, match := range pdfTextOperator.FindAllSubmatch(stream, -1) {
+		if len(match[3]) > 0 {
+			text.WriteString("\n")
+			continue
+		}
+
+		operand := match[1]
+		if operand[0] == '[' {
+			for _, literal := range pdfLiteral.FindAll(operand, -1) {
+				text.WriteString(decodePDFString(literal))
+			}
+		} else {
+			text.WriteString(decodePDFString(operand))
+		}
+		text.WriteString(" ")
+	}
+}
+
+// decodePDFString decodes a PDF literal string including its parentheses
+func decodePDFString(literal []byte) string {
+	literal = literal[1 : len(literal)-1]
+
+	var out strings.Builder
+	for i := 0; i < len(literal); i++ {
+		c := literal[i]
+		if c != '\\' || i+1 >= len(literal) {
+			// PDFDocEncoding matches Latin-1 for printable characters
+			out.WriteRune(rune(c))
+			continue
+		}
+
+		i++
+		switch literal[i] {
+		case 'n':
+			out.WriteByte('\n')
+		case 'r':
+			out.WriteByte('\r')
+		case 't':
+			out.WriteByte('\t')
+		case 'b', 'f':
+		case '0', '1', '2', '3', '4', '5', '6', '7':
+			value := 0
+			for j := 0; j < 3 && i < len(literal) && literal[i] >= '0' && literal[i] <= '7'; j++ {
+				value = value*8 + int(literal[i]-'0')
+				i++
+			}
+			i--
+			out.WriteRune(rune(value))
+		default:
+			out.WriteByte(literal[i])
+		}
+	}
+	return out.String()
+}
@@ -0,0 +1,10 @@
+package document
+
+// WebDAVPrefix is the path the folder hierarchy is served under over WebDAV
+const WebDAVPrefix = "/webdav"
+
+// WebDAVMethods are the HTTP methods WebDAV clients use, routed to the document service
+var WebDAVMethods = []string{
+	"OPTIONS", "GET", "HEAD", "PUT", "DELETE",
+	"MKCOL", "COPY", "MOVE", "LOCK", "UNLOCK", "PROPFIND", "PROPPATCH",
+}
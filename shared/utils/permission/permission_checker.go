@@ -2,10 +2,13 @@ package permission
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"time"
+
+	"forgecrud-backend/shared/clients"
 )
 
 // PermissionCheck represents a single permission check request
@@ -13,6 +16,8 @@ type PermissionCheck struct {
 	UserID       string `json:"user_id"`
 	ResourceSlug string `json:"resource_slug"`
 	ActionSlug   string `json:"action_slug"`
+	ObjectType   string `json:"object_type,omitempty"` // "folder" or "document"
+	ObjectID     string `json:"object_id,omitempty"`
 }
 
 // PermissionCheckResponse represents the response from permission service
@@ -46,31 +51,39 @@ type PermissionClient struct {
 // NewPermissionClient creates a new permission service client
 func NewPermissionClient(baseURL string) *PermissionClient {
 	return &PermissionClient{
-		baseURL: baseURL,
-		httpClient: &http.Client{
-			Timeout: 5 * time.Second,
-		},
+		baseURL:    baseURL,
+		httpClient: clients.NewHTTPClient("permissions", 5*time.Second),
 	}
 }
 
 // CheckPermission checks if user has permission for specific resource and action
 func (pc *PermissionClient) CheckPermission(userID, resourceSlug, actionSlug string) (bool, error) {
-	check := PermissionCheck{
+	return pc.check(PermissionCheck{
 		UserID:       userID,
 		ResourceSlug: resourceSlug,
 		ActionSlug:   actionSlug,
-	}
+	})
+}
 
+// CheckObjectPermission checks a resource and action on a folder or document; the object's access
+// grants decide when it has any
+func (pc *PermissionClient) CheckObjectPermission(userID, resourceSlug, actionSlug, objectType, objectID string) (bool, error) {
+	return pc.check(PermissionCheck{
+		UserID:       userID,
+		ResourceSlug: resourceSlug,
+		ActionSlug:   actionSlug,
+		ObjectType:   objectType,
+		ObjectID:     objectID,
+	})
+}
+
+func (pc *PermissionClient) check(check PermissionCheck) (bool, error) {
 	jsonData, err := json.Marshal(check)
 	if err != nil {
 		return false, fmt.Errorf("failed to marshal request: %v", err)
 	}
 
-	resp, err := pc.httpClient.Post(
-		fmt.Sprintf("%s/api/permissions/check", pc.baseURL),
-		"application/json",
-		bytes.NewBuffer(jsonData),
-	)
+	resp, err := pc.post("/api/permissions/check", jsonData)
 	if err != nil {
 		return false, fmt.Errorf("failed to make request: %v", err)
 	}
@@ -100,11 +113,7 @@ func (pc *PermissionClient) BatchCheckPermissions(userID string, checks []Resour
 		return nil, fmt.Errorf("failed to marshal request: %v", err)
 	}
 
-	resp, err := pc.httpClient.Post(
-		fmt.Sprintf("%s/api/permissions/batch-check", pc.baseURL),
-		"application/json",
-		bytes.NewBuffer(jsonData),
-	)
+	resp, err := pc.post("/api/permissions/batch-check", jsonData)
 	if err != nil {
 		return nil, fmt.Errorf("failed to make request: %v", err)
 	}
@@ -122,6 +131,16 @@ func (pc *PermissionClient) BatchCheckPermissions(userID string, checks []Resour
 	return result.Results, nil
 }
 
+// post sends a check; checks only read, so they are retried like idempotent calls
+func (pc *PermissionClient) post(path string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(clients.Retryable(context.Background()), http.MethodPost, pc.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return pc.httpClient.Do(req)
+}
+
 // Global permission client instance
 var defaultClient *PermissionClient
 
@@ -138,6 +157,14 @@ func CheckPermission(userID, resourceSlug, actionSlug string) (bool, error) {
 	return defaultClient.CheckPermission(userID, resourceSlug, actionSlug)
 }
 
+// CheckObjectPermission is a convenience function using the global client
+func CheckObjectPermission(userID, resourceSlug, actionSlug, objectType, objectID string) (bool, error) {
+	if defaultClient == nil {
+		return false, fmt.Errorf("permission client not initialized")
+	}
+	return defaultClient.CheckObjectPermission(userID, resourceSlug, actionSlug, objectType, objectID)
+}
+
 // BatchCheckPermissions is a convenience function using the global client
 func BatchCheckPermissions(userID string, checks []ResourceActionCheck) (map[string]bool, error) {
 	if defaultClient == nil {
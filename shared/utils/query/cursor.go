@@ -0,0 +1,88 @@
+package query
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CursorPaginationResponse represents keyset pagination metadata
+type CursorPaginationResponse struct {
+	Limit      int    `json:"limit"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasNext    bool   `json:"has_next"`
+}
+
+// cursorPosition is the decoded form of an opaque cursor: the sort key of the last row of a page
+type cursorPosition struct {
+	CreatedAt time.Time `json:"t"`
+	ID        string    `json:"id"`
+}
+
+// EncodeCursor builds the opaque cursor pointing after the row with the given created_at and id
+func EncodeCursor(createdAt time.Time, id string) string {
+	encoded, _ := json.Marshal(cursorPosition{CreatedAt: createdAt, ID: id})
+	return base64.RawURLEncoding.EncodeToString(encoded)
+}
+
+// decodeCursor parses an opaque cursor produced by EncodeCursor
+func decodeCursor(cursor string) (*cursorPosition, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	var position cursorPosition
+	if err := json.Unmarshal(raw, &position); err != nil || position.ID == "" {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	return &position, nil
+}
+
+// ApplyCursor applies keyset pagination ordered by created_at with id as tie breaker, which keeps the
+// order stable and avoids scanning skipped rows like OFFSET does. Order is ascending only when
+// sort[field]=created_at&sort[order]=asc is requested. table qualifies the columns for joined queries.
+// One row more than the limit is fetched so TrimCursorPage can tell whether a next page exists.
+func ApplyCursor(query *gorm.DB, params FilterParams, table string) (*gorm.DB, error) {
+	createdAtColumn, idColumn := "created_at", "id"
+	if table != "" {
+		createdAtColumn, idColumn = table+".created_at", table+".id"
+	}
+
+	direction, comparison := "DESC", "<"
+	if params.Sort.Field == "created_at" && params.Sort.Order == "asc" {
+		direction, comparison = "ASC", ">"
+	}
+
+	if params.Cursor != "" {
+		position, err := decodeCursor(params.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where(fmt.Sprintf("(%s, %s) %s (?, ?)", createdAtColumn, idColumn, comparison),
+			position.CreatedAt, position.ID)
+	}
+
+	return query.
+		Order(fmt.Sprintf("%s %s", createdAtColumn, direction)).
+		Order(fmt.Sprintf("%s %s", idColumn, direction)).
+		Limit(params.Limit + 1), nil
+}
+
+// TrimCursorPage drops the extra row fetched by ApplyCursor and builds the pagination metadata.
+// key returns the created_at and id of an item.
+func TrimCursorPage[T any](items []T, limit int, key func(T) (time.Time, string)) ([]T, CursorPaginationResponse) {
+	pagination := CursorPaginationResponse{Limit: limit}
+
+	if len(items) > limit {
+		items = items[:limit]
+		createdAt, id := key(items[len(items)-1])
+		pagination.HasNext = true
+		pagination.NextCursor = EncodeCursor(createdAt, id)
+	}
+
+	return items, pagination
+}
@@ -0,0 +1,54 @@
+package query
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	createdAt := time.Date(2024, 3, 1, 12, 30, 0, 123456789, time.UTC)
+	position, err := decodeCursor(EncodeCursor(createdAt, "8f14e45f-ceea-467a-9575-0a4d1e1a1c11"))
+	if err != nil {
+		t.Fatalf("decodeCursor() error = %v", err)
+	}
+	if !position.CreatedAt.Equal(createdAt) || position.ID != "8f14e45f-ceea-467a-9575-0a4d1e1a1c11" {
+		t.Fatalf("decodeCursor() = %+v, want %v and the id", position, createdAt)
+	}
+}
+
+func TestDecodeCursorRejectsInvalidCursors(t *testing.T) {
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{"not base64", "!!!"},
+		{"padded base64", base64.URLEncoding.EncodeToString([]byte(`{"t":"2024-03-01T00:00:00Z","id":"x"}`))},
+		{"not json", base64.RawURLEncoding.EncodeToString([]byte("created_at=2024"))},
+		{"without id", base64.RawURLEncoding.EncodeToString([]byte(`{"t":"2024-03-01T00:00:00Z"}`))},
+		{"invalid time", base64.RawURLEncoding.EncodeToString([]byte(`{"t":"yesterday","id":"x"}`))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if position, err := decodeCursor(tt.cursor); err == nil {
+				t.Fatalf("decodeCursor(%q) = %+v, want an error", tt.cursor, position)
+			}
+		})
+	}
+}
+
+func TestTrimCursorPage(t *testing.T) {
+	createdAt := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	key := func(id string) (time.Time, string) { return createdAt, id }
+
+	items, pagination := TrimCursorPage([]string{"a", "b", "c"}, 2, key)
+	if len(items) != 2 || !pagination.HasNext || pagination.NextCursor != EncodeCursor(createdAt, "b") {
+		t.Fatalf("TrimCursorPage() = %v, %+v, want two items and a cursor after b", items, pagination)
+	}
+
+	items, pagination = TrimCursorPage([]string{"a", "b"}, 2, key)
+	if len(items) != 2 || pagination.HasNext || pagination.NextCursor != "" {
+		t.Fatalf("TrimCursorPage() = %v, %+v, want the last page", items, pagination)
+	}
+}
@@ -0,0 +1,110 @@
+package query
+
+import (
+	"encoding/json"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// parseFieldList parses a comma separated query value like "id,email,role" into lowercase names
+func parseFieldList(value string) []string {
+	fields := []string{}
+	for _, field := range strings.Split(value, ",") {
+		if field = strings.ToLower(strings.TrimSpace(field)); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// ResolveExpand returns the relations to embed. Without an expand parameter the endpoint's defaults
+// are used so existing clients keep their payload; expand= (empty) embeds nothing.
+// Relations not present in allowedRelations are dropped.
+func ResolveExpand(expand []string, allowedRelations map[string]string, defaults ...string) []string {
+	if expand == nil {
+		expand = defaults
+	}
+
+	relations := make([]string, 0, len(expand))
+	seen := make(map[string]bool)
+	for _, relation := range expand {
+		if _, allowed := allowedRelations[relation]; allowed && !seen[relation] {
+			seen[relation] = true
+			relations = append(relations, relation)
+		}
+	}
+	return relations
+}
+
+// ApplyExpand preloads the resolved relations. allowedRelations maps the public relation name
+// to the GORM association name, e.g. "role" -> "Role".
+func ApplyExpand(query *gorm.DB, relations []string, allowedRelations map[string]string) *gorm.DB {
+	for _, relation := range relations {
+		if association, allowed := allowedRelations[relation]; allowed {
+			query = query.Preload(association)
+		}
+	}
+	return query
+}
+
+// ApplyFieldSelection limits the selected columns to the requested fields. required lists columns
+// that must always be loaded, such as the primary key, cursor columns and foreign keys of
+// expanded relations. Without a fields parameter all columns are selected.
+func ApplyFieldSelection(query *gorm.DB, fields []string, allowedFields map[string]string, required ...string) *gorm.DB {
+	if len(fields) == 0 {
+		return query
+	}
+
+	columns := make([]string, 0, len(required)+len(fields))
+	seen := make(map[string]bool)
+	add := func(column string) {
+		if column != "" && !seen[column] {
+			seen[column] = true
+			columns = append(columns, column)
+		}
+	}
+
+	for _, column := range required {
+		add(column)
+	}
+	for _, field := range fields {
+		add(allowedFields[field])
+	}
+
+	return query.Select(columns)
+}
+
+// SparseFieldset reduces serialized items to the requested fields plus "id" and the expanded
+// relations. items is returned unchanged when no fields were requested.
+func SparseFieldset(items interface{}, fields []string, relations []string) interface{} {
+	if len(fields) == 0 {
+		return items
+	}
+
+	encoded, err := json.Marshal(items)
+	if err != nil {
+		return items
+	}
+	var decoded []map[string]interface{}
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		return items
+	}
+
+	keep := map[string]bool{"id": true}
+	for _, field := range fields {
+		keep[field] = true
+	}
+	for _, relation := range relations {
+		keep[relation] = true
+	}
+
+	for _, item := range decoded {
+		for key := range item {
+			if !keep[key] {
+				delete(item, key)
+			}
+		}
+	}
+	return decoded
+}
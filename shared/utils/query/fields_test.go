@@ -0,0 +1,28 @@
+package query
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestResolveExpand(t *testing.T) {
+	allowed := map[string]string{"role": "Role", "organization": "Organization"}
+	tests := []struct {
+		name   string
+		expand []string
+		want   []string
+	}{
+		{"defaults without the parameter", nil, []string{"role"}},
+		{"nothing for an empty parameter", []string{}, []string{}},
+		{"requested relations", []string{"organization", "role"}, []string{"organization", "role"}},
+		{"unknown and repeated relations dropped", []string{"role", "password", "role"}, []string{"role"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ResolveExpand(tt.expand, allowed, "role"); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("ResolveExpand(%q) = %q, want %q", tt.expand, got, tt.want)
+			}
+		})
+	}
+}
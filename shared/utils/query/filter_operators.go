@@ -0,0 +1,123 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FilterOperator is the comparison used by a filters[field][operator]=value query parameter
+type FilterOperator string
+
+const (
+	FilterOpEqual          FilterOperator = "eq"
+	FilterOpNotEqual       FilterOperator = "ne"
+	FilterOpGreater        FilterOperator = "gt"
+	FilterOpGreaterOrEqual FilterOperator = "gte"
+	FilterOpLess           FilterOperator = "lt"
+	FilterOpLessOrEqual    FilterOperator = "lte"
+	FilterOpIn             FilterOperator = "in"  // Comma separated list
+	FilterOpNotIn          FilterOperator = "nin" // Comma separated list
+	FilterOpNull           FilterOperator = "null"
+	FilterOpLike           FilterOperator = "like" // Case-insensitive substring match
+)
+
+// filterComparisons maps scalar operators to their SQL comparison
+var filterComparisons = map[FilterOperator]string{
+	FilterOpEqual:          "=",
+	FilterOpNotEqual:       "<>",
+	FilterOpGreater:        ">",
+	FilterOpGreaterOrEqual: ">=",
+	FilterOpLess:           "<",
+	FilterOpLessOrEqual:    "<=",
+}
+
+// filterCondition is a single SQL condition built from a filter parameter
+type filterCondition struct {
+	sql  string
+	args []interface{}
+}
+
+// parseFilterKey splits a parsed filter key into its OR group, field and operator:
+// "status" -> ("", "status", eq), "created_at[gte]" -> ("", "created_at", gte),
+// "or[0][status][in]" -> ("0", "status", in)
+func parseFilterKey(key string) (group, field string, operator FilterOperator, ok bool) {
+	segments := splitFilterKey(key)
+
+	if len(segments) >= 3 && segments[0] == "or" {
+		group = segments[1]
+		segments = segments[2:]
+	}
+
+	switch len(segments) {
+	case 1:
+		return group, segments[0], FilterOpEqual, segments[0] != ""
+	case 2:
+		return group, segments[0], FilterOperator(strings.ToLower(segments[1])), segments[0] != ""
+	default:
+		return "", "", "", false
+	}
+}
+
+// splitFilterKey turns "a[b][c]" into ["a", "b", "c"]
+func splitFilterKey(key string) []string {
+	head, rest, found := strings.Cut(key, "[")
+	if !found {
+		return []string{key}
+	}
+	if !strings.HasSuffix(rest, "]") {
+		return nil
+	}
+	return append([]string{head}, strings.Split(rest[:len(rest)-1], "][")...)
+}
+
+// buildFilterCondition builds the SQL condition for a whitelisted column
+func buildFilterCondition(dbField string, operator FilterOperator, value string) (filterCondition, bool) {
+	if comparison, ok := filterComparisons[operator]; ok {
+		return filterCondition{
+			sql:  fmt.Sprintf("%s %s ?", dbField, comparison),
+			args: []interface{}{value},
+		}, true
+	}
+
+	switch operator {
+	case FilterOpIn, FilterOpNotIn:
+		values := splitFilterList(value)
+		if len(values) == 0 {
+			return filterCondition{}, false
+		}
+		keyword := "IN"
+		if operator == FilterOpNotIn {
+			keyword = "NOT IN"
+		}
+		return filterCondition{
+			sql:  fmt.Sprintf("%s %s ?", dbField, keyword),
+			args: []interface{}{values},
+		}, true
+	case FilterOpNull:
+		switch strings.ToLower(value) {
+		case "true", "1":
+			return filterCondition{sql: fmt.Sprintf("%s IS NULL", dbField)}, true
+		case "false", "0":
+			return filterCondition{sql: fmt.Sprintf("%s IS NOT NULL", dbField)}, true
+		}
+		return filterCondition{}, false
+	case FilterOpLike:
+		return filterCondition{
+			sql:  fmt.Sprintf("%s ILIKE ?", dbField),
+			args: []interface{}{"%" + value + "%"},
+		}, true
+	}
+
+	return filterCondition{}, false
+}
+
+// splitFilterList parses a comma separated value list, dropping empty entries
+func splitFilterList(value string) []string {
+	var values []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			values = append(values, item)
+		}
+	}
+	return values
+}
@@ -0,0 +1,70 @@
+package query
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFilterKey(t *testing.T) {
+	tests := []struct {
+		key      string
+		group    string
+		field    string
+		operator FilterOperator
+		ok       bool
+	}{
+		{"status", "", "status", FilterOpEqual, true},
+		{"created_at[gte]", "", "created_at", FilterOpGreaterOrEqual, true},
+		{"created_at[GTE]", "", "created_at", FilterOpGreaterOrEqual, true},
+		{"or[0][status][in]", "0", "status", FilterOpIn, true},
+		{"or[a][email]", "a", "email", FilterOpEqual, true},
+		{"", "", "", FilterOpEqual, false},
+		{"[eq]", "", "", FilterOpEqual, false},
+		{"status[eq", "", "", "", false},
+		{"a[b][c]", "", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			group, field, operator, ok := parseFilterKey(tt.key)
+			if ok != tt.ok {
+				t.Fatalf("parseFilterKey(%q) ok = %v, want %v", tt.key, ok, tt.ok)
+			}
+			if ok && (group != tt.group || field != tt.field || operator != tt.operator) {
+				t.Fatalf("parseFilterKey(%q) = (%q, %q, %q), want (%q, %q, %q)",
+					tt.key, group, field, operator, tt.group, tt.field, tt.operator)
+			}
+		})
+	}
+}
+
+func TestBuildFilterCondition(t *testing.T) {
+	tests := []struct {
+		name     string
+		operator FilterOperator
+		value    string
+		want     filterCondition
+		ok       bool
+	}{
+		{"equal", FilterOpEqual, "ACTIVE", filterCondition{"users.status = ?", []interface{}{"ACTIVE"}}, true},
+		{"not equal", FilterOpNotEqual, "ACTIVE", filterCondition{"users.status <> ?", []interface{}{"ACTIVE"}}, true},
+		{"less or equal", FilterOpLessOrEqual, "5", filterCondition{"users.status <= ?", []interface{}{"5"}}, true},
+		{"in", FilterOpIn, "ACTIVE, INVITED,,", filterCondition{"users.status IN ?", []interface{}{[]string{"ACTIVE", "INVITED"}}}, true},
+		{"not in", FilterOpNotIn, "SUSPENDED", filterCondition{"users.status NOT IN ?", []interface{}{[]string{"SUSPENDED"}}}, true},
+		{"empty in", FilterOpIn, " , ", filterCondition{}, false},
+		{"null", FilterOpNull, "true", filterCondition{sql: "users.status IS NULL"}, true},
+		{"not null", FilterOpNull, "0", filterCondition{sql: "users.status IS NOT NULL"}, true},
+		{"invalid null", FilterOpNull, "maybe", filterCondition{}, false},
+		{"like", FilterOpLike, "act", filterCondition{"users.status ILIKE ?", []interface{}{"%act%"}}, true},
+		{"unknown operator", FilterOperator("regex"), ".*", filterCondition{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := buildFilterCondition("users.status", tt.operator, tt.value)
+			if ok != tt.ok || !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("buildFilterCondition(%q, %q) = %+v, %v, want %+v, %v", tt.operator, tt.value, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
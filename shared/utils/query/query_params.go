@@ -2,6 +2,7 @@ package query
 
 import (
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -11,11 +12,15 @@ import (
 
 // FilterParams represents filtering parameters
 type FilterParams struct {
-	Filters map[string]string `json:"filters"`
-	Sort    SortParams        `json:"sort"`
-	Page    int               `json:"page"`
-	Limit   int               `json:"limit"`
-	Search  string            `json:"search"`
+	Filters    map[string]string `json:"filters"`
+	Sort       SortParams        `json:"sort"`
+	Page       int               `json:"page"`
+	Limit      int               `json:"limit"`
+	Search     string            `json:"search"`
+	Cursor     string            `json:"cursor"`
+	CursorMode bool              `json:"cursor_mode"` // Keyset pagination requested (cursor or pagination=cursor)
+	Fields     []string          `json:"fields"`      // Sparse fieldset, empty means all fields
+	Expand     []string          `json:"expand"`      // Relations to embed, nil when expand was not given
 }
 
 // SortParams represents sorting parameters
@@ -51,14 +56,31 @@ func ParseQueryParams(c *gin.Context) FilterParams {
 		limit = 100
 	}
 
+	// Parse cursor - format: cursor=<opaque> (empty or pagination=cursor for the first page)
+	cursor, hasCursor := c.GetQuery("cursor")
+	cursorMode := hasCursor || c.Query("pagination") == "cursor"
+
+	// Parse sparse fieldsets and relation expansion - format: fields=id,email&expand=role,organization
+	fields := parseFieldList(c.Query("fields"))
+	var expand []string
+	if value, hasExpand := c.GetQuery("expand"); hasExpand {
+		expand = parseFieldList(value)
+	}
+
 	// Parse search
 	search := c.Query("search")
 
-	// Parse filters - format: filters[field_name]=value
+	// Parse filters - format: filters[field_name]=value, filters[field_name][operator]=value
+	// or filters[or][group][field_name][operator]=value. Keys are stored without the filters prefix,
+	// e.g. "status", "created_at[gte]" or "or[0][status][in]", and interpreted by ApplyFilters.
 	filters := make(map[string]string)
 	for key, values := range c.Request.URL.Query() {
 		if strings.HasPrefix(key, "filters[") && strings.HasSuffix(key, "]") {
-			fieldName := key[8 : len(key)-1] // Extract field name from filters[field_name]
+			segments := strings.Split(key[8:len(key)-1], "][") // Extract segments from filters[a][b]...
+			fieldName := segments[0]
+			for _, segment := range segments[1:] {
+				fieldName += "[" + segment + "]"
+			}
 			if len(values) > 0 && values[0] != "" {
 				filters[fieldName] = values[0]
 			}
@@ -88,19 +110,72 @@ func ParseQueryParams(c *gin.Context) FilterParams {
 			Field: sortField,
 			Order: sortOrder,
 		},
-		Page:   page,
-		Limit:  limit,
-		Search: search,
+		Page:       page,
+		Limit:      limit,
+		Search:     search,
+		Cursor:     cursor,
+		CursorMode: cursorMode,
+		Fields:     fields,
+		Expand:     expand,
 	}
 }
 
-// ApplyFilters applies filters to a GORM query
+// ApplyFilters applies filters to a GORM query.
+// Plain keys filter by equality; keys with an operator suffix (see FilterOperator) build the matching
+// condition. Conditions sharing an or[group] prefix are combined with OR, everything else with AND.
+// Unknown fields and operators are ignored, like unknown plain filters always were.
 func ApplyFilters(query *gorm.DB, filters map[string]string, allowedFields map[string]string) *gorm.DB {
-	for field, value := range filters {
-		if dbField, allowed := allowedFields[field]; allowed && value != "" {
-			query = query.Where(fmt.Sprintf("%s = ?", dbField), value)
+	keys := make([]string, 0, len(filters))
+	for key := range filters {
+		keys = append(keys, key)
+	}
+	// Sorted so the generated SQL is stable across requests
+	sort.Strings(keys)
+
+	orGroups := make(map[string][]filterCondition)
+	var groupNames []string
+
+	for _, key := range keys {
+		value := filters[key]
+		if value == "" {
+			continue
+		}
+
+		group, field, operator, ok := parseFilterKey(key)
+		if !ok {
+			continue
 		}
+		dbField, allowed := allowedFields[field]
+		if !allowed {
+			continue
+		}
+
+		condition, ok := buildFilterCondition(dbField, operator, value)
+		if !ok {
+			continue
+		}
+
+		if group == "" {
+			query = query.Where(condition.sql, condition.args...)
+			continue
+		}
+		if _, exists := orGroups[group]; !exists {
+			groupNames = append(groupNames, group)
+		}
+		orGroups[group] = append(orGroups[group], condition)
 	}
+
+	for _, group := range groupNames {
+		conditions := orGroups[group]
+		clauses := make([]string, len(conditions))
+		var args []interface{}
+		for i, condition := range conditions {
+			clauses[i] = condition.sql
+			args = append(args, condition.args...)
+		}
+		query = query.Where("("+strings.Join(clauses, " OR ")+")", args...)
+	}
+
 	return query
 }
 
@@ -0,0 +1,78 @@
+package query
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseQuery parses the query parameters of a list request with the query string
+func parseQuery(query string) FilterParams {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/api/users?"+query, nil)
+	return ParseQueryParams(c)
+}
+
+func TestParseQueryParams(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		check func(t *testing.T, params FilterParams)
+	}{
+		{"defaults", "", func(t *testing.T, params FilterParams) {
+			if params.Page != 1 || params.Limit != 10 || params.Sort != (SortParams{Field: "created_at", Order: "desc"}) || params.CursorMode {
+				t.Fatalf("got %+v", params)
+			}
+		}},
+		{"limits clamped", "page=-3&limit=500", func(t *testing.T, params FilterParams) {
+			if params.Page != 1 || params.Limit != 100 {
+				t.Fatalf("page %d limit %d, want 1 and 100", params.Page, params.Limit)
+			}
+		}},
+		{"invalid sort order", "sort[field]=email&sort[order]=sideways", func(t *testing.T, params FilterParams) {
+			if params.Sort != (SortParams{Field: "email", Order: "desc"}) {
+				t.Fatalf("sort %+v", params.Sort)
+			}
+		}},
+		{"first cursor page", "pagination=cursor", func(t *testing.T, params FilterParams) {
+			if !params.CursorMode || params.Cursor != "" {
+				t.Fatalf("cursor mode %v cursor %q", params.CursorMode, params.Cursor)
+			}
+		}},
+		{"next cursor page", "cursor=abc", func(t *testing.T, params FilterParams) {
+			if !params.CursorMode || params.Cursor != "abc" {
+				t.Fatalf("cursor mode %v cursor %q", params.CursorMode, params.Cursor)
+			}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.check(t, parseQuery(tt.query))
+		})
+	}
+}
+
+func TestParseQueryParamsFilters(t *testing.T) {
+	params := parseQuery("filters[status]=ACTIVE&filters[created_at][gte]=2024-01-01&filters[or][0][email][like]=acme&filters[empty]=&other=1")
+	want := map[string]string{"status": "ACTIVE", "created_at[gte]": "2024-01-01", "or[0][email][like]": "acme"}
+	if !reflect.DeepEqual(params.Filters, want) {
+		t.Fatalf("filters %v, want %v", params.Filters, want)
+	}
+}
+
+func TestParseQueryParamsFieldsAndExpand(t *testing.T) {
+	params := parseQuery("fields=id,%20Email,&expand=role")
+	if !reflect.DeepEqual(params.Fields, []string{"id", "email"}) || !reflect.DeepEqual(params.Expand, []string{"role"}) {
+		t.Fatalf("fields %q expand %q", params.Fields, params.Expand)
+	}
+	if params := parseQuery(""); params.Expand != nil {
+		t.Fatalf("expand %q without the parameter, want nil", params.Expand)
+	}
+	if params := parseQuery("expand="); params.Expand == nil || len(params.Expand) != 0 {
+		t.Fatalf("expand %q for an empty parameter, want an empty list", params.Expand)
+	}
+}
@@ -0,0 +1,132 @@
+package redact
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+)
+
+// Mask replaces the values of redacted fields
+const Mask = "[REDACTED]"
+
+// Redactor masks sensitive fields of decoded JSON bodies. A field name without dots, like
+// "password", is masked at any depth; a dotted path, like "data.user.email", only below the root, with
+// "*" matching any one field. Array elements are walked as if they were their parent field, so
+// "items.secret" matches items[0].secret. Names are compared case-insensitively.
+type Redactor struct {
+	anywhere map[string]bool
+	paths    [][]string
+}
+
+// New creates a Redactor from a comma separated deny-list of field names and paths
+func New(denyList string) *Redactor {
+	r := &Redactor{anywhere: map[string]bool{}}
+	for _, entry := range strings.Split(denyList, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, ".") {
+			r.anywhere[entry] = true
+			continue
+		}
+		r.paths = append(r.paths, strings.Split(entry, "."))
+	}
+	return r
+}
+
+// Empty reports whether the deny-list is empty, so nothing is masked
+func (r *Redactor) Empty() bool {
+	return len(r.anywhere) == 0 && len(r.paths) == 0
+}
+
+// Value masks the sensitive fields of a value decoded from JSON, in place, and returns it
+func (r *Redactor) Value(value interface{}) interface{} {
+	if r.Empty() {
+		return value
+	}
+	return r.walk(value, nil)
+}
+
+// String masks the sensitive fields of a JSON document. Text that is not JSON is returned unchanged.
+func (r *Redactor) String(text string) string {
+	if r.Empty() || text == "" {
+		return text
+	}
+	var value interface{}
+	if err := json.Unmarshal([]byte(text), &value); err != nil {
+		return text
+	}
+	redacted, err := json.Marshal(r.walk(value, nil))
+	if err != nil {
+		return text
+	}
+	return string(redacted)
+}
+
+// Query masks the values of sensitive parameters of a URL query string. Parameters are matched by
+// the field names of the deny-list; dotted paths don't apply to queries.
+func (r *Redactor) Query(rawQuery string) string {
+	if len(r.anywhere) == 0 || rawQuery == "" {
+		return rawQuery
+	}
+	params := strings.Split(rawQuery, "&")
+	masked := false
+	for i, param := range params {
+		key, _, hasValue := strings.Cut(param, "=")
+		name, err := url.QueryUnescape(key)
+		if err != nil {
+			name = key
+		}
+		if hasValue && r.anywhere[strings.ToLower(name)] {
+			params[i] = key + "=" + url.QueryEscape(Mask)
+			masked = true
+		}
+	}
+	if !masked {
+		return rawQuery
+	}
+	return strings.Join(params, "&")
+}
+
+func (r *Redactor) walk(value interface{}, path []string) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			childPath := append(path[:len(path):len(path)], strings.ToLower(key))
+			if child != nil && r.denied(childPath) {
+				v[key] = Mask
+				continue
+			}
+			v[key] = r.walk(child, childPath)
+		}
+	case []interface{}:
+		for i, child := range v {
+			v[i] = r.walk(child, path)
+		}
+	}
+	return value
+}
+
+// denied reports whether the field at the path is on the deny-list
+func (r *Redactor) denied(path []string) bool {
+	if r.anywhere[path[len(path)-1]] {
+		return true
+	}
+	for _, denied := range r.paths {
+		if len(denied) != len(path) {
+			continue
+		}
+		matches := true
+		for i, segment := range denied {
+			if segment != "*" && segment != path[i] {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return true
+		}
+	}
+	return false
+}
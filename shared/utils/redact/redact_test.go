@@ -0,0 +1,83 @@
+package redact
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestRedactorString(t *testing.T) {
+	tests := []struct {
+		name     string
+		denyList string
+		text     string
+		want     string
+	}{
+		{"field at any depth", "password", `{"password":"a","user":{"password":"b","name":"c"}}`,
+			`{"password":"[REDACTED]","user":{"password":"[REDACTED]","name":"c"}}`},
+		{"case-insensitive", "Password", `{"PASSWORD":"a"}`, `{"PASSWORD":"[REDACTED]"}`},
+		{"path from the root only", "data.user.email", `{"data":{"user":{"email":"a"}},"email":"b"}`,
+			`{"data":{"user":{"email":"[REDACTED]"}},"email":"b"}`},
+		{"wildcard segment", "data.*.token", `{"data":{"a":{"token":"x"},"b":{"token":"y"}},"token":"z"}`,
+			`{"data":{"a":{"token":"[REDACTED]"},"b":{"token":"[REDACTED]"}},"token":"z"}`},
+		{"array elements", "items.secret", `{"items":[{"secret":"a"},{"secret":"b","id":1}]}`,
+			`{"items":[{"secret":"[REDACTED]"},{"id":1,"secret":"[REDACTED]"}]}`},
+		{"objects are masked whole", "credentials", `{"credentials":{"key":"a"}}`, `{"credentials":"[REDACTED]"}`},
+		{"null values are kept", "password", `{"password":null}`, `{"password":null}`},
+		{"blank entries", " , ,password ", `{"password":"a"}`, `{"password":"[REDACTED]"}`},
+		{"not json", "password", `password=a`, `password=a`},
+		{"empty deny-list", "", `{"password":"a"}`, `{"password":"a"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := New(tt.denyList).String(tt.text)
+			if !equalJSON(got, tt.want) {
+				t.Fatalf("String(%s) = %s, want %s", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactorQuery(t *testing.T) {
+	tests := []struct {
+		name     string
+		denyList string
+		query    string
+		want     string
+	}{
+		{"masked parameter", "access_token", "channels=system&access_token=abc.def", "channels=system&access_token=%5BREDACTED%5D"},
+		{"escaped and mixed case name", "access_token", "Access%5Ftoken=abc", "Access%5Ftoken=%5BREDACTED%5D"},
+		{"repeated parameter", "token", "token=a&token=b", "token=%5BREDACTED%5D&token=%5BREDACTED%5D"},
+		{"parameter without value", "token", "token&page=2", "token&page=2"},
+		{"paths don't apply", "data.token", "token=a", "token=a"},
+		{"nothing to mask", "token", "page=2", "page=2"},
+		{"empty query", "token", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := New(tt.denyList).Query(tt.query); got != tt.want {
+				t.Fatalf("Query(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactorEmpty(t *testing.T) {
+	if !New(" , ").Empty() {
+		t.Error("a deny-list of blank entries should be empty")
+	}
+	if New("password").Empty() {
+		t.Error("a deny-list with a field should not be empty")
+	}
+}
+
+// equalJSON compares JSON documents ignoring the order of fields, and other text exactly
+func equalJSON(a, b string) bool {
+	var va, vb interface{}
+	if json.Unmarshal([]byte(a), &va) != nil || json.Unmarshal([]byte(b), &vb) != nil {
+		return a == b
+	}
+	return reflect.DeepEqual(va, vb)
+}
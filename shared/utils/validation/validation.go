@@ -0,0 +1,179 @@
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"forgecrud-backend/shared/apperrors"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// FieldError describes why a field of a request failed validation
+type FieldError struct {
+	Field   string `json:"field"`           // JSON name, with the path for nested fields, e.g. items[0].name
+	Rule    string `json:"rule"`            // The failed rule, e.g. required, max, email or type
+	Param   string `json:"param,omitempty"` // The rule's parameter, e.g. 100 for max=100
+	Message string `json:"message"`
+}
+
+func init() {
+	// Report fields by their JSON names instead of the Go struct field names
+	if validate, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+			if name == "-" {
+				return ""
+			}
+			if name == "" {
+				return field.Name
+			}
+			return name
+		})
+	}
+}
+
+// Error returns the BAD_REQUEST error of a request that failed binding, with the field errors as
+// "fields" instead of the raw error
+func Error(err error, message string) *apperrors.Error {
+	appErr := apperrors.New(apperrors.CodeBadRequest, message).With("fields", Errors(err))
+	appErr.Err = err
+	return appErr
+}
+
+// Errors converts binding and validation errors to field errors
+func Errors(err error) []FieldError {
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		fields := make([]FieldError, 0, len(validationErrors))
+		for _, fieldErr := range validationErrors {
+			fields = append(fields, FieldError{
+				Field:   fieldPath(fieldErr),
+				Rule:    fieldErr.Tag(),
+				Param:   fieldErr.Param(),
+				Message: message(fieldErr),
+			})
+		}
+		return fields
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return []FieldError{{
+			Field:   typeErr.Field,
+			Rule:    "type",
+			Param:   typeErr.Type.String(),
+			Message: fmt.Sprintf("must be %s, not %s", typeName(typeErr.Type), typeErr.Value),
+		}}
+	}
+
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return []FieldError{{Rule: "json", Message: fmt.Sprintf("malformed JSON at offset %d", syntaxErr.Offset)}}
+	}
+	if errors.Is(err, io.EOF) {
+		return []FieldError{{Rule: "required", Message: "request body is empty"}}
+	}
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return []FieldError{{Rule: "json", Message: "request body ends unexpectedly"}}
+	}
+
+	// Values a field type refuses to decode, like an invalid UUID
+	return []FieldError{{Rule: "invalid", Message: err.Error()}}
+}
+
+// fieldPath returns the path of the field below the request struct, e.g. items[0].name
+func fieldPath(fieldErr validator.FieldError) string {
+	namespace := fieldErr.Namespace()
+	if i := strings.Index(namespace, "."); i >= 0 {
+		return namespace[i+1:]
+	}
+	return fieldErr.Field()
+}
+
+// message returns a readable message for the failed rule
+func message(fieldErr validator.FieldError) string {
+	param := fieldErr.Param()
+	sized := "characters"
+	switch fieldErr.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		sized = "items"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		sized = ""
+	}
+
+	switch fieldErr.Tag() {
+	case "required", "required_if", "required_unless", "required_with", "required_without":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "url", "http_url":
+		return "must be a valid URL"
+	case "uuid", "uuid4":
+		return "must be a valid UUID"
+	case "e164":
+		return "must be a phone number in E.164 format, e.g. +15005550006"
+	case "oneof":
+		return "must be one of: " + strings.Join(strings.Fields(param), ", ")
+	case "min", "gte":
+		if sized == "" {
+			return "must be at least " + param
+		}
+		return fmt.Sprintf("must have at least %s %s", param, sized)
+	case "max", "lte":
+		if sized == "" {
+			return "must be at most " + param
+		}
+		return fmt.Sprintf("must have at most %s %s", param, sized)
+	case "len":
+		if sized == "" {
+			return "must be " + param
+		}
+		return fmt.Sprintf("must have exactly %s %s", param, sized)
+	case "gt":
+		return "must be greater than " + param
+	case "lt":
+		return "must be less than " + param
+	case "eqfield":
+		return "must match " + param
+	case "nefield":
+		return "must differ from " + param
+	case "alphanum":
+		return "may only contain letters and digits"
+	case "hexcolor":
+		return "must be a hex color, e.g. #1a2b3c"
+	case "dive":
+		return "has an invalid item"
+	}
+	if param != "" {
+		return fmt.Sprintf("failed the %s=%s rule", fieldErr.Tag(), param)
+	}
+	return fmt.Sprintf("failed the %s rule", fieldErr.Tag())
+}
+
+// typeName returns a readable name of the JSON type a Go type is decoded from
+func typeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	}
+	return "a " + t.String()
+}